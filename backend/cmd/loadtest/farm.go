@@ -0,0 +1,148 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// receiverFarm is a set of local webhook receivers that record delivery latency
+type receiverFarm struct {
+	servers         []*http.Server
+	urls            []string
+	receiverLatency time.Duration
+	failureRate     float64
+	expected        int
+
+	mu          sync.Mutex
+	injectedAt  map[string]time.Time
+	latencies   []time.Duration
+	delivered   int
+	failed      int
+	inFlight    int
+	maxInFlight int
+	done        chan struct{}
+	closeOnce   sync.Once
+}
+
+// farmStats summarizes what the receivers observed
+type farmStats struct {
+	Delivered   int
+	Failed      int
+	MaxInFlight int
+	Latencies   []time.Duration
+}
+
+// newReceiverFarm starts n receivers on loopback ports.
+// Done is closed once expected requests have been received.
+func newReceiverFarm(n int, receiverLatency time.Duration, failureRate float64, expected int) (*receiverFarm, error) {
+	f := &receiverFarm{
+		receiverLatency: receiverLatency,
+		failureRate:     failureRate,
+		expected:        expected,
+		injectedAt:      make(map[string]time.Time),
+		latencies:       make([]time.Duration, 0, expected),
+		done:            make(chan struct{}),
+	}
+
+	for range n {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("failed to start receiver: %w", err)
+		}
+		srv := &http.Server{Handler: http.HandlerFunc(f.handle), ReadHeaderTimeout: 10 * time.Second}
+		go func() { _ = srv.Serve(ln) }()
+		f.servers = append(f.servers, srv)
+		f.urls = append(f.urls, "http://"+ln.Addr().String())
+	}
+	return f, nil
+}
+
+// URLs returns the base URL of each receiver
+func (f *receiverFarm) URLs() []string {
+	return f.urls
+}
+
+// MarkInjected records when an event entered the dispatcher
+func (f *receiverFarm) MarkInjected(eventID string, at time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.injectedAt[eventID] = at
+}
+
+// Done is closed when all expected requests have been received
+func (f *receiverFarm) Done() <-chan struct{} {
+	return f.done
+}
+
+// Stats returns a snapshot of the observed deliveries
+func (f *receiverFarm) Stats() farmStats {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	latencies := make([]time.Duration, len(f.latencies))
+	copy(latencies, f.latencies)
+	return farmStats{
+		Delivered:   f.delivered,
+		Failed:      f.failed,
+		MaxInFlight: f.maxInFlight,
+		Latencies:   latencies,
+	}
+}
+
+// Close shuts down all receivers
+func (f *receiverFarm) Close() {
+	for _, srv := range f.servers {
+		_ = srv.Close()
+	}
+}
+
+// handle simulates a webhook receiver
+func (f *receiverFarm) handle(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	f.inFlight++
+	f.maxInFlight = max(f.maxInFlight, f.inFlight)
+	f.mu.Unlock()
+
+	body, _ := io.ReadAll(r.Body)
+	var payload struct {
+		ID string `json:"_id"`
+	}
+	_ = json.Unmarshal(body, &payload)
+
+	if f.receiverLatency > 0 {
+		time.Sleep(f.receiverLatency)
+	}
+	fail := f.failureRate > 0 && rand.Float64() < f.failureRate
+	if fail {
+		w.WriteHeader(http.StatusInternalServerError)
+	} else {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	f.record(payload.ID, fail, time.Now())
+}
+
+// record counts a received request and its latency since injection
+func (f *receiverFarm) record(eventID string, failed bool, at time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.inFlight--
+	if failed {
+		f.failed++
+	} else {
+		f.delivered++
+	}
+	if injected, ok := f.injectedAt[eventID]; ok {
+		f.latencies = append(f.latencies, at.Sub(injected))
+	}
+	if f.delivered+f.failed >= f.expected {
+		f.closeOnce.Do(func() { close(f.done) })
+	}
+}
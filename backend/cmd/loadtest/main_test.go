@@ -0,0 +1,222 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/otiai10/namazu/backend/internal/subscription"
+)
+
+func TestRun_SmallBurst(t *testing.T) {
+	report, err := run(context.Background(), options{
+		Subscriptions: 20,
+		Receivers:     3,
+		Events:        3,
+		FailureRate:   0,
+		Timeout:       30 * time.Second,
+	})
+	if err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+
+	if !report.Complete() {
+		t.Fatalf("expected all deliveries, got %d/%d", report.Delivered+report.Failed, report.Expected)
+	}
+	if report.Delivered != 60 || report.Failed != 0 {
+		t.Errorf("delivered/failed = %d/%d, want 60/0", report.Delivered, report.Failed)
+	}
+	if len(report.Latencies) != 60 {
+		t.Errorf("expected 60 latency samples, got %d", len(report.Latencies))
+	}
+	if report.PeakHeapBytes == 0 || report.PeakGoroutines == 0 {
+		t.Error("expected memory samples")
+	}
+
+	var out bytes.Buffer
+	report.Print(&out)
+	if !strings.Contains(out.String(), "60/60 received") {
+		t.Errorf("unexpected report:\n%s", out.String())
+	}
+}
+
+func TestRun_AllFailures(t *testing.T) {
+	report, err := run(context.Background(), options{
+		Subscriptions: 5,
+		Receivers:     1,
+		Events:        1,
+		FailureRate:   1,
+		Timeout:       30 * time.Second,
+	})
+	if err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+	if report.Failed != 5 || report.Delivered != 0 {
+		t.Errorf("delivered/failed = %d/%d, want 0/5", report.Delivered, report.Failed)
+	}
+}
+
+func TestRun_RateAndFilters(t *testing.T) {
+	report, err := run(context.Background(), options{
+		Subscriptions: 10,
+		Receivers:     2,
+		Events:        3,
+		Rate:          50,
+		Filtered:      0.5,
+		Timeout:       30 * time.Second,
+	})
+	if err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+	if !report.Complete() || report.Delivered != 30 {
+		t.Errorf("delivered = %d/%d, want 30", report.Delivered, report.Expected)
+	}
+	// Three events at 50/s take at least two intervals
+	if report.Elapsed < 40*time.Millisecond {
+		t.Errorf("elapsed = %v, want the injections paced", report.Elapsed)
+	}
+	if report.TotalAllocs == 0 || report.AllocsPerDelivery() == 0 {
+		t.Error("expected allocation counts")
+	}
+}
+
+func TestRun_DeliveryQueue(t *testing.T) {
+	report, err := run(context.Background(), options{
+		Subscriptions: 20,
+		Receivers:     3,
+		Events:        3,
+		Timeout:       30 * time.Second,
+		Workers:       8,
+	})
+	if err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+	if !report.Complete() || report.Delivered != 60 {
+		t.Errorf("delivered = %d/%d, want 60", report.Delivered, report.Expected)
+	}
+}
+
+func TestOptions_Validate(t *testing.T) {
+	valid := options{Subscriptions: 1, Receivers: 1, Events: 1, Timeout: time.Second}
+	if err := valid.validate(); err != nil {
+		t.Errorf("validate() error = %v", err)
+	}
+
+	invalid := []options{
+		{Subscriptions: 0, Receivers: 1, Events: 1, Timeout: time.Second},
+		{Subscriptions: 1, Receivers: 0, Events: 1, Timeout: time.Second},
+		{Subscriptions: 1, Receivers: 1, Events: 0, Timeout: time.Second},
+		{Subscriptions: 1, Receivers: 1, Events: 1, Timeout: time.Second, FailureRate: 1.5},
+		{Subscriptions: 1, Receivers: 1, Events: 1},
+		{Subscriptions: 1, Receivers: 1, Events: 1, Timeout: time.Second, Workers: -1},
+		{Subscriptions: 1, Receivers: 1, Events: 1, Timeout: time.Second, Rate: -1},
+		{Subscriptions: 1, Receivers: 1, Events: 1, Timeout: time.Second, Rate: 1, Interval: time.Second},
+		{Subscriptions: 1, Receivers: 1, Events: 1, Timeout: time.Second, Filtered: 2},
+	}
+	for i, o := range invalid {
+		if err := o.validate(); err == nil {
+			t.Errorf("case %d: expected validation error", i)
+		}
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	sorted := make([]time.Duration, 100)
+	for i := range sorted {
+		sorted[i] = time.Duration(i+1) * time.Millisecond
+	}
+
+	tests := []struct {
+		p    float64
+		want time.Duration
+	}{
+		{p: 50, want: 50 * time.Millisecond},
+		{p: 99, want: 99 * time.Millisecond},
+		{p: 100, want: 100 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		if got := percentile(sorted, tt.p); got != tt.want {
+			t.Errorf("percentile(%v) = %v, want %v", tt.p, got, tt.want)
+		}
+	}
+
+	if got := percentile(nil, 99); got != 0 {
+		t.Errorf("percentile(nil) = %v, want 0", got)
+	}
+}
+
+func TestSeedSubscriptions(t *testing.T) {
+	subs := seedSubscriptions(5, []string{"http://a", "http://b"}, 0.4)
+	if len(subs) != 5 {
+		t.Fatalf("expected 5 subscriptions, got %d", len(subs))
+	}
+	if subs[0].Delivery.URL != "http://a/hook/0" || subs[1].Delivery.URL != "http://b/hook/1" {
+		t.Errorf("expected round-robin receivers, got %s, %s", subs[0].Delivery.URL, subs[1].Delivery.URL)
+	}
+	if subs[0].Filter == nil || subs[1].Filter == nil || subs[2].Filter != nil {
+		t.Errorf("expected the first 2 subscriptions to be filtered")
+	}
+}
+
+func TestSyntheticFilter_MatchesSyntheticEvents(t *testing.T) {
+	sub := subscription.FromConfig(seedSubscriptions(1, []string{"http://a"}, 1)[0])
+	if !sub.Filter.Matches(newSyntheticEvent("loadtest-0")) {
+		t.Error("synthetic events must match the synthetic filter, or filtered subscriptions receive nothing")
+	}
+}
+
+func TestOptions_Interval(t *testing.T) {
+	if got := (options{Rate: 4}).interval(); got != 250*time.Millisecond {
+		t.Errorf("interval() = %v, want 250ms", got)
+	}
+	if got := (options{Interval: time.Second}).interval(); got != time.Second {
+		t.Errorf("interval() = %v, want 1s", got)
+	}
+}
+
+// BenchmarkDelivery measures the delivery of b.N events through the filter
+// and sender pipeline to local receivers. Run with -benchtime=100x or similar
+// to keep the number of requests bounded.
+func BenchmarkDelivery(b *testing.B) {
+	for _, bm := range []struct {
+		name     string
+		subs     int
+		filtered float64
+	}{
+		{"100Subscriptions", 100, 0},
+		{"100Subscriptions_Filtered", 100, 1},
+		{"1000Subscriptions_Filtered", 1000, 1},
+	} {
+		b.Run(bm.name, func(b *testing.B) {
+			log.SetOutput(io.Discard)
+			defer log.SetOutput(os.Stderr)
+			b.ReportAllocs()
+
+			report, err := run(context.Background(), options{
+				Subscriptions: bm.subs,
+				Receivers:     4,
+				Events:        b.N,
+				Filtered:      bm.filtered,
+				Timeout:       5 * time.Minute,
+			})
+			if err != nil {
+				b.Fatal(err)
+			}
+			if !report.Complete() {
+				b.Fatalf("received %d/%d", report.Delivered+report.Failed, report.Expected)
+			}
+
+			sorted := append([]time.Duration(nil), report.Latencies...)
+			slices.Sort(sorted)
+			b.ReportMetric(float64(percentile(sorted, 50).Microseconds())/1000, "p50-ms")
+			b.ReportMetric(float64(percentile(sorted, 99).Microseconds())/1000, "p99-ms")
+			b.ReportMetric(report.AllocsPerDelivery(), "allocs/delivery")
+		})
+	}
+}
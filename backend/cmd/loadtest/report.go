@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"time"
+)
+
+// Report holds the results of a load test run
+type Report struct {
+	Subscriptions   int
+	Events          int
+	Expected        int
+	Delivered       int
+	Failed          int
+	Elapsed         time.Duration
+	Latencies       []time.Duration
+	MaxQueueDepth   int
+	MaxInFlight     int
+	PeakHeapBytes   uint64
+	PeakGoroutines  int
+	TotalAllocBytes uint64
+	TotalAllocs     uint64
+}
+
+// Complete reports whether every expected request reached a receiver
+func (r *Report) Complete() bool {
+	return r.Delivered+r.Failed >= r.Expected
+}
+
+// Throughput returns the received requests per second
+func (r *Report) Throughput() float64 {
+	if r.Elapsed <= 0 {
+		return 0
+	}
+	return float64(r.Delivered+r.Failed) / r.Elapsed.Seconds()
+}
+
+// AllocsPerDelivery returns the heap allocations per received request
+func (r *Report) AllocsPerDelivery() float64 {
+	if r.Delivered+r.Failed == 0 {
+		return 0
+	}
+	return float64(r.TotalAllocs) / float64(r.Delivered+r.Failed)
+}
+
+// Print writes a human-readable summary
+func (r *Report) Print(w io.Writer) {
+	sorted := make([]time.Duration, len(r.Latencies))
+	copy(sorted, r.Latencies)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	fmt.Fprintln(w, "=== namazu load test ===")
+	fmt.Fprintf(w, "subscriptions:     %d\n", r.Subscriptions)
+	fmt.Fprintf(w, "events:            %d\n", r.Events)
+	fmt.Fprintf(w, "requests:          %d/%d received (%d failed)\n", r.Delivered+r.Failed, r.Expected, r.Failed)
+	fmt.Fprintf(w, "elapsed:           %s\n", r.Elapsed.Round(time.Millisecond))
+	fmt.Fprintf(w, "throughput:        %.1f deliveries/s\n", r.Throughput())
+	fmt.Fprintf(w, "max queue depth:   %d events\n", r.MaxQueueDepth)
+	fmt.Fprintf(w, "max in-flight:     %d requests\n", r.MaxInFlight)
+	fmt.Fprintf(w, "latency p50:       %s\n", percentile(sorted, 50).Round(time.Millisecond))
+	fmt.Fprintf(w, "latency p95:       %s\n", percentile(sorted, 95).Round(time.Millisecond))
+	fmt.Fprintf(w, "latency p99:       %s\n", percentile(sorted, 99).Round(time.Millisecond))
+	fmt.Fprintf(w, "latency max:       %s\n", percentile(sorted, 100).Round(time.Millisecond))
+	fmt.Fprintf(w, "peak heap:         %.1f MiB\n", float64(r.PeakHeapBytes)/(1<<20))
+	fmt.Fprintf(w, "total allocated:   %.1f MiB\n", float64(r.TotalAllocBytes)/(1<<20))
+	fmt.Fprintf(w, "allocations:       %d (%.0f per delivery)\n", r.TotalAllocs, r.AllocsPerDelivery())
+	fmt.Fprintf(w, "peak goroutines:   %d\n", r.PeakGoroutines)
+	if !r.Complete() {
+		fmt.Fprintln(w, "WARNING: timed out before all deliveries were received")
+	}
+}
+
+// percentile returns the p-th percentile (nearest rank) of sorted durations
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p/100*float64(len(sorted)))) - 1
+	rank = max(0, min(rank, len(sorted)-1))
+	return sorted[rank]
+}
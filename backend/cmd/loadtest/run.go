@@ -0,0 +1,272 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/otiai10/namazu/backend/internal/app"
+	"github.com/otiai10/namazu/backend/internal/config"
+	"github.com/otiai10/namazu/backend/internal/delivery"
+	"github.com/otiai10/namazu/backend/internal/subscription"
+)
+
+// sampleInterval is how often queue depth and memory are sampled
+const sampleInterval = 10 * time.Millisecond
+
+// options configures a load test run
+type options struct {
+	Subscriptions   int
+	Receivers       int
+	Events          int
+	Interval        time.Duration
+	Rate            float64 // Events per second; overrides Interval when positive
+	Filtered        float64 // Fraction of subscriptions with filters (0.0-1.0)
+	ReceiverLatency time.Duration
+	FailureRate     float64
+	Timeout         time.Duration
+	Workers         int // Delivery queue workers; 0 delivers within the event loop
+}
+
+// validate checks that options describe a runnable test
+func (o options) validate() error {
+	switch {
+	case o.Subscriptions <= 0:
+		return fmt.Errorf("subscriptions must be positive")
+	case o.Receivers <= 0:
+		return fmt.Errorf("receivers must be positive")
+	case o.Events <= 0:
+		return fmt.Errorf("events must be positive")
+	case o.FailureRate < 0 || o.FailureRate > 1:
+		return fmt.Errorf("failure-rate must be between 0 and 1")
+	case o.Timeout <= 0:
+		return fmt.Errorf("timeout must be positive")
+	case o.Workers < 0:
+		return fmt.Errorf("workers must not be negative")
+	case o.Rate < 0:
+		return fmt.Errorf("rate must not be negative")
+	case o.Rate > 0 && o.Interval > 0:
+		return fmt.Errorf("rate and interval are mutually exclusive")
+	case o.Filtered < 0 || o.Filtered > 1:
+		return fmt.Errorf("filtered must be between 0 and 1")
+	}
+	return nil
+}
+
+// interval returns the delay between injected events
+func (o options) interval() time.Duration {
+	if o.Rate > 0 {
+		return time.Duration(float64(time.Second) / o.Rate)
+	}
+	return o.Interval
+}
+
+// run executes a load test and returns its report
+func run(ctx context.Context, opts options) (*Report, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+
+	expected := opts.Subscriptions * opts.Events
+	farm, err := newReceiverFarm(opts.Receivers, opts.ReceiverLatency, opts.FailureRate, expected)
+	if err != nil {
+		return nil, err
+	}
+	defer farm.Close()
+
+	cfg := &config.Config{
+		Source:        config.SourceConfig{Type: "p2pquake", Endpoint: "loadtest://synthetic"},
+		Subscriptions: seedSubscriptions(opts.Subscriptions, farm.URLs(), opts.Filtered),
+	}
+	client := newSyntheticClient(opts.Events)
+	appOpts := []app.Option{app.WithClient(client)}
+	if opts.Workers > 0 {
+		appOpts = append(appOpts, app.WithDeliveryQueue(delivery.NewQueue(opts.Workers, 0)))
+	}
+	application := app.NewApp(cfg, subscription.NewStaticRepository(cfg), appOpts...)
+
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	sampler := newSampler(client)
+	go sampler.Run(runCtx)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	var runErr error
+	go func() {
+		defer wg.Done()
+		runErr = application.Run(runCtx)
+	}()
+
+	// Injections are paced from the start, so slow injections do not lower the rate
+	interval := opts.interval()
+	started := time.Now()
+	for i := range opts.Events {
+		if interval > 0 {
+			time.Sleep(time.Until(started.Add(time.Duration(i) * interval)))
+		}
+		id := fmt.Sprintf("loadtest-%d", i)
+		farm.MarkInjected(id, time.Now())
+		client.Inject(newSyntheticEvent(id))
+	}
+
+	select {
+	case <-farm.Done():
+	case <-time.After(opts.Timeout):
+	case <-ctx.Done():
+	}
+	elapsed := time.Since(started)
+
+	cancel()
+	wg.Wait()
+	if runErr != nil {
+		return nil, runErr
+	}
+
+	stats := farm.Stats()
+	return &Report{
+		Subscriptions:   opts.Subscriptions,
+		Events:          opts.Events,
+		Expected:        expected,
+		Delivered:       stats.Delivered,
+		Failed:          stats.Failed,
+		Elapsed:         elapsed,
+		Latencies:       stats.Latencies,
+		MaxQueueDepth:   sampler.MaxQueueDepth(),
+		MaxInFlight:     stats.MaxInFlight,
+		PeakHeapBytes:   sampler.PeakHeapBytes(),
+		PeakGoroutines:  sampler.PeakGoroutines(),
+		TotalAllocBytes: sampler.TotalAllocBytes(),
+		TotalAllocs:     sampler.TotalAllocs(),
+	}, nil
+}
+
+// seedSubscriptions creates n webhook subscriptions spread across the receivers.
+// The filtered fraction of them get a filter that the synthetic events match,
+// so every subscription still receives every event.
+func seedSubscriptions(n int, urls []string, filtered float64) []config.SubscriptionConfig {
+	withFilter := int(float64(n) * filtered)
+	subs := make([]config.SubscriptionConfig, n)
+	for i := range subs {
+		subs[i] = config.SubscriptionConfig{
+			Name: fmt.Sprintf("loadtest-sub-%d", i),
+			Delivery: config.DeliveryConfig{
+				Type:   "webhook",
+				URL:    fmt.Sprintf("%s/hook/%d", urls[i%len(urls)], i),
+				Secret: "loadtest-secret",
+			},
+		}
+		if i < withFilter {
+			subs[i].Filter = syntheticFilter()
+		}
+	}
+	return subs
+}
+
+// syntheticFilter returns a filter exercising the scale, prefecture and
+// hypocenter conditions that the synthetic events satisfy
+func syntheticFilter() *config.FilterConfig {
+	return &config.FilterConfig{
+		MinScale:               30,
+		Prefectures:            []string{"大阪府", "東京都"},
+		MinMagnitude:           5.0,
+		MaxDepthKm:             100,
+		HypocenterNameContains: "東京",
+		Geofence:               &config.GeofenceConfig{Lat: 35.68, Lon: 139.77, RadiusKm: 100},
+	}
+}
+
+// sampler periodically records queue depth and memory usage
+type sampler struct {
+	client *syntheticClient
+
+	mu             sync.Mutex
+	maxQueueDepth  int
+	peakHeap       uint64
+	peakGoroutines int
+	startAlloc     uint64
+	lastAlloc      uint64
+	startMallocs   uint64
+	lastMallocs    uint64
+}
+
+// newSampler creates a sampler for the given client
+func newSampler(client *syntheticClient) *sampler {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	return &sampler{
+		client:       client,
+		startAlloc:   m.TotalAlloc,
+		lastAlloc:    m.TotalAlloc,
+		startMallocs: m.Mallocs,
+		lastMallocs:  m.Mallocs,
+	}
+}
+
+// Run samples until the context is cancelled
+func (s *sampler) Run(ctx context.Context) {
+	ticker := time.NewTicker(sampleInterval)
+	defer ticker.Stop()
+	for {
+		s.sample()
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// sample takes a single measurement
+func (s *sampler) sample() {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	depth := s.client.QueueDepth()
+	goroutines := runtime.NumGoroutine()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.maxQueueDepth = max(s.maxQueueDepth, depth)
+	s.peakHeap = max(s.peakHeap, m.HeapAlloc)
+	s.peakGoroutines = max(s.peakGoroutines, goroutines)
+	s.lastAlloc = m.TotalAlloc
+	s.lastMallocs = m.Mallocs
+}
+
+// MaxQueueDepth returns the largest number of events waiting to be dispatched
+func (s *sampler) MaxQueueDepth() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.maxQueueDepth
+}
+
+// PeakHeapBytes returns the largest observed heap size
+func (s *sampler) PeakHeapBytes() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.peakHeap
+}
+
+// PeakGoroutines returns the largest observed number of goroutines
+func (s *sampler) PeakGoroutines() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.peakGoroutines
+}
+
+// TotalAllocBytes returns the bytes allocated since the sampler was created
+func (s *sampler) TotalAllocBytes() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lastAlloc - s.startAlloc
+}
+
+// TotalAllocs returns the number of heap objects allocated since the sampler was created
+func (s *sampler) TotalAllocs() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lastMallocs - s.startMallocs
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/otiai10/namazu/backend/internal/source"
+)
+
+// syntheticClient is an event source fed by the load test instead of P2P地震情報
+type syntheticClient struct {
+	events chan source.Event
+}
+
+// newSyntheticClient creates a client whose queue can hold the whole burst
+func newSyntheticClient(capacity int) *syntheticClient {
+	return &syntheticClient{events: make(chan source.Event, capacity)}
+}
+
+func (c *syntheticClient) Connect(ctx context.Context) error { return nil }
+
+func (c *syntheticClient) Events() <-chan source.Event { return c.events }
+
+// Close is a no-op; the channel is left open so late injections cannot panic
+func (c *syntheticClient) Close() error { return nil }
+
+// Inject queues an event for dispatch
+func (c *syntheticClient) Inject(event source.Event) {
+	c.events <- event
+}
+
+// QueueDepth returns the number of events waiting to be dispatched
+func (c *syntheticClient) QueueDepth() int {
+	return len(c.events)
+}
+
+// syntheticEvent is a shindo-7 earthquake that matches every subscription
+type syntheticEvent struct {
+	id         string
+	receivedAt time.Time
+}
+
+// newSyntheticEvent creates an event with the given ID
+func newSyntheticEvent(id string) *syntheticEvent {
+	return &syntheticEvent{id: id, receivedAt: time.Now()}
+}
+
+func (e *syntheticEvent) GetID() string             { return e.id }
+func (e *syntheticEvent) GetType() source.EventType { return source.EventTypeEarthquake }
+func (e *syntheticEvent) GetSource() string         { return "loadtest" }
+func (e *syntheticEvent) GetSeverity() int          { return 70 }
+func (e *syntheticEvent) GetAffectedAreas() []string {
+	return []string{"東京都", "神奈川県", "千葉県", "埼玉県"}
+}
+func (e *syntheticEvent) GetOccurredAt() time.Time { return e.receivedAt }
+func (e *syntheticEvent) GetReceivedAt() time.Time { return e.receivedAt }
+
+// GetHypocenter lets hypocenter filters evaluate the event
+func (e *syntheticEvent) GetHypocenter() *source.Hypocenter {
+	return &source.Hypocenter{Name: "東京湾", Latitude: 35.5, Longitude: 139.8, Depth: 30, Magnitude: 7.3}
+}
+
+func (e *syntheticEvent) GetRawJSON() string {
+	return fmt.Sprintf(`{"_id":%q,"code":551,"time":%q,"earthquake":{"hypocenter":{"name":"東京湾","magnitude":7.3,"depth":30},"maxScale":70,"domesticTsunami":"Warning"},"points":[{"pref":"東京都","addr":"千代田区","scale":70},{"pref":"神奈川県","addr":"横浜市","scale":60}]}`,
+		e.id, e.receivedAt.Format("2006/01/02 15:04:05.000"))
+}
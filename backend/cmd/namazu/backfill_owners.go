@@ -0,0 +1,216 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/otiai10/namazu/backend/internal/config"
+	"github.com/otiai10/namazu/backend/internal/secrets"
+	"github.com/otiai10/namazu/backend/internal/store"
+	"github.com/otiai10/namazu/backend/internal/subscription"
+)
+
+const backfillOwnersUsage = `Usage: namazu backfill-owners [--owners FILE] [--owner UID] [--archive] [--apply]
+
+Assigns owners to legacy subscriptions that have none, which every signed-in
+user can otherwise read and change. FILE is a CSV of "subscription_id,uid"
+lines. Subscriptions missing from it are assigned to --owner if given, or
+else archived with --archive: suspended with the reason "orphaned", so that
+they receive no deliveries until an admin assigns an owner
+(POST /api/admin/subscriptions/{id}/assign-owner) and the owner reactivates
+them. Prints the changes without writing anything unless --apply is given.
+
+Once no ownerless subscription is left, set NAMAZU_DENY_OWNERLESS_ACCESS=true.
+
+The configuration is read from the environment, as by the server.
+`
+
+// Actions of a backfill step
+const (
+	backfillAssign  = "assign"
+	backfillArchive = "archive"
+	backfillSkip    = "skip"
+)
+
+// backfillStep is what the backfill does with one ownerless subscription
+type backfillStep struct {
+	Action string
+	Sub    subscription.Subscription // To write, unless skipped
+}
+
+// runBackfillOwners runs `namazu backfill-owners`
+func runBackfillOwners(ctx context.Context, args []string, w io.Writer) error {
+	fs := flag.NewFlagSet("backfill-owners", flag.ContinueOnError)
+	fs.SetOutput(w)
+	fs.Usage = func() { fmt.Fprint(w, backfillOwnersUsage) }
+	ownersPath := fs.String("owners", "", "CSV of subscription_id,uid lines")
+	owner := fs.String("owner", "", "UID owning the subscriptions missing from --owners")
+	archive := fs.Bool("archive", false, "suspend the subscriptions that get no owner")
+	apply := fs.Bool("apply", false, "write the changes (default: dry run)")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	if *owner != "" && *archive {
+		fs.Usage()
+		return errors.New("--owner and --archive cannot be combined")
+	}
+
+	owners := map[string]string{}
+	if *ownersPath != "" {
+		f, err := os.Open(*ownersPath)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		if owners, err = readOwners(f); err != nil {
+			return fmt.Errorf("%s: %w", *ownersPath, err)
+		}
+	}
+
+	cfg, err := config.LoadFromEnv(config.WithSecretResolver(ctx, secrets.NewSecretManager()))
+	if err != nil {
+		return err
+	}
+	repo, closeStore, err := openSubscriptionStore(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer closeStore()
+
+	return backfillOwners(ctx, repo, owners, *owner, *archive, *apply, w)
+}
+
+// openSubscriptionStore opens the subscription repository of the configured
+// Firestore or SQL store
+func openSubscriptionStore(ctx context.Context, cfg *config.Config) (subscription.Repository, func(), error) {
+	if cfg.Store == nil {
+		return nil, nil, errors.New("no store: set NAMAZU_STORE_PROJECT_ID or NAMAZU_STORE_TYPE")
+	}
+	switch cfg.Store.Type {
+	case store.DialectSQLite, store.DialectPostgres:
+		client, err := store.OpenSQL(ctx, cfg.Store.Type, cfg.Store.DSN)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to open %s store: %w", cfg.Store.Type, err)
+		}
+		return subscription.NewSQLRepository(client), func() { client.Close() }, nil
+	case "firestore":
+		client, err := store.NewFirestoreClient(ctx, store.FirestoreConfig{
+			ProjectID:   cfg.Store.ProjectID,
+			Database:    cfg.Store.Database,
+			Credentials: cfg.Store.Credentials,
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to connect to Firestore: %w", err)
+		}
+		repo := subscription.NewFirestoreRepository(client.Client())
+		envelope, err := newEnvelope(cfg.Security)
+		if err != nil {
+			client.Close()
+			return nil, nil, err
+		}
+		if envelope != nil {
+			repo.SetEnvelope(envelope)
+		}
+		return repo, func() { client.Close() }, nil
+	}
+	return nil, nil, fmt.Errorf("unsupported store: %q", cfg.Store.Type)
+}
+
+// readOwners reads "subscription_id,uid" lines
+func readOwners(r io.Reader) (map[string]string, error) {
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	owners := make(map[string]string, len(records))
+	for i, record := range records {
+		if len(record) != 2 {
+			return nil, fmt.Errorf("line %d: want subscription_id,uid", i+1)
+		}
+		id, uid := strings.TrimSpace(record[0]), strings.TrimSpace(record[1])
+		if id == "" || uid == "" {
+			return nil, fmt.Errorf("line %d: empty subscription_id or uid", i+1)
+		}
+		owners[id] = uid
+	}
+	return owners, nil
+}
+
+// backfillOwners plans the backfill of repo, prints it, and writes it if apply is set
+func backfillOwners(ctx context.Context, repo subscription.Repository, owners map[string]string, owner string, archive, apply bool, w io.Writer) error {
+	subs, err := repo.List(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list subscriptions: %w", err)
+	}
+	steps := planBackfill(subs, owners, owner, archive, time.Now().UTC())
+
+	counts := map[string]int{}
+	for _, step := range steps {
+		counts[step.Action]++
+		switch step.Action {
+		case backfillAssign:
+			fmt.Fprintf(w, "+ %s (%s) → %s\n", step.Sub.Name, step.Sub.ID, step.Sub.UserID)
+		case backfillArchive:
+			fmt.Fprintf(w, "- %s (%s): archive\n", step.Sub.Name, step.Sub.ID)
+		default:
+			fmt.Fprintf(w, "? %s (%s): no owner\n", step.Sub.Name, step.Sub.ID)
+		}
+	}
+	fmt.Fprintf(w, "%d to assign, %d to archive, %d left without an owner\n",
+		counts[backfillAssign], counts[backfillArchive], counts[backfillSkip])
+
+	if !apply {
+		fmt.Fprintln(w, "Dry run: nothing was written. Run again with --apply to backfill.")
+		return nil
+	}
+	for _, step := range steps {
+		if step.Action == backfillSkip {
+			continue
+		}
+		if err := repo.Update(ctx, step.Sub.ID, step.Sub); err != nil {
+			return fmt.Errorf("failed to update %s: %w", step.Sub.ID, err)
+		}
+	}
+	fmt.Fprintf(w, "Assigned %d and archived %d subscriptions\n", counts[backfillAssign], counts[backfillArchive])
+	return nil
+}
+
+// planBackfill decides what to do with each subscription without an owner.
+// Owners from the file take precedence over the default owner.
+func planBackfill(subs []subscription.Subscription, owners map[string]string, owner string, archive bool, now time.Time) []backfillStep {
+	var steps []backfillStep
+	for _, sub := range subs {
+		if sub.UserID != "" {
+			continue
+		}
+		uid := owners[sub.ID]
+		if uid == "" {
+			uid = owner
+		}
+		switch {
+		case uid != "":
+			sub.UserID = uid
+			steps = append(steps, backfillStep{Action: backfillAssign, Sub: sub})
+		case archive:
+			if sub.StatusReason != subscription.ReasonOrphaned {
+				sub.Status = subscription.StatusSuspended
+				sub.StatusReason = subscription.ReasonOrphaned
+				sub.StatusChangedAt = &now
+			}
+			steps = append(steps, backfillStep{Action: backfillArchive, Sub: sub})
+		default:
+			steps = append(steps, backfillStep{Action: backfillSkip, Sub: sub})
+		}
+	}
+	return steps
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/otiai10/namazu/backend/internal/subscription"
+)
+
+func seedBackfill(t *testing.T) (*subscription.MemoryRepository, map[string]string) {
+	t.Helper()
+	repo := subscription.NewMemoryRepository()
+	ids := map[string]string{}
+	for _, sub := range []subscription.Subscription{
+		{Name: "mapped"},
+		{Name: "orphan"},
+		{UserID: "owner", Name: "owned"},
+	} {
+		id, err := repo.Create(context.Background(), sub)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids[sub.Name] = id
+	}
+	return repo, ids
+}
+
+func TestBackfillOwners_DryRun(t *testing.T) {
+	repo, ids := seedBackfill(t)
+	var out bytes.Buffer
+
+	if err := backfillOwners(context.Background(), repo, map[string]string{ids["mapped"]: "user-1"}, "", false, false, &out); err != nil {
+		t.Fatalf("backfillOwners() error = %v", err)
+	}
+	for _, want := range []string{
+		"+ mapped (" + ids["mapped"] + ") → user-1",
+		"? orphan (" + ids["orphan"] + "): no owner",
+		"1 to assign, 0 to archive, 1 left without an owner",
+		"Dry run",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output is missing %q:\n%s", want, out.String())
+		}
+	}
+	if sub, _ := repo.Get(context.Background(), ids["mapped"]); sub.UserID != "" {
+		t.Errorf("expected nothing written in a dry run, got owner %q", sub.UserID)
+	}
+}
+
+func TestBackfillOwners_Apply(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("archives the rest", func(t *testing.T) {
+		repo, ids := seedBackfill(t)
+		var out bytes.Buffer
+		if err := backfillOwners(ctx, repo, map[string]string{ids["mapped"]: "user-1"}, "", true, true, &out); err != nil {
+			t.Fatalf("backfillOwners() error = %v", err)
+		}
+		if sub, _ := repo.Get(ctx, ids["mapped"]); sub.UserID != "user-1" || sub.Status == subscription.StatusSuspended {
+			t.Errorf("mapped = %+v, want assigned to user-1", sub)
+		}
+		sub, _ := repo.Get(ctx, ids["orphan"])
+		if sub.UserID != "" || sub.Status != subscription.StatusSuspended || sub.StatusReason != subscription.ReasonOrphaned || sub.StatusChangedAt == nil {
+			t.Errorf("orphan = %+v, want suspended as orphaned", sub)
+		}
+		if sub, _ := repo.Get(ctx, ids["owned"]); sub.UserID != "owner" || sub.Status != "" {
+			t.Errorf("owned = %+v, want untouched", sub)
+		}
+	})
+
+	t.Run("assigns the rest to the default owner", func(t *testing.T) {
+		repo, ids := seedBackfill(t)
+		var out bytes.Buffer
+		if err := backfillOwners(ctx, repo, map[string]string{ids["mapped"]: "user-1"}, "ops", false, true, &out); err != nil {
+			t.Fatalf("backfillOwners() error = %v", err)
+		}
+		if sub, _ := repo.Get(ctx, ids["mapped"]); sub.UserID != "user-1" {
+			t.Errorf("mapped owner = %q, want user-1 from the file", sub.UserID)
+		}
+		if sub, _ := repo.Get(ctx, ids["orphan"]); sub.UserID != "ops" {
+			t.Errorf("orphan owner = %q, want ops", sub.UserID)
+		}
+	})
+}
+
+func TestReadOwners(t *testing.T) {
+	owners, err := readOwners(strings.NewReader("sub-1,user-1\n sub-2 , user-2 \n"))
+	if err != nil {
+		t.Fatalf("readOwners() error = %v", err)
+	}
+	if len(owners) != 2 || owners["sub-1"] != "user-1" || owners["sub-2"] != "user-2" {
+		t.Errorf("readOwners() = %v", owners)
+	}
+
+	for _, input := range []string{"sub-1\n", "sub-1,\n", "sub-1,user-1,extra\n"} {
+		if _, err := readOwners(strings.NewReader(input)); err == nil {
+			t.Errorf("readOwners(%q) expected error", input)
+		}
+	}
+}
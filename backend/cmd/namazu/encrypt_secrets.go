@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/otiai10/namazu/backend/internal/config"
+	"github.com/otiai10/namazu/backend/internal/secrets"
+	"github.com/otiai10/namazu/backend/internal/store"
+	"github.com/otiai10/namazu/backend/internal/subscription"
+)
+
+const encryptSecretsUsage = `Usage: namazu encrypt-secrets [--apply]
+
+Encrypts the subscription secrets that are stored in plaintext in Firestore,
+with the key of NAMAZU_SECRETS_KMS_KEY or NAMAZU_SECRETS_ENCRYPTION_KEY. Run it
+once after enabling encryption: subscriptions created or changed afterwards are
+encrypted as they are written. Counts the plaintext secrets without writing
+anything unless --apply is given.
+
+The configuration is read from the environment, as by the server.
+`
+
+// runEncryptSecrets runs `namazu encrypt-secrets`
+func runEncryptSecrets(ctx context.Context, args []string, w io.Writer) error {
+	fs := flag.NewFlagSet("encrypt-secrets", flag.ContinueOnError)
+	fs.SetOutput(w)
+	fs.Usage = func() { fmt.Fprint(w, encryptSecretsUsage) }
+	apply := fs.Bool("apply", false, "write the changes (default: dry run)")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+
+	cfg, err := config.LoadFromEnv(config.WithSecretResolver(ctx, secrets.NewSecretManager()))
+	if err != nil {
+		return err
+	}
+	if cfg.Store == nil || cfg.Store.Type != "firestore" {
+		return errors.New("no Firestore store: set NAMAZU_STORE_PROJECT_ID")
+	}
+	envelope, err := newEnvelope(cfg.Security)
+	if err != nil {
+		return err
+	}
+	if envelope == nil {
+		return errors.New("no encryption key: set NAMAZU_SECRETS_KMS_KEY or NAMAZU_SECRETS_ENCRYPTION_KEY")
+	}
+
+	client, err := store.NewFirestoreClient(ctx, store.FirestoreConfig{
+		ProjectID:   cfg.Store.ProjectID,
+		Database:    cfg.Store.Database,
+		Credentials: cfg.Store.Credentials,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to connect to Firestore: %w", err)
+	}
+	defer client.Close()
+
+	repo := subscription.NewFirestoreRepository(client.Client())
+	repo.SetEnvelope(envelope)
+	n, err := repo.EncryptSecrets(ctx, *apply)
+	if err != nil {
+		return err
+	}
+	if *apply {
+		fmt.Fprintf(w, "Encrypted the secrets of %d subscriptions\n", n)
+	} else {
+		fmt.Fprintf(w, "%d subscriptions have plaintext secrets; run with --apply to encrypt them\n", n)
+	}
+	return nil
+}
+
+// newEnvelope returns the envelope that encrypts subscription secrets at rest,
+// or nil if no key is configured
+func newEnvelope(sec *config.SecurityConfig) (*secrets.Envelope, error) {
+	switch {
+	case sec == nil:
+		return nil, nil
+	case sec.SecretsKMSKey != "":
+		return secrets.NewEnvelope(secrets.NewKMSKeyWrapper(sec.SecretsKMSKey)), nil
+	case sec.SecretsEncryptionKey != "":
+		key, err := sec.SecretsKey()
+		if err != nil {
+			return nil, err
+		}
+		wrapper, err := secrets.NewLocalKeyWrapper(key)
+		if err != nil {
+			return nil, err
+		}
+		return secrets.NewEnvelope(wrapper), nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"testing"
+
+	"github.com/otiai10/namazu/backend/internal/config"
+)
+
+func TestNewEnvelope(t *testing.T) {
+	for _, sec := range []*config.SecurityConfig{nil, {}} {
+		env, err := newEnvelope(sec)
+		if env != nil || err != nil {
+			t.Errorf("newEnvelope(%+v) = %v, %v; want nil without a key", sec, env, err)
+		}
+	}
+
+	env, err := newEnvelope(&config.SecurityConfig{SecretsEncryptionKey: base64.StdEncoding.EncodeToString(make([]byte, 32))})
+	if err != nil || env == nil {
+		t.Fatalf("newEnvelope() = %v, %v; want an envelope", env, err)
+	}
+	sealed, err := env.Seal(context.Background(), "whsec_xxx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := env.Open(context.Background(), sealed); err != nil || got != "whsec_xxx" {
+		t.Errorf("Open() = %q, %v; want whsec_xxx", got, err)
+	}
+
+	if _, err := newEnvelope(&config.SecurityConfig{SecretsEncryptionKey: "short"}); err == nil {
+		t.Error("newEnvelope() expected error for an invalid key")
+	}
+}
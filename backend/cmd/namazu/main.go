@@ -4,6 +4,7 @@ import (
 	"context"
 	"flag"
 	"log"
+	"net"
 	"os"
 	"os/signal"
 	"syscall"
@@ -11,20 +12,72 @@ import (
 
 	"github.com/joho/godotenv"
 
+	"github.com/otiai10/namazu/backend/internal/account"
+	"github.com/otiai10/namazu/backend/internal/analytics"
 	"github.com/otiai10/namazu/backend/internal/api"
 	"github.com/otiai10/namazu/backend/internal/app"
+	"github.com/otiai10/namazu/backend/internal/archive"
+	"github.com/otiai10/namazu/backend/internal/audit"
 	"github.com/otiai10/namazu/backend/internal/auth"
+	"github.com/otiai10/namazu/backend/internal/badge"
 	"github.com/otiai10/namazu/backend/internal/config"
+	"github.com/otiai10/namazu/backend/internal/delivery"
+	"github.com/otiai10/namazu/backend/internal/delivery/aws"
+	"github.com/otiai10/namazu/backend/internal/delivery/fcm"
+	"github.com/otiai10/namazu/backend/internal/delivery/sms"
 	"github.com/otiai10/namazu/backend/internal/delivery/webhook"
+	"github.com/otiai10/namazu/backend/internal/delivery/webpush"
+	"github.com/otiai10/namazu/backend/internal/deliverylog"
+	"github.com/otiai10/namazu/backend/internal/egress"
+	"github.com/otiai10/namazu/backend/internal/idempotency"
+	"github.com/otiai10/namazu/backend/internal/lifecycle"
+	"github.com/otiai10/namazu/backend/internal/logging"
+	"github.com/otiai10/namazu/backend/internal/mail"
+	"github.com/otiai10/namazu/backend/internal/plan"
 	"github.com/otiai10/namazu/backend/internal/quota"
+	"github.com/otiai10/namazu/backend/internal/ratelimit"
+	"github.com/otiai10/namazu/backend/internal/secrets"
+	"github.com/otiai10/namazu/backend/internal/security"
 	"github.com/otiai10/namazu/backend/internal/store"
+	"github.com/otiai10/namazu/backend/internal/stream"
 	"github.com/otiai10/namazu/backend/internal/subscription"
+	"github.com/otiai10/namazu/backend/internal/tenant"
+	"github.com/otiai10/namazu/backend/internal/throttle"
+	"github.com/otiai10/namazu/backend/internal/tracing"
 	"github.com/otiai10/namazu/backend/internal/user"
+	"github.com/otiai10/namazu/backend/internal/webui"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(context.Background(), os.Args[2:], os.Stdout); err != nil {
+			log.Fatalf("migrate: %v", err)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "encrypt-secrets" {
+		if err := runEncryptSecrets(context.Background(), os.Args[2:], os.Stdout); err != nil {
+			log.Fatalf("encrypt-secrets: %v", err)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "backfill-owners" {
+		if err := runBackfillOwners(context.Background(), os.Args[2:], os.Stdout); err != nil {
+			log.Fatalf("backfill-owners: %v", err)
+		}
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "validate" {
+		if err := runValidate(context.Background(), os.Args[2:], os.Stdout); err != nil {
+			log.Fatalf("validate: %v", err)
+		}
+		return
+	}
+
 	// Parse command-line flags
 	testMode := flag.Bool("test-mode", false, "Run in test mode (disables authentication)")
+	configPath := flag.String("config", os.Getenv("NAMAZU_CONFIG_FILE"), "YAML config file, reloaded on SIGHUP or when it changes (default: environment variables only)")
 	flag.Parse()
 
 	if *testMode {
@@ -36,27 +89,102 @@ func main() {
 	// Silently ignore if file doesn't exist (production uses real env vars)
 	_ = godotenv.Load(".env.localdev")
 
-	// Load configuration from environment variables
-	cfg, err := config.LoadFromEnv()
+	// Load configuration from the config file (if any) and environment variables,
+	// resolving sm:// references with Secret Manager (Application Default Credentials)
+	secretManager := secrets.NewSecretManager()
+	loadConfig := func(path string) (*config.Config, error) {
+		return config.Load(path, config.WithSecretResolver(context.Background(), secretManager))
+	}
+	cfg, err := loadConfig(*configPath)
 	if err != nil {
 		log.Fatalf("Failed to load configuration: %v", err)
 	}
-
-	// In test mode, disable authentication
-	if *testMode && cfg.Auth != nil {
-		cfg.Auth.Enabled = false
+	var adjustConfig func(cfg *config.Config)
+	if *testMode {
+		adjustConfig = applyTestMode
+		adjustConfig(cfg)
 	}
+	logLevel, _ := logging.ParseLevel(cfg.LogLevel) // validated by Load
+	logging.SetLevel(logLevel)
+	allowLocalWebhooks := cfg.Security != nil && cfg.Security.AllowLocalWebhooks
 
 	// Setup context with signal handling
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	// Export traces if an OTLP collector is configured
+	shutdownTracing, err := tracing.Setup(ctx, cfg.Tracing)
+	if err != nil {
+		log.Fatalf("Failed to set up tracing: %v", err)
+	}
+	if cfg.Tracing != nil && cfg.Tracing.Endpoint != "" {
+		log.Printf("Exporting traces to %s", cfg.Tracing.Endpoint)
+	}
+
 	// Initialize repositories based on configuration
 	var subRepo subscription.Repository
+	var staticSubs *subscription.StaticRepository
+	var watchedSubs *subscription.WatchedRepository
 	var eventRepo store.EventRepository
+	var retryRepo store.RetryRepository
+	var deliveryRepo store.DeliveryRepository
+	var egressMeter *egress.Meter
+	var auditLog *audit.Logger
+	var throttleRepo throttle.Repository
+	var digestRepo store.DigestRepository
+	var rollupRepo analytics.Repository
 	var firestoreClient *store.FirestoreClient
+	var secretsEnvelope *secrets.Envelope // nil keeps secrets in plaintext
+	var sqlClient *store.SQLClient
+	var memoryUsers *user.MemoryRepository
 
+	// Test mode without a store keeps everything in memory so the whole API works locally
+	storeType := ""
 	if cfg.Store != nil {
+		storeType = cfg.Store.Type
+	} else if *testMode {
+		storeType = "memory"
+	}
+
+	switch storeType {
+	case "memory":
+		// Demo mode: every repository in process memory, seeded with the configured subscriptions
+		memorySubs := subscription.NewMemoryRepository()
+		static, _ := subscription.NewStaticRepository(cfg).List(ctx)
+		for _, sub := range static {
+			if _, err := memorySubs.Create(ctx, sub); err != nil {
+				log.Fatalf("Failed to seed subscriptions: %v", err)
+			}
+		}
+		subRepo = memorySubs
+		eventRepo = store.NewMemoryEventRepository()
+		retryRepo = store.NewMemoryRetryRepository()
+		deliveryRepo = store.NewMemoryDeliveryRepository()
+		egressMeter = egress.NewMeter(egress.NewMemoryRepository())
+		auditLog = audit.NewLogger(audit.NewMemoryRepository())
+		rollupRepo = analytics.NewMemoryRepository()
+		memoryUsers = user.NewMemoryRepository()
+		log.Println("Using in-memory store (data is lost on restart)")
+	case store.DialectSQLite, store.DialectPostgres:
+		// Self-hosted mode: subscriptions, events and users in SQLite or Postgres
+		log.Printf("Opening %s store", cfg.Store.Type)
+		sqlClient, err = store.OpenSQL(ctx, cfg.Store.Type, cfg.Store.DSN)
+		if err != nil {
+			log.Fatalf("Failed to open %s store: %v", cfg.Store.Type, err)
+		}
+		defer sqlClient.Close()
+
+		subRepo = subscription.NewCachedRepository(subscription.NewSQLRepository(sqlClient), subscription.CacheTTLFromStore(cfg.Store))
+		eventRepo = store.NewSQLEventRepository(sqlClient)
+		log.Printf("Using %s for subscriptions and event storage", cfg.Store.Type)
+	case "":
+		// Phase 1 mode: Use static subscriptions from config file
+		staticSubs = subscription.NewStaticRepository(cfg)
+		subRepo = staticSubs
+		// Without a store, config reloads are audited in memory
+		auditLog = audit.NewLogger(audit.NewMemoryRepository())
+		log.Println("Using static subscriptions from config file")
+	default:
 		// Phase 2 mode: Use Firestore for dynamic subscriptions and event storage
 		log.Printf("Initializing Firestore client for project: %s, database: %s",
 			cfg.Store.ProjectID, cfg.Store.Database)
@@ -70,17 +198,41 @@ func main() {
 		}
 		defer firestoreClient.Close()
 
-		subRepo = subscription.NewFirestoreRepository(firestoreClient.Client())
-		eventRepo = store.NewFirestoreEventRepository(firestoreClient.Client())
+		// All repositories share one guard, so a Firestore outage trips a single breaker
+		guard := store.NewGuard(store.GuardConfigFromStore(cfg.Store))
+		// Every event lists the subscriptions: a snapshot listener keeps them in memory,
+		// falling back to a cached read while it reconnects
+		firestoreSubs := subscription.NewFirestoreRepository(firestoreClient.Client())
+		envelope, err := newEnvelope(cfg.Security)
+		if err != nil {
+			log.Fatalf("Failed to load the secrets encryption key: %v", err)
+		}
+		secretsEnvelope = envelope
+		if envelope != nil {
+			firestoreSubs.SetEnvelope(envelope)
+			log.Println("Encrypting subscription secrets at rest")
+		}
+		watchedSubs = subscription.NewWatchedRepository(
+			subscription.NewCachedRepository(subscription.NewGuardedRepository(firestoreSubs, guard), subscription.CacheTTLFromStore(cfg.Store)),
+			firestoreSubs)
+		go watchedSubs.Run(ctx)
+		subRepo = watchedSubs
+		eventRepo = store.NewGuardedEventRepository(store.NewFirestoreEventRepository(firestoreClient.Client()), guard)
+		retryRepo = store.NewGuardedRetryRepository(store.NewFirestoreRetryRepository(firestoreClient.Client()), guard)
+		deliveryRepo = store.NewGuardedDeliveryRepository(store.NewFirestoreDeliveryRepository(firestoreClient.Client()), guard)
+		egressMeter = egress.NewMeter(egress.NewFirestoreRepository(firestoreClient.Client()))
+		auditLog = audit.NewLogger(audit.NewFirestoreRepository(firestoreClient.Client()))
+		throttleRepo = throttle.NewFirestoreRepository(firestoreClient.Client())
+		digestRepo = store.NewFirestoreDigestRepository(firestoreClient.Client())
+		rollupRepo = analytics.NewFirestoreRepository(firestoreClient.Client())
 		log.Println("Using Firestore for subscriptions and event storage")
-	} else {
-		// Phase 1 mode: Use static subscriptions from config file
-		subRepo = subscription.NewStaticRepository(cfg)
-		log.Println("Using static subscriptions from config file")
 	}
 
 	// Initialize authentication if configured
 	var tokenVerifier auth.TokenVerifier
+	var roleSetter auth.RoleSetter
+	var tokenRevoker auth.TokenRevoker
+	var providerLinker auth.ProviderLinker
 	var userRepo user.Repository
 	var quotaChecker quota.QuotaChecker
 
@@ -100,13 +252,22 @@ func main() {
 			log.Fatalf("Failed to create Firebase Auth verifier: %v", err)
 		}
 		tokenVerifier = verifier
+		roleSetter = verifier
+		tokenRevoker = verifier
+		providerLinker = verifier
 		log.Println("Firebase Auth enabled")
 
-		// User repository requires Firestore
-		if firestoreClient == nil {
-			log.Fatal("Auth requires store configuration (Firestore)")
+		// User repository requires a store
+		switch {
+		case firestoreClient != nil:
+			userRepo = user.NewFirestoreRepository(firestoreClient.Client())
+		case sqlClient != nil:
+			userRepo = user.NewSQLRepository(sqlClient)
+		case memoryUsers != nil:
+			userRepo = memoryUsers
+		default:
+			log.Fatal("Auth requires store configuration (Firestore, SQLite, Postgres or memory)")
 		}
-		userRepo = user.NewFirestoreRepository(firestoreClient.Client())
 
 		// Initialize quota checker for subscription limits
 		quotaChecker = quota.NewChecker(subRepo)
@@ -118,21 +279,279 @@ func main() {
 	if eventRepo != nil {
 		opts = append(opts, app.WithEventRepository(eventRepo))
 	}
+	if retryRepo != nil {
+		opts = append(opts, app.WithRetryRepository(retryRepo))
+	}
+	if deliveryRepo != nil {
+		opts = append(opts, app.WithDeliveryRepository(deliveryRepo))
+	}
+	if egressMeter != nil {
+		opts = append(opts, app.WithEgressMeter(egressMeter))
+	}
+	if digestRepo != nil {
+		opts = append(opts, app.WithDigestRepository(digestRepo))
+	}
+	if rollupRepo != nil {
+		opts = append(opts, app.WithRollups(rollupRepo))
+	}
+	if cfg.ValidatePayloads {
+		log.Println("Payload validation enabled: generated payloads are checked against their JSON Schemas")
+		opts = append(opts, app.WithPayloadValidation(true))
+	}
+	// Deliveries only connect to public addresses, checked on the address actually
+	// dialed so a host cannot be rebound to an internal one after validation
+	resolver := webhook.NewResolver(webhook.WithAddressCheck(func(ip net.IP) error {
+		return security.CheckIP(ip, allowLocalWebhooks)
+	}))
+	// Webhooks and URL verification challenges take the same route out. Through
+	// a proxy, the resolver still checks the target's addresses before each request.
+	senderOpts := []webhook.SenderOption{webhook.WithResolver(resolver)}
+	if proxy, _ := cfg.Egress.ProxyURL(); proxy != nil {
+		senderOpts = append(senderOpts, webhook.WithProxy(proxy))
+		log.Printf("Sending webhooks through the %s proxy at %s", proxy.Scheme, proxy.Host)
+	}
+	limits := webhook.DefaultLimits()
+	if cfg.Sender != nil {
+		if cfg.Sender.MaxInFlight > 0 {
+			limits.MaxInFlight = cfg.Sender.MaxInFlight
+		}
+		if cfg.Sender.MaxPerHost > 0 {
+			limits.MaxPerHost = cfg.Sender.MaxPerHost
+		}
+		if cfg.Sender.MaxIdlePerHost > 0 {
+			limits.MaxIdleConnsPerHost = cfg.Sender.MaxIdlePerHost
+		}
+		if cfg.Sender.MaxHeaderBytes > 0 {
+			limits.MaxResponseHeaderBytes = cfg.Sender.MaxHeaderBytes
+		}
+		if cfg.Sender.MaxBodyBytes > 0 {
+			limits.MaxResponseBodyBytes = cfg.Sender.MaxBodyBytes
+		}
+		switch {
+		case cfg.Sender.CaptureBytes < 0:
+			limits.ResponseCaptureBytes = 0
+		case cfg.Sender.CaptureBytes > 0:
+			limits.ResponseCaptureBytes = cfg.Sender.CaptureBytes
+		}
+	}
+	sender := webhook.NewSender(append(senderOpts, webhook.WithLimits(limits))...)
+	opts = append(opts, app.WithSender(sender))
+	log.Printf("Webhook sender: %d requests in flight, %d per host", limits.MaxInFlight, limits.MaxPerHost)
+	opts = append(opts, app.WithThrottle(throttle.NewLimiter(throttleRepo)))
+	awsDispatcher := aws.NewDispatcher(aws.NewClient())
+	opts = append(opts,
+		app.WithDispatcher(subscription.DeliveryTypeSNS, awsDispatcher),
+		app.WithDispatcher(subscription.DeliveryTypeSQS, awsDispatcher))
+	if cfg.SMS != nil {
+		smsClient := sms.NewClient(cfg.SMS.AccountSID, cfg.SMS.AuthToken, cfg.SMS.From)
+		opts = append(opts, app.WithDispatcher(subscription.DeliveryTypeSMS,
+			sms.NewDispatcher(smsClient, cfg.SMS.StatusCallbackURL, deliveryRepo)))
+		log.Printf("SMS delivery enabled (from %s)", cfg.SMS.From)
+	}
+	var vapidPublicKey string
+	if cfg.WebPush != nil && userRepo != nil {
+		vapid, err := webpush.NewVAPID(cfg.WebPush.VAPIDPrivateKey, cfg.WebPush.Subject)
+		if err != nil {
+			log.Fatalf("Failed to set up Web Push: %v", err)
+		}
+		vapidPublicKey = vapid.PublicKey()
+		opts = append(opts, app.WithDispatcher(subscription.DeliveryTypeWebPush,
+			webpush.NewDispatcher(webpush.NewClient(vapid), userRepo)))
+		log.Println("Web Push delivery enabled")
+	}
+	var deviceTopics *fcm.Topics
+	if cfg.FCM != nil && userRepo != nil {
+		messaging, err := fcm.NewMessaging(ctx, cfg.FCM.ProjectID, cfg.FCM.Credentials)
+		if err != nil {
+			log.Fatalf("Failed to set up FCM: %v", err)
+		}
+		deviceTopics = fcm.NewTopics(messaging)
+		opts = append(opts, app.WithDispatcher(subscription.DeliveryTypeFCM, fcm.NewDispatcher(messaging, userRepo)))
+		log.Printf("FCM delivery enabled (project: %s)", cfg.FCM.ProjectID)
+	}
+	healthTracker := delivery.NewHealthTracker(delivery.DefaultHealthWindow)
+	opts = append(opts, app.WithHealthTracker(healthTracker))
+	var queueWorkers, queueSize int
+	drainTimeout := app.DefaultDrainTimeout
+	if cfg.DeliveryQueue != nil {
+		queueWorkers, queueSize = cfg.DeliveryQueue.Workers, cfg.DeliveryQueue.Size
+		switch {
+		case cfg.DeliveryQueue.DrainMs < 0:
+			drainTimeout = 0
+		case cfg.DeliveryQueue.DrainMs > 0:
+			drainTimeout = time.Duration(cfg.DeliveryQueue.DrainMs) * time.Millisecond
+		}
+	}
+	deliveryQueue := delivery.NewQueue(queueWorkers, queueSize)
+	opts = append(opts, app.WithDeliveryQueue(deliveryQueue), app.WithDrainTimeout(drainTimeout))
+	log.Printf("Delivery queue: %d workers, %d slots, %v drain", deliveryQueue.Stats().Workers, deliveryQueue.Stats().Capacity, drainTimeout)
+	if len(cfg.Plans) > 0 {
+		tenant.SetDefaultPlans(cfg.Plans)
+		log.Printf("Plan catalog: %d plan(s)", len(cfg.Plans))
+	}
+	var tenants *tenant.Registry
+	if len(cfg.Tenants) > 0 {
+		tenants = tenant.NewRegistry(cfg.Tenants)
+		opts = append(opts, app.WithTenants(tenants))
+		log.Printf("White-label mode enabled for %d tenant(s)", len(cfg.Tenants))
+	}
+	if userRepo != nil {
+		// Plans change rarely; a minute of staleness spares a user read per delivery
+		opts = append(opts, app.WithPlanFeatures(plan.NewResolver(userRepo, tenants, time.Minute)))
+	}
+	liveStream := stream.NewHub()
+	opts = append(opts, app.WithStream(liveStream))
 	application := app.NewApp(cfg, subRepo, opts...)
 
+	// Subscription expiry and inactivity cleanup requires delivery history
+	var sweeper *lifecycle.Sweeper
+	if deliveryRepo != nil {
+		sweeperOpts := []lifecycle.Option{lifecycle.WithDeliveryHistory(deliveryRepo), lifecycle.WithTenants(tenants)}
+		if userRepo != nil {
+			sweeperOpts = append(sweeperOpts, lifecycle.WithUsers(userRepo))
+		}
+		if cfg.Mail != nil && cfg.Mail.SMTPAddr != "" {
+			sweeperOpts = append(sweeperOpts, lifecycle.WithMailer(mail.NewSMTPSender(*cfg.Mail)))
+			log.Printf("Owner notification emails enabled via %s", cfg.Mail.SMTPAddr)
+		}
+		sweeper = lifecycle.NewSweeper(subRepo, lifecycle.PolicyFromConfig(cfg.Lifecycle), sweeperOpts...)
+		go sweeper.Run(ctx, lifecycle.DefaultInterval)
+	}
+
+	// Old events are archived to Cloud Storage, then deleted from the store
+	if cfg.Archive != nil {
+		events, ok := eventRepo.(archive.EventStore)
+		if !ok {
+			log.Fatalf("Event archive requires a store (store.type)")
+		}
+		sink, err := archive.NewGCSSink(ctx, cfg.Archive.Bucket, cfg.Archive.Credentials)
+		if err != nil {
+			log.Fatalf("Failed to set up the event archive: %v", err)
+		}
+		defer sink.Close()
+		go archive.New(events, sink, cfg.Archive).Run(ctx, archive.DefaultInterval)
+		log.Printf("Archiving events to gs://%s/%s", cfg.Archive.Bucket, cfg.Archive.Prefix)
+	}
+
 	// Start API server if configured
 	var apiServer *api.Server
+	var securityReloader *api.SecurityReloader
 	if cfg.API != nil {
 		log.Printf("Starting REST API server on %s", cfg.API.Addr)
+		securityReloader = api.NewSecurityReloader()
 
 		// Use RouterConfig for auth-aware routing
 		routerCfg := api.RouterConfig{
 			SubscriptionRepo: subRepo,
 			EventRepo:        eventRepo,
 			TokenVerifier:    tokenVerifier,
+			RoleSetter:       roleSetter,
+			TokenRevoker:     tokenRevoker,
+			ProviderLinker:   providerLinker,
 			UserRepo:         userRepo,
 			QuotaChecker:     quotaChecker,
-			Challenger:       webhook.NewChallenger(10 * time.Second),
+			URLValidator:     security.NewWebhookURLValidator(allowLocalWebhooks),
+			Challenger:       webhook.NewChallenger(10*time.Second, senderOpts...),
+			SecurityConfig:   cfg.Security,
+			SecurityReloader: securityReloader,
+			AuthConfig:       cfg.Auth,
+			Config:           cfg,
+			Tenants:          tenants,
+			ResolverStats:    resolver,
+			QueueStats:       deliveryQueue,
+			SenderStats:      sender,
+			Broadcaster:      application,
+			Tester:           application,
+			EventPublisher:   application,
+			Stream:           liveStream,
+			Stats:            application,
+			HealthReporter:   healthTracker,
+			Rollups:          rollupRepo,
+		}
+		// /readyz checks what the API and deliveries depend on
+		routerCfg.Readiness = map[string]api.ReadinessCheck{
+			"source": sourceCheck(application, time.Now),
+		}
+		switch {
+		case firestoreClient != nil:
+			routerCfg.Readiness["firestore"] = pingCheck(firestoreClient.Ping)
+		case sqlClient != nil:
+			routerCfg.Readiness[sqlClient.Dialect()] = pingCheck(sqlClient.Ping)
+		}
+		if cfg.Auth != nil && cfg.Auth.Enabled {
+			routerCfg.Readiness["auth"] = authCheck(tokenVerifier)
+		}
+		if egressMeter != nil {
+			routerCfg.EgressMeter = egressMeter
+		}
+		if auditLog != nil {
+			routerCfg.AuditLog = auditLog
+		}
+		if sweeper != nil {
+			routerCfg.Lifecycle = sweeper
+		}
+		if watchedSubs != nil {
+			routerCfg.WatchStats = watchedSubs
+		}
+		if cfg.API.EventInjection || *testMode {
+			routerCfg.EventInjector = application
+			log.Println("⚠️  Event injection enabled: synthetic events are delivered to subscribers")
+		}
+		if cfg.SMS != nil {
+			routerCfg.SMS = cfg.SMS
+		}
+		if cfg.Egress != nil {
+			routerCfg.EgressIPs = cfg.Egress.IPs
+		}
+		routerCfg.VAPIDPublicKey = vapidPublicKey
+		// Validate ensures the firestore rate limit backend comes with a Firestore store
+		if cfg.Security != nil && cfg.Security.RateLimitBackend == "firestore" && firestoreClient != nil {
+			routerCfg.RateLimitStore = ratelimit.NewFirestoreStore(firestoreClient.Client())
+			log.Println("Sharing rate limit counts between instances through Firestore")
+		}
+		// Idempotency keys must be shared by every instance, which Firestore provides
+		if firestoreClient != nil {
+			routerCfg.IdempotencyRepo = idempotency.NewFirestoreRepository(firestoreClient.Client())
+			// Stored responses include the secrets of created subscriptions
+			routerCfg.SecretsEnvelope = secretsEnvelope
+		} else {
+			routerCfg.IdempotencyRepo = idempotency.NewMemoryRepository()
+		}
+		if deviceTopics != nil {
+			routerCfg.DeviceTopics = deviceTopics
+		}
+		if cfg.API.PublicEvents != nil && cfg.API.PublicEvents.Enabled {
+			routerCfg.PublicEvents = cfg.API.PublicEvents
+			log.Println("Public events API enabled")
+		}
+		if cfg.Security != nil && cfg.Security.BadgeSecret != "" {
+			routerCfg.BadgeSigner = badge.NewSigner(cfg.Security.BadgeSecret)
+			log.Println("Subscription health badges enabled")
+		}
+		if userRepo != nil {
+			secret := ""
+			if cfg.Security != nil {
+				secret = cfg.Security.AccountTokenSecret
+			}
+			if secret == "" {
+				log.Println("⚠️  No account token secret: deletion tokens and export links only work on this instance")
+			}
+			routerCfg.AccountSigner = account.NewSigner(secret)
+			if cfg.Mail != nil && cfg.Mail.SMTPAddr != "" {
+				routerCfg.Mailer = mail.NewSMTPSender(*cfg.Mail)
+			}
+		}
+		if deliveryRepo != nil {
+			routerCfg.DeliveryRepo = deliveryRepo
+			routerCfg.Redeliverer = application
+		}
+		if cfg.Security != nil && cfg.Security.DeliveryLogPrivateKey != "" && deliveryRepo != nil {
+			signer, err := deliverylog.ParseSigner(cfg.Security.DeliveryLogPrivateKey)
+			if err != nil {
+				log.Fatalf("Failed to load delivery log key: %v", err)
+			}
+			routerCfg.DeliveryLog = signer
+			log.Printf("Signed delivery log exports enabled (key %s)", signer.KeyID())
 		}
 		handler := api.NewRouterWithConfig(routerCfg)
 
@@ -141,6 +560,15 @@ func main() {
 			staticServer := api.NewStaticFileServer(staticFS, staticRoot())
 			handler = api.WithStaticFiles(handler, staticServer)
 			log.Println("Static file serving enabled")
+		} else {
+			uiCfg := webui.Config{}
+			if cfg.Auth != nil {
+				uiCfg.AuthEnabled = cfg.Auth.Enabled
+				uiCfg.FirebaseAPIKey = cfg.Auth.WebAPIKey
+				uiCfg.FirebaseTenantID = cfg.Auth.TenantID
+			}
+			handler = api.WithStaticFiles(handler, webui.Handler(uiCfg))
+			log.Println("Built-in web UI enabled")
 		}
 
 		apiServer = api.NewServerWithHandler(cfg.API.Addr, handler, subRepo, eventRepo)
@@ -151,17 +579,59 @@ func main() {
 		}()
 	}
 
-	// Handle shutdown signals
+	// Reload the config file on SIGHUP or when it changes
+	if *configPath != "" {
+		reloader := &configReloader{
+			path:     *configPath,
+			load:     loadConfig,
+			adjust:   adjustConfig,
+			static:   staticSubs,
+			security: securityReloader,
+			auditLog: auditLog,
+			running:  cfg,
+		}
+		hup := make(chan os.Signal, 1)
+		signal.Notify(hup, syscall.SIGHUP)
+		go func() {
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-hup:
+					_ = reloader.reload(ctx, "SIGHUP")
+				}
+			}
+		}()
+		go config.Watch(ctx, *configPath, configWatchInterval, func() {
+			_ = reloader.reload(ctx, "file")
+		})
+		log.Printf("Reloading %s on SIGHUP or when it changes", *configPath)
+	}
+
+	// Handle shutdown signals. The API server stops while the application
+	// drains its deliveries, so both fit in the time the platform allows.
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 
+	apiStopped := make(chan struct{})
 	go func() {
+		defer close(apiStopped)
 		sig := <-sigChan
 		log.Printf("Received signal: %v", sig)
 		cancel()
+
+		if apiServer == nil {
+			return
+		}
+		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), max(drainTimeout, 2*time.Second))
+		defer shutdownCancel()
+		if err := apiServer.Shutdown(shutdownCtx); err != nil {
+			log.Printf("API server shutdown error: %v", err)
+		}
+		log.Println("API server stopped")
 	}()
 
-	// Run application (WebSocket client)
+	// Run application (WebSocket client); it returns once deliveries are drained
 	log.Println("namazu - Earthquake Webhook Relay Server")
 	if err := application.Run(ctx); err != nil {
 		log.Fatalf("Application error: %v", err)
@@ -169,16 +639,28 @@ func main() {
 
 	// Graceful shutdown
 	log.Println("Shutting down...")
+	<-apiStopped
 
-	// Shutdown API server if running
-	if apiServer != nil {
-		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 10*time.Second)
-		defer shutdownCancel()
-		if err := apiServer.Shutdown(shutdownCtx); err != nil {
-			log.Printf("API server shutdown error: %v", err)
-		}
-		log.Println("API server stopped")
+	// Flush pending spans
+	tracingCtx, tracingCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer tracingCancel()
+	if err := shutdownTracing(tracingCtx); err != nil {
+		log.Printf("Tracing shutdown error: %v", err)
 	}
 
 	log.Println("Goodbye!")
 }
+
+// applyTestMode adjusts a loaded config for --test-mode: authentication is
+// disabled and webhooks may be delivered to receivers on localhost.
+func applyTestMode(cfg *config.Config) {
+	if cfg.Auth != nil {
+		cfg.Auth.Enabled = false
+		cfg.SetRuntime("auth.enabled", "--test-mode")
+	}
+	if cfg.Security == nil {
+		cfg.Security = &config.SecurityConfig{}
+	}
+	cfg.Security.AllowLocalWebhooks = true
+	cfg.SetRuntime("security.allow_local_webhooks", "--test-mode")
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/otiai10/namazu/backend/internal/config"
+	"github.com/otiai10/namazu/backend/internal/delivery/webhook"
+	"github.com/otiai10/namazu/backend/internal/secrets"
+	"github.com/otiai10/namazu/backend/internal/store"
+	"github.com/otiai10/namazu/backend/internal/subscription"
+)
+
+const migrateUsage = `Usage: namazu migrate --config config.yaml --owner UID [--project ID] [--database NAME] [--apply]
+
+Copies the subscriptions of a Phase 1 YAML config into Firestore, owned by the
+given user. Subscriptions are matched by name with the owner's existing ones:
+missing ones are created, changed ones are updated, the others are left alone.
+Prints the changes without writing anything unless --apply is given.
+
+The Firestore project defaults to the config's store section, or NAMAZU_STORE_PROJECT_ID.
+`
+
+// Actions of a migration step
+const (
+	migrateCreate    = "create"
+	migrateUpdate    = "update"
+	migrateUnchanged = "unchanged"
+)
+
+// migrationStep is what the migration does with one subscription of the config
+type migrationStep struct {
+	Action  string
+	Sub     subscription.Subscription // To write; has the ID of the existing subscription on update
+	Changes []string                  // Changed fields on update
+}
+
+// runMigrate runs `namazu migrate`
+func runMigrate(ctx context.Context, args []string, w io.Writer) error {
+	fs := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	fs.SetOutput(w)
+	fs.Usage = func() { fmt.Fprint(w, migrateUsage) }
+	path := fs.String("config", "", "legacy YAML config to migrate")
+	owner := fs.String("owner", "", "Firebase UID of the user owning the migrated subscriptions")
+	projectID := fs.String("project", "", "Firestore project ID")
+	database := fs.String("database", "", "Firestore database (default: (default))")
+	apply := fs.Bool("apply", false, "write the changes (default: dry run)")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	if *path == "" || *owner == "" {
+		fs.Usage()
+		return errors.New("--config and --owner are required")
+	}
+
+	cfg, err := config.Load(*path, config.WithSecretResolver(ctx, secrets.NewSecretManager()))
+	if err != nil {
+		return err
+	}
+	target := store.FirestoreConfig{ProjectID: *projectID, Database: *database}
+	if cfg.Store != nil && cfg.Store.Type == "firestore" {
+		if target.ProjectID == "" {
+			target.ProjectID = cfg.Store.ProjectID
+		}
+		if target.Database == "" {
+			target.Database = cfg.Store.Database
+		}
+		target.Credentials = cfg.Store.Credentials
+	}
+	if target.ProjectID == "" {
+		return errors.New("no Firestore project: pass --project or set NAMAZU_STORE_PROJECT_ID")
+	}
+
+	client, err := store.NewFirestoreClient(ctx, target)
+	if err != nil {
+		return fmt.Errorf("failed to connect to Firestore: %w", err)
+	}
+	defer client.Close()
+
+	repo := subscription.NewFirestoreRepository(client.Client())
+	envelope, err := newEnvelope(cfg.Security)
+	if err != nil {
+		return err
+	}
+	if envelope != nil {
+		repo.SetEnvelope(envelope)
+	}
+
+	fmt.Fprintf(w, "Migrating %s to Firestore project %s as %s\n", *path, target.ProjectID, *owner)
+	return migrate(ctx, repo, cfg.Subscriptions, *owner, *apply, w)
+}
+
+// migrate plans the migration of static into repo, prints it, and writes it if apply is set
+func migrate(ctx context.Context, repo subscription.Repository, static []config.SubscriptionConfig, owner string, apply bool, w io.Writer) error {
+	existing, err := repo.ListByUserID(ctx, owner)
+	if err != nil {
+		return fmt.Errorf("failed to list the subscriptions of %s: %w", owner, err)
+	}
+	steps, err := planMigration(static, existing, owner, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+
+	counts := map[string]int{}
+	for _, step := range steps {
+		counts[step.Action]++
+		switch step.Action {
+		case migrateCreate:
+			fmt.Fprintf(w, "+ %s (%s)\n", step.Sub.Name, step.Sub.Delivery.URL)
+		case migrateUpdate:
+			fmt.Fprintf(w, "~ %s: %s\n", step.Sub.Name, strings.Join(step.Changes, ", "))
+		default:
+			fmt.Fprintf(w, "= %s\n", step.Sub.Name)
+		}
+	}
+	fmt.Fprintf(w, "%d to create, %d to update, %d unchanged\n",
+		counts[migrateCreate], counts[migrateUpdate], counts[migrateUnchanged])
+
+	if !apply {
+		fmt.Fprintln(w, "Dry run: nothing was written. Run again with --apply to migrate.")
+		return nil
+	}
+	for _, step := range steps {
+		switch step.Action {
+		case migrateCreate:
+			id, err := repo.Create(ctx, step.Sub)
+			if err != nil {
+				return fmt.Errorf("failed to create %s: %w", step.Sub.Name, err)
+			}
+			fmt.Fprintf(w, "Created %s (%s)\n", step.Sub.Name, id)
+		case migrateUpdate:
+			if err := repo.Update(ctx, step.Sub.ID, step.Sub); err != nil {
+				return fmt.Errorf("failed to update %s: %w", step.Sub.Name, err)
+			}
+			fmt.Fprintf(w, "Updated %s (%s)\n", step.Sub.Name, step.Sub.ID)
+		}
+	}
+	return nil
+}
+
+// planMigration matches the static subscriptions by name with the owner's
+// existing ones (of the default tenant) and decides what to write.
+// Secrets from the config keep their legacy signature, so receivers need no change.
+func planMigration(static []config.SubscriptionConfig, existing []subscription.Subscription, owner string, now time.Time) ([]migrationStep, error) {
+	byName := make(map[string]subscription.Subscription, len(existing))
+	for _, sub := range existing {
+		if sub.TenantID != "" {
+			continue
+		}
+		if _, ok := byName[sub.Name]; ok {
+			return nil, fmt.Errorf("%s has more than one subscription named %q; rename them first", owner, sub.Name)
+		}
+		byName[sub.Name] = sub
+	}
+
+	steps := make([]migrationStep, 0, len(static))
+	for _, c := range static {
+		want := subscription.FromConfig(c)
+		current, ok := byName[c.Name]
+		if !ok {
+			want.UserID = owner
+			want.CreatedAt = now
+			want.Status = subscription.StatusActive
+			want.Delivery.SecretPrefix = webhook.SecretPrefixFromSecret(want.Delivery.Secret)
+			steps = append(steps, migrationStep{Action: migrateCreate, Sub: want})
+			continue
+		}
+
+		var changes []string
+		if current.Delivery.Type != want.Delivery.Type {
+			changes = append(changes, fmt.Sprintf("type %s → %s", current.Delivery.Type, want.Delivery.Type))
+		}
+		if current.Delivery.URL != want.Delivery.URL {
+			changes = append(changes, fmt.Sprintf("url %s → %s", current.Delivery.URL, want.Delivery.URL))
+		}
+		if current.Delivery.Secret != want.Delivery.Secret || current.Delivery.SignVersion != "" {
+			changes = append(changes, "secret")
+		}
+		if !reflect.DeepEqual(subscription.ToConfig(current).Filter, subscription.ToConfig(want).Filter) {
+			changes = append(changes, "filter")
+		}
+		if len(changes) == 0 {
+			steps = append(steps, migrationStep{Action: migrateUnchanged, Sub: current})
+			continue
+		}
+
+		// Only what the config describes is replaced; other settings are kept
+		updated := current
+		updated.Delivery.Type = want.Delivery.Type
+		updated.Delivery.URL = want.Delivery.URL
+		updated.Delivery.Secret = want.Delivery.Secret
+		updated.Delivery.SecretPrefix = webhook.SecretPrefixFromSecret(want.Delivery.Secret)
+		updated.Delivery.SignVersion = ""
+		updated.Delivery.PreviousSecret = ""
+		updated.Delivery.PreviousSecretExpiresAt = nil
+		updated.Filter = want.Filter
+		steps = append(steps, migrationStep{Action: migrateUpdate, Sub: updated, Changes: changes})
+	}
+	return steps, nil
+}
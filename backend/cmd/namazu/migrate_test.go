@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/otiai10/namazu/backend/internal/config"
+	"github.com/otiai10/namazu/backend/internal/subscription"
+)
+
+var legacySubscriptions = []config.SubscriptionConfig{
+	{Name: "new", Delivery: config.DeliveryConfig{Type: "webhook", URL: "https://example.com/new", Secret: "secret-new"}},
+	{Name: "same", Delivery: config.DeliveryConfig{Type: "webhook", URL: "https://example.com/same", Secret: "secret-same"}},
+	{
+		Name:     "changed",
+		Delivery: config.DeliveryConfig{Type: "webhook", URL: "https://example.com/changed", Secret: "secret-changed"},
+		Filter:   &config.FilterConfig{MinScale: 40},
+	},
+}
+
+func seedMigration(t *testing.T) *subscription.MemoryRepository {
+	t.Helper()
+	repo := subscription.NewMemoryRepository()
+	ctx := context.Background()
+	for _, sub := range []subscription.Subscription{
+		{UserID: "owner", Name: "same", Delivery: subscription.DeliveryConfig{Type: "webhook", URL: "https://example.com/same", Secret: "secret-same"}},
+		{UserID: "owner", Name: "changed", Delivery: subscription.DeliveryConfig{Type: "webhook", URL: "https://example.com/old", Secret: "secret-changed"},
+			Throttle: &subscription.ThrottleConfig{IntervalMinutes: 10}},
+		{UserID: "someone-else", Name: "new"},
+	} {
+		if _, err := repo.Create(ctx, sub); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return repo
+}
+
+func TestMigrate_DryRun(t *testing.T) {
+	repo := seedMigration(t)
+	var out bytes.Buffer
+
+	if err := migrate(context.Background(), repo, legacySubscriptions, "owner", false, &out); err != nil {
+		t.Fatalf("migrate() error = %v", err)
+	}
+	for _, want := range []string{
+		"+ new (https://example.com/new)",
+		"= same",
+		"~ changed: url https://example.com/old → https://example.com/changed, filter",
+		"1 to create, 1 to update, 1 unchanged",
+		"Dry run",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output is missing %q:\n%s", want, out.String())
+		}
+	}
+	if subs, _ := repo.ListByUserID(context.Background(), "owner"); len(subs) != 2 {
+		t.Errorf("expected nothing written in a dry run, got %d subscriptions", len(subs))
+	}
+}
+
+func TestMigrate_Apply(t *testing.T) {
+	repo := seedMigration(t)
+	ctx := context.Background()
+
+	if err := migrate(ctx, repo, legacySubscriptions, "owner", true, &bytes.Buffer{}); err != nil {
+		t.Fatalf("migrate() error = %v", err)
+	}
+	subs, _ := repo.ListByUserID(ctx, "owner")
+	if len(subs) != 3 {
+		t.Fatalf("expected 3 subscriptions, got %d", len(subs))
+	}
+	for _, sub := range subs {
+		switch sub.Name {
+		case "new":
+			if sub.Delivery.Secret != "secret-new" || sub.Delivery.SignVersion != "" || sub.Status != subscription.StatusActive {
+				t.Errorf("new = %+v, want the static secret with a legacy signature", sub)
+			}
+		case "changed":
+			if sub.Delivery.URL != "https://example.com/changed" || sub.Filter == nil || sub.Filter.MinScale != 40 {
+				t.Errorf("changed = %+v, want the static URL and filter", sub)
+			}
+			if sub.Throttle == nil {
+				t.Error("expected settings missing from the config to be kept")
+			}
+		}
+	}
+
+	// Migrating again changes nothing
+	var out bytes.Buffer
+	if err := migrate(ctx, repo, legacySubscriptions, "owner", false, &out); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "0 to create, 0 to update, 3 unchanged") {
+		t.Errorf("expected an idempotent migration, got:\n%s", out.String())
+	}
+}
+
+func TestPlanMigration_AmbiguousName(t *testing.T) {
+	existing := []subscription.Subscription{{ID: "a", Name: "dup"}, {ID: "b", Name: "dup"}}
+	if _, err := planMigration(legacySubscriptions, existing, "owner", time.Now()); err == nil {
+		t.Error("expected an error for subscriptions sharing a name")
+	}
+}
+
+func TestRunMigrate_RequiresFlags(t *testing.T) {
+	var out bytes.Buffer
+	if err := runMigrate(context.Background(), []string{"--owner", "owner"}, &out); err == nil {
+		t.Error("expected an error without --config")
+	}
+	if !strings.Contains(out.String(), "Usage: namazu migrate") {
+		t.Errorf("expected the usage, got %q", out.String())
+	}
+}
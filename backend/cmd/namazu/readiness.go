@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/otiai10/namazu/backend/internal/api"
+	"github.com/otiai10/namazu/backend/internal/auth"
+	"github.com/otiai10/namazu/backend/internal/source"
+)
+
+// sourceReadyGrace is how long the event source may stay disconnected before
+// the instance reports not ready. Scheduled reconnections take a second or so.
+const sourceReadyGrace = time.Minute
+
+// sourceStatus reports the connection of the event source (implemented by *app.App)
+type sourceStatus interface {
+	SourceStatus() (source.Status, bool)
+}
+
+// pingCheck checks a store that can be pinged
+func pingCheck(ping func(ctx context.Context) error) api.ReadinessCheck {
+	return func(ctx context.Context) (string, error) {
+		return "", ping(ctx)
+	}
+}
+
+// sourceCheck checks that the event source is connected, tolerating short reconnections
+func sourceCheck(s sourceStatus, now func() time.Time) api.ReadinessCheck {
+	return func(ctx context.Context) (string, error) {
+		status, ok := s.SourceStatus()
+		switch {
+		case !ok:
+			return "polling", nil
+		case status.Since.IsZero():
+			return "", errors.New("not connected yet")
+		case status.Connected:
+			return fmt.Sprintf("connected for %s", now().Sub(status.Since).Round(time.Second)), nil
+		}
+		down := now().Sub(status.Since).Round(time.Second)
+		if down > sourceReadyGrace {
+			return "", fmt.Errorf("disconnected for %s", down)
+		}
+		return fmt.Sprintf("reconnecting for %s", down), nil
+	}
+}
+
+// authCheck checks that the token verifier was initialized
+func authCheck(verifier auth.TokenVerifier) api.ReadinessCheck {
+	return func(ctx context.Context) (string, error) {
+		if verifier == nil {
+			return "", errors.New("token verifier is not initialized")
+		}
+		return "", nil
+	}
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/otiai10/namazu/backend/internal/source"
+)
+
+type fakeSource struct {
+	status source.Status
+	ok     bool
+}
+
+func (f fakeSource) SourceStatus() (source.Status, bool) { return f.status, f.ok }
+
+func TestSourceCheck(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+
+	tests := []struct {
+		name       string
+		source     fakeSource
+		wantDetail string
+		wantErr    bool
+	}{
+		{"polling source", fakeSource{ok: false}, "polling", false},
+		{"never connected", fakeSource{ok: true}, "", true},
+		{"connected", fakeSource{status: source.Status{Connected: true, Since: now.Add(-3 * time.Minute)}, ok: true}, "connected for 3m0s", false},
+		{"reconnecting", fakeSource{status: source.Status{Since: now.Add(-5 * time.Second)}, ok: true}, "reconnecting for 5s", false},
+		{"disconnected", fakeSource{status: source.Status{Since: now.Add(-2 * time.Minute)}, ok: true}, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			detail, err := sourceCheck(tt.source, clock)(context.Background())
+			if (err != nil) != tt.wantErr || detail != tt.wantDetail {
+				t.Errorf("got %q, %v; want %q, error %v", detail, err, tt.wantDetail, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAuthCheck(t *testing.T) {
+	if _, err := authCheck(nil)(context.Background()); err == nil {
+		t.Error("expected an error without a token verifier")
+	}
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/otiai10/namazu/backend/internal/api"
+	"github.com/otiai10/namazu/backend/internal/audit"
+	"github.com/otiai10/namazu/backend/internal/config"
+	"github.com/otiai10/namazu/backend/internal/logging"
+	"github.com/otiai10/namazu/backend/internal/subscription"
+)
+
+// configWatchInterval is how often the config file is checked for changes
+const configWatchInterval = 5 * time.Second
+
+// configReloader applies a changed config file to the running server.
+//
+// The new config is loaded and validated in full before anything changes, so
+// a broken file leaves the server as it was. Only the static subscriptions,
+// the CORS and rate limit settings and the log level are applied; changes to
+// other settings are reported as needing a restart.
+type configReloader struct {
+	path     string
+	load     func(path string) (*config.Config, error)
+	adjust   func(cfg *config.Config)       // runtime changes made at startup, such as --test-mode; may be nil
+	static   *subscription.StaticRepository // nil unless subscriptions come from the config
+	security *api.SecurityReloader          // nil without the API
+	auditLog *audit.Logger
+
+	mu      sync.Mutex
+	running *config.Config // startup config with the reloaded settings applied; never mutated
+}
+
+// reload loads the config file and applies it. trigger ("SIGHUP" or "file")
+// is recorded in the audit log.
+func (r *configReloader) reload(ctx context.Context, trigger string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	next, err := r.load(r.path)
+	if err != nil {
+		log.Printf("Config reload (%s) rejected, keeping the running config: %v", trigger, err)
+		r.record(ctx, trigger, "rejected", map[string]string{"error": err.Error()})
+		return err
+	}
+	if r.adjust != nil {
+		r.adjust(next)
+	}
+
+	applied, restart := r.running.Changes(next)
+	if len(restart) > 0 {
+		log.Printf("⚠️  Config reload (%s): restart to apply %s", trigger, strings.Join(restart, ", "))
+	}
+	if len(applied) == 0 {
+		if len(restart) == 0 && logging.Enabled(logging.Debug) {
+			log.Printf("Config reload (%s): no changes", trigger)
+		}
+		r.record(ctx, trigger, "unchanged", map[string]string{"restart": strings.Join(restart, ",")})
+		return nil
+	}
+
+	running := r.merge(next)
+	for _, key := range applied {
+		switch {
+		case key == "subscriptions":
+			if r.static != nil {
+				r.static.Replace(running)
+			}
+		case key == "log_level":
+			level, _ := logging.ParseLevel(running.LogLevel) // validated by load
+			logging.SetLevel(level)
+		}
+	}
+	if r.security != nil && hasPrefix(applied, "security.") {
+		r.security.Apply(running.Security)
+	}
+	r.running = running
+
+	log.Printf("Config reloaded (%s): %s", trigger, strings.Join(applied, ", "))
+	r.record(ctx, trigger, "applied", map[string]string{
+		"applied": strings.Join(applied, ","),
+		"restart": strings.Join(restart, ","),
+	})
+	return nil
+}
+
+// merge returns the running config with the reloadable settings of next
+func (r *configReloader) merge(next *config.Config) *config.Config {
+	merged := *r.running
+	merged.Subscriptions = next.Subscriptions
+	merged.LogLevel = next.LogLevel
+
+	if r.running.Security == nil && next.Security == nil {
+		return &merged
+	}
+	var security, reloaded config.SecurityConfig
+	if r.running.Security != nil {
+		security = *r.running.Security
+	}
+	if next.Security != nil {
+		reloaded = *next.Security
+	}
+	security.CORSAllowedOrigins = reloaded.CORSAllowedOrigins
+	security.RateLimitEnabled = reloaded.RateLimitEnabled
+	security.RateLimitRequestsPerMinute = reloaded.RateLimitRequestsPerMinute
+	security.RateLimitSubscriptionCreation = reloaded.RateLimitSubscriptionCreation
+	merged.Security = &security
+	return &merged
+}
+
+// record writes the outcome of a reload to the audit log
+func (r *configReloader) record(ctx context.Context, trigger, result string, details map[string]string) {
+	details["trigger"] = trigger
+	details["result"] = result
+	for key, value := range details {
+		if value == "" {
+			delete(details, key)
+		}
+	}
+	r.auditLog.Record(ctx, audit.Entry{
+		Action:   audit.ActionConfigReload,
+		TargetID: r.path,
+		Details:  details,
+	})
+}
+
+// hasPrefix reports whether any of keys starts with prefix
+func hasPrefix(keys []string, prefix string) bool {
+	for _, key := range keys {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
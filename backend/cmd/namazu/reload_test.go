@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/otiai10/namazu/backend/internal/audit"
+	"github.com/otiai10/namazu/backend/internal/config"
+	"github.com/otiai10/namazu/backend/internal/logging"
+	"github.com/otiai10/namazu/backend/internal/subscription"
+)
+
+func TestConfigReloader(t *testing.T) {
+	defer logging.SetLevel(logging.CurrentLevel())
+
+	startup := &config.Config{
+		Source: config.SourceConfig{Type: "p2pquake", Endpoint: "wss://example.com"},
+		Subscriptions: []config.SubscriptionConfig{
+			{Name: "a", Delivery: config.DeliveryConfig{Type: "webhook", URL: "https://a.example.com", Secret: "s"}},
+		},
+		Security: &config.SecurityConfig{BadgeSecret: "b"},
+	}
+	static := subscription.NewStaticRepository(startup)
+	auditRepo := audit.NewMemoryRepository()
+
+	var next *config.Config
+	var loadErr error
+	r := &configReloader{
+		path:     "config.yaml",
+		load:     func(string) (*config.Config, error) { return next, loadErr },
+		static:   static,
+		auditLog: audit.NewLogger(auditRepo),
+		running:  startup,
+	}
+	ctx := context.Background()
+	lastEntry := func() audit.Entry {
+		t.Helper()
+		entries, err := auditRepo.List(ctx, audit.Filter{Action: audit.ActionConfigReload})
+		if err != nil || len(entries) == 0 {
+			t.Fatalf("no audit entry: %v", err)
+		}
+		return entries[0]
+	}
+
+	t.Run("applies reloadable settings", func(t *testing.T) {
+		next = &config.Config{
+			Source: startup.Source,
+			Subscriptions: []config.SubscriptionConfig{
+				{Name: "a", Delivery: config.DeliveryConfig{Type: "webhook", URL: "https://a.example.com", Secret: "s"}},
+				{Name: "b", Delivery: config.DeliveryConfig{Type: "webhook", URL: "https://b.example.com", Secret: "s"}},
+			},
+			Security: &config.SecurityConfig{BadgeSecret: "rotated", CORSAllowedOrigins: "https://app.example.com"},
+			LogLevel: "warn",
+		}
+		if err := r.reload(ctx, "SIGHUP"); err != nil {
+			t.Fatalf("reload: %v", err)
+		}
+
+		subs, _ := static.List(ctx)
+		if len(subs) != 2 {
+			t.Errorf("%d subscriptions after the reload, want 2", len(subs))
+		}
+		if logging.CurrentLevel() != logging.Warn {
+			t.Errorf("log level = %v, want warn", logging.CurrentLevel())
+		}
+		if r.running.Security.BadgeSecret != "b" || r.running.Security.CORSAllowedOrigins != "https://app.example.com" {
+			t.Errorf("running security = %+v; want the old badge secret and the new origins", r.running.Security)
+		}
+		if startup.LogLevel != "" || len(startup.Subscriptions) != 1 {
+			t.Error("the startup config was modified")
+		}
+
+		e := lastEntry()
+		want := map[string]string{
+			"trigger": "SIGHUP",
+			"result":  "applied",
+			"applied": "log_level,security.cors_allowed_origins,subscriptions",
+			"restart": "security.badge_secret",
+		}
+		for key, value := range want {
+			if e.Details[key] != value {
+				t.Errorf("details[%s] = %q, want %q", key, e.Details[key], value)
+			}
+		}
+	})
+
+	t.Run("keeps the running config when the new one is invalid", func(t *testing.T) {
+		next, loadErr = nil, errors.New("invalid configuration: source.endpoint is required")
+		defer func() { loadErr = nil }()
+		running := r.running
+
+		if err := r.reload(ctx, "file"); err == nil {
+			t.Fatal("reload succeeded")
+		}
+		if r.running != running {
+			t.Error("the running config was replaced")
+		}
+		if subs, _ := static.List(ctx); len(subs) != 2 {
+			t.Errorf("%d subscriptions after a rejected reload, want 2", len(subs))
+		}
+		if e := lastEntry(); e.Details["result"] != "rejected" || e.Details["error"] == "" {
+			t.Errorf("audit details = %v", e.Details)
+		}
+	})
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/otiai10/namazu/backend/internal/config"
+	"github.com/otiai10/namazu/backend/internal/delivery/webhook"
+	"github.com/otiai10/namazu/backend/internal/secrets"
+	"github.com/otiai10/namazu/backend/internal/security"
+	"github.com/otiai10/namazu/backend/internal/source/jma"
+)
+
+const validateUsage = `Usage: namazu validate (--config config.yaml | --env) [--offline] [--challenge] [--timeout 10s]
+
+Loads the config as the server would, with environment variable overrides,
+secret references and validation, then checks that the source endpoints
+answer and that the webhook URLs of the subscriptions are allowed. With
+--challenge each webhook is also sent a url_verification challenge, as when a
+subscription is created through the API. --offline skips the checks that need
+the network.
+
+Prints a report and exits non-zero if any check fails.
+`
+
+// validateOptions selects the checks of `namazu validate`
+type validateOptions struct {
+	Offline   bool          // Skip checks that need the network
+	Challenge bool          // Send url_verification challenges to webhooks
+	Timeout   time.Duration // Of each network check
+}
+
+// runValidate runs `namazu validate`
+func runValidate(ctx context.Context, args []string, w io.Writer) error {
+	fs := flag.NewFlagSet("validate", flag.ContinueOnError)
+	fs.SetOutput(w)
+	fs.Usage = func() { fmt.Fprint(w, validateUsage) }
+	path := fs.String("config", "", "YAML config to validate")
+	env := fs.Bool("env", false, "validate the config of the environment variables only")
+	var opts validateOptions
+	fs.BoolVar(&opts.Offline, "offline", false, "skip the checks that need the network")
+	fs.BoolVar(&opts.Challenge, "challenge", false, "send a url_verification challenge to each webhook")
+	fs.DurationVar(&opts.Timeout, "timeout", 10*time.Second, "timeout of each network check")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	if (*path == "") == !*env {
+		fs.Usage()
+		return errors.New("either --config or --env is required")
+	}
+
+	name := *path
+	if *env {
+		name = "environment variables"
+	}
+	fmt.Fprintf(w, "Validating %s\n", name)
+	cfg, err := config.Load(*path, config.WithSecretResolver(ctx, secrets.NewSecretManager()))
+	if err != nil {
+		r := &validationReport{w: w}
+		r.fail("config", err)
+		return r.result()
+	}
+	return validateConfig(ctx, cfg, opts, w)
+}
+
+// validateConfig checks a loaded config and prints a report of each check.
+// It returns an error if any check failed.
+func validateConfig(ctx context.Context, cfg *config.Config, opts validateOptions, w io.Writer) error {
+	r := &validationReport{w: w}
+	r.pass("config", fmt.Sprintf("source %s, %d subscriptions", cfg.Source.Type, len(cfg.Subscriptions)))
+
+	allowLocal := cfg.Security != nil && cfg.Security.AllowLocalWebhooks
+	if opts.Offline {
+		r.skip("source", "--offline")
+	} else {
+		checkSource(ctx, cfg.Source, opts.Timeout, r)
+	}
+
+	var challenger *webhook.Challenger
+	if opts.Challenge && !opts.Offline {
+		// Challenges take the route of deliveries, refusing the same addresses
+		resolver := webhook.NewResolver(webhook.WithAddressCheck(func(ip net.IP) error {
+			return security.CheckIP(ip, allowLocal)
+		}))
+		senderOpts := []webhook.SenderOption{webhook.WithResolver(resolver)}
+		if proxy, _ := cfg.Egress.ProxyURL(); proxy != nil {
+			senderOpts = append(senderOpts, webhook.WithProxy(proxy))
+		}
+		challenger = webhook.NewChallenger(opts.Timeout, senderOpts...)
+	}
+	validator := security.NewWebhookURLValidator(allowLocal)
+	for i, sub := range cfg.Subscriptions {
+		name := fmt.Sprintf("subscriptions[%d] %s", i, sub.Name)
+		if sub.Delivery.Type != "webhook" {
+			r.skip(name, "not a webhook")
+			continue
+		}
+		// Resolving the host needs the network
+		var err error
+		if opts.Offline {
+			err = security.ValidateWebhookURL(sub.Delivery.URL, allowLocal)
+		} else {
+			err = validator.ValidateWebhookURL(sub.Delivery.URL)
+		}
+		if err != nil {
+			r.fail(name, fmt.Errorf("%s: %w", sub.Delivery.URL, err))
+			continue
+		}
+		if challenger == nil {
+			r.pass(name, sub.Delivery.URL)
+			continue
+		}
+		result := challenger.VerifyURL(ctx, sub.Delivery.URL, sub.Delivery.Secret, nil)
+		if !result.Success {
+			r.fail(name, fmt.Errorf("%s: challenge: %s", sub.Delivery.URL, result.ErrorMessage))
+			continue
+		}
+		r.pass(name, fmt.Sprintf("%s (challenge answered in %s)", sub.Delivery.URL, result.ResponseTime.Round(time.Millisecond)))
+	}
+	return r.result()
+}
+
+// checkSource checks that the endpoints of the configured source answer
+func checkSource(ctx context.Context, src config.SourceConfig, timeout time.Duration, r *validationReport) {
+	if src.Type == "p2pquake" || src.Type == "multi" {
+		start := time.Now()
+		if err := dialWebSocket(ctx, src.Endpoint, timeout); err != nil {
+			r.fail("source endpoint", fmt.Errorf("%s: %w", src.Endpoint, err))
+		} else {
+			r.pass("source endpoint", fmt.Sprintf("%s (connected in %s)", src.Endpoint, time.Since(start).Round(time.Millisecond)))
+		}
+		if src.History != "" {
+			checkURL(ctx, "source history", src.History, timeout, r)
+		}
+	}
+	if src.Type == "jma" || src.Type == "multi" {
+		feed := src.JMAFeed
+		if feed == "" {
+			feed = jma.DefaultFeedURL
+		}
+		checkURL(ctx, "source jma feed", feed, timeout, r)
+	}
+}
+
+// dialWebSocket opens and closes a WebSocket connection to endpoint
+func dialWebSocket(ctx context.Context, endpoint string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+// checkURL reports whether a GET of url succeeds
+func checkURL(ctx context.Context, name, url string, timeout time.Duration, r *validationReport) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		r.fail(name, err)
+		return
+	}
+	req.Header.Set("User-Agent", webhook.DefaultUserAgent)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		r.fail(name, err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		r.fail(name, fmt.Errorf("%s: status %d", url, resp.StatusCode))
+		return
+	}
+	r.pass(name, url)
+}
+
+// validationReport prints the outcome of each check and counts the failures
+type validationReport struct {
+	w      io.Writer
+	checks int
+	failed int
+}
+
+func (r *validationReport) pass(name, detail string) {
+	r.checks++
+	fmt.Fprintf(r.w, "ok    %s: %s\n", name, detail)
+}
+
+func (r *validationReport) fail(name string, err error) {
+	r.checks++
+	r.failed++
+	fmt.Fprintf(r.w, "FAIL  %s: %v\n", name, err)
+}
+
+func (r *validationReport) skip(name, reason string) {
+	fmt.Fprintf(r.w, "skip  %s: %s\n", name, reason)
+}
+
+// result prints the summary and returns an error if any check failed
+func (r *validationReport) result() error {
+	if r.failed > 0 {
+		fmt.Fprintf(r.w, "%d of %d checks failed\n", r.failed, r.checks)
+		return fmt.Errorf("%d of %d checks failed", r.failed, r.checks)
+	}
+	fmt.Fprintf(r.w, "All %d checks passed\n", r.checks)
+	return nil
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/otiai10/namazu/backend/internal/config"
+	"github.com/otiai10/namazu/backend/internal/delivery/webhook"
+)
+
+// newWebSocketServer accepts WebSocket connections and closes them
+func newWebSocketServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		conn.Close()
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// newChallengeServer answers url_verification challenges, or fails with status if not 200
+func newChallengeServer(t *testing.T, status int) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req webhook.ChallengeRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(webhook.ChallengeResponse{Challenge: req.Challenge})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestValidateConfig(t *testing.T) {
+	source := newWebSocketServer(t)
+	receiver := newChallengeServer(t, http.StatusOK)
+	broken := newChallengeServer(t, http.StatusInternalServerError)
+	opts := validateOptions{Challenge: true, Timeout: 2 * time.Second}
+	endpoint := "ws" + strings.TrimPrefix(source.URL, "http")
+
+	t.Run("passes", func(t *testing.T) {
+		cfg := &config.Config{
+			Source: config.SourceConfig{Type: "p2pquake", Endpoint: endpoint},
+			Subscriptions: []config.SubscriptionConfig{
+				{Name: "receiver", Delivery: config.DeliveryConfig{Type: "webhook", URL: receiver.URL, Secret: "s"}},
+			},
+			Security: &config.SecurityConfig{AllowLocalWebhooks: true},
+		}
+		var out bytes.Buffer
+		if err := validateConfig(context.Background(), cfg, opts, &out); err != nil {
+			t.Fatalf("validateConfig() error = %v\n%s", err, out.String())
+		}
+		for _, want := range []string{"ok    source endpoint: " + endpoint, "ok    subscriptions[0] receiver: " + receiver.URL + " (challenge answered", "All 3 checks passed"} {
+			if !strings.Contains(out.String(), want) {
+				t.Errorf("report is missing %q:\n%s", want, out.String())
+			}
+		}
+	})
+
+	t.Run("reports every failure", func(t *testing.T) {
+		closed := newWebSocketServer(t)
+		closed.Close()
+		cfg := &config.Config{
+			Source: config.SourceConfig{Type: "p2pquake", Endpoint: "ws" + strings.TrimPrefix(closed.URL, "http")},
+			Subscriptions: []config.SubscriptionConfig{
+				{Name: "broken", Delivery: config.DeliveryConfig{Type: "webhook", URL: broken.URL, Secret: "s"}},
+				{Name: "plain", Delivery: config.DeliveryConfig{Type: "webhook", URL: "http://example.com/hook", Secret: "s"}},
+				{Name: "receiver", Delivery: config.DeliveryConfig{Type: "webhook", URL: receiver.URL, Secret: "s"}},
+			},
+			Security: &config.SecurityConfig{AllowLocalWebhooks: true},
+		}
+		var out bytes.Buffer
+		err := validateConfig(context.Background(), cfg, opts, &out)
+		if err == nil {
+			t.Fatalf("validateConfig() succeeded:\n%s", out.String())
+		}
+		for _, want := range []string{
+			"FAIL  source endpoint",
+			"FAIL  subscriptions[0] broken: " + broken.URL + ": challenge: webhook returned status 500",
+			"FAIL  subscriptions[1] plain: http://example.com/hook",
+			"ok    subscriptions[2] receiver",
+			"3 of 5 checks failed",
+		} {
+			if !strings.Contains(out.String(), want) {
+				t.Errorf("report is missing %q:\n%s", want, out.String())
+			}
+		}
+	})
+
+	t.Run("offline", func(t *testing.T) {
+		cfg := &config.Config{
+			Source: config.SourceConfig{Type: "p2pquake", Endpoint: "wss://unreachable.invalid"},
+			Subscriptions: []config.SubscriptionConfig{
+				{Name: "prod", Delivery: config.DeliveryConfig{Type: "webhook", URL: "https://unreachable.invalid/hook", Secret: "s"}},
+			},
+		}
+		var out bytes.Buffer
+		if err := validateConfig(context.Background(), cfg, validateOptions{Offline: true, Challenge: true}, &out); err != nil {
+			t.Fatalf("validateConfig() error = %v\n%s", err, out.String())
+		}
+		if !strings.Contains(out.String(), "skip  source: --offline") {
+			t.Errorf("report does not skip the source:\n%s", out.String())
+		}
+	})
+}
+
+func TestRunValidate(t *testing.T) {
+	t.Run("requires a config", func(t *testing.T) {
+		if err := runValidate(context.Background(), nil, &bytes.Buffer{}); err == nil {
+			t.Error("expected an error without --config or --env")
+		}
+	})
+
+	t.Run("reports an invalid config", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "config.yaml")
+		if err := os.WriteFile(path, []byte("source:\n  type: unknown\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		var out bytes.Buffer
+		if err := runValidate(context.Background(), []string{"--config", path, "--offline"}, &out); err == nil {
+			t.Fatalf("expected an error:\n%s", out.String())
+		}
+		if !strings.Contains(out.String(), "FAIL  config: invalid configuration") {
+			t.Errorf("report is missing the config failure:\n%s", out.String())
+		}
+	})
+}
@@ -0,0 +1,294 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/otiai10/namazu/backend/pkg/client"
+)
+
+// tailReconnectDelay is the pause before events tail reconnects a dropped stream
+var tailReconnectDelay = 2 * time.Second
+
+// timeFormat formats times in tables, in the local time zone
+const timeFormat = "2006-01-02 15:04:05"
+
+// health checks that the server is up
+func (c *cli) health(ctx context.Context, args []string) error {
+	if err := parse(c.flags("health", ""), args, 0); err != nil {
+		return err
+	}
+	health, err := c.client.Health(ctx)
+	if err != nil {
+		return fmt.Errorf("server is not healthy: %w", err)
+	}
+	if c.json {
+		return c.printJSON(health)
+	}
+	fmt.Fprintf(c.out, "%s (%s)\n", health.Status, health.Hash)
+	return nil
+}
+
+// subscriptionsList prints the caller's subscriptions
+func (c *cli) subscriptionsList(ctx context.Context, args []string) error {
+	if err := parse(c.flags("subscriptions list", ""), args, 0); err != nil {
+		return err
+	}
+	subs, err := c.client.ListSubscriptions(ctx)
+	if err != nil {
+		return err
+	}
+	if c.json {
+		return c.printJSON(subs)
+	}
+	tw := tabwriter.NewWriter(c.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tNAME\tTYPE\tSTATUS\tURL")
+	for _, sub := range subs {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", sub.ID, sub.Name, sub.Delivery.Type, sub.Status, orDash(sub.Delivery.URL))
+	}
+	return tw.Flush()
+}
+
+// subscriptionsGet prints a subscription as JSON
+func (c *cli) subscriptionsGet(ctx context.Context, args []string) error {
+	fs := c.flags("subscriptions get", "ID")
+	if err := parse(fs, args, 1); err != nil {
+		return err
+	}
+	sub, err := c.client.GetSubscription(ctx, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	return c.printJSON(sub)
+}
+
+// subscriptionsCreate creates a subscription from flags or a JSON file
+func (c *cli) subscriptionsCreate(ctx context.Context, args []string) error {
+	fs := c.flags("subscriptions create", "-name NAME -url URL [flags] | -file request.json")
+	file := fs.String("file", "", "JSON body of POST /api/subscriptions (- for stdin); other flags are ignored")
+	name := fs.String("name", "", "subscription name")
+	deliveryType := fs.String("type", "webhook", "delivery type")
+	url := fs.String("url", "", "webhook URL")
+	minScale := fs.Int("min-scale", 0, "minimum scale in P2P地震情報 units (e.g. 40 = 震度4)")
+	prefectures := fs.String("prefectures", "", "comma-separated prefectures")
+	eventTypes := fs.String("event-types", "", "comma-separated event types: earthquake, tsunami")
+	eew := fs.Bool("eew", false, "also deliver Earthquake Early Warnings")
+	if err := parse(fs, args, 0); err != nil {
+		return err
+	}
+
+	var req client.SubscriptionRequest
+	if *file != "" {
+		data, err := readFile(*file)
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal(data, &req); err != nil {
+			return fmt.Errorf("invalid %s: %w", *file, err)
+		}
+	} else {
+		if *name == "" {
+			fs.Usage()
+			return errUsage
+		}
+		req = client.SubscriptionRequest{
+			Name:     *name,
+			Delivery: client.DeliveryConfig{Type: *deliveryType, URL: *url},
+		}
+		if *minScale > 0 || *prefectures != "" || *eventTypes != "" || *eew {
+			req.Filter = &client.FilterConfig{
+				MinScale:    *minScale,
+				Prefectures: splitList(*prefectures),
+				EventTypes:  splitList(*eventTypes),
+				EEW:         *eew,
+			}
+		}
+	}
+
+	sub, err := c.client.CreateSubscription(ctx, req)
+	if err != nil {
+		return err
+	}
+	if c.json {
+		return c.printJSON(sub)
+	}
+	fmt.Fprintf(c.out, "Created %s (%s)\n", sub.Name, sub.ID)
+	if sub.Delivery.Secret != "" {
+		fmt.Fprintf(c.out, "Secret: %s\n(shown only once: store it now to verify signatures)\n", sub.Delivery.Secret)
+	}
+	return nil
+}
+
+// subscriptionsDelete deletes a subscription
+func (c *cli) subscriptionsDelete(ctx context.Context, args []string) error {
+	fs := c.flags("subscriptions delete", "ID")
+	if err := parse(fs, args, 1); err != nil {
+		return err
+	}
+	if err := c.client.DeleteSubscription(ctx, fs.Arg(0)); err != nil {
+		return err
+	}
+	fmt.Fprintf(c.out, "Deleted %s\n", fs.Arg(0))
+	return nil
+}
+
+// eventsList prints the latest events
+func (c *cli) eventsList(ctx context.Context, args []string) error {
+	fs := c.flags("events list", "[flags]")
+	limit := fs.Int("limit", 20, "number of events (max 100)")
+	eventType := fs.String("type", "", "earthquake, tsunami or eew")
+	prefecture := fs.String("prefecture", "", "only events affecting this prefecture")
+	minSeverity := fs.Int("min-severity", 0, "minimum scale in P2P地震情報 units")
+	if err := parse(fs, args, 0); err != nil {
+		return err
+	}
+	list, err := c.client.ListEvents(ctx, client.EventQuery{
+		Limit: *limit, Type: *eventType, Prefecture: *prefecture, MinSeverity: *minSeverity,
+	})
+	if err != nil {
+		return err
+	}
+	if c.json {
+		return c.printJSON(list)
+	}
+	tw := tabwriter.NewWriter(c.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tOCCURRED\tTYPE\tSEVERITY\tAREAS")
+	for _, e := range list.Events {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%s\n", e.ID, e.OccurredAt.Local().Format(timeFormat), e.Type, e.Severity, areas(e.AffectedAreas))
+	}
+	return tw.Flush()
+}
+
+// eventsTail prints events as the server receives them, until ctx is done
+func (c *cli) eventsTail(ctx context.Context, args []string) error {
+	fs := c.flags("events tail", "[flags]")
+	minScale := fs.Int("min-scale", 0, "minimum scale in P2P地震情報 units")
+	prefectures := fs.String("prefectures", "", "comma-separated prefectures")
+	eventTypes := fs.String("event-types", "", "comma-separated event types: earthquake, tsunami")
+	eew := fs.Bool("eew", false, "include Earthquake Early Warnings")
+	if err := parse(fs, args, 0); err != nil {
+		return err
+	}
+	q := client.StreamQuery{
+		MinScale:    *minScale,
+		Prefectures: splitList(*prefectures),
+		EventTypes:  splitList(*eventTypes),
+		EEW:         *eew,
+	}
+
+	// Reconnect from the last event seen, so nothing is missed while disconnected
+	var lastID string
+	for {
+		err := c.client.StreamEvents(ctx, q, lastID, func(e client.Event) error {
+			lastID = e.ID
+			return c.printEvent(e)
+		})
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		var apiErr *client.APIError
+		if errors.As(err, &apiErr) {
+			return err
+		}
+		fmt.Fprintf(c.errOut, "Stream closed (%v), reconnecting in %v\n", err, tailReconnectDelay)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(tailReconnectDelay):
+		}
+	}
+}
+
+// printEvent prints an event of events tail on one line
+func (c *cli) printEvent(e client.Event) error {
+	if c.json {
+		return json.NewEncoder(c.out).Encode(e)
+	}
+	_, err := fmt.Fprintf(c.out, "%s  %-10s  %2d  %s  %s\n", e.OccurredAt.Local().Format(timeFormat), e.Type, e.Severity, areas(e.AffectedAreas), e.ID)
+	return err
+}
+
+// deliveriesList prints the latest deliveries of a subscription
+func (c *cli) deliveriesList(ctx context.Context, args []string) error {
+	fs := c.flags("deliveries list", "[-limit N] SUBSCRIPTION_ID")
+	limit := fs.Int("limit", 20, "number of deliveries (max 200)")
+	if err := parse(fs, args, 1); err != nil {
+		return err
+	}
+	deliveries, err := c.client.ListDeliveries(ctx, fs.Arg(0), *limit)
+	if err != nil {
+		return err
+	}
+	if c.json {
+		return c.printJSON(deliveries)
+	}
+	tw := tabwriter.NewWriter(c.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tDELIVERED\tEVENT\tSTATUS\tRETRIES\tRESULT")
+	for _, d := range deliveries {
+		result := "ok"
+		if !d.Success {
+			result = orDash(d.ErrorMessage)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%d\t%s\n", d.ID, d.DeliveredAt.Local().Format(timeFormat), d.EventID, d.StatusCode, d.RetryCount, result)
+	}
+	return tw.Flush()
+}
+
+// deliveriesReplay re-sends a failed delivery
+func (c *cli) deliveriesReplay(ctx context.Context, args []string) error {
+	fs := c.flags("deliveries replay", "DELIVERY_ID")
+	if err := parse(fs, args, 1); err != nil {
+		return err
+	}
+	result, err := c.client.Redeliver(ctx, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	if c.json {
+		return c.printJSON(result)
+	}
+	if !result.Success {
+		return fmt.Errorf("redelivery of event %s failed: %d %s", result.EventID, result.StatusCode, result.ErrorMessage)
+	}
+	fmt.Fprintf(c.out, "Redelivered event %s: %d in %dms\n", result.EventID, result.StatusCode, result.ResponseTimeMs)
+	return nil
+}
+
+// readFile reads path, or stdin for "-"
+func readFile(path string) ([]byte, error) {
+	if path == "-" {
+		return io.ReadAll(os.Stdin)
+	}
+	return os.ReadFile(path)
+}
+
+// splitList splits a comma-separated flag value
+func splitList(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, ",")
+}
+
+// areas shortens a list of affected areas for a table
+func areas(list []string) string {
+	if len(list) > 3 {
+		return strings.Join(list[:3], ",") + fmt.Sprintf(" +%d", len(list)-3)
+	}
+	return orDash(strings.Join(list, ","))
+}
+
+// orDash shows empty cells as "-"
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,46 @@
+// Command namazuctl operates a namazu server through its REST API.
+//
+// It lists and creates subscriptions, lists and tails events, lists and
+// replays deliveries, and checks the server's health, printing tables (or
+// JSON with -json) instead of requiring curl and hand-written JSON.
+//
+// Usage:
+//
+//	namazuctl [flags] <command> [args]
+//
+//	namazuctl health
+//	namazuctl subscriptions list
+//	namazuctl subscriptions create -name prod -url https://example.com/hook -min-scale 40
+//	namazuctl events tail -min-scale 30 -prefectures 東京都,神奈川県
+//	namazuctl deliveries list SUBSCRIPTION_ID
+//	namazuctl deliveries replay DELIVERY_ID
+//
+// The server and credentials default to NAMAZU_SERVER, NAMAZU_TOKEN (an ID
+// token), or NAMAZU_API_KEY and NAMAZU_REFRESH_TOKEN (exchanged for ID tokens).
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	err := run(ctx, os.Args[1:], os.Stdout, os.Stderr)
+	switch {
+	case err == nil, errors.Is(err, flag.ErrHelp):
+	case errors.Is(err, errUsage):
+		os.Exit(2)
+	case ctx.Err() != nil:
+		// Interrupted, e.g. events tail
+	default:
+		fmt.Fprintf(os.Stderr, "namazuctl: %v\n", err)
+		os.Exit(1)
+	}
+}
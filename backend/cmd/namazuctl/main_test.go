@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/otiai10/namazu/backend/internal/api"
+	"github.com/otiai10/namazu/backend/internal/delivery/webhook"
+	"github.com/otiai10/namazu/backend/internal/store"
+	"github.com/otiai10/namazu/backend/internal/subscription"
+)
+
+// newTestServer serves the API without authentication, like --test-mode
+func newTestServer(t *testing.T) (*httptest.Server, *store.MemoryEventRepository) {
+	t.Helper()
+	events := store.NewMemoryEventRepository()
+	server := httptest.NewServer(api.NewRouter(api.NewHandler(subscription.NewMemoryRepository(), events)))
+	t.Cleanup(server.Close)
+	return server, events
+}
+
+func runCLI(t *testing.T, server *httptest.Server, args ...string) (string, string, error) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	err := run(context.Background(), append([]string{"-server", server.URL}, args...), &stdout, &stderr)
+	return stdout.String(), stderr.String(), err
+}
+
+func TestRun_Health(t *testing.T) {
+	server, _ := newTestServer(t)
+
+	out, _, err := runCLI(t, server, "health")
+	if err != nil || !strings.HasPrefix(out, "ok") {
+		t.Errorf("health = %q, %v", out, err)
+	}
+}
+
+func TestRun_Subscriptions(t *testing.T) {
+	server, _ := newTestServer(t)
+
+	out, _, err := runCLI(t, server, "subscriptions", "create", "-name", "prod", "-url", "https://example.com/hook", "-min-scale", "40", "-prefectures", "東京都,神奈川県")
+	if err != nil {
+		t.Fatalf("create error = %v", err)
+	}
+	if !strings.Contains(out, "Created prod") || !strings.Contains(out, "Secret: "+webhook.SecretPrefix) {
+		t.Errorf("create output = %q, want the ID and the secret", out)
+	}
+
+	out, _, err = runCLI(t, server, "subscriptions", "list")
+	if err != nil {
+		t.Fatalf("list error = %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[0], "ID") || !strings.Contains(lines[1], "https://example.com/hook") {
+		t.Fatalf("list output = %q", out)
+	}
+	id := strings.Fields(lines[1])[0]
+
+	out, _, err = runCLI(t, server, "subscriptions", "get", id)
+	if err != nil || !strings.Contains(out, `"min_scale": 40`) {
+		t.Errorf("get = %q, %v", out, err)
+	}
+	if _, _, err := runCLI(t, server, "subscriptions", "delete", id); err != nil {
+		t.Errorf("delete error = %v", err)
+	}
+	if _, _, err := runCLI(t, server, "subscriptions", "get", id); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("get after delete error = %v, want 404", err)
+	}
+}
+
+func TestRun_EventsList(t *testing.T) {
+	server, events := newTestServer(t)
+	now := time.Now()
+	if _, err := events.Create(context.Background(), store.EventRecord{
+		Type: "earthquake", Severity: 45, AffectedAreas: []string{"石川県"}, OccurredAt: now, ReceivedAt: now, CreatedAt: now,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	out, _, err := runCLI(t, server, "events", "list")
+	if err != nil || !strings.Contains(out, "石川県") || !strings.Contains(out, "45") {
+		t.Errorf("events list = %q, %v", out, err)
+	}
+	out, _, err = runCLI(t, server, "-json", "events", "list")
+	if err != nil || !strings.Contains(out, `"severity": 45`) {
+		t.Errorf("events list -json = %q, %v", out, err)
+	}
+}
+
+func TestRun_Usage(t *testing.T) {
+	server, _ := newTestServer(t)
+
+	tests := [][]string{
+		{},
+		{"subscriptions"},
+		{"subscriptions", "frobnicate"},
+		{"subscriptions", "get"},
+		{"subscriptions", "create", "-url", "https://example.com"},
+	}
+	for _, args := range tests {
+		_, stderr, err := runCLI(t, server, args...)
+		if !errors.Is(err, errUsage) || !strings.Contains(stderr, "Usage:") {
+			t.Errorf("%v: error = %v, stderr = %q, want the usage", args, err, stderr)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/otiai10/namazu/backend/pkg/client"
+)
+
+// errUsage reports an invalid command line, already explained on stderr
+var errUsage = errors.New("invalid usage")
+
+const usage = `Usage: namazuctl [flags] <command> [args]
+
+Commands:
+  health                            Check that the server is up
+  subscriptions list                List your subscriptions
+  subscriptions get ID              Show a subscription as JSON
+  subscriptions create [flags]      Create a subscription; prints the webhook secret once
+  subscriptions delete ID           Delete a subscription
+  events list [flags]               List recent events, newest first
+  events tail [flags]               Print events as they happen, until interrupted
+  deliveries list SUBSCRIPTION_ID   List the latest deliveries of a subscription
+  deliveries replay DELIVERY_ID     Re-send a failed delivery
+
+Run "namazuctl <command> -h" for the flags of a command.
+
+Flags:
+`
+
+// commands maps "<resource> <verb>" (or "health") to its implementation
+var commands = map[string]func(*cli, context.Context, []string) error{
+	"health":               (*cli).health,
+	"subscriptions list":   (*cli).subscriptionsList,
+	"subscriptions get":    (*cli).subscriptionsGet,
+	"subscriptions create": (*cli).subscriptionsCreate,
+	"subscriptions delete": (*cli).subscriptionsDelete,
+	"events list":          (*cli).eventsList,
+	"events tail":          (*cli).eventsTail,
+	"deliveries list":      (*cli).deliveriesList,
+	"deliveries replay":    (*cli).deliveriesReplay,
+}
+
+// cli holds what the commands share
+type cli struct {
+	client *client.Client
+	out    io.Writer
+	errOut io.Writer
+	json   bool // Print JSON instead of tables
+}
+
+// run parses the global flags and runs the command
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("namazuctl", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprint(stderr, usage)
+		fs.PrintDefaults()
+	}
+	server := fs.String("server", envOr("NAMAZU_SERVER", "http://localhost:8080"), "namazu base URL ($NAMAZU_SERVER)")
+	token := fs.String("token", os.Getenv("NAMAZU_TOKEN"), "Firebase ID token ($NAMAZU_TOKEN); not needed with --test-mode")
+	apiKey := fs.String("api-key", os.Getenv("NAMAZU_API_KEY"), "Firebase Web API key, with -refresh-token ($NAMAZU_API_KEY)")
+	refreshToken := fs.String("refresh-token", os.Getenv("NAMAZU_REFRESH_TOKEN"), "Firebase refresh token ($NAMAZU_REFRESH_TOKEN)")
+	jsonOut := fs.Bool("json", false, "print JSON instead of tables")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return errUsage
+	}
+
+	rest := fs.Args()
+	if len(rest) == 0 {
+		fs.Usage()
+		return errUsage
+	}
+	name, rest := rest[0], rest[1:]
+	if name != "health" && len(rest) > 0 {
+		name, rest = name+" "+rest[0], rest[1:]
+	}
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(stderr, "unknown command %q\n\n", name)
+		fs.Usage()
+		return errUsage
+	}
+
+	var opts []client.Option
+	switch {
+	case *token != "":
+		opts = append(opts, client.WithToken(*token))
+	case *apiKey != "" && *refreshToken != "":
+		opts = append(opts, client.WithAPIKey(*apiKey, *refreshToken))
+	}
+	c, err := client.New(*server, opts...)
+	if err != nil {
+		return err
+	}
+	return cmd(&cli{client: c, out: stdout, errOut: stderr, json: *jsonOut}, ctx, rest)
+}
+
+// flags creates the flag set of a command
+func (c *cli) flags(name, args string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(c.errOut)
+	fs.Usage = func() {
+		fmt.Fprintf(c.errOut, "Usage: namazuctl %s %s\n", name, args)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parse parses the flags of a command and checks its number of arguments
+func parse(fs *flag.FlagSet, args []string, nargs int) error {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return errUsage
+	}
+	if fs.NArg() != nargs {
+		fs.Usage()
+		return errUsage
+	}
+	return nil
+}
+
+// printJSON prints v as indented JSON
+func (c *cli) printJSON(v any) error {
+	enc := json.NewEncoder(c.out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// envOr returns the environment variable key, or fallback if it is unset
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/otiai10/namazu/backend/internal/source/p2pquake"
+)
+
+// region is a prefecture with a typical hypocenter and observation points
+type region struct {
+	Prefecture string
+	Hypocenter string
+	Latitude   float64
+	Longitude  float64
+	Depth      int
+	Cities     []string
+}
+
+// regions is the table of prefectures the generator can shake
+var regions = []region{
+	{"北海道", "胆振地方中東部", 42.7, 142.0, 37, []string{"札幌市中央区", "苫小牧市", "厚真町"}},
+	{"宮城県", "宮城県沖", 38.3, 141.6, 50, []string{"仙台市青葉区", "石巻市", "気仙沼市"}},
+	{"福島県", "福島県沖", 37.7, 141.6, 57, []string{"福島市", "いわき市", "相馬市"}},
+	{"茨城県", "茨城県南部", 36.1, 140.1, 46, []string{"水戸市", "つくば市", "土浦市"}},
+	{"千葉県", "千葉県北西部", 35.6, 140.1, 73, []string{"千葉市中央区", "船橋市", "市川市"}},
+	{"埼玉県", "埼玉県南部", 35.9, 139.6, 60, []string{"さいたま市浦和区", "川口市", "川越市"}},
+	{"東京都", "東京湾", 35.5, 139.9, 30, []string{"千代田区", "新宿区", "八王子市"}},
+	{"神奈川県", "神奈川県西部", 35.4, 139.1, 20, []string{"横浜市中区", "川崎市川崎区", "小田原市"}},
+	{"静岡県", "駿河湾", 34.8, 138.5, 23, []string{"静岡市葵区", "浜松市中区", "沼津市"}},
+	{"長野県", "長野県北部", 36.7, 138.2, 10, []string{"長野市", "松本市", "白馬村"}},
+	{"石川県", "能登半島沖", 37.5, 137.3, 12, []string{"金沢市", "輪島市", "珠洲市"}},
+	{"大阪府", "大阪府北部", 34.8, 135.6, 13, []string{"大阪市北区", "高槻市", "枚方市"}},
+	{"兵庫県", "淡路島付近", 34.6, 135.0, 16, []string{"神戸市中央区", "淡路市", "洲本市"}},
+	{"高知県", "土佐湾", 33.2, 133.6, 40, []string{"高知市", "室戸市", "土佐清水市"}},
+	{"熊本県", "熊本県熊本地方", 32.7, 130.8, 11, []string{"熊本市中央区", "益城町", "阿蘇市"}},
+	{"鹿児島県", "薩摩半島西方沖", 31.2, 130.0, 10, []string{"鹿児島市", "薩摩川内市", "指宿市"}},
+}
+
+// scales are the valid P2P地震情報 scale values from 震度1 to 震度7
+var scales = []int{
+	p2pquake.Scale1, p2pquake.Scale2, p2pquake.Scale3, p2pquake.Scale4,
+	p2pquake.Scale5Weak, p2pquake.Scale5Strong, p2pquake.Scale6Weak,
+	p2pquake.Scale6Strong, p2pquake.Scale7,
+}
+
+// findRegion returns the region for a prefecture name
+func findRegion(prefecture string) (region, bool) {
+	for _, r := range regions {
+		if r.Prefecture == prefecture {
+			return r, true
+		}
+	}
+	return region{}, false
+}
+
+// validScale reports whether scale is one of the P2P地震情報 scale values
+func validScale(scale int) bool {
+	for _, s := range scales {
+		if s == scale {
+			return true
+		}
+	}
+	return false
+}
+
+// generator builds synthetic earthquake reports
+type generator struct {
+	rnd         *rand.Rand
+	scale       int
+	prefectures []string
+	seq         int
+	now         func() time.Time
+}
+
+// newGenerator creates a generator; scale 0 and empty prefectures are randomized per event
+func newGenerator(seed int64, scale int, prefectures []string) *generator {
+	return &generator{
+		rnd:         rand.New(rand.NewPCG(uint64(seed), 0)),
+		scale:       scale,
+		prefectures: prefectures,
+		now:         time.Now,
+	}
+}
+
+// Next returns the next report as JSON
+func (g *generator) Next() ([]byte, error) {
+	return json.Marshal(g.quake())
+}
+
+// quake builds the next report. The first prefecture holds the hypocenter and
+// the strongest shaking; intensity falls off by one step per prefecture.
+func (g *generator) quake() *p2pquake.JMAQuake {
+	g.seq++
+	now := g.now()
+
+	maxScale := g.scale
+	if maxScale == 0 {
+		maxScale = scales[g.rnd.IntN(len(scales))]
+	}
+
+	prefectures := g.prefectures
+	if len(prefectures) == 0 {
+		epicenter := regions[g.rnd.IntN(len(regions))]
+		prefectures = []string{epicenter.Prefecture}
+		// Larger quakes are felt in more prefectures
+		for i := 0; i < scaleIndex(maxScale)/2; i++ {
+			prefectures = append(prefectures, regions[g.rnd.IntN(len(regions))].Prefecture)
+		}
+	}
+
+	epicenter, _ := findRegion(prefectures[0])
+	var points []p2pquake.Point
+	seen := make(map[string]bool)
+	for i, pref := range prefectures {
+		if seen[pref] {
+			continue
+		}
+		seen[pref] = true
+		r, _ := findRegion(pref)
+		prefScale := scales[max(scaleIndex(maxScale)-i, 0)]
+		for j, city := range r.Cities {
+			// The first city records the prefecture's maximum; the rest may be a step lower
+			cityScale := prefScale
+			if j > 0 {
+				cityScale = scales[max(scaleIndex(prefScale)-g.rnd.IntN(2), 0)]
+			}
+			points = append(points, p2pquake.Point{Prefecture: pref, Name: city, Scale: cityScale})
+		}
+	}
+
+	occurred := now.Add(-90 * time.Second).Format("2006/01/02 15:04:05")
+	tsunami := "None"
+	if maxScale >= p2pquake.Scale6Strong && epicenter.Depth <= 40 {
+		tsunami = "Watch"
+	}
+
+	return &p2pquake.JMAQuake{
+		ID:   fmt.Sprintf("quakegen-%d-%d", now.UnixNano(), g.seq),
+		Code: 551,
+		Time: now.Format("2006/01/02 15:04:05.000"),
+		Issue: p2pquake.Issue{
+			Source: "quakegen",
+			Time:   now.Format("2006/01/02 15:04:05"),
+			Type:   "DetailScale",
+		},
+		Earthquake: &p2pquake.Earthquake{
+			Time: occurred,
+			Hypocenter: p2pquake.Hypocenter{
+				Name:      epicenter.Hypocenter,
+				Latitude:  epicenter.Latitude,
+				Longitude: epicenter.Longitude,
+				Depth:     epicenter.Depth,
+				Magnitude: magnitudeFor(maxScale) + float64(g.rnd.IntN(5))/10,
+			},
+			MaxScale:        maxScale,
+			DomesticTsunami: tsunami,
+		},
+		Points: points,
+	}
+}
+
+// scaleIndex returns the position of scale in scales
+func scaleIndex(scale int) int {
+	for i, s := range scales {
+		if s == scale {
+			return i
+		}
+	}
+	return 0
+}
+
+// magnitudeFor returns a plausible base magnitude for a shallow quake of the given scale
+func magnitudeFor(scale int) float64 {
+	return 3.0 + 0.5*float64(scaleIndex(scale))
+}
@@ -0,0 +1,56 @@
+// Command quakegen emits synthetic P2P地震情報 earthquake reports.
+//
+// The reports are realistic code 551 JSON messages whose maximum scale,
+// prefectures and pacing are configurable. They are written to stdout,
+// served from a local WebSocket endpoint that namazu can use as its source
+// (NAMAZU_SOURCE_ENDPOINT=ws://localhost:8765/), or POSTed to a running
+// instance's /api/admin/inject-event endpoint.
+//
+// Usage:
+//
+//	go run ./backend/cmd/quakegen -count 5 -scale 55 -prefectures 東京都,神奈川県
+//	go run ./backend/cmd/quakegen -mode ws -addr :8765 -interval 10s
+//	go run ./backend/cmd/quakegen -mode post -target http://localhost:8080 -token $TOKEN
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+)
+
+func main() {
+	var opts options
+	var prefectures string
+	flag.StringVar(&opts.Mode, "mode", modeStdout, "output: stdout, ws or post")
+	flag.IntVar(&opts.Scale, "scale", 0, "maximum scale in P2P地震情報 units (10-70, 0 = random)")
+	flag.StringVar(&prefectures, "prefectures", "", "comma-separated prefectures to shake (default: random)")
+	flag.IntVar(&opts.Count, "count", 1, "number of events to emit (0 = until interrupted)")
+	flag.DurationVar(&opts.Interval, "interval", 5*time.Second, "delay between events")
+	flag.StringVar(&opts.Addr, "addr", ":8765", "listen address for -mode ws")
+	flag.StringVar(&opts.Target, "target", "http://localhost:8080", "namazu base URL for -mode post")
+	flag.StringVar(&opts.Token, "token", "", "bearer token for -mode post (not needed with --test-mode)")
+	flag.Int64Var(&opts.Seed, "seed", 0, "random seed (0 = time based)")
+	flag.Parse()
+
+	if prefectures != "" {
+		opts.Prefectures = strings.Split(prefectures, ",")
+	}
+	if err := opts.validate(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if err := run(ctx, opts, os.Stdout); err != nil && ctx.Err() == nil {
+		fmt.Fprintf(os.Stderr, "quakegen: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/otiai10/namazu/backend/internal/source/p2pquake"
+)
+
+func TestGenerator_Next(t *testing.T) {
+	gen := newGenerator(1, p2pquake.Scale6Weak, []string{"東京都", "神奈川県"})
+
+	data, err := gen.Next()
+	if err != nil {
+		t.Fatalf("Next() error = %v", err)
+	}
+	event, err := p2pquake.ParseMessage(data)
+	if err != nil {
+		t.Fatalf("ParseMessage() error = %v", err)
+	}
+
+	quake := event.(*p2pquake.JMAQuake)
+	if quake.Earthquake.MaxScale != p2pquake.Scale6Weak {
+		t.Errorf("MaxScale = %d, want %d", quake.Earthquake.MaxScale, p2pquake.Scale6Weak)
+	}
+	if quake.Earthquake.Hypocenter.Name != "東京湾" {
+		t.Errorf("Hypocenter = %q, want the first prefecture's", quake.Earthquake.Hypocenter.Name)
+	}
+	areas := quake.GetAffectedAreas()
+	if len(areas) != 2 || areas[0] != "東京都" || areas[1] != "神奈川県" {
+		t.Errorf("GetAffectedAreas() = %v", areas)
+	}
+	for _, p := range quake.Points {
+		if p.Scale > p2pquake.Scale6Weak {
+			t.Errorf("point %s scale %d exceeds the maximum", p.Name, p.Scale)
+		}
+	}
+
+	next, _ := gen.Next()
+	if second, _ := p2pquake.ParseMessage(next); second.GetID() == event.GetID() {
+		t.Error("expected unique IDs")
+	}
+}
+
+func TestGenerator_Random(t *testing.T) {
+	gen := newGenerator(42, 0, nil)
+	for i := 0; i < 50; i++ {
+		data, err := gen.Next()
+		if err != nil {
+			t.Fatalf("Next() error = %v", err)
+		}
+		event, err := p2pquake.ParseMessage(data)
+		if err != nil {
+			t.Fatalf("ParseMessage() error = %v", err)
+		}
+		quake := event.(*p2pquake.JMAQuake)
+		if !validScale(quake.Earthquake.MaxScale) {
+			t.Errorf("invalid MaxScale %d", quake.Earthquake.MaxScale)
+		}
+		if len(quake.Points) == 0 {
+			t.Error("expected observation points")
+		}
+	}
+}
+
+func TestOptions_Validate(t *testing.T) {
+	valid := options{Mode: modeStdout, Count: 1, Target: "http://localhost:8080"}
+	tests := []struct {
+		name    string
+		modify  func(*options)
+		wantErr bool
+	}{
+		{"valid", func(o *options) {}, false},
+		{"unknown mode", func(o *options) { o.Mode = "file" }, true},
+		{"invalid scale", func(o *options) { o.Scale = 35 }, true},
+		{"unknown prefecture", func(o *options) { o.Prefectures = []string{"東京"} }, true},
+		{"negative count", func(o *options) { o.Count = -1 }, true},
+		{"post without target", func(o *options) { o.Mode = modePost; o.Target = "" }, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := valid
+			tt.modify(&o)
+			if err := o.validate(); (err != nil) != tt.wantErr {
+				t.Errorf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRun_Stdout(t *testing.T) {
+	var out bytes.Buffer
+	if err := run(context.Background(), options{Mode: modeStdout, Count: 3, Seed: 1}, &out); err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 lines, got %d", len(lines))
+	}
+	for _, line := range lines {
+		if !json.Valid([]byte(line)) {
+			t.Errorf("invalid JSON: %s", line)
+		}
+	}
+}
+
+func TestRun_Post(t *testing.T) {
+	var received []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != injectPath || r.Header.Get("Authorization") != "Bearer secret" {
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		received = append(received, string(body))
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	err := run(context.Background(), options{Mode: modePost, Count: 2, Target: server.URL + "/", Token: "secret"}, io.Discard)
+	if err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+	if len(received) != 2 {
+		t.Errorf("expected 2 events, got %d", len(received))
+	}
+}
+
+func TestRun_PostRejected(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "event injection is not enabled", http.StatusNotImplemented)
+	}))
+	defer server.Close()
+
+	err := run(context.Background(), options{Mode: modePost, Count: 1, Target: server.URL}, io.Discard)
+	if err == nil || !strings.Contains(err.Error(), "501") {
+		t.Errorf("expected the status in the error, got %v", err)
+	}
+}
+
+func TestHub_Broadcast(t *testing.T) {
+	hub := newHub()
+	server := httptest.NewServer(hub)
+	defer server.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := hub.WaitForClient(ctx); err != nil {
+		t.Fatalf("WaitForClient() error = %v", err)
+	}
+
+	if err := hub.Emit(ctx, []byte(`{"code":551}`)); err != nil {
+		t.Fatalf("Emit() error = %v", err)
+	}
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	_, msg, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatalf("ReadMessage() error = %v", err)
+	}
+	if string(msg) != `{"code":551}` {
+		t.Errorf("unexpected message: %s", msg)
+	}
+}
@@ -0,0 +1,233 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Output modes
+const (
+	modeStdout = "stdout"
+	modeWS     = "ws"
+	modePost   = "post"
+)
+
+// injectPath is the namazu endpoint that accepts synthetic events
+const injectPath = "/api/admin/inject-event"
+
+// options configures a generator run
+type options struct {
+	Mode        string
+	Scale       int
+	Prefectures []string
+	Count       int
+	Interval    time.Duration
+	Addr        string
+	Target      string
+	Token       string
+	Seed        int64
+}
+
+func (o options) validate() error {
+	switch o.Mode {
+	case modeStdout, modeWS, modePost:
+	default:
+		return fmt.Errorf("-mode must be one of %s, %s, %s", modeStdout, modeWS, modePost)
+	}
+	if o.Scale != 0 && !validScale(o.Scale) {
+		return fmt.Errorf("-scale must be one of %v", scales)
+	}
+	for _, pref := range o.Prefectures {
+		if _, ok := findRegion(pref); !ok {
+			return fmt.Errorf("unknown prefecture %q", pref)
+		}
+	}
+	if o.Count < 0 {
+		return errors.New("-count must not be negative")
+	}
+	if o.Interval < 0 {
+		return errors.New("-interval must not be negative")
+	}
+	if o.Mode == modePost && o.Target == "" {
+		return errors.New("-target is required with -mode post")
+	}
+	return nil
+}
+
+// emitter delivers one generated message
+type emitter interface {
+	Emit(ctx context.Context, msg []byte) error
+}
+
+// run generates opts.Count events (forever when 0) and hands them to the emitter for opts.Mode
+func run(ctx context.Context, opts options, stdout io.Writer) error {
+	seed := opts.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	gen := newGenerator(seed, opts.Scale, opts.Prefectures)
+
+	var out emitter
+	switch opts.Mode {
+	case modeWS:
+		ln, err := net.Listen("tcp", opts.Addr)
+		if err != nil {
+			return err
+		}
+		hub := newHub()
+		server := &http.Server{Handler: hub}
+		go server.Serve(ln)
+		defer server.Close()
+		log.Printf("Serving on ws://%s/ — waiting for a client", ln.Addr())
+		if err := hub.WaitForClient(ctx); err != nil {
+			return err
+		}
+		out = hub
+	case modePost:
+		out = &poster{
+			url:    strings.TrimSuffix(opts.Target, "/") + injectPath,
+			token:  opts.Token,
+			client: &http.Client{Timeout: 10 * time.Second},
+		}
+	default:
+		out = &writer{w: stdout}
+	}
+
+	for i := 0; opts.Count == 0 || i < opts.Count; i++ {
+		if i > 0 && opts.Interval > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(opts.Interval):
+			}
+		}
+		msg, err := gen.Next()
+		if err != nil {
+			return err
+		}
+		if err := out.Emit(ctx, msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writer prints one message per line
+type writer struct {
+	w io.Writer
+}
+
+func (e *writer) Emit(ctx context.Context, msg []byte) error {
+	_, err := fmt.Fprintf(e.w, "%s\n", msg)
+	return err
+}
+
+// poster POSTs messages to a running namazu instance
+type poster struct {
+	url    string
+	token  string
+	client *http.Client
+}
+
+func (e *poster) Emit(ctx context.Context, msg []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.url, bytes.NewReader(msg))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if e.token != "" {
+		req.Header.Set("Authorization", "Bearer "+e.token)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if resp.StatusCode != http.StatusAccepted {
+		return fmt.Errorf("%s returned %d: %s", e.url, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	log.Printf("Injected: %s", strings.TrimSpace(string(body)))
+	return nil
+}
+
+// hub is a WebSocket server that broadcasts every message to all connected clients,
+// standing in for the P2P地震情報 API
+type hub struct {
+	upgrader websocket.Upgrader
+	mu       sync.Mutex
+	clients  map[*websocket.Conn]bool
+	joined   chan struct{}
+	once     sync.Once
+}
+
+func newHub() *hub {
+	return &hub{
+		upgrader: websocket.Upgrader{CheckOrigin: func(r *http.Request) bool { return true }},
+		clients:  make(map[*websocket.Conn]bool),
+		joined:   make(chan struct{}),
+	}
+}
+
+// ServeHTTP upgrades the connection and keeps it registered until the client goes away
+func (h *hub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	conn, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+
+	h.mu.Lock()
+	h.clients[conn] = true
+	h.mu.Unlock()
+	h.once.Do(func() { close(h.joined) })
+	log.Printf("Client connected: %s", r.RemoteAddr)
+
+	// Reads only detect the disconnect; clients never send anything
+	for {
+		if _, _, err := conn.ReadMessage(); err != nil {
+			break
+		}
+	}
+
+	h.mu.Lock()
+	delete(h.clients, conn)
+	h.mu.Unlock()
+	conn.Close()
+	log.Printf("Client disconnected: %s", r.RemoteAddr)
+}
+
+// WaitForClient blocks until the first client connects
+func (h *hub) WaitForClient(ctx context.Context) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-h.joined:
+		return nil
+	}
+}
+
+// Emit sends the message to every connected client
+func (h *hub) Emit(ctx context.Context, msg []byte) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for conn := range h.clients {
+		if err := conn.WriteMessage(websocket.TextMessage, msg); err != nil {
+			log.Printf("Write to client failed: %v", err)
+		}
+	}
+	log.Printf("Broadcast to %d client(s)", len(h.clients))
+	return nil
+}
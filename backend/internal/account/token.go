@@ -0,0 +1,137 @@
+// Package account issues the signed tokens that guard account deletion and
+// export downloads.
+package account
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DeletionTokenTTL is how long a deletion confirmation token is valid
+	DeletionTokenTTL = 10 * time.Minute
+
+	// ExportLinkTTL is how long an emailed export download link is valid
+	ExportLinkTTL = 24 * time.Hour
+)
+
+// Token purposes, signed into each token so one kind cannot be used as another
+const (
+	purposeDeletion = "account-deletion"
+	purposeExport   = "account-export"
+)
+
+var (
+	// ErrInvalidToken is returned when a token is malformed, was issued for
+	// another user or purpose, or its signature does not match
+	ErrInvalidToken = errors.New("invalid account token")
+
+	// ErrTokenExpired is returned when a token is past its expiry
+	ErrTokenExpired = errors.New("account token expired")
+)
+
+// Signer issues and verifies stateless account tokens.
+// A token embeds the UID, its expiry and an HMAC over both and its purpose,
+// so no storage is needed; rotating the secret revokes every token at once.
+type Signer struct {
+	secret []byte
+	now    func() time.Time
+}
+
+// NewSigner creates a Signer with the given secret. An empty secret uses a
+// random one, so tokens are only accepted by the process that issued them.
+func NewSigner(secret string) *Signer {
+	key := []byte(secret)
+	if len(key) == 0 {
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			panic("account: failed to generate a token secret: " + err.Error())
+		}
+	}
+	return &Signer{secret: key, now: time.Now}
+}
+
+// IssueDeletion returns a token confirming the deletion of the user's account and when it expires
+func (s *Signer) IssueDeletion(uid string) (string, time.Time) {
+	return s.issue(purposeDeletion, uid, DeletionTokenTTL)
+}
+
+// VerifyDeletion checks that token confirms deleting the user's account
+func (s *Signer) VerifyDeletion(token, uid string) error {
+	issuedTo, err := s.verify(purposeDeletion, token)
+	if err != nil {
+		return err
+	}
+	if issuedTo != uid {
+		return ErrInvalidToken
+	}
+	return nil
+}
+
+// IssueExport returns a token for downloading the user's export archive and when it expires
+func (s *Signer) IssueExport(uid string) (string, time.Time) {
+	return s.issue(purposeExport, uid, ExportLinkTTL)
+}
+
+// VerifyExport checks an export token and returns the UID it was issued for
+func (s *Signer) VerifyExport(token string) (string, error) {
+	return s.verify(purposeExport, token)
+}
+
+// issue encodes the UID and expiry with their signature
+func (s *Signer) issue(purpose, uid string, ttl time.Duration) (string, time.Time) {
+	expiresAt := s.now().Add(ttl).Truncate(time.Second)
+	expiry := strconv.FormatInt(expiresAt.Unix(), 10)
+	enc := base64.RawURLEncoding
+	token := enc.EncodeToString([]byte(uid)) + "." +
+		enc.EncodeToString([]byte(expiry)) + "." +
+		enc.EncodeToString(s.sign(purpose, uid, expiry))
+	return token, expiresAt
+}
+
+// verify checks a token's signature and expiry and returns its UID
+func (s *Signer) verify(purpose, token string) (string, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return "", ErrInvalidToken
+	}
+
+	enc := base64.RawURLEncoding
+	uid, err := enc.DecodeString(parts[0])
+	if err != nil || len(uid) == 0 {
+		return "", ErrInvalidToken
+	}
+	expiry, err := enc.DecodeString(parts[1])
+	if err != nil {
+		return "", ErrInvalidToken
+	}
+	unix, err := strconv.ParseInt(string(expiry), 10, 64)
+	if err != nil {
+		return "", ErrInvalidToken
+	}
+	sig, err := enc.DecodeString(parts[2])
+	if err != nil {
+		return "", ErrInvalidToken
+	}
+
+	if !hmac.Equal(sig, s.sign(purpose, string(uid), string(expiry))) {
+		return "", ErrInvalidToken
+	}
+	if !s.now().Before(time.Unix(unix, 0)) {
+		return "", ErrTokenExpired
+	}
+	return string(uid), nil
+}
+
+// sign computes the HMAC for a purpose, UID and expiry
+func (s *Signer) sign(purpose, uid, expiry string) []byte {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(purpose + ":" + uid + ":" + expiry))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,88 @@
+package account
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSigner_Deletion(t *testing.T) {
+	s := NewSigner("secret")
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+
+	token, expiresAt := s.IssueDeletion("uid-1")
+	if !expiresAt.Equal(now.Add(DeletionTokenTTL)) {
+		t.Errorf("expiresAt = %v, want %v", expiresAt, now.Add(DeletionTokenTTL))
+	}
+	if strings.Contains(token, "uid-1") {
+		t.Error("token should not contain the raw UID")
+	}
+	if err := s.VerifyDeletion(token, "uid-1"); err != nil {
+		t.Fatalf("VerifyDeletion() error = %v", err)
+	}
+	if err := s.VerifyDeletion(token, "uid-2"); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("VerifyDeletion(another user) error = %v, want ErrInvalidToken", err)
+	}
+
+	now = expiresAt
+	if err := s.VerifyDeletion(token, "uid-1"); !errors.Is(err, ErrTokenExpired) {
+		t.Errorf("VerifyDeletion(expired) error = %v, want ErrTokenExpired", err)
+	}
+}
+
+func TestSigner_Export(t *testing.T) {
+	s := NewSigner("secret")
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+
+	token, expiresAt := s.IssueExport("uid-1")
+	if !expiresAt.Equal(now.Add(ExportLinkTTL)) {
+		t.Errorf("expiresAt = %v, want %v", expiresAt, now.Add(ExportLinkTTL))
+	}
+	uid, err := s.VerifyExport(token)
+	if err != nil || uid != "uid-1" {
+		t.Fatalf("VerifyExport() = %q, %v, want uid-1", uid, err)
+	}
+
+	now = expiresAt
+	if _, err := s.VerifyExport(token); !errors.Is(err, ErrTokenExpired) {
+		t.Errorf("VerifyExport(expired) error = %v, want ErrTokenExpired", err)
+	}
+}
+
+func TestSigner_RejectsInvalid(t *testing.T) {
+	s := NewSigner("secret")
+	deletion, _ := s.IssueDeletion("uid-1")
+	forged, _ := NewSigner("other-secret").IssueExport("uid-1")
+	valid, _ := s.IssueExport("uid-1")
+	parts := strings.Split(valid, ".")
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"deletion token", deletion},
+		{"other secret", forged},
+		{"another user", "dWlkLTI." + parts[1] + "." + parts[2]},
+		{"extended expiry", parts[0] + ".OTk5OTk5OTk5OQ." + parts[2]},
+		{"missing part", parts[0] + "." + parts[2]},
+		{"bad encoding", parts[0] + ".!!." + parts[2]},
+		{"empty", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := s.VerifyExport(tt.token); !errors.Is(err, ErrInvalidToken) {
+				t.Errorf("VerifyExport() error = %v, want ErrInvalidToken", err)
+			}
+		})
+	}
+}
+
+func TestNewSigner_RandomSecret(t *testing.T) {
+	token, _ := NewSigner("").IssueDeletion("uid-1")
+	if err := NewSigner("").VerifyDeletion(token, "uid-1"); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("VerifyDeletion() with another random secret error = %v, want ErrInvalidToken", err)
+	}
+}
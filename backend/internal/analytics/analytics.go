@@ -0,0 +1,82 @@
+// Package analytics maintains daily rollups of earthquake events, so
+// frequency and intensity trends can be charted without scanning the raw
+// events collection.
+package analytics
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/otiai10/namazu/backend/internal/source"
+	"github.com/otiai10/namazu/backend/internal/source/p2pquake"
+	"github.com/otiai10/namazu/backend/internal/store"
+)
+
+// DateLayout is the layout of rollup dates
+const DateLayout = "2006-01-02"
+
+// Rollup holds the earthquakes that occurred on a day
+type Rollup struct {
+	Date         string           `json:"date" firestore:"date"` // "YYYY-MM-DD" (UTC)
+	Events       int64            `json:"events" firestore:"events"`
+	MaxScale     int              `json:"max_scale" firestore:"maxScale"`         // Highest JMA scale code (e.g. 45 for 5弱), 0 if unknown
+	ByScale      map[string]int64 `json:"by_scale" firestore:"byScale"`           // Keyed by JMA scale code; events of unknown scale are omitted
+	ByPrefecture map[string]int64 `json:"by_prefecture" firestore:"byPrefecture"` // Keyed by affected prefecture
+	UpdatedAt    time.Time        `json:"updated_at" firestore:"updatedAt"`
+}
+
+// Delta is one event's contribution to a day's rollup
+type Delta struct {
+	Scale       int      // JMA scale code, 0 if unknown
+	Prefectures []string // Affected prefectures, each counted once
+}
+
+// Repository persists daily rollups
+type Repository interface {
+	// Add atomically adds the delta to the rollup of the date
+	Add(ctx context.Context, date string, d Delta) error
+
+	// List returns the rollups from one date to another, both inclusive,
+	// oldest first. Days without events have no rollup.
+	List(ctx context.Context, from, to string) ([]Rollup, error)
+}
+
+// Date returns the rollup date ("YYYY-MM-DD" in UTC) for the given time
+func Date(t time.Time) string {
+	return t.UTC().Format(DateLayout)
+}
+
+// DeltaFor returns the rollup date and delta for a stored event.
+// ok is false for events that are not earthquake reports, such as tsunami
+// warnings and early warnings, which are not counted.
+func DeltaFor(event store.EventRecord) (date string, d Delta, ok bool) {
+	if event.Type != string(source.EventTypeEarthquake) {
+		return "", Delta{}, false
+	}
+	seen := make(map[string]bool, len(event.AffectedAreas))
+	for _, area := range event.AffectedAreas {
+		if area == "" || seen[area] {
+			continue
+		}
+		seen[area] = true
+		d.Prefectures = append(d.Prefectures, area)
+	}
+	d.Scale = p2pquake.SeverityToScale(event.Severity)
+	return Date(event.OccurredAt), d, true
+}
+
+// Record adds the event to its day's rollup; events that are not counted
+// are ignored
+func Record(ctx context.Context, repo Repository, event store.EventRecord) error {
+	date, d, ok := DeltaFor(event)
+	if !ok {
+		return nil
+	}
+	return repo.Add(ctx, date, d)
+}
+
+// scaleKey returns the ByScale key of a scale
+func scaleKey(scale int) string {
+	return strconv.Itoa(scale)
+}
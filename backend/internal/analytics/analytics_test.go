@@ -0,0 +1,63 @@
+package analytics
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/otiai10/namazu/backend/internal/source/p2pquake"
+	"github.com/otiai10/namazu/backend/internal/store"
+)
+
+func TestDate(t *testing.T) {
+	jst := time.FixedZone("JST", 9*60*60)
+	if got := Date(time.Date(2024, 4, 1, 5, 0, 0, 0, jst)); got != "2024-03-31" {
+		t.Errorf("Date() = %q, want %q", got, "2024-03-31")
+	}
+}
+
+func TestDeltaFor(t *testing.T) {
+	occurred := time.Date(2024, 1, 1, 7, 10, 0, 0, time.UTC)
+
+	date, d, ok := DeltaFor(store.EventRecord{
+		Type:          "earthquake",
+		Severity:      p2pquake.ScaleToSeverity(p2pquake.Scale5Strong),
+		AffectedAreas: []string{"石川県", "富山県", "石川県", ""},
+		OccurredAt:    occurred,
+	})
+	if !ok || date != "2024-01-01" {
+		t.Fatalf("DeltaFor() = %q, %v", date, ok)
+	}
+	if d.Scale != p2pquake.Scale5Strong {
+		t.Errorf("Scale = %d, want %d", d.Scale, p2pquake.Scale5Strong)
+	}
+	if len(d.Prefectures) != 2 || d.Prefectures[0] != "石川県" || d.Prefectures[1] != "富山県" {
+		t.Errorf("Prefectures = %v, want each prefecture once", d.Prefectures)
+	}
+
+	for _, typ := range []string{"tsunami", "eew"} {
+		if _, _, ok := DeltaFor(store.EventRecord{Type: typ, OccurredAt: occurred}); ok {
+			t.Errorf("DeltaFor(%s) should not be counted", typ)
+		}
+	}
+}
+
+func TestRecord(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryRepository()
+	occurred := time.Date(2024, 1, 1, 7, 10, 0, 0, time.UTC)
+
+	for _, event := range []store.EventRecord{
+		{Type: "earthquake", Severity: p2pquake.ScaleToSeverity(p2pquake.Scale3), AffectedAreas: []string{"石川県"}, OccurredAt: occurred},
+		{Type: "tsunami", AffectedAreas: []string{"石川県"}, OccurredAt: occurred},
+	} {
+		if err := Record(ctx, repo, event); err != nil {
+			t.Fatalf("Record() error = %v", err)
+		}
+	}
+
+	rollups, _ := repo.List(ctx, "2024-01-01", "2024-01-01")
+	if len(rollups) != 1 || rollups[0].Events != 1 || rollups[0].ByPrefecture["石川県"] != 1 {
+		t.Errorf("rollups = %+v, want only the earthquake", rollups)
+	}
+}
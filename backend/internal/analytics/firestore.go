@@ -0,0 +1,93 @@
+package analytics
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/api/iterator"
+)
+
+// rollupCollection holds one document per day, keyed by date
+const rollupCollection = "event_rollups"
+
+// FirestoreRepository implements Repository using Firestore
+type FirestoreRepository struct {
+	client *firestore.Client
+}
+
+// Compile-time interface check
+var _ Repository = (*FirestoreRepository)(nil)
+
+// NewFirestoreRepository creates a new FirestoreRepository
+func NewFirestoreRepository(client *firestore.Client) *FirestoreRepository {
+	return &FirestoreRepository{client: client}
+}
+
+// Add updates the day's counters using server-side transforms, so events
+// processed concurrently by several instances do not lose updates.
+func (r *FirestoreRepository) Add(ctx context.Context, date string, d Delta) error {
+	if r.client == nil {
+		return fmt.Errorf("firestore client is nil")
+	}
+	if date == "" {
+		return fmt.Errorf("date is required")
+	}
+
+	data := map[string]interface{}{
+		"date":      date,
+		"events":    firestore.Increment(1),
+		"maxScale":  firestore.FieldTransformMaximum(d.Scale),
+		"updatedAt": time.Now().UTC(),
+	}
+	// An empty map would be merged as a value and clear the counters,
+	// so the maps are only written with keys to increment
+	if d.Scale > 0 {
+		data["byScale"] = map[string]interface{}{scaleKey(d.Scale): firestore.Increment(1)}
+	}
+	if len(d.Prefectures) > 0 {
+		byPrefecture := make(map[string]interface{}, len(d.Prefectures))
+		for _, prefecture := range d.Prefectures {
+			byPrefecture[prefecture] = firestore.Increment(1)
+		}
+		data["byPrefecture"] = byPrefecture
+	}
+
+	_, err := r.client.Collection(rollupCollection).Doc(date).Set(ctx, data, firestore.MergeAll)
+	if err != nil {
+		return fmt.Errorf("failed to add to rollup: %w", err)
+	}
+	return nil
+}
+
+// List returns the rollups from one date to another, oldest first
+func (r *FirestoreRepository) List(ctx context.Context, from, to string) ([]Rollup, error) {
+	if r.client == nil {
+		return nil, fmt.Errorf("firestore client is nil")
+	}
+
+	iter := r.client.Collection(rollupCollection).
+		Where("date", ">=", from).
+		Where("date", "<=", to).
+		OrderBy("date", firestore.Asc).
+		Documents(ctx)
+	defer iter.Stop()
+
+	rollups := []Rollup{}
+	for {
+		docSnap, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list rollups: %w", err)
+		}
+		var rollup Rollup
+		if err := docSnap.DataTo(&rollup); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal rollup: %w", err)
+		}
+		rollups = append(rollups, rollup)
+	}
+	return rollups, nil
+}
@@ -0,0 +1,18 @@
+package analytics
+
+import (
+	"context"
+	"testing"
+)
+
+func TestFirestoreRepository_NilClient(t *testing.T) {
+	repo := NewFirestoreRepository(nil)
+	ctx := context.Background()
+
+	if err := repo.Add(ctx, "2024-01-01", Delta{Scale: 10}); err == nil {
+		t.Error("Add() expected error for nil client")
+	}
+	if _, err := repo.List(ctx, "2024-01-01", "2024-01-31"); err == nil {
+		t.Error("List() expected error for nil client")
+	}
+}
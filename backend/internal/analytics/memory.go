@@ -0,0 +1,68 @@
+package analytics
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryRepository implements Repository in process memory.
+// Used under --test-mode and NAMAZU_STORE_TYPE=memory; nothing survives a restart.
+type MemoryRepository struct {
+	mu      sync.Mutex
+	rollups map[string]Rollup // Keyed by date
+}
+
+// Compile-time interface check
+var _ Repository = (*MemoryRepository)(nil)
+
+// NewMemoryRepository creates an empty MemoryRepository
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{rollups: map[string]Rollup{}}
+}
+
+// Add adds the delta to the rollup of the date
+func (r *MemoryRepository) Add(ctx context.Context, date string, d Delta) error {
+	if date == "" {
+		return fmt.Errorf("date is required")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rollup, ok := r.rollups[date]
+	if !ok {
+		rollup = Rollup{Date: date, ByScale: map[string]int64{}, ByPrefecture: map[string]int64{}}
+	}
+	rollup.Events++
+	rollup.MaxScale = max(rollup.MaxScale, d.Scale)
+	if d.Scale > 0 {
+		rollup.ByScale[scaleKey(d.Scale)]++
+	}
+	for _, prefecture := range d.Prefectures {
+		rollup.ByPrefecture[prefecture]++
+	}
+	rollup.UpdatedAt = time.Now().UTC()
+	r.rollups[date] = rollup
+	return nil
+}
+
+// List returns the rollups from one date to another, oldest first
+func (r *MemoryRepository) List(ctx context.Context, from, to string) ([]Rollup, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rollups := []Rollup{}
+	for date, rollup := range r.rollups {
+		if date < from || date > to {
+			continue
+		}
+		// Copy the maps so callers cannot race with Add
+		rollup.ByScale = maps.Clone(rollup.ByScale)
+		rollup.ByPrefecture = maps.Clone(rollup.ByPrefecture)
+		rollups = append(rollups, rollup)
+	}
+	sort.Slice(rollups, func(i, j int) bool { return rollups[i].Date < rollups[j].Date })
+	return rollups, nil
+}
@@ -0,0 +1,70 @@
+package analytics
+
+import (
+	"context"
+	"sync"
+	"testing"
+)
+
+func TestMemoryRepository_AddAndList(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryRepository()
+
+	adds := []struct {
+		date string
+		d    Delta
+	}{
+		{"2024-01-01", Delta{Scale: 30, Prefectures: []string{"石川県"}}},
+		{"2024-01-01", Delta{Scale: 70, Prefectures: []string{"石川県", "富山県"}}},
+		{"2024-01-01", Delta{}},
+		{"2024-01-03", Delta{Scale: 10, Prefectures: []string{"千葉県"}}},
+		{"2024-02-01", Delta{Scale: 40}},
+	}
+	for _, add := range adds {
+		if err := repo.Add(ctx, add.date, add.d); err != nil {
+			t.Fatalf("Add() error = %v", err)
+		}
+	}
+
+	rollups, err := repo.List(ctx, "2024-01-01", "2024-01-31")
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(rollups) != 2 || rollups[0].Date != "2024-01-01" || rollups[1].Date != "2024-01-03" {
+		t.Fatalf("rollups = %+v, want 2024-01-01 and 2024-01-03", rollups)
+	}
+	day := rollups[0]
+	if day.Events != 3 || day.MaxScale != 70 {
+		t.Errorf("events = %d, max scale = %d, want 3 and 70", day.Events, day.MaxScale)
+	}
+	if len(day.ByScale) != 2 || day.ByScale["30"] != 1 || day.ByScale["70"] != 1 {
+		t.Errorf("by scale = %v, want unknown scales omitted", day.ByScale)
+	}
+	if day.ByPrefecture["石川県"] != 2 || day.ByPrefecture["富山県"] != 1 {
+		t.Errorf("by prefecture = %v", day.ByPrefecture)
+	}
+
+	if err := repo.Add(ctx, "", Delta{}); err == nil {
+		t.Error("Add() expected error for empty date")
+	}
+}
+
+func TestMemoryRepository_ConcurrentAdd(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryRepository()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = repo.Add(ctx, "2024-01-01", Delta{Scale: 10, Prefectures: []string{"東京都"}})
+		}()
+	}
+	wg.Wait()
+
+	rollups, _ := repo.List(ctx, "2024-01-01", "2024-01-01")
+	if len(rollups) != 1 || rollups[0].Events != 50 || rollups[0].ByPrefecture["東京都"] != 50 {
+		t.Errorf("rollups = %+v, want 50 events", rollups)
+	}
+}
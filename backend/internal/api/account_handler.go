@@ -0,0 +1,165 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/otiai10/namazu/backend/internal/account"
+	"github.com/otiai10/namazu/backend/internal/audit"
+	"github.com/otiai10/namazu/backend/internal/auth"
+	"github.com/otiai10/namazu/backend/internal/subscription"
+	"github.com/otiai10/namazu/backend/internal/user"
+)
+
+// SubscriptionCanceler cancels Stripe subscriptions (implemented by *billing.Client)
+type SubscriptionCanceler interface {
+	CancelSubscription(ctx context.Context, subscriptionID string) error
+}
+
+// DeletionTokenResponse is the body of POST /api/me/deletion
+type DeletionTokenResponse struct {
+	ConfirmationToken string    `json:"confirmation_token"`
+	ExpiresAt         time.Time `json:"expires_at"`
+}
+
+// DeleteAccountRequest is the body of DELETE /api/me
+type DeleteAccountRequest struct {
+	ConfirmationToken string `json:"confirmation_token"` // From POST /api/me/deletion
+}
+
+// DeleteAccountResponse reports what DELETE /api/me removed
+type DeleteAccountResponse struct {
+	Subscriptions int `json:"subscriptions"`
+	Deliveries    int `json:"deliveries"`
+}
+
+// SetSubscriptionCanceler makes account deletion cancel the user's Stripe subscription
+func (h *MeHandler) SetSubscriptionCanceler(c SubscriptionCanceler) {
+	h.canceler = c
+}
+
+// SetTokenRevoker makes account deletion sign the user out of every device
+func (h *MeHandler) SetTokenRevoker(r auth.TokenRevoker) {
+	h.tokenRevoker = r
+}
+
+// CreateDeletionToken handles POST /api/me/deletion
+// Returns a short-lived token that DELETE /api/me requires, so that an account
+// is only deleted by two deliberate requests.
+func (h *MeHandler) CreateDeletionToken(w http.ResponseWriter, r *http.Request) {
+	claims := auth.MustGetClaims(r.Context())
+
+	if h.signer == nil || h.subscriptionRepo == nil {
+		writeError(w, "account deletion is not enabled", http.StatusNotImplemented)
+		return
+	}
+
+	token, expiresAt := h.signer.IssueDeletion(claims.UID)
+	writeJSON(w, DeletionTokenResponse{ConfirmationToken: token, ExpiresAt: expiresAt.UTC()}, http.StatusOK)
+}
+
+// DeleteAccount handles DELETE /api/me
+// Cancels the Stripe subscription, then deletes the user's subscriptions with
+// their delivery history, unregisters their devices, deletes the user record
+// and revokes their refresh tokens. Stops at the first failure, so a retry
+// with a new token finishes the purge.
+func (h *MeHandler) DeleteAccount(w http.ResponseWriter, r *http.Request) {
+	claims := auth.MustGetClaims(r.Context())
+	ctx := r.Context()
+
+	if h.signer == nil || h.subscriptionRepo == nil {
+		writeError(w, "account deletion is not enabled", http.StatusNotImplemented)
+		return
+	}
+
+	var req DeleteAccountRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.ConfirmationToken == "" {
+		writeError(w, "confirmation_token is required; request one with POST /api/me/deletion", http.StatusBadRequest)
+		return
+	}
+	if err := h.signer.VerifyDeletion(req.ConfirmationToken, claims.UID); err != nil {
+		if errors.Is(err, account.ErrTokenExpired) {
+			writeError(w, "confirmation token expired", http.StatusBadRequest)
+			return
+		}
+		writeError(w, "invalid confirmation token", http.StatusBadRequest)
+		return
+	}
+
+	u, err := h.userRepo.GetByUID(ctx, claims.UID)
+	if err != nil {
+		writeError(w, "failed to get user", http.StatusInternalServerError)
+		return
+	}
+
+	// Stop billing first: everything after it cannot be undone
+	if u != nil && u.SubscriptionID != "" && u.SubscriptionStatus != user.SubscriptionStatusCanceled {
+		if h.canceler == nil {
+			writeError(w, "billing is not enabled; cancel the subscription before deleting the account", http.StatusConflict)
+			return
+		}
+		if err := h.canceler.CancelSubscription(ctx, u.SubscriptionID); err != nil {
+			log.Printf("Failed to cancel subscription %s of %s: %v", u.SubscriptionID, claims.UID, err)
+			writeError(w, "failed to cancel billing subscription", http.StatusBadGateway)
+			return
+		}
+	}
+
+	var resp DeleteAccountResponse
+	subs, err := h.subscriptionRepo.ListByUserID(ctx, claims.UID)
+	if err != nil {
+		writeError(w, "failed to list subscriptions", http.StatusInternalServerError)
+		return
+	}
+	for _, sub := range subs {
+		if sub.UserID != claims.UID {
+			continue // Never purge legacy subscriptions without an owner
+		}
+		if h.deliveryRepo != nil {
+			n, err := h.deliveryRepo.DeleteBySubscription(ctx, sub.ID)
+			resp.Deliveries += n
+			if err != nil {
+				writeError(w, "failed to delete delivery history", http.StatusInternalServerError)
+				return
+			}
+		}
+		if err := h.subscriptionRepo.Delete(ctx, sub.ID); err != nil && !errors.Is(err, subscription.ErrNotFound) {
+			writeError(w, "failed to delete subscription", http.StatusInternalServerError)
+			return
+		}
+		resp.Subscriptions++
+	}
+
+	if u != nil {
+		if h.deviceTopics != nil {
+			for i := range u.Devices {
+				if err := h.deviceTopics.Sync(ctx, &u.Devices[i], nil); err != nil {
+					log.Printf("Failed to unsubscribe device of %s from topics: %v", claims.UID, err)
+				}
+			}
+		}
+		if err := h.userRepo.Delete(ctx, u.ID); err != nil && !errors.Is(err, user.ErrNotFound) {
+			writeError(w, "failed to delete user", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	// The data is gone either way, so a failed revocation is only logged
+	if h.tokenRevoker != nil {
+		if err := h.tokenRevoker.RevokeTokens(ctx, claims.UID); err != nil {
+			log.Printf("Failed to revoke tokens of deleted account %s: %v", claims.UID, err)
+		}
+	}
+
+	log.Printf("Deleted account %s (%d subscriptions, %d delivery records)", claims.UID, resp.Subscriptions, resp.Deliveries)
+	h.auditLog.Record(ctx, auditEntry(r, audit.ActionAccountDelete, claims.UID, map[string]string{
+		"subscriptions": strconv.Itoa(resp.Subscriptions),
+		"deliveries":    strconv.Itoa(resp.Deliveries),
+	}))
+	writeJSON(w, resp, http.StatusOK)
+}
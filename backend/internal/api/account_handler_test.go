@@ -0,0 +1,198 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/otiai10/namazu/backend/internal/account"
+	"github.com/otiai10/namazu/backend/internal/store"
+	"github.com/otiai10/namazu/backend/internal/subscription"
+	"github.com/otiai10/namazu/backend/internal/user"
+)
+
+// fakeCanceler records canceled Stripe subscriptions
+type fakeCanceler struct {
+	canceled []string
+	err      error
+}
+
+func (f *fakeCanceler) CancelSubscription(ctx context.Context, subscriptionID string) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.canceled = append(f.canceled, subscriptionID)
+	return nil
+}
+
+// fakeRevoker records UIDs whose tokens were revoked
+type fakeRevoker struct{ revoked []string }
+
+func (f *fakeRevoker) RevokeTokens(ctx context.Context, uid string) error {
+	f.revoked = append(f.revoked, uid)
+	return nil
+}
+
+// deletionToken requests a confirmation token for uid
+func deletionToken(t *testing.T, h *MeHandler, uid string) string {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.CreateDeletionToken(rec, withUser(httptest.NewRequest(http.MethodPost, "/api/me/deletion", nil), uid))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+	}
+	var resp DeletionTokenResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.ConfirmationToken == "" || resp.ExpiresAt.IsZero() {
+		t.Fatalf("response = %+v, want a token and its expiry", resp)
+	}
+	return resp.ConfirmationToken
+}
+
+// deleteAccount calls DELETE /api/me as uid with the given confirmation token
+func deleteAccount(h *MeHandler, uid, token string) *httptest.ResponseRecorder {
+	body := fmt.Sprintf(`{"confirmation_token": %q}`, token)
+	rec := httptest.NewRecorder()
+	h.DeleteAccount(rec, withUser(httptest.NewRequest(http.MethodDelete, "/api/me", bytes.NewBufferString(body)), uid))
+	return rec
+}
+
+func TestMeHandler_DeleteAccount(t *testing.T) {
+	users := newMockUserRepo()
+	users.users["user-1"] = &user.User{
+		ID:                 "user-1",
+		UID:                "uid-1",
+		Plan:               user.PlanPro,
+		SubscriptionID:     "sub_stripe",
+		SubscriptionStatus: user.SubscriptionStatusActive,
+		Devices:            []user.Device{{Token: "tok", Prefectures: []string{"石川県"}}},
+	}
+	users.uidIndex["uid-1"] = "user-1"
+	subs := newMockSubscriptionRepo()
+	subs.subscriptions["mine"] = subscription.Subscription{ID: "mine", UserID: "uid-1"}
+	subs.subscriptions["theirs"] = subscription.Subscription{ID: "theirs", UserID: "uid-2"}
+	subs.subscriptions["legacy"] = subscription.Subscription{ID: "legacy"}
+	deliveries := &mockDeliveryRepo{records: []store.DeliveryRecord{
+		{SubscriptionID: "mine"}, {SubscriptionID: "mine"}, {SubscriptionID: "theirs"},
+	}}
+	canceler := &fakeCanceler{}
+	revoker := &fakeRevoker{}
+	topics := &fakeDeviceTopics{}
+
+	h := NewMeHandler(users)
+	h.SetAccountSigner(account.NewSigner("secret"))
+	h.SetSubscriptionRepository(subs)
+	h.SetDeliveryRepository(deliveries)
+	h.SetSubscriptionCanceler(canceler)
+	h.SetTokenRevoker(revoker)
+	h.SetDeviceTopics(topics)
+
+	rec := deleteAccount(h, "uid-1", deletionToken(t, h, "uid-1"))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+	}
+	var resp DeleteAccountResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp != (DeleteAccountResponse{Subscriptions: 1, Deliveries: 2}) {
+		t.Errorf("response = %+v, want 1 subscription and 2 deliveries", resp)
+	}
+
+	if len(canceler.canceled) != 1 || canceler.canceled[0] != "sub_stripe" {
+		t.Errorf("canceled = %v, want the Stripe subscription", canceler.canceled)
+	}
+	if _, ok := subs.subscriptions["mine"]; ok {
+		t.Error("expected the user's subscription to be deleted")
+	}
+	if _, ok := subs.subscriptions["theirs"]; !ok {
+		t.Error("expected another user's subscription to be kept")
+	}
+	if _, ok := subs.subscriptions["legacy"]; !ok {
+		t.Error("expected the ownerless subscription to be kept")
+	}
+	if len(deliveries.records) != 1 || deliveries.records[0].SubscriptionID != "theirs" {
+		t.Errorf("delivery records = %+v, want only the other user's", deliveries.records)
+	}
+	if u, _ := users.GetByUID(context.Background(), "uid-1"); u != nil {
+		t.Errorf("user = %+v, want deleted", u)
+	}
+	if want := []string{"[石川県] -> none"}; fmt.Sprint(topics.syncs) != fmt.Sprint(want) {
+		t.Errorf("syncs = %v, want %v", topics.syncs, want)
+	}
+	if len(revoker.revoked) != 1 || revoker.revoked[0] != "uid-1" {
+		t.Errorf("revoked = %v, want [uid-1]", revoker.revoked)
+	}
+}
+
+func TestMeHandler_DeleteAccount_RequiresConfirmation(t *testing.T) {
+	users := newMockUserRepo()
+	users.users["user-1"] = &user.User{ID: "user-1", UID: "uid-1"}
+	users.uidIndex["uid-1"] = "user-1"
+	h := NewMeHandler(users)
+	h.SetAccountSigner(account.NewSigner("secret"))
+	h.SetSubscriptionRepository(newMockSubscriptionRepo())
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"missing token", ""},
+		{"garbage", "not-a-token"},
+		{"another user's token", deletionToken(t, h, "uid-2")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if rec := deleteAccount(h, "uid-1", tt.token); rec.Code != http.StatusBadRequest {
+				t.Errorf("expected status %d, got %d: %s", http.StatusBadRequest, rec.Code, rec.Body.String())
+			}
+		})
+	}
+	if u, _ := users.GetByUID(context.Background(), "uid-1"); u == nil {
+		t.Error("expected the user to be kept")
+	}
+}
+
+func TestMeHandler_DeleteAccount_BillingFailure(t *testing.T) {
+	users := newMockUserRepo()
+	users.users["user-1"] = &user.User{ID: "user-1", UID: "uid-1", SubscriptionID: "sub_stripe", SubscriptionStatus: user.SubscriptionStatusActive}
+	users.uidIndex["uid-1"] = "user-1"
+	subs := newMockSubscriptionRepo()
+	subs.subscriptions["mine"] = subscription.Subscription{ID: "mine", UserID: "uid-1"}
+
+	h := NewMeHandler(users)
+	h.SetAccountSigner(account.NewSigner("secret"))
+	h.SetSubscriptionRepository(subs)
+
+	// Without billing an active subscription cannot be canceled
+	if rec := deleteAccount(h, "uid-1", deletionToken(t, h, "uid-1")); rec.Code != http.StatusConflict {
+		t.Errorf("expected status %d without billing, got %d: %s", http.StatusConflict, rec.Code, rec.Body.String())
+	}
+
+	h.SetSubscriptionCanceler(&fakeCanceler{err: errors.New("stripe unavailable")})
+	if rec := deleteAccount(h, "uid-1", deletionToken(t, h, "uid-1")); rec.Code != http.StatusBadGateway {
+		t.Errorf("expected status %d, got %d: %s", http.StatusBadGateway, rec.Code, rec.Body.String())
+	}
+	if _, ok := subs.subscriptions["mine"]; !ok {
+		t.Error("expected nothing to be deleted when billing cannot be canceled")
+	}
+}
+
+func TestMeHandler_DeleteAccount_Disabled(t *testing.T) {
+	h := NewMeHandler(newMockUserRepo())
+	rec := httptest.NewRecorder()
+	h.CreateDeletionToken(rec, withUser(httptest.NewRequest(http.MethodPost, "/api/me/deletion", nil), "uid-1"))
+	if rec.Code != http.StatusNotImplemented {
+		t.Errorf("expected status %d, got %d", http.StatusNotImplemented, rec.Code)
+	}
+	if rec := deleteAccount(h, "uid-1", "token"); rec.Code != http.StatusNotImplemented {
+		t.Errorf("expected status %d, got %d", http.StatusNotImplemented, rec.Code)
+	}
+}
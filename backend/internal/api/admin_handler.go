@@ -0,0 +1,583 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/otiai10/namazu/backend/internal/apierr"
+	"github.com/otiai10/namazu/backend/internal/audit"
+	"github.com/otiai10/namazu/backend/internal/auth"
+	"github.com/otiai10/namazu/backend/internal/config"
+	"github.com/otiai10/namazu/backend/internal/delivery"
+	"github.com/otiai10/namazu/backend/internal/delivery/webhook"
+	"github.com/otiai10/namazu/backend/internal/lifecycle"
+	"github.com/otiai10/namazu/backend/internal/notice"
+	"github.com/otiai10/namazu/backend/internal/source"
+	"github.com/otiai10/namazu/backend/internal/source/p2pquake"
+	"github.com/otiai10/namazu/backend/internal/subscription"
+	"github.com/otiai10/namazu/backend/internal/tenant"
+	"github.com/otiai10/namazu/backend/internal/user"
+)
+
+// ResolverStats reports DNS resolution metrics of webhook deliveries
+type ResolverStats interface {
+	Stats() []webhook.HostStats
+}
+
+// QueueStats reports the backpressure of the delivery queue
+type QueueStats interface {
+	Stats() delivery.QueueStats
+}
+
+// SenderStats reports the concurrency of webhook requests under the sender's limits
+type SenderStats interface {
+	Stats() webhook.LimitStats
+}
+
+// WatchStats reports the health of the subscription listener
+type WatchStats interface {
+	Stats() subscription.WatchStats
+}
+
+// Broadcaster queues service notices for delivery to subscribers
+type Broadcaster interface {
+	Broadcast(ctx context.Context, n notice.Notice) (int, error)
+}
+
+// LifecycleReporter reports the lifecycle state of subscriptions without changing it
+type LifecycleReporter interface {
+	Report(ctx context.Context) (*lifecycle.Report, error)
+}
+
+// EventInjector queues synthetic events for processing as if they had been received
+type EventInjector interface {
+	Inject(ctx context.Context, event source.Event) error
+}
+
+// maxInjectedEventBytes limits the body of an injected event
+const maxInjectedEventBytes = 256 << 10
+
+// AdminHandler handles operator-only endpoints
+type AdminHandler struct {
+	egressMeter EgressMeter
+	config      *config.Config
+	resolver    ResolverStats
+	queue       QueueStats
+	sender      SenderStats
+	watch       WatchStats
+	broadcaster Broadcaster
+	lifecycle   LifecycleReporter
+	injector    EventInjector
+	publisher   EventInjector
+	userRepo    user.Repository
+	subRepo     subscription.Repository
+	roleSetter  auth.RoleSetter
+	auditLog    *audit.Logger // nil disables audit records and GET /api/admin/audit
+}
+
+// NewAdminHandler creates a new AdminHandler
+func NewAdminHandler() *AdminHandler {
+	return &AdminHandler{}
+}
+
+// SetEgressMeter sets the egress meter used for per-user budgets
+func (h *AdminHandler) SetEgressMeter(m EgressMeter) {
+	h.egressMeter = m
+}
+
+// SetConfig sets the loaded configuration exposed by GetConfig
+func (h *AdminHandler) SetConfig(cfg *config.Config) {
+	h.config = cfg
+}
+
+// SetResolverStats sets the resolver whose metrics are exposed by GetDNSStats
+func (h *AdminHandler) SetResolverStats(r ResolverStats) {
+	h.resolver = r
+}
+
+// SetQueueStats sets the delivery queue whose metrics are exposed by GetQueueStats
+func (h *AdminHandler) SetQueueStats(q QueueStats) {
+	h.queue = q
+}
+
+// SetSenderStats sets the webhook sender whose concurrency is exposed by GetSenderStats
+func (h *AdminHandler) SetSenderStats(s SenderStats) {
+	h.sender = s
+}
+
+// SetWatchStats sets the subscription listener whose health is exposed by GetWatchStats
+func (h *AdminHandler) SetWatchStats(w WatchStats) {
+	h.watch = w
+}
+
+// SetBroadcaster sets the broadcaster used by BroadcastNotice
+func (h *AdminHandler) SetBroadcaster(b Broadcaster) {
+	h.broadcaster = b
+}
+
+// SetLifecycleReporter sets the reporter used by GetLifecycleReport
+func (h *AdminHandler) SetLifecycleReporter(l LifecycleReporter) {
+	h.lifecycle = l
+}
+
+// SetEventInjector enables InjectEvent
+func (h *AdminHandler) SetEventInjector(i EventInjector) {
+	h.injector = i
+}
+
+// SetEventPublisher enables PublishEvent
+func (h *AdminHandler) SetEventPublisher(p EventInjector) {
+	h.publisher = p
+}
+
+// SetUserRepo sets the user repository used by the user management endpoints
+func (h *AdminHandler) SetUserRepo(repo user.Repository) {
+	h.userRepo = repo
+}
+
+// SetSubscriptionRepo sets the subscription repository used by AssignOwner
+func (h *AdminHandler) SetSubscriptionRepo(repo subscription.Repository) {
+	h.subRepo = repo
+}
+
+// SetRoleSetter sets where SetUserRole publishes roles as custom claims.
+// Without it roles are only stored on the user record.
+func (h *AdminHandler) SetRoleSetter(s auth.RoleSetter) {
+	h.roleSetter = s
+}
+
+// NoticeRequest represents the request body for broadcasting a service notice
+type NoticeRequest struct {
+	Title    string `json:"title"`
+	Message  string `json:"message"`
+	Severity string `json:"severity,omitempty"` // info (default) | warning | critical
+}
+
+// NoticeResponse represents a queued service notice
+type NoticeResponse struct {
+	Notice     notice.Notice `json:"notice"`
+	Recipients int           `json:"recipients"`
+}
+
+// BroadcastNotice handles POST /api/admin/notices
+// Queues an operational notice for every webhook subscription that opted into
+// service notices and returns 202 with the number of recipients.
+func (h *AdminHandler) BroadcastNotice(w http.ResponseWriter, r *http.Request) {
+	if h.broadcaster == nil {
+		writeError(w, "service notices are not enabled", http.StatusNotImplemented)
+		return
+	}
+
+	var req NoticeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	n, err := notice.New(req.Title, req.Message, req.Severity)
+	if err != nil {
+		writeErrorCode(w, apierr.ValidationFailed, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	recipients, err := h.broadcaster.Broadcast(r.Context(), n)
+	if errors.Is(err, notice.ErrQueueFull) {
+		writeError(w, "too many notices pending, try again later", http.StatusServiceUnavailable)
+		return
+	}
+	if err != nil {
+		writeError(w, "failed to broadcast notice", http.StatusInternalServerError)
+		return
+	}
+
+	issuedBy := "unknown"
+	if claims, ok := auth.GetClaims(r.Context()); ok {
+		issuedBy = claims.UID
+	}
+	log.Printf("Notice %s queued for %d subscription(s) by %s", n.ID, recipients, issuedBy)
+	h.auditLog.Record(r.Context(), auditEntry(r, audit.ActionAdminNotice, n.ID, map[string]string{"title": n.Title, "recipients": strconv.Itoa(recipients)}))
+
+	writeJSON(w, NoticeResponse{Notice: n, Recipients: recipients}, http.StatusAccepted)
+}
+
+// GetLifecycleReport handles GET /api/admin/lifecycle
+// Returns a dry run of the lifecycle sweep: subscriptions that are warned,
+// suspended, or would be acted on by the next sweep.
+func (h *AdminHandler) GetLifecycleReport(w http.ResponseWriter, r *http.Request) {
+	if h.lifecycle == nil {
+		writeError(w, "subscription lifecycle is not enabled", http.StatusNotImplemented)
+		return
+	}
+
+	report, err := h.lifecycle.Report(r.Context())
+	if err != nil {
+		writeError(w, "failed to build lifecycle report", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, report, http.StatusOK)
+}
+
+// DNSStatsResponse represents DNS resolution metrics per webhook host
+type DNSStatsResponse struct {
+	Hosts []webhook.HostStats `json:"hosts"`
+}
+
+// GetDNSStats handles GET /api/admin/dns
+// Returns resolution counts and failures per webhook host since startup.
+func (h *AdminHandler) GetDNSStats(w http.ResponseWriter, r *http.Request) {
+	if h.resolver == nil {
+		writeError(w, "DNS caching is not enabled", http.StatusNotImplemented)
+		return
+	}
+
+	writeJSON(w, DNSStatsResponse{Hosts: h.resolver.Stats()}, http.StatusOK)
+}
+
+// GetQueueStats handles GET /api/admin/queue
+// Returns the depth, worker usage and backpressure of the delivery queue.
+func (h *AdminHandler) GetQueueStats(w http.ResponseWriter, r *http.Request) {
+	if h.queue == nil {
+		writeError(w, "delivery queue is not enabled", http.StatusNotImplemented)
+		return
+	}
+
+	writeJSON(w, h.queue.Stats(), http.StatusOK)
+}
+
+// GetSenderStats handles GET /api/admin/sender
+// Returns the webhook requests in flight and waiting for a slot, overall and per
+// host, and how often the concurrency limits made requests wait since startup.
+func (h *AdminHandler) GetSenderStats(w http.ResponseWriter, r *http.Request) {
+	if h.sender == nil {
+		writeError(w, "sender limits are not enabled", http.StatusNotImplemented)
+		return
+	}
+
+	writeJSON(w, h.sender.Stats(), http.StatusOK)
+}
+
+// GetWatchStats handles GET /api/admin/subscriptions/watch
+// Returns whether subscriptions are served from the Firestore listener, and its restarts.
+func (h *AdminHandler) GetWatchStats(w http.ResponseWriter, r *http.Request) {
+	if h.watch == nil {
+		writeError(w, "subscription listener is not enabled", http.StatusNotImplemented)
+		return
+	}
+
+	writeJSON(w, h.watch.Stats(), http.StatusOK)
+}
+
+// ConfigResponse represents the effective configuration
+type ConfigResponse struct {
+	Settings []config.Setting `json:"settings"`
+}
+
+// GetConfig handles GET /api/admin/config
+// Returns the effective configuration with the origin of each value. Secrets are masked.
+func (h *AdminHandler) GetConfig(w http.ResponseWriter, r *http.Request) {
+	if h.config == nil {
+		writeError(w, "config export is not enabled", http.StatusNotImplemented)
+		return
+	}
+
+	writeJSON(w, ConfigResponse{Settings: h.config.Export()}, http.StatusOK)
+}
+
+// EgressBudgetRequest represents the request body for setting an egress budget
+type EgressBudgetRequest struct {
+	MonthlyBytes int64 `json:"monthly_bytes"` // 0 removes the limit
+}
+
+// GetUserEgress handles GET /api/admin/users/{uid}/egress
+// Returns the user's current usage and budget
+func (h *AdminHandler) GetUserEgress(w http.ResponseWriter, r *http.Request, uid string) {
+	if h.egressMeter == nil {
+		writeError(w, "usage tracking is not enabled", http.StatusNotImplemented)
+		return
+	}
+
+	summary, err := h.egressMeter.Summary(r.Context(), uid)
+	if err != nil {
+		writeError(w, "failed to get usage", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, summary, http.StatusOK)
+}
+
+// SetUserEgressBudget handles PUT /api/admin/users/{uid}/egress
+// Sets the user's monthly egress budget. Deliveries over budget are throttled, not dropped.
+func (h *AdminHandler) SetUserEgressBudget(w http.ResponseWriter, r *http.Request, uid string) {
+	if h.egressMeter == nil {
+		writeError(w, "usage tracking is not enabled", http.StatusNotImplemented)
+		return
+	}
+
+	var req EgressBudgetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.MonthlyBytes < 0 {
+		writeErrorCode(w, apierr.ValidationFailed, "monthly_bytes must not be negative", http.StatusBadRequest)
+		return
+	}
+
+	updatedBy := ""
+	if claims, ok := auth.GetClaims(r.Context()); ok {
+		updatedBy = claims.UID
+	}
+
+	budget, err := h.egressMeter.SetBudget(r.Context(), uid, req.MonthlyBytes, updatedBy)
+	if err != nil {
+		writeError(w, "failed to set budget", http.StatusInternalServerError)
+		return
+	}
+	h.auditLog.Record(r.Context(), auditEntry(r, audit.ActionAdminEgressBudget, uid, map[string]string{"monthly_bytes": strconv.FormatInt(req.MonthlyBytes, 10)}))
+
+	writeJSON(w, budget, http.StatusOK)
+}
+
+// parseAdminUserPath extracts the user ID and sub-resource from
+// /api/admin/users/{uid}/{resource}
+// parseAdminUserID extracts the UID from /api/admin/users/{uid}
+func parseAdminUserID(path string) (string, bool) {
+	uid := strings.TrimPrefix(path, "/api/admin/users/")
+	if uid == "" || strings.Contains(uid, "/") {
+		return "", false
+	}
+	return uid, true
+}
+
+func parseAdminUserPath(path string) (uid, resource string, ok bool) {
+	rest := strings.TrimPrefix(path, "/api/admin/users/")
+	parts := strings.Split(rest, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
+
+// InjectedEventResponse describes a queued synthetic event
+type InjectedEventResponse struct {
+	ID       string `json:"id"`
+	Type     string `json:"type"`
+	Severity int    `json:"severity"`
+}
+
+// InjectEvent handles POST /api/admin/inject-event
+// Accepts a P2P地震情報 JSON message (code 551 or 556), e.g. from cmd/quakegen,
+// and queues it as if it had been received over the WebSocket. The event is
+// stored and delivered to real subscribers, so this is only enabled for testing.
+func (h *AdminHandler) InjectEvent(w http.ResponseWriter, r *http.Request) {
+	if h.injector == nil {
+		writeError(w, "event injection is not enabled", http.StatusNotImplemented)
+		return
+	}
+	h.queueEvent(w, r, h.injector, "Synthetic event", audit.ActionAdminInjectEvent)
+}
+
+// PublishEvent handles POST /api/admin/events
+// Accepts a P2P地震情報 JSON message written by an operator and pushes it through
+// the normal pipeline (store, filter, deliver). Unlike InjectEvent it is available
+// to admins in production, for drills and incident rehearsals.
+func (h *AdminHandler) PublishEvent(w http.ResponseWriter, r *http.Request) {
+	if h.publisher == nil {
+		writeError(w, "event publishing is not configured", http.StatusNotImplemented)
+		return
+	}
+	h.queueEvent(w, r, h.publisher, "Drill event", audit.ActionAdminPublishEvent)
+}
+
+// queueEvent parses the request body as a P2P地震情報 message and hands it to q
+func (h *AdminHandler) queueEvent(w http.ResponseWriter, r *http.Request, q EventInjector, kind, action string) {
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxInjectedEventBytes))
+	if err != nil {
+		writeError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	event, err := p2pquake.ParseMessage(data)
+	if err != nil {
+		writeError(w, "invalid p2pquake message: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if event == nil {
+		writeError(w, "EEW test broadcasts are not delivered", http.StatusBadRequest)
+		return
+	}
+
+	if err := q.Inject(r.Context(), event); err != nil {
+		writeError(w, "too many injected events pending, try again later", http.StatusServiceUnavailable)
+		return
+	}
+
+	injectedBy := "unknown"
+	if claims, ok := auth.GetClaims(r.Context()); ok {
+		injectedBy = claims.UID
+	}
+	log.Printf("%s %s injected by %s", kind, event.GetID(), injectedBy)
+	h.auditLog.Record(r.Context(), auditEntry(r, action, event.GetID(), map[string]string{"type": string(event.GetType())}))
+
+	writeJSON(w, InjectedEventResponse{
+		ID:       event.GetID(),
+		Type:     string(event.GetType()),
+		Severity: event.GetSeverity(),
+	}, http.StatusAccepted)
+}
+
+// UserRoleRequest is the request body for PUT /api/admin/users/{uid}/role
+type UserRoleRequest struct {
+	Role string `json:"role"`
+}
+
+// GetUser handles GET /api/admin/users/{uid}
+func (h *AdminHandler) GetUser(w http.ResponseWriter, r *http.Request, uid string) {
+	if h.userRepo == nil {
+		writeError(w, "user management is not enabled", http.StatusNotImplemented)
+		return
+	}
+
+	u, err := h.userRepo.GetByUID(r.Context(), uid)
+	if err != nil {
+		writeError(w, "failed to get user", http.StatusInternalServerError)
+		return
+	}
+	if u == nil {
+		writeError(w, "user not found", http.StatusNotFound)
+		return
+	}
+
+	writeJSON(w, u, http.StatusOK)
+}
+
+// SetUserRole handles PUT /api/admin/users/{uid}/role
+// Grants or revokes the admin role. The role is published as a custom claim
+// first so that the user record never claims a role the tokens do not carry.
+// Admins cannot demote themselves, so the last admin cannot lock everyone out.
+func (h *AdminHandler) SetUserRole(w http.ResponseWriter, r *http.Request, uid string) {
+	if h.userRepo == nil {
+		writeError(w, "user management is not enabled", http.StatusNotImplemented)
+		return
+	}
+
+	var req UserRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.Role != user.RoleUser && req.Role != user.RoleAdmin {
+		writeErrorCode(w, apierr.ValidationFailed, "role must be user or admin", http.StatusBadRequest)
+		return
+	}
+
+	updatedBy := "unknown"
+	if claims, ok := auth.GetClaims(r.Context()); ok {
+		updatedBy = claims.UID
+		if claims.UID == uid && req.Role != user.RoleAdmin {
+			writeError(w, "admins cannot revoke their own role", http.StatusBadRequest)
+			return
+		}
+	}
+
+	u, err := h.userRepo.GetByUID(r.Context(), uid)
+	if err != nil {
+		writeError(w, "failed to get user", http.StatusInternalServerError)
+		return
+	}
+	if u == nil {
+		writeError(w, "user not found", http.StatusNotFound)
+		return
+	}
+
+	if h.roleSetter != nil {
+		if err := h.roleSetter.SetRole(r.Context(), uid, req.Role); err != nil {
+			log.Printf("Failed to set role claim for %s: %v", uid, err)
+			writeError(w, "failed to update custom claims", http.StatusBadGateway)
+			return
+		}
+	}
+
+	updated := u.Copy()
+	updated.Role = req.Role
+	if err := h.userRepo.Update(r.Context(), u.ID, updated); err != nil {
+		writeError(w, "failed to update user", http.StatusInternalServerError)
+		return
+	}
+	log.Printf("Role of user %s set to %s by %s", uid, req.Role, updatedBy)
+	h.auditLog.Record(r.Context(), auditEntry(r, audit.ActionAdminSetRole, uid, map[string]string{"from": u.Role, "to": req.Role}))
+
+	writeJSON(w, updated, http.StatusOK)
+}
+
+// AssignOwnerRequest is the request body for POST /api/admin/subscriptions/{id}/assign-owner
+type AssignOwnerRequest struct {
+	UserID string `json:"user_id"`
+}
+
+// AssignOwner handles POST /api/admin/subscriptions/{id}/assign-owner
+// Claims a legacy subscription without an owner for a user. Subscriptions
+// that already have another owner are not reassigned. Subscriptions archived
+// by `namazu backfill-owners` stay suspended until the new owner reactivates them.
+func (h *AdminHandler) AssignOwner(w http.ResponseWriter, r *http.Request, id string) {
+	if h.subRepo == nil {
+		writeError(w, "subscription management is not enabled", http.StatusNotImplemented)
+		return
+	}
+
+	var req AssignOwnerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.UserID == "" {
+		writeErrorCode(w, apierr.ValidationFailed, "user_id is required", http.StatusBadRequest)
+		return
+	}
+
+	sub, err := h.subRepo.Get(r.Context(), id)
+	if err != nil {
+		writeError(w, "failed to get subscription", http.StatusInternalServerError)
+		return
+	}
+	if sub == nil || sub.TenantID != tenant.FromContext(r.Context()).ID {
+		writeError(w, "subscription not found", http.StatusNotFound)
+		return
+	}
+	if sub.UserID != "" && sub.UserID != req.UserID {
+		writeError(w, "subscription already has an owner", http.StatusConflict)
+		return
+	}
+
+	if h.userRepo != nil {
+		u, err := h.userRepo.GetByUID(r.Context(), req.UserID)
+		if err != nil {
+			writeError(w, "failed to get user", http.StatusInternalServerError)
+			return
+		}
+		if u == nil {
+			writeError(w, "user not found", http.StatusBadRequest)
+			return
+		}
+	}
+
+	if sub.UserID == "" {
+		updated := *sub
+		updated.UserID = req.UserID
+		updated, err = saveSubscription(r.Context(), h.subRepo, id, updated)
+		if err != nil {
+			writeSaveError(w, err)
+			return
+		}
+		log.Printf("Subscription %s assigned to %s", id, req.UserID)
+		h.auditLog.Record(r.Context(), auditEntry(r, audit.ActionAdminAssignOwner, id, map[string]string{"name": sub.Name, "user_id": req.UserID}))
+		sub = &updated
+	}
+
+	writeJSON(w, subscriptionToResponse(*sub), http.StatusOK)
+}
@@ -0,0 +1,832 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/otiai10/namazu/backend/internal/auth"
+	"github.com/otiai10/namazu/backend/internal/config"
+	"github.com/otiai10/namazu/backend/internal/delivery"
+	"github.com/otiai10/namazu/backend/internal/delivery/webhook"
+	"github.com/otiai10/namazu/backend/internal/egress"
+	"github.com/otiai10/namazu/backend/internal/lifecycle"
+	"github.com/otiai10/namazu/backend/internal/notice"
+	"github.com/otiai10/namazu/backend/internal/source"
+	"github.com/otiai10/namazu/backend/internal/subscription"
+	"github.com/otiai10/namazu/backend/internal/user"
+)
+
+// mockEgressMeter implements EgressMeter for testing
+type mockEgressMeter struct {
+	summaries map[string]egress.Summary
+	budgets   map[string]egress.Budget
+	err       error
+}
+
+func newMockEgressMeter() *mockEgressMeter {
+	return &mockEgressMeter{
+		summaries: make(map[string]egress.Summary),
+		budgets:   make(map[string]egress.Budget),
+	}
+}
+
+func (m *mockEgressMeter) Summary(ctx context.Context, userID string) (egress.Summary, error) {
+	if m.err != nil {
+		return egress.Summary{}, m.err
+	}
+	return m.summaries[userID], nil
+}
+
+func (m *mockEgressMeter) SetBudget(ctx context.Context, userID string, monthlyBytes int64, updatedBy string) (egress.Budget, error) {
+	if m.err != nil {
+		return egress.Budget{}, m.err
+	}
+	b := egress.Budget{UserID: userID, MonthlyBytes: monthlyBytes, UpdatedBy: updatedBy}
+	m.budgets[userID] = b
+	return b, nil
+}
+
+func TestAdminHandler_GetUserEgress(t *testing.T) {
+	meter := newMockEgressMeter()
+	meter.summaries["user-1"] = egress.Summary{Period: "2024-03", Requests: 4, BytesSent: 4096, BudgetBytes: 1024, Throttled: true}
+	handler := NewAdminHandler()
+	handler.SetEgressMeter(meter)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/admin/users/user-1/egress", nil)
+	rec := httptest.NewRecorder()
+
+	handler.GetUserEgress(rec, req, "user-1")
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rec.Code)
+	}
+
+	var summary egress.Summary
+	if err := json.Unmarshal(rec.Body.Bytes(), &summary); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if summary.BytesSent != 4096 || !summary.Throttled {
+		t.Errorf("unexpected summary: %+v", summary)
+	}
+}
+
+func TestAdminHandler_SetUserEgressBudget(t *testing.T) {
+	meter := newMockEgressMeter()
+	handler := NewAdminHandler()
+	handler.SetEgressMeter(meter)
+
+	body := bytes.NewBufferString(`{"monthly_bytes": 1048576}`)
+	req := httptest.NewRequest(http.MethodPut, "/api/admin/users/user-1/egress", body)
+	req = req.WithContext(auth.WithClaims(req.Context(), &auth.Claims{UID: "admin-1", Admin: true}))
+	rec := httptest.NewRecorder()
+
+	handler.SetUserEgressBudget(rec, req, "user-1")
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rec.Code)
+	}
+
+	b, ok := meter.budgets["user-1"]
+	if !ok {
+		t.Fatal("expected budget to be set")
+	}
+	if b.MonthlyBytes != 1048576 || b.UpdatedBy != "admin-1" {
+		t.Errorf("unexpected budget: %+v", b)
+	}
+}
+
+func TestAdminHandler_SetUserEgressBudget_Invalid(t *testing.T) {
+	handler := NewAdminHandler()
+	handler.SetEgressMeter(newMockEgressMeter())
+
+	tests := []struct {
+		name string
+		body string
+	}{
+		{name: "malformed json", body: `{`},
+		{name: "negative budget", body: `{"monthly_bytes": -1}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPut, "/api/admin/users/user-1/egress", bytes.NewBufferString(tt.body))
+			rec := httptest.NewRecorder()
+
+			handler.SetUserEgressBudget(rec, req, "user-1")
+
+			if rec.Code != http.StatusBadRequest {
+				t.Errorf("expected status %d, got %d", http.StatusBadRequest, rec.Code)
+			}
+		})
+	}
+}
+
+func TestAdminHandler_NoMeter(t *testing.T) {
+	handler := NewAdminHandler()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/admin/users/user-1/egress", nil)
+	rec := httptest.NewRecorder()
+
+	handler.GetUserEgress(rec, req, "user-1")
+
+	if rec.Code != http.StatusNotImplemented {
+		t.Errorf("expected status %d, got %d", http.StatusNotImplemented, rec.Code)
+	}
+}
+
+func TestAdminHandler_MeterError(t *testing.T) {
+	meter := newMockEgressMeter()
+	meter.err = errors.New("unavailable")
+	handler := NewAdminHandler()
+	handler.SetEgressMeter(meter)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/admin/users/user-1/egress", nil)
+	rec := httptest.NewRecorder()
+
+	handler.GetUserEgress(rec, req, "user-1")
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("expected status %d, got %d", http.StatusInternalServerError, rec.Code)
+	}
+}
+
+func TestAdminHandler_GetConfig(t *testing.T) {
+	cfg := &config.Config{
+		Source:  config.SourceConfig{Type: "p2pquake", Endpoint: "wss://example.com/ws"},
+		Billing: &config.BillingConfig{SecretKey: "sk_test_abc"},
+	}
+	cfg.SetRuntime("source.endpoint", "test override")
+
+	handler := NewAdminHandler()
+	handler.SetConfig(cfg)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/admin/config", nil)
+	rec := httptest.NewRecorder()
+	handler.GetConfig(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rec.Code)
+	}
+	if bytes.Contains(rec.Body.Bytes(), []byte("sk_test_abc")) {
+		t.Error("expected secrets to be masked")
+	}
+
+	var resp ConfigResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	found := false
+	for _, s := range resp.Settings {
+		if s.Key == "source.endpoint" {
+			found = true
+			if s.Source != config.SourceRuntime || s.Value != "wss://example.com/ws" {
+				t.Errorf("unexpected setting: %+v", s)
+			}
+		}
+	}
+	if !found {
+		t.Error("expected source.endpoint in settings")
+	}
+}
+
+func TestAdminHandler_GetConfig_NotConfigured(t *testing.T) {
+	handler := NewAdminHandler()
+
+	rec := httptest.NewRecorder()
+	handler.GetConfig(rec, httptest.NewRequest(http.MethodGet, "/api/admin/config", nil))
+
+	if rec.Code != http.StatusNotImplemented {
+		t.Errorf("expected status %d, got %d", http.StatusNotImplemented, rec.Code)
+	}
+}
+
+// mockResolverStats implements ResolverStats for testing
+type mockResolverStats struct {
+	stats []webhook.HostStats
+}
+
+func (m *mockResolverStats) Stats() []webhook.HostStats {
+	return m.stats
+}
+
+func TestAdminHandler_GetDNSStats(t *testing.T) {
+	handler := NewAdminHandler()
+	handler.SetResolverStats(&mockResolverStats{stats: []webhook.HostStats{
+		{Host: "hooks.example.com", Lookups: 3, Failures: 1, LastError: "server misbehaving"},
+	}})
+
+	rec := httptest.NewRecorder()
+	handler.GetDNSStats(rec, httptest.NewRequest(http.MethodGet, "/api/admin/dns", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rec.Code)
+	}
+	var resp DNSStatsResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if len(resp.Hosts) != 1 || resp.Hosts[0].Host != "hooks.example.com" || resp.Hosts[0].Failures != 1 {
+		t.Errorf("unexpected hosts: %+v", resp.Hosts)
+	}
+}
+
+func TestAdminHandler_GetDNSStats_NotConfigured(t *testing.T) {
+	handler := NewAdminHandler()
+
+	rec := httptest.NewRecorder()
+	handler.GetDNSStats(rec, httptest.NewRequest(http.MethodGet, "/api/admin/dns", nil))
+
+	if rec.Code != http.StatusNotImplemented {
+		t.Errorf("expected status %d, got %d", http.StatusNotImplemented, rec.Code)
+	}
+}
+
+// mockQueueStats implements QueueStats for testing
+type mockQueueStats struct {
+	stats delivery.QueueStats
+}
+
+func (m *mockQueueStats) Stats() delivery.QueueStats {
+	return m.stats
+}
+
+func TestAdminHandler_GetQueueStats(t *testing.T) {
+	handler := NewAdminHandler()
+	handler.SetQueueStats(&mockQueueStats{stats: delivery.QueueStats{Workers: 16, Capacity: 1024, Depth: 3, Blocked: 2, BlockedMs: 150}})
+
+	rec := httptest.NewRecorder()
+	handler.GetQueueStats(rec, httptest.NewRequest(http.MethodGet, "/api/admin/queue", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rec.Code)
+	}
+	var resp delivery.QueueStats
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if resp.Workers != 16 || resp.Depth != 3 || resp.Blocked != 2 || resp.BlockedMs != 150 {
+		t.Errorf("unexpected stats: %+v", resp)
+	}
+}
+
+func TestAdminHandler_GetQueueStats_NotConfigured(t *testing.T) {
+	handler := NewAdminHandler()
+
+	rec := httptest.NewRecorder()
+	handler.GetQueueStats(rec, httptest.NewRequest(http.MethodGet, "/api/admin/queue", nil))
+
+	if rec.Code != http.StatusNotImplemented {
+		t.Errorf("expected status %d, got %d", http.StatusNotImplemented, rec.Code)
+	}
+}
+
+// mockSenderStats implements SenderStats for testing
+type mockSenderStats struct {
+	stats webhook.LimitStats
+}
+
+func (m *mockSenderStats) Stats() webhook.LimitStats {
+	return m.stats
+}
+
+func TestAdminHandler_GetSenderStats(t *testing.T) {
+	handler := NewAdminHandler()
+	handler.SetSenderStats(&mockSenderStats{stats: webhook.LimitStats{
+		MaxInFlight: 512, MaxPerHost: 32, InFlight: 40, Waiting: 8, Queued: 120, QueuedMs: 900,
+		Hosts: []webhook.HostLimitStats{{Host: "hooks.example.com", InFlight: 32, Waiting: 8}},
+	}})
+
+	rec := httptest.NewRecorder()
+	handler.GetSenderStats(rec, httptest.NewRequest(http.MethodGet, "/api/admin/sender", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rec.Code)
+	}
+	var resp webhook.LimitStats
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if resp.InFlight != 40 || resp.Waiting != 8 || resp.Queued != 120 || len(resp.Hosts) != 1 || resp.Hosts[0].Waiting != 8 {
+		t.Errorf("unexpected stats: %+v", resp)
+	}
+}
+
+func TestAdminHandler_GetSenderStats_NotConfigured(t *testing.T) {
+	handler := NewAdminHandler()
+
+	rec := httptest.NewRecorder()
+	handler.GetSenderStats(rec, httptest.NewRequest(http.MethodGet, "/api/admin/sender", nil))
+
+	if rec.Code != http.StatusNotImplemented {
+		t.Errorf("expected status %d, got %d", http.StatusNotImplemented, rec.Code)
+	}
+}
+
+// mockWatchStats implements WatchStats for testing
+type mockWatchStats struct {
+	stats subscription.WatchStats
+}
+
+func (m *mockWatchStats) Stats() subscription.WatchStats {
+	return m.stats
+}
+
+func TestAdminHandler_GetWatchStats(t *testing.T) {
+	handler := NewAdminHandler()
+
+	rec := httptest.NewRecorder()
+	handler.GetWatchStats(rec, httptest.NewRequest(http.MethodGet, "/api/admin/subscriptions/watch", nil))
+	if rec.Code != http.StatusNotImplemented {
+		t.Errorf("expected status %d without a listener, got %d", http.StatusNotImplemented, rec.Code)
+	}
+
+	handler.SetWatchStats(&mockWatchStats{stats: subscription.WatchStats{Connected: true, Subscriptions: 12, Reconnects: 1}})
+	rec = httptest.NewRecorder()
+	handler.GetWatchStats(rec, httptest.NewRequest(http.MethodGet, "/api/admin/subscriptions/watch", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rec.Code)
+	}
+	var resp subscription.WatchStats
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if !resp.Connected || resp.Subscriptions != 12 || resp.Reconnects != 1 {
+		t.Errorf("unexpected stats: %+v", resp)
+	}
+}
+
+// mockBroadcaster implements Broadcaster for testing
+type mockBroadcaster struct {
+	notices    []notice.Notice
+	recipients int
+	err        error
+}
+
+func (m *mockBroadcaster) Broadcast(ctx context.Context, n notice.Notice) (int, error) {
+	if m.err != nil {
+		return 0, m.err
+	}
+	m.notices = append(m.notices, n)
+	return m.recipients, nil
+}
+
+func TestAdminHandler_BroadcastNotice(t *testing.T) {
+	broadcaster := &mockBroadcaster{recipients: 42}
+	handler := NewAdminHandler()
+	handler.SetBroadcaster(broadcaster)
+
+	body := `{"title":"Relay maintenance","message":"The relay restarts at 02:00 JST.","severity":"warning"}`
+	rec := httptest.NewRecorder()
+	handler.BroadcastNotice(rec, httptest.NewRequest(http.MethodPost, "/api/admin/notices", strings.NewReader(body)))
+
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusAccepted, rec.Code, rec.Body.String())
+	}
+	var resp NoticeResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if resp.Recipients != 42 || resp.Notice.Type != notice.Type || resp.Notice.Severity != notice.SeverityWarning {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+	if len(broadcaster.notices) != 1 || broadcaster.notices[0].ID != resp.Notice.ID {
+		t.Errorf("expected the notice to be broadcast, got %+v", broadcaster.notices)
+	}
+}
+
+func TestAdminHandler_BroadcastNotice_Errors(t *testing.T) {
+	tests := []struct {
+		name        string
+		broadcaster Broadcaster
+		body        string
+		want        int
+	}{
+		{"not configured", nil, `{"title":"t","message":"m"}`, http.StatusNotImplemented},
+		{"invalid body", &mockBroadcaster{}, `{`, http.StatusBadRequest},
+		{"missing message", &mockBroadcaster{}, `{"title":"t"}`, http.StatusBadRequest},
+		{"invalid severity", &mockBroadcaster{}, `{"title":"t","message":"m","severity":"urgent"}`, http.StatusBadRequest},
+		{"queue full", &mockBroadcaster{err: notice.ErrQueueFull}, `{"title":"t","message":"m"}`, http.StatusServiceUnavailable},
+		{"failure", &mockBroadcaster{err: errors.New("firestore down")}, `{"title":"t","message":"m"}`, http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewAdminHandler()
+			if tt.broadcaster != nil {
+				handler.SetBroadcaster(tt.broadcaster)
+			}
+			rec := httptest.NewRecorder()
+			handler.BroadcastNotice(rec, httptest.NewRequest(http.MethodPost, "/api/admin/notices", strings.NewReader(tt.body)))
+			if rec.Code != tt.want {
+				t.Errorf("expected status %d, got %d", tt.want, rec.Code)
+			}
+		})
+	}
+}
+
+// mockInjector implements EventInjector for testing
+type mockInjector struct {
+	events []source.Event
+	err    error
+}
+
+func (m *mockInjector) Inject(ctx context.Context, event source.Event) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.events = append(m.events, event)
+	return nil
+}
+
+func TestAdminHandler_InjectEvent(t *testing.T) {
+	injector := &mockInjector{}
+	router := NewRouterWithConfig(RouterConfig{SubscriptionRepo: newMockSubscriptionRepo(), EventInjector: injector})
+
+	body := `{"_id":"syn-1","code":551,"time":"2026/01/01 00:00:00.000","earthquake":{"maxScale":60},"points":[{"pref":"東京都","addr":"千代田区","scale":60}]}`
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/admin/inject-event", strings.NewReader(body)))
+
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusAccepted, rec.Code, rec.Body.String())
+	}
+	var resp InjectedEventResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if resp.ID != "syn-1" || resp.Type != string(source.EventTypeEarthquake) || resp.Severity == 0 {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+	if len(injector.events) != 1 || injector.events[0].GetRawJSON() != body {
+		t.Errorf("expected the raw message to be injected, got %+v", injector.events)
+	}
+}
+
+func TestAdminHandler_InjectEvent_Errors(t *testing.T) {
+	quake := `{"_id":"syn-1","code":551}`
+	tests := []struct {
+		name     string
+		injector EventInjector
+		method   string
+		body     string
+		want     int
+	}{
+		{"not configured", nil, http.MethodPost, quake, http.StatusNotImplemented},
+		{"invalid JSON", &mockInjector{}, http.MethodPost, `{`, http.StatusBadRequest},
+		{"unsupported code", &mockInjector{}, http.MethodPost, `{"_id":"x","code":555}`, http.StatusBadRequest},
+		{"missing ID", &mockInjector{}, http.MethodPost, `{"code":551}`, http.StatusBadRequest},
+		{"EEW test broadcast", &mockInjector{}, http.MethodPost, `{"_id":"x","code":556,"test":true}`, http.StatusBadRequest},
+		{"queue full", &mockInjector{err: errors.New("full")}, http.MethodPost, quake, http.StatusServiceUnavailable},
+		{"too large", &mockInjector{}, http.MethodPost, `{"_id":"x","code":551,"pad":"` + strings.Repeat("a", maxInjectedEventBytes) + `"}`, http.StatusBadRequest},
+		{"GET is not allowed", &mockInjector{}, http.MethodGet, "", http.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := RouterConfig{SubscriptionRepo: newMockSubscriptionRepo()}
+			if tt.injector != nil {
+				cfg.EventInjector = tt.injector
+			}
+			rec := httptest.NewRecorder()
+			NewRouterWithConfig(cfg).ServeHTTP(rec, httptest.NewRequest(tt.method, "/api/admin/inject-event", strings.NewReader(tt.body)))
+			if rec.Code != tt.want {
+				t.Errorf("expected status %d, got %d: %s", tt.want, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestAdminHandler_PublishEvent(t *testing.T) {
+	body := `{"_id":"drill-1","code":551,"earthquake":{"maxScale":45},"points":[{"pref":"東京都","addr":"千代田区","scale":45}]}`
+	newRouter := func(claims *auth.Claims, publisher EventInjector) http.Handler {
+		return NewRouterWithConfig(RouterConfig{
+			SubscriptionRepo: newMockSubscriptionRepo(),
+			UserRepo:         newMockUserRepo(),
+			TokenVerifier:    &mockTokenVerifier{claims: claims},
+			EventPublisher:   publisher,
+		})
+	}
+	post := func(router http.Handler) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/admin/events", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer valid-token")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("admin publishes", func(t *testing.T) {
+		publisher := &mockInjector{}
+		rec := post(newRouter(&auth.Claims{UID: "admin-1", Admin: true}, publisher))
+		if rec.Code != http.StatusAccepted {
+			t.Fatalf("expected status %d, got %d: %s", http.StatusAccepted, rec.Code, rec.Body.String())
+		}
+		if len(publisher.events) != 1 || publisher.events[0].GetID() != "drill-1" {
+			t.Errorf("expected the drill event to be published, got %+v", publisher.events)
+		}
+	})
+
+	t.Run("rejects non-admin", func(t *testing.T) {
+		publisher := &mockInjector{}
+		rec := post(newRouter(&auth.Claims{UID: "user-1"}, publisher))
+		if rec.Code != http.StatusForbidden {
+			t.Errorf("expected status %d, got %d", http.StatusForbidden, rec.Code)
+		}
+		if len(publisher.events) != 0 {
+			t.Error("expected no event to be published")
+		}
+	})
+
+	t.Run("independent of event injection", func(t *testing.T) {
+		router := newRouter(&auth.Claims{UID: "admin-1", Admin: true}, &mockInjector{})
+		req := httptest.NewRequest(http.MethodPost, "/api/admin/inject-event", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer valid-token")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if rec.Code != http.StatusNotImplemented {
+			t.Errorf("expected status %d, got %d", http.StatusNotImplemented, rec.Code)
+		}
+	})
+
+	t.Run("not configured", func(t *testing.T) {
+		var publisher EventInjector
+		rec := post(newRouter(&auth.Claims{UID: "admin-1", Admin: true}, publisher))
+		if rec.Code != http.StatusNotImplemented {
+			t.Errorf("expected status %d, got %d", http.StatusNotImplemented, rec.Code)
+		}
+	})
+
+	t.Run("invalid message", func(t *testing.T) {
+		router := newRouter(&auth.Claims{UID: "admin-1", Admin: true}, &mockInjector{})
+		req := httptest.NewRequest(http.MethodPost, "/api/admin/events", strings.NewReader(`{"code":551}`))
+		req.Header.Set("Authorization", "Bearer valid-token")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("expected status %d, got %d", http.StatusBadRequest, rec.Code)
+		}
+	})
+}
+
+// mockRoleSetter implements auth.RoleSetter for testing
+type mockRoleSetter struct {
+	roles map[string]string
+	err   error
+}
+
+func (m *mockRoleSetter) SetRole(ctx context.Context, uid, role string) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.roles[uid] = role
+	return nil
+}
+
+func TestAdminHandler_SetUserRole(t *testing.T) {
+	newRouter := func(repo *mockUserRepo, setter auth.RoleSetter) http.Handler {
+		return NewRouterWithConfig(RouterConfig{
+			SubscriptionRepo: newMockSubscriptionRepo(),
+			UserRepo:         repo,
+			TokenVerifier:    &mockTokenVerifier{claims: &auth.Claims{UID: "admin-1", Admin: true}},
+			RoleSetter:       setter,
+		})
+	}
+	put := func(router http.Handler, uid, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPut, "/api/admin/users/"+uid+"/role", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer valid-token")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("grants admin", func(t *testing.T) {
+		repo := newMockUserRepo()
+		repo.Create(context.Background(), user.User{UID: "user-1", Plan: user.PlanFree})
+		setter := &mockRoleSetter{roles: map[string]string{}}
+
+		rec := put(newRouter(repo, setter), "user-1", `{"role":"admin"}`)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+		}
+		if setter.roles["user-1"] != user.RoleAdmin {
+			t.Errorf("expected the role claim to be set, got %v", setter.roles)
+		}
+		if u, _ := repo.GetByUID(context.Background(), "user-1"); !u.IsAdmin() {
+			t.Errorf("expected the stored role to be admin, got %q", u.Role)
+		}
+	})
+
+	t.Run("claim failure leaves the record unchanged", func(t *testing.T) {
+		repo := newMockUserRepo()
+		repo.Create(context.Background(), user.User{UID: "user-1", Plan: user.PlanFree})
+
+		rec := put(newRouter(repo, &mockRoleSetter{err: errors.New("unavailable")}), "user-1", `{"role":"admin"}`)
+		if rec.Code != http.StatusBadGateway {
+			t.Errorf("expected status %d, got %d", http.StatusBadGateway, rec.Code)
+		}
+		if u, _ := repo.GetByUID(context.Background(), "user-1"); u.Role != "" {
+			t.Errorf("expected no stored role, got %q", u.Role)
+		}
+	})
+
+	tests := []struct {
+		name string
+		uid  string
+		body string
+		want int
+	}{
+		{"invalid role", "user-1", `{"role":"owner"}`, http.StatusBadRequest},
+		{"invalid body", "user-1", `{`, http.StatusBadRequest},
+		{"self demotion", "admin-1", `{"role":"user"}`, http.StatusBadRequest},
+		{"unknown user", "user-2", `{"role":"admin"}`, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newMockUserRepo()
+			repo.Create(context.Background(), user.User{UID: "user-1"})
+			repo.Create(context.Background(), user.User{UID: "admin-1", Role: user.RoleAdmin})
+			if rec := put(newRouter(repo, nil), tt.uid, tt.body); rec.Code != tt.want {
+				t.Errorf("expected status %d, got %d: %s", tt.want, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestAdminHandler_GetUser(t *testing.T) {
+	repo := newMockUserRepo()
+	repo.Create(context.Background(), user.User{UID: "user-1", Email: "a@example.com", Role: user.RoleAdmin})
+	router := NewRouterWithConfig(RouterConfig{SubscriptionRepo: newMockSubscriptionRepo(), UserRepo: repo})
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/admin/users/user-1", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rec.Code)
+	}
+	var got user.User
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if got.Email != "a@example.com" || got.Role != user.RoleAdmin {
+		t.Errorf("unexpected user: %+v", got)
+	}
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/admin/users/missing", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected status %d, got %d", http.StatusNotFound, rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/admin/users/user-1/unknown", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected status %d, got %d", http.StatusNotFound, rec.Code)
+	}
+}
+
+func TestParseAdminUserPath(t *testing.T) {
+	tests := []struct {
+		path         string
+		wantUID      string
+		wantResource string
+		wantOK       bool
+	}{
+		{path: "/api/admin/users/user-1/egress", wantUID: "user-1", wantResource: "egress", wantOK: true},
+		{path: "/api/admin/users/user-1", wantOK: false},
+		{path: "/api/admin/users//egress", wantOK: false},
+		{path: "/api/admin/users/user-1/egress/extra", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			uid, resource, ok := parseAdminUserPath(tt.path)
+			if ok != tt.wantOK || uid != tt.wantUID || resource != tt.wantResource {
+				t.Errorf("parseAdminUserPath(%q) = (%q, %q, %v), want (%q, %q, %v)",
+					tt.path, uid, resource, ok, tt.wantUID, tt.wantResource, tt.wantOK)
+			}
+		})
+	}
+}
+
+// mockLifecycleReporter implements LifecycleReporter for testing
+type mockLifecycleReporter struct {
+	report *lifecycle.Report
+	err    error
+}
+
+func (m *mockLifecycleReporter) Report(ctx context.Context) (*lifecycle.Report, error) {
+	return m.report, m.err
+}
+
+func TestAdminHandler_GetLifecycleReport(t *testing.T) {
+	handler := NewAdminHandler()
+	handler.SetLifecycleReporter(&mockLifecycleReporter{report: &lifecycle.Report{
+		InactiveMonths: 6,
+		GraceDays:      14,
+		Subscriptions: []lifecycle.Entry{
+			{SubscriptionID: "sub-1", Name: "Dead", Status: subscription.StatusActive, Action: lifecycle.ActionWarn, ActionReason: subscription.ReasonInactive},
+		},
+	}})
+
+	rec := httptest.NewRecorder()
+	handler.GetLifecycleReport(rec, httptest.NewRequest(http.MethodGet, "/api/admin/lifecycle", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rec.Code)
+	}
+	var resp lifecycle.Report
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if len(resp.Subscriptions) != 1 || resp.Subscriptions[0].Action != lifecycle.ActionWarn {
+		t.Errorf("unexpected report: %+v", resp)
+	}
+}
+
+func TestAdminHandler_GetLifecycleReport_Errors(t *testing.T) {
+	handler := NewAdminHandler()
+	rec := httptest.NewRecorder()
+	handler.GetLifecycleReport(rec, httptest.NewRequest(http.MethodGet, "/api/admin/lifecycle", nil))
+	if rec.Code != http.StatusNotImplemented {
+		t.Errorf("expected status %d, got %d", http.StatusNotImplemented, rec.Code)
+	}
+
+	handler.SetLifecycleReporter(&mockLifecycleReporter{err: errors.New("firestore unavailable")})
+	rec = httptest.NewRecorder()
+	handler.GetLifecycleReport(rec, httptest.NewRequest(http.MethodGet, "/api/admin/lifecycle", nil))
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("expected status %d, got %d", http.StatusInternalServerError, rec.Code)
+	}
+}
+
+func TestAdminHandler_AssignOwner(t *testing.T) {
+	newRouter := func(subRepo *mockSubscriptionRepo) http.Handler {
+		users := newMockUserRepo()
+		users.Create(context.Background(), user.User{UID: "user-1"})
+		users.Create(context.Background(), user.User{UID: "user-2"})
+		return NewRouterWithConfig(RouterConfig{
+			SubscriptionRepo: subRepo,
+			UserRepo:         users,
+			TokenVerifier:    &mockTokenVerifier{claims: &auth.Claims{UID: "admin-1", Admin: true}},
+		})
+	}
+	post := func(router http.Handler, id, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/admin/subscriptions/"+id+"/assign-owner", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer valid-token")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("claims an ownerless subscription", func(t *testing.T) {
+		subRepo := newMockSubscriptionRepo()
+		subRepo.subscriptions["sub-1"] = subscription.Subscription{ID: "sub-1", Name: "Legacy", Status: subscription.StatusSuspended, StatusReason: subscription.ReasonOrphaned}
+		router := newRouter(subRepo)
+
+		rec := post(router, "sub-1", `{"user_id":"user-1"}`)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+		}
+		got := subRepo.subscriptions["sub-1"]
+		if got.UserID != "user-1" {
+			t.Errorf("owner = %q, want user-1", got.UserID)
+		}
+		if got.Status != subscription.StatusSuspended {
+			t.Errorf("status = %q, want still suspended until the owner reactivates it", got.Status)
+		}
+
+		// Repeating the assignment is a no-op
+		if rec := post(router, "sub-1", `{"user_id":"user-1"}`); rec.Code != http.StatusOK {
+			t.Errorf("expected status %d, got %d", http.StatusOK, rec.Code)
+		}
+	})
+
+	tests := []struct {
+		name string
+		id   string
+		body string
+		want int
+	}{
+		{"owned by someone else", "sub-owned", `{"user_id":"user-1"}`, http.StatusConflict},
+		{"unknown user", "sub-1", `{"user_id":"user-3"}`, http.StatusBadRequest},
+		{"missing user", "sub-1", `{}`, http.StatusBadRequest},
+		{"invalid body", "sub-1", `{`, http.StatusBadRequest},
+		{"unknown subscription", "sub-missing", `{"user_id":"user-1"}`, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subRepo := newMockSubscriptionRepo()
+			subRepo.subscriptions["sub-1"] = subscription.Subscription{ID: "sub-1", Name: "Legacy"}
+			subRepo.subscriptions["sub-owned"] = subscription.Subscription{ID: "sub-owned", UserID: "user-2", Name: "Owned"}
+			if rec := post(newRouter(subRepo), tt.id, tt.body); rec.Code != tt.want {
+				t.Errorf("expected status %d, got %d: %s", tt.want, rec.Code, rec.Body.String())
+			}
+			if got := subRepo.subscriptions["sub-1"].UserID; got != "" {
+				t.Errorf("owner = %q, want unchanged", got)
+			}
+		})
+	}
+}
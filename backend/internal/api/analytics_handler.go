@@ -0,0 +1,132 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/otiai10/namazu/backend/internal/analytics"
+	"github.com/otiai10/namazu/backend/internal/apierr"
+)
+
+const (
+	// defaultAnalyticsDays is the number of days returned without from
+	defaultAnalyticsDays = 30
+
+	// maxAnalyticsDays is the longest range that can be requested
+	maxAnalyticsDays = 366
+)
+
+// EventAnalyticsTotals sums the daily rollups of a range
+type EventAnalyticsTotals struct {
+	Events       int64            `json:"events"`
+	MaxScale     int              `json:"max_scale"`
+	ByScale      map[string]int64 `json:"by_scale"`
+	ByPrefecture map[string]int64 `json:"by_prefecture"`
+}
+
+// EventAnalyticsResponse is the response of GET /api/analytics/events.
+// Days has an entry for every date in the range, oldest first, so charts
+// need no gap filling.
+type EventAnalyticsResponse struct {
+	From   string               `json:"from"`
+	To     string               `json:"to"`
+	Days   []analytics.Rollup   `json:"days"`
+	Totals EventAnalyticsTotals `json:"totals"`
+}
+
+// SetRollups serves the analytics rollups at GET /api/analytics/events
+func (h *Handler) SetRollups(repo analytics.Repository) {
+	h.rollups = repo
+}
+
+// GetEventAnalytics handles GET /api/analytics/events
+// The optional from and to query parameters are UTC dates (YYYY-MM-DD),
+// both inclusive; the default is the last 30 days up to today.
+func (h *Handler) GetEventAnalytics(w http.ResponseWriter, r *http.Request) {
+	if h.rollups == nil {
+		writeError(w, "analytics are not enabled", http.StatusNotImplemented)
+		return
+	}
+
+	from, to, msg := parseAnalyticsRange(r, time.Now())
+	if msg != "" {
+		writeErrorCode(w, apierr.ValidationFailed, msg, http.StatusBadRequest)
+		return
+	}
+
+	rollups, err := h.rollups.List(r.Context(), from.Format(analytics.DateLayout), to.Format(analytics.DateLayout))
+	if err != nil {
+		writeError(w, "failed to list analytics", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "public, max-age=60")
+	writeJSON(w, buildEventAnalytics(from, to, rollups), http.StatusOK)
+}
+
+// parseAnalyticsRange returns the requested dates, or a message describing
+// why they are invalid
+func parseAnalyticsRange(r *http.Request, now time.Time) (from, to time.Time, msg string) {
+	params := r.URL.Query()
+	to = now.UTC().Truncate(24 * time.Hour)
+	var err error
+	if s := params.Get("to"); s != "" {
+		if to, err = time.Parse(analytics.DateLayout, s); err != nil {
+			return from, to, "to must be a date (YYYY-MM-DD)"
+		}
+	}
+	from = to.AddDate(0, 0, -(defaultAnalyticsDays - 1))
+	if s := params.Get("from"); s != "" {
+		if from, err = time.Parse(analytics.DateLayout, s); err != nil {
+			return from, to, "from must be a date (YYYY-MM-DD)"
+		}
+	}
+
+	if from.After(to) {
+		return from, to, "from must not be after to"
+	}
+	if to.Sub(from) >= maxAnalyticsDays*24*time.Hour {
+		return from, to, "the range must not exceed 366 days"
+	}
+	return from, to, ""
+}
+
+// buildEventAnalytics fills the days without events and sums the range
+func buildEventAnalytics(from, to time.Time, rollups []analytics.Rollup) EventAnalyticsResponse {
+	byDate := make(map[string]analytics.Rollup, len(rollups))
+	for _, rollup := range rollups {
+		byDate[rollup.Date] = rollup
+	}
+
+	resp := EventAnalyticsResponse{
+		From:   from.Format(analytics.DateLayout),
+		To:     to.Format(analytics.DateLayout),
+		Days:   []analytics.Rollup{},
+		Totals: EventAnalyticsTotals{ByScale: map[string]int64{}, ByPrefecture: map[string]int64{}},
+	}
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		date := day.Format(analytics.DateLayout)
+		rollup, ok := byDate[date]
+		if !ok {
+			rollup = analytics.Rollup{Date: date}
+		}
+		// Firestore omits the maps of days whose events had no scale or areas
+		if rollup.ByScale == nil {
+			rollup.ByScale = map[string]int64{}
+		}
+		if rollup.ByPrefecture == nil {
+			rollup.ByPrefecture = map[string]int64{}
+		}
+		resp.Days = append(resp.Days, rollup)
+
+		resp.Totals.Events += rollup.Events
+		resp.Totals.MaxScale = max(resp.Totals.MaxScale, rollup.MaxScale)
+		for scale, n := range rollup.ByScale {
+			resp.Totals.ByScale[scale] += n
+		}
+		for prefecture, n := range rollup.ByPrefecture {
+			resp.Totals.ByPrefecture[prefecture] += n
+		}
+	}
+	return resp
+}
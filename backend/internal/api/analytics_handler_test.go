@@ -0,0 +1,117 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/otiai10/namazu/backend/internal/analytics"
+)
+
+func TestRouter_EventAnalytics(t *testing.T) {
+	ctx := context.Background()
+	rollups := analytics.NewMemoryRepository()
+	for _, add := range []struct {
+		date string
+		d    analytics.Delta
+	}{
+		{"2024-01-01", analytics.Delta{Scale: 70, Prefectures: []string{"石川県", "富山県"}}},
+		{"2024-01-01", analytics.Delta{Scale: 30, Prefectures: []string{"石川県"}}},
+		{"2024-01-03", analytics.Delta{Scale: 30, Prefectures: []string{"千葉県"}}},
+		{"2024-01-05", analytics.Delta{Scale: 10}}, // Outside the range
+	} {
+		if err := rollups.Add(ctx, add.date, add.d); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	request := func(repo analytics.Repository, query string) *httptest.ResponseRecorder {
+		router := NewRouterWithConfig(RouterConfig{
+			SubscriptionRepo: newMockSubscriptionRepo(),
+			EventRepo:        newMockEventRepo(),
+			Rollups:          repo,
+		})
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/analytics/events"+query, nil))
+		return rec
+	}
+
+	rec := request(rollups, "?from=2024-01-01&to=2024-01-04")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+	}
+	var resp EventAnalyticsResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.From != "2024-01-01" || resp.To != "2024-01-04" || len(resp.Days) != 4 {
+		t.Fatalf("response = %+v, want 4 days", resp)
+	}
+	if resp.Days[0].Events != 2 || resp.Days[0].MaxScale != 70 || resp.Days[1].Date != "2024-01-02" || resp.Days[1].Events != 0 {
+		t.Errorf("days = %+v, want days without events filled", resp.Days)
+	}
+	totals := resp.Totals
+	if totals.Events != 3 || totals.MaxScale != 70 || totals.ByScale["30"] != 2 || totals.ByPrefecture["石川県"] != 2 || totals.ByPrefecture["千葉県"] != 1 {
+		t.Errorf("totals = %+v", totals)
+	}
+	if got := rec.Header().Get("Cache-Control"); got != "public, max-age=60" {
+		t.Errorf("Cache-Control = %q", got)
+	}
+
+	if rec := request(nil, ""); rec.Code != http.StatusNotImplemented {
+		t.Errorf("expected status %d without rollups, got %d", http.StatusNotImplemented, rec.Code)
+	}
+}
+
+func TestRouter_EventAnalytics_DefaultRange(t *testing.T) {
+	router := NewRouterWithConfig(RouterConfig{
+		SubscriptionRepo: newMockSubscriptionRepo(),
+		EventRepo:        newMockEventRepo(),
+		Rollups:          analytics.NewMemoryRepository(),
+	})
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/analytics/events", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+	}
+	var resp EventAnalyticsResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(resp.Days) != defaultAnalyticsDays || resp.To != analytics.Date(time.Now()) {
+		t.Errorf("from = %s, to = %s, days = %d, want the last %d days", resp.From, resp.To, len(resp.Days), defaultAnalyticsDays)
+	}
+}
+
+func TestParseAnalyticsRange(t *testing.T) {
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		query    string
+		from, to string
+		wantErr  bool
+	}{
+		{query: "", from: "2024-02-10", to: "2024-03-10"},
+		{query: "to=2024-01-31", from: "2024-01-02", to: "2024-01-31"},
+		{query: "from=2023-03-11&to=2024-03-10", from: "2023-03-11", to: "2024-03-10"},
+		{query: "from=2023-03-09&to=2024-03-10", wantErr: true},
+		{query: "from=2024-03-11&to=2024-03-10", wantErr: true},
+		{query: "from=2024/03/01", wantErr: true},
+		{query: "to=yesterday", wantErr: true},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/api/analytics/events?"+tt.query, nil)
+		from, to, msg := parseAnalyticsRange(r, now)
+		if tt.wantErr {
+			if msg == "" {
+				t.Errorf("%q: expected an error", tt.query)
+			}
+			continue
+		}
+		if msg != "" || from.Format(analytics.DateLayout) != tt.from || to.Format(analytics.DateLayout) != tt.to {
+			t.Errorf("%q: got %s..%s (%q), want %s..%s", tt.query, from.Format(analytics.DateLayout), to.Format(analytics.DateLayout), msg, tt.from, tt.to)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/otiai10/namazu/backend/internal/apierr"
+	"github.com/otiai10/namazu/backend/internal/audit"
+	"github.com/otiai10/namazu/backend/internal/auth"
+	"github.com/otiai10/namazu/backend/internal/tenant"
+)
+
+// SetAuditLog records subscription changes and secret rotations
+func (h *Handler) SetAuditLog(l *audit.Logger) {
+	h.auditLog = l
+}
+
+// SetAuditLog records login provider links and account deletions
+func (h *MeHandler) SetAuditLog(l *audit.Logger) {
+	h.auditLog = l
+}
+
+// SetAuditLog records plan changes made by Stripe webhooks
+func (h *BillingHandler) SetAuditLog(l *audit.Logger) {
+	h.auditLog = l
+}
+
+// SetAuditLog records admin actions and enables GET /api/admin/audit
+func (h *AdminHandler) SetAuditLog(l *audit.Logger) {
+	h.auditLog = l
+}
+
+// auditEntry returns an audit entry for a change made by the request,
+// attributed to the signed-in user
+func auditEntry(r *http.Request, action, targetID string, details map[string]string) audit.Entry {
+	e := audit.Entry{
+		Action:    action,
+		TargetID:  targetID,
+		TenantID:  tenant.FromContext(r.Context()).ID,
+		IP:        extractClientIP(r),
+		UserAgent: r.UserAgent(),
+		Details:   details,
+	}
+	if claims, ok := auth.GetClaims(r.Context()); ok {
+		e.ActorUID = claims.UID
+	}
+	return e
+}
+
+// ListAuditLog handles GET /api/admin/audit?actor=&action=&target=&from=&to=&limit=
+// Returns the matching audit entries, newest first. To page back, pass the
+// created_at of the oldest entry as to.
+func (h *AdminHandler) ListAuditLog(w http.ResponseWriter, r *http.Request) {
+	if h.auditLog == nil {
+		writeError(w, "audit log is not enabled", http.StatusNotImplemented)
+		return
+	}
+
+	q := r.URL.Query()
+	f := audit.Filter{
+		ActorUID: q.Get("actor"),
+		Action:   q.Get("action"),
+		TargetID: q.Get("target"),
+	}
+	for _, p := range []struct {
+		name string
+		dst  *time.Time
+	}{{"from", &f.From}, {"to", &f.To}} {
+		if v := q.Get(p.name); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				writeErrorCode(w, apierr.ValidationFailed, p.name+" must be an RFC3339 time", http.StatusBadRequest)
+				return
+			}
+			*p.dst = t
+		}
+	}
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > audit.MaxLimit {
+			writeErrorCode(w, apierr.ValidationFailed, "limit must be between 1 and 500", http.StatusBadRequest)
+			return
+		}
+		f.Limit = n
+	}
+
+	entries, err := h.auditLog.List(r.Context(), f)
+	if err != nil {
+		writeError(w, "failed to list audit log", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, entries, http.StatusOK)
+}
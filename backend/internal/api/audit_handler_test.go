@@ -0,0 +1,186 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/otiai10/namazu/backend/internal/audit"
+	"github.com/otiai10/namazu/backend/internal/auth"
+	"github.com/otiai10/namazu/backend/internal/user"
+)
+
+// auditedRequest returns a request by uid from a proxied client
+func auditedRequest(method, target, body, uid string) *http.Request {
+	req := httptest.NewRequest(method, target, bytes.NewBufferString(body))
+	req.Header.Set("X-Forwarded-For", "203.0.113.7, 10.0.0.1")
+	req.Header.Set("User-Agent", "namazu-cli/1.0")
+	return req.WithContext(auth.WithClaims(req.Context(), &auth.Claims{UID: uid}))
+}
+
+func TestHandler_AuditsSubscriptionChanges(t *testing.T) {
+	subRepo := newMockSubscriptionRepo()
+	auditRepo := audit.NewMemoryRepository()
+	handler := NewHandler(subRepo, newMockEventRepo())
+	handler.SetAuditLog(audit.NewLogger(auditRepo))
+
+	rec := httptest.NewRecorder()
+	handler.CreateSubscription(rec, auditedRequest(http.MethodPost, "/api/subscriptions",
+		`{"name": "Hook", "delivery": {"type": "webhook", "url": "https://example.com/hook"}}`, "user-1"))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, rec.Code, rec.Body.String())
+	}
+	var created SubscriptionResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &created); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	path := "/api/subscriptions/" + created.ID
+
+	// An update that changes nothing is not recorded
+	for _, name := range []string{"Hook", "Renamed"} {
+		rec = httptest.NewRecorder()
+		handler.UpdateSubscription(rec, withIfMatch(auditedRequest(http.MethodPut, path,
+			`{"name": "`+name+`", "delivery": {"type": "webhook", "url": "https://example.com/hook"}}`, "user-1"), subscriptionETag(subRepo.subscriptions[created.ID])))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+		}
+	}
+	rec = httptest.NewRecorder()
+	handler.RotateSecret(rec, auditedRequest(http.MethodPost, path+"/rotate-secret", "", "user-1"), created.ID)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+	}
+	// Forbidden changes are not recorded
+	rec = httptest.NewRecorder()
+	handler.DeleteSubscription(rec, auditedRequest(http.MethodDelete, path, "", "user-2"))
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("expected status %d, got %d", http.StatusForbidden, rec.Code)
+	}
+	rec = httptest.NewRecorder()
+	handler.DeleteSubscription(rec, auditedRequest(http.MethodDelete, path, "", "user-1"))
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusNoContent, rec.Code, rec.Body.String())
+	}
+
+	entries, _ := auditRepo.List(context.Background(), audit.Filter{})
+	want := []string{audit.ActionSubscriptionDelete, audit.ActionSecretRotate, audit.ActionSubscriptionUpdate, audit.ActionSubscriptionCreate}
+	if len(entries) != len(want) {
+		t.Fatalf("audit entries = %+v, want %v", entries, want)
+	}
+	for i, e := range entries {
+		if e.Action != want[i] || e.ActorUID != "user-1" || e.TargetID != created.ID || e.IP != "203.0.113.7" || e.UserAgent != "namazu-cli/1.0" {
+			t.Errorf("entries[%d] = %+v, want %s by user-1 from 203.0.113.7", i, e, want[i])
+		}
+	}
+	if entries[2].Details["name"] != "Renamed" {
+		t.Errorf("update details = %v, want the new name", entries[2].Details)
+	}
+}
+
+func TestMeHandler_AuditsProviderLink(t *testing.T) {
+	auditRepo := audit.NewMemoryRepository()
+	h := NewMeHandler(newMockUserRepo())
+	h.SetAuditLog(audit.NewLogger(auditRepo))
+
+	// Only the first login creates the user and links its provider
+	for i := 0; i < 2; i++ {
+		rec := httptest.NewRecorder()
+		h.GetProfile(rec, withUser(httptest.NewRequest(http.MethodGet, "/api/me", nil), "uid-1"))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, rec.Code)
+		}
+	}
+
+	entries, _ := auditRepo.List(context.Background(), audit.Filter{})
+	if len(entries) != 1 || entries[0].Action != audit.ActionProviderLink || entries[0].TargetID != "uid-1" ||
+		entries[0].Details["provider"] != user.ProviderGoogle {
+		t.Errorf("audit entries = %+v, want one provider link", entries)
+	}
+}
+
+func TestAdminHandler_ListAuditLog(t *testing.T) {
+	ctx := context.Background()
+	auditRepo := audit.NewMemoryRepository()
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, e := range []audit.Entry{
+		{Action: audit.ActionSubscriptionCreate, ActorUID: "user-1", TargetID: "sub-1"},
+		{Action: audit.ActionAdminSetRole, ActorUID: "admin", TargetID: "user-1"},
+		{Action: audit.ActionSubscriptionDelete, ActorUID: "user-1", TargetID: "sub-1"},
+	} {
+		e.CreatedAt = base.Add(time.Duration(i) * time.Hour)
+		if _, err := auditRepo.Append(ctx, e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	h := NewAdminHandler()
+	h.SetAuditLog(audit.NewLogger(auditRepo))
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"", []string{audit.ActionSubscriptionDelete, audit.ActionAdminSetRole, audit.ActionSubscriptionCreate}},
+		{"?actor=user-1", []string{audit.ActionSubscriptionDelete, audit.ActionSubscriptionCreate}},
+		{"?target=user-1", []string{audit.ActionAdminSetRole}},
+		{"?action=subscription.create", []string{audit.ActionSubscriptionCreate}},
+		{"?to=2026-01-01T02:00:00Z&limit=1", []string{audit.ActionAdminSetRole}},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		h.ListAuditLog(rec, httptest.NewRequest(http.MethodGet, "/api/admin/audit"+tt.query, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: expected status %d, got %d: %s", tt.query, http.StatusOK, rec.Code, rec.Body.String())
+		}
+		var entries []audit.Entry
+		if err := json.Unmarshal(rec.Body.Bytes(), &entries); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		var got []string
+		for _, e := range entries {
+			got = append(got, e.Action)
+		}
+		if len(got) != len(tt.want) || (len(got) > 0 && got[0] != tt.want[0]) || (len(got) > 1 && got[1] != tt.want[1]) {
+			t.Errorf("%s: actions = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+
+	for _, query := range []string{"?from=yesterday", "?limit=0", "?limit=501"} {
+		rec := httptest.NewRecorder()
+		h.ListAuditLog(rec, httptest.NewRequest(http.MethodGet, "/api/admin/audit"+query, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status %d, got %d", query, http.StatusBadRequest, rec.Code)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	NewAdminHandler().ListAuditLog(rec, httptest.NewRequest(http.MethodGet, "/api/admin/audit", nil))
+	if rec.Code != http.StatusNotImplemented {
+		t.Errorf("expected status %d without an audit log, got %d", http.StatusNotImplemented, rec.Code)
+	}
+}
+
+func TestAdminHandler_AuditsRoleChange(t *testing.T) {
+	users := newMockUserRepo()
+	users.users["user-1"] = &user.User{ID: "user-1", UID: "uid-1", Role: user.RoleUser}
+	users.uidIndex["uid-1"] = "user-1"
+	auditRepo := audit.NewMemoryRepository()
+	h := NewAdminHandler()
+	h.SetUserRepo(users)
+	h.SetAuditLog(audit.NewLogger(auditRepo))
+
+	rec := httptest.NewRecorder()
+	h.SetUserRole(rec, auditedRequest(http.MethodPut, "/api/admin/users/uid-1/role", `{"role": "admin"}`, "admin-1"), "uid-1")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+	}
+
+	entries, _ := auditRepo.List(context.Background(), audit.Filter{})
+	if len(entries) != 1 || entries[0].Action != audit.ActionAdminSetRole || entries[0].ActorUID != "admin-1" ||
+		entries[0].TargetID != "uid-1" || entries[0].Details["to"] != user.RoleAdmin {
+		t.Errorf("audit entries = %+v, want the role change by admin-1", entries)
+	}
+}
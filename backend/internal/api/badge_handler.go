@@ -0,0 +1,123 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/otiai10/namazu/backend/internal/badge"
+	"github.com/otiai10/namazu/backend/internal/delivery"
+	"github.com/otiai10/namazu/backend/internal/subscription"
+	"github.com/otiai10/namazu/backend/internal/tenant"
+)
+
+// badgePrefix is the path prefix of public badge routes
+const badgePrefix = "/api/badge/"
+
+// HealthReporter reports the recent delivery health of a subscription
+type HealthReporter interface {
+	Status(subscriptionID string) delivery.HealthStatus
+}
+
+// BadgeResponse represents the badge URLs issued for a subscription
+type BadgeResponse struct {
+	Token   string `json:"token"`
+	SVGURL  string `json:"svg_url"`
+	JSONURL string `json:"json_url"`
+}
+
+// BadgeHandler serves public subscription health badges.
+// Badges are addressed by signed tokens, so no authentication is required
+// and the subscription ID is never accepted directly.
+type BadgeHandler struct {
+	signer  *badge.Signer
+	health  HealthReporter
+	subRepo subscription.Repository
+}
+
+// NewBadgeHandler creates a new BadgeHandler
+func NewBadgeHandler(signer *badge.Signer, health HealthReporter, subRepo subscription.Repository) *BadgeHandler {
+	return &BadgeHandler{
+		signer:  signer,
+		health:  health,
+		subRepo: subRepo,
+	}
+}
+
+// ServeBadge handles GET /api/badge/{token}.svg and GET /api/badge/{token}.json
+// The JSON variant follows the shields.io endpoint badge schema.
+func (h *BadgeHandler) ServeBadge(w http.ResponseWriter, r *http.Request) {
+	// Tokens contain a dot themselves; the extension follows the last one
+	name := strings.TrimPrefix(r.URL.Path, badgePrefix)
+	idx := strings.LastIndex(name, ".")
+	if idx < 0 {
+		writeError(w, "not found", http.StatusNotFound)
+		return
+	}
+	token, format := name[:idx], name[idx+1:]
+	if format != "svg" && format != "json" {
+		writeError(w, "not found", http.StatusNotFound)
+		return
+	}
+
+	id, err := h.signer.Verify(token)
+	if err != nil {
+		writeError(w, "not found", http.StatusNotFound)
+		return
+	}
+
+	sub, err := h.subRepo.Get(r.Context(), id)
+	if err != nil {
+		writeError(w, "failed to get badge", http.StatusInternalServerError)
+		return
+	}
+	// Badges are only served under the subscription's own tenant domain
+	if sub == nil || sub.TenantID != tenant.FromContext(r.Context()).ID {
+		writeError(w, "not found", http.StatusNotFound)
+		return
+	}
+
+	state := delivery.HealthUnknown
+	if h.health != nil {
+		state = h.health.Status(id).State
+	}
+	color := badgeColor(state)
+
+	// Badges are cheap to render but embedded widely; allow short caching
+	w.Header().Set("Cache-Control", "public, max-age=60")
+
+	if format == "json" {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(badge.NewEndpoint(badge.DefaultLabel, state, color))
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/svg+xml")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(badge.SVG(badge.DefaultLabel, state, color))
+}
+
+// badgeColor maps a health state to a badge color
+func badgeColor(state string) string {
+	switch state {
+	case delivery.HealthOperational:
+		return badge.ColorGreen
+	case delivery.HealthDegraded:
+		return badge.ColorYellow
+	case delivery.HealthFailing:
+		return badge.ColorRed
+	default:
+		return badge.ColorGrey
+	}
+}
+
+// newBadgeResponse builds the badge URLs for a subscription
+func newBadgeResponse(signer *badge.Signer, subscriptionID string) BadgeResponse {
+	token := signer.Token(subscriptionID)
+	return BadgeResponse{
+		Token:   token,
+		SVGURL:  badgePrefix + token + ".svg",
+		JSONURL: badgePrefix + token + ".json",
+	}
+}
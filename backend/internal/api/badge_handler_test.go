@@ -0,0 +1,161 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/otiai10/namazu/backend/internal/auth"
+	"github.com/otiai10/namazu/backend/internal/badge"
+	"github.com/otiai10/namazu/backend/internal/delivery"
+	"github.com/otiai10/namazu/backend/internal/subscription"
+)
+
+func newBadgeTestRouter(subRepo *mockSubscriptionRepo, health HealthReporter) (http.Handler, *badge.Signer) {
+	signer := badge.NewSigner("test-secret")
+	router := NewRouterWithConfig(RouterConfig{
+		SubscriptionRepo: subRepo,
+		EventRepo:        newMockEventRepo(),
+		BadgeSigner:      signer,
+		HealthReporter:   health,
+	})
+	return router, signer
+}
+
+func TestServeBadge_JSON(t *testing.T) {
+	subRepo := newMockSubscriptionRepo()
+	subRepo.subscriptions["badge-sub"] = subscription.Subscription{ID: "badge-sub", Name: "Status"}
+
+	tracker := delivery.NewHealthTracker(0)
+	tracker.Record("badge-sub", true, time.Now())
+	router, signer := newBadgeTestRouter(subRepo, tracker)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/badge/"+signer.Token("badge-sub")+".json", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+	}
+
+	var got badge.Endpoint
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if got.SchemaVersion != 1 || got.Label != badge.DefaultLabel {
+		t.Errorf("unexpected endpoint payload: %+v", got)
+	}
+	if got.Message != delivery.HealthOperational || got.Color != badge.ColorGreen {
+		t.Errorf("expected operational/green, got %s/%s", got.Message, got.Color)
+	}
+}
+
+func TestServeBadge_SVG(t *testing.T) {
+	subRepo := newMockSubscriptionRepo()
+	subRepo.subscriptions["badge-sub"] = subscription.Subscription{ID: "badge-sub", Name: "Status"}
+	router, signer := newBadgeTestRouter(subRepo, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/badge/"+signer.Token("badge-sub")+".svg", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "image/svg+xml" {
+		t.Errorf("expected svg content type, got %q", ct)
+	}
+	if !strings.Contains(rec.Body.String(), delivery.HealthUnknown) {
+		t.Error("expected unknown state without health reporter")
+	}
+}
+
+func TestServeBadge_NotFound(t *testing.T) {
+	subRepo := newMockSubscriptionRepo()
+	subRepo.subscriptions["badge-sub"] = subscription.Subscription{ID: "badge-sub"}
+	router, signer := newBadgeTestRouter(subRepo, nil)
+
+	tests := []struct {
+		name string
+		path string
+	}{
+		{name: "forged token", path: "/api/badge/" + badge.NewSigner("other").Token("badge-sub") + ".svg"},
+		{name: "unknown format", path: "/api/badge/" + signer.Token("badge-sub") + ".png"},
+		{name: "no extension", path: "/api/badge/abc"},
+		{name: "deleted subscription", path: "/api/badge/" + signer.Token("deleted-sub") + ".json"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if rec.Code != http.StatusNotFound {
+				t.Errorf("expected status %d, got %d", http.StatusNotFound, rec.Code)
+			}
+		})
+	}
+}
+
+func TestServeBadge_DisabledWithoutSigner(t *testing.T) {
+	router := NewRouterWithConfig(RouterConfig{
+		SubscriptionRepo: newMockSubscriptionRepo(),
+		EventRepo:        newMockEventRepo(),
+	})
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/badge/abc.def.svg", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected status %d, got %d", http.StatusNotFound, rec.Code)
+	}
+}
+
+func TestGetSubscriptionBadge(t *testing.T) {
+	subRepo := newMockSubscriptionRepo()
+	subRepo.subscriptions["badge-sub"] = subscription.Subscription{ID: "badge-sub", UserID: "owner-uid"}
+	router, signer := newBadgeTestRouter(subRepo, nil)
+
+	t.Run("owner receives badge URLs", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/subscriptions/badge-sub/badge", nil)
+		req = req.WithContext(auth.WithClaims(req.Context(), &auth.Claims{UID: "owner-uid"}))
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+		}
+		var got BadgeResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+			t.Fatalf("failed to unmarshal response: %v", err)
+		}
+		if got.Token != signer.Token("badge-sub") {
+			t.Errorf("unexpected token %q", got.Token)
+		}
+		if got.SVGURL != "/api/badge/"+got.Token+".svg" || got.JSONURL != "/api/badge/"+got.Token+".json" {
+			t.Errorf("unexpected URLs: %+v", got)
+		}
+	})
+
+	t.Run("other users are forbidden", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/subscriptions/badge-sub/badge", nil)
+		req = req.WithContext(auth.WithClaims(req.Context(), &auth.Claims{UID: "other-uid"}))
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusForbidden {
+			t.Errorf("expected status %d, got %d", http.StatusForbidden, rec.Code)
+		}
+	})
+
+	t.Run("not implemented without signer", func(t *testing.T) {
+		h := NewHandler(subRepo, newMockEventRepo())
+		rec := httptest.NewRecorder()
+		h.GetSubscriptionBadge(rec, httptest.NewRequest(http.MethodGet, "/api/subscriptions/badge-sub/badge", nil), "badge-sub")
+
+		if rec.Code != http.StatusNotImplemented {
+			t.Errorf("expected status %d, got %d", http.StatusNotImplemented, rec.Code)
+		}
+	})
+}
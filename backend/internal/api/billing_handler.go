@@ -3,13 +3,19 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"io"
+	"log"
 	"net/http"
+	"net/url"
 	"time"
 
+	"github.com/otiai10/namazu/backend/internal/apierr"
+	"github.com/otiai10/namazu/backend/internal/audit"
 	"github.com/otiai10/namazu/backend/internal/auth"
 	"github.com/otiai10/namazu/backend/internal/billing"
 	"github.com/otiai10/namazu/backend/internal/config"
+	"github.com/otiai10/namazu/backend/internal/tenant"
 	"github.com/otiai10/namazu/backend/internal/user"
 	"github.com/stripe/stripe-go/v78"
 )
@@ -19,6 +25,17 @@ type BillingHandler struct {
 	client   *billing.Client
 	userRepo BillingUserRepository
 	config   *config.BillingConfig
+
+	idempotency *Idempotency
+	enforcer    QuotaEnforcer    // nil leaves subscriptions untouched on plan changes
+	tenants     *tenant.Registry // nil maps Stripe prices with the default plan catalog only
+	auditLog    *audit.Logger    // nil disables audit records
+}
+
+// QuotaEnforcer brings a user's subscriptions within a plan's limit
+// (implemented by *quota.Enforcer)
+type QuotaEnforcer interface {
+	Enforce(ctx context.Context, userID, plan string) error
 }
 
 // BillingUserRepository defines the user repository interface needed by billing
@@ -35,15 +52,30 @@ type BillingStatusResponse struct {
 	HasActiveSubscription bool       `json:"hasActiveSubscription"`
 	SubscriptionStatus    string     `json:"subscriptionStatus,omitempty"`
 	SubscriptionEndsAt    *time.Time `json:"subscriptionEndsAt,omitempty"`
+	CancelAtPeriodEnd     bool       `json:"cancelAtPeriodEnd,omitempty"` // Downgrades to free at SubscriptionEndsAt
+	BillingInterval       string     `json:"billingInterval,omitempty"`   // "month" | "year"
+	RenewsAt              *time.Time `json:"renewsAt,omitempty"`          // Next renewal; omitted when the subscription will not renew
 	StripeCustomerID      string     `json:"stripeCustomerId,omitempty"`
 }
 
+// CheckoutSessionRequest is the optional body of POST /api/billing/create-checkout-session
+type CheckoutSessionRequest struct {
+	Plan          string `json:"plan,omitempty"`           // Plan to purchase from the tenant's catalog; defaults to pro
+	Interval      string `json:"interval,omitempty"`       // "month" (default) | "year"
+	PromotionCode string `json:"promotion_code,omitempty"` // Code to apply; without one, codes can be entered at checkout
+}
+
 // CheckoutSessionResponse represents the response for creating checkout session
 type CheckoutSessionResponse struct {
 	SessionID  string `json:"sessionId"`
 	SessionURL string `json:"sessionUrl"`
 }
 
+// PortalSessionRequest is the optional body of POST /api/billing/create-portal-session
+type PortalSessionRequest struct {
+	ReturnURL string `json:"return_url,omitempty"` // Where the portal links back to; defaults to the checkout success URL
+}
+
 // PortalSessionResponse represents the response for creating portal session
 type PortalSessionResponse struct {
 	URL string `json:"url"`
@@ -58,6 +90,22 @@ func NewBillingHandler(client *billing.Client, userRepo BillingUserRepository, c
 	}
 }
 
+// SetIdempotency enables Idempotency-Key support on checkout session creation
+func (h *BillingHandler) SetIdempotency(i *Idempotency) {
+	h.idempotency = i
+}
+
+// SetQuotaEnforcer enables suspending subscriptions over the limit after a
+// downgrade, and reactivating them after an upgrade
+func (h *BillingHandler) SetQuotaEnforcer(e QuotaEnforcer) {
+	h.enforcer = e
+}
+
+// SetTenants enables mapping Stripe prices to the plans of every tenant's catalog
+func (h *BillingHandler) SetTenants(reg *tenant.Registry) {
+	h.tenants = reg
+}
+
 // GetStatus handles GET /api/billing/status
 // Returns the current user's billing/plan status
 func (h *BillingHandler) GetStatus(w http.ResponseWriter, r *http.Request) {
@@ -77,21 +125,48 @@ func (h *BillingHandler) GetStatus(w http.ResponseWriter, r *http.Request) {
 		Plan:                  u.Plan,
 		HasActiveSubscription: u.SubscriptionStatus == user.SubscriptionStatusActive,
 		SubscriptionStatus:    u.SubscriptionStatus,
+		CancelAtPeriodEnd:     u.CancelAtPeriodEnd,
+		BillingInterval:       u.BillingInterval,
 		StripeCustomerID:      u.StripeCustomerID,
 	}
 
 	if !u.SubscriptionEndsAt.IsZero() {
 		response.SubscriptionEndsAt = &u.SubscriptionEndsAt
+		if renews(u) {
+			response.RenewsAt = &u.SubscriptionEndsAt
+		}
 	}
 
 	writeJSON(w, response, http.StatusOK)
 }
 
 // CreateCheckoutSession handles POST /api/billing/create-checkout-session
-// Creates a Stripe Checkout session for Pro upgrade
+// Creates a Stripe Checkout session for a paid plan of the tenant's catalog
 func (h *BillingHandler) CreateCheckoutSession(w http.ResponseWriter, r *http.Request) {
 	claims := auth.MustGetClaims(r.Context())
 
+	var req CheckoutSessionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.Plan == "" {
+		req.Plan = user.PlanPro
+	}
+	switch req.Interval {
+	case "":
+		req.Interval = billing.IntervalMonth
+	case billing.IntervalMonth, billing.IntervalYear:
+	default:
+		writeErrorCode(w, apierr.ValidationFailed, "interval must be month or year", http.StatusBadRequest)
+		return
+	}
+	priceID, ok := h.checkoutPrice(r.Context(), req.Plan, req.Interval)
+	if !ok {
+		writeErrorCode(w, apierr.PlanNotForSale, "plan is not available for purchase with this interval", http.StatusBadRequest)
+		return
+	}
+
 	u, err := h.userRepo.GetByUID(r.Context(), claims.UID)
 	if err != nil {
 		writeError(w, "failed to get user", http.StatusInternalServerError)
@@ -104,7 +179,7 @@ func (h *BillingHandler) CreateCheckoutSession(w http.ResponseWriter, r *http.Re
 
 	// Check if user already has an active subscription
 	if u.SubscriptionStatus == user.SubscriptionStatusActive {
-		writeError(w, "user already has an active subscription", http.StatusBadRequest)
+		writeErrorCode(w, apierr.AlreadySubscribed, "user already has an active subscription", http.StatusBadRequest)
 		return
 	}
 
@@ -126,14 +201,28 @@ func (h *BillingHandler) CreateCheckoutSession(w http.ResponseWriter, r *http.Re
 		}
 	}
 
-	// Create checkout session
-	session, err := h.client.CreateCheckoutSession(
-		r.Context(),
-		customerID,
-		h.config.PriceID,
-		h.config.SuccessURL,
-		h.config.CancelURL,
-	)
+	var promotionCodeID string
+	if req.PromotionCode != "" {
+		promotionCodeID, err = h.client.FindPromotionCode(r.Context(), req.PromotionCode)
+		if errors.Is(err, billing.ErrPromotionCodeNotFound) {
+			writeErrorCode(w, apierr.InvalidPromotionCode, "invalid or expired promotion code", http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			writeError(w, "failed to look up promotion code", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	session, err := h.client.CreateCheckoutSession(r.Context(), billing.CheckoutRequest{
+		CustomerID:      customerID,
+		PriceID:         priceID,
+		PlanID:          req.Plan,
+		Interval:        req.Interval,
+		PromotionCodeID: promotionCodeID,
+		SuccessURL:      h.config.SuccessURL,
+		CancelURL:       h.config.CancelURL,
+	})
 	if err != nil {
 		writeError(w, "failed to create checkout session", http.StatusInternalServerError)
 		return
@@ -147,9 +236,33 @@ func (h *BillingHandler) CreateCheckoutSession(w http.ResponseWriter, r *http.Re
 	writeJSON(w, response, http.StatusOK)
 }
 
-// GetPortalSession handles GET /api/billing/portal-session
-// Returns a Stripe Customer Portal URL
+// CreatePortalSession handles POST /api/billing/create-portal-session
+// Creates a Stripe Billing Portal session, where the user updates cards, views
+// invoices and cancels, and returns its URL
+func (h *BillingHandler) CreatePortalSession(w http.ResponseWriter, r *http.Request) {
+	var req PortalSessionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.ReturnURL != "" {
+		if u, err := url.Parse(req.ReturnURL); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			writeErrorCode(w, apierr.ValidationFailed, "return_url must be an absolute http(s) URL", http.StatusBadRequest)
+			return
+		}
+	}
+
+	h.portalSession(w, r, req.ReturnURL)
+}
+
+// GetPortalSession handles GET /api/billing/portal-session?return_url=
+// Returns a Stripe Customer Portal URL. Kept for older clients; see CreatePortalSession.
 func (h *BillingHandler) GetPortalSession(w http.ResponseWriter, r *http.Request) {
+	h.portalSession(w, r, r.URL.Query().Get("return_url"))
+}
+
+// portalSession creates a portal session for the current user's Stripe customer
+func (h *BillingHandler) portalSession(w http.ResponseWriter, r *http.Request, returnURL string) {
 	claims := auth.MustGetClaims(r.Context())
 
 	u, err := h.userRepo.GetByUID(r.Context(), claims.UID)
@@ -164,12 +277,11 @@ func (h *BillingHandler) GetPortalSession(w http.ResponseWriter, r *http.Request
 
 	// Check if user has a Stripe customer ID
 	if u.StripeCustomerID == "" {
-		writeError(w, "user has no Stripe customer ID", http.StatusBadRequest)
+		writeErrorCode(w, apierr.NoBillingCustomer, "user has no Stripe customer ID", http.StatusBadRequest)
 		return
 	}
 
 	// Create portal session
-	returnURL := r.URL.Query().Get("return_url")
 	if returnURL == "" {
 		returnURL = h.config.SuccessURL
 	}
@@ -200,14 +312,14 @@ func (h *BillingHandler) StripeWebhook(w http.ResponseWriter, r *http.Request) {
 	// Get signature header
 	signature := r.Header.Get("Stripe-Signature")
 	if signature == "" {
-		writeError(w, "missing Stripe-Signature header", http.StatusBadRequest)
+		writeErrorCode(w, apierr.InvalidSignature, "missing Stripe-Signature header", http.StatusBadRequest)
 		return
 	}
 
 	// Verify signature
 	event, err := billing.VerifyWebhookSignature(body, signature, h.config.WebhookSecret)
 	if err != nil {
-		writeError(w, "invalid webhook signature", http.StatusBadRequest)
+		writeErrorCode(w, apierr.InvalidSignature, "invalid webhook signature", http.StatusBadRequest)
 		return
 	}
 
@@ -219,6 +331,8 @@ func (h *BillingHandler) StripeWebhook(w http.ResponseWriter, r *http.Request) {
 		h.handleSubscriptionUpdated(w, event)
 	case billing.EventSubscriptionDeleted:
 		h.handleSubscriptionDeleted(w, event)
+	case billing.EventInvoicePaymentFailed:
+		h.handleInvoicePaymentFailed(w, event)
 	default:
 		// Acknowledge unhandled events
 		w.WriteHeader(http.StatusOK)
@@ -248,11 +362,17 @@ func (h *BillingHandler) handleCheckoutSessionCompleted(w http.ResponseWriter, e
 		return
 	}
 
-	// Update user to Pro plan
+	// Update user to the purchased plan
+	plan := billing.CheckoutPlan(&session)
+	if plan == "" {
+		plan = user.PlanPro
+	}
 	updatedUser := u.Copy()
-	updatedUser.Plan = user.PlanPro
+	updatedUser.Plan = plan
+	updatedUser.BillingInterval = billing.CheckoutInterval(&session)
 	updatedUser.SubscriptionID = subscriptionID
 	updatedUser.SubscriptionStatus = user.SubscriptionStatusActive
+	updatedUser.CancelAtPeriodEnd = false
 	updatedUser.UpdatedAt = time.Now().UTC()
 
 	if err := h.userRepo.Update(ctx, u.ID, updatedUser); err != nil {
@@ -260,7 +380,7 @@ func (h *BillingHandler) handleCheckoutSessionCompleted(w http.ResponseWriter, e
 		return
 	}
 
-	w.WriteHeader(http.StatusOK)
+	h.finishPlanChange(ctx, w, event, u.Plan, updatedUser)
 }
 
 // handleSubscriptionUpdated processes customer.subscription.updated events
@@ -286,25 +406,29 @@ func (h *BillingHandler) handleSubscriptionUpdated(w http.ResponseWriter, event
 		return
 	}
 
-	// Update subscription status
+	if isStaleSubscription(u, info.SubscriptionID) {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	// Update subscription status. A subscription set to cancel at period end
+	// stays active until Stripe deletes it then.
 	updatedUser := u.Copy()
 	updatedUser.SubscriptionStatus = info.Status
 	updatedUser.SubscriptionEndsAt = info.PeriodEnd
-	updatedUser.UpdatedAt = time.Now().UTC()
-
-	// Update plan based on status
-	if info.Status == user.SubscriptionStatusActive {
-		updatedUser.Plan = user.PlanPro
-	} else if info.Status == user.SubscriptionStatusCanceled {
-		updatedUser.Plan = user.PlanFree
+	updatedUser.CancelAtPeriodEnd = info.CanceledAtPeriodEnd
+	updatedUser.Plan = planForStatus(info.Status, u.Plan, h.paidPlan(info))
+	if info.Interval != "" {
+		updatedUser.BillingInterval = info.Interval
 	}
+	updatedUser.UpdatedAt = time.Now().UTC()
 
 	if err := h.userRepo.Update(ctx, u.ID, updatedUser); err != nil {
 		writeError(w, "failed to update user", http.StatusInternalServerError)
 		return
 	}
 
-	w.WriteHeader(http.StatusOK)
+	h.finishPlanChange(ctx, w, event, u.Plan, updatedUser)
 }
 
 // handleSubscriptionDeleted processes customer.subscription.deleted events
@@ -330,11 +454,18 @@ func (h *BillingHandler) handleSubscriptionDeleted(w http.ResponseWriter, event
 		return
 	}
 
+	if isStaleSubscription(u, info.SubscriptionID) {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
 	// Downgrade to free plan
 	updatedUser := u.Copy()
 	updatedUser.Plan = user.PlanFree
 	updatedUser.SubscriptionID = ""
 	updatedUser.SubscriptionStatus = user.SubscriptionStatusCanceled
+	updatedUser.CancelAtPeriodEnd = false
+	updatedUser.BillingInterval = ""
 	updatedUser.UpdatedAt = time.Now().UTC()
 
 	if err := h.userRepo.Update(ctx, u.ID, updatedUser); err != nil {
@@ -342,5 +473,150 @@ func (h *BillingHandler) handleSubscriptionDeleted(w http.ResponseWriter, event
 		return
 	}
 
+	h.finishPlanChange(ctx, w, event, u.Plan, updatedUser)
+}
+
+// handleInvoicePaymentFailed processes invoice.payment_failed events.
+// The subscription becomes past due but keeps its plan while Stripe retries
+// the payment; if the retries fail, Stripe cancels it or marks it unpaid.
+func (h *BillingHandler) handleInvoicePaymentFailed(w http.ResponseWriter, event stripe.Event) {
+	ctx := context.Background()
+
+	var invoice stripe.Invoice
+	if err := json.Unmarshal(event.Data.Raw, &invoice); err != nil {
+		writeError(w, "failed to parse invoice", http.StatusBadRequest)
+		return
+	}
+
+	customerID, subscriptionID := billing.ParseInvoicePaymentFailed(&invoice)
+	if customerID == "" {
+		writeError(w, "missing customer ID", http.StatusBadRequest)
+		return
+	}
+	if subscriptionID == "" {
+		// One-off invoices do not affect the plan
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	u, err := h.userRepo.GetByStripeCustomerID(ctx, customerID)
+	if err != nil || u == nil {
+		writeError(w, "user not found for customer", http.StatusNotFound)
+		return
+	}
+	if u.SubscriptionID != subscriptionID {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	updatedUser := u.Copy()
+	updatedUser.SubscriptionStatus = user.SubscriptionStatusPastDue
+	updatedUser.UpdatedAt = time.Now().UTC()
+
+	if err := h.userRepo.Update(ctx, u.ID, updatedUser); err != nil {
+		writeError(w, "failed to update user", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// finishPlanChange records a change from the previous plan, brings the user's
+// subscriptions within their plan's limit and acknowledges the event.
+// A failure is reported so that Stripe retries.
+func (h *BillingHandler) finishPlanChange(ctx context.Context, w http.ResponseWriter, event stripe.Event, previous string, u user.User) {
+	if u.Plan != previous {
+		h.auditLog.Record(ctx, audit.Entry{
+			Action:   audit.ActionPlanChange,
+			ActorUID: audit.ActorStripe,
+			TargetID: u.UID,
+			Details:  map[string]string{"from": previous, "to": u.Plan, "stripe_event": event.ID},
+		})
+	}
+	if h.enforcer != nil {
+		if err := h.enforcer.Enforce(ctx, u.UID, u.Plan); err != nil {
+			log.Printf("Billing: failed to enforce the %s plan for user %s: %v", u.Plan, u.ID, err)
+			writeError(w, "failed to apply plan limits", http.StatusInternalServerError)
+			return
+		}
+	}
 	w.WriteHeader(http.StatusOK)
 }
+
+// isStaleSubscription reports whether an event is about a Stripe subscription
+// the user has since replaced
+func isStaleSubscription(u *user.User, subscriptionID string) bool {
+	return u.SubscriptionID != "" && subscriptionID != "" && u.SubscriptionID != subscriptionID
+}
+
+// checkoutPrice returns the Stripe price of a paid plan billed at interval in
+// the catalog of the tenant in ctx. Tenants without a catalog sell the default
+// plans, and the pro plan falls back to the configured prices.
+func (h *BillingHandler) checkoutPrice(ctx context.Context, planID, interval string) (string, bool) {
+	t := tenant.FromContext(ctx)
+	if len(t.Plans) == 0 {
+		t = tenant.Default
+	}
+	price, fallback := func(p tenant.Plan) string { return p.PriceID }, h.config.PriceID
+	if interval == billing.IntervalYear {
+		price, fallback = func(p tenant.Plan) string { return p.YearlyPriceID }, h.config.YearlyPriceID
+	}
+	if p, ok := t.Plan(planID); ok && price(p) != "" {
+		return price(p), true
+	}
+	if planID == user.PlanPro && fallback != "" {
+		return fallback, true
+	}
+	return "", false
+}
+
+// renews reports whether the user's Stripe subscription renews at SubscriptionEndsAt
+func renews(u *user.User) bool {
+	if u.CancelAtPeriodEnd {
+		return false
+	}
+	switch u.SubscriptionStatus {
+	case user.SubscriptionStatusActive, user.SubscriptionStatusTrialing, user.SubscriptionStatusPastDue:
+		return true
+	}
+	return false
+}
+
+// paidPlan returns the plan granted by a Stripe subscription: the plan its
+// price belongs to, else the plan recorded at checkout, else pro
+func (h *BillingHandler) paidPlan(info billing.SubscriptionInfo) string {
+	if info.PriceID != "" {
+		var p tenant.Plan
+		var ok bool
+		if h.tenants != nil {
+			p, ok = h.tenants.PlanForPrice(info.PriceID)
+		} else {
+			p, ok = tenant.Default.PlanForPrice(info.PriceID)
+		}
+		if ok {
+			return p.ID
+		}
+		if info.PriceID == h.config.PriceID {
+			return user.PlanPro
+		}
+	}
+	if info.Plan != "" {
+		return info.Plan
+	}
+	return user.PlanPro
+}
+
+// planForStatus returns the plan granted by a Stripe subscription status.
+// Active subscriptions grant the paid plan; past due and incomplete ones keep
+// the current plan while Stripe retries the payment; statuses without paid
+// service fall back to free.
+func planForStatus(status, current, paid string) string {
+	switch status {
+	case user.SubscriptionStatusActive, user.SubscriptionStatusTrialing:
+		return paid
+	case user.SubscriptionStatusCanceled, user.SubscriptionStatusUnpaid, "incomplete_expired", "paused":
+		return user.PlanFree
+	default:
+		return current
+	}
+}
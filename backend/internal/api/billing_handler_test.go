@@ -4,15 +4,20 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 	"time"
 
+	"github.com/otiai10/namazu/backend/internal/audit"
 	"github.com/otiai10/namazu/backend/internal/auth"
 	"github.com/otiai10/namazu/backend/internal/billing"
 	"github.com/otiai10/namazu/backend/internal/config"
+	"github.com/otiai10/namazu/backend/internal/tenant"
 	"github.com/otiai10/namazu/backend/internal/user"
+	"github.com/stripe/stripe-go/v78"
+	"github.com/stripe/stripe-go/v78/webhook"
 )
 
 // billingMockUserRepo extends mockUserRepo with billing-specific methods
@@ -54,6 +59,11 @@ func (m *billingMockUserRepo) Update(ctx context.Context, id string, u user.User
 	return nil
 }
 
+func (m *billingMockUserRepo) Delete(ctx context.Context, id string) error {
+	delete(m.users, id)
+	return nil
+}
+
 func (m *billingMockUserRepo) UpdateLastLogin(ctx context.Context, id string, t time.Time) error {
 	if u, ok := m.users[id]; ok {
 		u.LastLoginAt = t
@@ -69,6 +79,22 @@ func (m *billingMockUserRepo) RemoveProvider(ctx context.Context, id string, pro
 	return nil
 }
 
+func (m *billingMockUserRepo) AddPushSubscription(ctx context.Context, id string, sub user.PushSubscription) error {
+	return nil
+}
+
+func (m *billingMockUserRepo) RemovePushSubscription(ctx context.Context, id string, endpoint string) error {
+	return nil
+}
+
+func (m *billingMockUserRepo) AddDevice(ctx context.Context, id string, device user.Device) error {
+	return nil
+}
+
+func (m *billingMockUserRepo) RemoveDevice(ctx context.Context, id string, token string) error {
+	return nil
+}
+
 // GetByStripeCustomerID gets a user by their Stripe customer ID
 func (m *billingMockUserRepo) GetByStripeCustomerID(ctx context.Context, customerID string) (*user.User, error) {
 	for _, u := range m.users {
@@ -165,6 +191,7 @@ func TestBillingHandler_GetStatus(t *testing.T) {
 			SubscriptionID:     "sub_456",
 			SubscriptionStatus: user.SubscriptionStatusActive,
 			SubscriptionEndsAt: time.Now().Add(30 * 24 * time.Hour),
+			BillingInterval:    billing.IntervalYear,
 		}
 		repo.users["user-123"] = testUser
 		repo.uidIndex["uid-456"] = "user-123"
@@ -205,6 +232,37 @@ func TestBillingHandler_GetStatus(t *testing.T) {
 		if response.SubscriptionStatus != user.SubscriptionStatusActive {
 			t.Errorf("Expected subscription status 'active', got %s", response.SubscriptionStatus)
 		}
+		if response.BillingInterval != billing.IntervalYear {
+			t.Errorf("Expected billing interval 'year', got %s", response.BillingInterval)
+		}
+		if response.RenewsAt == nil || !response.RenewsAt.Equal(*response.SubscriptionEndsAt) {
+			t.Errorf("Expected renewsAt at the end of the period, got %v", response.RenewsAt)
+		}
+	})
+
+	t.Run("omits renewal when canceling at period end", func(t *testing.T) {
+		repo := newBillingMockUserRepo()
+		repo.users["user-123"] = &user.User{
+			ID: "user-123", UID: "uid-456", Plan: user.PlanPro,
+			SubscriptionStatus: user.SubscriptionStatusActive,
+			SubscriptionEndsAt: time.Now().Add(30 * 24 * time.Hour),
+			CancelAtPeriodEnd:  true,
+		}
+		repo.uidIndex["uid-456"] = "user-123"
+		handler := NewBillingHandler(billing.NewClient("sk_test_123"), repo, &config.BillingConfig{})
+
+		req := httptest.NewRequest(http.MethodGet, "/api/billing/status", nil)
+		req = req.WithContext(auth.WithClaims(req.Context(), &auth.Claims{UID: "uid-456"}))
+		w := httptest.NewRecorder()
+		handler.GetStatus(w, req)
+
+		var response BillingStatusResponse
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if response.RenewsAt != nil || response.SubscriptionEndsAt == nil {
+			t.Errorf("Expected subscriptionEndsAt without renewsAt, got %v / %v", response.SubscriptionEndsAt, response.RenewsAt)
+		}
 	})
 
 	t.Run("returns 404 for user not found", func(t *testing.T) {
@@ -293,6 +351,52 @@ func TestBillingHandler_CreateCheckoutSession(t *testing.T) {
 			t.Errorf("Expected status 400, got %d", w.Code)
 		}
 	})
+
+	t.Run("rejects unknown intervals and plans without a price", func(t *testing.T) {
+		handler := NewBillingHandler(billing.NewClient("sk_test_123"), newBillingMockUserRepo(), &config.BillingConfig{PriceID: "price_123"})
+		for _, body := range []string{`{"interval":"week"}`, `{"interval":"year"}`, `{"plan":"free"}`} {
+			req := httptest.NewRequest(http.MethodPost, "/api/billing/create-checkout-session", bytes.NewBufferString(body))
+			req = req.WithContext(auth.WithClaims(req.Context(), &auth.Claims{UID: "uid-456"}))
+			w := httptest.NewRecorder()
+			handler.CreateCheckoutSession(w, req)
+
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("%s: expected status 400, got %d", body, w.Code)
+			}
+		}
+	})
+}
+
+func TestBillingHandler_CheckoutPrice(t *testing.T) {
+	handler := NewBillingHandler(nil, newBillingMockUserRepo(), &config.BillingConfig{PriceID: "price_pro", YearlyPriceID: "price_pro_yearly"})
+	acme := &tenant.Tenant{ID: "acme", Plans: []tenant.Plan{
+		{ID: user.PlanFree, MaxSubscriptions: 2},
+		{ID: "gold", MaxSubscriptions: 40, PriceID: "price_acme_gold"},
+	}}
+
+	tests := []struct {
+		name      string
+		tenant    *tenant.Tenant
+		plan      string
+		interval  string
+		wantPrice string
+	}{
+		{"default pro", tenant.Default, user.PlanPro, billing.IntervalMonth, "price_pro"},
+		{"default pro yearly", tenant.Default, user.PlanPro, billing.IntervalYear, "price_pro_yearly"},
+		{"default free is not for sale", tenant.Default, user.PlanFree, billing.IntervalMonth, ""},
+		{"tenant plan", acme, "gold", billing.IntervalMonth, "price_acme_gold"},
+		{"tenant plan without a yearly price", acme, "gold", billing.IntervalYear, ""},
+		{"tenant pro falls back to the configured price", acme, user.PlanPro, billing.IntervalMonth, "price_pro"},
+		{"unknown plan", acme, "platinum", billing.IntervalMonth, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			price, ok := handler.checkoutPrice(tenant.WithTenant(context.Background(), tt.tenant), tt.plan, tt.interval)
+			if price != tt.wantPrice || ok != (tt.wantPrice != "") {
+				t.Errorf("checkoutPrice(%q, %q) = %q, %v; want %q", tt.plan, tt.interval, price, ok, tt.wantPrice)
+			}
+		})
+	}
 }
 
 func TestBillingHandler_StripeWebhook(t *testing.T) {
@@ -344,6 +448,132 @@ func TestBillingHandler_StripeWebhook(t *testing.T) {
 	})
 }
 
+// recordingEnforcer records the plans enforced per user
+type recordingEnforcer struct{ plans map[string]string }
+
+func (e *recordingEnforcer) Enforce(ctx context.Context, userID, plan string) error {
+	e.plans[userID] = plan
+	return nil
+}
+
+// signedStripeEvent returns a webhook request for an event carrying object (JSON)
+func signedStripeEvent(eventType, object string) *http.Request {
+	payload := fmt.Sprintf(`{"id":"evt_1","object":"event","api_version":%q,"type":%q,"data":{"object":%s}}`,
+		stripe.APIVersion, eventType, object)
+	signed := webhook.GenerateTestSignedPayload(&webhook.UnsignedPayload{Payload: []byte(payload), Secret: "whsec_123"})
+	req := httptest.NewRequest(http.MethodPost, "/api/webhooks/stripe", bytes.NewReader(signed.Payload))
+	req.Header.Set("Stripe-Signature", signed.Header)
+	return req
+}
+
+func TestBillingHandler_StripeLifecycle(t *testing.T) {
+	tests := []struct {
+		name         string
+		eventType    string
+		object       string
+		wantPlan     string
+		wantStatus   string
+		wantCancel   bool
+		wantSubID    string
+		wantEnforced bool
+	}{
+		{
+			name:      "cancel at period end keeps pro",
+			eventType: billing.EventSubscriptionUpdated,
+			object:    `{"id":"sub_1","customer":"cus_1","status":"active","cancel_at_period_end":true,"current_period_end":1767225600}`,
+			wantPlan:  user.PlanPro, wantStatus: user.SubscriptionStatusActive, wantCancel: true, wantSubID: "sub_1", wantEnforced: true,
+		},
+		{
+			name:      "unpaid downgrades",
+			eventType: billing.EventSubscriptionUpdated,
+			object:    `{"id":"sub_1","customer":"cus_1","status":"unpaid","current_period_end":1767225600}`,
+			wantPlan:  user.PlanFree, wantStatus: user.SubscriptionStatusUnpaid, wantSubID: "sub_1", wantEnforced: true,
+		},
+		{
+			name:      "past due keeps pro",
+			eventType: billing.EventSubscriptionUpdated,
+			object:    `{"id":"sub_1","customer":"cus_1","status":"past_due","current_period_end":1767225600}`,
+			wantPlan:  user.PlanPro, wantStatus: user.SubscriptionStatusPastDue, wantSubID: "sub_1", wantEnforced: true,
+		},
+		{
+			name:      "deleted downgrades",
+			eventType: billing.EventSubscriptionDeleted,
+			object:    `{"id":"sub_1","customer":"cus_1","status":"canceled","current_period_end":1767225600}`,
+			wantPlan:  user.PlanFree, wantStatus: user.SubscriptionStatusCanceled, wantEnforced: true,
+		},
+		{
+			name:      "replaced subscription is ignored",
+			eventType: billing.EventSubscriptionDeleted,
+			object:    `{"id":"sub_old","customer":"cus_1","status":"canceled","current_period_end":1767225600}`,
+			wantPlan:  user.PlanPro, wantStatus: user.SubscriptionStatusActive, wantSubID: "sub_1",
+		},
+		{
+			name:      "payment failed marks past due",
+			eventType: billing.EventInvoicePaymentFailed,
+			object:    `{"id":"in_1","customer":"cus_1","subscription":"sub_1"}`,
+			wantPlan:  user.PlanPro, wantStatus: user.SubscriptionStatusPastDue, wantSubID: "sub_1",
+		},
+		{
+			name:      "price maps to its plan",
+			eventType: billing.EventSubscriptionUpdated,
+			object:    `{"id":"sub_1","customer":"cus_1","status":"active","current_period_end":1767225600,"items":{"object":"list","data":[{"id":"si_1","price":{"id":"price_business"}}]}}`,
+			wantPlan:  "business", wantStatus: user.SubscriptionStatusActive, wantSubID: "sub_1", wantEnforced: true,
+		},
+		{
+			name:      "checkout grants the purchased plan",
+			eventType: billing.EventCheckoutSessionCompleted,
+			object:    `{"id":"cs_1","customer":"cus_1","subscription":"sub_2","metadata":{"plan":"business"}}`,
+			wantPlan:  "business", wantStatus: user.SubscriptionStatusActive, wantSubID: "sub_2", wantEnforced: true,
+		},
+	}
+	tenant.SetDefaultPlans([]config.PlanConfig{
+		{ID: user.PlanFree, MaxSubscriptions: 1},
+		{ID: "business", MaxSubscriptions: 50, PriceID: "price_business"},
+	})
+	t.Cleanup(func() { tenant.SetDefaultPlans(nil) })
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newBillingMockUserRepo()
+			repo.users["user-123"] = &user.User{
+				ID: "user-123", UID: "uid-456", Plan: user.PlanPro,
+				StripeCustomerID: "cus_1", SubscriptionID: "sub_1", SubscriptionStatus: user.SubscriptionStatusActive,
+			}
+			repo.uidIndex["uid-456"] = "user-123"
+			enforcer := &recordingEnforcer{plans: map[string]string{}}
+			handler := NewBillingHandler(billing.NewClient("sk_test_123"), repo, &config.BillingConfig{WebhookSecret: "whsec_123"})
+			handler.SetQuotaEnforcer(enforcer)
+			auditRepo := audit.NewMemoryRepository()
+			handler.SetAuditLog(audit.NewLogger(auditRepo))
+
+			w := httptest.NewRecorder()
+			handler.StripeWebhook(w, signedStripeEvent(tt.eventType, tt.object))
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+			}
+			u := repo.users["user-123"]
+			if u.Plan != tt.wantPlan || u.SubscriptionStatus != tt.wantStatus || u.CancelAtPeriodEnd != tt.wantCancel || u.SubscriptionID != tt.wantSubID {
+				t.Errorf("user = plan %s, status %s, cancel %v, subscription %q", u.Plan, u.SubscriptionStatus, u.CancelAtPeriodEnd, u.SubscriptionID)
+			}
+			if plan, ok := enforcer.plans["uid-456"]; ok != tt.wantEnforced || (ok && plan != tt.wantPlan) {
+				t.Errorf("enforced %q (%v), want %q (%v)", plan, ok, tt.wantPlan, tt.wantEnforced)
+			}
+			entries, _ := auditRepo.List(context.Background(), audit.Filter{})
+			if tt.wantPlan == user.PlanPro && len(entries) != 0 {
+				t.Errorf("audit entries = %+v, want none without a plan change", entries)
+			}
+			if tt.wantPlan != user.PlanPro {
+				want := map[string]string{"from": user.PlanPro, "to": tt.wantPlan, "stripe_event": "evt_1"}
+				if len(entries) != 1 || entries[0].Action != audit.ActionPlanChange || entries[0].ActorUID != audit.ActorStripe ||
+					entries[0].TargetID != "uid-456" || fmt.Sprint(entries[0].Details) != fmt.Sprint(want) {
+					t.Errorf("audit entries = %+v, want the change to %s by Stripe", entries, tt.wantPlan)
+				}
+			}
+		})
+	}
+}
+
 func TestBillingHandler_GetPortalSession(t *testing.T) {
 	t.Run("returns error for user not found", func(t *testing.T) {
 		repo := newBillingMockUserRepo()
@@ -406,6 +636,39 @@ func TestBillingHandler_GetPortalSession(t *testing.T) {
 	})
 }
 
+func TestBillingHandler_CreatePortalSession(t *testing.T) {
+	repo := newBillingMockUserRepo()
+	repo.users["user-123"] = &user.User{ID: "user-123", UID: "uid-456", Plan: user.PlanFree}
+	repo.uidIndex["uid-456"] = "user-123"
+	cfg := &config.BillingConfig{SecretKey: "sk_test_123", SuccessURL: "https://example.com/success"}
+	handler := NewBillingHandler(billing.NewClient("sk_test_123"), repo, cfg)
+
+	tests := []struct {
+		name     string
+		uid      string
+		body     string
+		wantCode int
+	}{
+		{"user not found", "unknown-uid", "", http.StatusNotFound},
+		{"no Stripe customer ID", "uid-456", "", http.StatusBadRequest},
+		{"relative return_url", "uid-456", `{"return_url": "/billing"}`, http.StatusBadRequest},
+		{"invalid body", "uid-456", `{`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/billing/create-portal-session", bytes.NewBufferString(tt.body))
+			req = req.WithContext(auth.WithClaims(req.Context(), &auth.Claims{UID: tt.uid}))
+			w := httptest.NewRecorder()
+
+			handler.CreatePortalSession(w, req)
+
+			if w.Code != tt.wantCode {
+				t.Errorf("Expected status %d, got %d: %s", tt.wantCode, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
 func TestBillingStatusResponse(t *testing.T) {
 	t.Run("response fields are correct", func(t *testing.T) {
 		now := time.Now()
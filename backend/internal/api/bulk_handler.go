@@ -0,0 +1,253 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/otiai10/namazu/backend/internal/apierr"
+	"github.com/otiai10/namazu/backend/internal/audit"
+	"github.com/otiai10/namazu/backend/internal/auth"
+	"github.com/otiai10/namazu/backend/internal/config"
+	"github.com/otiai10/namazu/backend/internal/delivery/webhook"
+	"github.com/otiai10/namazu/backend/internal/subscription"
+	"github.com/otiai10/namazu/backend/internal/tenant"
+)
+
+// maxImportSubscriptions caps the subscriptions of a single import
+const maxImportSubscriptions = 100
+
+// maxImportBodyBytes bounds the body of an import
+const maxImportBodyBytes = 1 << 20
+
+// SubscriptionDocument is the format of subscription import and export:
+// the subscriptions section of the static config file
+type SubscriptionDocument struct {
+	Subscriptions []config.SubscriptionConfig `yaml:"subscriptions" json:"subscriptions"`
+}
+
+// ImportResponse represents the response for POST /api/subscriptions/import
+type ImportResponse struct {
+	Subscriptions []SubscriptionResponse `json:"subscriptions"` // In the order of the document
+}
+
+// ExportSubscriptions handles GET /api/subscriptions/export?format=yaml|json
+// Returns the caller's subscriptions in the static config format (YAML by default).
+// Secrets are not exported, and settings the static config has no field for are dropped.
+func (h *Handler) ExportSubscriptions(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "yaml"
+	}
+	if format != "yaml" && format != "json" {
+		writeErrorCode(w, apierr.ValidationFailed, "format must be yaml or json", http.StatusBadRequest)
+		return
+	}
+
+	var subs []subscription.Subscription
+	var err error
+	if claims, ok := auth.GetClaims(r.Context()); ok {
+		subs, err = h.subscriptionRepo.ListByUserID(r.Context(), claims.UID)
+	} else {
+		subs, err = h.subscriptionRepo.List(r.Context())
+	}
+	if err != nil {
+		writeError(w, "failed to list subscriptions", http.StatusInternalServerError)
+		return
+	}
+
+	tenantID := tenant.FromContext(r.Context()).ID
+	doc := SubscriptionDocument{Subscriptions: make([]config.SubscriptionConfig, 0, len(subs))}
+	for _, sub := range subs {
+		if sub.TenantID != tenantID {
+			continue
+		}
+		c := subscription.ToConfig(sub)
+		c.Delivery.Secret = ""
+		doc.Subscriptions = append(doc.Subscriptions, c)
+	}
+
+	w.Header().Set("Content-Disposition", `attachment; filename="subscriptions.`+format+`"`)
+	if format == "json" {
+		writeJSON(w, doc, http.StatusOK)
+		return
+	}
+	out, err := yaml.Marshal(doc)
+	if err != nil {
+		writeError(w, "failed to encode subscriptions", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/yaml")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(out)
+}
+
+// ImportSubscriptions handles POST /api/subscriptions/import
+// Accepts a SubscriptionDocument as YAML, or as JSON with Content-Type: application/json;
+// a whole static config file can be posted as is. Either every subscription is
+// created, or none is: all of them are validated and counted against the plan
+// first, and the ones already created are deleted if a later one fails.
+//
+// A webhook with a secret keeps it and its legacy signature, so receivers set up
+// for the static config need no change. Without a secret one is generated and
+// the URL is verified, as in POST /api/subscriptions.
+func (h *Handler) ImportSubscriptions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxImportBodyBytes))
+	if err != nil {
+		writeError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	var doc SubscriptionDocument
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "application/json" {
+		err = json.Unmarshal(body, &doc)
+	} else {
+		err = yaml.Unmarshal(body, &doc)
+	}
+	if err != nil {
+		writeError(w, "invalid request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(doc.Subscriptions) == 0 {
+		writeError(w, "no subscriptions to import", http.StatusBadRequest)
+		return
+	}
+	if len(doc.Subscriptions) > maxImportSubscriptions {
+		writeErrorCode(w, apierr.ValidationFailed, fmt.Sprintf("at most %d subscriptions can be imported at once", maxImportSubscriptions), http.StatusBadRequest)
+		return
+	}
+
+	names := make(map[string]bool, len(doc.Subscriptions))
+	subs := make([]subscription.Subscription, len(doc.Subscriptions))
+	for i, c := range doc.Subscriptions {
+		sub := subscription.FromConfig(c)
+		req := SubscriptionRequest{Name: sub.Name, Delivery: sub.Delivery, Filter: sub.Filter}
+		if msg := h.validateSubscriptionRequest(req); msg != "" {
+			writeErrorCode(w, apierr.ValidationFailed, fmt.Sprintf("subscriptions[%d]: %s", i, msg), http.StatusBadRequest)
+			return
+		}
+		if names[sub.Name] {
+			writeErrorCode(w, apierr.ValidationFailed, fmt.Sprintf("subscriptions[%d]: duplicate name %q", i, sub.Name), http.StatusBadRequest)
+			return
+		}
+		names[sub.Name] = true
+		subs[i] = sub
+	}
+
+	var userID, quotaPlan string
+	limit := -1
+	if claims, ok := auth.GetClaims(r.Context()); ok {
+		userID = claims.UID
+		if h.quotaChecker != nil {
+			plan := h.getUserPlan(r.Context(), claims.UID)
+			quotaPlan = plan
+			canCreate, err := h.quotaChecker.CanCreateSubscriptions(r.Context(), claims.UID, plan, len(subs))
+			if err != nil {
+				writeError(w, "failed to check quota", http.StatusInternalServerError)
+				return
+			}
+			if !canCreate {
+				h.writeQuotaExceeded(w, r.Context(), plan)
+				return
+			}
+			features := h.quotaChecker.Features(r.Context(), plan)
+			limit = features.MaxSubscriptions
+			for i, sub := range subs {
+				if err := features.Check(sub); err != nil {
+					writePlanFeatureError(w, err, fmt.Sprintf("subscriptions[%d]: ", i))
+					return
+				}
+			}
+		}
+	}
+
+	tenantID := tenant.FromContext(r.Context()).ID
+	now := time.Now().UTC()
+	generated := make([]string, len(subs))
+	for i := range subs {
+		sub := &subs[i]
+		sub.TenantID = tenantID
+		sub.UserID = userID
+		sub.CreatedAt = now
+		sub.Status = subscription.StatusActive
+
+		// Web Push and FCM notify the owner's browsers and devices, so there must be an owner
+		if (sub.Delivery.Type == subscription.DeliveryTypeWebPush || sub.Delivery.Type == subscription.DeliveryTypeFCM) && userID == "" {
+			writeErrorCode(w, apierr.ValidationFailed, fmt.Sprintf("subscriptions[%d]: %s delivery requires authentication", i, sub.Delivery.Type), http.StatusBadRequest)
+			return
+		}
+		if sub.Delivery.Type != "webhook" {
+			continue
+		}
+		if sub.Delivery.Secret == "" {
+			secret, err := webhook.GenerateSecret()
+			if err != nil {
+				writeError(w, "failed to generate webhook secret", http.StatusInternalServerError)
+				return
+			}
+			generated[i] = secret
+			sub.Delivery.Secret = secret
+			sub.Delivery.SignVersion = "v0"
+
+			if h.challenger != nil {
+				result := h.challenger.VerifyURL(r.Context(), sub.Delivery.URL, secret, sub.Delivery.Headers)
+				if !result.Success {
+					writeErrorCode(w, apierr.WebhookVerificationFailed, fmt.Sprintf("subscriptions[%d]: webhook URL verification failed: %s", i, result.ErrorMessage), http.StatusBadRequest)
+					return
+				}
+				sub.Delivery.Verified = true
+			}
+		}
+		sub.Delivery.SecretPrefix = webhook.SecretPrefixFromSecret(sub.Delivery.Secret)
+	}
+
+	responses := make([]SubscriptionResponse, len(subs))
+	ids := make([]string, 0, len(subs))
+	for i, sub := range subs {
+		// Another request may have created subscriptions since the quota check
+		id, err := h.createWithinLimit(r.Context(), sub, limit)
+		if errors.Is(err, subscription.ErrLimitExceeded) {
+			h.rollbackImport(r.Context(), ids)
+			h.writeQuotaExceeded(w, r.Context(), quotaPlan)
+			return
+		}
+		if err != nil {
+			h.rollbackImport(r.Context(), ids)
+			writeError(w, "failed to import subscriptions", http.StatusInternalServerError)
+			return
+		}
+		ids = append(ids, id)
+		h.auditLog.Record(r.Context(), auditEntry(r, audit.ActionSubscriptionCreate, id, map[string]string{"name": sub.Name, "source": "import"}))
+
+		sub.ID = id
+		sub.Revision = 1
+		responses[i] = subscriptionToResponse(sub)
+		if generated[i] != "" {
+			responses[i].Delivery.Secret = generated[i]
+		}
+	}
+
+	writeJSON(w, ImportResponse{Subscriptions: responses}, http.StatusCreated)
+}
+
+// rollbackImport deletes the subscriptions created by a failed import
+func (h *Handler) rollbackImport(ctx context.Context, ids []string) {
+	ctx = context.WithoutCancel(ctx)
+	for _, id := range ids {
+		if err := h.subscriptionRepo.Delete(ctx, id); err != nil {
+			log.Printf("Failed to roll back imported subscription %s: %v", id, err)
+		}
+	}
+}
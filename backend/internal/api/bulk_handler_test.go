@@ -0,0 +1,219 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/otiai10/namazu/backend/internal/auth"
+	"github.com/otiai10/namazu/backend/internal/delivery/webhook"
+	"github.com/otiai10/namazu/backend/internal/subscription"
+)
+
+// staticConfig is a Phase 1 config file, imported as is
+const staticConfig = `
+source:
+  type: p2pquake
+subscriptions:
+  - name: legacy
+    delivery:
+      type: webhook
+      url: https://example.com/legacy
+      secret: my-static-secret
+    filter:
+      min_scale: 40
+      prefectures: ["東京都"]
+  - name: fresh
+    delivery:
+      type: webhook
+      url: https://example.com/fresh
+`
+
+func importSubscriptions(h http.Handler, uid, contentType, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/api/subscriptions/import", bytes.NewBufferString(body))
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if uid != "" {
+		req = req.WithContext(auth.WithClaims(req.Context(), &auth.Claims{UID: uid}))
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestImportSubscriptions(t *testing.T) {
+	subRepo := newMockSubscriptionRepo()
+	handler := NewHandler(subRepo, newMockEventRepo())
+	handler.SetChallenger(&mockChallenger{result: webhook.ChallengeResult{Success: true}})
+	router := NewRouter(handler)
+
+	rec := importSubscriptions(router, "user-1", "application/yaml", staticConfig)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, rec.Code, rec.Body.String())
+	}
+	var resp ImportResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if len(resp.Subscriptions) != 2 || resp.Subscriptions[0].Name != "legacy" || resp.Subscriptions[1].Name != "fresh" {
+		t.Fatalf("unexpected response: %+v", resp.Subscriptions)
+	}
+
+	// The static secret is kept with its legacy signature
+	legacy := subRepo.subscriptions[resp.Subscriptions[0].ID]
+	if legacy.UserID != "user-1" || legacy.Delivery.Secret != "my-static-secret" || legacy.Delivery.SignVersion != "" {
+		t.Errorf("legacy = %+v, want the static secret and signature kept", legacy.Delivery)
+	}
+	if legacy.Filter == nil || legacy.Filter.MinScale != 40 || len(legacy.Filter.Prefectures) != 1 {
+		t.Errorf("legacy filter = %+v, want the static filter", legacy.Filter)
+	}
+	if resp.Subscriptions[0].Delivery.Secret == "my-static-secret" {
+		t.Error("expected the imported secret to be masked in the response")
+	}
+
+	// Without a secret, one is generated and returned once, as on create
+	fresh := subRepo.subscriptions[resp.Subscriptions[1].ID]
+	if fresh.Delivery.SignVersion != "v0" || !fresh.Delivery.Verified || !strings.HasPrefix(fresh.Delivery.Secret, webhook.SecretPrefix) {
+		t.Errorf("fresh = %+v, want a generated, verified v0 secret", fresh.Delivery)
+	}
+	if resp.Subscriptions[1].Delivery.Secret != fresh.Delivery.Secret {
+		t.Error("expected the generated secret in the response")
+	}
+}
+
+func TestImportSubscriptions_JSON(t *testing.T) {
+	subRepo := newMockSubscriptionRepo()
+	router := NewRouter(NewHandler(subRepo, newMockEventRepo()))
+
+	body := `{"subscriptions": [{"name": "a", "delivery": {"type": "webhook", "url": "https://example.com/a"}}]}`
+	if rec := importSubscriptions(router, "user-1", "application/json", body); rec.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, rec.Code, rec.Body.String())
+	}
+	if len(subRepo.subscriptions) != 1 {
+		t.Errorf("expected 1 subscription, got %d", len(subRepo.subscriptions))
+	}
+}
+
+func TestImportSubscriptions_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"empty", `subscriptions: []`, "no subscriptions to import"},
+		{"malformed", `subscriptions: {`, "invalid request body"},
+		{"missing url", "subscriptions:\n  - name: a\n    delivery: {type: webhook}\n  - name: b\n    delivery: {type: webhook, url: https://example.com}", "subscriptions[0]: delivery type and URL are required"},
+		{"duplicate name", "subscriptions:\n  - name: a\n    delivery: {type: webhook, url: https://example.com}\n  - name: a\n    delivery: {type: webhook, url: https://example.com}", `subscriptions[1]: duplicate name "a"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subRepo := newMockSubscriptionRepo()
+			router := NewRouter(NewHandler(subRepo, newMockEventRepo()))
+
+			rec := importSubscriptions(router, "user-1", "", tt.body)
+			var resp ErrorResponse
+			_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+			if rec.Code != http.StatusBadRequest || !strings.Contains(resp.Error.Message, tt.want) {
+				t.Errorf("got %d %q, want 400 with %q", rec.Code, resp.Error.Message, tt.want)
+			}
+			if len(subRepo.subscriptions) != 0 {
+				t.Errorf("expected nothing imported, got %d subscriptions", len(subRepo.subscriptions))
+			}
+		})
+	}
+}
+
+func TestImportSubscriptions_Quota(t *testing.T) {
+	subRepo := newMockSubscriptionRepo()
+	handler := NewHandlerWithQuota(subRepo, newMockEventRepo(), newQuotaUserRepo(), &mockQuotaChecker{canCreate: false})
+	router := NewRouter(handler)
+
+	if rec := importSubscriptions(router, "user-1", "", staticConfig); rec.Code != http.StatusForbidden {
+		t.Errorf("expected status %d, got %d", http.StatusForbidden, rec.Code)
+	}
+	if len(subRepo.subscriptions) != 0 {
+		t.Errorf("expected nothing imported, got %d subscriptions", len(subRepo.subscriptions))
+	}
+}
+
+// failingCreateRepo fails to create once `remaining` subscriptions were created
+type failingCreateRepo struct {
+	*mockSubscriptionRepo
+	remaining int
+}
+
+func (m *failingCreateRepo) Create(ctx context.Context, sub subscription.Subscription) (string, error) {
+	if m.remaining == 0 {
+		return "", errors.New("store unavailable")
+	}
+	m.remaining--
+	return m.mockSubscriptionRepo.Create(ctx, sub)
+}
+
+func TestImportSubscriptions_RollsBack(t *testing.T) {
+	subRepo := &failingCreateRepo{mockSubscriptionRepo: newMockSubscriptionRepo(), remaining: 1}
+	router := NewRouter(NewHandler(subRepo, newMockEventRepo()))
+
+	if rec := importSubscriptions(router, "user-1", "", staticConfig); rec.Code != http.StatusInternalServerError {
+		t.Errorf("expected status %d, got %d", http.StatusInternalServerError, rec.Code)
+	}
+	if len(subRepo.subscriptions) != 0 {
+		t.Errorf("expected the partial import to be rolled back, got %d subscriptions", len(subRepo.subscriptions))
+	}
+}
+
+func TestExportSubscriptions(t *testing.T) {
+	subRepo := newMockSubscriptionRepo()
+	router := NewRouter(NewHandler(subRepo, newMockEventRepo()))
+	if rec := importSubscriptions(router, "user-1", "", staticConfig); rec.Code != http.StatusCreated {
+		t.Fatalf("import failed: %d %s", rec.Code, rec.Body.String())
+	}
+	if _, err := subRepo.Create(context.Background(), subscription.Subscription{UserID: "user-2", Name: "other"}); err != nil {
+		t.Fatal(err)
+	}
+
+	export := func(query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/subscriptions/export"+query, nil)
+		req = req.WithContext(auth.WithClaims(req.Context(), &auth.Claims{UID: "user-1"}))
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := export("")
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "application/yaml" {
+		t.Fatalf("got %d %s, want YAML", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	var doc SubscriptionDocument
+	if err := yaml.Unmarshal(rec.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("failed to parse export: %v", err)
+	}
+	if len(doc.Subscriptions) != 2 {
+		t.Fatalf("expected the caller's 2 subscriptions, got %+v", doc.Subscriptions)
+	}
+	for _, c := range doc.Subscriptions {
+		if c.Delivery.Secret != "" {
+			t.Errorf("%s: expected the secret not to be exported", c.Name)
+		}
+		if c.Name == "legacy" && (c.Filter == nil || c.Filter.MinScale != 40) {
+			t.Errorf("legacy filter = %+v, want the imported filter", c.Filter)
+		}
+	}
+
+	rec = export("?format=json")
+	if err := json.Unmarshal(rec.Body.Bytes(), &doc); rec.Code != http.StatusOK || err != nil || len(doc.Subscriptions) != 2 {
+		t.Errorf("JSON export = %d %s", rec.Code, rec.Body.String())
+	}
+
+	if rec := export("?format=xml"); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d for an unknown format, got %d", http.StatusBadRequest, rec.Code)
+	}
+}
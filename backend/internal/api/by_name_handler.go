@@ -0,0 +1,186 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/otiai10/namazu/backend/internal/apierr"
+	"github.com/otiai10/namazu/backend/internal/audit"
+	"github.com/otiai10/namazu/backend/internal/auth"
+	"github.com/otiai10/namazu/backend/internal/subscription"
+	"github.com/otiai10/namazu/backend/internal/tenant"
+)
+
+// byNamePrefix is the path prefix for name-keyed subscription routes
+const byNamePrefix = "/api/subscriptions/by-name/"
+
+// errAmbiguousName is reported when more than one subscription has the requested name
+const errAmbiguousName = "multiple subscriptions share this name; rename them or use the ID-based API"
+
+// GetSubscriptionByName handles GET /api/subscriptions/by-name/{name}
+func (h *Handler) GetSubscriptionByName(w http.ResponseWriter, r *http.Request) {
+	name, ok := nameFromPath(r)
+	if !ok {
+		writeErrorCode(w, apierr.ValidationFailed, "invalid subscription name", http.StatusBadRequest)
+		return
+	}
+
+	sub, ambiguous, err := h.findByName(r.Context(), name)
+	if err != nil {
+		writeError(w, "failed to get subscription", http.StatusInternalServerError)
+		return
+	}
+	if ambiguous {
+		writeErrorCode(w, apierr.AmbiguousName, errAmbiguousName, http.StatusConflict)
+		return
+	}
+	if sub == nil {
+		writeError(w, "subscription not found", http.StatusNotFound)
+		return
+	}
+
+	writeSubscription(w, r, *sub)
+}
+
+// PutSubscriptionByName handles PUT /api/subscriptions/by-name/{name}
+// Creates the subscription if no subscription of the caller has this name,
+// otherwise replaces it. Replacing requires If-Match, as for PUT by ID
+// (If-Match: * replaces whatever version is stored); If-None-Match: *
+// makes the request create only. Creating passes the signup gate, as POST does.
+func (h *Handler) PutSubscriptionByName(w http.ResponseWriter, r *http.Request) {
+	name, ok := nameFromPath(r)
+	if !ok {
+		writeErrorCode(w, apierr.ValidationFailed, "invalid subscription name", http.StatusBadRequest)
+		return
+	}
+
+	var req SubscriptionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if req.Name == "" {
+		req.Name = name
+	}
+	if req.Name != name {
+		writeErrorCode(w, apierr.ValidationFailed, "name in body must match the name in the path", http.StatusBadRequest)
+		return
+	}
+
+	if msg := h.validateSubscriptionRequest(req); msg != "" {
+		writeErrorCode(w, apierr.ValidationFailed, msg, http.StatusBadRequest)
+		return
+	}
+
+	existing, ambiguous, err := h.findByName(r.Context(), name)
+	if err != nil {
+		writeError(w, "failed to get subscription", http.StatusInternalServerError)
+		return
+	}
+	if ambiguous {
+		writeErrorCode(w, apierr.AmbiguousName, errAmbiguousName, http.StatusConflict)
+		return
+	}
+
+	if !checkPreconditions(w, r, existing) {
+		return
+	}
+
+	if existing == nil {
+		h.signupGate.Wrap(func(w http.ResponseWriter, r *http.Request) {
+			h.createSubscription(w, r, req, true)
+		})(w, r)
+		return
+	}
+	if !requireIfMatch(w, r) {
+		return
+	}
+
+	h.updateSubscription(w, r, existing.ID, *existing, req)
+}
+
+// DeleteSubscriptionByName handles DELETE /api/subscriptions/by-name/{name}
+func (h *Handler) DeleteSubscriptionByName(w http.ResponseWriter, r *http.Request) {
+	name, ok := nameFromPath(r)
+	if !ok {
+		writeErrorCode(w, apierr.ValidationFailed, "invalid subscription name", http.StatusBadRequest)
+		return
+	}
+
+	existing, ambiguous, err := h.findByName(r.Context(), name)
+	if err != nil {
+		writeError(w, "failed to get subscription", http.StatusInternalServerError)
+		return
+	}
+	if ambiguous {
+		writeErrorCode(w, apierr.AmbiguousName, errAmbiguousName, http.StatusConflict)
+		return
+	}
+	if existing == nil {
+		writeError(w, "subscription not found", http.StatusNotFound)
+		return
+	}
+
+	if !checkPreconditions(w, r, existing) {
+		return
+	}
+
+	if err := h.subscriptionRepo.Delete(r.Context(), existing.ID); err != nil {
+		writeError(w, "failed to delete subscription", http.StatusInternalServerError)
+		return
+	}
+	h.auditLog.Record(r.Context(), auditEntry(r, audit.ActionSubscriptionDelete, existing.ID, map[string]string{"name": existing.Name}))
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// findByName looks up the caller's subscription with the given name.
+// Names are scoped to the owner: authenticated callers only see their own
+// subscriptions, unauthenticated callers only see ownerless ones, and
+// both only see subscriptions of the request's tenant.
+// Returns ambiguous=true if more than one subscription matches.
+func (h *Handler) findByName(ctx context.Context, name string) (*subscription.Subscription, bool, error) {
+	var subs []subscription.Subscription
+	var err error
+	owner := ""
+	if claims, ok := auth.GetClaims(ctx); ok {
+		owner = claims.UID
+		subs, err = h.subscriptionRepo.ListByUserID(ctx, owner)
+	} else {
+		subs, err = h.subscriptionRepo.List(ctx)
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	tenantID := tenant.FromContext(ctx).ID
+	var found *subscription.Subscription
+	for i := range subs {
+		if subs[i].UserID != owner || subs[i].TenantID != tenantID || subs[i].Name != name {
+			continue
+		}
+		if found != nil {
+			return nil, true, nil
+		}
+		found = &subs[i]
+	}
+	return found, false, nil
+}
+
+// nameFromPath extracts the URL-decoded name from a by-name path.
+// Names may contain "/" when it is percent-encoded.
+func nameFromPath(r *http.Request) (string, bool) {
+	escaped := strings.TrimPrefix(r.URL.EscapedPath(), byNamePrefix)
+	if escaped == "" || strings.Contains(escaped, "/") {
+		return "", false
+	}
+	name, err := url.PathUnescape(escaped)
+	if err != nil || name == "" {
+		return "", false
+	}
+	return name, true
+}
@@ -0,0 +1,333 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/otiai10/namazu/backend/internal/auth"
+	"github.com/otiai10/namazu/backend/internal/subscription"
+)
+
+func putByName(t *testing.T, h http.Handler, name, body string, headers map[string]string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPut, byNamePrefix+name, bytes.NewBufferString(body))
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestPutSubscriptionByName_CreateThenIdempotentUpdate(t *testing.T) {
+	subRepo := newMockSubscriptionRepo()
+	router := NewRouter(NewHandler(subRepo, newMockEventRepo()))
+	body := `{"delivery":{"type":"webhook","url":"https://example.com/hook"},"filter":{"min_scale":30}}`
+
+	rec := putByName(t, router, "prod-alerts", body, nil)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, rec.Code, rec.Body.String())
+	}
+	firstETag := rec.Header().Get("ETag")
+	if firstETag == "" {
+		t.Fatal("expected ETag header on create")
+	}
+
+	var created SubscriptionResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &created); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if created.Name != "prod-alerts" {
+		t.Errorf("expected name from path, got %q", created.Name)
+	}
+
+	// Replacing without the ETag it was read with is refused
+	if rec := putByName(t, router, "prod-alerts", body, nil); rec.Code != http.StatusPreconditionRequired {
+		t.Errorf("expected status %d without If-Match, got %d", http.StatusPreconditionRequired, rec.Code)
+	}
+
+	// Same request again: no drift, same ETag, no new subscription
+	rec = putByName(t, router, "prod-alerts", body, map[string]string{"If-Match": firstETag})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rec.Code)
+	}
+	if got := rec.Header().Get("ETag"); got != firstETag {
+		t.Errorf("expected unchanged ETag %s, got %s", firstETag, got)
+	}
+	if len(subRepo.subscriptions) != 1 {
+		t.Errorf("expected 1 subscription, got %d", len(subRepo.subscriptions))
+	}
+
+	// Changing the filter changes the ETag and keeps the secret
+	rec = putByName(t, router, "prod-alerts",
+		`{"delivery":{"type":"webhook","url":"https://example.com/hook"},"filter":{"min_scale":50}}`, map[string]string{"If-Match": "*"})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rec.Code)
+	}
+	if rec.Header().Get("ETag") == firstETag {
+		t.Error("expected ETag to change after update")
+	}
+	stored := subRepo.subscriptions[created.ID]
+	if stored.Filter == nil || stored.Filter.MinScale != 50 {
+		t.Errorf("expected filter to be updated, got %+v", stored.Filter)
+	}
+	if stored.Delivery.Secret != created.Delivery.Secret {
+		t.Error("expected secret to be preserved across updates")
+	}
+}
+
+// listBarrierRepo holds every List call until n callers have listed, so that
+// concurrent by-name PUTs all find the name unused
+type listBarrierRepo struct {
+	subscription.Repository
+	listed sync.WaitGroup
+}
+
+func (r *listBarrierRepo) List(ctx context.Context) ([]subscription.Subscription, error) {
+	subs, err := r.Repository.List(ctx)
+	r.listed.Done()
+	r.listed.Wait()
+	return subs, err
+}
+
+func TestPutSubscriptionByName_ConcurrentCreate(t *testing.T) {
+	const n = 5
+	repo := &listBarrierRepo{Repository: subscription.NewMemoryRepository()}
+	repo.listed.Add(n)
+	router := NewRouter(NewHandler(repo, newMockEventRepo()))
+	body := `{"delivery":{"type":"webhook","url":"https://example.com/hook"}}`
+
+	codes := make(chan int, n)
+	for i := 0; i < n; i++ {
+		go func() {
+			codes <- putByName(t, router, "prod-alerts", body, nil).Code
+		}()
+	}
+	created := 0
+	for i := 0; i < n; i++ {
+		switch code := <-codes; code {
+		case http.StatusCreated:
+			created++
+		case http.StatusConflict:
+		default:
+			t.Errorf("unexpected status %d", code)
+		}
+	}
+	if created != 1 {
+		t.Errorf("%d PUTs created the subscription, want 1", created)
+	}
+	if subs, _ := repo.Repository.List(context.Background()); len(subs) != 1 {
+		t.Fatalf("expected 1 subscription, got %d", len(subs))
+	}
+
+	// The name still resolves to a single subscription
+	req := httptest.NewRequest(http.MethodGet, byNamePrefix+"prod-alerts", nil)
+	rec := httptest.NewRecorder()
+	repo.listed.Add(1)
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+	}
+}
+
+func TestPutSubscriptionByName_Preconditions(t *testing.T) {
+	subRepo := newMockSubscriptionRepo()
+	router := NewRouter(NewHandler(subRepo, newMockEventRepo()))
+	body := `{"delivery":{"type":"webhook","url":"https://example.com/hook"}}`
+
+	rec := putByName(t, router, "alerts", body, map[string]string{"If-None-Match": "*"})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected create-only PUT to succeed, got %d", rec.Code)
+	}
+	etag := rec.Header().Get("ETag")
+
+	rec = putByName(t, router, "alerts", body, map[string]string{"If-None-Match": "*"})
+	if rec.Code != http.StatusPreconditionFailed {
+		t.Errorf("expected %d for create-only PUT on existing, got %d", http.StatusPreconditionFailed, rec.Code)
+	}
+
+	rec = putByName(t, router, "alerts", body, map[string]string{"If-Match": `"stale"`})
+	if rec.Code != http.StatusPreconditionFailed {
+		t.Errorf("expected %d for stale If-Match, got %d", http.StatusPreconditionFailed, rec.Code)
+	}
+
+	rec = putByName(t, router, "alerts", body, map[string]string{"If-Match": etag})
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected %d for matching If-Match, got %d", http.StatusOK, rec.Code)
+	}
+
+	rec = putByName(t, router, "missing", body, map[string]string{"If-Match": "*"})
+	if rec.Code != http.StatusPreconditionFailed {
+		t.Errorf("expected %d for If-Match on missing subscription, got %d", http.StatusPreconditionFailed, rec.Code)
+	}
+}
+
+func TestPutSubscriptionByName_Validation(t *testing.T) {
+	router := NewRouter(NewHandler(newMockSubscriptionRepo(), newMockEventRepo()))
+
+	tests := []struct {
+		name string
+		path string
+		body string
+	}{
+		{name: "name mismatch", path: "alerts", body: `{"name":"other","delivery":{"type":"webhook","url":"https://example.com"}}`},
+		{name: "missing url", path: "alerts", body: `{"delivery":{"type":"webhook"}}`},
+		{name: "malformed body", path: "alerts", body: `{`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := putByName(t, router, tt.path, tt.body, nil)
+			if rec.Code != http.StatusBadRequest {
+				t.Errorf("expected status %d, got %d", http.StatusBadRequest, rec.Code)
+			}
+		})
+	}
+}
+
+func TestPutSubscriptionByName_EncodedName(t *testing.T) {
+	subRepo := newMockSubscriptionRepo()
+	router := NewRouter(NewHandler(subRepo, newMockEventRepo()))
+
+	rec := putByName(t, router, "team%2Fprod%20alerts",
+		`{"delivery":{"type":"webhook","url":"https://example.com/hook"}}`, nil)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, rec.Code, rec.Body.String())
+	}
+
+	var created SubscriptionResponse
+	_ = json.Unmarshal(rec.Body.Bytes(), &created)
+	if created.Name != "team/prod alerts" {
+		t.Errorf("expected decoded name, got %q", created.Name)
+	}
+}
+
+func TestGetSubscriptionByName(t *testing.T) {
+	subRepo := newMockSubscriptionRepo()
+	subRepo.subscriptions["sub-1"] = subscription.Subscription{
+		ID:       "sub-1",
+		Name:     "alerts",
+		Delivery: subscription.DeliveryConfig{Type: "webhook", URL: "https://example.com", Secret: "secret-value"},
+	}
+	router := NewRouter(NewHandler(subRepo, newMockEventRepo()))
+
+	req := httptest.NewRequest(http.MethodGet, byNamePrefix+"alerts", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rec.Code)
+	}
+	etag := rec.Header().Get("ETag")
+	if etag != subscriptionETag(subRepo.subscriptions["sub-1"]) {
+		t.Errorf("unexpected ETag %s", etag)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, byNamePrefix+"alerts", nil)
+	req.Header.Set("If-None-Match", etag)
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotModified {
+		t.Errorf("expected status %d, got %d", http.StatusNotModified, rec.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, byNamePrefix+"missing", nil)
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected status %d, got %d", http.StatusNotFound, rec.Code)
+	}
+}
+
+func TestSubscriptionByName_ScopedToOwner(t *testing.T) {
+	subRepo := newMockSubscriptionRepo()
+	subRepo.subscriptions["other-sub"] = subscription.Subscription{
+		ID:       "other-sub",
+		UserID:   "other-user",
+		Name:     "alerts",
+		Delivery: subscription.DeliveryConfig{Type: "webhook", URL: "https://other.example.com"},
+	}
+	subRepo.subscriptions["legacy-sub"] = subscription.Subscription{
+		ID:       "legacy-sub",
+		Name:     "alerts",
+		Delivery: subscription.DeliveryConfig{Type: "webhook", URL: "https://legacy.example.com"},
+	}
+	h := NewHandler(subRepo, newMockEventRepo())
+
+	req := httptest.NewRequest(http.MethodPut, byNamePrefix+"alerts",
+		bytes.NewBufferString(`{"delivery":{"type":"webhook","url":"https://mine.example.com"}}`))
+	req = req.WithContext(auth.WithClaims(req.Context(), &auth.Claims{UID: "test-uid"}))
+	rec := httptest.NewRecorder()
+	h.PutSubscriptionByName(rec, req)
+
+	// Neither the other user's nor the legacy subscription is touched
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d", http.StatusCreated, rec.Code)
+	}
+	if subRepo.subscriptions["other-sub"].Delivery.URL != "https://other.example.com" {
+		t.Error("other user's subscription must not be modified")
+	}
+	if subRepo.subscriptions["legacy-sub"].Delivery.URL != "https://legacy.example.com" {
+		t.Error("legacy subscription must not be modified")
+	}
+}
+
+func TestSubscriptionByName_Ambiguous(t *testing.T) {
+	subRepo := newMockSubscriptionRepo()
+	for _, id := range []string{"sub-1", "sub-2"} {
+		subRepo.subscriptions[id] = subscription.Subscription{
+			ID:       id,
+			Name:     "dup",
+			Delivery: subscription.DeliveryConfig{Type: "webhook", URL: "https://example.com"},
+		}
+	}
+	router := NewRouter(NewHandler(subRepo, newMockEventRepo()))
+
+	req := httptest.NewRequest(http.MethodGet, byNamePrefix+"dup", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusConflict {
+		t.Errorf("expected status %d, got %d", http.StatusConflict, rec.Code)
+	}
+}
+
+func TestDeleteSubscriptionByName(t *testing.T) {
+	subRepo := newMockSubscriptionRepo()
+	subRepo.subscriptions["sub-1"] = subscription.Subscription{
+		ID:       "sub-1",
+		Name:     "alerts",
+		Delivery: subscription.DeliveryConfig{Type: "webhook", URL: "https://example.com"},
+	}
+	router := NewRouter(NewHandler(subRepo, newMockEventRepo()))
+
+	req := httptest.NewRequest(http.MethodDelete, byNamePrefix+"alerts", nil)
+	req.Header.Set("If-Match", `"stale"`)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusPreconditionFailed {
+		t.Errorf("expected status %d, got %d", http.StatusPreconditionFailed, rec.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodDelete, byNamePrefix+"alerts", nil)
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNoContent {
+		t.Errorf("expected status %d, got %d", http.StatusNoContent, rec.Code)
+	}
+	if len(subRepo.subscriptions) != 0 {
+		t.Error("expected subscription to be deleted")
+	}
+
+	req = httptest.NewRequest(http.MethodDelete, byNamePrefix+"alerts", nil)
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected status %d, got %d", http.StatusNotFound, rec.Code)
+	}
+}
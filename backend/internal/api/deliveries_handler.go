@@ -0,0 +1,193 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/otiai10/namazu/backend/internal/apierr"
+	"github.com/otiai10/namazu/backend/internal/delivery/webhook"
+	"github.com/otiai10/namazu/backend/internal/store"
+	"github.com/otiai10/namazu/backend/internal/subscription"
+)
+
+// Limits of the delivery history listing
+const (
+	defaultDeliveriesLimit = 50
+	maxDeliveriesLimit     = 200
+)
+
+// Redeliverer re-sends a stored event payload to a webhook subscription
+type Redeliverer interface {
+	Redeliver(ctx context.Context, sub subscription.Subscription, eventID string, payload []byte) webhook.DeliveryResult
+}
+
+// DeliveryResponse is a delivery record as returned by the API
+type DeliveryResponse struct {
+	ID             string    `json:"id"`
+	EventID        string    `json:"event_id"`
+	DeliveryID     string    `json:"delivery_id,omitempty"` // X-Delivery-Id of the request
+	StatusCode     int       `json:"status_code"`
+	Success        bool      `json:"success"`
+	ErrorMessage   string    `json:"error_message,omitempty"`
+	RetryCount     int       `json:"retry_count"`
+	ResponseTimeMs int64     `json:"response_time_ms"`
+	ResponseBody   string    `json:"response_body,omitempty"` // Leading bytes of the receiver's response
+	Redelivery     bool      `json:"redelivery"`
+	DeliveredAt    time.Time `json:"delivered_at"`
+}
+
+// GetSubscriptionDeliveries handles GET /api/subscriptions/{id}/deliveries?from=&to=&limit=
+// Returns the most recent deliveries of the subscription in [from, to), newest first.
+// The range defaults to the last 30 days, like the delivery log export.
+func (h *Handler) GetSubscriptionDeliveries(w http.ResponseWriter, r *http.Request, id string) {
+	if h.deliveryRepo == nil {
+		writeError(w, "delivery history is not configured", http.StatusNotImplemented)
+		return
+	}
+
+	from, to, msg := parseDeliveryLogRange(r, time.Now())
+	if msg != "" {
+		writeErrorCode(w, apierr.ValidationFailed, msg, http.StatusBadRequest)
+		return
+	}
+	limit := defaultDeliveriesLimit
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > maxDeliveriesLimit {
+			writeErrorCode(w, apierr.ValidationFailed, "limit must be between 1 and 200", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	sub, forbidden, err := h.checkOwnership(r.Context(), id)
+	if err != nil {
+		writeError(w, "failed to get subscription", http.StatusInternalServerError)
+		return
+	}
+	if sub == nil {
+		writeError(w, "subscription not found", http.StatusNotFound)
+		return
+	}
+	if forbidden {
+		writeErrorCode(w, apierr.NotOwner, "forbidden", http.StatusForbidden)
+		return
+	}
+
+	records, err := h.deliveryRepo.ListBySubscription(r.Context(), sub.ID, from, to)
+	if err != nil {
+		writeError(w, "failed to get deliveries", http.StatusInternalServerError)
+		return
+	}
+
+	// Records are stored oldest first; the dashboard wants the latest
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].DeliveredAt.After(records[j].DeliveredAt)
+	})
+	if len(records) > limit {
+		records = records[:limit]
+	}
+
+	response := make([]DeliveryResponse, 0, len(records))
+	for _, record := range records {
+		response = append(response, newDeliveryResponse(record))
+	}
+	writeJSON(w, response, http.StatusOK)
+}
+
+// newDeliveryResponse converts a delivery record to its API representation
+func newDeliveryResponse(record store.DeliveryRecord) DeliveryResponse {
+	retries := record.Attempts - 1
+	if retries < 0 {
+		retries = 0
+	}
+	return DeliveryResponse{
+		ID:             record.ID,
+		EventID:        record.EventID,
+		DeliveryID:     record.DeliveryID,
+		StatusCode:     record.StatusCode,
+		Success:        record.Success,
+		ErrorMessage:   record.ErrorMessage,
+		RetryCount:     retries,
+		ResponseTimeMs: record.ResponseTimeMs,
+		ResponseBody:   record.ResponseBody,
+		Redelivery:     record.Redelivery,
+		DeliveredAt:    record.DeliveredAt,
+	}
+}
+
+// RedeliverResponse is the outcome of a manual redelivery
+type RedeliverResponse struct {
+	EventID        string `json:"event_id"`
+	StatusCode     int    `json:"status_code"`
+	Success        bool   `json:"success"`
+	ErrorMessage   string `json:"error_message,omitempty"`
+	ResponseTimeMs int64  `json:"response_time_ms"`
+	ResponseBody   string `json:"response_body,omitempty"` // Leading bytes of the receiver's response
+}
+
+// RedeliverDelivery handles POST /api/deliveries/{id}/redeliver
+// Re-sends the stored event payload of a failed delivery to the subscription's
+// current URL, once and without retries. The request carries the
+// X-Namazu-Redelivery header and is recorded as a new delivery.
+func (h *Handler) RedeliverDelivery(w http.ResponseWriter, r *http.Request, id string) {
+	if h.deliveryRepo == nil || h.redeliverer == nil || h.eventRepo == nil {
+		writeError(w, "redelivery is not configured", http.StatusNotImplemented)
+		return
+	}
+
+	record, err := h.deliveryRepo.Get(r.Context(), id)
+	if err != nil {
+		writeError(w, "failed to get delivery", http.StatusInternalServerError)
+		return
+	}
+	if record == nil {
+		writeError(w, "delivery not found", http.StatusNotFound)
+		return
+	}
+
+	sub, forbidden, err := h.checkOwnership(r.Context(), record.SubscriptionID)
+	if err != nil {
+		writeError(w, "failed to get subscription", http.StatusInternalServerError)
+		return
+	}
+	if sub == nil {
+		writeError(w, "delivery not found", http.StatusNotFound)
+		return
+	}
+	if forbidden {
+		writeErrorCode(w, apierr.NotOwner, "forbidden", http.StatusForbidden)
+		return
+	}
+	if record.Success {
+		writeError(w, "delivery already succeeded", http.StatusConflict)
+		return
+	}
+	if sub.Delivery.Type != "webhook" {
+		writeError(w, "only webhook deliveries can be redelivered", http.StatusBadRequest)
+		return
+	}
+
+	event, err := h.eventRepo.Get(r.Context(), record.EventID)
+	if err != nil {
+		writeError(w, "failed to get event", http.StatusInternalServerError)
+		return
+	}
+	if event == nil || event.RawJSON == "" {
+		writeError(w, "event payload is no longer available", http.StatusGone)
+		return
+	}
+
+	result := h.redeliverer.Redeliver(r.Context(), *sub, record.EventID, []byte(event.RawJSON))
+	writeJSON(w, RedeliverResponse{
+		EventID:        record.EventID,
+		StatusCode:     result.StatusCode,
+		Success:        result.Success,
+		ErrorMessage:   result.ErrorMessage,
+		ResponseTimeMs: result.ResponseTime.Milliseconds(),
+		ResponseBody:   result.ResponseBody,
+	}, http.StatusOK)
+}
@@ -0,0 +1,200 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/otiai10/namazu/backend/internal/auth"
+	"github.com/otiai10/namazu/backend/internal/delivery/webhook"
+	"github.com/otiai10/namazu/backend/internal/store"
+	"github.com/otiai10/namazu/backend/internal/subscription"
+)
+
+func TestGetSubscriptionDeliveries(t *testing.T) {
+	subRepo := newMockSubscriptionRepo()
+	subRepo.subscriptions["hist-sub"] = subscription.Subscription{ID: "hist-sub", UserID: "owner-uid"}
+	at := time.Now().Add(-time.Hour).UTC().Truncate(time.Second)
+	deliveryRepo := &mockDeliveryRepo{records: []store.DeliveryRecord{
+		{ID: "d-1", SubscriptionID: "hist-sub", EventID: "ev-1", StatusCode: 200, Success: true, Attempts: 1, ResponseTimeMs: 120, DeliveredAt: at},
+		{SubscriptionID: "hist-sub", EventID: "ev-2", StatusCode: 500, ErrorMessage: "server error", ResponseBody: `{"error":"boom"}`, Attempts: 3, ResponseTimeMs: 80, DeliveredAt: at.Add(time.Minute)},
+		{SubscriptionID: "other-sub", EventID: "ev-1", DeliveredAt: at},
+	}}
+
+	h := NewHandler(subRepo, newMockEventRepo())
+	h.SetDeliveryRepository(deliveryRepo)
+	router := NewRouter(h)
+
+	request := func(path, uid string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req = req.WithContext(auth.WithClaims(req.Context(), &auth.Claims{UID: uid}))
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("lists newest first", func(t *testing.T) {
+		rec := request("/api/subscriptions/hist-sub/deliveries", "owner-uid")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+		}
+		var deliveries []DeliveryResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &deliveries); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if len(deliveries) != 2 {
+			t.Fatalf("len(deliveries) = %d, want 2", len(deliveries))
+		}
+		got := deliveries[0]
+		if got.EventID != "ev-2" || got.StatusCode != 500 || got.Success || got.RetryCount != 2 || got.ResponseTimeMs != 80 || got.ErrorMessage != "server error" || got.ResponseBody != `{"error":"boom"}` {
+			t.Errorf("deliveries[0] = %+v", got)
+		}
+		if deliveries[1].ID != "d-1" || deliveries[1].EventID != "ev-1" || deliveries[1].RetryCount != 0 {
+			t.Errorf("deliveries[1] = %+v", deliveries[1])
+		}
+	})
+
+	t.Run("limit", func(t *testing.T) {
+		rec := request("/api/subscriptions/hist-sub/deliveries?limit=1", "owner-uid")
+		var deliveries []DeliveryResponse
+		json.Unmarshal(rec.Body.Bytes(), &deliveries)
+		if len(deliveries) != 1 || deliveries[0].EventID != "ev-2" {
+			t.Errorf("deliveries = %+v, want only ev-2", deliveries)
+		}
+
+		for _, limit := range []string{"0", "201", "abc"} {
+			if rec := request("/api/subscriptions/hist-sub/deliveries?limit="+limit, "owner-uid"); rec.Code != http.StatusBadRequest {
+				t.Errorf("limit=%s: expected status %d, got %d", limit, http.StatusBadRequest, rec.Code)
+			}
+		}
+	})
+
+	t.Run("forbidden for other users", func(t *testing.T) {
+		if rec := request("/api/subscriptions/hist-sub/deliveries", "other-uid"); rec.Code != http.StatusForbidden {
+			t.Errorf("expected status %d, got %d", http.StatusForbidden, rec.Code)
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		if rec := request("/api/subscriptions/missing/deliveries", "owner-uid"); rec.Code != http.StatusNotFound {
+			t.Errorf("expected status %d, got %d", http.StatusNotFound, rec.Code)
+		}
+	})
+}
+
+func TestGetSubscriptionDeliveries_NotConfigured(t *testing.T) {
+	subRepo := newMockSubscriptionRepo()
+	subRepo.subscriptions["hist-sub"] = subscription.Subscription{ID: "hist-sub"}
+	router := NewRouter(NewHandler(subRepo, newMockEventRepo()))
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/subscriptions/hist-sub/deliveries", nil))
+	if rec.Code != http.StatusNotImplemented {
+		t.Errorf("expected status %d, got %d", http.StatusNotImplemented, rec.Code)
+	}
+}
+
+// mockRedeliverer records redeliveries and returns a fixed result
+type mockRedeliverer struct {
+	result  webhook.DeliveryResult
+	subs    []subscription.Subscription
+	payload []byte
+}
+
+func (m *mockRedeliverer) Redeliver(ctx context.Context, sub subscription.Subscription, eventID string, payload []byte) webhook.DeliveryResult {
+	m.subs = append(m.subs, sub)
+	m.payload = payload
+	return m.result
+}
+
+func TestRedeliverDelivery(t *testing.T) {
+	subRepo := newMockSubscriptionRepo()
+	subRepo.subscriptions["hook-sub"] = subscription.Subscription{ID: "hook-sub", UserID: "owner-uid", Delivery: subscription.DeliveryConfig{Type: "webhook", URL: "https://example.com/hook"}}
+	subRepo.subscriptions["mail-sub"] = subscription.Subscription{ID: "mail-sub", UserID: "owner-uid", Delivery: subscription.DeliveryConfig{Type: "email"}}
+	eventRepo := newMockEventRepo()
+	eventRepo.events = append(eventRepo.events,
+		store.EventRecord{ID: "ev-1", RawJSON: `{"_id":"ev-1"}`},
+		store.EventRecord{ID: "ev-empty"},
+	)
+	deliveryRepo := &mockDeliveryRepo{records: []store.DeliveryRecord{
+		{ID: "failed", SubscriptionID: "hook-sub", EventID: "ev-1", StatusCode: 500},
+		{ID: "succeeded", SubscriptionID: "hook-sub", EventID: "ev-1", StatusCode: 200, Success: true},
+		{ID: "gone", SubscriptionID: "hook-sub", EventID: "ev-missing", StatusCode: 500},
+		{ID: "empty", SubscriptionID: "hook-sub", EventID: "ev-empty", StatusCode: 500},
+		{ID: "mail", SubscriptionID: "mail-sub", EventID: "ev-1"},
+		{ID: "orphan", SubscriptionID: "deleted-sub", EventID: "ev-1"},
+	}}
+	redeliverer := &mockRedeliverer{result: webhook.DeliveryResult{StatusCode: 200, Success: true, ResponseTime: 42 * time.Millisecond}}
+
+	h := NewHandler(subRepo, eventRepo)
+	h.SetDeliveryRepository(deliveryRepo)
+	h.SetRedeliverer(redeliverer)
+	router := NewRouter(h)
+
+	request := func(method, path, uid string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req = req.WithContext(auth.WithClaims(req.Context(), &auth.Claims{UID: uid}))
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("re-sends the stored payload", func(t *testing.T) {
+		rec := request(http.MethodPost, "/api/deliveries/failed/redeliver", "owner-uid")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+		}
+		var resp RedeliverResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if !resp.Success || resp.StatusCode != 200 || resp.EventID != "ev-1" || resp.ResponseTimeMs != 42 {
+			t.Errorf("response = %+v", resp)
+		}
+		if len(redeliverer.subs) != 1 || redeliverer.subs[0].ID != "hook-sub" || string(redeliverer.payload) != `{"_id":"ev-1"}` {
+			t.Errorf("redelivered %+v with %s", redeliverer.subs, redeliverer.payload)
+		}
+	})
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		uid    string
+		want   int
+	}{
+		{"other users are forbidden", http.MethodPost, "/api/deliveries/failed/redeliver", "other-uid", http.StatusForbidden},
+		{"unknown delivery", http.MethodPost, "/api/deliveries/missing/redeliver", "owner-uid", http.StatusNotFound},
+		{"deleted subscription", http.MethodPost, "/api/deliveries/orphan/redeliver", "owner-uid", http.StatusNotFound},
+		{"successful delivery", http.MethodPost, "/api/deliveries/succeeded/redeliver", "owner-uid", http.StatusConflict},
+		{"event without payload", http.MethodPost, "/api/deliveries/empty/redeliver", "owner-uid", http.StatusGone},
+		{"non-webhook subscription", http.MethodPost, "/api/deliveries/mail/redeliver", "owner-uid", http.StatusBadRequest},
+		{"GET is not allowed", http.MethodGet, "/api/deliveries/failed/redeliver", "owner-uid", http.StatusMethodNotAllowed},
+		{"invalid path", http.MethodPost, "/api/deliveries/failed", "owner-uid", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			redeliverer.subs = nil
+			rec := request(tt.method, tt.path, tt.uid)
+			if rec.Code != tt.want {
+				t.Errorf("expected status %d, got %d: %s", tt.want, rec.Code, rec.Body.String())
+			}
+			if len(redeliverer.subs) != 0 {
+				t.Error("nothing should be redelivered")
+			}
+		})
+	}
+}
+
+func TestRedeliverDelivery_NotConfigured(t *testing.T) {
+	router := NewRouter(NewHandler(newMockSubscriptionRepo(), newMockEventRepo()))
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/deliveries/d-1/redeliver", nil))
+	if rec.Code != http.StatusNotImplemented {
+		t.Errorf("expected status %d, got %d", http.StatusNotImplemented, rec.Code)
+	}
+}
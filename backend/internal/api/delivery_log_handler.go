@@ -0,0 +1,112 @@
+package api
+
+import (
+	"encoding/base64"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/otiai10/namazu/backend/internal/apierr"
+	"github.com/otiai10/namazu/backend/internal/deliverylog"
+)
+
+// Range limits of delivery log exports
+const (
+	defaultDeliveryLogRange = 30 * 24 * time.Hour
+	maxDeliveryLogRange     = 366 * 24 * time.Hour
+)
+
+// PublicKeyResponse describes the key that signs delivery logs
+type PublicKeyResponse struct {
+	KeyID     string `json:"key_id"`
+	Algorithm string `json:"algorithm"`
+	PublicKey string `json:"public_key"` // Base64-encoded raw Ed25519 public key
+}
+
+// GetSubscriptionDeliveryLog handles GET /api/subscriptions/{id}/delivery-log?from=&to=
+// Returns the deliveries of the subscription in [from, to) as signed NDJSON
+// (see package deliverylog). from and to are RFC 3339; the default range is
+// the last 30 days.
+func (h *Handler) GetSubscriptionDeliveryLog(w http.ResponseWriter, r *http.Request, id string) {
+	if h.deliveryLog == nil || h.deliveryRepo == nil {
+		writeError(w, "delivery log export is not configured", http.StatusNotImplemented)
+		return
+	}
+
+	from, to, msg := parseDeliveryLogRange(r, time.Now())
+	if msg != "" {
+		writeErrorCode(w, apierr.ValidationFailed, msg, http.StatusBadRequest)
+		return
+	}
+
+	sub, forbidden, err := h.checkOwnership(r.Context(), id)
+	if err != nil {
+		writeError(w, "failed to get subscription", http.StatusInternalServerError)
+		return
+	}
+	if sub == nil {
+		writeError(w, "subscription not found", http.StatusNotFound)
+		return
+	}
+	if forbidden {
+		writeErrorCode(w, apierr.NotOwner, "forbidden", http.StatusForbidden)
+		return
+	}
+
+	records, err := h.deliveryRepo.ListBySubscription(r.Context(), sub.ID, from, to)
+	if err != nil {
+		writeError(w, "failed to get deliveries", http.StatusInternalServerError)
+		return
+	}
+
+	filename := "delivery-log-" + sub.ID + "-" + from.UTC().Format("20060102") + "-" + to.UTC().Format("20060102") + ".ndjson"
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+	w.WriteHeader(http.StatusOK)
+	if err := h.deliveryLog.Write(w, sub.ID, from, to, records); err != nil {
+		// Already wrote headers, can only log
+		log.Printf("Subscription [%s]: failed to write delivery log: %v", sub.ID, err)
+	}
+}
+
+// GetDeliveryLogPublicKey handles GET /api/delivery-log/public-key
+func (h *Handler) GetDeliveryLogPublicKey(w http.ResponseWriter, r *http.Request) {
+	if h.deliveryLog == nil {
+		writeError(w, "delivery log export is not configured", http.StatusNotImplemented)
+		return
+	}
+	writeJSON(w, PublicKeyResponse{
+		KeyID:     h.deliveryLog.KeyID(),
+		Algorithm: deliverylog.Algorithm,
+		PublicKey: base64.StdEncoding.EncodeToString(h.deliveryLog.PublicKey()),
+	}, http.StatusOK)
+}
+
+// parseDeliveryLogRange reads the from/to query parameters.
+// It returns an error message for invalid ranges.
+func parseDeliveryLogRange(r *http.Request, now time.Time) (from, to time.Time, msg string) {
+	q := r.URL.Query()
+	to = now
+	if v := q.Get("to"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return from, to, "to must be an RFC 3339 timestamp"
+		}
+		to = t
+	}
+	from = to.Add(-defaultDeliveryLogRange)
+	if v := q.Get("from"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return from, to, "from must be an RFC 3339 timestamp"
+		}
+		from = t
+	}
+	if !from.Before(to) {
+		return from, to, "from must be before to"
+	}
+	if to.Sub(from) > maxDeliveryLogRange {
+		return from, to, "range must not exceed 366 days"
+	}
+	return from, to, ""
+}
@@ -0,0 +1,228 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/otiai10/namazu/backend/internal/auth"
+	"github.com/otiai10/namazu/backend/internal/deliverylog"
+	"github.com/otiai10/namazu/backend/internal/store"
+	"github.com/otiai10/namazu/backend/internal/subscription"
+)
+
+// mockDeliveryRepo is a mock implementation of store.DeliveryRepository
+type mockDeliveryRepo struct {
+	records []store.DeliveryRecord
+}
+
+func (m *mockDeliveryRepo) Create(ctx context.Context, record store.DeliveryRecord) (string, error) {
+	m.records = append(m.records, record)
+	return "delivery-1", nil
+}
+
+func (m *mockDeliveryRepo) Get(ctx context.Context, id string) (*store.DeliveryRecord, error) {
+	for i, r := range m.records {
+		if r.ID == id {
+			record := m.records[i]
+			return &record, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *mockDeliveryRepo) ListBySubscription(ctx context.Context, subscriptionID string, from, to time.Time) ([]store.DeliveryRecord, error) {
+	var result []store.DeliveryRecord
+	for _, r := range m.records {
+		if r.SubscriptionID == subscriptionID && !r.DeliveredAt.Before(from) && r.DeliveredAt.Before(to) {
+			result = append(result, r)
+		}
+	}
+	return result, nil
+}
+
+func (m *mockDeliveryRepo) LastSuccess(ctx context.Context, subscriptionID string) (*store.DeliveryRecord, error) {
+	var last *store.DeliveryRecord
+	for i, r := range m.records {
+		if r.SubscriptionID == subscriptionID && r.Success && (last == nil || r.DeliveredAt.After(last.DeliveredAt)) {
+			last = &m.records[i]
+		}
+	}
+	return last, nil
+}
+
+func (m *mockDeliveryRepo) SummarizeSubscription(ctx context.Context, subscriptionID string, from, to time.Time) (store.DeliverySummary, error) {
+	var summary store.DeliverySummary
+	for _, r := range m.records {
+		if r.SubscriptionID != subscriptionID || r.DeliveredAt.Before(from) || !r.DeliveredAt.Before(to) {
+			continue
+		}
+		if r.Success {
+			summary.Delivered++
+		} else {
+			summary.Failed++
+		}
+	}
+	return summary, nil
+}
+
+func (m *mockDeliveryRepo) SummarizeEvent(ctx context.Context, eventID string) (store.DeliverySummary, error) {
+	var summary store.DeliverySummary
+	for _, r := range m.records {
+		if r.EventID != eventID {
+			continue
+		}
+		if r.Success {
+			summary.Delivered++
+		} else {
+			summary.Failed++
+		}
+	}
+	return summary, nil
+}
+
+func (m *mockDeliveryRepo) DeleteBySubscription(ctx context.Context, subscriptionID string) (int, error) {
+	kept := m.records[:0]
+	for _, r := range m.records {
+		if r.SubscriptionID != subscriptionID {
+			kept = append(kept, r)
+		}
+	}
+	deleted := len(m.records) - len(kept)
+	m.records = kept
+	return deleted, nil
+}
+
+func TestGetSubscriptionDeliveryLog(t *testing.T) {
+	subRepo := newMockSubscriptionRepo()
+	subRepo.subscriptions["log-sub"] = subscription.Subscription{
+		ID:       "log-sub",
+		UserID:   "owner-uid",
+		Name:     "Prod Alerts",
+		Delivery: subscription.DeliveryConfig{Type: "webhook", URL: "https://example.com/hook"},
+	}
+	at := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	deliveryRepo := &mockDeliveryRepo{records: []store.DeliveryRecord{
+		{SubscriptionID: "log-sub", EventID: "ev-1", URL: "https://example.com/hook", StatusCode: 200, Success: true, Attempts: 1, DeliveredAt: at},
+		{SubscriptionID: "log-sub", EventID: "ev-2", URL: "https://example.com/hook", StatusCode: 500, Attempts: 3, DeliveredAt: at.Add(time.Hour)},
+		{SubscriptionID: "other-sub", EventID: "ev-1", DeliveredAt: at},
+	}}
+	signer, err := deliverylog.NewSigner(bytes.Repeat([]byte{7}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h := NewHandler(subRepo, newMockEventRepo())
+	h.SetDeliveryLog(deliveryRepo, signer)
+	router := NewRouter(h)
+
+	request := func(path, uid string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if uid != "" {
+			req = req.WithContext(auth.WithClaims(req.Context(), &auth.Claims{UID: uid}))
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("exports a verifiable log", func(t *testing.T) {
+		rec := request("/api/subscriptions/log-sub/delivery-log?from=2026-03-01T00:00:00Z&to=2026-03-02T00:00:00Z", "owner-uid")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+		}
+		if ct := rec.Header().Get("Content-Type"); ct != "application/x-ndjson" {
+			t.Errorf("unexpected content type %q", ct)
+		}
+		if cd := rec.Header().Get("Content-Disposition"); !strings.Contains(cd, "delivery-log-log-sub-20260301-20260302.ndjson") {
+			t.Errorf("unexpected content disposition %q", cd)
+		}
+
+		log, err := deliverylog.Verify(rec.Body, signer.PublicKey())
+		if err != nil {
+			t.Fatalf("Verify() error = %v", err)
+		}
+		if log.Header.SubscriptionID != "log-sub" {
+			t.Errorf("SubscriptionID = %q, want log-sub", log.Header.SubscriptionID)
+		}
+		if len(log.Entries) != 2 || log.Entries[1].EventID != "ev-2" {
+			t.Errorf("unexpected entries: %+v", log.Entries)
+		}
+	})
+
+	t.Run("range excludes other deliveries", func(t *testing.T) {
+		rec := request("/api/subscriptions/log-sub/delivery-log?from=2026-03-01T12:30:00Z&to=2026-03-02T00:00:00Z", "owner-uid")
+		log, err := deliverylog.Verify(rec.Body, signer.PublicKey())
+		if err != nil {
+			t.Fatalf("Verify() error = %v", err)
+		}
+		if len(log.Entries) != 1 {
+			t.Errorf("expected 1 entry, got %d", len(log.Entries))
+		}
+	})
+
+	t.Run("rejects invalid ranges", func(t *testing.T) {
+		for _, query := range []string{
+			"from=yesterday",
+			"to=2026-03-01",
+			"from=2026-03-02T00:00:00Z&to=2026-03-01T00:00:00Z",
+			"from=2020-01-01T00:00:00Z&to=2026-03-01T00:00:00Z",
+		} {
+			rec := request("/api/subscriptions/log-sub/delivery-log?"+query, "owner-uid")
+			if rec.Code != http.StatusBadRequest {
+				t.Errorf("%s: expected status %d, got %d", query, http.StatusBadRequest, rec.Code)
+			}
+		}
+	})
+
+	t.Run("forbidden for other users", func(t *testing.T) {
+		rec := request("/api/subscriptions/log-sub/delivery-log", "other-uid")
+		if rec.Code != http.StatusForbidden {
+			t.Errorf("expected status %d, got %d", http.StatusForbidden, rec.Code)
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		rec := request("/api/subscriptions/missing/delivery-log", "owner-uid")
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("expected status %d, got %d", http.StatusNotFound, rec.Code)
+		}
+	})
+
+	t.Run("public key", func(t *testing.T) {
+		rec := request("/api/delivery-log/public-key", "")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, rec.Code)
+		}
+		var resp PublicKeyResponse
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		if resp.KeyID != signer.KeyID() || resp.Algorithm != "ed25519" {
+			t.Errorf("unexpected response: %+v", resp)
+		}
+		if resp.PublicKey != base64.StdEncoding.EncodeToString(signer.PublicKey()) {
+			t.Errorf("PublicKey = %q", resp.PublicKey)
+		}
+	})
+}
+
+func TestGetSubscriptionDeliveryLog_NotConfigured(t *testing.T) {
+	subRepo := newMockSubscriptionRepo()
+	subRepo.subscriptions["log-sub"] = subscription.Subscription{ID: "log-sub"}
+	router := NewRouter(NewHandler(subRepo, newMockEventRepo()))
+
+	for _, path := range []string{"/api/subscriptions/log-sub/delivery-log", "/api/delivery-log/public-key"} {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusNotImplemented {
+			t.Errorf("%s: expected status %d, got %d", path, http.StatusNotImplemented, rec.Code)
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/otiai10/namazu/backend/internal/apierr"
+	"github.com/otiai10/namazu/backend/internal/auth"
+	"github.com/otiai10/namazu/backend/internal/delivery/fcm"
+	"github.com/otiai10/namazu/backend/internal/region"
+	"github.com/otiai10/namazu/backend/internal/user"
+)
+
+// maxDeviceTokenLength bounds an FCM registration token
+const maxDeviceTokenLength = 4096
+
+// maxDeviceNameLength bounds the display name of a device
+const maxDeviceNameLength = 100
+
+// DeviceTopics keeps registered devices subscribed to their prefecture alert topics
+type DeviceTopics interface {
+	// Sync moves a device from the topics of previous (nil for a new device)
+	// to those of current (nil for a removed device)
+	Sync(ctx context.Context, previous, current *user.Device) error
+}
+
+// DeviceRequest registers a mobile app install for FCM
+type DeviceRequest struct {
+	Token       string   `json:"token"`
+	Platform    string   `json:"platform"` // "android" | "ios" | "web"
+	Name        string   `json:"name,omitempty"`
+	Prefectures []string `json:"prefectures,omitempty"` // Prefecture alerts, delivered without a subscription; regions expand to their prefectures
+	MinScale    int      `json:"minScale,omitempty"`    // Threshold of prefecture alerts; 0 means 震度3
+}
+
+// DevicesResponse lists the devices registered for FCM
+type DevicesResponse struct {
+	Devices []user.Device `json:"devices"`
+}
+
+// SetDeviceTopics enables /api/me/devices
+func (h *MeHandler) SetDeviceTopics(t DeviceTopics) {
+	h.deviceTopics = t
+}
+
+// ListDevices handles GET /api/me/devices
+func (h *MeHandler) ListDevices(w http.ResponseWriter, r *http.Request) {
+	claims := auth.MustGetClaims(r.Context())
+
+	if h.deviceTopics == nil {
+		writeError(w, "fcm is not enabled", http.StatusNotImplemented)
+		return
+	}
+
+	u, err := h.userRepo.GetByUID(r.Context(), claims.UID)
+	if err != nil {
+		writeError(w, "failed to get user", http.StatusInternalServerError)
+		return
+	}
+
+	resp := DevicesResponse{Devices: []user.Device{}}
+	if u != nil && u.Devices != nil {
+		resp.Devices = u.Devices
+	}
+	writeJSON(w, resp, http.StatusOK)
+}
+
+// CreateDevice handles POST /api/me/devices.
+// Registering a token again replaces its settings and prefecture alerts.
+func (h *MeHandler) CreateDevice(w http.ResponseWriter, r *http.Request) {
+	claims := auth.MustGetClaims(r.Context())
+
+	if h.deviceTopics == nil {
+		writeError(w, "fcm is not enabled", http.StatusNotImplemented)
+		return
+	}
+
+	var req DeviceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.Token == "" || len(req.Token) > maxDeviceTokenLength {
+		writeErrorCode(w, apierr.ValidationFailed, "token is required", http.StatusBadRequest)
+		return
+	}
+	switch req.Platform {
+	case user.PlatformAndroid, user.PlatformIOS, user.PlatformWeb:
+	default:
+		writeErrorCode(w, apierr.ValidationFailed, "platform must be android, ios or web", http.StatusBadRequest)
+		return
+	}
+	name := strings.TrimSpace(req.Name)
+	if len(name) > maxDeviceNameLength {
+		writeErrorCode(w, apierr.ValidationFailed, "name is too long", http.StatusBadRequest)
+		return
+	}
+	// Stored as full names, so that topics and the app show one spelling
+	prefectures, err := region.Expand(req.Prefectures)
+	if err != nil {
+		writeErrorCode(w, apierr.ValidationFailed, "invalid prefecture alerts: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := fcm.ValidateAlerts(prefectures, req.MinScale); err != nil {
+		writeErrorCode(w, apierr.ValidationFailed, "invalid prefecture alerts: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	u, err := h.userRepo.GetByUID(r.Context(), claims.UID)
+	if err != nil {
+		writeError(w, "failed to get user", http.StatusInternalServerError)
+		return
+	}
+	if u == nil {
+		u, err = h.createNewUser(r, claims)
+		if err != nil {
+			writeError(w, "failed to create user", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	device := user.Device{
+		Token:       req.Token,
+		Platform:    req.Platform,
+		Name:        name,
+		Prefectures: prefectures,
+		MinScale:    req.MinScale,
+		CreatedAt:   time.Now().UTC(),
+	}
+	var previous *user.Device
+	for i := range u.Devices {
+		if u.Devices[i].Token == device.Token {
+			previous = &u.Devices[i]
+		}
+	}
+	err = h.deviceTopics.Sync(r.Context(), previous, &device)
+	if errors.Is(err, fcm.ErrTokenRejected) {
+		writeErrorCode(w, apierr.ValidationFailed, "invalid device token", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		writeError(w, "failed to subscribe device to alert topics", http.StatusBadGateway)
+		return
+	}
+
+	// The oldest device is dropped beyond the limit; stop its alerts too
+	if previous == nil && len(u.Devices) >= user.MaxDevices {
+		if err := h.deviceTopics.Sync(r.Context(), &u.Devices[0], nil); err != nil {
+			log.Printf("Failed to unsubscribe dropped device from alert topics: %v", err)
+		}
+	}
+
+	if err := h.userRepo.AddDevice(r.Context(), u.ID, device); err != nil {
+		writeError(w, "failed to save device", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, device, http.StatusCreated)
+}
+
+// DeleteDevice handles DELETE /api/me/devices?token=...
+func (h *MeHandler) DeleteDevice(w http.ResponseWriter, r *http.Request) {
+	claims := auth.MustGetClaims(r.Context())
+
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		writeErrorCode(w, apierr.ValidationFailed, "token is required", http.StatusBadRequest)
+		return
+	}
+
+	u, err := h.userRepo.GetByUID(r.Context(), claims.UID)
+	if err != nil {
+		writeError(w, "failed to get user", http.StatusInternalServerError)
+		return
+	}
+	var device *user.Device
+	if u != nil {
+		for i := range u.Devices {
+			if u.Devices[i].Token == token {
+				device = &u.Devices[i]
+			}
+		}
+	}
+	if device == nil {
+		writeError(w, "device not found", http.StatusNotFound)
+		return
+	}
+
+	// An uninstalled app's token can no longer be unsubscribed; remove it anyway
+	if h.deviceTopics != nil {
+		if err := h.deviceTopics.Sync(r.Context(), device, nil); err != nil {
+			log.Printf("Failed to unsubscribe device from alert topics: %v", err)
+		}
+	}
+
+	err = h.userRepo.RemoveDevice(r.Context(), u.ID, token)
+	if errors.Is(err, user.ErrDeviceNotFound) || errors.Is(err, user.ErrNotFound) {
+		writeError(w, "device not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		writeError(w, "failed to delete device", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
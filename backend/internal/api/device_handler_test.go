@@ -0,0 +1,118 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/otiai10/namazu/backend/internal/delivery/fcm"
+	"github.com/otiai10/namazu/backend/internal/user"
+)
+
+// fakeDeviceTopics records topic syncs as "previous -> current" prefectures
+type fakeDeviceTopics struct {
+	syncs []string
+	err   error
+}
+
+func (f *fakeDeviceTopics) Sync(ctx context.Context, previous, current *user.Device) error {
+	describe := func(d *user.Device) string {
+		if d == nil {
+			return "none"
+		}
+		return fmt.Sprint(d.Prefectures)
+	}
+	f.syncs = append(f.syncs, describe(previous)+" -> "+describe(current))
+	return f.err
+}
+
+func TestMeHandler_Devices(t *testing.T) {
+	repo := newMockUserRepo()
+	topics := &fakeDeviceTopics{}
+	handler := NewMeHandler(repo)
+	handler.SetDeviceTopics(topics)
+
+	register := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.CreateDevice(rec, withUser(httptest.NewRequest(http.MethodPost, "/api/me/devices", bytes.NewBufferString(body)), "test-uid"))
+		return rec
+	}
+
+	// Registering creates the user on first use
+	if rec := register(`{"token": "tok", "platform": "android", "name": "Pixel", "prefectures": ["石川県"]}`); rec.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, rec.Code, rec.Body.String())
+	}
+	// Other spellings are stored as full names
+	if rec := register(`{"token": "tok", "platform": "android", "prefectures": ["Ishikawa", "富山"], "minScale": 40}`); rec.Code != http.StatusCreated {
+		t.Fatalf("expected status %d re-registering, got %d: %s", http.StatusCreated, rec.Code, rec.Body.String())
+	}
+	want := []string{"none -> [石川県]", "[石川県] -> [石川県 富山県]"}
+	if fmt.Sprint(topics.syncs) != fmt.Sprint(want) {
+		t.Errorf("syncs = %v, want %v", topics.syncs, want)
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ListDevices(rec, withUser(httptest.NewRequest(http.MethodGet, "/api/me/devices", nil), "test-uid"))
+	var list DevicesResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &list); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if len(list.Devices) != 1 || list.Devices[0].MinScale != 40 || len(list.Devices[0].Prefectures) != 2 {
+		t.Fatalf("unexpected list: %+v", list)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.DeleteDevice(rec, withUser(httptest.NewRequest(http.MethodDelete, "/api/me/devices?token=tok", nil), "test-uid"))
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusNoContent, rec.Code, rec.Body.String())
+	}
+	if last := topics.syncs[len(topics.syncs)-1]; last != "[石川県 富山県] -> none" {
+		t.Errorf("expected the deleted device to leave its topics, got %s", last)
+	}
+	rec = httptest.NewRecorder()
+	handler.DeleteDevice(rec, withUser(httptest.NewRequest(http.MethodDelete, "/api/me/devices?token=tok", nil), "test-uid"))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected status %d for a removed device, got %d", http.StatusNotFound, rec.Code)
+	}
+}
+
+func TestMeHandler_CreateDevice_Invalid(t *testing.T) {
+	topics := &fakeDeviceTopics{}
+	handler := NewMeHandler(newMockUserRepo())
+	handler.SetDeviceTopics(topics)
+
+	tests := []struct {
+		name string
+		body string
+		err  error
+		want int
+	}{
+		{name: "missing token", body: `{"platform": "ios"}`, want: http.StatusBadRequest},
+		{name: "unknown platform", body: `{"token": "tok", "platform": "windows"}`, want: http.StatusBadRequest},
+		{name: "unknown prefecture", body: `{"token": "tok", "platform": "ios", "prefectures": ["Atlantis"]}`, want: http.StatusBadRequest},
+		{name: "unknown scale", body: `{"token": "tok", "platform": "ios", "prefectures": ["東京都"], "minScale": 35}`, want: http.StatusBadRequest},
+		{name: "rejected token", body: `{"token": "tok", "platform": "ios"}`, err: fmt.Errorf("subscribe: %w", fcm.ErrTokenRejected), want: http.StatusBadRequest},
+		{name: "fcm unavailable", body: `{"token": "tok", "platform": "ios"}`, err: errors.New("unavailable"), want: http.StatusBadGateway},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			topics.err = tt.err
+			rec := httptest.NewRecorder()
+			handler.CreateDevice(rec, withUser(httptest.NewRequest(http.MethodPost, "/api/me/devices", bytes.NewBufferString(tt.body)), "test-uid"))
+			if rec.Code != tt.want {
+				t.Errorf("expected status %d, got %d: %s", tt.want, rec.Code, rec.Body.String())
+			}
+		})
+	}
+
+	rec := httptest.NewRecorder()
+	NewMeHandler(newMockUserRepo()).ListDevices(rec, withUser(httptest.NewRequest(http.MethodGet, "/api/me/devices", nil), "test-uid"))
+	if rec.Code != http.StatusNotImplemented {
+		t.Errorf("expected status %d when fcm is not enabled, got %d", http.StatusNotImplemented, rec.Code)
+	}
+}
@@ -0,0 +1,24 @@
+package api
+
+import "net/http"
+
+// EgressIPsResponse lists the source addresses of webhook deliveries
+type EgressIPsResponse struct {
+	IPs []string `json:"ips"` // IP addresses or CIDR ranges
+}
+
+// SetEgressIPs publishes the source addresses of webhooks at GET /api/egress-ips
+func (h *Handler) SetEgressIPs(ips []string) {
+	h.egressIPs = ips
+}
+
+// GetEgressIPs handles GET /api/egress-ips
+// Receivers allowlist these addresses to accept only namazu's webhooks.
+func (h *Handler) GetEgressIPs(w http.ResponseWriter, r *http.Request) {
+	if len(h.egressIPs) == 0 {
+		writeError(w, "egress IPs are not published", http.StatusNotImplemented)
+		return
+	}
+	w.Header().Set("Cache-Control", "public, max-age=3600")
+	writeJSON(w, EgressIPsResponse{IPs: h.egressIPs}, http.StatusOK)
+}
@@ -0,0 +1,40 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRouter_EgressIPs(t *testing.T) {
+	request := func(ips []string) *httptest.ResponseRecorder {
+		router := NewRouterWithConfig(RouterConfig{
+			SubscriptionRepo: newMockSubscriptionRepo(),
+			EventRepo:        newMockEventRepo(),
+			EgressIPs:        ips,
+		})
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/egress-ips", nil))
+		return rec
+	}
+
+	rec := request([]string{"203.0.113.10", "198.51.100.0/28"})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+	}
+	var resp EgressIPsResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(resp.IPs) != 2 || resp.IPs[0] != "203.0.113.10" || resp.IPs[1] != "198.51.100.0/28" {
+		t.Errorf("ips = %v", resp.IPs)
+	}
+	if got := rec.Header().Get("Cache-Control"); got != "public, max-age=3600" {
+		t.Errorf("Cache-Control = %q", got)
+	}
+
+	if rec := request(nil); rec.Code != http.StatusNotImplemented {
+		t.Errorf("expected status %d without egress IPs, got %d", http.StatusNotImplemented, rec.Code)
+	}
+}
@@ -0,0 +1,150 @@
+package api
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/otiai10/namazu/backend/internal/subscription"
+)
+
+// subscriptionETag returns a strong ETag for the stored state of a subscription.
+// It covers every user-visible and server-managed field (including the secret,
+// so rotation changes the tag) and the revision, so a write that restores
+// earlier content still changes the tag. The ID and owner never change.
+func subscriptionETag(sub subscription.Subscription) string {
+	state := struct {
+		Revision   int64                        `json:"revision,omitempty"`
+		Name       string                       `json:"name"`
+		Delivery   subscription.DeliveryConfig  `json:"delivery"`
+		Filter     *subscription.FilterConfig   `json:"filter,omitempty"`
+		Locale     string                       `json:"locale,omitempty"`
+		QuietHours *subscription.QuietHours     `json:"quiet_hours,omitempty"`
+		Throttle   *subscription.ThrottleConfig `json:"throttle,omitempty"`
+		Digest     *subscription.DigestConfig   `json:"digest,omitempty"`
+		ExpiresAt  *time.Time                   `json:"expires_at,omitempty"`
+		Status     string                       `json:"status,omitempty"`
+		PausedAt   *time.Time                   `json:"paused_at,omitempty"`
+	}{
+		Revision:   sub.Revision,
+		Name:       sub.Name,
+		Delivery:   sub.Delivery,
+		Filter:     sub.Filter,
+		Locale:     sub.Locale,
+		QuietHours: sub.QuietHours,
+		Throttle:   sub.Throttle,
+		Digest:     sub.Digest,
+		ExpiresAt:  copyTime(sub.ExpiresAt),
+		Status:     sub.Status,
+		PausedAt:   copyTime(sub.PausedAt),
+	}
+	if state.Filter != nil && len(state.Filter.Prefectures) == 0 {
+		// nil and empty prefectures are equivalent
+		filter := *state.Filter
+		filter.Prefectures = nil
+		state.Filter = &filter
+	}
+
+	data, _ := json.Marshal(state)
+	sum := sha256.Sum256(data)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// etagMatches reports whether an If-Match / If-None-Match header value matches etag.
+// Only strong comparison is supported; weak validators never match.
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// checkPreconditions evaluates If-Match and If-None-Match against the current
+// subscription (nil if it does not exist). It writes 412 and returns false when
+// a precondition fails.
+func checkPreconditions(w http.ResponseWriter, r *http.Request, current *subscription.Subscription) bool {
+	currentETag := ""
+	if current != nil {
+		currentETag = subscriptionETag(*current)
+	}
+
+	if ifMatch := r.Header.Get("If-Match"); ifMatch != "" {
+		if current == nil || !etagMatches(ifMatch, currentETag) {
+			writeError(w, "precondition failed: subscription has changed", http.StatusPreconditionFailed)
+			return false
+		}
+	}
+
+	if ifNoneMatch := r.Header.Get("If-None-Match"); ifNoneMatch != "" {
+		if current != nil && etagMatches(ifNoneMatch, currentETag) {
+			writeError(w, "precondition failed: subscription already exists", http.StatusPreconditionFailed)
+			return false
+		}
+	}
+
+	return true
+}
+
+// requireIfMatch writes 428 and returns false if the request has no If-Match.
+// Replacing a subscription needs the ETag it was read with, so an edit made
+// from a stale copy (e.g. another dashboard tab) cannot silently overwrite a newer one.
+func requireIfMatch(w http.ResponseWriter, r *http.Request) bool {
+	if r.Header.Get("If-Match") == "" {
+		writeError(w, "If-Match is required: send the ETag of the subscription as read", http.StatusPreconditionRequired)
+		return false
+	}
+	return true
+}
+
+// saveSubscription writes sub over the revision it was read at and returns it
+// as stored: at the next revision, changed now. A write racing another one
+// fails with subscription.ErrRevisionConflict, also for subscriptions stored
+// before revisions existed (revision 0).
+func saveSubscription(ctx context.Context, repo subscription.Repository, id string, sub subscription.Subscription) (subscription.Subscription, error) {
+	sub.UpdatedAt = time.Now().UTC()
+	sub.ExpectRevision = true
+	if err := repo.Update(ctx, id, sub); err != nil {
+		return sub, err
+	}
+	sub.Revision++
+	sub.ExpectRevision = false
+	return sub, nil
+}
+
+// writeSaveError writes the error of saveSubscription: 412 if the subscription
+// changed since it was read (as for a stale If-Match), 500 otherwise
+func writeSaveError(w http.ResponseWriter, err error) {
+	if errors.Is(err, subscription.ErrRevisionConflict) {
+		writeError(w, "precondition failed: subscription has changed", http.StatusPreconditionFailed)
+		return
+	}
+	writeError(w, "failed to update subscription", http.StatusInternalServerError)
+}
+
+// writeSubscription writes a subscription with its ETag, answering
+// 304 Not Modified when the client already has the current version.
+func writeSubscription(w http.ResponseWriter, r *http.Request, sub subscription.Subscription) {
+	writeSubscriptionResponse(w, r, sub, subscriptionToResponse(sub))
+}
+
+// writeSubscriptionResponse is writeSubscription with a prepared response.
+// The ETag covers the subscription, not the delivery statistics in resp.
+func writeSubscriptionResponse(w http.ResponseWriter, r *http.Request, sub subscription.Subscription, resp SubscriptionResponse) {
+	etag := subscriptionETag(sub)
+	w.Header().Set("ETag", etag)
+
+	if ifNoneMatch := r.Header.Get("If-None-Match"); ifNoneMatch != "" && etagMatches(ifNoneMatch, etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	writeJSON(w, resp, http.StatusOK)
+}
@@ -0,0 +1,180 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/otiai10/namazu/backend/internal/subscription"
+)
+
+func TestSubscriptionETag(t *testing.T) {
+	base := subscription.Subscription{
+		ID:       "sub-1",
+		Name:     "alerts",
+		Delivery: subscription.DeliveryConfig{Type: "webhook", URL: "https://example.com", Secret: "s1"},
+		Filter:   &subscription.FilterConfig{MinScale: 30},
+	}
+
+	etag := subscriptionETag(base)
+	if !strings.HasPrefix(etag, `"`) || !strings.HasSuffix(etag, `"`) {
+		t.Errorf("expected quoted strong ETag, got %s", etag)
+	}
+
+	t.Run("stable across ID and empty prefectures", func(t *testing.T) {
+		other := base
+		other.ID = ""
+		other.Filter = &subscription.FilterConfig{MinScale: 30, Prefectures: []string{}}
+		if subscriptionETag(other) != etag {
+			t.Error("expected equivalent subscriptions to share an ETag")
+		}
+	})
+
+	t.Run("changes with secret rotation", func(t *testing.T) {
+		other := base
+		other.Delivery.Secret = "s2"
+		if subscriptionETag(other) == etag {
+			t.Error("expected ETag to change when the secret changes")
+		}
+	})
+
+	t.Run("changes with revision", func(t *testing.T) {
+		other := base
+		other.Revision = 2
+		if subscriptionETag(other) == etag {
+			t.Error("expected ETag to change with the revision")
+		}
+	})
+
+	t.Run("changes with filter", func(t *testing.T) {
+		other := base
+		other.Filter = &subscription.FilterConfig{MinScale: 40}
+		if subscriptionETag(other) == etag {
+			t.Error("expected ETag to change when the filter changes")
+		}
+	})
+}
+
+func TestEtagMatches(t *testing.T) {
+	tests := []struct {
+		header string
+		want   bool
+	}{
+		{header: `"abc"`, want: true},
+		{header: `"x", "abc"`, want: true},
+		{header: `*`, want: true},
+		{header: `W/"abc"`, want: false},
+		{header: `"xyz"`, want: false},
+	}
+
+	for _, tt := range tests {
+		if got := etagMatches(tt.header, `"abc"`); got != tt.want {
+			t.Errorf("etagMatches(%q) = %v, want %v", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestGetSubscription_ETag(t *testing.T) {
+	subRepo := newMockSubscriptionRepo()
+	subRepo.subscriptions["sub-1"] = subscription.Subscription{
+		ID:       "sub-1",
+		Name:     "alerts",
+		Delivery: subscription.DeliveryConfig{Type: "webhook", URL: "https://example.com"},
+	}
+	router := NewRouter(NewHandler(subRepo, newMockEventRepo()))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/subscriptions/sub-1", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	etag := rec.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("expected ETag header")
+	}
+
+	req = httptest.NewRequest(http.MethodPut, "/api/subscriptions/sub-1",
+		strings.NewReader(`{"name":"renamed","delivery":{"type":"webhook","url":"https://example.com"}}`))
+	req.Header.Set("If-Match", `"stale"`)
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusPreconditionFailed {
+		t.Errorf("expected status %d, got %d", http.StatusPreconditionFailed, rec.Code)
+	}
+	if subRepo.subscriptions["sub-1"].Name != "alerts" {
+		t.Error("subscription must not be modified when precondition fails")
+	}
+}
+
+func TestUpdateSubscription_RequiresIfMatch(t *testing.T) {
+	subRepo := newMockSubscriptionRepo()
+	subRepo.subscriptions["sub-1"] = subscription.Subscription{
+		ID:       "sub-1",
+		Name:     "alerts",
+		Delivery: subscription.DeliveryConfig{Type: "webhook", URL: "https://example.com"},
+		Revision: 4,
+	}
+	router := NewRouter(NewHandler(subRepo, newMockEventRepo()))
+	body := `{"name":"renamed","delivery":{"type":"webhook","url":"https://example.com"}}`
+
+	for _, method := range []string{http.MethodPut, http.MethodPatch} {
+		req := httptest.NewRequest(method, "/api/subscriptions/sub-1", strings.NewReader(body))
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if rec.Code != http.StatusPreconditionRequired || !strings.Contains(rec.Body.String(), `"precondition_required"`) {
+			t.Errorf("%s without If-Match: status %d %s, want %d", method, rec.Code, rec.Body.String(), http.StatusPreconditionRequired)
+		}
+	}
+
+	etag := subscriptionETag(subRepo.subscriptions["sub-1"])
+	req := httptest.NewRequest(http.MethodPut, "/api/subscriptions/sub-1", strings.NewReader(body))
+	req.Header.Set("If-Match", etag)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+	}
+	var resp SubscriptionResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Revision != 5 || resp.UpdatedAt == nil {
+		t.Errorf("response revision = %d, updated_at = %v, want 5 and set", resp.Revision, resp.UpdatedAt)
+	}
+	if got := rec.Header().Get("ETag"); got == etag || got != subscriptionETag(subRepo.subscriptions["sub-1"]) {
+		t.Errorf("ETag = %s, want the ETag of the stored revision", got)
+	}
+
+	// The second tab still holds the first ETag
+	req = httptest.NewRequest(http.MethodPut, "/api/subscriptions/sub-1",
+		strings.NewReader(`{"name":"other tab","delivery":{"type":"webhook","url":"https://example.com"}}`))
+	req.Header.Set("If-Match", etag)
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusPreconditionFailed || subRepo.subscriptions["sub-1"].Name != "renamed" {
+		t.Errorf("stale If-Match: status %d, name %q, want %d and the first edit kept", rec.Code, subRepo.subscriptions["sub-1"].Name, http.StatusPreconditionFailed)
+	}
+}
+
+func TestSaveSubscription_Conflict(t *testing.T) {
+	subRepo := newMockSubscriptionRepo()
+	subRepo.subscriptions["sub-1"] = subscription.Subscription{ID: "sub-1", Name: "alerts", Revision: 2}
+	read := subRepo.subscriptions["sub-1"]
+
+	// Written by another request between the read and the write
+	if _, err := saveSubscription(context.Background(), subRepo, "sub-1", read); err != nil {
+		t.Fatal(err)
+	}
+	_, err := saveSubscription(context.Background(), subRepo, "sub-1", read)
+	if !errors.Is(err, subscription.ErrRevisionConflict) {
+		t.Fatalf("saveSubscription(stale) error = %v, want ErrRevisionConflict", err)
+	}
+	rec := httptest.NewRecorder()
+	writeSaveError(rec, err)
+	if rec.Code != http.StatusPreconditionFailed {
+		t.Errorf("writeSaveError status = %d, want %d", rec.Code, http.StatusPreconditionFailed)
+	}
+}
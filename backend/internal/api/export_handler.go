@@ -0,0 +1,192 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/otiai10/namazu/backend/internal/account"
+	"github.com/otiai10/namazu/backend/internal/auth"
+	"github.com/otiai10/namazu/backend/internal/mail"
+	"github.com/otiai10/namazu/backend/internal/tenant"
+	"github.com/otiai10/namazu/backend/internal/user"
+)
+
+// exportDownloadPrefix is the path of the signed download links sent by POST /api/me/export
+const exportDownloadPrefix = "/api/export/"
+
+// ExportArchive is everything namazu stores about a user, as returned by GET /api/me/export
+type ExportArchive struct {
+	ExportedAt    time.Time              `json:"exported_at"`
+	UID           string                 `json:"uid"`
+	Profile       *user.User             `json:"profile,omitempty"` // Omitted before the first login
+	Subscriptions []ExportedSubscription `json:"subscriptions"`
+}
+
+// ExportedSubscription is a subscription with its recent delivery history.
+// Secrets and credentials are masked as in the subscription API.
+type ExportedSubscription struct {
+	SubscriptionResponse
+	Deliveries []DeliveryResponse `json:"deliveries,omitempty"` // Last 30 days, newest first
+}
+
+// ExportEmailResponse is the body of POST /api/me/export
+type ExportEmailResponse struct {
+	Email     string    `json:"email"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// SetMailer enables POST /api/me/export, which emails a signed download link
+func (h *MeHandler) SetMailer(m mail.Sender) {
+	h.mailer = m
+}
+
+// ExportData handles GET /api/me/export
+// Returns the user's profile, subscriptions and recent delivery history as a
+// JSON file download.
+func (h *MeHandler) ExportData(w http.ResponseWriter, r *http.Request) {
+	claims := auth.MustGetClaims(r.Context())
+
+	if h.subscriptionRepo == nil {
+		writeError(w, "data export is not enabled", http.StatusNotImplemented)
+		return
+	}
+	h.writeExport(w, r, claims.UID)
+}
+
+// EmailExport handles POST /api/me/export
+// Emails the user a link that downloads the export archive without signing in,
+// valid for 24 hours.
+func (h *MeHandler) EmailExport(w http.ResponseWriter, r *http.Request) {
+	claims := auth.MustGetClaims(r.Context())
+	ctx := r.Context()
+
+	if h.subscriptionRepo == nil || h.signer == nil || h.mailer == nil {
+		writeError(w, "emailed exports are not enabled", http.StatusNotImplemented)
+		return
+	}
+
+	u, err := h.userRepo.GetByUID(ctx, claims.UID)
+	if err != nil {
+		writeError(w, "failed to get user", http.StatusInternalServerError)
+		return
+	}
+	email := claims.Email
+	if u != nil && u.Email != "" {
+		email = u.Email
+	}
+	if email == "" {
+		writeError(w, "no email address is registered for this account", http.StatusBadRequest)
+		return
+	}
+
+	token, expiresAt := h.signer.IssueExport(claims.UID)
+	t := tenant.FromContext(ctx)
+	msg := mail.Message{
+		From:    t.EmailFrom,
+		To:      email,
+		Subject: fmt.Sprintf("[%s] データのエクスポート", t.Name),
+		Body: fmt.Sprintf("以下のリンクから、登録情報・Subscription・直近30日間の配信履歴をJSONファイルでダウンロードできます。\n"+
+			"リンクの有効期限は %s です。\n\n%s\n\n心当たりがない場合はこのメールを破棄してください。\n",
+			expiresAt.UTC().Format("2006-01-02 15:04 MST"), requestBaseURL(r)+exportDownloadPrefix+token),
+	}
+	if err := h.mailer.Send(ctx, msg); err != nil {
+		log.Printf("Failed to email export link to %s: %v", claims.UID, err)
+		writeError(w, "failed to send email", http.StatusBadGateway)
+		return
+	}
+
+	writeJSON(w, ExportEmailResponse{Email: email, ExpiresAt: expiresAt.UTC()}, http.StatusAccepted)
+}
+
+// DownloadExport handles GET /api/export/{token}
+// Serves the export archive of the user a link from POST /api/me/export was issued to.
+func (h *MeHandler) DownloadExport(w http.ResponseWriter, r *http.Request) {
+	if h.subscriptionRepo == nil || h.signer == nil {
+		writeError(w, "emailed exports are not enabled", http.StatusNotImplemented)
+		return
+	}
+
+	uid, err := h.signer.VerifyExport(strings.TrimPrefix(r.URL.Path, exportDownloadPrefix))
+	if err != nil {
+		if errors.Is(err, account.ErrTokenExpired) {
+			writeError(w, "download link expired; request a new export", http.StatusGone)
+			return
+		}
+		writeError(w, "download link not found", http.StatusNotFound)
+		return
+	}
+	h.writeExport(w, r, uid)
+}
+
+// writeExport writes the export archive of uid as an attachment
+func (h *MeHandler) writeExport(w http.ResponseWriter, r *http.Request, uid string) {
+	archive, err := h.exportArchive(r.Context(), uid, time.Now().UTC())
+	if err != nil {
+		log.Printf("Failed to export data of %s: %v", uid, err)
+		writeError(w, "failed to export data", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Disposition",
+		fmt.Sprintf(`attachment; filename="namazu-export-%s.json"`, archive.ExportedAt.Format("20060102")))
+	writeJSON(w, archive, http.StatusOK)
+}
+
+// exportArchive collects the data of uid. Only subscriptions the user owns are
+// included, with deliveries in the same range as the delivery log export.
+func (h *MeHandler) exportArchive(ctx context.Context, uid string, now time.Time) (*ExportArchive, error) {
+	u, err := h.userRepo.GetByUID(ctx, uid)
+	if err != nil {
+		return nil, fmt.Errorf("get user: %w", err)
+	}
+	subs, err := h.subscriptionRepo.ListByUserID(ctx, uid)
+	if err != nil {
+		return nil, fmt.Errorf("list subscriptions: %w", err)
+	}
+
+	archive := &ExportArchive{
+		ExportedAt:    now,
+		UID:           uid,
+		Profile:       u,
+		Subscriptions: make([]ExportedSubscription, 0, len(subs)),
+	}
+	for _, sub := range subs {
+		if sub.UserID != uid {
+			continue
+		}
+		exported := ExportedSubscription{SubscriptionResponse: subscriptionToResponse(sub)}
+		if h.deliveryRepo != nil {
+			records, err := h.deliveryRepo.ListBySubscription(ctx, sub.ID, now.Add(-defaultDeliveryLogRange), now)
+			if err != nil {
+				return nil, fmt.Errorf("list deliveries of %s: %w", sub.ID, err)
+			}
+			sort.SliceStable(records, func(i, j int) bool {
+				return records[i].DeliveredAt.After(records[j].DeliveredAt)
+			})
+			for _, record := range records {
+				exported.Deliveries = append(exported.Deliveries, newDeliveryResponse(record))
+			}
+		}
+		archive.Subscriptions = append(archive.Subscriptions, exported)
+	}
+	return archive, nil
+}
+
+// requestBaseURL returns the scheme and host the request was made to,
+// honoring X-Forwarded-Proto from the load balancer
+func requestBaseURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	if proto := r.Header.Get("X-Forwarded-Proto"); proto == "http" || proto == "https" {
+		scheme = proto
+	}
+	return scheme + "://" + r.Host
+}
@@ -0,0 +1,190 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/otiai10/namazu/backend/internal/account"
+	"github.com/otiai10/namazu/backend/internal/mail"
+	"github.com/otiai10/namazu/backend/internal/store"
+	"github.com/otiai10/namazu/backend/internal/subscription"
+	"github.com/otiai10/namazu/backend/internal/user"
+)
+
+// fakeMailer records sent messages
+type fakeMailer struct {
+	sent []mail.Message
+	err  error
+}
+
+func (f *fakeMailer) Send(ctx context.Context, msg mail.Message) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.sent = append(f.sent, msg)
+	return nil
+}
+
+// newExportHandler returns a MeHandler with one user owning one subscription
+// that has an old and two recent deliveries
+func newExportHandler() *MeHandler {
+	users := newMockUserRepo()
+	users.users["user-1"] = &user.User{ID: "user-1", UID: "uid-1", Email: "user@example.com", Plan: user.PlanFree}
+	users.uidIndex["uid-1"] = "user-1"
+	subs := newMockSubscriptionRepo()
+	subs.subscriptions["mine"] = subscription.Subscription{
+		ID:       "mine",
+		Name:     "Mine",
+		UserID:   "uid-1",
+		Delivery: subscription.DeliveryConfig{Type: "webhook", URL: "https://example.com/hook", Secret: "supersecretvalue"},
+	}
+	subs.subscriptions["theirs"] = subscription.Subscription{ID: "theirs", UserID: "uid-2"}
+	subs.subscriptions["legacy"] = subscription.Subscription{ID: "legacy"}
+	now := time.Now().UTC()
+	deliveries := &mockDeliveryRepo{records: []store.DeliveryRecord{
+		{ID: "d-old", SubscriptionID: "mine", DeliveredAt: now.Add(-60 * 24 * time.Hour)},
+		{ID: "d-1", SubscriptionID: "mine", DeliveredAt: now.Add(-2 * time.Hour), Attempts: 2},
+		{ID: "d-2", SubscriptionID: "mine", DeliveredAt: now.Add(-time.Hour), Success: true},
+		{ID: "d-3", SubscriptionID: "theirs", DeliveredAt: now.Add(-time.Hour)},
+	}}
+
+	h := NewMeHandler(users)
+	h.SetSubscriptionRepository(subs)
+	h.SetDeliveryRepository(deliveries)
+	return h
+}
+
+// decodeExport checks the download headers and decodes the archive
+func decodeExport(t *testing.T, rec *httptest.ResponseRecorder) ExportArchive {
+	t.Helper()
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+	}
+	if cd := rec.Header().Get("Content-Disposition"); !strings.HasPrefix(cd, `attachment; filename="namazu-export-`) {
+		t.Errorf("Content-Disposition = %q, want an attachment", cd)
+	}
+	var archive ExportArchive
+	if err := json.Unmarshal(rec.Body.Bytes(), &archive); err != nil {
+		t.Fatalf("failed to decode archive: %v", err)
+	}
+	return archive
+}
+
+func TestMeHandler_ExportData(t *testing.T) {
+	h := newExportHandler()
+
+	rec := httptest.NewRecorder()
+	h.ExportData(rec, withUser(httptest.NewRequest(http.MethodGet, "/api/me/export", nil), "uid-1"))
+	archive := decodeExport(t, rec)
+
+	if archive.UID != "uid-1" || archive.Profile == nil || archive.Profile.Email != "user@example.com" {
+		t.Errorf("archive = %+v, want the profile of uid-1", archive)
+	}
+	if len(archive.Subscriptions) != 1 || archive.Subscriptions[0].ID != "mine" {
+		t.Fatalf("subscriptions = %+v, want only the owned one", archive.Subscriptions)
+	}
+	sub := archive.Subscriptions[0]
+	if strings.Contains(rec.Body.String(), "supersecretvalue") || sub.Delivery.Secret == "" {
+		t.Errorf("secret = %q, want it masked", sub.Delivery.Secret)
+	}
+	if len(sub.Deliveries) != 2 || sub.Deliveries[0].ID != "d-2" || sub.Deliveries[1].ID != "d-1" {
+		t.Fatalf("deliveries = %+v, want the last 30 days newest first", sub.Deliveries)
+	}
+	if sub.Deliveries[1].RetryCount != 1 {
+		t.Errorf("retry_count = %d, want 1", sub.Deliveries[1].RetryCount)
+	}
+}
+
+func TestMeHandler_ExportData_Disabled(t *testing.T) {
+	h := NewMeHandler(newMockUserRepo())
+	rec := httptest.NewRecorder()
+	h.ExportData(rec, withUser(httptest.NewRequest(http.MethodGet, "/api/me/export", nil), "uid-1"))
+	if rec.Code != http.StatusNotImplemented {
+		t.Errorf("expected status %d, got %d", http.StatusNotImplemented, rec.Code)
+	}
+}
+
+func TestMeHandler_EmailExport(t *testing.T) {
+	h := newExportHandler()
+	signer := account.NewSigner("secret")
+	mailer := &fakeMailer{}
+	h.SetAccountSigner(signer)
+	h.SetMailer(mailer)
+
+	req := withUser(httptest.NewRequest(http.MethodPost, "/api/me/export", nil), "uid-1")
+	req.Host = "namazu.example.com"
+	req.Header.Set("X-Forwarded-Proto", "https")
+	rec := httptest.NewRecorder()
+	h.EmailExport(rec, req)
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusAccepted, rec.Code, rec.Body.String())
+	}
+	var resp ExportEmailResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.Email != "user@example.com" || resp.ExpiresAt.IsZero() {
+		t.Errorf("response = %+v, want the user's email and an expiry", resp)
+	}
+
+	if len(mailer.sent) != 1 || mailer.sent[0].To != "user@example.com" {
+		t.Fatalf("sent = %+v, want one email to the user", mailer.sent)
+	}
+	prefix := "https://namazu.example.com" + exportDownloadPrefix
+	start := strings.Index(mailer.sent[0].Body, prefix)
+	if start < 0 {
+		t.Fatalf("body = %q, want a download link", mailer.sent[0].Body)
+	}
+	link := strings.Fields(mailer.sent[0].Body[start:])[0]
+
+	// The link downloads the archive without a session
+	rec = httptest.NewRecorder()
+	h.DownloadExport(rec, httptest.NewRequest(http.MethodGet, strings.TrimPrefix(link, "https://namazu.example.com"), nil))
+	if archive := decodeExport(t, rec); archive.UID != "uid-1" || len(archive.Subscriptions) != 1 {
+		t.Errorf("archive = %+v, want uid-1's data", archive)
+	}
+
+	// Deletion tokens and forged links are rejected
+	deletion, _ := signer.IssueDeletion("uid-1")
+	for _, token := range []string{deletion, "not-a-token"} {
+		rec = httptest.NewRecorder()
+		h.DownloadExport(rec, httptest.NewRequest(http.MethodGet, exportDownloadPrefix+token, nil))
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("expected status %d for %q, got %d", http.StatusNotFound, token, rec.Code)
+		}
+	}
+}
+
+func TestMeHandler_EmailExport_Errors(t *testing.T) {
+	h := newExportHandler()
+	req := func(uid string) *http.Request {
+		return withUser(httptest.NewRequest(http.MethodPost, "/api/me/export", nil), uid)
+	}
+
+	rec := httptest.NewRecorder()
+	h.EmailExport(rec, req("uid-1"))
+	if rec.Code != http.StatusNotImplemented {
+		t.Errorf("expected status %d without a mailer, got %d", http.StatusNotImplemented, rec.Code)
+	}
+
+	h.SetAccountSigner(account.NewSigner("secret"))
+	h.SetMailer(&fakeMailer{})
+	rec = httptest.NewRecorder()
+	h.EmailExport(rec, req("uid-without-email"))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d without an email address, got %d", http.StatusBadRequest, rec.Code)
+	}
+
+	h.SetMailer(&fakeMailer{err: errors.New("smtp unavailable")})
+	rec = httptest.NewRecorder()
+	h.EmailExport(rec, req("uid-1"))
+	if rec.Code != http.StatusBadGateway {
+		t.Errorf("expected status %d when sending fails, got %d", http.StatusBadGateway, rec.Code)
+	}
+}
@@ -3,32 +3,100 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"math"
 	"net/http"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/otiai10/namazu/backend/internal/analytics"
+	"github.com/otiai10/namazu/backend/internal/apierr"
+	"github.com/otiai10/namazu/backend/internal/audit"
 	"github.com/otiai10/namazu/backend/internal/auth"
+	"github.com/otiai10/namazu/backend/internal/badge"
+	"github.com/otiai10/namazu/backend/internal/delivery/schema"
+	"github.com/otiai10/namazu/backend/internal/delivery/transform"
 	"github.com/otiai10/namazu/backend/internal/delivery/webhook"
+	"github.com/otiai10/namazu/backend/internal/deliverylog"
+	"github.com/otiai10/namazu/backend/internal/i18n"
+	"github.com/otiai10/namazu/backend/internal/plan"
 	"github.com/otiai10/namazu/backend/internal/quota"
+	"github.com/otiai10/namazu/backend/internal/region"
+	"github.com/otiai10/namazu/backend/internal/source"
 	"github.com/otiai10/namazu/backend/internal/store"
 	"github.com/otiai10/namazu/backend/internal/subscription"
+	"github.com/otiai10/namazu/backend/internal/tenant"
 	"github.com/otiai10/namazu/backend/internal/user"
 )
 
 // SubscriptionRequest represents the request body for creating/updating a subscription
 type SubscriptionRequest struct {
-	Name     string                      `json:"name"`
-	Delivery subscription.DeliveryConfig `json:"delivery"`
-	Filter   *subscription.FilterConfig  `json:"filter,omitempty"`
+	Name       string                       `json:"name"`
+	Delivery   subscription.DeliveryConfig  `json:"delivery"`
+	Filter     *subscription.FilterConfig   `json:"filter,omitempty"`
+	Locale     string                       `json:"locale,omitempty"` // Language of notification text: "ja" (default) or "en"
+	QuietHours *subscription.QuietHours     `json:"quiet_hours,omitempty"`
+	Throttle   *subscription.ThrottleConfig `json:"throttle,omitempty"`
+	Digest     *subscription.DigestConfig   `json:"digest,omitempty"`
+	ExpiresAt  *time.Time                   `json:"expires_at,omitempty"`
+
+	// SkipVerification creates or keeps a webhook deliverable without passing
+	// the URL verification challenge. Requires a plan with the feature.
+	SkipVerification bool `json:"skip_verification,omitempty"`
 }
 
 // SubscriptionResponse represents the response for subscription endpoints
 type SubscriptionResponse struct {
-	ID       string                      `json:"id"`
-	Name     string                      `json:"name"`
-	Delivery subscription.DeliveryConfig `json:"delivery"`
-	Filter   *subscription.FilterConfig  `json:"filter,omitempty"`
+	ID           string                       `json:"id"`
+	Name         string                       `json:"name"`
+	Delivery     SubscriptionDelivery         `json:"delivery"`
+	Filter       *subscription.FilterConfig   `json:"filter,omitempty"`
+	Locale       string                       `json:"locale,omitempty"`
+	QuietHours   *subscription.QuietHours     `json:"quiet_hours,omitempty"`
+	Throttle     *subscription.ThrottleConfig `json:"throttle,omitempty"`
+	Digest       *subscription.DigestConfig   `json:"digest,omitempty"`
+	ExpiresAt    *time.Time                   `json:"expires_at,omitempty"`
+	Status       string                       `json:"status"`
+	StatusReason string                       `json:"status_reason,omitempty"`
+	Active       bool                         `json:"active"`               // False while paused; see /pause and /resume
+	PausedAt     *time.Time                   `json:"paused_at,omitempty"`  // When the subscription was paused
+	Revision     int64                        `json:"revision,omitempty"`   // Incremented on every write; see the ETag header
+	UpdatedAt    *time.Time                   `json:"updated_at,omitempty"` // Last change through the API
+	ETag         string                       `json:"etag"`                 // As in the ETag header; send as If-Match to update a subscription read from a list
+	Stats        *SubscriptionStats           `json:"stats,omitempty"`      // GET /api/subscriptions/{id} only, with delivery history
+}
+
+// SubscriptionDelivery is the delivery of a subscription in responses.
+// Secrets are write-only: Secret and PreviousSecret are masked ("****1234"),
+// except in the response that generates the secret, and the AWS secret access
+// key is never returned. A lost secret cannot be retrieved; rotate it instead.
+type SubscriptionDelivery struct {
+	Type                    string                    `json:"type"`
+	URL                     string                    `json:"url,omitempty"`
+	Secret                  string                    `json:"secret,omitempty"`
+	SecretPrefix            string                    `json:"secret_prefix,omitempty"`
+	PreviousSecret          string                    `json:"previous_secret,omitempty"`
+	PreviousSecretExpiresAt *time.Time                `json:"previous_secret_expires_at,omitempty"`
+	Verified                bool                      `json:"verified"`
+	VerificationSkipped     bool                      `json:"verification_skipped,omitempty"`
+	SignVersion             string                    `json:"sign_version,omitempty"`
+	Retry                   *subscription.RetryConfig `json:"retry,omitempty"`
+	ServiceNotices          bool                      `json:"service_notices,omitempty"`
+	Template                string                    `json:"template,omitempty"`
+	Enrich                  bool                      `json:"enrich,omitempty"`
+	Format                  string                    `json:"format,omitempty"`
+	Headers                 map[string]string         `json:"headers,omitempty"`
+	AWS                     *AWSDestination           `json:"aws,omitempty"`
+	SMS                     *subscription.SMSConfig   `json:"sms,omitempty"`
+}
+
+// AWSDestination is the SNS topic or SQS queue of a delivery, without its secret access key
+type AWSDestination struct {
+	Region      string `json:"region"`
+	TopicARN    string `json:"topic_arn,omitempty"`
+	QueueURL    string `json:"queue_url,omitempty"`
+	AccessKeyID string `json:"access_key_id"`
 }
 
 // EventResponse represents the response for event endpoints
@@ -41,13 +109,36 @@ type EventResponse struct {
 	OccurredAt    time.Time `json:"occurredAt"`
 	ReceivedAt    time.Time `json:"receivedAt"`
 	CreatedAt     time.Time `json:"createdAt"`
+	Backfilled    bool      `json:"backfilled,omitempty"` // recovered from the history after a disconnection
 }
 
-// ErrorResponse represents an error response
-type ErrorResponse struct {
-	Error string `json:"error"`
+// EventListResponse represents a page of GET /api/events
+type EventListResponse struct {
+	Events     []EventResponse `json:"events"`
+	NextCursor string          `json:"next_cursor,omitempty"` // pass as ?cursor= for the next page
+	Total      int             `json:"total"`                 // events matching the filters across all pages
 }
 
+// EventDetailResponse represents the response for GET /api/events/{id}
+type EventDetailResponse struct {
+	EventResponse
+	RawJSON    string                   `json:"rawJson"`              // payload as received from the source
+	Deliveries *DeliverySummaryResponse `json:"deliveries,omitempty"` // omitted when delivery history is disabled
+}
+
+// DeliverySummaryResponse counts the deliveries of an event across all subscriptions
+type DeliverySummaryResponse struct {
+	Delivered int `json:"delivered"`
+	Failed    int `json:"failed"`
+}
+
+// maxEventsLimit caps the page size of GET /api/events
+const maxEventsLimit = 100
+
+// ErrorResponse is the envelope of an error response. The codes of the
+// error are listed in the apierr package.
+type ErrorResponse = apierr.Response
+
 // URLValidator validates webhook URLs for security
 type URLValidator interface {
 	ValidateWebhookURL(url string) error
@@ -55,7 +146,7 @@ type URLValidator interface {
 
 // Challenger verifies webhook URLs via challenge-response protocol
 type Challenger interface {
-	VerifyURL(ctx context.Context, url, secret string) webhook.ChallengeResult
+	VerifyURL(ctx context.Context, url, secret string, headers map[string]string) webhook.ChallengeResult
 }
 
 // Handler contains the HTTP handlers for the API
@@ -66,6 +157,21 @@ type Handler struct {
 	quotaChecker     quota.QuotaChecker
 	urlValidator     URLValidator
 	challenger       Challenger
+	badgeSigner      *badge.Signer
+	deliveryRepo     store.DeliveryRepository
+	deliveryLog      *deliverylog.Signer
+	redeliverer      Redeliverer
+	tester           Tester
+	idempotency      *Idempotency
+	stats            InstanceStats
+	statsCache       eventStatsCache
+	subStatsCache    subscriptionStatsCache
+	health           HealthReporter       // nil omits the health state from subscription statistics
+	auditLog         *audit.Logger        // nil disables audit records
+	egressIPs        []string             // Empty disables GET /api/egress-ips
+	rollups          analytics.Repository // nil disables GET /api/analytics/events
+	denyOwnerless    bool                 // Signed-in users cannot access subscriptions without an owner
+	signupGate       *SignupGate          // nil lets every user create subscriptions
 }
 
 // NewHandler creates a new Handler instance (backward compatible, no quota checking)
@@ -110,6 +216,43 @@ func (h *Handler) SetChallenger(c Challenger) {
 	h.challenger = c
 }
 
+// SetBadgeSigner enables health badge tokens for subscriptions
+func (h *Handler) SetBadgeSigner(s *badge.Signer) {
+	h.badgeSigner = s
+}
+
+// SetDeliveryRepository enables the delivery history of subscriptions
+func (h *Handler) SetDeliveryRepository(repo store.DeliveryRepository) {
+	h.deliveryRepo = repo
+}
+
+// SetIdempotency enables Idempotency-Key support on subscription creation
+func (h *Handler) SetIdempotency(i *Idempotency) {
+	h.idempotency = i
+}
+
+// SetRedeliverer enables manual redelivery of failed deliveries
+func (h *Handler) SetRedeliverer(r Redeliverer) {
+	h.redeliverer = r
+}
+
+// SetSignupGate holds back subscription creation until users meet the requirements of g
+func (h *Handler) SetSignupGate(g *SignupGate) {
+	h.signupGate = g
+}
+
+// SetDenyOwnerless closes the legacy access path: when deny is set, signed-in
+// users are forbidden from subscriptions without an owner
+func (h *Handler) SetDenyOwnerless(deny bool) {
+	h.denyOwnerless = deny
+}
+
+// SetDeliveryLog enables signed delivery log exports for subscriptions
+func (h *Handler) SetDeliveryLog(repo store.DeliveryRepository, s *deliverylog.Signer) {
+	h.deliveryRepo = repo
+	h.deliveryLog = s
+}
+
 // CreateSubscription handles POST /api/subscriptions
 func (h *Handler) CreateSubscription(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
@@ -123,24 +266,139 @@ func (h *Handler) CreateSubscription(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if req.Name == "" {
-		writeError(w, "name is required", http.StatusBadRequest)
+	if msg := h.validateSubscriptionRequest(req); msg != "" {
+		writeErrorCode(w, apierr.ValidationFailed, msg, http.StatusBadRequest)
 		return
 	}
 
-	if req.Delivery.Type == "" || req.Delivery.URL == "" {
-		writeError(w, "delivery type and URL are required", http.StatusBadRequest)
-		return
+	h.createSubscription(w, r, req, false)
+}
+
+// validateSubscriptionRequest checks required fields, the webhook URL and the
+// AWS destination. Returns an error message, or "" if the request is valid.
+func (h *Handler) validateSubscriptionRequest(req SubscriptionRequest) string {
+	if req.Name == "" {
+		return "name is required"
+	}
+
+	switch req.Delivery.Type {
+	case subscription.DeliveryTypeSNS, subscription.DeliveryTypeSQS:
+		if req.Delivery.AWS == nil {
+			return "delivery.aws is required for " + req.Delivery.Type
+		}
+		if msg := req.Delivery.AWS.Validate(req.Delivery.Type); msg != "" {
+			return msg
+		}
+	case subscription.DeliveryTypeSMS:
+		if req.Delivery.SMS == nil {
+			return "delivery.sms is required for sms"
+		}
+		if msg := req.Delivery.SMS.Validate(); msg != "" {
+			return msg
+		}
+	case subscription.DeliveryTypeWebPush:
+		// Sent to the browsers registered under /api/me/push-subscriptions
+	case subscription.DeliveryTypeFCM:
+		// Sent to the devices registered under /api/me/devices
+	default:
+		if req.Delivery.Type == "" || req.Delivery.URL == "" {
+			return "delivery type and URL are required"
+		}
+	}
+	if req.Delivery.AWS != nil && req.Delivery.Type != subscription.DeliveryTypeSNS && req.Delivery.Type != subscription.DeliveryTypeSQS {
+		return "delivery.aws is only used by sns and sqs deliveries"
+	}
+	if req.Delivery.SMS != nil && req.Delivery.Type != subscription.DeliveryTypeSMS {
+		return "delivery.sms is only used by sms deliveries"
 	}
 
 	// Validate webhook URL for security (SSRF prevention, HTTPS enforcement)
 	if req.Delivery.Type == "webhook" && h.urlValidator != nil {
 		if err := h.urlValidator.ValidateWebhookURL(req.Delivery.URL); err != nil {
-			writeError(w, "invalid webhook URL: "+err.Error(), http.StatusBadRequest)
-			return
+			return "invalid webhook URL: " + err.Error()
+		}
+	}
+
+	if req.Delivery.Retry != nil {
+		if msg := req.Delivery.Retry.Validate(); msg != "" {
+			return msg
+		}
+	}
+
+	if err := webhook.ValidateHeaders(req.Delivery.Headers); err != nil {
+		return "invalid delivery.headers: " + err.Error()
+	}
+
+	if req.Delivery.Template != "" {
+		if err := transform.Validate(req.Delivery.Template); err != nil {
+			return "invalid delivery.template: " + err.Error()
 		}
 	}
 
+	if !schema.ValidFormat(req.Delivery.Format) {
+		return "delivery.format must be one of " + strings.Join(schema.Formats, ", ")
+	}
+
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return "expires_at must be in the future"
+	}
+
+	if !i18n.Supported(req.Locale) {
+		return "locale must be one of " + strings.Join(i18n.Locales, ", ")
+	}
+
+	if req.QuietHours != nil {
+		if msg := req.QuietHours.Validate(); msg != "" {
+			return msg
+		}
+	}
+
+	if req.Throttle != nil {
+		if msg := req.Throttle.Validate(); msg != "" {
+			return msg
+		}
+	}
+
+	if req.Digest != nil {
+		if msg := req.Digest.Validate(); msg != "" {
+			return msg
+		}
+	}
+
+	if req.Filter != nil {
+		for _, t := range req.Filter.EventTypes {
+			if !subscription.IsKnownEventType(t) {
+				return "unknown event type: " + t
+			}
+		}
+		if _, err := region.Expand(req.Filter.Prefectures); err != nil {
+			return err.Error()
+		}
+		if req.Filter.MinMagnitude < 0 {
+			return "min_magnitude must not be negative"
+		}
+		if req.Filter.MaxDepthKm < 0 {
+			return "max_depth_km must not be negative"
+		}
+		if req.Filter.Geofence != nil {
+			if msg := req.Filter.Geofence.Validate(); msg != "" {
+				return msg
+			}
+		}
+	}
+
+	return ""
+}
+
+// createSubscription persists a new subscription from a validated request
+// and writes the 201 response, including the generated secret. With
+// uniqueName, it fails with 409 if the owner has created a subscription of
+// the same name since the caller looked it up.
+func (h *Handler) createSubscription(w http.ResponseWriter, r *http.Request, req SubscriptionRequest, uniqueName bool) {
+	// Only rotation sets a previous secret
+	req.Delivery.PreviousSecret = ""
+	req.Delivery.PreviousSecretExpiresAt = nil
+
 	// Generate server-side secret for webhook subscriptions
 	var generatedSecret string
 	if req.Delivery.Type == "webhook" {
@@ -155,64 +413,98 @@ func (h *Handler) CreateSubscription(w http.ResponseWriter, r *http.Request) {
 		req.Delivery.SignVersion = "v0"
 	}
 
-	// Verify webhook URL via challenge
-	if req.Delivery.Type == "webhook" && h.challenger != nil {
-		challengeResult := h.challenger.VerifyURL(r.Context(), req.Delivery.URL, req.Delivery.Secret)
+	// Verify webhook URL via challenge, unless skipped (checked against the plan below)
+	req.Delivery.Verified = false
+	req.Delivery.VerificationSkipped = req.Delivery.Type == "webhook" && req.SkipVerification
+	if req.Delivery.Type == "webhook" && !req.Delivery.VerificationSkipped && h.challenger != nil {
+		challengeResult := h.challenger.VerifyURL(r.Context(), req.Delivery.URL, req.Delivery.Secret, req.Delivery.Headers)
 		if !challengeResult.Success {
-			writeError(w, "webhook URL verification failed: "+challengeResult.ErrorMessage, http.StatusBadRequest)
+			writeErrorCode(w, apierr.WebhookVerificationFailed, "webhook URL verification failed: "+challengeResult.ErrorMessage, http.StatusBadRequest)
 			return
 		}
 		req.Delivery.Verified = true
 	}
 
 	sub := subscription.Subscription{
-		Name:     req.Name,
-		Delivery: copyDeliveryConfig(req.Delivery),
-		Filter:   copyFilterConfig(req.Filter),
+		TenantID:   tenant.FromContext(r.Context()).ID,
+		Name:       req.Name,
+		Delivery:   copyDeliveryConfig(req.Delivery),
+		Filter:     copyFilterConfig(req.Filter),
+		Locale:     req.Locale,
+		QuietHours: req.QuietHours.Copy(),
+		Throttle:   req.Throttle.Copy(),
+		Digest:     req.Digest.Copy(),
+		CreatedAt:  time.Now().UTC(),
+		ExpiresAt:  copyTime(req.ExpiresAt),
+		Status:     subscription.StatusActive,
+		UniqueName: uniqueName,
 	}
 
-	// Set UserID from claims if authenticated and check quota
+	// Set UserID from claims if authenticated and check quota.
+	// The check fails fast; the limit is enforced again when creating.
+	var quotaPlan string
+	limit := -1
 	if claims, ok := auth.GetClaims(r.Context()); ok {
 		sub.UserID = claims.UID
 
 		// Check quota if quota checker is configured
 		if h.quotaChecker != nil {
 			plan := h.getUserPlan(r.Context(), claims.UID)
+			quotaPlan = plan
+			limit = h.quotaChecker.Features(r.Context(), plan).MaxSubscriptions
 			canCreate, err := h.quotaChecker.CanCreateSubscription(r.Context(), claims.UID, plan)
 			if err != nil {
 				writeError(w, "failed to check quota", http.StatusInternalServerError)
 				return
 			}
 			if !canCreate {
-				writeError(w, "Subscription limit reached for your plan", http.StatusForbidden)
+				h.writeQuotaExceeded(w, r.Context(), plan)
+				return
+			}
+			if err := h.quotaChecker.Features(r.Context(), plan).Check(sub); err != nil {
+				writePlanFeatureError(w, err, "")
 				return
 			}
 		}
 	}
 
-	id, err := h.subscriptionRepo.Create(r.Context(), sub)
+	// Web Push and FCM notify the owner's browsers and devices, so there must be an owner
+	if (sub.Delivery.Type == subscription.DeliveryTypeWebPush || sub.Delivery.Type == subscription.DeliveryTypeFCM) && sub.UserID == "" {
+		writeErrorCode(w, apierr.ValidationFailed, sub.Delivery.Type+" delivery requires authentication", http.StatusBadRequest)
+		return
+	}
+
+	id, err := h.createWithinLimit(r.Context(), sub, limit)
+	if errors.Is(err, subscription.ErrLimitExceeded) {
+		h.writeQuotaExceeded(w, r.Context(), quotaPlan)
+		return
+	}
+	if errors.Is(err, subscription.ErrNameTaken) {
+		writeError(w, "a subscription with this name was created concurrently", http.StatusConflict)
+		return
+	}
 	if err != nil {
 		writeError(w, "failed to create subscription", http.StatusInternalServerError)
 		return
 	}
+	h.auditLog.Record(r.Context(), auditEntry(r, audit.ActionSubscriptionCreate, id, map[string]string{"name": sub.Name}))
 
-	responseDelivery := copyDeliveryConfig(sub.Delivery)
+	sub.ID = id
+	sub.Revision = 1
+	response := subscriptionToResponse(sub)
 	if generatedSecret != "" {
-		responseDelivery.Secret = generatedSecret
-	}
-
-	response := SubscriptionResponse{
-		ID:       id,
-		Name:     sub.Name,
-		Delivery: responseDelivery,
-		Filter:   sub.Filter,
+		// The only time the secret is shown in full
+		response.Delivery.Secret = generatedSecret
 	}
 
+	w.Header().Set("ETag", subscriptionETag(sub))
 	writeJSON(w, response, http.StatusCreated)
 }
 
 // ListSubscriptions handles GET /api/subscriptions
-// When authenticated, returns only user's own subscriptions + legacy (ownerless) subscriptions
+// When authenticated, returns only user's own subscriptions + legacy (ownerless) subscriptions.
+// Admins can list another user's subscriptions with ?user_id= or everyone's with ?all=true.
+// Only subscriptions of the request's tenant are returned.
 func (h *Handler) ListSubscriptions(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		writeError(w, "method not allowed", http.StatusMethodNotAllowed)
@@ -222,9 +514,21 @@ func (h *Handler) ListSubscriptions(w http.ResponseWriter, r *http.Request) {
 	var subs []subscription.Subscription
 	var err error
 
-	// If authenticated, filter by user ID
+	// Listing another user's subscriptions (?user_id=) or everyone's (?all=true) requires admin
+	userID := r.URL.Query().Get("user_id")
+	all := r.URL.Query().Get("all") == "true"
 	if claims, ok := auth.GetClaims(r.Context()); ok {
-		subs, err = h.subscriptionRepo.ListByUserID(r.Context(), claims.UID)
+		if (all || (userID != "" && userID != claims.UID)) && !claims.Admin {
+			writeErrorCode(w, apierr.AdminRequired, "admin privileges required", http.StatusForbidden)
+			return
+		}
+		if userID == "" && !all {
+			userID = claims.UID
+		}
+	}
+
+	if userID != "" {
+		subs, err = h.subscriptionRepo.ListByUserID(r.Context(), userID)
 	} else {
 		subs, err = h.subscriptionRepo.List(r.Context())
 	}
@@ -234,8 +538,12 @@ func (h *Handler) ListSubscriptions(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	tenantID := tenant.FromContext(r.Context()).ID
 	responses := make([]SubscriptionResponse, 0, len(subs))
 	for _, sub := range subs {
+		if sub.TenantID != tenantID {
+			continue
+		}
 		responses = append(responses, subscriptionToResponse(sub))
 	}
 
@@ -265,11 +573,38 @@ func (h *Handler) GetSubscription(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	if forbidden {
-		writeError(w, "forbidden", http.StatusForbidden)
+		writeErrorCode(w, apierr.NotOwner, "forbidden", http.StatusForbidden)
 		return
 	}
 
-	writeJSON(w, subscriptionToResponse(*sub), http.StatusOK)
+	resp := subscriptionToResponse(*sub)
+	resp.Stats = h.subscriptionStats(r.Context(), *sub, time.Now().UTC())
+	writeSubscriptionResponse(w, r, *sub, resp)
+}
+
+// GetSubscriptionBadge handles GET /api/subscriptions/{id}/badge
+// Returns the public badge URLs for a subscription owned by the caller
+func (h *Handler) GetSubscriptionBadge(w http.ResponseWriter, r *http.Request, id string) {
+	if h.badgeSigner == nil {
+		writeError(w, "badges are not enabled", http.StatusNotImplemented)
+		return
+	}
+
+	sub, forbidden, err := h.checkOwnership(r.Context(), id)
+	if err != nil {
+		writeError(w, "failed to get subscription", http.StatusInternalServerError)
+		return
+	}
+	if sub == nil {
+		writeError(w, "subscription not found", http.StatusNotFound)
+		return
+	}
+	if forbidden {
+		writeErrorCode(w, apierr.NotOwner, "forbidden", http.StatusForbidden)
+		return
+	}
+
+	writeJSON(w, newBadgeResponse(h.badgeSigner, sub.ID), http.StatusOK)
 }
 
 // UpdateSubscription handles PUT /api/subscriptions/{id}
@@ -291,24 +626,11 @@ func (h *Handler) UpdateSubscription(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if req.Name == "" {
-		writeError(w, "name is required", http.StatusBadRequest)
+	if msg := h.validateSubscriptionRequest(req); msg != "" {
+		writeErrorCode(w, apierr.ValidationFailed, msg, http.StatusBadRequest)
 		return
 	}
 
-	if req.Delivery.Type == "" || req.Delivery.URL == "" {
-		writeError(w, "delivery type and URL are required", http.StatusBadRequest)
-		return
-	}
-
-	// Validate webhook URL for security (SSRF prevention, HTTPS enforcement)
-	if req.Delivery.Type == "webhook" && h.urlValidator != nil {
-		if err := h.urlValidator.ValidateWebhookURL(req.Delivery.URL); err != nil {
-			writeError(w, "invalid webhook URL: "+err.Error(), http.StatusBadRequest)
-			return
-		}
-	}
-
 	// Check if subscription exists and verify ownership
 	existing, forbidden, err := h.checkOwnership(r.Context(), id)
 	if err != nil {
@@ -320,21 +642,40 @@ func (h *Handler) UpdateSubscription(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	if forbidden {
-		writeError(w, "forbidden", http.StatusForbidden)
+		writeErrorCode(w, apierr.NotOwner, "forbidden", http.StatusForbidden)
+		return
+	}
+
+	if !requireIfMatch(w, r) || !checkPreconditions(w, r, existing) {
 		return
 	}
 
+	h.updateSubscription(w, r, id, *existing, req)
+}
+
+// updateSubscription applies a validated request to an existing subscription
+// and writes the 200 response. Server-managed fields (secret, signing version,
+// owner, lifecycle status, pause) are preserved. If nothing changes, the repository is not written, so
+// repeated identical requests are no-ops.
+func (h *Handler) updateSubscription(w http.ResponseWriter, r *http.Request, id string, existing subscription.Subscription, req SubscriptionRequest) {
 	delivery := copyDeliveryConfig(req.Delivery)
-	// Preserve server-generated secret
+	// Preserve server-generated secrets
 	delivery.Secret = existing.Delivery.Secret
 	delivery.SecretPrefix = existing.Delivery.SecretPrefix
+	delivery.PreviousSecret = existing.Delivery.PreviousSecret
+	delivery.PreviousSecretExpiresAt = copyTime(existing.Delivery.PreviousSecretExpiresAt)
 	delivery.SignVersion = existing.Delivery.SignVersion
-
-	// Re-verify URL if changed
-	if existing.Delivery.URL != req.Delivery.URL && h.challenger != nil {
-		challengeResult := h.challenger.VerifyURL(r.Context(), req.Delivery.URL, existing.Delivery.Secret)
+	// Header values are masked in responses; keep the stored ones sent back masked
+	delivery.Headers = unmaskHeaders(req.Delivery.Headers, existing.Delivery.Headers)
+
+	// Re-verify URL if changed, unless verification is skipped
+	delivery.VerificationSkipped = existing.Delivery.VerificationSkipped || (delivery.Type == "webhook" && req.SkipVerification)
+	if existing.Delivery.URL != req.Delivery.URL && delivery.VerificationSkipped {
+		delivery.Verified = false
+	} else if existing.Delivery.URL != req.Delivery.URL && h.challenger != nil {
+		challengeResult := h.challenger.VerifyURL(r.Context(), req.Delivery.URL, existing.Delivery.Secret, delivery.Headers)
 		if !challengeResult.Success {
-			writeError(w, "webhook URL verification failed: "+challengeResult.ErrorMessage, http.StatusBadRequest)
+			writeErrorCode(w, apierr.WebhookVerificationFailed, "webhook URL verification failed: "+challengeResult.ErrorMessage, http.StatusBadRequest)
 			return
 		}
 		delivery.Verified = true
@@ -343,18 +684,42 @@ func (h *Handler) UpdateSubscription(w http.ResponseWriter, r *http.Request) {
 	}
 
 	sub := subscription.Subscription{
-		ID:       id,
-		UserID:   existing.UserID, // Preserve the original owner
-		Name:     req.Name,
-		Delivery: delivery,
-		Filter:   copyFilterConfig(req.Filter),
+		ID:              id,
+		UserID:          existing.UserID,   // Preserve the original owner
+		TenantID:        existing.TenantID, // and tenant
+		Name:            req.Name,
+		Delivery:        delivery,
+		Filter:          copyFilterConfig(req.Filter),
+		Locale:          req.Locale,
+		QuietHours:      req.QuietHours.Copy(),
+		Throttle:        req.Throttle.Copy(),
+		Digest:          req.Digest.Copy(),
+		CreatedAt:       existing.CreatedAt,
+		ExpiresAt:       copyTime(req.ExpiresAt),
+		Status:          existing.Status,
+		StatusReason:    existing.StatusReason,
+		StatusChangedAt: existing.StatusChangedAt,
+		PausedAt:        copyTime(existing.PausedAt),
+		Revision:        existing.Revision, // Written only over the revision the preconditions were checked against
+		UpdatedAt:       existing.UpdatedAt,
 	}
 
-	if err := h.subscriptionRepo.Update(r.Context(), id, sub); err != nil {
-		writeError(w, "failed to update subscription", http.StatusInternalServerError)
+	if err := h.checkPlanFeatures(r.Context(), sub); err != nil {
+		writePlanFeatureError(w, err, "")
 		return
 	}
 
+	if subscriptionETag(sub) != subscriptionETag(existing) {
+		saved, err := saveSubscription(r.Context(), h.subscriptionRepo, id, sub)
+		if err != nil {
+			writeSaveError(w, err)
+			return
+		}
+		sub = saved
+		h.auditLog.Record(r.Context(), auditEntry(r, audit.ActionSubscriptionUpdate, id, map[string]string{"name": sub.Name}))
+	}
+
+	w.Header().Set("ETag", subscriptionETag(sub))
 	writeJSON(w, subscriptionToResponse(sub), http.StatusOK)
 }
 
@@ -382,7 +747,11 @@ func (h *Handler) DeleteSubscription(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	if forbidden {
-		writeError(w, "forbidden", http.StatusForbidden)
+		writeErrorCode(w, apierr.NotOwner, "forbidden", http.StatusForbidden)
+		return
+	}
+
+	if !checkPreconditions(w, r, existing) {
 		return
 	}
 
@@ -390,44 +759,213 @@ func (h *Handler) DeleteSubscription(w http.ResponseWriter, r *http.Request) {
 		writeError(w, "failed to delete subscription", http.StatusInternalServerError)
 		return
 	}
+	h.auditLog.Record(r.Context(), auditEntry(r, audit.ActionSubscriptionDelete, id, map[string]string{"name": existing.Name}))
 
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// ReactivateSubscription handles POST /api/subscriptions/{id}/reactivate
+// Returns a subscription suspended or warned by the lifecycle sweep to active.
+// Expired subscriptions must get a new expires_at first.
+func (h *Handler) ReactivateSubscription(w http.ResponseWriter, r *http.Request, id string) {
+	existing, forbidden, err := h.checkOwnership(r.Context(), id)
+	if err != nil {
+		writeError(w, "failed to get subscription", http.StatusInternalServerError)
+		return
+	}
+	if existing == nil {
+		writeError(w, "subscription not found", http.StatusNotFound)
+		return
+	}
+	if forbidden {
+		writeErrorCode(w, apierr.NotOwner, "forbidden", http.StatusForbidden)
+		return
+	}
+
+	if !checkPreconditions(w, r, existing) {
+		return
+	}
+
+	now := time.Now().UTC()
+	if existing.IsExpired(now) {
+		writeErrorCode(w, apierr.SubscriptionExpired, "subscription has expired; update expires_at first", http.StatusConflict)
+		return
+	}
+
+	if existing.StatusReason == subscription.ReasonOrphaned && existing.UserID == "" {
+		writeErrorCode(w, apierr.OwnerlessSubscription, "subscription has no owner; an admin must assign one first", http.StatusConflict)
+		return
+	}
+
+	if existing.StatusReason == subscription.ReasonOverQuota {
+		if ok, err := h.canReactivateOverQuota(r.Context(), *existing); err != nil {
+			writeError(w, "failed to check quota", http.StatusInternalServerError)
+			return
+		} else if !ok {
+			h.writeQuotaExceeded(w, r.Context(), h.getUserPlan(r.Context(), existing.UserID))
+			return
+		}
+	}
+
+	sub := *existing
+	if sub.Status != "" && sub.Status != subscription.StatusActive {
+		sub.Status = subscription.StatusActive
+		sub.StatusReason = ""
+		sub.StatusChangedAt = &now
+		saved, err := saveSubscription(r.Context(), h.subscriptionRepo, id, sub)
+		if err != nil {
+			writeSaveError(w, err)
+			return
+		}
+		sub = saved
+		h.auditLog.Record(r.Context(), auditEntry(r, audit.ActionSubscriptionUpdate, id, map[string]string{"name": sub.Name, "status": sub.Status}))
+	}
+
+	w.Header().Set("ETag", subscriptionETag(sub))
+	writeJSON(w, subscriptionToResponse(sub), http.StatusOK)
+}
+
 // ListEvents handles GET /api/events
+// Query parameters:
+//   - limit: page size (default 10, max 100)
+//   - cursor: next_cursor of the previous page
+//   - order: desc (newest first, default) or asc
+//   - min_severity, type, prefecture: filters
+//   - from, to: occurrence time range (RFC3339; from inclusive, to exclusive)
+//   - start_after: legacy alias of to
 func (h *Handler) ListEvents(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		writeError(w, "method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	// Parse query parameters
-	limit := 10
-	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
-		if parsedLimit, err := strconv.Atoi(limitStr); err == nil && parsedLimit > 0 {
-			limit = parsedLimit
-		}
+	q, msg := parseEventQuery(r)
+	if msg != "" {
+		writeErrorCode(w, apierr.ValidationFailed, msg, http.StatusBadRequest)
+		return
 	}
 
-	var startAfter *time.Time
-	if startAfterStr := r.URL.Query().Get("start_after"); startAfterStr != "" {
-		if t, err := time.Parse(time.RFC3339, startAfterStr); err == nil {
-			startAfter = &t
-		}
+	page, err := h.eventRepo.Query(r.Context(), q)
+	if errors.Is(err, store.ErrInvalidCursor) {
+		writeError(w, "invalid cursor", http.StatusBadRequest)
+		return
 	}
-
-	events, err := h.eventRepo.List(r.Context(), limit, startAfter)
 	if err != nil {
 		writeError(w, "failed to list events", http.StatusInternalServerError)
 		return
 	}
 
-	responses := make([]EventResponse, 0, len(events))
-	for _, event := range events {
+	responses := make([]EventResponse, 0, len(page.Events))
+	for _, event := range page.Events {
 		responses = append(responses, eventToResponse(event))
 	}
 
-	writeJSON(w, responses, http.StatusOK)
+	writeJSON(w, EventListResponse{Events: responses, NextCursor: page.NextCursor, Total: page.Total}, http.StatusOK)
+}
+
+// GetEvent handles GET /api/events/{id}
+// Returns the stored event with the raw payload and, when delivery history
+// is enabled, how many deliveries of it succeeded and failed.
+func (h *Handler) GetEvent(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id := strings.TrimPrefix(r.URL.Path, "/api/events/")
+	if id == "" || strings.Contains(id, "/") {
+		writeError(w, "event not found", http.StatusNotFound)
+		return
+	}
+
+	event, err := h.eventRepo.Get(r.Context(), id)
+	if err != nil {
+		writeError(w, "failed to get event", http.StatusInternalServerError)
+		return
+	}
+	if event == nil {
+		writeError(w, "event not found", http.StatusNotFound)
+		return
+	}
+
+	response := EventDetailResponse{EventResponse: eventToResponse(*event), RawJSON: event.RawJSON}
+	if h.deliveryRepo != nil {
+		summary, err := h.deliveryRepo.SummarizeEvent(r.Context(), event.ID)
+		if err != nil {
+			writeError(w, "failed to summarize deliveries", http.StatusInternalServerError)
+			return
+		}
+		response.Deliveries = &DeliverySummaryResponse{Delivered: summary.Delivered, Failed: summary.Failed}
+	}
+
+	writeJSON(w, response, http.StatusOK)
+}
+
+// parseEventQuery builds the event query from the query string.
+// Returns an error message if a parameter is invalid.
+func parseEventQuery(r *http.Request) (store.EventQuery, string) {
+	params := r.URL.Query()
+	q := store.EventQuery{
+		Limit:      10,
+		Cursor:     params.Get("cursor"),
+		Type:       params.Get("type"),
+		Prefecture: params.Get("prefecture"),
+	}
+	// Events store full names, so "Tokyo" finds "東京都"
+	if name, ok := region.Normalize(q.Prefecture); ok {
+		q.Prefecture = name
+	}
+
+	// An invalid limit falls back to the default, as before cursors existed
+	if limitStr := params.Get("limit"); limitStr != "" {
+		if parsedLimit, err := strconv.Atoi(limitStr); err == nil && parsedLimit > 0 {
+			q.Limit = min(parsedLimit, maxEventsLimit)
+		}
+	}
+
+	switch params.Get("order") {
+	case "", "desc":
+	case "asc":
+		q.Ascending = true
+	default:
+		return q, "order must be asc or desc"
+	}
+
+	if s := params.Get("min_severity"); s != "" {
+		v, err := strconv.Atoi(s)
+		if err != nil || v < 0 {
+			return q, "min_severity must be a non-negative integer"
+		}
+		q.MinSeverity = v
+	}
+
+	switch source.EventType(q.Type) {
+	case "", source.EventTypeEarthquake, source.EventTypeTsunami, source.EventTypeEEW:
+	default:
+		return q, "unknown event type: " + q.Type
+	}
+
+	for _, p := range []struct {
+		name string
+		dst  **time.Time
+	}{{"from", &q.From}, {"to", &q.To}} {
+		if s := params.Get(p.name); s != "" {
+			t, err := time.Parse(time.RFC3339, s)
+			if err != nil {
+				return q, p.name + " must be an RFC3339 timestamp"
+			}
+			*p.dst = &t
+		}
+	}
+	if q.To == nil {
+		if startAfterStr := params.Get("start_after"); startAfterStr != "" {
+			if t, err := time.Parse(time.RFC3339, startAfterStr); err == nil {
+				q.To = &t
+			}
+		}
+	}
+
+	return q, ""
 }
 
 // Helper functions
@@ -439,8 +977,10 @@ func (h *Handler) ListEvents(w http.ResponseWriter, r *http.Request) {
 //   - err: database error
 //
 // Rules:
+//   - If subscription belongs to another tenant: treat as not found
 //   - If no auth claims in context: allow access (backward compatibility during transition)
-//   - If subscription has no owner (UserID == ""): allow access (legacy data)
+//   - If subscription has no owner (UserID == ""): allow access (legacy data),
+//     unless SetDenyOwnerless closed this path
 //   - If subscription owner matches current user: allow access
 //   - Otherwise: forbidden
 func (h *Handler) checkOwnership(ctx context.Context, subID string) (*subscription.Subscription, bool, error) {
@@ -452,6 +992,11 @@ func (h *Handler) checkOwnership(ctx context.Context, subID string) (*subscripti
 		return nil, false, nil // not found
 	}
 
+	// Tenants must not learn of each other's subscriptions
+	if sub.TenantID != tenant.FromContext(ctx).ID {
+		return nil, false, nil
+	}
+
 	claims, ok := auth.GetClaims(ctx)
 	if !ok {
 		// No auth context, allow access (backward compatibility)
@@ -460,7 +1005,7 @@ func (h *Handler) checkOwnership(ctx context.Context, subID string) (*subscripti
 
 	// Legacy subscription with no owner
 	if sub.UserID == "" {
-		return sub, false, nil
+		return sub, h.denyOwnerless, nil
 	}
 
 	// Check ownership
@@ -479,13 +1024,27 @@ func extractIDFromPath(path, prefix string) string {
 }
 
 func subscriptionToResponse(sub subscription.Subscription) SubscriptionResponse {
-	maskedDelivery := copyDeliveryConfig(sub.Delivery)
-	maskedDelivery.Secret = webhook.MaskSecret(sub.Delivery.Secret)
+	status := sub.Status
+	if status == "" {
+		status = subscription.StatusActive
+	}
 	return SubscriptionResponse{
-		ID:       sub.ID,
-		Name:     sub.Name,
-		Delivery: maskedDelivery,
-		Filter:   sub.Filter,
+		ID:           sub.ID,
+		Name:         sub.Name,
+		Delivery:     deliveryToResponse(sub.Delivery),
+		Filter:       sub.Filter,
+		Locale:       sub.Locale,
+		QuietHours:   sub.QuietHours,
+		Throttle:     sub.Throttle,
+		Digest:       sub.Digest,
+		ExpiresAt:    sub.ExpiresAt,
+		Status:       status,
+		StatusReason: sub.StatusReason,
+		Active:       !sub.IsPaused(),
+		PausedAt:     sub.PausedAt,
+		Revision:     sub.Revision,
+		UpdatedAt:    optionalTime(sub.UpdatedAt),
+		ETag:         subscriptionETag(sub),
 	}
 }
 
@@ -499,6 +1058,7 @@ func eventToResponse(event store.EventRecord) EventResponse {
 		OccurredAt:    event.OccurredAt,
 		ReceivedAt:    event.ReceivedAt,
 		CreatedAt:     event.CreatedAt,
+		Backfilled:    event.Backfilled,
 	}
 }
 
@@ -510,20 +1070,125 @@ func writeJSON(w http.ResponseWriter, data interface{}, status int) {
 	}
 }
 
+// writeError writes an error response with the code of the status
 func writeError(w http.ResponseWriter, message string, status int) {
-	w.WriteHeader(status)
-	_ = json.NewEncoder(w).Encode(ErrorResponse{Error: message})
+	apierr.Write(w, status, "", message, nil)
+}
+
+// writeErrorCode writes an error response with a code of the apierr catalog
+func writeErrorCode(w http.ResponseWriter, code, message string, status int) {
+	apierr.Write(w, status, code, message, nil)
+}
+
+// writeErrorDetails writes an error response with a code and details for clients
+func writeErrorDetails(w http.ResponseWriter, code, message string, status int, details map[string]any) {
+	apierr.Write(w, status, code, message, details)
+}
+
+// writeQuotaExceeded writes the response for a user at the subscription limit of their plan
+func (h *Handler) writeQuotaExceeded(w http.ResponseWriter, ctx context.Context, planID string) {
+	writeErrorDetails(w, apierr.QuotaExceeded, "Subscription limit reached for your plan", http.StatusForbidden, map[string]any{
+		"plan":  planID,
+		"limit": h.quotaChecker.Features(ctx, planID).MaxSubscriptions,
+	})
+}
+
+// writePlanFeatureError writes the response for a subscription using a
+// feature its owner's plan does not include. prefix locates the subscription
+// in a request with several.
+func writePlanFeatureError(w http.ResponseWriter, err error, prefix string) {
+	details := map[string]any{}
+	var fe *plan.FeatureError
+	if errors.As(err, &fe) {
+		details["feature"] = fe.Feature
+		if fe.Detail != "" {
+			details["limit"] = fe.Detail
+		}
+	}
+	writeErrorDetails(w, apierr.PlanFeature, prefix+err.Error(), http.StatusForbidden, details)
+}
+
+// deliveryToResponse returns a delivery with its secrets masked or left out
+func deliveryToResponse(d subscription.DeliveryConfig) SubscriptionDelivery {
+	resp := SubscriptionDelivery{
+		Type:                    d.Type,
+		URL:                     d.URL,
+		SecretPrefix:            d.SecretPrefix,
+		PreviousSecretExpiresAt: copyTime(d.PreviousSecretExpiresAt),
+		Verified:                d.Verified,
+		VerificationSkipped:     d.VerificationSkipped,
+		SignVersion:             d.SignVersion,
+		Retry:                   d.Retry.Copy(),
+		ServiceNotices:          d.ServiceNotices,
+		Template:                d.Template,
+		Enrich:                  d.Enrich,
+		Format:                  d.Format,
+		Headers:                 maskHeaders(d.Headers),
+		SMS:                     d.SMS.Copy(),
+	}
+	if d.Secret != "" {
+		resp.Secret = webhook.MaskSecret(d.Secret)
+	}
+	if d.PreviousSecret != "" {
+		resp.PreviousSecret = webhook.MaskSecret(d.PreviousSecret)
+	}
+	if d.AWS != nil {
+		resp.AWS = &AWSDestination{
+			Region:      d.AWS.Region,
+			TopicARN:    d.AWS.TopicARN,
+			QueueURL:    d.AWS.QueueURL,
+			AccessKeyID: d.AWS.AccessKeyID,
+		}
+	}
+	return resp
+}
+
+// maskHeaders returns custom headers with their values masked like the
+// secret, since they typically carry credentials (Authorization: Bearer ...)
+func maskHeaders(headers map[string]string) map[string]string {
+	if len(headers) == 0 {
+		return nil
+	}
+	masked := make(map[string]string, len(headers))
+	for name, value := range headers {
+		masked[name] = webhook.MaskSecret(value)
+	}
+	return masked
+}
+
+// unmaskHeaders returns the requested headers with each value that is the
+// masked form of the stored value of the same header replaced by the stored
+// value, so that a read subscription can be written back unchanged
+func unmaskHeaders(requested, stored map[string]string) map[string]string {
+	headers := subscription.CopyHeaders(requested)
+	for name, value := range headers {
+		if current, ok := stored[name]; ok && value != current && value == webhook.MaskSecret(current) {
+			headers[name] = current
+		}
+	}
+	return headers
 }
 
 // copyDeliveryConfig creates an immutable copy of DeliveryConfig
 func copyDeliveryConfig(d subscription.DeliveryConfig) subscription.DeliveryConfig {
 	return subscription.DeliveryConfig{
-		Type:         d.Type,
-		URL:          d.URL,
-		Secret:       d.Secret,
-		SecretPrefix: d.SecretPrefix,
-		Verified:     d.Verified,
-		SignVersion:  d.SignVersion,
+		Type:                    d.Type,
+		URL:                     d.URL,
+		Secret:                  d.Secret,
+		SecretPrefix:            d.SecretPrefix,
+		PreviousSecret:          d.PreviousSecret,
+		PreviousSecretExpiresAt: copyTime(d.PreviousSecretExpiresAt),
+		Verified:                d.Verified,
+		VerificationSkipped:     d.VerificationSkipped,
+		SignVersion:             d.SignVersion,
+		Retry:                   d.Retry.Copy(),
+		ServiceNotices:          d.ServiceNotices,
+		Template:                d.Template,
+		Enrich:                  d.Enrich,
+		Format:                  d.Format,
+		Headers:                 subscription.CopyHeaders(d.Headers),
+		AWS:                     d.AWS.Copy(),
+		SMS:                     d.SMS.Copy(),
 	}
 }
 
@@ -534,10 +1199,83 @@ func copyFilterConfig(f *subscription.FilterConfig) *subscription.FilterConfig {
 	}
 	prefectures := make([]string, len(f.Prefectures))
 	copy(prefectures, f.Prefectures)
+	var eventTypes []string
+	if len(f.EventTypes) > 0 {
+		eventTypes = make([]string, len(f.EventTypes))
+		copy(eventTypes, f.EventTypes)
+	}
 	return &subscription.FilterConfig{
 		MinScale:    f.MinScale,
 		Prefectures: prefectures,
+		EventTypes:  eventTypes,
+		EEW:         f.EEW,
+
+		MinMagnitude:           f.MinMagnitude,
+		MaxDepthKm:             f.MaxDepthKm,
+		HypocenterNameContains: f.HypocenterNameContains,
+		Geofence:               f.Geofence.Copy(),
+	}
+}
+
+func copyTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	c := t.UTC()
+	return &c
+}
+
+// optionalTime returns t as a pointer, or nil if it is zero
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+// canReactivateOverQuota reports whether a subscription suspended over its
+// owner's plan limit fits within the limit again, counting the owner's other
+// subscriptions in the tenant that are not suspended
+func (h *Handler) canReactivateOverQuota(ctx context.Context, sub subscription.Subscription) (bool, error) {
+	if h.quotaChecker == nil || sub.UserID == "" {
+		return true, nil
+	}
+	subs, err := h.subscriptionRepo.ListByUserID(ctx, sub.UserID)
+	if err != nil {
+		return false, err
+	}
+	counted := 0
+	for _, s := range subs {
+		if s.TenantID == sub.TenantID && s.ID != sub.ID && s.Status != subscription.StatusSuspended {
+			counted++
+		}
+	}
+	limit := h.quotaChecker.Features(ctx, h.getUserPlan(ctx, sub.UserID)).MaxSubscriptions
+	return counted < limit, nil
+}
+
+// createWithinLimit creates a subscription, atomically checking that its owner
+// has fewer than limit subscriptions in the tenant. A negative limit creates
+// without checking, for ownerless subscriptions and handlers without quota,
+// unless the name must be unique: that is checked in the same step.
+func (h *Handler) createWithinLimit(ctx context.Context, sub subscription.Subscription, limit int) (string, error) {
+	if limit < 0 && !sub.UniqueName {
+		return h.subscriptionRepo.Create(ctx, sub)
+	}
+	if limit < 0 {
+		limit = math.MaxInt
+	}
+	return h.subscriptionRepo.CreateWithinLimit(ctx, sub, limit)
+}
+
+// checkPlanFeatures returns a *plan.FeatureError if the subscription uses a
+// feature its owner's plan does not include. Ownerless subscriptions and
+// handlers without quota checking are not restricted.
+func (h *Handler) checkPlanFeatures(ctx context.Context, sub subscription.Subscription) error {
+	if h.quotaChecker == nil || sub.UserID == "" {
+		return nil
 	}
+	return h.quotaChecker.Features(ctx, h.getUserPlan(ctx, sub.UserID)).Check(sub)
 }
 
 // getUserPlan retrieves the user's plan from the user repository
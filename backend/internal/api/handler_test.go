@@ -7,12 +7,15 @@ import (
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/otiai10/namazu/backend/internal/apierr"
 	"github.com/otiai10/namazu/backend/internal/auth"
 	"github.com/otiai10/namazu/backend/internal/delivery/webhook"
+	"github.com/otiai10/namazu/backend/internal/plan"
 	"github.com/otiai10/namazu/backend/internal/quota"
 	"github.com/otiai10/namazu/backend/internal/store"
 	"github.com/otiai10/namazu/backend/internal/subscription"
@@ -25,6 +28,12 @@ type mockSubscriptionRepo struct {
 	nextID        int
 }
 
+// withIfMatch sets the If-Match header of req
+func withIfMatch(req *http.Request, etag string) *http.Request {
+	req.Header.Set("If-Match", etag)
+	return req
+}
+
 func newMockSubscriptionRepo() *mockSubscriptionRepo {
 	return &mockSubscriptionRepo{
 		subscriptions: make(map[string]subscription.Subscription),
@@ -44,10 +53,28 @@ func (m *mockSubscriptionRepo) Create(ctx context.Context, sub subscription.Subs
 	id := "sub-" + string(rune('0'+m.nextID))
 	m.nextID++
 	sub.ID = id
+	sub.Revision = 1
 	m.subscriptions[id] = sub
 	return id, nil
 }
 
+func (m *mockSubscriptionRepo) CreateWithinLimit(ctx context.Context, sub subscription.Subscription, limit int) (string, error) {
+	used := 0
+	for _, s := range m.subscriptions {
+		if s.UserID == sub.UserID && s.TenantID == sub.TenantID {
+			used++
+			if sub.UniqueName && s.Name == sub.Name {
+				return "", subscription.ErrNameTaken
+			}
+		}
+	}
+	if used >= limit {
+		return "", subscription.ErrLimitExceeded
+	}
+	sub.UniqueName = false
+	return m.Create(ctx, sub)
+}
+
 func (m *mockSubscriptionRepo) Get(ctx context.Context, id string) (*subscription.Subscription, error) {
 	sub, ok := m.subscriptions[id]
 	if !ok {
@@ -57,7 +84,13 @@ func (m *mockSubscriptionRepo) Get(ctx context.Context, id string) (*subscriptio
 }
 
 func (m *mockSubscriptionRepo) Update(ctx context.Context, id string, sub subscription.Subscription) error {
+	current := m.subscriptions[id]
+	if sub.ChecksRevision() && sub.Revision != current.Revision {
+		return subscription.ErrRevisionConflict
+	}
 	sub.ID = id
+	sub.ExpectRevision = false
+	sub.Revision = current.Revision + 1
 	m.subscriptions[id] = sub
 	return nil
 }
@@ -117,6 +150,17 @@ func (m *mockEventRepo) List(ctx context.Context, limit int, startAfter *time.Ti
 	return result, nil
 }
 
+// Query runs the query against an in-memory copy of the events
+func (m *mockEventRepo) Query(ctx context.Context, q store.EventQuery) (*store.EventPage, error) {
+	repo := store.NewMemoryEventRepository()
+	for _, e := range m.events {
+		if _, err := repo.Create(ctx, e); err != nil {
+			return nil, err
+		}
+	}
+	return repo.Query(ctx, q)
+}
+
 func TestCreateSubscription(t *testing.T) {
 	subRepo := newMockSubscriptionRepo()
 	eventRepo := newMockEventRepo()
@@ -316,6 +360,7 @@ func TestUpdateSubscription(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			req := httptest.NewRequest(http.MethodPut, "/api/subscriptions/"+tt.id, bytes.NewBufferString(tt.body))
+			req.Header.Set("If-Match", subscriptionETag(subRepo.subscriptions[tt.id]))
 			req.Header.Set("Content-Type", "application/json")
 			rec := httptest.NewRecorder()
 
@@ -439,18 +484,148 @@ func TestListEvents(t *testing.T) {
 				t.Errorf("expected status %d, got %d", tt.expectedStatus, rec.Code)
 			}
 
-			var response []EventResponse
+			var response EventListResponse
 			if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
 				t.Fatalf("failed to unmarshal response: %v", err)
 			}
 
-			if len(response) != tt.expectedCount {
-				t.Errorf("expected %d events, got %d", tt.expectedCount, len(response))
+			if len(response.Events) != tt.expectedCount {
+				t.Errorf("expected %d events, got %d", tt.expectedCount, len(response.Events))
 			}
 		})
 	}
 }
 
+func TestListEvents_Cursor(t *testing.T) {
+	eventRepo := newMockEventRepo()
+	base := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+	for i, area := range []string{"東京都", "大阪府", "東京都", "東京都"} {
+		eventRepo.events = append(eventRepo.events, store.EventRecord{
+			ID:            string(rune('a' + i)),
+			Type:          "earthquake",
+			Severity:      10 * (i + 1),
+			AffectedAreas: []string{area},
+			OccurredAt:    base.Add(time.Duration(i) * time.Hour),
+		})
+	}
+	router := NewRouter(NewHandler(newMockSubscriptionRepo(), eventRepo))
+
+	list := func(query string) EventListResponse {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/events"+query, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("GET /api/events%s: status %d: %s", query, rec.Code, rec.Body.String())
+		}
+		var response EventListResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+			t.Fatalf("failed to unmarshal response: %v", err)
+		}
+		return response
+	}
+
+	first := list("?limit=2&order=asc&prefecture=" + url.QueryEscape("東京都"))
+	if len(first.Events) != 2 || first.Events[0].ID != "a" || first.Events[1].ID != "c" || first.Total != 3 {
+		t.Fatalf("first page = %+v", first)
+	}
+	if first.NextCursor == "" {
+		t.Fatal("expected a next_cursor")
+	}
+	second := list("?limit=2&order=asc&prefecture=" + url.QueryEscape("東京都") + "&cursor=" + first.NextCursor)
+	if len(second.Events) != 1 || second.Events[0].ID != "d" || second.NextCursor != "" {
+		t.Errorf("second page = %+v", second)
+	}
+
+	filtered := list("?min_severity=20&type=earthquake&from=2024-01-15T13:00:00Z&to=2024-01-15T15:00:00Z")
+	if len(filtered.Events) != 2 || filtered.Events[0].ID != "c" || filtered.Total != 2 {
+		t.Errorf("filtered = %+v", filtered)
+	}
+	legacy := list("?start_after=2024-01-15T13:00:00Z")
+	if len(legacy.Events) != 1 || legacy.Events[0].ID != "a" {
+		t.Errorf("start_after = %+v", legacy)
+	}
+}
+
+func TestGetEvent(t *testing.T) {
+	eventRepo := newMockEventRepo()
+	eventRepo.events = []store.EventRecord{{
+		ID:            "event-1",
+		Type:          "earthquake",
+		Source:        "p2pquake",
+		Severity:      50,
+		AffectedAreas: []string{"東京都"},
+		OccurredAt:    time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC),
+		RawJSON:       `{"code":551}`,
+	}}
+	handler := NewHandler(newMockSubscriptionRepo(), eventRepo)
+	router := NewRouter(handler)
+
+	get := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+
+	rec := get("/api/events/event-1")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var response EventDetailResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if response.ID != "event-1" || response.RawJSON != `{"code":551}` || response.Severity != 50 {
+		t.Errorf("unexpected response: %+v", response)
+	}
+	if response.Deliveries != nil {
+		t.Errorf("expected no delivery summary without delivery history, got %+v", response.Deliveries)
+	}
+
+	handler.SetDeliveryRepository(&mockDeliveryRepo{records: []store.DeliveryRecord{
+		{SubscriptionID: "sub-1", EventID: "event-1", Success: true},
+		{SubscriptionID: "sub-2", EventID: "event-1", Success: true},
+		{SubscriptionID: "sub-3", EventID: "event-1", Success: false},
+		{SubscriptionID: "sub-1", EventID: "event-2", Success: false},
+	}})
+	response = EventDetailResponse{}
+	if err := json.Unmarshal(get("/api/events/event-1").Body.Bytes(), &response); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if response.Deliveries == nil || *response.Deliveries != (DeliverySummaryResponse{Delivered: 2, Failed: 1}) {
+		t.Errorf("Deliveries = %+v", response.Deliveries)
+	}
+
+	for _, path := range []string{"/api/events/missing", "/api/events/event-1/raw"} {
+		if rec := get(path); rec.Code != http.StatusNotFound {
+			t.Errorf("%s: expected status 404, got %d", path, rec.Code)
+		}
+	}
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/api/events/event-1", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected status 405, got %d", rec.Code)
+	}
+}
+
+func TestListEvents_InvalidQuery(t *testing.T) {
+	router := NewRouter(NewHandler(newMockSubscriptionRepo(), newMockEventRepo()))
+	for _, query := range []string{
+		"?order=newest",
+		"?min_severity=-1",
+		"?type=volcano",
+		"?from=yesterday",
+		"?to=2024-01-15",
+		"?cursor=not-a-cursor",
+	} {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/events"+query, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d", query, rec.Code)
+		}
+	}
+}
+
 func TestHealthEndpoint(t *testing.T) {
 	subRepo := newMockSubscriptionRepo()
 	eventRepo := newMockEventRepo()
@@ -690,6 +865,21 @@ func TestGetSubscription_AllowsAccessToLegacySubscriptionWithoutUserID(t *testin
 	if rec.Code != http.StatusOK {
 		t.Errorf("expected status %d, got %d", http.StatusOK, rec.Code)
 	}
+
+	// Until the legacy access path is closed
+	handler.SetDenyOwnerless(true)
+	rec = httptest.NewRecorder()
+	handler.GetSubscription(rec, req)
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("expected status %d with ownerless access denied, got %d", http.StatusForbidden, rec.Code)
+	}
+
+	// Without auth there are no owners to check
+	rec = httptest.NewRecorder()
+	handler.GetSubscription(rec, httptest.NewRequest(http.MethodGet, "/api/subscriptions/sub-legacy", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected status %d without auth, got %d", http.StatusOK, rec.Code)
+	}
 }
 
 func TestUpdateSubscription_Returns403ForOtherUsersSubscription(t *testing.T) {
@@ -852,6 +1042,53 @@ func TestListSubscriptions_FiltersToUserOwnSubscriptions(t *testing.T) {
 	}
 }
 
+func TestListSubscriptions_CrossUser(t *testing.T) {
+	subRepo := newMockSubscriptionRepo()
+	subRepo.subscriptions["sub-a"] = subscription.Subscription{ID: "sub-a", UserID: "user-a", Name: "A"}
+	subRepo.subscriptions["sub-b"] = subscription.Subscription{ID: "sub-b", UserID: "user-b", Name: "B"}
+	handler := NewHandler(subRepo, newMockEventRepo())
+
+	list := func(claims *auth.Claims, query string) (int, []SubscriptionResponse) {
+		req := httptest.NewRequest(http.MethodGet, "/api/subscriptions"+query, nil)
+		req = req.WithContext(auth.WithClaims(req.Context(), claims))
+		rec := httptest.NewRecorder()
+		handler.ListSubscriptions(rec, req)
+		var response []SubscriptionResponse
+		_ = json.Unmarshal(rec.Body.Bytes(), &response)
+		return rec.Code, response
+	}
+
+	user := &auth.Claims{UID: "user-a"}
+	admin := &auth.Claims{UID: "admin-1", Admin: true}
+
+	tests := []struct {
+		name      string
+		claims    *auth.Claims
+		query     string
+		wantCode  int
+		wantCount int
+	}{
+		{"user lists another user", user, "?user_id=user-b", http.StatusForbidden, 0},
+		{"user lists everyone", user, "?all=true", http.StatusForbidden, 0},
+		{"user lists self explicitly", user, "?user_id=user-a", http.StatusOK, 1},
+		{"admin lists another user", admin, "?user_id=user-b", http.StatusOK, 1},
+		{"admin lists everyone", admin, "?all=true", http.StatusOK, 2},
+		{"admin lists own by default", admin, "", http.StatusOK, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, response := list(tt.claims, tt.query)
+			if code != tt.wantCode {
+				t.Fatalf("expected status %d, got %d", tt.wantCode, code)
+			}
+			if len(response) != tt.wantCount {
+				t.Errorf("expected %d subscriptions, got %d", tt.wantCount, len(response))
+			}
+		})
+	}
+}
+
 // quotaUserRepo implements user.Repository for quota testing
 // (separate from mockUserRepo in me_handler_test.go to avoid conflicts)
 type quotaUserRepo struct {
@@ -896,6 +1133,11 @@ func (m *quotaUserRepo) Update(ctx context.Context, id string, u user.User) erro
 	return nil
 }
 
+func (m *quotaUserRepo) Delete(ctx context.Context, id string) error {
+	delete(m.users, id)
+	return nil
+}
+
 func (m *quotaUserRepo) UpdateLastLogin(ctx context.Context, id string, t time.Time) error {
 	return nil
 }
@@ -908,6 +1150,22 @@ func (m *quotaUserRepo) RemoveProvider(ctx context.Context, id string, providerI
 	return nil
 }
 
+func (m *quotaUserRepo) AddPushSubscription(ctx context.Context, id string, sub user.PushSubscription) error {
+	return nil
+}
+
+func (m *quotaUserRepo) RemovePushSubscription(ctx context.Context, id string, endpoint string) error {
+	return nil
+}
+
+func (m *quotaUserRepo) AddDevice(ctx context.Context, id string, device user.Device) error {
+	return nil
+}
+
+func (m *quotaUserRepo) RemoveDevice(ctx context.Context, id string, token string) error {
+	return nil
+}
+
 func (m *quotaUserRepo) GetByStripeCustomerID(ctx context.Context, customerID string) (*user.User, error) {
 	for _, u := range m.users {
 		if u.StripeCustomerID == customerID {
@@ -920,6 +1178,9 @@ func (m *quotaUserRepo) GetByStripeCustomerID(ctx context.Context, customerID st
 // mockQuotaChecker implements quota.QuotaChecker for testing
 type mockQuotaChecker struct {
 	canCreate bool
+	used      int
+	limit     int
+	features  plan.Features
 	err       error
 }
 
@@ -927,6 +1188,18 @@ func (m *mockQuotaChecker) CanCreateSubscription(ctx context.Context, userID, pl
 	return m.canCreate, m.err
 }
 
+func (m *mockQuotaChecker) CanCreateSubscriptions(ctx context.Context, userID, plan string, n int) (bool, error) {
+	return m.canCreate, m.err
+}
+
+func (m *mockQuotaChecker) Features(ctx context.Context, planID string) plan.Features {
+	return m.features
+}
+
+func (m *mockQuotaChecker) SubscriptionUsage(ctx context.Context, userID, plan string) (int, int, error) {
+	return m.used, m.limit, m.err
+}
+
 // Tests for quota checking in CreateSubscription
 
 func TestCreateSubscription_Returns403WhenQuotaExceeded(t *testing.T) {
@@ -976,8 +1249,11 @@ func TestCreateSubscription_Returns403WhenQuotaExceeded(t *testing.T) {
 		t.Fatalf("failed to unmarshal error response: %v", err)
 	}
 
-	if errResp.Error != "Subscription limit reached for your plan" {
-		t.Errorf("expected quota error message, got %s", errResp.Error)
+	if errResp.Error.Code != apierr.QuotaExceeded || errResp.Error.Message != "Subscription limit reached for your plan" {
+		t.Errorf("expected quota error, got %+v", errResp.Error)
+	}
+	if errResp.Error.Details["plan"] != user.PlanFree {
+		t.Errorf("expected the plan in the details, got %v", errResp.Error.Details)
 	}
 }
 
@@ -994,7 +1270,7 @@ func TestCreateSubscription_AllowsCreationWithinQuota(t *testing.T) {
 	_, _ = userRepo.Create(context.Background(), testUser)
 
 	// Create quota checker that allows creation
-	quotaChecker := &mockQuotaChecker{canCreate: true, err: nil}
+	quotaChecker := &mockQuotaChecker{canCreate: true, features: plan.For("free"), err: nil}
 
 	handler := NewHandlerWithQuota(subRepo, eventRepo, userRepo, quotaChecker)
 
@@ -1024,6 +1300,147 @@ func TestCreateSubscription_AllowsCreationWithinQuota(t *testing.T) {
 	}
 }
 
+func TestCreateSubscription_EnforcesLimitWhenCreating(t *testing.T) {
+	// The quota check passed, but another request created a subscription since
+	subRepo := newMockSubscriptionRepo()
+	subRepo.subscriptions["sub-other"] = subscription.Subscription{ID: "sub-other", UserID: "test-user-uid", Name: "Other"}
+	userRepo := newQuotaUserRepo()
+	_, _ = userRepo.Create(context.Background(), user.User{UID: "test-user-uid", Plan: user.PlanFree})
+
+	quotaChecker := &mockQuotaChecker{canCreate: true, features: plan.For("free")}
+	handler := NewHandlerWithQuota(subRepo, newMockEventRepo(), userRepo, quotaChecker)
+
+	body := `{"name": "New Subscription", "delivery": {"type": "webhook", "url": "https://example.com/webhook"}}`
+	req := httptest.NewRequest(http.MethodPost, "/api/subscriptions", bytes.NewBufferString(body))
+	req = req.WithContext(auth.WithClaims(req.Context(), &auth.Claims{UID: "test-user-uid"}))
+	rec := httptest.NewRecorder()
+
+	handler.CreateSubscription(rec, req)
+
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusForbidden, rec.Code, rec.Body.String())
+	}
+	var errResp ErrorResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &errResp); err != nil {
+		t.Fatalf("failed to unmarshal error response: %v", err)
+	}
+	if errResp.Error.Code != apierr.QuotaExceeded {
+		t.Errorf("error code = %q, want %q", errResp.Error.Code, apierr.QuotaExceeded)
+	}
+	if len(subRepo.subscriptions) != 1 {
+		t.Errorf("expected no subscription to be created, have %d", len(subRepo.subscriptions))
+	}
+}
+
+func TestCreateSubscription_ChecksPlanFeatures(t *testing.T) {
+	body := `{
+		"name": "Digest",
+		"delivery": {"type": "webhook", "url": "https://example.com/webhook"},
+		"digest": {"interval_minutes": 60}
+	}`
+	tests := []struct {
+		name     string
+		features plan.Features
+		wantCode int
+	}{
+		{"free", plan.Free, http.StatusForbidden},
+		{"pro", plan.Pro, http.StatusCreated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewHandlerWithQuota(newMockSubscriptionRepo(), newMockEventRepo(), newQuotaUserRepo(),
+				&mockQuotaChecker{canCreate: true, features: tt.features})
+
+			req := httptest.NewRequest(http.MethodPost, "/api/subscriptions", bytes.NewBufferString(body))
+			req = req.WithContext(auth.WithClaims(req.Context(), &auth.Claims{UID: "test-user-uid"}))
+			rec := httptest.NewRecorder()
+
+			handler.CreateSubscription(rec, req)
+
+			if rec.Code != tt.wantCode {
+				t.Errorf("expected status %d, got %d: %s", tt.wantCode, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestCreateSubscription_RetrySettings(t *testing.T) {
+	tests := []struct {
+		name     string
+		retry    string
+		features plan.Features
+		wantCode int
+		wantMsg  string
+	}{
+		{"jitter and retry after", `{"enabled": true, "max_retries": 3, "initial_ms": 1000, "max_ms": 60000, "jitter": 0.3, "status_overrides": [{"status": 429, "retry": true, "retry_after": true}]}`, plan.Free, http.StatusCreated, ""},
+		{"invalid jitter", `{"enabled": true, "max_retries": 3, "jitter": 2}`, plan.Pro, http.StatusBadRequest, "jitter must be between 0 and 1"},
+		{"invalid status", `{"enabled": true, "max_retries": 3, "status_overrides": [{"status": 200, "retry": true}]}`, plan.Pro, http.StatusBadRequest, "non-2xx HTTP status code"},
+		{"too many retries on free", `{"enabled": true, "max_retries": 10, "initial_ms": 1000, "max_ms": 60000}`, plan.Free, http.StatusForbidden, "10 retries"},
+		{"window too long on free", `{"enabled": true, "max_retries": 3, "initial_ms": 60000, "max_ms": 600000}`, plan.Free, http.StatusForbidden, "7m0s retry window"},
+		{"long window on pro", `{"enabled": true, "max_retries": 10, "initial_ms": 1000, "max_ms": 600000}`, plan.Pro, http.StatusCreated, ""},
+		{"window too long on pro", `{"enabled": true, "max_retries": 10, "initial_ms": 60000, "max_ms": 600000}`, plan.Pro, http.StatusForbidden, "at most 1h0m0s"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subRepo := newMockSubscriptionRepo()
+			handler := NewHandlerWithQuota(subRepo, newMockEventRepo(), newQuotaUserRepo(),
+				&mockQuotaChecker{canCreate: true, features: tt.features})
+
+			body := `{"name": "Retrying", "delivery": {"type": "webhook", "url": "https://example.com/webhook", "retry": ` + tt.retry + `}}`
+			req := httptest.NewRequest(http.MethodPost, "/api/subscriptions", bytes.NewBufferString(body))
+			req = req.WithContext(auth.WithClaims(req.Context(), &auth.Claims{UID: "test-user-uid"}))
+			rec := httptest.NewRecorder()
+
+			handler.CreateSubscription(rec, req)
+
+			if rec.Code != tt.wantCode || !strings.Contains(rec.Body.String(), tt.wantMsg) {
+				t.Fatalf("expected status %d with %q, got %d: %s", tt.wantCode, tt.wantMsg, rec.Code, rec.Body.String())
+			}
+			if tt.wantCode != http.StatusCreated {
+				return
+			}
+			var resp SubscriptionResponse
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if stored := subRepo.subscriptions[resp.ID].Delivery.Retry; stored == nil || stored.Jitter != resp.Delivery.Retry.Jitter ||
+				len(stored.StatusOverrides) != len(resp.Delivery.Retry.StatusOverrides) {
+				t.Errorf("stored retry %+v does not match response %+v", stored, resp.Delivery.Retry)
+			}
+		})
+	}
+}
+
+func TestUpdateSubscription_ChecksOwnerPlanFeatures(t *testing.T) {
+	subRepo := newMockSubscriptionRepo()
+	subRepo.subscriptions["sub-1"] = subscription.Subscription{
+		ID:       "sub-1",
+		UserID:   "test-user-uid",
+		Name:     "Webhook",
+		Delivery: subscription.DeliveryConfig{Type: "webhook", URL: "https://example.com/webhook"},
+	}
+	handler := NewHandlerWithQuota(subRepo, newMockEventRepo(), newQuotaUserRepo(), &mockQuotaChecker{features: plan.Free})
+
+	body := `{
+		"name": "Webhook",
+		"delivery": {"type": "webhook", "url": "https://example.com/webhook"},
+		"filter": {"geofence": {"lat": 35.68, "lon": 139.76, "radius_km": 100}}
+	}`
+	req := httptest.NewRequest(http.MethodPut, "/api/subscriptions/sub-1", bytes.NewBufferString(body))
+	req.Header.Set("If-Match", "*")
+	req = req.WithContext(auth.WithClaims(req.Context(), &auth.Claims{UID: "test-user-uid"}))
+	rec := httptest.NewRecorder()
+
+	handler.UpdateSubscription(rec, req)
+
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusForbidden, rec.Code, rec.Body.String())
+	}
+	if subRepo.subscriptions["sub-1"].Filter != nil {
+		t.Error("the subscription was updated")
+	}
+}
+
 func TestCreateSubscription_SkipsQuotaCheckWithoutAuth(t *testing.T) {
 	subRepo := newMockSubscriptionRepo()
 	eventRepo := newMockEventRepo()
@@ -1241,8 +1658,8 @@ func TestCreateSubscription_WithURLValidation(t *testing.T) {
 			t.Fatalf("failed to unmarshal response: %v", err)
 		}
 
-		if response.Error == "" {
-			t.Error("expected error message in response")
+		if response.Error.Code != apierr.ValidationFailed || response.Error.Message == "" {
+			t.Errorf("expected a validation error, got %+v", response.Error)
 		}
 	})
 
@@ -1363,6 +1780,7 @@ func TestUpdateSubscription_WithURLValidation(t *testing.T) {
 		}`
 
 		req := httptest.NewRequest(http.MethodPut, "/api/subscriptions/sub-1", bytes.NewBufferString(body))
+		req.Header.Set("If-Match", "*")
 		req.Header.Set("Content-Type", "application/json")
 		rec := httptest.NewRecorder()
 
@@ -1401,6 +1819,7 @@ func TestUpdateSubscription_WithURLValidation(t *testing.T) {
 		}`
 
 		req := httptest.NewRequest(http.MethodPut, "/api/subscriptions/sub-1", bytes.NewBufferString(body))
+		req.Header.Set("If-Match", "*")
 		req.Header.Set("Content-Type", "application/json")
 		rec := httptest.NewRecorder()
 
@@ -1538,8 +1957,8 @@ func TestGetSubscription_MasksSecret(t *testing.T) {
 		t.Error("GET response should not return unmasked secret")
 	}
 
-	if !strings.Contains(response.Delivery.Secret, "...") {
-		t.Errorf("expected masked secret to contain '...', got %q", response.Delivery.Secret)
+	if response.Delivery.Secret != "****5678" {
+		t.Errorf("expected masked secret %q, got %q", "****5678", response.Delivery.Secret)
 	}
 }
 
@@ -1591,8 +2010,8 @@ func TestListSubscriptions_MasksSecrets(t *testing.T) {
 		if sub.Delivery.Secret == generatedSecret {
 			t.Errorf("LIST response should not return unmasked secret for %s", sub.ID)
 		}
-		if !strings.Contains(sub.Delivery.Secret, "...") {
-			t.Errorf("expected masked secret to contain '...' for %s, got %q", sub.ID, sub.Delivery.Secret)
+		if sub.Delivery.Secret != "****5678" {
+			t.Errorf("expected masked secret %q for %s, got %q", "****5678", sub.ID, sub.Delivery.Secret)
 		}
 	}
 }
@@ -1639,6 +2058,7 @@ func TestUpdateSubscription_PreservesSecret(t *testing.T) {
 	}`
 
 	updateReq := httptest.NewRequest(http.MethodPut, "/api/subscriptions/"+subID, bytes.NewBufferString(updateBody))
+	updateReq.Header.Set("If-Match", "*")
 	updateReq.Header.Set("Content-Type", "application/json")
 	updateRec := httptest.NewRecorder()
 
@@ -1668,7 +2088,7 @@ type mockChallenger struct {
 	result webhook.ChallengeResult
 }
 
-func (m *mockChallenger) VerifyURL(ctx context.Context, url, secret string) webhook.ChallengeResult {
+func (m *mockChallenger) VerifyURL(ctx context.Context, url, secret string, headers map[string]string) webhook.ChallengeResult {
 	return m.result
 }
 
@@ -1743,7 +2163,7 @@ func TestCreateSubscription_RejectsUnverifiableURL(t *testing.T) {
 		t.Fatalf("failed to unmarshal error response: %v", err)
 	}
 
-	if errResp.Error == "" {
+	if errResp.Error.Message == "" {
 		t.Error("expected error message in response")
 	}
 }
@@ -1812,6 +2232,7 @@ func TestUpdateSubscription_ReVerifiesOnURLChange(t *testing.T) {
 	}`
 
 	req := httptest.NewRequest(http.MethodPut, "/api/subscriptions/sub-1", bytes.NewBufferString(body))
+	req.Header.Set("If-Match", "*")
 	req.Header.Set("Content-Type", "application/json")
 	rec := httptest.NewRecorder()
 
@@ -1867,6 +2288,7 @@ func TestUpdateSubscription_SkipsVerificationOnSameURL(t *testing.T) {
 	}`
 
 	req := httptest.NewRequest(http.MethodPut, "/api/subscriptions/sub-1", bytes.NewBufferString(body))
+	req.Header.Set("If-Match", "*")
 	req.Header.Set("Content-Type", "application/json")
 	rec := httptest.NewRecorder()
 
@@ -1885,3 +2307,710 @@ func TestUpdateSubscription_SkipsVerificationOnSameURL(t *testing.T) {
 		t.Error("expected Verified to remain true when URL unchanged")
 	}
 }
+
+func TestCreateSubscription_Lifecycle(t *testing.T) {
+	subRepo := newMockSubscriptionRepo()
+	handler := NewHandler(subRepo, newMockEventRepo())
+
+	expiresAt := time.Now().Add(30 * 24 * time.Hour).UTC().Truncate(time.Second)
+	body := `{"name": "Campaign", "delivery": {"type": "webhook", "url": "https://example.com/webhook"}, "expires_at": "` + expiresAt.Format(time.RFC3339) + `"}`
+	rec := httptest.NewRecorder()
+	handler.CreateSubscription(rec, httptest.NewRequest(http.MethodPost, "/api/subscriptions", bytes.NewBufferString(body)))
+
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, rec.Code, rec.Body.String())
+	}
+	var resp SubscriptionResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if resp.Status != subscription.StatusActive {
+		t.Errorf("expected status active, got %q", resp.Status)
+	}
+	if resp.ExpiresAt == nil || !resp.ExpiresAt.Equal(expiresAt) {
+		t.Errorf("expected expires_at %v, got %v", expiresAt, resp.ExpiresAt)
+	}
+
+	stored := subRepo.subscriptions[resp.ID]
+	if stored.CreatedAt.IsZero() {
+		t.Error("expected CreatedAt to be set")
+	}
+	if stored.Status != subscription.StatusActive {
+		t.Errorf("expected stored status active, got %q", stored.Status)
+	}
+}
+
+func TestCreateSubscription_RejectsPastExpiry(t *testing.T) {
+	handler := NewHandler(newMockSubscriptionRepo(), newMockEventRepo())
+
+	body := `{"name": "Campaign", "delivery": {"type": "webhook", "url": "https://example.com/webhook"}, "expires_at": "2020-01-01T00:00:00Z"}`
+	rec := httptest.NewRecorder()
+	handler.CreateSubscription(rec, httptest.NewRequest(http.MethodPost, "/api/subscriptions", bytes.NewBufferString(body)))
+
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d, got %d", http.StatusBadRequest, rec.Code)
+	}
+}
+
+func TestCreateSubscription_EventTypes(t *testing.T) {
+	subRepo := newMockSubscriptionRepo()
+	handler := NewHandler(subRepo, newMockEventRepo())
+
+	body := `{"name": "Tsunami", "delivery": {"type": "webhook", "url": "https://example.com/webhook"}, "filter": {"event_types": ["tsunami"], "eew": true}}`
+	rec := httptest.NewRecorder()
+	handler.CreateSubscription(rec, httptest.NewRequest(http.MethodPost, "/api/subscriptions", bytes.NewBufferString(body)))
+
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, rec.Code, rec.Body.String())
+	}
+	var resp SubscriptionResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	stored := subRepo.subscriptions[resp.ID]
+	if stored.Filter == nil || len(stored.Filter.EventTypes) != 1 || stored.Filter.EventTypes[0] != "tsunami" {
+		t.Errorf("expected stored event types [tsunami], got %+v", stored.Filter)
+	}
+	if stored.Filter == nil || !stored.Filter.EEW {
+		t.Errorf("expected EEW opt-in to be stored, got %+v", stored.Filter)
+	}
+}
+
+func TestCreateSubscription_Locale(t *testing.T) {
+	subRepo := newMockSubscriptionRepo()
+	handler := NewHandler(subRepo, newMockEventRepo())
+	create := func(locale string) *httptest.ResponseRecorder {
+		body := `{"name": "Intl", "delivery": {"type": "webhook", "url": "https://example.com/webhook"}, "locale": "` + locale + `"}`
+		rec := httptest.NewRecorder()
+		handler.CreateSubscription(rec, httptest.NewRequest(http.MethodPost, "/api/subscriptions", bytes.NewBufferString(body)))
+		return rec
+	}
+
+	rec := create("en")
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, rec.Code, rec.Body.String())
+	}
+	var resp SubscriptionResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.Locale != "en" || subRepo.subscriptions[resp.ID].Locale != "en" {
+		t.Errorf("locale = %q (stored %q), want en", resp.Locale, subRepo.subscriptions[resp.ID].Locale)
+	}
+
+	if rec := create("fr"); rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "locale must be one of ja, en") {
+		t.Errorf("expected 400 for an unsupported locale, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestCreateSubscription_RejectsUnknownEventType(t *testing.T) {
+	handler := NewHandler(newMockSubscriptionRepo(), newMockEventRepo())
+
+	body := `{"name": "Volcano", "delivery": {"type": "webhook", "url": "https://example.com/webhook"}, "filter": {"event_types": ["volcano"]}}`
+	rec := httptest.NewRecorder()
+	handler.CreateSubscription(rec, httptest.NewRequest(http.MethodPost, "/api/subscriptions", bytes.NewBufferString(body)))
+
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d, got %d", http.StatusBadRequest, rec.Code)
+	}
+}
+
+func TestCreateSubscription_PrefectureNames(t *testing.T) {
+	subRepo := newMockSubscriptionRepo()
+	handler := NewHandler(subRepo, newMockEventRepo())
+
+	body := `{"name": "Kanto", "delivery": {"type": "webhook", "url": "https://example.com/webhook"}, "filter": {"prefectures": ["Tokyo", "関東", "osaka-fu"]}}`
+	rec := httptest.NewRecorder()
+	handler.CreateSubscription(rec, httptest.NewRequest(http.MethodPost, "/api/subscriptions", bytes.NewBufferString(body)))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, rec.Code, rec.Body.String())
+	}
+
+	body = `{"name": "Typo", "delivery": {"type": "webhook", "url": "https://example.com/webhook"}, "filter": {"prefectures": ["Tokio"]}}`
+	rec = httptest.NewRecorder()
+	handler.CreateSubscription(rec, httptest.NewRequest(http.MethodPost, "/api/subscriptions", bytes.NewBufferString(body)))
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "unknown prefecture or region: Tokio") {
+		t.Errorf("expected 400 for an unknown prefecture, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestCreateSubscription_HypocenterFilter(t *testing.T) {
+	subRepo := newMockSubscriptionRepo()
+	handler := NewHandler(subRepo, newMockEventRepo())
+
+	body := `{"name": "Noto", "delivery": {"type": "webhook", "url": "https://example.com/webhook"}, "filter": {"min_magnitude": 5.5, "max_depth_km": 30, "hypocenter_name_contains": "能登"}}`
+	rec := httptest.NewRecorder()
+	handler.CreateSubscription(rec, httptest.NewRequest(http.MethodPost, "/api/subscriptions", bytes.NewBufferString(body)))
+
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, rec.Code, rec.Body.String())
+	}
+	var resp SubscriptionResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.Filter == nil || resp.Filter.MinMagnitude != 5.5 || resp.Filter.MaxDepthKm != 30 || resp.Filter.HypocenterNameContains != "能登" {
+		t.Errorf("unexpected filter in response: %+v", resp.Filter)
+	}
+	if stored := subRepo.subscriptions[resp.ID]; stored.Filter == nil || stored.Filter.MaxDepthKm != 30 {
+		t.Errorf("expected the hypocenter filter to be stored, got %+v", stored.Filter)
+	}
+
+	for _, filter := range []string{`{"min_magnitude": -1}`, `{"max_depth_km": -5}`} {
+		body := `{"name": "Bad", "delivery": {"type": "webhook", "url": "https://example.com/webhook"}, "filter": ` + filter + `}`
+		rec := httptest.NewRecorder()
+		handler.CreateSubscription(rec, httptest.NewRequest(http.MethodPost, "/api/subscriptions", bytes.NewBufferString(body)))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status %d, got %d", filter, http.StatusBadRequest, rec.Code)
+		}
+	}
+}
+
+func TestCreateSubscription_Geofence(t *testing.T) {
+	subRepo := newMockSubscriptionRepo()
+	handler := NewHandler(subRepo, newMockEventRepo())
+
+	body := `{"name": "Tokyo 100km", "delivery": {"type": "webhook", "url": "https://example.com/webhook"}, "filter": {"geofence": {"lat": 35.68, "lon": 139.77, "radius_km": 100}}}`
+	rec := httptest.NewRecorder()
+	handler.CreateSubscription(rec, httptest.NewRequest(http.MethodPost, "/api/subscriptions", bytes.NewBufferString(body)))
+
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, rec.Code, rec.Body.String())
+	}
+	var resp SubscriptionResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.Filter == nil || resp.Filter.Geofence == nil || *resp.Filter.Geofence != (subscription.Geofence{Lat: 35.68, Lon: 139.77, RadiusKm: 100}) {
+		t.Errorf("unexpected filter in response: %+v", resp.Filter)
+	}
+
+	for _, geofence := range []string{`{"lat": 95, "lon": 139.77, "radius_km": 100}`, `{"lat": 35.68, "lon": 139.77, "radius_km": 0}`} {
+		body := `{"name": "Bad", "delivery": {"type": "webhook", "url": "https://example.com/webhook"}, "filter": {"geofence": ` + geofence + `}}`
+		rec := httptest.NewRecorder()
+		handler.CreateSubscription(rec, httptest.NewRequest(http.MethodPost, "/api/subscriptions", bytes.NewBufferString(body)))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status %d, got %d", geofence, http.StatusBadRequest, rec.Code)
+		}
+	}
+}
+
+func TestCreateSubscription_QuietHours(t *testing.T) {
+	subRepo := newMockSubscriptionRepo()
+	handler := NewHandler(subRepo, newMockEventRepo())
+
+	body := `{"name": "Night", "delivery": {"type": "webhook", "url": "https://example.com/webhook"}, "quiet_hours": {"start": "23:00", "end": "07:00", "timezone": "Asia/Tokyo", "min_scale_override": 50}}`
+	rec := httptest.NewRecorder()
+	handler.CreateSubscription(rec, httptest.NewRequest(http.MethodPost, "/api/subscriptions", bytes.NewBufferString(body)))
+
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, rec.Code, rec.Body.String())
+	}
+	var resp SubscriptionResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	want := subscription.QuietHours{Start: "23:00", End: "07:00", Timezone: "Asia/Tokyo", MinScaleOverride: 50}
+	if resp.QuietHours == nil || *resp.QuietHours != want {
+		t.Errorf("unexpected quiet hours in response: %+v", resp.QuietHours)
+	}
+	if stored := subRepo.subscriptions[resp.ID]; stored.QuietHours == nil || *stored.QuietHours != want {
+		t.Errorf("expected the quiet hours to be stored, got %+v", stored.QuietHours)
+	}
+
+	for _, quietHours := range []string{`{"start": "23:00"}`, `{"start": "23:00", "end": "07:00", "timezone": "Nowhere/City"}`} {
+		body := `{"name": "Bad", "delivery": {"type": "webhook", "url": "https://example.com/webhook"}, "quiet_hours": ` + quietHours + `}`
+		rec := httptest.NewRecorder()
+		handler.CreateSubscription(rec, httptest.NewRequest(http.MethodPost, "/api/subscriptions", bytes.NewBufferString(body)))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status %d, got %d", quietHours, http.StatusBadRequest, rec.Code)
+		}
+	}
+}
+
+func TestCreateSubscription_Throttle(t *testing.T) {
+	subRepo := newMockSubscriptionRepo()
+	handler := NewHandler(subRepo, newMockEventRepo())
+
+	body := `{"name": "Swarm", "delivery": {"type": "webhook", "url": "https://example.com/webhook"}, "throttle": {"interval_minutes": 15, "dedupe_by": "hypocenter"}}`
+	rec := httptest.NewRecorder()
+	handler.CreateSubscription(rec, httptest.NewRequest(http.MethodPost, "/api/subscriptions", bytes.NewBufferString(body)))
+
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, rec.Code, rec.Body.String())
+	}
+	var resp SubscriptionResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	want := subscription.ThrottleConfig{IntervalMinutes: 15, DedupeBy: subscription.DedupeByHypocenter}
+	if resp.Throttle == nil || *resp.Throttle != want {
+		t.Errorf("unexpected throttle in response: %+v", resp.Throttle)
+	}
+	if stored := subRepo.subscriptions[resp.ID]; stored.Throttle == nil || *stored.Throttle != want {
+		t.Errorf("expected the throttle to be stored, got %+v", stored.Throttle)
+	}
+
+	for _, throttle := range []string{`{"interval_minutes": 0}`, `{"interval_minutes": 5, "dedupe_by": "region"}`} {
+		body := `{"name": "Bad", "delivery": {"type": "webhook", "url": "https://example.com/webhook"}, "throttle": ` + throttle + `}`
+		rec := httptest.NewRecorder()
+		handler.CreateSubscription(rec, httptest.NewRequest(http.MethodPost, "/api/subscriptions", bytes.NewBufferString(body)))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status %d, got %d", throttle, http.StatusBadRequest, rec.Code)
+		}
+	}
+}
+
+func TestCreateSubscription_Digest(t *testing.T) {
+	subRepo := newMockSubscriptionRepo()
+	handler := NewHandler(subRepo, newMockEventRepo())
+
+	body := `{"name": "Hourly", "delivery": {"type": "webhook", "url": "https://example.com/webhook"}, "digest": {"interval_minutes": 60}}`
+	rec := httptest.NewRecorder()
+	handler.CreateSubscription(rec, httptest.NewRequest(http.MethodPost, "/api/subscriptions", bytes.NewBufferString(body)))
+
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, rec.Code, rec.Body.String())
+	}
+	var resp SubscriptionResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.Digest == nil || resp.Digest.IntervalMinutes != 60 {
+		t.Errorf("unexpected digest in response: %+v", resp.Digest)
+	}
+	if stored := subRepo.subscriptions[resp.ID]; stored.Digest == nil || stored.Digest.IntervalMinutes != 60 {
+		t.Errorf("expected the digest to be stored, got %+v", stored.Digest)
+	}
+
+	body = `{"name": "Bad", "delivery": {"type": "webhook", "url": "https://example.com/webhook"}, "digest": {"interval_minutes": 0}}`
+	rec = httptest.NewRecorder()
+	handler.CreateSubscription(rec, httptest.NewRequest(http.MethodPost, "/api/subscriptions", bytes.NewBufferString(body)))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d, got %d", http.StatusBadRequest, rec.Code)
+	}
+}
+
+func TestUpdateSubscription_PreservesLifecycleStatus(t *testing.T) {
+	subRepo := newMockSubscriptionRepo()
+	createdAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	warnedAt := time.Now().Add(-24 * time.Hour).UTC()
+	subRepo.subscriptions["sub-1"] = subscription.Subscription{
+		ID:              "sub-1",
+		Name:            "Old",
+		Delivery:        subscription.DeliveryConfig{Type: "webhook", URL: "https://example.com/webhook"},
+		CreatedAt:       createdAt,
+		Status:          subscription.StatusWarned,
+		StatusReason:    subscription.ReasonInactive,
+		StatusChangedAt: &warnedAt,
+	}
+	handler := NewHandler(subRepo, newMockEventRepo())
+
+	body := `{"name": "New", "delivery": {"type": "webhook", "url": "https://example.com/webhook"}}`
+	rec := httptest.NewRecorder()
+	handler.UpdateSubscription(rec, withIfMatch(httptest.NewRequest(http.MethodPut, "/api/subscriptions/sub-1", bytes.NewBufferString(body)), "*"))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+	}
+	stored := subRepo.subscriptions["sub-1"]
+	if !stored.CreatedAt.Equal(createdAt) {
+		t.Errorf("expected CreatedAt to be preserved, got %v", stored.CreatedAt)
+	}
+	if stored.Status != subscription.StatusWarned || stored.StatusReason != subscription.ReasonInactive {
+		t.Errorf("expected status to be preserved, got %q (%q)", stored.Status, stored.StatusReason)
+	}
+
+	var resp SubscriptionResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if resp.Status != subscription.StatusWarned || resp.StatusReason != subscription.ReasonInactive {
+		t.Errorf("expected warned (inactive) in response, got %q (%q)", resp.Status, resp.StatusReason)
+	}
+}
+
+func TestGetSubscription_DefaultsStatusToActive(t *testing.T) {
+	subRepo := newMockSubscriptionRepo()
+	subRepo.subscriptions["sub-1"] = subscription.Subscription{ID: "sub-1", Name: "Legacy"}
+	handler := NewHandler(subRepo, newMockEventRepo())
+
+	rec := httptest.NewRecorder()
+	handler.GetSubscription(rec, httptest.NewRequest(http.MethodGet, "/api/subscriptions/sub-1", nil))
+
+	var resp SubscriptionResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if resp.Status != subscription.StatusActive {
+		t.Errorf("expected status active, got %q", resp.Status)
+	}
+}
+
+func TestReactivateSubscription(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name       string
+		sub        subscription.Subscription
+		claimsUID  string
+		wantCode   int
+		wantStatus string
+	}{
+		{
+			name:       "suspended for inactivity",
+			sub:        subscription.Subscription{UserID: "user-1", Status: subscription.StatusSuspended, StatusReason: subscription.ReasonInactive},
+			claimsUID:  "user-1",
+			wantCode:   http.StatusOK,
+			wantStatus: subscription.StatusActive,
+		},
+		{
+			name:       "warned before expiry",
+			sub:        subscription.Subscription{UserID: "user-1", ExpiresAt: &future, Status: subscription.StatusWarned, StatusReason: subscription.ReasonExpiring},
+			claimsUID:  "user-1",
+			wantCode:   http.StatusOK,
+			wantStatus: subscription.StatusActive,
+		},
+		{
+			name:       "expired",
+			sub:        subscription.Subscription{UserID: "user-1", ExpiresAt: &past, Status: subscription.StatusSuspended, StatusReason: subscription.ReasonExpired},
+			claimsUID:  "user-1",
+			wantCode:   http.StatusConflict,
+			wantStatus: subscription.StatusSuspended,
+		},
+		{
+			name:       "other user's subscription",
+			sub:        subscription.Subscription{UserID: "user-2", Status: subscription.StatusSuspended},
+			claimsUID:  "user-1",
+			wantCode:   http.StatusForbidden,
+			wantStatus: subscription.StatusSuspended,
+		},
+		{
+			name:       "archived without an owner",
+			sub:        subscription.Subscription{Status: subscription.StatusSuspended, StatusReason: subscription.ReasonOrphaned},
+			claimsUID:  "user-1",
+			wantCode:   http.StatusConflict,
+			wantStatus: subscription.StatusSuspended,
+		},
+		{
+			name:       "archived, then assigned",
+			sub:        subscription.Subscription{UserID: "user-1", Status: subscription.StatusSuspended, StatusReason: subscription.ReasonOrphaned},
+			claimsUID:  "user-1",
+			wantCode:   http.StatusOK,
+			wantStatus: subscription.StatusActive,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subRepo := newMockSubscriptionRepo()
+			tt.sub.ID = "sub-1"
+			subRepo.subscriptions["sub-1"] = tt.sub
+			handler := NewHandler(subRepo, newMockEventRepo())
+
+			req := httptest.NewRequest(http.MethodPost, "/api/subscriptions/sub-1/reactivate", nil)
+			req = req.WithContext(auth.WithClaims(req.Context(), &auth.Claims{UID: tt.claimsUID}))
+			rec := httptest.NewRecorder()
+			handler.ReactivateSubscription(rec, req, "sub-1")
+
+			if rec.Code != tt.wantCode {
+				t.Fatalf("expected status %d, got %d: %s", tt.wantCode, rec.Code, rec.Body.String())
+			}
+			stored := subRepo.subscriptions["sub-1"]
+			if stored.Status != tt.wantStatus {
+				t.Errorf("expected stored status %q, got %q", tt.wantStatus, stored.Status)
+			}
+			if tt.wantCode == http.StatusOK && (stored.StatusReason != "" || stored.StatusChangedAt == nil) {
+				t.Errorf("expected reason cleared and change time set, got %+v", stored)
+			}
+		})
+	}
+}
+
+func TestReactivateSubscription_OverQuota(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		limit    int
+		wantCode int
+	}{
+		{"still over the limit", 1, http.StatusForbidden},
+		{"within the limit", 2, http.StatusOK},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			subRepo := newMockSubscriptionRepo()
+			subRepo.subscriptions["active"] = subscription.Subscription{ID: "active", UserID: "user-1", Status: subscription.StatusActive}
+			subRepo.subscriptions["sub-1"] = subscription.Subscription{ID: "sub-1", UserID: "user-1", Status: subscription.StatusSuspended, StatusReason: subscription.ReasonOverQuota}
+			handler := NewHandlerWithQuota(subRepo, newMockEventRepo(), newQuotaUserRepo(),
+				&mockQuotaChecker{features: plan.Features{MaxSubscriptions: tt.limit}})
+
+			req := httptest.NewRequest(http.MethodPost, "/api/subscriptions/sub-1/reactivate", nil)
+			req = req.WithContext(auth.WithClaims(req.Context(), &auth.Claims{UID: "user-1"}))
+			rec := httptest.NewRecorder()
+			handler.ReactivateSubscription(rec, req, "sub-1")
+
+			if rec.Code != tt.wantCode {
+				t.Errorf("expected status %d, got %d: %s", tt.wantCode, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestCreateSubscription_Template(t *testing.T) {
+	subRepo := newMockSubscriptionRepo()
+	handler := NewHandler(subRepo, newMockEventRepo())
+
+	template := `{"text": {{json (scaleName .Event.Scale)}}}`
+	reqBody, _ := json.Marshal(SubscriptionRequest{
+		Name:     "Templated",
+		Delivery: subscription.DeliveryConfig{Type: "webhook", URL: "https://example.com/webhook", Template: template},
+	})
+	rec := httptest.NewRecorder()
+	handler.CreateSubscription(rec, httptest.NewRequest(http.MethodPost, "/api/subscriptions", bytes.NewReader(reqBody)))
+
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, rec.Code, rec.Body.String())
+	}
+	var resp SubscriptionResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.Delivery.Template != template {
+		t.Errorf("unexpected template in response: %q", resp.Delivery.Template)
+	}
+	if stored := subRepo.subscriptions[resp.ID]; stored.Delivery.Template != template {
+		t.Errorf("expected the template to be stored, got %q", stored.Delivery.Template)
+	}
+
+	for _, bad := range []string{`{{.Event.ID`, `{{env "HOME"}}`, `{"a": {{.Event.Nope}}}`, `plain text`} {
+		reqBody, _ := json.Marshal(SubscriptionRequest{
+			Name:     "Bad",
+			Delivery: subscription.DeliveryConfig{Type: "webhook", URL: "https://example.com/webhook", Template: bad},
+		})
+		rec := httptest.NewRecorder()
+		handler.CreateSubscription(rec, httptest.NewRequest(http.MethodPost, "/api/subscriptions", bytes.NewReader(reqBody)))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status %d, got %d", bad, http.StatusBadRequest, rec.Code)
+		}
+	}
+}
+
+func TestCreateSubscription_Format(t *testing.T) {
+	subRepo := newMockSubscriptionRepo()
+	handler := NewHandler(subRepo, newMockEventRepo())
+
+	body := `{"name": "Normalized", "delivery": {"type": "webhook", "url": "https://example.com/webhook", "format": "namazu.v1"}}`
+	rec := httptest.NewRecorder()
+	handler.CreateSubscription(rec, httptest.NewRequest(http.MethodPost, "/api/subscriptions", bytes.NewBufferString(body)))
+
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, rec.Code, rec.Body.String())
+	}
+	var resp SubscriptionResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.Delivery.Format != "namazu.v1" || subRepo.subscriptions[resp.ID].Delivery.Format != "namazu.v1" {
+		t.Errorf("expected the format to be stored and returned, got %q", resp.Delivery.Format)
+	}
+
+	body = `{"name": "Unknown", "delivery": {"type": "webhook", "url": "https://example.com/webhook", "format": "namazu.v2"}}`
+	rec = httptest.NewRecorder()
+	handler.CreateSubscription(rec, httptest.NewRequest(http.MethodPost, "/api/subscriptions", bytes.NewBufferString(body)))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d for an unknown format, got %d", http.StatusBadRequest, rec.Code)
+	}
+}
+
+func TestCreateSubscription_Headers(t *testing.T) {
+	subRepo := newMockSubscriptionRepo()
+	handler := NewHandler(subRepo, newMockEventRepo())
+
+	body := `{"name": "Authorized", "delivery": {"type": "webhook", "url": "https://example.com/webhook", "headers": {"Authorization": "Bearer token"}}}`
+	rec := httptest.NewRecorder()
+	handler.CreateSubscription(rec, httptest.NewRequest(http.MethodPost, "/api/subscriptions", bytes.NewBufferString(body)))
+
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, rec.Code, rec.Body.String())
+	}
+	var resp SubscriptionResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if stored := subRepo.subscriptions[resp.ID]; stored.Delivery.Headers["Authorization"] != "Bearer token" {
+		t.Errorf("expected the headers to be stored, got %v", stored.Delivery.Headers)
+	}
+
+	for _, headers := range []string{`{"Host": "evil.example.com"}`, `{"Content-Length": "0"}`, `{"X-Route": "a\r\nb"}`} {
+		body := `{"name": "Bad", "delivery": {"type": "webhook", "url": "https://example.com/webhook", "headers": ` + headers + `}}`
+		rec := httptest.NewRecorder()
+		handler.CreateSubscription(rec, httptest.NewRequest(http.MethodPost, "/api/subscriptions", bytes.NewBufferString(body)))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status %d, got %d", headers, http.StatusBadRequest, rec.Code)
+		}
+	}
+}
+
+func TestSubscriptionResponses_MaskHeaders(t *testing.T) {
+	subRepo := newMockSubscriptionRepo()
+	router := NewRouter(NewHandler(subRepo, newMockEventRepo()))
+	const token = "Bearer 0123456789abcdef0123456789abcdef"
+
+	body := `{"name": "Authorized", "delivery": {"type": "webhook", "url": "https://example.com/webhook", "headers": {"Authorization": "` + token + `", "X-Team": "ops"}}}`
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/subscriptions", bytes.NewBufferString(body)))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, rec.Code, rec.Body.String())
+	}
+	if strings.Contains(rec.Body.String(), token) {
+		t.Fatalf("create response echoes the bearer token: %s", rec.Body.String())
+	}
+	var created SubscriptionResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &created); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if got := created.Delivery.Headers["Authorization"]; got != "****cdef" {
+		t.Errorf("Authorization = %q, want ****cdef", got)
+	}
+
+	for _, path := range []string{"/api/subscriptions/" + created.ID, "/api/subscriptions"} {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusOK || strings.Contains(rec.Body.String(), token) {
+			t.Errorf("GET %s = %d, echoes the bearer token: %v", path, rec.Code, strings.Contains(rec.Body.String(), token))
+		}
+	}
+
+	// Writing back what was read keeps the stored header values
+	created.Delivery.Headers["X-Team"] = "sre"
+	update, _ := json.Marshal(map[string]any{"name": "Authorized", "delivery": created.Delivery})
+	req := httptest.NewRequest(http.MethodPut, "/api/subscriptions/"+created.ID, bytes.NewReader(update))
+	req.Header.Set("If-Match", "*")
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+	}
+	if strings.Contains(rec.Body.String(), token) {
+		t.Errorf("PUT response echoes the bearer token: %s", rec.Body.String())
+	}
+	stored := subRepo.subscriptions[created.ID].Delivery.Headers
+	if stored["Authorization"] != token || stored["X-Team"] != "sre" {
+		t.Errorf("stored headers = %v, want the token kept and X-Team replaced", stored)
+	}
+}
+
+func TestCreateSubscription_SNS(t *testing.T) {
+	subRepo := newMockSubscriptionRepo()
+	handler := NewHandler(subRepo, newMockEventRepo())
+
+	body := `{"name": "Topic", "delivery": {"type": "sns", "aws": {"region": "ap-northeast-1", "topic_arn": "arn:aws:sns:ap-northeast-1:123456789012:quakes", "access_key_id": "AKID", "secret_access_key": "secret"}}}`
+	rec := httptest.NewRecorder()
+	handler.CreateSubscription(rec, httptest.NewRequest(http.MethodPost, "/api/subscriptions", bytes.NewBufferString(body)))
+
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, rec.Code, rec.Body.String())
+	}
+	if strings.Contains(rec.Body.String(), "secret_access_key") {
+		t.Error("expected the secret access key to be omitted from the response")
+	}
+	var resp SubscriptionResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.Delivery.AWS == nil || resp.Delivery.AWS.TopicARN != "arn:aws:sns:ap-northeast-1:123456789012:quakes" {
+		t.Fatalf("unexpected aws config in response: %+v", resp.Delivery.AWS)
+	}
+	if stored := subRepo.subscriptions[resp.ID]; stored.Delivery.AWS == nil || stored.Delivery.AWS.SecretAccessKey != "secret" {
+		t.Errorf("expected the credentials to be stored, got %+v", stored.Delivery.AWS)
+	}
+
+	for _, delivery := range []string{
+		`{"type": "sns"}`,
+		`{"type": "sqs", "aws": {"region": "ap-northeast-1", "queue_url": "https://internal.example.com/123456789012/quakes", "access_key_id": "AKID", "secret_access_key": "secret"}}`,
+		`{"type": "webhook", "url": "https://example.com/webhook", "aws": {"region": "ap-northeast-1"}}`,
+	} {
+		body := `{"name": "Bad", "delivery": ` + delivery + `}`
+		rec := httptest.NewRecorder()
+		handler.CreateSubscription(rec, httptest.NewRequest(http.MethodPost, "/api/subscriptions", bytes.NewBufferString(body)))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status %d, got %d", delivery, http.StatusBadRequest, rec.Code)
+		}
+	}
+}
+
+func TestCreateSubscription_SMS(t *testing.T) {
+	subRepo := newMockSubscriptionRepo()
+	handler := NewHandler(subRepo, newMockEventRepo())
+
+	body := `{"name": "Phone", "delivery": {"type": "sms", "sms": {"phone": "+819012345678", "min_scale": 55}}}`
+	rec := httptest.NewRecorder()
+	handler.CreateSubscription(rec, httptest.NewRequest(http.MethodPost, "/api/subscriptions", bytes.NewBufferString(body)))
+
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, rec.Code, rec.Body.String())
+	}
+	var resp SubscriptionResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.Delivery.SMS == nil || resp.Delivery.SMS.Phone != "+819012345678" || resp.Delivery.SMS.MinScale != 55 {
+		t.Fatalf("unexpected sms config in response: %+v", resp.Delivery.SMS)
+	}
+	if stored := subRepo.subscriptions[resp.ID]; stored.Delivery.SMS == nil || stored.Delivery.URL != "" {
+		t.Errorf("unexpected stored delivery: %+v", stored.Delivery)
+	}
+
+	for _, delivery := range []string{
+		`{"type": "sms"}`,
+		`{"type": "sms", "sms": {"phone": "090-1234-5678"}}`,
+		`{"type": "sms", "sms": {"phone": "+819012345678", "min_scale": 80}}`,
+		`{"type": "webhook", "url": "https://example.com/webhook", "sms": {"phone": "+819012345678"}}`,
+	} {
+		body := `{"name": "Bad", "delivery": ` + delivery + `}`
+		rec := httptest.NewRecorder()
+		handler.CreateSubscription(rec, httptest.NewRequest(http.MethodPost, "/api/subscriptions", bytes.NewBufferString(body)))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status %d, got %d", delivery, http.StatusBadRequest, rec.Code)
+		}
+	}
+}
+
+func TestCreateSubscription_WebPush(t *testing.T) {
+	subRepo := newMockSubscriptionRepo()
+	handler := NewHandler(subRepo, newMockEventRepo())
+	body := `{"name": "Browser", "delivery": {"type": "webpush"}, "filter": {"min_scale": 40}}`
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/subscriptions", bytes.NewBufferString(body))
+	handler.CreateSubscription(rec, req.WithContext(auth.WithClaims(req.Context(), &auth.Claims{UID: "test-uid"})))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, rec.Code, rec.Body.String())
+	}
+
+	// Without an owner there is nobody to notify
+	rec = httptest.NewRecorder()
+	handler.CreateSubscription(rec, httptest.NewRequest(http.MethodPost, "/api/subscriptions", bytes.NewBufferString(body)))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d without authentication, got %d", http.StatusBadRequest, rec.Code)
+	}
+}
+
+func TestCreateSubscription_FCM(t *testing.T) {
+	subRepo := newMockSubscriptionRepo()
+	handler := NewHandler(subRepo, newMockEventRepo())
+	body := `{"name": "Phone", "delivery": {"type": "fcm"}, "filter": {"min_scale": 40}}`
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/subscriptions", bytes.NewBufferString(body))
+	handler.CreateSubscription(rec, req.WithContext(auth.WithClaims(req.Context(), &auth.Claims{UID: "test-uid"})))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	handler.CreateSubscription(rec, httptest.NewRequest(http.MethodPost, "/api/subscriptions", bytes.NewBufferString(body)))
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "fcm delivery requires authentication") {
+		t.Errorf("expected status %d without authentication, got %d: %s", http.StatusBadRequest, rec.Code, rec.Body.String())
+	}
+}
@@ -0,0 +1,114 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/otiai10/namazu/backend/internal/version"
+)
+
+// readinessTimeout bounds each readiness check, below the probes' own timeouts
+const readinessTimeout = 2 * time.Second
+
+// Health statuses
+const (
+	healthOK          = "ok"
+	healthError       = "error"
+	healthUnavailable = "unavailable"
+)
+
+// ReadinessCheck checks that a dependency is usable.
+// detail describes its state, e.g. the age of a connection.
+type ReadinessCheck func(ctx context.Context) (detail string, err error)
+
+// HealthResponse is the body of /healthz (and /health)
+type HealthResponse struct {
+	Status string `json:"status"`
+	Hash   string `json:"hash"`
+}
+
+// ReadinessResponse is the body of /readyz
+type ReadinessResponse struct {
+	Status string                      `json:"status"` // ok, or unavailable if a check failed
+	Hash   string                      `json:"hash"`
+	Checks map[string]DependencyStatus `json:"checks"`
+}
+
+// DependencyStatus is the result of one readiness check
+type DependencyStatus struct {
+	Status    string `json:"status"` // ok or error
+	Detail    string `json:"detail,omitempty"`
+	Error     string `json:"error,omitempty"`
+	LatencyMs int64  `json:"latency_ms"`
+}
+
+// HealthHandler serves the liveness and readiness probes
+type HealthHandler struct {
+	checks map[string]ReadinessCheck
+}
+
+// NewHealthHandler creates a handler running the given readiness checks, by dependency name
+func NewHealthHandler(checks map[string]ReadinessCheck) *HealthHandler {
+	return &HealthHandler{checks: checks}
+}
+
+// Liveness handles GET /healthz: the process is up and serving HTTP.
+// It never checks dependencies, so that an outage does not get instances restarted.
+func (h *HealthHandler) Liveness(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, HealthResponse{Status: healthOK, Hash: version.CommitHash}, http.StatusOK)
+}
+
+// Readiness handles GET /readyz: every dependency is usable.
+// The checks run concurrently; any failure makes the response 503 so that
+// load balancers stop routing traffic to the instance.
+func (h *HealthHandler) Readiness(w http.ResponseWriter, r *http.Request) {
+	resp := ReadinessResponse{
+		Status: healthOK,
+		Hash:   version.CommitHash,
+		Checks: make(map[string]DependencyStatus, len(h.checks)),
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, check := range h.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result := runCheck(r.Context(), check)
+			mu.Lock()
+			defer mu.Unlock()
+			resp.Checks[name] = result
+			if result.Status != healthOK {
+				resp.Status = healthUnavailable
+			}
+		}()
+	}
+	wg.Wait()
+
+	status := http.StatusOK
+	if resp.Status != healthOK {
+		status = http.StatusServiceUnavailable
+	}
+	writeJSON(w, resp, status)
+}
+
+// runCheck runs one check within readinessTimeout
+func runCheck(ctx context.Context, check ReadinessCheck) DependencyStatus {
+	ctx, cancel := context.WithTimeout(ctx, readinessTimeout)
+	defer cancel()
+
+	start := time.Now()
+	detail, err := check(ctx)
+	result := DependencyStatus{
+		Status:    healthOK,
+		Detail:    detail,
+		LatencyMs: time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		result.Status = healthError
+		result.Error = err.Error()
+	}
+	return result
+}
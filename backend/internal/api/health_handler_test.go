@@ -0,0 +1,88 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/otiai10/namazu/backend/internal/version"
+)
+
+func TestHealthHandler_Liveness(t *testing.T) {
+	router := NewRouter(NewHandler(newMockSubscriptionRepo(), newMockEventRepo()))
+
+	for _, path := range []string{"/healthz", "/health"} {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		var resp HealthResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("%s: failed to unmarshal response: %v", path, err)
+		}
+		if rec.Code != http.StatusOK || resp.Status != "ok" || resp.Hash != version.CommitHash {
+			t.Errorf("%s = %d %+v, want 200 ok", path, rec.Code, resp)
+		}
+	}
+}
+
+func TestHealthHandler_Readiness(t *testing.T) {
+	ok := func(ctx context.Context) (string, error) { return "connected for 5s", nil }
+	failing := func(ctx context.Context) (string, error) { return "", errors.New("unavailable") }
+
+	tests := []struct {
+		name       string
+		checks     map[string]ReadinessCheck
+		wantCode   int
+		wantStatus string
+	}{
+		{"no checks", nil, http.StatusOK, "ok"},
+		{"all ok", map[string]ReadinessCheck{"source": ok, "firestore": ok}, http.StatusOK, "ok"},
+		{"one failing", map[string]ReadinessCheck{"source": ok, "firestore": failing}, http.StatusServiceUnavailable, "unavailable"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := NewRouterWithConfig(RouterConfig{
+				SubscriptionRepo: newMockSubscriptionRepo(),
+				EventRepo:        newMockEventRepo(),
+				Readiness:        tt.checks,
+			})
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+			var resp ReadinessResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("failed to unmarshal response: %v", err)
+			}
+			if rec.Code != tt.wantCode || resp.Status != tt.wantStatus {
+				t.Errorf("got %d %q, want %d %q", rec.Code, resp.Status, tt.wantCode, tt.wantStatus)
+			}
+			if len(resp.Checks) != len(tt.checks) {
+				t.Fatalf("checks = %+v, want one per dependency", resp.Checks)
+			}
+			if got, ok := resp.Checks["source"]; ok && (got.Status != "ok" || got.Detail != "connected for 5s") {
+				t.Errorf("source = %+v, want ok with its detail", got)
+			}
+			if got, ok := resp.Checks["firestore"]; ok && tt.wantStatus != "ok" && (got.Status != "error" || got.Error != "unavailable") {
+				t.Errorf("firestore = %+v, want the error", got)
+			}
+		})
+	}
+}
+
+func TestHealthHandler_ReadinessTimeout(t *testing.T) {
+	hanging := func(ctx context.Context) (string, error) {
+		<-ctx.Done()
+		return "", ctx.Err()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req := httptest.NewRequest(http.MethodGet, "/readyz", nil).WithContext(ctx)
+	rec := httptest.NewRecorder()
+	NewHealthHandler(map[string]ReadinessCheck{"firestore": hanging}).Readiness(rec, req)
+
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected status %d for a check that does not answer, got %d", http.StatusServiceUnavailable, rec.Code)
+	}
+}
@@ -0,0 +1,183 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/otiai10/namazu/backend/internal/apierr"
+	"github.com/otiai10/namazu/backend/internal/auth"
+	"github.com/otiai10/namazu/backend/internal/idempotency"
+	"github.com/otiai10/namazu/backend/internal/secrets"
+)
+
+// IdempotencyKeyHeader lets clients retry a POST without repeating its effect
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// IdempotentReplayedHeader marks a response replayed from an earlier request with the same key
+const IdempotentReplayedHeader = "Idempotent-Replayed"
+
+// maxIdempotencyKeyLength bounds the Idempotency-Key header
+const maxIdempotencyKeyLength = 255
+
+// maxIdempotentBodyBytes bounds the body of a request made with an Idempotency-Key
+const maxIdempotentBodyBytes = 1 << 20
+
+// Idempotency replays the stored response when a request is retried with the
+// same Idempotency-Key, instead of running the handler again.
+// Keys are scoped to the caller and the endpoint, and kept for idempotency.DefaultTTL.
+type Idempotency struct {
+	repo     idempotency.Repository
+	ttl      time.Duration
+	envelope *secrets.Envelope // Encrypts stored responses; nil stores them as sent
+}
+
+// NewIdempotency creates an Idempotency storing keys in repo
+func NewIdempotency(repo idempotency.Repository) *Idempotency {
+	return &Idempotency{repo: repo, ttl: idempotency.DefaultTTL}
+}
+
+// SetEnvelope encrypts stored responses at rest. Responses can carry
+// secrets (a created subscription's webhook secret), so they are sealed
+// with the envelope of subscription secrets.
+func (i *Idempotency) SetEnvelope(e *secrets.Envelope) {
+	i.envelope = e
+}
+
+// Wrap makes next idempotent for requests that carry an Idempotency-Key.
+// A nil Idempotency, or a request without the header, runs next as is.
+//
+//   - A retry with the same key and body gets the original status and body, with Idempotent-Replayed: true
+//   - A retry while the first request is still running gets 409
+//   - The same key with a different body gets 422
+//   - 5xx responses are not stored, so the request can be retried with the same key
+//
+// If the key store fails, the request is handled without idempotency rather than rejected.
+func (i *Idempotency) Wrap(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(IdempotencyKeyHeader)
+		if i == nil || key == "" {
+			next(w, r)
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			writeErrorCode(w, apierr.ValidationFailed, "Idempotency-Key is too long", http.StatusBadRequest)
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxIdempotentBodyBytes))
+		if err != nil {
+			writeError(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		var userID string
+		if claims, ok := auth.GetClaims(r.Context()); ok {
+			userID = claims.UID
+		}
+		now := time.Now()
+		record := idempotency.Record{
+			Key:         idempotency.Key(userID, r.Method, r.URL.Path, key),
+			RequestHash: idempotency.RequestHash(body),
+			CreatedAt:   now,
+			ExpiresAt:   now.Add(i.ttl),
+		}
+
+		existing, reserved, err := i.repo.Reserve(r.Context(), record)
+		if err != nil {
+			log.Printf("Idempotency-Key ignored: %v", err)
+			next(w, r)
+			return
+		}
+		if !reserved {
+			i.replay(r.Context(), w, existing, record.RequestHash)
+			return
+		}
+
+		rec := &idempotencyRecorder{ResponseWriter: w, status: http.StatusOK}
+		next(rec, r)
+
+		// Store the outcome even if the client has gone away: that is when it retries
+		ctx := context.WithoutCancel(r.Context())
+		if rec.status >= http.StatusInternalServerError {
+			if err := i.repo.Release(ctx, record.Key); err != nil {
+				log.Printf("Failed to release Idempotency-Key: %v", err)
+			}
+			return
+		}
+		record.Completed = true
+		record.StatusCode = rec.status
+		record.ContentType = rec.Header().Get("Content-Type")
+		record.Body = rec.body.Bytes()
+		if i.envelope != nil {
+			sealed, err := i.envelope.Seal(ctx, string(record.Body))
+			if err != nil {
+				// Never store the response unsealed; a retry runs the request again
+				log.Printf("Failed to encrypt the response of an Idempotency-Key: %v", err)
+				if err := i.repo.Release(ctx, record.Key); err != nil {
+					log.Printf("Failed to release Idempotency-Key: %v", err)
+				}
+				return
+			}
+			record.Body = []byte(sealed)
+		}
+		if err := i.repo.Complete(ctx, record); err != nil {
+			log.Printf("Failed to store the response of an Idempotency-Key: %v", err)
+		}
+	}
+}
+
+// replay answers a request whose key was already used
+func (i *Idempotency) replay(ctx context.Context, w http.ResponseWriter, existing *idempotency.Record, requestHash string) {
+	body := existing.Body
+	if existing.Completed && secrets.IsSealed(string(body)) {
+		if i.envelope == nil {
+			log.Printf("Idempotency-Key response is encrypted but no secrets key is configured")
+			writeError(w, "failed to replay the response", http.StatusInternalServerError)
+			return
+		}
+		opened, err := i.envelope.Open(ctx, string(body))
+		if err != nil {
+			log.Printf("Failed to decrypt the response of an Idempotency-Key: %v", err)
+			writeError(w, "failed to replay the response", http.StatusInternalServerError)
+			return
+		}
+		body = []byte(opened)
+	}
+	switch {
+	case existing.RequestHash != requestHash:
+		writeErrorCode(w, apierr.IdempotencyKeyReused, "Idempotency-Key was already used with a different request", http.StatusUnprocessableEntity)
+	case !existing.Completed:
+		writeErrorCode(w, apierr.IdempotencyInProgress, "a request with this Idempotency-Key is in progress", http.StatusConflict)
+	default:
+		if existing.ContentType != "" {
+			w.Header().Set("Content-Type", existing.ContentType)
+		}
+		w.Header().Set(IdempotentReplayedHeader, "true")
+		w.WriteHeader(existing.StatusCode)
+		_, _ = w.Write(body)
+	}
+}
+
+// idempotencyRecorder passes a response through while keeping a copy to store
+type idempotencyRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+// WriteHeader captures the status code before writing
+func (w *idempotencyRecorder) WriteHeader(code int) {
+	w.status = code
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Write copies the body before writing
+func (w *idempotencyRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
@@ -0,0 +1,207 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/otiai10/namazu/backend/internal/auth"
+	"github.com/otiai10/namazu/backend/internal/idempotency"
+	"github.com/otiai10/namazu/backend/internal/secrets"
+)
+
+const idempotentBody = `{"name": "Hook", "delivery": {"type": "webhook", "url": "https://example.com/webhook"}}`
+
+func idempotentRequest(handler http.Handler, uid, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/api/subscriptions", bytes.NewBufferString(body))
+	if key != "" {
+		req.Header.Set(IdempotencyKeyHeader, key)
+	}
+	req = req.WithContext(auth.WithClaims(req.Context(), &auth.Claims{UID: uid}))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestIdempotency_CreateSubscription(t *testing.T) {
+	subRepo := newMockSubscriptionRepo()
+	handler := NewHandler(subRepo, newMockEventRepo())
+	handler.SetIdempotency(NewIdempotency(idempotency.NewMemoryRepository()))
+	router := NewRouter(handler)
+
+	first := idempotentRequest(router, "user-1", "key-1", idempotentBody)
+	if first.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, first.Code, first.Body.String())
+	}
+
+	retry := idempotentRequest(router, "user-1", "key-1", idempotentBody)
+	if retry.Code != http.StatusCreated || retry.Body.String() != first.Body.String() {
+		t.Errorf("retry = %d %s, want the original response", retry.Code, retry.Body.String())
+	}
+	if retry.Header().Get(IdempotentReplayedHeader) != "true" {
+		t.Errorf("expected %s: true on the replayed response", IdempotentReplayedHeader)
+	}
+	if len(subRepo.subscriptions) != 1 {
+		t.Errorf("expected 1 subscription, got %d", len(subRepo.subscriptions))
+	}
+
+	// The same key with another body, or from another user
+	if rec := idempotentRequest(router, "user-1", "key-1", `{"name": "Other"}`); rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected status %d for a reused key, got %d", http.StatusUnprocessableEntity, rec.Code)
+	}
+	if rec := idempotentRequest(router, "user-2", "key-1", idempotentBody); rec.Code != http.StatusCreated || rec.Header().Get(IdempotentReplayedHeader) != "" {
+		t.Errorf("expected another user's key not to be replayed, got %d", rec.Code)
+	}
+	if rec := idempotentRequest(router, "user-1", "", idempotentBody); rec.Code != http.StatusCreated {
+		t.Errorf("expected status %d without a key, got %d", http.StatusCreated, rec.Code)
+	}
+	if len(subRepo.subscriptions) != 3 {
+		t.Errorf("expected 3 subscriptions, got %d", len(subRepo.subscriptions))
+	}
+}
+
+func TestIdempotency_InProgress(t *testing.T) {
+	repo := idempotency.NewMemoryRepository()
+	var inner *httptest.ResponseRecorder
+	var calls int
+	var wrapped http.HandlerFunc
+	wrapped = NewIdempotency(repo).Wrap(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		// A retry arrives while the first request is still running
+		inner = idempotentRequest(wrapped, "user-1", "key-1", `{}`)
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	idempotentRequest(wrapped, "user-1", "key-1", `{}`)
+	if calls != 1 || inner.Code != http.StatusConflict {
+		t.Errorf("calls = %d, concurrent retry = %d, want 1 call and %d", calls, inner.Code, http.StatusConflict)
+	}
+}
+
+func TestIdempotency_StaleReservation(t *testing.T) {
+	repo := idempotency.NewMemoryRepository()
+	// Left by an instance that crashed while handling the first request
+	created := time.Now().Add(-idempotency.InProgressLease)
+	_, _, _ = repo.Reserve(context.Background(), idempotency.Record{
+		Key:         idempotency.Key("user-1", http.MethodPost, "/api/subscriptions", "key-1"),
+		RequestHash: idempotency.RequestHash([]byte(`{}`)),
+		CreatedAt:   created,
+		ExpiresAt:   created.Add(idempotency.DefaultTTL),
+	})
+	var calls int
+	wrapped := NewIdempotency(repo).Wrap(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	if rec := idempotentRequest(wrapped, "user-1", "key-1", `{}`); rec.Code != http.StatusCreated || calls != 1 {
+		t.Fatalf("retry after the lease = %d with %d calls, want %d", rec.Code, calls, http.StatusCreated)
+	}
+	if rec := idempotentRequest(wrapped, "user-1", "key-1", `{}`); rec.Code != http.StatusCreated || calls != 1 {
+		t.Errorf("replay = %d with %d calls, want the stored response", rec.Code, calls)
+	}
+}
+
+func TestIdempotency_ServerErrorIsNotStored(t *testing.T) {
+	status := http.StatusServiceUnavailable
+	var calls int
+	wrapped := NewIdempotency(idempotency.NewMemoryRepository()).Wrap(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(status)
+	})
+
+	idempotentRequest(wrapped, "user-1", "key-1", `{}`)
+	status = http.StatusCreated
+	if rec := idempotentRequest(wrapped, "user-1", "key-1", `{}`); rec.Code != http.StatusCreated || calls != 2 {
+		t.Errorf("retry = %d after %d calls, want the request to run again", rec.Code, calls)
+	}
+}
+
+func TestIdempotency_Disabled(t *testing.T) {
+	var i *Idempotency
+	var calls int
+	wrapped := i.Wrap(func(w http.ResponseWriter, r *http.Request) { calls++ })
+
+	idempotentRequest(wrapped, "user-1", "key-1", `{}`)
+	idempotentRequest(wrapped, "user-1", "key-1", `{}`)
+	if calls != 2 {
+		t.Errorf("calls = %d, want every request handled without an idempotency store", calls)
+	}
+}
+
+// failingIdempotencyRepo fails every call, like an unreachable store
+type failingIdempotencyRepo struct {
+	idempotency.Repository
+}
+
+func (failingIdempotencyRepo) Reserve(ctx context.Context, record idempotency.Record) (*idempotency.Record, bool, error) {
+	return nil, false, context.DeadlineExceeded
+}
+
+func TestIdempotency_StoreFailure(t *testing.T) {
+	var calls int
+	wrapped := NewIdempotency(failingIdempotencyRepo{}).Wrap(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	if rec := idempotentRequest(wrapped, "user-1", "key-1", `{}`); rec.Code != http.StatusCreated || calls != 1 {
+		t.Errorf("got %d after %d calls, want the request handled without idempotency", rec.Code, calls)
+	}
+}
+
+// recordingIdempotencyRepo keeps a copy of every completed record, as stored
+type recordingIdempotencyRepo struct {
+	idempotency.Repository
+	completed []idempotency.Record
+}
+
+func (r *recordingIdempotencyRepo) Complete(ctx context.Context, record idempotency.Record) error {
+	r.completed = append(r.completed, record)
+	return r.Repository.Complete(ctx, record)
+}
+
+func TestIdempotency_SealsStoredResponses(t *testing.T) {
+	wrapper, err := secrets.NewLocalKeyWrapper(bytes.Repeat([]byte{7}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := &recordingIdempotencyRepo{Repository: idempotency.NewMemoryRepository()}
+	router := NewRouterWithConfig(RouterConfig{
+		SubscriptionRepo: newMockSubscriptionRepo(),
+		EventRepo:        newMockEventRepo(),
+		IdempotencyRepo:  repo,
+		SecretsEnvelope:  secrets.NewEnvelope(wrapper),
+	})
+
+	first := idempotentRequest(router, "", "key-1", idempotentBody)
+	if first.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, first.Code, first.Body.String())
+	}
+	var created SubscriptionResponse
+	if err := json.Unmarshal(first.Body.Bytes(), &created); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	secret := created.Delivery.Secret
+	if secret == "" {
+		t.Fatal("expected the generated secret in the response")
+	}
+
+	if len(repo.completed) != 1 {
+		t.Fatalf("expected 1 stored response, got %d", len(repo.completed))
+	}
+	stored := string(repo.completed[0].Body)
+	if strings.Contains(stored, secret) || !secrets.IsSealed(stored) {
+		t.Errorf("stored response is not sealed: %s", stored)
+	}
+
+	retry := idempotentRequest(router, "", "key-1", idempotentBody)
+	if retry.Code != http.StatusCreated || retry.Body.String() != first.Body.String() {
+		t.Errorf("retry = %d %s, want the original response", retry.Code, retry.Body.String())
+	}
+}
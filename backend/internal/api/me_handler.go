@@ -2,16 +2,51 @@ package api
 
 import (
 	"context"
+	"encoding/json"
 	"net/http"
 	"time"
 
+	"github.com/otiai10/namazu/backend/internal/account"
+	"github.com/otiai10/namazu/backend/internal/apierr"
+	"github.com/otiai10/namazu/backend/internal/audit"
 	"github.com/otiai10/namazu/backend/internal/auth"
+	"github.com/otiai10/namazu/backend/internal/egress"
+	"github.com/otiai10/namazu/backend/internal/mail"
+	"github.com/otiai10/namazu/backend/internal/quota"
+	"github.com/otiai10/namazu/backend/internal/store"
+	"github.com/otiai10/namazu/backend/internal/subscription"
 	"github.com/otiai10/namazu/backend/internal/user"
 )
 
+// EgressMeter reports and manages per-user webhook egress
+type EgressMeter interface {
+	Summary(ctx context.Context, userID string) (egress.Summary, error)
+	SetBudget(ctx context.Context, userID string, monthlyBytes int64, updatedBy string) (egress.Budget, error)
+}
+
 // MeHandler handles user profile endpoints
 type MeHandler struct {
-	userRepo user.Repository
+	userRepo       user.Repository
+	egressMeter    EgressMeter
+	quotaChecker   quota.QuotaChecker // nil omits the subscription quota from usage
+	vapidPublicKey string             // empty disables Web Push registration
+	urlValidator   URLValidator       // nil means push endpoints are not validated
+	deviceTopics   DeviceTopics       // nil disables FCM device registration
+	signupGate     *SignupGate        // nil requires neither a verified email nor accepted terms
+
+	// Account linking
+	tokenVerifier  auth.TokenVerifier  // nil disables linking providers
+	providerLinker auth.ProviderLinker // nil leaves Identity Platform users as they are
+
+	// Account export and deletion
+	subscriptionRepo subscription.Repository  // nil disables export and deletion
+	deliveryRepo     store.DeliveryRepository // nil leaves delivery history out of both
+	signer           *account.Signer          // nil disables deletion and emailed exports
+	mailer           mail.Sender              // nil disables emailed exports
+	canceler         SubscriptionCanceler     // nil when billing is disabled
+	tokenRevoker     auth.TokenRevoker        // nil leaves refresh tokens valid
+
+	auditLog *audit.Logger // nil disables audit records
 }
 
 // NewMeHandler creates a new MeHandler
@@ -19,6 +54,66 @@ func NewMeHandler(userRepo user.Repository) *MeHandler {
 	return &MeHandler{userRepo: userRepo}
 }
 
+// SetEgressMeter sets the egress meter used by GET /api/me/usage
+func (h *MeHandler) SetEgressMeter(m EgressMeter) {
+	h.egressMeter = m
+}
+
+// SetQuotaChecker sets the quota checker reporting subscription usage in GET /api/me/usage
+func (h *MeHandler) SetQuotaChecker(q quota.QuotaChecker) {
+	h.quotaChecker = q
+}
+
+// SetSubscriptionRepository enables GET /api/me/export and lets account
+// deletion remove the user's subscriptions
+func (h *MeHandler) SetSubscriptionRepository(r subscription.Repository) {
+	h.subscriptionRepo = r
+}
+
+// SetDeliveryRepository adds recent delivery history to exports and lets
+// account deletion purge it
+func (h *MeHandler) SetDeliveryRepository(r store.DeliveryRepository) {
+	h.deliveryRepo = r
+}
+
+// SetAccountSigner enables account deletion and emailed export links, which are
+// guarded by tokens the signer issues
+func (h *MeHandler) SetAccountSigner(s *account.Signer) {
+	h.signer = s
+}
+
+// SetSignupGate reports the requirements of the gate in GET /api/me and
+// enables POST /api/me/accept-terms
+func (h *MeHandler) SetSignupGate(g *SignupGate) {
+	h.signupGate = g
+}
+
+// ProfileResponse is the body of GET /api/me
+type ProfileResponse struct {
+	user.User
+	EmailVerified       bool   `json:"emailVerified"`                 // From the ID token
+	TermsAccepted       bool   `json:"termsAccepted"`                 // The current terms are accepted, or there are none
+	CurrentTermsVersion string `json:"currentTermsVersion,omitempty"` // Terms users must accept to create subscriptions
+}
+
+// AcceptTermsRequest is the request body for POST /api/me/accept-terms
+type AcceptTermsRequest struct {
+	Version string `json:"version"`
+}
+
+// UsageResponse is the body of GET /api/me/usage
+type UsageResponse struct {
+	egress.Summary
+	Subscriptions *SubscriptionUsage `json:"subscriptions,omitempty"` // Omitted without quota checking
+}
+
+// SubscriptionUsage is the user's subscription count against their plan's limit
+type SubscriptionUsage struct {
+	Plan  string `json:"plan"`
+	Count int    `json:"count"`
+	Limit int    `json:"limit"`
+}
+
 // GetProfile handles GET /api/me
 // Returns the current user's profile, creating it if first login
 func (h *MeHandler) GetProfile(w http.ResponseWriter, r *http.Request) {
@@ -33,7 +128,7 @@ func (h *MeHandler) GetProfile(w http.ResponseWriter, r *http.Request) {
 
 	// Create user if first login
 	if u == nil {
-		u, err = h.createNewUser(r.Context(), claims)
+		u, err = h.createNewUser(r, claims)
 		if err != nil {
 			writeError(w, "failed to create user", http.StatusInternalServerError)
 			return
@@ -43,7 +138,71 @@ func (h *MeHandler) GetProfile(w http.ResponseWriter, r *http.Request) {
 		_ = h.userRepo.UpdateLastLogin(r.Context(), u.ID, time.Now().UTC())
 	}
 
-	writeJSON(w, u, http.StatusOK)
+	writeJSON(w, h.profile(claims, u), http.StatusOK)
+}
+
+// AcceptTerms handles POST /api/me/accept-terms
+// Records that the user accepted the current terms of service. The version
+// must be the current one, so that a client showing outdated terms cannot
+// accept newer ones for the user.
+func (h *MeHandler) AcceptTerms(w http.ResponseWriter, r *http.Request) {
+	claims := auth.MustGetClaims(r.Context())
+
+	current := h.signupGate.TermsVersion()
+	if current == "" {
+		writeError(w, "terms of service are not configured", http.StatusNotImplemented)
+		return
+	}
+
+	var req AcceptTermsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.Version == "" {
+		writeErrorCode(w, apierr.ValidationFailed, "version is required", http.StatusBadRequest)
+		return
+	}
+	if req.Version != current {
+		writeErrorCode(w, apierr.TermsOutdated, "terms of service have changed; accept version "+current, http.StatusConflict)
+		return
+	}
+
+	u, err := h.userRepo.GetByUID(r.Context(), claims.UID)
+	if err != nil {
+		writeError(w, "failed to get user", http.StatusInternalServerError)
+		return
+	}
+	if u == nil {
+		writeError(w, "user not found", http.StatusNotFound)
+		return
+	}
+
+	if u.TermsVersion != current {
+		now := time.Now().UTC()
+		updated := u.Copy()
+		updated.TermsVersion = current
+		updated.TermsAcceptedAt = &now
+		updated.UpdatedAt = now
+		if err := h.userRepo.Update(r.Context(), u.ID, updated); err != nil {
+			writeError(w, "failed to update user", http.StatusInternalServerError)
+			return
+		}
+		h.auditLog.Record(r.Context(), auditEntry(r, audit.ActionTermsAccept, claims.UID, map[string]string{"version": current}))
+		u = &updated
+	}
+
+	writeJSON(w, h.profile(claims, u), http.StatusOK)
+}
+
+// profile returns the profile of u with the state of the signup requirements
+func (h *MeHandler) profile(claims *auth.Claims, u *user.User) ProfileResponse {
+	return ProfileResponse{
+		User:                *u,
+		EmailVerified:       claims.EmailVerified,
+		TermsAccepted:       h.signupGate.termsAccepted(u),
+		CurrentTermsVersion: h.signupGate.TermsVersion(),
+	}
 }
 
 // GetProviders handles GET /api/me/providers
@@ -64,15 +223,61 @@ func (h *MeHandler) GetProviders(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, u.Providers, http.StatusOK)
 }
 
-// createNewUser creates a new user from authentication claims
-func (h *MeHandler) createNewUser(ctx context.Context, claims *auth.Claims) (*user.User, error) {
+// GetUsage handles GET /api/me/usage
+// Returns the current user's webhook egress, deliveries and matched events for
+// this month, their budget and their subscription count against the plan limit
+func (h *MeHandler) GetUsage(w http.ResponseWriter, r *http.Request) {
+	claims := auth.MustGetClaims(r.Context())
+
+	if h.egressMeter == nil {
+		writeError(w, "usage tracking is not enabled", http.StatusNotImplemented)
+		return
+	}
+
+	summary, err := h.egressMeter.Summary(r.Context(), claims.UID)
+	if err != nil {
+		writeError(w, "failed to get usage", http.StatusInternalServerError)
+		return
+	}
+	resp := UsageResponse{Summary: summary}
+
+	if h.quotaChecker != nil {
+		u, err := h.userRepo.GetByUID(r.Context(), claims.UID)
+		if err != nil {
+			writeError(w, "failed to get user", http.StatusInternalServerError)
+			return
+		}
+		plan := user.PlanFree
+		if u != nil && u.Plan != "" {
+			plan = u.Plan
+		}
+		count, limit, err := h.quotaChecker.SubscriptionUsage(r.Context(), claims.UID, plan)
+		if err != nil {
+			writeError(w, "failed to get usage", http.StatusInternalServerError)
+			return
+		}
+		resp.Subscriptions = &SubscriptionUsage{Plan: plan, Count: count, Limit: limit}
+	}
+
+	writeJSON(w, resp, http.StatusOK)
+}
+
+// createNewUser creates a new user from authentication claims, linking the
+// provider the user signed in with
+func (h *MeHandler) createNewUser(r *http.Request, claims *auth.Claims) (*user.User, error) {
+	ctx := r.Context()
 	now := time.Now().UTC()
+	role := user.RoleUser
+	if claims.Admin {
+		role = user.RoleAdmin
+	}
 	newUser := user.User{
 		UID:         claims.UID,
 		Email:       claims.Email,
 		DisplayName: claims.Name,
 		PictureURL:  claims.Picture,
 		Plan:        user.PlanFree,
+		Role:        role,
 		Providers: []user.LinkedProvider{
 			{
 				ProviderID:  claims.ProviderID,
@@ -93,5 +298,6 @@ func (h *MeHandler) createNewUser(ctx context.Context, claims *auth.Claims) (*us
 	}
 
 	newUser.ID = id
+	h.auditLog.Record(ctx, auditEntry(r, audit.ActionProviderLink, claims.UID, map[string]string{"provider": claims.ProviderID}))
 	return &newUser, nil
 }
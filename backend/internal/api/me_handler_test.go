@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/otiai10/namazu/backend/internal/auth"
+	"github.com/otiai10/namazu/backend/internal/egress"
 	"github.com/otiai10/namazu/backend/internal/user"
 )
 
@@ -66,6 +67,16 @@ func (m *mockUserRepo) Update(ctx context.Context, id string, u user.User) error
 	return nil
 }
 
+func (m *mockUserRepo) Delete(ctx context.Context, id string) error {
+	u, ok := m.users[id]
+	if !ok {
+		return user.ErrNotFound
+	}
+	delete(m.uidIndex, u.UID)
+	delete(m.users, id)
+	return nil
+}
+
 func (m *mockUserRepo) UpdateLastLogin(ctx context.Context, id string, t time.Time) error {
 	if u, ok := m.users[id]; ok {
 		u.LastLoginAt = t
@@ -92,6 +103,64 @@ func (m *mockUserRepo) RemoveProvider(ctx context.Context, id string, providerID
 	return nil
 }
 
+func (m *mockUserRepo) AddPushSubscription(ctx context.Context, id string, sub user.PushSubscription) error {
+	u, ok := m.users[id]
+	if !ok {
+		return user.ErrNotFound
+	}
+	for i, existing := range u.PushSubscriptions {
+		if existing.Endpoint == sub.Endpoint {
+			u.PushSubscriptions[i] = sub
+			return nil
+		}
+	}
+	u.PushSubscriptions = append(u.PushSubscriptions, sub)
+	return nil
+}
+
+func (m *mockUserRepo) RemovePushSubscription(ctx context.Context, id string, endpoint string) error {
+	u, ok := m.users[id]
+	if !ok {
+		return user.ErrNotFound
+	}
+	for i, existing := range u.PushSubscriptions {
+		if existing.Endpoint == endpoint {
+			u.PushSubscriptions = append(u.PushSubscriptions[:i], u.PushSubscriptions[i+1:]...)
+			return nil
+		}
+	}
+	return user.ErrPushSubscriptionNotFound
+}
+
+func (m *mockUserRepo) AddDevice(ctx context.Context, id string, device user.Device) error {
+	u, ok := m.users[id]
+	if !ok {
+		return user.ErrNotFound
+	}
+	for i, existing := range u.Devices {
+		if existing.Token == device.Token {
+			u.Devices[i] = device
+			return nil
+		}
+	}
+	u.Devices = append(u.Devices, device)
+	return nil
+}
+
+func (m *mockUserRepo) RemoveDevice(ctx context.Context, id string, token string) error {
+	u, ok := m.users[id]
+	if !ok {
+		return user.ErrNotFound
+	}
+	for i, existing := range u.Devices {
+		if existing.Token == token {
+			u.Devices = append(u.Devices[:i], u.Devices[i+1:]...)
+			return nil
+		}
+	}
+	return user.ErrDeviceNotFound
+}
+
 func (m *mockUserRepo) GetByStripeCustomerID(ctx context.Context, customerID string) (*user.User, error) {
 	for _, u := range m.users {
 		if u.StripeCustomerID == customerID {
@@ -145,6 +214,10 @@ func TestMeHandler_GetProfile_CreatesUserOnFirstLogin(t *testing.T) {
 		t.Errorf("expected Plan %s, got %s", user.PlanFree, response.Plan)
 	}
 
+	if response.Role != user.RoleUser {
+		t.Errorf("expected Role %s, got %s", user.RoleUser, response.Role)
+	}
+
 	if len(response.Providers) != 1 {
 		t.Fatalf("expected 1 provider, got %d", len(response.Providers))
 	}
@@ -154,6 +227,21 @@ func TestMeHandler_GetProfile_CreatesUserOnFirstLogin(t *testing.T) {
 	}
 }
 
+func TestMeHandler_GetProfile_RecordsAdminClaim(t *testing.T) {
+	userRepo := newMockUserRepo()
+	handler := NewMeHandler(userRepo)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/me", nil)
+	req = req.WithContext(auth.WithClaims(req.Context(), &auth.Claims{UID: "admin-uid", Admin: true}))
+	rec := httptest.NewRecorder()
+
+	handler.GetProfile(rec, req)
+
+	if u, _ := userRepo.GetByUID(req.Context(), "admin-uid"); u == nil || !u.IsAdmin() {
+		t.Errorf("expected the admin claim to be recorded as the admin role, got %+v", u)
+	}
+}
+
 func TestMeHandler_GetProfile_ReturnsExistingUser(t *testing.T) {
 	userRepo := newMockUserRepo()
 
@@ -362,3 +450,74 @@ func TestMeHandler_GetProviders_ReturnsErrorOnDBFailure(t *testing.T) {
 		t.Errorf("expected status %d, got %d", http.StatusInternalServerError, rec.Code)
 	}
 }
+
+func TestMeHandler_GetUsage(t *testing.T) {
+	meter := newMockEgressMeter()
+	meter.summaries["test-uid"] = egress.Summary{Period: "2024-03", Requests: 12, BytesSent: 6000}
+	handler := NewMeHandler(newMockUserRepo())
+	handler.SetEgressMeter(meter)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/me/usage", nil)
+	req = req.WithContext(auth.WithClaims(req.Context(), &auth.Claims{UID: "test-uid"}))
+	rec := httptest.NewRecorder()
+
+	handler.GetUsage(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rec.Code)
+	}
+
+	var summary egress.Summary
+	if err := json.Unmarshal(rec.Body.Bytes(), &summary); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if summary.Requests != 12 || summary.BytesSent != 6000 {
+		t.Errorf("unexpected summary: %+v", summary)
+	}
+}
+
+func TestMeHandler_GetUsage_Subscriptions(t *testing.T) {
+	meter := newMockEgressMeter()
+	meter.summaries["test-uid"] = egress.Summary{Period: "2024-03", Deliveries: 4, EventsMatched: 3}
+	userRepo := newMockUserRepo()
+	if _, err := userRepo.Create(context.Background(), user.User{UID: "test-uid", Plan: user.PlanPro}); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	handler := NewMeHandler(userRepo)
+	handler.SetEgressMeter(meter)
+	handler.SetQuotaChecker(&mockQuotaChecker{used: 2, limit: 12})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/me/usage", nil)
+	req = req.WithContext(auth.WithClaims(req.Context(), &auth.Claims{UID: "test-uid"}))
+	rec := httptest.NewRecorder()
+
+	handler.GetUsage(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rec.Code)
+	}
+	var resp UsageResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if resp.Deliveries != 4 || resp.EventsMatched != 3 {
+		t.Errorf("unexpected summary: %+v", resp.Summary)
+	}
+	if s := resp.Subscriptions; s == nil || s.Plan != user.PlanPro || s.Count != 2 || s.Limit != 12 {
+		t.Errorf("subscriptions = %+v, want 2 of 12 on pro", s)
+	}
+}
+
+func TestMeHandler_GetUsage_NotEnabled(t *testing.T) {
+	handler := NewMeHandler(newMockUserRepo())
+
+	req := httptest.NewRequest(http.MethodGet, "/api/me/usage", nil)
+	req = req.WithContext(auth.WithClaims(req.Context(), &auth.Claims{UID: "test-uid"}))
+	rec := httptest.NewRecorder()
+
+	handler.GetUsage(rec, req)
+
+	if rec.Code != http.StatusNotImplemented {
+		t.Errorf("expected status %d, got %d", http.StatusNotImplemented, rec.Code)
+	}
+}
@@ -1,12 +1,19 @@
 package api
 
 import (
+	"bufio"
+	"errors"
 	"log"
+	"net"
 	"net/http"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/otiai10/namazu/backend/internal/apierr"
+	"github.com/otiai10/namazu/backend/internal/logging"
+	"github.com/otiai10/namazu/backend/internal/ratelimit"
 )
 
 // Middleware represents an HTTP middleware function
@@ -32,8 +39,9 @@ func LoggingMiddleware(next http.Handler) http.Handler {
 
 		next.ServeHTTP(wrapped, r)
 
-		duration := time.Since(start)
-		log.Printf("%s %s %d %v", r.Method, r.URL.Path, wrapped.status, duration)
+		if logging.Enabled(logging.Info) || wrapped.status >= http.StatusInternalServerError {
+			log.Printf("%s %s %d %v", r.Method, r.URL.Path, wrapped.status, time.Since(start))
+		}
 	})
 }
 
@@ -49,12 +57,28 @@ func (w *statusResponseWriter) WriteHeader(code int) {
 	w.ResponseWriter.WriteHeader(code)
 }
 
+// Unwrap exposes the underlying writer to http.ResponseController (flushing, deadlines)
+func (w *statusResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Hijack lets WebSocket upgrades take over the connection
+func (w *statusResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("response writer does not support hijacking")
+	}
+	w.status = http.StatusSwitchingProtocols
+	return h.Hijack()
+}
+
 // CORSMiddleware adds CORS headers for development
 func CORSMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, If-Match, If-None-Match, Idempotency-Key")
+		w.Header().Set("Access-Control-Expose-Headers", "ETag, Idempotent-Replayed")
 		w.Header().Set("Access-Control-Max-Age", "86400")
 
 		// Handle preflight requests
@@ -81,7 +105,7 @@ func RecoveryMiddleware(next http.Handler) http.Handler {
 		defer func() {
 			if err := recover(); err != nil {
 				log.Printf("panic recovered: %v", err)
-				http.Error(w, `{"error":"internal server error"}`, http.StatusInternalServerError)
+				writeError(w, "internal server error", http.StatusInternalServerError)
 			}
 		}()
 
@@ -140,8 +164,9 @@ func NewConfigurableCORSMiddleware(config CORSConfig) Middleware {
 				w.Header().Set("Access-Control-Allow-Origin", allowedOrigin)
 			}
 
-			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, If-Match, If-None-Match, Idempotency-Key")
+			w.Header().Set("Access-Control-Expose-Headers", "ETag, Idempotent-Replayed")
 			w.Header().Set("Access-Control-Max-Age", "86400")
 
 			if config.AllowCredentials && allowedOrigin != "" && allowedOrigin != "*" {
@@ -316,9 +341,9 @@ func NewRateLimitMiddleware(limiter RateLimiter) Middleware {
 			allowed, retryAfter := limiter.Allow(ip)
 			if !allowed {
 				w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
-				w.Header().Set("Content-Type", "application/json")
-				w.WriteHeader(http.StatusTooManyRequests)
-				_, _ = w.Write([]byte(`{"error":"rate limit exceeded"}`))
+				writeErrorDetails(w, apierr.RateLimited, "rate limit exceeded", http.StatusTooManyRequests, map[string]any{
+					"retry_after_seconds": retryAfter,
+				})
 				return
 			}
 
@@ -367,30 +392,41 @@ type EndpointRateLimitConfig struct {
 
 	// EndpointLimits maps path prefixes to their rate limit configs
 	EndpointLimits map[string]RateLimitConfig
+
+	// Store shares request counts between instances. nil keeps them in memory,
+	// so each instance enforces the limits on its own.
+	Store ratelimit.Store
 }
 
 // EndpointRateLimiter holds rate limiters per endpoint
 type EndpointRateLimiter struct {
-	defaultLimiter   *InMemoryRateLimiter
-	endpointLimiters map[string]*InMemoryRateLimiter
+	defaultLimiter   RateLimiter
+	endpointLimiters map[string]RateLimiter
 }
 
 // NewEndpointRateLimiter creates a new endpoint-aware rate limiter
 func NewEndpointRateLimiter(config EndpointRateLimitConfig) *EndpointRateLimiter {
+	newLimiter := func(name string, c RateLimitConfig) RateLimiter {
+		if config.Store != nil {
+			return ratelimit.NewLimiter(config.Store, name, c.WithDefaults().RequestsPerMinute)
+		}
+		return NewInMemoryRateLimiter(c)
+	}
+
 	erl := &EndpointRateLimiter{
-		defaultLimiter:   NewInMemoryRateLimiter(config.DefaultLimit),
-		endpointLimiters: make(map[string]*InMemoryRateLimiter),
+		defaultLimiter:   newLimiter("default", config.DefaultLimit),
+		endpointLimiters: make(map[string]RateLimiter),
 	}
 
 	for path, limitConfig := range config.EndpointLimits {
-		erl.endpointLimiters[path] = NewInMemoryRateLimiter(limitConfig)
+		erl.endpointLimiters[path] = newLimiter(path, limitConfig)
 	}
 
 	return erl
 }
 
 // GetLimiter returns the appropriate limiter for the given path
-func (erl *EndpointRateLimiter) GetLimiter(path string) *InMemoryRateLimiter {
+func (erl *EndpointRateLimiter) GetLimiter(path string) RateLimiter {
 	// Check for path prefix matches
 	for prefix, limiter := range erl.endpointLimiters {
 		if strings.HasPrefix(path, prefix) {
@@ -412,9 +448,9 @@ func NewEndpointRateLimitMiddleware(config EndpointRateLimitConfig) Middleware {
 			allowed, retryAfter := limiter.Allow(ip)
 			if !allowed {
 				w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
-				w.Header().Set("Content-Type", "application/json")
-				w.WriteHeader(http.StatusTooManyRequests)
-				_, _ = w.Write([]byte(`{"error":"rate limit exceeded"}`))
+				writeErrorDetails(w, apierr.RateLimited, "rate limit exceeded", http.StatusTooManyRequests, map[string]any{
+					"retry_after_seconds": retryAfter,
+				})
 				return
 			}
 
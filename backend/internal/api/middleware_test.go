@@ -6,6 +6,8 @@ import (
 	"sync"
 	"testing"
 	"time"
+
+	"github.com/otiai10/namazu/backend/internal/ratelimit"
 )
 
 func TestChain(t *testing.T) {
@@ -79,6 +81,35 @@ func TestLoggingMiddleware(t *testing.T) {
 	}
 }
 
+func TestLoggingMiddleware_Hijack(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hijacker, ok := w.(http.Hijacker)
+		if !ok {
+			t.Fatal("expected the wrapped writer to implement http.Hijacker")
+		}
+		// ResponseRecorder cannot be hijacked, which must surface as an error
+		if _, _, err := hijacker.Hijack(); err == nil {
+			t.Error("expected an error from a writer that cannot be hijacked")
+		}
+	})
+
+	LoggingMiddleware(handler).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/stream", nil))
+}
+
+func TestLoggingMiddleware_Flush(t *testing.T) {
+	rec := httptest.NewRecorder()
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := http.NewResponseController(w).Flush(); err != nil {
+			t.Errorf("Flush() error = %v", err)
+		}
+	})
+
+	LoggingMiddleware(handler).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/events/stream", nil))
+	if !rec.Flushed {
+		t.Error("expected the underlying writer to be flushed")
+	}
+}
+
 func TestCORSMiddleware(t *testing.T) {
 	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
@@ -490,4 +521,38 @@ func TestEndpointRateLimitMiddleware(t *testing.T) {
 			t.Errorf("expected status %d for events endpoint, got %d", http.StatusOK, rec.Code)
 		}
 	})
+
+	t.Run("shares counts between instances through a store", func(t *testing.T) {
+		config := EndpointRateLimitConfig{
+			DefaultLimit: RateLimitConfig{RequestsPerMinute: 100},
+			EndpointLimits: map[string]RateLimitConfig{
+				"/api/subscriptions": {RequestsPerMinute: 2},
+			},
+			Store: ratelimit.NewMemoryStore(),
+		}
+		instances := []http.Handler{
+			NewEndpointRateLimitMiddleware(config)(handler),
+			NewEndpointRateLimitMiddleware(config)(handler),
+		}
+
+		codes := make([]int, 0, 3)
+		for i := 0; i < 3; i++ {
+			req := httptest.NewRequest(http.MethodPost, "/api/subscriptions", nil)
+			req.RemoteAddr = "192.168.1.2:12345"
+			rec := httptest.NewRecorder()
+			instances[i%2].ServeHTTP(rec, req)
+			codes = append(codes, rec.Code)
+		}
+		if codes[0] != http.StatusOK || codes[1] != http.StatusOK || codes[2] != http.StatusTooManyRequests {
+			t.Errorf("status codes = %v, want the 3rd request limited across instances", codes)
+		}
+
+		req := httptest.NewRequest(http.MethodGet, "/api/events", nil)
+		req.RemoteAddr = "192.168.1.2:12345"
+		rec := httptest.NewRecorder()
+		instances[0].ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Errorf("expected status %d for events endpoint, got %d", http.StatusOK, rec.Code)
+		}
+	})
 }
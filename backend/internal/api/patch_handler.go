@@ -0,0 +1,208 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/otiai10/namazu/backend/internal/apierr"
+	"github.com/otiai10/namazu/backend/internal/subscription"
+)
+
+// MergePatchContentType is the media type of JSON merge patches (RFC 7386)
+const MergePatchContentType = "application/merge-patch+json"
+
+// maxPatchBodyBytes bounds the body of a PATCH request
+const maxPatchBodyBytes = 64 << 10
+
+// readOnlySubscriptionFields are response fields a patch cannot set.
+// Secrets are managed with rotate-secret, lifecycle status with reactivate,
+// and pausing with pause and resume.
+var readOnlySubscriptionFields = map[string]bool{
+	"id":                                  true,
+	"status":                              true,
+	"status_reason":                       true,
+	"active":                              true,
+	"paused_at":                           true,
+	"revision":                            true,
+	"updated_at":                          true,
+	"delivery.secret":                     true,
+	"delivery.secret_prefix":              true,
+	"delivery.previous_secret":            true,
+	"delivery.previous_secret_expires_at": true,
+	"delivery.verified":                   true,
+	"delivery.sign_version":               true,
+}
+
+// PatchSubscription handles PATCH /api/subscriptions/{id}
+// The body is a JSON merge patch (RFC 7386) applied to the subscription as it
+// would be sent to PUT: members replace, objects merge recursively and null
+// removes a member. Fields the patch does not mention keep their values, so
+// delivery credentials (including the AWS secret access key) need not be resent.
+// The result is validated like a PUT, and If-Match is required.
+func (h *Handler) PatchSubscription(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPatch {
+		writeError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id := extractIDFromPath(r.URL.Path, "/api/subscriptions/")
+	if id == "" {
+		writeError(w, "subscription ID is required", http.StatusBadRequest)
+		return
+	}
+
+	if contentType := r.Header.Get("Content-Type"); contentType != "" {
+		mediaType, _, _ := mime.ParseMediaType(contentType)
+		if mediaType != MergePatchContentType && mediaType != "application/json" {
+			writeError(w, "unsupported content type: use "+MergePatchContentType, http.StatusUnsupportedMediaType)
+			return
+		}
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxPatchBodyBytes))
+	if err != nil {
+		writeError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	patch, ok := decodeJSONValue(body).(map[string]any)
+	if !ok {
+		writeError(w, "request body must be a JSON object", http.StatusBadRequest)
+		return
+	}
+	if field := readOnlyField(patch, ""); field != "" {
+		writeErrorCode(w, apierr.ValidationFailed, field+" is read-only", http.StatusBadRequest)
+		return
+	}
+
+	existing, forbidden, err := h.checkOwnership(r.Context(), id)
+	if err != nil {
+		writeError(w, "failed to get subscription", http.StatusInternalServerError)
+		return
+	}
+	if existing == nil {
+		writeError(w, "subscription not found", http.StatusNotFound)
+		return
+	}
+	if forbidden {
+		writeErrorCode(w, apierr.NotOwner, "forbidden", http.StatusForbidden)
+		return
+	}
+
+	if !requireIfMatch(w, r) || !checkPreconditions(w, r, existing) {
+		return
+	}
+
+	req, err := applyMergePatch(subscriptionToRequest(*existing), patch)
+	if err != nil {
+		writeErrorCode(w, apierr.ValidationFailed, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if msg := h.validateSubscriptionRequest(req); msg != "" {
+		writeErrorCode(w, apierr.ValidationFailed, msg, http.StatusBadRequest)
+		return
+	}
+
+	h.updateSubscription(w, r, id, *existing, req)
+}
+
+// subscriptionToRequest returns the request that would recreate a stored
+// subscription, without the server-managed delivery fields
+func subscriptionToRequest(sub subscription.Subscription) SubscriptionRequest {
+	delivery := copyDeliveryConfig(sub.Delivery)
+	delivery.Secret = ""
+	delivery.SecretPrefix = ""
+	delivery.PreviousSecret = ""
+	delivery.PreviousSecretExpiresAt = nil
+	delivery.Verified = false
+	delivery.VerificationSkipped = false
+	delivery.SignVersion = ""
+	return SubscriptionRequest{
+		Name:       sub.Name,
+		Delivery:   delivery,
+		Filter:     copyFilterConfig(sub.Filter),
+		Locale:     sub.Locale,
+		QuietHours: sub.QuietHours.Copy(),
+		Throttle:   sub.Throttle.Copy(),
+		Digest:     sub.Digest.Copy(),
+		ExpiresAt:  copyTime(sub.ExpiresAt),
+	}
+}
+
+// applyMergePatch applies a merge patch to a request. Errors name the offending field.
+func applyMergePatch(req SubscriptionRequest, patch map[string]any) (SubscriptionRequest, error) {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return SubscriptionRequest{}, err
+	}
+	merged, err := json.Marshal(mergePatch(decodeJSONValue(data), patch))
+	if err != nil {
+		return SubscriptionRequest{}, err
+	}
+
+	var patched SubscriptionRequest
+	dec := json.NewDecoder(bytes.NewReader(merged))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&patched); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) && typeErr.Field != "" {
+			return SubscriptionRequest{}, errors.New("invalid " + typeErr.Field + ": expected " + typeErr.Type.String())
+		}
+		if field, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+			return SubscriptionRequest{}, errors.New("unknown field " + field)
+		}
+		return SubscriptionRequest{}, errors.New("invalid patch: " + err.Error())
+	}
+	return patched, nil
+}
+
+// mergePatch applies patch to target as defined by RFC 7386
+func mergePatch(target, patch any) any {
+	patchObject, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	targetObject, ok := target.(map[string]any)
+	if !ok {
+		targetObject = map[string]any{}
+	}
+	for name, value := range patchObject {
+		if value == nil {
+			delete(targetObject, name)
+			continue
+		}
+		targetObject[name] = mergePatch(targetObject[name], value)
+	}
+	return targetObject
+}
+
+// readOnlyField returns the first read-only field a patch sets, or ""
+func readOnlyField(patch map[string]any, prefix string) string {
+	for name, value := range patch {
+		field := prefix + name
+		if readOnlySubscriptionFields[field] {
+			return field
+		}
+		if object, ok := value.(map[string]any); ok && field == "delivery" {
+			if nested := readOnlyField(object, field+"."); nested != "" {
+				return nested
+			}
+		}
+	}
+	return ""
+}
+
+// decodeJSONValue decodes a JSON document keeping numbers exact, or returns nil
+func decodeJSONValue(data []byte) any {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil || dec.More() {
+		return nil
+	}
+	return v
+}
@@ -0,0 +1,208 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/otiai10/namazu/backend/internal/subscription"
+)
+
+// patchRequest returns a merge patch request for a subscription, with
+// If-Match: * unless the test sets another
+func patchRequest(id, body string) *http.Request {
+	req := httptest.NewRequest(http.MethodPatch, "/api/subscriptions/"+id, bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", MergePatchContentType)
+	req.Header.Set("If-Match", "*")
+	return req
+}
+
+func TestPatchSubscription_UpdatesOnlyGivenFields(t *testing.T) {
+	subRepo := newMockSubscriptionRepo()
+	subRepo.subscriptions["sub-1"] = subscription.Subscription{
+		ID:   "sub-1",
+		Name: "Queue",
+		Delivery: subscription.DeliveryConfig{
+			Type: subscription.DeliveryTypeSQS,
+			AWS: &subscription.AWSConfig{
+				Region:          "ap-northeast-1",
+				QueueURL:        "https://sqs.ap-northeast-1.amazonaws.com/123456789012/quakes",
+				AccessKeyID:     "AKIAEXAMPLE",
+				SecretAccessKey: "aws-secret",
+			},
+			Headers: map[string]string{"X-Team": "ops"},
+		},
+		Filter: &subscription.FilterConfig{MinScale: 30, Prefectures: []string{"東京都"}},
+		Status: subscription.StatusActive,
+	}
+	handler := NewHandler(subRepo, newMockEventRepo())
+
+	rec := httptest.NewRecorder()
+	handler.PatchSubscription(rec, patchRequest("sub-1", `{"name": "Renamed", "filter": {"min_scale": 50}, "delivery": {"headers": null}}`))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+	}
+
+	got := subRepo.subscriptions["sub-1"]
+	if got.Name != "Renamed" {
+		t.Errorf("name = %q, want Renamed", got.Name)
+	}
+	if got.Filter == nil || got.Filter.MinScale != 50 || len(got.Filter.Prefectures) != 1 {
+		t.Errorf("filter = %+v, want min_scale 50 with prefectures kept", got.Filter)
+	}
+	if got.Delivery.Headers != nil {
+		t.Errorf("headers = %v, want removed by null", got.Delivery.Headers)
+	}
+	if got.Delivery.AWS == nil || got.Delivery.AWS.SecretAccessKey != "aws-secret" || got.Delivery.AWS.AccessKeyID != "AKIAEXAMPLE" {
+		t.Errorf("aws = %+v, want credentials kept", got.Delivery.AWS)
+	}
+	if got.Status != subscription.StatusActive {
+		t.Errorf("status = %q, want active", got.Status)
+	}
+	if rec.Header().Get("ETag") != subscriptionETag(got) {
+		t.Errorf("ETag = %q, want the patched version", rec.Header().Get("ETag"))
+	}
+}
+
+func TestPatchSubscription_PreservesSecret(t *testing.T) {
+	subRepo := newMockSubscriptionRepo()
+	handler := NewHandler(subRepo, newMockEventRepo())
+
+	rec := httptest.NewRecorder()
+	handler.CreateSubscription(rec, httptest.NewRequest(http.MethodPost, "/api/subscriptions",
+		bytes.NewBufferString(`{"name": "Hook", "delivery": {"type": "webhook", "url": "https://example.com/hook"}}`)))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, rec.Code, rec.Body.String())
+	}
+	var created SubscriptionResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &created); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.PatchSubscription(rec, patchRequest(created.ID, `{"filter": {"min_magnitude": 4.5}}`))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+	}
+
+	got := subRepo.subscriptions[created.ID]
+	if got.Delivery.Secret != created.Delivery.Secret || got.Delivery.URL != "https://example.com/hook" || got.Name != "Hook" {
+		t.Errorf("delivery = %+v, name = %q, want unchanged", got.Delivery, got.Name)
+	}
+	if got.Filter == nil || got.Filter.MinMagnitude != 4.5 {
+		t.Errorf("filter = %+v, want min_magnitude 4.5", got.Filter)
+	}
+}
+
+func TestPatchSubscription_ValidatesFields(t *testing.T) {
+	subRepo := newMockSubscriptionRepo()
+	subRepo.subscriptions["sub-1"] = subscription.Subscription{
+		ID:       "sub-1",
+		Name:     "Hook",
+		Delivery: subscription.DeliveryConfig{Type: "webhook", URL: "https://example.com/hook", Secret: "secret"},
+	}
+	handler := NewHandler(subRepo, newMockEventRepo())
+
+	tests := []struct {
+		name    string
+		body    string
+		wantErr string
+	}{
+		{"not an object", `["name"]`, "request body must be a JSON object"},
+		{"invalid JSON", `{"name": `, "request body must be a JSON object"},
+		{"wrong type", `{"filter": {"min_scale": "five"}}`, "invalid filter.min_scale"},
+		{"unknown field", `{"nmae": "typo"}`, `unknown field \"nmae\"`},
+		{"read-only secret", `{"delivery": {"secret": "mine"}}`, "delivery.secret is read-only"},
+		{"read-only status", `{"status": "active"}`, "status is read-only"},
+		{"required name removed", `{"name": null}`, "name is required"},
+		{"invalid value", `{"filter": {"min_magnitude": -1}}`, "min_magnitude must not be negative"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handler.PatchSubscription(rec, patchRequest("sub-1", tt.body))
+			if rec.Code != http.StatusBadRequest {
+				t.Fatalf("expected status %d, got %d: %s", http.StatusBadRequest, rec.Code, rec.Body.String())
+			}
+			if !strings.Contains(rec.Body.String(), tt.wantErr) {
+				t.Errorf("body = %s, want %q", rec.Body.String(), tt.wantErr)
+			}
+		})
+	}
+	if got := subRepo.subscriptions["sub-1"]; got.Name != "Hook" || got.Filter != nil {
+		t.Errorf("subscription = %+v, want unchanged", got)
+	}
+}
+
+func TestPatchSubscription_Preconditions(t *testing.T) {
+	subRepo := newMockSubscriptionRepo()
+	existing := subscription.Subscription{
+		ID:       "sub-1",
+		Name:     "Hook",
+		Delivery: subscription.DeliveryConfig{Type: "webhook", URL: "https://example.com/hook"},
+	}
+	subRepo.subscriptions["sub-1"] = existing
+	handler := NewHandler(subRepo, newMockEventRepo())
+
+	req := patchRequest("sub-1", `{"name": "Stale"}`)
+	req.Header.Set("If-Match", `"stale"`)
+	rec := httptest.NewRecorder()
+	handler.PatchSubscription(rec, req)
+	if rec.Code != http.StatusPreconditionFailed {
+		t.Fatalf("expected status %d, got %d", http.StatusPreconditionFailed, rec.Code)
+	}
+
+	req = patchRequest("sub-1", `{"name": "Fresh"}`)
+	req.Header.Set("If-Match", subscriptionETag(existing))
+	rec = httptest.NewRecorder()
+	handler.PatchSubscription(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+	}
+	if subRepo.subscriptions["sub-1"].Name != "Fresh" {
+		t.Errorf("name = %q, want Fresh", subRepo.subscriptions["sub-1"].Name)
+	}
+}
+
+func TestPatchSubscription_RejectsOtherMediaTypes(t *testing.T) {
+	handler := NewHandler(newMockSubscriptionRepo(), newMockEventRepo())
+
+	req := patchRequest("sub-1", `[{"op": "replace", "path": "/name", "value": "x"}]`)
+	req.Header.Set("Content-Type", "application/json-patch+json")
+	rec := httptest.NewRecorder()
+	handler.PatchSubscription(rec, req)
+	if rec.Code != http.StatusUnsupportedMediaType {
+		t.Errorf("expected status %d, got %d", http.StatusUnsupportedMediaType, rec.Code)
+	}
+}
+
+func TestMergePatch(t *testing.T) {
+	// Examples from RFC 7386, Appendix A
+	tests := []struct {
+		target, patch, want string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`{"e":null}`, `{"a":1}`, `{"a":1,"e":null}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+	for _, tt := range tests {
+		got, err := json.Marshal(mergePatch(decodeJSONValue([]byte(tt.target)), decodeJSONValue([]byte(tt.patch))))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != tt.want {
+			t.Errorf("mergePatch(%s, %s) = %s, want %s", tt.target, tt.patch, got, tt.want)
+		}
+	}
+}
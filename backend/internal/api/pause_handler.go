@@ -0,0 +1,66 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/otiai10/namazu/backend/internal/apierr"
+	"github.com/otiai10/namazu/backend/internal/audit"
+)
+
+// PauseSubscription handles POST /api/subscriptions/{id}/pause
+// Deliveries stop until the subscription is resumed; its settings, secret,
+// lifecycle status and delivery history are kept. Pausing a paused
+// subscription is a no-op.
+func (h *Handler) PauseSubscription(w http.ResponseWriter, r *http.Request, id string) {
+	h.setPaused(w, r, id, true)
+}
+
+// ResumeSubscription handles POST /api/subscriptions/{id}/resume
+// Deliveries restart with the next event; events during the pause are not
+// replayed. Resuming an active subscription is a no-op.
+func (h *Handler) ResumeSubscription(w http.ResponseWriter, r *http.Request, id string) {
+	h.setPaused(w, r, id, false)
+}
+
+// setPaused pauses or resumes a subscription and writes the 200 response
+func (h *Handler) setPaused(w http.ResponseWriter, r *http.Request, id string, paused bool) {
+	existing, forbidden, err := h.checkOwnership(r.Context(), id)
+	if err != nil {
+		writeError(w, "failed to get subscription", http.StatusInternalServerError)
+		return
+	}
+	if existing == nil {
+		writeError(w, "subscription not found", http.StatusNotFound)
+		return
+	}
+	if forbidden {
+		writeErrorCode(w, apierr.NotOwner, "forbidden", http.StatusForbidden)
+		return
+	}
+
+	if !checkPreconditions(w, r, existing) {
+		return
+	}
+
+	sub := *existing
+	if sub.IsPaused() != paused {
+		action := audit.ActionSubscriptionResume
+		sub.PausedAt = nil
+		if paused {
+			now := time.Now().UTC()
+			action = audit.ActionSubscriptionPause
+			sub.PausedAt = &now
+		}
+		saved, err := saveSubscription(r.Context(), h.subscriptionRepo, id, sub)
+		if err != nil {
+			writeSaveError(w, err)
+			return
+		}
+		sub = saved
+		h.auditLog.Record(r.Context(), auditEntry(r, action, id, map[string]string{"name": sub.Name}))
+	}
+
+	w.Header().Set("ETag", subscriptionETag(sub))
+	writeJSON(w, subscriptionToResponse(sub), http.StatusOK)
+}
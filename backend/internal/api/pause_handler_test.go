@@ -0,0 +1,95 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/otiai10/namazu/backend/internal/audit"
+	"github.com/otiai10/namazu/backend/internal/subscription"
+)
+
+func TestPauseAndResumeSubscription(t *testing.T) {
+	subRepo := newMockSubscriptionRepo()
+	subRepo.subscriptions["sub-1"] = subscription.Subscription{
+		ID:       "sub-1",
+		UserID:   "user-1",
+		Name:     "Hook",
+		Delivery: subscription.DeliveryConfig{Type: "webhook", URL: "https://example.com/hook", Secret: "secret"},
+		Status:   subscription.StatusWarned,
+	}
+	auditRepo := audit.NewMemoryRepository()
+	handler := NewHandler(subRepo, newMockEventRepo())
+	handler.SetAuditLog(audit.NewLogger(auditRepo))
+
+	call := func(action func(http.ResponseWriter, *http.Request, string), uid string) (*httptest.ResponseRecorder, SubscriptionResponse) {
+		rec := httptest.NewRecorder()
+		action(rec, auditedRequest(http.MethodPost, "/api/subscriptions/sub-1/pause", "", uid), "sub-1")
+		var resp SubscriptionResponse
+		if rec.Code == http.StatusOK {
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+		}
+		return rec, resp
+	}
+
+	// Pausing twice is a no-op the second time
+	for i := 0; i < 2; i++ {
+		rec, resp := call(handler.PauseSubscription, "user-1")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+		}
+		if resp.Active || resp.PausedAt == nil || resp.Status != subscription.StatusWarned {
+			t.Errorf("response = %+v, want paused with the lifecycle status kept", resp)
+		}
+	}
+	paused := subRepo.subscriptions["sub-1"]
+	if !paused.IsPaused() || paused.Delivery.Secret != "secret" {
+		t.Errorf("stored = %+v, want paused with the secret kept", paused)
+	}
+
+	// A PUT keeps the pause
+	rec := httptest.NewRecorder()
+	handler.UpdateSubscription(rec, withIfMatch(auditedRequest(http.MethodPut, "/api/subscriptions/sub-1",
+		`{"name": "Renamed", "delivery": {"type": "webhook", "url": "https://example.com/hook"}}`, "user-1"), subscriptionETag(paused)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+	}
+	if !subRepo.subscriptions["sub-1"].IsPaused() {
+		t.Error("update resumed the subscription")
+	}
+
+	if rec, _ := call(handler.ResumeSubscription, "user-2"); rec.Code != http.StatusForbidden {
+		t.Errorf("expected status %d for another user, got %d", http.StatusForbidden, rec.Code)
+	}
+	rec, resp := call(handler.ResumeSubscription, "user-1")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+	}
+	if !resp.Active || resp.PausedAt != nil || subRepo.subscriptions["sub-1"].IsPaused() {
+		t.Errorf("response = %+v, want resumed", resp)
+	}
+	if rec.Header().Get("ETag") != subscriptionETag(subRepo.subscriptions["sub-1"]) {
+		t.Errorf("ETag = %q, want the resumed version", rec.Header().Get("ETag"))
+	}
+
+	entries, _ := auditRepo.List(context.Background(), audit.Filter{})
+	want := []string{audit.ActionSubscriptionResume, audit.ActionSubscriptionUpdate, audit.ActionSubscriptionPause}
+	if len(entries) != len(want) {
+		t.Fatalf("audit entries = %+v, want %v", entries, want)
+	}
+	for i, e := range entries {
+		if e.Action != want[i] {
+			t.Errorf("entries[%d].Action = %s, want %s", i, e.Action, want[i])
+		}
+	}
+
+	rec = httptest.NewRecorder()
+	handler.PauseSubscription(rec, httptest.NewRequest(http.MethodPost, "/api/subscriptions/missing/pause", nil), "missing")
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected status %d, got %d", http.StatusNotFound, rec.Code)
+	}
+}
@@ -0,0 +1,201 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/otiai10/namazu/backend/internal/apierr"
+	"github.com/otiai10/namazu/backend/internal/audit"
+	"github.com/otiai10/namazu/backend/internal/auth"
+	"github.com/otiai10/namazu/backend/internal/user"
+)
+
+// LinkProviderRequest is the request body for POST /api/me/providers/link
+type LinkProviderRequest struct {
+	IDToken string `json:"id_token"` // ID token of a sign-in with the provider to link
+}
+
+// ProviderConflict describes the account a credential already signs in to.
+// It is the "conflict" detail of the provider_conflict error of
+// POST /api/me/providers/link.
+type ProviderConflict struct {
+	UID        string `json:"uid"`
+	ProviderID string `json:"providerId"`
+	Email      string `json:"email,omitempty"`
+	HasData    bool   `json:"hasData"` // The account has a namazu profile, so it is not taken over
+}
+
+// SetProviderLinking enables POST /api/me/providers/link, which verifies the
+// credential with verifier. linker moves provider identities between Identity
+// Platform users and unlinks removed providers; nil leaves Identity Platform
+// users as they are, so credentials of other accounts cannot be linked.
+func (h *MeHandler) SetProviderLinking(verifier auth.TokenVerifier, linker auth.ProviderLinker) {
+	h.tokenVerifier = verifier
+	h.providerLinker = linker
+}
+
+// LinkProvider handles POST /api/me/providers/link
+// Links the provider of a second sign-in to the current user. The client
+// signs in with the provider and sends the resulting ID token:
+//   - A token of the current user (linked on the client) only records the provider.
+//   - A token of another Identity Platform user without a namazu profile moves
+//     the provider identity to the current user.
+//   - A token of a user with a profile is a conflict (409), reported with the
+//     other account, which must be deleted or unlinked first.
+func (h *MeHandler) LinkProvider(w http.ResponseWriter, r *http.Request) {
+	claims := auth.MustGetClaims(r.Context())
+
+	if h.tokenVerifier == nil {
+		writeError(w, "account linking is not enabled", http.StatusNotImplemented)
+		return
+	}
+
+	var req LinkProviderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.IDToken == "" {
+		writeErrorCode(w, apierr.ValidationFailed, "id_token is required", http.StatusBadRequest)
+		return
+	}
+	linked, err := h.tokenVerifier.VerifyIDToken(r.Context(), req.IDToken)
+	if err != nil {
+		writeError(w, "invalid id_token", http.StatusBadRequest)
+		return
+	}
+	if linked.ProviderID == "" {
+		writeError(w, "id_token has no sign-in provider", http.StatusBadRequest)
+		return
+	}
+
+	u, err := h.userRepo.GetByUID(r.Context(), claims.UID)
+	if err != nil {
+		writeError(w, "failed to get user", http.StatusInternalServerError)
+		return
+	}
+	if u == nil {
+		writeError(w, "user not found", http.StatusNotFound)
+		return
+	}
+	if hasProvider(u, linked.ProviderID) {
+		writeErrorCode(w, apierr.AlreadyLinked, "provider is already linked", http.StatusConflict)
+		return
+	}
+
+	if linked.UID != claims.UID {
+		other, err := h.userRepo.GetByUID(r.Context(), linked.UID)
+		if err != nil {
+			writeError(w, "failed to get user", http.StatusInternalServerError)
+			return
+		}
+		// Password and anonymous sign-ins have no identity that can be moved
+		if other != nil || h.providerLinker == nil || linked.ProviderUID == "" {
+			writeErrorDetails(w, apierr.ProviderConflict, "this sign-in method belongs to another account", http.StatusConflict, map[string]any{
+				"conflict": ProviderConflict{
+					UID:        linked.UID,
+					ProviderID: linked.ProviderID,
+					Email:      linked.Email,
+					HasData:    other != nil,
+				},
+			})
+			return
+		}
+		if err := h.providerLinker.UnlinkProvider(r.Context(), linked.UID, linked.ProviderID); err != nil {
+			log.Printf("Failed to unlink %s from %s: %v", linked.ProviderID, linked.UID, err)
+			writeError(w, "failed to link provider", http.StatusInternalServerError)
+			return
+		}
+		if err := h.providerLinker.LinkProvider(r.Context(), claims.UID, auth.ProviderIdentity{
+			ProviderID:  linked.ProviderID,
+			UID:         linked.ProviderUID,
+			Email:       linked.Email,
+			DisplayName: linked.Name,
+		}); err != nil {
+			log.Printf("Failed to link %s to %s: %v", linked.ProviderID, claims.UID, err)
+			writeError(w, "failed to link provider", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	subject := linked.ProviderUID
+	if subject == "" {
+		subject = linked.UID
+	}
+	provider := user.LinkedProvider{
+		ProviderID:  linked.ProviderID,
+		Subject:     subject,
+		Email:       linked.Email,
+		DisplayName: linked.Name,
+		LinkedAt:    time.Now().UTC(),
+	}
+	if err := h.userRepo.AddProvider(r.Context(), u.ID, provider); err != nil {
+		if errors.Is(err, user.ErrProviderExists) {
+			writeErrorCode(w, apierr.AlreadyLinked, "provider is already linked", http.StatusConflict)
+			return
+		}
+		writeError(w, "failed to link provider", http.StatusInternalServerError)
+		return
+	}
+	h.auditLog.Record(r.Context(), auditEntry(r, audit.ActionProviderLink, claims.UID, map[string]string{"provider": linked.ProviderID}))
+
+	writeJSON(w, provider, http.StatusCreated)
+}
+
+// UnlinkProvider handles DELETE /api/me/providers/{providerId}
+// The last sign-in method cannot be removed, since the user could no longer
+// sign in.
+func (h *MeHandler) UnlinkProvider(w http.ResponseWriter, r *http.Request, providerID string) {
+	claims := auth.MustGetClaims(r.Context())
+
+	u, err := h.userRepo.GetByUID(r.Context(), claims.UID)
+	if err != nil {
+		writeError(w, "failed to get user", http.StatusInternalServerError)
+		return
+	}
+	if u == nil {
+		writeError(w, "user not found", http.StatusNotFound)
+		return
+	}
+	if !hasProvider(u, providerID) {
+		writeError(w, "provider not linked", http.StatusNotFound)
+		return
+	}
+	if len(u.Providers) <= 1 {
+		writeErrorCode(w, apierr.LastSignInMethod, "cannot remove the last sign-in method", http.StatusConflict)
+		return
+	}
+
+	// Unlinked in Identity Platform first: a provider left there would still sign in
+	if h.providerLinker != nil {
+		if err := h.providerLinker.UnlinkProvider(r.Context(), claims.UID, providerID); err != nil {
+			log.Printf("Failed to unlink %s from %s: %v", providerID, claims.UID, err)
+			writeError(w, "failed to unlink provider", http.StatusInternalServerError)
+			return
+		}
+	}
+	if err := h.userRepo.RemoveProvider(r.Context(), u.ID, providerID); err != nil {
+		if errors.Is(err, user.ErrProviderNotFound) {
+			writeError(w, "provider not linked", http.StatusNotFound)
+			return
+		}
+		writeError(w, "failed to unlink provider", http.StatusInternalServerError)
+		return
+	}
+	h.auditLog.Record(r.Context(), auditEntry(r, audit.ActionProviderUnlink, claims.UID, map[string]string{"provider": providerID}))
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// hasProvider reports whether the provider is linked to u
+func hasProvider(u *user.User, providerID string) bool {
+	for _, p := range u.Providers {
+		if p.ProviderID == providerID {
+			return true
+		}
+	}
+	return false
+}
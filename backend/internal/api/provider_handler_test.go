@@ -0,0 +1,209 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/otiai10/namazu/backend/internal/apierr"
+	"github.com/otiai10/namazu/backend/internal/auth"
+	"github.com/otiai10/namazu/backend/internal/user"
+)
+
+// tokenMapVerifier implements auth.TokenVerifier with a fixed set of tokens
+type tokenMapVerifier map[string]*auth.Claims
+
+func (v tokenMapVerifier) VerifyIDToken(ctx context.Context, idToken string) (*auth.Claims, error) {
+	claims, ok := v[idToken]
+	if !ok {
+		return nil, errors.New("invalid token")
+	}
+	return claims, nil
+}
+
+// mockProviderLinker records the provider changes made in Identity Platform
+type mockProviderLinker struct {
+	linked   []string // "uid:providerId:providerUid"
+	unlinked []string // "uid:providerId"
+}
+
+func (m *mockProviderLinker) LinkProvider(ctx context.Context, uid string, p auth.ProviderIdentity) error {
+	m.linked = append(m.linked, uid+":"+p.ProviderID+":"+p.UID)
+	return nil
+}
+
+func (m *mockProviderLinker) UnlinkProvider(ctx context.Context, uid, providerID string) error {
+	m.unlinked = append(m.unlinked, uid+":"+providerID)
+	return nil
+}
+
+func TestProviderLinking(t *testing.T) {
+	setup := func(linker auth.ProviderLinker) (http.Handler, *mockUserRepo) {
+		users := newMockUserRepo()
+		users.Create(context.Background(), user.User{
+			UID:       "uid-1",
+			Providers: []user.LinkedProvider{{ProviderID: user.ProviderGoogle, Subject: "uid-1", LinkedAt: time.Now()}},
+		})
+		users.Create(context.Background(), user.User{UID: "uid-other"})
+		cfg := RouterConfig{
+			SubscriptionRepo: newMockSubscriptionRepo(),
+			EventRepo:        newMockEventRepo(),
+			UserRepo:         users,
+			TokenVerifier: tokenMapVerifier{
+				"session":      {UID: "uid-1", ProviderID: user.ProviderGoogle},
+				"apple-linked": {UID: "uid-1", ProviderID: user.ProviderApple, ProviderUID: "a-1", Email: "me@privaterelay.appleid.com"},
+				"apple-new":    {UID: "uid-new", ProviderID: user.ProviderApple, ProviderUID: "a-2"},
+				"apple-other":  {UID: "uid-other", ProviderID: user.ProviderApple, ProviderUID: "a-3", Email: "other@example.com"},
+				"no-provider":  {UID: "uid-1"},
+			},
+		}
+		if linker != nil {
+			cfg.ProviderLinker = linker
+		}
+		return NewRouterWithConfig(cfg), users
+	}
+	do := func(router http.Handler, method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer session")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+	providers := func(users *mockUserRepo) []string {
+		u, _ := users.GetByUID(context.Background(), "uid-1")
+		var ids []string
+		for _, p := range u.Providers {
+			ids = append(ids, p.ProviderID+":"+p.Subject)
+		}
+		return ids
+	}
+
+	t.Run("links a credential of the current user", func(t *testing.T) {
+		router, users := setup(nil)
+		rec := do(router, http.MethodPost, "/api/me/providers/link", `{"id_token": "apple-linked"}`)
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, rec.Code, rec.Body.String())
+		}
+		var linked user.LinkedProvider
+		if err := json.Unmarshal(rec.Body.Bytes(), &linked); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if linked.ProviderID != user.ProviderApple || linked.Subject != "a-1" || linked.Email != "me@privaterelay.appleid.com" {
+			t.Errorf("linked = %+v, want apple.com a-1", linked)
+		}
+		if got := providers(users); len(got) != 2 || got[1] != "apple.com:a-1" {
+			t.Errorf("providers = %v, want google.com and apple.com", got)
+		}
+
+		if rec := do(router, http.MethodPost, "/api/me/providers/link", `{"id_token": "apple-linked"}`); rec.Code != http.StatusConflict {
+			t.Errorf("expected status %d for a linked provider, got %d", http.StatusConflict, rec.Code)
+		}
+	})
+
+	t.Run("rejects invalid credentials", func(t *testing.T) {
+		router, _ := setup(nil)
+		for body, want := range map[string]int{
+			`{}`:                          http.StatusBadRequest,
+			`{"id_token": "forged"}`:      http.StatusBadRequest,
+			`{"id_token": "no-provider"}`: http.StatusBadRequest,
+			`not json`:                    http.StatusBadRequest,
+		} {
+			if rec := do(router, http.MethodPost, "/api/me/providers/link", body); rec.Code != want {
+				t.Errorf("%s: expected status %d, got %d", body, want, rec.Code)
+			}
+		}
+	})
+
+	t.Run("moves the identity of an account without data", func(t *testing.T) {
+		linker := &mockProviderLinker{}
+		router, users := setup(linker)
+		if rec := do(router, http.MethodPost, "/api/me/providers/link", `{"id_token": "apple-new"}`); rec.Code != http.StatusCreated {
+			t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, rec.Code, rec.Body.String())
+		}
+		if len(linker.unlinked) != 1 || linker.unlinked[0] != "uid-new:apple.com" {
+			t.Errorf("unlinked = %v, want apple.com from uid-new", linker.unlinked)
+		}
+		if len(linker.linked) != 1 || linker.linked[0] != "uid-1:apple.com:a-2" {
+			t.Errorf("linked = %v, want apple.com a-2 to uid-1", linker.linked)
+		}
+		if got := providers(users); len(got) != 2 {
+			t.Errorf("providers = %v, want apple.com recorded", got)
+		}
+	})
+
+	t.Run("reports a conflict with an account with data", func(t *testing.T) {
+		linker := &mockProviderLinker{}
+		router, users := setup(linker)
+		rec := do(router, http.MethodPost, "/api/me/providers/link", `{"id_token": "apple-other"}`)
+		if rec.Code != http.StatusConflict {
+			t.Fatalf("expected status %d, got %d: %s", http.StatusConflict, rec.Code, rec.Body.String())
+		}
+		var resp struct {
+			Error struct {
+				Code    string `json:"code"`
+				Details struct {
+					Conflict ProviderConflict `json:"conflict"`
+				} `json:"details"`
+			} `json:"error"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		want := ProviderConflict{UID: "uid-other", ProviderID: user.ProviderApple, Email: "other@example.com", HasData: true}
+		if resp.Error.Code != apierr.ProviderConflict || resp.Error.Details.Conflict != want {
+			t.Errorf("error = %+v, want provider_conflict with %+v", resp.Error, want)
+		}
+		if len(linker.linked)+len(linker.unlinked) != 0 || len(providers(users)) != 1 {
+			t.Error("expected nothing to be linked on a conflict")
+		}
+	})
+
+	t.Run("cannot move identities without a linker", func(t *testing.T) {
+		router, _ := setup(nil)
+		if rec := do(router, http.MethodPost, "/api/me/providers/link", `{"id_token": "apple-new"}`); rec.Code != http.StatusConflict {
+			t.Errorf("expected status %d, got %d", http.StatusConflict, rec.Code)
+		}
+	})
+
+	t.Run("unlinks all but the last provider", func(t *testing.T) {
+		linker := &mockProviderLinker{}
+		router, users := setup(linker)
+		do(router, http.MethodPost, "/api/me/providers/link", `{"id_token": "apple-linked"}`)
+
+		if rec := do(router, http.MethodDelete, "/api/me/providers/github.com", ""); rec.Code != http.StatusNotFound {
+			t.Errorf("expected status %d for an unlinked provider, got %d", http.StatusNotFound, rec.Code)
+		}
+		if rec := do(router, http.MethodDelete, "/api/me/providers/google.com", ""); rec.Code != http.StatusNoContent {
+			t.Fatalf("expected status %d, got %d: %s", http.StatusNoContent, rec.Code, rec.Body.String())
+		}
+		if got := providers(users); len(got) != 1 || got[0] != "apple.com:a-1" {
+			t.Errorf("providers = %v, want apple.com only", got)
+		}
+		if len(linker.unlinked) != 1 || linker.unlinked[0] != "uid-1:google.com" {
+			t.Errorf("unlinked = %v, want google.com from uid-1", linker.unlinked)
+		}
+
+		rec := do(router, http.MethodDelete, "/api/me/providers/apple.com", "")
+		if rec.Code != http.StatusConflict || !strings.Contains(rec.Body.String(), "last sign-in method") {
+			t.Errorf("expected 409 for the last provider, got %d: %s", rec.Code, rec.Body.String())
+		}
+		if len(providers(users)) != 1 || len(linker.unlinked) != 1 {
+			t.Error("expected the last provider to be kept")
+		}
+	})
+
+	t.Run("routes", func(t *testing.T) {
+		router, _ := setup(nil)
+		if rec := do(router, http.MethodGet, "/api/me/providers/link", ""); rec.Code != http.StatusMethodNotAllowed {
+			t.Errorf("GET link: expected status %d, got %d", http.StatusMethodNotAllowed, rec.Code)
+		}
+		if rec := do(router, http.MethodDelete, "/api/me/providers/google.com/x", ""); rec.Code != http.StatusNotFound {
+			t.Errorf("nested path: expected status %d, got %d", http.StatusNotFound, rec.Code)
+		}
+	})
+}
@@ -0,0 +1,150 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/otiai10/namazu/backend/internal/config"
+	"github.com/otiai10/namazu/backend/internal/source"
+	"github.com/otiai10/namazu/backend/internal/source/p2pquake"
+	"github.com/otiai10/namazu/backend/internal/store"
+)
+
+// publicEventsPath is the path of the public events API
+const publicEventsPath = "/api/public/events"
+
+// Defaults for the public events API
+const (
+	DefaultPublicEventsMinScale = p2pquake.Scale3
+	DefaultPublicEventsLimit    = 20
+	DefaultPublicEventsCache    = 60 * time.Second
+
+	// publicEventsScan is how many recent events are read per refresh
+	// to find the significant ones
+	publicEventsScan = 100
+)
+
+// PublicEventResponse is an event as served by the public events API.
+// It carries only what an embed needs; no raw payloads or internal timestamps.
+type PublicEventResponse struct {
+	ID            string    `json:"id"`
+	Type          string    `json:"type"`
+	Severity      int       `json:"severity"`
+	AffectedAreas []string  `json:"affectedAreas"`
+	OccurredAt    time.Time `json:"occurredAt"`
+}
+
+// PublicEventsHandler serves recent significant events without authentication
+// so community sites can embed them. Responses are CORS-open and cached both
+// here and by clients, so embeds never translate into one Firestore read each.
+type PublicEventsHandler struct {
+	eventRepo   store.EventRepository
+	minSeverity int
+	limit       int
+	ttl         time.Duration
+	now         func() time.Time
+
+	mu      sync.Mutex // Also serializes refreshes so a burst causes a single read
+	events  []PublicEventResponse
+	expires time.Time
+}
+
+// NewPublicEventsHandler creates a new PublicEventsHandler.
+// Zero values in cfg use the defaults.
+func NewPublicEventsHandler(eventRepo store.EventRepository, cfg *config.PublicEventsConfig) *PublicEventsHandler {
+	minScale, limit, ttl := DefaultPublicEventsMinScale, DefaultPublicEventsLimit, DefaultPublicEventsCache
+	if cfg != nil {
+		if cfg.MinScale > 0 {
+			minScale = cfg.MinScale
+		}
+		if cfg.Limit > 0 {
+			limit = cfg.Limit
+		}
+		if cfg.CacheSeconds > 0 {
+			ttl = time.Duration(cfg.CacheSeconds) * time.Second
+		}
+	}
+	return &PublicEventsHandler{
+		eventRepo:   eventRepo,
+		minSeverity: p2pquake.ScaleToSeverity(minScale),
+		limit:       limit,
+		ttl:         ttl,
+		now:         time.Now,
+	}
+}
+
+// ListEvents handles GET /api/public/events
+// The optional limit query parameter can only lower the configured limit.
+func (h *PublicEventsHandler) ListEvents(w http.ResponseWriter, r *http.Request) {
+	events, err := h.recent(r.Context())
+	if err != nil {
+		writeError(w, "failed to list events", http.StatusInternalServerError)
+		return
+	}
+
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if limit, err := strconv.Atoi(limitStr); err == nil && limit > 0 && limit < len(events) {
+			events = events[:limit]
+		}
+	}
+
+	// Embeds are anonymous; open CORS regardless of the configured origins
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Del("Access-Control-Allow-Credentials")
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(h.ttl.Seconds())))
+
+	if r.Method == http.MethodHead {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	writeJSON(w, events, http.StatusOK)
+}
+
+// recent returns the cached significant events, refreshing them when expired.
+// If a refresh fails, the previous events are served until the next attempt.
+func (h *PublicEventsHandler) recent(ctx context.Context) ([]PublicEventResponse, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	now := h.now()
+	if h.events != nil && now.Before(h.expires) {
+		return h.events, nil
+	}
+
+	records, err := h.eventRepo.List(ctx, publicEventsScan, nil)
+	if err != nil {
+		if h.events != nil {
+			log.Printf("Public events: refresh failed, serving cached events: %v", err)
+			h.expires = now.Add(h.ttl)
+			return h.events, nil
+		}
+		return nil, err
+	}
+
+	events := make([]PublicEventResponse, 0, h.limit)
+	for _, record := range records {
+		// Early warnings are forecasts superseded by the earthquake reports
+		if record.Type == string(source.EventTypeEEW) || record.Severity < h.minSeverity {
+			continue
+		}
+		events = append(events, PublicEventResponse{
+			ID:            record.ID,
+			Type:          record.Type,
+			Severity:      record.Severity,
+			AffectedAreas: record.AffectedAreas,
+			OccurredAt:    record.OccurredAt,
+		})
+		if len(events) == h.limit {
+			break
+		}
+	}
+
+	h.events = events
+	h.expires = now.Add(h.ttl)
+	return events, nil
+}
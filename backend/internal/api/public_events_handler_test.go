@@ -0,0 +1,206 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/otiai10/namazu/backend/internal/config"
+	"github.com/otiai10/namazu/backend/internal/store"
+)
+
+// countingEventRepo counts List calls and can be made to fail
+type countingEventRepo struct {
+	*mockEventRepo
+	lists   int
+	listErr error
+}
+
+func (m *countingEventRepo) List(ctx context.Context, limit int, startAfter *time.Time) ([]store.EventRecord, error) {
+	m.lists++
+	if m.listErr != nil {
+		return nil, m.listErr
+	}
+	return m.mockEventRepo.List(ctx, limit, startAfter)
+}
+
+func newPublicEventsRepo() *countingEventRepo {
+	repo := &countingEventRepo{mockEventRepo: newMockEventRepo()}
+	occurredAt := time.Date(2024, 1, 1, 16, 10, 0, 0, time.UTC)
+	for i, severity := range []int{70, 20, 50, 30, 100} {
+		repo.events = append(repo.events, store.EventRecord{
+			ID:            string(rune('a' + i)),
+			Type:          "earthquake",
+			Source:        "p2pquake",
+			Severity:      severity,
+			AffectedAreas: []string{"石川県"},
+			OccurredAt:    occurredAt.Add(-time.Duration(i) * time.Minute),
+			RawJSON:       `{"secret":"raw"}`,
+		})
+	}
+	repo.events = append(repo.events, store.EventRecord{ID: "eew", Type: "eew", Severity: 100})
+	return repo
+}
+
+func decodePublicEvents(t *testing.T, rec *httptest.ResponseRecorder) []PublicEventResponse {
+	t.Helper()
+	var events []PublicEventResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &events); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	return events
+}
+
+func TestPublicEventsHandler_ListEvents(t *testing.T) {
+	repo := newPublicEventsRepo()
+	h := NewPublicEventsHandler(repo, &config.PublicEventsConfig{Enabled: true})
+
+	rec := httptest.NewRecorder()
+	h.ListEvents(rec, httptest.NewRequest(http.MethodGet, publicEventsPath, nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rec.Code)
+	}
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "*" {
+		t.Errorf("Access-Control-Allow-Origin = %q, want *", got)
+	}
+	if got := rec.Header().Get("Cache-Control"); got != "public, max-age=60" {
+		t.Errorf("Cache-Control = %q", got)
+	}
+
+	// Default min scale is 3 (severity 30)
+	events := decodePublicEvents(t, rec)
+	var ids []string
+	for _, e := range events {
+		ids = append(ids, e.ID)
+	}
+	if len(ids) != 4 || ids[0] != "a" || ids[1] != "c" || ids[2] != "d" || ids[3] != "e" {
+		t.Errorf("events = %v, want [a c d e]", ids)
+	}
+	if strings.Contains(rec.Body.String(), "raw") {
+		t.Error("raw payload must not be exposed")
+	}
+}
+
+func TestPublicEventsHandler_ConfigAndLimit(t *testing.T) {
+	repo := newPublicEventsRepo()
+	h := NewPublicEventsHandler(repo, &config.PublicEventsConfig{Enabled: true, MinScale: 45, Limit: 2, CacheSeconds: 300})
+
+	rec := httptest.NewRecorder()
+	h.ListEvents(rec, httptest.NewRequest(http.MethodGet, publicEventsPath, nil))
+	if events := decodePublicEvents(t, rec); len(events) != 2 || events[0].ID != "a" || events[1].ID != "c" {
+		t.Errorf("events = %+v, want a and c (scale 5弱 and above, limit 2)", events)
+	}
+	if got := rec.Header().Get("Cache-Control"); got != "public, max-age=300" {
+		t.Errorf("Cache-Control = %q", got)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ListEvents(rec, httptest.NewRequest(http.MethodGet, publicEventsPath+"?limit=1", nil))
+	if events := decodePublicEvents(t, rec); len(events) != 1 {
+		t.Errorf("len(events) = %d, want 1", len(events))
+	}
+
+	rec = httptest.NewRecorder()
+	h.ListEvents(rec, httptest.NewRequest(http.MethodGet, publicEventsPath+"?limit=100", nil))
+	if events := decodePublicEvents(t, rec); len(events) != 2 {
+		t.Errorf("len(events) = %d, want the configured limit 2", len(events))
+	}
+}
+
+func TestPublicEventsHandler_Caches(t *testing.T) {
+	repo := newPublicEventsRepo()
+	h := NewPublicEventsHandler(repo, nil)
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	h.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		h.ListEvents(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, publicEventsPath, nil))
+	}
+	if repo.lists != 1 {
+		t.Errorf("List calls = %d, want 1 while cached", repo.lists)
+	}
+
+	now = now.Add(DefaultPublicEventsCache)
+	h.ListEvents(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, publicEventsPath, nil))
+	if repo.lists != 2 {
+		t.Errorf("List calls = %d, want 2 after expiry", repo.lists)
+	}
+}
+
+func TestPublicEventsHandler_ServesStaleOnError(t *testing.T) {
+	repo := newPublicEventsRepo()
+	h := NewPublicEventsHandler(repo, nil)
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	h.now = func() time.Time { return now }
+
+	h.ListEvents(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, publicEventsPath, nil))
+
+	repo.listErr = errors.New("firestore unavailable")
+	now = now.Add(time.Hour)
+	rec := httptest.NewRecorder()
+	h.ListEvents(rec, httptest.NewRequest(http.MethodGet, publicEventsPath, nil))
+	if rec.Code != http.StatusOK || len(decodePublicEvents(t, rec)) != 4 {
+		t.Errorf("expected cached events on error, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	cold := NewPublicEventsHandler(repo, nil)
+	rec = httptest.NewRecorder()
+	cold.ListEvents(rec, httptest.NewRequest(http.MethodGet, publicEventsPath, nil))
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("expected status %d without a cache, got %d", http.StatusInternalServerError, rec.Code)
+	}
+}
+
+func TestNewRouterWithConfig_PublicEvents(t *testing.T) {
+	newRouter := func(public *config.PublicEventsConfig) http.Handler {
+		return NewRouterWithConfig(RouterConfig{
+			SubscriptionRepo: newMockSubscriptionRepo(),
+			EventRepo:        newPublicEventsRepo(),
+			UserRepo:         newMockUserRepo(),
+			TokenVerifier:    &mockTokenVerifier{},
+			SecurityConfig:   &config.SecurityConfig{CORSAllowedOrigins: "https://namazu.example"},
+			PublicEvents:     public,
+		})
+	}
+
+	t.Run("served without auth and with open CORS", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, publicEventsPath, nil)
+		req.Header.Set("Origin", "https://community.example")
+		rec := httptest.NewRecorder()
+		newRouter(&config.PublicEventsConfig{Enabled: true}).ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, rec.Code)
+		}
+		if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "*" {
+			t.Errorf("Access-Control-Allow-Origin = %q, want *", got)
+		}
+		if got := rec.Header().Get("Access-Control-Allow-Credentials"); got != "" {
+			t.Errorf("Access-Control-Allow-Credentials = %q, want none", got)
+		}
+	})
+
+	t.Run("not found when disabled", func(t *testing.T) {
+		for _, public := range []*config.PublicEventsConfig{nil, {Enabled: false}} {
+			rec := httptest.NewRecorder()
+			newRouter(public).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, publicEventsPath, nil))
+			if rec.Code != http.StatusNotFound {
+				t.Errorf("expected status %d, got %d", http.StatusNotFound, rec.Code)
+			}
+		}
+	})
+
+	t.Run("rejects writes", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		newRouter(&config.PublicEventsConfig{Enabled: true}).ServeHTTP(rec, httptest.NewRequest(http.MethodPost, publicEventsPath, nil))
+		if rec.Code != http.StatusMethodNotAllowed {
+			t.Errorf("expected status %d, got %d", http.StatusMethodNotAllowed, rec.Code)
+		}
+	})
+}
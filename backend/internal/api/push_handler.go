@@ -0,0 +1,159 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/otiai10/namazu/backend/internal/apierr"
+	"github.com/otiai10/namazu/backend/internal/auth"
+	"github.com/otiai10/namazu/backend/internal/delivery/webpush"
+	"github.com/otiai10/namazu/backend/internal/user"
+)
+
+// maxUserAgentLength bounds the User-Agent stored with a push subscription
+const maxUserAgentLength = 256
+
+// PushSubscriptionRequest is a browser's PushSubscription.toJSON()
+type PushSubscriptionRequest struct {
+	Endpoint string `json:"endpoint"`
+	Keys     struct {
+		P256DH string `json:"p256dh"`
+		Auth   string `json:"auth"`
+	} `json:"keys"`
+}
+
+// PushSubscriptionsResponse lists the browsers registered for Web Push
+type PushSubscriptionsResponse struct {
+	PublicKey     string                  `json:"publicKey"` // applicationServerKey for PushManager.subscribe
+	Subscriptions []user.PushSubscription `json:"subscriptions"`
+}
+
+// SetWebPush enables /api/me/push-subscriptions. publicKey is the VAPID
+// public key browsers subscribe with.
+func (h *MeHandler) SetWebPush(publicKey string) {
+	h.vapidPublicKey = publicKey
+}
+
+// SetURLValidator sets the validator push endpoints must pass (SSRF prevention)
+func (h *MeHandler) SetURLValidator(v URLValidator) {
+	h.urlValidator = v
+}
+
+// ListPushSubscriptions handles GET /api/me/push-subscriptions
+func (h *MeHandler) ListPushSubscriptions(w http.ResponseWriter, r *http.Request) {
+	claims := auth.MustGetClaims(r.Context())
+
+	if h.vapidPublicKey == "" {
+		writeError(w, "web push is not enabled", http.StatusNotImplemented)
+		return
+	}
+
+	u, err := h.userRepo.GetByUID(r.Context(), claims.UID)
+	if err != nil {
+		writeError(w, "failed to get user", http.StatusInternalServerError)
+		return
+	}
+
+	resp := PushSubscriptionsResponse{PublicKey: h.vapidPublicKey, Subscriptions: []user.PushSubscription{}}
+	if u != nil && u.PushSubscriptions != nil {
+		resp.Subscriptions = u.PushSubscriptions
+	}
+	writeJSON(w, resp, http.StatusOK)
+}
+
+// CreatePushSubscription handles POST /api/me/push-subscriptions.
+// Registering an endpoint again replaces its keys.
+func (h *MeHandler) CreatePushSubscription(w http.ResponseWriter, r *http.Request) {
+	claims := auth.MustGetClaims(r.Context())
+
+	if h.vapidPublicKey == "" {
+		writeError(w, "web push is not enabled", http.StatusNotImplemented)
+		return
+	}
+
+	var req PushSubscriptionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.Endpoint == "" {
+		writeErrorCode(w, apierr.ValidationFailed, "endpoint is required", http.StatusBadRequest)
+		return
+	}
+	if h.urlValidator != nil {
+		if err := h.urlValidator.ValidateWebhookURL(req.Endpoint); err != nil {
+			writeErrorCode(w, apierr.ValidationFailed, "invalid endpoint: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	if err := webpush.ValidateKeys(req.Keys.P256DH, req.Keys.Auth); err != nil {
+		writeErrorCode(w, apierr.ValidationFailed, "invalid keys: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	u, err := h.userRepo.GetByUID(r.Context(), claims.UID)
+	if err != nil {
+		writeError(w, "failed to get user", http.StatusInternalServerError)
+		return
+	}
+	if u == nil {
+		u, err = h.createNewUser(r, claims)
+		if err != nil {
+			writeError(w, "failed to create user", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	userAgent := r.UserAgent()
+	if len(userAgent) > maxUserAgentLength {
+		userAgent = userAgent[:maxUserAgentLength]
+	}
+	sub := user.PushSubscription{
+		Endpoint:  req.Endpoint,
+		P256DH:    req.Keys.P256DH,
+		Auth:      req.Keys.Auth,
+		UserAgent: userAgent,
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := h.userRepo.AddPushSubscription(r.Context(), u.ID, sub); err != nil {
+		writeError(w, "failed to save push subscription", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, sub, http.StatusCreated)
+}
+
+// DeletePushSubscription handles DELETE /api/me/push-subscriptions?endpoint=...
+func (h *MeHandler) DeletePushSubscription(w http.ResponseWriter, r *http.Request) {
+	claims := auth.MustGetClaims(r.Context())
+
+	endpoint := r.URL.Query().Get("endpoint")
+	if endpoint == "" {
+		writeErrorCode(w, apierr.ValidationFailed, "endpoint is required", http.StatusBadRequest)
+		return
+	}
+
+	u, err := h.userRepo.GetByUID(r.Context(), claims.UID)
+	if err != nil {
+		writeError(w, "failed to get user", http.StatusInternalServerError)
+		return
+	}
+	if u == nil {
+		writeError(w, "push subscription not found", http.StatusNotFound)
+		return
+	}
+
+	err = h.userRepo.RemovePushSubscription(r.Context(), u.ID, endpoint)
+	if errors.Is(err, user.ErrPushSubscriptionNotFound) || errors.Is(err, user.ErrNotFound) {
+		writeError(w, "push subscription not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		writeError(w, "failed to delete push subscription", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
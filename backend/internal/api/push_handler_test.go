@@ -0,0 +1,114 @@
+package api
+
+import (
+	"bytes"
+	"crypto/ecdh"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/otiai10/namazu/backend/internal/auth"
+	"github.com/otiai10/namazu/backend/internal/user"
+)
+
+// pushSubscriptionBody returns a PushSubscription.toJSON() body with valid keys
+func pushSubscriptionBody(t *testing.T, endpoint string) string {
+	t.Helper()
+	key, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	authSecret := make([]byte, 16)
+	rand.Read(authSecret)
+	body, _ := json.Marshal(map[string]any{
+		"endpoint":       endpoint,
+		"expirationTime": nil,
+		"keys": map[string]string{
+			"p256dh": base64.RawURLEncoding.EncodeToString(key.PublicKey().Bytes()),
+			"auth":   base64.RawURLEncoding.EncodeToString(authSecret),
+		},
+	})
+	return string(body)
+}
+
+func withUser(r *http.Request, uid string) *http.Request {
+	return r.WithContext(auth.WithClaims(r.Context(), &auth.Claims{UID: uid, ProviderID: user.ProviderGoogle}))
+}
+
+func TestMeHandler_PushSubscriptions(t *testing.T) {
+	repo := newMockUserRepo()
+	handler := NewMeHandler(repo)
+	handler.SetWebPush("BPublicKey")
+	endpoint := "https://fcm.googleapis.com/fcm/send/abc"
+
+	// Registering creates the user on first use
+	req := withUser(httptest.NewRequest(http.MethodPost, "/api/me/push-subscriptions", bytes.NewBufferString(pushSubscriptionBody(t, endpoint))), "test-uid")
+	req.Header.Set("User-Agent", "Firefox")
+	rec := httptest.NewRecorder()
+	handler.CreatePushSubscription(rec, req)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ListPushSubscriptions(rec, withUser(httptest.NewRequest(http.MethodGet, "/api/me/push-subscriptions", nil), "test-uid"))
+	var list PushSubscriptionsResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &list); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if list.PublicKey != "BPublicKey" || len(list.Subscriptions) != 1 || list.Subscriptions[0].Endpoint != endpoint || list.Subscriptions[0].UserAgent != "Firefox" {
+		t.Fatalf("unexpected list: %+v", list)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.DeletePushSubscription(rec, withUser(httptest.NewRequest(http.MethodDelete, "/api/me/push-subscriptions?endpoint="+url.QueryEscape(endpoint), nil), "test-uid"))
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusNoContent, rec.Code, rec.Body.String())
+	}
+	rec = httptest.NewRecorder()
+	handler.DeletePushSubscription(rec, withUser(httptest.NewRequest(http.MethodDelete, "/api/me/push-subscriptions?endpoint="+url.QueryEscape(endpoint), nil), "test-uid"))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected status %d for a removed endpoint, got %d", http.StatusNotFound, rec.Code)
+	}
+}
+
+func TestMeHandler_CreatePushSubscription_Invalid(t *testing.T) {
+	handler := NewMeHandler(newMockUserRepo())
+	handler.SetWebPush("BPublicKey")
+	validator := newMockURLValidator()
+	validator.allowedURLs["https://fcm.googleapis.com/fcm/send/abc"] = true
+	handler.SetURLValidator(validator)
+
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"malformed", `{`},
+		{"missing endpoint", pushSubscriptionBody(t, "")},
+		{"rejected endpoint", pushSubscriptionBody(t, "https://169.254.169.254/latest")},
+		{"invalid keys", `{"endpoint": "https://fcm.googleapis.com/fcm/send/abc", "keys": {"p256dh": "AAAA", "auth": "AAAA"}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handler.CreatePushSubscription(rec, withUser(httptest.NewRequest(http.MethodPost, "/api/me/push-subscriptions", bytes.NewBufferString(tt.body)), "test-uid"))
+			if rec.Code != http.StatusBadRequest {
+				t.Errorf("expected status %d, got %d: %s", http.StatusBadRequest, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestMeHandler_PushSubscriptions_NotEnabled(t *testing.T) {
+	handler := NewMeHandler(newMockUserRepo())
+
+	rec := httptest.NewRecorder()
+	handler.CreatePushSubscription(rec, withUser(httptest.NewRequest(http.MethodPost, "/api/me/push-subscriptions", bytes.NewBufferString(pushSubscriptionBody(t, "https://fcm.googleapis.com/fcm/send/abc"))), "test-uid"))
+	if rec.Code != http.StatusNotImplemented {
+		t.Errorf("expected status %d, got %d", http.StatusNotImplemented, rec.Code)
+	}
+}
@@ -1,18 +1,27 @@
 package api
 
 import (
-	"fmt"
 	"net/http"
 	"strings"
 
+	"github.com/otiai10/namazu/backend/internal/account"
+	"github.com/otiai10/namazu/backend/internal/analytics"
+	"github.com/otiai10/namazu/backend/internal/audit"
 	"github.com/otiai10/namazu/backend/internal/auth"
+	"github.com/otiai10/namazu/backend/internal/badge"
 	"github.com/otiai10/namazu/backend/internal/billing"
 	"github.com/otiai10/namazu/backend/internal/config"
+	"github.com/otiai10/namazu/backend/internal/deliverylog"
+	"github.com/otiai10/namazu/backend/internal/idempotency"
+	"github.com/otiai10/namazu/backend/internal/mail"
 	"github.com/otiai10/namazu/backend/internal/quota"
+	"github.com/otiai10/namazu/backend/internal/ratelimit"
+	"github.com/otiai10/namazu/backend/internal/secrets"
 	"github.com/otiai10/namazu/backend/internal/store"
+	"github.com/otiai10/namazu/backend/internal/stream"
 	"github.com/otiai10/namazu/backend/internal/subscription"
+	"github.com/otiai10/namazu/backend/internal/tenant"
 	"github.com/otiai10/namazu/backend/internal/user"
-	"github.com/otiai10/namazu/backend/internal/version"
 )
 
 // RouterConfig holds dependencies for the router
@@ -20,20 +29,60 @@ type RouterConfig struct {
 	SubscriptionRepo subscription.Repository
 	EventRepo        store.EventRepository
 	UserRepo         user.Repository
-	TokenVerifier    auth.TokenVerifier // nil means no auth
-	QuotaChecker     quota.QuotaChecker // nil means no quota checking
-	BillingClient    *billing.Client    // nil means no billing
+	TokenVerifier    auth.TokenVerifier  // nil means no auth
+	RoleSetter       auth.RoleSetter     // nil stores roles on user records only
+	TokenRevoker     auth.TokenRevoker   // nil leaves the refresh tokens of deleted accounts valid
+	ProviderLinker   auth.ProviderLinker // nil records linked providers on user records only
+	AccountSigner    *account.Signer     // nil disables account deletion and emailed exports
+	Mailer           mail.Sender         // nil disables emailed exports
+	AuditLog         *audit.Logger       // nil disables the audit log
+	QuotaChecker     quota.QuotaChecker  // nil means no quota checking
+	BillingClient    *billing.Client     // nil means no billing
 	BillingConfig    *config.BillingConfig
-	SecurityConfig   *config.SecurityConfig // nil uses defaults
-	URLValidator     URLValidator           // nil means no URL validation
-	Challenger       Challenger             // nil means no challenge verification
+	SecurityConfig   *config.SecurityConfig     // nil uses defaults
+	RateLimitStore   ratelimit.Store            // nil enforces rate limits per instance
+	SecurityReloader *SecurityReloader          // nil fixes the CORS and rate limit settings at startup
+	URLValidator     URLValidator               // nil means no URL validation
+	Challenger       Challenger                 // nil means no challenge verification
+	EgressMeter      EgressMeter                // nil means no egress tracking
+	EgressIPs        []string                   // empty disables GET /api/egress-ips
+	Rollups          analytics.Repository       // nil disables GET /api/analytics/events
+	BadgeSigner      *badge.Signer              // nil means badges are disabled
+	HealthReporter   HealthReporter             // nil reports every badge as unknown and omits health from subscription stats
+	Config           *config.Config             // nil disables the admin config export
+	Tenants          *tenant.Registry           // nil serves every request as the default tenant
+	ResolverStats    ResolverStats              // nil disables the admin DNS metrics
+	QueueStats       QueueStats                 // nil disables the admin delivery queue metrics
+	SenderStats      SenderStats                // nil disables the admin sender concurrency metrics
+	WatchStats       WatchStats                 // nil disables the admin subscription listener health
+	DeliveryRepo     store.DeliveryRepository   // nil disables delivery history and log exports
+	DeliveryLog      *deliverylog.Signer        // nil disables delivery log exports
+	Redeliverer      Redeliverer                // nil disables manual redelivery
+	Tester           Tester                     // nil disables test deliveries
+	Broadcaster      Broadcaster                // nil disables service notices
+	Lifecycle        LifecycleReporter          // nil disables the admin lifecycle report
+	EventInjector    EventInjector              // nil disables synthetic event injection
+	EventPublisher   EventInjector              // nil disables admin drill events
+	PublicEvents     *config.PublicEventsConfig // nil disables the public events API
+	Stream           *stream.Hub                // nil disables the live event streams (WebSocket and SSE)
+	SMS              *config.SMSConfig          // nil disables the Twilio status callback
+	VAPIDPublicKey   string                     // empty disables Web Push registration
+	DeviceTopics     DeviceTopics               // nil disables FCM device registration
+	IdempotencyRepo  idempotency.Repository     // nil disables Idempotency-Key support
+	SecretsEnvelope  *secrets.Envelope          // nil stores Idempotency-Key responses unencrypted
+	AuthConfig       *config.AuthConfig         // nil requires neither a verified email nor accepted terms
+	Readiness        map[string]ReadinessCheck  // Dependencies checked by /readyz, by name
+	Stats            InstanceStats              // nil omits the instance section of /api/stats
 }
 
 // NewRouter creates a new router with all API routes configured
 func NewRouter(h *Handler) http.Handler {
 	mux := http.NewServeMux()
+	registerHealthRoutes(mux, NewHealthHandler(nil))
 	registerPublicRoutes(mux, h)
 	registerSubscriptionRoutes(mux, h)
+	registerDeliveryRoutes(mux, h)
+	registerStatsRoutes(mux, h)
 	return applyMiddlewareChain(mux)
 }
 
@@ -58,25 +107,187 @@ func NewRouterWithConfig(cfg RouterConfig) http.Handler {
 		h.SetChallenger(cfg.Challenger)
 	}
 
+	if cfg.DeliveryRepo != nil {
+		h.SetDeliveryRepository(cfg.DeliveryRepo)
+		if cfg.DeliveryLog != nil {
+			h.SetDeliveryLog(cfg.DeliveryRepo, cfg.DeliveryLog)
+		}
+	}
+	if cfg.Redeliverer != nil {
+		h.SetRedeliverer(cfg.Redeliverer)
+	}
+	if cfg.Tester != nil {
+		h.SetTester(cfg.Tester)
+	}
+	if cfg.Stats != nil {
+		h.SetStats(cfg.Stats)
+	}
+	if cfg.HealthReporter != nil {
+		h.SetHealthReporter(cfg.HealthReporter)
+	}
+	if cfg.AuditLog != nil {
+		h.SetAuditLog(cfg.AuditLog)
+	}
+	if len(cfg.EgressIPs) > 0 {
+		h.SetEgressIPs(cfg.EgressIPs)
+	}
+	if cfg.Rollups != nil {
+		h.SetRollups(cfg.Rollups)
+	}
+	if cfg.SecurityConfig != nil && cfg.SecurityConfig.DenyOwnerlessAccess {
+		h.SetDenyOwnerless(true)
+	}
+	var idempotent *Idempotency
+	if cfg.IdempotencyRepo != nil {
+		idempotent = NewIdempotency(cfg.IdempotencyRepo)
+		if cfg.SecretsEnvelope != nil {
+			idempotent.SetEnvelope(cfg.SecretsEnvelope)
+		}
+		h.SetIdempotency(idempotent)
+	}
+
 	// Public routes (no auth required)
+	registerHealthRoutes(mux, NewHealthHandler(cfg.Readiness))
 	registerPublicRoutes(mux, h)
 
+	// Badge routes are public; access is controlled by signed tokens
+	if cfg.BadgeSigner != nil {
+		h.SetBadgeSigner(cfg.BadgeSigner)
+		registerBadgeRoutes(mux, NewBadgeHandler(cfg.BadgeSigner, cfg.HealthReporter, cfg.SubscriptionRepo))
+	}
+
+	// Public events for website embeds; read-only, so no auth even in auth mode
+	if cfg.PublicEvents != nil && cfg.PublicEvents.Enabled && cfg.EventRepo != nil {
+		registerPublicEventsRoutes(mux, NewPublicEventsHandler(cfg.EventRepo, cfg.PublicEvents))
+	}
+
 	// Stripe webhook route (no auth required - uses signature verification)
 	if cfg.BillingClient != nil && cfg.BillingConfig != nil {
 		billingHandler := NewBillingHandler(cfg.BillingClient, cfg.UserRepo, cfg.BillingConfig)
+		if cfg.QuotaChecker != nil {
+			billingHandler.SetQuotaEnforcer(quota.NewEnforcer(cfg.SubscriptionRepo, cfg.Tenants))
+		}
+		billingHandler.SetTenants(cfg.Tenants)
+		if cfg.AuditLog != nil {
+			billingHandler.SetAuditLog(cfg.AuditLog)
+		}
 		registerStripeWebhookRoute(mux, billingHandler)
 	}
 
+	// Twilio status callback (no auth required - uses signature verification)
+	if cfg.SMS != nil && cfg.SMS.StatusCallbackURL != "" && cfg.DeliveryRepo != nil {
+		registerTwilioWebhookRoute(mux, NewTwilioHandler(cfg.SMS, cfg.DeliveryRepo))
+	}
+
+	adminHandler := NewAdminHandler()
+	if cfg.EgressMeter != nil {
+		adminHandler.SetEgressMeter(cfg.EgressMeter)
+	}
+	if cfg.Config != nil {
+		adminHandler.SetConfig(cfg.Config)
+	}
+	if cfg.ResolverStats != nil {
+		adminHandler.SetResolverStats(cfg.ResolverStats)
+	}
+	if cfg.QueueStats != nil {
+		adminHandler.SetQueueStats(cfg.QueueStats)
+	}
+	if cfg.SenderStats != nil {
+		adminHandler.SetSenderStats(cfg.SenderStats)
+	}
+	if cfg.WatchStats != nil {
+		adminHandler.SetWatchStats(cfg.WatchStats)
+	}
+	if cfg.Broadcaster != nil {
+		adminHandler.SetBroadcaster(cfg.Broadcaster)
+	}
+	if cfg.Lifecycle != nil {
+		adminHandler.SetLifecycleReporter(cfg.Lifecycle)
+	}
+	if cfg.EventInjector != nil {
+		adminHandler.SetEventInjector(cfg.EventInjector)
+	}
+	if cfg.EventPublisher != nil {
+		adminHandler.SetEventPublisher(cfg.EventPublisher)
+	}
+	if cfg.UserRepo != nil {
+		adminHandler.SetUserRepo(cfg.UserRepo)
+	}
+	if cfg.SubscriptionRepo != nil {
+		adminHandler.SetSubscriptionRepo(cfg.SubscriptionRepo)
+	}
+	if cfg.RoleSetter != nil {
+		adminHandler.SetRoleSetter(cfg.RoleSetter)
+	}
+	if cfg.AuditLog != nil {
+		adminHandler.SetAuditLog(cfg.AuditLog)
+	}
+
+	// The streams authenticate themselves: browsers pass the token as a query parameter
+	if cfg.Stream != nil {
+		streamHandler := NewStreamHandler(cfg.Stream, cfg.TokenVerifier)
+		if cfg.EventRepo != nil {
+			streamHandler.SetEventRepository(cfg.EventRepo)
+		}
+		mux.Handle("/api/stream", streamHandler)
+		mux.HandleFunc("/api/events/stream", streamHandler.ServeSSE)
+	}
+
 	// Protected routes (auth required when TokenVerifier is provided)
 	if cfg.TokenVerifier != nil {
 		protectedMux := http.NewServeMux()
 		meHandler := NewMeHandler(cfg.UserRepo)
+		if a := cfg.AuthConfig; a != nil && (a.RequireEmailVerified || a.TermsVersion != "") && cfg.UserRepo != nil {
+			gate := NewSignupGate(cfg.UserRepo, a.RequireEmailVerified, a.TermsVersion)
+			h.SetSignupGate(gate)
+			meHandler.SetSignupGate(gate)
+		}
+		if cfg.EgressMeter != nil {
+			meHandler.SetEgressMeter(cfg.EgressMeter)
+		}
+		if cfg.QuotaChecker != nil {
+			meHandler.SetQuotaChecker(cfg.QuotaChecker)
+		}
+		if cfg.VAPIDPublicKey != "" {
+			meHandler.SetWebPush(cfg.VAPIDPublicKey)
+		}
+		if cfg.URLValidator != nil {
+			meHandler.SetURLValidator(cfg.URLValidator)
+		}
+		if cfg.DeviceTopics != nil {
+			meHandler.SetDeviceTopics(cfg.DeviceTopics)
+		}
+		if cfg.AuditLog != nil {
+			meHandler.SetAuditLog(cfg.AuditLog)
+		}
+		meHandler.SetProviderLinking(cfg.TokenVerifier, cfg.ProviderLinker)
+		meHandler.SetSubscriptionRepository(cfg.SubscriptionRepo)
+		if cfg.DeliveryRepo != nil {
+			meHandler.SetDeliveryRepository(cfg.DeliveryRepo)
+		}
+		if cfg.AccountSigner != nil {
+			meHandler.SetAccountSigner(cfg.AccountSigner)
+			if cfg.Mailer != nil {
+				meHandler.SetMailer(cfg.Mailer)
+			}
+			if cfg.BillingClient != nil {
+				meHandler.SetSubscriptionCanceler(cfg.BillingClient)
+			}
+			if cfg.TokenRevoker != nil {
+				meHandler.SetTokenRevoker(cfg.TokenRevoker)
+			}
+			// Emailed export links are opened without a session
+			registerExportDownloadRoute(mux, meHandler)
+		}
 		registerMeRoutes(protectedMux, meHandler)
 		registerSubscriptionRoutes(protectedMux, h)
+		registerDeliveryRoutes(protectedMux, h)
+		registerStatsRoutes(protectedMux, h)
 
 		// Register billing routes if billing is configured
 		if cfg.BillingClient != nil && cfg.BillingConfig != nil {
 			billingHandler := NewBillingHandler(cfg.BillingClient, cfg.UserRepo, cfg.BillingConfig)
+			billingHandler.SetIdempotency(idempotent)
 			registerBillingRoutes(protectedMux, billingHandler)
 		}
 
@@ -86,25 +297,141 @@ func NewRouterWithConfig(cfg RouterConfig) http.Handler {
 		mux.Handle("/api/me/", authHandler)
 		mux.Handle("/api/subscriptions", authHandler)
 		mux.Handle("/api/subscriptions/", authHandler)
+		mux.Handle("/api/deliveries/", authHandler)
+		mux.Handle("/api/stats", authHandler)
 		mux.Handle("/api/billing/", authHandler)
+
+		// Admin routes require the admin claim on top of authentication
+		adminMux := http.NewServeMux()
+		registerAdminRoutes(adminMux, adminHandler)
+		mux.Handle("/api/admin/", auth.AuthMiddleware(cfg.TokenVerifier)(auth.RequireAdmin(adminMux)))
 	} else {
 		// No auth mode (backward compatibility)
 		registerSubscriptionRoutes(mux, h)
+		registerDeliveryRoutes(mux, h)
+		registerStatsRoutes(mux, h)
+		registerAdminRoutes(mux, adminHandler)
+	}
+
+	// Resolve the white-label tenant from the request host
+	var handler http.Handler = mux
+	if cfg.Tenants != nil {
+		handler = tenant.Middleware(cfg.Tenants)(mux)
 	}
 
-	return applyMiddlewareChainWithConfig(mux, cfg.SecurityConfig)
+	if cfg.SecurityReloader != nil {
+		return cfg.SecurityReloader.wrap(handler, cfg.SecurityConfig, cfg.RateLimitStore)
+	}
+	return applyMiddlewareChainWithConfig(handler, cfg.SecurityConfig, cfg.RateLimitStore)
+}
+
+// registerHealthRoutes registers the liveness and readiness probes.
+// /health is the liveness probe under its original name.
+func registerHealthRoutes(mux *http.ServeMux, hh *HealthHandler) {
+	mux.HandleFunc("/health", hh.Liveness)
+	mux.HandleFunc("/healthz", hh.Liveness)
+	mux.HandleFunc("/readyz", hh.Readiness)
 }
 
 // registerPublicRoutes registers routes that don't require authentication
 func registerPublicRoutes(mux *http.ServeMux, h *Handler) {
-	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
-		_, _ = w.Write([]byte(fmt.Sprintf(`{"status":"ok","hash":"%s"}`, version.CommitHash)))
+	mux.HandleFunc("/api/events", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			h.ListEvents(w, r)
+		case http.MethodOptions:
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			writeError(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
 	})
 
-	mux.HandleFunc("/api/events", func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc("/api/events/", func(w http.ResponseWriter, r *http.Request) {
 		switch r.Method {
 		case http.MethodGet:
+			h.GetEvent(w, r)
+		case http.MethodOptions:
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			writeError(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	mux.HandleFunc("/api/analytics/events", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			h.GetEventAnalytics(w, r)
+		case http.MethodOptions:
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			writeError(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	mux.HandleFunc("/api/tenant", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			h.GetTenant(w, r)
+		case http.MethodOptions:
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			writeError(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	mux.HandleFunc("/api/delivery-log/public-key", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			h.GetDeliveryLogPublicKey(w, r)
+		case http.MethodOptions:
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			writeError(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	mux.HandleFunc("/api/egress-ips", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			h.GetEgressIPs(w, r)
+		case http.MethodOptions:
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			writeError(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	mux.HandleFunc(schemasPrefix, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			h.GetSchema(w, r)
+		case http.MethodOptions:
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			writeError(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+}
+
+// registerBadgeRoutes registers public subscription health badge routes
+func registerBadgeRoutes(mux *http.ServeMux, h *BadgeHandler) {
+	mux.HandleFunc(badgePrefix, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead:
+			h.ServeBadge(w, r)
+		case http.MethodOptions:
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			writeError(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+}
+
+// registerPublicEventsRoutes registers the read-only public events route
+func registerPublicEventsRoutes(mux *http.ServeMux, h *PublicEventsHandler) {
+	mux.HandleFunc(publicEventsPath, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead:
 			h.ListEvents(w, r)
 		case http.MethodOptions:
 			w.WriteHeader(http.StatusNoContent)
@@ -114,12 +441,63 @@ func registerPublicRoutes(mux *http.ServeMux, h *Handler) {
 	})
 }
 
+// registerExportDownloadRoute registers the signed export download links sent by email
+func registerExportDownloadRoute(mux *http.ServeMux, h *MeHandler) {
+	mux.HandleFunc(exportDownloadPrefix, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			h.DownloadExport(w, r)
+		case http.MethodOptions:
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			writeError(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+}
+
 // registerMeRoutes registers user profile routes
 func registerMeRoutes(mux *http.ServeMux, h *MeHandler) {
 	mux.HandleFunc("/api/me", func(w http.ResponseWriter, r *http.Request) {
 		switch r.Method {
 		case http.MethodGet:
 			h.GetProfile(w, r)
+		case http.MethodDelete:
+			h.DeleteAccount(w, r)
+		case http.MethodOptions:
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			writeError(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	mux.HandleFunc("/api/me/accept-terms", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			h.AcceptTerms(w, r)
+		case http.MethodOptions:
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			writeError(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	mux.HandleFunc("/api/me/deletion", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			h.CreateDeletionToken(w, r)
+		case http.MethodOptions:
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			writeError(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	mux.HandleFunc("/api/me/export", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			h.ExportData(w, r)
+		case http.MethodPost:
+			h.EmailExport(w, r)
 		case http.MethodOptions:
 			w.WriteHeader(http.StatusNoContent)
 		default:
@@ -137,6 +515,239 @@ func registerMeRoutes(mux *http.ServeMux, h *MeHandler) {
 			writeError(w, "method not allowed", http.StatusMethodNotAllowed)
 		}
 	})
+
+	mux.HandleFunc("/api/me/providers/", func(w http.ResponseWriter, r *http.Request) {
+		providerID := strings.TrimPrefix(r.URL.Path, "/api/me/providers/")
+		if providerID == "" || strings.Contains(providerID, "/") {
+			writeError(w, "not found", http.StatusNotFound)
+			return
+		}
+		switch {
+		case r.Method == http.MethodOptions:
+			w.WriteHeader(http.StatusNoContent)
+		case providerID == "link" && r.Method == http.MethodPost:
+			h.LinkProvider(w, r)
+		case providerID != "link" && r.Method == http.MethodDelete:
+			h.UnlinkProvider(w, r, providerID)
+		default:
+			writeError(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	mux.HandleFunc("/api/me/usage", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			h.GetUsage(w, r)
+		case http.MethodOptions:
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			writeError(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	mux.HandleFunc("/api/me/push-subscriptions", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			h.ListPushSubscriptions(w, r)
+		case http.MethodPost:
+			h.CreatePushSubscription(w, r)
+		case http.MethodDelete:
+			h.DeletePushSubscription(w, r)
+		case http.MethodOptions:
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			writeError(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	mux.HandleFunc("/api/me/devices", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			h.ListDevices(w, r)
+		case http.MethodPost:
+			h.CreateDevice(w, r)
+		case http.MethodDelete:
+			h.DeleteDevice(w, r)
+		case http.MethodOptions:
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			writeError(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+}
+
+// registerAdminRoutes registers operator-only routes
+func registerAdminRoutes(mux *http.ServeMux, h *AdminHandler) {
+	mux.HandleFunc("/api/admin/config", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			h.GetConfig(w, r)
+		case http.MethodOptions:
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			writeError(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	mux.HandleFunc("/api/admin/notices", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			h.BroadcastNotice(w, r)
+		case http.MethodOptions:
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			writeError(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	mux.HandleFunc("/api/admin/inject-event", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			h.InjectEvent(w, r)
+		case http.MethodOptions:
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			writeError(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	mux.HandleFunc("/api/admin/events", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			h.PublishEvent(w, r)
+		case http.MethodOptions:
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			writeError(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	mux.HandleFunc("/api/admin/lifecycle", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			h.GetLifecycleReport(w, r)
+		case http.MethodOptions:
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			writeError(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	mux.HandleFunc("/api/admin/queue", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			h.GetQueueStats(w, r)
+		case http.MethodOptions:
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			writeError(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	mux.HandleFunc("/api/admin/sender", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			h.GetSenderStats(w, r)
+		case http.MethodOptions:
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			writeError(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	mux.HandleFunc("/api/admin/subscriptions/watch", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			h.GetWatchStats(w, r)
+		case http.MethodOptions:
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			writeError(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	mux.HandleFunc("/api/admin/subscriptions/", func(w http.ResponseWriter, r *http.Request) {
+		id, ok := strings.CutSuffix(strings.TrimPrefix(r.URL.Path, "/api/admin/subscriptions/"), "/assign-owner")
+		if !ok || id == "" || strings.Contains(id, "/") {
+			writeError(w, "not found", http.StatusNotFound)
+			return
+		}
+		switch r.Method {
+		case http.MethodPost:
+			h.AssignOwner(w, r, id)
+		case http.MethodOptions:
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			writeError(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	mux.HandleFunc("/api/admin/audit", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			h.ListAuditLog(w, r)
+		case http.MethodOptions:
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			writeError(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	mux.HandleFunc("/api/admin/dns", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			h.GetDNSStats(w, r)
+		case http.MethodOptions:
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			writeError(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	mux.HandleFunc("/api/admin/users/", func(w http.ResponseWriter, r *http.Request) {
+		if uid, ok := parseAdminUserID(r.URL.Path); ok {
+			switch r.Method {
+			case http.MethodGet:
+				h.GetUser(w, r, uid)
+			case http.MethodOptions:
+				w.WriteHeader(http.StatusNoContent)
+			default:
+				writeError(w, "method not allowed", http.StatusMethodNotAllowed)
+			}
+			return
+		}
+
+		uid, resource, ok := parseAdminUserPath(r.URL.Path)
+		if !ok {
+			writeError(w, "not found", http.StatusNotFound)
+			return
+		}
+
+		switch resource {
+		case "egress":
+			switch r.Method {
+			case http.MethodGet:
+				h.GetUserEgress(w, r, uid)
+			case http.MethodPut:
+				h.SetUserEgressBudget(w, r, uid)
+			case http.MethodOptions:
+				w.WriteHeader(http.StatusNoContent)
+			default:
+				writeError(w, "method not allowed", http.StatusMethodNotAllowed)
+			}
+		case "role":
+			switch r.Method {
+			case http.MethodPut:
+				h.SetUserRole(w, r, uid)
+			case http.MethodOptions:
+				w.WriteHeader(http.StatusNoContent)
+			default:
+				writeError(w, "method not allowed", http.StatusMethodNotAllowed)
+			}
+		default:
+			writeError(w, "not found", http.StatusNotFound)
+		}
+	})
 }
 
 // registerSubscriptionRoutes registers subscription resource routes
@@ -144,7 +755,7 @@ func registerSubscriptionRoutes(mux *http.ServeMux, h *Handler) {
 	mux.HandleFunc("/api/subscriptions", func(w http.ResponseWriter, r *http.Request) {
 		switch r.Method {
 		case http.MethodPost:
-			h.CreateSubscription(w, r)
+			h.signupGate.Wrap(h.idempotency.Wrap(h.CreateSubscription))(w, r)
 		case http.MethodGet:
 			h.ListSubscriptions(w, r)
 		case http.MethodOptions:
@@ -154,9 +765,50 @@ func registerSubscriptionRoutes(mux *http.ServeMux, h *Handler) {
 		}
 	})
 
+	mux.HandleFunc("/api/subscriptions/export", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			h.ExportSubscriptions(w, r)
+		case http.MethodOptions:
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			writeError(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	mux.HandleFunc("/api/subscriptions/import", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			h.signupGate.Wrap(h.idempotency.Wrap(h.ImportSubscriptions))(w, r)
+		case http.MethodOptions:
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			writeError(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	mux.HandleFunc("/api/subscriptions/by-name/", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			h.GetSubscriptionByName(w, r)
+		case http.MethodPut:
+			h.PutSubscriptionByName(w, r)
+		case http.MethodDelete:
+			h.DeleteSubscriptionByName(w, r)
+		case http.MethodOptions:
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			writeError(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
 	mux.HandleFunc("/api/subscriptions/", func(w http.ResponseWriter, r *http.Request) {
 		path := strings.TrimPrefix(r.URL.Path, "/api/subscriptions/")
-		if path == "" || strings.Contains(path, "/") {
+		if id, resource, ok := strings.Cut(path, "/"); ok && id != "" {
+			serveSubscriptionResource(w, r, h, id, resource)
+			return
+		}
+		if path == "" {
 			writeError(w, "invalid path", http.StatusBadRequest)
 			return
 		}
@@ -166,6 +818,8 @@ func registerSubscriptionRoutes(mux *http.ServeMux, h *Handler) {
 			h.GetSubscription(w, r)
 		case http.MethodPut:
 			h.UpdateSubscription(w, r)
+		case http.MethodPatch:
+			h.PatchSubscription(w, r)
 		case http.MethodDelete:
 			h.DeleteSubscription(w, r)
 		case http.MethodOptions:
@@ -176,6 +830,93 @@ func registerSubscriptionRoutes(mux *http.ServeMux, h *Handler) {
 	})
 }
 
+// serveSubscriptionResource dispatches /api/subscriptions/{id}/{resource}
+func serveSubscriptionResource(w http.ResponseWriter, r *http.Request, h *Handler, id, resource string) {
+	var post func(http.ResponseWriter, *http.Request, string)
+	switch resource {
+	case "reactivate", "enable":
+		post = h.ReactivateSubscription
+	case "test":
+		post = h.TestSubscription
+	case "rotate-secret":
+		post = h.RotateSecret
+	case "pause":
+		post = h.PauseSubscription
+	case "resume":
+		post = h.ResumeSubscription
+	case "verify":
+		post = h.VerifySubscription
+	}
+	if post != nil {
+		switch r.Method {
+		case http.MethodPost:
+			post(w, r, id)
+		case http.MethodOptions:
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			writeError(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+		return
+	}
+
+	var get func(http.ResponseWriter, *http.Request, string)
+	switch resource {
+	case "badge":
+		get = h.GetSubscriptionBadge
+	case "snippets":
+		get = h.GetSubscriptionSnippets
+	case "deliveries":
+		get = h.GetSubscriptionDeliveries
+	case "delivery-log":
+		get = h.GetSubscriptionDeliveryLog
+	default:
+		writeError(w, "invalid path", http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		get(w, r, id)
+	case http.MethodOptions:
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// registerDeliveryRoutes registers delivery API routes
+func registerDeliveryRoutes(mux *http.ServeMux, h *Handler) {
+	mux.HandleFunc("/api/deliveries/", func(w http.ResponseWriter, r *http.Request) {
+		parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/api/deliveries/"), "/")
+		if len(parts) != 2 || parts[0] == "" || parts[1] != "redeliver" {
+			writeError(w, "invalid path", http.StatusBadRequest)
+			return
+		}
+		switch r.Method {
+		case http.MethodPost:
+			h.RedeliverDelivery(w, r, parts[0])
+		case http.MethodOptions:
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			writeError(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+}
+
+// registerStatsRoutes registers the dashboard statistics route
+func registerStatsRoutes(mux *http.ServeMux, h *Handler) {
+	mux.HandleFunc("/api/stats", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			h.GetStats(w, r)
+		case http.MethodOptions:
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			writeError(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+}
+
 // registerBillingRoutes registers billing API routes (requires auth)
 func registerBillingRoutes(mux *http.ServeMux, h *BillingHandler) {
 	mux.HandleFunc("/api/billing/status", func(w http.ResponseWriter, r *http.Request) {
@@ -192,7 +933,18 @@ func registerBillingRoutes(mux *http.ServeMux, h *BillingHandler) {
 	mux.HandleFunc("/api/billing/create-checkout-session", func(w http.ResponseWriter, r *http.Request) {
 		switch r.Method {
 		case http.MethodPost:
-			h.CreateCheckoutSession(w, r)
+			h.idempotency.Wrap(h.CreateCheckoutSession)(w, r)
+		case http.MethodOptions:
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			writeError(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	mux.HandleFunc("/api/billing/create-portal-session", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			h.CreatePortalSession(w, r)
 		case http.MethodOptions:
 			w.WriteHeader(http.StatusNoContent)
 		default:
@@ -226,6 +978,20 @@ func registerStripeWebhookRoute(mux *http.ServeMux, h *BillingHandler) {
 	})
 }
 
+// registerTwilioWebhookRoute registers the Twilio status callback route (no auth required)
+func registerTwilioWebhookRoute(mux *http.ServeMux, h *TwilioHandler) {
+	mux.HandleFunc("/api/webhooks/twilio", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			h.StatusCallback(w, r)
+		case http.MethodOptions:
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			writeError(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+}
+
 // applyMiddlewareChain wraps a handler with the standard middleware stack
 func applyMiddlewareChain(h http.Handler) http.Handler {
 	return Chain(
@@ -236,8 +1002,9 @@ func applyMiddlewareChain(h http.Handler) http.Handler {
 	)(h)
 }
 
-// applyMiddlewareChainWithConfig wraps a handler with the middleware stack using security config
-func applyMiddlewareChainWithConfig(h http.Handler, securityCfg *config.SecurityConfig) http.Handler {
+// applyMiddlewareChainWithConfig wraps a handler with the middleware stack using security config.
+// Rate limits are counted in rateLimitStore when it is not nil.
+func applyMiddlewareChainWithConfig(h http.Handler, securityCfg *config.SecurityConfig, rateLimitStore ratelimit.Store) http.Handler {
 	middlewares := []Middleware{
 		RecoveryMiddleware,
 		LoggingMiddleware,
@@ -279,6 +1046,7 @@ func applyMiddlewareChainWithConfig(h http.Handler, securityCfg *config.Security
 					BurstSize:         subscriptionRPM,
 				},
 			},
+			Store: rateLimitStore,
 		}
 		middlewares = append(middlewares, NewEndpointRateLimitMiddleware(rateLimitConfig))
 	}
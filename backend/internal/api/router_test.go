@@ -10,7 +10,9 @@ import (
 	"time"
 
 	"github.com/otiai10/namazu/backend/internal/auth"
+	"github.com/otiai10/namazu/backend/internal/config"
 	"github.com/otiai10/namazu/backend/internal/delivery/webhook"
+	"github.com/otiai10/namazu/backend/internal/subscription"
 	"github.com/otiai10/namazu/backend/internal/user"
 )
 
@@ -229,6 +231,67 @@ func TestNewRouterWithConfig_ProtectedSubscriptionRoutes(t *testing.T) {
 			t.Errorf("expected status %d, got %d", http.StatusOK, rec.Code)
 		}
 	})
+
+	t.Run("POST /api/subscriptions/{id}/reactivate", func(t *testing.T) {
+		subRepo.subscriptions["sub-1"] = subscription.Subscription{ID: "sub-1", UserID: "test-uid", Status: subscription.StatusSuspended}
+
+		req := httptest.NewRequest(http.MethodPost, "/api/subscriptions/sub-1/reactivate", nil)
+		req.Header.Set("Authorization", "Bearer valid-token")
+		rec := httptest.NewRecorder()
+
+		router.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Errorf("expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+		}
+
+		req = httptest.NewRequest(http.MethodGet, "/api/subscriptions/sub-1/reactivate", nil)
+		req.Header.Set("Authorization", "Bearer valid-token")
+		rec = httptest.NewRecorder()
+
+		router.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusMethodNotAllowed {
+			t.Errorf("expected status %d, got %d", http.StatusMethodNotAllowed, rec.Code)
+		}
+	})
+
+	t.Run("POST /api/subscriptions/{id}/pause and /resume", func(t *testing.T) {
+		subRepo.subscriptions["sub-1"] = subscription.Subscription{ID: "sub-1", UserID: "test-uid", Status: subscription.StatusActive}
+
+		for _, action := range []string{"pause", "resume"} {
+			req := httptest.NewRequest(http.MethodPost, "/api/subscriptions/sub-1/"+action, nil)
+			req.Header.Set("Authorization", "Bearer valid-token")
+			rec := httptest.NewRecorder()
+
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != http.StatusOK {
+				t.Errorf("%s: expected status %d, got %d: %s", action, http.StatusOK, rec.Code, rec.Body.String())
+			}
+			if paused := subRepo.subscriptions["sub-1"].IsPaused(); paused != (action == "pause") {
+				t.Errorf("%s: paused = %v", action, paused)
+			}
+		}
+	})
+
+	t.Run("POST /api/subscriptions/{id}/enable", func(t *testing.T) {
+		subRepo.subscriptions["sub-1"] = subscription.Subscription{ID: "sub-1", UserID: "test-uid",
+			Status: subscription.StatusSuspended, StatusReason: subscription.ReasonFailing}
+
+		req := httptest.NewRequest(http.MethodPost, "/api/subscriptions/sub-1/enable", nil)
+		req.Header.Set("Authorization", "Bearer valid-token")
+		rec := httptest.NewRecorder()
+
+		router.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Errorf("expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+		}
+		if sub := subRepo.subscriptions["sub-1"]; sub.Status != subscription.StatusActive || sub.StatusReason != "" {
+			t.Errorf("status = %s (%s), want active", sub.Status, sub.StatusReason)
+		}
+	})
 }
 
 // routerMockChallenger implements Challenger for router tests
@@ -237,7 +300,7 @@ type routerMockChallenger struct {
 	called bool
 }
 
-func (m *routerMockChallenger) VerifyURL(ctx context.Context, url, secret string) webhook.ChallengeResult {
+func (m *routerMockChallenger) VerifyURL(ctx context.Context, url, secret string, headers map[string]string) webhook.ChallengeResult {
 	m.called = true
 	return m.result
 }
@@ -294,3 +357,81 @@ func TestNewRouterWithConfig_NilChallengerSkipsVerification(t *testing.T) {
 		t.Errorf("expected status %d without challenger, got %d", http.StatusCreated, rec.Code)
 	}
 }
+
+func TestNewRouterWithConfig_AdminRoutes(t *testing.T) {
+	newRouter := func(claims *auth.Claims) http.Handler {
+		return NewRouterWithConfig(RouterConfig{
+			SubscriptionRepo: newMockSubscriptionRepo(),
+			EventRepo:        newMockEventRepo(),
+			UserRepo:         newMockUserRepo(),
+			TokenVerifier:    &mockTokenVerifier{claims: claims},
+			EgressMeter:      newMockEgressMeter(),
+			Config:           &config.Config{Source: config.SourceConfig{Type: "p2pquake"}},
+		})
+	}
+
+	t.Run("requires auth", func(t *testing.T) {
+		router := newRouter(&auth.Claims{UID: "admin-uid", Admin: true})
+		req := httptest.NewRequest(http.MethodGet, "/api/admin/users/user-1/egress", nil)
+		rec := httptest.NewRecorder()
+
+		router.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("expected status %d, got %d", http.StatusUnauthorized, rec.Code)
+		}
+	})
+
+	t.Run("rejects non-admin", func(t *testing.T) {
+		router := newRouter(&auth.Claims{UID: "test-uid"})
+		req := httptest.NewRequest(http.MethodGet, "/api/admin/users/user-1/egress", nil)
+		req.Header.Set("Authorization", "Bearer valid-token")
+		rec := httptest.NewRecorder()
+
+		router.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusForbidden {
+			t.Errorf("expected status %d, got %d", http.StatusForbidden, rec.Code)
+		}
+	})
+
+	t.Run("allows admin", func(t *testing.T) {
+		router := newRouter(&auth.Claims{UID: "admin-uid", Admin: true})
+		req := httptest.NewRequest(http.MethodPut, "/api/admin/users/user-1/egress",
+			bytes.NewBufferString(`{"monthly_bytes": 1024}`))
+		req.Header.Set("Authorization", "Bearer valid-token")
+		rec := httptest.NewRecorder()
+
+		router.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Errorf("expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+		}
+	})
+
+	t.Run("GET /api/admin/config", func(t *testing.T) {
+		router := newRouter(&auth.Claims{UID: "admin-uid", Admin: true})
+		req := httptest.NewRequest(http.MethodGet, "/api/admin/config", nil)
+		req.Header.Set("Authorization", "Bearer valid-token")
+		rec := httptest.NewRecorder()
+
+		router.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Errorf("expected status %d, got %d", http.StatusOK, rec.Code)
+		}
+	})
+
+	t.Run("GET /api/me/usage", func(t *testing.T) {
+		router := newRouter(&auth.Claims{UID: "test-uid"})
+		req := httptest.NewRequest(http.MethodGet, "/api/me/usage", nil)
+		req.Header.Set("Authorization", "Bearer valid-token")
+		rec := httptest.NewRecorder()
+
+		router.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Errorf("expected status %d, got %d", http.StatusOK, rec.Code)
+		}
+	})
+}
@@ -0,0 +1,31 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/otiai10/namazu/backend/internal/delivery/schema"
+)
+
+// schemasPrefix is the path of the published payload schemas
+const schemasPrefix = "/api/schemas/"
+
+// GetSchema handles GET /api/schemas/{name}.json
+// It serves the JSON Schema of a payload namazu generates, e.g.
+// namazu.v1.json, for receivers to generate types from.
+func (h *Handler) GetSchema(w http.ResponseWriter, r *http.Request) {
+	name, ok := strings.CutSuffix(strings.TrimPrefix(r.URL.Path, schemasPrefix), ".json")
+	if !ok {
+		writeError(w, "schema not found", http.StatusNotFound)
+		return
+	}
+	doc, ok := schema.Document(name)
+	if !ok {
+		writeError(w, "schema not found", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/schema+json")
+	w.Header().Set("Cache-Control", "public, max-age=3600")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(doc)
+}
@@ -0,0 +1,46 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRouter_Schemas(t *testing.T) {
+	router := NewRouterWithConfig(RouterConfig{
+		SubscriptionRepo: newMockSubscriptionRepo(),
+		EventRepo:        newMockEventRepo(),
+	})
+	request := func(method, path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(method, path, nil))
+		return rec
+	}
+
+	for _, name := range []string{"namazu.v1", "namazu.service_notice", "namazu.digest"} {
+		rec := request(http.MethodGet, "/api/schemas/"+name+".json")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: expected status %d, got %d: %s", name, http.StatusOK, rec.Code, rec.Body.String())
+		}
+		if got := rec.Header().Get("Content-Type"); got != "application/schema+json" {
+			t.Errorf("%s: Content-Type = %q", name, got)
+		}
+		var doc map[string]any
+		if err := json.Unmarshal(rec.Body.Bytes(), &doc); err != nil {
+			t.Fatalf("%s: failed to decode schema: %v", name, err)
+		}
+		if doc["title"] != name || doc["$schema"] != "https://json-schema.org/draft/2020-12/schema" {
+			t.Errorf("%s: unexpected schema %v", name, doc)
+		}
+	}
+
+	for _, path := range []string{"/api/schemas/unknown.json", "/api/schemas/namazu.v1", "/api/schemas/"} {
+		if rec := request(http.MethodGet, path); rec.Code != http.StatusNotFound {
+			t.Errorf("GET %s: expected status %d, got %d", path, http.StatusNotFound, rec.Code)
+		}
+	}
+	if rec := request(http.MethodPost, "/api/schemas/namazu.v1.json"); rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST: expected status %d, got %d", http.StatusMethodNotAllowed, rec.Code)
+	}
+}
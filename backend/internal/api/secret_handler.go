@@ -0,0 +1,109 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/otiai10/namazu/backend/internal/apierr"
+	"github.com/otiai10/namazu/backend/internal/audit"
+	"github.com/otiai10/namazu/backend/internal/delivery/webhook"
+)
+
+// DefaultSecretGracePeriod is how long the replaced secret keeps signing
+// deliveries after a rotation, unless the request says otherwise
+const DefaultSecretGracePeriod = 24 * time.Hour
+
+// MaxSecretGracePeriod bounds the grace period of a rotation
+const MaxSecretGracePeriod = 7 * 24 * time.Hour
+
+// RotateSecretRequest is the optional body of a secret rotation
+type RotateSecretRequest struct {
+	// GracePeriodSeconds is how long the old secret keeps signing deliveries
+	// (X-Signature-256-Previous). nil means DefaultSecretGracePeriod; 0 drops it at once.
+	GracePeriodSeconds *int `json:"grace_period_seconds,omitempty"`
+}
+
+// RotateSecretResponse carries the new secret. It is the only time the
+// secret is returned in full.
+type RotateSecretResponse struct {
+	Secret                  string     `json:"secret"`
+	SecretPrefix            string     `json:"secret_prefix"`
+	PreviousSecretExpiresAt *time.Time `json:"previous_secret_expires_at,omitempty"` // nil if the old secret was dropped at once
+}
+
+// RotateSecret handles POST /api/subscriptions/{id}/rotate-secret
+// Generates a new webhook secret. During the grace period deliveries are
+// signed with both secrets, so receivers can switch over without dropping
+// events; a rotation within the grace period of an earlier one drops the
+// secret before last.
+func (h *Handler) RotateSecret(w http.ResponseWriter, r *http.Request, id string) {
+	var req RotateSecretRequest
+	err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<10)).Decode(&req)
+	if err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	grace := DefaultSecretGracePeriod
+	if req.GracePeriodSeconds != nil {
+		grace = time.Duration(*req.GracePeriodSeconds) * time.Second
+		if grace < 0 || grace > MaxSecretGracePeriod {
+			writeErrorCode(w, apierr.ValidationFailed, "grace_period_seconds must be between 0 and 604800", http.StatusBadRequest)
+			return
+		}
+	}
+
+	existing, forbidden, err := h.checkOwnership(r.Context(), id)
+	if err != nil {
+		writeError(w, "failed to get subscription", http.StatusInternalServerError)
+		return
+	}
+	if existing == nil {
+		writeError(w, "subscription not found", http.StatusNotFound)
+		return
+	}
+	if forbidden {
+		writeErrorCode(w, apierr.NotOwner, "forbidden", http.StatusForbidden)
+		return
+	}
+	if existing.Delivery.Type != "webhook" {
+		writeError(w, "only webhook subscriptions have a secret", http.StatusBadRequest)
+		return
+	}
+
+	if !checkPreconditions(w, r, existing) {
+		return
+	}
+
+	secret, err := webhook.GenerateSecret()
+	if err != nil {
+		writeError(w, "failed to generate webhook secret", http.StatusInternalServerError)
+		return
+	}
+
+	sub := *existing
+	sub.Delivery.PreviousSecret = ""
+	sub.Delivery.PreviousSecretExpiresAt = nil
+	if grace > 0 && existing.Delivery.Secret != "" {
+		expiresAt := time.Now().UTC().Add(grace)
+		sub.Delivery.PreviousSecret = existing.Delivery.Secret
+		sub.Delivery.PreviousSecretExpiresAt = &expiresAt
+	}
+	sub.Delivery.Secret = secret
+	sub.Delivery.SecretPrefix = webhook.SecretPrefixFromSecret(secret)
+	sub, err = saveSubscription(r.Context(), h.subscriptionRepo, id, sub)
+	if err != nil {
+		writeSaveError(w, err)
+		return
+	}
+	h.auditLog.Record(r.Context(), auditEntry(r, audit.ActionSecretRotate, id, map[string]string{"grace_period": grace.String()}))
+
+	w.Header().Set("ETag", subscriptionETag(sub))
+	writeJSON(w, RotateSecretResponse{
+		Secret:                  secret,
+		SecretPrefix:            sub.Delivery.SecretPrefix,
+		PreviousSecretExpiresAt: sub.Delivery.PreviousSecretExpiresAt,
+	}, http.StatusOK)
+}
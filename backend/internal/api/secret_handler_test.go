@@ -0,0 +1,142 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/otiai10/namazu/backend/internal/auth"
+	"github.com/otiai10/namazu/backend/internal/subscription"
+)
+
+func rotateSecret(handler *Handler, uid, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/api/subscriptions/sub-1/rotate-secret", bytes.NewBufferString(body))
+	req = req.WithContext(auth.WithClaims(req.Context(), &auth.Claims{UID: uid}))
+	rec := httptest.NewRecorder()
+	handler.RotateSecret(rec, req, "sub-1")
+	return rec
+}
+
+func TestRotateSecret(t *testing.T) {
+	subRepo := newMockSubscriptionRepo()
+	subRepo.subscriptions["sub-1"] = subscription.Subscription{
+		ID:       "sub-1",
+		UserID:   "user-1",
+		Name:     "Hook",
+		Delivery: subscription.DeliveryConfig{Type: "webhook", URL: "https://example.com/hook", Secret: "nmz_original", SignVersion: "v0"},
+	}
+	handler := NewHandler(subRepo, newMockEventRepo())
+
+	rec := rotateSecret(handler, "user-1", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+	}
+	var resp RotateSecretResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	stored := subRepo.subscriptions["sub-1"]
+	if !strings.HasPrefix(resp.Secret, "nmz_") || resp.Secret == "nmz_original" || stored.Delivery.Secret != resp.Secret {
+		t.Errorf("expected a new stored secret, got %q (stored %q)", resp.Secret, stored.Delivery.Secret)
+	}
+	if stored.Delivery.SecretPrefix != resp.SecretPrefix || !strings.HasPrefix(resp.Secret, resp.SecretPrefix) {
+		t.Errorf("SecretPrefix = %q, want the prefix of %q", resp.SecretPrefix, resp.Secret)
+	}
+	if stored.Delivery.PreviousSecret != "nmz_original" || resp.PreviousSecretExpiresAt == nil {
+		t.Fatalf("expected the original secret to keep signing, got %+v", stored.Delivery)
+	}
+	if until := time.Until(*resp.PreviousSecretExpiresAt); until < DefaultSecretGracePeriod-time.Minute || until > DefaultSecretGracePeriod {
+		t.Errorf("expected the default grace period, got %v", until)
+	}
+	if rec.Header().Get("ETag") != subscriptionETag(stored) {
+		t.Error("expected the ETag of the rotated subscription")
+	}
+
+	// Rotating again drops the original secret
+	first := resp.Secret
+	rec = rotateSecret(handler, "user-1", `{"grace_period_seconds": 3600}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+	}
+	stored = subRepo.subscriptions["sub-1"]
+	if stored.Delivery.PreviousSecret != first {
+		t.Errorf("PreviousSecret = %q, want the secret of the first rotation", stored.Delivery.PreviousSecret)
+	}
+
+	// A zero grace period stops signing with the old secret at once
+	rec = rotateSecret(handler, "user-1", `{"grace_period_seconds": 0}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+	}
+	stored = subRepo.subscriptions["sub-1"]
+	if stored.Delivery.PreviousSecret != "" || stored.Delivery.PreviousSecretExpiresAt != nil {
+		t.Errorf("expected no previous secret, got %+v", stored.Delivery)
+	}
+	if !strings.Contains(rec.Body.String(), `"secret"`) || strings.Contains(rec.Body.String(), "previous_secret_expires_at") {
+		t.Errorf("unexpected response: %s", rec.Body.String())
+	}
+}
+
+func TestRotateSecret_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		sub      *subscription.Subscription
+		uid      string
+		body     string
+		wantCode int
+	}{
+		{name: "missing", uid: "user-1", wantCode: http.StatusNotFound},
+		{
+			name:     "other user's subscription",
+			sub:      &subscription.Subscription{UserID: "user-2", Delivery: subscription.DeliveryConfig{Type: "webhook", Secret: "nmz_x"}},
+			uid:      "user-1",
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "no secret to rotate",
+			sub:      &subscription.Subscription{UserID: "user-1", Delivery: subscription.DeliveryConfig{Type: subscription.DeliveryTypeWebPush}},
+			uid:      "user-1",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "grace period too long",
+			sub:      &subscription.Subscription{UserID: "user-1", Delivery: subscription.DeliveryConfig{Type: "webhook", Secret: "nmz_x"}},
+			uid:      "user-1",
+			body:     `{"grace_period_seconds": 604801}`,
+			wantCode: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subRepo := newMockSubscriptionRepo()
+			if tt.sub != nil {
+				tt.sub.ID = "sub-1"
+				subRepo.subscriptions["sub-1"] = *tt.sub
+			}
+			rec := rotateSecret(NewHandler(subRepo, newMockEventRepo()), tt.uid, tt.body)
+			if rec.Code != tt.wantCode {
+				t.Errorf("expected status %d, got %d: %s", tt.wantCode, rec.Code, rec.Body.String())
+			}
+			if tt.sub != nil && subRepo.subscriptions["sub-1"].Delivery.Secret != tt.sub.Delivery.Secret {
+				t.Error("expected the secret to be unchanged")
+			}
+		})
+	}
+}
+
+func TestSubscriptionToResponse_MasksPreviousSecret(t *testing.T) {
+	expiresAt := time.Now().Add(time.Hour)
+	resp := subscriptionToResponse(subscription.Subscription{Delivery: subscription.DeliveryConfig{
+		Type:                    "webhook",
+		Secret:                  "nmz_0123456789abcdef",
+		PreviousSecret:          "nmz_fedcba9876543210",
+		PreviousSecretExpiresAt: &expiresAt,
+	}})
+	if resp.Delivery.PreviousSecret != "****3210" || resp.Delivery.PreviousSecretExpiresAt == nil {
+		t.Errorf("expected a masked previous secret with its expiry, got %+v", resp.Delivery)
+	}
+}
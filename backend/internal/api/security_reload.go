@@ -0,0 +1,62 @@
+package api
+
+import (
+	"net/http"
+	"sync"
+	"sync/atomic"
+
+	"github.com/otiai10/namazu/backend/internal/config"
+	"github.com/otiai10/namazu/backend/internal/ratelimit"
+)
+
+// SecurityReloader lets the CORS and rate limit settings of a router change
+// while it serves, as on a config reload. Set it as RouterConfig.SecurityReloader
+// and call Apply with the new settings.
+type SecurityReloader struct {
+	mu    sync.Mutex
+	next  http.Handler
+	store ratelimit.Store
+	chain atomic.Pointer[http.Handler]
+}
+
+// NewSecurityReloader returns a SecurityReloader to pass to NewRouterWithConfig
+func NewSecurityReloader() *SecurityReloader {
+	return &SecurityReloader{}
+}
+
+// wrap serves next behind the middleware chain of securityCfg
+func (s *SecurityReloader) wrap(next http.Handler, securityCfg *config.SecurityConfig, store ratelimit.Store) http.Handler {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.next, s.store = next, store
+	s.swap(securityCfg)
+	return s
+}
+
+// Apply rebuilds the middleware chain from securityCfg. Requests in progress
+// finish under the old settings. Rate limit counts kept in memory start over;
+// those in a shared store are kept.
+func (s *SecurityReloader) Apply(securityCfg *config.SecurityConfig) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.next == nil {
+		return
+	}
+	s.swap(securityCfg)
+}
+
+// swap builds and installs the chain; s.mu must be held
+func (s *SecurityReloader) swap(securityCfg *config.SecurityConfig) {
+	chain := applyMiddlewareChainWithConfig(s.next, securityCfg, s.store)
+	s.chain.Store(&chain)
+}
+
+// ServeHTTP serves the request with the current middleware chain
+func (s *SecurityReloader) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	chain := s.chain.Load()
+	if chain == nil {
+		writeError(w, "router not configured", http.StatusServiceUnavailable)
+		return
+	}
+	(*chain).ServeHTTP(w, r)
+}
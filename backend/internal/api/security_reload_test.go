@@ -0,0 +1,44 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/otiai10/namazu/backend/internal/config"
+)
+
+func TestSecurityReloader_Apply(t *testing.T) {
+	reloader := NewSecurityReloader()
+	router := NewRouterWithConfig(RouterConfig{
+		SubscriptionRepo: newMockSubscriptionRepo(),
+		EventRepo:        newMockEventRepo(),
+		SecurityConfig:   &config.SecurityConfig{CORSAllowedOrigins: "https://old.example.com"},
+		SecurityReloader: reloader,
+	})
+
+	get := func(origin string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/health", nil)
+		req.Header.Set("Origin", origin)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if got := get("https://old.example.com").Header().Get("Access-Control-Allow-Origin"); got != "https://old.example.com" {
+		t.Fatalf("Access-Control-Allow-Origin = %q before the reload", got)
+	}
+
+	reloader.Apply(&config.SecurityConfig{
+		CORSAllowedOrigins:         "https://new.example.com",
+		RateLimitEnabled:           true,
+		RateLimitRequestsPerMinute: 1,
+	})
+
+	if got := get("https://old.example.com").Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Errorf("Access-Control-Allow-Origin = %q for a removed origin", got)
+	}
+	if got := get("https://new.example.com").Code; got != http.StatusTooManyRequests {
+		t.Errorf("status = %d after the limit was lowered, want 429", got)
+	}
+}
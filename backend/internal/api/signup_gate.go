@@ -0,0 +1,88 @@
+package api
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/otiai10/namazu/backend/internal/apierr"
+	"github.com/otiai10/namazu/backend/internal/auth"
+	"github.com/otiai10/namazu/backend/internal/user"
+)
+
+// SignupGate holds back subscription creation until the user has verified
+// their email address and accepted the current terms of service.
+// A nil gate lets every request through.
+type SignupGate struct {
+	userRepo             user.Repository
+	requireEmailVerified bool
+	termsVersion         string // Empty when there are no terms to accept
+}
+
+// NewSignupGate creates a SignupGate. termsVersion is the version of the terms
+// of service users must have accepted, or "" to require none.
+func NewSignupGate(userRepo user.Repository, requireEmailVerified bool, termsVersion string) *SignupGate {
+	return &SignupGate{
+		userRepo:             userRepo,
+		requireEmailVerified: requireEmailVerified,
+		termsVersion:         termsVersion,
+	}
+}
+
+// TermsVersion returns the version of the terms of service users must accept,
+// or "" if there is none
+func (g *SignupGate) TermsVersion() string {
+	if g == nil {
+		return ""
+	}
+	return g.termsVersion
+}
+
+// termsAccepted reports whether u accepted the current terms of service
+func (g *SignupGate) termsAccepted(u *user.User) bool {
+	if g.TermsVersion() == "" {
+		return true
+	}
+	return u != nil && u.TermsVersion == g.termsVersion
+}
+
+// Wrap returns next guarded by the gate. Requests without claims (auth
+// disabled) are let through. Rejected requests get 403 with the reason, so
+// clients can send the user to verify their email or accept the terms.
+func (g *SignupGate) Wrap(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		claims, ok := auth.GetClaims(r.Context())
+		if g == nil || !ok {
+			next(w, r)
+			return
+		}
+		code, msg, err := g.check(r.Context(), claims)
+		if err != nil {
+			writeError(w, "failed to get user", http.StatusInternalServerError)
+			return
+		}
+		if code != "" {
+			writeErrorCode(w, code, msg, http.StatusForbidden)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// check returns the error code and message of why the user cannot create
+// subscriptions yet, or empty strings
+func (g *SignupGate) check(ctx context.Context, claims *auth.Claims) (string, string, error) {
+	if g.requireEmailVerified && !claims.EmailVerified {
+		return apierr.EmailNotVerified, "email address is not verified", nil
+	}
+	if g.termsVersion == "" {
+		return "", "", nil
+	}
+	u, err := g.userRepo.GetByUID(ctx, claims.UID)
+	if err != nil {
+		return "", "", err
+	}
+	if !g.termsAccepted(u) {
+		return apierr.TermsNotAccepted, "terms of service must be accepted", nil
+	}
+	return "", "", nil
+}
@@ -0,0 +1,109 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/otiai10/namazu/backend/internal/auth"
+	"github.com/otiai10/namazu/backend/internal/config"
+	"github.com/otiai10/namazu/backend/internal/user"
+)
+
+func TestSignupGate(t *testing.T) {
+	newRouter := func(claims *auth.Claims, users *mockUserRepo, authCfg *config.AuthConfig) http.Handler {
+		return NewRouterWithConfig(RouterConfig{
+			SubscriptionRepo: newMockSubscriptionRepo(),
+			EventRepo:        newMockEventRepo(),
+			UserRepo:         users,
+			TokenVerifier:    &mockTokenVerifier{claims: claims},
+			AuthConfig:       authCfg,
+		})
+	}
+	do := func(router http.Handler, method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer valid-token")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+	const create = `{"name": "Hook", "delivery": {"type": "webhook", "url": "https://example.com/hook"}}`
+	profile := func(t *testing.T, rec *httptest.ResponseRecorder) ProfileResponse {
+		t.Helper()
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+		}
+		var resp ProfileResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		return resp
+	}
+
+	t.Run("requires a verified email", func(t *testing.T) {
+		router := newRouter(&auth.Claims{UID: "user-1"}, newMockUserRepo(), &config.AuthConfig{RequireEmailVerified: true})
+		rec := do(router, http.MethodPost, "/api/subscriptions", create)
+		if rec.Code != http.StatusForbidden || !strings.Contains(rec.Body.String(), "email address is not verified") {
+			t.Errorf("expected 403 for an unverified email, got %d: %s", rec.Code, rec.Body.String())
+		}
+		if p := profile(t, do(router, http.MethodGet, "/api/me", "")); p.EmailVerified || !p.TermsAccepted {
+			t.Errorf("profile = %+v, want unverified with no terms to accept", p)
+		}
+
+		rec = do(router, http.MethodPut, "/api/subscriptions/by-name/Hook", create)
+		if rec.Code != http.StatusForbidden || !strings.Contains(rec.Body.String(), "email address is not verified") {
+			t.Errorf("expected 403 for a by-name create with an unverified email, got %d: %s", rec.Code, rec.Body.String())
+		}
+
+		router = newRouter(&auth.Claims{UID: "user-1", EmailVerified: true}, newMockUserRepo(), &config.AuthConfig{RequireEmailVerified: true})
+		if rec := do(router, http.MethodPost, "/api/subscriptions", create); rec.Code != http.StatusCreated {
+			t.Errorf("expected status %d, got %d: %s", http.StatusCreated, rec.Code, rec.Body.String())
+		}
+		if rec := do(router, http.MethodPut, "/api/subscriptions/by-name/Other", strings.Replace(create, "Hook", "Other", 1)); rec.Code != http.StatusCreated {
+			t.Errorf("expected status %d for a by-name create, got %d: %s", http.StatusCreated, rec.Code, rec.Body.String())
+		}
+	})
+
+	t.Run("requires the current terms", func(t *testing.T) {
+		users := newMockUserRepo()
+		users.Create(context.Background(), user.User{UID: "user-1", TermsVersion: "2025-01"})
+		router := newRouter(&auth.Claims{UID: "user-1", EmailVerified: true}, users, &config.AuthConfig{TermsVersion: "2026-04"})
+
+		rec := do(router, http.MethodPost, "/api/subscriptions/import", `{"subscriptions": []}`)
+		if rec.Code != http.StatusForbidden || !strings.Contains(rec.Body.String(), "terms of service must be accepted") {
+			t.Errorf("expected 403 before accepting the terms, got %d: %s", rec.Code, rec.Body.String())
+		}
+		rec = do(router, http.MethodPut, "/api/subscriptions/by-name/Hook", create)
+		if rec.Code != http.StatusForbidden || !strings.Contains(rec.Body.String(), "terms of service must be accepted") {
+			t.Errorf("expected 403 for a by-name create before accepting the terms, got %d: %s", rec.Code, rec.Body.String())
+		}
+		if p := profile(t, do(router, http.MethodGet, "/api/me", "")); p.TermsAccepted || p.CurrentTermsVersion != "2026-04" || !p.EmailVerified {
+			t.Errorf("profile = %+v, want terms 2026-04 not yet accepted", p)
+		}
+
+		if rec := do(router, http.MethodPost, "/api/me/accept-terms", `{"version": "2025-01"}`); rec.Code != http.StatusConflict {
+			t.Errorf("expected status %d for outdated terms, got %d", http.StatusConflict, rec.Code)
+		}
+		p := profile(t, do(router, http.MethodPost, "/api/me/accept-terms", `{"version": "2026-04"}`))
+		if !p.TermsAccepted || p.TermsVersion != "2026-04" || p.TermsAcceptedAt == nil {
+			t.Errorf("profile = %+v, want terms 2026-04 accepted", p)
+		}
+
+		if rec := do(router, http.MethodPost, "/api/subscriptions", create); rec.Code != http.StatusCreated {
+			t.Errorf("expected status %d after accepting the terms, got %d: %s", http.StatusCreated, rec.Code, rec.Body.String())
+		}
+	})
+
+	t.Run("nothing to accept without terms", func(t *testing.T) {
+		router := newRouter(&auth.Claims{UID: "user-1"}, newMockUserRepo(), nil)
+		if rec := do(router, http.MethodPost, "/api/subscriptions", create); rec.Code != http.StatusCreated {
+			t.Errorf("expected status %d, got %d: %s", http.StatusCreated, rec.Code, rec.Body.String())
+		}
+		if rec := do(router, http.MethodPost, "/api/me/accept-terms", `{"version": "2026-04"}`); rec.Code != http.StatusNotImplemented {
+			t.Errorf("expected status %d, got %d", http.StatusNotImplemented, rec.Code)
+		}
+	})
+}
@@ -0,0 +1,55 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/otiai10/namazu/backend/internal/apierr"
+	"github.com/otiai10/namazu/backend/internal/snippet"
+)
+
+// GetSubscriptionSnippets handles GET /api/subscriptions/{id}/snippets?lang=go|node|python
+// Returns receiver code that verifies this subscription's signature scheme and answers
+// the URL verification challenge. The secret itself is never included.
+func (h *Handler) GetSubscriptionSnippets(w http.ResponseWriter, r *http.Request, id string) {
+	lang := r.URL.Query().Get("lang")
+	if lang == "" {
+		writeErrorCode(w, apierr.ValidationFailed, "lang is required (supported: "+strings.Join(snippet.Languages, ", ")+")", http.StatusBadRequest)
+		return
+	}
+
+	sub, forbidden, err := h.checkOwnership(r.Context(), id)
+	if err != nil {
+		writeError(w, "failed to get subscription", http.StatusInternalServerError)
+		return
+	}
+	if sub == nil {
+		writeError(w, "subscription not found", http.StatusNotFound)
+		return
+	}
+	if forbidden {
+		writeErrorCode(w, apierr.NotOwner, "forbidden", http.StatusForbidden)
+		return
+	}
+
+	code, err := snippet.Render(lang, snippet.Params{
+		SubscriptionID: sub.ID,
+		Name:           sub.Name,
+		SignVersion:    sub.Delivery.SignVersion,
+		SecretPrefix:   sub.Delivery.SecretPrefix,
+	})
+	if errors.Is(err, snippet.ErrUnsupportedLanguage) {
+		writeErrorCode(w, apierr.ValidationFailed, "unsupported lang (supported: "+strings.Join(snippet.Languages, ", ")+")", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		writeError(w, "failed to generate snippet", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Content-Disposition", `inline; filename="`+snippet.Filename(lang)+`"`)
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte(code))
+}
@@ -0,0 +1,86 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/otiai10/namazu/backend/internal/auth"
+	"github.com/otiai10/namazu/backend/internal/subscription"
+)
+
+func TestGetSubscriptionSnippets(t *testing.T) {
+	subRepo := newMockSubscriptionRepo()
+	subRepo.subscriptions["snippet-sub"] = subscription.Subscription{
+		ID:     "snippet-sub",
+		UserID: "owner-uid",
+		Name:   "Prod Alerts",
+		Delivery: subscription.DeliveryConfig{
+			Type:         "webhook",
+			URL:          "https://example.com/hook",
+			Secret:       "abcd1234-full-secret",
+			SecretPrefix: "abcd1234",
+			SignVersion:  "v0",
+		},
+	}
+	router := NewRouter(NewHandler(subRepo, newMockEventRepo()))
+
+	request := func(path, uid string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if uid != "" {
+			req = req.WithContext(auth.WithClaims(req.Context(), &auth.Claims{UID: uid}))
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("returns code for the subscription's sign version", func(t *testing.T) {
+		for _, lang := range []string{"go", "node", "python"} {
+			rec := request("/api/subscriptions/snippet-sub/snippets?lang="+lang, "owner-uid")
+			if rec.Code != http.StatusOK {
+				t.Fatalf("%s: expected status %d, got %d: %s", lang, http.StatusOK, rec.Code, rec.Body.String())
+			}
+			if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+				t.Errorf("%s: unexpected content type %q", lang, ct)
+			}
+			body := rec.Body.String()
+			if !strings.Contains(body, `"v0="`) || !strings.Contains(body, "Prod Alerts") {
+				t.Errorf("%s: expected v0 verification for the subscription", lang)
+			}
+			if strings.Contains(body, "abcd1234-full-secret") {
+				t.Errorf("%s: snippet must not contain the secret", lang)
+			}
+		}
+	})
+
+	t.Run("requires a supported lang", func(t *testing.T) {
+		for _, path := range []string{
+			"/api/subscriptions/snippet-sub/snippets",
+			"/api/subscriptions/snippet-sub/snippets?lang=cobol",
+		} {
+			if rec := request(path, "owner-uid"); rec.Code != http.StatusBadRequest {
+				t.Errorf("%s: expected status %d, got %d", path, http.StatusBadRequest, rec.Code)
+			}
+		}
+	})
+
+	t.Run("rejects other users", func(t *testing.T) {
+		if rec := request("/api/subscriptions/snippet-sub/snippets?lang=go", "other-uid"); rec.Code != http.StatusForbidden {
+			t.Errorf("expected status %d, got %d", http.StatusForbidden, rec.Code)
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		if rec := request("/api/subscriptions/missing/snippets?lang=go", "owner-uid"); rec.Code != http.StatusNotFound {
+			t.Errorf("expected status %d, got %d", http.StatusNotFound, rec.Code)
+		}
+	})
+
+	t.Run("unknown sub-resource", func(t *testing.T) {
+		if rec := request("/api/subscriptions/snippet-sub/unknown", "owner-uid"); rec.Code != http.StatusBadRequest {
+			t.Errorf("expected status %d, got %d", http.StatusBadRequest, rec.Code)
+		}
+	})
+}
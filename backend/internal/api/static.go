@@ -64,14 +64,15 @@ func (s *StaticFileServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 }
 
 // WithStaticFiles wraps an API router with static file serving.
-// API routes (starting with /api or /health) are handled by the apiHandler,
-// all other routes fall through to the static file server.
-func WithStaticFiles(apiHandler http.Handler, staticServer *StaticFileServer) http.Handler {
+// API routes (starting with /api or /health, and /readyz) are handled by the apiHandler,
+// all other routes fall through to the static file server (a *StaticFileServer
+// or any other UI handler).
+func WithStaticFiles(apiHandler http.Handler, staticServer http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		path := r.URL.Path
 
 		// API routes
-		if strings.HasPrefix(path, "/api") || strings.HasPrefix(path, "/health") {
+		if strings.HasPrefix(path, "/api") || strings.HasPrefix(path, "/health") || path == "/readyz" {
 			apiHandler.ServeHTTP(w, r)
 			return
 		}
@@ -0,0 +1,239 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/otiai10/namazu/backend/internal/app"
+	"github.com/otiai10/namazu/backend/internal/auth"
+	"github.com/otiai10/namazu/backend/internal/source/p2pquake"
+	"github.com/otiai10/namazu/backend/internal/store"
+	"github.com/otiai10/namazu/backend/internal/subscription"
+	"github.com/otiai10/namazu/backend/internal/tenant"
+)
+
+// statsScales are the buckets of the event counts, by maximum scale
+var statsScales = []int{
+	p2pquake.Scale1, p2pquake.Scale2, p2pquake.Scale3, p2pquake.Scale4, p2pquake.Scale5Weak,
+	p2pquake.Scale5Strong, p2pquake.Scale6Weak, p2pquake.Scale6Strong, p2pquake.Scale7,
+}
+
+// statsWindows are the periods of the event counts
+var statsWindows = map[string]time.Duration{
+	"24h": 24 * time.Hour,
+	"7d":  7 * 24 * time.Hour,
+}
+
+// statsDeliveryWindow is the period of the delivery counts
+const statsDeliveryWindow = 7 * 24 * time.Hour
+
+// statsCacheTTL is how long event counts are reused: they are the same for every
+// user and cost one count aggregation per scale
+const statsCacheTTL = time.Minute
+
+// InstanceStats reports the counters and source status of this instance
+type InstanceStats interface {
+	Stats() app.Stats
+}
+
+// StatsResponse is the body of GET /api/stats
+type StatsResponse struct {
+	GeneratedAt time.Time              `json:"generated_at"`
+	Events      map[string]EventCounts `json:"events"` // By window: 24h and 7d
+	Deliveries  *DeliveryStats         `json:"deliveries,omitempty"`
+	Instance    *InstanceStatus        `json:"instance,omitempty"`
+}
+
+// EventCounts counts the events of a window
+type EventCounts struct {
+	Total   int            `json:"total"`
+	ByScale map[string]int `json:"by_scale"` // By maximum scale (10 = 震度1 … 70 = 震度7); "unknown" for events without one
+}
+
+// DeliveryStats counts the deliveries to the caller's subscriptions over the last 7 days
+type DeliveryStats struct {
+	Window        string                      `json:"window"`
+	Delivered     int                         `json:"delivered"`
+	Failed        int                         `json:"failed"`
+	SuccessRate   *float64                    `json:"success_rate"` // null without deliveries
+	Subscriptions []SubscriptionDeliveryStats `json:"subscriptions"`
+}
+
+// SubscriptionDeliveryStats counts the deliveries to one subscription
+type SubscriptionDeliveryStats struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Delivered int    `json:"delivered"`
+	Failed    int    `json:"failed"`
+}
+
+// InstanceStatus describes the instance serving the request, since it started
+type InstanceStatus struct {
+	StartedAt           time.Time     `json:"started_at"`
+	UptimeSeconds       int64         `json:"uptime_seconds"`
+	Source              *SourceStatus `json:"source,omitempty"` // Omitted for sources that poll
+	EventsReceived      int64         `json:"events_received"`
+	DeliveriesSucceeded int64         `json:"deliveries_succeeded"`
+	DeliveriesFailed    int64         `json:"deliveries_failed"`
+}
+
+// SourceStatus is the connection of the event source (the P2P地震情報 WebSocket)
+type SourceStatus struct {
+	Connected bool       `json:"connected"`
+	Since     *time.Time `json:"since,omitempty"` // Omitted if it never connected
+}
+
+// eventStatsCache keeps the event counts for statsCacheTTL
+type eventStatsCache struct {
+	mu        sync.Mutex
+	counts    map[string]EventCounts
+	expiresAt time.Time
+}
+
+// SetStats enables the instance section of /api/stats
+func (h *Handler) SetStats(s InstanceStats) {
+	h.stats = s
+}
+
+// GetStats handles GET /api/stats
+// Returns event counts, the caller's delivery counts (when delivery history is
+// enabled) and the status of this instance (when configured).
+func (h *Handler) GetStats(w http.ResponseWriter, r *http.Request) {
+	now := time.Now().UTC()
+	resp := StatsResponse{GeneratedAt: now}
+
+	events, err := h.eventCounts(r.Context(), now)
+	if err != nil {
+		writeError(w, "failed to count events", http.StatusInternalServerError)
+		return
+	}
+	resp.Events = events
+
+	if h.deliveryRepo != nil {
+		deliveries, err := h.deliveryStats(r, now)
+		if err != nil {
+			writeError(w, "failed to count deliveries", http.StatusInternalServerError)
+			return
+		}
+		resp.Deliveries = deliveries
+	}
+
+	if h.stats != nil {
+		resp.Instance = newInstanceStatus(h.stats.Stats(), now)
+	}
+
+	writeJSON(w, resp, http.StatusOK)
+}
+
+// eventCounts counts the events of each window, reusing recent counts
+func (h *Handler) eventCounts(ctx context.Context, now time.Time) (map[string]EventCounts, error) {
+	h.statsCache.mu.Lock()
+	defer h.statsCache.mu.Unlock()
+	if h.statsCache.counts != nil && now.Before(h.statsCache.expiresAt) {
+		return h.statsCache.counts, nil
+	}
+
+	counts := make(map[string]EventCounts, len(statsWindows))
+	for name, window := range statsWindows {
+		c, err := h.countEvents(ctx, now.Add(-window))
+		if err != nil {
+			return nil, err
+		}
+		counts[name] = c
+	}
+	h.statsCache.counts = counts
+	h.statsCache.expiresAt = now.Add(statsCacheTTL)
+	return counts, nil
+}
+
+// countEvents counts the events since from by maximum scale.
+// Each scale's count is the difference between the totals at or above it and
+// at or above the next one.
+func (h *Handler) countEvents(ctx context.Context, from time.Time) (EventCounts, error) {
+	atLeast := func(minSeverity int) (int, error) {
+		page, err := h.eventRepo.Query(ctx, store.EventQuery{Limit: 1, MinSeverity: minSeverity, From: &from})
+		if err != nil {
+			return 0, err
+		}
+		return page.Total, nil
+	}
+
+	total, err := atLeast(0)
+	if err != nil {
+		return EventCounts{}, err
+	}
+	counts := EventCounts{Total: total, ByScale: make(map[string]int, len(statsScales)+1)}
+	above := 0
+	for i := len(statsScales) - 1; i >= 0; i-- {
+		n, err := atLeast(statsScales[i])
+		if err != nil {
+			return EventCounts{}, err
+		}
+		counts.ByScale[strconv.Itoa(statsScales[i])] = n - above
+		above = n
+	}
+	counts.ByScale["unknown"] = total - above
+	return counts, nil
+}
+
+// deliveryStats counts the deliveries to the caller's subscriptions of the request's tenant
+func (h *Handler) deliveryStats(r *http.Request, now time.Time) (*DeliveryStats, error) {
+	var subs []subscription.Subscription
+	var err error
+	if claims, ok := auth.GetClaims(r.Context()); ok {
+		subs, err = h.subscriptionRepo.ListByUserID(r.Context(), claims.UID)
+	} else {
+		subs, err = h.subscriptionRepo.List(r.Context())
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	tenantID := tenant.FromContext(r.Context()).ID
+	from := now.Add(-statsDeliveryWindow)
+	stats := &DeliveryStats{Window: "7d", Subscriptions: make([]SubscriptionDeliveryStats, 0, len(subs))}
+	for _, sub := range subs {
+		if sub.TenantID != tenantID {
+			continue
+		}
+		summary, err := h.deliveryRepo.SummarizeSubscription(r.Context(), sub.ID, from, now)
+		if err != nil {
+			return nil, err
+		}
+		stats.Delivered += summary.Delivered
+		stats.Failed += summary.Failed
+		stats.Subscriptions = append(stats.Subscriptions, SubscriptionDeliveryStats{
+			ID:        sub.ID,
+			Name:      sub.Name,
+			Delivered: summary.Delivered,
+			Failed:    summary.Failed,
+		})
+	}
+	if total := stats.Delivered + stats.Failed; total > 0 {
+		rate := float64(stats.Delivered) / float64(total)
+		stats.SuccessRate = &rate
+	}
+	return stats, nil
+}
+
+// newInstanceStatus converts the app's stats for the response
+func newInstanceStatus(s app.Stats, now time.Time) *InstanceStatus {
+	status := &InstanceStatus{
+		StartedAt:           s.StartedAt.UTC(),
+		UptimeSeconds:       int64(now.Sub(s.StartedAt).Seconds()),
+		EventsReceived:      s.EventsReceived,
+		DeliveriesSucceeded: s.DeliveriesSucceeded,
+		DeliveriesFailed:    s.DeliveriesFailed,
+	}
+	if s.SourceMonitored {
+		status.Source = &SourceStatus{Connected: s.Source.Connected}
+		if !s.Source.Since.IsZero() {
+			since := s.Source.Since.UTC()
+			status.Source.Since = &since
+		}
+	}
+	return status
+}
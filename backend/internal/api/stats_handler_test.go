@@ -0,0 +1,120 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/otiai10/namazu/backend/internal/app"
+	"github.com/otiai10/namazu/backend/internal/auth"
+	"github.com/otiai10/namazu/backend/internal/source"
+	"github.com/otiai10/namazu/backend/internal/store"
+	"github.com/otiai10/namazu/backend/internal/subscription"
+)
+
+type mockInstanceStats struct{ stats app.Stats }
+
+func (m mockInstanceStats) Stats() app.Stats { return m.stats }
+
+func getStats(t *testing.T, h http.Handler, uid string) StatsResponse {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/api/stats", nil)
+	req = req.WithContext(auth.WithClaims(req.Context(), &auth.Claims{UID: uid}))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+	}
+	var resp StatsResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	return resp
+}
+
+func TestGetStats(t *testing.T) {
+	now := time.Now()
+	eventRepo := newMockEventRepo()
+	eventRepo.events = []store.EventRecord{
+		{ID: "recent-3", Severity: 30, OccurredAt: now.Add(-time.Hour)},
+		{ID: "recent-5", Severity: 45, OccurredAt: now.Add(-2 * time.Hour)},
+		{ID: "recent-tsunami", Type: "tsunami", Severity: -1, OccurredAt: now.Add(-3 * time.Hour)},
+		{ID: "week-3", Severity: 30, OccurredAt: now.Add(-3 * 24 * time.Hour)},
+		{ID: "old", Severity: 70, OccurredAt: now.Add(-30 * 24 * time.Hour)},
+	}
+
+	subRepo := newMockSubscriptionRepo()
+	subRepo.subscriptions["mine"] = subscription.Subscription{ID: "mine", UserID: "user-1", Name: "prod"}
+	subRepo.subscriptions["theirs"] = subscription.Subscription{ID: "theirs", UserID: "user-2", Name: "other"}
+	deliveryRepo := &mockDeliveryRepo{records: []store.DeliveryRecord{
+		{SubscriptionID: "mine", Success: true, DeliveredAt: now.Add(-time.Hour)},
+		{SubscriptionID: "mine", Success: true, DeliveredAt: now.Add(-2 * time.Hour)},
+		{SubscriptionID: "mine", Success: false, DeliveredAt: now.Add(-3 * time.Hour)},
+		{SubscriptionID: "mine", Success: false, DeliveredAt: now.Add(-10 * 24 * time.Hour)},
+		{SubscriptionID: "theirs", Success: true, DeliveredAt: now.Add(-time.Hour)},
+	}}
+
+	h := NewHandler(subRepo, eventRepo)
+	h.SetDeliveryRepository(deliveryRepo)
+	started := now.Add(-time.Hour)
+	h.SetStats(mockInstanceStats{app.Stats{
+		StartedAt:           started,
+		EventsReceived:      3,
+		DeliveriesSucceeded: 5,
+		DeliveriesFailed:    1,
+		Source:              source.Status{Connected: true, Since: now.Add(-time.Minute)},
+		SourceMonitored:     true,
+	}})
+
+	resp := getStats(t, NewRouter(h), "user-1")
+
+	day := resp.Events["24h"]
+	if day.Total != 3 || day.ByScale["30"] != 1 || day.ByScale["45"] != 1 || day.ByScale["unknown"] != 1 || day.ByScale["70"] != 0 {
+		t.Errorf("events[24h] = %+v", day)
+	}
+	if week := resp.Events["7d"]; week.Total != 4 || week.ByScale["30"] != 2 {
+		t.Errorf("events[7d] = %+v", week)
+	}
+
+	d := resp.Deliveries
+	if d == nil || d.Delivered != 2 || d.Failed != 1 || len(d.Subscriptions) != 1 {
+		t.Fatalf("deliveries = %+v, want the caller's deliveries of the last 7 days", d)
+	}
+	if d.SuccessRate == nil || *d.SuccessRate < 0.66 || *d.SuccessRate > 0.67 {
+		t.Errorf("success_rate = %v, want 2/3", d.SuccessRate)
+	}
+	if s := d.Subscriptions[0]; s.ID != "mine" || s.Name != "prod" || s.Delivered != 2 || s.Failed != 1 {
+		t.Errorf("subscriptions[0] = %+v", s)
+	}
+
+	inst := resp.Instance
+	if inst == nil || inst.UptimeSeconds < 3599 || inst.EventsReceived != 3 || inst.DeliveriesFailed != 1 {
+		t.Fatalf("instance = %+v", inst)
+	}
+	if inst.Source == nil || !inst.Source.Connected || inst.Source.Since == nil {
+		t.Errorf("instance.source = %+v, want connected", inst.Source)
+	}
+}
+
+func TestGetStats_Minimal(t *testing.T) {
+	resp := getStats(t, NewRouter(NewHandler(newMockSubscriptionRepo(), newMockEventRepo())), "user-1")
+	if resp.Events["24h"].Total != 0 || resp.Events["7d"].ByScale["10"] != 0 {
+		t.Errorf("events = %+v, want zero counts", resp.Events)
+	}
+	if resp.Deliveries != nil || resp.Instance != nil {
+		t.Errorf("expected no deliveries or instance sections, got %+v, %+v", resp.Deliveries, resp.Instance)
+	}
+}
+
+func TestGetStats_CachesEventCounts(t *testing.T) {
+	eventRepo := newMockEventRepo()
+	router := NewRouter(NewHandler(newMockSubscriptionRepo(), eventRepo))
+
+	getStats(t, router, "user-1")
+	eventRepo.events = append(eventRepo.events, store.EventRecord{ID: "new", Severity: 10, OccurredAt: time.Now()})
+	if resp := getStats(t, router, "user-1"); resp.Events["24h"].Total != 0 {
+		t.Errorf("events[24h].total = %d, want the cached count", resp.Events["24h"].Total)
+	}
+}
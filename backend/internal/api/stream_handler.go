@@ -0,0 +1,308 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/otiai10/namazu/backend/internal/apierr"
+	"github.com/otiai10/namazu/backend/internal/auth"
+	"github.com/otiai10/namazu/backend/internal/region"
+	"github.com/otiai10/namazu/backend/internal/store"
+	"github.com/otiai10/namazu/backend/internal/stream"
+	"github.com/otiai10/namazu/backend/internal/subscription"
+)
+
+// Stream connection timing
+const (
+	streamPingInterval = 30 * time.Second
+	streamWriteTimeout = 10 * time.Second
+)
+
+// sseReplayLimit is the number of recent stored events searched when an SSE client resumes
+const sseReplayLimit = 100
+
+// StreamHandler serves live events over WebSocket and Server-Sent Events
+type StreamHandler struct {
+	hub          *stream.Hub
+	verifier     auth.TokenVerifier    // nil accepts anonymous clients
+	eventRepo    store.EventRepository // nil disables resuming SSE streams
+	upgrader     websocket.Upgrader
+	pingInterval time.Duration
+}
+
+// NewStreamHandler creates a new StreamHandler.
+// When verifier is nil (no auth mode) clients connect without a token.
+func NewStreamHandler(hub *stream.Hub, verifier auth.TokenVerifier) *StreamHandler {
+	return &StreamHandler{
+		hub:      hub,
+		verifier: verifier,
+		// The ID token is checked instead of the origin, like the rest of the API
+		upgrader:     websocket.Upgrader{CheckOrigin: func(r *http.Request) bool { return true }},
+		pingInterval: streamPingInterval,
+	}
+}
+
+// SetEventRepository sets the repository SSE clients resume from (Last-Event-ID)
+func (h *StreamHandler) SetEventRepository(repo store.EventRepository) {
+	h.eventRepo = repo
+}
+
+// authenticate verifies the ID token from the Authorization header or the token
+// query parameter. It writes a 401 and returns false when the token is missing or invalid.
+func (h *StreamHandler) authenticate(w http.ResponseWriter, r *http.Request) bool {
+	if h.verifier == nil {
+		return true
+	}
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if token == "" {
+		token = r.URL.Query().Get("token")
+	}
+	if token == "" {
+		writeError(w, "authentication required", http.StatusUnauthorized)
+		return false
+	}
+	if _, err := h.verifier.VerifyIDToken(r.Context(), token); err != nil {
+		writeErrorCode(w, apierr.InvalidToken, "invalid token", http.StatusUnauthorized)
+		return false
+	}
+	return true
+}
+
+// ServeHTTP handles GET /api/stream
+// Upgrades to a WebSocket and sends each matching event as a JSON EventResponse.
+// Browsers cannot set headers on WebSocket requests, so the ID token may be
+// passed as ?token= instead of the Authorization header.
+// The filter is given as query parameters with the same semantics as a
+// subscription's FilterConfig: min_scale, prefectures, event_types (comma-separated) and eew.
+func (h *StreamHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if !h.authenticate(w, r) {
+		return
+	}
+
+	filter, msg := parseStreamFilter(r)
+	if msg != "" {
+		writeErrorCode(w, apierr.ValidationFailed, msg, http.StatusBadRequest)
+		return
+	}
+
+	conn, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// Upgrade has already written the error response
+		return
+	}
+	defer conn.Close()
+
+	client := h.hub.Subscribe(filter)
+	defer h.hub.Unsubscribe(client)
+
+	// Clients never send anything; reading detects the disconnect and handles pongs
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	ping := time.NewTicker(h.pingInterval)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-closed:
+			return
+		case <-r.Context().Done():
+			return
+		case record, ok := <-client.Events():
+			if !ok {
+				return
+			}
+			conn.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+			if err := conn.WriteJSON(eventToResponse(record)); err != nil {
+				return
+			}
+		case <-ping.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(streamWriteTimeout)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// ServeSSE handles GET /api/events/stream
+// Sends matching events as Server-Sent Events for clients that cannot use
+// WebSockets. Each event's id is the stored event ID; a reconnecting client's
+// Last-Event-ID header (or ?last_event_id=) replays the stored events that
+// occurred after it, searching the most recent sseReplayLimit events.
+// A heartbeat comment is sent every 30 seconds to keep proxies from closing the connection.
+func (h *StreamHandler) ServeSSE(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !h.authenticate(w, r) {
+		return
+	}
+	filter, msg := parseStreamFilter(r)
+	if msg != "" {
+		writeErrorCode(w, apierr.ValidationFailed, msg, http.StatusBadRequest)
+		return
+	}
+
+	rc := http.NewResponseController(w)
+	// The stream outlives the server's write timeout
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil && err != http.ErrNotSupported {
+		writeError(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	// Subscribe before loading the replay so nothing falls in between
+	client := h.hub.Subscribe(filter)
+	defer h.hub.Unsubscribe(client)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	sent := make(map[string]bool)
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = r.URL.Query().Get("last_event_id")
+	}
+	if lastEventID != "" {
+		for _, record := range h.missedEvents(r, lastEventID, filter) {
+			if err := writeSSEEvent(w, record); err != nil {
+				return
+			}
+			sent[record.ID] = true
+		}
+	}
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(h.pingInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case record, ok := <-client.Events():
+			if !ok {
+				return
+			}
+			if sent[record.ID] {
+				continue
+			}
+			if err := writeSSEEvent(w, record); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// missedEvents returns the stored events matching filter that occurred after
+// the event lastEventID, oldest first. Unknown IDs replay nothing.
+func (h *StreamHandler) missedEvents(r *http.Request, lastEventID string, filter *subscription.FilterConfig) []store.EventRecord {
+	if h.eventRepo == nil {
+		return nil
+	}
+	last, err := h.eventRepo.Get(r.Context(), lastEventID)
+	if err != nil || last == nil {
+		if err != nil {
+			log.Printf("Failed to resume event stream from %s: %v", lastEventID, err)
+		}
+		return nil
+	}
+	recent, err := h.eventRepo.List(r.Context(), sseReplayLimit, nil)
+	if err != nil {
+		log.Printf("Failed to resume event stream from %s: %v", lastEventID, err)
+		return nil
+	}
+
+	var missed []store.EventRecord
+	for _, record := range recent {
+		if record.ID == last.ID || !record.OccurredAt.After(last.OccurredAt) {
+			continue
+		}
+		if filter.Matches(record.ToSource()) {
+			missed = append(missed, record)
+		}
+	}
+	sort.Slice(missed, func(i, j int) bool {
+		return missed[i].OccurredAt.Before(missed[j].OccurredAt)
+	})
+	return missed
+}
+
+// writeSSEEvent writes one event in the text/event-stream format
+func writeSSEEvent(w http.ResponseWriter, record store.EventRecord) error {
+	data, err := json.Marshal(eventToResponse(record))
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %s\ndata: %s\n\n", record.ID, data)
+	return err
+}
+
+// parseStreamFilter builds the filter from the query string.
+// Returns an error message if a parameter is invalid.
+func parseStreamFilter(r *http.Request) (*subscription.FilterConfig, string) {
+	q := r.URL.Query()
+	filter := &subscription.FilterConfig{}
+
+	if s := q.Get("min_scale"); s != "" {
+		v, err := strconv.Atoi(s)
+		if err != nil || v < 0 {
+			return nil, "min_scale must be a non-negative integer"
+		}
+		filter.MinScale = v
+	}
+	filter.Prefectures = splitQueryList(q.Get("prefectures"))
+	if _, err := region.Expand(filter.Prefectures); err != nil {
+		return nil, err.Error()
+	}
+	filter.EventTypes = splitQueryList(q.Get("event_types"))
+	for _, t := range filter.EventTypes {
+		if !subscription.IsKnownEventType(t) {
+			return nil, "unknown event type: " + t
+		}
+	}
+	filter.EEW = q.Get("eew") == "true"
+
+	return filter, ""
+}
+
+// splitQueryList splits a comma-separated query value, dropping empty items
+func splitQueryList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,301 @@
+package api
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/otiai10/namazu/backend/internal/auth"
+	"github.com/otiai10/namazu/backend/internal/source/p2pquake"
+	"github.com/otiai10/namazu/backend/internal/store"
+	"github.com/otiai10/namazu/backend/internal/stream"
+)
+
+func newStreamServer(t *testing.T, hub *stream.Hub, verifier auth.TokenVerifier) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(NewRouterWithConfig(RouterConfig{
+		SubscriptionRepo: newMockSubscriptionRepo(),
+		EventRepo:        newMockEventRepo(),
+		UserRepo:         newMockUserRepo(),
+		TokenVerifier:    verifier,
+		Stream:           hub,
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func dialStream(server *httptest.Server, query string) (*websocket.Conn, *http.Response, error) {
+	return websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/api/stream"+query, nil)
+}
+
+// waitForClients waits until the hub has n clients, as Subscribe runs after the handshake
+func waitForClients(t *testing.T, hub *stream.Hub, n int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for hub.Count() != n {
+		if time.Now().After(deadline) {
+			t.Fatalf("hub has %d clients, want %d", hub.Count(), n)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestStreamHandler_DeliversFilteredEvents(t *testing.T) {
+	hub := stream.NewHub()
+	server := newStreamServer(t, hub, &mockTokenVerifier{claims: &auth.Claims{UID: "user-1"}})
+
+	conn, _, err := dialStream(server, "?token=valid-token&prefectures=東京都&min_scale=30")
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	defer conn.Close()
+	waitForClients(t, hub, 1)
+
+	hub.Publish(&p2pquake.JMAQuake{ID: "osaka", Code: 551, Earthquake: &p2pquake.Earthquake{MaxScale: 50},
+		Points: []p2pquake.Point{{Prefecture: "大阪府", Scale: 50}}}, "")
+	hub.Publish(&p2pquake.JMAQuake{ID: "weak", Code: 551, Earthquake: &p2pquake.Earthquake{MaxScale: 10},
+		Points: []p2pquake.Point{{Prefecture: "東京都", Scale: 10}}}, "")
+	hub.Publish(&p2pquake.JMAQuake{ID: "tokyo", Code: 551, Earthquake: &p2pquake.Earthquake{MaxScale: 45},
+		Points: []p2pquake.Point{{Prefecture: "東京都", Scale: 45}}}, "stored-tokyo")
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	var got EventResponse
+	if err := conn.ReadJSON(&got); err != nil {
+		t.Fatalf("ReadJSON() error = %v", err)
+	}
+	if got.ID != "stored-tokyo" || got.Type != "earthquake" || len(got.AffectedAreas) != 1 {
+		t.Errorf("unexpected event: %+v", got)
+	}
+
+	conn.Close()
+	waitForClients(t, hub, 0)
+}
+
+func TestStreamHandler_Rejects(t *testing.T) {
+	hub := stream.NewHub()
+
+	tests := []struct {
+		name     string
+		verifier auth.TokenVerifier
+		query    string
+		want     int
+	}{
+		{"missing token", &mockTokenVerifier{claims: &auth.Claims{UID: "user-1"}}, "", http.StatusUnauthorized},
+		{"invalid token", &mockTokenVerifier{err: errors.New("expired")}, "?token=bad", http.StatusUnauthorized},
+		{"invalid min_scale", &mockTokenVerifier{claims: &auth.Claims{UID: "user-1"}}, "?token=t&min_scale=high", http.StatusBadRequest},
+		{"unknown event type", &mockTokenVerifier{claims: &auth.Claims{UID: "user-1"}}, "?token=t&event_types=volcano", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newStreamServer(t, hub, tt.verifier)
+			_, resp, err := dialStream(server, tt.query)
+			if err == nil {
+				t.Fatal("expected the handshake to fail")
+			}
+			if resp == nil || resp.StatusCode != tt.want {
+				t.Errorf("expected status %d, got %+v", tt.want, resp)
+			}
+		})
+	}
+	if hub.Count() != 0 {
+		t.Errorf("rejected clients should not subscribe, hub has %d", hub.Count())
+	}
+}
+
+func TestStreamHandler_NoAuth(t *testing.T) {
+	hub := stream.NewHub()
+	server := newStreamServer(t, hub, nil)
+
+	conn, _, err := dialStream(server, "")
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	defer conn.Close()
+	waitForClients(t, hub, 1)
+}
+
+func TestParseStreamFilter(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/api/stream?min_scale=45&prefectures=東京都,+神奈川県,&event_types=earthquake,tsunami&eew=true", nil)
+	filter, msg := parseStreamFilter(req)
+	if msg != "" {
+		t.Fatalf("parseStreamFilter() error = %s", msg)
+	}
+	if filter.MinScale != 45 || !filter.EEW {
+		t.Errorf("unexpected filter: %+v", filter)
+	}
+	if len(filter.Prefectures) != 2 || filter.Prefectures[1] != "神奈川県" {
+		t.Errorf("Prefectures = %v", filter.Prefectures)
+	}
+	if len(filter.EventTypes) != 2 {
+		t.Errorf("EventTypes = %v", filter.EventTypes)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/stream?prefectures=Tokyo,Atlantis", nil)
+	if _, msg := parseStreamFilter(req); !strings.Contains(msg, "Atlantis") {
+		t.Errorf("parseStreamFilter(unknown prefecture) = %q, want an error", msg)
+	}
+}
+
+// readSSE reads one message (or comment) up to the blank line that ends it
+func readSSE(t *testing.T, r *bufio.Reader) []string {
+	t.Helper()
+	var lines []string
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("ReadString() error = %v", err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+		if line == "" {
+			return lines
+		}
+		lines = append(lines, line)
+	}
+}
+
+// sseEvent returns the id and decoded data of an SSE message
+func sseEvent(t *testing.T, lines []string) (string, EventResponse) {
+	t.Helper()
+	var id string
+	var got EventResponse
+	for _, line := range lines {
+		switch {
+		case strings.HasPrefix(line, "id: "):
+			id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "data: "):
+			if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &got); err != nil {
+				t.Fatalf("invalid data %q: %v", line, err)
+			}
+		}
+	}
+	return id, got
+}
+
+func TestStreamHandler_SSE(t *testing.T) {
+	hub := stream.NewHub()
+	server := newStreamServer(t, hub, &mockTokenVerifier{claims: &auth.Claims{UID: "user-1"}})
+
+	resp, err := http.Get(server.URL + "/api/events/stream?token=valid-token&prefectures=東京都")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", resp.StatusCode)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	waitForClients(t, hub, 1)
+
+	hub.Publish(&p2pquake.JMAQuake{ID: "osaka", Code: 551, Earthquake: &p2pquake.Earthquake{MaxScale: 50},
+		Points: []p2pquake.Point{{Prefecture: "大阪府", Scale: 50}}}, "")
+	hub.Publish(&p2pquake.JMAQuake{ID: "tokyo", Code: 551, Earthquake: &p2pquake.Earthquake{MaxScale: 45},
+		Points: []p2pquake.Point{{Prefecture: "東京都", Scale: 45}}}, "stored-tokyo")
+
+	id, got := sseEvent(t, readSSE(t, bufio.NewReader(resp.Body)))
+	if id != "stored-tokyo" || got.ID != "stored-tokyo" || got.Type != "earthquake" {
+		t.Errorf("unexpected event %s: %+v", id, got)
+	}
+}
+
+func TestStreamHandler_SSEResume(t *testing.T) {
+	base := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+	repo := newMockEventRepo()
+	for i, e := range []struct {
+		id   string
+		area string
+	}{{"e3", "東京都"}, {"e2", "大阪府"}, {"e1", "東京都"}, {"e0", "東京都"}} {
+		repo.events = append(repo.events, store.EventRecord{
+			ID: e.id, Type: "earthquake", Severity: 50, AffectedAreas: []string{e.area},
+			OccurredAt: base.Add(-time.Duration(i) * time.Minute),
+		})
+	}
+
+	hub := stream.NewHub()
+	handler := NewStreamHandler(hub, nil)
+	handler.SetEventRepository(repo)
+	server := httptest.NewServer(http.HandlerFunc(handler.ServeSSE))
+	defer server.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"?prefectures=東京都", nil)
+	req.Header.Set("Last-Event-ID", "e0")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	defer resp.Body.Close()
+
+	// e2 is filtered out; the rest is replayed oldest first
+	r := bufio.NewReader(resp.Body)
+	for _, want := range []string{"e1", "e3"} {
+		if id, _ := sseEvent(t, readSSE(t, r)); id != want {
+			t.Errorf("replayed %q, want %q", id, want)
+		}
+	}
+
+	// A live event that was already replayed is not sent twice
+	hub.Publish(&p2pquake.JMAQuake{ID: "e3", Code: 551, Earthquake: &p2pquake.Earthquake{MaxScale: 50},
+		Points: []p2pquake.Point{{Prefecture: "東京都", Scale: 50}}}, "e3")
+	hub.Publish(&p2pquake.JMAQuake{ID: "e4", Code: 551, Earthquake: &p2pquake.Earthquake{MaxScale: 50},
+		Points: []p2pquake.Point{{Prefecture: "東京都", Scale: 50}}}, "e4")
+	if id, _ := sseEvent(t, readSSE(t, r)); id != "e4" {
+		t.Errorf("live event %q, want e4", id)
+	}
+}
+
+func TestStreamHandler_SSEHeartbeat(t *testing.T) {
+	handler := NewStreamHandler(stream.NewHub(), nil)
+	handler.pingInterval = 10 * time.Millisecond
+	server := httptest.NewServer(http.HandlerFunc(handler.ServeSSE))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	defer resp.Body.Close()
+
+	lines := readSSE(t, bufio.NewReader(resp.Body))
+	if len(lines) != 1 || !strings.HasPrefix(lines[0], ":") {
+		t.Errorf("expected a heartbeat comment, got %q", lines)
+	}
+}
+
+func TestStreamHandler_SSERejects(t *testing.T) {
+	hub := stream.NewHub()
+	server := newStreamServer(t, hub, &mockTokenVerifier{claims: &auth.Claims{UID: "user-1"}})
+
+	tests := []struct {
+		name   string
+		method string
+		query  string
+		want   int
+	}{
+		{"missing token", http.MethodGet, "", http.StatusUnauthorized},
+		{"invalid min_scale", http.MethodGet, "?token=t&min_scale=-1", http.StatusBadRequest},
+		{"wrong method", http.MethodPost, "?token=t", http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(tt.method, server.URL+"/api/events/stream"+tt.query, nil)
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("Do() error = %v", err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.want {
+				t.Errorf("expected status %d, got %d", tt.want, resp.StatusCode)
+			}
+		})
+	}
+	if hub.Count() != 0 {
+		t.Errorf("rejected clients should not subscribe, hub has %d", hub.Count())
+	}
+}
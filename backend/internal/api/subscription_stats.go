@@ -0,0 +1,134 @@
+package api
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/otiai10/namazu/backend/internal/subscription"
+)
+
+// subscriptionStatsWindow is the period of the delivery statistics of a subscription
+const subscriptionStatsWindow = 7 * 24 * time.Hour
+
+// subscriptionStatsTTL is how long the statistics of a subscription are reused.
+// They aggregate a week of delivery records, so repeated GETs (dashboards
+// polling, IaC refreshes) must not read them every time.
+const subscriptionStatsTTL = time.Minute
+
+// maxCachedSubscriptionStats bounds the number of cached statistics
+const maxCachedSubscriptionStats = 10000
+
+// SubscriptionStats summarizes the recent deliveries to a subscription
+type SubscriptionStats struct {
+	Window         string     `json:"window"`
+	Delivered      int        `json:"delivered"`
+	Failed         int        `json:"failed"`
+	SuccessRate    *float64   `json:"success_rate"`   // null without deliveries
+	AvgLatencyMs   *int64     `json:"avg_latency_ms"` // Response time of the last attempt; null without deliveries
+	LastDeliveryAt *time.Time `json:"last_delivery_at,omitempty"`
+	LastStatus     string     `json:"last_status,omitempty"`      // "success" | "failed"
+	LastStatusCode int        `json:"last_status_code,omitempty"` // Omitted when no response was received
+	Health         string     `json:"health,omitempty"`           // State of the recent deliveries on this instance; see delivery.HealthTracker
+	ComputedAt     time.Time  `json:"computed_at"`                // Counts may be up to a minute old
+}
+
+// Delivery outcomes reported as SubscriptionStats.LastStatus
+const (
+	lastStatusSuccess = "success"
+	lastStatusFailed  = "failed"
+)
+
+// subscriptionStatsCache keeps the statistics of subscriptions for subscriptionStatsTTL
+type subscriptionStatsCache struct {
+	mu      sync.Mutex
+	entries map[string]SubscriptionStats
+}
+
+// SetHealthReporter enables the health state in subscription statistics
+func (h *Handler) SetHealthReporter(r HealthReporter) {
+	h.health = r
+}
+
+// subscriptionStats returns the statistics of a subscription, or nil if
+// delivery history is not configured or cannot be read
+func (h *Handler) subscriptionStats(ctx context.Context, sub subscription.Subscription, now time.Time) *SubscriptionStats {
+	if h.deliveryRepo == nil {
+		return nil
+	}
+
+	stats, ok := h.subStatsCache.get(sub.ID, now)
+	if !ok {
+		records, err := h.deliveryRepo.ListBySubscription(ctx, sub.ID, now.Add(-subscriptionStatsWindow), now)
+		if err != nil {
+			log.Printf("Failed to get deliveries of subscription %s for statistics: %v", sub.ID, err)
+			return nil
+		}
+
+		stats = SubscriptionStats{Window: "7d", ComputedAt: now}
+		var latency int64
+		for _, record := range records {
+			if record.Success {
+				stats.Delivered++
+			} else {
+				stats.Failed++
+			}
+			latency += record.ResponseTimeMs
+		}
+		if n := len(records); n > 0 {
+			rate := float64(stats.Delivered) / float64(n)
+			avg := latency / int64(n)
+			stats.SuccessRate = &rate
+			stats.AvgLatencyMs = &avg
+
+			// Records are stored oldest first
+			last := records[n-1]
+			stats.LastDeliveryAt = &last.DeliveredAt
+			stats.LastStatus = lastStatusFailed
+			if last.Success {
+				stats.LastStatus = lastStatusSuccess
+			}
+			stats.LastStatusCode = last.StatusCode
+		}
+		h.subStatsCache.put(sub.ID, stats)
+	}
+
+	// In memory and always current, so not cached
+	if h.health != nil {
+		stats.Health = h.health.Status(sub.ID).State
+	}
+	return &stats
+}
+
+// get returns the cached statistics of a subscription if they are fresh at now
+func (c *subscriptionStatsCache) get(id string, now time.Time) (SubscriptionStats, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats, ok := c.entries[id]
+	if !ok || !now.Before(stats.ComputedAt.Add(subscriptionStatsTTL)) {
+		return SubscriptionStats{}, false
+	}
+	return stats, true
+}
+
+// put caches the statistics of a subscription. When the cache is full,
+// expired entries are dropped, or everything if none has expired.
+func (c *subscriptionStatsCache) put(id string, stats SubscriptionStats) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entries == nil {
+		c.entries = make(map[string]SubscriptionStats)
+	}
+	if len(c.entries) >= maxCachedSubscriptionStats {
+		for key, cached := range c.entries {
+			if !stats.ComputedAt.Before(cached.ComputedAt.Add(subscriptionStatsTTL)) {
+				delete(c.entries, key)
+			}
+		}
+		if len(c.entries) >= maxCachedSubscriptionStats {
+			c.entries = make(map[string]SubscriptionStats)
+		}
+	}
+	c.entries[id] = stats
+}
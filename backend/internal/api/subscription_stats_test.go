@@ -0,0 +1,126 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/otiai10/namazu/backend/internal/delivery"
+	"github.com/otiai10/namazu/backend/internal/store"
+	"github.com/otiai10/namazu/backend/internal/subscription"
+)
+
+func TestGetSubscription_Stats(t *testing.T) {
+	now := time.Now().UTC()
+	subRepo := newMockSubscriptionRepo()
+	subRepo.subscriptions["sub-1"] = subscription.Subscription{ID: "sub-1", Name: "Hook", Delivery: subscription.DeliveryConfig{Type: "webhook", URL: "https://example.com/hook"}}
+	deliveries := &mockDeliveryRepo{records: []store.DeliveryRecord{
+		{SubscriptionID: "sub-1", Success: true, StatusCode: 200, ResponseTimeMs: 100, DeliveredAt: now.AddDate(0, 0, -10)}, // Outside the window
+		{SubscriptionID: "sub-1", Success: true, StatusCode: 200, ResponseTimeMs: 100, DeliveredAt: now.Add(-3 * time.Hour)},
+		{SubscriptionID: "sub-1", Success: true, StatusCode: 204, ResponseTimeMs: 200, DeliveredAt: now.Add(-2 * time.Hour)},
+		{SubscriptionID: "sub-1", Success: false, StatusCode: 503, ResponseTimeMs: 600, DeliveredAt: now.Add(-time.Hour)},
+		{SubscriptionID: "sub-2", Success: false, StatusCode: 500, DeliveredAt: now.Add(-time.Hour)},
+	}}
+	tracker := delivery.NewHealthTracker(0)
+	tracker.Record("sub-1", false, now)
+	handler := NewHandler(subRepo, newMockEventRepo())
+	handler.SetDeliveryRepository(deliveries)
+	handler.SetHealthReporter(tracker)
+
+	get := func() SubscriptionResponse {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		handler.GetSubscription(rec, httptest.NewRequest(http.MethodGet, "/api/subscriptions/sub-1", nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+		}
+		var resp SubscriptionResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		return resp
+	}
+
+	stats := get().Stats
+	if stats == nil {
+		t.Fatal("expected stats")
+	}
+	if stats.Window != "7d" || stats.Delivered != 2 || stats.Failed != 1 {
+		t.Errorf("counts = %+v, want 2 delivered and 1 failed in 7d", stats)
+	}
+	if stats.SuccessRate == nil || *stats.SuccessRate < 0.66 || *stats.SuccessRate > 0.67 {
+		t.Errorf("success_rate = %v, want 2/3", stats.SuccessRate)
+	}
+	if stats.AvgLatencyMs == nil || *stats.AvgLatencyMs != 300 {
+		t.Errorf("avg_latency_ms = %v, want 300", stats.AvgLatencyMs)
+	}
+	if stats.LastDeliveryAt == nil || !stats.LastDeliveryAt.Equal(now.Add(-time.Hour)) || stats.LastStatus != "failed" || stats.LastStatusCode != 503 {
+		t.Errorf("last delivery = %v %s %d, want the 503 an hour ago", stats.LastDeliveryAt, stats.LastStatus, stats.LastStatusCode)
+	}
+	if stats.Health != delivery.HealthFailing {
+		t.Errorf("health = %q, want %q", stats.Health, delivery.HealthFailing)
+	}
+
+	// Counts are cached; health is not
+	deliveries.records = append(deliveries.records, store.DeliveryRecord{SubscriptionID: "sub-1", Success: true, DeliveredAt: now})
+	tracker.Record("sub-1", true, now)
+	tracker.Record("sub-1", true, now)
+	stats = get().Stats
+	if stats.Delivered != 2 {
+		t.Errorf("delivered = %d, want the cached 2", stats.Delivered)
+	}
+	if stats.Health != delivery.HealthDegraded {
+		t.Errorf("health = %q, want %q", stats.Health, delivery.HealthDegraded)
+	}
+}
+
+func TestGetSubscription_StatsWithoutDeliveries(t *testing.T) {
+	subRepo := newMockSubscriptionRepo()
+	subRepo.subscriptions["sub-1"] = subscription.Subscription{ID: "sub-1", Name: "Hook"}
+	handler := NewHandler(subRepo, newMockEventRepo())
+
+	rec := httptest.NewRecorder()
+	handler.GetSubscription(rec, httptest.NewRequest(http.MethodGet, "/api/subscriptions/sub-1", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rec.Code)
+	}
+	var raw map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &raw); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if _, ok := raw["stats"]; ok {
+		t.Errorf("stats = %v, want omitted without delivery history", raw["stats"])
+	}
+
+	handler.SetDeliveryRepository(&mockDeliveryRepo{})
+	rec = httptest.NewRecorder()
+	handler.GetSubscription(rec, httptest.NewRequest(http.MethodGet, "/api/subscriptions/sub-1", nil))
+	var resp SubscriptionResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if s := resp.Stats; s == nil || s.SuccessRate != nil || s.AvgLatencyMs != nil || s.LastDeliveryAt != nil || s.Health != "" {
+		t.Errorf("stats = %+v, want empty counts", s)
+	}
+}
+
+func TestSubscriptionStatsCache_Bounded(t *testing.T) {
+	var c subscriptionStatsCache
+	now := time.Now()
+	for i := 0; i < maxCachedSubscriptionStats; i++ {
+		c.put(strconv.Itoa(i), SubscriptionStats{ComputedAt: now.Add(-subscriptionStatsTTL)})
+	}
+	c.put("fresh", SubscriptionStats{ComputedAt: now})
+	if len(c.entries) != 1 {
+		t.Errorf("entries = %d, want expired entries dropped", len(c.entries))
+	}
+	if _, ok := c.get("fresh", now); !ok {
+		t.Error("fresh entry not cached")
+	}
+	if _, ok := c.get("fresh", now.Add(subscriptionStatsTTL)); ok {
+		t.Error("entry served after its TTL")
+	}
+}
@@ -0,0 +1,52 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/otiai10/namazu/backend/internal/delivery/webhook"
+	"github.com/otiai10/namazu/backend/internal/quota"
+	"github.com/otiai10/namazu/backend/internal/tenant"
+	"github.com/otiai10/namazu/backend/internal/user"
+)
+
+// TenantResponse describes the branding and plans of the tenant serving the request
+type TenantResponse struct {
+	ID         string        `json:"id,omitempty"`
+	Name       string        `json:"name"`
+	SenderName string        `json:"senderName"`
+	EmailFrom  string        `json:"emailFrom,omitempty"`
+	Plans      []tenant.Plan `json:"plans"`
+}
+
+// GetTenant handles GET /api/tenant
+// The tenant is resolved from the request host; unknown hosts get the default tenant.
+func (h *Handler) GetTenant(w http.ResponseWriter, r *http.Request) {
+	t := tenant.FromContext(r.Context())
+	writeJSON(w, newTenantResponse(t), http.StatusOK)
+}
+
+// newTenantResponse builds the response, filling in the default plan catalog
+// (configured or built in) and sender name for tenants that do not define their own
+func newTenantResponse(t *tenant.Tenant) TenantResponse {
+	plans := t.Plans
+	if len(plans) == 0 {
+		plans = tenant.Default.Plans
+	}
+	if len(plans) == 0 {
+		plans = []tenant.Plan{
+			{ID: user.PlanFree, Name: "Free", MaxSubscriptions: quota.FreePlanLimits.MaxSubscriptions},
+			{ID: user.PlanPro, Name: "Pro", MaxSubscriptions: quota.ProPlanLimits.MaxSubscriptions, Paid: true},
+		}
+	}
+	senderName := t.SenderName
+	if senderName == "" {
+		senderName = webhook.DefaultUserAgent
+	}
+	return TenantResponse{
+		ID:         t.ID,
+		Name:       t.Name,
+		SenderName: senderName,
+		EmailFrom:  t.EmailFrom,
+		Plans:      plans,
+	}
+}
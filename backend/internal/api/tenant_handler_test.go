@@ -0,0 +1,206 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/otiai10/namazu/backend/internal/auth"
+	"github.com/otiai10/namazu/backend/internal/badge"
+	"github.com/otiai10/namazu/backend/internal/config"
+	"github.com/otiai10/namazu/backend/internal/subscription"
+	"github.com/otiai10/namazu/backend/internal/tenant"
+)
+
+func newTestTenants() *tenant.Registry {
+	return tenant.NewRegistry([]config.TenantConfig{
+		{
+			ID:         "acme",
+			Name:       "ACME Alerts",
+			Domains:    []string{"alerts.acme.example"},
+			SenderName: "acme-alerts/1.0",
+			EmailFrom:  "alerts@acme.example",
+			Plans: []config.PlanConfig{
+				{ID: "free", Name: "Starter", MaxSubscriptions: 2},
+				{ID: "pro", Name: "Business", MaxSubscriptions: 50, PriceID: "price_acme_pro"},
+			},
+		},
+	})
+}
+
+func TestGetTenant(t *testing.T) {
+	router := NewRouterWithConfig(RouterConfig{
+		SubscriptionRepo: newMockSubscriptionRepo(),
+		EventRepo:        newMockEventRepo(),
+		Tenants:          newTestTenants(),
+	})
+
+	t.Run("tenant domain", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/tenant", nil)
+		req.Host = "alerts.acme.example"
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, rec.Code)
+		}
+		if bytes.Contains(rec.Body.Bytes(), []byte("price_acme_pro")) {
+			t.Error("response must not expose Stripe price IDs")
+		}
+
+		var got TenantResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+			t.Fatalf("failed to unmarshal response: %v", err)
+		}
+		if got.ID != "acme" || got.Name != "ACME Alerts" || got.SenderName != "acme-alerts/1.0" || got.EmailFrom != "alerts@acme.example" {
+			t.Errorf("unexpected tenant: %+v", got)
+		}
+		if len(got.Plans) != 2 || got.Plans[1].Name != "Business" || got.Plans[1].MaxSubscriptions != 50 {
+			t.Errorf("unexpected plans: %+v", got.Plans)
+		}
+	})
+
+	t.Run("unknown host gets default tenant", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/tenant", nil)
+		req.Host = "namazu.live"
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		var got TenantResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+			t.Fatalf("failed to unmarshal response: %v", err)
+		}
+		if got.ID != "" || got.Name != "namazu" || got.SenderName != "namazu/1.0" {
+			t.Errorf("unexpected tenant: %+v", got)
+		}
+		if len(got.Plans) != 2 || got.Plans[0].ID != "free" || got.Plans[1].ID != "pro" {
+			t.Errorf("expected default plan catalog, got %+v", got.Plans)
+		}
+	})
+
+	t.Run("method not allowed", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/api/tenant", nil)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusMethodNotAllowed {
+			t.Errorf("expected status %d, got %d", http.StatusMethodNotAllowed, rec.Code)
+		}
+	})
+}
+
+func TestTenantIsolation(t *testing.T) {
+	subRepo := newMockSubscriptionRepo()
+	subRepo.subscriptions["acme-sub"] = subscription.Subscription{
+		ID: "acme-sub", UserID: "test-uid", TenantID: "acme", Name: "shared-name",
+		Delivery: subscription.DeliveryConfig{Type: "webhook", URL: "https://acme.example.com/hook"},
+	}
+	subRepo.subscriptions["default-sub"] = subscription.Subscription{
+		ID: "default-sub", UserID: "test-uid", Name: "shared-name",
+		Delivery: subscription.DeliveryConfig{Type: "webhook", URL: "https://example.com/hook"},
+	}
+
+	signer := badge.NewSigner("test-secret")
+	router := NewRouterWithConfig(RouterConfig{
+		SubscriptionRepo: subRepo,
+		EventRepo:        newMockEventRepo(),
+		UserRepo:         newMockUserRepo(),
+		TokenVerifier:    &mockTokenVerifier{claims: &auth.Claims{UID: "test-uid"}},
+		BadgeSigner:      signer,
+		Tenants:          newTestTenants(),
+	})
+
+	do := func(method, host, path string, body []byte) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewReader(body))
+		req.Host = host
+		req.Header.Set("Authorization", "Bearer valid-token")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("list only returns the tenant's subscriptions", func(t *testing.T) {
+		for host, want := range map[string]string{"alerts.acme.example": "acme-sub", "namazu.live": "default-sub"} {
+			rec := do(http.MethodGet, host, "/api/subscriptions", nil)
+			var got []SubscriptionResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+				t.Fatalf("failed to unmarshal response: %v", err)
+			}
+			if len(got) != 1 || got[0].ID != want {
+				t.Errorf("host %s: expected only %s, got %+v", host, want, got)
+			}
+		}
+	})
+
+	t.Run("other tenant's subscription is not found", func(t *testing.T) {
+		if rec := do(http.MethodGet, "namazu.live", "/api/subscriptions/acme-sub", nil); rec.Code != http.StatusNotFound {
+			t.Errorf("GET: expected status %d, got %d", http.StatusNotFound, rec.Code)
+		}
+		if rec := do(http.MethodDelete, "namazu.live", "/api/subscriptions/acme-sub", nil); rec.Code != http.StatusNotFound {
+			t.Errorf("DELETE: expected status %d, got %d", http.StatusNotFound, rec.Code)
+		}
+		if _, ok := subRepo.subscriptions["acme-sub"]; !ok {
+			t.Error("subscription of another tenant was deleted")
+		}
+		if rec := do(http.MethodGet, "alerts.acme.example", "/api/subscriptions/acme-sub", nil); rec.Code != http.StatusOK {
+			t.Errorf("GET from own tenant: expected status %d, got %d", http.StatusOK, rec.Code)
+		}
+	})
+
+	t.Run("names are scoped to the tenant", func(t *testing.T) {
+		rec := do(http.MethodGet, "alerts.acme.example", "/api/subscriptions/by-name/shared-name", nil)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+		}
+		var got SubscriptionResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+			t.Fatalf("failed to unmarshal response: %v", err)
+		}
+		if got.ID != "acme-sub" {
+			t.Errorf("expected acme-sub, got %s", got.ID)
+		}
+	})
+
+	t.Run("create assigns the request's tenant", func(t *testing.T) {
+		body := []byte(`{"name":"new","delivery":{"type":"webhook","url":"https://acme.example.com/new"}}`)
+		rec := do(http.MethodPost, "alerts.acme.example", "/api/subscriptions", body)
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, rec.Code, rec.Body.String())
+		}
+		var got SubscriptionResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+			t.Fatalf("failed to unmarshal response: %v", err)
+		}
+		if tenantID := subRepo.subscriptions[got.ID].TenantID; tenantID != "acme" {
+			t.Errorf("expected TenantID acme, got %q", tenantID)
+		}
+	})
+
+	t.Run("update preserves the tenant", func(t *testing.T) {
+		body := []byte(`{"name":"renamed","delivery":{"type":"webhook","url":"https://acme.example.com/hook"}}`)
+		req := httptest.NewRequest(http.MethodPut, "/api/subscriptions/acme-sub", bytes.NewReader(body))
+		req.Host = "alerts.acme.example"
+		req.Header.Set("Authorization", "Bearer valid-token")
+		req.Header.Set("If-Match", subscriptionETag(subRepo.subscriptions["acme-sub"]))
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+		}
+		if tenantID := subRepo.subscriptions["acme-sub"].TenantID; tenantID != "acme" {
+			t.Errorf("expected TenantID acme, got %q", tenantID)
+		}
+	})
+
+	t.Run("badges are only served under the tenant's domain", func(t *testing.T) {
+		path := "/api/badge/" + signer.Token("acme-sub") + ".json"
+		if rec := do(http.MethodGet, "namazu.live", path, nil); rec.Code != http.StatusNotFound {
+			t.Errorf("expected status %d, got %d", http.StatusNotFound, rec.Code)
+		}
+		if rec := do(http.MethodGet, "alerts.acme.example", path, nil); rec.Code != http.StatusOK {
+			t.Errorf("expected status %d, got %d", http.StatusOK, rec.Code)
+		}
+	})
+}
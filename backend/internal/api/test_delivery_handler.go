@@ -0,0 +1,137 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/otiai10/namazu/backend/internal/apierr"
+	"github.com/otiai10/namazu/backend/internal/delivery/webhook"
+	"github.com/otiai10/namazu/backend/internal/subscription"
+)
+
+// maxTestPayloadBytes limits the body of a test delivery request
+const maxTestPayloadBytes = 64 << 10
+
+// Tester sends a sample payload to a webhook subscription
+type Tester interface {
+	SendTest(ctx context.Context, sub subscription.Subscription, eventID string, payload []byte) webhook.DeliveryResult
+}
+
+// TestDeliveryRequest is the optional body of a test delivery.
+// Without either field the most recent stored event is sent.
+type TestDeliveryRequest struct {
+	EventID string          `json:"event_id,omitempty"` // Stored event to send
+	Payload json.RawMessage `json:"payload,omitempty"`  // Inline sample payload, sent as is
+}
+
+// TestDeliveryResponse is the outcome of a test delivery
+type TestDeliveryResponse struct {
+	EventID        string `json:"event_id,omitempty"` // Empty for an inline payload
+	StatusCode     int    `json:"status_code"`
+	Success        bool   `json:"success"`
+	ErrorMessage   string `json:"error_message,omitempty"`
+	ResponseTimeMs int64  `json:"response_time_ms"`
+	ResponseBody   string `json:"response_body,omitempty"` // Leading bytes of the receiver's response
+}
+
+// SetTester enables test deliveries to subscriptions
+func (h *Handler) SetTester(t Tester) {
+	h.tester = t
+}
+
+// TestSubscription handles POST /api/subscriptions/{id}/test
+// Sends a stored event (the most recent one unless event_id is given) or an
+// inline payload to the subscription's webhook once, signed with its secret and
+// marked with the X-Namazu-Test header. Test deliveries are not recorded in
+// the delivery history.
+func (h *Handler) TestSubscription(w http.ResponseWriter, r *http.Request, id string) {
+	if h.tester == nil {
+		writeError(w, "test delivery is not configured", http.StatusNotImplemented)
+		return
+	}
+
+	var req TestDeliveryRequest
+	err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxTestPayloadBytes)).Decode(&req)
+	if err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.EventID != "" && len(req.Payload) > 0 {
+		writeErrorCode(w, apierr.ValidationFailed, "event_id and payload are mutually exclusive", http.StatusBadRequest)
+		return
+	}
+	if len(req.Payload) > 0 && req.Payload[0] != '{' {
+		writeErrorCode(w, apierr.ValidationFailed, "payload must be a JSON object", http.StatusBadRequest)
+		return
+	}
+
+	sub, forbidden, err := h.checkOwnership(r.Context(), id)
+	if err != nil {
+		writeError(w, "failed to get subscription", http.StatusInternalServerError)
+		return
+	}
+	if sub == nil {
+		writeError(w, "subscription not found", http.StatusNotFound)
+		return
+	}
+	if forbidden {
+		writeErrorCode(w, apierr.NotOwner, "forbidden", http.StatusForbidden)
+		return
+	}
+	if sub.Delivery.Type != "webhook" {
+		writeError(w, "only webhook subscriptions can be tested", http.StatusBadRequest)
+		return
+	}
+
+	eventID, payload := "", []byte(req.Payload)
+	if len(payload) == 0 {
+		var status int
+		var msg string
+		eventID, payload, status, msg = h.testEventPayload(r.Context(), req.EventID)
+		if msg != "" {
+			writeError(w, msg, status)
+			return
+		}
+	}
+
+	result := h.tester.SendTest(r.Context(), *sub, eventID, payload)
+	writeJSON(w, TestDeliveryResponse{
+		EventID:        eventID,
+		StatusCode:     result.StatusCode,
+		Success:        result.Success,
+		ErrorMessage:   result.ErrorMessage,
+		ResponseTimeMs: result.ResponseTime.Milliseconds(),
+		ResponseBody:   result.ResponseBody,
+	}, http.StatusOK)
+}
+
+// testEventPayload returns the raw payload of a stored event, or of the most
+// recent one when eventID is empty. On failure it returns an HTTP status and message.
+func (h *Handler) testEventPayload(ctx context.Context, eventID string) (string, []byte, int, string) {
+	if h.eventRepo == nil {
+		return "", nil, http.StatusNotFound, "no stored events; send a payload instead"
+	}
+
+	if eventID == "" {
+		events, err := h.eventRepo.List(ctx, 1, nil)
+		if err != nil {
+			return "", nil, http.StatusInternalServerError, "failed to get events"
+		}
+		if len(events) == 0 || events[0].RawJSON == "" {
+			return "", nil, http.StatusNotFound, "no stored events; send a payload instead"
+		}
+		return events[0].ID, []byte(events[0].RawJSON), 0, ""
+	}
+
+	event, err := h.eventRepo.Get(ctx, eventID)
+	if err != nil {
+		return "", nil, http.StatusInternalServerError, "failed to get event"
+	}
+	if event == nil || event.RawJSON == "" {
+		return "", nil, http.StatusNotFound, "event not found"
+	}
+	return event.ID, []byte(event.RawJSON), 0, ""
+}
@@ -0,0 +1,153 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/otiai10/namazu/backend/internal/auth"
+	"github.com/otiai10/namazu/backend/internal/delivery/webhook"
+	"github.com/otiai10/namazu/backend/internal/store"
+	"github.com/otiai10/namazu/backend/internal/subscription"
+)
+
+// mockTester records test deliveries and returns a fixed result
+type mockTester struct {
+	result  webhook.DeliveryResult
+	subs    []subscription.Subscription
+	payload []byte
+}
+
+func (m *mockTester) SendTest(ctx context.Context, sub subscription.Subscription, eventID string, payload []byte) webhook.DeliveryResult {
+	m.subs = append(m.subs, sub)
+	m.payload = payload
+	return m.result
+}
+
+func TestTestSubscription(t *testing.T) {
+	subRepo := newMockSubscriptionRepo()
+	subRepo.subscriptions["hook-sub"] = subscription.Subscription{ID: "hook-sub", UserID: "owner-uid", Delivery: subscription.DeliveryConfig{Type: "webhook", URL: "https://example.com/hook"}}
+	subRepo.subscriptions["mail-sub"] = subscription.Subscription{ID: "mail-sub", UserID: "owner-uid", Delivery: subscription.DeliveryConfig{Type: "email"}}
+	eventRepo := newMockEventRepo()
+	eventRepo.events = append(eventRepo.events,
+		store.EventRecord{ID: "ev-latest", RawJSON: `{"_id":"ev-latest"}`},
+		store.EventRecord{ID: "ev-old", RawJSON: `{"_id":"ev-old"}`},
+		store.EventRecord{ID: "ev-empty"},
+	)
+	tester := &mockTester{result: webhook.DeliveryResult{StatusCode: 502, ErrorMessage: "unexpected status: 502", ResponseTime: 42 * time.Millisecond}}
+
+	h := NewHandler(subRepo, eventRepo)
+	h.SetTester(tester)
+	router := NewRouter(h)
+
+	request := func(method, path, uid, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req = req.WithContext(auth.WithClaims(req.Context(), &auth.Claims{UID: uid}))
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	sends := []struct {
+		name        string
+		body        string
+		wantEventID string
+		wantPayload string
+	}{
+		{"most recent event", "", "ev-latest", `{"_id":"ev-latest"}`},
+		{"empty object", "{}", "ev-latest", `{"_id":"ev-latest"}`},
+		{"selected event", `{"event_id":"ev-old"}`, "ev-old", `{"_id":"ev-old"}`},
+		{"inline payload", `{"payload":{"code":551,"test":true}}`, "", `{"code":551,"test":true}`},
+	}
+	for _, tt := range sends {
+		t.Run(tt.name, func(t *testing.T) {
+			tester.subs = nil
+			rec := request(http.MethodPost, "/api/subscriptions/hook-sub/test", "owner-uid", tt.body)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+			}
+			var resp TestDeliveryResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			// The outcome of the delivery is reported, not turned into an error status
+			if resp.Success || resp.StatusCode != 502 || resp.ErrorMessage == "" || resp.ResponseTimeMs != 42 || resp.EventID != tt.wantEventID {
+				t.Errorf("response = %+v", resp)
+			}
+			if len(tester.subs) != 1 || tester.subs[0].ID != "hook-sub" || string(tester.payload) != tt.wantPayload {
+				t.Errorf("sent %+v with %s, want %s", tester.subs, tester.payload, tt.wantPayload)
+			}
+		})
+	}
+
+	rejects := []struct {
+		name   string
+		method string
+		path   string
+		uid    string
+		body   string
+		want   int
+	}{
+		{"other users are forbidden", http.MethodPost, "/api/subscriptions/hook-sub/test", "other-uid", "", http.StatusForbidden},
+		{"unknown subscription", http.MethodPost, "/api/subscriptions/missing/test", "owner-uid", "", http.StatusNotFound},
+		{"non-webhook subscription", http.MethodPost, "/api/subscriptions/mail-sub/test", "owner-uid", "", http.StatusBadRequest},
+		{"unknown event", http.MethodPost, "/api/subscriptions/hook-sub/test", "owner-uid", `{"event_id":"ev-missing"}`, http.StatusNotFound},
+		{"event without payload", http.MethodPost, "/api/subscriptions/hook-sub/test", "owner-uid", `{"event_id":"ev-empty"}`, http.StatusNotFound},
+		{"event and payload", http.MethodPost, "/api/subscriptions/hook-sub/test", "owner-uid", `{"event_id":"ev-old","payload":{}}`, http.StatusBadRequest},
+		{"payload is not an object", http.MethodPost, "/api/subscriptions/hook-sub/test", "owner-uid", `{"payload":[1]}`, http.StatusBadRequest},
+		{"invalid body", http.MethodPost, "/api/subscriptions/hook-sub/test", "owner-uid", `{`, http.StatusBadRequest},
+		{"GET is not allowed", http.MethodGet, "/api/subscriptions/hook-sub/test", "owner-uid", "", http.StatusMethodNotAllowed},
+	}
+	for _, tt := range rejects {
+		t.Run(tt.name, func(t *testing.T) {
+			tester.subs = nil
+			rec := request(tt.method, tt.path, tt.uid, tt.body)
+			if rec.Code != tt.want {
+				t.Errorf("expected status %d, got %d: %s", tt.want, rec.Code, rec.Body.String())
+			}
+			if len(tester.subs) != 0 {
+				t.Error("nothing should be sent")
+			}
+		})
+	}
+}
+
+func TestTestSubscription_NoStoredEvents(t *testing.T) {
+	subRepo := newMockSubscriptionRepo()
+	subRepo.subscriptions["hook-sub"] = subscription.Subscription{ID: "hook-sub", Delivery: subscription.DeliveryConfig{Type: "webhook", URL: "https://example.com/hook"}}
+	tester := &mockTester{result: webhook.DeliveryResult{StatusCode: 200, Success: true}}
+
+	for name, eventRepo := range map[string]store.EventRepository{"empty": newMockEventRepo(), "not configured": nil} {
+		t.Run(name, func(t *testing.T) {
+			h := NewHandler(subRepo, eventRepo)
+			h.SetTester(tester)
+			router := NewRouter(h)
+
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/subscriptions/hook-sub/test", nil))
+			if rec.Code != http.StatusNotFound {
+				t.Errorf("expected status %d without stored events, got %d", http.StatusNotFound, rec.Code)
+			}
+
+			rec = httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/subscriptions/hook-sub/test", strings.NewReader(`{"payload":{"code":551}}`)))
+			if rec.Code != http.StatusOK {
+				t.Errorf("inline payload: expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestTestSubscription_NotConfigured(t *testing.T) {
+	router := NewRouter(NewHandler(newMockSubscriptionRepo(), newMockEventRepo()))
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/subscriptions/sub-1/test", nil))
+	if rec.Code != http.StatusNotImplemented {
+		t.Errorf("expected status %d, got %d", http.StatusNotImplemented, rec.Code)
+	}
+}
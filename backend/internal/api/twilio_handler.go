@@ -0,0 +1,62 @@
+package api
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/otiai10/namazu/backend/internal/apierr"
+	"github.com/otiai10/namazu/backend/internal/config"
+	"github.com/otiai10/namazu/backend/internal/delivery/sms"
+	"github.com/otiai10/namazu/backend/internal/store"
+)
+
+// maxTwilioCallbackBytes bounds the form body of a status callback
+const maxTwilioCallbackBytes = 64 << 10
+
+// TwilioHandler records SMS delivery results reported by Twilio status callbacks
+type TwilioHandler struct {
+	config *config.SMSConfig
+	repo   store.DeliveryRepository
+}
+
+// NewTwilioHandler creates a new TwilioHandler
+func NewTwilioHandler(cfg *config.SMSConfig, repo store.DeliveryRepository) *TwilioHandler {
+	return &TwilioHandler{config: cfg, repo: repo}
+}
+
+// StatusCallback handles POST /api/webhooks/twilio.
+// Final statuses are written to the delivery log; intermediate ones are acknowledged only.
+func (h *TwilioHandler) StatusCallback(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxTwilioCallbackBytes)
+	if err := r.ParseForm(); err != nil {
+		writeError(w, "invalid form body", http.StatusBadRequest)
+		return
+	}
+
+	signature := r.Header.Get(sms.SignatureHeader)
+	if signature == "" {
+		writeErrorCode(w, apierr.InvalidSignature, "missing "+sms.SignatureHeader+" header", http.StatusBadRequest)
+		return
+	}
+
+	// Twilio signs the URL it was given, which is the public callback URL, not r.URL
+	callbackURL := h.config.StatusCallbackURL
+	if r.URL.RawQuery != "" {
+		callbackURL += "?" + r.URL.RawQuery
+	}
+	if !sms.ValidSignature(h.config.AuthToken, callbackURL, r.PostForm, signature) {
+		writeErrorCode(w, apierr.InvalidSignature, "invalid webhook signature", http.StatusForbidden)
+		return
+	}
+
+	record, final := sms.RecordFromStatus(r.URL.Query(), r.PostForm, time.Now())
+	if final {
+		if _, err := h.repo.Create(r.Context(), record); err != nil {
+			log.Printf("Subscription %s: failed to record SMS status: %v", record.SubscriptionID, err)
+			writeError(w, "failed to record delivery", http.StatusInternalServerError)
+			return
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,72 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/otiai10/namazu/backend/internal/config"
+	"github.com/otiai10/namazu/backend/internal/delivery/sms"
+)
+
+func TestTwilioHandler_StatusCallback(t *testing.T) {
+	cfg := &config.SMSConfig{AuthToken: "token", StatusCallbackURL: "https://namazu.example.com/api/webhooks/twilio"}
+	query := "event_id=e1&payload_sha256=abc&subscription_id=s1&user_id=u1"
+
+	post := func(params url.Values, signature string) (*httptest.ResponseRecorder, *mockDeliveryRepo) {
+		repo := &mockDeliveryRepo{}
+		req := httptest.NewRequest(http.MethodPost, "/api/webhooks/twilio?"+query, strings.NewReader(params.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		if signature != "" {
+			req.Header.Set(sms.SignatureHeader, signature)
+		}
+		rec := httptest.NewRecorder()
+		NewTwilioHandler(cfg, repo).StatusCallback(rec, req)
+		return rec, repo
+	}
+	sign := func(params url.Values) string {
+		return sms.Signature(cfg.AuthToken, cfg.StatusCallbackURL+"?"+query, params)
+	}
+
+	t.Run("final status is recorded", func(t *testing.T) {
+		params := url.Values{"MessageSid": {"SM123"}, "MessageStatus": {"undelivered"}, "To": {"+819012345678"}, "ErrorCode": {"30003"}}
+		rec, repo := post(params, sign(params))
+		if rec.Code != http.StatusNoContent {
+			t.Fatalf("expected status %d, got %d: %s", http.StatusNoContent, rec.Code, rec.Body.String())
+		}
+		if len(repo.records) != 1 {
+			t.Fatalf("expected one record, got %d", len(repo.records))
+		}
+		record := repo.records[0]
+		if record.SubscriptionID != "s1" || record.UserID != "u1" || record.EventID != "e1" || record.Success ||
+			record.ErrorMessage != "undelivered (twilio error 30003)" {
+			t.Errorf("unexpected record: %+v", record)
+		}
+	})
+
+	t.Run("intermediate status is acknowledged only", func(t *testing.T) {
+		params := url.Values{"MessageSid": {"SM123"}, "MessageStatus": {"sent"}}
+		rec, repo := post(params, sign(params))
+		if rec.Code != http.StatusNoContent || len(repo.records) != 0 {
+			t.Errorf("expected 204 without a record, got %d and %d records", rec.Code, len(repo.records))
+		}
+	})
+
+	t.Run("invalid signature", func(t *testing.T) {
+		params := url.Values{"MessageSid": {"SM123"}, "MessageStatus": {"delivered"}}
+		forged := url.Values{"MessageSid": {"SM123"}, "MessageStatus": {"failed"}}
+		rec, repo := post(params, sign(forged))
+		if rec.Code != http.StatusForbidden || len(repo.records) != 0 {
+			t.Errorf("expected 403 without a record, got %d and %d records", rec.Code, len(repo.records))
+		}
+	})
+
+	t.Run("missing signature", func(t *testing.T) {
+		rec, _ := post(url.Values{"MessageStatus": {"delivered"}}, "")
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("expected status %d, got %d", http.StatusBadRequest, rec.Code)
+		}
+	})
+}
@@ -0,0 +1,66 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/otiai10/namazu/backend/internal/apierr"
+	"github.com/otiai10/namazu/backend/internal/audit"
+)
+
+// VerifySubscription handles POST /api/subscriptions/{id}/verify
+// Sends the url_verification challenge to the webhook again and marks it
+// verified if the receiver echoes it, so that an unverified subscription
+// (its URL was changed while verification was skipped, or the challenge
+// failed after a PATCH) starts receiving deliveries. A failed challenge
+// leaves the subscription as it was.
+func (h *Handler) VerifySubscription(w http.ResponseWriter, r *http.Request, id string) {
+	if h.challenger == nil {
+		writeError(w, "URL verification is not enabled", http.StatusNotImplemented)
+		return
+	}
+
+	existing, forbidden, err := h.checkOwnership(r.Context(), id)
+	if err != nil {
+		writeError(w, "failed to get subscription", http.StatusInternalServerError)
+		return
+	}
+	if existing == nil {
+		writeError(w, "subscription not found", http.StatusNotFound)
+		return
+	}
+	if forbidden {
+		writeErrorCode(w, apierr.NotOwner, "forbidden", http.StatusForbidden)
+		return
+	}
+	if existing.Delivery.Type != "webhook" {
+		writeError(w, "only webhook subscriptions are verified", http.StatusBadRequest)
+		return
+	}
+
+	if !checkPreconditions(w, r, existing) {
+		return
+	}
+
+	result := h.challenger.VerifyURL(r.Context(), existing.Delivery.URL, existing.Delivery.Secret, existing.Delivery.Headers)
+	if !result.Success {
+		writeErrorCode(w, apierr.WebhookVerificationFailed, "webhook URL verification failed: "+result.ErrorMessage, http.StatusBadRequest)
+		return
+	}
+
+	sub := *existing
+	if !sub.Delivery.Verified || sub.Delivery.VerificationSkipped {
+		sub.Delivery = copyDeliveryConfig(existing.Delivery)
+		sub.Delivery.Verified = true
+		sub.Delivery.VerificationSkipped = false
+		saved, err := saveSubscription(r.Context(), h.subscriptionRepo, id, sub)
+		if err != nil {
+			writeSaveError(w, err)
+			return
+		}
+		sub = saved
+		h.auditLog.Record(r.Context(), auditEntry(r, audit.ActionSubscriptionVerify, id, map[string]string{"name": sub.Name}))
+	}
+
+	w.Header().Set("ETag", subscriptionETag(sub))
+	writeJSON(w, subscriptionToResponse(sub), http.StatusOK)
+}
@@ -0,0 +1,126 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/otiai10/namazu/backend/internal/auth"
+	"github.com/otiai10/namazu/backend/internal/delivery/webhook"
+	"github.com/otiai10/namazu/backend/internal/plan"
+	"github.com/otiai10/namazu/backend/internal/subscription"
+)
+
+func TestCreateSubscription_SkipVerification(t *testing.T) {
+	const body = `{"name": "Hook", "delivery": {"type": "webhook", "url": "https://example.com/hook"}, "skip_verification": true}`
+	create := func(features plan.Features) *httptest.ResponseRecorder {
+		handler := NewHandlerWithQuota(newMockSubscriptionRepo(), newMockEventRepo(), newQuotaUserRepo(), &mockQuotaChecker{canCreate: true, features: features})
+		// The receiver does not answer challenges yet
+		handler.SetChallenger(&mockChallenger{result: webhook.ChallengeResult{ErrorMessage: "connection refused"}})
+		req := httptest.NewRequest(http.MethodPost, "/api/subscriptions", strings.NewReader(body))
+		req = req.WithContext(auth.WithClaims(req.Context(), &auth.Claims{UID: "user-1"}))
+		rec := httptest.NewRecorder()
+		handler.CreateSubscription(rec, req)
+		return rec
+	}
+
+	rec := create(plan.Free)
+	if rec.Code != http.StatusForbidden || !strings.Contains(rec.Body.String(), "skipping URL verification") {
+		t.Errorf("free plan: expected 403, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec = create(plan.Pro)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("pro plan: expected status %d, got %d: %s", http.StatusCreated, rec.Code, rec.Body.String())
+	}
+	var resp SubscriptionResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.Delivery.Verified || !resp.Delivery.VerificationSkipped {
+		t.Errorf("verified/skipped = %v/%v, want an unverified webhook with verification skipped", resp.Delivery.Verified, resp.Delivery.VerificationSkipped)
+	}
+}
+
+func TestCreateSubscription_IgnoresClientVerifiedFlag(t *testing.T) {
+	handler := NewHandler(newMockSubscriptionRepo(), newMockEventRepo())
+	body := `{"name": "Hook", "delivery": {"type": "webhook", "url": "https://example.com/hook", "verified": true, "verification_skipped": true}}`
+	rec := httptest.NewRecorder()
+	handler.CreateSubscription(rec, httptest.NewRequest(http.MethodPost, "/api/subscriptions", strings.NewReader(body)))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, rec.Code, rec.Body.String())
+	}
+	var resp SubscriptionResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.Delivery.Verified || resp.Delivery.VerificationSkipped {
+		t.Errorf("verified/skipped = %v/%v, want both false without a challenge", resp.Delivery.Verified, resp.Delivery.VerificationSkipped)
+	}
+}
+
+func TestVerifySubscription(t *testing.T) {
+	subRepo := newMockSubscriptionRepo()
+	subRepo.subscriptions["sub-1"] = subscription.Subscription{ID: "sub-1", Name: "Hook", Delivery: subscription.DeliveryConfig{
+		Type: "webhook", URL: "https://example.com/hook", Secret: "secret", SignVersion: "v0", VerificationSkipped: true,
+	}}
+	subRepo.subscriptions["sub-2"] = subscription.Subscription{ID: "sub-2", Name: "Mail", Delivery: subscription.DeliveryConfig{Type: "email"}}
+	handler := NewHandler(subRepo, newMockEventRepo())
+	verify := func(id string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.VerifySubscription(rec, httptest.NewRequest(http.MethodPost, "/api/subscriptions/"+id+"/verify", nil), id)
+		return rec
+	}
+
+	if rec := verify("sub-1"); rec.Code != http.StatusNotImplemented {
+		t.Errorf("without a challenger: expected status %d, got %d", http.StatusNotImplemented, rec.Code)
+	}
+
+	challenger := &mockChallenger{result: webhook.ChallengeResult{ErrorMessage: "challenge response does not match"}}
+	handler.SetChallenger(challenger)
+	if rec := verify("sub-1"); rec.Code != http.StatusBadRequest {
+		t.Errorf("failed challenge: expected status %d, got %d", http.StatusBadRequest, rec.Code)
+	}
+	if got := subRepo.subscriptions["sub-1"].Delivery; got.Verified || !got.VerificationSkipped {
+		t.Errorf("delivery = %+v, want unchanged after a failed challenge", got)
+	}
+
+	challenger.result = webhook.ChallengeResult{Success: true}
+	if rec := verify("sub-1"); rec.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+	}
+	got := subRepo.subscriptions["sub-1"].Delivery
+	if !got.Verified || got.VerificationSkipped || got.AwaitingVerification() {
+		t.Errorf("delivery = %+v, want verified", got)
+	}
+
+	if rec := verify("sub-2"); rec.Code != http.StatusBadRequest {
+		t.Errorf("email subscription: expected status %d, got %d", http.StatusBadRequest, rec.Code)
+	}
+	if rec := verify("missing"); rec.Code != http.StatusNotFound {
+		t.Errorf("missing subscription: expected status %d, got %d", http.StatusNotFound, rec.Code)
+	}
+}
+
+func TestUpdateSubscription_KeepsSkippedVerification(t *testing.T) {
+	subRepo := newMockSubscriptionRepo()
+	subRepo.subscriptions["sub-1"] = subscription.Subscription{ID: "sub-1", Name: "Hook", Delivery: subscription.DeliveryConfig{
+		Type: "webhook", URL: "https://example.com/hook", Secret: "secret", SignVersion: "v0", VerificationSkipped: true,
+	}}
+	handler := NewHandler(subRepo, newMockEventRepo())
+	handler.SetChallenger(&mockChallenger{result: webhook.ChallengeResult{ErrorMessage: "connection refused"}})
+
+	body := `{"name": "Hook", "delivery": {"type": "webhook", "url": "https://example.com/moved"}}`
+	req := httptest.NewRequest(http.MethodPut, "/api/subscriptions/sub-1", strings.NewReader(body))
+	req.Header.Set("If-Match", subscriptionETag(subRepo.subscriptions["sub-1"]))
+	rec := httptest.NewRecorder()
+	handler.UpdateSubscription(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+	}
+	if got := subRepo.subscriptions["sub-1"].Delivery; got.URL != "https://example.com/moved" || !got.VerificationSkipped || got.Verified {
+		t.Errorf("delivery = %+v, want the new URL with verification still skipped", got)
+	}
+}
@@ -0,0 +1,142 @@
+// Package apierr is the catalog of error codes of the REST API and writes
+// error responses in its envelope:
+//
+//	{"error": {"code": "quota_exceeded", "message": "...", "details": {...}}}
+//
+// Codes are stable: clients branch on them, while messages are for people
+// and may change. A response without a more specific code carries the code
+// of its HTTP status (see CodeForStatus).
+package apierr
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// Codes of the HTTP statuses, used when no more specific code applies
+const (
+	InvalidRequest       = "invalid_request"        // 400
+	Unauthenticated      = "unauthenticated"        // 401
+	Forbidden            = "forbidden"              // 403
+	NotFound             = "not_found"              // 404
+	MethodNotAllowed     = "method_not_allowed"     // 405
+	Conflict             = "conflict"               // 409
+	Gone                 = "gone"                   // 410
+	PreconditionFailed   = "precondition_failed"    // 412
+	PreconditionRequired = "precondition_required"  // 428: the request needs If-Match
+	PayloadTooLarge      = "payload_too_large"      // 413
+	UnsupportedMediaType = "unsupported_media_type" // 415
+	Unprocessable        = "unprocessable"          // 422
+	RateLimited          = "rate_limited"           // 429
+	Internal             = "internal"               // 500
+	NotImplemented       = "not_implemented"        // 501: the feature is not enabled on this server
+	BadGateway           = "bad_gateway"            // 502
+	Unavailable          = "unavailable"            // 503
+)
+
+// Validation
+const (
+	ValidationFailed          = "validation_failed"           // A field of the request is invalid
+	WebhookVerificationFailed = "webhook_verification_failed" // The webhook did not answer the URL verification challenge
+)
+
+// Authentication and ownership
+const (
+	InvalidToken          = "invalid_token"          // The ID token is invalid or expired
+	AdminRequired         = "admin_required"         // The caller is not an admin
+	NotOwner              = "not_owner"              // The resource belongs to another user
+	OwnerlessSubscription = "ownerless_subscription" // The subscription has no owner yet
+	EmailNotVerified      = "email_not_verified"     // Creating subscriptions needs a verified email address
+	TermsNotAccepted      = "terms_not_accepted"     // Creating subscriptions needs the terms of service accepted
+	TermsOutdated         = "terms_outdated"         // The accepted version is not the current one
+	InvalidSignature      = "invalid_signature"      // A Stripe or Twilio webhook signature does not match
+)
+
+// Quota and plans
+const (
+	QuotaExceeded = "quota_exceeded" // The plan's subscription limit is reached
+	PlanFeature   = "plan_feature"   // The plan does not include a feature the request uses
+)
+
+// Billing
+const (
+	PlanNotForSale       = "plan_not_for_sale"      // The plan cannot be bought with the interval
+	AlreadySubscribed    = "already_subscribed"     // The user has an active paid subscription
+	InvalidPromotionCode = "invalid_promotion_code" // The promotion code is unknown or expired
+	NoBillingCustomer    = "no_billing_customer"    // The user has no Stripe customer yet
+)
+
+// State of a resource
+const (
+	SubscriptionExpired   = "subscription_expired"    // Resuming needs a later expires_at
+	AmbiguousName         = "ambiguous_name"          // More than one subscription has the name
+	AlreadyLinked         = "already_linked"          // The sign-in provider is linked already
+	ProviderConflict      = "provider_conflict"       // The credential signs in to another account
+	LastSignInMethod      = "last_sign_in_method"     // The only sign-in provider cannot be unlinked
+	IdempotencyInProgress = "idempotency_in_progress" // A request with the Idempotency-Key is in progress
+	IdempotencyKeyReused  = "idempotency_key_reused"  // The Idempotency-Key was used with a different request
+)
+
+// Body is the error object of a response
+type Body struct {
+	Code    string         `json:"code"`
+	Message string         `json:"message"`
+	Details map[string]any `json:"details,omitempty"`
+}
+
+// Response is the envelope of an error response
+type Response struct {
+	Error Body `json:"error"`
+}
+
+// CodeForStatus returns the generic code of an HTTP status
+func CodeForStatus(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return InvalidRequest
+	case http.StatusUnauthorized:
+		return Unauthenticated
+	case http.StatusForbidden:
+		return Forbidden
+	case http.StatusNotFound:
+		return NotFound
+	case http.StatusMethodNotAllowed:
+		return MethodNotAllowed
+	case http.StatusConflict:
+		return Conflict
+	case http.StatusGone:
+		return Gone
+	case http.StatusPreconditionFailed:
+		return PreconditionFailed
+	case http.StatusRequestEntityTooLarge:
+		return PayloadTooLarge
+	case http.StatusUnsupportedMediaType:
+		return UnsupportedMediaType
+	case http.StatusUnprocessableEntity:
+		return Unprocessable
+	case http.StatusPreconditionRequired:
+		return PreconditionRequired
+	case http.StatusTooManyRequests:
+		return RateLimited
+	case http.StatusNotImplemented:
+		return NotImplemented
+	case http.StatusBadGateway:
+		return BadGateway
+	case http.StatusServiceUnavailable:
+		return Unavailable
+	}
+	if status >= 500 {
+		return Internal
+	}
+	return InvalidRequest
+}
+
+// Write writes an error response. An empty code is the code of the status.
+func Write(w http.ResponseWriter, status int, code, message string, details map[string]any) {
+	if code == "" {
+		code = CodeForStatus(status)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(Response{Error: Body{Code: code, Message: message, Details: details}})
+}
@@ -0,0 +1,54 @@
+package apierr
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWrite(t *testing.T) {
+	t.Run("code of the status", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		Write(rec, http.StatusNotFound, "", "subscription not found", nil)
+
+		if rec.Code != http.StatusNotFound || rec.Header().Get("Content-Type") != "application/json" {
+			t.Errorf("got %d %q", rec.Code, rec.Header().Get("Content-Type"))
+		}
+		want := `{"error":{"code":"not_found","message":"subscription not found"}}` + "\n"
+		if rec.Body.String() != want {
+			t.Errorf("body = %s, want %s", rec.Body.String(), want)
+		}
+	})
+
+	t.Run("specific code with details", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		Write(rec, http.StatusForbidden, QuotaExceeded, "Subscription limit reached for your plan", map[string]any{"limit": 3})
+
+		var resp Response
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		if resp.Error.Code != QuotaExceeded || resp.Error.Details["limit"] != float64(3) {
+			t.Errorf("error = %+v", resp.Error)
+		}
+	})
+}
+
+func TestCodeForStatus(t *testing.T) {
+	tests := map[int]string{
+		http.StatusBadRequest:          InvalidRequest,
+		http.StatusUnauthorized:        Unauthenticated,
+		http.StatusPreconditionFailed:  PreconditionFailed,
+		http.StatusTooManyRequests:     RateLimited,
+		http.StatusInternalServerError: Internal,
+		http.StatusNotImplemented:      NotImplemented,
+		http.StatusGatewayTimeout:      Internal,
+		http.StatusTeapot:              InvalidRequest,
+	}
+	for status, want := range tests {
+		if got := CodeForStatus(status); got != want {
+			t.Errorf("CodeForStatus(%d) = %q, want %q", status, got, want)
+		}
+	}
+}
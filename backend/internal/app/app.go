@@ -2,16 +2,34 @@ package app
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"log"
 	"sync"
+	"time"
 
+	"github.com/otiai10/namazu/backend/internal/analytics"
 	"github.com/otiai10/namazu/backend/internal/config"
+	"github.com/otiai10/namazu/backend/internal/delivery"
+	"github.com/otiai10/namazu/backend/internal/delivery/schema"
 	"github.com/otiai10/namazu/backend/internal/delivery/webhook"
+	"github.com/otiai10/namazu/backend/internal/egress"
+	"github.com/otiai10/namazu/backend/internal/logging"
+	"github.com/otiai10/namazu/backend/internal/notice"
+	"github.com/otiai10/namazu/backend/internal/plan"
 	"github.com/otiai10/namazu/backend/internal/source"
+	"github.com/otiai10/namazu/backend/internal/source/jma"
 	"github.com/otiai10/namazu/backend/internal/source/p2pquake"
 	"github.com/otiai10/namazu/backend/internal/store"
+	"github.com/otiai10/namazu/backend/internal/stream"
 	"github.com/otiai10/namazu/backend/internal/subscription"
+	"github.com/otiai10/namazu/backend/internal/tenant"
+	"github.com/otiai10/namazu/backend/internal/throttle"
+	"github.com/otiai10/namazu/backend/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Client interface abstracts the p2pquake.Client for testing
@@ -40,12 +58,74 @@ type App struct {
 	sender       Sender
 	singleSender SingleSender
 	repository   subscription.Repository
-	eventRepo    store.EventRepository // optional, can be nil
+	eventRepo    store.EventRepository    // optional, can be nil
+	retryRepo    store.RetryRepository    // optional, can be nil
+	deliveryRepo store.DeliveryRepository // optional, can be nil
+	egress       *egress.Meter            // optional, can be nil
+	plans        PlanFeatures             // optional; nil applies no plan restrictions
+	health       *delivery.HealthTracker  // optional, can be nil
+	tenants      *tenant.Registry         // optional, can be nil
+	dispatchers  *delivery.Registry       // delivery channels keyed by DeliveryConfig.Type
+	queue        *delivery.Queue          // optional; nil delivers within the event loop
+	stream       *stream.Hub              // optional, can be nil
+	throttle     *throttle.Limiter        // optional; nil ignores subscription throttles
+	digestRepo   store.DigestRepository   // optional; nil keeps pending digests in memory only
+	rollups      analytics.Repository     // optional; nil maintains no analytics rollups
+	validate     bool                     // check generated payloads against their JSON Schemas
+	digestTick   time.Duration            // how often Run looks for due digests
+	digestMu     sync.Mutex
+	digests      map[string]*store.PendingDigest // keyed by digestKey
+	retrySweep   time.Duration                   // how often Run looks for pending retries nobody is running
+	retryMu      sync.Mutex                      // guards retrying
+	retrying     map[string]struct{}             // pending retry IDs being delivered by this process
+	broadcasts   chan broadcast                  // notices waiting for the event loop
+	injected     chan source.Event               // synthetic events waiting for the event loop
+	history      History                         // optional; nil skips backfilling after reconnections
+	backfills    chan source.Event               // missed events waiting for the event loop
+	background   sync.WaitGroup                  // tracks deliveries running outside the event loop
+	drainTimeout time.Duration                   // how long Run lets deliveries finish after it stops
+	draining     chan struct{}                   // closed when Run stops accepting events
+	startedAt    time.Time
+	counters     counters
 }
 
+// broadcastQueueSize is the number of notices that can wait for the event loop
+const broadcastQueueSize = 16
+
+// defaultRetrySweep is how often Run looks for pending retries that are not
+// running, e.g. because resuming them failed on a transient error
+const defaultRetrySweep = 5 * time.Minute
+
+// DefaultDrainTimeout is how long Run lets deliveries in flight finish after
+// its context is cancelled. It stays under the 10 seconds Cloud Run allows
+// between SIGTERM and SIGKILL.
+const DefaultDrainTimeout = 8 * time.Second
+
+// injectQueueSize is the number of injected events that can wait for the event loop
+const injectQueueSize = 64
+
+// ErrInjectQueueFull is returned by Inject when the event loop is behind
+var ErrInjectQueueFull = errors.New("too many injected events pending")
+
 // Option is a functional option for configuring the App.
 type Option func(*App)
 
+// WithClient replaces the P2P地震情報 client with another event source.
+// It is used to inject synthetic events, e.g. by the load test harness.
+func WithClient(c Client) Option {
+	return func(a *App) {
+		a.client = c
+	}
+}
+
+// WithDispatcher registers the dispatcher for a delivery type (DeliveryConfig.Type),
+// replacing any existing one. The "webhook" dispatcher is registered by default.
+func WithDispatcher(deliveryType string, d delivery.Dispatcher) Option {
+	return func(a *App) {
+		a.dispatchers.Register(deliveryType, d)
+	}
+}
+
 // WithEventRepository sets the event repository for storing events.
 // If not provided, events will not be persisted.
 func WithEventRepository(repo store.EventRepository) Option {
@@ -54,6 +134,130 @@ func WithEventRepository(repo store.EventRepository) Option {
 	}
 }
 
+// WithRetryRepository sets the repository used to persist pending retries.
+// When provided together with an event repository, unfinished retry schedules
+// survive restarts and are resumed when Run starts.
+func WithRetryRepository(repo store.RetryRepository) Option {
+	return func(a *App) {
+		a.retryRepo = repo
+	}
+}
+
+// WithDeliveryRepository sets the repository that records the final outcome
+// of every delivery. The records back the signed delivery log exports.
+func WithDeliveryRepository(repo store.DeliveryRepository) Option {
+	return func(a *App) {
+		a.deliveryRepo = repo
+	}
+}
+
+// WithEgressMeter sets the meter used to attribute webhook egress to users.
+// Deliveries of users who exceeded their egress budget are throttled.
+func WithEgressMeter(m *egress.Meter) Option {
+	return func(a *App) {
+		a.egress = m
+	}
+}
+
+// PlanFeatures looks up the features of a subscription owner's plan
+type PlanFeatures interface {
+	Features(ctx context.Context, tenantID, uid string) (plan.Features, error)
+}
+
+// WithPlanFeatures restricts owned subscriptions to the features of their
+// owner's plan, e.g. after a downgrade. See plan.Features.Restrict.
+func WithPlanFeatures(p PlanFeatures) Option {
+	return func(a *App) {
+		a.plans = p
+	}
+}
+
+// WithHealthTracker sets the tracker that records delivery outcomes per subscription.
+// It backs the public subscription health badges.
+func WithHealthTracker(t *delivery.HealthTracker) Option {
+	return func(a *App) {
+		a.health = t
+	}
+}
+
+// WithTenants sets the white-label tenants. Webhooks of a tenant's
+// subscriptions are sent with the tenant's sender name as User-Agent.
+func WithTenants(reg *tenant.Registry) Option {
+	return func(a *App) {
+		a.tenants = reg
+	}
+}
+
+// WithResolver makes webhook deliveries resolve hosts through a caching resolver.
+func WithResolver(r *webhook.Resolver) Option {
+	return WithSender(webhook.NewSender(webhook.WithResolver(r)))
+}
+
+// WithSender delivers webhooks with s, e.g. one that sends through an egress proxy.
+func WithSender(s *webhook.Sender) Option {
+	return func(a *App) {
+		a.sender = s
+		a.singleSender = s
+	}
+}
+
+// WithDeliveryQueue delivers webhooks through a worker pool instead of within
+// the event loop. Run starts the queue's workers.
+func WithDeliveryQueue(q *delivery.Queue) Option {
+	return func(a *App) {
+		a.queue = q
+	}
+}
+
+// WithDrainTimeout sets how long Run lets deliveries in flight finish after
+// its context is cancelled (default DefaultDrainTimeout). Zero or less cancels
+// them right away.
+func WithDrainTimeout(d time.Duration) Option {
+	return func(a *App) {
+		a.drainTimeout = d
+	}
+}
+
+// WithStream publishes every event to the live stream hub, which forwards it
+// to connected clients whose filters match.
+func WithStream(h *stream.Hub) Option {
+	return func(a *App) {
+		a.stream = h
+	}
+}
+
+// WithThrottle enforces the per-subscription throttles with the limiter.
+// Run restores the windows the limiter persisted before a restart.
+func WithThrottle(l *throttle.Limiter) Option {
+	return func(a *App) {
+		a.throttle = l
+	}
+}
+
+// WithDigestRepository persists the events collected for digests, so they
+// are still delivered after a restart. Run restores them.
+func WithDigestRepository(repo store.DigestRepository) Option {
+	return func(a *App) {
+		a.digestRepo = repo
+	}
+}
+
+// WithRollups adds each stored earthquake to its day's analytics rollup
+func WithRollups(repo analytics.Repository) Option {
+	return func(a *App) {
+		a.rollups = repo
+	}
+}
+
+// WithPayloadValidation checks the payloads the app generates (namazu.v1
+// bodies, service notices and digests) against their published JSON Schemas
+// and logs violations. It is meant for debugging; payloads are sent either way.
+func WithPayloadValidation(enabled bool) Option {
+	return func(a *App) {
+		a.validate = enabled
+	}
+}
+
 // NewApp creates a new application instance with the provided configuration and repository.
 // It initializes the P2P地震情報 WebSocket client and webhook sender.
 //
@@ -85,11 +289,23 @@ func NewApp(cfg *config.Config, repo subscription.Repository, opts ...Option) *A
 	baseSender := webhook.NewSender()
 	app := &App{
 		config:       cfg,
-		client:       p2pquake.NewClient(cfg.Source.Endpoint),
 		sender:       baseSender,
 		singleSender: baseSender,
 		repository:   repo,
+		dispatchers:  delivery.NewRegistry(),
+		broadcasts:   make(chan broadcast, broadcastQueueSize),
+		injected:     make(chan source.Event, injectQueueSize),
+		backfills:    make(chan source.Event),
+		digestTick:   defaultDigestTick,
+		retrySweep:   defaultRetrySweep,
+		retrying:     make(map[string]struct{}),
+		drainTimeout: DefaultDrainTimeout,
+		draining:     make(chan struct{}),
+		digests:      make(map[string]*store.PendingDigest),
+		startedAt:    time.Now(),
 	}
+	app.client, app.history = newClient(cfg.Source, app.backfill)
+	app.dispatchers.Register("webhook", delivery.DispatcherFunc(app.dispatchWebhooks))
 
 	for _, opt := range opts {
 		opt(app)
@@ -98,6 +314,28 @@ func NewApp(cfg *config.Config, repo subscription.Repository, opts ...Option) *A
 	return app
 }
 
+// newClient creates the event source selected by source.type, and the history
+// of the P2P地震情報 WebSocket, which onReconnect backfills from.
+// Validate rejects unknown types; p2pquake is the fallback.
+func newClient(cfg config.SourceConfig, onReconnect func(ctx context.Context)) (Client, History) {
+	if cfg.Type == "jma" {
+		return jma.NewClient(cfg.JMAFeed), nil
+	}
+
+	ws := p2pquake.NewClient(cfg.Endpoint)
+	ws.OnReconnect(onReconnect)
+	historyURL := cfg.History
+	if historyURL == "" {
+		historyURL = p2pquake.HistoryURL(cfg.Endpoint)
+	}
+	history := p2pquake.NewHistory(historyURL)
+
+	if cfg.Type == "multi" {
+		return source.NewMulti(ws, jma.NewClient(cfg.JMAFeed)), history
+	}
+	return ws, history
+}
+
 // Run starts the application and blocks until the context is cancelled.
 // It connects to the P2P地震情報 WebSocket API, processes incoming events,
 // and fans them out to all configured webhook targets.
@@ -135,26 +373,104 @@ func NewApp(cfg *config.Config, repo subscription.Repository, opts ...Option) *A
 //	    log.Fatal(err)
 //	}
 func (a *App) Run(ctx context.Context) error {
-	log.Printf("Starting namazu - connecting to %s", a.config.Source.Endpoint)
+	log.Printf("Starting namazu - source %s", a.config.Source.Type)
 
-	// Connect to P2P地震情報 API
+	// Connect to the event source
 	if err := a.client.Connect(ctx); err != nil {
 		return err
 	}
 	defer a.client.Close()
 
+	// Events are handled under a context of their own, so deliveries in
+	// flight when ctx is cancelled can finish while Run drains
+	stopAccepting := ctx
+	ctx, stop := context.WithCancel(context.WithoutCancel(ctx))
+	defer stop()
+
+	// Deliveries run on the queue's workers so the event loop never waits for a subscriber
+	if a.queue != nil {
+		a.queue.Start(ctx)
+		defer a.queue.Wait()
+	}
+
+	// Keep swarms throttled across restarts
+	if a.throttle != nil {
+		if err := a.throttle.Restore(ctx); err != nil {
+			log.Printf("Failed to restore throttle windows: %v", err)
+		}
+	}
+
+	// Resume retries left unfinished by a previous run, and later ones that
+	// could not be resumed because of a transient error
+	a.resumePendingRetries(ctx)
+	defer a.background.Wait()
+	retryTicker := time.NewTicker(a.retrySweep)
+	defer retryTicker.Stop()
+
+	// Deliver digests collected before a restart once they are due
+	a.restoreDigests(ctx)
+	digestTicker := time.NewTicker(a.digestTick)
+	defer digestTicker.Stop()
+
 	// Process events
 	for {
 		select {
-		case <-ctx.Done():
+		case <-stopAccepting.Done():
 			log.Println("Shutting down...")
+			a.drain()
+			stop()
 			return nil
 		case event := <-a.client.Events():
 			a.handleEvent(ctx, event)
+		case event := <-a.injected:
+			a.handleEvent(ctx, event)
+		case event := <-a.backfills:
+			a.handleEvent(ctx, event)
+		case b := <-a.broadcasts:
+			a.handleBroadcast(ctx, b)
+		case now := <-digestTicker.C:
+			a.flushDigests(ctx, now)
+		case <-retryTicker.C:
+			a.resumePendingRetries(ctx)
 		}
 	}
 }
 
+// drain waits up to drainTimeout for the deliveries in flight once Run has
+// stopped accepting events: jobs left in the delivery queue and deliveries
+// running in the background. Retries waiting for their next attempt stop
+// waiting right away, as their schedule is persisted and resumed at the next
+// start. Run cancels whatever is left when drain returns.
+func (a *App) drain() {
+	close(a.draining)
+	if a.drainTimeout <= 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), a.drainTimeout)
+	defer cancel()
+	start := time.Now()
+	if a.queue != nil {
+		if err := a.queue.Drain(ctx); err != nil {
+			stats := a.queue.Stats()
+			log.Printf("Delivery queue not drained within %v, cancelling %d running and %d queued delivery(ies)",
+				a.drainTimeout, stats.Busy, stats.Depth)
+			return
+		}
+	}
+	done := make(chan struct{})
+	go func() {
+		a.background.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		log.Printf("Deliveries drained in %v", time.Since(start).Round(time.Millisecond))
+	case <-ctx.Done():
+		log.Printf("Background deliveries not drained within %v, cancelling them", a.drainTimeout)
+	}
+}
+
 // handleEvent processes a single earthquake event and sends it to all webhooks.
 // It queries the repository for current subscriptions, extracts the raw JSON payload
 // from the event, and uses the webhook sender to deliver it to all targets in parallel.
@@ -171,18 +487,104 @@ func (a *App) Run(ctx context.Context) error {
 // If the event's RawJSON is empty, the method falls back to JSON encoding
 // the event structure itself.
 func (a *App) handleEvent(ctx context.Context, event source.Event) {
-	log.Printf("Received earthquake: ID=%s, Severity=%d, Source=%s",
-		event.GetID(), event.GetSeverity(), event.GetSource())
+	ctx, span := startEventSpan(ctx, event)
+	defer span.End()
+
+	if event.GetType() == source.EventTypeEEW {
+		a.handleEEW(ctx, event)
+		return
+	}
+
+	if a.alreadyStored(ctx, event) {
+		return
+	}
+
+	a.counters.events.Add(1)
+	log.Printf("Received earthquake: ID=%s, Severity=%d, Source=%s, Backfilled=%t",
+		event.GetID(), event.GetSeverity(), event.GetSource(), source.IsBackfilled(event))
 
 	// Save event to repository (if configured)
+	eventID := ""
 	if a.eventRepo != nil {
 		record := store.EventFromSource(event)
-		if _, err := a.eventRepo.Create(ctx, record); err != nil {
+		id, err := a.saveEvent(ctx, record)
+		if err != nil {
 			log.Printf("Failed to save event: %v", err)
 			// Continue processing even if save fails
+		} else {
+			eventID = id
+			a.addToRollup(ctx, record)
 		}
 	}
 
+	a.deliverEvent(ctx, event, eventID)
+}
+
+// startEventSpan starts the span covering the processing of an event,
+// continuing the trace of its receipt when the source recorded one.
+func startEventSpan(ctx context.Context, event source.Event) (context.Context, trace.Span) {
+	if traced, ok := event.(source.Traced); ok {
+		ctx = tracing.Link(ctx, traced.GetSpanContext())
+	}
+	return tracing.Start(ctx, "namazu.event",
+		attribute.String("namazu.event.id", event.GetID()),
+		attribute.String("namazu.event.type", string(event.GetType())),
+		attribute.String("namazu.event.source", event.GetSource()),
+		attribute.Int("namazu.event.severity", event.GetSeverity()))
+}
+
+// saveEvent persists an event, traced as a "store.save_event" span
+func (a *App) saveEvent(ctx context.Context, record store.EventRecord) (string, error) {
+	ctx, span := tracing.Start(ctx, "store.save_event")
+	defer span.End()
+	id, err := a.eventRepo.Create(ctx, record)
+	if err != nil {
+		tracing.Fail(span, err.Error())
+	}
+	return id, err
+}
+
+// addToRollup counts a stored event in the analytics rollups
+func (a *App) addToRollup(ctx context.Context, record store.EventRecord) {
+	if a.rollups == nil {
+		return
+	}
+	if err := analytics.Record(ctx, a.rollups, record); err != nil {
+		log.Printf("Failed to add event %s to analytics rollup: %v", record.ID, err)
+	}
+}
+
+// handleEEW delivers an Earthquake Early Warning before persisting it.
+// A warning is only useful in the seconds before shaking arrives, so the
+// Firestore write is taken off the hot path and done in the background.
+// The event is stored under its source ID, which delivery records and
+// persisted retries can refer to before the write completes.
+func (a *App) handleEEW(ctx context.Context, event source.Event) {
+	log.Printf("Received EEW: ID=%s, Severity=%d, Source=%s",
+		event.GetID(), event.GetSeverity(), event.GetSource())
+
+	eventID := ""
+	if a.eventRepo != nil {
+		record := store.EventFromSource(event)
+		eventID = record.ID
+		a.background.Add(1)
+		go func() {
+			defer a.background.Done()
+			if _, err := a.saveEvent(ctx, record); err != nil {
+				log.Printf("Failed to save EEW %s: %v", record.ID, err)
+			}
+		}()
+	}
+
+	a.deliverEvent(ctx, event, eventID)
+}
+
+// deliverEvent delivers an event to the live stream and to the subscriptions whose filters match.
+func (a *App) deliverEvent(ctx context.Context, event source.Event, eventID string) {
+	if a.stream != nil {
+		a.stream.Publish(event, eventID)
+	}
+
 	// Get current subscriptions (dynamic)
 	subscriptions, err := a.repository.List(ctx)
 	if err != nil {
@@ -204,11 +606,180 @@ func (a *App) handleEvent(ctx context.Context, event source.Event) {
 		}
 	}
 
-	// Filter and collect webhook subscriptions
-	webhookSubs := filterWebhookSubscriptions(subscriptions, event)
+	// Deliver to the matching subscriptions over their channels
+	_, span := tracing.Start(ctx, "namazu.filter", attribute.Int("namazu.subscriptions", len(subscriptions)))
+	filtered := filterSubscriptions(a.applyPlans(ctx, subscriptions), event)
+	matched := a.applyThrottles(ctx, filtered, event)
+	span.SetAttributes(attribute.Int("namazu.matched", len(matched)))
+	span.End()
+	matched = a.collectDigests(ctx, matched, event, eventID)
+	a.dispatch(ctx, delivery.Message{ID: eventID, Payload: payload, Event: event}, matched)
+	a.recordMatches(ctx, filtered)
+}
+
+// applyPlans restricts owned subscriptions to the features of their owner's
+// plan. Subscriptions whose owner's plan cannot be read are left unrestricted.
+func (a *App) applyPlans(ctx context.Context, subs []subscription.Subscription) []subscription.Subscription {
+	if a.plans == nil {
+		return subs
+	}
+	result := make([]subscription.Subscription, 0, len(subs))
+	for _, sub := range subs {
+		if sub.UserID == "" {
+			result = append(result, sub)
+			continue
+		}
+		features, err := a.plans.Features(ctx, sub.TenantID, sub.UserID)
+		if err != nil {
+			log.Printf("Subscription [%s]: failed to get plan features: %v", sub.Name, err)
+			result = append(result, sub)
+			continue
+		}
+		restricted, ok := features.Restrict(sub)
+		if !ok {
+			log.Printf("Subscription [%s]: skipped (delivery type %q not in plan)", sub.Name, sub.Delivery.Type)
+			continue
+		}
+		result = append(result, restricted)
+	}
+	return result
+}
+
+// dispatch hands subscriptions to the dispatchers of their delivery types.
+func (a *App) dispatch(ctx context.Context, msg delivery.Message, subs []subscription.Subscription) {
+	for _, sub := range a.dispatchers.Dispatch(ctx, msg, subs) {
+		log.Printf("Subscription [%s]: no dispatcher for delivery type %q", sub.Name, sub.Delivery.Type)
+	}
+}
+
+// dispatchWebhooks is the dispatcher of "webhook" subscriptions.
+// Subscriptions with a payload format, template or enrichment get their own
+// rendered body and are delivered alongside the others; if rendering fails they are skipped.
+func (a *App) dispatchWebhooks(ctx context.Context, msg delivery.Message, subs []subscription.Subscription) {
+	eventID := msg.ID
+	if eventID == "" && msg.Event != nil {
+		eventID = msg.Event.GetID()
+	}
+	targets := make([]deliveryTarget, 0, len(subs))
+	var wg sync.WaitGroup
+	for _, sub := range subs {
+		target := webhookTarget(sub, eventID)
+		target.UserAgent = a.senderName(sub)
+		target.Backfilled = source.IsBackfilled(msg.Event)
+		dt := deliveryTarget{sub: sub, target: target}
+		if !customPayload(sub) {
+			targets = append(targets, dt)
+			continue
+		}
+
+		payload, err := renderPayload(sub, msg.Event, msg.Payload)
+		if err != nil {
+			log.Printf("Subscription [%s]: payload template failed, not delivered - %v", sub.Name, err)
+			continue
+		}
+		if name := payloadSchema(sub, msg.Event); name != "" {
+			a.checkPayload(name, payload)
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			a.deliverToSubscriptions(ctx, []deliveryTarget{dt}, payload, msg.ID)
+		}()
+	}
+	a.deliverToSubscriptions(ctx, targets, msg.Payload, msg.ID)
+	wg.Wait()
+}
+
+// broadcast is a notice queued for delivery
+type broadcast struct {
+	notice  notice.Notice
+	subs    []subscription.Subscription
+	payload []byte
+}
+
+// Broadcast queues a service notice for delivery to every subscription
+// that opted into service notices, and returns the number of recipients.
+// The notice goes through the normal delivery pipeline (signing, retries,
+// egress metering, health and delivery records) once Run picks it up;
+// earthquake events are not held up while it is delivered.
+func (a *App) Broadcast(ctx context.Context, n notice.Notice) (int, error) {
+	if err := n.Validate(); err != nil {
+		return 0, err
+	}
+	payload, err := json.Marshal(n)
+	if err != nil {
+		return 0, err
+	}
+	a.checkPayload(schema.SchemaNotice, payload)
+
+	subscriptions, err := a.repository.List(ctx)
+	if err != nil {
+		return 0, err
+	}
+	subs := a.filterNoticeSubscriptions(subscriptions)
 
-	// Deliver to all filtered subscriptions concurrently
-	a.deliverToSubscriptions(ctx, webhookSubs, payload)
+	select {
+	case a.broadcasts <- broadcast{notice: n, subs: subs, payload: payload}:
+		return len(subs), nil
+	default:
+		return 0, notice.ErrQueueFull
+	}
+}
+
+// Inject queues a synthetic event, e.g. from cmd/quakegen, for processing as
+// if it had been received from the event source. It is stored, filtered and
+// delivered to real subscribers like any other event.
+func (a *App) Inject(ctx context.Context, event source.Event) error {
+	select {
+	case a.injected <- event:
+		log.Printf("Injected event queued: ID=%s, Source=%s", event.GetID(), event.GetSource())
+		return nil
+	default:
+		return ErrInjectQueueFull
+	}
+}
+
+// SourceStatus reports the connection of the event source.
+// ok is false for sources that poll instead of holding a connection (the JMA feed).
+func (a *App) SourceStatus() (status source.Status, ok bool) {
+	monitored, ok := a.client.(source.Monitored)
+	if !ok {
+		return source.Status{}, false
+	}
+	return monitored.Status(), true
+}
+
+// handleBroadcast delivers a queued notice in the background.
+func (a *App) handleBroadcast(ctx context.Context, b broadcast) {
+	log.Printf("Broadcasting notice: ID=%s, Severity=%s, Title=%q to %d subscription(s)",
+		b.notice.ID, b.notice.Severity, b.notice.Title, len(b.subs))
+
+	a.background.Add(1)
+	go func() {
+		defer a.background.Done()
+		a.dispatch(ctx, delivery.Message{ID: b.notice.ID, Payload: b.payload}, b.subs)
+	}()
+}
+
+// filterNoticeSubscriptions selects the subscriptions that opted into service
+// notices over a channel with a dispatcher. Event filters do not apply to notices.
+func (a *App) filterNoticeSubscriptions(subs []subscription.Subscription) []subscription.Subscription {
+	now := time.Now()
+	result := make([]subscription.Subscription, 0, len(subs))
+	for _, sub := range subs {
+		if !sub.Delivery.ServiceNotices || !sub.Deliverable(now) {
+			continue
+		}
+		if _, ok := a.dispatchers.Get(sub.Delivery.Type); !ok {
+			continue
+		}
+		// Skip unverified v0 subscriptions
+		if sub.Delivery.AwaitingVerification() {
+			continue
+		}
+		result = append(result, sub)
+	}
+	return result
 }
 
 // deliveryTarget holds subscription info for delivery
@@ -217,41 +788,146 @@ type deliveryTarget struct {
 	target webhook.Target
 }
 
-// filterWebhookSubscriptions filters subscriptions to only include webhook
-// subscriptions that match the event filter.
-func filterWebhookSubscriptions(subs []subscription.Subscription, event source.Event) []deliveryTarget {
-	targets := make([]deliveryTarget, 0, len(subs))
+// filterSubscriptions filters subscriptions to those that match the event filter.
+func filterSubscriptions(subs []subscription.Subscription, event source.Event) []subscription.Subscription {
+	now := time.Now()
+	result := make([]subscription.Subscription, 0, len(subs))
 	for _, sub := range subs {
-		if sub.Delivery.Type != "webhook" {
-			continue
-		}
 		// Skip unverified v0 subscriptions
-		if sub.Delivery.SignVersion == "v0" && !sub.Delivery.Verified {
+		if sub.Delivery.AwaitingVerification() {
 			log.Printf("Subscription [%s]: skipped (unverified v0)", sub.Name)
 			continue
 		}
+		// Skip paused, suspended and expired subscriptions
+		if !sub.Deliverable(now) {
+			log.Printf("Subscription [%s]: skipped (paused, suspended or expired)", sub.Name)
+			continue
+		}
 		// Check filter - skip if event doesn't match
-		if sub.Filter != nil && !sub.Filter.Matches(event) {
-			log.Printf("Subscription [%s]: filtered out (MinScale=%d, Prefectures=%v)",
-				sub.Name, sub.Filter.MinScale, sub.Filter.Prefectures)
+		if !sub.Filter.Matches(event) {
+			if sub.Filter == nil {
+				log.Printf("Subscription [%s]: filtered out (Type=%s, EventTypes=default)", sub.Name, event.GetType())
+			} else {
+				log.Printf("Subscription [%s]: filtered out (Type=%s, EventTypes=%v, MinScale=%d, Prefectures=%v, MinMagnitude=%g, MaxDepthKm=%d, HypocenterNameContains=%q, Geofence=%+v)",
+					sub.Name, event.GetType(), sub.Filter.EventTypes, sub.Filter.MinScale, sub.Filter.Prefectures,
+					sub.Filter.MinMagnitude, sub.Filter.MaxDepthKm, sub.Filter.HypocenterNameContains, sub.Filter.Geofence)
+			}
 			continue
 		}
-		targets = append(targets, deliveryTarget{
-			sub: sub,
-			target: webhook.Target{
-				URL:         sub.Delivery.URL,
-				Secret:      sub.Delivery.Secret,
-				Name:        sub.Name,
-				SignVersion: sub.Delivery.SignVersion,
-			},
-		})
+		if !sub.QuietHours.Allows(event, now) {
+			log.Printf("Subscription [%s]: suppressed (quiet hours %s-%s, MinScaleOverride=%d)",
+				sub.Name, sub.QuietHours.Start, sub.QuietHours.End, sub.QuietHours.MinScaleOverride)
+			continue
+		}
+		if !sub.Delivery.SMS.Allows(event) {
+			log.Printf("Subscription [%s]: below the SMS minimum scale", sub.Name)
+			continue
+		}
+		result = append(result, sub)
+	}
+	return result
+}
+
+// applyThrottles drops the subscriptions whose throttle window holds back the event.
+func (a *App) applyThrottles(ctx context.Context, subs []subscription.Subscription, event source.Event) []subscription.Subscription {
+	if a.throttle == nil {
+		return subs
+	}
+	result := make([]subscription.Subscription, 0, len(subs))
+	for _, sub := range subs {
+		if !a.throttle.Allow(ctx, sub, event) {
+			log.Printf("Subscription [%s]: throttled (IntervalMinutes=%d, DedupeBy=%q)",
+				sub.Name, sub.Throttle.IntervalMinutes, sub.Throttle.DedupeBy)
+			continue
+		}
+		result = append(result, sub)
+	}
+	return result
+}
+
+// webhookTarget builds the webhook target for delivering an event to a
+// subscription, with a new delivery ID. During a secret rotation grace period
+// it is signed with the previous secret too.
+func webhookTarget(sub subscription.Subscription, eventID string) webhook.Target {
+	return webhook.Target{
+		DeliveryID:     webhook.NewDeliveryID(),
+		EventID:        eventID,
+		URL:            sub.Delivery.URL,
+		Secret:         sub.Delivery.Secret,
+		PreviousSecret: sub.Delivery.ActivePreviousSecret(time.Now()),
+		Name:           sub.Name,
+		SignVersion:    sub.Delivery.SignVersion,
+		Headers:        sub.Delivery.Headers,
+	}
+}
+
+// senderName returns the User-Agent for a subscription's webhooks.
+// Empty means the sender default.
+func (a *App) senderName(sub subscription.Subscription) string {
+	if a.tenants == nil {
+		return ""
+	}
+	return a.tenants.Get(sub.TenantID).SenderName
+}
+
+// deliverToSubscriptions sends the payload to all targets. Targets whose owner
+// exceeded their egress budget are delivered in the background once the
+// throttle allows; the rest are sent immediately.
+// eventID identifies the stored event and is used to persist pending retries.
+func (a *App) deliverToSubscriptions(ctx context.Context, targets []deliveryTarget, payload []byte, eventID string) {
+	immediate, throttled := a.splitThrottled(ctx, targets)
+	for _, dt := range throttled {
+		a.background.Add(1)
+		go func(dt deliveryTarget) {
+			defer a.background.Done()
+			if err := a.egress.Wait(ctx, dt.sub.UserID); err != nil {
+				log.Printf("Subscription [%s]: throttled delivery abandoned - %v", dt.target.Name, err)
+				return
+			}
+			a.sendToTargets(ctx, []deliveryTarget{dt}, payload, eventID)
+		}(dt)
+	}
+	a.sendToTargets(ctx, immediate, payload, eventID)
+}
+
+// splitThrottled separates targets whose owner is over their egress budget.
+// Each owner is checked once. If the budget cannot be checked, the delivery
+// is not delayed.
+func (a *App) splitThrottled(ctx context.Context, targets []deliveryTarget) (immediate, throttled []deliveryTarget) {
+	if a.egress == nil {
+		return targets, nil
+	}
+	immediate = make([]deliveryTarget, 0, len(targets))
+	owners := make(map[string]bool) // exceeded per owner
+	for _, dt := range targets {
+		exceeded, checked := owners[dt.sub.UserID]
+		if !checked {
+			var err error
+			exceeded, err = a.egress.Exceeded(ctx, dt.sub.UserID)
+			if err != nil {
+				log.Printf("Subscription [%s]: failed to check egress budget: %v", dt.target.Name, err)
+			}
+			owners[dt.sub.UserID] = exceeded
+		}
+		if exceeded {
+			log.Printf("Subscription [%s]: egress budget exceeded, throttling", dt.target.Name)
+			throttled = append(throttled, dt)
+			continue
+		}
+		immediate = append(immediate, dt)
 	}
-	return targets
+	return immediate, throttled
 }
 
-// deliverToSubscriptions sends the payload to all targets concurrently,
+// sendToTargets sends the payload to all targets concurrently,
 // using per-subscription retry configuration if available.
-func (a *App) deliverToSubscriptions(ctx context.Context, targets []deliveryTarget, payload []byte) {
+// With a delivery queue, it only enqueues one job per target.
+func (a *App) sendToTargets(ctx context.Context, targets []deliveryTarget, payload []byte, eventID string) {
+	if a.queue != nil {
+		a.enqueueTargets(ctx, targets, payload, eventID)
+		return
+	}
+
 	// Check if any subscription has retry config
 	hasRetryConfig := false
 	for _, dt := range targets {
@@ -271,6 +947,9 @@ func (a *App) deliverToSubscriptions(ctx context.Context, targets []deliveryTarg
 		for i, result := range results {
 			logDeliveryResult(targets[i].target.Name, result)
 		}
+		a.recordEgress(ctx, targets, results, payload)
+		a.recordHealth(targets, results)
+		a.recordDeliveries(ctx, targets, results, payload, eventID)
 		return
 	}
 
@@ -282,33 +961,235 @@ func (a *App) deliverToSubscriptions(ctx context.Context, targets []deliveryTarg
 		wg.Add(1)
 		go func(index int, target deliveryTarget) {
 			defer wg.Done()
-			results[index] = a.deliverWithRetry(ctx, target, payload)
+			results[index] = a.deliverWithRetry(ctx, target, payload, eventID)
 		}(i, dt)
 	}
 
 	wg.Wait()
+	targets, results = settled(targets, results)
 
 	// Log results
 	for i, result := range results {
 		logDeliveryResult(targets[i].target.Name, result)
 	}
+	a.recordEgress(ctx, targets, results, payload)
+	a.recordHealth(targets, results)
+	a.recordDeliveries(ctx, targets, results, payload, eventID)
+}
+
+// enqueueTargets hands one job per target to the delivery queue, so a slow
+// subscriber occupies a single worker instead of holding up every delivery.
+// It blocks while the queue is full.
+// Jobs continue the trace of the event, since workers run under their own context.
+func (a *App) enqueueTargets(ctx context.Context, targets []deliveryTarget, payload []byte, eventID string) {
+	sc := trace.SpanContextFromContext(ctx)
+	for _, dt := range targets {
+		dt := dt
+		err := a.queue.Enqueue(ctx, func(ctx context.Context) {
+			a.deliverTarget(tracing.Link(ctx, sc), dt, payload, eventID)
+		})
+		if err != nil {
+			log.Printf("Subscription [%s]: delivery abandoned - %v", dt.target.Name, err)
+		}
+	}
+}
+
+// deliverTarget sends the payload to a single target and records the outcome.
+func (a *App) deliverTarget(ctx context.Context, dt deliveryTarget, payload []byte, eventID string) {
+	var result webhook.DeliveryResult
+	if dt.sub.Delivery.Retry != nil && dt.sub.Delivery.Retry.Enabled {
+		result = a.deliverWithRetry(ctx, dt, payload, eventID)
+	} else {
+		results := a.sender.SendAll(ctx, []webhook.Target{dt.target}, payload)
+		if len(results) == 0 {
+			return
+		}
+		result = results[0]
+	}
+
+	targets, results := settled([]deliveryTarget{dt}, []webhook.DeliveryResult{result})
+	if len(results) == 0 {
+		return
+	}
+	logDeliveryResult(dt.target.Name, result)
+	a.recordEgress(ctx, targets, results, payload)
+	a.recordHealth(targets, results)
+	a.recordDeliveries(ctx, targets, results, payload, eventID)
+}
+
+// settled drops the deliveries whose retry was deferred by a drain. They are
+// not finished: their schedule resumes at the next start and is recorded then.
+func settled(targets []deliveryTarget, results []webhook.DeliveryResult) ([]deliveryTarget, []webhook.DeliveryResult) {
+	keptTargets := make([]deliveryTarget, 0, len(targets))
+	keptResults := make([]webhook.DeliveryResult, 0, len(results))
+	for i, result := range results {
+		if result.Deferred {
+			log.Printf("Subscription [%s]: retry deferred to the next start", targets[i].target.Name)
+			continue
+		}
+		keptTargets = append(keptTargets, targets[i])
+		keptResults = append(keptResults, result)
+	}
+	return keptTargets, keptResults
+}
+
+// Redeliver re-sends a stored event payload to a webhook subscription once,
+// marking the request as a redelivery. The outcome is recorded like any other
+// delivery. Manual redeliveries are not retried.
+func (a *App) Redeliver(ctx context.Context, sub subscription.Subscription, eventID string, payload []byte) webhook.DeliveryResult {
+	target := webhookTarget(sub, eventID)
+	target.UserAgent = a.senderName(sub)
+	target.Redelivery = true
+	targets := []deliveryTarget{{sub: sub, target: target}}
+
+	payload, err := renderPayload(sub, a.storedEvent(ctx, eventID), payload)
+	if err != nil {
+		log.Printf("Subscription [%s]: payload template failed - %v", sub.Name, err)
+		return webhook.DeliveryResult{URL: target.URL, ErrorMessage: "payload template failed: " + err.Error()}
+	}
+
+	log.Printf("Subscription [%s]: redelivering event %s", sub.Name, eventID)
+	results := a.sender.SendAll(ctx, []webhook.Target{target}, payload)
+	for _, result := range results {
+		logDeliveryResult(sub.Name, result)
+	}
+	a.recordEgress(ctx, targets, results, payload)
+	a.recordHealth(targets, results)
+	a.recordDeliveries(ctx, targets, results, payload, eventID)
+
+	if len(results) == 0 {
+		return webhook.DeliveryResult{URL: target.URL, ErrorMessage: "no delivery result"}
+	}
+	return results[0]
+}
+
+// SendTest delivers a sample payload to a webhook subscription once, marking the
+// request as a test. eventID names the stored event the payload came from, if
+// any, for the payload template. Only egress is recorded: test deliveries do not
+// count towards the delivery history, health or lifecycle of the subscription.
+func (a *App) SendTest(ctx context.Context, sub subscription.Subscription, eventID string, payload []byte) webhook.DeliveryResult {
+	target := webhookTarget(sub, eventID)
+	target.UserAgent = a.senderName(sub)
+	target.Test = true
+	targets := []deliveryTarget{{sub: sub, target: target}}
+
+	payload, err := renderPayload(sub, a.storedEvent(ctx, eventID), payload)
+	if err != nil {
+		log.Printf("Subscription [%s]: payload template failed - %v", sub.Name, err)
+		return webhook.DeliveryResult{URL: target.URL, ErrorMessage: "payload template failed: " + err.Error()}
+	}
+
+	log.Printf("Subscription [%s]: sending test delivery", sub.Name)
+	results := a.sender.SendAll(ctx, []webhook.Target{target}, payload)
+	for _, result := range results {
+		logDeliveryResult(sub.Name, result)
+	}
+	a.recordEgress(ctx, targets, results, payload)
+
+	if len(results) == 0 {
+		return webhook.DeliveryResult{URL: target.URL, ErrorMessage: "no delivery result"}
+	}
+	return results[0]
+}
+
+// recordHealth records the final outcome of each delivery in the health tracker.
+func (a *App) recordHealth(targets []deliveryTarget, results []webhook.DeliveryResult) {
+	if a.health == nil {
+		return
+	}
+	now := time.Now()
+	for i, result := range results {
+		if i >= len(targets) {
+			continue
+		}
+		a.health.Record(targets[i].sub.ID, result.Success, now)
+	}
+}
+
+// recordDeliveries counts the final outcome of each delivery and stores it in the delivery repository.
+func (a *App) recordDeliveries(ctx context.Context, targets []deliveryTarget, results []webhook.DeliveryResult, payload []byte, eventID string) {
+	a.countDeliveries(results)
+	if a.deliveryRepo == nil {
+		return
+	}
+	sum := sha256.Sum256(payload)
+	payloadHash := hex.EncodeToString(sum[:])
+	now := time.Now()
+	for i, result := range results {
+		if i >= len(targets) {
+			continue
+		}
+		record := store.DeliveryRecord{
+			SubscriptionID: targets[i].sub.ID,
+			UserID:         targets[i].sub.UserID,
+			EventID:        eventID,
+			DeliveryID:     targets[i].target.DeliveryID,
+			URL:            result.URL,
+			StatusCode:     result.StatusCode,
+			Success:        result.Success,
+			ErrorMessage:   result.ErrorMessage,
+			Attempts:       result.RetryCount + 1,
+			ResponseTimeMs: result.ResponseTime.Milliseconds(),
+			ResponseBody:   result.ResponseBody,
+			PayloadSHA256:  payloadHash,
+			Redelivery:     targets[i].target.Redelivery,
+			DeliveredAt:    now,
+		}
+		if record.URL == "" {
+			record.URL = targets[i].target.URL
+		}
+		if _, err := a.deliveryRepo.Create(ctx, record); err != nil {
+			log.Printf("Subscription [%s]: failed to record delivery: %v", targets[i].target.Name, err)
+		}
+	}
+}
+
+// recordMatches counts the event once for each owner of a matching subscription,
+// throttled and digested ones included.
+func (a *App) recordMatches(ctx context.Context, subs []subscription.Subscription) {
+	if a.egress == nil {
+		return
+	}
+	seen := make(map[string]bool)
+	for _, sub := range subs {
+		if sub.UserID == "" || seen[sub.UserID] {
+			continue
+		}
+		seen[sub.UserID] = true
+		if err := a.egress.Record(ctx, sub.UserID, egress.Delta{EventsMatched: 1}); err != nil {
+			log.Printf("Subscription [%s]: failed to record usage: %v", sub.Name, err)
+		}
+	}
+}
+
+// recordEgress attributes the requests made for each delivery to the
+// subscription owner. Every attempt, including retries, counts as one request
+// carrying the full payload; the delivery itself counts once.
+func (a *App) recordEgress(ctx context.Context, targets []deliveryTarget, results []webhook.DeliveryResult, payload []byte) {
+	if a.egress == nil {
+		return
+	}
+	for i, result := range results {
+		if i >= len(targets) || targets[i].sub.UserID == "" {
+			continue
+		}
+		requests := int64(result.RetryCount + 1)
+		d := egress.Delta{Requests: requests, Bytes: requests * int64(len(payload)), Deliveries: 1}
+		if err := a.egress.Record(ctx, targets[i].sub.UserID, d); err != nil {
+			log.Printf("Subscription [%s]: failed to record egress: %v", targets[i].target.Name, err)
+		}
+	}
 }
 
 // deliverWithRetry sends the payload to a single target with retry logic
 // based on the subscription's retry configuration.
-func (a *App) deliverWithRetry(ctx context.Context, dt deliveryTarget, payload []byte) webhook.DeliveryResult {
+func (a *App) deliverWithRetry(ctx context.Context, dt deliveryTarget, payload []byte, eventID string) webhook.DeliveryResult {
 	// If no retry config or retry disabled, use direct send
 	if dt.sub.Delivery.Retry == nil || !dt.sub.Delivery.Retry.Enabled {
 		return a.singleSender.Send(ctx, dt.target.URL, dt.target.Secret, payload)
 	}
 
-	// Convert subscription RetryConfig to webhook RetryConfig
-	retryConfig := webhook.RetryConfig{
-		Enabled:    dt.sub.Delivery.Retry.Enabled,
-		MaxRetries: dt.sub.Delivery.Retry.MaxRetries,
-		InitialMs:  dt.sub.Delivery.Retry.InitialMs,
-		MaxMs:      dt.sub.Delivery.Retry.MaxMs,
-	}
+	retryConfig := toWebhookRetryConfig(dt.sub.Delivery.Retry)
 
 	// Create retrying sender with per-subscription config
 	// Note: We create a new RetryingSender per delivery to use the subscription's config.
@@ -320,12 +1201,291 @@ func (a *App) deliverWithRetry(ctx context.Context, dt deliveryTarget, payload [
 	}
 
 	retryingSender := webhook.NewRetryingSender(baseSender, retryConfig)
-	return retryingSender.Send(ctx, dt.target, payload)
+	if !a.canPersistRetry(dt.sub.ID, eventID) {
+		return retryingSender.Send(ctx, dt.target, payload)
+	}
+
+	// Keep the periodic sweep away from the schedule while it is running here
+	id := store.PendingRetryID(dt.sub.ID, eventID)
+	if a.claimRetry(id) {
+		defer a.releaseRetry(id)
+	}
+
+	// The first attempt is persisted before it is sent, so a crash during it
+	// leaves a schedule to resume from the start
+	now := time.Now()
+	expiresAt := now.Add(retryConfig.MaxWindow())
+	scheduled := a.trackRetrySchedule(ctx, retryingSender, dt.sub.ID, eventID, dt.target.DeliveryID, expiresAt)
+	*scheduled = a.savePendingRetry(ctx, store.PendingRetry{
+		SubscriptionID: dt.sub.ID,
+		EventID:        eventID,
+		DeliveryID:     dt.target.DeliveryID,
+		NextAttemptAt:  now,
+		ExpiresAt:      expiresAt,
+	})
+	// While draining, the persisted schedule is left for the next start
+	retryingSender.StopWaitingOn(a.draining)
+	result := retryingSender.Send(ctx, dt.target, payload)
+	if !result.Deferred {
+		a.finishPendingRetry(ctx, dt.sub.ID, eventID, *scheduled)
+	}
+	return result
+}
+
+// toWebhookRetryConfig converts a subscription RetryConfig to a webhook RetryConfig.
+func toWebhookRetryConfig(cfg *subscription.RetryConfig) webhook.RetryConfig {
+	var statuses []webhook.StatusOverride
+	for _, o := range cfg.StatusOverrides {
+		statuses = append(statuses, webhook.StatusOverride{Status: o.Status, Retry: o.Retry, RetryAfter: o.RetryAfter})
+	}
+	return webhook.RetryConfig{
+		Enabled:    cfg.Enabled,
+		MaxRetries: cfg.MaxRetries,
+		InitialMs:  cfg.InitialMs,
+		MaxMs:      cfg.MaxMs,
+		Jitter:     cfg.Jitter,
+		Statuses:   statuses,
+	}
+}
+
+// canPersistRetry reports whether a retry schedule for the given delivery can be persisted.
+// Notices are not stored as events, so their retries cannot be resumed after a restart.
+func (a *App) canPersistRetry(subscriptionID, eventID string) bool {
+	return a.retryRepo != nil && subscriptionID != "" && eventID != "" && !notice.IsID(eventID)
+}
+
+// trackRetrySchedule persists every retry the sender schedules, so the delivery
+// can be resumed after a restart (with the same delivery ID).
+// The returned flag reports whether anything was persisted.
+func (a *App) trackRetrySchedule(ctx context.Context, rs *webhook.RetryingSender, subscriptionID, eventID, deliveryID string, expiresAt time.Time) *bool {
+	scheduled := false
+	rs.OnRetryScheduled(func(attempt int, next time.Time) {
+		// The last attempt may start after the nominal window because of send latency
+		windowEnd := expiresAt
+		if next.After(windowEnd) {
+			windowEnd = next
+		}
+		if a.savePendingRetry(ctx, store.PendingRetry{
+			SubscriptionID: subscriptionID,
+			EventID:        eventID,
+			DeliveryID:     deliveryID,
+			Attempt:        attempt,
+			NextAttemptAt:  next,
+			ExpiresAt:      windowEnd,
+		}) {
+			scheduled = true
+		}
+	})
+	return &scheduled
+}
+
+// savePendingRetry persists the next attempt of a delivery and reports whether it was saved.
+func (a *App) savePendingRetry(ctx context.Context, pending store.PendingRetry) bool {
+	if _, err := a.retryRepo.Save(ctx, pending); err != nil {
+		log.Printf("Failed to persist pending retry (subscription=%s, event=%s): %v",
+			pending.SubscriptionID, pending.EventID, err)
+		return false
+	}
+	return true
+}
+
+// finishPendingRetry removes the persisted schedule once a delivery has completed.
+// If the context was cancelled (shutdown), the record is kept so the delivery resumes on restart.
+func (a *App) finishPendingRetry(ctx context.Context, subscriptionID, eventID string, scheduled bool) {
+	if !scheduled || ctx.Err() != nil {
+		return
+	}
+	if err := a.retryRepo.Delete(ctx, store.PendingRetryID(subscriptionID, eventID)); err != nil {
+		log.Printf("Failed to delete pending retry (subscription=%s, event=%s): %v",
+			subscriptionID, eventID, err)
+	}
+}
+
+// claimRetry marks a pending retry as being delivered by this process.
+// It reports false if the retry was already claimed.
+func (a *App) claimRetry(id string) bool {
+	a.retryMu.Lock()
+	defer a.retryMu.Unlock()
+	if _, ok := a.retrying[id]; ok {
+		return false
+	}
+	a.retrying[id] = struct{}{}
+	return true
+}
+
+// releaseRetry undoes claimRetry once the delivery has finished.
+func (a *App) releaseRetry(id string) {
+	a.retryMu.Lock()
+	defer a.retryMu.Unlock()
+	delete(a.retrying, id)
+}
+
+// resumePendingRetries loads persisted retry schedules that this process is
+// not running, i.e. ones left unfinished by a previous run or skipped earlier
+// because of a transient error, and resumes those whose window has not expired
+// yet. Expired schedules, and ones whose subscription or event no longer
+// exists or whose subscription is not deliverable, are discarded.
+// Each resumed delivery runs in its own goroutine tracked by a.background.
+func (a *App) resumePendingRetries(ctx context.Context) {
+	if a.retryRepo == nil || a.eventRepo == nil {
+		return
+	}
+
+	pending, err := a.retryRepo.List(ctx)
+	if err != nil {
+		log.Printf("Failed to load pending retries: %v", err)
+		return
+	}
+	idle := pending[:0]
+	for _, p := range pending {
+		if !a.isRetrying(pendingRetryKey(p)) {
+			idle = append(idle, p)
+		}
+	}
+	if len(idle) == 0 {
+		return
+	}
+
+	log.Printf("Found %d pending retry schedule(s) to resume", len(idle))
+
+	now := time.Now()
+	for _, p := range idle {
+		if p.Expired(now) {
+			log.Printf("Pending retry (subscription=%s, event=%s): window expired, discarding",
+				p.SubscriptionID, p.EventID)
+			a.discardPendingRetry(ctx, p)
+			continue
+		}
+
+		sub, err := a.repository.Get(ctx, p.SubscriptionID)
+		if err != nil {
+			// Keep the record; it will be retried or expire on the next start
+			log.Printf("Pending retry (subscription=%s, event=%s): failed to get subscription: %v",
+				p.SubscriptionID, p.EventID, err)
+			continue
+		}
+		if sub == nil || sub.Delivery.Type != "webhook" ||
+			sub.Delivery.Retry == nil || !sub.Delivery.Retry.Enabled {
+			log.Printf("Pending retry (subscription=%s, event=%s): subscription gone or retry disabled, discarding",
+				p.SubscriptionID, p.EventID)
+			a.discardPendingRetry(ctx, p)
+			continue
+		}
+		// As in the fan-out, paused, suspended and expired subscriptions receive nothing
+		if !sub.Deliverable(now) {
+			log.Printf("Pending retry (subscription=%s, event=%s): subscription paused, suspended or expired, discarding",
+				p.SubscriptionID, p.EventID)
+			a.discardPendingRetry(ctx, p)
+			continue
+		}
+
+		event, err := a.eventRepo.Get(ctx, p.EventID)
+		if err != nil {
+			log.Printf("Pending retry (subscription=%s, event=%s): failed to get event: %v",
+				p.SubscriptionID, p.EventID, err)
+			continue
+		}
+		if event == nil || event.RawJSON == "" {
+			log.Printf("Pending retry (subscription=%s, event=%s): event payload unavailable, discarding",
+				p.SubscriptionID, p.EventID)
+			a.discardPendingRetry(ctx, p)
+			continue
+		}
+
+		payload, err := renderPayload(*sub, recordEvent{*event}, []byte(event.RawJSON))
+		if err != nil {
+			log.Printf("Pending retry (subscription=%s, event=%s): payload template failed, discarding: %v",
+				p.SubscriptionID, p.EventID, err)
+			a.discardPendingRetry(ctx, p)
+			continue
+		}
+
+		id := pendingRetryKey(p)
+		if !a.claimRetry(id) {
+			continue
+		}
+		a.background.Add(1)
+		go func(p store.PendingRetry, sub subscription.Subscription, payload []byte) {
+			defer a.background.Done()
+			defer a.releaseRetry(id)
+			a.resumeRetry(ctx, p, sub, payload)
+		}(p, *sub, payload)
+	}
+}
+
+// resumeRetry waits until the persisted attempt is due and continues the retry schedule.
+func (a *App) resumeRetry(ctx context.Context, p store.PendingRetry, sub subscription.Subscription, payload []byte) {
+	baseSender, ok := a.singleSender.(*webhook.Sender)
+	if !ok {
+		return
+	}
+
+	if wait := time.Until(p.NextAttemptAt); wait > 0 {
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			return
+		case <-a.draining:
+			return
+		case <-timer.C:
+		}
+	}
+
+	retryConfig := toWebhookRetryConfig(sub.Delivery.Retry)
+	retryingSender := webhook.NewRetryingSender(baseSender, retryConfig)
+	target := webhookTarget(sub, p.EventID)
+	target.UserAgent = a.senderName(sub)
+	if p.DeliveryID != "" {
+		target.DeliveryID = p.DeliveryID
+	}
+	a.trackRetrySchedule(ctx, retryingSender, p.SubscriptionID, p.EventID, target.DeliveryID, p.ExpiresAt)
+	retryingSender.StopWaitingOn(a.draining)
+
+	result := retryingSender.Resume(ctx, target, payload, p.Attempt)
+	if result.Deferred {
+		log.Printf("Subscription [%s]: retry deferred to the next start", sub.Name)
+		return
+	}
+	// The record exists from the previous run, so it must be removed on completion
+	a.finishPendingRetry(ctx, p.SubscriptionID, p.EventID, true)
+	logDeliveryResult(sub.Name, result)
+	if a.health != nil {
+		a.health.Record(sub.ID, result.Success, time.Now())
+	}
+	a.recordDeliveries(ctx, []deliveryTarget{{sub: sub, target: target}}, []webhook.DeliveryResult{result}, payload, p.EventID)
+}
+
+// isRetrying reports whether this process is delivering the given pending retry.
+func (a *App) isRetrying(id string) bool {
+	a.retryMu.Lock()
+	defer a.retryMu.Unlock()
+	_, ok := a.retrying[id]
+	return ok
+}
+
+// pendingRetryKey returns the document ID of a pending retry.
+func pendingRetryKey(p store.PendingRetry) string {
+	if p.ID != "" {
+		return p.ID
+	}
+	return store.PendingRetryID(p.SubscriptionID, p.EventID)
+}
+
+// discardPendingRetry deletes a pending retry that will not be resumed.
+func (a *App) discardPendingRetry(ctx context.Context, p store.PendingRetry) {
+	id := pendingRetryKey(p)
+	if err := a.retryRepo.Delete(ctx, id); err != nil {
+		log.Printf("Failed to delete pending retry %s: %v", id, err)
+	}
 }
 
 // logDeliveryResult logs the result of a delivery attempt.
 func logDeliveryResult(name string, result webhook.DeliveryResult) {
 	if result.Success {
+		if !logging.Enabled(logging.Info) {
+			return
+		}
 		if result.RetryCount > 0 {
 			log.Printf("Subscription [%s]: delivered in %v (after %d retries)",
 				name, result.ResponseTime, result.RetryCount)
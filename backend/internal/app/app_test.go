@@ -1,19 +1,42 @@
 package app
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/otiai10/namazu/backend/internal/analytics"
 	"github.com/otiai10/namazu/backend/internal/config"
+	"github.com/otiai10/namazu/backend/internal/delivery"
+	"github.com/otiai10/namazu/backend/internal/delivery/schema"
 	"github.com/otiai10/namazu/backend/internal/delivery/webhook"
+	"github.com/otiai10/namazu/backend/internal/egress"
+	"github.com/otiai10/namazu/backend/internal/notice"
+	"github.com/otiai10/namazu/backend/internal/plan"
 	"github.com/otiai10/namazu/backend/internal/source"
 	"github.com/otiai10/namazu/backend/internal/source/p2pquake"
 	"github.com/otiai10/namazu/backend/internal/store"
+	"github.com/otiai10/namazu/backend/internal/stream"
 	"github.com/otiai10/namazu/backend/internal/subscription"
+	"github.com/otiai10/namazu/backend/internal/tenant"
+	"github.com/otiai10/namazu/backend/internal/throttle"
+	"github.com/otiai10/namazu/backend/internal/tracing"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 )
 
 // mockClient is a mock implementation of p2pquake.Client for testing
@@ -136,6 +159,10 @@ func (m *mockRepository) Create(ctx context.Context, sub subscription.Subscripti
 	return "", errors.New("mock repository: create not implemented")
 }
 
+func (m *mockRepository) CreateWithinLimit(ctx context.Context, sub subscription.Subscription, limit int) (string, error) {
+	return "", errors.New("mock repository: create not implemented")
+}
+
 func (m *mockRepository) Get(ctx context.Context, id string) (*subscription.Subscription, error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -218,6 +245,14 @@ func (m *mockEventRepository) List(ctx context.Context, limit int, startAfter *t
 	return result, nil
 }
 
+func (m *mockEventRepository) Query(ctx context.Context, q store.EventQuery) (*store.EventPage, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	result := make([]store.EventRecord, len(m.events))
+	copy(result, m.events)
+	return &store.EventPage{Events: result, Total: len(result)}, nil
+}
+
 func (m *mockEventRepository) GetEvents() []store.EventRecord {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -226,7 +261,204 @@ func (m *mockEventRepository) GetEvents() []store.EventRecord {
 	return result
 }
 
-// mockEvent implements source.Event for testing
+// mockDeliveryRepository is a mock implementation of store.DeliveryRepository for testing
+type mockDeliveryRepository struct {
+	records []store.DeliveryRecord
+	mu      sync.Mutex
+}
+
+func (m *mockDeliveryRepository) Create(ctx context.Context, record store.DeliveryRecord) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.records = append(m.records, record)
+	return fmt.Sprintf("delivery-%d", len(m.records)), nil
+}
+
+func (m *mockDeliveryRepository) Get(ctx context.Context, id string) (*store.DeliveryRecord, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, r := range m.records {
+		if r.ID == id {
+			record := m.records[i]
+			return &record, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *mockDeliveryRepository) ListBySubscription(ctx context.Context, subscriptionID string, from, to time.Time) ([]store.DeliveryRecord, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var result []store.DeliveryRecord
+	for _, r := range m.records {
+		if r.SubscriptionID == subscriptionID && !r.DeliveredAt.Before(from) && r.DeliveredAt.Before(to) {
+			result = append(result, r)
+		}
+	}
+	return result, nil
+}
+
+func (m *mockDeliveryRepository) LastSuccess(ctx context.Context, subscriptionID string) (*store.DeliveryRecord, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var last *store.DeliveryRecord
+	for i, r := range m.records {
+		if r.SubscriptionID == subscriptionID && r.Success && (last == nil || r.DeliveredAt.After(last.DeliveredAt)) {
+			last = &m.records[i]
+		}
+	}
+	return last, nil
+}
+
+func (m *mockDeliveryRepository) SummarizeSubscription(ctx context.Context, subscriptionID string, from, to time.Time) (store.DeliverySummary, error) {
+	return store.DeliverySummary{}, nil
+}
+
+func (m *mockDeliveryRepository) DeleteBySubscription(ctx context.Context, subscriptionID string) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	kept := m.records[:0]
+	for _, r := range m.records {
+		if r.SubscriptionID != subscriptionID {
+			kept = append(kept, r)
+		}
+	}
+	deleted := len(m.records) - len(kept)
+	m.records = kept
+	return deleted, nil
+}
+
+func (m *mockDeliveryRepository) SummarizeEvent(ctx context.Context, eventID string) (store.DeliverySummary, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var summary store.DeliverySummary
+	for _, r := range m.records {
+		if r.EventID != eventID {
+			continue
+		}
+		if r.Success {
+			summary.Delivered++
+		} else {
+			summary.Failed++
+		}
+	}
+	return summary, nil
+}
+
+// mockRetryRepository is a mock implementation of store.RetryRepository for testing
+type mockRetryRepository struct {
+	retries map[string]store.PendingRetry
+	saved   []store.PendingRetry
+	deleted []string
+	mu      sync.Mutex
+}
+
+func newMockRetryRepository(pending ...store.PendingRetry) *mockRetryRepository {
+	m := &mockRetryRepository{retries: make(map[string]store.PendingRetry)}
+	for _, p := range pending {
+		if p.ID == "" {
+			p.ID = store.PendingRetryID(p.SubscriptionID, p.EventID)
+		}
+		m.retries[p.ID] = p
+	}
+	return m
+}
+
+func (m *mockRetryRepository) Save(ctx context.Context, retry store.PendingRetry) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	id := store.PendingRetryID(retry.SubscriptionID, retry.EventID)
+	retry.ID = id
+	m.retries[id] = retry
+	m.saved = append(m.saved, retry)
+	return id, nil
+}
+
+func (m *mockRetryRepository) Delete(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.retries, id)
+	m.deleted = append(m.deleted, id)
+	return nil
+}
+
+func (m *mockRetryRepository) List(ctx context.Context) ([]store.PendingRetry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	result := make([]store.PendingRetry, 0, len(m.retries))
+	for _, p := range m.retries {
+		result = append(result, p)
+	}
+	return result, nil
+}
+
+func (m *mockRetryRepository) snapshot() (remaining int, saved []store.PendingRetry, deleted []string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.retries), append([]store.PendingRetry(nil), m.saved...), append([]string(nil), m.deleted...)
+}
+
+// mockEgressRepository is a mock implementation of egress.Repository for testing
+type mockEgressRepository struct {
+	usage   map[string]*egress.Usage
+	budgets map[string]int64
+	reads   int // GetBudget calls
+	mu      sync.Mutex
+}
+
+func newMockEgressRepository() *mockEgressRepository {
+	return &mockEgressRepository{
+		usage:   make(map[string]*egress.Usage),
+		budgets: make(map[string]int64),
+	}
+}
+
+func (m *mockEgressRepository) AddUsage(ctx context.Context, userID, period string, d egress.Delta) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	u, ok := m.usage[userID]
+	if !ok {
+		u = &egress.Usage{UserID: userID, Period: period}
+		m.usage[userID] = u
+	}
+	u.Requests += d.Requests
+	u.BytesSent += d.Bytes
+	u.Deliveries += d.Deliveries
+	u.EventsMatched += d.EventsMatched
+	return nil
+}
+
+func (m *mockEgressRepository) GetUsage(ctx context.Context, userID, period string) (*egress.Usage, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	u, ok := m.usage[userID]
+	if !ok {
+		return nil, nil
+	}
+	result := *u
+	return &result, nil
+}
+
+func (m *mockEgressRepository) GetBudget(ctx context.Context, userID string) (*egress.Budget, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.reads++
+	b, ok := m.budgets[userID]
+	if !ok {
+		return nil, nil
+	}
+	return &egress.Budget{UserID: userID, MonthlyBytes: b}, nil
+}
+
+func (m *mockEgressRepository) SetBudget(ctx context.Context, budget egress.Budget) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.budgets[budget.UserID] = budget.MonthlyBytes
+	return nil
+}
+
+// mockEvent implements source.Event for testing.
+// An unset eventType reports an earthquake.
 type mockEvent struct {
 	id            string
 	eventType     source.EventType
@@ -239,7 +471,6 @@ type mockEvent struct {
 }
 
 func (m *mockEvent) GetID() string              { return m.id }
-func (m *mockEvent) GetType() source.EventType  { return m.eventType }
 func (m *mockEvent) GetSource() string          { return m.source }
 func (m *mockEvent) GetSeverity() int           { return m.severity }
 func (m *mockEvent) GetAffectedAreas() []string { return m.affectedAreas }
@@ -247,7 +478,43 @@ func (m *mockEvent) GetOccurredAt() time.Time   { return m.occurredAt }
 func (m *mockEvent) GetReceivedAt() time.Time   { return m.receivedAt }
 func (m *mockEvent) GetRawJSON() string         { return m.rawJSON }
 
+func (m *mockEvent) GetType() source.EventType {
+	if m.eventType == "" {
+		return source.EventTypeEarthquake
+	}
+	return m.eventType
+}
+
 // TestNewApp tests the App constructor
+func TestWithClient(t *testing.T) {
+	cfg := &config.Config{
+		Source: config.SourceConfig{Type: "p2pquake", Endpoint: "ws://example.com/ws"},
+	}
+	client := newMockClient()
+
+	app := NewApp(cfg, newMockRepository(nil), WithClient(client))
+
+	if app.client != client {
+		t.Error("expected the provided client to replace the default one")
+	}
+}
+
+func TestWithResolver(t *testing.T) {
+	cfg := &config.Config{
+		Source: config.SourceConfig{Type: "p2pquake", Endpoint: "ws://example.com/ws"},
+	}
+
+	app := NewApp(cfg, newMockRepository(nil), WithResolver(webhook.NewResolver()))
+
+	sender, ok := app.sender.(*webhook.Sender)
+	if !ok {
+		t.Fatalf("expected *webhook.Sender, got %T", app.sender)
+	}
+	if app.singleSender != SingleSender(sender) {
+		t.Error("expected the single sender to share the resolving sender")
+	}
+}
+
 func TestNewApp(t *testing.T) {
 	t.Run("creates app with valid config and repository", func(t *testing.T) {
 		cfg := &config.Config{
@@ -317,6 +584,28 @@ func TestNewApp(t *testing.T) {
 	})
 }
 
+func TestNewClient_SourceType(t *testing.T) {
+	tests := []struct {
+		sourceType string
+		want       string
+	}{
+		{"p2pquake", "*p2pquake.Client"},
+		{"jma", "*jma.Client"},
+		{"multi", "*source.Multi"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.sourceType, func(t *testing.T) {
+			client, history := newClient(config.SourceConfig{Type: tt.sourceType, Endpoint: "ws://example.com/ws"}, nil)
+			if got := fmt.Sprintf("%T", client); got != tt.want {
+				t.Errorf("newClient(%q) = %s, want %s", tt.sourceType, got, tt.want)
+			}
+			if (history != nil) != (tt.sourceType != "jma") {
+				t.Errorf("newClient(%q) history = %v, want one for the P2P地震情報 WebSocket", tt.sourceType, history)
+			}
+		})
+	}
+}
+
 // TestApp_handleEvent tests the event handling logic
 func TestApp_handleEvent(t *testing.T) {
 	t.Run("sends event to all subscriptions", func(t *testing.T) {
@@ -368,7 +657,15 @@ func TestApp_handleEvent(t *testing.T) {
 
 		call := calls[0]
 		if len(call.targets) != 2 {
-			t.Errorf("Expected 2 targets, got %d", len(call.targets))
+			t.Fatalf("Expected 2 targets, got %d", len(call.targets))
+		}
+		for _, target := range call.targets {
+			if target.EventID != "test-event-123" || target.DeliveryID == "" {
+				t.Errorf("target %s: EventID=%q DeliveryID=%q, want the event ID and a delivery ID", target.Name, target.EventID, target.DeliveryID)
+			}
+		}
+		if call.targets[0].DeliveryID == call.targets[1].DeliveryID {
+			t.Error("Expected a delivery ID per subscription")
 		}
 		if string(call.payload) != `{"_id":"test-event-123","code":551}` {
 			t.Errorf("Unexpected payload: %s", string(call.payload))
@@ -1182,6 +1479,57 @@ func TestApp_Filter(t *testing.T) {
 			}
 		}
 	})
+
+	t.Run("event types default to earthquake only", func(t *testing.T) {
+		cfg := &config.Config{
+			Source: config.SourceConfig{
+				Type:     "p2pquake",
+				Endpoint: "ws://example.com/ws",
+			},
+		}
+
+		subs := []subscription.Subscription{
+			{
+				Name:     "Default Types Webhook",
+				Delivery: subscription.DeliveryConfig{Type: "webhook", URL: "https://webhook1.example.com"},
+			},
+			{
+				Name:     "Tsunami Webhook",
+				Delivery: subscription.DeliveryConfig{Type: "webhook", URL: "https://webhook2.example.com"},
+				Filter:   &subscription.FilterConfig{EventTypes: []string{"tsunami"}},
+			},
+			{
+				Name:     "All Types Webhook",
+				Delivery: subscription.DeliveryConfig{Type: "webhook", URL: "https://webhook3.example.com"},
+				Filter:   &subscription.FilterConfig{EventTypes: []string{"earthquake", "tsunami"}},
+			},
+		}
+		repo := newMockRepository(subs)
+
+		app := NewApp(cfg, repo)
+		mockSender := newMockSender()
+		app.sender = mockSender
+
+		app.handleEvent(context.Background(), &mockEvent{
+			id:        "test-filter-tsunami",
+			eventType: source.EventTypeTsunami,
+			severity:  50,
+			source:    "p2pquake",
+			rawJSON:   `{"_id":"test-filter-tsunami"}`,
+		})
+
+		calls := mockSender.GetSendAllCalls()
+		if len(calls) != 1 {
+			t.Fatalf("Expected 1 SendAll call, got %d", len(calls))
+		}
+		targetURLs := make(map[string]bool)
+		for _, target := range calls[0].targets {
+			targetURLs[target.URL] = true
+		}
+		if len(targetURLs) != 2 || !targetURLs["https://webhook2.example.com"] || !targetURLs["https://webhook3.example.com"] {
+			t.Errorf("Expected tsunami to reach only the Tsunami and All Types webhooks, got %v", targetURLs)
+		}
+	})
 }
 
 // TestApp_Retry tests the retry functionality with per-subscription retry config
@@ -1378,6 +1726,16 @@ func TestApp_FilterUnverifiedSubscriptions(t *testing.T) {
 					Verified:    false,
 				},
 			},
+			{
+				Name: "Skipped Webhook",
+				Delivery: subscription.DeliveryConfig{
+					Type:                "webhook",
+					URL:                 "https://skipped.example.com",
+					Secret:              "secret4",
+					SignVersion:         "v0",
+					VerificationSkipped: true,
+				},
+			},
 		}
 		repo := newMockRepository(subs)
 
@@ -1400,9 +1758,9 @@ func TestApp_FilterUnverifiedSubscriptions(t *testing.T) {
 			t.Fatalf("Expected 1 SendAll call, got %d", len(calls))
 		}
 
-		// Should include verified v0 and legacy (no sign version), but not unverified v0
-		if len(calls[0].targets) != 2 {
-			t.Errorf("Expected 2 targets (verified + legacy), got %d", len(calls[0].targets))
+		// Should include verified v0, skipped v0 and legacy (no sign version), but not unverified v0
+		if len(calls[0].targets) != 3 {
+			t.Errorf("Expected 3 targets (verified + skipped + legacy), got %d", len(calls[0].targets))
 		}
 
 		targetURLs := make(map[string]bool)
@@ -1416,8 +1774,1426 @@ func TestApp_FilterUnverifiedSubscriptions(t *testing.T) {
 		if !targetURLs["https://legacy.example.com"] {
 			t.Error("expected legacy webhook to be included")
 		}
+		if !targetURLs["https://skipped.example.com"] {
+			t.Error("expected webhook with skipped verification to be included")
+		}
 		if targetURLs["https://unverified.example.com"] {
 			t.Error("expected unverified v0 webhook to be excluded")
 		}
 	})
 }
+
+func TestApp_FilterSuspendedSubscriptions(t *testing.T) {
+	cfg := &config.Config{
+		Source: config.SourceConfig{Type: "p2pquake", Endpoint: "ws://example.com/ws"},
+	}
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(time.Hour)
+
+	subs := []subscription.Subscription{
+		{Name: "Active", Status: subscription.StatusActive, Delivery: subscription.DeliveryConfig{Type: "webhook", URL: "https://active.example.com"}},
+		{Name: "Warned", Status: subscription.StatusWarned, Delivery: subscription.DeliveryConfig{Type: "webhook", URL: "https://warned.example.com"}},
+		{Name: "Suspended", Status: subscription.StatusSuspended, Delivery: subscription.DeliveryConfig{Type: "webhook", URL: "https://suspended.example.com"}},
+		{Name: "Expired", ExpiresAt: &past, Delivery: subscription.DeliveryConfig{Type: "webhook", URL: "https://expired.example.com"}},
+		{Name: "Expires later", ExpiresAt: &future, Delivery: subscription.DeliveryConfig{Type: "webhook", URL: "https://later.example.com"}},
+		{Name: "Paused", PausedAt: &past, Delivery: subscription.DeliveryConfig{Type: "webhook", URL: "https://paused.example.com"}},
+	}
+
+	app := NewApp(cfg, newMockRepository(subs))
+	mockSender := newMockSender()
+	app.sender = mockSender
+
+	app.handleEvent(context.Background(), &mockEvent{id: "test-lifecycle-1", severity: 50, source: "p2pquake", rawJSON: `{}`})
+
+	calls := mockSender.GetSendAllCalls()
+	if len(calls) != 1 {
+		t.Fatalf("Expected 1 SendAll call, got %d", len(calls))
+	}
+	got := make(map[string]bool)
+	for _, target := range calls[0].targets {
+		got[target.URL] = true
+	}
+	for _, url := range []string{"https://active.example.com", "https://warned.example.com", "https://later.example.com"} {
+		if !got[url] {
+			t.Errorf("expected %s to be included", url)
+		}
+	}
+	for _, url := range []string{"https://suspended.example.com", "https://expired.example.com", "https://paused.example.com"} {
+		if got[url] {
+			t.Errorf("expected %s to be excluded", url)
+		}
+	}
+}
+
+func TestApp_FilterQuietHours(t *testing.T) {
+	cfg := &config.Config{
+		Source: config.SourceConfig{Type: "p2pquake", Endpoint: "ws://example.com/ws"},
+	}
+	// A window around the current time, and one that ended an hour ago
+	now := time.Now().UTC()
+	current := subscription.QuietHours{Start: now.Add(-time.Hour).Format("15:04"), End: now.Add(time.Hour).Format("15:04"), Timezone: "UTC"}
+	past := subscription.QuietHours{Start: now.Add(-3 * time.Hour).Format("15:04"), End: now.Add(-time.Hour).Format("15:04"), Timezone: "UTC"}
+	override := current
+	override.MinScaleOverride = 50
+
+	subs := []subscription.Subscription{
+		{Name: "Quiet", QuietHours: &current, Delivery: subscription.DeliveryConfig{Type: "webhook", URL: "https://quiet.example.com"}},
+		{Name: "Awake", QuietHours: &past, Delivery: subscription.DeliveryConfig{Type: "webhook", URL: "https://awake.example.com"}},
+		{Name: "Override", QuietHours: &override, Delivery: subscription.DeliveryConfig{Type: "webhook", URL: "https://override.example.com"}},
+	}
+
+	app := NewApp(cfg, newMockRepository(subs))
+	mockSender := newMockSender()
+	app.sender = mockSender
+
+	app.handleEvent(context.Background(), &mockEvent{id: "test-quiet-1", severity: p2pquake.ScaleToSeverity(50), source: "p2pquake", rawJSON: `{}`})
+
+	calls := mockSender.GetSendAllCalls()
+	if len(calls) != 1 {
+		t.Fatalf("Expected 1 SendAll call, got %d", len(calls))
+	}
+	got := make(map[string]bool)
+	for _, target := range calls[0].targets {
+		got[target.URL] = true
+	}
+	if !got["https://awake.example.com"] || !got["https://override.example.com"] {
+		t.Errorf("expected awake and override subscriptions to be included, got %v", got)
+	}
+	if got["https://quiet.example.com"] {
+		t.Error("expected the subscription in quiet hours to be excluded")
+	}
+}
+
+func TestApp_FilterSMSMinScale(t *testing.T) {
+	cfg := &config.Config{
+		Source: config.SourceConfig{Type: "p2pquake", Endpoint: "ws://example.com/ws"},
+	}
+	subs := []subscription.Subscription{
+		{ID: "sub-default", Name: "Default", Delivery: subscription.DeliveryConfig{Type: subscription.DeliveryTypeSMS,
+			SMS: &subscription.SMSConfig{Phone: "+819012345678"}}},
+		{ID: "sub-low", Name: "Low", Delivery: subscription.DeliveryConfig{Type: subscription.DeliveryTypeSMS,
+			SMS: &subscription.SMSConfig{Phone: "+819012345679", MinScale: p2pquake.Scale4}}},
+	}
+
+	var (
+		mu      sync.Mutex
+		smsSubs []string
+	)
+	sms := delivery.DispatcherFunc(func(ctx context.Context, msg delivery.Message, subs []subscription.Subscription) {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, sub := range subs {
+			smsSubs = append(smsSubs, sub.ID)
+		}
+	})
+
+	app := NewApp(cfg, newMockRepository(subs), WithDispatcher(subscription.DeliveryTypeSMS, sms))
+	app.sender = newMockSender()
+
+	app.handleEvent(context.Background(), &mockEvent{id: "test-sms-1", severity: p2pquake.ScaleToSeverity(p2pquake.Scale4), source: "p2pquake", rawJSON: `{}`})
+
+	if len(smsSubs) != 1 || smsSubs[0] != "sub-low" {
+		t.Errorf("sms dispatcher got %v, want [sub-low] (the default minimum is 5弱)", smsSubs)
+	}
+}
+
+func TestApp_PayloadTemplate(t *testing.T) {
+	cfg := &config.Config{
+		Source: config.SourceConfig{Type: "p2pquake", Endpoint: "ws://example.com/ws"},
+	}
+	subs := []subscription.Subscription{
+		{Name: "Plain", Delivery: subscription.DeliveryConfig{Type: "webhook", URL: "https://plain.example.com"}},
+		{Name: "Templated", Delivery: subscription.DeliveryConfig{Type: "webhook", URL: "https://templated.example.com",
+			Template: `{"text": {{json (scaleName .Event.Scale)}}, "code": {{.Payload.code}}}`}},
+		{Name: "Broken", Delivery: subscription.DeliveryConfig{Type: "webhook", URL: "https://broken.example.com",
+			Template: `not json`}},
+		{Name: "Enriched", Delivery: subscription.DeliveryConfig{Type: "webhook", URL: "https://enriched.example.com", Enrich: true}},
+		{Name: "Enriched template", Delivery: subscription.DeliveryConfig{Type: "webhook", URL: "https://enriched-templated.example.com", Enrich: true,
+			Template: `{"text": {{json .Payload.namazu.scale_label_en}}}`}},
+	}
+
+	app := NewApp(cfg, newMockRepository(subs))
+	mockSender := newMockSender()
+	app.sender = mockSender
+
+	app.handleEvent(context.Background(), &mockEvent{id: "test-template-1", severity: p2pquake.ScaleToSeverity(p2pquake.Scale4), source: "p2pquake", rawJSON: `{"code":551}`})
+
+	calls := mockSender.GetSendAllCalls()
+	if len(calls) != 4 {
+		t.Fatalf("Expected 4 SendAll calls, got %d", len(calls))
+	}
+	payloads := make(map[string]string)
+	for _, call := range calls {
+		for _, target := range call.targets {
+			payloads[target.URL] = string(call.payload)
+		}
+	}
+	if got := payloads["https://plain.example.com"]; got != `{"code":551}` {
+		t.Errorf("plain payload = %s, want the original", got)
+	}
+	if got := payloads["https://templated.example.com"]; got != `{"text":"震度4","code":551}` {
+		t.Errorf("templated payload = %s", got)
+	}
+	if _, ok := payloads["https://broken.example.com"]; ok {
+		t.Error("expected the subscription with a failing template to be skipped")
+	}
+	if got := payloads["https://enriched.example.com"]; !strings.HasPrefix(got, `{"code":551,"namazu":{"scale":40,"scale_label":"震度4"`) {
+		t.Errorf("enriched payload = %s", got)
+	}
+	if got := payloads["https://enriched-templated.example.com"]; got != `{"text":"Shindo 4"}` {
+		t.Errorf("enriched templated payload = %s", got)
+	}
+}
+
+func TestApp_PayloadFormat(t *testing.T) {
+	cfg := &config.Config{
+		Source: config.SourceConfig{Type: "p2pquake", Endpoint: "ws://example.com/ws"},
+	}
+	subs := []subscription.Subscription{
+		{Name: "Raw", Delivery: subscription.DeliveryConfig{Type: "webhook", URL: "https://raw.example.com", Format: "raw"}},
+		{Name: "Normalized", Delivery: subscription.DeliveryConfig{Type: "webhook", URL: "https://v1.example.com", Format: "namazu.v1"}},
+		{Name: "Normalized template", Delivery: subscription.DeliveryConfig{Type: "webhook", URL: "https://v1-templated.example.com", Format: "namazu.v1",
+			Template: `{"scale": {{.Payload.max_scale}}}`}},
+	}
+
+	app := NewApp(cfg, newMockRepository(subs))
+	mockSender := newMockSender()
+	app.sender = mockSender
+
+	occurred := time.Date(2024, 1, 1, 7, 10, 0, 0, time.UTC)
+	app.handleEvent(context.Background(), &mockEvent{id: "test-format-1", eventType: source.EventTypeEarthquake, severity: p2pquake.ScaleToSeverity(p2pquake.Scale4),
+		source: "p2pquake", affectedAreas: []string{"石川県"}, occurredAt: occurred, receivedAt: occurred, rawJSON: `{"code":551}`})
+
+	payloads := make(map[string]string)
+	for _, call := range mockSender.GetSendAllCalls() {
+		for _, target := range call.targets {
+			payloads[target.URL] = string(call.payload)
+		}
+	}
+	if got := payloads["https://raw.example.com"]; got != `{"code":551}` {
+		t.Errorf("raw payload = %s, want the original", got)
+	}
+	var v1 struct {
+		SchemaVersion int      `json:"schema_version"`
+		ID            string   `json:"id"`
+		MaxScale      int      `json:"max_scale"`
+		Prefectures   []string `json:"prefectures"`
+		Code          int      `json:"code"`
+	}
+	if err := json.Unmarshal([]byte(payloads["https://v1.example.com"]), &v1); err != nil {
+		t.Fatalf("v1 payload is not JSON: %v", err)
+	}
+	if v1.SchemaVersion != 1 || v1.ID != "test-format-1" || v1.MaxScale != p2pquake.Scale4 || len(v1.Prefectures) != 1 || v1.Code != 0 {
+		t.Errorf("v1 payload = %s", payloads["https://v1.example.com"])
+	}
+	if err := schema.Validate(schema.SchemaEvent, []byte(payloads["https://v1.example.com"])); err != nil {
+		t.Errorf("v1 payload does not match its published schema: %v", err)
+	}
+	if got := payloads["https://v1-templated.example.com"]; got != `{"scale":40}` {
+		t.Errorf("templated v1 payload = %s, want the template to see the v1 body", got)
+	}
+}
+
+func TestApp_CheckPayload(t *testing.T) {
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	invalid := []byte(`{"type":"namazu.service_notice","id":"notice-1"}`)
+	app := NewApp(&config.Config{}, newMockRepository(nil))
+	app.checkPayload(schema.SchemaNotice, invalid)
+	if logs.Len() != 0 {
+		t.Errorf("validation is off by default, logged %q", logs.String())
+	}
+
+	app = NewApp(&config.Config{}, newMockRepository(nil), WithPayloadValidation(true))
+	app.checkPayload(schema.SchemaNotice, invalid)
+	if !strings.Contains(logs.String(), "does not match schema namazu.service_notice: $: severity is required") {
+		t.Errorf("logs = %q, want the violation", logs.String())
+	}
+
+	v1 := subscription.Subscription{Delivery: subscription.DeliveryConfig{Format: schema.FormatV1}}
+	if got := payloadSchema(v1, &mockEvent{}); got != schema.SchemaEvent {
+		t.Errorf("payloadSchema(v1) = %q", got)
+	}
+	templated := v1
+	templated.Delivery.Template = `{}`
+	for _, sub := range []subscription.Subscription{{}, templated} {
+		if got := payloadSchema(sub, &mockEvent{}); got != "" {
+			t.Errorf("payloadSchema(%+v) = %q, want none", sub.Delivery, got)
+		}
+	}
+	if got := payloadSchema(v1, nil); got != "" {
+		t.Errorf("payloadSchema(v1, no event) = %q, want none", got)
+	}
+}
+
+func TestRecordEvent_GetHypocenter(t *testing.T) {
+	tests := []struct {
+		name   string
+		record store.EventRecord
+		want   string
+	}{
+		{"p2pquake", store.EventRecord{Source: "p2pquake", RawJSON: `{"_id":"a","code":551,"earthquake":{"hypocenter":{"name":"石川県能登地方","latitude":37.5,"longitude":137.2,"depth":10,"magnitude":7.6}}}`}, "石川県能登地方"},
+		{"jma", store.EventRecord{Source: "jma", RawJSON: `{"id":"b","earthquake":{"hypocenter":{"name":"能登半島沖","latitude":37.3,"longitude":137.1,"depth":10},"magnitude":7.6}}`}, "能登半島沖"},
+		{"no hypocenter", store.EventRecord{Source: "p2pquake", RawJSON: `{"_id":"c","code":551}`}, ""},
+		{"unknown source", store.EventRecord{Source: "usgs", RawJSON: `{}`}, ""},
+		{"invalid JSON", store.EventRecord{Source: "jma", RawJSON: `not json`}, ""},
+	}
+	for _, tt := range tests {
+		h := recordEvent{tt.record}.GetHypocenter()
+		if got := ""; h != nil {
+			got = h.Name
+			if got != tt.want || h.Magnitude != 7.6 {
+				t.Errorf("%s: hypocenter = %+v, want %q", tt.name, h, tt.want)
+			}
+		} else if tt.want != "" {
+			t.Errorf("%s: hypocenter = nil, want %q", tt.name, tt.want)
+		}
+	}
+}
+
+func TestApp_CustomHeaders(t *testing.T) {
+	cfg := &config.Config{
+		Source: config.SourceConfig{Type: "p2pquake", Endpoint: "ws://example.com/ws"},
+	}
+	subs := []subscription.Subscription{
+		{Name: "Authorized", Delivery: subscription.DeliveryConfig{Type: "webhook", URL: "https://authorized.example.com",
+			Headers: map[string]string{"Authorization": "Bearer token"}}},
+	}
+
+	app := NewApp(cfg, newMockRepository(subs))
+	mockSender := newMockSender()
+	app.sender = mockSender
+
+	app.handleEvent(context.Background(), &mockEvent{id: "test-headers-1", severity: 30, source: "p2pquake", rawJSON: `{}`})
+
+	calls := mockSender.GetSendAllCalls()
+	if len(calls) != 1 || len(calls[0].targets) != 1 {
+		t.Fatalf("Expected 1 SendAll call with 1 target, got %+v", calls)
+	}
+	if got := calls[0].targets[0].Headers["Authorization"]; got != "Bearer token" {
+		t.Errorf("Authorization header = %q, want the subscription's", got)
+	}
+}
+
+func TestApp_Throttle(t *testing.T) {
+	cfg := &config.Config{
+		Source: config.SourceConfig{Type: "p2pquake", Endpoint: "ws://example.com/ws"},
+	}
+	subs := []subscription.Subscription{
+		{ID: "throttled", Name: "Throttled", Throttle: &subscription.ThrottleConfig{IntervalMinutes: 10}, Delivery: subscription.DeliveryConfig{Type: "webhook", URL: "https://throttled.example.com"}},
+		{ID: "every", Name: "Every", Delivery: subscription.DeliveryConfig{Type: "webhook", URL: "https://every.example.com"}},
+	}
+
+	app := NewApp(cfg, newMockRepository(subs), WithThrottle(throttle.NewLimiter(nil)))
+	mockSender := newMockSender()
+	app.sender = mockSender
+
+	app.handleEvent(context.Background(), &mockEvent{id: "swarm-1", severity: 30, source: "p2pquake", rawJSON: `{}`})
+	app.handleEvent(context.Background(), &mockEvent{id: "swarm-2", severity: 30, source: "p2pquake", rawJSON: `{}`})
+	app.handleEvent(context.Background(), &mockEvent{id: "swarm-3", severity: 50, source: "p2pquake", rawJSON: `{}`})
+
+	calls := mockSender.GetSendAllCalls()
+	if len(calls) != 3 {
+		t.Fatalf("Expected 3 SendAll calls, got %d", len(calls))
+	}
+	for i, want := range []int{2, 1, 2} {
+		if got := len(calls[i].targets); got != want {
+			t.Errorf("event %d: expected %d targets, got %d", i+1, want, got)
+		}
+	}
+}
+
+type mockPlanFeatures map[string]plan.Features // keyed by UID
+
+func (m mockPlanFeatures) Features(ctx context.Context, tenantID, uid string) (plan.Features, error) {
+	return m[uid], nil
+}
+
+func TestApp_PlanFeatures(t *testing.T) {
+	cfg := &config.Config{
+		Source: config.SourceConfig{Type: "p2pquake", Endpoint: "ws://example.com/ws"},
+	}
+	digest := &subscription.DigestConfig{IntervalMinutes: 60}
+	subs := []subscription.Subscription{
+		{ID: "downgraded", Name: "Downgraded", UserID: "free-user", Digest: digest, Delivery: subscription.DeliveryConfig{Type: "webhook", URL: "https://downgraded.example.com"}},
+		{ID: "pro", Name: "Pro", UserID: "pro-user", Digest: digest, Delivery: subscription.DeliveryConfig{Type: "webhook", URL: "https://pro.example.com"}},
+		{ID: "legacy", Name: "Legacy", Digest: digest, Delivery: subscription.DeliveryConfig{Type: "webhook", URL: "https://legacy.example.com"}},
+	}
+
+	app := NewApp(cfg, newMockRepository(subs), WithPlanFeatures(mockPlanFeatures{"free-user": plan.Free, "pro-user": plan.Pro}))
+	mockSender := newMockSender()
+	app.sender = mockSender
+
+	app.handleEvent(context.Background(), &mockEvent{id: "evt-1", severity: 30, source: "p2pquake", rawJSON: `{}`})
+
+	calls := mockSender.GetSendAllCalls()
+	if len(calls) != 1 || len(calls[0].targets) != 1 || calls[0].targets[0].URL != "https://downgraded.example.com" {
+		t.Fatalf("SendAll calls = %+v, want only the downgraded subscription delivered without its digest", calls)
+	}
+}
+
+func TestApp_PersistPendingRetries(t *testing.T) {
+	t.Run("persists scheduled retries and deletes them on completion", func(t *testing.T) {
+		var attempts int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if atomic.AddInt32(&attempts, 1) == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer server.Close()
+
+		cfg := &config.Config{
+			Source: config.SourceConfig{Type: "p2pquake", Endpoint: "ws://example.com/ws"},
+		}
+		subs := []subscription.Subscription{
+			{
+				ID:   "sub-1",
+				Name: "Retrying Webhook",
+				Delivery: subscription.DeliveryConfig{
+					Type:   "webhook",
+					URL:    server.URL,
+					Secret: "secret1",
+					Retry:  &subscription.RetryConfig{Enabled: true, MaxRetries: 3, InitialMs: 10, MaxMs: 100},
+				},
+			},
+		}
+		retryRepo := newMockRetryRepository()
+		app := NewApp(cfg, newMockRepository(subs),
+			WithEventRepository(newMockEventRepository()),
+			WithRetryRepository(retryRepo))
+
+		app.handleEvent(context.Background(), &mockEvent{
+			id:      "evt-1",
+			rawJSON: `{"_id":"evt-1"}`,
+		})
+
+		if atomic.LoadInt32(&attempts) != 2 {
+			t.Fatalf("expected 2 attempts, got %d", atomic.LoadInt32(&attempts))
+		}
+
+		remaining, saved, deleted := retryRepo.snapshot()
+		if len(saved) != 2 {
+			t.Fatalf("expected 2 saved schedules, got %d", len(saved))
+		}
+		for i, s := range saved {
+			if s.SubscriptionID != "sub-1" || s.EventID != "evt-1" || s.Attempt != i {
+				t.Errorf("unexpected saved schedule %d: %+v", i, s)
+			}
+			if s.ExpiresAt.Before(s.NextAttemptAt) {
+				t.Errorf("expected ExpiresAt >= NextAttemptAt, got %v < %v", s.ExpiresAt, s.NextAttemptAt)
+			}
+		}
+		if remaining != 0 {
+			t.Errorf("expected no remaining schedules, got %d", remaining)
+		}
+		if len(deleted) != 1 || deleted[0] != "sub-1_evt-1" {
+			t.Errorf("expected schedule sub-1_evt-1 to be deleted, got %v", deleted)
+		}
+	})
+
+	t.Run("persists the first attempt while it is sent", func(t *testing.T) {
+		retryRepo := newMockRetryRepository()
+		var pendingDuringSend int
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			pendingDuringSend, _, _ = retryRepo.snapshot()
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer server.Close()
+
+		cfg := &config.Config{
+			Source: config.SourceConfig{Type: "p2pquake", Endpoint: "ws://example.com/ws"},
+		}
+		subs := []subscription.Subscription{
+			{
+				ID:   "sub-1",
+				Name: "Retrying Webhook",
+				Delivery: subscription.DeliveryConfig{
+					Type:   "webhook",
+					URL:    server.URL,
+					Secret: "secret1",
+					Retry:  &subscription.RetryConfig{Enabled: true, MaxRetries: 3, InitialMs: 10, MaxMs: 100},
+				},
+			},
+		}
+		app := NewApp(cfg, newMockRepository(subs),
+			WithEventRepository(newMockEventRepository()),
+			WithRetryRepository(retryRepo))
+
+		app.handleEvent(context.Background(), &mockEvent{id: "evt-1", rawJSON: `{"_id":"evt-1"}`})
+
+		if pendingDuringSend != 1 {
+			t.Errorf("expected the delivery to be persisted during the first attempt, got %d schedules", pendingDuringSend)
+		}
+		remaining, saved, deleted := retryRepo.snapshot()
+		if len(saved) != 1 || saved[0].Attempt != 0 {
+			t.Errorf("expected the first attempt to be saved, got %+v", saved)
+		}
+		if remaining != 0 || len(deleted) != 1 || deleted[0] != "sub-1_evt-1" {
+			t.Errorf("expected the schedule to be deleted on success, remaining=%d deleted=%v", remaining, deleted)
+		}
+	})
+}
+
+func TestApp_ResumePendingRetries(t *testing.T) {
+	var received int32
+	var deliveryID atomic.Value
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&received, 1)
+		deliveryID.Store(r.Header.Get(webhook.DeliveryIDHeader))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{
+		Source: config.SourceConfig{Type: "p2pquake", Endpoint: "ws://example.com/ws"},
+	}
+	retry := &subscription.RetryConfig{Enabled: true, MaxRetries: 3, InitialMs: 10, MaxMs: 100}
+	pausedAt := time.Now().Add(-time.Hour)
+	subs := []subscription.Subscription{
+		{ID: "sub-1", Name: "Active", Delivery: subscription.DeliveryConfig{Type: "webhook", URL: server.URL, Secret: "s", Retry: retry}},
+		{ID: "sub-2", Name: "No Retry", Delivery: subscription.DeliveryConfig{Type: "webhook", URL: server.URL, Secret: "s"}},
+		{ID: "sub-paused", Name: "Paused", PausedAt: &pausedAt, Delivery: subscription.DeliveryConfig{Type: "webhook", URL: server.URL, Secret: "s", Retry: retry}},
+		{ID: "sub-suspended", Name: "Suspended", Status: subscription.StatusSuspended, Delivery: subscription.DeliveryConfig{Type: "webhook", URL: server.URL, Secret: "s", Retry: retry}},
+	}
+
+	eventRepo := newMockEventRepository()
+	eventRepo.events = append(eventRepo.events, store.EventRecord{ID: "evt-1", RawJSON: `{"_id":"evt-1"}`})
+
+	now := time.Now()
+	retryRepo := newMockRetryRepository(
+		store.PendingRetry{SubscriptionID: "sub-1", EventID: "evt-1", DeliveryID: "delivery-1", Attempt: 2, NextAttemptAt: now.Add(20 * time.Millisecond), ExpiresAt: now.Add(time.Minute)},
+		store.PendingRetry{SubscriptionID: "sub-1", EventID: "evt-expired", Attempt: 1, NextAttemptAt: now.Add(-2 * time.Minute), ExpiresAt: now.Add(-time.Minute)},
+		store.PendingRetry{SubscriptionID: "sub-missing", EventID: "evt-1", Attempt: 1, NextAttemptAt: now, ExpiresAt: now.Add(time.Minute)},
+		store.PendingRetry{SubscriptionID: "sub-2", EventID: "evt-1", Attempt: 1, NextAttemptAt: now, ExpiresAt: now.Add(time.Minute)},
+		store.PendingRetry{SubscriptionID: "sub-1", EventID: "evt-missing", Attempt: 1, NextAttemptAt: now, ExpiresAt: now.Add(time.Minute)},
+		store.PendingRetry{SubscriptionID: "sub-paused", EventID: "evt-1", Attempt: 1, NextAttemptAt: now, ExpiresAt: now.Add(time.Minute)},
+		store.PendingRetry{SubscriptionID: "sub-suspended", EventID: "evt-1", Attempt: 1, NextAttemptAt: now, ExpiresAt: now.Add(time.Minute)},
+	)
+
+	app := NewApp(cfg, newMockRepository(subs),
+		WithEventRepository(eventRepo),
+		WithRetryRepository(retryRepo))
+
+	app.resumePendingRetries(context.Background())
+	app.background.Wait()
+
+	if atomic.LoadInt32(&received) != 1 {
+		t.Errorf("expected 1 resumed delivery, got %d", atomic.LoadInt32(&received))
+	}
+	if got := deliveryID.Load(); got != "delivery-1" {
+		t.Errorf("%s = %v, want the persisted delivery ID", webhook.DeliveryIDHeader, got)
+	}
+
+	remaining, _, deleted := retryRepo.snapshot()
+	if remaining != 0 {
+		t.Errorf("expected all schedules to be cleared, %d remaining", remaining)
+	}
+	if len(deleted) != 7 {
+		t.Errorf("expected 7 deletions, got %d (%v)", len(deleted), deleted)
+	}
+}
+
+func TestApp_ResumePendingRetries_KeepsOnCancel(t *testing.T) {
+	cfg := &config.Config{
+		Source: config.SourceConfig{Type: "p2pquake", Endpoint: "ws://example.com/ws"},
+	}
+	retry := &subscription.RetryConfig{Enabled: true, MaxRetries: 3, InitialMs: 10, MaxMs: 100}
+	subs := []subscription.Subscription{
+		{ID: "sub-1", Name: "Active", Delivery: subscription.DeliveryConfig{Type: "webhook", URL: "http://127.0.0.1:0", Secret: "s", Retry: retry}},
+	}
+
+	eventRepo := newMockEventRepository()
+	eventRepo.events = append(eventRepo.events, store.EventRecord{ID: "evt-1", RawJSON: `{"_id":"evt-1"}`})
+
+	now := time.Now()
+	retryRepo := newMockRetryRepository(
+		store.PendingRetry{SubscriptionID: "sub-1", EventID: "evt-1", Attempt: 1, NextAttemptAt: now.Add(time.Hour), ExpiresAt: now.Add(2 * time.Hour)},
+	)
+
+	app := NewApp(cfg, newMockRepository(subs),
+		WithEventRepository(eventRepo),
+		WithRetryRepository(retryRepo))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	app.resumePendingRetries(ctx)
+	cancel()
+	app.background.Wait()
+
+	remaining, _, deleted := retryRepo.snapshot()
+	if remaining != 1 || len(deleted) != 0 {
+		t.Errorf("expected schedule to be kept on shutdown, remaining=%d deleted=%v", remaining, deleted)
+	}
+}
+
+func TestApp_ResumePendingRetries_SkipsRunning(t *testing.T) {
+	var received int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&received, 1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{
+		Source: config.SourceConfig{Type: "p2pquake", Endpoint: "ws://example.com/ws"},
+	}
+	retry := &subscription.RetryConfig{Enabled: true, MaxRetries: 3, InitialMs: 10, MaxMs: 100}
+	subs := []subscription.Subscription{
+		{ID: "sub-1", Name: "Active", Delivery: subscription.DeliveryConfig{Type: "webhook", URL: server.URL, Secret: "s", Retry: retry}},
+	}
+
+	eventRepo := newMockEventRepository()
+	eventRepo.events = append(eventRepo.events,
+		store.EventRecord{ID: "evt-1", RawJSON: `{"_id":"evt-1"}`},
+		store.EventRecord{ID: "evt-2", RawJSON: `{"_id":"evt-2"}`})
+
+	now := time.Now()
+	retryRepo := newMockRetryRepository(
+		store.PendingRetry{SubscriptionID: "sub-1", EventID: "evt-1", Attempt: 1, NextAttemptAt: now.Add(50 * time.Millisecond), ExpiresAt: now.Add(time.Minute)},
+		store.PendingRetry{SubscriptionID: "sub-1", EventID: "evt-2", Attempt: 1, NextAttemptAt: now, ExpiresAt: now.Add(time.Minute)},
+	)
+
+	app := NewApp(cfg, newMockRepository(subs),
+		WithEventRepository(eventRepo),
+		WithRetryRepository(retryRepo))
+
+	// evt-2 is being delivered by the event loop
+	app.claimRetry(store.PendingRetryID("sub-1", "evt-2"))
+
+	// A sweep while evt-1 is still waiting must not resume it twice
+	app.resumePendingRetries(context.Background())
+	app.resumePendingRetries(context.Background())
+	app.background.Wait()
+
+	if got := atomic.LoadInt32(&received); got != 1 {
+		t.Errorf("expected 1 resumed delivery, got %d", got)
+	}
+	remaining, _, _ := retryRepo.snapshot()
+	if remaining != 1 {
+		t.Errorf("expected the running schedule to be kept, %d remaining", remaining)
+	}
+	if app.isRetrying(store.PendingRetryID("sub-1", "evt-1")) {
+		t.Error("expected the resumed schedule to be released")
+	}
+}
+
+func TestApp_EgressMetering(t *testing.T) {
+	cfg := &config.Config{
+		Source: config.SourceConfig{Type: "p2pquake", Endpoint: "ws://example.com/ws"},
+	}
+	subs := []subscription.Subscription{
+		{ID: "sub-1", UserID: "user-1", Name: "Within Budget", Delivery: subscription.DeliveryConfig{Type: "webhook", URL: "https://a.example.com"}},
+		{ID: "sub-2", UserID: "user-2", Name: "Over Budget", Delivery: subscription.DeliveryConfig{Type: "webhook", URL: "https://b.example.com"}},
+		{ID: "sub-3", Name: "Legacy", Delivery: subscription.DeliveryConfig{Type: "webhook", URL: "https://c.example.com"}},
+	}
+
+	egressRepo := newMockEgressRepository()
+	egressRepo.budgets["user-2"] = 10
+	egressRepo.usage["user-2"] = &egress.Usage{UserID: "user-2", BytesSent: 10, Requests: 1}
+
+	meter := egress.NewMeter(egressRepo, egress.WithThrottleInterval(10*time.Millisecond))
+	app := NewApp(cfg, newMockRepository(subs), WithEgressMeter(meter))
+	mockSender := newMockSender()
+	app.sender = mockSender
+
+	payload := `{"_id":"evt-1"}`
+	app.handleEvent(context.Background(), &mockEvent{id: "evt-1", rawJSON: payload})
+	app.background.Wait()
+
+	calls := mockSender.GetSendAllCalls()
+	if len(calls) != 2 {
+		t.Fatalf("expected 2 SendAll calls (immediate + throttled), got %d", len(calls))
+	}
+	if len(calls[0].targets) != 2 {
+		t.Errorf("expected 2 immediate targets, got %d", len(calls[0].targets))
+	}
+	if len(calls[1].targets) != 1 || calls[1].targets[0].URL != "https://b.example.com" {
+		t.Errorf("expected throttled delivery to over-budget subscription, got %+v", calls[1].targets)
+	}
+
+	u1, _ := egressRepo.GetUsage(context.Background(), "user-1", "")
+	if u1 == nil || u1.Requests != 1 || u1.BytesSent != int64(len(payload)) {
+		t.Errorf("unexpected usage for user-1: %+v", u1)
+	}
+	u2, _ := egressRepo.GetUsage(context.Background(), "user-2", "")
+	if u2 == nil || u2.Requests != 2 || u2.BytesSent != 10+int64(len(payload)) {
+		t.Errorf("unexpected usage for user-2: %+v", u2)
+	}
+	if _, ok := egressRepo.usage[""]; ok {
+		t.Error("legacy subscriptions without owner should not be attributed")
+	}
+
+	// Budgets are kept by the meter, so later events do not read them again
+	app.handleEvent(context.Background(), &mockEvent{id: "evt-2", rawJSON: `{"_id":"evt-2"}`})
+	app.background.Wait()
+	if egressRepo.reads != 2 {
+		t.Errorf("budget reads = %d, want one per owner", egressRepo.reads)
+	}
+}
+
+func TestApp_SplitThrottled_OncePerOwner(t *testing.T) {
+	egressRepo := newMockEgressRepository()
+	app := NewApp(&config.Config{}, newMockRepository(nil), WithEgressMeter(egress.NewMeter(egressRepo)))
+
+	targets := make([]deliveryTarget, 5)
+	for i := range targets {
+		targets[i] = deliveryTarget{sub: subscription.Subscription{UserID: "user-1"}}
+	}
+	immediate, throttled := app.splitThrottled(context.Background(), targets)
+	if len(immediate) != 5 || len(throttled) != 0 {
+		t.Errorf("split = %d immediate, %d throttled", len(immediate), len(throttled))
+	}
+	if egressRepo.reads != 1 {
+		t.Errorf("budget reads = %d, want 1 for one owner", egressRepo.reads)
+	}
+}
+
+func TestApp_HealthTracking(t *testing.T) {
+	cfg := &config.Config{
+		Source: config.SourceConfig{Type: "p2pquake", Endpoint: "ws://example.com/ws"},
+	}
+	subs := []subscription.Subscription{
+		{ID: "sub-ok", Name: "Healthy", Delivery: subscription.DeliveryConfig{Type: "webhook", URL: "https://a.example.com"}},
+		{ID: "sub-ng", Name: "Broken", Delivery: subscription.DeliveryConfig{Type: "webhook", URL: "https://b.example.com"}},
+	}
+
+	tracker := delivery.NewHealthTracker(0)
+	app := NewApp(cfg, newMockRepository(subs), WithHealthTracker(tracker))
+	mockSender := newMockSender()
+	mockSender.results = []webhook.DeliveryResult{
+		{URL: "https://a.example.com", Success: true, StatusCode: 200},
+		{URL: "https://b.example.com", Success: false, StatusCode: 500},
+	}
+	app.sender = mockSender
+
+	app.handleEvent(context.Background(), &mockEvent{id: "evt-1", rawJSON: `{"_id":"evt-1"}`})
+
+	if got := tracker.Status("sub-ok").State; got != delivery.HealthOperational {
+		t.Errorf("sub-ok state = %q, want %q", got, delivery.HealthOperational)
+	}
+	if got := tracker.Status("sub-ng").State; got != delivery.HealthFailing {
+		t.Errorf("sub-ng state = %q, want %q", got, delivery.HealthFailing)
+	}
+}
+
+func TestApp_TenantSenderName(t *testing.T) {
+	cfg := &config.Config{
+		Source: config.SourceConfig{Type: "p2pquake", Endpoint: "ws://example.com/ws"},
+	}
+	subs := []subscription.Subscription{
+		{ID: "sub-default", Name: "Default", Delivery: subscription.DeliveryConfig{Type: "webhook", URL: "https://a.example.com"}},
+		{ID: "sub-acme", TenantID: "acme", Name: "ACME", Delivery: subscription.DeliveryConfig{Type: "webhook", URL: "https://b.example.com"}},
+	}
+	tenants := tenant.NewRegistry([]config.TenantConfig{
+		{ID: "acme", Domains: []string{"alerts.acme.example"}, SenderName: "acme-alerts/1.0"},
+	})
+
+	app := NewApp(cfg, newMockRepository(subs), WithTenants(tenants))
+	mockSender := newMockSender()
+	app.sender = mockSender
+
+	app.handleEvent(context.Background(), &mockEvent{id: "evt-1", rawJSON: `{"_id":"evt-1"}`})
+
+	if len(mockSender.sendAllCalls) != 1 {
+		t.Fatalf("expected 1 SendAll call, got %d", len(mockSender.sendAllCalls))
+	}
+	got := make(map[string]string)
+	for _, target := range mockSender.sendAllCalls[0].targets {
+		got[target.URL] = target.UserAgent
+	}
+	if got["https://a.example.com"] != "" {
+		t.Errorf("default tenant UserAgent = %q, want empty", got["https://a.example.com"])
+	}
+	if got["https://b.example.com"] != "acme-alerts/1.0" {
+		t.Errorf("acme UserAgent = %q, want %q", got["https://b.example.com"], "acme-alerts/1.0")
+	}
+}
+
+// blockingEventRepository holds Create until released
+type blockingEventRepository struct {
+	*mockEventRepository
+	release chan struct{}
+}
+
+func (m *blockingEventRepository) Create(ctx context.Context, event store.EventRecord) (string, error) {
+	<-m.release
+	return m.mockEventRepository.Create(ctx, event)
+}
+
+func TestApp_EEW(t *testing.T) {
+	cfg := &config.Config{
+		Source: config.SourceConfig{Type: "p2pquake", Endpoint: "ws://example.com/ws"},
+	}
+	subs := []subscription.Subscription{
+		{ID: "sub-default", Name: "Default", Delivery: subscription.DeliveryConfig{Type: "webhook", URL: "https://a.example.com"}},
+		{ID: "sub-eew", Name: "EEW", Delivery: subscription.DeliveryConfig{Type: "webhook", URL: "https://b.example.com"},
+			Filter: &subscription.FilterConfig{EEW: true}},
+	}
+
+	eventRepo := &blockingEventRepository{mockEventRepository: newMockEventRepository(), release: make(chan struct{})}
+	deliveryRepo := &mockDeliveryRepository{}
+	app := NewApp(cfg, newMockRepository(subs), WithEventRepository(eventRepo), WithDeliveryRepository(deliveryRepo))
+	mockSender := newMockSender()
+	app.sender = mockSender
+
+	// Delivery must not wait for the event to be persisted
+	app.handleEvent(context.Background(), &mockEvent{
+		id:        "eew-1",
+		eventType: source.EventTypeEEW,
+		severity:  70,
+		source:    "p2pquake",
+		rawJSON:   `{"_id":"eew-1","code":556}`,
+	})
+
+	calls := mockSender.GetSendAllCalls()
+	if len(calls) != 1 {
+		t.Fatalf("Expected 1 SendAll call, got %d", len(calls))
+	}
+	if len(calls[0].targets) != 1 || calls[0].targets[0].URL != "https://b.example.com" {
+		t.Errorf("Expected only the opted-in subscription, got %+v", calls[0].targets)
+	}
+	if len(deliveryRepo.records) != 1 || deliveryRepo.records[0].EventID != "eew-1" {
+		t.Errorf("Expected a delivery record for eew-1, got %+v", deliveryRepo.records)
+	}
+	if len(eventRepo.GetEvents()) != 0 {
+		t.Error("EEW should not be persisted before delivery")
+	}
+
+	close(eventRepo.release)
+	app.background.Wait()
+	events := eventRepo.GetEvents()
+	if len(events) != 1 || events[0].ID != "eew-1" || events[0].Type != "eew" {
+		t.Errorf("Expected the EEW to be persisted in the background, got %+v", events)
+	}
+}
+
+func TestApp_DeliveryRecords(t *testing.T) {
+	cfg := &config.Config{
+		Source: config.SourceConfig{Type: "p2pquake", Endpoint: "ws://example.com/ws"},
+	}
+	subs := []subscription.Subscription{
+		{ID: "sub-ok", UserID: "user-1", Name: "Healthy", Delivery: subscription.DeliveryConfig{Type: "webhook", URL: "https://a.example.com"}},
+		{ID: "sub-ng", UserID: "user-2", Name: "Broken", Delivery: subscription.DeliveryConfig{Type: "webhook", URL: "https://b.example.com"}},
+	}
+
+	deliveryRepo := &mockDeliveryRepository{}
+	app := NewApp(cfg, newMockRepository(subs), WithEventRepository(newMockEventRepository()), WithDeliveryRepository(deliveryRepo))
+	mockSender := newMockSender()
+	mockSender.results = []webhook.DeliveryResult{
+		{URL: "https://a.example.com", Success: true, StatusCode: 200, ResponseTime: 120 * time.Millisecond},
+		{URL: "https://b.example.com", Success: false, StatusCode: 500, ErrorMessage: "HTTP 500", ResponseBody: "overloaded", RetryCount: 2},
+	}
+	app.sender = mockSender
+
+	payload := `{"_id":"evt-1"}`
+	app.handleEvent(context.Background(), &mockEvent{id: "evt-1", rawJSON: payload})
+
+	if len(deliveryRepo.records) != 2 {
+		t.Fatalf("expected 2 delivery records, got %d", len(deliveryRepo.records))
+	}
+	sum := sha256.Sum256([]byte(payload))
+	wantHash := hex.EncodeToString(sum[:])
+
+	ok, ng := deliveryRepo.records[0], deliveryRepo.records[1]
+	if ok.SubscriptionID != "sub-ok" || ok.UserID != "user-1" || ok.EventID != "evt-1" || !ok.Success || ok.Attempts != 1 || ok.ResponseTimeMs != 120 {
+		t.Errorf("unexpected record: %+v", ok)
+	}
+	if ng.SubscriptionID != "sub-ng" || ng.Success || ng.StatusCode != 500 || ng.ErrorMessage != "HTTP 500" || ng.ResponseBody != "overloaded" || ng.Attempts != 3 {
+		t.Errorf("unexpected record: %+v", ng)
+	}
+	for _, r := range deliveryRepo.records {
+		if r.PayloadSHA256 != wantHash {
+			t.Errorf("PayloadSHA256 = %q, want %q", r.PayloadSHA256, wantHash)
+		}
+		if r.DeliveredAt.IsZero() {
+			t.Error("DeliveredAt is zero")
+		}
+	}
+}
+
+// slowSender blocks deliveries to one URL until released
+type slowSender struct {
+	slowURL string
+	release chan struct{}
+}
+
+func (s *slowSender) SendAll(ctx context.Context, targets []webhook.Target, payload []byte) []webhook.DeliveryResult {
+	results := make([]webhook.DeliveryResult, len(targets))
+	for i, target := range targets {
+		if target.URL == s.slowURL {
+			select {
+			case <-s.release:
+			case <-ctx.Done():
+				results[i] = webhook.DeliveryResult{URL: target.URL, ErrorMessage: "request failed: " + ctx.Err().Error()}
+				continue
+			}
+		}
+		results[i] = webhook.DeliveryResult{URL: target.URL, StatusCode: 200, Success: true}
+	}
+	return results
+}
+
+func TestApp_DeliveryQueue(t *testing.T) {
+	cfg := &config.Config{
+		Source: config.SourceConfig{Type: "p2pquake", Endpoint: "ws://example.com/ws"},
+	}
+	subs := []subscription.Subscription{
+		{ID: "sub-slow", Name: "Slow", Delivery: subscription.DeliveryConfig{Type: "webhook", URL: "https://slow.example.com"}},
+		{ID: "sub-fast", Name: "Fast", Delivery: subscription.DeliveryConfig{Type: "webhook", URL: "https://fast.example.com"}},
+	}
+
+	deliveryRepo := &mockDeliveryRepository{}
+	queue := delivery.NewQueue(4, 16)
+	app := NewApp(cfg, newMockRepository(subs), WithDeliveryRepository(deliveryRepo), WithDeliveryQueue(queue))
+	mockClient := newMockClient()
+	sender := &slowSender{slowURL: "https://slow.example.com", release: make(chan struct{})}
+	app.client = mockClient
+	app.sender = sender
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() { errCh <- app.Run(ctx) }()
+
+	// The slow subscriber must hold up neither the other one nor the next event
+	mockClient.events <- &mockEvent{id: "evt-1", rawJSON: `{"_id":"evt-1"}`}
+	mockClient.events <- &mockEvent{id: "evt-2", rawJSON: `{"_id":"evt-2"}`}
+
+	fastDeliveries := func() []string {
+		deliveryRepo.mu.Lock()
+		defer deliveryRepo.mu.Unlock()
+		var events []string
+		for _, r := range deliveryRepo.records {
+			if r.SubscriptionID == "sub-fast" {
+				events = append(events, r.EventID)
+			}
+		}
+		return events
+	}
+	deadline := time.After(time.Second)
+	for len(fastDeliveries()) < 2 {
+		select {
+		case <-deadline:
+			t.Fatalf("fast deliveries = %v while the slow one is pending, want both events", fastDeliveries())
+		case <-time.After(5 * time.Millisecond):
+		}
+	}
+	if stats := queue.Stats(); stats.Busy != 2 {
+		t.Errorf("Busy = %d, want 2 workers held by the slow subscriber", stats.Busy)
+	}
+
+	close(sender.release)
+	deadline = time.After(time.Second)
+	for queue.Stats().Completed < 4 {
+		select {
+		case <-deadline:
+			t.Fatalf("Stats() = %+v, want 4 completed jobs", queue.Stats())
+		case <-time.After(5 * time.Millisecond):
+		}
+	}
+
+	cancel()
+	if err := <-errCh; err != nil {
+		t.Errorf("Run() error = %v", err)
+	}
+	deliveryRepo.mu.Lock()
+	defer deliveryRepo.mu.Unlock()
+	if len(deliveryRepo.records) != 4 {
+		t.Errorf("expected 4 delivery records, got %d", len(deliveryRepo.records))
+	}
+}
+
+func TestApp_Drain(t *testing.T) {
+	cfg := &config.Config{
+		Source: config.SourceConfig{Type: "p2pquake", Endpoint: "ws://example.com/ws"},
+	}
+	subs := []subscription.Subscription{
+		{ID: "sub-slow", Name: "Slow", Delivery: subscription.DeliveryConfig{Type: "webhook", URL: "https://slow.example.com"}},
+	}
+
+	// start runs the app, delivers one event and waits until its delivery is in flight
+	start := func(t *testing.T, opts ...Option) (*App, *slowSender, *mockDeliveryRepository, context.CancelFunc, chan error) {
+		t.Helper()
+		deliveryRepo := &mockDeliveryRepository{}
+		queue := delivery.NewQueue(1, 4)
+		app := NewApp(cfg, newMockRepository(subs), append([]Option{WithDeliveryRepository(deliveryRepo), WithDeliveryQueue(queue)}, opts...)...)
+		mockClient := newMockClient()
+		sender := &slowSender{slowURL: "https://slow.example.com", release: make(chan struct{})}
+		app.client = mockClient
+		app.sender = sender
+
+		ctx, cancel := context.WithCancel(context.Background())
+		errCh := make(chan error, 1)
+		go func() { errCh <- app.Run(ctx) }()
+		mockClient.events <- &mockEvent{id: "evt-1", rawJSON: `{"_id":"evt-1"}`}
+
+		deadline := time.After(time.Second)
+		for queue.Stats().Busy != 1 {
+			select {
+			case <-deadline:
+				t.Fatalf("Stats() = %+v, want the delivery in flight", queue.Stats())
+			case <-time.After(5 * time.Millisecond):
+			}
+		}
+		return app, sender, deliveryRepo, cancel, errCh
+	}
+
+	t.Run("finishes deliveries in flight", func(t *testing.T) {
+		_, sender, deliveryRepo, cancel, errCh := start(t)
+		cancel()
+		time.Sleep(20 * time.Millisecond)
+		select {
+		case <-errCh:
+			t.Fatal("Run returned before the delivery in flight finished")
+		default:
+		}
+
+		close(sender.release)
+		if err := <-errCh; err != nil {
+			t.Errorf("Run() error = %v", err)
+		}
+		deliveryRepo.mu.Lock()
+		defer deliveryRepo.mu.Unlock()
+		if len(deliveryRepo.records) != 1 || !deliveryRepo.records[0].Success {
+			t.Errorf("records = %+v, want the delivery to succeed", deliveryRepo.records)
+		}
+	})
+
+	t.Run("cancels deliveries after the timeout", func(t *testing.T) {
+		_, _, deliveryRepo, cancel, errCh := start(t, WithDrainTimeout(20*time.Millisecond))
+		cancel()
+		select {
+		case err := <-errCh:
+			if err != nil {
+				t.Errorf("Run() error = %v", err)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatal("Run did not return after the drain timeout")
+		}
+		deliveryRepo.mu.Lock()
+		defer deliveryRepo.mu.Unlock()
+		if len(deliveryRepo.records) == 1 && deliveryRepo.records[0].Success {
+			t.Errorf("records = %+v, want the delivery cancelled", deliveryRepo.records)
+		}
+	})
+}
+
+func TestApp_Drain_DefersPersistedRetries(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{
+		Source: config.SourceConfig{Type: "p2pquake", Endpoint: "ws://example.com/ws"},
+	}
+	subs := []subscription.Subscription{
+		{ID: "sub-1", Name: "Retrying Webhook", Delivery: subscription.DeliveryConfig{
+			Type: "webhook", URL: server.URL, Secret: "s",
+			Retry: &subscription.RetryConfig{Enabled: true, MaxRetries: 3, InitialMs: 60000, MaxMs: 60000},
+		}},
+	}
+	retryRepo := newMockRetryRepository()
+	deliveryRepo := &mockDeliveryRepository{}
+	app := NewApp(cfg, newMockRepository(subs),
+		WithEventRepository(newMockEventRepository()),
+		WithRetryRepository(retryRepo),
+		WithDeliveryRepository(deliveryRepo),
+		WithDeliveryQueue(delivery.NewQueue(1, 4)))
+	mockClient := newMockClient()
+	app.client = mockClient
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() { errCh <- app.Run(ctx) }()
+	mockClient.events <- &mockEvent{id: "evt-1", rawJSON: `{"_id":"evt-1"}`}
+
+	deadline := time.After(2 * time.Second)
+	for {
+		// The first attempt is saved before it is sent, then the retry
+		if _, saved, _ := retryRepo.snapshot(); len(saved) == 2 {
+			break
+		}
+		select {
+		case <-deadline:
+			t.Fatal("the retry was not scheduled")
+		case <-time.After(5 * time.Millisecond):
+		}
+	}
+
+	// The retry waits a minute for its next attempt; draining does not wait for it
+	cancel()
+	select {
+	case err := <-errCh:
+		if err != nil {
+			t.Errorf("Run() error = %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Run waited for the next attempt of the retry")
+	}
+
+	if got := atomic.LoadInt32(&attempts); got != 1 {
+		t.Errorf("expected 1 attempt, got %d", got)
+	}
+	remaining, _, deleted := retryRepo.snapshot()
+	if remaining != 1 || len(deleted) != 0 {
+		t.Errorf("expected the schedule to be kept for the next start, remaining=%d deleted=%v", remaining, deleted)
+	}
+	deliveryRepo.mu.Lock()
+	defer deliveryRepo.mu.Unlock()
+	if len(deliveryRepo.records) != 0 {
+		t.Errorf("expected no delivery record for the deferred retry, got %+v", deliveryRepo.records)
+	}
+}
+
+func TestApp_Redeliver(t *testing.T) {
+	cfg := &config.Config{
+		Source: config.SourceConfig{Type: "p2pquake", Endpoint: "ws://example.com/ws"},
+	}
+	sub := subscription.Subscription{ID: "sub-1", UserID: "user-1", Name: "Prod", Delivery: subscription.DeliveryConfig{Type: "webhook", URL: "https://a.example.com", SignVersion: "v0"}}
+
+	deliveryRepo := &mockDeliveryRepository{}
+	app := NewApp(cfg, newMockRepository(nil), WithDeliveryRepository(deliveryRepo))
+	mockSender := newMockSender()
+	app.sender = mockSender
+
+	result := app.Redeliver(context.Background(), sub, "evt-1", []byte(`{"_id":"evt-1"}`))
+	if !result.Success {
+		t.Errorf("Redeliver() = %+v, want success", result)
+	}
+
+	calls := mockSender.GetSendAllCalls()
+	if len(calls) != 1 || len(calls[0].targets) != 1 {
+		t.Fatalf("expected a single send to one target, got %+v", calls)
+	}
+	target := calls[0].targets[0]
+	if !target.Redelivery || target.URL != "https://a.example.com" || target.SignVersion != "v0" {
+		t.Errorf("target = %+v, want a v0 redelivery to the subscription URL", target)
+	}
+	if target.EventID != "evt-1" || target.DeliveryID == "" {
+		t.Errorf("target = %+v, want the original event ID and a delivery ID", target)
+	}
+	if string(calls[0].payload) != `{"_id":"evt-1"}` {
+		t.Errorf("payload = %s", calls[0].payload)
+	}
+
+	if len(deliveryRepo.records) != 1 {
+		t.Fatalf("expected 1 delivery record, got %d", len(deliveryRepo.records))
+	}
+	if r := deliveryRepo.records[0]; r.SubscriptionID != "sub-1" || r.EventID != "evt-1" || !r.Redelivery || !r.Success {
+		t.Errorf("unexpected record: %+v", r)
+	}
+
+	// Another redelivery of the same event is a new delivery
+	app.Redeliver(context.Background(), sub, "evt-1", []byte(`{"_id":"evt-1"}`))
+	if second := deliveryRepo.records[1]; second.DeliveryID == "" || second.DeliveryID == deliveryRepo.records[0].DeliveryID {
+		t.Errorf("delivery IDs = %q, %q, want a new one per redelivery", deliveryRepo.records[0].DeliveryID, second.DeliveryID)
+	}
+}
+
+func TestApp_Inject(t *testing.T) {
+	cfg := &config.Config{
+		Source: config.SourceConfig{Type: "p2pquake", Endpoint: "ws://example.com/ws"},
+	}
+	subs := []subscription.Subscription{
+		{ID: "sub-1", Name: "Prod", Delivery: subscription.DeliveryConfig{Type: "webhook", URL: "https://a.example.com"}},
+	}
+	eventRepo := newMockEventRepository()
+	app := NewApp(cfg, newMockRepository(subs), WithEventRepository(eventRepo))
+	mockSender := newMockSender()
+	app.client = newMockClient()
+	app.sender = mockSender
+
+	// Injected events wait for Run like events from the source
+	for i := 0; i < injectQueueSize; i++ {
+		if err := app.Inject(context.Background(), &mockEvent{id: fmt.Sprintf("syn-%d", i), rawJSON: `{}`}); err != nil {
+			t.Fatalf("Inject() #%d error = %v", i, err)
+		}
+	}
+	if err := app.Inject(context.Background(), &mockEvent{id: "overflow"}); !errors.Is(err, ErrInjectQueueFull) {
+		t.Fatalf("Inject() on a full queue error = %v, want ErrInjectQueueFull", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() { errCh <- app.Run(ctx) }()
+
+	deadline := time.After(2 * time.Second)
+	for len(mockSender.GetSendAllCalls()) < injectQueueSize {
+		select {
+		case <-deadline:
+			t.Fatalf("delivered %d injected events, want %d", len(mockSender.GetSendAllCalls()), injectQueueSize)
+		case <-time.After(5 * time.Millisecond):
+		}
+	}
+	cancel()
+	if err := <-errCh; err != nil {
+		t.Errorf("Run() error = %v", err)
+	}
+	if saved, _ := eventRepo.Get(context.Background(), "syn-0"); saved == nil {
+		t.Error("injected events should be stored like received ones")
+	}
+}
+
+func TestApp_SendTest(t *testing.T) {
+	cfg := &config.Config{
+		Source: config.SourceConfig{Type: "p2pquake", Endpoint: "ws://example.com/ws"},
+	}
+	sub := subscription.Subscription{ID: "sub-1", UserID: "user-1", Name: "Prod", Delivery: subscription.DeliveryConfig{Type: "webhook", URL: "https://a.example.com", Secret: "s"}}
+
+	deliveryRepo := &mockDeliveryRepository{}
+	health := delivery.NewHealthTracker(0)
+	app := NewApp(cfg, newMockRepository(nil), WithDeliveryRepository(deliveryRepo), WithHealthTracker(health))
+	mockSender := newMockSender()
+	app.sender = mockSender
+
+	result := app.SendTest(context.Background(), sub, "", []byte(`{"code":551}`))
+	if !result.Success {
+		t.Errorf("SendTest() = %+v, want success", result)
+	}
+
+	calls := mockSender.GetSendAllCalls()
+	if len(calls) != 1 || len(calls[0].targets) != 1 {
+		t.Fatalf("expected a single send to one target, got %+v", calls)
+	}
+	if target := calls[0].targets[0]; !target.Test || target.Redelivery || target.URL != "https://a.example.com" || target.Secret != "s" {
+		t.Errorf("target = %+v, want a test delivery to the subscription URL", target)
+	}
+
+	// Test deliveries leave no trace in the history or health of the subscription
+	if len(deliveryRepo.records) != 0 {
+		t.Errorf("expected no delivery records, got %d", len(deliveryRepo.records))
+	}
+	if h := health.Status("sub-1"); h.Deliveries != 0 {
+		t.Errorf("health = %+v, want no deliveries", h)
+	}
+}
+
+func TestApp_Broadcast(t *testing.T) {
+	cfg := &config.Config{
+		Source: config.SourceConfig{Type: "p2pquake", Endpoint: "ws://example.com/ws"},
+	}
+	subs := []subscription.Subscription{
+		{ID: "sub-in", Name: "Opted in", Delivery: subscription.DeliveryConfig{Type: "webhook", URL: "https://a.example.com", ServiceNotices: true},
+			Filter: &subscription.FilterConfig{MinScale: 70}},
+		{ID: "sub-out", Name: "Opted out", Delivery: subscription.DeliveryConfig{Type: "webhook", URL: "https://b.example.com"}},
+		{ID: "sub-v0", Name: "Unverified", Delivery: subscription.DeliveryConfig{Type: "webhook", URL: "https://c.example.com", SignVersion: "v0", ServiceNotices: true}},
+	}
+
+	deliveryRepo := &mockDeliveryRepository{}
+	app := NewApp(cfg, newMockRepository(subs), WithDeliveryRepository(deliveryRepo))
+	mockSender := newMockSender()
+	app.sender = mockSender
+
+	n, err := notice.New("Relay maintenance", "The relay restarts at 02:00 JST.", notice.SeverityWarning)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recipients, err := app.Broadcast(context.Background(), n)
+	if err != nil {
+		t.Fatalf("Broadcast() error = %v", err)
+	}
+	if recipients != 1 {
+		t.Errorf("recipients = %d, want 1", recipients)
+	}
+
+	// Deliver as the event loop would
+	app.handleBroadcast(context.Background(), <-app.broadcasts)
+	app.background.Wait()
+
+	calls := mockSender.GetSendAllCalls()
+	if len(calls) != 1 || len(calls[0].targets) != 1 {
+		t.Fatalf("expected 1 SendAll call with 1 target, got %+v", calls)
+	}
+	if calls[0].targets[0].URL != "https://a.example.com" {
+		t.Errorf("target = %s, want https://a.example.com", calls[0].targets[0].URL)
+	}
+	var payload notice.Notice
+	if err := json.Unmarshal(calls[0].payload, &payload); err != nil {
+		t.Fatalf("payload is not a notice: %v", err)
+	}
+	if payload.Type != notice.Type || payload.ID != n.ID || payload.Title != "Relay maintenance" {
+		t.Errorf("unexpected payload: %+v", payload)
+	}
+	if len(deliveryRepo.records) != 1 || deliveryRepo.records[0].EventID != n.ID {
+		t.Errorf("expected a delivery record for the notice, got %+v", deliveryRepo.records)
+	}
+}
+
+func TestApp_Broadcast_QueueFull(t *testing.T) {
+	cfg := &config.Config{
+		Source: config.SourceConfig{Type: "p2pquake", Endpoint: "ws://example.com/ws"},
+	}
+	app := NewApp(cfg, newMockRepository(nil))
+	n, _ := notice.New("title", "message", "")
+
+	for i := 0; i < broadcastQueueSize; i++ {
+		if _, err := app.Broadcast(context.Background(), n); err != nil {
+			t.Fatalf("Broadcast() #%d error = %v", i, err)
+		}
+	}
+	if _, err := app.Broadcast(context.Background(), n); !errors.Is(err, notice.ErrQueueFull) {
+		t.Errorf("Broadcast() error = %v, want ErrQueueFull", err)
+	}
+}
+
+func TestApp_Broadcast_Invalid(t *testing.T) {
+	cfg := &config.Config{
+		Source: config.SourceConfig{Type: "p2pquake", Endpoint: "ws://example.com/ws"},
+	}
+	app := NewApp(cfg, newMockRepository(nil))
+	if _, err := app.Broadcast(context.Background(), notice.Notice{Title: "no message"}); !errors.Is(err, notice.ErrMessageRequired) {
+		t.Errorf("Broadcast() error = %v, want ErrMessageRequired", err)
+	}
+}
+
+func TestCanPersistRetry_Notice(t *testing.T) {
+	app := &App{retryRepo: newMockRetryRepository()}
+	if !app.canPersistRetry("sub-1", "evt-1") {
+		t.Error("expected event retries to be persisted")
+	}
+	if app.canPersistRetry("sub-1", "notice-0123") {
+		t.Error("expected notice retries not to be persisted")
+	}
+}
+
+func TestWithDispatcher(t *testing.T) {
+	cfg := &config.Config{
+		Source: config.SourceConfig{Type: "p2pquake", Endpoint: "ws://example.com/ws"},
+	}
+	subs := []subscription.Subscription{
+		{ID: "sub-webhook", Name: "Webhook", Delivery: subscription.DeliveryConfig{Type: "webhook", URL: "https://a.example.com"}},
+		{ID: "sub-slack", Name: "Slack", Delivery: subscription.DeliveryConfig{Type: "slack", URL: "https://hooks.slack.example/T000"}},
+		{ID: "sub-quiet", Name: "Quiet Slack", Delivery: subscription.DeliveryConfig{Type: "slack", URL: "https://hooks.slack.example/T001"},
+			Filter: &subscription.FilterConfig{MinScale: 70}},
+		{ID: "sub-sms", Name: "SMS", Delivery: subscription.DeliveryConfig{Type: "sms"}},
+	}
+
+	var (
+		mu         sync.Mutex
+		slackSubs  []string
+		slackEvent string
+	)
+	slack := delivery.DispatcherFunc(func(ctx context.Context, msg delivery.Message, subs []subscription.Subscription) {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, sub := range subs {
+			slackSubs = append(slackSubs, sub.ID)
+		}
+		slackEvent = msg.Event.GetID()
+	})
+
+	app := NewApp(cfg, newMockRepository(subs), WithDispatcher("slack", slack))
+	mockSender := newMockSender()
+	app.sender = mockSender
+
+	app.handleEvent(context.Background(), &mockEvent{id: "evt-1", rawJSON: `{"_id":"evt-1"}`})
+
+	calls := mockSender.GetSendAllCalls()
+	if len(calls) != 1 || len(calls[0].targets) != 1 || calls[0].targets[0].URL != "https://a.example.com" {
+		t.Errorf("expected the webhook only to be sent by the webhook sender, got %+v", calls)
+	}
+	if len(slackSubs) != 1 || slackSubs[0] != "sub-slack" {
+		t.Errorf("slack dispatcher got %v, want [sub-slack]", slackSubs)
+	}
+	if slackEvent != "evt-1" {
+		t.Errorf("slack dispatcher got event %q, want evt-1", slackEvent)
+	}
+}
+
+func TestApp_Tracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	defer otel.SetTracerProvider(previous)
+
+	var traceHeader string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceHeader = r.Header.Get(tracing.TraceIDHeader)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{
+		Source: config.SourceConfig{Type: "p2pquake", Endpoint: "ws://example.com/ws"},
+	}
+	subs := []subscription.Subscription{
+		{ID: "sub-1", Name: "Traced", Delivery: subscription.DeliveryConfig{Type: "webhook", URL: server.URL, Secret: "s"}},
+	}
+	app := NewApp(cfg, newMockRepository(subs), WithEventRepository(newMockEventRepository()))
+
+	// The client starts the trace on receipt
+	_, receipt := tracing.Start(context.Background(), "p2pquake.receive")
+	receipt.End()
+	event := &p2pquake.JMAQuake{
+		ID:           "quake-1",
+		Code:         p2pquake.CodeJMAQuake,
+		Issue:        p2pquake.Issue{Type: "DetailScale"},
+		RawJSON:      `{"_id":"quake-1"}`,
+		TraceContext: receipt.SpanContext(),
+	}
+	app.handleEvent(context.Background(), event)
+
+	traceID := receipt.SpanContext().TraceID()
+	if traceHeader != traceID.String() {
+		t.Errorf("%s = %q, want %q", tracing.TraceIDHeader, traceHeader, traceID.String())
+	}
+	names := map[string]bool{}
+	for _, span := range recorder.Ended() {
+		if span.SpanContext().TraceID() != traceID {
+			t.Errorf("span %s is in trace %s, want %s", span.Name(), span.SpanContext().TraceID(), traceID)
+		}
+		names[span.Name()] = true
+	}
+	for _, name := range []string{"p2pquake.receive", "namazu.event", "store.save_event", "namazu.filter", "webhook.send"} {
+		if !names[name] {
+			t.Errorf("span %s was not recorded (got %v)", name, names)
+		}
+	}
+}
+
+func TestApp_Stream(t *testing.T) {
+	cfg := &config.Config{
+		Source: config.SourceConfig{Type: "p2pquake", Endpoint: "ws://example.com/ws"},
+	}
+	hub := stream.NewHub()
+	live := hub.Subscribe(&subscription.FilterConfig{Prefectures: []string{"東京都"}})
+	eventRepo := newMockEventRepository()
+	app := NewApp(cfg, newMockRepository(nil), WithEventRepository(eventRepo), WithStream(hub))
+	app.sender = newMockSender()
+
+	app.handleEvent(context.Background(), &mockEvent{id: "q-osaka", severity: 50, affectedAreas: []string{"大阪府"}})
+	app.handleEvent(context.Background(), &mockEvent{id: "q-tokyo", severity: 50, affectedAreas: []string{"東京都"}})
+
+	if got := len(live.Events()); got != 1 {
+		t.Fatalf("streamed %d events, want 1", got)
+	}
+	if record := <-live.Events(); record.ID != "q-tokyo" {
+		t.Errorf("streamed %q, want q-tokyo", record.ID)
+	}
+}
+
+func TestApp_Rollups(t *testing.T) {
+	cfg := &config.Config{
+		Source: config.SourceConfig{Type: "p2pquake", Endpoint: "ws://example.com/ws"},
+	}
+	rollups := analytics.NewMemoryRepository()
+	app := NewApp(cfg, newMockRepository(nil), WithEventRepository(newMockEventRepository()), WithRollups(rollups))
+	app.sender = newMockSender()
+
+	occurred := time.Date(2024, 1, 1, 7, 10, 0, 0, time.UTC)
+	app.handleEvent(context.Background(), &mockEvent{id: "q-1", eventType: source.EventTypeEarthquake, severity: p2pquake.ScaleToSeverity(p2pquake.Scale7), affectedAreas: []string{"石川県"}, occurredAt: occurred})
+	app.handleEvent(context.Background(), &mockEvent{id: "t-1", eventType: source.EventTypeTsunami, affectedAreas: []string{"石川県"}, occurredAt: occurred})
+
+	days, err := rollups.List(context.Background(), "2024-01-01", "2024-01-01")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(days) != 1 || days[0].Events != 1 || days[0].MaxScale != p2pquake.Scale7 || days[0].ByPrefecture["石川県"] != 1 {
+		t.Errorf("rollups = %+v, want only the earthquake", days)
+	}
+}
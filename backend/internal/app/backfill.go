@@ -0,0 +1,69 @@
+package app
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/otiai10/namazu/backend/internal/source"
+)
+
+// maxBackfillAge bounds how far back missed events are recovered after a
+// reconnection; older earthquakes are no longer worth alerting about
+const maxBackfillAge = time.Hour
+
+// History recovers the events a source missed while it was disconnected
+type History interface {
+	// Since returns the events that occurred after the given time, oldest first
+	Since(ctx context.Context, after time.Time) ([]source.Event, error)
+}
+
+// backfill queues the events that occurred since the last stored one (at most
+// maxBackfillAge ago) for the event loop, which skips those already stored.
+// It runs after the source reconnects. Without an event repository there is
+// nothing to tell missed events from delivered ones, so nothing is recovered.
+func (a *App) backfill(ctx context.Context) {
+	if a.history == nil || a.eventRepo == nil {
+		return
+	}
+
+	after := time.Now().Add(-maxBackfillAge)
+	latest, err := a.eventRepo.List(ctx, 1, nil)
+	if err != nil {
+		log.Printf("Backfill skipped: failed to get the latest event: %v", err)
+		return
+	}
+	if len(latest) > 0 && latest[0].OccurredAt.After(after) {
+		after = latest[0].OccurredAt
+	}
+
+	events, err := a.history.Since(ctx, after)
+	if err != nil {
+		log.Printf("Backfill failed: %v", err)
+		return
+	}
+	if len(events) > 0 {
+		log.Printf("Backfilling %d event(s) since %s", len(events), after.Format(time.RFC3339))
+	}
+	for _, event := range events {
+		select {
+		case a.backfills <- event:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// alreadyStored reports whether a backfilled event was delivered live before
+// the source disconnected (or by an earlier backfill)
+func (a *App) alreadyStored(ctx context.Context, event source.Event) bool {
+	if !source.IsBackfilled(event) || a.eventRepo == nil {
+		return false
+	}
+	record, err := a.eventRepo.Get(ctx, event.GetID())
+	if err != nil {
+		log.Printf("Failed to check backfilled event %s: %v", event.GetID(), err)
+		return true
+	}
+	return record != nil
+}
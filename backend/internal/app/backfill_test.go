@@ -0,0 +1,83 @@
+package app
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/otiai10/namazu/backend/internal/source"
+	"github.com/otiai10/namazu/backend/internal/store"
+	"github.com/otiai10/namazu/backend/internal/subscription"
+)
+
+// backfilledEvent is a mockEvent recovered from the history
+type backfilledEvent struct {
+	*mockEvent
+}
+
+func (e backfilledEvent) IsBackfilled() bool { return true }
+
+// mockHistory returns fixed events and records the time it was asked for
+type mockHistory struct {
+	events []source.Event
+	after  time.Time
+}
+
+func (m *mockHistory) Since(ctx context.Context, after time.Time) ([]source.Event, error) {
+	m.after = after
+	return m.events, nil
+}
+
+func TestApp_Backfill(t *testing.T) {
+	ctx := context.Background()
+	subs := []subscription.Subscription{
+		{ID: "sub-1", Name: "Hook", Delivery: subscription.DeliveryConfig{Type: "webhook", URL: "https://hook.example.com"}},
+	}
+	eventRepo := newMockEventRepository()
+	lastSeen := time.Now().Add(-10 * time.Minute)
+	if _, err := eventRepo.Create(ctx, store.EventRecord{ID: "live", OccurredAt: lastSeen}); err != nil {
+		t.Fatal(err)
+	}
+	app, sender := newDigestTestApp(subs, WithEventRepository(eventRepo))
+
+	history := &mockHistory{events: []source.Event{
+		backfilledEvent{&mockEvent{id: "live", severity: 50, source: "p2pquake", occurredAt: lastSeen}},
+		backfilledEvent{&mockEvent{id: "missed", severity: 50, source: "p2pquake", occurredAt: lastSeen.Add(time.Minute)}},
+	}}
+	app.history = history
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		app.backfill(ctx)
+	}()
+	for range history.events {
+		app.handleEvent(ctx, <-app.backfills)
+	}
+	<-done
+
+	if !history.after.Equal(lastSeen) {
+		t.Errorf("after = %v, want the latest stored event %v", history.after, lastSeen)
+	}
+	calls := sender.GetSendAllCalls()
+	if len(calls) != 1 {
+		t.Fatalf("Expected only the missed event to be delivered, got %d SendAll calls", len(calls))
+	}
+	if !calls[0].targets[0].Backfilled {
+		t.Error("Expected the delivery to be marked as backfilled")
+	}
+	if record, _ := eventRepo.Get(ctx, "missed"); record == nil || !record.Backfilled {
+		t.Errorf("Expected the missed event to be stored as backfilled, got %+v", record)
+	}
+}
+
+func TestApp_Backfill_MaxAge(t *testing.T) {
+	app, _ := newDigestTestApp(nil, WithEventRepository(newMockEventRepository()))
+	history := &mockHistory{}
+	app.history = history
+
+	app.backfill(context.Background())
+	if age := time.Since(history.after); age < maxBackfillAge || age > maxBackfillAge+time.Minute {
+		t.Errorf("Expected an empty store to backfill the last %v, got %v", maxBackfillAge, age)
+	}
+}
@@ -0,0 +1,224 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/otiai10/namazu/backend/internal/delivery"
+	"github.com/otiai10/namazu/backend/internal/delivery/schema"
+	"github.com/otiai10/namazu/backend/internal/i18n"
+	"github.com/otiai10/namazu/backend/internal/source"
+	"github.com/otiai10/namazu/backend/internal/source/p2pquake"
+	"github.com/otiai10/namazu/backend/internal/store"
+	"github.com/otiai10/namazu/backend/internal/subscription"
+)
+
+// DigestType is the "type" of every digest payload, so receivers can tell
+// digests apart from earthquake payloads and service notices.
+const DigestType = "namazu.digest"
+
+// defaultDigestTick is how often Run looks for digests that are due
+const defaultDigestTick = time.Minute
+
+// Digest is the payload delivered to a subscription in digest mode
+type Digest struct {
+	Type           string              `json:"type"` // Always DigestType
+	ID             string              `json:"id"`
+	SubscriptionID string              `json:"subscription_id"`
+	From           time.Time           `json:"from"` // When the first event was collected
+	To             time.Time           `json:"to"`   // When the digest was sent
+	Count          int                 `json:"count"`
+	MaxSeverity    int                 `json:"max_severity"`
+	MaxScale       int                 `json:"max_scale"` // P2P地震情報 scale (10-70) of MaxSeverity; 0 below 震度1
+	Summary        string              `json:"summary"`   // One line for people, in the subscription's locale, e.g. "3件の地震情報（最大震度4）"
+	Events         []store.DigestEvent `json:"events"`    // At most store.MaxDigestEvents, oldest first
+}
+
+// digestKey identifies a subscription's pending digest.
+// Static subscriptions have no ID, so their name is used.
+func digestKey(sub subscription.Subscription) string {
+	if sub.ID != "" {
+		return sub.ID
+	}
+	return sub.Name
+}
+
+// collectDigests adds the event to the pending digests of subscriptions in
+// digest mode and returns the subscriptions to deliver to immediately.
+func (a *App) collectDigests(ctx context.Context, subs []subscription.Subscription, event source.Event, eventID string) []subscription.Subscription {
+	result := make([]subscription.Subscription, 0, len(subs))
+	for _, sub := range subs {
+		if sub.Digest == nil {
+			result = append(result, sub)
+			continue
+		}
+		a.addToDigest(ctx, digestKey(sub), event, eventID)
+		log.Printf("Subscription [%s]: collected for digest (IntervalMinutes=%d)", sub.Name, sub.Digest.IntervalMinutes)
+	}
+	return result
+}
+
+// addToDigest appends the event to the pending digest and persists it
+func (a *App) addToDigest(ctx context.Context, key string, event source.Event, eventID string) {
+	entry := store.DigestEvent{
+		ID:            eventID,
+		Type:          string(event.GetType()),
+		Source:        event.GetSource(),
+		Severity:      event.GetSeverity(),
+		AffectedAreas: event.GetAffectedAreas(),
+		OccurredAt:    event.GetOccurredAt(),
+	}
+	if entry.ID == "" {
+		entry.ID = event.GetID()
+	}
+	if located, ok := event.(source.Located); ok {
+		if h := located.GetHypocenter(); h != nil {
+			entry.Hypocenter = h.Name
+			if h.Magnitude > 0 {
+				entry.Magnitude = h.Magnitude
+			}
+		}
+	}
+
+	a.digestMu.Lock()
+	pending, ok := a.digests[key]
+	if !ok {
+		pending = &store.PendingDigest{SubscriptionID: key, StartedAt: time.Now().UTC()}
+		a.digests[key] = pending
+	}
+	pending.Count++
+	if entry.Severity > pending.MaxSeverity {
+		pending.MaxSeverity = entry.Severity
+	}
+	if len(pending.Events) < store.MaxDigestEvents {
+		pending.Events = append(pending.Events, entry)
+	}
+	snapshot := *pending
+	snapshot.Events = append([]store.DigestEvent(nil), pending.Events...)
+	a.digestMu.Unlock()
+
+	if a.digestRepo != nil {
+		if err := a.digestRepo.Save(ctx, snapshot); err != nil {
+			log.Printf("Failed to save pending digest of %s: %v", key, err)
+		}
+	}
+}
+
+// restoreDigests loads the digests collected before a restart
+func (a *App) restoreDigests(ctx context.Context) {
+	if a.digestRepo == nil {
+		return
+	}
+	digests, err := a.digestRepo.List(ctx)
+	if err != nil {
+		log.Printf("Failed to load pending digests: %v", err)
+		return
+	}
+
+	a.digestMu.Lock()
+	defer a.digestMu.Unlock()
+	for i := range digests {
+		a.digests[digests[i].SubscriptionID] = &digests[i]
+	}
+	if len(digests) > 0 {
+		log.Printf("Restored %d pending digest(s)", len(digests))
+	}
+}
+
+// flushDigests sends the digests whose interval has passed at now.
+// Digests of subscriptions that left digest mode are sent right away;
+// those of deleted, suspended or expired subscriptions are discarded.
+func (a *App) flushDigests(ctx context.Context, now time.Time) {
+	a.digestMu.Lock()
+	empty := len(a.digests) == 0
+	a.digestMu.Unlock()
+	if empty {
+		return
+	}
+
+	subscriptions, err := a.repository.List(ctx)
+	if err != nil {
+		log.Printf("Failed to get subscriptions for digests: %v", err)
+		return
+	}
+	subs := make(map[string]subscription.Subscription, len(subscriptions))
+	for _, sub := range subscriptions {
+		subs[digestKey(sub)] = sub
+	}
+
+	a.digestMu.Lock()
+	var due []store.PendingDigest
+	var discarded []string
+	for key, pending := range a.digests {
+		sub, ok := subs[key]
+		if !ok || !sub.Deliverable(now) {
+			discarded = append(discarded, key)
+			delete(a.digests, key)
+			continue
+		}
+		if sub.Digest != nil && now.Before(pending.StartedAt.Add(sub.Digest.Interval())) {
+			continue
+		}
+		due = append(due, *pending)
+		delete(a.digests, key)
+	}
+	a.digestMu.Unlock()
+
+	for _, key := range discarded {
+		log.Printf("Discarding pending digest of %s: subscription is gone or not deliverable", key)
+		a.deleteDigest(ctx, key)
+	}
+	for _, pending := range due {
+		a.sendDigest(ctx, subs[pending.SubscriptionID], pending, now)
+		a.deleteDigest(ctx, pending.SubscriptionID)
+	}
+}
+
+// sendDigest delivers a digest through the subscription's channel in the background
+func (a *App) sendDigest(ctx context.Context, sub subscription.Subscription, pending store.PendingDigest, now time.Time) {
+	digest := Digest{
+		Type:           DigestType,
+		ID:             fmt.Sprintf("digest-%s-%d", pending.SubscriptionID, now.Unix()),
+		SubscriptionID: sub.ID,
+		From:           pending.StartedAt,
+		To:             now.UTC(),
+		Count:          pending.Count,
+		MaxSeverity:    pending.MaxSeverity,
+		MaxScale:       p2pquake.SeverityToScale(pending.MaxSeverity),
+		Events:         pending.Events,
+	}
+	digest.Summary = digestSummary(sub.Locale, digest.Count, digest.MaxScale)
+	payload, err := json.Marshal(digest)
+	if err != nil {
+		log.Printf("Failed to marshal digest %s: %v", digest.ID, err)
+		return
+	}
+	a.checkPayload(schema.SchemaDigest, payload)
+
+	log.Printf("Subscription [%s]: sending digest %s of %d event(s)", sub.Name, digest.ID, digest.Count)
+	a.background.Add(1)
+	go func() {
+		defer a.background.Done()
+		a.dispatch(ctx, delivery.Message{ID: digest.ID, Payload: payload}, []subscription.Subscription{sub})
+	}()
+}
+
+// digestSummary describes a digest in locale
+func digestSummary(locale string, count, maxScale int) string {
+	if maxScale <= 0 {
+		return i18n.T(locale, "digest.summary_small", count)
+	}
+	return i18n.T(locale, "digest.summary", count, i18n.Scale(locale, maxScale))
+}
+
+func (a *App) deleteDigest(ctx context.Context, key string) {
+	if a.digestRepo == nil {
+		return
+	}
+	if err := a.digestRepo.Delete(ctx, key); err != nil {
+		log.Printf("Failed to delete pending digest of %s: %v", key, err)
+	}
+}
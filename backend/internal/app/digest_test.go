@@ -0,0 +1,155 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/otiai10/namazu/backend/internal/config"
+	"github.com/otiai10/namazu/backend/internal/delivery/schema"
+	"github.com/otiai10/namazu/backend/internal/source/p2pquake"
+	"github.com/otiai10/namazu/backend/internal/store"
+	"github.com/otiai10/namazu/backend/internal/subscription"
+)
+
+func newDigestTestApp(subs []subscription.Subscription, opts ...Option) (*App, *mockSender) {
+	cfg := &config.Config{
+		Source: config.SourceConfig{Type: "p2pquake", Endpoint: "ws://example.com/ws"},
+	}
+	app := NewApp(cfg, newMockRepository(subs), opts...)
+	sender := newMockSender()
+	app.sender = sender
+	return app, sender
+}
+
+func TestApp_Digest(t *testing.T) {
+	ctx := context.Background()
+	subs := []subscription.Subscription{
+		{ID: "digest", Name: "Hourly", Digest: &subscription.DigestConfig{IntervalMinutes: 60}, Delivery: subscription.DeliveryConfig{Type: "webhook", URL: "https://digest.example.com"}},
+		{ID: "live", Name: "Live", Delivery: subscription.DeliveryConfig{Type: "webhook", URL: "https://live.example.com"}},
+	}
+	repo := store.NewMemoryDigestRepository()
+	app, sender := newDigestTestApp(subs, WithDigestRepository(repo))
+
+	app.handleEvent(ctx, &mockEvent{id: "quake-1", severity: p2pquake.ScaleToSeverity(p2pquake.Scale3), source: "p2pquake", affectedAreas: []string{"石川県"}, rawJSON: `{}`})
+	app.handleEvent(ctx, &mockEvent{id: "quake-2", severity: p2pquake.ScaleToSeverity(p2pquake.Scale5Weak), source: "p2pquake", rawJSON: `{}`})
+
+	calls := sender.GetSendAllCalls()
+	if len(calls) != 2 {
+		t.Fatalf("Expected 2 SendAll calls, got %d", len(calls))
+	}
+	for _, call := range calls {
+		if len(call.targets) != 1 || call.targets[0].URL != "https://live.example.com" {
+			t.Fatalf("expected only the live subscription to be delivered immediately, got %+v", call.targets)
+		}
+	}
+	if pending, _ := repo.List(ctx); len(pending) != 1 || pending[0].Count != 2 {
+		t.Fatalf("expected the digest to be persisted with 2 events, got %+v", pending)
+	}
+
+	// Not due yet
+	app.flushDigests(ctx, time.Now().Add(30*time.Minute))
+	app.background.Wait()
+	if len(sender.GetSendAllCalls()) != 2 {
+		t.Fatal("digest sent before its interval passed")
+	}
+
+	app.flushDigests(ctx, time.Now().Add(61*time.Minute))
+	app.background.Wait()
+	calls = sender.GetSendAllCalls()
+	if len(calls) != 3 {
+		t.Fatalf("Expected the digest to be sent, got %d SendAll calls", len(calls))
+	}
+	if calls[2].targets[0].URL != "https://digest.example.com" {
+		t.Errorf("digest sent to %s", calls[2].targets[0].URL)
+	}
+	var digest Digest
+	if err := json.Unmarshal(calls[2].payload, &digest); err != nil {
+		t.Fatalf("failed to decode digest: %v", err)
+	}
+	if err := schema.Validate(schema.SchemaDigest, calls[2].payload); err != nil {
+		t.Errorf("digest does not match its published schema: %v", err)
+	}
+	if digest.Type != DigestType || digest.SubscriptionID != "digest" || digest.Count != 2 || digest.MaxScale != p2pquake.Scale5Weak || len(digest.Events) != 2 {
+		t.Errorf("unexpected digest: %+v", digest)
+	}
+	if digest.Summary != "2件の地震情報（最大震度5弱）" {
+		t.Errorf("Summary = %q", digest.Summary)
+	}
+	if digest.Events[0].ID != "quake-1" || digest.Events[0].AffectedAreas[0] != "石川県" {
+		t.Errorf("unexpected first event: %+v", digest.Events[0])
+	}
+	if pending, _ := repo.List(ctx); len(pending) != 0 {
+		t.Errorf("expected the pending digest to be deleted, got %+v", pending)
+	}
+
+	// Nothing left to send
+	app.flushDigests(ctx, time.Now().Add(3*time.Hour))
+	app.background.Wait()
+	if len(sender.GetSendAllCalls()) != 3 {
+		t.Error("an empty digest was sent")
+	}
+}
+
+func TestApp_DigestRestore(t *testing.T) {
+	ctx := context.Background()
+	subs := []subscription.Subscription{
+		{ID: "digest", Name: "Hourly", Digest: &subscription.DigestConfig{IntervalMinutes: 60}, Delivery: subscription.DeliveryConfig{Type: "webhook", URL: "https://digest.example.com"}},
+	}
+	repo := store.NewMemoryDigestRepository()
+	startedAt := time.Now().Add(-2 * time.Hour)
+	if err := repo.Save(ctx, store.PendingDigest{SubscriptionID: "digest", StartedAt: startedAt, Count: 1, MaxSeverity: 30, Events: []store.DigestEvent{{ID: "quake-1", Severity: 30}}}); err != nil {
+		t.Fatal(err)
+	}
+	// A digest of a subscription that was deleted meanwhile
+	if err := repo.Save(ctx, store.PendingDigest{SubscriptionID: "deleted", StartedAt: startedAt, Count: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	app, sender := newDigestTestApp(subs, WithDigestRepository(repo))
+	app.restoreDigests(ctx)
+	app.flushDigests(ctx, time.Now())
+	app.background.Wait()
+
+	calls := sender.GetSendAllCalls()
+	if len(calls) != 1 || calls[0].targets[0].URL != "https://digest.example.com" {
+		t.Fatalf("expected the restored digest to be sent, got %+v", calls)
+	}
+	if pending, _ := repo.List(ctx); len(pending) != 0 {
+		t.Errorf("expected all pending digests to be removed, got %+v", pending)
+	}
+}
+
+func TestApp_DigestModeTurnedOff(t *testing.T) {
+	ctx := context.Background()
+	repo := newMockRepository([]subscription.Subscription{
+		{ID: "digest", Name: "Hourly", Digest: &subscription.DigestConfig{IntervalMinutes: 60}, Delivery: subscription.DeliveryConfig{Type: "webhook", URL: "https://digest.example.com"}},
+	})
+	cfg := &config.Config{
+		Source: config.SourceConfig{Type: "p2pquake", Endpoint: "ws://example.com/ws"},
+	}
+	app := NewApp(cfg, repo)
+	sender := newMockSender()
+	app.sender = sender
+
+	app.handleEvent(ctx, &mockEvent{id: "quake-1", severity: 30, source: "p2pquake", rawJSON: `{}`})
+	repo.subscriptions[0].Digest = nil
+
+	// The events collected so far are sent without waiting for the interval
+	app.flushDigests(ctx, time.Now())
+	app.background.Wait()
+	calls := sender.GetSendAllCalls()
+	if last := calls[len(calls)-1]; len(last.targets) != 1 || last.targets[0].URL != "https://digest.example.com" {
+		t.Fatalf("Expected the collected digest to be sent, got %+v", last.targets)
+	}
+}
+
+func TestDigestSummary(t *testing.T) {
+	if got := digestSummary("en", 3, p2pquake.Scale4); got != "3 earthquake reports (up to Shindo 4)" {
+		t.Errorf("digestSummary(en) = %q", got)
+	}
+	if got := digestSummary("", 1, 0); got != "1件の地震情報" {
+		t.Errorf("digestSummary(no scale) = %q", got)
+	}
+}
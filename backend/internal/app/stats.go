@@ -0,0 +1,51 @@
+package app
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/otiai10/namazu/backend/internal/delivery/webhook"
+	"github.com/otiai10/namazu/backend/internal/source"
+)
+
+// Stats describes this instance since it started.
+// The counters are kept in memory: they restart from zero with the process.
+type Stats struct {
+	StartedAt           time.Time
+	EventsReceived      int64         // Earthquakes and tsunamis handled, duplicates and EEWs excluded
+	DeliveriesSucceeded int64         // Final outcomes, after retries
+	DeliveriesFailed    int64         // Final outcomes, after retries
+	Source              source.Status // Zero when SourceMonitored is false
+	SourceMonitored     bool          // false for sources that poll (the JMA feed)
+}
+
+// counters are the lightweight counters behind Stats
+type counters struct {
+	events    atomic.Int64
+	succeeded atomic.Int64
+	failed    atomic.Int64
+}
+
+// Stats returns the counters and source status of this instance
+func (a *App) Stats() Stats {
+	status, monitored := a.SourceStatus()
+	return Stats{
+		StartedAt:           a.startedAt,
+		EventsReceived:      a.counters.events.Load(),
+		DeliveriesSucceeded: a.counters.succeeded.Load(),
+		DeliveriesFailed:    a.counters.failed.Load(),
+		Source:              status,
+		SourceMonitored:     monitored,
+	}
+}
+
+// countDeliveries adds the final outcomes of deliveries to the counters
+func (a *App) countDeliveries(results []webhook.DeliveryResult) {
+	for _, result := range results {
+		if result.Success {
+			a.counters.succeeded.Add(1)
+		} else {
+			a.counters.failed.Add(1)
+		}
+	}
+}
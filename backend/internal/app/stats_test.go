@@ -0,0 +1,52 @@
+package app
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/otiai10/namazu/backend/internal/config"
+	"github.com/otiai10/namazu/backend/internal/delivery/webhook"
+	"github.com/otiai10/namazu/backend/internal/source"
+	"github.com/otiai10/namazu/backend/internal/subscription"
+)
+
+// monitoredClient is a mock client reporting a connection status
+type monitoredClient struct {
+	*mockClient
+	status source.Status
+}
+
+func (m *monitoredClient) Status() source.Status { return m.status }
+
+func TestApp_Stats(t *testing.T) {
+	cfg := &config.Config{Source: config.SourceConfig{Type: "p2pquake", Endpoint: "ws://example.com/ws"}}
+	repo := newMockRepository([]subscription.Subscription{
+		{Name: "ok", Delivery: subscription.DeliveryConfig{Type: "webhook", URL: "https://ok.example.com"}},
+		{Name: "down", Delivery: subscription.DeliveryConfig{Type: "webhook", URL: "https://down.example.com"}},
+	})
+	since := time.Now().Add(-time.Minute)
+	app := NewApp(cfg, repo, WithClient(&monitoredClient{mockClient: newMockClient(), status: source.Status{Connected: true, Since: since}}))
+	sender := newMockSender()
+	sender.results = []webhook.DeliveryResult{{Success: true}, {Success: false}}
+	app.sender = sender
+
+	app.handleEvent(context.Background(), &mockEvent{id: "ev-1", severity: 40, source: "p2pquake", rawJSON: `{}`})
+
+	stats := app.Stats()
+	if stats.EventsReceived != 1 || stats.DeliveriesSucceeded != 1 || stats.DeliveriesFailed != 1 {
+		t.Errorf("Stats() = %+v, want 1 event, 1 success and 1 failure", stats)
+	}
+	if !stats.SourceMonitored || !stats.Source.Connected || !stats.Source.Since.Equal(since) {
+		t.Errorf("Stats().Source = %+v, want connected since %v", stats.Source, since)
+	}
+	if stats.StartedAt.IsZero() || stats.StartedAt.After(time.Now()) {
+		t.Errorf("Stats().StartedAt = %v", stats.StartedAt)
+	}
+
+	// Sources that poll report no status
+	app = NewApp(cfg, repo, WithClient(newMockClient()))
+	if _, ok := app.SourceStatus(); ok {
+		t.Error("SourceStatus() ok = true for a client without a connection status")
+	}
+}
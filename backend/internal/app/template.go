@@ -0,0 +1,125 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/otiai10/namazu/backend/internal/delivery/enrich"
+	"github.com/otiai10/namazu/backend/internal/delivery/schema"
+	"github.com/otiai10/namazu/backend/internal/delivery/transform"
+	"github.com/otiai10/namazu/backend/internal/source"
+	"github.com/otiai10/namazu/backend/internal/source/jma"
+	"github.com/otiai10/namazu/backend/internal/source/p2pquake"
+	"github.com/otiai10/namazu/backend/internal/store"
+	"github.com/otiai10/namazu/backend/internal/subscription"
+)
+
+// customPayload reports whether the subscription gets its own rendered body
+func customPayload(sub subscription.Subscription) bool {
+	return sub.Delivery.Template != "" || sub.Delivery.Enrich || sub.Delivery.Format == schema.FormatV1
+}
+
+// renderPayload converts the body to the subscription's payload format, adds
+// the derived fields of the event to it if the subscription enriches it, then
+// applies the subscription's payload template, if any, which sees the
+// resulting body as .Payload. The event is nil for notices and digests.
+// The result is what gets signed and sent.
+func renderPayload(sub subscription.Subscription, event source.Event, payload []byte) ([]byte, error) {
+	payload, err := schema.Render(sub.Delivery.Format, event, payload)
+	if err != nil {
+		return nil, err
+	}
+	if sub.Delivery.Enrich {
+		enriched, err := enrich.Apply(event, payload)
+		if err != nil {
+			return nil, err
+		}
+		payload = enriched
+	}
+	if sub.Delivery.Template == "" {
+		return payload, nil
+	}
+	tmpl, err := transform.Compile(sub.Delivery.Template)
+	if err != nil {
+		return nil, err
+	}
+	return tmpl.Render(event, payload)
+}
+
+// payloadSchema returns the published schema the subscription's rendered body
+// follows, or "" if it has none (raw and templated bodies)
+func payloadSchema(sub subscription.Subscription, event source.Event) string {
+	if sub.Delivery.Format != schema.FormatV1 || sub.Delivery.Template != "" || event == nil {
+		return ""
+	}
+	return schema.SchemaEvent
+}
+
+// checkPayload logs where a generated payload violates its published schema,
+// if payload validation is enabled
+func (a *App) checkPayload(name string, payload []byte) {
+	if !a.validate {
+		return
+	}
+	if err := schema.Validate(name, payload); err != nil {
+		log.Printf("Payload does not match schema %s: %v", name, err)
+	}
+}
+
+// storedEvent returns a stored event for templates, or nil if eventID is empty
+// or the event cannot be loaded (templates then see no .Event).
+func (a *App) storedEvent(ctx context.Context, eventID string) source.Event {
+	if eventID == "" || a.eventRepo == nil {
+		return nil
+	}
+	record, err := a.eventRepo.Get(ctx, eventID)
+	if err != nil {
+		log.Printf("Failed to get event %s for template: %v", eventID, err)
+		return nil
+	}
+	if record == nil {
+		return nil
+	}
+	return recordEvent{*record}
+}
+
+// recordEvent adapts a stored event to source.Event.
+// The hypocenter is not stored; it is read back from the raw payload.
+type recordEvent struct {
+	record store.EventRecord
+}
+
+// GetHypocenter returns the hypocenter in the raw payload of the known
+// sources, or nil if there is none
+func (e recordEvent) GetHypocenter() *source.Hypocenter {
+	var event source.Event
+	switch e.record.Source {
+	case "p2pquake":
+		parsed, err := p2pquake.ParseMessage([]byte(e.record.RawJSON))
+		if err != nil || parsed == nil {
+			return nil
+		}
+		event = parsed
+	case "jma":
+		var quake jma.Quake
+		if err := json.Unmarshal([]byte(e.record.RawJSON), &quake); err != nil {
+			return nil
+		}
+		event = &quake
+	}
+	if located, ok := event.(source.Located); ok {
+		return located.GetHypocenter()
+	}
+	return nil
+}
+
+func (e recordEvent) GetID() string              { return e.record.ID }
+func (e recordEvent) GetType() source.EventType  { return source.EventType(e.record.Type) }
+func (e recordEvent) GetSource() string          { return e.record.Source }
+func (e recordEvent) GetSeverity() int           { return e.record.Severity }
+func (e recordEvent) GetAffectedAreas() []string { return e.record.AffectedAreas }
+func (e recordEvent) GetOccurredAt() time.Time   { return e.record.OccurredAt }
+func (e recordEvent) GetReceivedAt() time.Time   { return e.record.ReceivedAt }
+func (e recordEvent) GetRawJSON() string         { return e.record.RawJSON }
@@ -0,0 +1,219 @@
+// Package archive moves old events out of the event store.
+//
+// An Archiver periodically writes every event older than the retention period
+// to daily gzipped JSONL files in object storage and then deletes them from
+// the store, so the store stays small while the full history remains
+// available for analytics (e.g. as a BigQuery external table).
+//
+// Files are named {prefix}dt={YYYY-MM-DD}/events-{run}.jsonl.gz, where the
+// date is the UTC day the events occurred and run is the start of the run
+// that wrote it. A day is normally archived in one file; a day is only split
+// when a run fails after writing and the next run picks up the rest.
+package archive
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/otiai10/namazu/backend/internal/config"
+	"github.com/otiai10/namazu/backend/internal/store"
+)
+
+const (
+	// DefaultRetention is how long events stay in the store
+	DefaultRetention = 90 * 24 * time.Hour
+
+	// DefaultInterval is how often Run archives
+	DefaultInterval = 24 * time.Hour
+
+	// pageSize is the number of events read and deleted at a time
+	pageSize = 500
+)
+
+// EventStore reads and deletes the archived events
+type EventStore interface {
+	Query(ctx context.Context, q store.EventQuery) (*store.EventPage, error)
+	store.EventDeleter
+}
+
+// Sink stores archive files
+type Sink interface {
+	// Write stores data under name, replacing any object with that name
+	Write(ctx context.Context, name string, data []byte) error
+}
+
+// Report describes one archive run
+type Report struct {
+	Cutoff time.Time `json:"cutoff"` // Events that occurred before this were archived
+	Files  []string  `json:"files"`
+	Events int       `json:"events"`
+}
+
+// Archiver archives and purges events older than the retention period
+type Archiver struct {
+	events    EventStore
+	sink      Sink
+	prefix    string
+	retention time.Duration
+	now       func() time.Time
+}
+
+// New creates an archiver writing to sink with the prefix and retention of
+// cfg (nil uses no prefix and DefaultRetention)
+func New(events EventStore, sink Sink, cfg *config.ArchiveConfig) *Archiver {
+	a := &Archiver{events: events, sink: sink, retention: DefaultRetention, now: time.Now}
+	if cfg != nil {
+		a.prefix = cfg.Prefix
+		if cfg.RetentionDays > 0 {
+			a.retention = time.Duration(cfg.RetentionDays) * 24 * time.Hour
+		}
+	}
+	return a
+}
+
+// Run archives once at startup and then every interval until ctx is cancelled
+func (a *Archiver) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		report, err := a.Archive(ctx)
+		if err != nil {
+			log.Printf("Event archive failed: %v", err)
+		}
+		if report != nil && report.Events > 0 {
+			log.Printf("Archived %d event(s) before %s to %d file(s)", report.Events, report.Cutoff.Format("2006-01-02"), len(report.Files))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Archive writes the events that occurred before the retention period, one
+// file per day, and deletes each day's events once its file is written.
+// The cutoff is a UTC midnight, so only whole days are archived.
+// On error the report lists what was archived before it.
+func (a *Archiver) Archive(ctx context.Context) (*Report, error) {
+	started := a.now().UTC()
+	cutoff := started.Add(-a.retention).Truncate(24 * time.Hour)
+	report := &Report{Cutoff: cutoff, Files: []string{}}
+
+	for {
+		oldest, err := a.events.Query(ctx, store.EventQuery{Limit: 1, Ascending: true, To: &cutoff})
+		if err != nil {
+			return report, fmt.Errorf("failed to find events to archive: %w", err)
+		}
+		if len(oldest.Events) == 0 {
+			return report, nil
+		}
+
+		day := oldest.Events[0].OccurredAt.UTC().Truncate(24 * time.Hour)
+		end := day.Add(24 * time.Hour)
+		if end.After(cutoff) {
+			end = cutoff
+		}
+		name, n, err := a.archiveDay(ctx, day, end, started)
+		if err != nil {
+			return report, err
+		}
+		report.Files = append(report.Files, name)
+		report.Events += n
+	}
+}
+
+// archiveDay archives the events in [day, end) and returns the file name and
+// the number of events
+func (a *Archiver) archiveDay(ctx context.Context, day, end, started time.Time) (string, int, error) {
+	var events []store.EventRecord
+	q := store.EventQuery{Limit: pageSize, Ascending: true, From: &day, To: &end}
+	for {
+		page, err := a.events.Query(ctx, q)
+		if err != nil {
+			return "", 0, fmt.Errorf("failed to read events of %s: %w", day.Format("2006-01-02"), err)
+		}
+		events = append(events, page.Events...)
+		if page.NextCursor == "" {
+			break
+		}
+		q.Cursor = page.NextCursor
+	}
+
+	data, err := encodeJSONL(events)
+	if err != nil {
+		return "", 0, err
+	}
+	name := fmt.Sprintf("%sdt=%s/events-%s.jsonl.gz", a.prefix, day.Format("2006-01-02"), started.Format("20060102T150405Z"))
+	if err := a.sink.Write(ctx, name, data); err != nil {
+		return "", 0, fmt.Errorf("failed to write %s: %w", name, err)
+	}
+
+	for start := 0; start < len(events); start += pageSize {
+		batch := events[start:min(start+pageSize, len(events))]
+		ids := make([]string, len(batch))
+		for i, event := range batch {
+			ids[i] = event.ID
+		}
+		if err := a.events.Delete(ctx, ids); err != nil {
+			return "", 0, fmt.Errorf("failed to delete events archived to %s: %w", name, err)
+		}
+	}
+	return name, len(events), nil
+}
+
+// archivedEvent is a line of an archive file
+type archivedEvent struct {
+	ID            string          `json:"id"`
+	Type          string          `json:"type"`
+	Source        string          `json:"source"`
+	Severity      int             `json:"severity"`
+	AffectedAreas []string        `json:"affected_areas"`
+	OccurredAt    time.Time       `json:"occurred_at"`
+	ReceivedAt    time.Time       `json:"received_at"`
+	CreatedAt     time.Time       `json:"created_at"`
+	Backfilled    bool            `json:"backfilled"`
+	Raw           json.RawMessage `json:"raw,omitempty"`      // The source's payload, if it is JSON
+	RawText       string          `json:"raw_text,omitempty"` // The source's payload otherwise
+}
+
+// encodeJSONL returns the events as gzipped JSON lines
+func encodeJSONL(events []store.EventRecord) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	enc := json.NewEncoder(zw)
+	for _, event := range events {
+		line := archivedEvent{
+			ID:            event.ID,
+			Type:          event.Type,
+			Source:        event.Source,
+			Severity:      event.Severity,
+			AffectedAreas: event.AffectedAreas,
+			OccurredAt:    event.OccurredAt.UTC(),
+			ReceivedAt:    event.ReceivedAt.UTC(),
+			CreatedAt:     event.CreatedAt.UTC(),
+			Backfilled:    event.Backfilled,
+		}
+		if line.AffectedAreas == nil {
+			line.AffectedAreas = []string{}
+		}
+		if json.Valid([]byte(event.RawJSON)) {
+			line.Raw = json.RawMessage(event.RawJSON)
+		} else {
+			line.RawText = event.RawJSON
+		}
+		if err := enc.Encode(line); err != nil {
+			return nil, fmt.Errorf("failed to encode event %s: %w", event.ID, err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to compress events: %w", err)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,132 @@
+package archive
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/otiai10/namazu/backend/internal/config"
+	"github.com/otiai10/namazu/backend/internal/store"
+)
+
+type memorySink struct {
+	files map[string][]byte
+	err   error
+}
+
+func (s *memorySink) Write(ctx context.Context, name string, data []byte) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.files[name] = data
+	return nil
+}
+
+// readJSONL decompresses an archive file into its lines
+func readJSONL(t *testing.T, data []byte) []map[string]any {
+	t.Helper()
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var lines []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(string(raw)), "\n") {
+		var v map[string]any
+		if err := json.Unmarshal([]byte(line), &v); err != nil {
+			t.Fatalf("invalid line %q: %v", line, err)
+		}
+		lines = append(lines, v)
+	}
+	return lines
+}
+
+func TestArchiver_Archive(t *testing.T) {
+	ctx := context.Background()
+	events := store.NewMemoryEventRepository()
+	now := time.Date(2026, 4, 20, 9, 30, 0, 0, time.UTC)
+	for _, e := range []store.EventRecord{
+		{ID: "old-1", Type: "earthquake", Severity: 5, OccurredAt: time.Date(2026, 1, 10, 3, 0, 0, 0, time.UTC), RawJSON: `{"code": 551}`},
+		{ID: "old-2", Type: "tsunami", OccurredAt: time.Date(2026, 1, 10, 23, 59, 0, 0, time.UTC), RawJSON: "not json"},
+		{ID: "old-3", Type: "earthquake", OccurredAt: time.Date(2026, 1, 19, 12, 0, 0, 0, time.UTC)},
+		// Within the retention period: the cutoff is 2026-01-20T00:00Z
+		{ID: "recent", Type: "earthquake", OccurredAt: time.Date(2026, 1, 20, 0, 0, 0, 0, time.UTC)},
+	} {
+		if _, err := events.Create(ctx, e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	sink := &memorySink{files: map[string][]byte{}}
+	a := New(events, sink, &config.ArchiveConfig{Bucket: "archive", Prefix: "events/", RetentionDays: 90})
+	a.now = func() time.Time { return now }
+
+	report, err := a.Archive(ctx)
+	if err != nil {
+		t.Fatalf("Archive() error = %v", err)
+	}
+	if !report.Cutoff.Equal(time.Date(2026, 1, 20, 0, 0, 0, 0, time.UTC)) || report.Events != 3 {
+		t.Errorf("report = %+v", report)
+	}
+	wantFiles := []string{
+		"events/dt=2026-01-10/events-20260420T093000Z.jsonl.gz",
+		"events/dt=2026-01-19/events-20260420T093000Z.jsonl.gz",
+	}
+	if len(report.Files) != 2 || report.Files[0] != wantFiles[0] || report.Files[1] != wantFiles[1] {
+		t.Errorf("files = %v, want %v", report.Files, wantFiles)
+	}
+
+	lines := readJSONL(t, sink.files[wantFiles[0]])
+	if len(lines) != 2 || lines[0]["id"] != "old-1" || lines[1]["id"] != "old-2" {
+		t.Fatalf("lines of %s = %v", wantFiles[0], lines)
+	}
+	if raw, ok := lines[0]["raw"].(map[string]any); !ok || raw["code"] != float64(551) {
+		t.Errorf("raw = %v, want the JSON payload", lines[0]["raw"])
+	}
+	if lines[1]["raw_text"] != "not json" || lines[0]["occurred_at"] != "2026-01-10T03:00:00Z" {
+		t.Errorf("lines = %v", lines)
+	}
+
+	page, _ := events.Query(ctx, store.EventQuery{Limit: 10})
+	if len(page.Events) != 1 || page.Events[0].ID != "recent" {
+		t.Errorf("events left = %+v, want only recent", page.Events)
+	}
+
+	// Nothing is left to archive
+	report, err = a.Archive(ctx)
+	if err != nil || report.Events != 0 || len(report.Files) != 0 {
+		t.Errorf("second Archive() = %+v, %v", report, err)
+	}
+}
+
+func TestArchiver_KeepsEventsWhenWriteFails(t *testing.T) {
+	ctx := context.Background()
+	events := store.NewMemoryEventRepository()
+	if _, err := events.Create(ctx, store.EventRecord{ID: "old", OccurredAt: time.Now().AddDate(-1, 0, 0)}); err != nil {
+		t.Fatal(err)
+	}
+
+	a := New(events, &memorySink{err: errors.New("bucket unavailable")}, nil)
+	if _, err := a.Archive(ctx); err == nil {
+		t.Fatal("Archive() should fail when the file cannot be written")
+	}
+	if event, _ := events.Get(ctx, "old"); event == nil {
+		t.Error("events must not be deleted before they are archived")
+	}
+}
+
+func TestNew_Defaults(t *testing.T) {
+	a := New(store.NewMemoryEventRepository(), &memorySink{}, nil)
+	if a.retention != DefaultRetention || a.prefix != "" {
+		t.Errorf("retention = %v, prefix = %q", a.retention, a.prefix)
+	}
+}
@@ -0,0 +1,49 @@
+package archive
+
+import (
+	"context"
+	"fmt"
+
+	"cloud.google.com/go/storage"
+	"google.golang.org/api/option"
+)
+
+// GCSSink writes archive files to a Cloud Storage bucket
+type GCSSink struct {
+	client *storage.Client
+	bucket string
+}
+
+// Compile-time interface check
+var _ Sink = (*GCSSink)(nil)
+
+// NewGCSSink creates a sink for bucket.
+// credentialsPath may be empty to use Application Default Credentials.
+func NewGCSSink(ctx context.Context, bucket, credentialsPath string) (*GCSSink, error) {
+	var opts []option.ClientOption
+	if credentialsPath != "" {
+		opts = append(opts, option.WithCredentialsFile(credentialsPath))
+	}
+
+	client, err := storage.NewClient(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create storage client: %w", err)
+	}
+	return &GCSSink{client: client, bucket: bucket}, nil
+}
+
+// Write uploads data as the object name
+func (s *GCSSink) Write(ctx context.Context, name string, data []byte) error {
+	w := s.client.Bucket(s.bucket).Object(name).NewWriter(ctx)
+	w.ContentType = "application/gzip"
+	if _, err := w.Write(data); err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+// Close closes the storage client
+func (s *GCSSink) Close() error {
+	return s.client.Close()
+}
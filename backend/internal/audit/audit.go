@@ -0,0 +1,127 @@
+// Package audit records security-relevant changes — subscription CRUD, secret
+// rotations, plan changes, login provider links and admin actions — in an
+// append-only log with who made them and from where.
+package audit
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+)
+
+// Actions recorded in the audit log
+const (
+	ActionSubscriptionCreate = "subscription.create"
+	ActionSubscriptionUpdate = "subscription.update"
+	ActionSubscriptionDelete = "subscription.delete"
+	ActionSecretRotate       = "subscription.rotate_secret"
+	ActionSubscriptionPause  = "subscription.pause"
+	ActionSubscriptionResume = "subscription.resume"
+	ActionSubscriptionVerify = "subscription.verify"
+	ActionPlanChange         = "user.plan_change"
+	ActionProviderLink       = "user.provider_link"
+	ActionProviderUnlink     = "user.provider_unlink"
+	ActionTermsAccept        = "user.accept_terms"
+	ActionAccountDelete      = "user.delete"
+	ActionAdminNotice        = "admin.broadcast_notice"
+	ActionAdminEgressBudget  = "admin.set_egress_budget"
+	ActionAdminInjectEvent   = "admin.inject_event"
+	ActionAdminPublishEvent  = "admin.publish_event"
+	ActionAdminSetRole       = "admin.set_role"
+	ActionAdminAssignOwner   = "admin.assign_owner"
+	ActionConfigReload       = "config.reload"
+)
+
+// ActorStripe is the actor of changes made by Stripe webhooks
+const ActorStripe = "stripe"
+
+// Limits of a List
+const (
+	DefaultLimit = 100
+	MaxLimit     = 500
+)
+
+// Entry is one recorded change. Entries are never updated or deleted.
+type Entry struct {
+	ID        string            `json:"id" firestore:"-"`
+	Action    string            `json:"action" firestore:"action"`
+	ActorUID  string            `json:"actor_uid" firestore:"actorUid"` // Empty when unauthenticated; ActorStripe for billing webhooks
+	TargetID  string            `json:"target_id,omitempty" firestore:"targetId"`
+	TenantID  string            `json:"tenant_id,omitempty" firestore:"tenantId"`
+	IP        string            `json:"ip,omitempty" firestore:"ip"`
+	UserAgent string            `json:"user_agent,omitempty" firestore:"userAgent"`
+	Details   map[string]string `json:"details,omitempty" firestore:"details,omitempty"`
+	CreatedAt time.Time         `json:"created_at" firestore:"createdAt"`
+}
+
+// Filter selects entries to list. Zero fields match everything.
+type Filter struct {
+	ActorUID string
+	Action   string
+	TargetID string
+	From     time.Time // Inclusive
+	To       time.Time // Exclusive
+	Limit    int       // At most MaxLimit; zero means DefaultLimit
+}
+
+// matches reports whether e passes the filter
+func (f Filter) matches(e Entry) bool {
+	return (f.ActorUID == "" || e.ActorUID == f.ActorUID) &&
+		(f.Action == "" || e.Action == f.Action) &&
+		(f.TargetID == "" || e.TargetID == f.TargetID) &&
+		(f.From.IsZero() || !e.CreatedAt.Before(f.From)) &&
+		(f.To.IsZero() || e.CreatedAt.Before(f.To))
+}
+
+// limit returns the effective number of entries to list
+func (f Filter) limit() int {
+	if f.Limit <= 0 {
+		return DefaultLimit
+	}
+	if f.Limit > MaxLimit {
+		return MaxLimit
+	}
+	return f.Limit
+}
+
+// Repository stores audit entries
+type Repository interface {
+	// Append stores a new entry and returns its ID
+	Append(ctx context.Context, e Entry) (string, error)
+
+	// List returns the entries matching the filter, newest first
+	List(ctx context.Context, f Filter) ([]Entry, error)
+}
+
+// Logger records entries without failing the change being audited.
+// A nil Logger discards entries.
+type Logger struct {
+	repo Repository
+	now  func() time.Time
+}
+
+// NewLogger creates a Logger storing entries in repo
+func NewLogger(repo Repository) *Logger {
+	return &Logger{repo: repo, now: time.Now}
+}
+
+// Record stores an entry, stamping it with the current time.
+// Failures are logged: the change has already been made.
+func (l *Logger) Record(ctx context.Context, e Entry) {
+	if l == nil {
+		return
+	}
+	e.CreatedAt = l.now().UTC()
+	if _, err := l.repo.Append(ctx, e); err != nil {
+		log.Printf("Audit: failed to record %s by %q on %q: %v", e.Action, e.ActorUID, e.TargetID, err)
+	}
+}
+
+// List returns the recorded entries matching the filter, newest first
+func (l *Logger) List(ctx context.Context, f Filter) ([]Entry, error) {
+	if l == nil {
+		return nil, fmt.Errorf("audit log is not enabled")
+	}
+	return l.repo.List(ctx, f)
+}
@@ -0,0 +1,62 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// failingRepository fails every call
+type failingRepository struct{}
+
+func (failingRepository) Append(ctx context.Context, e Entry) (string, error) {
+	return "", errors.New("unavailable")
+}
+
+func (failingRepository) List(ctx context.Context, f Filter) ([]Entry, error) {
+	return nil, errors.New("unavailable")
+}
+
+func TestLogger_Record(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryRepository()
+	l := NewLogger(repo)
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.FixedZone("JST", 9*60*60))
+	l.now = func() time.Time { return now }
+
+	l.Record(ctx, Entry{Action: ActionSubscriptionCreate, ActorUID: "uid-1", TargetID: "sub-1"})
+	entries, err := l.List(ctx, Filter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].ID == "" || !entries[0].CreatedAt.Equal(now) || entries[0].CreatedAt.Location() != time.UTC {
+		t.Errorf("entries = %+v, want one entry stamped in UTC", entries)
+	}
+
+	// Failures do not reach the caller
+	NewLogger(failingRepository{}).Record(ctx, Entry{Action: ActionSubscriptionDelete})
+
+	var disabled *Logger
+	disabled.Record(ctx, Entry{Action: ActionSubscriptionDelete})
+	if _, err := disabled.List(ctx, Filter{}); err == nil {
+		t.Error("List() on a nil Logger should fail")
+	}
+}
+
+func TestFilter_Limit(t *testing.T) {
+	tests := []struct {
+		limit int
+		want  int
+	}{
+		{0, DefaultLimit},
+		{-1, DefaultLimit},
+		{10, 10},
+		{MaxLimit + 1, MaxLimit},
+	}
+	for _, tt := range tests {
+		if got := (Filter{Limit: tt.limit}).limit(); got != tt.want {
+			t.Errorf("Filter{Limit: %d}.limit() = %d, want %d", tt.limit, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/api/iterator"
+)
+
+// collection holds one document per entry. The service account only ever
+// creates documents in it, and security rules deny all client access.
+const collection = "audit_log"
+
+// FirestoreRepository implements Repository using Firestore
+type FirestoreRepository struct {
+	client *firestore.Client
+}
+
+// Compile-time interface check
+var _ Repository = (*FirestoreRepository)(nil)
+
+// NewFirestoreRepository creates a new FirestoreRepository
+func NewFirestoreRepository(client *firestore.Client) *FirestoreRepository {
+	return &FirestoreRepository{client: client}
+}
+
+// Append creates a new document, so an existing entry can never be overwritten
+func (r *FirestoreRepository) Append(ctx context.Context, e Entry) (string, error) {
+	if r.client == nil {
+		return "", fmt.Errorf("firestore client is nil")
+	}
+	if e.Action == "" {
+		return "", fmt.Errorf("action is required")
+	}
+
+	doc := r.client.Collection(collection).NewDoc()
+	if _, err := doc.Create(ctx, e); err != nil {
+		return "", fmt.Errorf("failed to append audit entry: %w", err)
+	}
+	return doc.ID, nil
+}
+
+// List returns the entries matching the filter, newest first.
+// Requires a composite index on the filtered fields and createdAt (see infra).
+func (r *FirestoreRepository) List(ctx context.Context, f Filter) ([]Entry, error) {
+	if r.client == nil {
+		return nil, fmt.Errorf("firestore client is nil")
+	}
+
+	q := r.client.Collection(collection).Query
+	if f.ActorUID != "" {
+		q = q.Where("actorUid", "==", f.ActorUID)
+	}
+	if f.Action != "" {
+		q = q.Where("action", "==", f.Action)
+	}
+	if f.TargetID != "" {
+		q = q.Where("targetId", "==", f.TargetID)
+	}
+	if !f.From.IsZero() {
+		q = q.Where("createdAt", ">=", f.From)
+	}
+	if !f.To.IsZero() {
+		q = q.Where("createdAt", "<", f.To)
+	}
+	iter := q.OrderBy("createdAt", firestore.Desc).Limit(f.limit()).Documents(ctx)
+	defer iter.Stop()
+
+	entries := make([]Entry, 0)
+	for {
+		docSnap, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to iterate audit entries: %w", err)
+		}
+
+		var e Entry
+		if err := docSnap.DataTo(&e); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal audit entry: %w", err)
+		}
+		e.ID = docSnap.Ref.ID
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
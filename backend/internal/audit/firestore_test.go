@@ -0,0 +1,22 @@
+package audit
+
+import (
+	"context"
+	"testing"
+)
+
+func TestFirestoreRepository_ImplementsRepository(t *testing.T) {
+	var _ Repository = (*FirestoreRepository)(nil)
+}
+
+func TestFirestoreRepository_NilClient(t *testing.T) {
+	repo := NewFirestoreRepository(nil)
+	ctx := context.Background()
+
+	if _, err := repo.Append(ctx, Entry{Action: ActionSubscriptionCreate}); err == nil {
+		t.Error("Append() expected error for nil client")
+	}
+	if _, err := repo.List(ctx, Filter{}); err == nil {
+		t.Error("List() expected error for nil client")
+	}
+}
@@ -0,0 +1,64 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+)
+
+// MemoryRepository implements Repository in process memory.
+// Used under --test-mode and NAMAZU_STORE_TYPE=memory; nothing survives a restart.
+type MemoryRepository struct {
+	mu      sync.Mutex
+	entries []Entry // Oldest first
+}
+
+// Compile-time interface check
+var _ Repository = (*MemoryRepository)(nil)
+
+// NewMemoryRepository creates an empty MemoryRepository
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{}
+}
+
+// Append stores a new entry and returns its ID
+func (r *MemoryRepository) Append(ctx context.Context, e Entry) (string, error) {
+	if e.Action == "" {
+		return "", fmt.Errorf("action is required")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	e.ID = strconv.Itoa(len(r.entries) + 1)
+	e.Details = copyDetails(e.Details)
+	r.entries = append(r.entries, e)
+	return e.ID, nil
+}
+
+// List returns the entries matching the filter, newest first
+func (r *MemoryRepository) List(ctx context.Context, f Filter) ([]Entry, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	entries := make([]Entry, 0)
+	for i := len(r.entries) - 1; i >= 0 && len(entries) < f.limit(); i-- {
+		if f.matches(r.entries[i]) {
+			e := r.entries[i]
+			e.Details = copyDetails(e.Details)
+			entries = append(entries, e)
+		}
+	}
+	return entries, nil
+}
+
+// copyDetails returns a copy of an entry's details
+func copyDetails(details map[string]string) map[string]string {
+	if details == nil {
+		return nil
+	}
+	c := make(map[string]string, len(details))
+	for k, v := range details {
+		c[k] = v
+	}
+	return c
+}
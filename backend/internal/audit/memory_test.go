@@ -0,0 +1,65 @@
+package audit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryRepository(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryRepository()
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	if _, err := repo.Append(ctx, Entry{}); err == nil {
+		t.Error("Append() without an action should fail")
+	}
+
+	details := map[string]string{"name": "first"}
+	for i, e := range []Entry{
+		{Action: ActionSubscriptionCreate, ActorUID: "uid-1", TargetID: "sub-1", Details: details},
+		{Action: ActionSubscriptionUpdate, ActorUID: "uid-1", TargetID: "sub-1"},
+		{Action: ActionSubscriptionCreate, ActorUID: "uid-2", TargetID: "sub-2"},
+		{Action: ActionAdminSetRole, ActorUID: "admin", TargetID: "uid-1"},
+	} {
+		e.CreatedAt = base.Add(time.Duration(i) * time.Hour)
+		if _, err := repo.Append(ctx, e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	details["name"] = "changed"
+
+	tests := []struct {
+		name   string
+		filter Filter
+		want   []string // Actions, newest first
+	}{
+		{"all", Filter{}, []string{ActionAdminSetRole, ActionSubscriptionCreate, ActionSubscriptionUpdate, ActionSubscriptionCreate}},
+		{"actor", Filter{ActorUID: "uid-1"}, []string{ActionSubscriptionUpdate, ActionSubscriptionCreate}},
+		{"action", Filter{Action: ActionSubscriptionCreate}, []string{ActionSubscriptionCreate, ActionSubscriptionCreate}},
+		{"target", Filter{TargetID: "uid-1"}, []string{ActionAdminSetRole}},
+		{"range", Filter{From: base.Add(time.Hour), To: base.Add(3 * time.Hour)}, []string{ActionSubscriptionCreate, ActionSubscriptionUpdate}},
+		{"limit", Filter{Limit: 1}, []string{ActionAdminSetRole}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, err := repo.List(ctx, tt.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(entries) != len(tt.want) {
+				t.Fatalf("List() returned %d entries, want %d", len(entries), len(tt.want))
+			}
+			for i, e := range entries {
+				if e.Action != tt.want[i] || e.ID == "" {
+					t.Errorf("entries[%d] = %+v, want action %s", i, e, tt.want[i])
+				}
+			}
+		})
+	}
+
+	entries, _ := repo.List(ctx, Filter{TargetID: "sub-1", Action: ActionSubscriptionCreate})
+	if len(entries) != 1 || entries[0].Details["name"] != "first" {
+		t.Errorf("details = %v, want the recorded copy", entries[0].Details)
+	}
+}
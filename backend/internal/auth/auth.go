@@ -13,9 +13,44 @@ type Claims struct {
 	Name          string `json:"name,omitempty"`
 	Picture       string `json:"picture,omitempty"`
 	ProviderID    string `json:"provider_id,omitempty"`
+	ProviderUID   string `json:"provider_uid,omitempty"` // User ID at the sign-in provider, from the "identities" claim
+	Role          string `json:"role,omitempty"`         // From the "role" custom claim
+	Admin         bool   `json:"admin,omitempty"`        // Role is admin, or the legacy "admin" custom claim
 }
 
+// Roles carried in the "role" custom claim
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
 // TokenVerifier verifies Firebase ID tokens
 type TokenVerifier interface {
 	VerifyIDToken(ctx context.Context, idToken string) (*Claims, error)
 }
+
+// RoleSetter publishes a user's role as a custom claim.
+// The role is carried by ID tokens issued after the change.
+type RoleSetter interface {
+	SetRole(ctx context.Context, uid, role string) error
+}
+
+// TokenRevoker signs a user out of every device by revoking their refresh tokens.
+// ID tokens already issued stay valid until they expire (up to an hour).
+type TokenRevoker interface {
+	RevokeTokens(ctx context.Context, uid string) error
+}
+
+// ProviderLinker links federated sign-in providers to users and unlinks them
+type ProviderLinker interface {
+	LinkProvider(ctx context.Context, uid string, p ProviderIdentity) error
+	UnlinkProvider(ctx context.Context, uid, providerID string) error
+}
+
+// ProviderIdentity is a user's identity at a federated sign-in provider
+type ProviderIdentity struct {
+	ProviderID  string // "google.com", "apple.com"
+	UID         string // User ID at the provider
+	Email       string
+	DisplayName string
+}
@@ -15,12 +15,31 @@ type idTokenVerifier interface {
 	VerifyIDToken(ctx context.Context, idToken string) (*firebaseAuth.Token, error)
 }
 
-// FirebaseTokenVerifier implements TokenVerifier using Firebase Admin SDK
+// customClaimsClient reads and writes custom claims, revokes tokens and updates users
+// Both firebaseAuth.Client and firebaseAuth.TenantClient implement this
+type customClaimsClient interface {
+	GetUser(ctx context.Context, uid string) (*firebaseAuth.UserRecord, error)
+	SetCustomUserClaims(ctx context.Context, uid string, customClaims map[string]interface{}) error
+	RevokeRefreshTokens(ctx context.Context, uid string) error
+	UpdateUser(ctx context.Context, uid string, user *firebaseAuth.UserToUpdate) (*firebaseAuth.UserRecord, error)
+}
+
+// FirebaseTokenVerifier implements TokenVerifier, RoleSetter, TokenRevoker and
+// ProviderLinker using Firebase Admin SDK
 type FirebaseTokenVerifier struct {
 	verifier idTokenVerifier
+	claims   customClaimsClient
 	tenantID string
 }
 
+// Compile-time interface checks
+var (
+	_ TokenVerifier  = (*FirebaseTokenVerifier)(nil)
+	_ RoleSetter     = (*FirebaseTokenVerifier)(nil)
+	_ TokenRevoker   = (*FirebaseTokenVerifier)(nil)
+	_ ProviderLinker = (*FirebaseTokenVerifier)(nil)
+)
+
 // FirebaseTokenVerifierConfig holds configuration for FirebaseTokenVerifier
 type FirebaseTokenVerifierConfig struct {
 	ProjectID       string
@@ -55,7 +74,7 @@ func NewFirebaseTokenVerifierWithConfig(ctx context.Context, cfg FirebaseTokenVe
 		return nil, fmt.Errorf("failed to get auth client: %w", err)
 	}
 
-	var verifier idTokenVerifier
+	v := &FirebaseTokenVerifier{tenantID: cfg.TenantID}
 
 	if cfg.TenantID != "" {
 		// Multi-tenant mode: use tenant-specific auth client
@@ -63,16 +82,15 @@ func NewFirebaseTokenVerifierWithConfig(ctx context.Context, cfg FirebaseTokenVe
 		if err != nil {
 			return nil, fmt.Errorf("failed to get tenant auth client for %s: %w", cfg.TenantID, err)
 		}
-		verifier = tenantClient
+		v.verifier = tenantClient
+		v.claims = tenantClient
 	} else {
 		// Single-tenant mode
-		verifier = authClient
+		v.verifier = authClient
+		v.claims = authClient
 	}
 
-	return &FirebaseTokenVerifier{
-		verifier: verifier,
-		tenantID: cfg.TenantID,
-	}, nil
+	return v, nil
 }
 
 // VerifyIDToken verifies a Firebase ID token and returns the decoded claims
@@ -88,16 +106,86 @@ func (v *FirebaseTokenVerifier) VerifyIDToken(ctx context.Context, idToken strin
 		EmailVerified: getBoolClaim(token.Claims, "email_verified"),
 		Name:          getStringClaim(token.Claims, "name"),
 		Picture:       getStringClaim(token.Claims, "picture"),
+		Role:          getStringClaim(token.Claims, "role"),
 	}
+	claims.Admin = claims.Role == RoleAdmin || getBoolClaim(token.Claims, "admin")
 
 	// Set provider ID from Firebase token
 	if token.Firebase.SignInProvider != "" {
 		claims.ProviderID = token.Firebase.SignInProvider
+		claims.ProviderUID = identity(token.Firebase.Identities, token.Firebase.SignInProvider)
 	}
 
 	return claims, nil
 }
 
+// SetRole stores the role in the user's "role" custom claim.
+// Other custom claims are kept, except the legacy "admin" claim which the role
+// replaces (otherwise a demoted admin would keep admin access).
+// Existing ID tokens keep the old role until they are refreshed (up to an hour).
+func (v *FirebaseTokenVerifier) SetRole(ctx context.Context, uid, role string) error {
+	record, err := v.claims.GetUser(ctx, uid)
+	if err != nil {
+		return fmt.Errorf("failed to get user %s: %w", uid, err)
+	}
+
+	customClaims := make(map[string]interface{}, len(record.CustomClaims)+1)
+	for k, val := range record.CustomClaims {
+		customClaims[k] = val
+	}
+	delete(customClaims, "admin")
+	customClaims["role"] = role
+
+	if err := v.claims.SetCustomUserClaims(ctx, uid, customClaims); err != nil {
+		return fmt.Errorf("failed to set custom claims for %s: %w", uid, err)
+	}
+	return nil
+}
+
+// RevokeTokens revokes the user's refresh tokens, so no new ID tokens are issued
+// until they sign in again
+func (v *FirebaseTokenVerifier) RevokeTokens(ctx context.Context, uid string) error {
+	if err := v.claims.RevokeRefreshTokens(ctx, uid); err != nil {
+		return fmt.Errorf("failed to revoke tokens for %s: %w", uid, err)
+	}
+	return nil
+}
+
+// LinkProvider links a federated provider identity to the user, so that they
+// can sign in with it. It fails if the identity is linked to another user.
+func (v *FirebaseTokenVerifier) LinkProvider(ctx context.Context, uid string, p ProviderIdentity) error {
+	update := (&firebaseAuth.UserToUpdate{}).ProviderToLink(&firebaseAuth.UserProvider{
+		UID:         p.UID,
+		ProviderID:  p.ProviderID,
+		Email:       p.Email,
+		DisplayName: p.DisplayName,
+	})
+	if _, err := v.claims.UpdateUser(ctx, uid, update); err != nil {
+		return fmt.Errorf("failed to link %s to %s: %w", p.ProviderID, uid, err)
+	}
+	return nil
+}
+
+// UnlinkProvider unlinks a sign-in provider from the user
+func (v *FirebaseTokenVerifier) UnlinkProvider(ctx context.Context, uid, providerID string) error {
+	update := (&firebaseAuth.UserToUpdate{}).ProvidersToDelete([]string{providerID})
+	if _, err := v.claims.UpdateUser(ctx, uid, update); err != nil {
+		return fmt.Errorf("failed to unlink %s from %s: %w", providerID, uid, err)
+	}
+	return nil
+}
+
+// identity returns the user's first ID at the provider from the "identities"
+// claim, which maps provider IDs to lists of IDs
+func identity(identities map[string]interface{}, providerID string) string {
+	ids, ok := identities[providerID].([]interface{})
+	if !ok || len(ids) == 0 {
+		return ""
+	}
+	id, _ := ids[0].(string)
+	return id
+}
+
 // getStringClaim safely extracts a string claim from the claims map
 func getStringClaim(claims map[string]any, key string) string {
 	val, ok := claims[key]
@@ -1,7 +1,11 @@
 package auth
 
 import (
+	"context"
+	"errors"
 	"testing"
+
+	firebaseAuth "firebase.google.com/go/v4/auth"
 )
 
 func TestGetStringClaim(t *testing.T) {
@@ -107,3 +111,156 @@ func TestGetBoolClaim(t *testing.T) {
 		})
 	}
 }
+
+// fakeFirebase implements idTokenVerifier and customClaimsClient for testing
+type fakeFirebase struct {
+	token   *firebaseAuth.Token
+	custom  map[string]map[string]interface{}
+	revoked []string
+	updated []string
+	err     error
+}
+
+func (f *fakeFirebase) VerifyIDToken(ctx context.Context, idToken string) (*firebaseAuth.Token, error) {
+	return f.token, f.err
+}
+
+func (f *fakeFirebase) GetUser(ctx context.Context, uid string) (*firebaseAuth.UserRecord, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &firebaseAuth.UserRecord{UserInfo: &firebaseAuth.UserInfo{UID: uid}, CustomClaims: f.custom[uid]}, nil
+}
+
+func (f *fakeFirebase) SetCustomUserClaims(ctx context.Context, uid string, customClaims map[string]interface{}) error {
+	f.custom[uid] = customClaims
+	return nil
+}
+
+func (f *fakeFirebase) RevokeRefreshTokens(ctx context.Context, uid string) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.revoked = append(f.revoked, uid)
+	return nil
+}
+
+func (f *fakeFirebase) UpdateUser(ctx context.Context, uid string, user *firebaseAuth.UserToUpdate) (*firebaseAuth.UserRecord, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	f.updated = append(f.updated, uid)
+	return &firebaseAuth.UserRecord{UserInfo: &firebaseAuth.UserInfo{UID: uid}}, nil
+}
+
+func TestFirebaseTokenVerifier_VerifyIDToken_Provider(t *testing.T) {
+	token := &firebaseAuth.Token{UID: "uid-1"}
+	token.Firebase.SignInProvider = "google.com"
+	token.Firebase.Identities = map[string]interface{}{
+		"google.com": []interface{}{"1234567890"},
+		"email":      []interface{}{"user@example.com"},
+	}
+	v := &FirebaseTokenVerifier{verifier: &fakeFirebase{token: token}}
+
+	claims, err := v.VerifyIDToken(context.Background(), "token")
+	if err != nil {
+		t.Fatalf("VerifyIDToken() error = %v", err)
+	}
+	if claims.ProviderID != "google.com" || claims.ProviderUID != "1234567890" {
+		t.Errorf("ProviderID/ProviderUID = %q/%q, want google.com/1234567890", claims.ProviderID, claims.ProviderUID)
+	}
+}
+
+func TestFirebaseTokenVerifier_VerifyIDToken_Role(t *testing.T) {
+	tests := []struct {
+		name      string
+		claims    map[string]interface{}
+		wantRole  string
+		wantAdmin bool
+	}{
+		{"no custom claims", map[string]interface{}{}, "", false},
+		{"user role", map[string]interface{}{"role": "user"}, RoleUser, false},
+		{"admin role", map[string]interface{}{"role": "admin"}, RoleAdmin, true},
+		{"legacy admin claim", map[string]interface{}{"admin": true}, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := &FirebaseTokenVerifier{verifier: &fakeFirebase{token: &firebaseAuth.Token{UID: "uid-1", Claims: tt.claims}}}
+			claims, err := v.VerifyIDToken(context.Background(), "token")
+			if err != nil {
+				t.Fatalf("VerifyIDToken() error = %v", err)
+			}
+			if claims.Role != tt.wantRole || claims.Admin != tt.wantAdmin {
+				t.Errorf("Role/Admin = %q/%v, want %q/%v", claims.Role, claims.Admin, tt.wantRole, tt.wantAdmin)
+			}
+		})
+	}
+}
+
+func TestFirebaseTokenVerifier_SetRole(t *testing.T) {
+	fake := &fakeFirebase{custom: map[string]map[string]interface{}{
+		"uid-1": {"admin": true, "tier": "gold"},
+	}}
+	v := &FirebaseTokenVerifier{claims: fake}
+
+	if err := v.SetRole(context.Background(), "uid-1", RoleUser); err != nil {
+		t.Fatalf("SetRole() error = %v", err)
+	}
+
+	got := fake.custom["uid-1"]
+	if got["role"] != RoleUser {
+		t.Errorf("role = %v, want user", got["role"])
+	}
+	if _, ok := got["admin"]; ok {
+		t.Error("expected the legacy admin claim to be removed")
+	}
+	if got["tier"] != "gold" {
+		t.Error("expected other custom claims to be kept")
+	}
+
+	fake.err = errors.New("not found")
+	if err := v.SetRole(context.Background(), "uid-2", RoleAdmin); err == nil {
+		t.Error("expected an error when the user cannot be read")
+	}
+}
+
+func TestFirebaseTokenVerifier_RevokeTokens(t *testing.T) {
+	fake := &fakeFirebase{}
+	v := &FirebaseTokenVerifier{claims: fake}
+
+	if err := v.RevokeTokens(context.Background(), "uid-1"); err != nil {
+		t.Fatalf("RevokeTokens() error = %v", err)
+	}
+	if len(fake.revoked) != 1 || fake.revoked[0] != "uid-1" {
+		t.Errorf("revoked = %v, want [uid-1]", fake.revoked)
+	}
+
+	fake.err = errors.New("not found")
+	if err := v.RevokeTokens(context.Background(), "uid-2"); err == nil {
+		t.Error("expected an error when revocation fails")
+	}
+}
+
+func TestFirebaseTokenVerifier_LinkProvider(t *testing.T) {
+	fake := &fakeFirebase{}
+	v := &FirebaseTokenVerifier{claims: fake}
+
+	if err := v.LinkProvider(context.Background(), "uid-1", ProviderIdentity{ProviderID: "apple.com", UID: "a-1"}); err != nil {
+		t.Fatalf("LinkProvider() error = %v", err)
+	}
+	if err := v.UnlinkProvider(context.Background(), "uid-2", "google.com"); err != nil {
+		t.Fatalf("UnlinkProvider() error = %v", err)
+	}
+	if len(fake.updated) != 2 || fake.updated[0] != "uid-1" || fake.updated[1] != "uid-2" {
+		t.Errorf("updated = %v, want [uid-1 uid-2]", fake.updated)
+	}
+
+	fake.err = errors.New("already linked")
+	if err := v.LinkProvider(context.Background(), "uid-1", ProviderIdentity{ProviderID: "apple.com", UID: "a-1"}); err == nil {
+		t.Error("expected an error when linking fails")
+	}
+	if err := v.UnlinkProvider(context.Background(), "uid-1", "apple.com"); err == nil {
+		t.Error("expected an error when unlinking fails")
+	}
+}
@@ -1,9 +1,10 @@
 package auth
 
 import (
-	"encoding/json"
 	"net/http"
 	"strings"
+
+	"github.com/otiai10/namazu/backend/internal/apierr"
 )
 
 // AuthMiddleware returns middleware that validates Firebase tokens.
@@ -15,25 +16,25 @@ func AuthMiddleware(verifier TokenVerifier) func(http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			authHeader := r.Header.Get("Authorization")
 			if authHeader == "" {
-				writeJSONError(w, http.StatusUnauthorized, "Authorization header required")
+				writeJSONError(w, http.StatusUnauthorized, apierr.Unauthenticated, "Authorization header required")
 				return
 			}
 
 			// Check for "Bearer " prefix (case-sensitive)
 			if !strings.HasPrefix(authHeader, "Bearer ") {
-				writeJSONError(w, http.StatusUnauthorized, "Invalid authorization header format")
+				writeJSONError(w, http.StatusUnauthorized, apierr.Unauthenticated, "Invalid authorization header format")
 				return
 			}
 
 			token := strings.TrimPrefix(authHeader, "Bearer ")
 			if token == "" {
-				writeJSONError(w, http.StatusUnauthorized, "Invalid authorization header format")
+				writeJSONError(w, http.StatusUnauthorized, apierr.Unauthenticated, "Invalid authorization header format")
 				return
 			}
 
 			claims, err := verifier.VerifyIDToken(r.Context(), token)
 			if err != nil {
-				writeJSONError(w, http.StatusUnauthorized, "Invalid token")
+				writeJSONError(w, http.StatusUnauthorized, apierr.InvalidToken, "Invalid token")
 				return
 			}
 
@@ -44,9 +45,25 @@ func AuthMiddleware(verifier TokenVerifier) func(http.Handler) http.Handler {
 	}
 }
 
-// writeJSONError writes a JSON error response with the given status code and message
-func writeJSONError(w http.ResponseWriter, statusCode int, message string) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(statusCode)
-	_ = json.NewEncoder(w).Encode(map[string]any{"error": message})
+// RequireAdmin returns middleware that only allows requests whose claims carry
+// the admin flag. It must be applied after AuthMiddleware.
+// Returns 401 if claims are missing and 403 if the caller is not an admin.
+func RequireAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, ok := GetClaims(r.Context())
+		if !ok {
+			writeJSONError(w, http.StatusUnauthorized, apierr.Unauthenticated, "Authentication required")
+			return
+		}
+		if !claims.Admin {
+			writeJSONError(w, http.StatusForbidden, apierr.AdminRequired, "Admin privileges required")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// writeJSONError writes an error response with the given status code, code and message
+func writeJSONError(w http.ResponseWriter, statusCode int, code, message string) {
+	apierr.Write(w, statusCode, code, message, nil)
 }
@@ -7,6 +7,8 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+
+	"github.com/otiai10/namazu/backend/internal/apierr"
 )
 
 // mockTokenVerifier implements TokenVerifier for testing
@@ -39,13 +41,13 @@ func TestAuthMiddleware_MissingAuthorizationHeader(t *testing.T) {
 		t.Errorf("expected status %d, got %d", http.StatusUnauthorized, rec.Code)
 	}
 
-	var response map[string]string
+	var response apierr.Response
 	if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
 		t.Fatalf("failed to unmarshal response: %v", err)
 	}
 
-	if response["error"] != "Authorization header required" {
-		t.Errorf("expected error 'Authorization header required', got '%s'", response["error"])
+	if response.Error.Message != "Authorization header required" {
+		t.Errorf("expected error 'Authorization header required', got '%s'", response.Error.Message)
 	}
 }
 
@@ -91,13 +93,13 @@ func TestAuthMiddleware_InvalidAuthorizationFormat(t *testing.T) {
 				t.Errorf("expected status %d, got %d", http.StatusUnauthorized, rec.Code)
 			}
 
-			var response map[string]string
+			var response apierr.Response
 			if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
 				t.Fatalf("failed to unmarshal response: %v", err)
 			}
 
-			if response["error"] != "Invalid authorization header format" {
-				t.Errorf("expected error 'Invalid authorization header format', got '%s'", response["error"])
+			if response.Error.Message != "Invalid authorization header format" {
+				t.Errorf("expected error 'Invalid authorization header format', got '%s'", response.Error.Message)
 			}
 		})
 	}
@@ -123,13 +125,16 @@ func TestAuthMiddleware_TokenVerificationFailed(t *testing.T) {
 		t.Errorf("expected status %d, got %d", http.StatusUnauthorized, rec.Code)
 	}
 
-	var response map[string]string
+	var response apierr.Response
 	if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
 		t.Fatalf("failed to unmarshal response: %v", err)
 	}
 
-	if response["error"] != "Invalid token" {
-		t.Errorf("expected error 'Invalid token', got '%s'", response["error"])
+	if response.Error.Code != apierr.InvalidToken {
+		t.Errorf("expected code %s, got %s", apierr.InvalidToken, response.Error.Code)
+	}
+	if response.Error.Message != "Invalid token" {
+		t.Errorf("expected error 'Invalid token', got '%s'", response.Error.Message)
 	}
 }
 
@@ -326,3 +331,35 @@ func TestAuthMiddleware_ContentTypeJSON(t *testing.T) {
 		t.Errorf("expected Content-Type 'application/json', got '%s'", contentType)
 	}
 }
+
+func TestRequireAdmin(t *testing.T) {
+	tests := []struct {
+		name       string
+		claims     *Claims
+		wantStatus int
+	}{
+		{name: "no claims", claims: nil, wantStatus: http.StatusUnauthorized},
+		{name: "non-admin", claims: &Claims{UID: "user-1"}, wantStatus: http.StatusForbidden},
+		{name: "admin", claims: &Claims{UID: "admin-1", Admin: true}, wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := RequireAdmin(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/api/admin/test", nil)
+			if tt.claims != nil {
+				req = req.WithContext(WithClaims(req.Context(), tt.claims))
+			}
+			rec := httptest.NewRecorder()
+
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("expected status %d, got %d", tt.wantStatus, rec.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package badge
+
+import (
+	"fmt"
+	"html"
+)
+
+// DefaultLabel is the left-hand text of a badge
+const DefaultLabel = "earthquake alerts"
+
+// Badge colors, named as in shields.io
+const (
+	ColorGreen  = "brightgreen"
+	ColorYellow = "yellow"
+	ColorRed    = "red"
+	ColorGrey   = "lightgrey"
+)
+
+// colorHex maps badge color names to SVG fill colors
+var colorHex = map[string]string{
+	ColorGreen:  "#4c1",
+	ColorYellow: "#dfb317",
+	ColorRed:    "#e05d44",
+	ColorGrey:   "#9f9f9f",
+}
+
+// Endpoint is the shields.io endpoint badge schema
+// (https://shields.io/badges/endpoint-badge)
+type Endpoint struct {
+	SchemaVersion int    `json:"schemaVersion"`
+	Label         string `json:"label"`
+	Message       string `json:"message"`
+	Color         string `json:"color"`
+}
+
+// NewEndpoint creates a shields.io endpoint payload
+func NewEndpoint(label, message, color string) Endpoint {
+	return Endpoint{SchemaVersion: 1, Label: label, Message: message, Color: color}
+}
+
+// SVG renders a flat two-part badge. Text widths are approximated,
+// which is sufficient for the short ASCII labels used here.
+func SVG(label, message, color string) []byte {
+	fill, ok := colorHex[color]
+	if !ok {
+		fill = colorHex[ColorGrey]
+	}
+
+	labelWidth := textWidth(label)
+	messageWidth := textWidth(message)
+	total := labelWidth + messageWidth
+
+	return []byte(fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="20" role="img" aria-label="%s: %s">`+
+		`<title>%s: %s</title>`+
+		`<rect width="%d" height="20" fill="#555"/>`+
+		`<rect x="%d" width="%d" height="20" fill="%s"/>`+
+		`<g fill="#fff" text-anchor="middle" font-family="Verdana,Geneva,DejaVu Sans,sans-serif" font-size="11">`+
+		`<text x="%d" y="14">%s</text>`+
+		`<text x="%d" y="14">%s</text>`+
+		`</g></svg>`,
+		total, html.EscapeString(label), html.EscapeString(message),
+		html.EscapeString(label), html.EscapeString(message),
+		labelWidth,
+		labelWidth, messageWidth, fill,
+		labelWidth/2, html.EscapeString(label),
+		labelWidth+messageWidth/2, html.EscapeString(message),
+	))
+}
+
+// textWidth approximates the rendered width of s in pixels, including padding
+func textWidth(s string) int {
+	return len(s)*7 + 10
+}
@@ -0,0 +1,41 @@
+package badge
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSVG(t *testing.T) {
+	svg := string(SVG(DefaultLabel, "operational", ColorGreen))
+
+	if !strings.HasPrefix(svg, "<svg") || !strings.HasSuffix(svg, "</svg>") {
+		t.Error("expected a complete svg document")
+	}
+	if !strings.Contains(svg, "operational") || !strings.Contains(svg, DefaultLabel) {
+		t.Error("expected label and message in svg")
+	}
+	if !strings.Contains(svg, colorHex[ColorGreen]) {
+		t.Error("expected message color in svg")
+	}
+}
+
+func TestSVG_EscapesText(t *testing.T) {
+	svg := string(SVG("<script>", "a&b", "unknown-color"))
+
+	if strings.Contains(svg, "<script>") {
+		t.Error("expected label to be escaped")
+	}
+	if !strings.Contains(svg, "a&amp;b") {
+		t.Error("expected message to be escaped")
+	}
+	if !strings.Contains(svg, colorHex[ColorGrey]) {
+		t.Error("expected unknown colors to fall back to grey")
+	}
+}
+
+func TestNewEndpoint(t *testing.T) {
+	e := NewEndpoint(DefaultLabel, "degraded", ColorYellow)
+	if e.SchemaVersion != 1 || e.Message != "degraded" || e.Color != ColorYellow {
+		t.Errorf("unexpected endpoint: %+v", e)
+	}
+}
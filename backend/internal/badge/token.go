@@ -0,0 +1,65 @@
+// Package badge issues signed tokens for public subscription health badges
+// and renders the badges as SVG or shields.io endpoint JSON.
+package badge
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strings"
+)
+
+// ErrInvalidToken is returned when a badge token is malformed or its signature does not match
+var ErrInvalidToken = errors.New("invalid badge token")
+
+// signatureSize is the number of HMAC bytes kept in a token
+const signatureSize = 16
+
+// Signer issues and verifies stateless badge tokens.
+// A token embeds the subscription ID and an HMAC over it, so no storage is
+// needed; rotating the secret revokes every token at once.
+type Signer struct {
+	secret []byte
+}
+
+// NewSigner creates a Signer with the given secret
+func NewSigner(secret string) *Signer {
+	return &Signer{secret: []byte(secret)}
+}
+
+// Token returns the badge token for a subscription
+func (s *Signer) Token(subscriptionID string) string {
+	enc := base64.RawURLEncoding
+	return enc.EncodeToString([]byte(subscriptionID)) + "." + enc.EncodeToString(s.sign(subscriptionID))
+}
+
+// Verify checks a token and returns the subscription ID it was issued for
+func (s *Signer) Verify(token string) (string, error) {
+	encodedID, encodedSig, ok := strings.Cut(token, ".")
+	if !ok {
+		return "", ErrInvalidToken
+	}
+
+	enc := base64.RawURLEncoding
+	id, err := enc.DecodeString(encodedID)
+	if err != nil || len(id) == 0 {
+		return "", ErrInvalidToken
+	}
+	sig, err := enc.DecodeString(encodedSig)
+	if err != nil {
+		return "", ErrInvalidToken
+	}
+
+	if !hmac.Equal(sig, s.sign(string(id))) {
+		return "", ErrInvalidToken
+	}
+	return string(id), nil
+}
+
+// sign computes the truncated HMAC for a subscription ID
+func (s *Signer) sign(subscriptionID string) []byte {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte("badge:" + subscriptionID))
+	return mac.Sum(nil)[:signatureSize]
+}
@@ -0,0 +1,59 @@
+package badge
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestSigner_RoundTrip(t *testing.T) {
+	signer := NewSigner("secret")
+
+	token := signer.Token("sub-123")
+	if strings.Contains(token, "sub-123") {
+		t.Error("token should not contain the raw subscription ID")
+	}
+
+	id, err := signer.Verify(token)
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if id != "sub-123" {
+		t.Errorf("Verify() = %q, want %q", id, "sub-123")
+	}
+}
+
+func TestSigner_Deterministic(t *testing.T) {
+	signer := NewSigner("secret")
+	if signer.Token("sub-1") != signer.Token("sub-1") {
+		t.Error("expected the same token for the same subscription")
+	}
+}
+
+func TestSigner_RejectsInvalid(t *testing.T) {
+	signer := NewSigner("secret")
+	valid := signer.Token("sub-1")
+	forged := NewSigner("other-secret").Token("sub-1")
+	encodedID, _, _ := strings.Cut(signer.Token("sub-2"), ".")
+	_, sig, _ := strings.Cut(valid, ".")
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{name: "empty", token: ""},
+		{name: "no separator", token: "abc"},
+		{name: "bad base64", token: "!!!.!!!"},
+		{name: "wrong secret", token: forged},
+		{name: "swapped id", token: encodedID + "." + sig},
+		{name: "empty id", token: "." + sig},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := signer.Verify(tt.token); !errors.Is(err, ErrInvalidToken) {
+				t.Errorf("Verify() error = %v, want ErrInvalidToken", err)
+			}
+		})
+	}
+}
@@ -2,28 +2,47 @@ package billing
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"github.com/stripe/stripe-go/v78"
 	portalsession "github.com/stripe/stripe-go/v78/billingportal/session"
 	checkoutsession "github.com/stripe/stripe-go/v78/checkout/session"
+	"github.com/stripe/stripe-go/v78/promotioncode"
 	"github.com/stripe/stripe-go/v78/subscription"
 )
 
+// Billing intervals
+const (
+	IntervalMonth = "month"
+	IntervalYear  = "year"
+)
+
+// ErrPromotionCodeNotFound is returned for codes that do not exist or are no longer active
+var ErrPromotionCodeNotFound = errors.New("promotion code not found")
+
+// CheckoutRequest describes a Checkout session to create
+type CheckoutRequest struct {
+	CustomerID      string // Stripe customer ID
+	PriceID         string // Stripe price ID for the subscription
+	PlanID          string // Plan granted by the price, recorded in the session and subscription metadata
+	Interval        string // IntervalMonth | IntervalYear, recorded in the metadata
+	PromotionCodeID string // Promotion code to apply; empty lets the customer enter one at checkout
+	SuccessURL      string // URL to redirect to after successful checkout
+	CancelURL       string // URL to redirect to if checkout is canceled
+}
+
 // CreateCheckoutSession creates a Stripe Checkout session for subscription
 //
 // Parameters:
 //   - ctx: Context for cancellation control
-//   - customerID: Stripe customer ID
-//   - priceID: Stripe price ID for the subscription
-//   - successURL: URL to redirect to after successful checkout
-//   - cancelURL: URL to redirect to if checkout is canceled
+//   - req: Customer, price and redirect URLs of the session
 //
 // Returns:
 //   - Stripe Checkout session
 //   - Error if Stripe API call fails
-func (c *Client) CreateCheckoutSession(ctx context.Context, customerID, priceID, successURL, cancelURL string) (*stripe.CheckoutSession, error) {
-	params := buildCheckoutSessionParams(customerID, priceID, successURL, cancelURL)
+func (c *Client) CreateCheckoutSession(ctx context.Context, req CheckoutRequest) (*stripe.CheckoutSession, error) {
+	params := buildCheckoutSessionParams(req)
 	session, err := checkoutsession.New(params)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create checkout session: %w", err)
@@ -52,6 +71,32 @@ func (c *Client) CreatePortalSession(ctx context.Context, customerID, returnURL
 	return session, nil
 }
 
+// FindPromotionCode returns the ID of the active promotion code with the given
+// customer-facing code (case-insensitive)
+//
+// Parameters:
+//   - ctx: Context for cancellation control
+//   - code: Code entered by the customer, e.g. "LAUNCH2026"
+//
+// Returns:
+//   - Stripe promotion code ID
+//   - ErrPromotionCodeNotFound if no active code matches, or an error if Stripe API call fails
+func (c *Client) FindPromotionCode(ctx context.Context, code string) (string, error) {
+	params := &stripe.PromotionCodeListParams{
+		Code:   stripe.String(code),
+		Active: stripe.Bool(true),
+	}
+	params.Limit = stripe.Int64(1)
+	iter := promotioncode.List(params)
+	if iter.Next() {
+		return iter.PromotionCode().ID, nil
+	}
+	if err := iter.Err(); err != nil {
+		return "", fmt.Errorf("failed to find promotion code: %w", err)
+	}
+	return "", ErrPromotionCodeNotFound
+}
+
 // GetSubscription retrieves a subscription by ID
 //
 // Parameters:
@@ -70,20 +115,65 @@ func (c *Client) GetSubscription(ctx context.Context, subscriptionID string) (*s
 	return sub, nil
 }
 
+// CancelSubscription cancels a subscription immediately, without proration.
+// Subscriptions that no longer exist are treated as canceled.
+//
+// Parameters:
+//   - ctx: Context for cancellation control
+//   - subscriptionID: Stripe subscription ID
+//
+// Returns:
+//   - Error if Stripe API call fails
+func (c *Client) CancelSubscription(ctx context.Context, subscriptionID string) error {
+	if _, err := subscription.Cancel(subscriptionID, nil); err != nil {
+		var stripeErr *stripe.Error
+		if errors.As(err, &stripeErr) && stripeErr.Code == stripe.ErrorCodeResourceMissing {
+			return nil
+		}
+		return fmt.Errorf("failed to cancel subscription: %w", err)
+	}
+
+	return nil
+}
+
 // buildCheckoutSessionParams creates Stripe Checkout session parameters
-func buildCheckoutSessionParams(customerID, priceID, successURL, cancelURL string) *stripe.CheckoutSessionParams {
-	return &stripe.CheckoutSessionParams{
-		Customer:   stripe.String(customerID),
-		SuccessURL: stripe.String(successURL),
-		CancelURL:  stripe.String(cancelURL),
+func buildCheckoutSessionParams(req CheckoutRequest) *stripe.CheckoutSessionParams {
+	params := &stripe.CheckoutSessionParams{
+		Customer:   stripe.String(req.CustomerID),
+		SuccessURL: stripe.String(req.SuccessURL),
+		CancelURL:  stripe.String(req.CancelURL),
 		Mode:       stripe.String(string(stripe.CheckoutSessionModeSubscription)),
 		LineItems: []*stripe.CheckoutSessionLineItemParams{
 			{
-				Price:    stripe.String(priceID),
+				Price:    stripe.String(req.PriceID),
 				Quantity: stripe.Int64(1),
 			},
 		},
 	}
+
+	// Stripe accepts either a promotion code or the field to enter one, not both
+	if req.PromotionCodeID != "" {
+		params.Discounts = []*stripe.CheckoutSessionDiscountParams{
+			{PromotionCode: stripe.String(req.PromotionCodeID)},
+		}
+	} else {
+		params.AllowPromotionCodes = stripe.Bool(true)
+	}
+
+	metadata := map[string]string{}
+	if req.PlanID != "" {
+		metadata[MetadataPlan] = req.PlanID
+	}
+	if req.Interval != "" {
+		metadata[MetadataInterval] = req.Interval
+	}
+	if len(metadata) > 0 {
+		for k, v := range metadata {
+			params.AddMetadata(k, v)
+		}
+		params.SubscriptionData = &stripe.CheckoutSessionSubscriptionDataParams{Metadata: metadata}
+	}
+	return params
 }
 
 // buildPortalSessionParams creates Stripe Billing Portal session parameters
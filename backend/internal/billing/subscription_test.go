@@ -7,12 +7,14 @@ import (
 
 func TestBuildCheckoutSessionParams(t *testing.T) {
 	t.Run("builds params with all required fields", func(t *testing.T) {
-		params := buildCheckoutSessionParams(
-			"cus_123",
-			"price_456",
-			"https://example.com/success?session_id={CHECKOUT_SESSION_ID}",
-			"https://example.com/cancel",
-		)
+		params := buildCheckoutSessionParams(CheckoutRequest{
+			CustomerID: "cus_123",
+			PriceID:    "price_456",
+			PlanID:     "business",
+			Interval:   IntervalYear,
+			SuccessURL: "https://example.com/success?session_id={CHECKOUT_SESSION_ID}",
+			CancelURL:  "https://example.com/cancel",
+		})
 
 		if params.Customer == nil || *params.Customer != "cus_123" {
 			t.Errorf("Expected customer 'cus_123', got %v", params.Customer)
@@ -35,6 +37,29 @@ func TestBuildCheckoutSessionParams(t *testing.T) {
 		if params.LineItems[0].Quantity == nil || *params.LineItems[0].Quantity != 1 {
 			t.Errorf("Expected quantity 1, got %v", params.LineItems[0].Quantity)
 		}
+		if params.Metadata[MetadataPlan] != "business" {
+			t.Errorf("Expected plan metadata 'business', got %v", params.Metadata)
+		}
+		if params.SubscriptionData == nil || params.SubscriptionData.Metadata[MetadataPlan] != "business" {
+			t.Errorf("Expected subscription plan metadata 'business', got %+v", params.SubscriptionData)
+		}
+		if params.Metadata[MetadataInterval] != IntervalYear {
+			t.Errorf("Expected interval metadata 'year', got %v", params.Metadata)
+		}
+		if params.AllowPromotionCodes == nil || !*params.AllowPromotionCodes || params.Discounts != nil {
+			t.Errorf("Expected promotion codes to be allowed at checkout, got %v / %v", params.AllowPromotionCodes, params.Discounts)
+		}
+	})
+
+	t.Run("applies a promotion code", func(t *testing.T) {
+		params := buildCheckoutSessionParams(CheckoutRequest{CustomerID: "cus_123", PriceID: "price_456", PromotionCodeID: "promo_789"})
+
+		if len(params.Discounts) != 1 || *params.Discounts[0].PromotionCode != "promo_789" {
+			t.Errorf("Expected promotion code 'promo_789', got %v", params.Discounts)
+		}
+		if params.AllowPromotionCodes != nil {
+			t.Error("Expected AllowPromotionCodes to be unset with a promotion code")
+		}
 	})
 }
 
@@ -58,13 +83,13 @@ func TestCreateCheckoutSession_RequiresStripeConnection(t *testing.T) {
 	t.Run("returns error without valid Stripe connection", func(t *testing.T) {
 		client := NewClient("invalid_key")
 
-		_, err := client.CreateCheckoutSession(
-			context.Background(),
-			"cus_123",
-			"price_456",
-			"https://example.com/success",
-			"https://example.com/cancel",
-		)
+		_, err := client.CreateCheckoutSession(context.Background(), CheckoutRequest{
+			CustomerID: "cus_123",
+			PriceID:    "price_456",
+			PlanID:     "pro",
+			SuccessURL: "https://example.com/success",
+			CancelURL:  "https://example.com/cancel",
+		})
 		if err == nil {
 			t.Error("Expected error when calling Stripe with invalid key")
 		}
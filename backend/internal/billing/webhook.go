@@ -13,6 +13,13 @@ const (
 	EventCheckoutSessionCompleted = "checkout.session.completed"
 	EventSubscriptionUpdated      = "customer.subscription.updated"
 	EventSubscriptionDeleted      = "customer.subscription.deleted"
+	EventInvoicePaymentFailed     = "invoice.payment_failed"
+)
+
+// Metadata keys of the plan and billing interval a checkout was created for
+const (
+	MetadataPlan     = "plan"
+	MetadataInterval = "interval"
 )
 
 // SubscriptionInfo contains parsed subscription information from webhook events
@@ -22,6 +29,9 @@ type SubscriptionInfo struct {
 	Status              string
 	PeriodEnd           time.Time
 	CanceledAtPeriodEnd bool
+	PriceID             string // Price of the first subscription item
+	Interval            string // Billing interval of that price: IntervalMonth | IntervalYear
+	Plan                string // Plan in the subscription metadata, if created by checkout
 }
 
 // VerifyWebhookSignature verifies the Stripe webhook signature and returns the event
@@ -61,6 +71,36 @@ func ParseCheckoutSessionCompleted(session *stripe.CheckoutSession) (customerID,
 	return customerID, subscriptionID
 }
 
+// CheckoutPlan returns the plan a checkout session was created for, or "" for
+// sessions created without one
+func CheckoutPlan(session *stripe.CheckoutSession) string {
+	return session.Metadata[MetadataPlan]
+}
+
+// CheckoutInterval returns the billing interval a checkout session was created
+// for, or "" for sessions created without one
+func CheckoutInterval(session *stripe.CheckoutSession) string {
+	return session.Metadata[MetadataInterval]
+}
+
+// ParseInvoicePaymentFailed extracts customer and subscription IDs from invoice.payment_failed event
+//
+// Parameters:
+//   - invoice: Stripe Invoice object
+//
+// Returns:
+//   - customerID: Stripe customer ID
+//   - subscriptionID: Stripe subscription ID (empty for one-off invoices)
+func ParseInvoicePaymentFailed(invoice *stripe.Invoice) (customerID, subscriptionID string) {
+	if invoice.Customer != nil {
+		customerID = invoice.Customer.ID
+	}
+	if invoice.Subscription != nil {
+		subscriptionID = invoice.Subscription.ID
+	}
+	return customerID, subscriptionID
+}
+
 // ParseSubscriptionUpdate extracts subscription details from subscription update/delete events
 //
 // Parameters:
@@ -79,6 +119,14 @@ func ParseSubscriptionUpdate(sub *stripe.Subscription) SubscriptionInfo {
 	if sub.Customer != nil {
 		info.CustomerID = sub.Customer.ID
 	}
+	if sub.Items != nil && len(sub.Items.Data) > 0 && sub.Items.Data[0].Price != nil {
+		price := sub.Items.Data[0].Price
+		info.PriceID = price.ID
+		if price.Recurring != nil {
+			info.Interval = string(price.Recurring.Interval)
+		}
+	}
+	info.Plan = sub.Metadata[MetadataPlan]
 
 	return info
 }
@@ -61,6 +61,16 @@ func TestParseCheckoutSessionCompleted(t *testing.T) {
 		}
 	})
 
+	t.Run("extracts the plan from metadata", func(t *testing.T) {
+		session := &stripe.CheckoutSession{Metadata: map[string]string{MetadataPlan: "business"}}
+		if plan := CheckoutPlan(session); plan != "business" {
+			t.Errorf("Expected plan 'business', got %s", plan)
+		}
+		if plan := CheckoutPlan(&stripe.CheckoutSession{}); plan != "" {
+			t.Errorf("Expected no plan, got %s", plan)
+		}
+	})
+
 	t.Run("handles nil customer", func(t *testing.T) {
 		session := &stripe.CheckoutSession{
 			ID:           "cs_123",
@@ -160,6 +170,24 @@ func TestParseSubscriptionUpdate(t *testing.T) {
 		}
 	})
 
+	t.Run("extracts the price and plan", func(t *testing.T) {
+		sub := &stripe.Subscription{
+			ID:       "sub_123",
+			Customer: &stripe.Customer{ID: "cus_456"},
+			Status:   stripe.SubscriptionStatusActive,
+			Items: &stripe.SubscriptionItemList{Data: []*stripe.SubscriptionItem{
+				{Price: &stripe.Price{ID: "price_business", Recurring: &stripe.PriceRecurring{Interval: stripe.PriceRecurringIntervalYear}}},
+			}},
+			Metadata: map[string]string{MetadataPlan: "business"},
+		}
+
+		info := ParseSubscriptionUpdate(sub)
+
+		if info.PriceID != "price_business" || info.Plan != "business" || info.Interval != IntervalYear {
+			t.Errorf("Expected price 'price_business', plan 'business' and interval 'year', got %q, %q and %q", info.PriceID, info.Plan, info.Interval)
+		}
+	})
+
 	t.Run("handles nil customer", func(t *testing.T) {
 		sub := &stripe.Subscription{
 			ID:               "sub_123",
@@ -214,3 +242,20 @@ func TestWebhookEventTypes(t *testing.T) {
 		}
 	})
 }
+
+func TestParseInvoicePaymentFailed(t *testing.T) {
+	invoice := &stripe.Invoice{
+		ID:           "in_123",
+		Customer:     &stripe.Customer{ID: "cus_456"},
+		Subscription: &stripe.Subscription{ID: "sub_789"},
+	}
+
+	customerID, subscriptionID := ParseInvoicePaymentFailed(invoice)
+
+	if customerID != "cus_456" || subscriptionID != "sub_789" {
+		t.Errorf("got %q, %q; want cus_456, sub_789", customerID, subscriptionID)
+	}
+	if customerID, subscriptionID := ParseInvoicePaymentFailed(&stripe.Invoice{ID: "in_123"}); customerID != "" || subscriptionID != "" {
+		t.Errorf("got %q, %q for an invoice without customer and subscription", customerID, subscriptionID)
+	}
+}
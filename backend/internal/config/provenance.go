@@ -0,0 +1,241 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/otiai10/namazu/backend/internal/secrets"
+)
+
+// Origins of configuration values, in increasing order of precedence
+const (
+	SourceDefault = "default" // Not set anywhere, or filled in by the loader
+	SourceFile    = "file"    // Read from the YAML config file
+	SourceEnv     = "env"     // Overridden by an environment variable
+	SourceRuntime = "runtime" // Changed by the process after loading (e.g., command-line flags)
+)
+
+// maskedValue replaces the secret part of masked settings
+const maskedValue = "********"
+
+// Origin records where a configuration value came from
+type Origin struct {
+	Source string `json:"source"`
+	Detail string `json:"detail,omitempty"` // File path, environment variable or reason
+}
+
+// Setting is one entry of the effective configuration
+type Setting struct {
+	Key       string      `json:"key"`
+	Value     interface{} `json:"value"`
+	Source    string      `json:"source"`
+	Detail    string      `json:"detail,omitempty"`
+	FileValue interface{} `json:"file_value,omitempty"` // Value in the config file when overridden
+}
+
+// Origin returns where the value for a key (e.g., "source.endpoint") came from
+func (c *Config) Origin(key string) Origin {
+	if origin, ok := c.origins[key]; ok {
+		return origin
+	}
+	return Origin{Source: SourceDefault}
+}
+
+// SetRuntime records that a value was changed after loading.
+// Call it whenever the process modifies the loaded configuration.
+func (c *Config) SetRuntime(key, reason string) {
+	c.setOrigin(key, SourceRuntime, reason)
+}
+
+// setOrigin records the origin of a value
+func (c *Config) setOrigin(key, source, detail string) {
+	if c.origins == nil {
+		c.origins = make(map[string]Origin)
+	}
+	c.origins[key] = Origin{Source: source, Detail: detail}
+}
+
+// recordFile marks every key present in the YAML document as coming from the file
+// and remembers the file values so overrides can be shown side by side.
+func (c *Config) recordFile(path string, data []byte) error {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+
+	present := make(map[string]bool)
+	collectYAMLKeys(&doc, "", present)
+
+	values := flattenConfig(c)
+	c.fileValues = make(map[string]interface{})
+	for key := range present {
+		c.setOrigin(key, SourceFile, path)
+		if v, ok := values[key]; ok {
+			c.fileValues[key] = v
+		}
+	}
+	return nil
+}
+
+// Export returns the effective configuration with the origin of each value.
+// Secrets are masked; well-known key prefixes such as "sk_test_" are kept
+// so that sandbox and live credentials can be told apart. Values resolved
+// from secret references are shown as the reference.
+func (c *Config) Export() []Setting {
+	values := flattenConfig(c)
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	settings := make([]Setting, 0, len(keys))
+	for _, key := range keys {
+		origin := c.Origin(key)
+		setting := Setting{
+			Key:    key,
+			Value:  maskSetting(key, values[key]),
+			Source: origin.Source,
+			Detail: origin.Detail,
+		}
+		if ref, ok := c.secretRefs[key]; ok {
+			setting.Value = ref
+		}
+		if fileValue, ok := c.fileValues[key]; ok && origin.Source != SourceFile {
+			setting.FileValue = maskSetting(key, fileValue)
+		}
+		settings = append(settings, setting)
+	}
+	return settings
+}
+
+// collectYAMLKeys adds the dotted key of every leaf in a YAML node
+func collectYAMLKeys(node *yaml.Node, prefix string, out map[string]bool) {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, child := range node.Content {
+			collectYAMLKeys(child, prefix, out)
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			collectYAMLKeys(node.Content[i+1], joinKey(prefix, node.Content[i].Value), out)
+		}
+	case yaml.SequenceNode:
+		// Lists of scalars are a single value; lists of mappings are indexed
+		if len(node.Content) > 0 && node.Content[0].Kind == yaml.MappingNode {
+			for i, child := range node.Content {
+				collectYAMLKeys(child, fmt.Sprintf("%s[%d]", prefix, i), out)
+			}
+			return
+		}
+		out[prefix] = true
+	default:
+		out[prefix] = true
+	}
+}
+
+// flattenConfig returns every leaf value of the config keyed by its dotted YAML path.
+// Absent optional sections (nil pointers) are omitted.
+func flattenConfig(c *Config) map[string]interface{} {
+	out := make(map[string]interface{})
+	flattenValue(reflect.ValueOf(*c), "", out)
+	return out
+}
+
+// flattenValue walks v following yaml tags
+func flattenValue(v reflect.Value, prefix string, out map[string]interface{}) {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if !v.IsNil() {
+			flattenValue(v.Elem(), prefix, out)
+		}
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+			if !field.IsExported() || name == "" || name == "-" {
+				continue
+			}
+			flattenValue(v.Field(i), joinKey(prefix, name), out)
+		}
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Struct {
+			for i := 0; i < v.Len(); i++ {
+				flattenValue(v.Index(i), fmt.Sprintf("%s[%d]", prefix, i), out)
+			}
+			return
+		}
+		out[prefix] = v.Interface()
+	default:
+		out[prefix] = v.Interface()
+	}
+}
+
+// joinKey joins a dotted key prefix and a name
+func joinKey(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
+
+// isSecretKey reports whether the value for key must not be exposed
+func isSecretKey(key string) bool {
+	name := key
+	if idx := strings.LastIndex(key, "."); idx >= 0 {
+		name = key[idx+1:]
+	}
+	return name == "secret" || strings.HasSuffix(name, "_secret") ||
+		strings.HasSuffix(name, "secret_key") || strings.HasSuffix(name, "private_key") ||
+		strings.HasSuffix(name, "encryption_key") ||
+		strings.HasSuffix(name, "_token") || name == "password"
+}
+
+// maskSetting masks the value if the key holds a secret.
+// Secret references are not secret and are kept.
+func maskSetting(key string, value interface{}) interface{} {
+	s, ok := value.(string)
+	if !ok || secrets.IsRef(s) {
+		return value
+	}
+	if key == "store.dsn" || key == "egress.proxy" {
+		return maskDSN(s)
+	}
+	if !isSecretKey(key) {
+		return value
+	}
+	return maskSecret(s)
+}
+
+// maskDSN hides the password of a database connection string, keeping the
+// host and database visible. Key/value DSNs with a password are masked entirely.
+func maskDSN(s string) string {
+	if u, err := url.Parse(s); err == nil && u.User != nil {
+		return u.Redacted()
+	}
+	if strings.Contains(s, "password=") {
+		return maskedValue
+	}
+	return s
+}
+
+// maskSecret hides a secret, keeping a short "xx_" style prefix (e.g., "sk_test_", "whsec_")
+func maskSecret(s string) string {
+	if s == "" {
+		return ""
+	}
+	head := s
+	if len(head) > 8 {
+		head = head[:8]
+	}
+	if idx := strings.LastIndex(head, "_"); idx >= 0 {
+		return s[:idx+1] + maskedValue
+	}
+	return maskedValue
+}
@@ -0,0 +1,88 @@
+package config
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// reloadableKeys are the settings a running server applies when the config
+// is reloaded. Keys under "subscriptions" are reloadable as well.
+var reloadableKeys = map[string]bool{
+	"log_level":                                 true,
+	"security.cors_allowed_origins":             true,
+	"security.rate_limit_enabled":               true,
+	"security.rate_limit_requests_per_minute":   true,
+	"security.rate_limit_subscription_creation": true,
+}
+
+// Reloadable reports whether a change of the setting at the dotted key is
+// applied by a reload, as opposed to needing a restart
+func Reloadable(key string) bool {
+	return key == "subscriptions" || strings.HasPrefix(key, "subscriptions[") || reloadableKeys[key]
+}
+
+// Changes returns the settings that differ in next, keyed by dotted YAML
+// path, split into those a reload applies and those that need a restart.
+// Changes to any subscription are reported once, as "subscriptions".
+func (c *Config) Changes(next *Config) (reloadable, restart []string) {
+	before, after := flattenConfig(c), flattenConfig(next)
+	changed := make(map[string]bool)
+	for key, v := range before {
+		if w, ok := after[key]; !ok || !reflect.DeepEqual(v, w) {
+			changed[key] = true
+		}
+	}
+	for key := range after {
+		if _, ok := before[key]; !ok {
+			changed[key] = true
+		}
+	}
+
+	seen := make(map[string]bool)
+	for key := range changed {
+		if strings.HasPrefix(key, "subscriptions[") {
+			key = "subscriptions"
+		}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		if Reloadable(key) {
+			reloadable = append(reloadable, key)
+		} else {
+			restart = append(restart, key)
+		}
+	}
+	sort.Strings(reloadable)
+	sort.Strings(restart)
+	return reloadable, restart
+}
+
+// Watch calls onChange whenever the file at path changes, until ctx is done.
+// The file is polled every interval by its size and modification time, which
+// also catches a replaced symlink (a Kubernetes ConfigMap update). A file
+// that cannot be read is not a change; it is compared again on the next poll.
+func Watch(ctx context.Context, path string, interval time.Duration, onChange func()) {
+	last, _ := os.Stat(path)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		if last == nil || info.Size() != last.Size() || !info.ModTime().Equal(last.ModTime()) {
+			last = info
+			onChange()
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestConfig_Changes(t *testing.T) {
+	base := func() *Config {
+		return &Config{
+			Source: SourceConfig{Type: "p2pquake", Endpoint: "wss://example.com"},
+			Subscriptions: []SubscriptionConfig{
+				{Name: "a", Delivery: DeliveryConfig{Type: "webhook", URL: "https://a.example.com", Secret: "s"}},
+			},
+			API:      &APIConfig{Addr: ":8080"},
+			Security: &SecurityConfig{CORSAllowedOrigins: "https://app.example.com", BadgeSecret: "b"},
+		}
+	}
+
+	tests := []struct {
+		name           string
+		change         func(c *Config)
+		wantReloadable []string
+		wantRestart    []string
+	}{
+		{name: "no change", change: func(c *Config) {}},
+		{
+			name: "subscription filter",
+			change: func(c *Config) {
+				c.Subscriptions[0].Filter = &FilterConfig{MinScale: 40}
+			},
+			wantReloadable: []string{"subscriptions"},
+		},
+		{
+			name: "subscription added",
+			change: func(c *Config) {
+				c.Subscriptions = append(c.Subscriptions, SubscriptionConfig{Name: "b"})
+			},
+			wantReloadable: []string{"subscriptions"},
+		},
+		{
+			name: "security and log level",
+			change: func(c *Config) {
+				c.Security.CORSAllowedOrigins = "*"
+				c.Security.RateLimitEnabled = true
+				c.LogLevel = "warn"
+			},
+			wantReloadable: []string{"log_level", "security.cors_allowed_origins", "security.rate_limit_enabled"},
+		},
+		{
+			name: "needs a restart",
+			change: func(c *Config) {
+				c.Source.Endpoint = "wss://other.example.com"
+				c.Security.BadgeSecret = "rotated"
+				c.API.Addr = ":9090"
+			},
+			wantRestart: []string{"api.addr", "security.badge_secret", "source.endpoint"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := base()
+			tt.change(next)
+			reloadable, restart := base().Changes(next)
+			if !reflect.DeepEqual(reloadable, tt.wantReloadable) || !reflect.DeepEqual(restart, tt.wantRestart) {
+				t.Errorf("Changes() = %v, %v; want %v, %v", reloadable, restart, tt.wantReloadable, tt.wantRestart)
+			}
+		})
+	}
+}
+
+func TestWatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("log_level: info\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var changes int32
+	done := make(chan struct{})
+	go func() {
+		Watch(ctx, path, 5*time.Millisecond, func() { atomic.AddInt32(&changes, 1) })
+		close(done)
+	}()
+
+	time.Sleep(30 * time.Millisecond)
+	if got := atomic.LoadInt32(&changes); got != 0 {
+		t.Fatalf("%d changes reported for an untouched file", got)
+	}
+
+	if err := os.WriteFile(path, []byte("log_level: debug\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.After(2 * time.Second)
+	for atomic.LoadInt32(&changes) == 0 {
+		select {
+		case <-deadline:
+			t.Fatal("the change was not reported")
+		case <-time.After(5 * time.Millisecond):
+		}
+	}
+
+	cancel()
+	<-done
+}
@@ -0,0 +1,92 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/otiai10/namazu/backend/internal/secrets"
+)
+
+// LoadOption configures Load and LoadFromEnv
+type LoadOption func(*loadOptions)
+
+// loadOptions are the options of a load
+type loadOptions struct {
+	ctx     context.Context
+	secrets secrets.Provider
+}
+
+// WithSecretResolver resolves secret references ("sm://projects/x/secrets/y")
+// in string values, from the file or the environment, before validation.
+// Without it, a configuration containing references fails to load.
+func WithSecretResolver(ctx context.Context, p secrets.Provider) LoadOption {
+	return func(o *loadOptions) {
+		o.ctx = ctx
+		o.secrets = p
+	}
+}
+
+// resolveSecrets replaces secret references with their values and remembers
+// the references, so that Export shows them instead of the values
+func (c *Config) resolveSecrets(opts ...LoadOption) error {
+	o := loadOptions{ctx: context.Background()}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return resolveValue(reflect.ValueOf(c).Elem(), "", func(key, ref string) (string, error) {
+		if o.secrets == nil {
+			return "", fmt.Errorf("%s: secret reference %s needs a secret resolver", key, ref)
+		}
+		value, err := o.secrets.Resolve(o.ctx, ref)
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", key, err)
+		}
+		if c.secretRefs == nil {
+			c.secretRefs = make(map[string]string)
+		}
+		c.secretRefs[key] = ref
+		return value, nil
+	})
+}
+
+// resolveValue walks v like flattenValue and replaces the string fields that
+// hold secret references
+func resolveValue(v reflect.Value, prefix string, resolve func(key, ref string) (string, error)) error {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if !v.IsNil() {
+			return resolveValue(v.Elem(), prefix, resolve)
+		}
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+			if !field.IsExported() || name == "" || name == "-" {
+				continue
+			}
+			if err := resolveValue(v.Field(i), joinKey(prefix, name), resolve); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Struct {
+			for i := 0; i < v.Len(); i++ {
+				if err := resolveValue(v.Index(i), fmt.Sprintf("%s[%d]", prefix, i), resolve); err != nil {
+					return err
+				}
+			}
+		}
+	case reflect.String:
+		if secrets.IsRef(v.String()) {
+			value, err := resolve(prefix, v.String())
+			if err != nil {
+				return err
+			}
+			v.SetString(value)
+		}
+	}
+	return nil
+}
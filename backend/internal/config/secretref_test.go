@@ -0,0 +1,99 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeSecrets resolves references from a map
+type fakeSecrets map[string]string
+
+func (f fakeSecrets) Resolve(_ context.Context, ref string) (string, error) {
+	if v, ok := f[ref]; ok {
+		return v, nil
+	}
+	return "", errors.New("secret not found")
+}
+
+func TestLoad_SecretReferences(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	yamlContent := `source:
+  endpoint: wss://api-realtime.p2pquake.net/v2/ws
+
+subscriptions:
+  - name: test-webhook
+    delivery:
+      type: webhook
+      url: https://example.com/webhook
+      secret: sm://projects/namazu/secrets/webhook
+
+billing:
+  secret_key: sm://projects/namazu/secrets/stripe-key
+  webhook_secret: whsec_file
+  price_id: price_1
+  success_url: https://example.com/ok
+  cancel_url: https://example.com/cancel
+`
+	if err := os.WriteFile(configPath, []byte(yamlContent), 0644); err != nil {
+		t.Fatalf("Failed to write test config file: %v", err)
+	}
+	t.Setenv("NAMAZU_SOURCE_TYPE", "")
+	t.Setenv("NAMAZU_BADGE_SECRET", "sm://projects/namazu/secrets/badge/versions/2")
+
+	resolver := fakeSecrets{
+		"sm://projects/namazu/secrets/webhook":          "secret1",
+		"sm://projects/namazu/secrets/stripe-key":       "sk_live_123456",
+		"sm://projects/namazu/secrets/badge/versions/2": "badge-secret",
+	}
+	cfg, err := Load(configPath, WithSecretResolver(context.Background(), resolver))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Billing.SecretKey != "sk_live_123456" || cfg.Subscriptions[0].Delivery.Secret != "secret1" ||
+		cfg.Security.BadgeSecret != "badge-secret" {
+		t.Errorf("secrets not resolved: billing=%q delivery=%q badge=%q",
+			cfg.Billing.SecretKey, cfg.Subscriptions[0].Delivery.Secret, cfg.Security.BadgeSecret)
+	}
+
+	// Export shows the references, not the values
+	settings := cfg.Export()
+	for key, want := range map[string]string{
+		"billing.secret_key":               "sm://projects/namazu/secrets/stripe-key",
+		"subscriptions[0].delivery.secret": "sm://projects/namazu/secrets/webhook",
+		"security.badge_secret":            "sm://projects/namazu/secrets/badge/versions/2",
+		"billing.webhook_secret":           "whsec_********",
+	} {
+		if got := findSetting(t, settings, key); got.Value != want {
+			t.Errorf("%s = %v, want %s", key, got.Value, want)
+		}
+	}
+
+	// Without a resolver, or when a secret is missing, loading fails
+	if _, err := Load(configPath); err == nil || !strings.Contains(err.Error(), "needs a secret resolver") {
+		t.Errorf("Load() error = %v, want a missing resolver error", err)
+	}
+	delete(resolver, "sm://projects/namazu/secrets/stripe-key")
+	_, err = Load(configPath, WithSecretResolver(context.Background(), resolver))
+	if err == nil || !strings.Contains(err.Error(), "billing.secret_key") {
+		t.Errorf("Load() error = %v, want the key of the missing secret", err)
+	}
+}
+
+func TestLoadFromEnv_SecretReferences(t *testing.T) {
+	t.Setenv("NAMAZU_SOURCE_ENDPOINT", "wss://api-realtime.p2pquake.net/v2/ws")
+	t.Setenv("NAMAZU_ACCOUNT_TOKEN_SECRET", "sm://projects/namazu/secrets/account-token")
+
+	cfg, err := LoadFromEnv(WithSecretResolver(context.Background(), fakeSecrets{
+		"sm://projects/namazu/secrets/account-token": "token-secret",
+	}))
+	if err != nil {
+		t.Fatalf("LoadFromEnv() error = %v", err)
+	}
+	if cfg.Security.AccountTokenSecret != "token-secret" {
+		t.Errorf("AccountTokenSecret = %q, want the resolved secret", cfg.Security.AccountTokenSecret)
+	}
+}
@@ -0,0 +1,164 @@
+// Package aws delivers events to Amazon SNS topics and SQS queues without the
+// AWS SDK: requests use the Query API and are signed with Signature Version 4.
+package aws
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/otiai10/namazu/backend/internal/delivery"
+)
+
+// DefaultTimeout is the request timeout unless WithTimeout is given
+const DefaultTimeout = 10 * time.Second
+
+// Attribute data types
+const (
+	TypeString      = "String"
+	TypeNumber      = "Number"
+	TypeStringArray = "String.Array" // SNS only; the value is a JSON array
+)
+
+// Attribute is a message attribute subscribers can filter on
+type Attribute struct {
+	DataType string
+	Value    string
+}
+
+// Client publishes to SNS and SQS.
+//
+// Client is safe for concurrent use by multiple goroutines.
+type Client struct {
+	client   *http.Client
+	endpoint func(service, region string) string
+	now      func() time.Time
+}
+
+// ClientOption configures the Client
+type ClientOption func(*Client)
+
+// WithTimeout sets the request timeout
+func WithTimeout(d time.Duration) ClientOption {
+	return func(c *Client) {
+		c.client.Timeout = d
+	}
+}
+
+// WithSNSEndpoint overrides the SNS endpoint of a region (used in tests)
+func WithSNSEndpoint(fn func(region string) string) ClientOption {
+	return func(c *Client) {
+		c.endpoint = func(service, region string) string { return fn(region) }
+	}
+}
+
+// NewClient creates a Client
+func NewClient(opts ...ClientOption) *Client {
+	c := &Client{
+		client:   &http.Client{Timeout: DefaultTimeout},
+		endpoint: func(service, region string) string { return "https://" + service + "." + region + ".amazonaws.com/" },
+		now:      time.Now,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Publish publishes message to an SNS topic
+func (c *Client) Publish(ctx context.Context, creds Credentials, region, topicARN string, message []byte, attrs map[string]Attribute) delivery.Result {
+	form := url.Values{
+		"Action":   {"Publish"},
+		"Version":  {"2010-03-31"},
+		"TopicArn": {topicARN},
+		"Message":  {string(message)},
+	}
+	for i, name := range sortedNames(attrs) {
+		prefix := "MessageAttributes.entry." + strconv.Itoa(i+1) + "."
+		form.Set(prefix+"Name", name)
+		form.Set(prefix+"Value.DataType", attrs[name].DataType)
+		form.Set(prefix+"Value.StringValue", attrs[name].Value)
+	}
+	return c.post(ctx, creds, "sns", region, c.endpoint("sns", region), topicARN, form)
+}
+
+// SendMessage sends message to an SQS queue. String.Array attributes are
+// sent as String, since SQS does not support them.
+func (c *Client) SendMessage(ctx context.Context, creds Credentials, region, queueURL string, message []byte, attrs map[string]Attribute) delivery.Result {
+	form := url.Values{
+		"Action":      {"SendMessage"},
+		"Version":     {"2012-11-05"},
+		"MessageBody": {string(message)},
+	}
+	for i, name := range sortedNames(attrs) {
+		dataType := attrs[name].DataType
+		if dataType == TypeStringArray {
+			dataType = TypeString
+		}
+		prefix := "MessageAttribute." + strconv.Itoa(i+1) + "."
+		form.Set(prefix+"Name", name)
+		form.Set(prefix+"Value.DataType", dataType)
+		form.Set(prefix+"Value.StringValue", attrs[name].Value)
+	}
+	return c.post(ctx, creds, "sqs", region, queueURL, queueURL, form)
+}
+
+// errorResponse is the error body of the Query API
+type errorResponse struct {
+	Code    string `xml:"Error>Code"`
+	Message string `xml:"Error>Message"`
+}
+
+// post sends a signed Query API request. target identifies the topic or queue in the result.
+func (c *Client) post(ctx context.Context, creds Credentials, service, region, endpoint, target string, form url.Values) delivery.Result {
+	start := time.Now()
+	result := delivery.Result{URL: target}
+
+	body := []byte(form.Encode())
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		result.ErrorMessage = fmt.Sprintf("failed to create request: %v", err)
+		result.ResponseTime = time.Since(start)
+		return result
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+	signV4(req, body, creds, region, service, c.now())
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		result.ErrorMessage = fmt.Sprintf("request failed: %v", err)
+		result.ResponseTime = time.Since(start)
+		return result
+	}
+	defer resp.Body.Close()
+
+	result.StatusCode = resp.StatusCode
+	result.Success = resp.StatusCode >= 200 && resp.StatusCode < 300
+	result.ResponseTime = time.Since(start)
+	if !result.Success {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		var e errorResponse
+		if xml.Unmarshal(respBody, &e) == nil && e.Code != "" {
+			result.ErrorMessage = fmt.Sprintf("%s: %s", e.Code, e.Message)
+		} else {
+			result.ErrorMessage = fmt.Sprintf("unexpected status: %d", resp.StatusCode)
+		}
+	}
+	return result
+}
+
+func sortedNames(attrs map[string]Attribute) []string {
+	names := make([]string, 0, len(attrs))
+	for name := range attrs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
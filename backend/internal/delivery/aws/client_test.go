@@ -0,0 +1,174 @@
+package aws
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/otiai10/namazu/backend/internal/delivery"
+	"github.com/otiai10/namazu/backend/internal/source"
+	"github.com/otiai10/namazu/backend/internal/subscription"
+)
+
+// recorder is a fake Query API endpoint that records request forms
+type recorder struct {
+	mu     sync.Mutex
+	forms  []url.Values
+	auth   []string
+	status int
+	body   string
+}
+
+func (rec *recorder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	rec.mu.Lock()
+	rec.forms = append(rec.forms, r.PostForm)
+	rec.auth = append(rec.auth, r.Header.Get("Authorization"))
+	rec.mu.Unlock()
+	if rec.status != 0 {
+		w.WriteHeader(rec.status)
+	}
+	w.Write([]byte(rec.body))
+}
+
+func TestClient_Publish(t *testing.T) {
+	rec := &recorder{}
+	server := httptest.NewServer(rec)
+	defer server.Close()
+
+	client := NewClient(WithSNSEndpoint(func(region string) string { return server.URL + "/" }))
+	topic := "arn:aws:sns:ap-northeast-1:123456789012:quakes"
+	result := client.Publish(context.Background(), Credentials{AccessKeyID: "AKID", SecretAccessKey: "secret"}, "ap-northeast-1", topic,
+		[]byte(`{"code":551}`), map[string]Attribute{
+			"scale":       {DataType: TypeNumber, Value: "45"},
+			"prefectures": {DataType: TypeStringArray, Value: `["石川県"]`},
+		})
+
+	if !result.Success || result.URL != topic {
+		t.Fatalf("Publish() = %+v, want success for the topic", result)
+	}
+	form := rec.forms[0]
+	want := map[string]string{
+		"Action":                         "Publish",
+		"TopicArn":                       topic,
+		"Message":                        `{"code":551}`,
+		"MessageAttributes.entry.1.Name": "prefectures",
+		"MessageAttributes.entry.1.Value.DataType":    "String.Array",
+		"MessageAttributes.entry.2.Name":              "scale",
+		"MessageAttributes.entry.2.Value.DataType":    "Number",
+		"MessageAttributes.entry.2.Value.StringValue": "45",
+	}
+	for key, value := range want {
+		if got := form.Get(key); got != value {
+			t.Errorf("%s = %q, want %q", key, got, value)
+		}
+	}
+	if !strings.HasPrefix(rec.auth[0], "AWS4-HMAC-SHA256 Credential=AKID/") || !strings.Contains(rec.auth[0], "/ap-northeast-1/sns/aws4_request") {
+		t.Errorf("Authorization = %q, want a SigV4 signature for sns", rec.auth[0])
+	}
+}
+
+func TestClient_SendMessage(t *testing.T) {
+	rec := &recorder{}
+	server := httptest.NewServer(rec)
+	defer server.Close()
+
+	client := NewClient()
+	queueURL := server.URL + "/123456789012/quakes"
+	result := client.SendMessage(context.Background(), Credentials{AccessKeyID: "AKID", SecretAccessKey: "secret"}, "ap-northeast-1", queueURL,
+		[]byte(`{"code":551}`), map[string]Attribute{"prefectures": {DataType: TypeStringArray, Value: `["石川県"]`}})
+
+	if !result.Success {
+		t.Fatalf("SendMessage() = %+v, want success", result)
+	}
+	form := rec.forms[0]
+	if form.Get("Action") != "SendMessage" || form.Get("MessageBody") != `{"code":551}` {
+		t.Errorf("unexpected form %v", form)
+	}
+	if got := form.Get("MessageAttribute.1.Value.DataType"); got != "String" {
+		t.Errorf("DataType = %q, want String.Array sent as String", got)
+	}
+	if !strings.Contains(rec.auth[0], "/ap-northeast-1/sqs/aws4_request") {
+		t.Errorf("Authorization = %q, want a SigV4 signature for sqs", rec.auth[0])
+	}
+}
+
+func TestClient_Publish_Error(t *testing.T) {
+	rec := &recorder{
+		status: http.StatusForbidden,
+		body:   `<ErrorResponse><Error><Type>Sender</Type><Code>AuthorizationError</Code><Message>not authorized to perform SNS:Publish</Message></Error></ErrorResponse>`,
+	}
+	server := httptest.NewServer(rec)
+	defer server.Close()
+
+	client := NewClient(WithSNSEndpoint(func(string) string { return server.URL + "/" }), WithTimeout(time.Second))
+	result := client.Publish(context.Background(), Credentials{AccessKeyID: "AKID", SecretAccessKey: "secret"}, "ap-northeast-1",
+		"arn:aws:sns:ap-northeast-1:123456789012:quakes", []byte(`{}`), nil)
+
+	if result.Success || result.StatusCode != http.StatusForbidden {
+		t.Fatalf("Publish() = %+v, want a 403 failure", result)
+	}
+	if result.ErrorMessage != "AuthorizationError: not authorized to perform SNS:Publish" {
+		t.Errorf("ErrorMessage = %q", result.ErrorMessage)
+	}
+}
+
+type testEvent struct{}
+
+func (testEvent) GetID() string              { return "e1" }
+func (testEvent) GetType() source.EventType  { return source.EventTypeEarthquake }
+func (testEvent) GetSource() string          { return "p2pquake" }
+func (testEvent) GetSeverity() int           { return 50 }
+func (testEvent) GetAffectedAreas() []string { return []string{"石川県", "富山県"} }
+func (testEvent) GetOccurredAt() time.Time   { return time.Time{} }
+func (testEvent) GetReceivedAt() time.Time   { return time.Time{} }
+func (testEvent) GetRawJSON() string         { return `{}` }
+
+func TestAttributes(t *testing.T) {
+	attrs := Attributes(delivery.Message{Payload: []byte(`{}`), Event: testEvent{}})
+	if attrs["scale"] != (Attribute{DataType: TypeNumber, Value: "45"}) {
+		t.Errorf("scale = %+v", attrs["scale"])
+	}
+	if attrs["prefectures"] != (Attribute{DataType: TypeStringArray, Value: `["石川県","富山県"]`}) {
+		t.Errorf("prefectures = %+v", attrs["prefectures"])
+	}
+	if attrs["event_type"].Value != "earthquake" {
+		t.Errorf("event_type = %+v", attrs["event_type"])
+	}
+
+	notice := Attributes(delivery.Message{Payload: []byte(`{"type":"namazu.service_notice"}`)})
+	if len(notice) != 1 || notice["event_type"].Value != "namazu.service_notice" {
+		t.Errorf("notice attributes = %+v", notice)
+	}
+}
+
+func TestDispatcher_Dispatch(t *testing.T) {
+	rec := &recorder{}
+	server := httptest.NewServer(rec)
+	defer server.Close()
+
+	d := NewDispatcher(NewClient(WithSNSEndpoint(func(string) string { return server.URL + "/" })))
+	creds := subscription.AWSConfig{Region: "ap-northeast-1", AccessKeyID: "AKID", SecretAccessKey: "secret"}
+	sns, sqs := creds, creds
+	sns.TopicARN = "arn:aws:sns:ap-northeast-1:123456789012:quakes"
+	sqs.QueueURL = server.URL + "/123456789012/quakes"
+
+	d.Dispatch(context.Background(), delivery.Message{Payload: []byte(`{"code":551}`), Event: testEvent{}}, []subscription.Subscription{
+		{Name: "Topic", Delivery: subscription.DeliveryConfig{Type: subscription.DeliveryTypeSNS, AWS: &sns}},
+		{Name: "Queue", Delivery: subscription.DeliveryConfig{Type: subscription.DeliveryTypeSQS, AWS: &sqs}},
+		{Name: "Unconfigured", Delivery: subscription.DeliveryConfig{Type: subscription.DeliveryTypeSNS}},
+	})
+
+	actions := map[string]bool{}
+	for _, form := range rec.forms {
+		actions[form.Get("Action")] = true
+	}
+	if len(rec.forms) != 2 || !actions["Publish"] || !actions["SendMessage"] {
+		t.Errorf("expected one Publish and one SendMessage, got %v", rec.forms)
+	}
+}
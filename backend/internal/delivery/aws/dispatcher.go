@@ -0,0 +1,94 @@
+package aws
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"strconv"
+	"sync"
+
+	"github.com/otiai10/namazu/backend/internal/delivery"
+	"github.com/otiai10/namazu/backend/internal/source/p2pquake"
+	"github.com/otiai10/namazu/backend/internal/subscription"
+)
+
+// Dispatcher delivers messages to "sns" and "sqs" subscriptions.
+// Each subscription gets one attempt; failures are logged.
+type Dispatcher struct {
+	client *Client
+}
+
+// Compile-time interface check
+var _ delivery.Dispatcher = (*Dispatcher)(nil)
+
+// NewDispatcher creates a Dispatcher that publishes with client
+func NewDispatcher(client *Client) *Dispatcher {
+	return &Dispatcher{client: client}
+}
+
+// Dispatch publishes the message payload to each subscription's topic or
+// queue concurrently, with the attributes from Attributes.
+func (d *Dispatcher) Dispatch(ctx context.Context, msg delivery.Message, subs []subscription.Subscription) {
+	attrs := Attributes(msg)
+
+	var wg sync.WaitGroup
+	for _, sub := range subs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result := d.send(ctx, sub, msg.Payload, attrs)
+			if result.Success {
+				log.Printf("Subscription [%s]: published to %s in %v", sub.Name, result.URL, result.ResponseTime)
+			} else {
+				log.Printf("Subscription [%s]: failed - %s", sub.Name, result.ErrorMessage)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func (d *Dispatcher) send(ctx context.Context, sub subscription.Subscription, payload []byte, attrs map[string]Attribute) delivery.Result {
+	cfg := sub.Delivery.AWS
+	if cfg == nil {
+		return delivery.Result{ErrorMessage: "delivery.aws is not configured"}
+	}
+	creds := Credentials{AccessKeyID: cfg.AccessKeyID, SecretAccessKey: cfg.SecretAccessKey}
+
+	switch sub.Delivery.Type {
+	case subscription.DeliveryTypeSNS:
+		return d.client.Publish(ctx, creds, cfg.Region, cfg.TopicARN, payload, attrs)
+	case subscription.DeliveryTypeSQS:
+		return d.client.SendMessage(ctx, creds, cfg.Region, cfg.QueueURL, payload, attrs)
+	default:
+		return delivery.Result{ErrorMessage: "unsupported delivery type " + sub.Delivery.Type}
+	}
+}
+
+// Attributes returns the message attributes of a message, for subscription
+// filter policies: "event_type", "source", "scale" (p2pquake scale, Number)
+// and "prefectures" (String.Array). Messages without an event (service notices,
+// digests) only carry "event_type", taken from the "type" of their payload.
+func Attributes(msg delivery.Message) map[string]Attribute {
+	if msg.Event == nil {
+		var payload struct {
+			Type string `json:"type"`
+		}
+		if json.Unmarshal(msg.Payload, &payload) != nil || payload.Type == "" {
+			return map[string]Attribute{}
+		}
+		return map[string]Attribute{"event_type": {DataType: TypeString, Value: payload.Type}}
+	}
+
+	attrs := map[string]Attribute{
+		"event_type": {DataType: TypeString, Value: string(msg.Event.GetType())},
+		"source":     {DataType: TypeString, Value: msg.Event.GetSource()},
+		"scale":      {DataType: TypeNumber, Value: strconv.Itoa(p2pquake.SeverityToScale(msg.Event.GetSeverity()))},
+	}
+	if areas := msg.Event.GetAffectedAreas(); len(areas) > 0 {
+		data, err := json.Marshal(areas)
+		if err == nil {
+			attrs["prefectures"] = Attribute{DataType: TypeStringArray, Value: string(data)}
+		}
+	}
+	return attrs
+}
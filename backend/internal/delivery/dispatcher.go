@@ -0,0 +1,110 @@
+package delivery
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/otiai10/namazu/backend/internal/source"
+	"github.com/otiai10/namazu/backend/internal/subscription"
+)
+
+// Message is what gets delivered to subscriptions: an earthquake event or a
+// service notice.
+type Message struct {
+	ID      string       // ID of the stored event or notice; may be empty
+	Payload []byte       // JSON body delivered to webhooks
+	Event   source.Event // nil for service notices
+}
+
+// Dispatcher delivers messages over one channel (webhook, slack, email, ...).
+// Dispatch is given only subscriptions whose DeliveryConfig.Type the
+// dispatcher is registered for, already filtered for the message (possibly
+// none). It returns
+// when the deliveries have completed (or have been handed off to background
+// work) and is responsible for its own retries and logging.
+type Dispatcher interface {
+	Dispatch(ctx context.Context, msg Message, subs []subscription.Subscription)
+}
+
+// DispatcherFunc adapts a function to the Dispatcher interface
+type DispatcherFunc func(ctx context.Context, msg Message, subs []subscription.Subscription)
+
+// Dispatch calls f(ctx, msg, subs)
+func (f DispatcherFunc) Dispatch(ctx context.Context, msg Message, subs []subscription.Subscription) {
+	f(ctx, msg, subs)
+}
+
+// Registry maps delivery types to dispatchers.
+//
+// Registry is safe for concurrent use by multiple goroutines.
+type Registry struct {
+	mu          sync.RWMutex
+	dispatchers map[string]Dispatcher
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{dispatchers: make(map[string]Dispatcher)}
+}
+
+// Register sets the dispatcher for a delivery type, replacing any previous one
+func (r *Registry) Register(deliveryType string, d Dispatcher) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.dispatchers[deliveryType] = d
+}
+
+// Get returns the dispatcher for a delivery type
+func (r *Registry) Get(deliveryType string) (Dispatcher, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	d, ok := r.dispatchers[deliveryType]
+	return d, ok
+}
+
+// Types returns the registered delivery types, sorted
+func (r *Registry) Types() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	types := make([]string, 0, len(r.dispatchers))
+	for t := range r.dispatchers {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// Dispatch groups subs by delivery type and hands each group to its
+// dispatcher. Every registered dispatcher is called, with an empty group if no
+// subscription uses it. Groups are dispatched concurrently, so a slow channel
+// does not hold up the others; Dispatch returns when all dispatchers have
+// returned. Subscriptions of types without a dispatcher are returned as unhandled.
+func (r *Registry) Dispatch(ctx context.Context, msg Message, subs []subscription.Subscription) (unhandled []subscription.Subscription) {
+	r.mu.RLock()
+	groups := make(map[string][]subscription.Subscription, len(r.dispatchers))
+	for t := range r.dispatchers {
+		groups[t] = nil
+	}
+	for _, sub := range subs {
+		t := sub.Delivery.Type
+		if _, ok := r.dispatchers[t]; !ok {
+			unhandled = append(unhandled, sub)
+			continue
+		}
+		groups[t] = append(groups[t], sub)
+	}
+
+	var wg sync.WaitGroup
+	for t, group := range groups {
+		wg.Add(1)
+		go func(d Dispatcher, group []subscription.Subscription) {
+			defer wg.Done()
+			d.Dispatch(ctx, msg, group)
+		}(r.dispatchers[t], group)
+	}
+	r.mu.RUnlock()
+
+	wg.Wait()
+	return unhandled
+}
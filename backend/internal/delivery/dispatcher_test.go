@@ -0,0 +1,86 @@
+package delivery
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/otiai10/namazu/backend/internal/subscription"
+)
+
+// recordingDispatcher records the subscriptions it is given
+type recordingDispatcher struct {
+	mu    sync.Mutex
+	calls [][]string
+}
+
+func (d *recordingDispatcher) Dispatch(ctx context.Context, msg Message, subs []subscription.Subscription) {
+	ids := make([]string, 0, len(subs))
+	for _, sub := range subs {
+		ids = append(ids, sub.ID)
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.calls = append(d.calls, ids)
+}
+
+func sub(id, deliveryType string) subscription.Subscription {
+	return subscription.Subscription{ID: id, Delivery: subscription.DeliveryConfig{Type: deliveryType}}
+}
+
+func TestRegistry_Dispatch(t *testing.T) {
+	webhook := &recordingDispatcher{}
+	slack := &recordingDispatcher{}
+	email := &recordingDispatcher{}
+	r := NewRegistry()
+	r.Register("webhook", webhook)
+	r.Register("slack", slack)
+	r.Register("email", email)
+
+	unhandled := r.Dispatch(context.Background(), Message{ID: "evt-1"}, []subscription.Subscription{
+		sub("w1", "webhook"), sub("s1", "slack"), sub("w2", "webhook"), sub("x1", "sms"),
+	})
+
+	if !reflect.DeepEqual(webhook.calls, [][]string{{"w1", "w2"}}) {
+		t.Errorf("webhook calls = %v", webhook.calls)
+	}
+	if !reflect.DeepEqual(slack.calls, [][]string{{"s1"}}) {
+		t.Errorf("slack calls = %v", slack.calls)
+	}
+	// Dispatchers without subscriptions are still called
+	if !reflect.DeepEqual(email.calls, [][]string{{}}) {
+		t.Errorf("email calls = %v", email.calls)
+	}
+	if len(unhandled) != 1 || unhandled[0].ID != "x1" {
+		t.Errorf("unhandled = %v, want [x1]", unhandled)
+	}
+}
+
+func TestRegistry_Register(t *testing.T) {
+	r := NewRegistry()
+	if _, ok := r.Get("webhook"); ok {
+		t.Error("empty registry returned a dispatcher")
+	}
+
+	var called string
+	r.Register("webhook", DispatcherFunc(func(ctx context.Context, msg Message, subs []subscription.Subscription) {
+		called = "first"
+	}))
+	r.Register("webhook", DispatcherFunc(func(ctx context.Context, msg Message, subs []subscription.Subscription) {
+		called = "second"
+	}))
+	r.Register("slack", &recordingDispatcher{})
+
+	d, ok := r.Get("webhook")
+	if !ok {
+		t.Fatal("Get(webhook) not found")
+	}
+	d.Dispatch(context.Background(), Message{}, nil)
+	if called != "second" {
+		t.Errorf("called %q dispatcher, want the replacement", called)
+	}
+	if got := r.Types(); !reflect.DeepEqual(got, []string{"slack", "webhook"}) {
+		t.Errorf("Types() = %v", got)
+	}
+}
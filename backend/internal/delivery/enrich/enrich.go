@@ -0,0 +1,108 @@
+// Package enrich adds fields derived from an event to webhook bodies, for
+// receivers that would otherwise have to decode scale codes, convert time
+// zones or geocode the hypocenter themselves. Subscriptions opt in with
+// delivery.enrich; the original fields of the body are left untouched.
+package enrich
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/otiai10/namazu/backend/internal/i18n"
+	"github.com/otiai10/namazu/backend/internal/source"
+	"github.com/otiai10/namazu/backend/internal/source/p2pquake"
+)
+
+// Key is the field of the body the derived fields are added under
+const Key = "namazu"
+
+// MapURL is the static map image of a hypocenter, centered on it with a
+// marker. It is OpenStreetMap's static map service, which needs no API key;
+// the verbs are latitude, longitude, latitude, longitude.
+const MapURL = "https://staticmap.openstreetmap.de/staticmap.php?center=%.4f,%.4f&zoom=7&size=600x400&markers=%.4f,%.4f,red-pushpin"
+
+var jst = time.FixedZone("JST", 9*60*60)
+
+// Fields are the derived fields of an event. Fields that cannot be derived
+// (no scale, no hypocenter) are omitted.
+type Fields struct {
+	Scale             int    `json:"scale,omitempty"`              // JMA scale code (10-70)
+	ScaleLabel        string `json:"scale_label,omitempty"`        // e.g. "震度5弱"
+	ScaleLabelEn      string `json:"scale_label_en,omitempty"`     // e.g. "Shindo 5 Lower"
+	ShindoDescription string `json:"shindo_description,omitempty"` // What the shaking is like, in English
+	OccurredAtUTC     string `json:"occurred_at_utc,omitempty"`    // RFC 3339, e.g. "2024-01-01T07:10:00Z"
+	OccurredAtJST     string `json:"occurred_at_jst,omitempty"`    // RFC 3339, e.g. "2024-01-01T16:10:00+09:00"
+	MapURL            string `json:"map_url,omitempty"`            // Static map image of the hypocenter
+}
+
+// descriptions summarize the JMA seismic intensity scale for each scale code
+var descriptions = map[int]string{
+	p2pquake.Scale1:       "Felt slightly by some people keeping quiet indoors.",
+	p2pquake.Scale2:       "Felt by many people keeping quiet indoors. Hanging objects swing slightly.",
+	p2pquake.Scale3:       "Felt by most people indoors. Dishes in cupboards may rattle.",
+	p2pquake.Scale4:       "Most people are startled. Hanging objects swing considerably and unstable ornaments may fall.",
+	p2pquake.Scale5Weak:   "Many people are frightened and hold on to something. Dishes and books may fall.",
+	p2pquake.Scale5Strong: "Many people find it difficult to walk without holding on to something. Unsecured furniture may topple.",
+	p2pquake.Scale6Weak:   "It is difficult to remain standing. Unsecured furniture moves or topples and doors may jam.",
+	p2pquake.Scale6Strong: "It is impossible to move without crawling. Buildings with low earthquake resistance may collapse.",
+	p2pquake.Scale7:       "People are thrown by the shaking. Even buildings with high earthquake resistance may lean.",
+}
+
+// Derive returns the derived fields of an event
+func Derive(event source.Event) Fields {
+	var f Fields
+	if scale := p2pquake.SeverityToScale(event.GetSeverity()); scale > 0 {
+		f.Scale = scale
+		f.ScaleLabel = i18n.Scale(i18n.Japanese, scale)
+		f.ScaleLabelEn = i18n.Scale(i18n.English, scale)
+		f.ShindoDescription = descriptions[scale]
+	}
+	if occurredAt := event.GetOccurredAt(); !occurredAt.IsZero() {
+		f.OccurredAtUTC = occurredAt.UTC().Format(time.RFC3339)
+		f.OccurredAtJST = occurredAt.In(jst).Format(time.RFC3339)
+	}
+	if located, ok := event.(source.Located); ok {
+		if h := located.GetHypocenter(); h != nil {
+			f.MapURL = fmt.Sprintf(MapURL, h.Latitude, h.Longitude, h.Latitude, h.Longitude)
+		}
+	}
+	return f
+}
+
+// Apply adds the derived fields of event to a JSON object body under Key.
+// Bodies without an event (service notices, digests), bodies that are not
+// objects and bodies that already have Key are returned unchanged. The
+// original bytes are kept, so the fields of the body keep their order.
+func Apply(event source.Event, payload []byte) ([]byte, error) {
+	if event == nil {
+		return payload, nil
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(payload, &fields); err != nil || fields == nil {
+		return payload, nil
+	}
+	if _, ok := fields[Key]; ok {
+		return payload, nil
+	}
+
+	derived, err := json.Marshal(Derive(event))
+	if err != nil {
+		return nil, err
+	}
+	body := bytes.TrimSpace(payload)
+	inner := bytes.TrimSpace(body[1 : len(body)-1])
+
+	var buf bytes.Buffer
+	buf.Grow(len(body) + len(derived) + len(Key) + 4)
+	buf.WriteByte('{')
+	if len(inner) > 0 {
+		buf.Write(inner)
+		buf.WriteByte(',')
+	}
+	fmt.Fprintf(&buf, "%q:", Key)
+	buf.Write(derived)
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
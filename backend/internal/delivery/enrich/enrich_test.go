@@ -0,0 +1,78 @@
+package enrich
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/otiai10/namazu/backend/internal/source"
+)
+
+type testEvent struct {
+	severity   int
+	hypocenter *source.Hypocenter
+}
+
+func (testEvent) GetID() string                       { return "e1" }
+func (testEvent) GetType() source.EventType           { return source.EventTypeEarthquake }
+func (testEvent) GetSource() string                   { return "p2pquake" }
+func (e testEvent) GetSeverity() int                  { return e.severity }
+func (testEvent) GetAffectedAreas() []string          { return []string{"石川県"} }
+func (testEvent) GetOccurredAt() time.Time            { return time.Date(2024, 1, 1, 7, 10, 0, 0, time.UTC) }
+func (testEvent) GetReceivedAt() time.Time            { return time.Time{} }
+func (testEvent) GetRawJSON() string                  { return `{}` }
+func (e testEvent) GetHypocenter() *source.Hypocenter { return e.hypocenter }
+
+func TestDerive(t *testing.T) {
+	f := Derive(testEvent{severity: 50, hypocenter: &source.Hypocenter{Name: "石川県能登地方", Latitude: 37.5, Longitude: 137.27}})
+	if f.Scale != 45 || f.ScaleLabel != "震度5弱" || f.ScaleLabelEn != "Shindo 5 Lower" || f.ShindoDescription == "" {
+		t.Errorf("scale fields = %+v", f)
+	}
+	if f.OccurredAtUTC != "2024-01-01T07:10:00Z" || f.OccurredAtJST != "2024-01-01T16:10:00+09:00" {
+		t.Errorf("timestamps = %s / %s", f.OccurredAtUTC, f.OccurredAtJST)
+	}
+	if !strings.Contains(f.MapURL, "center=37.5000,137.2700") {
+		t.Errorf("MapURL = %q", f.MapURL)
+	}
+
+	f = Derive(testEvent{})
+	if f.Scale != 0 || f.ScaleLabel != "" || f.MapURL != "" || f.OccurredAtUTC == "" {
+		t.Errorf("Derive(no scale or hypocenter) = %+v", f)
+	}
+}
+
+func TestApply(t *testing.T) {
+	event := testEvent{severity: 50}
+
+	got, err := Apply(event, []byte(`{"code": 551, "issue": {"type": "DetailScale"}}`))
+	if err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if !strings.HasPrefix(string(got), `{"code": 551, "issue": {"type": "DetailScale"},"namazu":{`) {
+		t.Errorf("Apply() = %s, want the original fields first", got)
+	}
+	var body struct {
+		Code   int    `json:"code"`
+		Namazu Fields `json:"namazu"`
+	}
+	if err := json.Unmarshal(got, &body); err != nil {
+		t.Fatalf("Apply() is not valid JSON: %v: %s", err, got)
+	}
+	if body.Code != 551 || body.Namazu.ScaleLabel != "震度5弱" {
+		t.Errorf("Apply() = %+v", body)
+	}
+
+	if got, _ := Apply(event, []byte(` {} `)); !json.Valid(got) || !strings.Contains(string(got), `"scale_label_en":"Shindo 5 Lower"`) {
+		t.Errorf("Apply(empty object) = %s", got)
+	}
+
+	for _, payload := range []string{`[1, 2]`, `"text"`, `null`, `not json`, `{"namazu": "taken"}`} {
+		if got, _ := Apply(event, []byte(payload)); string(got) != payload {
+			t.Errorf("Apply(%s) = %s, want it unchanged", payload, got)
+		}
+	}
+	if got, _ := Apply(nil, []byte(`{"type": "namazu.digest"}`)); string(got) != `{"type": "namazu.digest"}` {
+		t.Errorf("Apply(no event) = %s, want it unchanged", got)
+	}
+}
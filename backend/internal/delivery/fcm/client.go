@@ -0,0 +1,113 @@
+package fcm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	firebase "firebase.google.com/go/v4"
+	"firebase.google.com/go/v4/messaging"
+	"google.golang.org/api/option"
+
+	"github.com/otiai10/namazu/backend/internal/user"
+)
+
+// ErrTokenRejected is returned when FCM refuses to subscribe a registration token to a topic
+var ErrTokenRejected = errors.New("registration token rejected")
+
+// Messaging is the part of the FCM client used for delivery and topic
+// management. *messaging.Client implements it.
+type Messaging interface {
+	Send(ctx context.Context, message *messaging.Message) (string, error)
+	SendEachForMulticast(ctx context.Context, message *messaging.MulticastMessage) (*messaging.BatchResponse, error)
+	SubscribeToTopic(ctx context.Context, tokens []string, topic string) (*messaging.TopicManagementResponse, error)
+	UnsubscribeFromTopic(ctx context.Context, tokens []string, topic string) (*messaging.TopicManagementResponse, error)
+}
+
+// Compile-time interface check
+var _ Messaging = (*messaging.Client)(nil)
+
+// NewMessaging creates an FCM client for a Firebase project.
+// credentialsPath may be empty to use Application Default Credentials.
+func NewMessaging(ctx context.Context, projectID, credentialsPath string) (*messaging.Client, error) {
+	var opts []option.ClientOption
+	if credentialsPath != "" {
+		opts = append(opts, option.WithCredentialsFile(credentialsPath))
+	}
+
+	app, err := firebase.NewApp(ctx, &firebase.Config{
+		ProjectID: projectID,
+	}, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create firebase app: %w", err)
+	}
+
+	client, err := app.Messaging(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get messaging client: %w", err)
+	}
+	return client, nil
+}
+
+// Topics keeps registered devices subscribed to their prefecture alert topics
+type Topics struct {
+	client Messaging
+}
+
+// NewTopics creates a Topics
+func NewTopics(client Messaging) *Topics {
+	return &Topics{client: client}
+}
+
+// Sync moves a device from the topics of previous (nil for a new device) to
+// those of current (nil for a removed device), leaving unchanged topics alone
+func (t *Topics) Sync(ctx context.Context, previous, current *user.Device) error {
+	var from, to []string
+	var token string
+	if previous != nil {
+		from, token = DeviceTopics(*previous), previous.Token
+	}
+	if current != nil {
+		to, token = DeviceTopics(*current), current.Token
+	}
+
+	for _, topic := range to {
+		if contains(from, topic) {
+			continue
+		}
+		resp, err := t.client.SubscribeToTopic(ctx, []string{token}, topic)
+		if err := topicError(resp, err); err != nil {
+			return fmt.Errorf("failed to subscribe to %s: %w", topic, err)
+		}
+	}
+	for _, topic := range from {
+		if contains(to, topic) {
+			continue
+		}
+		resp, err := t.client.UnsubscribeFromTopic(ctx, []string{token}, topic)
+		if err := topicError(resp, err); err != nil {
+			return fmt.Errorf("failed to unsubscribe from %s: %w", topic, err)
+		}
+	}
+	return nil
+}
+
+// topicError returns the error of a single-token topic management call
+func topicError(resp *messaging.TopicManagementResponse, err error) error {
+	if err != nil {
+		return err
+	}
+	if resp != nil && resp.FailureCount > 0 && len(resp.Errors) > 0 {
+		return fmt.Errorf("%w: %s", ErrTokenRejected, resp.Errors[0].Reason)
+	}
+	return nil
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,126 @@
+package fcm
+
+import (
+	"context"
+	"log"
+	"sync"
+
+	"firebase.google.com/go/v4/messaging"
+
+	"github.com/otiai10/namazu/backend/internal/delivery"
+	"github.com/otiai10/namazu/backend/internal/delivery/webpush"
+	"github.com/otiai10/namazu/backend/internal/i18n"
+	"github.com/otiai10/namazu/backend/internal/source"
+	"github.com/otiai10/namazu/backend/internal/subscription"
+	"github.com/otiai10/namazu/backend/internal/user"
+)
+
+// Users looks up the devices registered by subscription owners
+type Users interface {
+	GetByUID(ctx context.Context, uid string) (*user.User, error)
+	RemoveDevice(ctx context.Context, id string, token string) error
+}
+
+// Dispatcher delivers earthquake events to mobile devices over FCM.
+//
+// Owners of "fcm" subscriptions get the event on all of their devices in one
+// multicast request. Independently of subscriptions, every earthquake report is
+// also sent to the prefecture alert topics it matches, so devices that only
+// want alerts for their prefectures cost a handful of requests per event
+// rather than one per device. Messages without an event are not pushed.
+type Dispatcher struct {
+	client Messaging
+	users  Users
+
+	// unregistered reports whether a send error means the token is gone
+	unregistered func(error) bool
+}
+
+// Compile-time interface check
+var _ delivery.Dispatcher = (*Dispatcher)(nil)
+
+// NewDispatcher creates a Dispatcher
+func NewDispatcher(client Messaging, users Users) *Dispatcher {
+	return &Dispatcher{client: client, users: users, unregistered: messaging.IsUnregistered}
+}
+
+// Dispatch publishes the event to its prefecture topics and pushes it once per
+// owner, however many of their subscriptions matched
+func (d *Dispatcher) Dispatch(ctx context.Context, msg delivery.Message, subs []subscription.Subscription) {
+	if msg.Event == nil {
+		return
+	}
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		// Devices have no locale, so prefecture alerts are in the default one
+		d.publish(ctx, msg.Event, webpush.NewNotification(msg.Event, i18n.Default))
+	}()
+
+	owners := make(map[string]bool)
+	for _, sub := range subs {
+		if sub.UserID == "" {
+			log.Printf("Subscription [%s]: fcm requires an owner", sub.Name)
+			continue
+		}
+		if owners[sub.UserID] {
+			continue
+		}
+		owners[sub.UserID] = true
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			d.push(ctx, sub, webpush.NewNotification(msg.Event, sub.Locale))
+		}()
+	}
+	wg.Wait()
+}
+
+// publish sends the event to the devices whose prefecture alerts it matches
+func (d *Dispatcher) publish(ctx context.Context, event source.Event, n webpush.Notification) {
+	for _, condition := range eventConditions(event) {
+		m := newMessage(n)
+		m.Condition = condition
+		if _, err := d.client.Send(ctx, m); err != nil {
+			log.Printf("FCM: failed to publish event %s to %s: %v", event.GetID(), condition, err)
+		}
+	}
+}
+
+// push sends the notification to every device of the subscription's owner,
+// forgetting devices whose tokens are no longer registered
+func (d *Dispatcher) push(ctx context.Context, sub subscription.Subscription, n webpush.Notification) {
+	u, err := d.users.GetByUID(ctx, sub.UserID)
+	if err != nil {
+		log.Printf("Subscription [%s]: failed to get owner: %v", sub.Name, err)
+		return
+	}
+	if u == nil || len(u.Devices) == 0 {
+		log.Printf("Subscription [%s]: owner has no devices registered for FCM", sub.Name)
+		return
+	}
+
+	tokens := make([]string, len(u.Devices))
+	for i, device := range u.Devices {
+		tokens[i] = device.Token
+	}
+	resp, err := d.client.SendEachForMulticast(ctx, newMulticast(n, tokens))
+	if err != nil {
+		log.Printf("Subscription [%s]: fcm send failed - %v", sub.Name, err)
+		return
+	}
+	log.Printf("Subscription [%s]: pushed to %d/%d device(s)", sub.Name, resp.SuccessCount, len(tokens))
+
+	for i, result := range resp.Responses {
+		if result.Success || i >= len(tokens) {
+			continue
+		}
+		log.Printf("Subscription [%s]: fcm push failed - %v", sub.Name, result.Error)
+		if d.unregistered(result.Error) {
+			if err := d.users.RemoveDevice(ctx, u.ID, tokens[i]); err != nil {
+				log.Printf("Subscription [%s]: failed to remove unregistered device: %v", sub.Name, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,222 @@
+package fcm
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"firebase.google.com/go/v4/messaging"
+
+	"github.com/otiai10/namazu/backend/internal/delivery"
+	"github.com/otiai10/namazu/backend/internal/source"
+	"github.com/otiai10/namazu/backend/internal/subscription"
+	"github.com/otiai10/namazu/backend/internal/user"
+)
+
+var errUnregistered = errors.New("unregistered")
+
+// fakeMessaging records requests and fails the tokens in unregistered
+type fakeMessaging struct {
+	mu           sync.Mutex
+	sent         []*messaging.Message
+	multicasts   []*messaging.MulticastMessage
+	subscribed   []string // "topic token"
+	unsubscribed []string
+	unregistered map[string]bool
+	reject       bool
+}
+
+func (f *fakeMessaging) Send(ctx context.Context, m *messaging.Message) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.sent = append(f.sent, m)
+	return "projects/p/messages/1", nil
+}
+
+func (f *fakeMessaging) SendEachForMulticast(ctx context.Context, m *messaging.MulticastMessage) (*messaging.BatchResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.multicasts = append(f.multicasts, m)
+	resp := &messaging.BatchResponse{}
+	for _, token := range m.Tokens {
+		if f.unregistered[token] {
+			resp.FailureCount++
+			resp.Responses = append(resp.Responses, &messaging.SendResponse{Error: errUnregistered})
+			continue
+		}
+		resp.SuccessCount++
+		resp.Responses = append(resp.Responses, &messaging.SendResponse{Success: true})
+	}
+	return resp, nil
+}
+
+func (f *fakeMessaging) SubscribeToTopic(ctx context.Context, tokens []string, topic string) (*messaging.TopicManagementResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.subscribed = append(f.subscribed, topic+" "+strings.Join(tokens, ","))
+	return f.topicResponse(), nil
+}
+
+func (f *fakeMessaging) UnsubscribeFromTopic(ctx context.Context, tokens []string, topic string) (*messaging.TopicManagementResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.unsubscribed = append(f.unsubscribed, topic+" "+strings.Join(tokens, ","))
+	return f.topicResponse(), nil
+}
+
+func (f *fakeMessaging) topicResponse() *messaging.TopicManagementResponse {
+	if f.reject {
+		return &messaging.TopicManagementResponse{FailureCount: 1, Errors: []*messaging.ErrorInfo{{Reason: "invalid-argument"}}}
+	}
+	return &messaging.TopicManagementResponse{SuccessCount: 1}
+}
+
+type testEvent struct {
+	eventType source.EventType
+	severity  int
+	areas     []string
+}
+
+func (testEvent) GetID() string                { return "e1" }
+func (e testEvent) GetType() source.EventType  { return e.eventType }
+func (testEvent) GetSource() string            { return "p2pquake" }
+func (e testEvent) GetSeverity() int           { return e.severity }
+func (e testEvent) GetAffectedAreas() []string { return e.areas }
+func (testEvent) GetOccurredAt() time.Time     { return time.Date(2024, 1, 1, 7, 10, 0, 0, time.UTC) }
+func (testEvent) GetReceivedAt() time.Time     { return time.Time{} }
+func (testEvent) GetRawJSON() string           { return `{}` }
+
+func TestValidateAlerts(t *testing.T) {
+	if err := ValidateAlerts([]string{"東京都", "石川県"}, 45); err != nil {
+		t.Errorf("ValidateAlerts(valid) error = %v", err)
+	}
+	if err := ValidateAlerts(nil, 0); err != nil {
+		t.Errorf("ValidateAlerts(none) error = %v", err)
+	}
+	if err := ValidateAlerts([]string{"東京", "Ishikawa"}, 0); err != nil {
+		t.Errorf("ValidateAlerts(short and romaji names) error = %v", err)
+	}
+	if err := ValidateAlerts([]string{"関東"}, 0); err == nil {
+		t.Error("expected a region to be rejected")
+	}
+	if err := ValidateAlerts([]string{"東京都"}, 35); err == nil {
+		t.Error("expected a non-JMA scale to be rejected")
+	}
+}
+
+func TestDeviceTopics(t *testing.T) {
+	got := DeviceTopics(user.Device{Prefectures: []string{"北海道", "石川県"}})
+	want := []string{"pref-01-scale-30", "pref-17-scale-30"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DeviceTopics(default scale) = %v, want %v", got, want)
+	}
+	if got := DeviceTopics(user.Device{Prefectures: []string{"沖縄県"}, MinScale: 50}); !reflect.DeepEqual(got, []string{"pref-47-scale-50"}) {
+		t.Errorf("DeviceTopics(min scale) = %v", got)
+	}
+	if got := DeviceTopics(user.Device{}); got != nil {
+		t.Errorf("DeviceTopics(no prefectures) = %v, want nil", got)
+	}
+}
+
+func TestEventConditions(t *testing.T) {
+	// 震度5弱 reaches five thresholds in each of two prefectures
+	conditions := eventConditions(testEvent{
+		eventType: source.EventTypeEarthquake,
+		severity:  50,
+		areas:     []string{"石川県", "富山県", "石川県", "不明"},
+	})
+	if len(conditions) != 2 {
+		t.Fatalf("expected 2 conditions of 5 topics, got %v", conditions)
+	}
+	want := "'pref-17-scale-10' in topics || 'pref-17-scale-20' in topics || 'pref-17-scale-30' in topics || " +
+		"'pref-17-scale-40' in topics || 'pref-17-scale-45' in topics"
+	if conditions[0] != want {
+		t.Errorf("conditions[0] = %q, want %q", conditions[0], want)
+	}
+	if !strings.Contains(conditions[1], "'pref-16-scale-45' in topics") || strings.Contains(conditions[1], "scale-50") {
+		t.Errorf("conditions[1] = %q, want Toyama up to 震度5弱", conditions[1])
+	}
+
+	if got := eventConditions(testEvent{eventType: source.EventTypeEEW, severity: 50, areas: []string{"石川県"}}); got != nil {
+		t.Errorf("expected early warnings not to be sent to topics, got %v", got)
+	}
+	if got := eventConditions(testEvent{eventType: source.EventTypeEarthquake, areas: []string{"石川県"}}); got != nil {
+		t.Errorf("expected events without a scale not to be sent to topics, got %v", got)
+	}
+}
+
+func TestTopics_Sync(t *testing.T) {
+	ctx := context.Background()
+	client := &fakeMessaging{}
+	topics := NewTopics(client)
+
+	previous := &user.Device{Token: "tok", Prefectures: []string{"石川県", "富山県"}}
+	current := &user.Device{Token: "tok", Prefectures: []string{"石川県", "福井県"}}
+	if err := topics.Sync(ctx, previous, current); err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	if !reflect.DeepEqual(client.subscribed, []string{"pref-18-scale-30 tok"}) {
+		t.Errorf("subscribed = %v, want only Fukui", client.subscribed)
+	}
+	if !reflect.DeepEqual(client.unsubscribed, []string{"pref-16-scale-30 tok"}) {
+		t.Errorf("unsubscribed = %v, want only Toyama", client.unsubscribed)
+	}
+
+	client.reject = true
+	if err := topics.Sync(ctx, nil, &user.Device{Token: "bad", Prefectures: []string{"東京都"}}); !errors.Is(err, ErrTokenRejected) {
+		t.Errorf("Sync(rejected) error = %v, want ErrTokenRejected", err)
+	}
+	if err := topics.Sync(ctx, current, nil); !errors.Is(err, ErrTokenRejected) {
+		t.Errorf("Sync(removed) error = %v, want an unsubscribe attempt", err)
+	}
+}
+
+func TestDispatcher_Dispatch(t *testing.T) {
+	ctx := context.Background()
+	users := user.NewMemoryRepository()
+	id, err := users.Create(ctx, user.User{UID: "uid-1", Devices: []user.Device{
+		{Token: "phone", Platform: user.PlatformAndroid},
+		{Token: "old-tablet", Platform: user.PlatformIOS},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := &fakeMessaging{unregistered: map[string]bool{"old-tablet": true}}
+	d := NewDispatcher(client, users)
+	d.unregistered = func(err error) bool { return errors.Is(err, errUnregistered) }
+
+	event := testEvent{eventType: source.EventTypeEarthquake, severity: 50, areas: []string{"石川県"}}
+	d.Dispatch(ctx, delivery.Message{ID: "e1", Payload: []byte(`{}`), Event: event}, []subscription.Subscription{
+		{Name: "a", UserID: "uid-1"},
+		{Name: "b", UserID: "uid-1"},
+		{Name: "ownerless"},
+	})
+
+	if len(client.multicasts) != 1 {
+		t.Fatalf("expected one multicast per owner, got %d", len(client.multicasts))
+	}
+	m := client.multicasts[0]
+	if !reflect.DeepEqual(m.Tokens, []string{"phone", "old-tablet"}) {
+		t.Errorf("Tokens = %v", m.Tokens)
+	}
+	if m.Notification.Title != "震度5弱" || m.Android.CollapseKey != "namazu-e1" || m.Data["event_id"] != "e1" {
+		t.Errorf("unexpected message %+v", m)
+	}
+	if len(client.sent) != 1 || !strings.Contains(client.sent[0].Condition, "'pref-17-scale-45' in topics") {
+		t.Errorf("expected one prefecture topic message, got %+v", client.sent)
+	}
+	if u, _ := users.Get(ctx, id); len(u.Devices) != 1 || u.Devices[0].Token != "phone" {
+		t.Errorf("Devices = %+v, want the unregistered token removed", u.Devices)
+	}
+
+	client.sent, client.multicasts = nil, nil
+	d.Dispatch(ctx, delivery.Message{ID: "n1", Payload: []byte(`{}`)}, []subscription.Subscription{{Name: "a", UserID: "uid-1"}})
+	if len(client.sent)+len(client.multicasts) != 0 {
+		t.Error("expected messages without an event not to be pushed")
+	}
+}
@@ -0,0 +1,79 @@
+package fcm
+
+import (
+	"strconv"
+	"time"
+
+	"firebase.google.com/go/v4/messaging"
+
+	"github.com/otiai10/namazu/backend/internal/delivery/webpush"
+)
+
+// TTL is how long FCM keeps a message for an offline device.
+// An earthquake alert that arrives much later is noise.
+const TTL = time.Hour
+
+// newMessage builds a message showing the same notification as Web Push.
+// The caller sets its Token, Topic or Condition. Notifications for the same
+// event replace each other, so a device that receives it twice (through a
+// subscription and a prefecture alert) shows it once.
+func newMessage(n webpush.Notification) *messaging.Message {
+	ttl := TTL
+	return &messaging.Message{
+		Notification: &messaging.Notification{
+			Title: n.Title,
+			Body:  n.Body,
+		},
+		Data: notificationData(n),
+		Android: &messaging.AndroidConfig{
+			CollapseKey: n.Tag,
+			Priority:    "high",
+			TTL:         &ttl,
+			Notification: &messaging.AndroidNotification{
+				Tag: n.Tag,
+			},
+		},
+		APNS: &messaging.APNSConfig{
+			Headers: map[string]string{
+				"apns-collapse-id": n.Tag,
+				"apns-priority":    "10",
+				"apns-expiration":  strconv.FormatInt(time.Now().Add(TTL).Unix(), 10),
+			},
+		},
+		Webpush: &messaging.WebpushConfig{
+			Headers: map[string]string{
+				"TTL":     strconv.Itoa(int(TTL.Seconds())),
+				"Urgency": "high",
+			},
+			Notification: &messaging.WebpushNotification{
+				Tag: n.Tag,
+			},
+		},
+	}
+}
+
+// newMulticast builds the message of a notification for a set of device tokens
+func newMulticast(n webpush.Notification, tokens []string) *messaging.MulticastMessage {
+	m := newMessage(n)
+	return &messaging.MulticastMessage{
+		Tokens:       tokens,
+		Notification: m.Notification,
+		Data:         m.Data,
+		Android:      m.Android,
+		APNS:         m.APNS,
+		Webpush:      m.Webpush,
+	}
+}
+
+// notificationData is the data payload apps read when the notification is opened
+func notificationData(n webpush.Notification) map[string]string {
+	data := map[string]string{
+		"event_id": n.EventID,
+		"type":     n.Type,
+		"url":      n.URL,
+	}
+	if n.Scale > 0 {
+		data["scale"] = strconv.Itoa(n.Scale)
+	}
+	return data
+}
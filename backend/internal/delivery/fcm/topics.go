@@ -0,0 +1,112 @@
+package fcm
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/otiai10/namazu/backend/internal/region"
+	"github.com/otiai10/namazu/backend/internal/source"
+	"github.com/otiai10/namazu/backend/internal/source/p2pquake"
+	"github.com/otiai10/namazu/backend/internal/user"
+)
+
+// DefaultMinScale applies to the prefecture alerts of devices without a MinScale
+const DefaultMinScale = p2pquake.Scale3
+
+// maxConditionTopics is the number of topics FCM allows in one condition
+const maxConditionTopics = 5
+
+// scales are the thresholds a device can choose as MinScale
+var scales = []int{
+	p2pquake.Scale1, p2pquake.Scale2, p2pquake.Scale3, p2pquake.Scale4,
+	p2pquake.Scale5Weak, p2pquake.Scale5Strong, p2pquake.Scale6Weak, p2pquake.Scale6Strong, p2pquake.Scale7,
+}
+
+// prefectureCode returns the JIS code (1-47) of a prefecture, or 0 if unknown.
+// Topic names must be ASCII, so topics use the code instead of the name.
+func prefectureCode(name string) int {
+	return region.Code(name)
+}
+
+// ValidateAlerts checks the prefecture alert settings of a device.
+// Prefectures are names such as "東京都" or "Tokyo"; minScale 0 means DefaultMinScale.
+func ValidateAlerts(prefs []string, minScale int) error {
+	if len(prefs) > region.Count {
+		return fmt.Errorf("at most %d prefectures", region.Count)
+	}
+	for _, pref := range prefs {
+		if prefectureCode(pref) == 0 {
+			return fmt.Errorf("unknown prefecture: %s", pref)
+		}
+	}
+	if minScale == 0 {
+		return nil
+	}
+	for _, scale := range scales {
+		if minScale == scale {
+			return nil
+		}
+	}
+	return fmt.Errorf("min_scale must be a JMA scale (10, 20, 30, 40, 45, 50, 55, 60 or 70)")
+}
+
+// topic names the topic of alerts for a prefecture at or above scale, e.g. "pref-17-scale-40"
+func topic(code, scale int) string {
+	return fmt.Sprintf("pref-%02d-scale-%d", code, scale)
+}
+
+// DeviceTopics returns the topics a device is subscribed to for its prefecture
+// alerts: one per prefecture, at the device's MinScale.
+func DeviceTopics(d user.Device) []string {
+	minScale := d.MinScale
+	if minScale == 0 {
+		minScale = DefaultMinScale
+	}
+	var topics []string
+	for _, pref := range d.Prefectures {
+		if code := prefectureCode(pref); code > 0 {
+			topics = append(topics, topic(code, minScale))
+		}
+	}
+	return topics
+}
+
+// eventConditions returns the FCM conditions that reach every device with a
+// prefecture alert matching event. A device subscribes to one threshold per
+// prefecture, so for each affected prefecture the event goes to the topics of
+// every threshold it reaches. FCM limits a condition to five topics, so large
+// events need several messages; devices in topics of different messages get
+// one notification per message, collapsed by the notification tag.
+// Only earthquake reports with a known scale are sent to topics.
+func eventConditions(event source.Event) []string {
+	if event.GetType() != source.EventTypeEarthquake {
+		return nil
+	}
+	eventScale := p2pquake.SeverityToScale(event.GetSeverity())
+
+	var topics []string
+	seen := make(map[int]bool)
+	for _, area := range event.GetAffectedAreas() {
+		code := prefectureCode(area)
+		if code == 0 || seen[code] {
+			continue
+		}
+		seen[code] = true
+		for _, scale := range scales {
+			if scale <= eventScale {
+				topics = append(topics, topic(code, scale))
+			}
+		}
+	}
+
+	var conditions []string
+	for start := 0; start < len(topics); start += maxConditionTopics {
+		end := min(start+maxConditionTopics, len(topics))
+		terms := make([]string, 0, end-start)
+		for _, t := range topics[start:end] {
+			terms = append(terms, "'"+t+"' in topics")
+		}
+		conditions = append(conditions, strings.Join(terms, " || "))
+	}
+	return conditions
+}
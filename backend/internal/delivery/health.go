@@ -0,0 +1,127 @@
+package delivery
+
+import (
+	"sync"
+	"time"
+)
+
+// DefaultHealthWindow is the number of recent deliveries considered per subscription
+const DefaultHealthWindow = 20
+
+// Health states reported by HealthTracker
+const (
+	HealthUnknown     = "unknown"     // No deliveries recorded yet
+	HealthOperational = "operational" // At least 95% of recent deliveries succeeded
+	HealthDegraded    = "degraded"    // At least half of recent deliveries succeeded
+	HealthFailing     = "failing"     // Most recent deliveries failed
+)
+
+// HealthStatus summarizes recent delivery outcomes of a subscription
+type HealthStatus struct {
+	State          string     `json:"state"`
+	SuccessRate    float64    `json:"success_rate"`
+	Deliveries     int        `json:"deliveries"`
+	LastDeliveryAt *time.Time `json:"last_delivery_at,omitempty"`
+	LastSuccess    bool       `json:"last_success"`
+}
+
+// healthWindow is a fixed-size ring buffer of delivery outcomes
+type healthWindow struct {
+	outcomes []bool
+	next     int
+	full     bool
+	lastAt   time.Time
+	lastOK   bool
+}
+
+// HealthTracker keeps the outcomes of recent deliveries per subscription in memory.
+// History starts empty on every process start. It is safe for concurrent use.
+type HealthTracker struct {
+	mu      sync.RWMutex
+	size    int
+	windows map[string]*healthWindow
+}
+
+// NewHealthTracker creates a tracker that keeps the last size outcomes per subscription.
+// A non-positive size uses DefaultHealthWindow.
+func NewHealthTracker(size int) *HealthTracker {
+	if size <= 0 {
+		size = DefaultHealthWindow
+	}
+	return &HealthTracker{
+		size:    size,
+		windows: make(map[string]*healthWindow),
+	}
+}
+
+// Record adds a delivery outcome for a subscription
+func (t *HealthTracker) Record(subscriptionID string, success bool, at time.Time) {
+	if subscriptionID == "" {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	w, ok := t.windows[subscriptionID]
+	if !ok {
+		w = &healthWindow{outcomes: make([]bool, t.size)}
+		t.windows[subscriptionID] = w
+	}
+	w.outcomes[w.next] = success
+	w.next = (w.next + 1) % t.size
+	if w.next == 0 {
+		w.full = true
+	}
+	w.lastAt = at
+	w.lastOK = success
+}
+
+// Status returns the health of a subscription based on its recent deliveries
+func (t *HealthTracker) Status(subscriptionID string) HealthStatus {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	w, ok := t.windows[subscriptionID]
+	if !ok {
+		return HealthStatus{State: HealthUnknown}
+	}
+
+	count := w.next
+	if w.full {
+		count = t.size
+	}
+
+	succeeded := 0
+	for i := range count {
+		if w.outcomes[i] {
+			succeeded++
+		}
+	}
+
+	rate := float64(succeeded) / float64(count)
+	lastAt := w.lastAt
+	status := HealthStatus{
+		SuccessRate:    rate,
+		Deliveries:     count,
+		LastDeliveryAt: &lastAt,
+		LastSuccess:    w.lastOK,
+	}
+
+	switch {
+	case rate >= 0.95:
+		status.State = HealthOperational
+	case rate >= 0.5:
+		status.State = HealthDegraded
+	default:
+		status.State = HealthFailing
+	}
+	return status
+}
+
+// Forget drops the history of a subscription (e.g., after deletion)
+func (t *HealthTracker) Forget(subscriptionID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.windows, subscriptionID)
+}
@@ -0,0 +1,109 @@
+package delivery
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestHealthTracker_Unknown(t *testing.T) {
+	tracker := NewHealthTracker(0)
+
+	status := tracker.Status("sub-1")
+	if status.State != HealthUnknown {
+		t.Errorf("State = %q, want %q", status.State, HealthUnknown)
+	}
+	if status.LastDeliveryAt != nil {
+		t.Error("expected no last delivery time")
+	}
+}
+
+func TestHealthTracker_States(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name     string
+		outcomes []bool
+		want     string
+	}{
+		{name: "all success", outcomes: []bool{true, true, true}, want: HealthOperational},
+		{name: "some failures", outcomes: []bool{true, false, true, true}, want: HealthDegraded},
+		{name: "mostly failing", outcomes: []bool{false, false, true}, want: HealthFailing},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracker := NewHealthTracker(10)
+			for _, ok := range tt.outcomes {
+				tracker.Record("sub-1", ok, now)
+			}
+
+			status := tracker.Status("sub-1")
+			if status.State != tt.want {
+				t.Errorf("State = %q, want %q", status.State, tt.want)
+			}
+			if status.Deliveries != len(tt.outcomes) {
+				t.Errorf("Deliveries = %d, want %d", status.Deliveries, len(tt.outcomes))
+			}
+		})
+	}
+}
+
+func TestHealthTracker_WindowEvictsOldOutcomes(t *testing.T) {
+	tracker := NewHealthTracker(3)
+	now := time.Now()
+
+	tracker.Record("sub-1", false, now)
+	tracker.Record("sub-1", false, now)
+	for range 3 {
+		tracker.Record("sub-1", true, now)
+	}
+
+	status := tracker.Status("sub-1")
+	if status.State != HealthOperational {
+		t.Errorf("State = %q, want %q", status.State, HealthOperational)
+	}
+	if status.Deliveries != 3 {
+		t.Errorf("Deliveries = %d, want 3", status.Deliveries)
+	}
+	if !status.LastSuccess {
+		t.Error("expected last delivery to be successful")
+	}
+}
+
+func TestHealthTracker_IgnoresEmptyID(t *testing.T) {
+	tracker := NewHealthTracker(3)
+	tracker.Record("", true, time.Now())
+
+	if status := tracker.Status(""); status.State != HealthUnknown {
+		t.Errorf("State = %q, want %q", status.State, HealthUnknown)
+	}
+}
+
+func TestHealthTracker_Forget(t *testing.T) {
+	tracker := NewHealthTracker(3)
+	tracker.Record("sub-1", true, time.Now())
+	tracker.Forget("sub-1")
+
+	if status := tracker.Status("sub-1"); status.State != HealthUnknown {
+		t.Errorf("State = %q, want %q", status.State, HealthUnknown)
+	}
+}
+
+func TestHealthTracker_Concurrent(t *testing.T) {
+	tracker := NewHealthTracker(5)
+	var wg sync.WaitGroup
+	for i := range 50 {
+		wg.Add(1)
+		go func(ok bool) {
+			defer wg.Done()
+			tracker.Record("sub-1", ok, time.Now())
+			_ = tracker.Status("sub-1")
+		}(i%2 == 0)
+	}
+	wg.Wait()
+
+	if status := tracker.Status("sub-1"); status.Deliveries != 5 {
+		t.Errorf("Deliveries = %d, want 5", status.Deliveries)
+	}
+}
@@ -0,0 +1,215 @@
+package delivery
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Defaults of the delivery queue
+const (
+	DefaultQueueWorkers = 16
+	DefaultQueueSize    = 1024
+)
+
+// ErrDraining is returned by Enqueue once Drain has been called
+var ErrDraining = errors.New("delivery queue is draining")
+
+// Job is one unit of delivery work, typically one message to one subscription
+type Job func(ctx context.Context)
+
+// QueueStats is a snapshot of the queue for monitoring backpressure
+type QueueStats struct {
+	Workers   int   `json:"workers"`
+	Capacity  int   `json:"capacity"`   // Jobs that can wait before Enqueue blocks
+	Depth     int   `json:"depth"`      // Jobs waiting for a worker
+	Busy      int64 `json:"busy"`       // Workers running a job
+	Enqueued  int64 `json:"enqueued"`   // Jobs accepted since start
+	Completed int64 `json:"completed"`  // Jobs finished since start
+	Dropped   int64 `json:"dropped"`    // Jobs abandoned because the queue was stopped
+	Blocked   int64 `json:"blocked"`    // Enqueue calls that had to wait for space
+	BlockedMs int64 `json:"blocked_ms"` // Total time spent waiting for space
+	Draining  bool  `json:"draining"`   // No longer accepting jobs, see Drain
+}
+
+// Queue runs delivery jobs on a fixed pool of workers.
+// Jobs wait in a bounded buffer; when it is full, Enqueue blocks, so a burst
+// of events slows down the producer instead of growing memory without bound.
+// Jobs run concurrently and in no particular order.
+//
+// Queue is safe for concurrent use by multiple goroutines.
+type Queue struct {
+	jobs    chan Job
+	workers int
+	wg      sync.WaitGroup
+	once    sync.Once
+
+	draining  chan struct{} // closed by Drain
+	drainOnce sync.Once
+
+	busy      int64
+	enqueued  int64
+	completed int64
+	dropped   int64
+	blocked   int64
+	blockedNs int64
+}
+
+// NewQueue creates a queue with the given number of workers and buffer size.
+// Non-positive values use DefaultQueueWorkers and DefaultQueueSize.
+func NewQueue(workers, size int) *Queue {
+	if workers <= 0 {
+		workers = DefaultQueueWorkers
+	}
+	if size <= 0 {
+		size = DefaultQueueSize
+	}
+	return &Queue{
+		jobs:     make(chan Job, size),
+		workers:  workers,
+		draining: make(chan struct{}),
+	}
+}
+
+// Start launches the workers. They run until ctx is cancelled; jobs still
+// waiting at that point are dropped. Start is a no-op after the first call.
+func (q *Queue) Start(ctx context.Context) {
+	q.once.Do(func() {
+		for i := 0; i < q.workers; i++ {
+			q.wg.Add(1)
+			go q.work(ctx)
+		}
+	})
+}
+
+// Wait blocks until all workers have exited after ctx of Start was cancelled
+func (q *Queue) Wait() {
+	q.wg.Wait()
+}
+
+// Drain stops accepting jobs and lets the workers finish the jobs already
+// queued, then exit. It returns nil once they have, or ctx.Err() if ctx is
+// done first, in which case the workers go on until the ctx of Start is
+// cancelled. A job enqueued while Drain is called may be left in the buffer.
+func (q *Queue) Drain(ctx context.Context) error {
+	q.drainOnce.Do(func() { close(q.draining) })
+
+	done := make(chan struct{})
+	go func() {
+		q.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Enqueue adds a job, blocking while the queue is full.
+// It returns ctx.Err() if ctx is cancelled before there is space, and
+// ErrDraining once the queue is draining.
+func (q *Queue) Enqueue(ctx context.Context, job Job) error {
+	select {
+	case <-q.draining:
+		return ErrDraining
+	default:
+	}
+	select {
+	case q.jobs <- job:
+		atomic.AddInt64(&q.enqueued, 1)
+		return nil
+	default:
+	}
+
+	// Full: record the backpressure and wait for a worker to take a job
+	atomic.AddInt64(&q.blocked, 1)
+	start := time.Now()
+	defer func() { atomic.AddInt64(&q.blockedNs, int64(time.Since(start))) }()
+
+	select {
+	case q.jobs <- job:
+		atomic.AddInt64(&q.enqueued, 1)
+		return nil
+	case <-q.draining:
+		return ErrDraining
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Stats returns the current queue statistics
+func (q *Queue) Stats() QueueStats {
+	return QueueStats{
+		Workers:   q.workers,
+		Capacity:  cap(q.jobs),
+		Depth:     len(q.jobs),
+		Busy:      atomic.LoadInt64(&q.busy),
+		Enqueued:  atomic.LoadInt64(&q.enqueued),
+		Completed: atomic.LoadInt64(&q.completed),
+		Dropped:   atomic.LoadInt64(&q.dropped),
+		Blocked:   atomic.LoadInt64(&q.blocked),
+		BlockedMs: time.Duration(atomic.LoadInt64(&q.blockedNs)).Milliseconds(),
+		Draining:  q.isDraining(),
+	}
+}
+
+// isDraining reports whether Drain has been called
+func (q *Queue) isDraining() bool {
+	select {
+	case <-q.draining:
+		return true
+	default:
+		return false
+	}
+}
+
+// work runs jobs until ctx is cancelled, or until the queue is drained
+func (q *Queue) work(ctx context.Context) {
+	defer q.wg.Done()
+	for {
+		// Prefer stopping over taking another job once cancelled
+		if ctx.Err() != nil {
+			q.drop()
+			return
+		}
+		select {
+		case <-ctx.Done():
+			q.drop()
+			return
+		case job := <-q.jobs:
+			q.run(ctx, job)
+		case <-q.draining:
+			// Finish what is buffered, then stop
+			select {
+			case job := <-q.jobs:
+				q.run(ctx, job)
+			default:
+				return
+			}
+		}
+	}
+}
+
+// run runs a job, counting it as busy while it runs
+func (q *Queue) run(ctx context.Context, job Job) {
+	atomic.AddInt64(&q.busy, 1)
+	job(ctx)
+	atomic.AddInt64(&q.busy, -1)
+	atomic.AddInt64(&q.completed, 1)
+}
+
+// drop discards the jobs left in the buffer
+func (q *Queue) drop() {
+	for {
+		select {
+		case <-q.jobs:
+			atomic.AddInt64(&q.dropped, 1)
+		default:
+			return
+		}
+	}
+}
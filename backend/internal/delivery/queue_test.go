@@ -0,0 +1,216 @@
+package delivery
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestNewQueue_Defaults(t *testing.T) {
+	stats := NewQueue(0, -1).Stats()
+	if stats.Workers != DefaultQueueWorkers || stats.Capacity != DefaultQueueSize {
+		t.Errorf("Stats() = %+v, want %d workers and capacity %d", stats, DefaultQueueWorkers, DefaultQueueSize)
+	}
+}
+
+func TestQueue_RunsJobsConcurrently(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	q := NewQueue(3, 10)
+	q.Start(ctx)
+
+	var running, peak int64
+	var wg sync.WaitGroup
+	release := make(chan struct{})
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		err := q.Enqueue(ctx, func(ctx context.Context) {
+			defer wg.Done()
+			n := atomic.AddInt64(&running, 1)
+			for {
+				p := atomic.LoadInt64(&peak)
+				if n <= p || atomic.CompareAndSwapInt64(&peak, p, n) {
+					break
+				}
+			}
+			<-release
+			atomic.AddInt64(&running, -1)
+		})
+		if err != nil {
+			t.Fatalf("Enqueue() error = %v", err)
+		}
+	}
+
+	// A slow job must not hold up the others
+	deadline := time.After(time.Second)
+	for atomic.LoadInt64(&peak) < 3 {
+		select {
+		case <-deadline:
+			t.Fatalf("peak concurrency = %d, want 3", atomic.LoadInt64(&peak))
+		case <-time.After(time.Millisecond):
+		}
+	}
+	if busy := q.Stats().Busy; busy != 3 {
+		t.Errorf("Busy = %d, want 3", busy)
+	}
+	close(release)
+	wg.Wait()
+
+	cancel()
+	q.Wait()
+	if stats := q.Stats(); stats.Enqueued != 3 || stats.Completed != 3 || stats.Busy != 0 {
+		t.Errorf("Stats() = %+v, want 3 enqueued and completed", stats)
+	}
+}
+
+func TestQueue_Backpressure(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	q := NewQueue(1, 1)
+	q.Start(ctx)
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	block := func(ctx context.Context) {
+		select {
+		case started <- struct{}{}:
+		default:
+		}
+		<-release
+	}
+
+	// One job occupies the worker, one fills the buffer
+	if err := q.Enqueue(ctx, block); err != nil {
+		t.Fatal(err)
+	}
+	<-started
+	if err := q.Enqueue(ctx, block); err != nil {
+		t.Fatal(err)
+	}
+	if depth := q.Stats().Depth; depth != 1 {
+		t.Errorf("Depth = %d, want 1", depth)
+	}
+
+	// The next one has to wait
+	enqueued := make(chan error)
+	go func() { enqueued <- q.Enqueue(ctx, func(ctx context.Context) {}) }()
+	select {
+	case err := <-enqueued:
+		t.Fatalf("Enqueue() returned %v while the queue was full", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	close(release)
+	if err := <-enqueued; err != nil {
+		t.Errorf("Enqueue() error = %v", err)
+	}
+	if stats := q.Stats(); stats.Blocked != 1 || stats.BlockedMs < 10 {
+		t.Errorf("Stats() = %+v, want one blocked enqueue of at least 10ms", stats)
+	}
+}
+
+func TestQueue_EnqueueCancelled(t *testing.T) {
+	q := NewQueue(1, 1) // Never started, so the buffer stays full
+	if err := q.Enqueue(context.Background(), func(ctx context.Context) {}); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := q.Enqueue(ctx, func(ctx context.Context) {}); err != context.Canceled {
+		t.Errorf("Enqueue() error = %v, want context.Canceled", err)
+	}
+}
+
+func TestQueue_DropsPendingJobsOnStop(t *testing.T) {
+	q := NewQueue(1, 5)
+	for i := 0; i < 3; i++ {
+		if err := q.Enqueue(context.Background(), func(ctx context.Context) {
+			t.Error("job should not run after the queue was stopped")
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	q.Start(ctx)
+	q.Wait()
+
+	if stats := q.Stats(); stats.Dropped != 3 || stats.Depth != 0 {
+		t.Errorf("Stats() = %+v, want 3 dropped", stats)
+	}
+}
+
+func TestQueue_DrainFinishesQueuedJobs(t *testing.T) {
+	q := NewQueue(2, 10)
+	var ran int32
+	for i := 0; i < 6; i++ {
+		if err := q.Enqueue(context.Background(), func(ctx context.Context) {
+			time.Sleep(10 * time.Millisecond)
+			if ctx.Err() == nil {
+				atomic.AddInt32(&ran, 1)
+			}
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	q.Start(ctx)
+
+	drainCtx, drainCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer drainCancel()
+	if err := q.Drain(drainCtx); err != nil {
+		t.Fatalf("Drain() error = %v", err)
+	}
+	if ran != 6 {
+		t.Errorf("%d jobs ran, want 6", ran)
+	}
+	if err := q.Enqueue(context.Background(), func(ctx context.Context) {}); err != ErrDraining {
+		t.Errorf("Enqueue() after Drain error = %v, want ErrDraining", err)
+	}
+	if stats := q.Stats(); !stats.Draining || stats.Completed != 6 || stats.Dropped != 0 {
+		t.Errorf("Stats() = %+v, want draining with 6 completed", stats)
+	}
+}
+
+func TestQueue_DrainTimeout(t *testing.T) {
+	q := NewQueue(1, 5)
+	ctx, cancel := context.WithCancel(context.Background())
+	q.Start(ctx)
+
+	release := make(chan struct{})
+	if err := q.Enqueue(context.Background(), func(ctx context.Context) {
+		select {
+		case <-release:
+		case <-ctx.Done():
+		}
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.Enqueue(context.Background(), func(ctx context.Context) {
+		t.Error("job should not run after the drain timed out")
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	drainCtx, drainCancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer drainCancel()
+	if err := q.Drain(drainCtx); err != context.DeadlineExceeded {
+		t.Fatalf("Drain() error = %v, want context.DeadlineExceeded", err)
+	}
+
+	// The caller then cancels what is left
+	cancel()
+	q.Wait()
+	close(release)
+	if stats := q.Stats(); stats.Completed != 1 || stats.Dropped != 1 {
+		t.Errorf("Stats() = %+v, want 1 completed and 1 dropped", stats)
+	}
+}
@@ -0,0 +1,101 @@
+// Package schema defines namazu's own versioned event payloads. Webhook
+// bodies are the source's JSON as received by default, which ties receivers
+// to a third-party format; subscriptions that set delivery.format to a
+// namazu version get the same shape whichever source reported the event.
+//
+// A version's fields are never renamed or removed and never change meaning.
+// Fields may be added; receivers must ignore fields they do not know.
+//
+// The JSON Schemas in schemas/ describe every payload namazu generates
+// itself (this format, service notices and digests). They are published
+// for integrators to generate types from, and Validate checks payloads
+// against them.
+package schema
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/otiai10/namazu/backend/internal/source"
+	"github.com/otiai10/namazu/backend/internal/source/p2pquake"
+)
+
+// Payload formats of delivery.format
+const (
+	FormatRaw = "raw"       // The source's JSON as received (the default)
+	FormatV1  = "namazu.v1" // EventV1
+)
+
+// Version1 is the schema_version of EventV1 payloads
+const Version1 = 1
+
+// Formats lists the supported payload formats
+var Formats = []string{FormatRaw, FormatV1}
+
+// ValidFormat reports whether format is supported. Empty means FormatRaw.
+func ValidFormat(format string) bool {
+	return format == "" || format == FormatRaw || format == FormatV1
+}
+
+// EventV1 is an event in the namazu.v1 format
+type EventV1 struct {
+	SchemaVersion int           `json:"schema_version"` // Always 1
+	ID            string        `json:"id"`             // The source's ID of the report
+	Type          string        `json:"type"`           // "earthquake" | "tsunami" | "eew"
+	Source        string        `json:"source"`         // "p2pquake" | "jma"
+	OccurredAt    time.Time     `json:"occurred_at"`    // UTC
+	ReceivedAt    time.Time     `json:"received_at"`    // UTC
+	Severity      int           `json:"severity"`       // 0-100
+	MaxScale      int           `json:"max_scale"`      // JMA scale code (10-70), 0 if unknown
+	Prefectures   []string      `json:"prefectures"`    // Affected prefectures, e.g. "石川県"
+	Hypocenter    *HypocenterV1 `json:"hypocenter"`     // null if not reported
+	Backfilled    bool          `json:"backfilled"`     // Recovered after being missed live
+}
+
+// HypocenterV1 is the hypocenter of an EventV1
+type HypocenterV1 struct {
+	Name      string   `json:"name"` // e.g. "石川県能登地方"; empty if unknown
+	Latitude  float64  `json:"latitude"`
+	Longitude float64  `json:"longitude"`
+	DepthKm   *int     `json:"depth_km"`  // null if unknown
+	Magnitude *float64 `json:"magnitude"` // null if unknown
+}
+
+// NewEventV1 converts an event to the namazu.v1 format
+func NewEventV1(event source.Event) EventV1 {
+	v := EventV1{
+		SchemaVersion: Version1,
+		ID:            event.GetID(),
+		Type:          string(event.GetType()),
+		Source:        event.GetSource(),
+		OccurredAt:    event.GetOccurredAt().UTC(),
+		ReceivedAt:    event.GetReceivedAt().UTC(),
+		Severity:      event.GetSeverity(),
+		MaxScale:      p2pquake.SeverityToScale(event.GetSeverity()),
+		Prefectures:   append([]string{}, event.GetAffectedAreas()...),
+		Backfilled:    source.IsBackfilled(event),
+	}
+	if located, ok := event.(source.Located); ok {
+		if h := located.GetHypocenter(); h != nil {
+			v.Hypocenter = &HypocenterV1{Name: h.Name, Latitude: h.Latitude, Longitude: h.Longitude}
+			if h.Depth >= 0 {
+				depth := h.Depth
+				v.Hypocenter.DepthKm = &depth
+			}
+			if h.Magnitude >= 0 {
+				magnitude := h.Magnitude
+				v.Hypocenter.Magnitude = &magnitude
+			}
+		}
+	}
+	return v
+}
+
+// Render returns the body of event in format. Raw bodies, and bodies
+// without an event (service notices, digests), are returned unchanged.
+func Render(format string, event source.Event, payload []byte) ([]byte, error) {
+	if format != FormatV1 || event == nil {
+		return payload, nil
+	}
+	return json.Marshal(NewEventV1(event))
+}
@@ -0,0 +1,88 @@
+package schema
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/otiai10/namazu/backend/internal/source"
+)
+
+type testEvent struct {
+	hypocenter *source.Hypocenter
+}
+
+func (testEvent) GetID() string              { return "e1" }
+func (testEvent) GetType() source.EventType  { return source.EventTypeEarthquake }
+func (testEvent) GetSource() string          { return "p2pquake" }
+func (testEvent) GetSeverity() int           { return 50 }
+func (testEvent) GetAffectedAreas() []string { return []string{"石川県", "富山県"} }
+func (testEvent) GetOccurredAt() time.Time {
+	return time.Date(2024, 1, 1, 16, 10, 0, 0, time.FixedZone("JST", 9*60*60))
+}
+func (testEvent) GetReceivedAt() time.Time            { return time.Date(2024, 1, 1, 7, 11, 0, 0, time.UTC) }
+func (testEvent) GetRawJSON() string                  { return `{"code":551}` }
+func (e testEvent) GetHypocenter() *source.Hypocenter { return e.hypocenter }
+
+func TestNewEventV1(t *testing.T) {
+	v := NewEventV1(testEvent{hypocenter: &source.Hypocenter{Name: "石川県能登地方", Latitude: 37.5, Longitude: 137.27, Depth: 10, Magnitude: 7.6}})
+	if v.SchemaVersion != Version1 || v.ID != "e1" || v.Type != "earthquake" || v.Source != "p2pquake" || v.Severity != 50 || v.MaxScale != 45 {
+		t.Errorf("NewEventV1() = %+v", v)
+	}
+	if !v.OccurredAt.Equal(time.Date(2024, 1, 1, 7, 10, 0, 0, time.UTC)) || v.OccurredAt.Location() != time.UTC {
+		t.Errorf("OccurredAt = %v, want UTC", v.OccurredAt)
+	}
+	if len(v.Prefectures) != 2 || v.Hypocenter == nil || *v.Hypocenter.DepthKm != 10 || *v.Hypocenter.Magnitude != 7.6 {
+		t.Errorf("NewEventV1() = %+v", v)
+	}
+
+	// Unknown depth and magnitude are null rather than -1
+	v = NewEventV1(testEvent{hypocenter: &source.Hypocenter{Name: "不明", Depth: -1, Magnitude: -1}})
+	if v.Hypocenter.DepthKm != nil || v.Hypocenter.Magnitude != nil {
+		t.Errorf("hypocenter = %+v, want unknown values omitted", v.Hypocenter)
+	}
+}
+
+func TestRender(t *testing.T) {
+	raw := []byte(`{"code":551}`)
+	for _, format := range []string{"", FormatRaw} {
+		got, err := Render(format, testEvent{}, raw)
+		if err != nil || string(got) != string(raw) {
+			t.Errorf("Render(%q) = %s, %v, want the raw body", format, got, err)
+		}
+	}
+	if got, _ := Render(FormatV1, nil, raw); string(got) != string(raw) {
+		t.Errorf("Render(v1, no event) = %s, want the body unchanged", got)
+	}
+
+	got, err := Render(FormatV1, testEvent{}, raw)
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	var body map[string]any
+	if err := json.Unmarshal(got, &body); err != nil {
+		t.Fatalf("Render() is not JSON: %v", err)
+	}
+	if body["schema_version"] != float64(1) || body["occurred_at"] != "2024-01-01T07:10:00Z" {
+		t.Errorf("Render() = %s", got)
+	}
+	if h, ok := body["hypocenter"]; !ok || h != nil {
+		t.Errorf("hypocenter = %v, want null without a hypocenter", h)
+	}
+	if _, ok := body["code"]; ok {
+		t.Error("the source's fields must not leak into v1 bodies")
+	}
+}
+
+func TestValidFormat(t *testing.T) {
+	for _, format := range []string{"", "raw", "namazu.v1"} {
+		if !ValidFormat(format) {
+			t.Errorf("ValidFormat(%q) = false", format)
+		}
+	}
+	for _, format := range []string{"namazu.v2", "RAW", "v1"} {
+		if ValidFormat(format) {
+			t.Errorf("ValidFormat(%q) = true", format)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package schema
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"math"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Names of the published JSON Schemas of outgoing payloads.
+// GET /api/schemas/{name}.json serves them.
+const (
+	SchemaEvent  = FormatV1                // EventV1
+	SchemaNotice = "namazu.service_notice" // notice.Notice
+	SchemaDigest = "namazu.digest"         // app.Digest
+)
+
+//go:embed schemas/*.json
+var documents embed.FS
+
+// Names lists the published schemas, sorted
+func Names() []string {
+	entries, _ := documents.ReadDir("schemas")
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, strings.TrimSuffix(entry.Name(), ".json"))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Document returns the JSON Schema (draft 2020-12) of a payload, or false if
+// there is no schema with that name
+func Document(name string) ([]byte, bool) {
+	if name == "" || strings.ContainsAny(name, `/\`) {
+		return nil, false
+	}
+	doc, err := documents.ReadFile(path.Join("schemas", name+".json"))
+	if err != nil {
+		return nil, false
+	}
+	return doc, true
+}
+
+// Validate checks a payload against the named schema and returns the first
+// violation found. Only the keywords the published schemas use are
+// supported: type, const, enum, required, properties, items, minimum,
+// maximum and the date-time format.
+func Validate(name string, payload []byte) error {
+	doc, ok := Document(name)
+	if !ok {
+		return fmt.Errorf("unknown schema %q", name)
+	}
+	var s map[string]any
+	if err := json.Unmarshal(doc, &s); err != nil {
+		return fmt.Errorf("schema %s: %w", name, err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(payload))
+	dec.UseNumber()
+	var value any
+	if err := dec.Decode(&value); err != nil {
+		return fmt.Errorf("invalid JSON: %w", err)
+	}
+	return validate(s, value, "$")
+}
+
+// validate checks value, found at JSON path at, against the schema s
+func validate(s map[string]any, value any, at string) error {
+	if types, ok := s["type"]; ok && !matchesType(types, value) {
+		return fmt.Errorf("%s: must be of type %v", at, types)
+	}
+	if c, ok := s["const"]; ok && !equal(c, value) {
+		return fmt.Errorf("%s: must be %v", at, c)
+	}
+	if enum, ok := s["enum"].([]any); ok && !slices.ContainsFunc(enum, func(e any) bool { return equal(e, value) }) {
+		return fmt.Errorf("%s: must be one of %v", at, enum)
+	}
+	switch v := value.(type) {
+	case map[string]any:
+		if required, ok := s["required"].([]any); ok {
+			for _, key := range required {
+				if _, ok := v[key.(string)]; !ok {
+					return fmt.Errorf("%s: %s is required", at, key)
+				}
+			}
+		}
+		properties, _ := s["properties"].(map[string]any)
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if sub, ok := properties[key].(map[string]any); ok {
+				if err := validate(sub, v[key], at+"."+key); err != nil {
+					return err
+				}
+			}
+		}
+	case []any:
+		if items, ok := s["items"].(map[string]any); ok {
+			for i, item := range v {
+				if err := validate(items, item, fmt.Sprintf("%s[%d]", at, i)); err != nil {
+					return err
+				}
+			}
+		}
+	case json.Number:
+		n, _ := v.Float64()
+		if minimum, ok := s["minimum"].(float64); ok && n < minimum {
+			return fmt.Errorf("%s: must be at least %v", at, minimum)
+		}
+		if maximum, ok := s["maximum"].(float64); ok && n > maximum {
+			return fmt.Errorf("%s: must be at most %v", at, maximum)
+		}
+	case string:
+		if s["format"] == "date-time" {
+			if _, err := time.Parse(time.RFC3339Nano, v); err != nil {
+				return fmt.Errorf("%s: must be an RFC 3339 date-time", at)
+			}
+		}
+	}
+	return nil
+}
+
+// matchesType reports whether value is of the schema type, or one of the
+// schema types if types is a list
+func matchesType(types any, value any) bool {
+	if list, ok := types.([]any); ok {
+		return slices.ContainsFunc(list, func(t any) bool { return matchesType(t, value) })
+	}
+	switch types {
+	case "object":
+		_, ok := value.(map[string]any)
+		return ok
+	case "array":
+		_, ok := value.([]any)
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "null":
+		return value == nil
+	case "number":
+		_, ok := value.(json.Number)
+		return ok
+	case "integer":
+		n, ok := value.(json.Number)
+		if !ok {
+			return false
+		}
+		f, err := n.Float64()
+		return err == nil && f == math.Trunc(f)
+	}
+	return false
+}
+
+// equal compares a value of a schema with a payload value, whose numbers
+// are json.Number
+func equal(want, got any) bool {
+	if n, ok := got.(json.Number); ok {
+		f, err := n.Float64()
+		return err == nil && want == f
+	}
+	return want == got
+}
@@ -0,0 +1,98 @@
+package schema
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/otiai10/namazu/backend/internal/source"
+)
+
+func TestNames(t *testing.T) {
+	want := []string{SchemaDigest, SchemaNotice, SchemaEvent}
+	if got := Names(); !slices.Equal(got, want) {
+		t.Errorf("Names() = %v, want %v", got, want)
+	}
+	for _, name := range Names() {
+		doc, ok := Document(name)
+		if !ok {
+			t.Fatalf("Document(%q) not found", name)
+		}
+		var s map[string]any
+		if err := json.Unmarshal(doc, &s); err != nil {
+			t.Fatalf("Document(%q) is not JSON: %v", name, err)
+		}
+		if s["title"] != name {
+			t.Errorf("Document(%q) title = %v", name, s["title"])
+		}
+	}
+	for _, name := range []string{"", "unknown", "../schema", "schemas/namazu.v1"} {
+		if _, ok := Document(name); ok {
+			t.Errorf("Document(%q) found", name)
+		}
+	}
+}
+
+func TestValidate_EventV1(t *testing.T) {
+	for _, event := range []testEvent{
+		{},
+		{hypocenter: &source.Hypocenter{Name: "石川県能登地方", Latitude: 37.5, Longitude: 137.27, Depth: 10, Magnitude: 7.6}},
+		{hypocenter: &source.Hypocenter{Depth: -1, Magnitude: -1}},
+	} {
+		payload, err := Render(FormatV1, event, nil)
+		if err != nil {
+			t.Fatalf("Render() error = %v", err)
+		}
+		if err := Validate(SchemaEvent, payload); err != nil {
+			t.Errorf("Validate(%s) error = %v", payload, err)
+		}
+	}
+}
+
+func TestValidate_Violations(t *testing.T) {
+	valid, _ := Render(FormatV1, testEvent{}, nil)
+	tests := []struct {
+		name    string
+		change  func(map[string]any)
+		wantErr string
+	}{
+		{"missing field", func(m map[string]any) { delete(m, "severity") }, "$: severity is required"},
+		{"wrong type", func(m map[string]any) { m["prefectures"] = "石川県" }, "$.prefectures: must be of type array"},
+		{"wrong item type", func(m map[string]any) { m["prefectures"] = []any{1} }, "$.prefectures[0]: must be of type string"},
+		{"not an integer", func(m map[string]any) { m["severity"] = 4.5 }, "$.severity: must be of type integer"},
+		{"out of range", func(m map[string]any) { m["severity"] = 101 }, "$.severity: must be at most 100"},
+		{"wrong const", func(m map[string]any) { m["schema_version"] = 2 }, "$.schema_version: must be 1"},
+		{"not in enum", func(m map[string]any) { m["type"] = "volcano" }, "$.type: must be one of"},
+		{"bad date-time", func(m map[string]any) { m["occurred_at"] = "2024-01-01 07:10" }, "$.occurred_at: must be an RFC 3339 date-time"},
+		{"nested", func(m map[string]any) { m["hypocenter"] = map[string]any{"name": "x"} }, "$.hypocenter: latitude is required"},
+		{"unknown fields are allowed", func(m map[string]any) { m["namazu"] = map[string]any{"scale": 45} }, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var m map[string]any
+			if err := json.Unmarshal(valid, &m); err != nil {
+				t.Fatal(err)
+			}
+			tt.change(m)
+			payload, _ := json.Marshal(m)
+			err := Validate(SchemaEvent, payload)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	if err := Validate("unknown", valid); err == nil {
+		t.Error("Validate(unknown schema) error = nil")
+	}
+	if err := Validate(SchemaEvent, []byte("{")); err == nil {
+		t.Error("Validate(invalid JSON) error = nil")
+	}
+}
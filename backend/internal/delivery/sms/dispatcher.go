@@ -0,0 +1,144 @@
+package sms
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/otiai10/namazu/backend/internal/delivery"
+	"github.com/otiai10/namazu/backend/internal/store"
+	"github.com/otiai10/namazu/backend/internal/subscription"
+)
+
+// Dispatcher delivers earthquake events to "sms" subscriptions.
+// Messages without an event (service notices, digests) are not sent by SMS.
+type Dispatcher struct {
+	client         *Client
+	statusCallback string                   // Public URL of the status callback; empty records on acceptance
+	repo           store.DeliveryRepository // optional; nil records nothing
+}
+
+// Compile-time interface check
+var _ delivery.Dispatcher = (*Dispatcher)(nil)
+
+// NewDispatcher creates a Dispatcher. If statusCallback is not empty, accepted
+// messages are recorded when Twilio reports their final status (see RecordFromStatus).
+func NewDispatcher(client *Client, statusCallback string, repo store.DeliveryRepository) *Dispatcher {
+	return &Dispatcher{client: client, statusCallback: statusCallback, repo: repo}
+}
+
+// Dispatch sends the event to each subscription's phone number concurrently
+func (d *Dispatcher) Dispatch(ctx context.Context, msg delivery.Message, subs []subscription.Subscription) {
+	if msg.Event == nil || len(subs) == 0 {
+		return
+	}
+	var wg sync.WaitGroup
+	for _, sub := range subs {
+		if sub.Delivery.SMS == nil {
+			log.Printf("Subscription [%s]: delivery.sms is not configured", sub.Name)
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			d.send(ctx, sub, msg.ID, Format(msg.Event, sub.Locale))
+		}()
+	}
+	wg.Wait()
+}
+
+func (d *Dispatcher) send(ctx context.Context, sub subscription.Subscription, eventID, body string) {
+	hash := bodyHash(body)
+	callback := ""
+	if d.statusCallback != "" {
+		callback = StatusCallbackURL(d.statusCallback, sub, eventID, hash)
+	}
+
+	result := d.client.Send(ctx, sub.Delivery.SMS.Phone, body, callback)
+	if !result.Success {
+		log.Printf("Subscription [%s]: failed - %s", sub.Name, result.ErrorMessage)
+	} else {
+		log.Printf("Subscription [%s]: SMS %s %s in %v", sub.Name, result.MessageSID, result.Status, result.ResponseTime)
+	}
+
+	// Accepted messages are recorded by the status callback, if there is one
+	if result.Success && callback != "" {
+		return
+	}
+	d.record(ctx, store.DeliveryRecord{
+		SubscriptionID: sub.ID,
+		UserID:         sub.UserID,
+		EventID:        eventID,
+		URL:            "sms:" + sub.Delivery.SMS.Phone,
+		StatusCode:     result.StatusCode,
+		Success:        result.Success,
+		ErrorMessage:   result.ErrorMessage,
+		Attempts:       1,
+		ResponseTimeMs: result.ResponseTime.Milliseconds(),
+		PayloadSHA256:  hash,
+		DeliveredAt:    time.Now(),
+	})
+}
+
+func (d *Dispatcher) record(ctx context.Context, record store.DeliveryRecord) {
+	if d.repo == nil {
+		return
+	}
+	if _, err := d.repo.Create(ctx, record); err != nil {
+		log.Printf("Subscription %s: failed to record SMS delivery: %v", record.SubscriptionID, err)
+	}
+}
+
+// StatusCallbackURL returns the status callback for one message. What the
+// delivery record needs travels in the query, which Twilio's signature covers.
+func StatusCallbackURL(base string, sub subscription.Subscription, eventID, payloadSHA256 string) string {
+	return base + "?" + url.Values{
+		"subscription_id": {sub.ID},
+		"user_id":         {sub.UserID},
+		"event_id":        {eventID},
+		"payload_sha256":  {payloadSHA256},
+	}.Encode()
+}
+
+// Final message statuses reported by Twilio
+const (
+	StatusDelivered   = "delivered"
+	StatusUndelivered = "undelivered"
+	StatusFailed      = "failed"
+)
+
+// RecordFromStatus builds the delivery record for a status callback, given
+// the query of the callback URL and the POST parameters. It returns false for
+// intermediate statuses (queued, sent, ...), which are not recorded.
+func RecordFromStatus(query, params url.Values, now time.Time) (store.DeliveryRecord, bool) {
+	status := params.Get("MessageStatus")
+	if status != StatusDelivered && status != StatusUndelivered && status != StatusFailed {
+		return store.DeliveryRecord{}, false
+	}
+	record := store.DeliveryRecord{
+		SubscriptionID: query.Get("subscription_id"),
+		UserID:         query.Get("user_id"),
+		EventID:        query.Get("event_id"),
+		URL:            "sms:" + params.Get("To"),
+		Success:        status == StatusDelivered,
+		Attempts:       1,
+		PayloadSHA256:  query.Get("payload_sha256"),
+		DeliveredAt:    now,
+	}
+	if !record.Success {
+		record.ErrorMessage = status
+		if code := params.Get("ErrorCode"); code != "" {
+			record.ErrorMessage += " (twilio error " + code + ")"
+		}
+	}
+	return record, true
+}
+
+func bodyHash(body string) string {
+	sum := sha256.Sum256([]byte(body))
+	return hex.EncodeToString(sum[:])
+}
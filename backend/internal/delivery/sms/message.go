@@ -0,0 +1,56 @@
+package sms
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/otiai10/namazu/backend/internal/i18n"
+	"github.com/otiai10/namazu/backend/internal/source"
+	"github.com/otiai10/namazu/backend/internal/source/p2pquake"
+)
+
+// MaxBodyLength is the maximum length of a message in characters. Japanese
+// text is sent as UCS-2, where one segment holds 70 characters; English text
+// is kept to the same length, as hypocenter names are in Japanese.
+const MaxBodyLength = 70
+
+// Format renders an event as a short text message in locale (see package
+// i18n), e.g. "[namazu] 震度5弱 石川県能登地方 M5.2 1/1 16:10 石川県、富山県"
+func Format(event source.Event, locale string) string {
+	parts := []string{"[namazu]"}
+	switch event.GetType() {
+	case source.EventTypeEEW:
+		parts = append(parts, i18n.T(locale, "event.eew"))
+	case source.EventTypeTsunami:
+		parts = append(parts, i18n.T(locale, "event.tsunami"))
+	}
+	if scale := p2pquake.SeverityToScale(event.GetSeverity()); scale > 0 {
+		parts = append(parts, i18n.Scale(locale, scale))
+	}
+	if located, ok := event.(source.Located); ok {
+		if h := located.GetHypocenter(); h != nil {
+			if h.Name != "" {
+				parts = append(parts, h.Name)
+			}
+			if h.Magnitude >= 0 {
+				parts = append(parts, fmt.Sprintf("M%.1f", h.Magnitude))
+			}
+		}
+	}
+	if occurredAt := event.GetOccurredAt(); !occurredAt.IsZero() {
+		parts = append(parts, i18n.FormatTime(locale, occurredAt))
+	}
+	if areas := event.GetAffectedAreas(); len(areas) > 0 {
+		parts = append(parts, i18n.Areas(locale, areas))
+	}
+	return truncate(strings.Join(parts, " "), MaxBodyLength)
+}
+
+// truncate shortens s to at most max characters, marking the cut with "…"
+func truncate(s string, max int) string {
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	return string(runes[:max-1]) + "…"
+}
@@ -0,0 +1,160 @@
+// Package sms sends earthquake alerts as text messages through Twilio.
+package sms
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultBaseURL is the Twilio REST API
+const DefaultBaseURL = "https://api.twilio.com"
+
+// SignatureHeader carries the signature of Twilio's status callbacks
+const SignatureHeader = "X-Twilio-Signature"
+
+// Result is the outcome of submitting one message to Twilio
+type Result struct {
+	MessageSID   string // Empty if Twilio did not accept the message
+	Status       string // Twilio message status, e.g. "queued"
+	StatusCode   int
+	Success      bool
+	ErrorMessage string
+	ResponseTime time.Duration
+}
+
+// Client sends messages with the Twilio Messages API.
+//
+// Client is safe for concurrent use by multiple goroutines.
+type Client struct {
+	accountSID string
+	authToken  string
+	from       string
+	baseURL    string
+	client     *http.Client
+}
+
+// ClientOption configures the Client
+type ClientOption func(*Client)
+
+// WithBaseURL overrides the Twilio API URL (used in tests)
+func WithBaseURL(baseURL string) ClientOption {
+	return func(c *Client) {
+		c.baseURL = strings.TrimSuffix(baseURL, "/")
+	}
+}
+
+// NewClient creates a Client that sends from the given E.164 number
+func NewClient(accountSID, authToken, from string, opts ...ClientOption) *Client {
+	c := &Client{
+		accountSID: accountSID,
+		authToken:  authToken,
+		from:       from,
+		baseURL:    DefaultBaseURL,
+		client:     &http.Client{Timeout: 10 * time.Second},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// messageResponse is the subset of a Twilio message resource (or error) used here
+type messageResponse struct {
+	SID     string `json:"sid"`
+	Status  string `json:"status"`
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Send submits a message to a phone number. If statusCallback is not empty,
+// Twilio reports the delivery status to it.
+func (c *Client) Send(ctx context.Context, to, body, statusCallback string) Result {
+	start := time.Now()
+	var result Result
+
+	form := url.Values{"To": {to}, "From": {c.from}, "Body": {body}}
+	if statusCallback != "" {
+		form.Set("StatusCallback", statusCallback)
+	}
+	endpoint := c.baseURL + "/2010-04-01/Accounts/" + url.PathEscape(c.accountSID) + "/Messages.json"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		result.ErrorMessage = fmt.Sprintf("failed to create request: %v", err)
+		result.ResponseTime = time.Since(start)
+		return result
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(c.accountSID, c.authToken)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		result.ErrorMessage = fmt.Sprintf("request failed: %v", err)
+		result.ResponseTime = time.Since(start)
+		return result
+	}
+	defer resp.Body.Close()
+
+	result.StatusCode = resp.StatusCode
+	result.ResponseTime = time.Since(start)
+	var msg messageResponse
+	_ = json.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&msg)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		if msg.Message != "" {
+			result.ErrorMessage = fmt.Sprintf("twilio error %d: %s", msg.Code, msg.Message)
+		} else {
+			result.ErrorMessage = fmt.Sprintf("unexpected status: %d", resp.StatusCode)
+		}
+		return result
+	}
+
+	result.Success = true
+	result.MessageSID = msg.SID
+	result.Status = msg.Status
+	return result
+}
+
+// ValidSignature reports whether signature is Twilio's signature of a request
+// to fullURL (including its query) with the given POST parameters.
+// See https://www.twilio.com/docs/usage/security#validating-requests
+func ValidSignature(authToken, fullURL string, params url.Values, signature string) bool {
+	expected, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	return hmac.Equal(expected, sign(authToken, fullURL, params))
+}
+
+// Signature returns the signature Twilio sends for a request to fullURL with
+// the given POST parameters
+func Signature(authToken, fullURL string, params url.Values) string {
+	return base64.StdEncoding.EncodeToString(sign(authToken, fullURL, params))
+}
+
+func sign(authToken, fullURL string, params url.Values) []byte {
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var data strings.Builder
+	data.WriteString(fullURL)
+	for _, key := range keys {
+		for _, value := range params[key] {
+			data.WriteString(key + value)
+		}
+	}
+	mac := hmac.New(sha1.New, []byte(authToken))
+	mac.Write([]byte(data.String()))
+	return mac.Sum(nil)
+}
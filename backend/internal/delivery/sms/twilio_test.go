@@ -0,0 +1,204 @@
+package sms
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/otiai10/namazu/backend/internal/delivery"
+	"github.com/otiai10/namazu/backend/internal/source"
+	"github.com/otiai10/namazu/backend/internal/store"
+	"github.com/otiai10/namazu/backend/internal/subscription"
+)
+
+// recorder is a fake Twilio Messages API that records request forms
+type recorder struct {
+	mu     sync.Mutex
+	paths  []string
+	forms  []url.Values
+	users  []string
+	status int
+	body   string
+}
+
+func (rec *recorder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	user, _, _ := r.BasicAuth()
+	rec.mu.Lock()
+	rec.paths = append(rec.paths, r.URL.Path)
+	rec.forms = append(rec.forms, r.PostForm)
+	rec.users = append(rec.users, user)
+	rec.mu.Unlock()
+	if rec.status != 0 {
+		w.WriteHeader(rec.status)
+	}
+	w.Write([]byte(rec.body))
+}
+
+func TestClient_Send(t *testing.T) {
+	rec := &recorder{status: http.StatusCreated, body: `{"sid":"SM123","status":"queued"}`}
+	server := httptest.NewServer(rec)
+	defer server.Close()
+
+	client := NewClient("AC123", "token", "+15005550006", WithBaseURL(server.URL))
+	result := client.Send(context.Background(), "+819012345678", "hello", "https://example.com/api/webhooks/twilio")
+
+	if !result.Success || result.MessageSID != "SM123" || result.Status != "queued" {
+		t.Fatalf("Send() = %+v, want an accepted message", result)
+	}
+	if rec.paths[0] != "/2010-04-01/Accounts/AC123/Messages.json" || rec.users[0] != "AC123" {
+		t.Errorf("request = %s as %q", rec.paths[0], rec.users[0])
+	}
+	form := rec.forms[0]
+	if form.Get("To") != "+819012345678" || form.Get("From") != "+15005550006" || form.Get("Body") != "hello" ||
+		form.Get("StatusCallback") != "https://example.com/api/webhooks/twilio" {
+		t.Errorf("form = %v", form)
+	}
+}
+
+func TestClient_Send_Error(t *testing.T) {
+	rec := &recorder{status: http.StatusBadRequest, body: `{"code":21211,"message":"The 'To' number is not a valid phone number."}`}
+	server := httptest.NewServer(rec)
+	defer server.Close()
+
+	result := NewClient("AC123", "token", "+15005550006", WithBaseURL(server.URL)).Send(context.Background(), "+10000000000", "hello", "")
+	if result.Success || result.StatusCode != http.StatusBadRequest {
+		t.Fatalf("Send() = %+v, want a 400 failure", result)
+	}
+	if result.ErrorMessage != "twilio error 21211: The 'To' number is not a valid phone number." {
+		t.Errorf("ErrorMessage = %q", result.ErrorMessage)
+	}
+	if _, ok := rec.forms[0]["StatusCallback"]; ok {
+		t.Error("StatusCallback should be omitted when empty")
+	}
+}
+
+func TestValidSignature(t *testing.T) {
+	fullURL := "https://example.com/api/webhooks/twilio?subscription_id=s1"
+	params := url.Values{"MessageStatus": {"delivered"}, "MessageSid": {"SM123"}, "To": {"+819012345678"}}
+	signature := Signature("token", fullURL, params)
+
+	if !ValidSignature("token", fullURL, params, signature) {
+		t.Error("expected the signature to be valid")
+	}
+	if ValidSignature("other", fullURL, params, signature) {
+		t.Error("expected a different auth token to fail")
+	}
+	if ValidSignature("token", "https://example.com/api/webhooks/twilio?subscription_id=s2", params, signature) {
+		t.Error("expected a tampered query to fail")
+	}
+	tampered := url.Values{"MessageStatus": {"failed"}, "MessageSid": {"SM123"}, "To": {"+819012345678"}}
+	if ValidSignature("token", fullURL, tampered, signature) {
+		t.Error("expected tampered parameters to fail")
+	}
+	if ValidSignature("token", fullURL, params, "not base64!") {
+		t.Error("expected a malformed signature to fail")
+	}
+}
+
+type testEvent struct{}
+
+func (testEvent) GetID() string              { return "e1" }
+func (testEvent) GetType() source.EventType  { return source.EventTypeEarthquake }
+func (testEvent) GetSource() string          { return "p2pquake" }
+func (testEvent) GetSeverity() int           { return 50 }
+func (testEvent) GetAffectedAreas() []string { return []string{"石川県", "富山県"} }
+func (testEvent) GetOccurredAt() time.Time   { return time.Date(2024, 1, 1, 7, 10, 0, 0, time.UTC) }
+func (testEvent) GetReceivedAt() time.Time   { return time.Time{} }
+func (testEvent) GetRawJSON() string         { return `{}` }
+
+func TestFormat(t *testing.T) {
+	got := Format(testEvent{}, "")
+	if got != "[namazu] 震度5弱 1/1 16:10 石川県、富山県" {
+		t.Errorf("Format() = %q", got)
+	}
+	got = Format(testEvent{}, "en")
+	if got != "[namazu] Shindo 5 Lower Jan 1 16:10 JST Ishikawa, Toyama" {
+		t.Errorf("Format(en) = %q", got)
+	}
+
+	long := truncate(strings.Repeat("あ", 100), MaxBodyLength)
+	if n := len([]rune(long)); n != MaxBodyLength || !strings.HasSuffix(long, "…") {
+		t.Errorf("truncate() = %d characters %q", n, long)
+	}
+}
+
+func TestDispatcher_Dispatch(t *testing.T) {
+	rec := &recorder{status: http.StatusCreated, body: `{"sid":"SM123","status":"queued"}`}
+	server := httptest.NewServer(rec)
+	defer server.Close()
+
+	repo := store.NewMemoryDeliveryRepository()
+	d := NewDispatcher(NewClient("AC123", "token", "+15005550006", WithBaseURL(server.URL)), "", repo)
+	d.Dispatch(context.Background(), delivery.Message{ID: "e1", Payload: []byte(`{}`), Event: testEvent{}}, []subscription.Subscription{
+		{ID: "s1", UserID: "u1", Name: "Phone", Delivery: subscription.DeliveryConfig{Type: subscription.DeliveryTypeSMS,
+			SMS: &subscription.SMSConfig{Phone: "+819012345678"}}},
+		{ID: "s2", Name: "Unconfigured", Delivery: subscription.DeliveryConfig{Type: subscription.DeliveryTypeSMS}},
+	})
+	// Messages without an event are not sent
+	d.Dispatch(context.Background(), delivery.Message{ID: "n1", Payload: []byte(`{}`)}, []subscription.Subscription{
+		{ID: "s1", Delivery: subscription.DeliveryConfig{Type: subscription.DeliveryTypeSMS, SMS: &subscription.SMSConfig{Phone: "+819012345678"}}},
+	})
+
+	if len(rec.forms) != 1 || rec.forms[0].Get("To") != "+819012345678" {
+		t.Fatalf("expected one message to the configured phone, got %v", rec.forms)
+	}
+	records, _ := repo.ListBySubscription(context.Background(), "s1", time.Time{}, time.Now().Add(time.Minute))
+	if len(records) != 1 || !records[0].Success || records[0].URL != "sms:+819012345678" || records[0].EventID != "e1" {
+		t.Errorf("records = %+v, want one successful SMS delivery", records)
+	}
+}
+
+func TestDispatcher_Dispatch_StatusCallback(t *testing.T) {
+	rec := &recorder{status: http.StatusCreated, body: `{"sid":"SM123","status":"queued"}`}
+	server := httptest.NewServer(rec)
+	defer server.Close()
+
+	repo := store.NewMemoryDeliveryRepository()
+	d := NewDispatcher(NewClient("AC123", "token", "+15005550006", WithBaseURL(server.URL)), "https://example.com/api/webhooks/twilio", repo)
+	d.Dispatch(context.Background(), delivery.Message{ID: "e1", Payload: []byte(`{}`), Event: testEvent{}}, []subscription.Subscription{
+		{ID: "s1", UserID: "u1", Name: "Phone", Delivery: subscription.DeliveryConfig{Type: subscription.DeliveryTypeSMS,
+			SMS: &subscription.SMSConfig{Phone: "+819012345678"}}},
+	})
+
+	// Accepted messages wait for the callback
+	records, _ := repo.ListBySubscription(context.Background(), "s1", time.Time{}, time.Now().Add(time.Minute))
+	if len(records) != 0 {
+		t.Errorf("records = %+v, want none before the status callback", records)
+	}
+
+	callback, err := url.Parse(rec.forms[0].Get("StatusCallback"))
+	if err != nil || callback.Host != "example.com" {
+		t.Fatalf("StatusCallback = %q", rec.forms[0].Get("StatusCallback"))
+	}
+	query := callback.Query()
+	if query.Get("subscription_id") != "s1" || query.Get("user_id") != "u1" || query.Get("event_id") != "e1" ||
+		query.Get("payload_sha256") != bodyHash(Format(testEvent{}, "")) {
+		t.Errorf("callback query = %v", query)
+	}
+}
+
+func TestRecordFromStatus(t *testing.T) {
+	query := url.Values{"subscription_id": {"s1"}, "user_id": {"u1"}, "event_id": {"e1"}, "payload_sha256": {"abc"}}
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	if _, ok := RecordFromStatus(query, url.Values{"MessageStatus": {"sent"}}, now); ok {
+		t.Error("intermediate statuses should not be recorded")
+	}
+
+	record, ok := RecordFromStatus(query, url.Values{"MessageStatus": {"delivered"}, "To": {"+819012345678"}}, now)
+	if !ok || !record.Success || record.SubscriptionID != "s1" || record.UserID != "u1" || record.EventID != "e1" ||
+		record.PayloadSHA256 != "abc" || record.URL != "sms:+819012345678" || !record.DeliveredAt.Equal(now) {
+		t.Errorf("delivered record = %+v", record)
+	}
+
+	record, ok = RecordFromStatus(query, url.Values{"MessageStatus": {"undelivered"}, "ErrorCode": {"30003"}}, now)
+	if !ok || record.Success || record.ErrorMessage != "undelivered (twilio error 30003)" {
+		t.Errorf("undelivered record = %+v", record)
+	}
+}
@@ -0,0 +1,32 @@
+package transform
+
+import (
+	"time"
+
+	"github.com/otiai10/namazu/backend/internal/source"
+)
+
+// sample is the event templates are validated against
+type sample struct {
+	ID         string
+	RawJSON    string
+	OccurredAt time.Time
+}
+
+func (s sample) GetID() string              { return s.ID }
+func (s sample) GetType() source.EventType  { return source.EventTypeEarthquake }
+func (s sample) GetSource() string          { return "p2pquake" }
+func (s sample) GetSeverity() int           { return 40 } // 震度4
+func (s sample) GetAffectedAreas() []string { return []string{"石川県", "富山県"} }
+func (s sample) GetOccurredAt() time.Time   { return s.OccurredAt }
+func (s sample) GetReceivedAt() time.Time   { return s.OccurredAt.Add(time.Minute) }
+func (s sample) GetRawJSON() string         { return s.RawJSON }
+func (s sample) GetHypocenter() *source.Hypocenter {
+	return &source.Hypocenter{Name: "石川県能登地方", Latitude: 37.5, Longitude: 137.2, Depth: 10, Magnitude: 5.2}
+}
+
+var sampleEvent = sample{
+	ID:         "sample",
+	RawJSON:    `{"code":551,"earthquake":{"maxScale":40,"hypocenter":{"name":"石川県能登地方","magnitude":5.2,"depth":10}}}`,
+	OccurredAt: time.Date(2024, 1, 1, 7, 10, 0, 0, time.UTC),
+}
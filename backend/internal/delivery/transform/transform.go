@@ -0,0 +1,171 @@
+// Package transform renders subscriber-defined Go templates into webhook bodies.
+//
+// Templates run in a sandbox: only the functions in this package are
+// available (text/template builtins aside), the data holds no methods that
+// reach outside the event, and both the template and its output are size
+// limited. The output must be valid JSON, since it is signed and sent as is.
+package transform
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+	_ "time/tzdata" // formatTime must resolve time zones in minimal container images
+
+	"github.com/otiai10/namazu/backend/internal/source"
+	"github.com/otiai10/namazu/backend/internal/source/p2pquake"
+)
+
+// MaxTemplateSize is the maximum length of a template in bytes
+const MaxTemplateSize = 8 * 1024
+
+// MaxOutputSize is the maximum length of a rendered body in bytes
+const MaxOutputSize = 64 * 1024
+
+// DefaultTimezone is used by formatTime when no zone is given
+const DefaultTimezone = "Asia/Tokyo"
+
+// ErrOutputTooLarge is returned when a rendered body exceeds MaxOutputSize
+var ErrOutputTooLarge = errors.New("rendered payload exceeds size limit")
+
+// Template is a compiled payload template
+type Template struct {
+	tmpl *template.Template
+}
+
+// Data is what a template is executed against
+type Data struct {
+	Event   *Event                 // nil for service notices and redeliveries
+	Payload map[string]interface{} // The original JSON body, decoded; nil if it is not an object
+}
+
+// Event is the template view of an earthquake event
+type Event struct {
+	ID            string
+	Type          string
+	Source        string
+	Severity      int
+	Scale         int // JMA scale code (10–70), derived from Severity
+	AffectedAreas []string
+	OccurredAt    time.Time
+	ReceivedAt    time.Time
+	Hypocenter    *source.Hypocenter // nil if unknown
+}
+
+// funcs are the only functions templates may call besides the builtins
+var funcs = template.FuncMap{
+	"scaleName":  p2pquake.ScaleToString,
+	"formatTime": formatTime,
+	"json":       toJSON,
+	"join":       join,
+}
+
+// Compile parses a template. It does not execute it; see Validate.
+func Compile(src string) (*Template, error) {
+	if len(src) > MaxTemplateSize {
+		return nil, fmt.Errorf("template exceeds %d bytes", MaxTemplateSize)
+	}
+	tmpl, err := template.New("payload").Option("missingkey=zero").Funcs(funcs).Parse(src)
+	if err != nil {
+		return nil, err
+	}
+	return &Template{tmpl: tmpl}, nil
+}
+
+// Validate compiles a template and renders it against a sample event,
+// so that mistakes surface when the subscription is saved rather than
+// when an earthquake happens.
+func Validate(src string) error {
+	t, err := Compile(src)
+	if err != nil {
+		return err
+	}
+	_, err = t.Render(sampleEvent, []byte(sampleEvent.RawJSON))
+	return err
+}
+
+// Render executes the template for an event (nil for service notices and
+// redeliveries) and its original payload. The result is compacted JSON.
+func (t *Template) Render(event source.Event, payload []byte) ([]byte, error) {
+	data := Data{Event: newEvent(event)}
+	// Payloads that are not JSON objects are simply not exposed
+	_ = json.Unmarshal(payload, &data.Payload)
+
+	var buf bytes.Buffer
+	if err := t.tmpl.Execute(&limitedWriter{buf: &buf, limit: MaxOutputSize}, data); err != nil {
+		if errors.Is(err, ErrOutputTooLarge) {
+			return nil, ErrOutputTooLarge
+		}
+		return nil, err
+	}
+
+	var compacted bytes.Buffer
+	if err := json.Compact(&compacted, buf.Bytes()); err != nil {
+		return nil, fmt.Errorf("rendered payload is not valid JSON: %w", err)
+	}
+	return compacted.Bytes(), nil
+}
+
+func newEvent(event source.Event) *Event {
+	if event == nil {
+		return nil
+	}
+	e := &Event{
+		ID:            event.GetID(),
+		Type:          string(event.GetType()),
+		Source:        event.GetSource(),
+		Severity:      event.GetSeverity(),
+		Scale:         p2pquake.SeverityToScale(event.GetSeverity()),
+		AffectedAreas: event.GetAffectedAreas(),
+		OccurredAt:    event.GetOccurredAt(),
+		ReceivedAt:    event.GetReceivedAt(),
+	}
+	if located, ok := event.(source.Located); ok {
+		e.Hypocenter = located.GetHypocenter()
+	}
+	return e
+}
+
+// formatTime formats t with a Go layout in a time zone (DefaultTimezone if omitted)
+func formatTime(layout string, t time.Time, zone ...string) (string, error) {
+	name := DefaultTimezone
+	if len(zone) > 0 {
+		name = zone[0]
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return "", err
+	}
+	return t.In(loc).Format(layout), nil
+}
+
+// join concatenates elems with sep; the separator comes first so lists can be piped in
+func join(sep string, elems []string) string {
+	return strings.Join(elems, sep)
+}
+
+// toJSON encodes v as JSON, so values can be embedded in the body safely
+func toJSON(v interface{}) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// limitedWriter fails once more than limit bytes are written
+type limitedWriter struct {
+	buf   *bytes.Buffer
+	limit int
+}
+
+func (w *limitedWriter) Write(p []byte) (int, error) {
+	if w.buf.Len()+len(p) > w.limit {
+		return 0, ErrOutputTooLarge
+	}
+	return w.buf.Write(p)
+}
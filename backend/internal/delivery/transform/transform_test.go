@@ -0,0 +1,102 @@
+package transform
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestTemplate_Render(t *testing.T) {
+	tmpl, err := Compile(`{"text": {{json (printf "%s %s %s" (scaleName .Event.Scale) .Event.Hypocenter.Name (formatTime "15:04" .Event.OccurredAt))}}, "areas": {{json (join ", " .Event.AffectedAreas)}}, "code": {{.Payload.code}}}`)
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+
+	got, err := tmpl.Render(sampleEvent, []byte(sampleEvent.RawJSON))
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	want := `{"text":"震度4 石川県能登地方 16:10","areas":"石川県, 富山県","code":551}`
+	if string(got) != want {
+		t.Errorf("Render() = %s, want %s", got, want)
+	}
+}
+
+func TestTemplate_Render_FormatTimeZone(t *testing.T) {
+	tmpl, err := Compile(`{"at": {{json (formatTime "15:04" .Event.OccurredAt "UTC")}}}`)
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+	got, err := tmpl.Render(sampleEvent, nil)
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if string(got) != `{"at":"07:10"}` {
+		t.Errorf("Render() = %s", got)
+	}
+}
+
+func TestTemplate_Render_WithoutEvent(t *testing.T) {
+	tmpl, err := Compile(`{{with .Event}}{"id": {{json .ID}}}{{else}}{"type": {{json .Payload.type}}}{{end}}`)
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+	got, err := tmpl.Render(nil, []byte(`{"type":"namazu.notice"}`))
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if string(got) != `{"type":"namazu.notice"}` {
+		t.Errorf("Render() = %s", got)
+	}
+}
+
+func TestTemplate_Render_InvalidJSON(t *testing.T) {
+	tmpl, err := Compile(`text: {{.Event.ID}}`)
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+	if _, err := tmpl.Render(sampleEvent, nil); err == nil || !strings.Contains(err.Error(), "not valid JSON") {
+		t.Errorf("Render() error = %v, want invalid JSON", err)
+	}
+}
+
+func TestTemplate_Render_OutputTooLarge(t *testing.T) {
+	tmpl, err := Compile(`[{{range $i, $_ := .Payload.items}}{{range $.Payload.items}}"{{printf "%0100d" 0}}",{{end}}{{end}}0]`)
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+	items := "[" + strings.Repeat("0,", 99) + "0]"
+	if _, err := tmpl.Render(nil, []byte(`{"items":`+items+`}`)); !errors.Is(err, ErrOutputTooLarge) {
+		t.Errorf("Render() error = %v, want ErrOutputTooLarge", err)
+	}
+}
+
+func TestCompile_Rejects(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+	}{
+		{"syntax error", `{"a": {{.Event.ID}`},
+		{"unknown function", `{"a": {{env "HOME"}}}`},
+		{"too large", strings.Repeat("x", MaxTemplateSize+1)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Compile(tt.src); err == nil {
+				t.Error("Compile() should fail")
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := Validate(`{"scale": {{.Event.Scale}}}`); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+	if err := Validate(`{"scale": {{.Event.Nope}}}`); err == nil {
+		t.Error("Validate() should fail for an unknown field")
+	}
+	if err := Validate(`not json`); err == nil {
+		t.Error("Validate() should fail for non-JSON output")
+	}
+}
@@ -31,13 +31,17 @@ type Challenger struct {
 	client *http.Client
 }
 
-func NewChallenger(timeout time.Duration) *Challenger {
+// NewChallenger creates a Challenger. Options such as WithResolver and
+// WithProxy make challenges take the same route as deliveries.
+func NewChallenger(timeout time.Duration, opts ...SenderOption) *Challenger {
 	return &Challenger{
-		client: &http.Client{Timeout: timeout},
+		client: NewSender(append(opts, WithTimeout(timeout))...).client,
 	}
 }
 
-func (c *Challenger) VerifyURL(ctx context.Context, url, secret string) ChallengeResult {
+// VerifyURL sends a url_verification challenge to url, with the subscription's
+// custom headers so endpoints that require them can answer.
+func (c *Challenger) VerifyURL(ctx context.Context, url, secret string, headers map[string]string) ChallengeResult {
 	start := time.Now()
 
 	token, err := generateChallengeToken()
@@ -67,9 +71,10 @@ func (c *Challenger) VerifyURL(ctx context.Context, url, secret string) Challeng
 			ResponseTime: time.Since(start),
 		}
 	}
+	setCustomHeaders(req.Header, headers)
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("X-Signature-256", Sign(secret, body))
-	req.Header.Set("User-Agent", "namazu/1.0")
+	req.Header.Set("User-Agent", DefaultUserAgent)
 
 	resp, err := c.client.Do(req)
 	if err != nil {
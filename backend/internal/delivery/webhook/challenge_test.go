@@ -3,7 +3,9 @@ package webhook
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -33,7 +35,7 @@ func TestVerifyURL_Success(t *testing.T) {
 	defer server.Close()
 
 	challenger := NewChallenger(5 * time.Second)
-	result := challenger.VerifyURL(context.Background(), server.URL, "test-secret")
+	result := challenger.VerifyURL(context.Background(), server.URL, "test-secret", nil)
 
 	if !result.Success {
 		t.Errorf("expected success, got failure: %s", result.ErrorMessage)
@@ -54,7 +56,7 @@ func TestVerifyURL_WrongChallenge(t *testing.T) {
 	defer server.Close()
 
 	challenger := NewChallenger(5 * time.Second)
-	result := challenger.VerifyURL(context.Background(), server.URL, "test-secret")
+	result := challenger.VerifyURL(context.Background(), server.URL, "test-secret", nil)
 
 	if result.Success {
 		t.Error("expected failure for wrong challenge")
@@ -71,7 +73,7 @@ func TestVerifyURL_Non200Status(t *testing.T) {
 	defer server.Close()
 
 	challenger := NewChallenger(5 * time.Second)
-	result := challenger.VerifyURL(context.Background(), server.URL, "test-secret")
+	result := challenger.VerifyURL(context.Background(), server.URL, "test-secret", nil)
 
 	if result.Success {
 		t.Error("expected failure for non-200 status")
@@ -89,7 +91,7 @@ func TestVerifyURL_Timeout(t *testing.T) {
 	defer server.Close()
 
 	challenger := NewChallenger(50 * time.Millisecond)
-	result := challenger.VerifyURL(context.Background(), server.URL, "test-secret")
+	result := challenger.VerifyURL(context.Background(), server.URL, "test-secret", nil)
 
 	if result.Success {
 		t.Error("expected failure for timeout")
@@ -107,7 +109,7 @@ func TestVerifyURL_InvalidJSON(t *testing.T) {
 	defer server.Close()
 
 	challenger := NewChallenger(5 * time.Second)
-	result := challenger.VerifyURL(context.Background(), server.URL, "test-secret")
+	result := challenger.VerifyURL(context.Background(), server.URL, "test-secret", nil)
 
 	if result.Success {
 		t.Error("expected failure for invalid JSON")
@@ -119,7 +121,7 @@ func TestVerifyURL_InvalidJSON(t *testing.T) {
 
 func TestVerifyURL_ConnectionRefused(t *testing.T) {
 	challenger := NewChallenger(2 * time.Second)
-	result := challenger.VerifyURL(context.Background(), "http://127.0.0.1:1", "test-secret")
+	result := challenger.VerifyURL(context.Background(), "http://127.0.0.1:1", "test-secret", nil)
 
 	if result.Success {
 		t.Error("expected failure for connection refused")
@@ -152,7 +154,7 @@ func TestVerifyURL_IncludesSignature(t *testing.T) {
 	defer server.Close()
 
 	challenger := NewChallenger(5 * time.Second)
-	result := challenger.VerifyURL(context.Background(), server.URL, secret)
+	result := challenger.VerifyURL(context.Background(), server.URL, secret, nil)
 
 	if !result.Success {
 		t.Fatalf("expected success, got failure: %s", result.ErrorMessage)
@@ -197,3 +199,39 @@ func TestGenerateChallengeToken_Uniqueness(t *testing.T) {
 		t.Error("expected two generated tokens to be different")
 	}
 }
+
+func TestVerifyURL_CustomHeaders(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var req ChallengeRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		json.NewEncoder(w).Encode(ChallengeResponse{Challenge: req.Challenge})
+	}))
+	defer server.Close()
+
+	challenger := NewChallenger(5 * time.Second)
+	result := challenger.VerifyURL(context.Background(), server.URL, "test-secret", map[string]string{"Authorization": "Bearer token"})
+	if !result.Success {
+		t.Errorf("expected success with the custom header, got: %s", result.ErrorMessage)
+	}
+}
+
+func TestVerifyURL_RefusedAddress(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("challenge should not reach a refused address")
+	}))
+	defer server.Close()
+
+	resolver := NewResolver(WithAddressCheck(func(ip net.IP) error {
+		return errors.New("loopback is not allowed")
+	}))
+	challenger := NewChallenger(2*time.Second, WithResolver(resolver))
+	result := challenger.VerifyURL(context.Background(), server.URL, "test-secret", nil)
+
+	if result.Success {
+		t.Error("expected failure for a refused address")
+	}
+}
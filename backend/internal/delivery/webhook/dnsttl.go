@@ -0,0 +1,187 @@
+package webhook
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"sync"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// ttlResolver is the Go resolver with a dialer that lets the DNS responses
+// it receives be inspected for their TTLs, which net.Resolver does not expose.
+var ttlResolver = &net.Resolver{
+	PreferGo: true,
+	Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+		var d net.Dialer
+		conn, err := d.DialContext(ctx, network, address)
+		if err != nil {
+			return nil, err
+		}
+		return wrapDNSConn(ctx, conn), nil
+	},
+}
+
+// wrapDNSConn wraps a connection to a DNS server so that responses are
+// observed by the ttlRecorder of the lookup in ctx, if any
+func wrapDNSConn(ctx context.Context, conn net.Conn) net.Conn {
+	rec, ok := ctx.Value(ttlRecorderKey{}).(*ttlRecorder)
+	if !ok {
+		return conn
+	}
+	// The resolver tells UDP from TCP by whether the conn is a PacketConn
+	if pc, ok := conn.(net.PacketConn); ok {
+		return &ttlPacketConn{ttlConn: ttlConn{Conn: conn, rec: rec}, pc: pc}
+	}
+	return &ttlConn{Conn: conn, rec: rec, stream: true}
+}
+
+// systemLookup resolves hosts with the system configuration
+var systemLookup = lookupWithTTL(ttlResolver)
+
+// lookupWithTTL returns a lookupFunc that resolves with resolver and reports
+// the smallest TTL seen in the responses, or unknownTTL if none was seen
+// (e.g., the host is in /etc/hosts). The resolver must dial with wrapDNSConn.
+func lookupWithTTL(resolver *net.Resolver) lookupFunc {
+	return func(ctx context.Context, host string) ([]net.IPAddr, time.Duration, error) {
+		rec := &ttlRecorder{}
+		addrs, err := resolver.LookupIPAddr(context.WithValue(ctx, ttlRecorderKey{}, rec), host)
+		return addrs, rec.ttl(), err
+	}
+}
+
+// ttlRecorderKey is the context key of the ttlRecorder of a lookup
+type ttlRecorderKey struct{}
+
+// ttlRecorder collects the minimum TTL of the DNS responses of one lookup
+type ttlRecorder struct {
+	mu     sync.Mutex
+	min    uint32
+	seen   bool
+	parser dnsmessage.Parser
+}
+
+// ttl returns the minimum TTL observed, or unknownTTL
+func (r *ttlRecorder) ttl() time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.seen {
+		return unknownTTL
+	}
+	return time.Duration(r.min) * time.Second
+}
+
+// add records a TTL in seconds
+func (r *ttlRecorder) add(ttl uint32) {
+	if !r.seen || ttl < r.min {
+		r.min = ttl
+		r.seen = true
+	}
+}
+
+// observe records the TTL of a DNS response. Answers count with their own TTL;
+// responses without answers (NXDOMAIN, NODATA) count with the negative caching
+// TTL of the SOA record in the authority section (RFC 2308).
+func (r *ttlRecorder) observe(msg []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	p := &r.parser
+	h, err := p.Start(msg)
+	if err != nil || !h.Response {
+		return
+	}
+	if err := p.SkipAllQuestions(); err != nil {
+		return
+	}
+
+	answered := false
+	for {
+		ah, err := p.AnswerHeader()
+		if err != nil {
+			break
+		}
+		answered = true
+		r.add(ah.TTL)
+		if err := p.SkipAnswer(); err != nil {
+			return
+		}
+	}
+	if answered {
+		return
+	}
+
+	if err := p.SkipAllAnswers(); err != nil {
+		return
+	}
+	for {
+		ah, err := p.AuthorityHeader()
+		if err != nil {
+			return
+		}
+		if ah.Type != dnsmessage.TypeSOA {
+			if err := p.SkipAuthority(); err != nil {
+				return
+			}
+			continue
+		}
+		soa, err := p.SOAResource()
+		if err != nil {
+			return
+		}
+		r.add(min(ah.TTL, soa.MinTTL))
+	}
+}
+
+// ttlConn passes DNS responses read from the connection to a ttlRecorder
+type ttlConn struct {
+	net.Conn
+	rec    *ttlRecorder
+	stream bool   // TCP: messages are prefixed with their length
+	buf    []byte // Partial TCP message
+}
+
+// Read reads from the connection, observing every complete DNS message
+func (c *ttlConn) Read(p []byte) (int, error) {
+	n, err := c.Conn.Read(p)
+	if n <= 0 {
+		return n, err
+	}
+	if !c.stream {
+		c.rec.observe(p[:n])
+		return n, err
+	}
+
+	c.buf = append(c.buf, p[:n]...)
+	for len(c.buf) >= 2 {
+		size := int(binary.BigEndian.Uint16(c.buf))
+		if len(c.buf) < 2+size {
+			break
+		}
+		c.rec.observe(c.buf[2 : 2+size])
+		c.buf = c.buf[2+size:]
+	}
+	return n, err
+}
+
+// ttlPacketConn is a ttlConn for UDP
+type ttlPacketConn struct {
+	ttlConn
+	pc net.PacketConn
+}
+
+// ReadFrom reads a packet, observing it as a DNS message
+func (c *ttlPacketConn) ReadFrom(p []byte) (int, net.Addr, error) {
+	n, addr, err := c.pc.ReadFrom(p)
+	if n > 0 {
+		c.rec.observe(p[:n])
+	}
+	return n, addr, err
+}
+
+// WriteTo writes a packet
+func (c *ttlPacketConn) WriteTo(p []byte, addr net.Addr) (int, error) {
+	return c.pc.WriteTo(p, addr)
+}
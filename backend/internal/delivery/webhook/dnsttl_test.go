@@ -0,0 +1,222 @@
+package webhook
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// buildResponse builds a DNS response to q with A answers of the given TTLs,
+// or an SOA authority record when rcode is NXDOMAIN
+func buildResponse(t *testing.T, id uint16, q dnsmessage.Question, rcode dnsmessage.RCode, ttls ...uint32) []byte {
+	t.Helper()
+	b := dnsmessage.NewBuilder(nil, dnsmessage.Header{ID: id, Response: true, RCode: rcode})
+	b.EnableCompression()
+	if err := b.StartQuestions(); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Question(q); err != nil {
+		t.Fatal(err)
+	}
+
+	if rcode == dnsmessage.RCodeNameError {
+		if err := b.StartAuthorities(); err != nil {
+			t.Fatal(err)
+		}
+		soa := dnsmessage.SOAResource{
+			NS:     dnsmessage.MustNewName("ns.example.com."),
+			MBox:   dnsmessage.MustNewName("admin.example.com."),
+			MinTTL: 300,
+		}
+		if err := b.SOAResource(dnsmessage.ResourceHeader{Name: dnsmessage.MustNewName("example.com."), Class: dnsmessage.ClassINET, TTL: 900}, soa); err != nil {
+			t.Fatal(err)
+		}
+	} else if q.Type == dnsmessage.TypeA {
+		if err := b.StartAnswers(); err != nil {
+			t.Fatal(err)
+		}
+		for _, ttl := range ttls {
+			if err := b.AResource(dnsmessage.ResourceHeader{Name: q.Name, Class: dnsmessage.ClassINET, TTL: ttl}, dnsmessage.AResource{A: [4]byte{127, 0, 0, 1}}); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	msg, err := b.Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return msg
+}
+
+func TestTTLRecorder_Observe(t *testing.T) {
+	q := dnsmessage.Question{Name: dnsmessage.MustNewName("hooks.example.com."), Type: dnsmessage.TypeA, Class: dnsmessage.ClassINET}
+
+	tests := []struct {
+		name     string
+		messages [][]byte
+		want     time.Duration
+	}{
+		{
+			name:     "minimum of answers",
+			messages: [][]byte{buildResponse(t, 1, q, dnsmessage.RCodeSuccess, 120, 60, 300)},
+			want:     60 * time.Second,
+		},
+		{
+			name:     "NXDOMAIN uses SOA minimum",
+			messages: [][]byte{buildResponse(t, 1, q, dnsmessage.RCodeNameError)},
+			want:     300 * time.Second,
+		},
+		{
+			name: "minimum across responses",
+			messages: [][]byte{
+				buildResponse(t, 1, q, dnsmessage.RCodeSuccess, 120),
+				buildResponse(t, 2, q, dnsmessage.RCodeSuccess, 45),
+			},
+			want: 45 * time.Second,
+		},
+		{
+			name:     "garbage is ignored",
+			messages: [][]byte{[]byte("not dns")},
+			want:     unknownTTL,
+		},
+		{
+			name: "no responses",
+			want: unknownTTL,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := &ttlRecorder{}
+			for _, msg := range tt.messages {
+				rec.observe(msg)
+			}
+			if got := rec.ttl(); got != tt.want {
+				t.Errorf("ttl() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTTLConn_Stream(t *testing.T) {
+	q := dnsmessage.Question{Name: dnsmessage.MustNewName("hooks.example.com."), Type: dnsmessage.TypeA, Class: dnsmessage.ClassINET}
+	msg := buildResponse(t, 1, q, dnsmessage.RCodeSuccess, 42)
+	framed := binary.BigEndian.AppendUint16(nil, uint16(len(msg)))
+	framed = append(framed, msg...)
+
+	client, server := net.Pipe()
+	defer client.Close()
+	rec := &ttlRecorder{}
+	conn := wrapDNSConn(context.WithValue(context.Background(), ttlRecorderKey{}, rec), client)
+
+	// Deliver the framed message in small pieces
+	go func() {
+		for i := 0; i < len(framed); i += 7 {
+			end := min(i+7, len(framed))
+			_, _ = server.Write(framed[i:end])
+		}
+		server.Close()
+	}()
+
+	buf := make([]byte, 5)
+	for {
+		if _, err := conn.Read(buf); err != nil {
+			break
+		}
+	}
+	if got := rec.ttl(); got != 42*time.Second {
+		t.Errorf("ttl() = %v, want 42s", got)
+	}
+}
+
+func TestWrapDNSConn_NoRecorder(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+
+	if conn := wrapDNSConn(context.Background(), client); conn != client {
+		t.Error("wrapDNSConn() wrapped a connection without a recorder")
+	}
+}
+
+// serveDNS answers A queries on a local UDP socket with the given TTL
+// and NXDOMAIN for names starting with "missing"
+func serveDNS(t *testing.T, ttl uint32) string {
+	t.Helper()
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	t.Cleanup(func() { pc.Close() })
+
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, addr, err := pc.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			var p dnsmessage.Parser
+			h, err := p.Start(buf[:n])
+			if err != nil {
+				continue
+			}
+			q, err := p.Question()
+			if err != nil {
+				continue
+			}
+			rcode := dnsmessage.RCodeSuccess
+			if strings.HasPrefix(q.Name.String(), "missing") {
+				rcode = dnsmessage.RCodeNameError
+			}
+			_, _ = pc.WriteTo(buildResponse(t, h.ID, q, rcode, ttl), addr)
+		}
+	}()
+	return pc.LocalAddr().String()
+}
+
+func TestLookupWithTTL(t *testing.T) {
+	server := serveDNS(t, 77)
+
+	// A resolver like ttlResolver that talks to the local server
+	resolver := &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+			var d net.Dialer
+			conn, err := d.DialContext(ctx, "udp", server)
+			if err != nil {
+				return nil, err
+			}
+			return wrapDNSConn(ctx, conn), nil
+		},
+	}
+	lookup := lookupWithTTL(resolver)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	addrs, ttl, err := lookup(ctx, "hooks.example.com.")
+	if err != nil {
+		t.Fatalf("lookup error = %v", err)
+	}
+	if len(addrs) != 1 || !addrs[0].IP.Equal(net.IPv4(127, 0, 0, 1)) {
+		t.Errorf("addrs = %v, want [127.0.0.1]", addrs)
+	}
+	if ttl != 77*time.Second {
+		t.Errorf("ttl = %v, want 77s", ttl)
+	}
+
+	_, ttl, err = lookup(ctx, "missing.example.com.")
+	if !isNotFound(err) {
+		t.Fatalf("lookup error = %v, want not found", err)
+	}
+	if ttl != 300*time.Second {
+		t.Errorf("negative ttl = %v, want 300s", ttl)
+	}
+}
@@ -0,0 +1,73 @@
+package webhook
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"golang.org/x/net/http/httpguts"
+)
+
+// MaxCustomHeaders is the maximum number of custom headers per target
+const MaxCustomHeaders = 20
+
+// MaxCustomHeaderValueLength is the maximum length of a custom header value
+const MaxCustomHeaderValueLength = 1024
+
+// bannedHeaders are managed by the HTTP client or by the sender itself
+// (content type, signatures) and cannot be set as custom headers.
+// Keys are in canonical form.
+var bannedHeaders = map[string]bool{
+	"Host":                     true,
+	"Content-Length":           true,
+	"Content-Type":             true,
+	"Transfer-Encoding":        true,
+	"Connection":               true,
+	"User-Agent":               true,
+	"X-Signature-256":          true,
+	"X-Signature-256-Previous": true,
+	"X-Signature-Timestamp":    true,
+	"X-Delivery-Id":            true,
+	"X-Event-Id":               true,
+}
+
+// reservedHeaderPrefix is reserved for headers namazu adds (RedeliveryHeader, TestHeader, BackfillHeader)
+const reservedHeaderPrefix = "X-Namazu-"
+
+// ValidateHeaders checks custom headers for a target: names and values must be
+// valid HTTP, and headers the sender manages (Host, Content-Length, signatures, ...)
+// may not be overridden.
+func ValidateHeaders(headers map[string]string) error {
+	if len(headers) > MaxCustomHeaders {
+		return fmt.Errorf("at most %d headers are allowed", MaxCustomHeaders)
+	}
+	for name, value := range headers {
+		if !httpguts.ValidHeaderFieldName(name) {
+			return fmt.Errorf("invalid header name %q", name)
+		}
+		canonical := http.CanonicalHeaderKey(name)
+		if bannedHeaders[canonical] || strings.HasPrefix(canonical, reservedHeaderPrefix) {
+			return fmt.Errorf("header %s cannot be set", canonical)
+		}
+		if !httpguts.ValidHeaderFieldValue(value) {
+			return fmt.Errorf("invalid value for header %s", canonical)
+		}
+		if len(value) > MaxCustomHeaderValueLength {
+			return fmt.Errorf("value for header %s exceeds %d bytes", canonical, MaxCustomHeaderValueLength)
+		}
+	}
+	return nil
+}
+
+// setCustomHeaders adds a target's custom headers to a request.
+// Banned headers are skipped in case they were stored before validation
+// tightened; the sender sets its own headers afterwards in any case.
+func setCustomHeaders(header http.Header, headers map[string]string) {
+	for name, value := range headers {
+		canonical := http.CanonicalHeaderKey(name)
+		if bannedHeaders[canonical] || strings.HasPrefix(canonical, reservedHeaderPrefix) {
+			continue
+		}
+		header.Set(canonical, value)
+	}
+}
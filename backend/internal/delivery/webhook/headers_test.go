@@ -0,0 +1,73 @@
+package webhook
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestValidateHeaders(t *testing.T) {
+	tooMany := map[string]string{}
+	for i := 0; i <= MaxCustomHeaders; i++ {
+		tooMany["X-Custom-"+strings.Repeat("a", i+1)] = "v"
+	}
+
+	tests := []struct {
+		name    string
+		headers map[string]string
+		wantErr bool
+	}{
+		{"nil", nil, false},
+		{"authorization", map[string]string{"Authorization": "Bearer token", "X-Route": "quake"}, false},
+		{"host", map[string]string{"Host": "example.com"}, true},
+		{"content length lowercase", map[string]string{"content-length": "10"}, true},
+		{"signature", map[string]string{"X-Signature-256": "sha256=forged"}, true},
+		{"reserved prefix", map[string]string{"X-Namazu-Test": "true"}, true},
+		{"invalid name", map[string]string{"Bad Header": "v"}, true},
+		{"header injection", map[string]string{"X-Route": "a\r\nHost: evil"}, true},
+		{"value too long", map[string]string{"X-Route": strings.Repeat("a", MaxCustomHeaderValueLength+1)}, true},
+		{"too many", tooMany, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateHeaders(tt.headers)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateHeaders() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestSendAll_CustomHeaders verifies custom headers are sent but cannot
+// override the headers the sender manages
+func TestSendAll_CustomHeaders(t *testing.T) {
+	var got http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	sender := NewSender()
+	sender.SendAll(context.Background(), []Target{{
+		URL:    server.URL,
+		Secret: "s",
+		Headers: map[string]string{
+			"authorization":   "Bearer token",
+			"X-Signature-256": "sha256=forged",
+			"Content-Type":    "text/plain",
+		},
+	}}, []byte(`{}`))
+
+	if got.Get("Authorization") != "Bearer token" {
+		t.Errorf("Authorization = %q, want the custom header", got.Get("Authorization"))
+	}
+	if got.Get("X-Signature-256") != Sign("s", []byte(`{}`)) {
+		t.Errorf("X-Signature-256 = %q, want the real signature", got.Get("X-Signature-256"))
+	}
+	if got.Get("Content-Type") != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", got.Get("Content-Type"))
+	}
+}
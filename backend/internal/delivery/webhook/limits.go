@@ -0,0 +1,232 @@
+package webhook
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Defaults of the sender's limits
+const (
+	DefaultMaxInFlight            = 512      // Requests in flight across all hosts
+	DefaultMaxPerHost             = 32       // Requests in flight to one host
+	DefaultMaxIdleConnsPerHost    = 32       // Kept-alive connections per host
+	DefaultMaxResponseHeaderBytes = 64 << 10 // Response header size
+	DefaultMaxResponseBodyBytes   = 64 << 10 // Response body read for connection reuse
+	DefaultResponseCaptureBytes   = 1024     // Response body kept in DeliveryResult
+)
+
+// Limits caps how many requests a Sender has in flight at once, so that an
+// event fanned out to thousands of subscriptions cannot exhaust sockets or
+// flood a receiver that many subscriptions point at. Requests over a limit
+// wait for a slot. Zero fields are unlimited, except MaxIdleConnsPerHost
+// and MaxResponseHeaderBytes where zero keeps the net/http default.
+//
+// Limits also bounds what a receiver can make the sender read: responses
+// with larger headers fail, and no more than MaxResponseBodyBytes of a body
+// is read. Of that, the first ResponseCaptureBytes are kept in
+// DeliveryResult.ResponseBody so error details of the receiver can be shown.
+// The rest is discarded to reuse the connection; a connection whose body is
+// longer is closed instead. If both are zero the body is not read.
+type Limits struct {
+	MaxInFlight            int // Requests in flight across all hosts
+	MaxPerHost             int // Requests in flight to one host (host:port of the URL)
+	MaxIdleConnsPerHost    int // Kept-alive connections per host for reuse
+	MaxResponseHeaderBytes int // Size of the response headers
+	MaxResponseBodyBytes   int // Bytes read from a response body
+	ResponseCaptureBytes   int // Leading bytes of a response body kept in the result
+}
+
+// DefaultLimits returns the limits used by the service
+func DefaultLimits() Limits {
+	return Limits{
+		MaxInFlight:            DefaultMaxInFlight,
+		MaxPerHost:             DefaultMaxPerHost,
+		MaxIdleConnsPerHost:    DefaultMaxIdleConnsPerHost,
+		MaxResponseHeaderBytes: DefaultMaxResponseHeaderBytes,
+		MaxResponseBodyBytes:   DefaultMaxResponseBodyBytes,
+		ResponseCaptureBytes:   DefaultResponseCaptureBytes,
+	}
+}
+
+// LimitStats is a snapshot of the sender's concurrency for monitoring
+type LimitStats struct {
+	MaxInFlight int              `json:"max_in_flight"` // 0 is unlimited
+	MaxPerHost  int              `json:"max_per_host"`  // 0 is unlimited
+	InFlight    int64            `json:"in_flight"`     // Requests being sent
+	Waiting     int64            `json:"waiting"`       // Requests waiting for a slot
+	Queued      int64            `json:"queued"`        // Requests that had to wait since start
+	QueuedMs    int64            `json:"queued_ms"`     // Total time spent waiting for a slot
+	Hosts       []HostLimitStats `json:"hosts"`         // Hosts with requests in flight or waiting, busiest first
+}
+
+// HostLimitStats is the concurrency of one destination host
+type HostLimitStats struct {
+	Host     string `json:"host"`
+	InFlight int    `json:"in_flight"`
+	Waiting  int    `json:"waiting"`
+}
+
+// limiter hands out request slots under Limits.
+//
+// A request takes a slot of its host first and then a global one, so requests
+// queued behind a busy host do not hold global slots other hosts could use.
+type limiter struct {
+	limits Limits
+	global chan struct{} // nil if unlimited
+
+	mu    sync.Mutex
+	hosts map[string]*hostSlots // hosts with requests in flight or waiting
+
+	inFlight int64
+	waiting  int64
+	queued   int64
+	queuedNs int64
+}
+
+// hostSlots tracks one host. It is removed from limiter.hosts once unused.
+type hostSlots struct {
+	slots    chan struct{} // nil if unlimited
+	users    int           // requests in flight or waiting
+	inFlight int
+	waiting  int
+}
+
+// newLimiter returns a limiter for l
+func newLimiter(l Limits) *limiter {
+	lim := &limiter{limits: l, hosts: make(map[string]*hostSlots)}
+	if l.MaxInFlight > 0 {
+		lim.global = make(chan struct{}, l.MaxInFlight)
+	}
+	return lim
+}
+
+// acquire waits for a slot to send a request to host. It returns the function
+// that releases the slot, or ctx.Err() if ctx is done before a slot is free.
+func (l *limiter) acquire(ctx context.Context, host string) (func(), error) {
+	h := l.join(host)
+	waiting := func(delta int) {
+		l.mu.Lock()
+		h.waiting += delta
+		l.mu.Unlock()
+		atomic.AddInt64(&l.waiting, int64(delta))
+	}
+
+	start := time.Now()
+	waitedHost, err := take(ctx, h.slots, waiting)
+	if err != nil {
+		l.leave(host, h, false)
+		return nil, err
+	}
+	waitedGlobal, err := take(ctx, l.global, waiting)
+	if err != nil {
+		if h.slots != nil {
+			<-h.slots
+		}
+		l.leave(host, h, false)
+		return nil, err
+	}
+	if waitedHost || waitedGlobal {
+		atomic.AddInt64(&l.queued, 1)
+		atomic.AddInt64(&l.queuedNs, int64(time.Since(start)))
+	}
+
+	l.mu.Lock()
+	h.inFlight++
+	l.mu.Unlock()
+	atomic.AddInt64(&l.inFlight, 1)
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			if l.global != nil {
+				<-l.global
+			}
+			if h.slots != nil {
+				<-h.slots
+			}
+			atomic.AddInt64(&l.inFlight, -1)
+			l.leave(host, h, true)
+		})
+	}, nil
+}
+
+// take acquires a slot of sem, reporting a blocking wait through waiting(+1)
+// and waiting(-1). It returns whether it had to wait. A nil sem is unlimited.
+func take(ctx context.Context, sem chan struct{}, waiting func(delta int)) (bool, error) {
+	if sem == nil {
+		return false, nil
+	}
+	select {
+	case sem <- struct{}{}:
+		return false, nil
+	default:
+	}
+
+	waiting(1)
+	defer waiting(-1)
+	select {
+	case sem <- struct{}{}:
+		return true, nil
+	case <-ctx.Done():
+		return true, ctx.Err()
+	}
+}
+
+// join returns the slots of host, registering one more user
+func (l *limiter) join(host string) *hostSlots {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	h, ok := l.hosts[host]
+	if !ok {
+		h = &hostSlots{}
+		if l.limits.MaxPerHost > 0 {
+			h.slots = make(chan struct{}, l.limits.MaxPerHost)
+		}
+		l.hosts[host] = h
+	}
+	h.users++
+	return h
+}
+
+// leave unregisters a user of host, which was in flight if sent is true
+func (l *limiter) leave(host string, h *hostSlots, sent bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if sent {
+		h.inFlight--
+	}
+	h.users--
+	if h.users == 0 {
+		delete(l.hosts, host)
+	}
+}
+
+// stats returns the current concurrency
+func (l *limiter) stats() LimitStats {
+	l.mu.Lock()
+	hosts := make([]HostLimitStats, 0, len(l.hosts))
+	for host, h := range l.hosts {
+		hosts = append(hosts, HostLimitStats{Host: host, InFlight: h.inFlight, Waiting: h.waiting})
+	}
+	l.mu.Unlock()
+	sort.Slice(hosts, func(i, j int) bool {
+		bi, bj := hosts[i].InFlight+hosts[i].Waiting, hosts[j].InFlight+hosts[j].Waiting
+		if bi != bj {
+			return bi > bj
+		}
+		return hosts[i].Host < hosts[j].Host
+	})
+
+	return LimitStats{
+		MaxInFlight: l.limits.MaxInFlight,
+		MaxPerHost:  l.limits.MaxPerHost,
+		InFlight:    atomic.LoadInt64(&l.inFlight),
+		Waiting:     atomic.LoadInt64(&l.waiting),
+		Queued:      atomic.LoadInt64(&l.queued),
+		QueuedMs:    time.Duration(atomic.LoadInt64(&l.queuedNs)).Milliseconds(),
+		Hosts:       hosts,
+	}
+}
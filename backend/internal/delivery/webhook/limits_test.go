@@ -0,0 +1,259 @@
+package webhook
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// concurrency records the most requests handled at once
+type concurrency struct {
+	current int32
+	peak    int32
+}
+
+func (c *concurrency) enter() {
+	n := atomic.AddInt32(&c.current, 1)
+	for {
+		peak := atomic.LoadInt32(&c.peak)
+		if n <= peak || atomic.CompareAndSwapInt32(&c.peak, peak, n) {
+			return
+		}
+	}
+}
+
+func (c *concurrency) leave() {
+	atomic.AddInt32(&c.current, -1)
+}
+
+// newConcurrencyServer returns a server that records its concurrency in c and in total
+func newConcurrencyServer(c, total *concurrency, delay time.Duration) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c.enter()
+		total.enter()
+		time.Sleep(delay)
+		total.leave()
+		c.leave()
+		w.WriteHeader(http.StatusOK)
+	}))
+}
+
+func TestSender_Limits(t *testing.T) {
+	tests := []struct {
+		name      string
+		limits    Limits
+		wantHost  int32 // peak per server
+		wantTotal int32 // peak across both servers
+	}{
+		{"per host", Limits{MaxPerHost: 2}, 2, 4},
+		{"global", Limits{MaxInFlight: 3}, 3, 3},
+		{"both", Limits{MaxInFlight: 3, MaxPerHost: 1}, 1, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var a, b, total concurrency
+			serverA := newConcurrencyServer(&a, &total, 20*time.Millisecond)
+			defer serverA.Close()
+			serverB := newConcurrencyServer(&b, &total, 20*time.Millisecond)
+			defer serverB.Close()
+
+			var targets []Target
+			for range 8 {
+				targets = append(targets, Target{URL: serverA.URL, Secret: "s"}, Target{URL: serverB.URL, Secret: "s"})
+			}
+
+			sender := NewSender(WithLimits(tt.limits))
+			for i, result := range sender.SendAll(context.Background(), targets, []byte(`{}`)) {
+				if !result.Success {
+					t.Fatalf("target %d failed: %s", i, result.ErrorMessage)
+				}
+			}
+
+			if a.peak > tt.wantHost || b.peak > tt.wantHost {
+				t.Errorf("peak concurrency = %d / %d, want at most %d per host", a.peak, b.peak, tt.wantHost)
+			}
+			if total.peak > tt.wantTotal {
+				t.Errorf("peak concurrency = %d, want at most %d in flight", total.peak, tt.wantTotal)
+			}
+
+			stats := sender.Stats()
+			if stats.InFlight != 0 || stats.Waiting != 0 || len(stats.Hosts) != 0 {
+				t.Errorf("expected an idle sender after delivery, got %+v", stats)
+			}
+			if stats.Queued == 0 {
+				t.Error("expected requests to have queued for a slot")
+			}
+		})
+	}
+}
+
+func TestSender_LimitsStats(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	sender := NewSender(WithLimits(Limits{MaxInFlight: 10, MaxPerHost: 1}))
+	var wg sync.WaitGroup
+	for range 3 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sender.sendTarget(context.Background(), Target{URL: server.URL, Secret: "s"}, []byte(`{}`))
+		}()
+	}
+
+	deadline := time.Now().Add(time.Second)
+	var stats LimitStats
+	for time.Now().Before(deadline) {
+		if stats = sender.Stats(); stats.Waiting == 2 {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if stats.InFlight != 1 || stats.Waiting != 2 {
+		t.Errorf("stats = %+v, want 1 in flight and 2 waiting", stats)
+	}
+	if len(stats.Hosts) != 1 || stats.Hosts[0].InFlight != 1 || stats.Hosts[0].Waiting != 2 {
+		t.Errorf("hosts = %+v, want the server with 1 in flight and 2 waiting", stats.Hosts)
+	}
+	if stats.MaxInFlight != 10 || stats.MaxPerHost != 1 {
+		t.Errorf("limits = %d / %d, want 10 / 1", stats.MaxInFlight, stats.MaxPerHost)
+	}
+
+	close(release)
+	wg.Wait()
+}
+
+func TestSender_LimitsCancelWhileWaiting(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+	defer close(release)
+
+	sender := NewSender(WithLimits(Limits{MaxPerHost: 1}))
+	go sender.sendTarget(context.Background(), Target{URL: server.URL, Secret: "s"}, []byte(`{}`))
+	for sender.Stats().InFlight == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	result := sender.sendTarget(ctx, Target{URL: server.URL, Secret: "s"}, []byte(`{}`))
+	if result.Success || result.StatusCode != 0 {
+		t.Fatalf("expected the waiting request to give up, got %+v", result)
+	}
+	if stats := sender.Stats(); stats.Waiting != 0 || stats.InFlight != 1 {
+		t.Errorf("stats = %+v, want the cancelled request gone", stats)
+	}
+}
+
+func TestSender_StatsWithoutLimits(t *testing.T) {
+	stats := NewSender().Stats()
+	if stats.MaxInFlight != 0 || stats.Hosts == nil {
+		t.Errorf("Stats() = %+v, want unlimited with no hosts", stats)
+	}
+}
+
+func TestSender_ResponseCapture(t *testing.T) {
+	tests := []struct {
+		name   string
+		limits *Limits
+		body   string
+		want   string
+	}{
+		{"captured", &Limits{ResponseCaptureBytes: 64}, `{"error":"invalid signature"}`, `{"error":"invalid signature"}`},
+		{"truncated", &Limits{ResponseCaptureBytes: 8}, `{"error":"invalid signature"}`, `{"error"`},
+		{"cut character dropped", &Limits{ResponseCaptureBytes: 4}, "署名", "署"},
+		{"capture disabled", &Limits{MaxResponseBodyBytes: 64}, "error", ""},
+		{"without limits", nil, "error", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			var opts []SenderOption
+			if tt.limits != nil {
+				opts = append(opts, WithLimits(*tt.limits))
+			}
+			sender := NewSender(opts...)
+
+			result := sender.SendAll(context.Background(), []Target{{URL: server.URL, Secret: "s"}}, []byte(`{}`))[0]
+			if result.StatusCode != http.StatusBadRequest {
+				t.Fatalf("StatusCode = %d, want %d", result.StatusCode, http.StatusBadRequest)
+			}
+			if result.ResponseBody != tt.want {
+				t.Errorf("ResponseBody = %q, want %q", result.ResponseBody, tt.want)
+			}
+			if got := sender.Send(context.Background(), server.URL, "s", []byte(`{}`)).ResponseBody; got != tt.want {
+				t.Errorf("Send ResponseBody = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSender_ResponseBodyReadLimit(t *testing.T) {
+	// The receiver keeps writing; the sender stops reading at the limit
+	// instead of waiting for the whole body until the timeout
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		chunk := make([]byte, 1024)
+		for i := 0; i < 1024; i++ {
+			if _, err := w.Write(chunk); err != nil {
+				return
+			}
+			w.(http.Flusher).Flush()
+			time.Sleep(time.Millisecond)
+		}
+	}))
+	defer server.Close()
+
+	sender := NewSender(WithTimeout(5*time.Second), WithLimits(Limits{MaxResponseBodyBytes: 4096, ResponseCaptureBytes: 16}))
+	start := time.Now()
+	result := sender.Send(context.Background(), server.URL, "s", []byte(`{}`))
+	if !result.Success {
+		t.Fatalf("expected success, got %+v", result)
+	}
+	if len(result.ResponseBody) != 16 {
+		t.Errorf("len(ResponseBody) = %d, want 16", len(result.ResponseBody))
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("reading the response took %v; the body limit was not applied", elapsed)
+	}
+}
+
+func TestSender_ResponseHeaderLimit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Padding", strings.Repeat("a", 8192))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	sender := NewSender(WithLimits(Limits{MaxResponseHeaderBytes: 1024}))
+	result := sender.Send(context.Background(), server.URL, "s", []byte(`{}`))
+	if result.Success {
+		t.Fatal("expected the oversized response headers to fail the delivery")
+	}
+	if !strings.Contains(result.ErrorMessage, "request failed") {
+		t.Errorf("ErrorMessage = %q", result.ErrorMessage)
+	}
+
+	sender = NewSender(WithLimits(Limits{MaxResponseHeaderBytes: 16 << 10}))
+	if result := sender.Send(context.Background(), server.URL, "s", []byte(`{}`)); !result.Success {
+		t.Errorf("expected success within the header limit, got %+v", result)
+	}
+}
@@ -0,0 +1,344 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Defaults of the caching resolver
+const (
+	DefaultResolverTTL = time.Minute      // Used when the answer carries no TTL (e.g., /etc/hosts)
+	DefaultMinTTL      = 5 * time.Second  // Lower bound of cached TTLs
+	DefaultMaxTTL      = 10 * time.Minute // Upper bound of cached TTLs
+	DefaultNegativeTTL = 10 * time.Second // How long failed lookups are remembered
+	DefaultStaleTTL    = time.Hour        // How long expired answers may be served while DNS is failing
+)
+
+// unknownTTL is returned by a lookup that could not observe a TTL
+const unknownTTL time.Duration = -1
+
+// lookupFunc resolves host and reports how long the answer may be cached
+type lookupFunc func(ctx context.Context, host string) ([]net.IPAddr, time.Duration, error)
+
+// HostStats holds resolution metrics for one host
+type HostStats struct {
+	Host                string     `json:"host"`
+	Lookups             int64      `json:"lookups"`       // Queries sent upstream
+	CacheHits           int64      `json:"cache_hits"`    // Answered from a fresh cache entry
+	NegativeHits        int64      `json:"negative_hits"` // Failed fast from a cached failure
+	StaleServed         int64      `json:"stale_served"`  // Expired answers served because DNS failed
+	Failures            int64      `json:"failures"`      // Failed upstream queries
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	LastError           string     `json:"last_error,omitempty"`
+	LastFailure         *time.Time `json:"last_failure,omitempty"`
+}
+
+// Resolver is a caching DNS resolver for outgoing webhook connections.
+//
+// Large fan-outs resolve the same hostnames over and over; the resolver keeps
+// answers for their TTL, remembers failures for a short time (negative
+// caching), collapses concurrent lookups of the same host into one query, and
+// keeps serving expired answers while the upstream DNS is failing, so that a
+// DNS hiccup does not turn into a failed delivery for every subscription.
+//
+// Resolver is safe for concurrent use by multiple goroutines.
+type Resolver struct {
+	lookup      lookupFunc
+	dialer      *net.Dialer
+	minTTL      time.Duration
+	maxTTL      time.Duration
+	negativeTTL time.Duration
+	staleTTL    time.Duration
+	checkAddr   func(net.IP) error // nil dials any address
+	now         func() time.Time
+
+	mu       sync.Mutex
+	entries  map[string]*resolverEntry
+	inflight map[string]*lookupCall
+	stats    map[string]*HostStats
+}
+
+// resolverEntry is a cached answer or failure
+type resolverEntry struct {
+	addrs      []net.IPAddr
+	err        error
+	expires    time.Time
+	staleUntil time.Time // Answers only
+}
+
+// lookupCall is an upstream lookup shared by concurrent callers
+type lookupCall struct {
+	done  chan struct{}
+	addrs []net.IPAddr
+	err   error
+}
+
+// ResolverOption configures the Resolver
+type ResolverOption func(*Resolver)
+
+// WithTTLBounds clamps the TTLs of cached answers to [min, max].
+func WithTTLBounds(min, max time.Duration) ResolverOption {
+	return func(r *Resolver) {
+		r.minTTL = min
+		r.maxTTL = max
+	}
+}
+
+// WithNegativeTTL sets how long failed lookups are remembered.
+// NXDOMAIN answers use the TTL of the zone's SOA record instead when available.
+func WithNegativeTTL(d time.Duration) ResolverOption {
+	return func(r *Resolver) {
+		r.negativeTTL = d
+	}
+}
+
+// WithStaleTTL sets how long after expiry an answer may still be served
+// while lookups of its host are failing. Zero disables serving stale answers.
+func WithStaleTTL(d time.Duration) ResolverOption {
+	return func(r *Resolver) {
+		r.staleTTL = d
+	}
+}
+
+// WithAddressCheck makes DialContext refuse addresses for which check returns
+// an error. The check applies to the address actually dialed, so a host that
+// passed URL validation cannot be rebound to an internal address afterwards.
+//
+// Example:
+//
+//	resolver := webhook.NewResolver(webhook.WithAddressCheck(func(ip net.IP) error {
+//		return security.CheckIP(ip, false)
+//	}))
+func WithAddressCheck(check func(net.IP) error) ResolverOption {
+	return func(r *Resolver) {
+		r.checkAddr = check
+	}
+}
+
+// NewResolver creates a caching resolver backed by the system resolver
+// (honoring /etc/hosts and /etc/resolv.conf).
+//
+// Example:
+//
+//	resolver := webhook.NewResolver()
+//	sender := webhook.NewSender(webhook.WithResolver(resolver))
+func NewResolver(opts ...ResolverOption) *Resolver {
+	r := &Resolver{
+		lookup:      systemLookup,
+		dialer:      &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second},
+		minTTL:      DefaultMinTTL,
+		maxTTL:      DefaultMaxTTL,
+		negativeTTL: DefaultNegativeTTL,
+		staleTTL:    DefaultStaleTTL,
+		now:         time.Now,
+		entries:     make(map[string]*resolverEntry),
+		inflight:    make(map[string]*lookupCall),
+		stats:       make(map[string]*HostStats),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// LookupIPAddr returns the addresses of host, from the cache when possible.
+func (r *Resolver) LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error) {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+
+	r.mu.Lock()
+	st := r.hostStats(host)
+	if e, ok := r.entries[host]; ok && r.now().Before(e.expires) {
+		if e.err != nil {
+			st.NegativeHits++
+		} else {
+			st.CacheHits++
+		}
+		r.mu.Unlock()
+		return e.addrs, e.err
+	}
+
+	c, ok := r.inflight[host]
+	if !ok {
+		c = &lookupCall{done: make(chan struct{})}
+		r.inflight[host] = c
+		st.Lookups++
+		// The query is shared, so it must not be cancelled with the first caller
+		go r.resolve(context.WithoutCancel(ctx), host, c)
+	}
+	r.mu.Unlock()
+
+	select {
+	case <-c.done:
+		return c.addrs, c.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// resolve performs an upstream lookup and records its outcome
+func (r *Resolver) resolve(ctx context.Context, host string, c *lookupCall) {
+	addrs, ttl, err := r.lookup(ctx, host)
+	if err == nil && len(addrs) == 0 {
+		err = &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	defer close(c.done)
+	delete(r.inflight, host)
+
+	now := r.now()
+	st := r.hostStats(host)
+	if err == nil {
+		st.ConsecutiveFailures = 0
+		expires := now.Add(r.clampTTL(ttl, DefaultResolverTTL))
+		r.entries[host] = &resolverEntry{addrs: addrs, expires: expires, staleUntil: expires.Add(r.staleTTL)}
+		c.addrs = addrs
+		return
+	}
+
+	st.Failures++
+	st.ConsecutiveFailures++
+	st.LastError = err.Error()
+	st.LastFailure = &now
+
+	notFound := isNotFound(err)
+	if prev, ok := r.entries[host]; ok && prev.err == nil && !notFound && now.Before(prev.staleUntil) {
+		// Keep serving the last answer; retry upstream after the negative TTL
+		st.StaleServed++
+		r.entries[host] = &resolverEntry{addrs: prev.addrs, expires: now.Add(r.negativeTTL), staleUntil: prev.staleUntil}
+		c.addrs = prev.addrs
+		return
+	}
+
+	negativeTTL := r.negativeTTL
+	if notFound && ttl != unknownTTL {
+		negativeTTL = r.clampTTL(ttl, r.negativeTTL)
+	}
+	r.entries[host] = &resolverEntry{err: err, expires: now.Add(negativeTTL)}
+	c.err = err
+}
+
+// DialContext connects to addr, resolving its host through the cache.
+// Addresses are tried in order until one accepts the connection; addresses
+// refused by the WithAddressCheck check are skipped.
+// It can be used as http.Transport.DialContext.
+func (r *Resolver) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		if err := r.check(ip); err != nil {
+			return nil, err
+		}
+		return r.dialer.DialContext(ctx, network, addr)
+	}
+
+	addrs, err := r.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+
+	var firstErr error
+	for _, a := range addrs {
+		if err := r.check(a.IP); err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("refusing to connect to %s: %w", host, err)
+			}
+			continue
+		}
+		conn, err := r.dialer.DialContext(ctx, network, net.JoinHostPort(a.String(), port))
+		if err == nil {
+			return conn, nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	return nil, firstErr
+}
+
+// CheckHost resolves host through the cache and returns an error if any of
+// its addresses is refused by the WithAddressCheck check. It is used where
+// the connection is made by someone else, e.g. a proxy, so that a host
+// cannot be rebound to an internal address after validation.
+func (r *Resolver) CheckHost(ctx context.Context, host string) error {
+	if ip := net.ParseIP(host); ip != nil {
+		return r.check(ip)
+	}
+	addrs, err := r.LookupIPAddr(ctx, host)
+	if err != nil {
+		return err
+	}
+	for _, a := range addrs {
+		if err := r.check(a.IP); err != nil {
+			return fmt.Errorf("refusing to connect to %s: %w", host, err)
+		}
+	}
+	return nil
+}
+
+// check returns an error if the address must not be dialed
+func (r *Resolver) check(ip net.IP) error {
+	if r.checkAddr == nil {
+		return nil
+	}
+	return r.checkAddr(ip)
+}
+
+// Stats returns resolution metrics per host, sorted by host
+func (r *Resolver) Stats() []HostStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stats := make([]HostStats, 0, len(r.stats))
+	for _, st := range r.stats {
+		s := *st
+		if st.LastFailure != nil {
+			t := *st.LastFailure
+			s.LastFailure = &t
+		}
+		stats = append(stats, s)
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Host < stats[j].Host })
+	return stats
+}
+
+// hostStats returns the metrics of host, creating them if needed.
+// Callers must hold r.mu.
+func (r *Resolver) hostStats(host string) *HostStats {
+	st, ok := r.stats[host]
+	if !ok {
+		st = &HostStats{Host: host}
+		r.stats[host] = st
+	}
+	return st
+}
+
+// clampTTL bounds ttl, substituting fallback when it is unknown
+func (r *Resolver) clampTTL(ttl, fallback time.Duration) time.Duration {
+	if ttl == unknownTTL {
+		return fallback
+	}
+	if ttl < r.minTTL {
+		return r.minTTL
+	}
+	if ttl > r.maxTTL {
+		return r.maxTTL
+	}
+	return ttl
+}
+
+// isNotFound reports whether err says the host does not exist (NXDOMAIN),
+// as opposed to a temporary failure of the DNS server
+func isNotFound(err error) bool {
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr) && dnsErr.IsNotFound
+}
@@ -0,0 +1,390 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeLookup is a lookupFunc with scripted answers
+type fakeLookup struct {
+	mu    sync.Mutex
+	calls int
+	addrs []net.IPAddr
+	ttl   time.Duration
+	err   error
+	block chan struct{} // If set, lookups wait for it to close
+}
+
+func (f *fakeLookup) lookup(ctx context.Context, host string) ([]net.IPAddr, time.Duration, error) {
+	f.mu.Lock()
+	f.calls++
+	block := f.block
+	addrs, ttl, err := f.addrs, f.ttl, f.err
+	f.mu.Unlock()
+	if block != nil {
+		<-block
+	}
+	return addrs, ttl, err
+}
+
+func (f *fakeLookup) set(addrs []net.IPAddr, ttl time.Duration, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.addrs, f.ttl, f.err = addrs, ttl, err
+}
+
+func (f *fakeLookup) count() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls
+}
+
+// fakeClock is a manually advanced clock
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func newTestResolver(f *fakeLookup, opts ...ResolverOption) (*Resolver, *fakeClock) {
+	clock := &fakeClock{now: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+	r := NewResolver(opts...)
+	r.lookup = f.lookup
+	r.now = clock.Now
+	return r, clock
+}
+
+var testAddrs = []net.IPAddr{{IP: net.ParseIP("192.0.2.1")}}
+
+var errServFail = &net.DNSError{Err: "server misbehaving", Name: "hooks.example.com", IsTemporary: true}
+
+func TestResolver_CachesForTTL(t *testing.T) {
+	f := &fakeLookup{addrs: testAddrs, ttl: 30 * time.Second}
+	r, clock := newTestResolver(f)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		addrs, err := r.LookupIPAddr(ctx, "Hooks.Example.com.")
+		if err != nil {
+			t.Fatalf("LookupIPAddr() error = %v", err)
+		}
+		if len(addrs) != 1 || !addrs[0].IP.Equal(testAddrs[0].IP) {
+			t.Errorf("LookupIPAddr() = %v, want %v", addrs, testAddrs)
+		}
+	}
+	if got := f.count(); got != 1 {
+		t.Errorf("lookups = %d, want 1 (cached)", got)
+	}
+
+	clock.Advance(29 * time.Second)
+	_, _ = r.LookupIPAddr(ctx, "hooks.example.com")
+	if got := f.count(); got != 1 {
+		t.Errorf("lookups before expiry = %d, want 1", got)
+	}
+
+	clock.Advance(2 * time.Second)
+	_, _ = r.LookupIPAddr(ctx, "hooks.example.com")
+	if got := f.count(); got != 2 {
+		t.Errorf("lookups after expiry = %d, want 2", got)
+	}
+}
+
+func TestResolver_ClampsTTL(t *testing.T) {
+	tests := []struct {
+		name string
+		ttl  time.Duration
+		want time.Duration
+	}{
+		{name: "zero uses minimum", ttl: 0, want: time.Second},
+		{name: "large uses maximum", ttl: 24 * time.Hour, want: time.Minute},
+		{name: "unknown uses default", ttl: unknownTTL, want: DefaultResolverTTL},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &fakeLookup{addrs: testAddrs, ttl: tt.ttl}
+			r, clock := newTestResolver(f, WithTTLBounds(time.Second, time.Minute))
+			ctx := context.Background()
+
+			_, _ = r.LookupIPAddr(ctx, "hooks.example.com")
+			clock.Advance(tt.want - time.Millisecond)
+			_, _ = r.LookupIPAddr(ctx, "hooks.example.com")
+			if got := f.count(); got != 1 {
+				t.Errorf("lookups just before %v = %d, want 1", tt.want, got)
+			}
+			clock.Advance(time.Millisecond)
+			_, _ = r.LookupIPAddr(ctx, "hooks.example.com")
+			if got := f.count(); got != 2 {
+				t.Errorf("lookups at %v = %d, want 2", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestResolver_NegativeCache(t *testing.T) {
+	f := &fakeLookup{err: errServFail, ttl: unknownTTL}
+	r, clock := newTestResolver(f, WithNegativeTTL(10*time.Second))
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if _, err := r.LookupIPAddr(ctx, "hooks.example.com"); !errors.Is(err, errServFail) {
+			t.Fatalf("LookupIPAddr() error = %v, want %v", err, errServFail)
+		}
+	}
+	if got := f.count(); got != 1 {
+		t.Errorf("lookups = %d, want 1 (negative cached)", got)
+	}
+
+	f.set(testAddrs, time.Minute, nil)
+	clock.Advance(10 * time.Second)
+	if _, err := r.LookupIPAddr(ctx, "hooks.example.com"); err != nil {
+		t.Errorf("LookupIPAddr() after negative TTL error = %v", err)
+	}
+	if got := f.count(); got != 2 {
+		t.Errorf("lookups = %d, want 2", got)
+	}
+}
+
+func TestResolver_NotFoundUsesSOATTL(t *testing.T) {
+	nxdomain := &net.DNSError{Err: "no such host", Name: "typo.example.com", IsNotFound: true}
+	f := &fakeLookup{err: nxdomain, ttl: 2 * time.Minute}
+	r, clock := newTestResolver(f, WithNegativeTTL(10*time.Second))
+	ctx := context.Background()
+
+	_, _ = r.LookupIPAddr(ctx, "typo.example.com")
+	clock.Advance(time.Minute)
+	if _, err := r.LookupIPAddr(ctx, "typo.example.com"); !isNotFound(err) {
+		t.Errorf("LookupIPAddr() error = %v, want not found", err)
+	}
+	if got := f.count(); got != 1 {
+		t.Errorf("lookups = %d, want 1 (cached for SOA TTL)", got)
+	}
+}
+
+func TestResolver_EmptyAnswerIsNotFound(t *testing.T) {
+	f := &fakeLookup{ttl: unknownTTL}
+	r, _ := newTestResolver(f)
+
+	if _, err := r.LookupIPAddr(context.Background(), "hooks.example.com"); !isNotFound(err) {
+		t.Errorf("LookupIPAddr() error = %v, want not found", err)
+	}
+}
+
+func TestResolver_ServesStaleOnFailure(t *testing.T) {
+	f := &fakeLookup{addrs: testAddrs, ttl: 30 * time.Second}
+	r, clock := newTestResolver(f, WithNegativeTTL(10*time.Second), WithStaleTTL(time.Hour))
+	ctx := context.Background()
+
+	_, _ = r.LookupIPAddr(ctx, "hooks.example.com")
+
+	// Upstream DNS fails after the answer expired
+	f.set(nil, unknownTTL, errServFail)
+	clock.Advance(time.Minute)
+	addrs, err := r.LookupIPAddr(ctx, "hooks.example.com")
+	if err != nil {
+		t.Fatalf("LookupIPAddr() error = %v, want stale answer", err)
+	}
+	if len(addrs) != 1 || !addrs[0].IP.Equal(testAddrs[0].IP) {
+		t.Errorf("LookupIPAddr() = %v, want %v", addrs, testAddrs)
+	}
+
+	// The stale answer is reused without querying until the negative TTL passes
+	_, _ = r.LookupIPAddr(ctx, "hooks.example.com")
+	if got := f.count(); got != 2 {
+		t.Errorf("lookups = %d, want 2", got)
+	}
+
+	// Past the stale window the failure is returned
+	clock.Advance(2 * time.Hour)
+	if _, err := r.LookupIPAddr(ctx, "hooks.example.com"); err == nil {
+		t.Error("LookupIPAddr() error = nil after stale window, want error")
+	}
+
+	st := r.Stats()[0]
+	if st.StaleServed != 1 || st.Failures != 2 || st.ConsecutiveFailures != 2 {
+		t.Errorf("Stats() = %+v", st)
+	}
+}
+
+func TestResolver_NoStaleForNotFound(t *testing.T) {
+	f := &fakeLookup{addrs: testAddrs, ttl: 30 * time.Second}
+	r, clock := newTestResolver(f)
+	ctx := context.Background()
+
+	_, _ = r.LookupIPAddr(ctx, "hooks.example.com")
+
+	// A deleted record is not papered over with the old answer
+	f.set(nil, unknownTTL, &net.DNSError{Err: "no such host", Name: "hooks.example.com", IsNotFound: true})
+	clock.Advance(time.Minute)
+	if _, err := r.LookupIPAddr(ctx, "hooks.example.com"); !isNotFound(err) {
+		t.Errorf("LookupIPAddr() error = %v, want not found", err)
+	}
+}
+
+func TestResolver_CollapsesConcurrentLookups(t *testing.T) {
+	f := &fakeLookup{addrs: testAddrs, ttl: time.Minute, block: make(chan struct{})}
+	r, _ := newTestResolver(f)
+
+	var wg sync.WaitGroup
+	var failures atomic.Int32
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := r.LookupIPAddr(context.Background(), "hooks.example.com"); err != nil {
+				failures.Add(1)
+			}
+		}()
+	}
+
+	// Let the goroutines join the in-flight lookup before releasing it
+	time.Sleep(50 * time.Millisecond)
+	close(f.block)
+	wg.Wait()
+
+	if got := f.count(); got != 1 {
+		t.Errorf("lookups = %d, want 1", got)
+	}
+	if failures.Load() != 0 {
+		t.Errorf("%d lookups failed", failures.Load())
+	}
+}
+
+func TestResolver_CallerCancellation(t *testing.T) {
+	f := &fakeLookup{addrs: testAddrs, ttl: time.Minute, block: make(chan struct{})}
+	r, _ := newTestResolver(f)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := r.LookupIPAddr(ctx, "hooks.example.com"); !errors.Is(err, context.Canceled) {
+		t.Errorf("LookupIPAddr() error = %v, want context.Canceled", err)
+	}
+
+	// The shared lookup is not cancelled with the caller
+	close(f.block)
+	if _, err := r.LookupIPAddr(context.Background(), "hooks.example.com"); err != nil {
+		t.Errorf("LookupIPAddr() error = %v", err)
+	}
+	if got := f.count(); got != 1 {
+		t.Errorf("lookups = %d, want 1", got)
+	}
+}
+
+func TestResolver_Stats(t *testing.T) {
+	f := &fakeLookup{addrs: testAddrs, ttl: time.Minute}
+	r, clock := newTestResolver(f, WithStaleTTL(0))
+	ctx := context.Background()
+
+	_, _ = r.LookupIPAddr(ctx, "b.example.com")
+	_, _ = r.LookupIPAddr(ctx, "b.example.com")
+	f.set(nil, unknownTTL, errServFail)
+	_, _ = r.LookupIPAddr(ctx, "a.example.com")
+	_, _ = r.LookupIPAddr(ctx, "a.example.com")
+	clock.Advance(time.Hour)
+
+	stats := r.Stats()
+	if len(stats) != 2 || stats[0].Host != "a.example.com" || stats[1].Host != "b.example.com" {
+		t.Fatalf("Stats() = %+v", stats)
+	}
+	a, b := stats[0], stats[1]
+	if a.Lookups != 1 || a.Failures != 1 || a.NegativeHits != 1 || a.ConsecutiveFailures != 1 {
+		t.Errorf("a.example.com stats = %+v", a)
+	}
+	if a.LastError == "" || a.LastFailure == nil {
+		t.Errorf("a.example.com last failure not recorded: %+v", a)
+	}
+	if b.Lookups != 1 || b.CacheHits != 1 || b.Failures != 0 || b.LastFailure != nil {
+		t.Errorf("b.example.com stats = %+v", b)
+	}
+}
+
+func TestResolver_DialContext(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+	u, _ := url.Parse(server.URL)
+	_, port, _ := net.SplitHostPort(u.Host)
+
+	f := &fakeLookup{addrs: []net.IPAddr{{IP: net.ParseIP("127.0.0.1")}}, ttl: time.Minute}
+	r, _ := newTestResolver(f)
+
+	conn, err := r.DialContext(context.Background(), "tcp", net.JoinHostPort("hooks.example.com", port))
+	if err != nil {
+		t.Fatalf("DialContext() error = %v", err)
+	}
+	conn.Close()
+
+	f.set(nil, unknownTTL, errServFail)
+	if _, err := r.DialContext(context.Background(), "tcp", "other.example.com:443"); !errors.Is(err, errServFail) {
+		t.Errorf("DialContext() error = %v, want %v", err, errServFail)
+	}
+
+	// IP literals bypass the resolver
+	conn, err = r.DialContext(context.Background(), "tcp", u.Host)
+	if err != nil {
+		t.Fatalf("DialContext(ip) error = %v", err)
+	}
+	conn.Close()
+	if got := f.count(); got != 2 {
+		t.Errorf("lookups = %d, want 2", got)
+	}
+}
+
+func TestResolver_AddressCheck(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+	u, _ := url.Parse(server.URL)
+	_, port, _ := net.SplitHostPort(u.Host)
+
+	errBlocked := errors.New("blocked")
+	blocked := net.ParseIP("10.0.0.1")
+	check := func(ip net.IP) error {
+		if ip.Equal(blocked) {
+			return errBlocked
+		}
+		return nil
+	}
+	f := &fakeLookup{addrs: []net.IPAddr{{IP: blocked}, {IP: net.ParseIP("127.0.0.1")}}, ttl: time.Minute}
+	r, clock := newTestResolver(f, WithAddressCheck(check))
+	addr := net.JoinHostPort("hooks.example.com", port)
+
+	// Refused addresses are skipped
+	conn, err := r.DialContext(context.Background(), "tcp", addr)
+	if err != nil {
+		t.Fatalf("DialContext() error = %v", err)
+	}
+	conn.Close()
+
+	// The host is rebound to a refused address after its TTL
+	f.set([]net.IPAddr{{IP: blocked}}, time.Minute, nil)
+	clock.Advance(2 * time.Minute)
+	if _, err := r.DialContext(context.Background(), "tcp", addr); !errors.Is(err, errBlocked) {
+		t.Errorf("DialContext() error = %v, want %v", err, errBlocked)
+	}
+
+	if _, err := r.DialContext(context.Background(), "tcp", net.JoinHostPort(blocked.String(), port)); !errors.Is(err, errBlocked) {
+		t.Errorf("DialContext(ip) error = %v, want %v", err, errBlocked)
+	}
+}
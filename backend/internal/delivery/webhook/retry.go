@@ -2,16 +2,26 @@ package webhook
 
 import (
 	"context"
+	"math/rand/v2"
 	"sync"
 	"time"
 )
 
 // RetryConfig holds retry settings for webhook delivery
 type RetryConfig struct {
-	Enabled    bool `json:"enabled"`     // Whether retry is enabled
-	MaxRetries int  `json:"max_retries"` // Maximum number of retry attempts (default: 3)
-	InitialMs  int  `json:"initial_ms"`  // Initial backoff delay in milliseconds (default: 1000)
-	MaxMs      int  `json:"max_ms"`      // Maximum backoff delay in milliseconds (default: 60000)
+	Enabled    bool             `json:"enabled"`                    // Whether retry is enabled
+	MaxRetries int              `json:"max_retries"`                // Maximum number of retry attempts (default: 3)
+	InitialMs  int              `json:"initial_ms"`                 // Initial backoff delay in milliseconds (default: 1000)
+	MaxMs      int              `json:"max_ms"`                     // Maximum backoff delay in milliseconds (default: 60000)
+	Jitter     float64          `json:"jitter,omitempty"`           // Fraction (0-1) by which each backoff is randomly shortened
+	Statuses   []StatusOverride `json:"status_overrides,omitempty"` // Per-status-code behavior, replacing the defaults
+}
+
+// StatusOverride changes how a failed response with a given status code is retried
+type StatusOverride struct {
+	Status     int  `json:"status"`                // HTTP status code, e.g. 429
+	Retry      bool `json:"retry"`                 // Whether the status is retried at all
+	RetryAfter bool `json:"retry_after,omitempty"` // Wait as long as the Retry-After header asks, within the retry window
 }
 
 // DefaultRetryConfig returns sensible default retry configuration.
@@ -28,8 +38,11 @@ func DefaultRetryConfig() RetryConfig {
 // RetryingSender wraps a Sender with retry logic using exponential backoff.
 // It is safe for concurrent use by multiple goroutines.
 type RetryingSender struct {
-	sender *Sender
-	config RetryConfig
+	sender     *Sender
+	config     RetryConfig
+	onSchedule func(attempt int, next time.Time)
+	stop       <-chan struct{} // ends backoff waits when closed, see StopWaitingOn
+	random     func() float64  // returns a number in [0, 1) for jitter
 }
 
 // NewRetryingSender creates a new retrying sender that wraps the given sender
@@ -38,12 +51,60 @@ func NewRetryingSender(sender *Sender, config RetryConfig) *RetryingSender {
 	return &RetryingSender{
 		sender: sender,
 		config: config,
+		random: rand.Float64,
 	}
 }
 
+// OnRetryScheduled registers a callback that is invoked whenever a retry is
+// scheduled, before the backoff wait begins. attempt is the 1-based retry
+// number and next is when it will be attempted. It is used to persist
+// pending retries so they can be resumed after a restart.
+func (r *RetryingSender) OnRetryScheduled(fn func(attempt int, next time.Time)) {
+	r.onSchedule = fn
+}
+
+// StopWaitingOn makes Send and Resume give up waiting for the next attempt
+// once done is closed, returning a result with Deferred set. An attempt in
+// flight is not interrupted. It is used at shutdown for retries persisted by
+// OnRetryScheduled, which are resumed at the next start.
+func (r *RetryingSender) StopWaitingOn(done <-chan struct{}) {
+	r.stop = done
+}
+
+// MaxWindow returns the total backoff time across all retries, i.e. the
+// longest a delivery can remain pending after its first attempt.
+// Jitter only shortens backoffs, and Retry-After waits are kept within the
+// window, so neither extends it.
+func (c RetryConfig) MaxWindow() time.Duration {
+	return c.windowFrom(0)
+}
+
+// windowFrom returns the total backoff time of the retries after the given attempt
+func (c RetryConfig) windowFrom(attempt int) time.Duration {
+	if !c.Enabled {
+		return 0
+	}
+	var total time.Duration
+	for a := max(attempt, 0); a < c.MaxRetries; a++ {
+		total += calculateBackoff(a, c.InitialMs, c.MaxMs)
+	}
+	return total
+}
+
+// override returns the StatusOverride for a status code, if any
+func (c RetryConfig) override(status int) (StatusOverride, bool) {
+	for _, o := range c.Statuses {
+		if o.Status == status {
+			return o, true
+		}
+	}
+	return StatusOverride{}, false
+}
+
 // Send attempts delivery with retries using exponential backoff.
 // It will retry on retryable errors (5xx, 408, 429, connection errors)
-// and stop immediately on non-retryable errors (4xx except 408, 429).
+// and stop immediately on non-retryable errors (4xx except 408, 429),
+// unless a StatusOverride for the status code says otherwise.
 //
 // The backoff schedule is:
 //   - Attempt 1: immediate
@@ -51,16 +112,33 @@ func NewRetryingSender(sender *Sender, config RetryConfig) *RetryingSender {
 //   - Attempt 3: wait InitialMs * 2
 //   - Attempt 4: wait InitialMs * 4
 //   - (capped at MaxMs)
+//
+// With Jitter, each wait is shortened by a random fraction of up to Jitter.
+// A StatusOverride with RetryAfter waits as long as the response's Retry-After
+// header asks instead, if that is longer; if the wait would end after the
+// retry window (see MaxWindow), retrying stops.
 func (r *RetryingSender) Send(ctx context.Context, target Target, payload []byte) DeliveryResult {
+	return r.Resume(ctx, target, payload, 0)
+}
+
+// Resume continues a retry schedule starting at the given attempt, which is
+// sent immediately. Attempt 0 is the initial delivery, so Resume(..., 0) is
+// equivalent to Send. Remaining retries follow the usual backoff schedule.
+func (r *RetryingSender) Resume(ctx context.Context, target Target, payload []byte, fromAttempt int) DeliveryResult {
 	// If retry is disabled, just send once
 	if !r.config.Enabled {
 		return r.sender.sendTarget(ctx, target, payload)
 	}
 
+	if fromAttempt < 0 {
+		fromAttempt = 0
+	}
+
 	var result DeliveryResult
-	retryCount := 0
+	retryCount := fromAttempt
+	deadline := time.Now().Add(r.config.windowFrom(fromAttempt))
 
-	for attempt := 0; attempt <= r.config.MaxRetries; attempt++ {
+	for attempt := fromAttempt; attempt <= r.config.MaxRetries; attempt++ {
 		// Check context before each attempt
 		if err := ctx.Err(); err != nil {
 			result = DeliveryResult{
@@ -73,8 +151,14 @@ func (r *RetryingSender) Send(ctx context.Context, target Target, payload []byte
 		}
 
 		// Wait before retry (not on first attempt)
-		if attempt > 0 {
-			backoff := calculateBackoff(attempt-1, r.config.InitialMs, r.config.MaxMs)
+		if attempt > fromAttempt {
+			backoff, ok := r.backoff(attempt-1, result, deadline)
+			if !ok {
+				return result
+			}
+			if r.onSchedule != nil {
+				r.onSchedule(attempt, time.Now().Add(backoff))
+			}
 			select {
 			case <-ctx.Done():
 				result = DeliveryResult{
@@ -84,6 +168,15 @@ func (r *RetryingSender) Send(ctx context.Context, target Target, payload []byte
 					RetryCount:   retryCount,
 				}
 				return result
+			case <-r.stop:
+				result = DeliveryResult{
+					URL:          target.URL,
+					Success:      false,
+					ErrorMessage: "retry deferred: stopped waiting for the next attempt",
+					RetryCount:   retryCount,
+					Deferred:     true,
+				}
+				return result
 			case <-time.After(backoff):
 				// Continue with retry
 			}
@@ -100,7 +193,7 @@ func (r *RetryingSender) Send(ctx context.Context, target Target, payload []byte
 		}
 
 		// Check if error is retryable
-		if !isRetryable(result) {
+		if !r.retryable(result) {
 			return result
 		}
 
@@ -146,6 +239,36 @@ func calculateBackoff(attempt, initialMs, maxMs int) time.Duration {
 	return time.Duration(backoffMs) * time.Millisecond
 }
 
+// backoff returns the wait before the given retry attempt (0-based) after
+// the failed result, and false if the Retry-After wait the result asks for
+// would end after the deadline.
+func (r *RetryingSender) backoff(attempt int, result DeliveryResult, deadline time.Time) (time.Duration, bool) {
+	wait := calculateBackoff(attempt, r.config.InitialMs, r.config.MaxMs)
+	if j := min(max(r.config.Jitter, 0), 1); j > 0 {
+		wait -= time.Duration(j * r.random() * float64(wait))
+	}
+
+	if o, ok := r.config.override(result.StatusCode); ok && o.RetryAfter && result.RetryAfter > wait {
+		if time.Now().Add(result.RetryAfter).After(deadline) {
+			return 0, false
+		}
+		wait = result.RetryAfter
+	}
+	return wait, true
+}
+
+// retryable reports whether a failed result is retried, applying the
+// StatusOverride for its status code before the defaults of isRetryable.
+func (r *RetryingSender) retryable(result DeliveryResult) bool {
+	if result.Success {
+		return false
+	}
+	if o, ok := r.config.override(result.StatusCode); ok && result.StatusCode != 0 {
+		return o.Retry
+	}
+	return isRetryable(result)
+}
+
 // isRetryable determines if a delivery result indicates a retryable error.
 // Retryable errors include:
 //   - HTTP 5xx (server errors)
@@ -4,6 +4,7 @@ import (
 	"context"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -45,7 +46,7 @@ func TestNewRetryingSender(t *testing.T) {
 		t.Error("sender not set correctly")
 	}
 
-	if rs.config != cfg {
+	if !reflect.DeepEqual(rs.config, cfg) {
 		t.Error("config not set correctly")
 	}
 }
@@ -678,3 +679,283 @@ func TestRetryingSender_ZeroMaxRetries(t *testing.T) {
 		t.Errorf("expected RetryCount=0, got %d", result.RetryCount)
 	}
 }
+
+// TestRetryingSender_Resume verifies resuming a schedule skips already-spent attempts
+func TestRetryingSender_Resume(t *testing.T) {
+	var attempts int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	sender := NewSender()
+	cfg := RetryConfig{
+		Enabled:    true,
+		MaxRetries: 3,
+		InitialMs:  10,
+		MaxMs:      100,
+	}
+	rs := NewRetryingSender(sender, cfg)
+
+	target := Target{URL: server.URL, Secret: "secret"}
+	result := rs.Resume(context.Background(), target, []byte(`{}`), 2)
+
+	if result.Success {
+		t.Error("expected failure")
+	}
+
+	// Attempts 2 and 3 remain
+	if atomic.LoadInt32(&attempts) != 2 {
+		t.Errorf("expected 2 attempts, got %d", atomic.LoadInt32(&attempts))
+	}
+
+	if result.RetryCount != 3 {
+		t.Errorf("expected RetryCount=3, got %d", result.RetryCount)
+	}
+}
+
+// TestRetryingSender_OnRetryScheduled verifies the schedule hook is invoked per retry
+func TestRetryingSender_OnRetryScheduled(t *testing.T) {
+	var attempts int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	sender := NewSender()
+	cfg := RetryConfig{
+		Enabled:    true,
+		MaxRetries: 3,
+		InitialMs:  10,
+		MaxMs:      100,
+	}
+	rs := NewRetryingSender(sender, cfg)
+
+	var scheduled []int
+	start := time.Now()
+	rs.OnRetryScheduled(func(attempt int, next time.Time) {
+		scheduled = append(scheduled, attempt)
+		if next.Before(start) {
+			t.Errorf("next attempt time %v is before start %v", next, start)
+		}
+	})
+
+	target := Target{URL: server.URL, Secret: "secret"}
+	result := rs.Send(context.Background(), target, []byte(`{}`))
+
+	if !result.Success {
+		t.Errorf("expected success, got error: %s", result.ErrorMessage)
+	}
+
+	if len(scheduled) != 2 || scheduled[0] != 1 || scheduled[1] != 2 {
+		t.Errorf("expected scheduled attempts [1 2], got %v", scheduled)
+	}
+}
+
+// TestRetryingSender_StopWaitingOn verifies a backoff wait ends when the stop
+// channel is closed, without waiting for the next attempt
+func TestRetryingSender_StopWaitingOn(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	rs := NewRetryingSender(NewSender(), RetryConfig{Enabled: true, MaxRetries: 3, InitialMs: 60000, MaxMs: 60000})
+	stop := make(chan struct{})
+	rs.StopWaitingOn(stop)
+	rs.OnRetryScheduled(func(attempt int, next time.Time) {
+		close(stop)
+	})
+
+	done := make(chan DeliveryResult, 1)
+	go func() {
+		done <- rs.Send(context.Background(), Target{URL: server.URL, Secret: "secret"}, []byte(`{}`))
+	}()
+
+	select {
+	case result := <-done:
+		if !result.Deferred || result.Success {
+			t.Errorf("expected a deferred failure, got %+v", result)
+		}
+		if got := atomic.LoadInt32(&attempts); got != 1 {
+			t.Errorf("expected 1 attempt, got %d", got)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Send kept waiting for the next attempt after stop was closed")
+	}
+}
+
+// TestRetryConfig_MaxWindow verifies the total backoff window calculation
+func TestRetryConfig_MaxWindow(t *testing.T) {
+	testCases := []struct {
+		name     string
+		cfg      RetryConfig
+		expected time.Duration
+	}{
+		{"disabled", RetryConfig{Enabled: false, MaxRetries: 3, InitialMs: 1000, MaxMs: 60000}, 0},
+		{"no retries", RetryConfig{Enabled: true, MaxRetries: 0, InitialMs: 1000, MaxMs: 60000}, 0},
+		{"default", DefaultRetryConfig(), 7 * time.Second},
+		{"capped", RetryConfig{Enabled: true, MaxRetries: 4, InitialMs: 1000, MaxMs: 2000}, 7 * time.Second},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.cfg.MaxWindow(); got != tc.expected {
+				t.Errorf("MaxWindow() = %v, want %v", got, tc.expected)
+			}
+		})
+	}
+}
+
+// TestRetryingSender_Jitter verifies jitter only shortens the backoff
+func TestRetryingSender_Jitter(t *testing.T) {
+	cfg := RetryConfig{Enabled: true, MaxRetries: 3, InitialMs: 1000, MaxMs: 60000, Jitter: 0.5}
+	rs := NewRetryingSender(NewSender(), cfg)
+	deadline := time.Now().Add(cfg.MaxWindow())
+
+	testCases := []struct {
+		random   float64
+		expected time.Duration
+	}{
+		{0, 2 * time.Second},
+		{0.5, 1500 * time.Millisecond},
+		{0.999, 1001 * time.Millisecond},
+	}
+
+	for _, tc := range testCases {
+		rs.random = func() float64 { return tc.random }
+		got, ok := rs.backoff(1, DeliveryResult{StatusCode: http.StatusServiceUnavailable}, deadline)
+		if !ok {
+			t.Fatalf("random=%v: expected a retry", tc.random)
+		}
+		if got != tc.expected {
+			t.Errorf("random=%v: backoff = %v, want %v", tc.random, got, tc.expected)
+		}
+	}
+}
+
+// TestRetryingSender_StatusOverrides verifies overrides replace the default retryability
+func TestRetryingSender_StatusOverrides(t *testing.T) {
+	testCases := []struct {
+		name     string
+		status   int
+		expected int32
+	}{
+		{"404 retried", http.StatusNotFound, 3},
+		{"503 not retried", http.StatusServiceUnavailable, 1},
+		{"500 uses default", http.StatusInternalServerError, 3},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var attempts int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&attempts, 1)
+				w.WriteHeader(tc.status)
+			}))
+			defer server.Close()
+
+			cfg := RetryConfig{
+				Enabled:    true,
+				MaxRetries: 2,
+				InitialMs:  10,
+				MaxMs:      100,
+				Statuses: []StatusOverride{
+					{Status: http.StatusNotFound, Retry: true},
+					{Status: http.StatusServiceUnavailable, Retry: false},
+				},
+			}
+			rs := NewRetryingSender(NewSender(), cfg)
+			rs.Send(context.Background(), Target{URL: server.URL, Secret: "secret"}, []byte(`{}`))
+
+			if got := atomic.LoadInt32(&attempts); got != tc.expected {
+				t.Errorf("expected %d attempts, got %d", tc.expected, got)
+			}
+		})
+	}
+}
+
+// TestRetryingSender_RetryAfter verifies Retry-After is honored within the retry window only
+func TestRetryingSender_RetryAfter(t *testing.T) {
+	newServer := func(retryAfter string, attempts *int32) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if atomic.AddInt32(attempts, 1) == 1 {
+				w.Header().Set("Retry-After", retryAfter)
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		}))
+	}
+	override := []StatusOverride{{Status: http.StatusTooManyRequests, Retry: true, RetryAfter: true}}
+
+	t.Run("within window", func(t *testing.T) {
+		var attempts int32
+		server := newServer("1", &attempts)
+		defer server.Close()
+
+		cfg := RetryConfig{Enabled: true, MaxRetries: 2, InitialMs: 600, MaxMs: 2000, Statuses: override}
+		rs := NewRetryingSender(NewSender(), cfg)
+
+		start := time.Now()
+		result := rs.Send(context.Background(), Target{URL: server.URL, Secret: "secret"}, []byte(`{}`))
+		if !result.Success {
+			t.Fatalf("expected success, got %s", result.ErrorMessage)
+		}
+		if elapsed := time.Since(start); elapsed < time.Second {
+			t.Errorf("expected to wait for Retry-After, retried after %v", elapsed)
+		}
+	})
+
+	t.Run("beyond window", func(t *testing.T) {
+		var attempts int32
+		server := newServer("3600", &attempts)
+		defer server.Close()
+
+		cfg := RetryConfig{Enabled: true, MaxRetries: 2, InitialMs: 10, MaxMs: 100, Statuses: override}
+		rs := NewRetryingSender(NewSender(), cfg)
+
+		result := rs.Send(context.Background(), Target{URL: server.URL, Secret: "secret"}, []byte(`{}`))
+		if result.Success || result.StatusCode != http.StatusTooManyRequests {
+			t.Errorf("expected to give up with 429, got %+v", result)
+		}
+		if got := atomic.LoadInt32(&attempts); got != 1 {
+			t.Errorf("expected 1 attempt, got %d", got)
+		}
+		if result.RetryAfter != time.Hour {
+			t.Errorf("RetryAfter = %v, want 1h", result.RetryAfter)
+		}
+	})
+}
+
+// TestParseRetryAfter verifies both Retry-After formats
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		value    string
+		expected time.Duration
+	}{
+		{"", 0},
+		{"120", 2 * time.Minute},
+		{"0", 0},
+		{"-5", 0},
+		{"soon", 0},
+		{"Mon, 01 Jan 2024 00:00:30 GMT", 30 * time.Second},
+		{"Sun, 31 Dec 2023 23:59:00 GMT", 0},
+	}
+
+	for _, tc := range testCases {
+		if got := parseRetryAfter(tc.value, now); got != tc.expected {
+			t.Errorf("parseRetryAfter(%q) = %v, want %v", tc.value, got, tc.expected)
+		}
+	}
+}
@@ -19,11 +19,13 @@ func GenerateSecret() (string, error) {
 	return SecretPrefix + hex.EncodeToString(b), nil
 }
 
+// MaskSecret hides a secret, keeping its last 4 characters (e.g. "****1234")
+// so that users can tell secrets apart. Short secrets are hidden entirely.
 func MaskSecret(secret string) string {
 	if len(secret) <= 12 {
 		return "****"
 	}
-	return secret[:8] + "..." + secret[len(secret)-4:]
+	return "****" + secret[len(secret)-4:]
 }
 
 func SecretPrefixFromSecret(secret string) string {
@@ -44,23 +44,9 @@ func TestMaskSecret(t *testing.T) {
 
 	masked := MaskSecret(secret)
 
-	// Should show first 8 chars and last 4 chars with "..." in the middle
-	expectedPrefix := secret[:8]
-	expectedSuffix := secret[len(secret)-4:]
-
-	if !strings.HasPrefix(masked, expectedPrefix) {
-		t.Errorf("expected masked secret to start with %q, got %q", expectedPrefix, masked)
-	}
-	if !strings.HasSuffix(masked, expectedSuffix) {
-		t.Errorf("expected masked secret to end with %q, got %q", expectedSuffix, masked)
-	}
-	if !strings.Contains(masked, "...") {
-		t.Error("expected masked secret to contain '...'")
-	}
-
-	expectedMasked := expectedPrefix + "..." + expectedSuffix
-	if masked != expectedMasked {
-		t.Errorf("expected %q, got %q", expectedMasked, masked)
+	// Should show only the last 4 chars
+	if masked != "****5678" {
+		t.Errorf("expected %q, got %q", "****5678", masked)
 	}
 }
 
@@ -3,13 +3,46 @@ package webhook
 import (
 	"bytes"
 	"context"
+	"crypto/rand"
 	"fmt"
+	"io"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
+
+	"github.com/otiai10/namazu/backend/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
 )
 
+// DefaultUserAgent is the User-Agent of outgoing requests unless a target overrides it
+const DefaultUserAgent = "namazu/1.0"
+
+// RedeliveryHeader marks a delivery manually re-sent by the user
+const RedeliveryHeader = "X-Namazu-Redelivery"
+
+// TestHeader marks a test delivery requested by the user, not a real event
+const TestHeader = "X-Namazu-Test"
+
+// BackfillHeader marks an event that was missed live and recovered from the
+// source's history after a reconnection, so it arrives late
+const BackfillHeader = "X-Namazu-Backfilled"
+
+// DeliveryIDHeader carries a UUID that identifies one delivery of an event to
+// one subscription. Retries of the delivery reuse it; redeliveries get a new one.
+const DeliveryIDHeader = "X-Delivery-Id"
+
+// EventIDHeader carries the ID of the delivered event (or notice, or digest).
+// Deliveries are at least once: receivers should deduplicate on it.
+const EventIDHeader = "X-Event-Id"
+
+// PreviousSignatureHeader carries the signature made with the previous secret
+// while a rotated secret is in its grace period, in the same format as
+// X-Signature-256. Receivers accept a delivery if either signature verifies.
+const PreviousSignatureHeader = "X-Signature-256-Previous"
+
 // DeliveryResult contains the result of a webhook delivery attempt.
 // It provides detailed information about the delivery including timing,
 // status codes, and any errors that occurred.
@@ -20,6 +53,9 @@ type DeliveryResult struct {
 	ErrorMessage string        // Error description if delivery failed
 	ResponseTime time.Duration // Time taken for the request
 	RetryCount   int           // Number of retry attempts made (0 if succeeded on first try)
+	RetryAfter   time.Duration // Wait requested by the Retry-After header of a failed response (0 if none)
+	ResponseBody string        // Leading bytes of the response body, see Limits.ResponseCaptureBytes
+	Deferred     bool          // Retrying stopped before the next attempt, see RetryingSender.StopWaitingOn
 }
 
 // Sender sends webhook notifications with configurable timeout and
@@ -27,8 +63,12 @@ type DeliveryResult struct {
 //
 // Sender is safe for concurrent use by multiple goroutines.
 type Sender struct {
-	client  *http.Client
-	timeout time.Duration
+	client   *http.Client
+	timeout  time.Duration
+	resolver *Resolver
+	proxy    *url.URL
+	limits   *Limits
+	limiter  *limiter // nil without WithLimits
 }
 
 // SenderOption configures the Sender
@@ -46,6 +86,48 @@ func WithTimeout(d time.Duration) SenderOption {
 	}
 }
 
+// WithResolver makes the sender resolve webhook hosts through a caching resolver.
+// Without it, every connection is resolved by the system resolver.
+//
+// Example:
+//
+//	sender := webhook.NewSender(webhook.WithResolver(webhook.NewResolver()))
+func WithResolver(r *Resolver) SenderOption {
+	return func(s *Sender) {
+		s.resolver = r
+	}
+}
+
+// WithProxy sends webhooks through an HTTP, HTTPS or SOCKS5 proxy, so they
+// leave from the proxy's addresses. The proxy resolves and connects to the
+// webhook hosts, so connections do not go through the resolver. With
+// WithResolver, every address of the target host is instead checked with the
+// resolver's address check before each request is handed to the proxy.
+//
+// Example:
+//
+//	proxy, _ := url.Parse("socks5://10.0.0.2:1080")
+//	sender := webhook.NewSender(webhook.WithProxy(proxy))
+func WithProxy(u *url.URL) SenderOption {
+	return func(s *Sender) {
+		s.proxy = u
+	}
+}
+
+// WithLimits caps the requests in flight, globally and per destination host,
+// tunes connection reuse and bounds the responses read. Requests over a limit
+// wait for a slot; see Limits. Without it, the sender does not limit
+// concurrency and does not read response bodies.
+//
+// Example:
+//
+//	sender := webhook.NewSender(webhook.WithLimits(webhook.DefaultLimits()))
+func WithLimits(l Limits) SenderOption {
+	return func(s *Sender) {
+		s.limits = &l
+	}
+}
+
 // NewSender creates a new webhook sender with the given options.
 // The default timeout is 10 seconds.
 //
@@ -66,9 +148,90 @@ func NewSender(opts ...SenderOption) *Sender {
 	s.client = &http.Client{
 		Timeout: s.timeout,
 	}
+	if s.proxy == nil && s.resolver == nil && s.limits == nil {
+		return s
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	switch {
+	case s.proxy != nil:
+		transport.Proxy = http.ProxyURL(s.proxy)
+	case s.resolver != nil:
+		transport.DialContext = s.resolver.DialContext
+	}
+	if s.limits != nil {
+		s.limiter = newLimiter(*s.limits)
+		if s.limits.MaxIdleConnsPerHost > 0 {
+			transport.MaxIdleConnsPerHost = s.limits.MaxIdleConnsPerHost
+		}
+		// Let a full fan-out keep its connections alive across hosts
+		transport.MaxIdleConns = max(transport.MaxIdleConns, s.limits.MaxInFlight)
+		if s.limits.MaxResponseHeaderBytes > 0 {
+			transport.MaxResponseHeaderBytes = int64(s.limits.MaxResponseHeaderBytes)
+		}
+	}
+	s.client.Transport = transport
+	if s.proxy != nil && s.resolver != nil && s.resolver.checkAddr != nil {
+		s.client.Transport = &checkedTransport{next: transport, resolver: s.resolver}
+	}
 	return s
 }
 
+// checkedTransport refuses requests to hosts with an address the resolver's
+// address check rejects. It guards proxied requests, whose connections to
+// the webhook hosts are made by the proxy rather than the resolver.
+type checkedTransport struct {
+	next     http.RoundTripper
+	resolver *Resolver
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *checkedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.resolver.CheckHost(req.Context(), req.URL.Hostname()); err != nil {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, err
+	}
+	return t.next.RoundTrip(req)
+}
+
+// Stats returns the concurrency of the sender under its Limits.
+// Without WithLimits, the counters are zero and Hosts is empty.
+func (s *Sender) Stats() LimitStats {
+	if s.limiter == nil {
+		return LimitStats{Hosts: []HostLimitStats{}}
+	}
+	return s.limiter.stats()
+}
+
+// acquire waits for a slot to send req under the sender's Limits and returns
+// the function that releases it
+func (s *Sender) acquire(req *http.Request) (func(), error) {
+	if s.limiter == nil {
+		return func() {}, nil
+	}
+	return s.limiter.acquire(req.Context(), req.URL.Host)
+}
+
+// readBody reads body within the sender's Limits, keeping the leading
+// ResponseCaptureBytes in result.ResponseBody. Read errors are ignored: the
+// outcome is already decided by the status code.
+func (s *Sender) readBody(body io.Reader, result *DeliveryResult) {
+	if s.limits == nil {
+		return
+	}
+	capture := s.limits.ResponseCaptureBytes
+	if capture > 0 {
+		data, _ := io.ReadAll(io.LimitReader(body, int64(capture)))
+		// A multi-byte character may be cut at the end
+		result.ResponseBody = strings.ToValidUTF8(string(data), "")
+	}
+	if rest := s.limits.MaxResponseBodyBytes - capture; rest > 0 {
+		_, _ = io.Copy(io.Discard, io.LimitReader(body, int64(rest)))
+	}
+}
+
 // Send sends a payload to a single webhook endpoint via HTTP POST.
 //
 // The request includes:
@@ -114,9 +277,16 @@ func (s *Sender) Send(ctx context.Context, url, secret string, payload []byte) D
 	// Set headers
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("X-Signature-256", Sign(secret, payload))
-	req.Header.Set("User-Agent", "namazu/1.0")
+	req.Header.Set("User-Agent", DefaultUserAgent)
 
 	// Send request
+	release, err := s.acquire(req)
+	if err != nil {
+		result.ErrorMessage = fmt.Sprintf("cancelled while waiting for a delivery slot: %v", err)
+		result.ResponseTime = time.Since(start)
+		return result
+	}
+	defer release()
 	resp, err := s.client.Do(req)
 	if err != nil {
 		result.ErrorMessage = fmt.Sprintf("request failed: %v", err)
@@ -131,7 +301,9 @@ func (s *Sender) Send(ctx context.Context, url, secret string, payload []byte) D
 
 	if !result.Success {
 		result.ErrorMessage = fmt.Sprintf("unexpected status: %d", resp.StatusCode)
+		result.RetryAfter = parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
 	}
+	s.readBody(resp.Body, &result)
 
 	return result
 }
@@ -183,9 +355,23 @@ func (s *Sender) SendAll(ctx context.Context, targets []Target, payload []byte)
 	return results
 }
 
-func (s *Sender) sendTarget(ctx context.Context, target Target, payload []byte) DeliveryResult {
+// sendTarget makes one delivery attempt, traced as a "webhook.send" span.
+// The trace ID is sent in tracing.TraceIDHeader.
+func (s *Sender) sendTarget(ctx context.Context, target Target, payload []byte) (result DeliveryResult) {
+	ctx, span := tracing.Start(ctx, "webhook.send",
+		attribute.String("namazu.subscription", target.Name),
+		attribute.Bool("namazu.redelivery", target.Redelivery),
+		attribute.Bool("namazu.test", target.Test))
+	defer func() {
+		span.SetAttributes(attribute.Int("http.response.status_code", result.StatusCode))
+		if !result.Success {
+			tracing.Fail(span, result.ErrorMessage)
+		}
+		span.End()
+	}()
+
 	start := time.Now()
-	result := DeliveryResult{URL: target.URL}
+	result = DeliveryResult{URL: target.URL}
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target.URL, bytes.NewReader(payload))
 	if err != nil {
@@ -193,19 +379,54 @@ func (s *Sender) sendTarget(ctx context.Context, target Target, payload []byte)
 		result.ResponseTime = time.Since(start)
 		return result
 	}
+	span.SetAttributes(attribute.String("server.address", req.URL.Host))
+	setCustomHeaders(req.Header, target.Headers)
+	tracing.Inject(ctx, req.Header)
 
+	userAgent := target.UserAgent
+	if userAgent == "" {
+		userAgent = DefaultUserAgent
+	}
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("User-Agent", "namazu/1.0")
+	req.Header.Set("User-Agent", userAgent)
+	if target.Redelivery {
+		req.Header.Set(RedeliveryHeader, "true")
+	}
+	if target.Test {
+		req.Header.Set(TestHeader, "true")
+	}
+	if target.Backfilled {
+		req.Header.Set(BackfillHeader, "true")
+	}
+	if target.DeliveryID != "" {
+		req.Header.Set(DeliveryIDHeader, target.DeliveryID)
+	}
+	if target.EventID != "" {
+		req.Header.Set(EventIDHeader, target.EventID)
+	}
 
 	switch target.SignVersion {
 	case "v0":
 		timestamp := time.Now().Unix()
 		req.Header.Set("X-Signature-256", SignV0(target.Secret, timestamp, payload))
 		req.Header.Set("X-Signature-Timestamp", strconv.FormatInt(timestamp, 10))
+		if target.PreviousSecret != "" {
+			req.Header.Set(PreviousSignatureHeader, SignV0(target.PreviousSecret, timestamp, payload))
+		}
 	default:
 		req.Header.Set("X-Signature-256", Sign(target.Secret, payload))
+		if target.PreviousSecret != "" {
+			req.Header.Set(PreviousSignatureHeader, Sign(target.PreviousSecret, payload))
+		}
 	}
 
+	release, err := s.acquire(req)
+	if err != nil {
+		result.ErrorMessage = fmt.Sprintf("cancelled while waiting for a delivery slot: %v", err)
+		result.ResponseTime = time.Since(start)
+		return result
+	}
+	defer release()
 	resp, err := s.client.Do(req)
 	if err != nil {
 		result.ErrorMessage = fmt.Sprintf("request failed: %v", err)
@@ -220,15 +441,58 @@ func (s *Sender) sendTarget(ctx context.Context, target Target, payload []byte)
 
 	if !result.Success {
 		result.ErrorMessage = fmt.Sprintf("unexpected status: %d", resp.StatusCode)
+		result.RetryAfter = parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
 	}
+	s.readBody(resp.Body, &result)
 
 	return result
 }
 
 // Target represents a webhook destination with its configuration.
 type Target struct {
-	URL         string // The webhook endpoint URL
-	Secret      string // Secret key for HMAC signature generation
-	Name        string // Optional human-readable name for logging/debugging
-	SignVersion string // Signing version ("v0" for timestamp-based, empty for legacy)
+	URL            string // The webhook endpoint URL
+	Secret         string // Secret key for HMAC signature generation
+	PreviousSecret string // Also signs (PreviousSignatureHeader) during a secret rotation grace period
+	Name           string // Optional human-readable name for logging/debugging
+	SignVersion    string // Signing version ("v0" for timestamp-based, empty for legacy)
+	UserAgent      string // Sender name sent as User-Agent (empty for DefaultUserAgent)
+	Redelivery     bool   // Sends RedeliveryHeader
+	Test           bool   // Sends TestHeader
+	Backfilled     bool   // Sends BackfillHeader
+	DeliveryID     string // Sent as DeliveryIDHeader if set, see NewDeliveryID
+	EventID        string // Sent as EventIDHeader if set
+
+	// Headers are added to every request, e.g. an Authorization header the
+	// endpoint requires. See ValidateHeaders for what may be set.
+	Headers map[string]string
+}
+
+// NewDeliveryID returns a random (version 4) UUID for DeliveryIDHeader
+func NewDeliveryID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(fmt.Sprintf("crypto/rand failed: %v", err))
+	}
+	b[6] = b[6]&0x0f | 0x40 // version 4
+	b[8] = b[8]&0x3f | 0x80 // RFC 4122 variant
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// parseRetryAfter returns the wait a Retry-After header value asks for, given
+// either as seconds or as an HTTP date. Returns 0 if the value is missing,
+// invalid or already past.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds <= 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil && at.After(now) {
+		return at.Sub(now)
+	}
+	return 0
 }
@@ -2,14 +2,20 @@ package webhook
 
 import (
 	"context"
+	"errors"
 	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"strconv"
 	"strings"
 	"sync"
 	"testing"
 	"time"
+
+	"github.com/otiai10/namazu/backend/internal/tracing"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // TestNewSender_DefaultTimeout verifies that NewSender creates a sender with default 10s timeout
@@ -47,6 +53,76 @@ func TestWithTimeout_Option(t *testing.T) {
 	}
 }
 
+func TestWithResolver_Option(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+	_, port, _ := net.SplitHostPort(strings.TrimPrefix(server.URL, "http://"))
+
+	f := &fakeLookup{addrs: []net.IPAddr{{IP: net.ParseIP("127.0.0.1")}}, ttl: time.Minute}
+	resolver, _ := newTestResolver(f)
+	sender := NewSender(WithResolver(resolver))
+
+	for i := 0; i < 3; i++ {
+		result := sender.Send(context.Background(), "http://hooks.example.com:"+port+"/webhook", "secret", []byte(`{}`))
+		if !result.Success {
+			t.Fatalf("expected success, got error: %s", result.ErrorMessage)
+		}
+	}
+	if got := resolver.Stats()[0]; got.Host != "hooks.example.com" || got.Lookups != 1 {
+		t.Errorf("expected one cached lookup of hooks.example.com, got %+v", got)
+	}
+}
+
+func TestWithProxy_Option(t *testing.T) {
+	// A forward proxy receives requests for any host
+	var proxiedHosts []string
+	var mu sync.Mutex
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		proxiedHosts = append(proxiedHosts, r.URL.Host)
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer proxy.Close()
+	proxyURL, _ := url.Parse(proxy.URL)
+
+	// The proxy connects to the hosts, but every address of the host is
+	// still checked before each request is handed to it
+	metadata := net.ParseIP("169.254.169.254")
+	f := &fakeLookup{addrs: testAddrs, ttl: time.Second}
+	resolver, clock := newTestResolver(f, WithAddressCheck(func(ip net.IP) error {
+		if ip.Equal(metadata) {
+			return errors.New("internal address")
+		}
+		return nil
+	}))
+	sender := NewSender(WithResolver(resolver), WithProxy(proxyURL))
+
+	result := sender.Send(context.Background(), "http://hooks.example.com/webhook", "secret", []byte(`{}`))
+	if !result.Success {
+		t.Fatalf("expected success, got error: %s", result.ErrorMessage)
+	}
+	if len(proxiedHosts) != 1 || proxiedHosts[0] != "hooks.example.com" {
+		t.Errorf("proxied hosts = %v, want hooks.example.com", proxiedHosts)
+	}
+
+	// The host is rebound to an internal address after validation
+	f.set([]net.IPAddr{testAddrs[0], {IP: metadata}}, time.Second, nil)
+	clock.Advance(time.Minute)
+	result = sender.SendAll(context.Background(), []Target{{URL: "http://hooks.example.com/webhook", Secret: "secret"}}, []byte(`{}`))[0]
+	if result.Success || !strings.Contains(result.ErrorMessage, "internal address") {
+		t.Errorf("expected the rebound host to be refused, got %+v", result)
+	}
+	if rebound := sender.Send(context.Background(), "http://169.254.169.254/latest/meta-data", "secret", []byte(`{}`)); rebound.Success {
+		t.Error("expected an internal IP literal to be refused")
+	}
+	if len(proxiedHosts) != 1 {
+		t.Errorf("refused requests reached the proxy: %v", proxiedHosts)
+	}
+}
+
 // TestSend_Success verifies successful webhook delivery
 func TestSend_Success(t *testing.T) {
 	secret := "test-secret"
@@ -688,6 +764,116 @@ func TestSendAll_MixedVersions(t *testing.T) {
 	}
 }
 
+// TestSendAll_RedeliveryHeader verifies manual redeliveries are marked
+func TestSendAll_RedeliveryHeader(t *testing.T) {
+	var headers []string
+	var mu sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		headers = append(headers, r.Header.Get(RedeliveryHeader))
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	sender := NewSender()
+	sender.SendAll(context.Background(), []Target{{URL: server.URL, Secret: "s"}}, []byte(`{}`))
+	sender.SendAll(context.Background(), []Target{{URL: server.URL, Secret: "s", Redelivery: true}}, []byte(`{}`))
+
+	if len(headers) != 2 || headers[0] != "" || headers[1] != "true" {
+		t.Errorf("%s headers = %q, want [\"\" \"true\"]", RedeliveryHeader, headers)
+	}
+}
+
+// TestSendAll_TestHeader verifies test deliveries are marked
+func TestSendAll_TestHeader(t *testing.T) {
+	var headers []string
+	var mu sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		headers = append(headers, r.Header.Get(TestHeader))
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	sender := NewSender()
+	sender.SendAll(context.Background(), []Target{{URL: server.URL, Secret: "s"}}, []byte(`{}`))
+	sender.SendAll(context.Background(), []Target{{URL: server.URL, Secret: "s", Test: true}}, []byte(`{}`))
+
+	if len(headers) != 2 || headers[0] != "" || headers[1] != "true" {
+		t.Errorf("%s headers = %q, want [\"\" \"true\"]", TestHeader, headers)
+	}
+}
+
+// TestSendAll_IdempotencyHeaders verifies retries of a delivery reuse its delivery ID
+func TestSendAll_IdempotencyHeaders(t *testing.T) {
+	var deliveryIDs, eventIDs []string
+	var mu sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		deliveryIDs = append(deliveryIDs, r.Header.Get(DeliveryIDHeader))
+		eventIDs = append(eventIDs, r.Header.Get(EventIDHeader))
+		if len(deliveryIDs) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	sender := NewRetryingSender(NewSender(), RetryConfig{Enabled: true, MaxRetries: 1, InitialMs: 1, MaxMs: 1})
+	target := Target{URL: server.URL, Secret: "s", DeliveryID: NewDeliveryID(), EventID: "event-1"}
+	if result := sender.Send(context.Background(), target, []byte(`{}`)); !result.Success {
+		t.Fatalf("Send() = %+v, want success after a retry", result)
+	}
+
+	if len(deliveryIDs) != 2 || deliveryIDs[0] != target.DeliveryID || deliveryIDs[1] != target.DeliveryID {
+		t.Errorf("%s headers = %q, want %q on every attempt", DeliveryIDHeader, deliveryIDs, target.DeliveryID)
+	}
+	if eventIDs[0] != "event-1" || eventIDs[1] != "event-1" {
+		t.Errorf("%s headers = %q, want event-1", EventIDHeader, eventIDs)
+	}
+}
+
+func TestNewDeliveryID(t *testing.T) {
+	id := NewDeliveryID()
+	if len(id) != 36 || id[14] != '4' || !strings.ContainsRune("89ab", rune(id[19])) || strings.Count(id, "-") != 4 {
+		t.Errorf("NewDeliveryID() = %q, want a version 4 UUID", id)
+	}
+	if NewDeliveryID() == id {
+		t.Error("NewDeliveryID() returned the same ID twice")
+	}
+}
+
+func TestSendAll_TraceIDHeader(t *testing.T) {
+	var headers []string
+	var mu sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		headers = append(headers, r.Header.Get(tracing.TraceIDHeader))
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	traceID := trace.TraceID{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36}
+	ctx := tracing.Link(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     trace.SpanID{0, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7},
+		TraceFlags: trace.FlagsSampled,
+	}))
+
+	sender := NewSender()
+	sender.SendAll(context.Background(), []Target{{URL: server.URL, Secret: "s"}}, []byte(`{}`))
+	sender.SendAll(ctx, []Target{{URL: server.URL, Secret: "s"}}, []byte(`{}`))
+
+	if len(headers) != 2 || headers[0] != "" || headers[1] != traceID.String() {
+		t.Errorf("%s headers = %q, want [\"\" %q]", tracing.TraceIDHeader, headers, traceID.String())
+	}
+}
+
 // Benchmark tests
 func BenchmarkSend_Success(b *testing.B) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -728,3 +914,69 @@ func BenchmarkSendAll_10Targets(b *testing.B) {
 		sender.SendAll(ctx, targets, payload)
 	}
 }
+
+func TestSendTarget_UserAgent(t *testing.T) {
+	tests := []struct {
+		name      string
+		userAgent string
+		want      string
+	}{
+		{name: "default", userAgent: "", want: DefaultUserAgent},
+		{name: "tenant sender name", userAgent: "acme-alerts/1.0", want: "acme-alerts/1.0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var received string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				received = r.Header.Get("User-Agent")
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer server.Close()
+
+			sender := NewSender()
+			target := Target{URL: server.URL, Secret: "test-secret", UserAgent: tt.userAgent}
+			result := sender.sendTarget(context.Background(), target, []byte(`{"event":"test"}`))
+
+			if !result.Success {
+				t.Fatalf("expected success, got error: %s", result.ErrorMessage)
+			}
+			if received != tt.want {
+				t.Errorf("User-Agent = %q, want %q", received, tt.want)
+			}
+		})
+	}
+}
+
+func TestSendTarget_PreviousSecret_DualSigns(t *testing.T) {
+	payload := []byte(`{"event":"test"}`)
+	var headers []http.Header
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = append(headers, r.Header.Clone())
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	sender := NewSender()
+	sender.sendTarget(context.Background(), Target{URL: server.URL, Secret: "new", PreviousSecret: "old", SignVersion: "v0"}, payload)
+	sender.sendTarget(context.Background(), Target{URL: server.URL, Secret: "new", PreviousSecret: "old"}, payload)
+	sender.sendTarget(context.Background(), Target{URL: server.URL, Secret: "new", SignVersion: "v0"}, payload)
+	if len(headers) != 3 {
+		t.Fatalf("expected 3 requests, got %d", len(headers))
+	}
+
+	ts, _ := strconv.ParseInt(headers[0].Get("X-Signature-Timestamp"), 10, 64)
+	if !VerifyV0("new", ts, payload, headers[0].Get("X-Signature-256"), DefaultMaxAge) {
+		t.Error("expected X-Signature-256 to verify with the new secret")
+	}
+	if !VerifyV0("old", ts, payload, headers[0].Get(PreviousSignatureHeader), DefaultMaxAge) {
+		t.Error("expected the previous signature to verify with the old secret")
+	}
+	if !Verify("old", payload, headers[1].Get(PreviousSignatureHeader)) {
+		t.Error("expected the legacy previous signature to verify with the old secret")
+	}
+	if got := headers[2].Get(PreviousSignatureHeader); got != "" {
+		t.Errorf("expected no previous signature without a previous secret, got %q", got)
+	}
+}
@@ -0,0 +1,119 @@
+package webpush
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/otiai10/namazu/backend/internal/user"
+)
+
+// DefaultTTL is how long a push service keeps a message for an offline browser.
+// An earthquake alert that arrives much later is noise.
+const DefaultTTL = time.Hour
+
+// Result is the outcome of one push
+type Result struct {
+	StatusCode   int
+	Success      bool
+	Gone         bool // The subscription expired or was revoked; stop sending to it
+	ErrorMessage string
+	ResponseTime time.Duration
+}
+
+// Client sends encrypted messages to push services.
+//
+// Client is safe for concurrent use by multiple goroutines.
+type Client struct {
+	vapid  *VAPID
+	ttl    time.Duration
+	client *http.Client
+}
+
+// ClientOption configures the Client
+type ClientOption func(*Client)
+
+// WithTimeout sets the HTTP timeout
+func WithTimeout(timeout time.Duration) ClientOption {
+	return func(c *Client) {
+		c.client.Timeout = timeout
+	}
+}
+
+// WithTTL sets how long push services keep undelivered messages
+func WithTTL(ttl time.Duration) ClientOption {
+	return func(c *Client) {
+		c.ttl = ttl
+	}
+}
+
+// NewClient creates a Client that identifies itself with vapid
+func NewClient(vapid *VAPID, opts ...ClientOption) *Client {
+	c := &Client{
+		vapid:  vapid,
+		ttl:    DefaultTTL,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// PublicKey returns the application server key browsers subscribe with
+func (c *Client) PublicKey() string {
+	return c.vapid.PublicKey()
+}
+
+// Send encrypts payload for a browser and posts it to its push endpoint
+func (c *Client) Send(ctx context.Context, sub user.PushSubscription, payload []byte) Result {
+	start := time.Now()
+	var result Result
+
+	body, err := Encrypt(sub.P256DH, sub.Auth, payload)
+	if err != nil {
+		result.ErrorMessage = fmt.Sprintf("failed to encrypt payload: %v", err)
+		return result
+	}
+	authorization, err := c.vapid.Authorization(sub.Endpoint, start)
+	if err != nil {
+		result.ErrorMessage = err.Error()
+		return result
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		result.ErrorMessage = fmt.Sprintf("failed to create request: %v", err)
+		return result
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("Content-Encoding", "aes128gcm")
+	req.Header.Set("Authorization", authorization)
+	req.Header.Set("TTL", strconv.Itoa(int(c.ttl.Seconds())))
+	req.Header.Set("Urgency", "high")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		result.ErrorMessage = fmt.Sprintf("request failed: %v", err)
+		result.ResponseTime = time.Since(start)
+		return result
+	}
+	defer resp.Body.Close()
+
+	result.StatusCode = resp.StatusCode
+	result.ResponseTime = time.Since(start)
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		result.Success = true
+		return result
+	}
+
+	// 404 and 410 mean the browser unsubscribed or the subscription expired
+	result.Gone = resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone
+	detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	result.ErrorMessage = fmt.Sprintf("unexpected status: %d %s", resp.StatusCode, bytes.TrimSpace(detail))
+	return result
+}
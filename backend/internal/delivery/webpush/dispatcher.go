@@ -0,0 +1,91 @@
+package webpush
+
+import (
+	"context"
+	"log"
+	"sync"
+
+	"github.com/otiai10/namazu/backend/internal/delivery"
+	"github.com/otiai10/namazu/backend/internal/subscription"
+	"github.com/otiai10/namazu/backend/internal/user"
+)
+
+// Users looks up the browsers registered by subscription owners
+type Users interface {
+	GetByUID(ctx context.Context, uid string) (*user.User, error)
+	RemovePushSubscription(ctx context.Context, id string, endpoint string) error
+}
+
+// Dispatcher delivers earthquake events to every browser of the owners of
+// "webpush" subscriptions. Messages without an event are not pushed.
+type Dispatcher struct {
+	client *Client
+	users  Users
+}
+
+// Compile-time interface check
+var _ delivery.Dispatcher = (*Dispatcher)(nil)
+
+// NewDispatcher creates a Dispatcher
+func NewDispatcher(client *Client, users Users) *Dispatcher {
+	return &Dispatcher{client: client, users: users}
+}
+
+// Dispatch pushes the event once per owner, however many of their
+// subscriptions matched, to all of their browsers concurrently. The
+// notification is in the locale of the first matching subscription.
+func (d *Dispatcher) Dispatch(ctx context.Context, msg delivery.Message, subs []subscription.Subscription) {
+	if msg.Event == nil || len(subs) == 0 {
+		return
+	}
+	owners := make(map[string]bool)
+	var wg sync.WaitGroup
+	for _, sub := range subs {
+		if sub.UserID == "" {
+			log.Printf("Subscription [%s]: webpush requires an owner", sub.Name)
+			continue
+		}
+		if owners[sub.UserID] {
+			continue
+		}
+		owners[sub.UserID] = true
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			d.push(ctx, sub, NewNotification(msg.Event, sub.Locale).Payload())
+		}()
+	}
+	wg.Wait()
+}
+
+func (d *Dispatcher) push(ctx context.Context, sub subscription.Subscription, payload []byte) {
+	u, err := d.users.GetByUID(ctx, sub.UserID)
+	if err != nil {
+		log.Printf("Subscription [%s]: failed to get owner: %v", sub.Name, err)
+		return
+	}
+	if u == nil || len(u.PushSubscriptions) == 0 {
+		log.Printf("Subscription [%s]: owner has no browsers registered for push", sub.Name)
+		return
+	}
+
+	var wg sync.WaitGroup
+	for _, browser := range u.PushSubscriptions {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result := d.client.Send(ctx, browser, payload)
+			if result.Success {
+				log.Printf("Subscription [%s]: pushed in %v", sub.Name, result.ResponseTime)
+				return
+			}
+			log.Printf("Subscription [%s]: push failed - %s", sub.Name, result.ErrorMessage)
+			if result.Gone {
+				if err := d.users.RemovePushSubscription(ctx, u.ID, browser.Endpoint); err != nil {
+					log.Printf("Subscription [%s]: failed to remove expired push endpoint: %v", sub.Name, err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,122 @@
+package webpush
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// recordSize is the aes128gcm record size. Everything is sent in one record.
+const recordSize = 4096
+
+// MaxPayloadSize is the largest plaintext that fits in one push message:
+// push services accept 4096 bytes, less the 86-byte header, the 16-byte
+// AEAD tag and the padding delimiter.
+const MaxPayloadSize = recordSize - 86 - 16 - 1
+
+// ErrPayloadTooLarge is returned when a payload exceeds MaxPayloadSize
+var ErrPayloadTooLarge = errors.New("push payload too large")
+
+// Encrypt encrypts plaintext for a browser's push subscription keys (p256dh
+// and auth, base64url) with the aes128gcm content coding of RFC 8291
+func Encrypt(p256dh, auth string, plaintext []byte) ([]byte, error) {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	serverKey, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return encrypt(p256dh, auth, plaintext, salt, serverKey)
+}
+
+func encrypt(p256dh, auth string, plaintext, salt []byte, serverKey *ecdh.PrivateKey) ([]byte, error) {
+	if len(plaintext) > MaxPayloadSize {
+		return nil, ErrPayloadTooLarge
+	}
+	clientPublic, authSecret, err := parseKeys(p256dh, auth)
+	if err != nil {
+		return nil, err
+	}
+	clientPublicBytes := clientPublic.Bytes()
+
+	sharedSecret, err := serverKey.ECDH(clientPublic)
+	if err != nil {
+		return nil, err
+	}
+	serverPublicBytes := serverKey.PublicKey().Bytes()
+
+	// RFC 8291 section 3.4: combine the shared secret with the auth secret
+	keyInfo := "WebPush: info\x00" + string(clientPublicBytes) + string(serverPublicBytes)
+	prkKey, err := hkdf.Extract(sha256.New, sharedSecret, authSecret)
+	if err != nil {
+		return nil, err
+	}
+	ikm, err := hkdf.Expand(sha256.New, prkKey, keyInfo, 32)
+	if err != nil {
+		return nil, err
+	}
+
+	// RFC 8188 section 2.2: derive the content encryption key and nonce
+	prk, err := hkdf.Extract(sha256.New, ikm, salt)
+	if err != nil {
+		return nil, err
+	}
+	cek, err := hkdf.Expand(sha256.New, prk, "Content-Encoding: aes128gcm\x00", 16)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := hkdf.Expand(sha256.New, prk, "Content-Encoding: nonce\x00", 12)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	// Header: salt || record size || key ID length || key ID (the server public key)
+	header := make([]byte, 0, 16+4+1+len(serverPublicBytes))
+	header = append(header, salt...)
+	header = binary.BigEndian.AppendUint32(header, recordSize)
+	header = append(header, byte(len(serverPublicBytes)))
+	header = append(header, serverPublicBytes...)
+
+	// A single, last record: plaintext followed by the 0x02 delimiter
+	record := append(append([]byte(nil), plaintext...), 0x02)
+	return gcm.Seal(header, nonce, record, nil), nil
+}
+
+// ValidateKeys checks the keys of a browser's push subscription
+func ValidateKeys(p256dh, auth string) error {
+	_, _, err := parseKeys(p256dh, auth)
+	return err
+}
+
+func parseKeys(p256dh, auth string) (*ecdh.PublicKey, []byte, error) {
+	publicBytes, err := decodeBase64(p256dh)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid p256dh key: %w", err)
+	}
+	public, err := ecdh.P256().NewPublicKey(publicBytes)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid p256dh key: %w", err)
+	}
+	authSecret, err := decodeBase64(auth)
+	if err != nil || len(authSecret) != 16 {
+		return nil, nil, errors.New("invalid auth secret: must be 16 bytes")
+	}
+	return public, authSecret, nil
+}
@@ -0,0 +1,83 @@
+package webpush
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/otiai10/namazu/backend/internal/i18n"
+	"github.com/otiai10/namazu/backend/internal/source"
+	"github.com/otiai10/namazu/backend/internal/source/p2pquake"
+)
+
+// Notification is the payload the dashboard's service worker shows with
+// ServiceWorkerRegistration.showNotification
+type Notification struct {
+	Title   string `json:"title"`
+	Body    string `json:"body"`
+	Tag     string `json:"tag"` // Replaces an earlier notification for the same event
+	URL     string `json:"url"` // Opened when the notification is clicked
+	EventID string `json:"event_id"`
+	Type    string `json:"type"`
+	Scale   int    `json:"scale,omitempty"`
+}
+
+// NewNotification builds the notification for an event in locale (see
+// package i18n), e.g. title "震度5弱 石川県能登地方" and body
+// "M5.2 1/1 16:10\n石川県、富山県"
+func NewNotification(event source.Event, locale string) Notification {
+	scale := p2pquake.SeverityToScale(event.GetSeverity())
+
+	var title []string
+	switch event.GetType() {
+	case source.EventTypeEEW:
+		title = append(title, i18n.T(locale, "event.eew"))
+	case source.EventTypeTsunami:
+		title = append(title, i18n.T(locale, "event.tsunami"))
+	}
+	if scale > 0 {
+		title = append(title, i18n.Scale(locale, scale))
+	}
+
+	var summary []string
+	if located, ok := event.(source.Located); ok {
+		if h := located.GetHypocenter(); h != nil {
+			if h.Name != "" {
+				title = append(title, h.Name)
+			}
+			if h.Magnitude >= 0 {
+				summary = append(summary, fmt.Sprintf("M%.1f", h.Magnitude))
+			}
+		}
+	}
+	if len(title) == 0 {
+		title = append(title, i18n.T(locale, "event.earthquake"))
+	}
+	if occurredAt := event.GetOccurredAt(); !occurredAt.IsZero() {
+		summary = append(summary, i18n.FormatTime(locale, occurredAt))
+	}
+
+	body := strings.Join(summary, " ")
+	if areas := event.GetAffectedAreas(); len(areas) > 0 {
+		if body != "" {
+			body += "\n"
+		}
+		body += i18n.Areas(locale, areas)
+	}
+
+	return Notification{
+		Title:   strings.Join(title, " "),
+		Body:    body,
+		Tag:     "namazu-" + event.GetID(),
+		URL:     "/",
+		EventID: event.GetID(),
+		Type:    string(event.GetType()),
+		Scale:   scale,
+	}
+}
+
+// Payload encodes the notification for Send
+func (n Notification) Payload() []byte {
+	data, _ := json.Marshal(n)
+	return data
+}
@@ -0,0 +1,112 @@
+// Package webpush sends earthquake alerts to browsers with the Web Push
+// protocol (RFC 8030), encrypting payloads (RFC 8291) and identifying the
+// server with VAPID (RFC 8292).
+package webpush
+
+import (
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// vapidTokenLifetime is how long a VAPID token is valid (RFC 8292 allows at most 24 hours)
+const vapidTokenLifetime = 12 * time.Hour
+
+// VAPID signs requests to push services as this server
+type VAPID struct {
+	key       *ecdsa.PrivateKey
+	publicKey string // Uncompressed P-256 point, base64url; the browser's applicationServerKey
+	subject   string
+}
+
+// NewVAPID creates a VAPID signer from a base64url P-256 private key (the
+// 32-byte scalar, as printed by `npx web-push generate-vapid-keys`) and a
+// contact subject ("mailto:..." or an https URL).
+func NewVAPID(privateKey, subject string) (*VAPID, error) {
+	if !strings.HasPrefix(subject, "mailto:") && !strings.HasPrefix(subject, "https://") {
+		return nil, errors.New("VAPID subject must be a mailto: or https: URL")
+	}
+	d, err := decodeBase64(privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid VAPID private key: %w", err)
+	}
+	ecdhKey, err := ecdh.P256().NewPrivateKey(d)
+	if err != nil {
+		return nil, fmt.Errorf("invalid VAPID private key: %w", err)
+	}
+	public := ecdhKey.PublicKey().Bytes() // 0x04 || X || Y
+	key := &ecdsa.PrivateKey{
+		PublicKey: ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(public[1:33]),
+			Y:     new(big.Int).SetBytes(public[33:]),
+		},
+		D: new(big.Int).SetBytes(d),
+	}
+	return &VAPID{key: key, publicKey: base64.RawURLEncoding.EncodeToString(public), subject: subject}, nil
+}
+
+// PublicKey returns the application server key browsers subscribe with
+func (v *VAPID) PublicKey() string {
+	return v.publicKey
+}
+
+// Authorization returns the Authorization header for a push to endpoint
+func (v *VAPID) Authorization(endpoint string, now time.Time) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return "", fmt.Errorf("invalid push endpoint: %s", endpoint)
+	}
+
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"typ":"JWT","alg":"ES256"}`))
+	claims, err := json.Marshal(map[string]any{
+		"aud": u.Scheme + "://" + u.Host,
+		"exp": now.Add(vapidTokenLifetime).Unix(),
+		"sub": v.subject,
+	})
+	if err != nil {
+		return "", err
+	}
+	unsigned := header + "." + base64.RawURLEncoding.EncodeToString(claims)
+
+	digest := sha256.Sum256([]byte(unsigned))
+	r, s, err := ecdsa.Sign(rand.Reader, v.key, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to sign VAPID token: %w", err)
+	}
+	signature := make([]byte, 64)
+	r.FillBytes(signature[:32])
+	s.FillBytes(signature[32:])
+
+	token := unsigned + "." + base64.RawURLEncoding.EncodeToString(signature)
+	return "vapid t=" + token + ", k=" + v.publicKey, nil
+}
+
+// GenerateVAPIDKey returns a new base64url VAPID private key
+func GenerateVAPIDKey() (string, error) {
+	key, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(key.Bytes()), nil
+}
+
+// decodeBase64 decodes base64url (as the Push API produces) or standard base64,
+// with or without padding
+func decodeBase64(s string) ([]byte, error) {
+	s = strings.TrimRight(s, "=")
+	if strings.ContainsAny(s, "+/") {
+		return base64.RawStdEncoding.DecodeString(s)
+	}
+	return base64.RawURLEncoding.DecodeString(s)
+}
@@ -0,0 +1,275 @@
+package webpush
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/otiai10/namazu/backend/internal/delivery"
+	"github.com/otiai10/namazu/backend/internal/source"
+	"github.com/otiai10/namazu/backend/internal/subscription"
+	"github.com/otiai10/namazu/backend/internal/user"
+)
+
+// browser is a fake user agent holding the keys of a push subscription
+type browser struct {
+	key  *ecdh.PrivateKey
+	auth []byte
+}
+
+func newBrowser(t *testing.T) *browser {
+	t.Helper()
+	key, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	auth := make([]byte, 16)
+	rand.Read(auth)
+	return &browser{key: key, auth: auth}
+}
+
+func (b *browser) subscription(endpoint string) user.PushSubscription {
+	return user.PushSubscription{
+		Endpoint: endpoint,
+		P256DH:   base64.RawURLEncoding.EncodeToString(b.key.PublicKey().Bytes()),
+		Auth:     base64.RawURLEncoding.EncodeToString(b.auth),
+	}
+}
+
+// decrypt reverses the aes128gcm content coding as a browser would
+func (b *browser) decrypt(t *testing.T, body []byte) []byte {
+	t.Helper()
+	salt, rs, idlen := body[:16], binary.BigEndian.Uint32(body[16:20]), int(body[20])
+	if rs != recordSize || idlen != 65 {
+		t.Fatalf("header: rs=%d idlen=%d", rs, idlen)
+	}
+	serverPublic, err := ecdh.P256().NewPublicKey(body[21 : 21+idlen])
+	if err != nil {
+		t.Fatal(err)
+	}
+	shared, _ := b.key.ECDH(serverPublic)
+	keyInfo := "WebPush: info\x00" + string(b.key.PublicKey().Bytes()) + string(serverPublic.Bytes())
+	prkKey, _ := hkdf.Extract(sha256.New, shared, b.auth)
+	ikm, _ := hkdf.Expand(sha256.New, prkKey, keyInfo, 32)
+	prk, _ := hkdf.Extract(sha256.New, ikm, salt)
+	cek, _ := hkdf.Expand(sha256.New, prk, "Content-Encoding: aes128gcm\x00", 16)
+	nonce, _ := hkdf.Expand(sha256.New, prk, "Content-Encoding: nonce\x00", 12)
+	block, _ := aes.NewCipher(cek)
+	gcm, _ := cipher.NewGCM(block)
+	record, err := gcm.Open(nil, nonce, body[21+idlen:], nil)
+	if err != nil {
+		t.Fatalf("failed to decrypt: %v", err)
+	}
+	if record[len(record)-1] != 0x02 {
+		t.Fatalf("missing last-record delimiter: %x", record[len(record)-1])
+	}
+	return record[:len(record)-1]
+}
+
+func newTestVAPID(t *testing.T) *VAPID {
+	t.Helper()
+	key, err := GenerateVAPIDKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	vapid, err := NewVAPID(key, "mailto:ops@namazu.live")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return vapid
+}
+
+func TestEncrypt(t *testing.T) {
+	b := newBrowser(t)
+	sub := b.subscription("https://push.example.com/1")
+
+	body, err := Encrypt(sub.P256DH, sub.Auth, []byte(`{"title":"震度5弱"}`))
+	if err != nil {
+		t.Fatalf("Encrypt() error = %v", err)
+	}
+	if got := b.decrypt(t, body); string(got) != `{"title":"震度5弱"}` {
+		t.Errorf("decrypted = %q", got)
+	}
+
+	if _, err := Encrypt(sub.P256DH, sub.Auth, make([]byte, MaxPayloadSize+1)); !errors.Is(err, ErrPayloadTooLarge) {
+		t.Errorf("Encrypt(oversized) error = %v, want ErrPayloadTooLarge", err)
+	}
+	if _, err := Encrypt(sub.P256DH, "c2hvcnQ", []byte("x")); err == nil {
+		t.Error("expected a short auth secret to be rejected")
+	}
+	if _, err := Encrypt("AAAA", sub.Auth, []byte("x")); err == nil {
+		t.Error("expected an invalid p256dh key to be rejected")
+	}
+}
+
+func TestVAPID_Authorization(t *testing.T) {
+	vapid := newTestVAPID(t)
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	header, err := vapid.Authorization("https://fcm.googleapis.com/fcm/send/abc?x=1", now)
+	if err != nil {
+		t.Fatalf("Authorization() error = %v", err)
+	}
+	token, key, ok := strings.Cut(strings.TrimPrefix(header, "vapid t="), ", k=")
+	if !ok || key != vapid.PublicKey() {
+		t.Fatalf("Authorization() = %q", header)
+	}
+
+	parts := strings.Split(token, ".")
+	claimsJSON, _ := base64.RawURLEncoding.DecodeString(parts[1])
+	var claims struct {
+		Aud string `json:"aud"`
+		Exp int64  `json:"exp"`
+		Sub string `json:"sub"`
+	}
+	json.Unmarshal(claimsJSON, &claims)
+	if claims.Aud != "https://fcm.googleapis.com" || claims.Sub != "mailto:ops@namazu.live" || claims.Exp != now.Add(12*time.Hour).Unix() {
+		t.Errorf("claims = %+v", claims)
+	}
+
+	signature, _ := base64.RawURLEncoding.DecodeString(parts[2])
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	r, s := new(big.Int).SetBytes(signature[:32]), new(big.Int).SetBytes(signature[32:])
+	if !ecdsa.Verify(&vapid.key.PublicKey, digest[:], r, s) {
+		t.Error("signature does not verify with the public key")
+	}
+}
+
+func TestNewVAPID_Invalid(t *testing.T) {
+	key, _ := GenerateVAPIDKey()
+	if _, err := NewVAPID(key, "ops@namazu.live"); err == nil {
+		t.Error("expected a subject without mailto: to be rejected")
+	}
+	if _, err := NewVAPID("not-a-key", "mailto:ops@namazu.live"); err == nil {
+		t.Error("expected an invalid private key to be rejected")
+	}
+}
+
+// pushService is a fake push service that records requests
+type pushService struct {
+	mu       sync.Mutex
+	requests []*http.Request
+	bodies   [][]byte
+	status   int
+}
+
+func (p *pushService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	p.mu.Lock()
+	p.requests = append(p.requests, r)
+	p.bodies = append(p.bodies, body)
+	p.mu.Unlock()
+	if p.status != 0 {
+		w.WriteHeader(p.status)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+}
+
+func TestClient_Send(t *testing.T) {
+	service := &pushService{}
+	server := httptest.NewServer(service)
+	defer server.Close()
+
+	b := newBrowser(t)
+	client := NewClient(newTestVAPID(t), WithTTL(10*time.Minute))
+	result := client.Send(context.Background(), b.subscription(server.URL+"/push/1"), []byte(`{"title":"test"}`))
+
+	if !result.Success || result.StatusCode != http.StatusCreated {
+		t.Fatalf("Send() = %+v", result)
+	}
+	req := service.requests[0]
+	if req.Header.Get("Content-Encoding") != "aes128gcm" || req.Header.Get("TTL") != "600" || req.Header.Get("Urgency") != "high" {
+		t.Errorf("headers = %v", req.Header)
+	}
+	if !strings.HasPrefix(req.Header.Get("Authorization"), "vapid t=") {
+		t.Errorf("Authorization = %q", req.Header.Get("Authorization"))
+	}
+	if got := b.decrypt(t, service.bodies[0]); string(got) != `{"title":"test"}` {
+		t.Errorf("decrypted = %q", got)
+	}
+}
+
+func TestClient_Send_Gone(t *testing.T) {
+	service := &pushService{status: http.StatusGone}
+	server := httptest.NewServer(service)
+	defer server.Close()
+
+	result := NewClient(newTestVAPID(t)).Send(context.Background(), newBrowser(t).subscription(server.URL+"/push/1"), []byte(`{}`))
+	if result.Success || !result.Gone {
+		t.Errorf("Send() = %+v, want a gone subscription", result)
+	}
+}
+
+type testEvent struct{}
+
+func (testEvent) GetID() string              { return "e1" }
+func (testEvent) GetType() source.EventType  { return source.EventTypeEarthquake }
+func (testEvent) GetSource() string          { return "p2pquake" }
+func (testEvent) GetSeverity() int           { return 50 }
+func (testEvent) GetAffectedAreas() []string { return []string{"石川県", "富山県"} }
+func (testEvent) GetOccurredAt() time.Time   { return time.Date(2024, 1, 1, 7, 10, 0, 0, time.UTC) }
+func (testEvent) GetReceivedAt() time.Time   { return time.Time{} }
+func (testEvent) GetRawJSON() string         { return `{}` }
+
+func TestNewNotification(t *testing.T) {
+	n := NewNotification(testEvent{}, "ja")
+	if n.Title != "震度5弱" || n.Body != "1/1 16:10\n石川県、富山県" || n.Tag != "namazu-e1" || n.EventID != "e1" || n.Type != "earthquake" {
+		t.Errorf("NewNotification() = %+v", n)
+	}
+	n = NewNotification(testEvent{}, "en-US")
+	if n.Title != "Shindo 5 Lower" || n.Body != "Jan 1 16:10 JST\nIshikawa, Toyama" || n.Tag != "namazu-e1" {
+		t.Errorf("NewNotification(en) = %+v", n)
+	}
+}
+
+func TestDispatcher_Dispatch(t *testing.T) {
+	service := &pushService{}
+	server := httptest.NewServer(service)
+	defer server.Close()
+	gone := &pushService{status: http.StatusGone}
+	goneServer := httptest.NewServer(gone)
+	defer goneServer.Close()
+
+	ctx := context.Background()
+	users := user.NewMemoryRepository()
+	id, _ := users.Create(ctx, user.User{UID: "uid-1"})
+	users.AddPushSubscription(ctx, id, newBrowser(t).subscription(server.URL+"/push/laptop"))
+	users.AddPushSubscription(ctx, id, newBrowser(t).subscription(goneServer.URL+"/push/old-phone"))
+
+	d := NewDispatcher(NewClient(newTestVAPID(t)), users)
+	d.Dispatch(ctx, delivery.Message{ID: "e1", Payload: []byte(`{}`), Event: testEvent{}}, []subscription.Subscription{
+		{Name: "Strong", UserID: "uid-1", Delivery: subscription.DeliveryConfig{Type: subscription.DeliveryTypeWebPush}},
+		{Name: "Tokyo", UserID: "uid-1", Delivery: subscription.DeliveryConfig{Type: subscription.DeliveryTypeWebPush}},
+		{Name: "Ownerless", Delivery: subscription.DeliveryConfig{Type: subscription.DeliveryTypeWebPush}},
+	})
+	// Messages without an event are not pushed
+	d.Dispatch(ctx, delivery.Message{ID: "n1", Payload: []byte(`{}`)}, []subscription.Subscription{
+		{Name: "Strong", UserID: "uid-1", Delivery: subscription.DeliveryConfig{Type: subscription.DeliveryTypeWebPush}},
+	})
+
+	if len(service.requests) != 1 {
+		t.Errorf("expected one push per owner and browser, got %d", len(service.requests))
+	}
+	u, _ := users.Get(ctx, id)
+	if len(u.PushSubscriptions) != 1 || !strings.HasSuffix(u.PushSubscriptions[0].Endpoint, "/push/laptop") {
+		t.Errorf("expected the gone endpoint to be removed, got %+v", u.PushSubscriptions)
+	}
+}
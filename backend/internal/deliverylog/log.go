@@ -0,0 +1,229 @@
+package deliverylog
+
+import (
+	"bufio"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/otiai10/namazu/backend/internal/store"
+)
+
+// Version is the format version of delivery logs
+const Version = 1
+
+// Line types
+const (
+	TypeHeader    = "header"
+	TypeDelivery  = "delivery"
+	TypeSignature = "signature"
+)
+
+// maxLineSize bounds the length of a line accepted by Verify
+const maxLineSize = 1 << 20
+
+// ErrInvalidLog is returned when a delivery log fails verification
+var ErrInvalidLog = errors.New("invalid delivery log")
+
+// Header is the first line of a delivery log
+type Header struct {
+	Type           string    `json:"type"`
+	Prev           string    `json:"prev"` // Always empty
+	Version        int       `json:"version"`
+	SubscriptionID string    `json:"subscription_id"`
+	From           time.Time `json:"from"`
+	To             time.Time `json:"to"`
+	GeneratedAt    time.Time `json:"generated_at"`
+	Algorithm      string    `json:"algorithm"`
+	KeyID          string    `json:"key_id"`
+}
+
+// Entry is one delivery in a delivery log
+type Entry struct {
+	Type           string    `json:"type"`
+	Prev           string    `json:"prev"` // SHA-256 (hex) of the previous line
+	Seq            int       `json:"seq"`  // 1-based
+	DeliveredAt    time.Time `json:"delivered_at"`
+	EventID        string    `json:"event_id"`
+	URL            string    `json:"url"`
+	StatusCode     int       `json:"status_code"`
+	Success        bool      `json:"success"`
+	Error          string    `json:"error,omitempty"`
+	Attempts       int       `json:"attempts"`
+	ResponseTimeMs int64     `json:"response_time_ms"`
+	PayloadSHA256  string    `json:"payload_sha256"`
+}
+
+// Signature is the last line of a delivery log
+type Signature struct {
+	Type      string `json:"type"`
+	Prev      string `json:"prev"` // Head of the chain: SHA-256 (hex) of the last entry (or header)
+	Count     int    `json:"count"`
+	KeyID     string `json:"key_id"`
+	Signature string `json:"signature"` // Base64 Ed25519 signature of "namazu-delivery-log-v1:" + prev
+}
+
+// Log is the content of a verified delivery log
+type Log struct {
+	Header  Header
+	Entries []Entry
+}
+
+// Write writes a signed delivery log of records to w
+func (s *Signer) Write(w io.Writer, subscriptionID string, from, to time.Time, records []store.DeliveryRecord) error {
+	cw := &chainWriter{w: w}
+
+	cw.write(&Header{
+		Type:           TypeHeader,
+		Version:        Version,
+		SubscriptionID: subscriptionID,
+		From:           from.UTC(),
+		To:             to.UTC(),
+		GeneratedAt:    time.Now().UTC(),
+		Algorithm:      Algorithm,
+		KeyID:          s.keyID,
+	})
+
+	for i, rec := range records {
+		cw.write(&Entry{
+			Type:           TypeDelivery,
+			Prev:           cw.prev,
+			Seq:            i + 1,
+			DeliveredAt:    rec.DeliveredAt.UTC(),
+			EventID:        rec.EventID,
+			URL:            rec.URL,
+			StatusCode:     rec.StatusCode,
+			Success:        rec.Success,
+			Error:          rec.ErrorMessage,
+			Attempts:       rec.Attempts,
+			ResponseTimeMs: rec.ResponseTimeMs,
+			PayloadSHA256:  rec.PayloadSHA256,
+		})
+	}
+
+	cw.write(&Signature{
+		Type:      TypeSignature,
+		Prev:      cw.prev,
+		Count:     len(records),
+		KeyID:     s.keyID,
+		Signature: s.sign(cw.prev),
+	})
+	return cw.err
+}
+
+// chainWriter writes JSON lines and tracks the hash of the last line
+type chainWriter struct {
+	w    io.Writer
+	prev string
+	err  error
+}
+
+func (c *chainWriter) write(v interface{}) {
+	if c.err != nil {
+		return
+	}
+	line, err := json.Marshal(v)
+	if err != nil {
+		c.err = err
+		return
+	}
+	if _, err := c.w.Write(append(line, '\n')); err != nil {
+		c.err = err
+		return
+	}
+	c.prev = lineHash(line)
+}
+
+// lineHash returns the SHA-256 (hex) of a line without its newline
+func lineHash(line []byte) string {
+	sum := sha256.Sum256(line)
+	return hex.EncodeToString(sum[:])
+}
+
+// Verify checks the hash chain and signature of a delivery log against pub
+// and returns its content.
+func Verify(r io.Reader, pub ed25519.PublicKey) (*Log, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+
+	var (
+		log       Log
+		prev      string
+		lineNo    int
+		signature *Signature
+	)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		lineNo++
+		if signature != nil {
+			return nil, fmt.Errorf("%w: line %d: content after signature", ErrInvalidLog, lineNo)
+		}
+
+		var common struct {
+			Type string `json:"type"`
+			Prev string `json:"prev"`
+		}
+		if err := json.Unmarshal(line, &common); err != nil {
+			return nil, fmt.Errorf("%w: line %d: %v", ErrInvalidLog, lineNo, err)
+		}
+		if common.Prev != prev {
+			return nil, fmt.Errorf("%w: line %d: hash chain broken", ErrInvalidLog, lineNo)
+		}
+
+		switch {
+		case lineNo == 1:
+			if common.Type != TypeHeader {
+				return nil, fmt.Errorf("%w: line 1: missing header", ErrInvalidLog)
+			}
+			if err := json.Unmarshal(line, &log.Header); err != nil {
+				return nil, fmt.Errorf("%w: line 1: %v", ErrInvalidLog, err)
+			}
+			if log.Header.Version != Version || log.Header.Algorithm != Algorithm {
+				return nil, fmt.Errorf("%w: unsupported version %d (%s)", ErrInvalidLog, log.Header.Version, log.Header.Algorithm)
+			}
+		case common.Type == TypeDelivery:
+			var entry Entry
+			if err := json.Unmarshal(line, &entry); err != nil {
+				return nil, fmt.Errorf("%w: line %d: %v", ErrInvalidLog, lineNo, err)
+			}
+			if entry.Seq != len(log.Entries)+1 {
+				return nil, fmt.Errorf("%w: line %d: expected seq %d, got %d", ErrInvalidLog, lineNo, len(log.Entries)+1, entry.Seq)
+			}
+			log.Entries = append(log.Entries, entry)
+		case common.Type == TypeSignature:
+			signature = &Signature{}
+			if err := json.Unmarshal(line, signature); err != nil {
+				return nil, fmt.Errorf("%w: line %d: %v", ErrInvalidLog, lineNo, err)
+			}
+		default:
+			return nil, fmt.Errorf("%w: line %d: unexpected type %q", ErrInvalidLog, lineNo, common.Type)
+		}
+
+		prev = lineHash(line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidLog, err)
+	}
+
+	if signature == nil {
+		return nil, fmt.Errorf("%w: missing signature", ErrInvalidLog)
+	}
+	if signature.Count != len(log.Entries) {
+		return nil, fmt.Errorf("%w: signature covers %d entries, log has %d", ErrInvalidLog, signature.Count, len(log.Entries))
+	}
+	if signature.KeyID != KeyID(pub) || log.Header.KeyID != signature.KeyID {
+		return nil, fmt.Errorf("%w: signed with key %s, not %s", ErrInvalidLog, signature.KeyID, KeyID(pub))
+	}
+	sig, err := base64.StdEncoding.DecodeString(signature.Signature)
+	if err != nil || !ed25519.Verify(pub, signedMessage(signature.Prev), sig) {
+		return nil, fmt.Errorf("%w: bad signature", ErrInvalidLog)
+	}
+
+	return &log, nil
+}
@@ -0,0 +1,127 @@
+package deliverylog
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/otiai10/namazu/backend/internal/store"
+)
+
+func testSigner(t *testing.T, b byte) *Signer {
+	t.Helper()
+	s, err := NewSigner(bytes.Repeat([]byte{b}, 32))
+	if err != nil {
+		t.Fatalf("NewSigner() error = %v", err)
+	}
+	return s
+}
+
+func testRecords() []store.DeliveryRecord {
+	at := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	return []store.DeliveryRecord{
+		{EventID: "ev-1", URL: "https://example.com/hook", StatusCode: 200, Success: true, Attempts: 1, ResponseTimeMs: 120, PayloadSHA256: "aa", DeliveredAt: at},
+		{EventID: "ev-2", URL: "https://example.com/hook", StatusCode: 500, Success: false, ErrorMessage: "HTTP 500", Attempts: 3, ResponseTimeMs: 80, PayloadSHA256: "bb", DeliveredAt: at.Add(time.Hour)},
+	}
+}
+
+func writeLog(t *testing.T, s *Signer, records []store.DeliveryRecord) string {
+	t.Helper()
+	var buf bytes.Buffer
+	from := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	if err := s.Write(&buf, "sub-1", from, from.AddDate(0, 0, 1), records); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	return buf.String()
+}
+
+func TestWriteVerify(t *testing.T) {
+	s := testSigner(t, 1)
+	out := writeLog(t, s, testRecords())
+
+	lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("got %d lines, want 4:\n%s", len(lines), out)
+	}
+
+	log, err := Verify(strings.NewReader(out), s.PublicKey())
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if log.Header.SubscriptionID != "sub-1" || log.Header.KeyID != s.KeyID() {
+		t.Errorf("Header = %+v", log.Header)
+	}
+	if len(log.Entries) != 2 {
+		t.Fatalf("len(Entries) = %d, want 2", len(log.Entries))
+	}
+	if e := log.Entries[1]; e.Seq != 2 || e.EventID != "ev-2" || e.Error != "HTTP 500" || e.Attempts != 3 {
+		t.Errorf("Entries[1] = %+v", e)
+	}
+}
+
+func TestWriteVerify_Empty(t *testing.T) {
+	s := testSigner(t, 1)
+	log, err := Verify(strings.NewReader(writeLog(t, s, nil)), s.PublicKey())
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if len(log.Entries) != 0 {
+		t.Errorf("len(Entries) = %d, want 0", len(log.Entries))
+	}
+}
+
+func TestVerify_Tampered(t *testing.T) {
+	s := testSigner(t, 1)
+	out := writeLog(t, s, testRecords())
+	lines := strings.SplitAfter(out, "\n")
+
+	tests := []struct {
+		name string
+		log  string
+		pub  *Signer
+	}{
+		{
+			name: "modified entry",
+			log:  strings.Replace(out, `"status_code":500`, `"status_code":200`, 1),
+		},
+		{
+			name: "removed entry",
+			log:  lines[0] + lines[2] + lines[3],
+		},
+		{
+			name: "truncated",
+			log:  lines[0] + lines[1],
+		},
+		{
+			name: "appended line",
+			log:  out + lines[1],
+		},
+		{
+			name: "other key",
+			log:  out,
+			pub:  testSigner(t, 2),
+		},
+		{
+			name: "re-signed by other key",
+			log:  writeLog(t, testSigner(t, 2), testRecords()),
+		},
+		{
+			name: "not json",
+			log:  "hello\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pub := s.PublicKey()
+			if tt.pub != nil {
+				pub = tt.pub.PublicKey()
+			}
+			if _, err := Verify(strings.NewReader(tt.log), pub); !errors.Is(err, ErrInvalidLog) {
+				t.Errorf("Verify() error = %v, want ErrInvalidLog", err)
+			}
+		})
+	}
+}
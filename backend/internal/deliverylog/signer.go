@@ -0,0 +1,88 @@
+// Package deliverylog exports delivery records as a verifiable, signed NDJSON log.
+//
+// Every line carries the SHA-256 of the previous line ("prev"), forming a hash
+// chain from the header to the last record. The final line signs the head of
+// the chain with the server's Ed25519 key, so anyone holding the published
+// public key can verify that the log is complete and unmodified.
+package deliverylog
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+)
+
+// Algorithm is the signature algorithm of delivery logs
+const Algorithm = "ed25519"
+
+// ErrInvalidKey is returned when a signing key cannot be parsed
+var ErrInvalidKey = errors.New("invalid delivery log key")
+
+// Signer signs delivery log exports
+type Signer struct {
+	key   ed25519.PrivateKey
+	keyID string
+}
+
+// NewSigner creates a Signer from a 32-byte Ed25519 seed
+func NewSigner(seed []byte) (*Signer, error) {
+	if len(seed) != ed25519.SeedSize {
+		return nil, fmt.Errorf("%w: seed must be %d bytes, got %d", ErrInvalidKey, ed25519.SeedSize, len(seed))
+	}
+	key := ed25519.NewKeyFromSeed(seed)
+	return &Signer{
+		key:   key,
+		keyID: KeyID(key.Public().(ed25519.PublicKey)),
+	}, nil
+}
+
+// ParseSigner creates a Signer from a base64-encoded 32-byte seed
+// (e.g., the output of `openssl rand -base64 32`)
+func ParseSigner(encoded string) (*Signer, error) {
+	seed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidKey, err)
+	}
+	return NewSigner(seed)
+}
+
+// PublicKey returns the public key that verifies the signer's logs
+func (s *Signer) PublicKey() ed25519.PublicKey {
+	return s.key.Public().(ed25519.PublicKey)
+}
+
+// KeyID returns the identifier of the signer's key
+func (s *Signer) KeyID() string {
+	return s.keyID
+}
+
+// sign signs the head of a hash chain
+func (s *Signer) sign(head string) string {
+	return base64.StdEncoding.EncodeToString(ed25519.Sign(s.key, signedMessage(head)))
+}
+
+// KeyID identifies a public key: the first 16 hex digits of its SHA-256
+func KeyID(pub ed25519.PublicKey) string {
+	sum := sha256.Sum256(pub)
+	return hex.EncodeToString(sum[:8])
+}
+
+// ParsePublicKey decodes a base64-encoded Ed25519 public key
+func ParsePublicKey(encoded string) (ed25519.PublicKey, error) {
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidKey, err)
+	}
+	if len(raw) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("%w: public key must be %d bytes, got %d", ErrInvalidKey, ed25519.PublicKeySize, len(raw))
+	}
+	return ed25519.PublicKey(raw), nil
+}
+
+// signedMessage is the message signed for a chain head
+func signedMessage(head string) []byte {
+	return []byte("namazu-delivery-log-v1:" + head)
+}
@@ -0,0 +1,51 @@
+package deliverylog
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"testing"
+)
+
+func TestParseSigner(t *testing.T) {
+	seed := bytes.Repeat([]byte{1}, 32)
+
+	s, err := ParseSigner(base64.StdEncoding.EncodeToString(seed))
+	if err != nil {
+		t.Fatalf("ParseSigner() error = %v", err)
+	}
+	if len(s.KeyID()) != 16 {
+		t.Errorf("KeyID() = %q, want 16 hex digits", s.KeyID())
+	}
+	if s.KeyID() != KeyID(s.PublicKey()) {
+		t.Errorf("KeyID() = %q, want %q", s.KeyID(), KeyID(s.PublicKey()))
+	}
+
+	// The same seed yields the same key
+	s2, _ := NewSigner(seed)
+	if !s.PublicKey().Equal(s2.PublicKey()) {
+		t.Error("same seed produced different keys")
+	}
+
+	for _, encoded := range []string{"", "not base64!", base64.StdEncoding.EncodeToString([]byte("short"))} {
+		if _, err := ParseSigner(encoded); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("ParseSigner(%q) error = %v, want ErrInvalidKey", encoded, err)
+		}
+	}
+}
+
+func TestParsePublicKey(t *testing.T) {
+	s, _ := NewSigner(bytes.Repeat([]byte{2}, 32))
+
+	pub, err := ParsePublicKey(base64.StdEncoding.EncodeToString(s.PublicKey()))
+	if err != nil {
+		t.Fatalf("ParsePublicKey() error = %v", err)
+	}
+	if !pub.Equal(s.PublicKey()) {
+		t.Error("ParsePublicKey() returned a different key")
+	}
+
+	if _, err := ParsePublicKey(base64.StdEncoding.EncodeToString([]byte("short"))); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("ParsePublicKey() error = %v, want ErrInvalidKey", err)
+	}
+}
@@ -0,0 +1,62 @@
+// Package egress tracks outbound webhook traffic and deliveries per user for
+// cost attribution and usage reporting, and enforces admin-defined monthly
+// egress budgets.
+package egress
+
+import (
+	"context"
+	"time"
+)
+
+// Usage holds the outbound traffic and deliveries attributed to a user within a billing period
+type Usage struct {
+	UserID        string    `json:"user_id" firestore:"userId"`
+	Period        string    `json:"period" firestore:"period"` // "YYYY-MM" (UTC)
+	Requests      int64     `json:"requests" firestore:"requests"`
+	BytesSent     int64     `json:"bytes_sent" firestore:"bytesSent"`
+	Deliveries    int64     `json:"deliveries" firestore:"deliveries"`        // Final outcomes, retries excluded
+	EventsMatched int64     `json:"events_matched" firestore:"eventsMatched"` // Events matching at least one subscription
+	UpdatedAt     time.Time `json:"updated_at" firestore:"updatedAt"`
+}
+
+// Delta is an increment of a user's usage counters
+type Delta struct {
+	Requests      int64
+	Bytes         int64
+	Deliveries    int64
+	EventsMatched int64
+}
+
+// isZero reports whether the delta changes nothing
+func (d Delta) isZero() bool {
+	return d == Delta{}
+}
+
+// Budget is a per-user monthly egress allowance set by an admin.
+// A MonthlyBytes of zero means unlimited.
+type Budget struct {
+	UserID       string    `json:"user_id" firestore:"userId"`
+	MonthlyBytes int64     `json:"monthly_bytes" firestore:"monthlyBytes"`
+	UpdatedBy    string    `json:"updated_by,omitempty" firestore:"updatedBy"`
+	UpdatedAt    time.Time `json:"updated_at" firestore:"updatedAt"`
+}
+
+// Repository persists usage counters and budgets
+type Repository interface {
+	// AddUsage atomically increments the user's counters for the period
+	AddUsage(ctx context.Context, userID, period string, d Delta) error
+
+	// GetUsage returns the user's usage for the period, or nil if none was recorded
+	GetUsage(ctx context.Context, userID, period string) (*Usage, error)
+
+	// GetBudget returns the user's budget, or nil if none is set
+	GetBudget(ctx context.Context, userID string) (*Budget, error)
+
+	// SetBudget creates or replaces the user's budget
+	SetBudget(ctx context.Context, budget Budget) error
+}
+
+// Period returns the billing period key ("YYYY-MM" in UTC) for the given time
+func Period(t time.Time) string {
+	return t.UTC().Format("2006-01")
+}
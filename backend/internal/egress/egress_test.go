@@ -0,0 +1,25 @@
+package egress
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPeriod(t *testing.T) {
+	tests := []struct {
+		name string
+		t    time.Time
+		want string
+	}{
+		{name: "utc", t: time.Date(2024, 3, 15, 12, 0, 0, 0, time.UTC), want: "2024-03"},
+		{name: "converted to utc", t: time.Date(2024, 4, 1, 5, 0, 0, 0, time.FixedZone("JST", 9*60*60)), want: "2024-03"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Period(tt.t); got != tt.want {
+				t.Errorf("Period() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package egress
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// usageCollection holds one document per user and period
+	usageCollection = "egress_usage"
+
+	// budgetCollection holds one document per user
+	budgetCollection = "egress_budgets"
+)
+
+// FirestoreRepository implements Repository using Firestore
+type FirestoreRepository struct {
+	client *firestore.Client
+}
+
+// Compile-time interface check
+var _ Repository = (*FirestoreRepository)(nil)
+
+// NewFirestoreRepository creates a new FirestoreRepository
+func NewFirestoreRepository(client *firestore.Client) *FirestoreRepository {
+	return &FirestoreRepository{client: client}
+}
+
+// usageDocID returns the document ID for a user's usage in a period
+func usageDocID(userID, period string) string {
+	return userID + "_" + period
+}
+
+// AddUsage increments the counters using server-side increments, so concurrent
+// deliveries for the same user do not lose updates.
+func (r *FirestoreRepository) AddUsage(ctx context.Context, userID, period string, d Delta) error {
+	if r.client == nil {
+		return fmt.Errorf("firestore client is nil")
+	}
+	if userID == "" {
+		return fmt.Errorf("user ID is required")
+	}
+
+	doc := r.client.Collection(usageCollection).Doc(usageDocID(userID, period))
+	_, err := doc.Set(ctx, map[string]interface{}{
+		"userId":        userID,
+		"period":        period,
+		"requests":      firestore.Increment(d.Requests),
+		"bytesSent":     firestore.Increment(d.Bytes),
+		"deliveries":    firestore.Increment(d.Deliveries),
+		"eventsMatched": firestore.Increment(d.EventsMatched),
+		"updatedAt":     time.Now().UTC(),
+	}, firestore.MergeAll)
+	if err != nil {
+		return fmt.Errorf("failed to add usage: %w", err)
+	}
+	return nil
+}
+
+// GetUsage retrieves the user's usage for a period
+func (r *FirestoreRepository) GetUsage(ctx context.Context, userID, period string) (*Usage, error) {
+	if r.client == nil {
+		return nil, fmt.Errorf("firestore client is nil")
+	}
+
+	docSnap, err := r.client.Collection(usageCollection).Doc(usageDocID(userID, period)).Get(ctx)
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get usage: %w", err)
+	}
+
+	var usage Usage
+	if err := docSnap.DataTo(&usage); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal usage: %w", err)
+	}
+	return &usage, nil
+}
+
+// GetBudget retrieves the user's budget
+func (r *FirestoreRepository) GetBudget(ctx context.Context, userID string) (*Budget, error) {
+	if r.client == nil {
+		return nil, fmt.Errorf("firestore client is nil")
+	}
+
+	docSnap, err := r.client.Collection(budgetCollection).Doc(userID).Get(ctx)
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get budget: %w", err)
+	}
+
+	var budget Budget
+	if err := docSnap.DataTo(&budget); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal budget: %w", err)
+	}
+	return &budget, nil
+}
+
+// SetBudget creates or replaces the user's budget
+func (r *FirestoreRepository) SetBudget(ctx context.Context, budget Budget) error {
+	if r.client == nil {
+		return fmt.Errorf("firestore client is nil")
+	}
+	if budget.UserID == "" {
+		return fmt.Errorf("user ID is required")
+	}
+
+	if budget.UpdatedAt.IsZero() {
+		budget.UpdatedAt = time.Now().UTC()
+	}
+
+	if _, err := r.client.Collection(budgetCollection).Doc(budget.UserID).Set(ctx, budget); err != nil {
+		return fmt.Errorf("failed to set budget: %w", err)
+	}
+	return nil
+}
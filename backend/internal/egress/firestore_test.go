@@ -0,0 +1,34 @@
+package egress
+
+import (
+	"context"
+	"testing"
+)
+
+func TestFirestoreRepository_ImplementsRepository(t *testing.T) {
+	var _ Repository = (*FirestoreRepository)(nil)
+}
+
+func TestUsageDocID(t *testing.T) {
+	if got := usageDocID("user-1", "2024-03"); got != "user-1_2024-03" {
+		t.Errorf("usageDocID() = %q, want %q", got, "user-1_2024-03")
+	}
+}
+
+func TestFirestoreRepository_NilClient(t *testing.T) {
+	repo := NewFirestoreRepository(nil)
+	ctx := context.Background()
+
+	if err := repo.AddUsage(ctx, "user-1", "2024-03", Delta{Requests: 1, Bytes: 100}); err == nil {
+		t.Error("AddUsage() expected error for nil client")
+	}
+	if _, err := repo.GetUsage(ctx, "user-1", "2024-03"); err == nil {
+		t.Error("GetUsage() expected error for nil client")
+	}
+	if _, err := repo.GetBudget(ctx, "user-1"); err == nil {
+		t.Error("GetBudget() expected error for nil client")
+	}
+	if err := repo.SetBudget(ctx, Budget{UserID: "user-1"}); err == nil {
+		t.Error("SetBudget() expected error for nil client")
+	}
+}
@@ -0,0 +1,82 @@
+package egress
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// MemoryRepository implements Repository in process memory.
+// Used under --test-mode and NAMAZU_STORE_TYPE=memory; nothing survives a restart.
+type MemoryRepository struct {
+	mu      sync.Mutex
+	usage   map[string]Usage  // Keyed by usageDocID
+	budgets map[string]Budget // Keyed by user ID
+}
+
+// Compile-time interface check
+var _ Repository = (*MemoryRepository)(nil)
+
+// NewMemoryRepository creates an empty MemoryRepository
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{usage: map[string]Usage{}, budgets: map[string]Budget{}}
+}
+
+// AddUsage increments the user's counters for the period
+func (r *MemoryRepository) AddUsage(ctx context.Context, userID, period string, d Delta) error {
+	if userID == "" {
+		return fmt.Errorf("user ID is required")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := usageDocID(userID, period)
+	usage := r.usage[key]
+	usage.UserID = userID
+	usage.Period = period
+	usage.Requests += d.Requests
+	usage.BytesSent += d.Bytes
+	usage.Deliveries += d.Deliveries
+	usage.EventsMatched += d.EventsMatched
+	usage.UpdatedAt = time.Now().UTC()
+	r.usage[key] = usage
+	return nil
+}
+
+// GetUsage returns the user's usage for the period, or nil if none was recorded
+func (r *MemoryRepository) GetUsage(ctx context.Context, userID, period string) (*Usage, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	usage, ok := r.usage[usageDocID(userID, period)]
+	if !ok {
+		return nil, nil
+	}
+	return &usage, nil
+}
+
+// GetBudget returns the user's budget, or nil if none is set
+func (r *MemoryRepository) GetBudget(ctx context.Context, userID string) (*Budget, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	budget, ok := r.budgets[userID]
+	if !ok {
+		return nil, nil
+	}
+	return &budget, nil
+}
+
+// SetBudget creates or replaces the user's budget
+func (r *MemoryRepository) SetBudget(ctx context.Context, budget Budget) error {
+	if budget.UserID == "" {
+		return fmt.Errorf("user ID is required")
+	}
+	if budget.UpdatedAt.IsZero() {
+		budget.UpdatedAt = time.Now().UTC()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.budgets[budget.UserID] = budget
+	return nil
+}
@@ -0,0 +1,45 @@
+package egress
+
+import (
+	"context"
+	"testing"
+)
+
+func TestMemoryRepository(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryRepository()
+
+	if err := repo.AddUsage(ctx, "", "2026-01", Delta{Requests: 1, Bytes: 100}); err == nil {
+		t.Error("AddUsage() without a user ID should fail")
+	}
+	if usage, err := repo.GetUsage(ctx, "user-1", "2026-01"); err != nil || usage != nil {
+		t.Errorf("GetUsage() before any usage = %v, %v, want nil, nil", usage, err)
+	}
+
+	for i := 0; i < 3; i++ {
+		if err := repo.AddUsage(ctx, "user-1", "2026-01", Delta{Requests: 1, Bytes: 100}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := repo.AddUsage(ctx, "user-1", "2026-02", Delta{Requests: 1, Bytes: 5}); err != nil {
+		t.Fatal(err)
+	}
+	usage, err := repo.GetUsage(ctx, "user-1", "2026-01")
+	if err != nil || usage == nil || usage.Requests != 3 || usage.BytesSent != 300 || usage.UpdatedAt.IsZero() {
+		t.Errorf("GetUsage() = %+v, %v", usage, err)
+	}
+
+	if budget, err := repo.GetBudget(ctx, "user-1"); err != nil || budget != nil {
+		t.Errorf("GetBudget() before SetBudget = %v, %v, want nil, nil", budget, err)
+	}
+	if err := repo.SetBudget(ctx, Budget{}); err == nil {
+		t.Error("SetBudget() without a user ID should fail")
+	}
+	if err := repo.SetBudget(ctx, Budget{UserID: "user-1", MonthlyBytes: 1024}); err != nil {
+		t.Fatal(err)
+	}
+	budget, err := repo.GetBudget(ctx, "user-1")
+	if err != nil || budget == nil || budget.MonthlyBytes != 1024 || budget.UpdatedAt.IsZero() {
+		t.Errorf("GetBudget() = %+v, %v", budget, err)
+	}
+}
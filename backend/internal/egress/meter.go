@@ -0,0 +1,282 @@
+package egress
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DefaultThrottleInterval is the minimum spacing between deliveries of a user
+// who has exceeded their budget.
+const DefaultThrottleInterval = 10 * time.Second
+
+// DefaultRefreshInterval is how often the budget and usage a Meter keeps for
+// a user are reloaded from the repository, picking up budget changes and
+// usage recorded by other instances.
+const DefaultRefreshInterval = time.Minute
+
+// Summary is a user's usage in the current period together with their budget
+type Summary struct {
+	Period        string `json:"period"`
+	Requests      int64  `json:"requests"`
+	BytesSent     int64  `json:"bytes_sent"`
+	Deliveries    int64  `json:"deliveries"`
+	EventsMatched int64  `json:"events_matched"`
+	BudgetBytes   int64  `json:"budget_bytes"` // 0 means unlimited
+	Throttled     bool   `json:"throttled"`
+}
+
+// Meter records egress per user and throttles users over budget.
+// Deliveries of throttled users are delayed, never dropped.
+//
+// Exceeded is on the delivery path, so the Meter keeps each user's budget
+// and usage in memory: usage it records is added to it directly, and both
+// are reloaded in the background every refresh interval. Only the first
+// check of a user in a period reads the repository.
+//
+// It is safe for concurrent use.
+type Meter struct {
+	repo     Repository
+	interval time.Duration
+	refresh  time.Duration
+	now      func() time.Time
+
+	mu       sync.Mutex
+	next     map[string]time.Time // next delivery slot per throttled user
+	pruned   time.Time            // when lapsed slots were last removed from next
+	accounts map[string]*account  // budget and usage per user
+}
+
+// account is a user's budget and usage as last known to the Meter
+type account struct {
+	period   string
+	bytes    int64 // BytesSent in period
+	budget   int64 // 0 means unlimited
+	loadedAt time.Time
+	loading  bool // a background refresh is running
+}
+
+// exceeded reports whether the account has used up its budget
+func (a *account) exceeded() bool {
+	return a.budget > 0 && a.bytes >= a.budget
+}
+
+// MeterOption configures a Meter
+type MeterOption func(*Meter)
+
+// WithThrottleInterval sets the spacing between deliveries of throttled users
+func WithThrottleInterval(d time.Duration) MeterOption {
+	return func(m *Meter) {
+		m.interval = d
+	}
+}
+
+// WithRefreshInterval sets how often budgets and usage kept in memory are
+// reloaded from the repository
+func WithRefreshInterval(d time.Duration) MeterOption {
+	return func(m *Meter) {
+		m.refresh = d
+	}
+}
+
+// NewMeter creates a new Meter backed by the given repository
+func NewMeter(repo Repository, opts ...MeterOption) *Meter {
+	m := &Meter{
+		repo:     repo,
+		interval: DefaultThrottleInterval,
+		refresh:  DefaultRefreshInterval,
+		now:      time.Now,
+		next:     make(map[string]time.Time),
+		accounts: make(map[string]*account),
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// Record adds to the user's counters for the current period
+func (m *Meter) Record(ctx context.Context, userID string, d Delta) error {
+	if userID == "" || d.isZero() {
+		return nil
+	}
+	period := Period(m.now())
+	if err := m.repo.AddUsage(ctx, userID, period, d); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	if a, ok := m.accounts[userID]; ok && a.period == period {
+		a.bytes += d.Bytes
+	}
+	m.mu.Unlock()
+	return nil
+}
+
+// Summary returns the user's usage in the current period and their budget
+func (m *Meter) Summary(ctx context.Context, userID string) (Summary, error) {
+	period := Period(m.now())
+	summary := Summary{Period: period}
+
+	usage, err := m.repo.GetUsage(ctx, userID, period)
+	if err != nil {
+		return summary, err
+	}
+	if usage != nil {
+		summary.Requests = usage.Requests
+		summary.BytesSent = usage.BytesSent
+		summary.Deliveries = usage.Deliveries
+		summary.EventsMatched = usage.EventsMatched
+	}
+
+	budget, err := m.repo.GetBudget(ctx, userID)
+	if err != nil {
+		return summary, err
+	}
+	if budget != nil {
+		summary.BudgetBytes = budget.MonthlyBytes
+	}
+
+	summary.Throttled = summary.BudgetBytes > 0 && summary.BytesSent >= summary.BudgetBytes
+	return summary, nil
+}
+
+// Exceeded reports whether the user has used up their budget for the current
+// period. It answers from memory; see Meter.
+func (m *Meter) Exceeded(ctx context.Context, userID string) (bool, error) {
+	if userID == "" {
+		return false, nil
+	}
+	now := m.now()
+	period := Period(now)
+
+	m.mu.Lock()
+	a, ok := m.accounts[userID]
+	if ok && a.period == period {
+		if now.Sub(a.loadedAt) >= m.refresh && !a.loading {
+			a.loading = true
+			go m.reload(context.WithoutCancel(ctx), userID, period)
+		}
+		exceeded := a.exceeded()
+		m.mu.Unlock()
+		return exceeded, nil
+	}
+	m.mu.Unlock()
+
+	loaded, err := m.load(ctx, userID, period)
+	if err != nil {
+		return false, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if a, ok := m.accounts[userID]; ok && a.period == period {
+		// Loaded concurrently; keep the account usage was recorded to
+		return a.exceeded(), nil
+	}
+	m.accounts[userID] = loaded
+	return loaded.exceeded(), nil
+}
+
+// load reads the user's budget and usage in period from the repository
+func (m *Meter) load(ctx context.Context, userID, period string) (*account, error) {
+	a := &account{period: period, loadedAt: m.now()}
+	usage, err := m.repo.GetUsage(ctx, userID, period)
+	if err != nil {
+		return nil, err
+	}
+	if usage != nil {
+		a.bytes = usage.BytesSent
+	}
+	budget, err := m.repo.GetBudget(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if budget != nil {
+		a.budget = budget.MonthlyBytes
+	}
+	return a, nil
+}
+
+// reload refreshes a user's account in the background. On failure the
+// account keeps its values and is tried again after the refresh interval.
+func (m *Meter) reload(ctx context.Context, userID, period string) {
+	loaded, err := m.load(ctx, userID, period)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	a, ok := m.accounts[userID]
+	if !ok || a.period != period {
+		return
+	}
+	a.loading = false
+	if err != nil {
+		a.loadedAt = m.now()
+		return
+	}
+	// The repository already includes usage recorded while loading, up to
+	// the moment it was read; anything recorded since is caught next time
+	a.bytes, a.budget, a.loadedAt = loaded.bytes, loaded.budget, loaded.loadedAt
+}
+
+// Wait blocks until the user's next delivery slot. Slots are handed out
+// interval apart, so a burst of deliveries is spread out instead of dropped.
+// Returns the context error if ctx is cancelled while waiting.
+func (m *Meter) Wait(ctx context.Context, userID string) error {
+	m.mu.Lock()
+	now := m.now()
+	if now.Sub(m.pruned) >= m.interval {
+		// Users whose slot has passed are no longer spaced out
+		for id, slot := range m.next {
+			if !slot.After(now) {
+				delete(m.next, id)
+			}
+		}
+		m.pruned = now
+	}
+	slot := m.next[userID]
+	if slot.Before(now) {
+		slot = now
+	}
+	m.next[userID] = slot.Add(m.interval)
+	m.mu.Unlock()
+
+	wait := slot.Sub(now)
+	if wait <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// SetBudget sets the user's monthly egress budget. Zero removes the limit.
+func (m *Meter) SetBudget(ctx context.Context, userID string, monthlyBytes int64, updatedBy string) (Budget, error) {
+	if userID == "" {
+		return Budget{}, fmt.Errorf("user ID is required")
+	}
+	if monthlyBytes < 0 {
+		return Budget{}, fmt.Errorf("monthly bytes must not be negative")
+	}
+
+	budget := Budget{
+		UserID:       userID,
+		MonthlyBytes: monthlyBytes,
+		UpdatedBy:    updatedBy,
+		UpdatedAt:    m.now().UTC(),
+	}
+	if err := m.repo.SetBudget(ctx, budget); err != nil {
+		return Budget{}, err
+	}
+	m.mu.Lock()
+	if a, ok := m.accounts[userID]; ok {
+		a.budget = monthlyBytes
+	}
+	m.mu.Unlock()
+	return budget, nil
+}
@@ -0,0 +1,295 @@
+package egress
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// mockRepository is an in-memory Repository for testing
+type mockRepository struct {
+	mu      sync.Mutex
+	usage   map[string]*Usage
+	budgets map[string]*Budget
+	getErr  error
+	reads   int // GetUsage and GetBudget calls
+}
+
+func (m *mockRepository) readCount() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.reads
+}
+
+func newMockRepository() *mockRepository {
+	return &mockRepository{
+		usage:   make(map[string]*Usage),
+		budgets: make(map[string]*Budget),
+	}
+}
+
+func (m *mockRepository) AddUsage(ctx context.Context, userID, period string, d Delta) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key := usageDocID(userID, period)
+	u, ok := m.usage[key]
+	if !ok {
+		u = &Usage{UserID: userID, Period: period}
+		m.usage[key] = u
+	}
+	u.Requests += d.Requests
+	u.BytesSent += d.Bytes
+	u.Deliveries += d.Deliveries
+	u.EventsMatched += d.EventsMatched
+	return nil
+}
+
+func (m *mockRepository) GetUsage(ctx context.Context, userID, period string) (*Usage, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.reads++
+	if m.getErr != nil {
+		return nil, m.getErr
+	}
+	u, ok := m.usage[usageDocID(userID, period)]
+	if !ok {
+		return nil, nil
+	}
+	result := *u
+	return &result, nil
+}
+
+func (m *mockRepository) GetBudget(ctx context.Context, userID string) (*Budget, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.reads++
+	b, ok := m.budgets[userID]
+	if !ok {
+		return nil, nil
+	}
+	result := *b
+	return &result, nil
+}
+
+func (m *mockRepository) SetBudget(ctx context.Context, budget Budget) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.budgets[budget.UserID] = &budget
+	return nil
+}
+
+func TestMeter_RecordAndSummary(t *testing.T) {
+	repo := newMockRepository()
+	meter := NewMeter(repo)
+	meter.now = func() time.Time { return time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC) }
+	ctx := context.Background()
+
+	if err := meter.Record(ctx, "user-1", Delta{Requests: 1, Bytes: 100}); err != nil {
+		t.Fatalf("Record() error = %v", err)
+	}
+	if err := meter.Record(ctx, "user-1", Delta{Requests: 2, Bytes: 250, Deliveries: 1}); err != nil {
+		t.Fatalf("Record() error = %v", err)
+	}
+	if err := meter.Record(ctx, "user-1", Delta{EventsMatched: 1}); err != nil {
+		t.Fatalf("Record() error = %v", err)
+	}
+	// Anonymous deliveries (legacy subscriptions) are not attributed
+	if err := meter.Record(ctx, "", Delta{Requests: 1, Bytes: 100}); err != nil {
+		t.Fatalf("Record() error = %v", err)
+	}
+
+	summary, err := meter.Summary(ctx, "user-1")
+	if err != nil {
+		t.Fatalf("Summary() error = %v", err)
+	}
+	if summary.Period != "2024-03" {
+		t.Errorf("Period = %q, want %q", summary.Period, "2024-03")
+	}
+	if summary.Requests != 3 {
+		t.Errorf("Requests = %d, want 3", summary.Requests)
+	}
+	if summary.BytesSent != 350 {
+		t.Errorf("BytesSent = %d, want 350", summary.BytesSent)
+	}
+	if summary.Deliveries != 1 || summary.EventsMatched != 1 {
+		t.Errorf("Deliveries = %d, EventsMatched = %d, want 1 and 1", summary.Deliveries, summary.EventsMatched)
+	}
+	if summary.BudgetBytes != 0 || summary.Throttled {
+		t.Errorf("expected no budget and not throttled, got %+v", summary)
+	}
+}
+
+func TestMeter_Exceeded(t *testing.T) {
+	repo := newMockRepository()
+	meter := NewMeter(repo)
+	ctx := context.Background()
+
+	if _, err := meter.SetBudget(ctx, "user-1", 200, "admin-1"); err != nil {
+		t.Fatalf("SetBudget() error = %v", err)
+	}
+
+	exceeded, err := meter.Exceeded(ctx, "user-1")
+	if err != nil {
+		t.Fatalf("Exceeded() error = %v", err)
+	}
+	if exceeded {
+		t.Error("expected not exceeded before any usage")
+	}
+
+	_ = meter.Record(ctx, "user-1", Delta{Requests: 2, Bytes: 200})
+
+	exceeded, err = meter.Exceeded(ctx, "user-1")
+	if err != nil {
+		t.Fatalf("Exceeded() error = %v", err)
+	}
+	if !exceeded {
+		t.Error("expected exceeded once usage reaches budget")
+	}
+
+	exceeded, _ = meter.Exceeded(ctx, "")
+	if exceeded {
+		t.Error("expected empty user ID never to be exceeded")
+	}
+}
+
+func TestMeter_ExceededFromMemory(t *testing.T) {
+	repo := newMockRepository()
+	repo.budgets["user-1"] = &Budget{UserID: "user-1", MonthlyBytes: 200}
+	meter := NewMeter(repo, WithRefreshInterval(time.Minute))
+	now := time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)
+	meter.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	for range 3 {
+		if exceeded, err := meter.Exceeded(ctx, "user-1"); err != nil || exceeded {
+			t.Fatalf("Exceeded() = %v, %v, want false", exceeded, err)
+		}
+	}
+	if got := repo.readCount(); got != 2 {
+		t.Errorf("repository reads = %d, want 2 (usage and budget, once)", got)
+	}
+
+	// Recorded usage counts without reading the repository again
+	_ = meter.Record(ctx, "user-1", Delta{Requests: 1, Bytes: 200})
+	if exceeded, _ := meter.Exceeded(ctx, "user-1"); !exceeded {
+		t.Error("expected exceeded once recorded usage reaches the budget")
+	}
+	if got := repo.readCount(); got != 2 {
+		t.Errorf("repository reads = %d, want 2", got)
+	}
+
+	// A budget raised elsewhere (e.g. by another instance) is picked up by
+	// the background refresh after the interval
+	repo.mu.Lock()
+	repo.budgets["user-1"] = &Budget{UserID: "user-1", MonthlyBytes: 1000}
+	repo.mu.Unlock()
+	now = now.Add(time.Minute)
+	_, _ = meter.Exceeded(ctx, "user-1") // Starts the refresh
+	deadline := time.Now().Add(time.Second)
+	for {
+		if exceeded, _ := meter.Exceeded(ctx, "user-1"); !exceeded {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("expected the raised budget to be picked up")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// A new period starts from the repository's usage
+	now = time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)
+	if exceeded, _ := meter.Exceeded(ctx, "user-1"); exceeded {
+		t.Error("expected a new period not to be exceeded")
+	}
+}
+
+func TestMeter_ExceededError(t *testing.T) {
+	repo := newMockRepository()
+	repo.getErr = errors.New("unavailable")
+	meter := NewMeter(repo)
+
+	if _, err := meter.Exceeded(context.Background(), "user-1"); err == nil {
+		t.Error("expected error from repository")
+	}
+}
+
+func TestMeter_SetBudget(t *testing.T) {
+	repo := newMockRepository()
+	meter := NewMeter(repo)
+	ctx := context.Background()
+
+	budget, err := meter.SetBudget(ctx, "user-1", 1024, "admin-1")
+	if err != nil {
+		t.Fatalf("SetBudget() error = %v", err)
+	}
+	if budget.MonthlyBytes != 1024 || budget.UpdatedBy != "admin-1" {
+		t.Errorf("unexpected budget: %+v", budget)
+	}
+
+	if _, err := meter.SetBudget(ctx, "user-1", -1, "admin-1"); err == nil {
+		t.Error("expected error for negative budget")
+	}
+	if _, err := meter.SetBudget(ctx, "", 1024, "admin-1"); err == nil {
+		t.Error("expected error for empty user ID")
+	}
+}
+
+func TestMeter_Wait(t *testing.T) {
+	meter := NewMeter(newMockRepository(), WithThrottleInterval(50*time.Millisecond))
+	ctx := context.Background()
+
+	start := time.Now()
+	for range 3 {
+		if err := meter.Wait(ctx, "user-1"); err != nil {
+			t.Fatalf("Wait() error = %v", err)
+		}
+	}
+	elapsed := time.Since(start)
+
+	// First slot is immediate, the next two are spaced by the interval
+	if elapsed < 100*time.Millisecond {
+		t.Errorf("expected at least 100ms of throttling, got %v", elapsed)
+	}
+
+	// Other users are not affected
+	start = time.Now()
+	if err := meter.Wait(ctx, "user-2"); err != nil {
+		t.Fatalf("Wait() error = %v", err)
+	}
+	if time.Since(start) > 20*time.Millisecond {
+		t.Error("expected other user to proceed immediately")
+	}
+}
+
+func TestMeter_WaitPrunesLapsedSlots(t *testing.T) {
+	meter := NewMeter(newMockRepository(), WithThrottleInterval(10*time.Second))
+	now := time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)
+	meter.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	for _, user := range []string{"user-1", "user-2", "user-3"} {
+		_ = meter.Wait(ctx, user)
+	}
+	now = now.Add(time.Minute)
+	_ = meter.Wait(ctx, "user-4")
+
+	meter.mu.Lock()
+	defer meter.mu.Unlock()
+	if len(meter.next) != 1 {
+		t.Errorf("next has %d users, want only the one throttled now", len(meter.next))
+	}
+}
+
+func TestMeter_WaitCancelled(t *testing.T) {
+	meter := NewMeter(newMockRepository(), WithThrottleInterval(time.Hour))
+	ctx, cancel := context.WithCancel(context.Background())
+
+	_ = meter.Wait(ctx, "user-1")
+	cancel()
+
+	if err := meter.Wait(ctx, "user-1"); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
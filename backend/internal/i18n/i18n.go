@@ -0,0 +1,96 @@
+// Package i18n localizes the text namazu generates for people: push and SMS
+// notifications, digest summaries and lifecycle emails. Payloads for machines
+// (webhook bodies, SNS/SQS messages) are not localized.
+//
+// Messages are looked up along a fallback chain: the locale as given
+// ("en-US"), its language ("en"), then Default. A key missing from every
+// bundle is returned as is, so a forgotten translation shows up in the text
+// rather than as an empty string.
+package i18n
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/otiai10/namazu/backend/internal/region"
+)
+
+// Supported locales
+const (
+	Japanese = "ja"
+	English  = "en"
+)
+
+// Default is the locale of subscriptions without one, and the last fallback
+const Default = Japanese
+
+// Locales lists the supported locales, Default first
+var Locales = []string{Japanese, English}
+
+var jst = time.FixedZone("JST", 9*60*60)
+
+// chain returns the bundles to look messages up in, most specific first
+func chain(locale string) []string {
+	locale = strings.ToLower(strings.TrimSpace(locale))
+	var locales []string
+	if _, ok := bundles[locale]; ok {
+		locales = append(locales, locale)
+	}
+	if lang, _, found := strings.Cut(strings.ReplaceAll(locale, "_", "-"), "-"); found {
+		if _, ok := bundles[lang]; ok {
+			locales = append(locales, lang)
+		}
+	}
+	return append(locales, Default)
+}
+
+// Supported reports whether locale, or its language, has a bundle.
+// The empty locale is supported and means Default.
+func Supported(locale string) bool {
+	return locale == "" || len(chain(locale)) > 1
+}
+
+// Resolve returns the bundle locale used for locale, e.g. "en" for "en-US"
+// and Default for unsupported locales
+func Resolve(locale string) string {
+	return chain(locale)[0]
+}
+
+// T returns the message for key in locale, formatted with args like fmt.Sprintf
+func T(locale, key string, args ...any) string {
+	for _, l := range chain(locale) {
+		if msg, ok := bundles[l][key]; ok {
+			if len(args) == 0 {
+				return msg
+			}
+			return fmt.Sprintf(msg, args...)
+		}
+	}
+	return key
+}
+
+// Scale returns the name of a JMA scale (10-70), e.g. "震度5弱" or "Shindo 5 Lower"
+func Scale(locale string, scale int) string {
+	return T(locale, fmt.Sprintf("scale.%d", scale))
+}
+
+// FormatTime formats t in JST for a notification, e.g. "1/2 16:10" or "Jan 2 16:10 JST"
+func FormatTime(locale string, t time.Time) string {
+	return t.In(jst).Format(T(locale, "layout.short"))
+}
+
+// Areas joins the names of affected areas. Prefectures are written in romaji
+// outside Japanese; other names (regions of a hypocenter) are kept as is.
+func Areas(locale string, areas []string) string {
+	names := make([]string, len(areas))
+	for i, area := range areas {
+		names[i] = area
+		if Resolve(locale) != Japanese {
+			if p, ok := region.Lookup(area); ok {
+				names[i] = p.Romaji
+			}
+		}
+	}
+	return strings.Join(names, T(locale, "list.separator"))
+}
@@ -0,0 +1,86 @@
+package i18n
+
+import (
+	"testing"
+	"time"
+)
+
+func TestT_Fallback(t *testing.T) {
+	tests := []struct {
+		locale string
+		want   string
+	}{
+		{"ja", "緊急地震速報"},
+		{"en", "Earthquake Early Warning"},
+		{"en-US", "Earthquake Early Warning"},
+		{"EN_gb", "Earthquake Early Warning"},
+		{"fr", "緊急地震速報"},
+		{"", "緊急地震速報"},
+	}
+	for _, tt := range tests {
+		if got := T(tt.locale, "event.eew"); got != tt.want {
+			t.Errorf("T(%q) = %q, want %q", tt.locale, got, tt.want)
+		}
+	}
+
+	if got := T("en", "no.such.key"); got != "no.such.key" {
+		t.Errorf("T(missing key) = %q, want the key", got)
+	}
+	if got := T("en", "digest.summary", 3, Scale("en", 45)); got != "3 earthquake reports (up to Shindo 5 Lower)" {
+		t.Errorf("T(with args) = %q", got)
+	}
+}
+
+func TestSupported(t *testing.T) {
+	for _, locale := range []string{"", "ja", "en", "en-US", "ja-JP", "JA"} {
+		if !Supported(locale) {
+			t.Errorf("Supported(%q) = false, want true", locale)
+		}
+	}
+	for _, locale := range []string{"fr", "zh-CN", "english"} {
+		if Supported(locale) {
+			t.Errorf("Supported(%q) = true, want false", locale)
+		}
+	}
+	if Resolve("en-US") != English || Resolve("fr") != Default {
+		t.Errorf("Resolve() = %q/%q, want en/%s", Resolve("en-US"), Resolve("fr"), Default)
+	}
+}
+
+// Every key of the default bundle must be translated, so that English text
+// does not fall back to Japanese halfway through a sentence
+func TestBundles_Complete(t *testing.T) {
+	for _, locale := range Locales {
+		for key := range bundles[Default] {
+			if _, ok := bundles[locale][key]; !ok {
+				t.Errorf("%s: missing %s", locale, key)
+			}
+		}
+		for key := range bundles[locale] {
+			if _, ok := bundles[Default][key]; !ok {
+				t.Errorf("%s: %s is not in the default bundle", locale, key)
+			}
+		}
+	}
+}
+
+func TestFormatting(t *testing.T) {
+	at := time.Date(2024, 1, 1, 7, 10, 0, 0, time.UTC)
+	if got := FormatTime("ja", at); got != "1/1 16:10" {
+		t.Errorf("FormatTime(ja) = %q", got)
+	}
+	if got := FormatTime("en", at); got != "Jan 1 16:10 JST" {
+		t.Errorf("FormatTime(en) = %q", got)
+	}
+
+	areas := []string{"石川県", "富山県", "能登半島沖"}
+	if got := Areas("ja", areas); got != "石川県、富山県、能登半島沖" {
+		t.Errorf("Areas(ja) = %q", got)
+	}
+	if got := Areas("en", areas); got != "Ishikawa, Toyama, 能登半島沖" {
+		t.Errorf("Areas(en) = %q", got)
+	}
+	if got := Scale("en", 45); got != "Shindo 5 Lower" {
+		t.Errorf("Scale(en, 45) = %q", got)
+	}
+}
@@ -0,0 +1,68 @@
+package i18n
+
+// bundles holds the messages of each locale by key. Messages with verbs are
+// formatted by T; layout.* are time layouts for FormatTime.
+var bundles = map[string]map[string]string{
+	Japanese: {
+		"event.earthquake": "地震情報",
+		"event.eew":        "緊急地震速報",
+		"event.tsunami":    "津波情報",
+		"list.separator":   "、",
+		"layout.short":     "1/2 15:04",
+
+		"scale.10": "震度1",
+		"scale.20": "震度2",
+		"scale.30": "震度3",
+		"scale.40": "震度4",
+		"scale.45": "震度5弱",
+		"scale.50": "震度5強",
+		"scale.55": "震度6弱",
+		"scale.60": "震度6強",
+		"scale.70": "震度7",
+
+		"digest.summary":       "%d件の地震情報（最大%s）",
+		"digest.summary_small": "%d件の地震情報",
+
+		"lifecycle.expiring.subject":  "[%s] Subscription「%s」の有効期限が近づいています",
+		"lifecycle.expiring.reason":   "有効期限（%s）を過ぎると配信が停止されます。継続する場合は有効期限を延長してください。",
+		"lifecycle.warned.subject":    "[%s] Subscription「%s」は%d日後に停止されます",
+		"lifecycle.warned.reason":     "長期間、配信の成功もオーナーのログインもありません。継続する場合はダッシュボードにログインするか、エンドポイントが応答することを確認してください。",
+		"lifecycle.failing.subject":   "[%s] Subscription「%s」への配信が失敗し続けているため停止しました",
+		"lifecycle.failing.reason":    "一定期間、すべての配信が失敗したため配信を停止しました。エンドポイントが応答することを確認してから、ダッシュボードで再有効化してください。",
+		"lifecycle.expired.subject":   "[%s] Subscription「%s」の有効期限が切れました",
+		"lifecycle.expired.reason":    "有効期限を過ぎたため配信を停止しました。再開するには有効期限を更新して再有効化してください。",
+		"lifecycle.suspended.subject": "[%s] Subscription「%s」を停止しました",
+		"lifecycle.suspended.reason":  "長期間利用がなかったため配信を停止しました。再開するにはダッシュボードから再有効化してください。",
+	},
+	English: {
+		"event.earthquake": "Earthquake",
+		"event.eew":        "Earthquake Early Warning",
+		"event.tsunami":    "Tsunami information",
+		"list.separator":   ", ",
+		"layout.short":     "Jan 2 15:04 MST",
+
+		"scale.10": "Shindo 1",
+		"scale.20": "Shindo 2",
+		"scale.30": "Shindo 3",
+		"scale.40": "Shindo 4",
+		"scale.45": "Shindo 5 Lower",
+		"scale.50": "Shindo 5 Upper",
+		"scale.55": "Shindo 6 Lower",
+		"scale.60": "Shindo 6 Upper",
+		"scale.70": "Shindo 7",
+
+		"digest.summary":       "%d earthquake reports (up to %s)",
+		"digest.summary_small": "%d earthquake reports",
+
+		"lifecycle.expiring.subject":  "[%s] Subscription \"%s\" expires soon",
+		"lifecycle.expiring.reason":   "Deliveries stop when it expires (%s). Extend the expiry to keep receiving them.",
+		"lifecycle.warned.subject":    "[%s] Subscription \"%s\" will be suspended in %d days",
+		"lifecycle.warned.reason":     "There have been no successful deliveries and no sign-ins by the owner for a long time. To keep it, sign in to the dashboard or make sure the endpoint responds.",
+		"lifecycle.failing.subject":   "[%s] Subscription \"%s\" was suspended because deliveries keep failing",
+		"lifecycle.failing.reason":    "Deliveries were suspended because all of them failed for a while. Make sure the endpoint responds, then reactivate it in the dashboard.",
+		"lifecycle.expired.subject":   "[%s] Subscription \"%s\" has expired",
+		"lifecycle.expired.reason":    "Deliveries were suspended because it expired. To resume them, update the expiry and reactivate it.",
+		"lifecycle.suspended.subject": "[%s] Subscription \"%s\" was suspended",
+		"lifecycle.suspended.reason":  "Deliveries were suspended because it was not used for a long time. To resume them, reactivate it in the dashboard.",
+	},
+}
@@ -0,0 +1,102 @@
+package idempotency
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// collection holds one document per scoped key; expiresAt is its TTL field
+const collection = "idempotency_keys"
+
+// FirestoreRepository implements Repository using Firestore, so keys are
+// shared by every instance behind the load balancer
+type FirestoreRepository struct {
+	client *firestore.Client
+}
+
+// Compile-time interface check
+var _ Repository = (*FirestoreRepository)(nil)
+
+// NewFirestoreRepository creates a new FirestoreRepository
+func NewFirestoreRepository(client *firestore.Client) *FirestoreRepository {
+	return &FirestoreRepository{client: client}
+}
+
+// docID returns the document ID for a key. Keys are chosen by clients and
+// may contain "/", so they are hashed.
+func docID(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// Reserve creates the record unless the key is taken. Create fails if the
+// document exists, so concurrent requests with the same key reserve it once.
+func (r *FirestoreRepository) Reserve(ctx context.Context, record Record) (*Record, bool, error) {
+	if r.client == nil {
+		return nil, false, fmt.Errorf("firestore client is nil")
+	}
+
+	ref := r.client.Collection(collection).Doc(docID(record.Key))
+	_, err := ref.Create(ctx, record)
+	if err == nil {
+		return nil, true, nil
+	}
+	if status.Code(err) != codes.AlreadyExists {
+		return nil, false, fmt.Errorf("failed to reserve idempotency key: %w", err)
+	}
+
+	doc, err := ref.Get(ctx)
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			// Released in the meantime
+			return r.Reserve(ctx, record)
+		}
+		return nil, false, fmt.Errorf("failed to get idempotency key: %w", err)
+	}
+	var existing Record
+	if err := doc.DataTo(&existing); err != nil {
+		return nil, false, fmt.Errorf("failed to decode idempotency key: %w", err)
+	}
+	if !existing.Expired(time.Now()) {
+		return &existing, false, nil
+	}
+
+	// The TTL policy deletes expired documents eventually; until then they are
+	// deleted here, unless another request replaced it first
+	_, err = ref.Delete(ctx, firestore.LastUpdateTime(doc.UpdateTime))
+	if err != nil && status.Code(err) != codes.FailedPrecondition {
+		return nil, false, fmt.Errorf("failed to delete expired idempotency key: %w", err)
+	}
+	return r.Reserve(ctx, record)
+}
+
+// Complete stores the response of a reserved key
+func (r *FirestoreRepository) Complete(ctx context.Context, record Record) error {
+	if r.client == nil {
+		return fmt.Errorf("firestore client is nil")
+	}
+
+	if _, err := r.client.Collection(collection).Doc(docID(record.Key)).Set(ctx, record); err != nil {
+		return fmt.Errorf("failed to complete idempotency key: %w", err)
+	}
+	return nil
+}
+
+// Release deletes a reserved key
+func (r *FirestoreRepository) Release(ctx context.Context, key string) error {
+	if r.client == nil {
+		return fmt.Errorf("firestore client is nil")
+	}
+
+	if _, err := r.client.Collection(collection).Doc(docID(key)).Delete(ctx); err != nil {
+		return fmt.Errorf("failed to release idempotency key: %w", err)
+	}
+	return nil
+}
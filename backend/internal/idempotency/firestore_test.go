@@ -0,0 +1,35 @@
+package idempotency
+
+import (
+	"context"
+	"testing"
+)
+
+func TestFirestoreRepository_ImplementsRepository(t *testing.T) {
+	var _ Repository = (*FirestoreRepository)(nil)
+}
+
+func TestDocID(t *testing.T) {
+	id := docID(Key("user-1", "POST", "/api/subscriptions", "a/b"))
+	if len(id) != 64 {
+		t.Errorf("docID() = %q, want 64 hex characters", id)
+	}
+	if id == docID(Key("user-2", "POST", "/api/subscriptions", "a/b")) {
+		t.Error("docID() should differ for different keys")
+	}
+}
+
+func TestFirestoreRepository_NilClient(t *testing.T) {
+	repo := NewFirestoreRepository(nil)
+	ctx := context.Background()
+
+	if _, _, err := repo.Reserve(ctx, Record{Key: "k1"}); err == nil {
+		t.Error("Reserve() expected error for nil client")
+	}
+	if err := repo.Complete(ctx, Record{Key: "k1"}); err == nil {
+		t.Error("Complete() expected error for nil client")
+	}
+	if err := repo.Release(ctx, "k1"); err == nil {
+		t.Error("Release() expected error for nil client")
+	}
+}
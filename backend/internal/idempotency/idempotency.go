@@ -0,0 +1,67 @@
+// Package idempotency stores the responses of requests made with an
+// Idempotency-Key header, so that a retried request returns the original
+// response instead of repeating its side effects (e.g. creating a duplicate subscription).
+package idempotency
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+)
+
+// DefaultTTL is how long a key and its response are kept
+const DefaultTTL = 24 * time.Hour
+
+// InProgressLease is how long a reservation holds the key before the request
+// completes. A request runs for seconds at most (the server's write timeout),
+// so a reservation older than this was left by a crashed instance and is
+// taken over by the next request with the key.
+const InProgressLease = 2 * time.Minute
+
+// Record is a request made with an idempotency key, and its response once completed
+type Record struct {
+	Key         string    `firestore:"key"`         // Scoped key, see Key
+	RequestHash string    `firestore:"requestHash"` // See RequestHash; a reused key with another request is rejected
+	Completed   bool      `firestore:"completed"`   // false while the first request is in flight
+	StatusCode  int       `firestore:"statusCode"`
+	ContentType string    `firestore:"contentType"`
+	Body        []byte    `firestore:"body"`
+	CreatedAt   time.Time `firestore:"createdAt"`
+	ExpiresAt   time.Time `firestore:"expiresAt"` // Firestore TTL policy field
+}
+
+// Expired reports whether the key can be reused at the given time: after
+// ExpiresAt, or once the lease of a reservation that never completed ends
+func (r Record) Expired(now time.Time) bool {
+	if !r.Completed && !now.Before(r.CreatedAt.Add(InProgressLease)) {
+		return true
+	}
+	return !now.Before(r.ExpiresAt)
+}
+
+// Repository persists idempotency records
+type Repository interface {
+	// Reserve stores record as in flight, unless an unexpired record with the
+	// same key exists: then that record is returned with reserved false.
+	// A reservation past its InProgressLease is taken over.
+	Reserve(ctx context.Context, record Record) (existing *Record, reserved bool, err error)
+
+	// Complete stores the response of a reserved key
+	Complete(ctx context.Context, record Record) error
+
+	// Release deletes a reserved key, so the request can be retried
+	Release(ctx context.Context, key string) error
+}
+
+// Key scopes a client's key to the caller and the endpoint, so that keys
+// chosen by different users (or reused across endpoints) never collide
+func Key(userID, method, path, key string) string {
+	return userID + " " + method + " " + path + " " + key
+}
+
+// RequestHash fingerprints a request body
+func RequestHash(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,57 @@
+package idempotency
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryRepository implements Repository in process memory.
+// Used without Firestore; keys are not shared between instances and do not survive a restart.
+type MemoryRepository struct {
+	mu      sync.Mutex
+	records map[string]Record
+}
+
+// Compile-time interface check
+var _ Repository = (*MemoryRepository)(nil)
+
+// NewMemoryRepository creates an empty MemoryRepository
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{records: map[string]Record{}}
+}
+
+// Reserve stores record as in flight unless the key is taken
+func (r *MemoryRepository) Reserve(ctx context.Context, record Record) (*Record, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	if existing, ok := r.records[record.Key]; ok && !existing.Expired(now) {
+		return &existing, false, nil
+	}
+	// Expired records are dropped lazily, as keys are reserved
+	for key, existing := range r.records {
+		if existing.Expired(now) {
+			delete(r.records, key)
+		}
+	}
+	r.records[record.Key] = record
+	return nil, true, nil
+}
+
+// Complete stores the response of a reserved key
+func (r *MemoryRepository) Complete(ctx context.Context, record Record) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.records[record.Key] = record
+	return nil
+}
+
+// Release deletes a reserved key
+func (r *MemoryRepository) Release(ctx context.Context, key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.records, key)
+	return nil
+}
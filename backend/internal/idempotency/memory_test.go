@@ -0,0 +1,106 @@
+package idempotency
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryRepository(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryRepository()
+	now := time.Now()
+	record := Record{Key: Key("user-1", "POST", "/api/subscriptions", "k1"), RequestHash: RequestHash([]byte(`{}`)), CreatedAt: now, ExpiresAt: now.Add(time.Hour)}
+
+	if _, reserved, err := repo.Reserve(ctx, record); err != nil || !reserved {
+		t.Fatalf("Reserve() = %v, %v, want reserved", reserved, err)
+	}
+	existing, reserved, err := repo.Reserve(ctx, record)
+	if err != nil || reserved || existing == nil || existing.Completed {
+		t.Fatalf("Reserve() = %+v, %v, %v, want the in-flight record", existing, reserved, err)
+	}
+
+	record.Completed = true
+	record.StatusCode = 201
+	record.Body = []byte(`{"id":"sub-1"}`)
+	if err := repo.Complete(ctx, record); err != nil {
+		t.Fatal(err)
+	}
+	existing, _, _ = repo.Reserve(ctx, record)
+	if !existing.Completed || existing.StatusCode != 201 || string(existing.Body) != `{"id":"sub-1"}` {
+		t.Errorf("Reserve() = %+v, want the completed record", existing)
+	}
+
+	// Other users' keys do not collide
+	other := Record{Key: Key("user-2", "POST", "/api/subscriptions", "k1"), CreatedAt: now, ExpiresAt: now.Add(time.Hour)}
+	if _, reserved, _ := repo.Reserve(ctx, other); !reserved {
+		t.Error("Reserve() of another user's key = not reserved")
+	}
+
+	if err := repo.Release(ctx, record.Key); err != nil {
+		t.Fatal(err)
+	}
+	if _, reserved, _ := repo.Reserve(ctx, record); !reserved {
+		t.Error("Reserve() after Release = not reserved")
+	}
+}
+
+func TestMemoryRepository_Expired(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryRepository()
+	record := Record{Key: "k1", ExpiresAt: time.Now().Add(-time.Second)}
+
+	if _, reserved, _ := repo.Reserve(ctx, record); !reserved {
+		t.Fatal("Reserve() = not reserved")
+	}
+	if _, reserved, _ := repo.Reserve(ctx, record); !reserved {
+		t.Error("Reserve() of an expired key = not reserved")
+	}
+}
+
+func TestMemoryRepository_InProgressLease(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryRepository()
+	now := time.Now()
+	stale := Record{Key: "k1", CreatedAt: now.Add(-InProgressLease), ExpiresAt: now.Add(time.Hour)}
+
+	if _, reserved, _ := repo.Reserve(ctx, stale); !reserved {
+		t.Fatal("Reserve() = not reserved")
+	}
+	retry := Record{Key: "k1", CreatedAt: now, ExpiresAt: now.Add(time.Hour)}
+	if existing, reserved, _ := repo.Reserve(ctx, retry); !reserved {
+		t.Fatalf("Reserve() of a reservation past its lease = %+v, want it taken over", existing)
+	}
+	if existing, reserved, _ := repo.Reserve(ctx, retry); reserved || existing == nil || !existing.CreatedAt.Equal(now) {
+		t.Errorf("Reserve() within the lease = %+v, %v, want the new reservation", existing, reserved)
+	}
+
+	// Completed records are kept until ExpiresAt
+	retry.Completed = true
+	retry.CreatedAt = now.Add(-time.Hour)
+	_ = repo.Complete(ctx, retry)
+	if _, reserved, _ := repo.Reserve(ctx, retry); reserved {
+		t.Error("Reserve() of a completed key = reserved")
+	}
+}
+
+func TestRecord_Expired(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name   string
+		record Record
+		want   bool
+	}{
+		{"in progress", Record{CreatedAt: now.Add(-time.Minute), ExpiresAt: now.Add(time.Hour)}, false},
+		{"lease ended", Record{CreatedAt: now.Add(-InProgressLease), ExpiresAt: now.Add(time.Hour)}, true},
+		{"completed", Record{Completed: true, CreatedAt: now.Add(-time.Hour), ExpiresAt: now.Add(time.Hour)}, false},
+		{"completed and expired", Record{Completed: true, CreatedAt: now.Add(-DefaultTTL), ExpiresAt: now}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.record.Expired(now); got != tt.want {
+				t.Errorf("Expired() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,437 @@
+// Package lifecycle keeps the fan-out list free of dead endpoints.
+//
+// A Sweeper periodically walks all subscriptions and:
+//   - suspends subscriptions whose expires_at has passed
+//   - warns owners a week before expires_at
+//   - warns owners of subscriptions without a successful delivery or owner
+//     login for Policy.InactiveMonths, and suspends them after GracePeriod
+//   - suspends subscriptions whose deliveries all failed for Policy.FailingPeriod
+//   - lifts inactivity warnings once activity resumes
+//
+// Suspended subscriptions stay suspended until the owner reactivates them.
+package lifecycle
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/otiai10/namazu/backend/internal/config"
+	"github.com/otiai10/namazu/backend/internal/i18n"
+	"github.com/otiai10/namazu/backend/internal/mail"
+	"github.com/otiai10/namazu/backend/internal/store"
+	"github.com/otiai10/namazu/backend/internal/subscription"
+	"github.com/otiai10/namazu/backend/internal/tenant"
+	"github.com/otiai10/namazu/backend/internal/user"
+)
+
+const (
+	// DefaultGracePeriod is the time between an inactivity warning and suspension
+	DefaultGracePeriod = 14 * 24 * time.Hour
+
+	// DefaultFailingPeriod is how long every delivery must fail before suspension
+	DefaultFailingPeriod = 7 * 24 * time.Hour
+
+	// DefaultInterval is how often Run sweeps
+	DefaultInterval = 24 * time.Hour
+
+	// expiryNotice is how long before expires_at the owner is warned
+	expiryNotice = 7 * 24 * time.Hour
+)
+
+// Actions taken (or planned) for a subscription
+const (
+	ActionWarn    = "warn"
+	ActionSuspend = "suspend"
+	ActionRestore = "restore" // Lift a warning
+)
+
+// Policy configures the inactivity and failure rules. Expiry is always enforced.
+type Policy struct {
+	InactiveMonths int           // 0 disables the inactivity policy
+	GracePeriod    time.Duration // Between warning and suspension
+	FailingPeriod  time.Duration // Of failed deliveries before suspension; 0 disables
+}
+
+// PolicyFromConfig builds a policy from configuration
+// (nil disables inactivity and uses the default failing period)
+func PolicyFromConfig(cfg *config.LifecycleConfig) Policy {
+	p := Policy{GracePeriod: DefaultGracePeriod, FailingPeriod: DefaultFailingPeriod}
+	if cfg == nil {
+		return p
+	}
+	p.InactiveMonths = cfg.InactiveMonths
+	if cfg.GraceDays > 0 {
+		p.GracePeriod = time.Duration(cfg.GraceDays) * 24 * time.Hour
+	}
+	if cfg.FailingDays > 0 {
+		p.FailingPeriod = time.Duration(cfg.FailingDays) * 24 * time.Hour
+	} else if cfg.FailingDays < 0 {
+		p.FailingPeriod = 0
+	}
+	return p
+}
+
+// DeliveryHistory reports past deliveries of a subscription
+type DeliveryHistory interface {
+	LastSuccess(ctx context.Context, subscriptionID string) (*store.DeliveryRecord, error)
+	ListBySubscription(ctx context.Context, subscriptionID string, from, to time.Time) ([]store.DeliveryRecord, error)
+}
+
+// UserLookup finds subscription owners by UID
+type UserLookup interface {
+	GetByUID(ctx context.Context, uid string) (*user.User, error)
+}
+
+// Entry describes a subscription that is warned, suspended or about to change
+type Entry struct {
+	SubscriptionID string     `json:"subscription_id"`
+	Name           string     `json:"name"`
+	UserID         string     `json:"user_id,omitempty"`
+	URL            string     `json:"url"`
+	Status         string     `json:"status"`
+	StatusReason   string     `json:"status_reason,omitempty"`
+	LastActivityAt *time.Time `json:"last_activity_at,omitempty"`
+	ExpiresAt      *time.Time `json:"expires_at,omitempty"`
+	Action         string     `json:"action,omitempty"` // What the (next) sweep does
+	ActionReason   string     `json:"action_reason,omitempty"`
+}
+
+// Report lists subscriptions that are not plainly active
+type Report struct {
+	GeneratedAt    time.Time `json:"generated_at"`
+	InactiveMonths int       `json:"inactive_months"`
+	GraceDays      int       `json:"grace_days"`
+	FailingDays    int       `json:"failing_days"`
+	Subscriptions  []Entry   `json:"subscriptions"`
+}
+
+// Option configures a Sweeper
+type Option func(*Sweeper)
+
+// WithDeliveryHistory considers successful deliveries as activity
+func WithDeliveryHistory(h DeliveryHistory) Option {
+	return func(s *Sweeper) { s.deliveries = h }
+}
+
+// WithUsers considers owner logins as activity and enables emails to owners
+func WithUsers(u UserLookup) Option {
+	return func(s *Sweeper) { s.users = u }
+}
+
+// WithMailer sends notification emails; without it changes are only logged
+func WithMailer(m mail.Sender) Option {
+	return func(s *Sweeper) { s.mailer = m }
+}
+
+// WithTenants uses each tenant's name and From address in emails
+func WithTenants(r *tenant.Registry) Option {
+	return func(s *Sweeper) { s.tenants = r }
+}
+
+// Sweeper applies the lifecycle policy to all subscriptions
+type Sweeper struct {
+	subs       subscription.Repository
+	policy     Policy
+	deliveries DeliveryHistory  // optional, can be nil
+	users      UserLookup       // optional, can be nil
+	mailer     mail.Sender      // optional, can be nil
+	tenants    *tenant.Registry // optional, can be nil
+	now        func() time.Time
+}
+
+// NewSweeper creates a sweeper over the subscription repository
+func NewSweeper(subs subscription.Repository, policy Policy, opts ...Option) *Sweeper {
+	if policy.GracePeriod <= 0 {
+		policy.GracePeriod = DefaultGracePeriod
+	}
+	s := &Sweeper{subs: subs, policy: policy, now: time.Now}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Run sweeps once at startup and then every interval until ctx is cancelled
+func (s *Sweeper) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.Sweep(ctx); err != nil {
+			log.Printf("Lifecycle sweep failed: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Report evaluates the policy without changing anything
+func (s *Sweeper) Report(ctx context.Context) (*Report, error) {
+	return s.run(ctx, false)
+}
+
+// Sweep applies the policy: updates subscription states and notifies owners.
+// The report lists the actions taken.
+func (s *Sweeper) Sweep(ctx context.Context) (*Report, error) {
+	return s.run(ctx, true)
+}
+
+func (s *Sweeper) run(ctx context.Context, apply bool) (*Report, error) {
+	subs, err := s.subs.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list subscriptions: %w", err)
+	}
+
+	now := s.now()
+	report := &Report{
+		GeneratedAt:    now,
+		InactiveMonths: s.policy.InactiveMonths,
+		GraceDays:      int(s.policy.GracePeriod / (24 * time.Hour)),
+		FailingDays:    int(s.policy.FailingPeriod / (24 * time.Hour)),
+		Subscriptions:  []Entry{},
+	}
+	owners := make(map[string]*user.User)
+
+	for _, sub := range subs {
+		if sub.ID == "" {
+			continue // Static subscriptions cannot be updated
+		}
+
+		owner := s.owner(ctx, owners, sub.UserID)
+		lastActivity := s.lastActivity(ctx, sub, owner)
+		failing := s.failing(ctx, sub, now)
+		action, reason := s.evaluate(sub, lastActivity, failing, now)
+
+		if action == "" && (sub.Status == "" || sub.Status == subscription.StatusActive) {
+			continue
+		}
+
+		entry := Entry{
+			SubscriptionID: sub.ID,
+			Name:           sub.Name,
+			UserID:         sub.UserID,
+			URL:            sub.Delivery.URL,
+			Status:         sub.Status,
+			StatusReason:   sub.StatusReason,
+			ExpiresAt:      sub.ExpiresAt,
+			Action:         action,
+			ActionReason:   reason,
+		}
+		if entry.Status == "" {
+			entry.Status = subscription.StatusActive
+		}
+		if !lastActivity.IsZero() {
+			entry.LastActivityAt = &lastActivity
+		}
+
+		if apply && action != "" {
+			if err := s.apply(ctx, sub, owner, action, reason, now); err != nil {
+				log.Printf("Lifecycle: failed to %s subscription %s: %v", action, sub.ID, err)
+				continue
+			}
+		}
+		report.Subscriptions = append(report.Subscriptions, entry)
+	}
+
+	return report, nil
+}
+
+// evaluate returns the action the policy requires for a subscription, if any
+func (s *Sweeper) evaluate(sub subscription.Subscription, lastActivity time.Time, failing bool, now time.Time) (action, reason string) {
+	if sub.Status == subscription.StatusSuspended {
+		return "", "" // Until the owner reactivates it
+	}
+	if sub.IsExpired(now) {
+		return ActionSuspend, subscription.ReasonExpired
+	}
+	if failing {
+		return ActionSuspend, subscription.ReasonFailing
+	}
+
+	warnedFor := ""
+	if sub.Status == subscription.StatusWarned {
+		warnedFor = sub.StatusReason
+	}
+
+	if s.policy.InactiveMonths > 0 && lastActivity.Before(now.AddDate(0, -s.policy.InactiveMonths, 0)) {
+		if warnedFor != subscription.ReasonInactive {
+			return ActionWarn, subscription.ReasonInactive
+		}
+		if sub.StatusChangedAt != nil && !now.Before(sub.StatusChangedAt.Add(s.policy.GracePeriod)) {
+			return ActionSuspend, subscription.ReasonInactive
+		}
+		return "", ""
+	}
+
+	if sub.ExpiresAt != nil && !now.Before(sub.ExpiresAt.Add(-expiryNotice)) {
+		if warnedFor != subscription.ReasonExpiring {
+			return ActionWarn, subscription.ReasonExpiring
+		}
+		return "", ""
+	}
+
+	if warnedFor != "" {
+		return ActionRestore, ""
+	}
+	return "", ""
+}
+
+// lastActivity returns the latest of creation, reactivation, successful
+// delivery and owner login. Zero means no activity is known.
+func (s *Sweeper) lastActivity(ctx context.Context, sub subscription.Subscription, owner *user.User) time.Time {
+	last := sub.CreatedAt
+	later := func(t time.Time) {
+		if t.After(last) {
+			last = t
+		}
+	}
+
+	// Warnings also set StatusChangedAt; only reactivation counts
+	if sub.StatusChangedAt != nil && (sub.Status == "" || sub.Status == subscription.StatusActive) {
+		later(*sub.StatusChangedAt)
+	}
+	if owner != nil {
+		later(owner.LastLoginAt)
+	}
+	if s.deliveries != nil {
+		record, err := s.deliveries.LastSuccess(ctx, sub.ID)
+		if err != nil {
+			log.Printf("Lifecycle: failed to get last delivery of %s: %v", sub.ID, err)
+		} else if record != nil {
+			later(record.DeliveredAt)
+		}
+	}
+	return last
+}
+
+// failing reports whether every delivery to the subscription failed during the
+// failing period. The subscription must have been active for the whole period
+// and have at least one delivery in it, so new or reactivated subscriptions and
+// ones without matching events are never considered failing. Paused
+// subscriptions get no deliveries and are not considered failing either.
+func (s *Sweeper) failing(ctx context.Context, sub subscription.Subscription, now time.Time) bool {
+	if s.deliveries == nil || s.policy.FailingPeriod <= 0 || sub.Status == subscription.StatusSuspended || sub.IsPaused() {
+		return false
+	}
+	since := now.Add(-s.policy.FailingPeriod)
+	if sub.CreatedAt.After(since) {
+		return false
+	}
+	if sub.StatusChangedAt != nil && (sub.Status == "" || sub.Status == subscription.StatusActive) && sub.StatusChangedAt.After(since) {
+		return false // Reactivated during the period
+	}
+
+	// Cheap check first: a recent success rules it out
+	last, err := s.deliveries.LastSuccess(ctx, sub.ID)
+	if err != nil {
+		log.Printf("Lifecycle: failed to get last delivery of %s: %v", sub.ID, err)
+		return false
+	}
+	if last != nil && !last.DeliveredAt.Before(since) {
+		return false
+	}
+
+	records, err := s.deliveries.ListBySubscription(ctx, sub.ID, since, now)
+	if err != nil {
+		log.Printf("Lifecycle: failed to list deliveries of %s: %v", sub.ID, err)
+		return false
+	}
+	for _, r := range records {
+		if r.Success {
+			return false
+		}
+	}
+	return len(records) > 0
+}
+
+// owner returns the subscription owner, caching lookups for one sweep
+func (s *Sweeper) owner(ctx context.Context, cache map[string]*user.User, uid string) *user.User {
+	if s.users == nil || uid == "" {
+		return nil
+	}
+	if u, ok := cache[uid]; ok {
+		return u
+	}
+	u, err := s.users.GetByUID(ctx, uid)
+	if err != nil {
+		log.Printf("Lifecycle: failed to get owner %s: %v", uid, err)
+		return nil
+	}
+	cache[uid] = u
+	return u
+}
+
+// apply stores the new state and notifies the owner
+func (s *Sweeper) apply(ctx context.Context, sub subscription.Subscription, owner *user.User, action, reason string, now time.Time) error {
+	updated := sub
+	switch action {
+	case ActionWarn:
+		updated.Status = subscription.StatusWarned
+	case ActionSuspend:
+		updated.Status = subscription.StatusSuspended
+	case ActionRestore:
+		updated.Status = subscription.StatusActive
+	}
+	updated.StatusReason = reason
+	updated.StatusChangedAt = &now
+
+	if err := s.subs.Update(ctx, sub.ID, updated); err != nil {
+		return err
+	}
+	log.Printf("Lifecycle: subscription %s (%s) %s: %s", sub.ID, sub.Name, updated.Status, reason)
+
+	if action != ActionRestore {
+		s.notify(ctx, updated, owner)
+	}
+	return nil
+}
+
+// notify emails the owner about a warning or suspension. Failures are logged.
+func (s *Sweeper) notify(ctx context.Context, sub subscription.Subscription, owner *user.User) {
+	if s.mailer == nil || owner == nil || owner.Email == "" {
+		return
+	}
+
+	t := tenant.Default
+	if s.tenants != nil {
+		t = s.tenants.Get(sub.TenantID)
+	}
+
+	msg := composeMessage(sub, t.Name, s.policy.GracePeriod)
+	msg.From = t.EmailFrom
+	msg.To = owner.Email
+	if err := s.mailer.Send(ctx, msg); err != nil {
+		log.Printf("Lifecycle: failed to notify owner of %s: %v", sub.ID, err)
+	}
+}
+
+// composeMessage builds the subject and body of a notification in the
+// subscription's locale
+func composeMessage(sub subscription.Subscription, brand string, grace time.Duration) mail.Message {
+	var subject, reason string
+	switch {
+	case sub.Status == subscription.StatusWarned && sub.StatusReason == subscription.ReasonExpiring:
+		subject = i18n.T(sub.Locale, "lifecycle.expiring.subject", brand, sub.Name)
+		reason = i18n.T(sub.Locale, "lifecycle.expiring.reason", sub.ExpiresAt.Format("2006-01-02 15:04 MST"))
+	case sub.Status == subscription.StatusWarned:
+		subject = i18n.T(sub.Locale, "lifecycle.warned.subject", brand, sub.Name, int(grace.Hours()/24))
+		reason = i18n.T(sub.Locale, "lifecycle.warned.reason")
+	case sub.StatusReason == subscription.ReasonFailing:
+		subject = i18n.T(sub.Locale, "lifecycle.failing.subject", brand, sub.Name)
+		reason = i18n.T(sub.Locale, "lifecycle.failing.reason")
+	case sub.StatusReason == subscription.ReasonExpired:
+		subject = i18n.T(sub.Locale, "lifecycle.expired.subject", brand, sub.Name)
+		reason = i18n.T(sub.Locale, "lifecycle.expired.reason")
+	default:
+		subject = i18n.T(sub.Locale, "lifecycle.suspended.subject", brand, sub.Name)
+		reason = i18n.T(sub.Locale, "lifecycle.suspended.reason")
+	}
+
+	body := fmt.Sprintf("%s\n\nSubscription: %s (%s)\nWebhook URL: %s\n", reason, sub.Name, sub.ID, sub.Delivery.URL)
+	return mail.Message{Subject: subject, Body: body}
+}
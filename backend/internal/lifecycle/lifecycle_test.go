@@ -0,0 +1,468 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/otiai10/namazu/backend/internal/config"
+	"github.com/otiai10/namazu/backend/internal/mail"
+	"github.com/otiai10/namazu/backend/internal/store"
+	"github.com/otiai10/namazu/backend/internal/subscription"
+	"github.com/otiai10/namazu/backend/internal/tenant"
+	"github.com/otiai10/namazu/backend/internal/user"
+)
+
+// mockRepository is an in-memory subscription.Repository
+type mockRepository struct {
+	mu   sync.Mutex
+	subs map[string]subscription.Subscription
+	ids  []string
+}
+
+func newMockRepository(subs ...subscription.Subscription) *mockRepository {
+	r := &mockRepository{subs: make(map[string]subscription.Subscription)}
+	for _, sub := range subs {
+		r.subs[sub.ID] = sub
+		r.ids = append(r.ids, sub.ID)
+	}
+	return r
+}
+
+func (r *mockRepository) List(ctx context.Context) ([]subscription.Subscription, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	result := make([]subscription.Subscription, 0, len(r.ids))
+	for _, id := range r.ids {
+		result = append(result, r.subs[id])
+	}
+	return result, nil
+}
+
+func (r *mockRepository) ListByUserID(ctx context.Context, userID string) ([]subscription.Subscription, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (r *mockRepository) Create(ctx context.Context, sub subscription.Subscription) (string, error) {
+	return "", errors.New("not implemented")
+}
+
+func (r *mockRepository) CreateWithinLimit(ctx context.Context, sub subscription.Subscription, limit int) (string, error) {
+	return "", errors.New("not implemented")
+}
+
+func (r *mockRepository) Get(ctx context.Context, id string) (*subscription.Subscription, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	sub, ok := r.subs[id]
+	if !ok {
+		return nil, nil
+	}
+	return &sub, nil
+}
+
+func (r *mockRepository) Update(ctx context.Context, id string, sub subscription.Subscription) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.subs[id] = sub
+	return nil
+}
+
+func (r *mockRepository) Delete(ctx context.Context, id string) error {
+	return errors.New("not implemented")
+}
+
+// mockHistory returns fixed last successful deliveries
+type mockHistory map[string]time.Time
+
+func (h mockHistory) LastSuccess(ctx context.Context, subscriptionID string) (*store.DeliveryRecord, error) {
+	at, ok := h[subscriptionID]
+	if !ok {
+		return nil, nil
+	}
+	return &store.DeliveryRecord{SubscriptionID: subscriptionID, Success: true, DeliveredAt: at}, nil
+}
+
+func (h mockHistory) ListBySubscription(ctx context.Context, subscriptionID string, from, to time.Time) ([]store.DeliveryRecord, error) {
+	return nil, nil
+}
+
+// mockRecords serves a fixed delivery log per subscription
+type mockRecords map[string][]store.DeliveryRecord
+
+func (h mockRecords) LastSuccess(ctx context.Context, subscriptionID string) (*store.DeliveryRecord, error) {
+	var last *store.DeliveryRecord
+	for i, r := range h[subscriptionID] {
+		if r.Success && (last == nil || r.DeliveredAt.After(last.DeliveredAt)) {
+			last = &h[subscriptionID][i]
+		}
+	}
+	return last, nil
+}
+
+func (h mockRecords) ListBySubscription(ctx context.Context, subscriptionID string, from, to time.Time) ([]store.DeliveryRecord, error) {
+	var result []store.DeliveryRecord
+	for _, r := range h[subscriptionID] {
+		if !r.DeliveredAt.Before(from) && r.DeliveredAt.Before(to) {
+			result = append(result, r)
+		}
+	}
+	return result, nil
+}
+
+// mockUsers returns fixed users by UID
+type mockUsers map[string]*user.User
+
+func (u mockUsers) GetByUID(ctx context.Context, uid string) (*user.User, error) {
+	return u[uid], nil
+}
+
+// mockMailer records sent messages
+type mockMailer struct {
+	sent []mail.Message
+}
+
+func (m *mockMailer) Send(ctx context.Context, msg mail.Message) error {
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+var now = time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+
+func newTestSweeper(repo *mockRepository, history mockHistory, users mockUsers, mailer *mockMailer, opts ...Option) *Sweeper {
+	opts = append([]Option{WithDeliveryHistory(history), WithUsers(users), WithMailer(mailer)}, opts...)
+	s := NewSweeper(repo, Policy{InactiveMonths: 6, GracePeriod: 14 * 24 * time.Hour}, opts...)
+	s.now = func() time.Time { return now }
+	return s
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
+
+func TestSweeper_Inactivity(t *testing.T) {
+	longAgo := now.AddDate(-1, 0, 0)
+	recent := now.AddDate(0, -1, 0)
+
+	repo := newMockRepository(
+		subscription.Subscription{ID: "delivering", UserID: "u1", Name: "Delivering", CreatedAt: longAgo},
+		subscription.Subscription{ID: "logged-in", UserID: "u2", Name: "Owner active", CreatedAt: longAgo},
+		subscription.Subscription{ID: "new", UserID: "u1", Name: "New", CreatedAt: recent},
+		subscription.Subscription{ID: "dead", UserID: "u1", Name: "Dead", CreatedAt: longAgo, Delivery: subscription.DeliveryConfig{URL: "https://dead.example.com"}},
+	)
+	history := mockHistory{"delivering": recent, "dead": longAgo}
+	users := mockUsers{
+		"u1": {UID: "u1", Email: "u1@example.com", LastLoginAt: longAgo},
+		"u2": {UID: "u2", Email: "u2@example.com", LastLoginAt: recent},
+	}
+	mailer := &mockMailer{}
+
+	report, err := newTestSweeper(repo, history, users, mailer).Sweep(context.Background())
+	if err != nil {
+		t.Fatalf("Sweep() error = %v", err)
+	}
+
+	if len(report.Subscriptions) != 1 || report.Subscriptions[0].SubscriptionID != "dead" {
+		t.Fatalf("report = %+v, want only the dead subscription", report.Subscriptions)
+	}
+	entry := report.Subscriptions[0]
+	if entry.Action != ActionWarn || entry.ActionReason != subscription.ReasonInactive {
+		t.Errorf("action = %s (%s), want warn (inactive)", entry.Action, entry.ActionReason)
+	}
+	if entry.LastActivityAt == nil || !entry.LastActivityAt.Equal(longAgo) {
+		t.Errorf("LastActivityAt = %v, want %v", entry.LastActivityAt, longAgo)
+	}
+
+	dead, _ := repo.Get(context.Background(), "dead")
+	if dead.Status != subscription.StatusWarned || dead.StatusReason != subscription.ReasonInactive {
+		t.Errorf("status = %s (%s), want warned (inactive)", dead.Status, dead.StatusReason)
+	}
+	if dead.StatusChangedAt == nil || !dead.StatusChangedAt.Equal(now) {
+		t.Errorf("StatusChangedAt = %v, want %v", dead.StatusChangedAt, now)
+	}
+	for _, id := range []string{"delivering", "logged-in", "new"} {
+		if sub, _ := repo.Get(context.Background(), id); sub.Status != "" {
+			t.Errorf("%s status = %q, want unchanged", id, sub.Status)
+		}
+	}
+
+	if len(mailer.sent) != 1 {
+		t.Fatalf("sent %d emails, want 1", len(mailer.sent))
+	}
+	if mailer.sent[0].To != "u1@example.com" || !strings.Contains(mailer.sent[0].Subject, "14日後に停止") {
+		t.Errorf("email = %+v", mailer.sent[0])
+	}
+	if !strings.Contains(mailer.sent[0].Body, "https://dead.example.com") {
+		t.Errorf("email body does not mention the URL: %q", mailer.sent[0].Body)
+	}
+}
+
+func TestSweeper_SuspendAfterGracePeriod(t *testing.T) {
+	longAgo := now.AddDate(-1, 0, 0)
+	users := mockUsers{"u1": {UID: "u1", Email: "u1@example.com"}}
+
+	tests := []struct {
+		name     string
+		warnedAt time.Time
+		want     string
+	}{
+		{"within grace period", now.Add(-13 * 24 * time.Hour), subscription.StatusWarned},
+		{"after grace period", now.Add(-14 * 24 * time.Hour), subscription.StatusSuspended},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newMockRepository(subscription.Subscription{
+				ID: "dead", UserID: "u1", Name: "Dead", CreatedAt: longAgo,
+				Status: subscription.StatusWarned, StatusReason: subscription.ReasonInactive, StatusChangedAt: timePtr(tt.warnedAt),
+			})
+			mailer := &mockMailer{}
+
+			if _, err := newTestSweeper(repo, mockHistory{}, users, mailer).Sweep(context.Background()); err != nil {
+				t.Fatalf("Sweep() error = %v", err)
+			}
+
+			sub, _ := repo.Get(context.Background(), "dead")
+			if sub.Status != tt.want {
+				t.Errorf("status = %s, want %s", sub.Status, tt.want)
+			}
+			wantMails := 0
+			if tt.want == subscription.StatusSuspended {
+				wantMails = 1
+			}
+			if len(mailer.sent) != wantMails {
+				t.Errorf("sent %d emails, want %d", len(mailer.sent), wantMails)
+			}
+		})
+	}
+}
+
+func TestSweeper_RestoreWhenActive(t *testing.T) {
+	repo := newMockRepository(subscription.Subscription{
+		ID: "revived", UserID: "u1", Name: "Revived", CreatedAt: now.AddDate(-1, 0, 0),
+		Status: subscription.StatusWarned, StatusReason: subscription.ReasonInactive, StatusChangedAt: timePtr(now.AddDate(0, 0, -3)),
+	})
+	history := mockHistory{"revived": now.AddDate(0, 0, -1)}
+	mailer := &mockMailer{}
+
+	if _, err := newTestSweeper(repo, history, mockUsers{}, mailer).Sweep(context.Background()); err != nil {
+		t.Fatalf("Sweep() error = %v", err)
+	}
+
+	sub, _ := repo.Get(context.Background(), "revived")
+	if sub.Status != subscription.StatusActive || sub.StatusReason != "" {
+		t.Errorf("status = %s (%s), want active", sub.Status, sub.StatusReason)
+	}
+	if len(mailer.sent) != 0 {
+		t.Errorf("sent %d emails, want none for a restore", len(mailer.sent))
+	}
+}
+
+func TestSweeper_Expiry(t *testing.T) {
+	recent := now.AddDate(0, 0, -1)
+	repo := newMockRepository(
+		subscription.Subscription{ID: "expired", UserID: "u1", Name: "Expired", CreatedAt: recent, ExpiresAt: timePtr(now.Add(-time.Hour))},
+		subscription.Subscription{ID: "expiring", UserID: "u1", Name: "Expiring", CreatedAt: recent, ExpiresAt: timePtr(now.Add(3 * 24 * time.Hour))},
+		subscription.Subscription{ID: "later", UserID: "u1", Name: "Later", CreatedAt: recent, ExpiresAt: timePtr(now.AddDate(0, 1, 0))},
+	)
+	users := mockUsers{"u1": {UID: "u1", Email: "u1@example.com"}}
+	mailer := &mockMailer{}
+	tenants := tenant.NewRegistry([]config.TenantConfig{{ID: "acme", Name: "Acme Alerts", EmailFrom: "alerts@acme.example"}})
+
+	if _, err := newTestSweeper(repo, mockHistory{}, users, mailer, WithTenants(tenants)).Sweep(context.Background()); err != nil {
+		t.Fatalf("Sweep() error = %v", err)
+	}
+
+	want := map[string]string{
+		"expired":  subscription.StatusSuspended,
+		"expiring": subscription.StatusWarned,
+		"later":    "",
+	}
+	for id, status := range want {
+		if sub, _ := repo.Get(context.Background(), id); sub.Status != status {
+			t.Errorf("%s status = %q, want %q", id, sub.Status, status)
+		}
+	}
+	if len(mailer.sent) != 2 {
+		t.Fatalf("sent %d emails, want 2", len(mailer.sent))
+	}
+	if !strings.HasPrefix(mailer.sent[0].Subject, "[namazu]") {
+		t.Errorf("subject = %q, want the default brand", mailer.sent[0].Subject)
+	}
+
+	// A second sweep does not warn again
+	mailer.sent = nil
+	if _, err := newTestSweeper(repo, mockHistory{}, users, mailer).Sweep(context.Background()); err != nil {
+		t.Fatalf("Sweep() error = %v", err)
+	}
+	if len(mailer.sent) != 0 {
+		t.Errorf("second sweep sent %d emails, want 0", len(mailer.sent))
+	}
+}
+
+func TestSweeper_TenantBranding(t *testing.T) {
+	repo := newMockRepository(subscription.Subscription{
+		ID: "expired", UserID: "u1", TenantID: "acme", Name: "Expired", ExpiresAt: timePtr(now.Add(-time.Hour)),
+	})
+	users := mockUsers{"u1": {UID: "u1", Email: "u1@example.com"}}
+	mailer := &mockMailer{}
+	tenants := tenant.NewRegistry([]config.TenantConfig{{ID: "acme", Name: "Acme Alerts", EmailFrom: "alerts@acme.example"}})
+
+	if _, err := newTestSweeper(repo, mockHistory{}, users, mailer, WithTenants(tenants)).Sweep(context.Background()); err != nil {
+		t.Fatalf("Sweep() error = %v", err)
+	}
+	if len(mailer.sent) != 1 {
+		t.Fatalf("sent %d emails, want 1", len(mailer.sent))
+	}
+	if mailer.sent[0].From != "alerts@acme.example" || !strings.HasPrefix(mailer.sent[0].Subject, "[Acme Alerts]") {
+		t.Errorf("email = %+v, want tenant branding", mailer.sent[0])
+	}
+}
+
+func TestComposeMessage_Locale(t *testing.T) {
+	sub := subscription.Subscription{ID: "s1", Name: "Alerts", Status: subscription.StatusSuspended, StatusReason: subscription.ReasonFailing}
+	if msg := composeMessage(sub, "namazu", 0); !strings.Contains(msg.Subject, "失敗し続けている") {
+		t.Errorf("subject = %q, want Japanese by default", msg.Subject)
+	}
+
+	sub.Locale = "en"
+	msg := composeMessage(sub, "namazu", 0)
+	if msg.Subject != `[namazu] Subscription "Alerts" was suspended because deliveries keep failing` {
+		t.Errorf("subject = %q", msg.Subject)
+	}
+	if !strings.HasPrefix(msg.Body, "Deliveries were suspended") {
+		t.Errorf("body = %q, want English", msg.Body)
+	}
+
+	sub = subscription.Subscription{Name: "Alerts", Locale: "en-GB", Status: subscription.StatusWarned, StatusReason: subscription.ReasonInactive}
+	if msg := composeMessage(sub, "namazu", 14*24*time.Hour); !strings.HasSuffix(msg.Subject, "will be suspended in 14 days") {
+		t.Errorf("subject = %q", msg.Subject)
+	}
+}
+
+func TestSweeper_Report(t *testing.T) {
+	longAgo := now.AddDate(-1, 0, 0)
+	repo := newMockRepository(
+		subscription.Subscription{ID: "dead", Name: "Dead", CreatedAt: longAgo},
+		subscription.Subscription{ID: "suspended", Name: "Suspended", CreatedAt: longAgo, Status: subscription.StatusSuspended, StatusReason: subscription.ReasonExpired},
+		subscription.Subscription{Name: "Static"},
+	)
+	mailer := &mockMailer{}
+
+	report, err := newTestSweeper(repo, mockHistory{}, mockUsers{}, mailer).Report(context.Background())
+	if err != nil {
+		t.Fatalf("Report() error = %v", err)
+	}
+	if report.InactiveMonths != 6 || report.GraceDays != 14 {
+		t.Errorf("policy = %d months / %d days", report.InactiveMonths, report.GraceDays)
+	}
+	if len(report.Subscriptions) != 2 {
+		t.Fatalf("report has %d entries, want 2", len(report.Subscriptions))
+	}
+	if report.Subscriptions[0].Action != ActionWarn {
+		t.Errorf("dead action = %q, want warn", report.Subscriptions[0].Action)
+	}
+	if report.Subscriptions[1].Status != subscription.StatusSuspended || report.Subscriptions[1].Action != "" {
+		t.Errorf("suspended entry = %+v", report.Subscriptions[1])
+	}
+
+	// Report does not change anything
+	if sub, _ := repo.Get(context.Background(), "dead"); sub.Status != "" {
+		t.Errorf("status = %q after Report, want unchanged", sub.Status)
+	}
+	if len(mailer.sent) != 0 {
+		t.Errorf("Report sent %d emails", len(mailer.sent))
+	}
+}
+
+func TestSweeper_InactivityDisabled(t *testing.T) {
+	repo := newMockRepository(subscription.Subscription{ID: "dead", Name: "Dead"})
+	s := NewSweeper(repo, PolicyFromConfig(nil))
+	s.now = func() time.Time { return now }
+
+	report, err := s.Sweep(context.Background())
+	if err != nil {
+		t.Fatalf("Sweep() error = %v", err)
+	}
+	if len(report.Subscriptions) != 0 {
+		t.Errorf("report = %+v, want empty with inactivity disabled", report.Subscriptions)
+	}
+}
+
+func TestSweeper_SuspendFailing(t *testing.T) {
+	longAgo := now.AddDate(-1, 0, 0)
+	failed := func(days int) store.DeliveryRecord {
+		return store.DeliveryRecord{DeliveredAt: now.AddDate(0, 0, -days), StatusCode: 500}
+	}
+	succeeded := func(days int) store.DeliveryRecord {
+		return store.DeliveryRecord{DeliveredAt: now.AddDate(0, 0, -days), StatusCode: 200, Success: true}
+	}
+
+	repo := newMockRepository(
+		subscription.Subscription{ID: "failing", UserID: "u1", Name: "Failing", CreatedAt: longAgo},
+		subscription.Subscription{ID: "recovered", UserID: "u1", Name: "Recovered", CreatedAt: longAgo},
+		subscription.Subscription{ID: "quiet", UserID: "u1", Name: "Quiet", CreatedAt: longAgo},
+		subscription.Subscription{ID: "new", UserID: "u1", Name: "New", CreatedAt: now.AddDate(0, 0, -3)},
+		subscription.Subscription{ID: "reactivated", UserID: "u1", Name: "Reactivated", CreatedAt: longAgo,
+			Status: subscription.StatusActive, StatusChangedAt: timePtr(now.AddDate(0, 0, -2))},
+		subscription.Subscription{ID: "paused", UserID: "u1", Name: "Paused", CreatedAt: longAgo, PausedAt: timePtr(now.AddDate(0, 0, -6))},
+	)
+	history := mockRecords{
+		"failing":     {succeeded(10), failed(6), failed(1)},
+		"recovered":   {failed(6), succeeded(2), failed(1)},
+		"quiet":       {succeeded(30)},
+		"new":         {failed(2), failed(1)},
+		"reactivated": {failed(5), failed(1)},
+		"paused":      {failed(6)},
+	}
+	users := mockUsers{"u1": {UID: "u1", Email: "u1@example.com", LastLoginAt: now}}
+	mailer := &mockMailer{}
+
+	s := NewSweeper(repo, Policy{FailingPeriod: 7 * 24 * time.Hour},
+		WithDeliveryHistory(history), WithUsers(users), WithMailer(mailer))
+	s.now = func() time.Time { return now }
+	report, err := s.Sweep(context.Background())
+	if err != nil {
+		t.Fatalf("Sweep() error = %v", err)
+	}
+
+	if len(report.Subscriptions) != 1 || report.Subscriptions[0].SubscriptionID != "failing" {
+		t.Fatalf("report = %+v, want only the failing subscription", report.Subscriptions)
+	}
+	if entry := report.Subscriptions[0]; entry.Action != ActionSuspend || entry.ActionReason != subscription.ReasonFailing {
+		t.Errorf("action = %s (%s), want suspend (failing)", entry.Action, entry.ActionReason)
+	}
+	if sub, _ := repo.Get(context.Background(), "failing"); sub.Status != subscription.StatusSuspended || sub.StatusReason != subscription.ReasonFailing {
+		t.Errorf("status = %s (%s), want suspended (failing)", sub.Status, sub.StatusReason)
+	}
+	for _, id := range []string{"recovered", "quiet", "new", "reactivated", "paused"} {
+		if sub, _ := repo.Get(context.Background(), id); sub.Status == subscription.StatusSuspended {
+			t.Errorf("%s was suspended", id)
+		}
+	}
+	if len(mailer.sent) != 1 || !strings.Contains(mailer.sent[0].Subject, "失敗") {
+		t.Errorf("emails = %+v, want one failure notice", mailer.sent)
+	}
+}
+
+func TestPolicyFromConfig(t *testing.T) {
+	p := PolicyFromConfig(&config.LifecycleConfig{InactiveMonths: 3})
+	if p.InactiveMonths != 3 || p.GracePeriod != DefaultGracePeriod {
+		t.Errorf("policy = %+v, want 3 months with the default grace period", p)
+	}
+	p = PolicyFromConfig(&config.LifecycleConfig{InactiveMonths: 3, GraceDays: 30})
+	if p.GracePeriod != 30*24*time.Hour {
+		t.Errorf("GracePeriod = %v, want 30 days", p.GracePeriod)
+	}
+	if p = PolicyFromConfig(nil); p.FailingPeriod != DefaultFailingPeriod {
+		t.Errorf("FailingPeriod = %v, want the default", p.FailingPeriod)
+	}
+	if p = PolicyFromConfig(&config.LifecycleConfig{FailingDays: 3}); p.FailingPeriod != 3*24*time.Hour {
+		t.Errorf("FailingPeriod = %v, want 3 days", p.FailingPeriod)
+	}
+	if p = PolicyFromConfig(&config.LifecycleConfig{FailingDays: -1}); p.FailingPeriod != 0 {
+		t.Errorf("FailingPeriod = %v, want disabled", p.FailingPeriod)
+	}
+}
@@ -0,0 +1,67 @@
+// Package logging holds the process-wide log level. Messages are written with
+// the standard log package; chatty call sites check Enabled first, so the
+// level can be raised to keep only warnings and errors, or changed at runtime
+// by a config reload.
+package logging
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+)
+
+// Level is a log level. Higher levels log less.
+type Level int32
+
+// Log levels
+const (
+	Debug Level = iota // Everything, including details of config reloads
+	Info               // Per-request and per-delivery logs (default)
+	Warn               // Failures and warnings only
+)
+
+var current atomic.Int32
+
+func init() {
+	current.Store(int32(Info))
+}
+
+// ParseLevel parses "debug", "info" or "warn". An empty string is Info.
+func ParseLevel(s string) (Level, error) {
+	switch strings.ToLower(s) {
+	case "debug":
+		return Debug, nil
+	case "", "info":
+		return Info, nil
+	case "warn":
+		return Warn, nil
+	}
+	return Info, fmt.Errorf("unknown log level %q (want debug, info or warn)", s)
+}
+
+// String returns the name of the level as accepted by ParseLevel
+func (l Level) String() string {
+	switch l {
+	case Debug:
+		return "debug"
+	case Warn:
+		return "warn"
+	default:
+		return "info"
+	}
+}
+
+// SetLevel sets the level of the process
+func SetLevel(l Level) {
+	current.Store(int32(l))
+}
+
+// CurrentLevel returns the level of the process
+func CurrentLevel() Level {
+	return Level(current.Load())
+}
+
+// Enabled reports whether messages at level l are logged
+func Enabled(l Level) bool {
+	return l >= CurrentLevel()
+}
@@ -0,0 +1,39 @@
+package logging
+
+import "testing"
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Level
+		wantErr bool
+	}{
+		{"", Info, false},
+		{"info", Info, false},
+		{"DEBUG", Debug, false},
+		{"warn", Warn, false},
+		{"error", Info, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseLevel(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseLevel(%q) = %v, %v; want %v, error %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+		if err == nil && tt.in != "" && got.String() != tt.want.String() {
+			t.Errorf("String() = %q", got.String())
+		}
+	}
+}
+
+func TestEnabled(t *testing.T) {
+	defer SetLevel(CurrentLevel())
+
+	SetLevel(Warn)
+	if Enabled(Info) || !Enabled(Warn) {
+		t.Errorf("at warn: Enabled(Info) = %v, Enabled(Warn) = %v", Enabled(Info), Enabled(Warn))
+	}
+	SetLevel(Debug)
+	if !Enabled(Debug) || !Enabled(Info) {
+		t.Errorf("at debug: Enabled(Debug) = %v, Enabled(Info) = %v", Enabled(Debug), Enabled(Info))
+	}
+}
@@ -0,0 +1,123 @@
+// Package mail sends notification emails to subscription owners over SMTP.
+package mail
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/otiai10/namazu/backend/internal/config"
+)
+
+// ErrInvalidMessage is returned for messages with a missing or malformed address or subject
+var ErrInvalidMessage = errors.New("invalid mail message")
+
+// Message is a plain-text email
+type Message struct {
+	From    string // Empty uses the sender's default
+	To      string
+	Subject string
+	Body    string
+}
+
+// Sender delivers emails
+type Sender interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// sendFunc matches smtp.SendMail
+type sendFunc func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+
+// SMTPSender sends emails through an SMTP server.
+// STARTTLS is used when the server supports it.
+type SMTPSender struct {
+	addr string
+	from string
+	auth smtp.Auth
+	send sendFunc
+}
+
+// Compile-time interface check
+var _ Sender = (*SMTPSender)(nil)
+
+// NewSMTPSender creates a sender from the mail configuration
+func NewSMTPSender(cfg config.MailConfig) *SMTPSender {
+	s := &SMTPSender{
+		addr: cfg.SMTPAddr,
+		from: cfg.From,
+		send: smtp.SendMail,
+	}
+	if cfg.Username != "" {
+		host, _, err := net.SplitHostPort(cfg.SMTPAddr)
+		if err != nil {
+			host = cfg.SMTPAddr
+		}
+		s.auth = smtp.PlainAuth("", cfg.Username, cfg.Password, host)
+	}
+	return s
+}
+
+// Send delivers a message. The context is only checked before sending.
+func (s *SMTPSender) Send(ctx context.Context, msg Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if msg.From == "" {
+		msg.From = s.from
+	}
+
+	data, err := Format(msg, time.Now())
+	if err != nil {
+		return err
+	}
+	from, _ := mail.ParseAddress(msg.From)
+	to, _ := mail.ParseAddress(msg.To)
+
+	if err := s.send(s.addr, s.auth, from.Address, []string{to.Address}, data); err != nil {
+		return fmt.Errorf("failed to send mail: %w", err)
+	}
+	return nil
+}
+
+// Format renders a message as RFC 5322 with a UTF-8 (base64) body.
+// Header values containing line breaks are rejected.
+func Format(msg Message, date time.Time) ([]byte, error) {
+	if msg.Subject == "" || strings.ContainsAny(msg.Subject, "\r\n") {
+		return nil, fmt.Errorf("%w: subject", ErrInvalidMessage)
+	}
+	from, err := mail.ParseAddress(msg.From)
+	if err != nil || strings.ContainsAny(msg.From, "\r\n") {
+		return nil, fmt.Errorf("%w: from %q", ErrInvalidMessage, msg.From)
+	}
+	to, err := mail.ParseAddress(msg.To)
+	if err != nil || strings.ContainsAny(msg.To, "\r\n") {
+		return nil, fmt.Errorf("%w: to %q", ErrInvalidMessage, msg.To)
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", from.String())
+	fmt.Fprintf(&b, "To: %s\r\n", to.String())
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.BEncoding.Encode("UTF-8", msg.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: base64\r\n")
+	b.WriteString("\r\n")
+
+	encoded := base64.StdEncoding.EncodeToString([]byte(msg.Body))
+	for len(encoded) > 76 {
+		b.WriteString(encoded[:76] + "\r\n")
+		encoded = encoded[76:]
+	}
+	b.WriteString(encoded + "\r\n")
+
+	return b.Bytes(), nil
+}
@@ -0,0 +1,127 @@
+package mail
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"net/smtp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/otiai10/namazu/backend/internal/config"
+)
+
+func TestFormat(t *testing.T) {
+	date := time.Date(2025, 6, 1, 9, 0, 0, 0, time.UTC)
+	data, err := Format(Message{
+		From:    "namazu <noreply@example.com>",
+		To:      "owner@example.com",
+		Subject: "Subscription の停止予告",
+		Body:    "こんにちは\n",
+	}, date)
+	if err != nil {
+		t.Fatalf("Format() error = %v", err)
+	}
+
+	header, body, ok := strings.Cut(string(data), "\r\n\r\n")
+	if !ok {
+		t.Fatal("no header/body separator")
+	}
+	for _, want := range []string{
+		`From: "namazu" <noreply@example.com>`,
+		"To: <owner@example.com>",
+		"Subject: =?UTF-8?b?",
+		"Date: Sun, 01 Jun 2025 09:00:00 +0000",
+		"Content-Type: text/plain; charset=UTF-8",
+		"Content-Transfer-Encoding: base64",
+	} {
+		if !strings.Contains(header, want) {
+			t.Errorf("header missing %q:\n%s", want, header)
+		}
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(body, "\r\n", ""))
+	if err != nil {
+		t.Fatalf("body is not base64: %v", err)
+	}
+	if string(decoded) != "こんにちは\n" {
+		t.Errorf("body = %q", decoded)
+	}
+}
+
+func TestFormat_Invalid(t *testing.T) {
+	valid := Message{From: "noreply@example.com", To: "owner@example.com", Subject: "Hello"}
+	tests := []struct {
+		name   string
+		modify func(m *Message)
+	}{
+		{"missing subject", func(m *Message) { m.Subject = "" }},
+		{"subject injection", func(m *Message) { m.Subject = "Hello\r\nBcc: victim@example.com" }},
+		{"missing from", func(m *Message) { m.From = "" }},
+		{"invalid to", func(m *Message) { m.To = "not an address" }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := valid
+			tt.modify(&msg)
+			if _, err := Format(msg, time.Now()); !errors.Is(err, ErrInvalidMessage) {
+				t.Errorf("Format() error = %v, want ErrInvalidMessage", err)
+			}
+		})
+	}
+}
+
+func TestSMTPSender_Send(t *testing.T) {
+	s := NewSMTPSender(config.MailConfig{
+		SMTPAddr: "smtp.example.com:587",
+		Username: "mailer",
+		Password: "secret",
+		From:     "namazu <noreply@example.com>",
+	})
+
+	var gotAddr, gotFrom string
+	var gotTo []string
+	var gotAuth smtp.Auth
+	s.send = func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+		gotAddr, gotAuth, gotFrom, gotTo = addr, a, from, to
+		return nil
+	}
+
+	err := s.Send(context.Background(), Message{To: "Owner <owner@example.com>", Subject: "Hello", Body: "Hi"})
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if gotAddr != "smtp.example.com:587" {
+		t.Errorf("addr = %q", gotAddr)
+	}
+	if gotAuth == nil {
+		t.Error("auth = nil, want PLAIN auth")
+	}
+	if gotFrom != "noreply@example.com" {
+		t.Errorf("envelope from = %q, want the default address", gotFrom)
+	}
+	if len(gotTo) != 1 || gotTo[0] != "owner@example.com" {
+		t.Errorf("envelope to = %v", gotTo)
+	}
+}
+
+func TestSMTPSender_Send_Errors(t *testing.T) {
+	s := NewSMTPSender(config.MailConfig{SMTPAddr: "localhost:25", From: "noreply@example.com"})
+	if s.auth != nil {
+		t.Error("auth should be nil without a username")
+	}
+	s.send = func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+		return errors.New("connection refused")
+	}
+
+	if err := s.Send(context.Background(), Message{To: "owner@example.com", Subject: "Hello"}); err == nil {
+		t.Error("Send() error = nil, want SMTP error")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := s.Send(ctx, Message{To: "owner@example.com", Subject: "Hello"}); !errors.Is(err, context.Canceled) {
+		t.Errorf("Send() error = %v, want context.Canceled", err)
+	}
+}
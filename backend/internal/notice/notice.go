@@ -0,0 +1,104 @@
+// Package notice defines operational notices broadcast to subscribers,
+// such as announcements of relay maintenance.
+package notice
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Type is the "type" of every notice payload. Earthquake payloads (P2P地震情報
+// JSON) never carry it, so receivers can tell notices apart.
+const Type = "namazu.service_notice"
+
+// Severities of a notice
+const (
+	SeverityInfo     = "info"
+	SeverityWarning  = "warning"
+	SeverityCritical = "critical"
+)
+
+// Length limits of a notice
+const (
+	MaxTitleLength   = 200
+	MaxMessageLength = 4000
+)
+
+// idPrefix distinguishes notice IDs from event IDs
+const idPrefix = "notice-"
+
+// ErrQueueFull is returned when too many notices are waiting to be delivered
+var ErrQueueFull = errors.New("too many notices pending")
+
+// Validation errors
+var (
+	ErrTitleRequired   = errors.New("title is required")
+	ErrMessageRequired = errors.New("message is required")
+	ErrTooLong         = errors.New("title or message is too long")
+	ErrInvalidSeverity = errors.New("severity must be info, warning or critical")
+)
+
+// Notice is a non-earthquake message sent to subscriptions that opted into
+// service notices. It is delivered as the webhook payload.
+type Notice struct {
+	Type     string    `json:"type"` // Always Type
+	ID       string    `json:"id"`
+	Severity string    `json:"severity"`
+	Title    string    `json:"title"`
+	Message  string    `json:"message"`
+	IssuedAt time.Time `json:"issued_at"`
+}
+
+// New creates a notice with a new ID. An empty severity means SeverityInfo.
+func New(title, message, severity string) (Notice, error) {
+	if severity == "" {
+		severity = SeverityInfo
+	}
+	n := Notice{
+		Type:     Type,
+		ID:       newID(),
+		Severity: severity,
+		Title:    strings.TrimSpace(title),
+		Message:  strings.TrimSpace(message),
+		IssuedAt: time.Now().UTC(),
+	}
+	if err := n.Validate(); err != nil {
+		return Notice{}, err
+	}
+	return n, nil
+}
+
+// Validate checks that the notice can be sent
+func (n Notice) Validate() error {
+	if n.Title == "" {
+		return ErrTitleRequired
+	}
+	if n.Message == "" {
+		return ErrMessageRequired
+	}
+	if utf8.RuneCountInString(n.Title) > MaxTitleLength || utf8.RuneCountInString(n.Message) > MaxMessageLength {
+		return ErrTooLong
+	}
+	switch n.Severity {
+	case SeverityInfo, SeverityWarning, SeverityCritical:
+		return nil
+	default:
+		return ErrInvalidSeverity
+	}
+}
+
+// IsID reports whether id identifies a notice rather than a stored event
+func IsID(id string) bool {
+	return strings.HasPrefix(id, idPrefix)
+}
+
+// newID generates a random notice ID
+func newID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return idPrefix + hex.EncodeToString(b)
+}
@@ -0,0 +1,68 @@
+package notice
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/otiai10/namazu/backend/internal/delivery/schema"
+)
+
+func TestNew(t *testing.T) {
+	n, err := New("  Relay maintenance  ", "The relay restarts at 02:00 JST.", "")
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if n.Type != Type || n.Severity != SeverityInfo || n.Title != "Relay maintenance" {
+		t.Errorf("unexpected notice: %+v", n)
+	}
+	if !IsID(n.ID) {
+		t.Errorf("IsID(%q) = false", n.ID)
+	}
+	if n.IssuedAt.IsZero() {
+		t.Error("IssuedAt is zero")
+	}
+
+	other, _ := New("a", "b", SeverityWarning)
+	if other.ID == n.ID {
+		t.Error("IDs must be unique")
+	}
+
+	data, _ := json.Marshal(n)
+	if !strings.Contains(string(data), `"type":"namazu.service_notice"`) {
+		t.Errorf("payload does not carry the notice type: %s", data)
+	}
+	if err := schema.Validate(schema.SchemaNotice, data); err != nil {
+		t.Errorf("payload does not match its published schema: %v", err)
+	}
+}
+
+func TestNew_Invalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		title    string
+		message  string
+		severity string
+		want     error
+	}{
+		{"no title", " ", "msg", "", ErrTitleRequired},
+		{"no message", "title", "", "", ErrMessageRequired},
+		{"long title", strings.Repeat("地", MaxTitleLength+1), "msg", "", ErrTooLong},
+		{"long message", "title", strings.Repeat("a", MaxMessageLength+1), "", ErrTooLong},
+		{"bad severity", "title", "msg", "urgent", ErrInvalidSeverity},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := New(tt.title, tt.message, tt.severity); !errors.Is(err, tt.want) {
+				t.Errorf("New() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsID(t *testing.T) {
+	if IsID("evt-1") {
+		t.Error("IsID(evt-1) = true")
+	}
+}
@@ -0,0 +1,263 @@
+// Package plan defines what each billing plan includes. The built-in free and
+// pro plans can be replaced or extended by a plan catalog in configuration,
+// for the default tenant and per tenant. Handlers check subscriptions against
+// the owner's Features when they are saved, and the delivery pipeline
+// restricts subscriptions whose owner was downgraded since.
+package plan
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/otiai10/namazu/backend/internal/delivery/webhook"
+	"github.com/otiai10/namazu/backend/internal/subscription"
+	"github.com/otiai10/namazu/backend/internal/tenant"
+	"github.com/otiai10/namazu/backend/internal/user"
+)
+
+// Retry tiers, from the lowest
+const (
+	RetryStandard = "standard" // Up to 3 retries within 5 minutes per delivery
+	RetryExtended = "extended" // Up to 10 retries within 1 hour per delivery
+)
+
+// retryLimit is the most a subscription may configure in a retry tier
+type retryLimit struct {
+	retries int           // RetryConfig.MaxRetries
+	window  time.Duration // Total backoff across all retries (webhook.RetryConfig.MaxWindow)
+}
+
+// retryLimits are the limits of each tier
+var retryLimits = map[string]retryLimit{
+	RetryStandard: {retries: 3, window: 5 * time.Minute},
+	RetryExtended: {retries: 10, window: time.Hour},
+}
+
+// Features are the limits and features included in a plan
+type Features struct {
+	MaxSubscriptions int
+	RetryTier        string   // RetryStandard | RetryExtended; empty means RetryStandard
+	DeliveryTypes    []string // Allowed DeliveryConfig.Type values; nil allows every type
+	Digest           bool     // Digest mode (DigestConfig)
+	Geofence         bool     // Geofence filters (FilterConfig.Geofence)
+	SkipVerification bool     // Webhooks may be delivered without passing URL verification
+}
+
+var (
+	// Free is included in the built-in free plan
+	Free = Features{
+		MaxSubscriptions: 1,
+		RetryTier:        RetryStandard,
+		DeliveryTypes:    []string{"webhook", subscription.DeliveryTypeWebPush, subscription.DeliveryTypeFCM},
+	}
+
+	// Pro is included in the built-in pro plan
+	Pro = Features{
+		MaxSubscriptions: 12,
+		RetryTier:        RetryExtended,
+		Digest:           true,
+		Geofence:         true,
+		SkipVerification: true,
+	}
+)
+
+// AllDeliveryTypes in a plan's configured delivery types allows every type
+const AllDeliveryTypes = "*"
+
+// For returns the features of a plan in the default catalog.
+// Unknown or empty plans get the free plan's features.
+func For(id string) Features {
+	f, _ := resolve(tenant.Default.Plans, id, builtin)
+	return f
+}
+
+// ForTenant returns the features of a plan in a tenant's plan catalog.
+// Tenants without a catalog use the default one. A catalog plan overrides the
+// limit and the features it sets of the default plan with the same ID (or of
+// the free plan for new IDs); plans the catalog does not define fall back to
+// the default catalog, or to the catalog's free plan if the ID is unknown.
+func ForTenant(t *tenant.Tenant, id string) Features {
+	if len(t.Plans) == 0 || t.IsDefault() {
+		return For(id)
+	}
+	f, _ := resolve(t.Plans, id, func(id string) (Features, bool) {
+		return resolve(tenant.Default.Plans, id, builtin)
+	})
+	return f
+}
+
+// builtin returns the features of the built-in plans, and whether id is one
+func builtin(id string) (Features, bool) {
+	switch id {
+	case user.PlanPro:
+		return Pro, true
+	case user.PlanFree:
+		return Free, true
+	}
+	return Free, false
+}
+
+// resolve returns the features of a plan in plans on top of base, and whether
+// either defines the plan
+func resolve(plans []tenant.Plan, id string, base func(string) (Features, bool)) (Features, bool) {
+	if p, ok := find(plans, id); ok {
+		f, _ := base(id)
+		return apply(f, p), true
+	}
+	if f, ok := base(id); ok {
+		return f, true
+	}
+	f, _ := base(user.PlanFree)
+	if p, ok := find(plans, user.PlanFree); ok {
+		f = apply(f, p)
+	}
+	return f, false
+}
+
+// find returns the plan with the given ID
+func find(plans []tenant.Plan, id string) (tenant.Plan, bool) {
+	for _, p := range plans {
+		if p.ID == id {
+			return p, true
+		}
+	}
+	return tenant.Plan{}, false
+}
+
+// apply overrides f with what a catalog plan sets
+func apply(f Features, p tenant.Plan) Features {
+	f.MaxSubscriptions = p.MaxSubscriptions
+	if p.RetryTier != "" {
+		f.RetryTier = p.RetryTier
+	}
+	if len(p.DeliveryTypes) > 0 {
+		f.DeliveryTypes = p.DeliveryTypes
+		for _, t := range p.DeliveryTypes {
+			if t == AllDeliveryTypes {
+				f.DeliveryTypes = nil
+				break
+			}
+		}
+	}
+	if p.Digest != nil {
+		f.Digest = *p.Digest
+	}
+	if p.Geofence != nil {
+		f.Geofence = *p.Geofence
+	}
+	if p.SkipVerification != nil {
+		f.SkipVerification = *p.SkipVerification
+	}
+	return f
+}
+
+// MaxRetries returns the most retries a subscription may configure
+func (f Features) MaxRetries() int {
+	return f.retryLimit().retries
+}
+
+// MaxRetryWindow returns the longest a subscription's retries may keep a
+// delivery pending, i.e. the total backoff across all retries
+func (f Features) MaxRetryWindow() time.Duration {
+	return f.retryLimit().window
+}
+
+// retryLimit returns the limits of f's retry tier
+func (f Features) retryLimit() retryLimit {
+	if l, ok := retryLimits[f.RetryTier]; ok {
+		return l
+	}
+	return retryLimits[RetryStandard]
+}
+
+// retryWindow returns the total backoff across all retries of r
+func retryWindow(r subscription.RetryConfig) time.Duration {
+	return webhook.RetryConfig{
+		Enabled:    r.Enabled,
+		MaxRetries: r.MaxRetries,
+		InitialMs:  r.InitialMs,
+		MaxMs:      r.MaxMs,
+	}.MaxWindow()
+}
+
+// AllowsDeliveryType reports whether subscriptions may deliver with the given type
+func (f Features) AllowsDeliveryType(t string) bool {
+	if f.DeliveryTypes == nil {
+		return true
+	}
+	for _, allowed := range f.DeliveryTypes {
+		if allowed == t {
+			return true
+		}
+	}
+	return false
+}
+
+// FeatureError reports a feature a subscription uses that its plan does not include
+type FeatureError struct {
+	Feature string // e.g. "digest", "geofence", "delivery type sms"
+	Detail  string // Optional; what the plan allows instead
+}
+
+func (e *FeatureError) Error() string {
+	msg := e.Feature + " is not available on your plan"
+	if e.Detail != "" {
+		msg += " (" + e.Detail + ")"
+	}
+	return msg
+}
+
+// Check returns a *FeatureError for the first feature sub uses that f does
+// not include, or nil if f includes all of them
+func (f Features) Check(sub subscription.Subscription) error {
+	if !f.AllowsDeliveryType(sub.Delivery.Type) {
+		return &FeatureError{Feature: "delivery type " + sub.Delivery.Type}
+	}
+	if sub.Digest != nil && !f.Digest {
+		return &FeatureError{Feature: "digest"}
+	}
+	if sub.Filter != nil && sub.Filter.Geofence != nil && !f.Geofence {
+		return &FeatureError{Feature: "geofence"}
+	}
+	if sub.Delivery.VerificationSkipped && !sub.Delivery.Verified && !f.SkipVerification {
+		return &FeatureError{Feature: "skipping URL verification"}
+	}
+	if r := sub.Delivery.Retry; r != nil && r.Enabled && r.MaxRetries > f.MaxRetries() {
+		return &FeatureError{Feature: fmt.Sprintf("%d retries", r.MaxRetries), Detail: fmt.Sprintf("at most %d", f.MaxRetries())}
+	}
+	if r := sub.Delivery.Retry; r != nil && retryWindow(*r) > f.MaxRetryWindow() {
+		return &FeatureError{Feature: fmt.Sprintf("a %s retry window", retryWindow(*r)), Detail: fmt.Sprintf("at most %s", f.MaxRetryWindow())}
+	}
+	return nil
+}
+
+// Restrict returns sub without the features f does not include: digests are
+// delivered one event at a time, geofences are dropped and retries are capped
+// (in number, then dropped from the end until they fit in the retry window).
+// Returns false if the delivery type is not allowed or an unverified webhook
+// may not skip verification, so sub must not be delivered.
+func (f Features) Restrict(sub subscription.Subscription) (subscription.Subscription, bool) {
+	if !f.AllowsDeliveryType(sub.Delivery.Type) {
+		return sub, false
+	}
+	if sub.Delivery.VerificationSkipped && !sub.Delivery.Verified && !f.SkipVerification {
+		return sub, false
+	}
+	if sub.Digest != nil && !f.Digest {
+		sub.Digest = nil
+	}
+	if sub.Filter != nil && sub.Filter.Geofence != nil && !f.Geofence {
+		filter := *sub.Filter
+		filter.Geofence = nil
+		sub.Filter = &filter
+	}
+	if r := sub.Delivery.Retry; r != nil && (r.MaxRetries > f.MaxRetries() || retryWindow(*r) > f.MaxRetryWindow()) {
+		retry := *r
+		retry.MaxRetries = min(retry.MaxRetries, f.MaxRetries())
+		for retry.MaxRetries > 0 && retryWindow(retry) > f.MaxRetryWindow() {
+			retry.MaxRetries--
+		}
+		sub.Delivery.Retry = &retry
+	}
+	return sub, true
+}
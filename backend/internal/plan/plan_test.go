@@ -0,0 +1,158 @@
+package plan
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/otiai10/namazu/backend/internal/config"
+	"github.com/otiai10/namazu/backend/internal/subscription"
+	"github.com/otiai10/namazu/backend/internal/tenant"
+)
+
+func TestFor(t *testing.T) {
+	if got := For("pro"); got.MaxSubscriptions != 12 || !got.Digest || got.MaxRetries() != 10 || got.MaxRetryWindow() != time.Hour {
+		t.Errorf("For(pro) = %+v", got)
+	}
+	for _, id := range []string{"free", "", "unknown"} {
+		if got := For(id); got.MaxSubscriptions != 1 || got.Digest || got.Geofence || got.MaxRetries() != 3 || got.MaxRetryWindow() != 5*time.Minute {
+			t.Errorf("For(%q) = %+v, want the free plan", id, got)
+		}
+	}
+}
+
+func TestForTenant(t *testing.T) {
+	acme := &tenant.Tenant{ID: "acme", Plans: []tenant.Plan{{ID: "free", MaxSubscriptions: 3}, {ID: "gold", MaxSubscriptions: 40}}}
+
+	if got := ForTenant(acme, "free"); got.MaxSubscriptions != 3 || got.Digest {
+		t.Errorf("free = %+v, want the tenant's limit with free features", got)
+	}
+	if got := ForTenant(acme, "gold"); got.MaxSubscriptions != 40 || got.Digest {
+		t.Errorf("gold = %+v, want the tenant's limit with free features", got)
+	}
+	if got := ForTenant(acme, "pro"); got.MaxSubscriptions != 12 || !got.Digest {
+		t.Errorf("pro = %+v, want the default pro plan", got)
+	}
+	if got := ForTenant(acme, "unknown"); got.MaxSubscriptions != 3 {
+		t.Errorf("unknown = %+v, want the tenant's free limit", got)
+	}
+}
+
+func TestFor_DefaultCatalog(t *testing.T) {
+	yes, no := true, false
+	tenant.SetDefaultPlans([]config.PlanConfig{
+		{ID: "free", MaxSubscriptions: 2},
+		{ID: "pro", MaxSubscriptions: 20, Geofence: &no},
+		{ID: "business", MaxSubscriptions: 100, RetryTier: RetryExtended, DeliveryTypes: []string{AllDeliveryTypes}, Digest: &yes},
+	})
+	t.Cleanup(func() { tenant.SetDefaultPlans(nil) })
+
+	if got := For("free"); got.MaxSubscriptions != 2 || got.Digest || got.AllowsDeliveryType("sms") {
+		t.Errorf("free = %+v, want the configured limit with free features", got)
+	}
+	if got := For("pro"); got.MaxSubscriptions != 20 || !got.Digest || got.Geofence {
+		t.Errorf("pro = %+v, want the pro features without geofence", got)
+	}
+	got := For("business")
+	if got.MaxSubscriptions != 100 || got.MaxRetries() != 10 || !got.Digest || got.Geofence || !got.AllowsDeliveryType("sms") {
+		t.Errorf("business = %+v, want the configured features on top of free", got)
+	}
+	if got := For("unknown"); got.MaxSubscriptions != 2 {
+		t.Errorf("unknown = %+v, want the configured free plan", got)
+	}
+
+	// Tenant plans build on the default catalog
+	acme := &tenant.Tenant{ID: "acme", Plans: []tenant.Plan{{ID: "business", MaxSubscriptions: 50}}}
+	if got := ForTenant(acme, "business"); got.MaxSubscriptions != 50 || !got.Digest {
+		t.Errorf("acme business = %+v, want the tenant's limit with the default business features", got)
+	}
+	if got := ForTenant(&tenant.Tenant{ID: "globex"}, "business"); got.MaxSubscriptions != 100 {
+		t.Errorf("globex business = %+v, want the default catalog", got)
+	}
+}
+
+func TestFeatures_Check(t *testing.T) {
+	webhook := subscription.Subscription{Delivery: subscription.DeliveryConfig{Type: "webhook"}}
+	digest := webhook
+	digest.Digest = &subscription.DigestConfig{IntervalMinutes: 60}
+	geofence := webhook
+	geofence.Filter = &subscription.FilterConfig{Geofence: &subscription.Geofence{Lat: 35, Lon: 139, RadiusKm: 50}}
+	retries := webhook
+	retries.Delivery.Retry = &subscription.RetryConfig{Enabled: true, MaxRetries: 5}
+	window := webhook
+	window.Delivery.Retry = &subscription.RetryConfig{Enabled: true, MaxRetries: 3, InitialMs: 60000, MaxMs: 600000}
+	sms := subscription.Subscription{Delivery: subscription.DeliveryConfig{Type: subscription.DeliveryTypeSMS}}
+	skipped := webhook
+	skipped.Delivery.VerificationSkipped = true
+
+	tests := []struct {
+		name        string
+		sub         subscription.Subscription
+		wantFeature string // empty for allowed on free
+	}{
+		{"webhook", webhook, ""},
+		{"digest", digest, "digest"},
+		{"geofence", geofence, "geofence"},
+		{"retries", retries, "5 retries"},
+		{"retry window", window, "a 7m0s retry window"},
+		{"sms", sms, "delivery type sms"},
+		{"skipped verification", skipped, "skipping URL verification"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Free.Check(tt.sub)
+			var fe *FeatureError
+			if tt.wantFeature == "" {
+				if err != nil {
+					t.Errorf("Free.Check() = %v, want nil", err)
+				}
+			} else if !errors.As(err, &fe) || fe.Feature != tt.wantFeature {
+				t.Errorf("Free.Check() = %v, want %q", err, tt.wantFeature)
+			}
+			if err := Pro.Check(tt.sub); err != nil {
+				t.Errorf("Pro.Check() = %v, want nil", err)
+			}
+		})
+	}
+}
+
+func TestFeatures_Restrict(t *testing.T) {
+	filter := &subscription.FilterConfig{MinScale: 30, Geofence: &subscription.Geofence{Lat: 35, Lon: 139, RadiusKm: 50}}
+	sub := subscription.Subscription{
+		Delivery: subscription.DeliveryConfig{Type: "webhook", Retry: &subscription.RetryConfig{Enabled: true, MaxRetries: 10}},
+		Filter:   filter,
+		Digest:   &subscription.DigestConfig{IntervalMinutes: 60},
+	}
+
+	got, ok := Free.Restrict(sub)
+	if !ok {
+		t.Fatal("Restrict() = false, want webhook deliveries allowed")
+	}
+	if got.Digest != nil || got.Filter.Geofence != nil || got.Filter.MinScale != 30 || got.Delivery.Retry.MaxRetries != 3 {
+		t.Errorf("Restrict() = %+v, want no digest or geofence and 3 retries", got)
+	}
+	if filter.Geofence == nil || sub.Delivery.Retry.MaxRetries != 10 {
+		t.Error("Restrict() modified the original subscription")
+	}
+
+	slow := subscription.Subscription{Delivery: subscription.DeliveryConfig{Type: "webhook",
+		Retry: &subscription.RetryConfig{Enabled: true, MaxRetries: 10, InitialMs: 60000, MaxMs: 600000}}}
+	if got, _ := Free.Restrict(slow); got.Delivery.Retry.MaxRetries != 2 {
+		t.Errorf("Restrict() kept %d retries, want 2 to fit in %s", got.Delivery.Retry.MaxRetries, Free.MaxRetryWindow())
+	}
+	if got, _ := Pro.Restrict(slow); got.Delivery.Retry.MaxRetries != 8 {
+		t.Errorf("Restrict() kept %d retries, want 8 to fit in %s", got.Delivery.Retry.MaxRetries, Pro.MaxRetryWindow())
+	}
+
+	if _, ok := Free.Restrict(subscription.Subscription{Delivery: subscription.DeliveryConfig{Type: subscription.DeliveryTypeSMS}}); ok {
+		t.Error("Restrict() = true for sms, want false")
+	}
+
+	skipped := subscription.Subscription{Delivery: subscription.DeliveryConfig{Type: "webhook", SignVersion: "v0", VerificationSkipped: true}}
+	if _, ok := Free.Restrict(skipped); ok {
+		t.Error("Restrict() = true for a webhook skipping verification, want false after a downgrade")
+	}
+	if _, ok := Pro.Restrict(skipped); !ok {
+		t.Error("Pro.Restrict() = false for a webhook skipping verification, want true")
+	}
+}
@@ -0,0 +1,79 @@
+package plan
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/otiai10/namazu/backend/internal/tenant"
+	"github.com/otiai10/namazu/backend/internal/user"
+)
+
+// Resolver looks up the features of subscription owners for the delivery
+// pipeline. Plans are cached for a TTL so that an event fanning out to many
+// subscriptions reads each owner once.
+type Resolver struct {
+	users   user.Repository
+	tenants *tenant.Registry // nil resolves every owner in the default tenant
+	ttl     time.Duration
+	now     func() time.Time
+
+	mu    sync.Mutex
+	plans map[string]cachedPlan // keyed by UID
+}
+
+// cachedPlan is an owner's plan and when it must be read again
+type cachedPlan struct {
+	id        string
+	expiresAt time.Time
+}
+
+// NewResolver creates a Resolver reading plans from users
+func NewResolver(users user.Repository, tenants *tenant.Registry, ttl time.Duration) *Resolver {
+	return &Resolver{
+		users:   users,
+		tenants: tenants,
+		ttl:     ttl,
+		now:     time.Now,
+		plans:   make(map[string]cachedPlan),
+	}
+}
+
+// Features returns the features of the owner's plan in the tenant.
+// Owners without a user record get the free plan's features.
+func (r *Resolver) Features(ctx context.Context, tenantID, uid string) (Features, error) {
+	id, err := r.planID(ctx, uid)
+	if err != nil {
+		return Features{}, err
+	}
+	t := tenant.Default
+	if r.tenants != nil {
+		t = r.tenants.Get(tenantID)
+	}
+	return ForTenant(t, id), nil
+}
+
+// planID returns the owner's plan, from the cache while it is fresh
+func (r *Resolver) planID(ctx context.Context, uid string) (string, error) {
+	now := r.now()
+	r.mu.Lock()
+	cached, ok := r.plans[uid]
+	r.mu.Unlock()
+	if ok && now.Before(cached.expiresAt) {
+		return cached.id, nil
+	}
+
+	u, err := r.users.GetByUID(ctx, uid)
+	if err != nil {
+		return "", err
+	}
+	id := user.PlanFree
+	if u != nil && u.Plan != "" {
+		id = u.Plan
+	}
+
+	r.mu.Lock()
+	r.plans[uid] = cachedPlan{id: id, expiresAt: now.Add(r.ttl)}
+	r.mu.Unlock()
+	return id, nil
+}
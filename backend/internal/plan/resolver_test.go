@@ -0,0 +1,48 @@
+package plan
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/otiai10/namazu/backend/internal/config"
+	"github.com/otiai10/namazu/backend/internal/tenant"
+	"github.com/otiai10/namazu/backend/internal/user"
+)
+
+func TestResolver_Features(t *testing.T) {
+	ctx := context.Background()
+	users := user.NewMemoryRepository()
+	id, err := users.Create(ctx, user.User{UID: "pro-user", Plan: user.PlanPro})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	tenants := tenant.NewRegistry([]config.TenantConfig{{ID: "acme", Plans: []config.PlanConfig{{ID: "pro", MaxSubscriptions: 100}}}})
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	r := NewResolver(users, tenants, time.Minute)
+	r.now = func() time.Time { return now }
+
+	if f, err := r.Features(ctx, "", "pro-user"); err != nil || !f.Digest || f.MaxSubscriptions != 12 {
+		t.Errorf("Features(pro-user) = %+v, %v", f, err)
+	}
+	if f, _ := r.Features(ctx, "acme", "pro-user"); f.MaxSubscriptions != 100 {
+		t.Errorf("Features(acme, pro-user).MaxSubscriptions = %d, want the tenant's 100", f.MaxSubscriptions)
+	}
+	if f, err := r.Features(ctx, "", "unknown"); err != nil || f.Digest {
+		t.Errorf("Features(unknown) = %+v, %v, want the free plan", f, err)
+	}
+
+	// Downgrades take effect once the cached plan expires
+	u, _ := users.Get(ctx, id)
+	u.Plan = user.PlanFree
+	if err := users.Update(ctx, id, *u); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if f, _ := r.Features(ctx, "", "pro-user"); !f.Digest {
+		t.Error("Features() read the user again before the TTL")
+	}
+	now = now.Add(2 * time.Minute)
+	if f, _ := r.Features(ctx, "", "pro-user"); f.Digest {
+		t.Error("Features() kept the pro plan after the TTL")
+	}
+}
@@ -4,7 +4,9 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/otiai10/namazu/backend/internal/plan"
 	"github.com/otiai10/namazu/backend/internal/subscription"
+	"github.com/otiai10/namazu/backend/internal/tenant"
 )
 
 // QuotaChecker checks if operations are allowed within quota
@@ -12,6 +14,15 @@ type QuotaChecker interface {
 	// CanCreateSubscription checks if a user can create a new subscription
 	// based on their plan limits and current subscription count
 	CanCreateSubscription(ctx context.Context, userID string, plan string) (bool, error)
+
+	// CanCreateSubscriptions checks if a user can create n subscriptions at once
+	CanCreateSubscriptions(ctx context.Context, userID string, plan string, n int) (bool, error)
+
+	// SubscriptionUsage returns the user's subscription count and their plan's limit
+	SubscriptionUsage(ctx context.Context, userID string, plan string) (used, limit int, err error)
+
+	// Features returns the features of a plan in the tenant in ctx
+	Features(ctx context.Context, planID string) plan.Features
 }
 
 // Checker implements QuotaChecker using subscription repository
@@ -27,18 +38,45 @@ func NewChecker(subRepo subscription.Repository) *Checker {
 }
 
 // CanCreateSubscription checks if the user can create a new subscription
-// Returns true if the user is under their plan's subscription limit
+// Returns true if the user is under their plan's subscription limit.
+// Limits and counts are scoped to the tenant in ctx.
 func (c *Checker) CanCreateSubscription(ctx context.Context, userID, plan string) (bool, error) {
+	return c.CanCreateSubscriptions(ctx, userID, plan, 1)
+}
+
+// CanCreateSubscriptions checks if the user stays within their plan's
+// subscription limit after creating n more subscriptions
+func (c *Checker) CanCreateSubscriptions(ctx context.Context, userID, plan string, n int) (bool, error) {
+	used, limit, err := c.SubscriptionUsage(ctx, userID, plan)
+	if err != nil {
+		return false, err
+	}
+	return used+n <= limit, nil
+}
+
+// SubscriptionUsage counts the user's subscriptions in the tenant in ctx and
+// returns them with the plan's limit in the tenant's catalog
+func (c *Checker) SubscriptionUsage(ctx context.Context, userID, plan string) (int, int, error) {
 	// Get current subscription count for user
 	subs, err := c.subRepo.ListByUserID(ctx, userID)
 	if err != nil {
-		return false, fmt.Errorf("failed to get user subscriptions: %w", err)
+		return 0, 0, fmt.Errorf("failed to get user subscriptions: %w", err)
 	}
 
-	// Get limits for the plan
-	limits := GetLimits(plan)
+	// Get limits for the plan in the tenant's catalog
+	t := tenant.FromContext(ctx)
+	limits := GetTenantLimits(t, plan)
+
+	used := 0
+	for _, sub := range subs {
+		if sub.TenantID == t.ID {
+			used++
+		}
+	}
+	return used, limits.MaxSubscriptions, nil
+}
 
-	// Check if under limit
-	currentCount := len(subs)
-	return currentCount < limits.MaxSubscriptions, nil
+// Features returns the features of the plan in the tenant's catalog
+func (c *Checker) Features(ctx context.Context, id string) plan.Features {
+	return plan.ForTenant(tenant.FromContext(ctx), id)
 }
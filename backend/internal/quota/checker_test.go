@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/otiai10/namazu/backend/internal/subscription"
+	"github.com/otiai10/namazu/backend/internal/tenant"
 )
 
 // mockSubscriptionRepo is a mock implementation of subscription.Repository for testing
@@ -35,6 +36,10 @@ func (m *mockSubscriptionRepo) Create(ctx context.Context, sub subscription.Subs
 	return "", nil
 }
 
+func (m *mockSubscriptionRepo) CreateWithinLimit(ctx context.Context, sub subscription.Subscription, limit int) (string, error) {
+	return "", nil
+}
+
 func (m *mockSubscriptionRepo) Get(ctx context.Context, id string) (*subscription.Subscription, error) {
 	return nil, nil
 }
@@ -244,3 +249,72 @@ func TestNewChecker(t *testing.T) {
 		t.Error("expected non-nil checker")
 	}
 }
+
+func TestChecker_CanCreateSubscription_TenantScoped(t *testing.T) {
+	// Subscriptions under other tenants do not count against the tenant's limit
+	repo := &mockSubscriptionRepo{
+		subscriptions: []subscription.Subscription{
+			{ID: "sub1", UserID: "user1"},
+			{ID: "sub2", UserID: "user1", TenantID: "acme"},
+		},
+	}
+	checker := NewChecker(repo)
+	acme := &tenant.Tenant{ID: "acme", Plans: []tenant.Plan{{ID: "free", MaxSubscriptions: 2}}}
+	ctx := tenant.WithTenant(context.Background(), acme)
+
+	canCreate, err := checker.CanCreateSubscription(ctx, "user1", "free")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !canCreate {
+		t.Error("expected canCreate = true under tenant limit, got false")
+	}
+
+	repo.subscriptions = append(repo.subscriptions, subscription.Subscription{ID: "sub3", UserID: "user1", TenantID: "acme"})
+	canCreate, err = checker.CanCreateSubscription(ctx, "user1", "free")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if canCreate {
+		t.Error("expected canCreate = false at tenant limit, got true")
+	}
+}
+
+func TestChecker_CanCreateSubscriptions(t *testing.T) {
+	// Pro user with 10 subscriptions can add 2 more at once, but not 3 (limit is 12)
+	subs := make([]subscription.Subscription, 10)
+	for i := range subs {
+		subs[i] = subscription.Subscription{UserID: "user1"}
+	}
+	checker := NewChecker(&mockSubscriptionRepo{subscriptions: subs})
+
+	for n, want := range map[int]bool{2: true, 3: false} {
+		canCreate, err := checker.CanCreateSubscriptions(context.Background(), "user1", "pro", n)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if canCreate != want {
+			t.Errorf("CanCreateSubscriptions(%d) = %v, want %v", n, canCreate, want)
+		}
+	}
+}
+
+func TestChecker_SubscriptionUsage(t *testing.T) {
+	repo := &mockSubscriptionRepo{
+		subscriptions: []subscription.Subscription{
+			{ID: "1", UserID: "user1"},
+			{ID: "2", UserID: "user1"},
+			{ID: "3", UserID: "user2"},
+		},
+	}
+	checker := NewChecker(repo)
+
+	used, limit, err := checker.SubscriptionUsage(context.Background(), "user1", "pro")
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if used != 2 || limit != GetLimits("pro").MaxSubscriptions {
+		t.Errorf("got %d of %d, want 2 of the pro limit", used, limit)
+	}
+}
@@ -0,0 +1,89 @@
+package quota
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/otiai10/namazu/backend/internal/plan"
+	"github.com/otiai10/namazu/backend/internal/subscription"
+	"github.com/otiai10/namazu/backend/internal/tenant"
+)
+
+// Enforcer brings a user's subscriptions within their plan's limit after a
+// plan change. Downgrades suspend the newest subscriptions over the limit;
+// upgrades reactivate subscriptions suspended that way, oldest first.
+// Subscriptions suspended for other reasons are left to their owner.
+type Enforcer struct {
+	subRepo subscription.Repository
+	tenants *tenant.Registry // nil enforces every subscription with the default plans
+	now     func() time.Time
+}
+
+// NewEnforcer creates a new Enforcer
+func NewEnforcer(subRepo subscription.Repository, tenants *tenant.Registry) *Enforcer {
+	return &Enforcer{subRepo: subRepo, tenants: tenants, now: time.Now}
+}
+
+// Enforce applies the limit of the plan to the user's subscriptions in each tenant
+func (e *Enforcer) Enforce(ctx context.Context, userID, planID string) error {
+	subs, err := e.subRepo.ListByUserID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to get user subscriptions: %w", err)
+	}
+
+	byTenant := make(map[string][]subscription.Subscription)
+	for _, sub := range subs {
+		byTenant[sub.TenantID] = append(byTenant[sub.TenantID], sub)
+	}
+	for tenantID, subs := range byTenant {
+		t := tenant.Default
+		if e.tenants != nil {
+			t = e.tenants.Get(tenantID)
+		}
+		if err := e.enforce(ctx, subs, plan.ForTenant(t, planID).MaxSubscriptions); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// enforce applies a limit to the subscriptions of one tenant
+func (e *Enforcer) enforce(ctx context.Context, subs []subscription.Subscription, limit int) error {
+	sort.SliceStable(subs, func(i, j int) bool { return subs[i].CreatedAt.Before(subs[j].CreatedAt) })
+
+	var counted, overQuota []subscription.Subscription
+	for _, sub := range subs {
+		switch {
+		case sub.Status != subscription.StatusSuspended:
+			counted = append(counted, sub)
+		case sub.StatusReason == subscription.ReasonOverQuota:
+			overQuota = append(overQuota, sub)
+		}
+	}
+
+	now := e.now().UTC()
+	for i := limit; i < len(counted); i++ {
+		sub := counted[i]
+		sub.Status = subscription.StatusSuspended
+		sub.StatusReason = subscription.ReasonOverQuota
+		sub.StatusChangedAt = &now
+		if err := e.subRepo.Update(ctx, sub.ID, sub); err != nil {
+			return fmt.Errorf("failed to suspend subscription %s: %w", sub.ID, err)
+		}
+		log.Printf("Quota: suspended subscription %s over the plan limit of %d", sub.ID, limit)
+	}
+	for i := 0; i < len(overQuota) && len(counted)+i < limit; i++ {
+		sub := overQuota[i]
+		sub.Status = subscription.StatusActive
+		sub.StatusReason = ""
+		sub.StatusChangedAt = &now
+		if err := e.subRepo.Update(ctx, sub.ID, sub); err != nil {
+			return fmt.Errorf("failed to reactivate subscription %s: %w", sub.ID, err)
+		}
+		log.Printf("Quota: reactivated subscription %s within the plan limit of %d", sub.ID, limit)
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package quota
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/otiai10/namazu/backend/internal/subscription"
+)
+
+func TestEnforcer_Enforce(t *testing.T) {
+	ctx := context.Background()
+	repo := subscription.NewMemoryRepository()
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	create := func(name string, age int, status, reason string) string {
+		id, err := repo.Create(ctx, subscription.Subscription{
+			UserID: "user1", Name: name, CreatedAt: base.Add(time.Duration(age) * time.Hour),
+			Status: status, StatusReason: reason,
+		})
+		if err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+		return id
+	}
+	oldest := create("oldest", 0, subscription.StatusActive, "")
+	failing := create("failing", 1, subscription.StatusSuspended, subscription.ReasonFailing)
+	second := create("second", 2, subscription.StatusActive, "")
+	third := create("third", 3, subscription.StatusWarned, subscription.ReasonInactive)
+
+	status := func(id string) (string, string) {
+		sub, _ := repo.Get(ctx, id)
+		return sub.Status, sub.StatusReason
+	}
+
+	// Downgrade: only the oldest subscription fits the free plan
+	if err := NewEnforcer(repo, nil).Enforce(ctx, "user1", "free"); err != nil {
+		t.Fatalf("Enforce(free) error = %v", err)
+	}
+	if s, _ := status(oldest); s != subscription.StatusActive {
+		t.Errorf("oldest = %s, want active", s)
+	}
+	for _, id := range []string{second, third} {
+		if s, r := status(id); s != subscription.StatusSuspended || r != subscription.ReasonOverQuota {
+			t.Errorf("%s = %s/%s, want suspended over quota", id, s, r)
+		}
+	}
+	if _, r := status(failing); r != subscription.ReasonFailing {
+		t.Errorf("failing = %s, want it left suspended as failing", r)
+	}
+
+	// Upgrade: subscriptions suspended over quota are reactivated
+	if err := NewEnforcer(repo, nil).Enforce(ctx, "user1", "pro"); err != nil {
+		t.Fatalf("Enforce(pro) error = %v", err)
+	}
+	for _, id := range []string{second, third} {
+		if s, r := status(id); s != subscription.StatusActive || r != "" {
+			t.Errorf("%s = %s/%s, want active", id, s, r)
+		}
+	}
+	if s, _ := status(failing); s != subscription.StatusSuspended {
+		t.Errorf("failing = %s, want suspended until its owner reactivates it", s)
+	}
+}
@@ -1,7 +1,8 @@
 package quota
 
 import (
-	"github.com/otiai10/namazu/backend/internal/user"
+	"github.com/otiai10/namazu/backend/internal/plan"
+	"github.com/otiai10/namazu/backend/internal/tenant"
 )
 
 // PlanLimits defines limits per plan
@@ -11,22 +12,20 @@ type PlanLimits struct {
 
 var (
 	// FreePlanLimits defines limits for free plan users
-	FreePlanLimits = PlanLimits{MaxSubscriptions: 1}
+	FreePlanLimits = PlanLimits{MaxSubscriptions: plan.Free.MaxSubscriptions}
 
 	// ProPlanLimits defines limits for pro plan users
-	ProPlanLimits = PlanLimits{MaxSubscriptions: 12}
+	ProPlanLimits = PlanLimits{MaxSubscriptions: plan.Pro.MaxSubscriptions}
 )
 
 // GetLimits returns limits for a plan
 // Unknown or empty plans default to free plan limits
-func GetLimits(plan string) PlanLimits {
-	switch plan {
-	case user.PlanPro:
-		return ProPlanLimits
-	case user.PlanFree:
-		return FreePlanLimits
-	default:
-		// Unknown or empty plan defaults to free
-		return FreePlanLimits
-	}
+func GetLimits(id string) PlanLimits {
+	return PlanLimits{MaxSubscriptions: plan.For(id).MaxSubscriptions}
+}
+
+// GetTenantLimits returns limits for a plan in a tenant's plan catalog.
+// Plans the tenant does not define fall back to GetLimits.
+func GetTenantLimits(t *tenant.Tenant, id string) PlanLimits {
+	return PlanLimits{MaxSubscriptions: plan.ForTenant(t, id).MaxSubscriptions}
 }
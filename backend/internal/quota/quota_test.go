@@ -2,6 +2,8 @@ package quota
 
 import (
 	"testing"
+
+	"github.com/otiai10/namazu/backend/internal/tenant"
 )
 
 func TestGetLimits_FreePlan(t *testing.T) {
@@ -45,3 +47,39 @@ func TestPlanLimitsConstants(t *testing.T) {
 		t.Errorf("ProPlanLimits.MaxSubscriptions should be 12, got %d", ProPlanLimits.MaxSubscriptions)
 	}
 }
+
+func TestGetTenantLimits(t *testing.T) {
+	acme := &tenant.Tenant{
+		ID: "acme",
+		Plans: []tenant.Plan{
+			{ID: "free", MaxSubscriptions: 3},
+			{ID: "pro", MaxSubscriptions: 100},
+		},
+	}
+	freeOnly := &tenant.Tenant{
+		ID:    "globex",
+		Plans: []tenant.Plan{{ID: "free", MaxSubscriptions: 2}},
+	}
+
+	tests := []struct {
+		name   string
+		tenant *tenant.Tenant
+		plan   string
+		want   int
+	}{
+		{name: "tenant free plan", tenant: acme, plan: "free", want: 3},
+		{name: "tenant pro plan", tenant: acme, plan: "pro", want: 100},
+		{name: "unknown plan uses tenant free plan", tenant: acme, plan: "", want: 3},
+		{name: "undefined pro plan uses default pro", tenant: freeOnly, plan: "pro", want: 12},
+		{name: "default tenant", tenant: tenant.Default, plan: "pro", want: 12},
+		{name: "default tenant unknown plan", tenant: tenant.Default, plan: "unknown", want: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := GetTenantLimits(tt.tenant, tt.plan).MaxSubscriptions; got != tt.want {
+				t.Errorf("GetTenantLimits().MaxSubscriptions = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
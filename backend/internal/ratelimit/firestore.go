@@ -0,0 +1,88 @@
+package ratelimit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"time"
+
+	"cloud.google.com/go/firestore"
+)
+
+// counterCollection holds one document per key, window and shard
+const counterCollection = "rate_limits"
+
+// DefaultShards is the number of counter documents per key and window.
+// A Firestore document sustains about one write per second, so the count of
+// a busy key is spread over shards and summed on read.
+const DefaultShards = 4
+
+// counterShard is one shard of a window's count
+type counterShard struct {
+	Count int64 `firestore:"count"`
+	// ExpiresAt is when the shard is no longer needed; a TTL policy on this
+	// field deletes it
+	ExpiresAt time.Time `firestore:"expiresAt"`
+}
+
+// FirestoreStore implements Store with sharded counters in Firestore
+type FirestoreStore struct {
+	client *firestore.Client
+	shards int
+}
+
+// Compile-time interface check
+var _ Store = (*FirestoreStore)(nil)
+
+// NewFirestoreStore creates a FirestoreStore with DefaultShards shards per counter
+func NewFirestoreStore(client *firestore.Client) *FirestoreStore {
+	return &FirestoreStore{client: client, shards: DefaultShards}
+}
+
+// shardDocID returns the document ID of a counter shard.
+// Keys contain client IPs and paths, so they are hashed.
+func shardDocID(key string, window time.Time, shard int) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:16]) + "-" + strconv.FormatInt(window.Unix(), 10) + "-" + strconv.Itoa(shard)
+}
+
+// Increment adds one request to a random shard of key's count in the window,
+// then sums all shards
+func (s *FirestoreStore) Increment(ctx context.Context, key string, window time.Time) (int64, error) {
+	if s.client == nil {
+		return 0, fmt.Errorf("firestore client is nil")
+	}
+
+	col := s.client.Collection(counterCollection)
+	_, err := col.Doc(shardDocID(key, window, rand.Intn(s.shards))).Set(ctx, map[string]interface{}{
+		"count":     firestore.Increment(1),
+		"expiresAt": window.Add(2 * Window),
+	}, firestore.MergeAll)
+	if err != nil {
+		return 0, fmt.Errorf("failed to increment rate limit counter: %w", err)
+	}
+
+	refs := make([]*firestore.DocumentRef, s.shards)
+	for i := range refs {
+		refs[i] = col.Doc(shardDocID(key, window, i))
+	}
+	docs, err := s.client.GetAll(ctx, refs)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read rate limit counter: %w", err)
+	}
+	var total int64
+	for _, doc := range docs {
+		if !doc.Exists() {
+			continue
+		}
+		var shard counterShard
+		if err := doc.DataTo(&shard); err != nil {
+			return 0, fmt.Errorf("failed to unmarshal rate limit counter: %w", err)
+		}
+		total += shard.Count
+	}
+	return total, nil
+}
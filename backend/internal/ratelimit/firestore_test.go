@@ -0,0 +1,31 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestFirestoreStore_NilClient(t *testing.T) {
+	store := NewFirestoreStore(nil)
+	if _, err := store.Increment(context.Background(), "1.2.3.4", time.Now()); err == nil {
+		t.Error("Increment() expected error for nil client")
+	}
+}
+
+func TestShardDocID(t *testing.T) {
+	window := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	id := shardDocID("default:2001:db8::1", window, 3)
+	if id != shardDocID("default:2001:db8::1", window, 3) {
+		t.Error("shardDocID() should be stable")
+	}
+	for _, other := range []string{
+		shardDocID("default:2001:db8::2", window, 3),
+		shardDocID("default:2001:db8::1", window.Add(Window), 3),
+		shardDocID("default:2001:db8::1", window, 2),
+	} {
+		if other == id {
+			t.Errorf("shardDocID() = %q for different inputs", id)
+		}
+	}
+}
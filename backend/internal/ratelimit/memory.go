@@ -0,0 +1,38 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryStore implements Store in process memory. Counts are not shared
+// between instances; it is meant for tests and single-instance setups.
+type MemoryStore struct {
+	mu     sync.Mutex
+	window time.Time        // The current window; older counts are dropped
+	counts map[string]int64 // Keyed by key, within window
+}
+
+// Compile-time interface check
+var _ Store = (*MemoryStore)(nil)
+
+// NewMemoryStore creates an empty MemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{counts: map[string]int64{}}
+}
+
+// Increment adds one request to key's count in the window
+func (s *MemoryStore) Increment(ctx context.Context, key string, window time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if window.After(s.window) {
+		s.window = window
+		s.counts = map[string]int64{}
+	} else if window.Before(s.window) {
+		// A request from a window that already ended; it cannot exceed anything now
+		return 1, nil
+	}
+	s.counts[key]++
+	return s.counts[key], nil
+}
@@ -0,0 +1,33 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryStore_Increment(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	window := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	for want := int64(1); want <= 2; want++ {
+		if got, _ := store.Increment(ctx, "a", window); got != want {
+			t.Errorf("Increment() = %d, want %d", got, want)
+		}
+	}
+	if got, _ := store.Increment(ctx, "b", window); got != 1 {
+		t.Errorf("Increment() for another key = %d, want 1", got)
+	}
+
+	next := window.Add(Window)
+	if got, _ := store.Increment(ctx, "a", next); got != 1 {
+		t.Errorf("Increment() in the next window = %d, want 1", got)
+	}
+	if len(store.counts) != 1 {
+		t.Errorf("counts = %v, want only the current window", store.counts)
+	}
+	if got, _ := store.Increment(ctx, "a", window); got != 1 {
+		t.Errorf("Increment() in an ended window = %d, want 1", got)
+	}
+}
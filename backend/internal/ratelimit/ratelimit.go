@@ -0,0 +1,70 @@
+// Package ratelimit enforces per-client request limits with counters kept in a
+// shared store, so every API instance sees the same counts.
+package ratelimit
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// Window is the length of a counting window. Limits are per minute.
+const Window = time.Minute
+
+// DefaultTimeout bounds a store round trip. Requests are let through when it
+// is exceeded, so a slow store does not stall the API.
+const DefaultTimeout = 500 * time.Millisecond
+
+// Store counts requests per key in fixed windows
+type Store interface {
+	// Increment adds one request to key's count in the window starting at
+	// window and returns the count including it
+	Increment(ctx context.Context, key string, window time.Time) (int64, error)
+}
+
+// Limiter allows up to a fixed number of requests per key in each window.
+// It satisfies api.RateLimiter.
+type Limiter struct {
+	store   Store
+	name    string // Namespaces keys so limiters sharing a store count separately
+	limit   int
+	timeout time.Duration
+	now     func() time.Time
+}
+
+// NewLimiter creates a Limiter allowing requestsPerMinute requests per key.
+// name must differ between limiters that share a store.
+func NewLimiter(store Store, name string, requestsPerMinute int) *Limiter {
+	return &Limiter{
+		store:   store,
+		name:    name,
+		limit:   requestsPerMinute,
+		timeout: DefaultTimeout,
+		now:     time.Now,
+	}
+}
+
+// Allow counts a request from key and reports whether it is within the limit.
+// When it is not, retryAfter is the number of seconds until the window ends.
+// Store failures let the request through: an outage must not lock out every client.
+func (l *Limiter) Allow(key string) (bool, int) {
+	now := l.now()
+	window := now.Truncate(Window)
+
+	ctx, cancel := context.WithTimeout(context.Background(), l.timeout)
+	defer cancel()
+	count, err := l.store.Increment(ctx, l.name+":"+key, window)
+	if err != nil {
+		log.Printf("RateLimit: failed to count %s for %q: %v", l.name, key, err)
+		return true, 0
+	}
+	if count <= int64(l.limit) {
+		return true, 0
+	}
+
+	retryAfter := int(window.Add(Window).Sub(now).Seconds())
+	if retryAfter < 1 {
+		retryAfter = 1
+	}
+	return false, retryAfter
+}
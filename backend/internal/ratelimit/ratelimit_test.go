@@ -0,0 +1,60 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// failingStore fails every call
+type failingStore struct{}
+
+func (failingStore) Increment(ctx context.Context, key string, window time.Time) (int64, error) {
+	return 0, errors.New("unavailable")
+}
+
+func TestLimiter_Allow(t *testing.T) {
+	store := NewMemoryStore()
+	now := time.Date(2026, 1, 1, 0, 0, 45, 0, time.UTC)
+	clock := func() time.Time { return now }
+
+	// Two instances sharing a store enforce one limit
+	a := NewLimiter(store, "default", 3)
+	b := NewLimiter(store, "default", 3)
+	a.now, b.now = clock, clock
+	for i, l := range []*Limiter{a, b, a} {
+		if ok, _ := l.Allow("1.2.3.4"); !ok {
+			t.Fatalf("request %d should be allowed", i+1)
+		}
+	}
+	ok, retryAfter := b.Allow("1.2.3.4")
+	if ok || retryAfter != 15 {
+		t.Errorf("Allow() = %v, %d; want false, 15 (until the window ends)", ok, retryAfter)
+	}
+
+	// Other clients and other limiters count separately
+	if ok, _ := a.Allow("5.6.7.8"); !ok {
+		t.Error("another client should be allowed")
+	}
+	other := NewLimiter(store, "subscriptions", 3)
+	other.now = clock
+	if ok, _ := other.Allow("1.2.3.4"); !ok {
+		t.Error("another limiter should be allowed")
+	}
+
+	// The next window starts over
+	now = now.Add(15 * time.Second)
+	if ok, _ := a.Allow("1.2.3.4"); !ok {
+		t.Error("request in the next window should be allowed")
+	}
+}
+
+func TestLimiter_AllowsWhenStoreFails(t *testing.T) {
+	l := NewLimiter(failingStore{}, "default", 1)
+	for i := 0; i < 3; i++ {
+		if ok, _ := l.Allow("1.2.3.4"); !ok {
+			t.Fatal("requests should be allowed when the store fails")
+		}
+	}
+}
@@ -0,0 +1,224 @@
+// Package region defines the prefectures of Japan and their regions, and
+// normalizes the ways users write them: full names ("東京都"), short names
+// ("東京") and romaji ("Tokyo", "tokyo-to"). Subscription filters, device
+// alerts and the API use it so that every spelling of a prefecture matches
+// the full names events report.
+package region
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Count is the number of prefectures
+const Count = 47
+
+// Prefecture is a prefecture of Japan
+type Prefecture struct {
+	Code   int    // JIS X 0401 code, 1-47
+	Name   string // Full name as events report it, e.g. "東京都"
+	Romaji string // e.g. "Tokyo"
+	Region string // ID of its region, e.g. RegionKanto
+}
+
+// IDs of the regions, in the common eight-region division
+const (
+	RegionHokkaido = "hokkaido"
+	RegionTohoku   = "tohoku"
+	RegionKanto    = "kanto"
+	RegionChubu    = "chubu"
+	RegionKinki    = "kinki" // Also called Kansai
+	RegionChugoku  = "chugoku"
+	RegionShikoku  = "shikoku"
+	RegionKyushu   = "kyushu" // Including Okinawa
+)
+
+// Region is a group of neighbouring prefectures
+type Region struct {
+	ID      string
+	Name    string   // e.g. "関東"
+	Aliases []string // Other names, e.g. "関西" and "Kansai" for 近畿
+}
+
+// prefectures in JIS X 0401 order; the code of a prefecture is its index + 1
+var prefectures = []Prefecture{
+	{1, "北海道", "Hokkaido", RegionHokkaido},
+	{2, "青森県", "Aomori", RegionTohoku},
+	{3, "岩手県", "Iwate", RegionTohoku},
+	{4, "宮城県", "Miyagi", RegionTohoku},
+	{5, "秋田県", "Akita", RegionTohoku},
+	{6, "山形県", "Yamagata", RegionTohoku},
+	{7, "福島県", "Fukushima", RegionTohoku},
+	{8, "茨城県", "Ibaraki", RegionKanto},
+	{9, "栃木県", "Tochigi", RegionKanto},
+	{10, "群馬県", "Gunma", RegionKanto},
+	{11, "埼玉県", "Saitama", RegionKanto},
+	{12, "千葉県", "Chiba", RegionKanto},
+	{13, "東京都", "Tokyo", RegionKanto},
+	{14, "神奈川県", "Kanagawa", RegionKanto},
+	{15, "新潟県", "Niigata", RegionChubu},
+	{16, "富山県", "Toyama", RegionChubu},
+	{17, "石川県", "Ishikawa", RegionChubu},
+	{18, "福井県", "Fukui", RegionChubu},
+	{19, "山梨県", "Yamanashi", RegionChubu},
+	{20, "長野県", "Nagano", RegionChubu},
+	{21, "岐阜県", "Gifu", RegionChubu},
+	{22, "静岡県", "Shizuoka", RegionChubu},
+	{23, "愛知県", "Aichi", RegionChubu},
+	{24, "三重県", "Mie", RegionKinki},
+	{25, "滋賀県", "Shiga", RegionKinki},
+	{26, "京都府", "Kyoto", RegionKinki},
+	{27, "大阪府", "Osaka", RegionKinki},
+	{28, "兵庫県", "Hyogo", RegionKinki},
+	{29, "奈良県", "Nara", RegionKinki},
+	{30, "和歌山県", "Wakayama", RegionKinki},
+	{31, "鳥取県", "Tottori", RegionChugoku},
+	{32, "島根県", "Shimane", RegionChugoku},
+	{33, "岡山県", "Okayama", RegionChugoku},
+	{34, "広島県", "Hiroshima", RegionChugoku},
+	{35, "山口県", "Yamaguchi", RegionChugoku},
+	{36, "徳島県", "Tokushima", RegionShikoku},
+	{37, "香川県", "Kagawa", RegionShikoku},
+	{38, "愛媛県", "Ehime", RegionShikoku},
+	{39, "高知県", "Kochi", RegionShikoku},
+	{40, "福岡県", "Fukuoka", RegionKyushu},
+	{41, "佐賀県", "Saga", RegionKyushu},
+	{42, "長崎県", "Nagasaki", RegionKyushu},
+	{43, "熊本県", "Kumamoto", RegionKyushu},
+	{44, "大分県", "Oita", RegionKyushu},
+	{45, "宮崎県", "Miyazaki", RegionKyushu},
+	{46, "鹿児島県", "Kagoshima", RegionKyushu},
+	{47, "沖縄県", "Okinawa", RegionKyushu},
+}
+
+// regions in north-to-south order
+var regions = []Region{
+	{RegionHokkaido, "北海道", nil},
+	{RegionTohoku, "東北", nil},
+	{RegionKanto, "関東", []string{"首都圏"}},
+	{RegionChubu, "中部", nil},
+	{RegionKinki, "近畿", []string{"関西", "Kansai"}},
+	{RegionChugoku, "中国", nil},
+	{RegionShikoku, "四国", nil},
+	{RegionKyushu, "九州", []string{"九州・沖縄", "九州沖縄", "Kyushu-Okinawa"}},
+}
+
+// Lookup tables by normalized key
+var (
+	prefectureIndex = make(map[string]int)    // Key → index in prefectures
+	regionIndex     = make(map[string]string) // Key → region ID
+)
+
+func init() {
+	for i, p := range prefectures {
+		prefectureIndex[key(p.Name)] = i
+		prefectureIndex[key(p.Romaji)] = i
+		for _, suffix := range []string{"都", "府", "県"} {
+			if short, ok := strings.CutSuffix(p.Name, suffix); ok {
+				prefectureIndex[key(short)] = i
+			}
+		}
+	}
+	for _, r := range regions {
+		for _, name := range append([]string{r.ID, r.Name, r.Name + "地方"}, r.Aliases...) {
+			regionIndex[key(name)] = r.ID
+		}
+	}
+}
+
+// key normalizes a name for lookup: trimmed, lower case, without long vowel
+// marks and the romaji suffixes of prefectures ("-to", " Prefecture"...)
+func key(name string) string {
+	k := strings.ToLower(strings.TrimSpace(name))
+	k = strings.NewReplacer("ō", "o", "ô", "o", "ū", "u", "û", "u", "　", " ").Replace(k)
+	for _, suffix := range []string{" prefecture", " region", " area"} {
+		k = strings.TrimSuffix(k, suffix)
+	}
+	for _, suffix := range []string{"to", "do", "fu", "ken", "chiho"} {
+		for _, sep := range []string{"-", " "} {
+			k = strings.TrimSuffix(k, sep+suffix)
+		}
+	}
+	return k
+}
+
+// Lookup returns the prefecture with the given name in any supported spelling
+func Lookup(name string) (Prefecture, bool) {
+	i, ok := prefectureIndex[key(name)]
+	if !ok {
+		return Prefecture{}, false
+	}
+	return prefectures[i], true
+}
+
+// ByCode returns the prefecture with a JIS code
+func ByCode(code int) (Prefecture, bool) {
+	if code < 1 || code > len(prefectures) {
+		return Prefecture{}, false
+	}
+	return prefectures[code-1], true
+}
+
+// Normalize returns the full name of a prefecture in any supported spelling,
+// e.g. "東京都" for "Tokyo"
+func Normalize(name string) (string, bool) {
+	p, ok := Lookup(name)
+	return p.Name, ok
+}
+
+// Code returns the JIS code of a prefecture in any supported spelling, or 0
+func Code(name string) int {
+	p, _ := Lookup(name)
+	return p.Code
+}
+
+// Resolve returns the full names of the prefectures a name stands for: the
+// prefecture itself, or every prefecture of a region ("関東", "Kansai").
+// Prefectures take precedence over regions of the same name (北海道).
+func Resolve(name string) ([]string, bool) {
+	if p, ok := Lookup(name); ok {
+		return []string{p.Name}, true
+	}
+	id, ok := regionIndex[key(name)]
+	if !ok {
+		return nil, false
+	}
+	var names []string
+	for _, p := range prefectures {
+		if p.Region == id {
+			names = append(names, p.Name)
+		}
+	}
+	return names, true
+}
+
+// Expand resolves a list of prefectures and regions to the full names of
+// their prefectures, without duplicates and in the order given. It fails on
+// the first name that is neither.
+func Expand(names []string) ([]string, error) {
+	var expanded []string
+	seen := make(map[string]bool)
+	for _, name := range names {
+		resolved, ok := Resolve(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown prefecture or region: %s", name)
+		}
+		for _, n := range resolved {
+			if !seen[n] {
+				seen[n] = true
+				expanded = append(expanded, n)
+			}
+		}
+	}
+	return expanded, nil
+}
+
+// Regions returns the regions in north-to-south order
+func Regions() []Region {
+	copied := make([]Region, len(regions))
+	for i, r := range regions {
+		copied[i] = r
+		copied[i].Aliases = append([]string(nil), r.Aliases...)
+	}
+	return copied
+}
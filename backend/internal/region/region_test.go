@@ -0,0 +1,105 @@
+package region
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"東京都", "東京都"},
+		{"東京", "東京都"},
+		{"Tokyo", "東京都"},
+		{"tokyo-to", "東京都"},
+		{" TOKYO ", "東京都"},
+		{"Tokyo Prefecture", "東京都"},
+		{"京都", "京都府"},
+		{"Kyoto-fu", "京都府"},
+		{"北海道", "北海道"},
+		{"Hokkaidō", "北海道"},
+		{"Hyōgo-ken", "兵庫県"},
+		{"神奈川", "神奈川県"},
+		{"沖縄県", "沖縄県"},
+	}
+	for _, tt := range tests {
+		got, ok := Normalize(tt.input)
+		if !ok || got != tt.want {
+			t.Errorf("Normalize(%q) = %q, %v; want %q", tt.input, got, ok, tt.want)
+		}
+	}
+
+	for _, input := range []string{"", "東", "Tokio", "関東", "東京都２３区"} {
+		if got, ok := Normalize(input); ok {
+			t.Errorf("Normalize(%q) = %q, want unknown", input, got)
+		}
+	}
+}
+
+func TestCodes(t *testing.T) {
+	if len(prefectures) != Count {
+		t.Fatalf("%d prefectures, want %d", len(prefectures), Count)
+	}
+	for i, p := range prefectures {
+		if p.Code != i+1 {
+			t.Errorf("%s has code %d, want %d", p.Name, p.Code, i+1)
+		}
+		if got, ok := ByCode(p.Code); !ok || got != p {
+			t.Errorf("ByCode(%d) = %+v, want %s", p.Code, got, p.Name)
+		}
+	}
+	if Code("Tokyo") != 13 || Code("unknown") != 0 {
+		t.Errorf("Code() = %d/%d, want 13/0", Code("Tokyo"), Code("unknown"))
+	}
+	if _, ok := ByCode(48); ok {
+		t.Error("ByCode(48) found a prefecture")
+	}
+}
+
+func TestResolve(t *testing.T) {
+	kanto := []string{"茨城県", "栃木県", "群馬県", "埼玉県", "千葉県", "東京都", "神奈川県"}
+	for _, name := range []string{"関東", "関東地方", "Kanto", "kanto region"} {
+		if got, ok := Resolve(name); !ok || !reflect.DeepEqual(got, kanto) {
+			t.Errorf("Resolve(%q) = %v, want Kanto", name, got)
+		}
+	}
+	if got, _ := Resolve("Kansai"); len(got) != 7 || got[0] != "三重県" {
+		t.Errorf("Resolve(Kansai) = %v, want the 7 prefectures of Kinki", got)
+	}
+	if got, _ := Resolve("北海道"); !reflect.DeepEqual(got, []string{"北海道"}) {
+		t.Errorf("Resolve(北海道) = %v, want the prefecture", got)
+	}
+
+	// Every prefecture belongs to one region
+	seen := 0
+	for _, r := range Regions() {
+		names, ok := Resolve(r.ID)
+		if !ok || len(names) == 0 {
+			t.Errorf("region %s has no prefectures", r.ID)
+		}
+		seen += len(names)
+	}
+	if seen != Count {
+		t.Errorf("regions cover %d prefectures, want %d", seen, Count)
+	}
+}
+
+func TestExpand(t *testing.T) {
+	got, err := Expand([]string{"Tokyo", "関東", "Osaka"})
+	if err != nil {
+		t.Fatalf("Expand() error = %v", err)
+	}
+	if len(got) != 8 || got[0] != "東京都" || got[1] != "茨城県" || got[7] != "大阪府" {
+		t.Errorf("Expand() = %v, want Tokyo, the rest of Kanto and Osaka without duplicates", got)
+	}
+
+	if _, err := Expand([]string{"Tokyo", "Atlantis"}); err == nil || !strings.Contains(err.Error(), "Atlantis") {
+		t.Errorf("Expand() error = %v, want unknown Atlantis", err)
+	}
+	if got, err := Expand(nil); err != nil || got != nil {
+		t.Errorf("Expand(nil) = %v, %v; want nil", got, err)
+	}
+}
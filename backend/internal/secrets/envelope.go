@@ -0,0 +1,194 @@
+package secrets
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// sealedPrefix marks values sealed by an Envelope; the version allows changing the format
+const sealedPrefix = "enc:v1:"
+
+// dataKeySize is the size of AES-256 data keys
+const dataKeySize = 32
+
+// KeyWrapper encrypts data keys with a key encryption key that never leaves
+// its keeper (e.g. Cloud KMS)
+type KeyWrapper interface {
+	WrapKey(ctx context.Context, key []byte) ([]byte, error)
+	UnwrapKey(ctx context.Context, wrapped []byte) ([]byte, error)
+}
+
+// Envelope seals values with AES-256-GCM under a data key that is stored,
+// wrapped by a KeyWrapper, next to each value.
+//
+// A process seals everything with one data key, and unwrapped data keys are
+// cached, so the KeyWrapper is called once per data key rather than per value.
+type Envelope struct {
+	wrapper KeyWrapper
+
+	mu      sync.Mutex
+	current *dataKey
+	keys    map[string]cipher.AEAD // Unwrapped data keys by wrapped key
+}
+
+// dataKey is the data key new values are sealed with
+type dataKey struct {
+	aead    cipher.AEAD
+	wrapped string // base64
+}
+
+// NewEnvelope creates an Envelope
+func NewEnvelope(wrapper KeyWrapper) *Envelope {
+	return &Envelope{wrapper: wrapper, keys: make(map[string]cipher.AEAD)}
+}
+
+// IsSealed reports whether s was sealed by an Envelope
+func IsSealed(s string) bool {
+	return strings.HasPrefix(s, sealedPrefix)
+}
+
+// Seal encrypts plaintext. The result is "enc:v1:<wrapped data key>:<ciphertext>".
+func (e *Envelope) Seal(ctx context.Context, plaintext string) (string, error) {
+	key, err := e.dataKey(ctx)
+	if err != nil {
+		return "", err
+	}
+	return sealedPrefix + key.wrapped + ":" + base64.RawStdEncoding.EncodeToString(seal(key.aead, []byte(plaintext))), nil
+}
+
+// Open decrypts a value sealed by Seal
+func (e *Envelope) Open(ctx context.Context, sealed string) (string, error) {
+	wrapped, ciphertext, ok := strings.Cut(strings.TrimPrefix(sealed, sealedPrefix), ":")
+	if !IsSealed(sealed) || !ok {
+		return "", errors.New("not a sealed value")
+	}
+	aead, err := e.unwrap(ctx, wrapped)
+	if err != nil {
+		return "", err
+	}
+	data, err := base64.RawStdEncoding.DecodeString(ciphertext)
+	if err != nil {
+		return "", fmt.Errorf("invalid ciphertext: %w", err)
+	}
+	plaintext, err := open(aead, data)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// dataKey returns the current data key, generating and wrapping it on first use
+func (e *Envelope) dataKey(ctx context.Context) (*dataKey, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.current != nil {
+		return e.current, nil
+	}
+
+	key := make([]byte, dataKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("failed to generate data key: %w", err)
+	}
+	wrapped, err := e.wrapper.WrapKey(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to wrap data key: %w", err)
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	e.current = &dataKey{aead: aead, wrapped: base64.RawStdEncoding.EncodeToString(wrapped)}
+	e.keys[e.current.wrapped] = aead
+	return e.current, nil
+}
+
+// unwrap returns the data key of a wrapped key
+func (e *Envelope) unwrap(ctx context.Context, wrapped string) (cipher.AEAD, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if aead, ok := e.keys[wrapped]; ok {
+		return aead, nil
+	}
+
+	data, err := base64.RawStdEncoding.DecodeString(wrapped)
+	if err != nil {
+		return nil, fmt.Errorf("invalid data key: %w", err)
+	}
+	key, err := e.wrapper.UnwrapKey(ctx, data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap data key: %w", err)
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	e.keys[wrapped] = aead
+	return aead, nil
+}
+
+// LocalKeyWrapper wraps data keys with AES-256-GCM under a key held in
+// configuration, for deployments without Cloud KMS
+type LocalKeyWrapper struct {
+	aead cipher.AEAD
+}
+
+// Compile-time interface check
+var _ KeyWrapper = (*LocalKeyWrapper)(nil)
+
+// NewLocalKeyWrapper creates a LocalKeyWrapper with a 32-byte key
+func NewLocalKeyWrapper(key []byte) (*LocalKeyWrapper, error) {
+	if len(key) != dataKeySize {
+		return nil, fmt.Errorf("key must be %d bytes, got %d", dataKeySize, len(key))
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	return &LocalKeyWrapper{aead: aead}, nil
+}
+
+// WrapKey encrypts a data key
+func (w *LocalKeyWrapper) WrapKey(_ context.Context, key []byte) ([]byte, error) {
+	return seal(w.aead, key), nil
+}
+
+// UnwrapKey decrypts a data key
+func (w *LocalKeyWrapper) UnwrapKey(_ context.Context, wrapped []byte) ([]byte, error) {
+	return open(w.aead, wrapped)
+}
+
+// newAEAD returns AES-GCM with a 32-byte key
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid key: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal encrypts plaintext with a random nonce, prepended to the result
+func seal(aead cipher.AEAD, plaintext []byte) []byte {
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	// crypto/rand.Read never fails on supported platforms
+	_, _ = rand.Read(nonce)
+	return aead.Seal(nonce, nonce, plaintext, nil)
+}
+
+// open decrypts the output of seal
+func open(aead cipher.AEAD, data []byte) ([]byte, error) {
+	if len(data) < aead.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+	plaintext, err := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], nil)
+	if err != nil {
+		return nil, errors.New("decryption failed")
+	}
+	return plaintext, nil
+}
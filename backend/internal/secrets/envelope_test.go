@@ -0,0 +1,119 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// countingWrapper counts the calls to a LocalKeyWrapper
+type countingWrapper struct {
+	*LocalKeyWrapper
+	wraps, unwraps int
+	fail           bool
+}
+
+func newCountingWrapper(t *testing.T) *countingWrapper {
+	t.Helper()
+	w, err := NewLocalKeyWrapper(bytes.Repeat([]byte{7}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &countingWrapper{LocalKeyWrapper: w}
+}
+
+func (w *countingWrapper) WrapKey(ctx context.Context, key []byte) ([]byte, error) {
+	w.wraps++
+	if w.fail {
+		return nil, errors.New("unavailable")
+	}
+	return w.LocalKeyWrapper.WrapKey(ctx, key)
+}
+
+func (w *countingWrapper) UnwrapKey(ctx context.Context, wrapped []byte) ([]byte, error) {
+	w.unwraps++
+	return w.LocalKeyWrapper.UnwrapKey(ctx, wrapped)
+}
+
+func TestEnvelope_SealOpen(t *testing.T) {
+	ctx := context.Background()
+	wrapper := newCountingWrapper(t)
+	env := NewEnvelope(wrapper)
+
+	sealed := make([]string, 0, 3)
+	for _, s := range []string{"whsec_first", "whsec_second", ""} {
+		v, err := env.Seal(ctx, s)
+		if err != nil {
+			t.Fatalf("Seal() error = %v", err)
+		}
+		if !IsSealed(v) || strings.Contains(v, "whsec") {
+			t.Errorf("Seal() = %q, want an opaque sealed value", v)
+		}
+		sealed = append(sealed, v)
+	}
+	if sealed[0] == sealed[1] {
+		t.Error("Seal() should not be deterministic")
+	}
+	if wrapper.wraps != 1 {
+		t.Errorf("WrapKey() called %d times, want once per process", wrapper.wraps)
+	}
+
+	// Another instance unwraps the data key once and caches it
+	other := NewEnvelope(wrapper)
+	for i, want := range []string{"whsec_first", "whsec_second", ""} {
+		got, err := other.Open(ctx, sealed[i])
+		if err != nil {
+			t.Fatalf("Open() error = %v", err)
+		}
+		if got != want {
+			t.Errorf("Open() = %q, want %q", got, want)
+		}
+	}
+	if wrapper.unwraps != 1 {
+		t.Errorf("UnwrapKey() called %d times, want 1", wrapper.unwraps)
+	}
+}
+
+func TestEnvelope_OpenRejects(t *testing.T) {
+	ctx := context.Background()
+	env := NewEnvelope(newCountingWrapper(t))
+	sealed, err := env.Seal(ctx, "whsec_xxx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tampered := sealed[:len(sealed)-2] + "AA"
+	if tampered == sealed {
+		tampered = sealed[:len(sealed)-2] + "BB"
+	}
+
+	otherKey, _ := NewLocalKeyWrapper(bytes.Repeat([]byte{8}, 32))
+	for name, tt := range map[string]struct {
+		env   *Envelope
+		value string
+	}{
+		"plaintext": {env, "whsec_xxx"},
+		"tampered":  {env, tampered},
+		"truncated": {env, strings.SplitN(sealed, ":", 3)[0] + ":" + strings.SplitN(sealed, ":", 3)[1] + ":"},
+		"other key": {NewEnvelope(otherKey), sealed},
+	} {
+		if _, err := tt.env.Open(ctx, tt.value); err == nil {
+			t.Errorf("%s: Open() expected error", name)
+		}
+	}
+}
+
+func TestEnvelope_WrapFailure(t *testing.T) {
+	wrapper := newCountingWrapper(t)
+	wrapper.fail = true
+	if _, err := NewEnvelope(wrapper).Seal(context.Background(), "whsec_xxx"); err == nil {
+		t.Error("Seal() expected error when the data key cannot be wrapped")
+	}
+}
+
+func TestNewLocalKeyWrapper(t *testing.T) {
+	if _, err := NewLocalKeyWrapper(make([]byte, 16)); err == nil {
+		t.Error("NewLocalKeyWrapper() expected error for a 16-byte key")
+	}
+}
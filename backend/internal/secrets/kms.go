@@ -0,0 +1,75 @@
+package secrets
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"sync"
+
+	cloudkms "google.golang.org/api/cloudkms/v1"
+	"google.golang.org/api/option"
+)
+
+// KMSKeyWrapper wraps data keys with a Cloud KMS symmetric key.
+// The client is created on first use.
+type KMSKeyWrapper struct {
+	keyName string
+	opts    []option.ClientOption
+
+	mu  sync.Mutex
+	svc *cloudkms.Service
+}
+
+// Compile-time interface check
+var _ KeyWrapper = (*KMSKeyWrapper)(nil)
+
+// NewKMSKeyWrapper creates a KMSKeyWrapper for a key named
+// "projects/PROJECT/locations/LOCATION/keyRings/RING/cryptoKeys/KEY".
+// Without options, Application Default Credentials are used.
+func NewKMSKeyWrapper(keyName string, opts ...option.ClientOption) *KMSKeyWrapper {
+	return &KMSKeyWrapper{keyName: keyName, opts: opts}
+}
+
+// WrapKey encrypts a data key with the KMS key
+func (w *KMSKeyWrapper) WrapKey(ctx context.Context, key []byte) ([]byte, error) {
+	svc, err := w.service(ctx)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := svc.Projects.Locations.KeyRings.CryptoKeys.Encrypt(w.keyName, &cloudkms.EncryptRequest{
+		Plaintext: base64.StdEncoding.EncodeToString(key),
+	}).Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("kms encrypt: %w", err)
+	}
+	return base64.StdEncoding.DecodeString(resp.Ciphertext)
+}
+
+// UnwrapKey decrypts a data key with the KMS key
+func (w *KMSKeyWrapper) UnwrapKey(ctx context.Context, wrapped []byte) ([]byte, error) {
+	svc, err := w.service(ctx)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := svc.Projects.Locations.KeyRings.CryptoKeys.Decrypt(w.keyName, &cloudkms.DecryptRequest{
+		Ciphertext: base64.StdEncoding.EncodeToString(wrapped),
+	}).Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("kms decrypt: %w", err)
+	}
+	return base64.StdEncoding.DecodeString(resp.Plaintext)
+}
+
+// service returns the Cloud KMS client, creating it on first use
+func (w *KMSKeyWrapper) service(ctx context.Context) (*cloudkms.Service, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.svc == nil {
+		svc, err := cloudkms.NewService(ctx, w.opts...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create kms client: %w", err)
+		}
+		w.svc = svc
+	}
+	return w.svc, nil
+}
@@ -0,0 +1,62 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"google.golang.org/api/option"
+)
+
+func TestKMSKeyWrapper(t *testing.T) {
+	const keyName = "projects/namazu/locations/global/keyRings/namazu/cryptoKeys/secrets"
+	// The fake KMS "encrypts" by prefixing the plaintext
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/" + keyName + ":encrypt":
+			plaintext, _ := base64.StdEncoding.DecodeString(req["plaintext"])
+			ciphertext := base64.StdEncoding.EncodeToString(append([]byte("kms:"), plaintext...))
+			_ = json.NewEncoder(w).Encode(map[string]string{"ciphertext": ciphertext})
+		case "/v1/" + keyName + ":decrypt":
+			ciphertext, _ := base64.StdEncoding.DecodeString(req["ciphertext"])
+			if !bytes.HasPrefix(ciphertext, []byte("kms:")) {
+				http.Error(w, `{"error": {"code": 400, "message": "bad ciphertext"}}`, http.StatusBadRequest)
+				return
+			}
+			plaintext := base64.StdEncoding.EncodeToString(ciphertext[len("kms:"):])
+			_ = json.NewEncoder(w).Encode(map[string]string{"plaintext": plaintext})
+		default:
+			http.Error(w, `{"error": {"code": 404, "message": "not found"}}`, http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	wrapper := NewKMSKeyWrapper(keyName, option.WithEndpoint(srv.URL+"/"), option.WithoutAuthentication())
+	env := NewEnvelope(wrapper)
+	sealed, err := env.Seal(ctx, "whsec_xxx")
+	if err != nil {
+		t.Fatalf("Seal() error = %v", err)
+	}
+	got, err := NewEnvelope(wrapper).Open(ctx, sealed)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if got != "whsec_xxx" {
+		t.Errorf("Open() = %q, want whsec_xxx", got)
+	}
+
+	missing := NewKMSKeyWrapper(keyName+"-missing", option.WithEndpoint(srv.URL+"/"), option.WithoutAuthentication())
+	if _, err := missing.WrapKey(ctx, []byte("key")); err == nil {
+		t.Error("WrapKey() expected error for an unknown key")
+	}
+}
@@ -0,0 +1,106 @@
+// Package secrets keeps sensitive values out of plain configuration and
+// storage: configuration values can reference secrets in GCP Secret Manager,
+// and stored secrets can be sealed with envelope encryption under a Cloud KMS key.
+package secrets
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"sync"
+
+	"google.golang.org/api/option"
+	secretmanager "google.golang.org/api/secretmanager/v1"
+)
+
+// Scheme prefixes secret references, e.g. "sm://projects/namazu/secrets/stripe-key".
+// The latest version is used unless the reference ends with "/versions/VERSION".
+const Scheme = "sm://"
+
+// Provider returns the value a secret reference points to
+type Provider interface {
+	Resolve(ctx context.Context, ref string) (string, error)
+}
+
+// IsRef reports whether s is a secret reference
+func IsRef(s string) bool {
+	return strings.HasPrefix(s, Scheme)
+}
+
+// SecretManager resolves references with GCP Secret Manager.
+// The client is created on first use, so constructing a SecretManager needs no credentials.
+type SecretManager struct {
+	opts []option.ClientOption
+
+	mu  sync.Mutex
+	svc *secretmanager.Service
+}
+
+// Compile-time interface check
+var _ Provider = (*SecretManager)(nil)
+
+// NewSecretManager creates a SecretManager.
+// Without options, Application Default Credentials are used.
+func NewSecretManager(opts ...option.ClientOption) *SecretManager {
+	return &SecretManager{opts: opts}
+}
+
+// Resolve returns the payload of the secret version a reference points to
+func (s *SecretManager) Resolve(ctx context.Context, ref string) (string, error) {
+	name, err := versionName(ref)
+	if err != nil {
+		return "", err
+	}
+	svc, err := s.service(ctx)
+	if err != nil {
+		return "", err
+	}
+	resp, err := svc.Projects.Secrets.Versions.Access(name).Context(ctx).Do()
+	if err != nil {
+		return "", fmt.Errorf("failed to access secret %s: %w", name, err)
+	}
+	if resp.Payload == nil {
+		return "", fmt.Errorf("secret %s has no payload", name)
+	}
+	data, err := base64.StdEncoding.DecodeString(resp.Payload.Data)
+	if err != nil {
+		return "", fmt.Errorf("secret %s: invalid payload: %w", name, err)
+	}
+	return string(data), nil
+}
+
+// service returns the Secret Manager client, creating it on first use
+func (s *SecretManager) service(ctx context.Context) (*secretmanager.Service, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.svc == nil {
+		svc, err := secretmanager.NewService(ctx, s.opts...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create secret manager client: %w", err)
+		}
+		s.svc = svc
+	}
+	return s.svc, nil
+}
+
+// versionName returns the secret version resource name of a reference
+func versionName(ref string) (string, error) {
+	name, ok := strings.CutPrefix(ref, Scheme)
+	if !ok {
+		return "", fmt.Errorf("secret reference must start with %s", Scheme)
+	}
+	parts := strings.Split(name, "/")
+	valid := (len(parts) == 4 || len(parts) == 6 && parts[4] == "versions") &&
+		parts[0] == "projects" && parts[2] == "secrets"
+	for _, p := range parts {
+		valid = valid && p != ""
+	}
+	if !valid {
+		return "", fmt.Errorf("invalid secret reference %q (want %sprojects/PROJECT/secrets/NAME[/versions/VERSION])", ref, Scheme)
+	}
+	if len(parts) == 4 {
+		name += "/versions/latest"
+	}
+	return name, nil
+}
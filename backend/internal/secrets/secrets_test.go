@@ -0,0 +1,81 @@
+package secrets
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"google.golang.org/api/option"
+)
+
+func TestVersionName(t *testing.T) {
+	tests := []struct {
+		ref     string
+		want    string
+		wantErr bool
+	}{
+		{"sm://projects/namazu/secrets/stripe-key", "projects/namazu/secrets/stripe-key/versions/latest", false},
+		{"sm://projects/namazu/secrets/stripe-key/versions/3", "projects/namazu/secrets/stripe-key/versions/3", false},
+		{"projects/namazu/secrets/stripe-key", "", true},
+		{"sm://projects/namazu/stripe-key", "", true},
+		{"sm://projects//secrets/stripe-key", "", true},
+		{"sm://projects/namazu/secrets/stripe-key/versions/", "", true},
+		{"sm://projects/namazu/locations/asia-northeast1/secrets/stripe-key", "", true},
+	}
+	for _, tt := range tests {
+		got, err := versionName(tt.ref)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("versionName(%q) = %q, %v; want %q (error: %v)", tt.ref, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestIsRef(t *testing.T) {
+	if !IsRef("sm://projects/namazu/secrets/stripe-key") {
+		t.Error("IsRef() = false for an sm:// reference")
+	}
+	if IsRef("sk_live_xxx") {
+		t.Error("IsRef() = true for a plain value")
+	}
+}
+
+func TestSecretManager_Resolve(t *testing.T) {
+	var accessed []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		accessed = append(accessed, r.URL.Path)
+		if r.URL.Path != "/v1/projects/namazu/secrets/stripe-key/versions/latest:access" {
+			http.Error(w, `{"error": {"code": 404, "message": "not found"}}`, http.StatusNotFound)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"name":    "projects/namazu/secrets/stripe-key/versions/2",
+			"payload": map[string]string{"data": base64.StdEncoding.EncodeToString([]byte("sk_live_xxx"))},
+		})
+	}))
+	defer srv.Close()
+
+	sm := NewSecretManager(option.WithEndpoint(srv.URL+"/"), option.WithoutAuthentication())
+	ctx := context.Background()
+
+	got, err := sm.Resolve(ctx, "sm://projects/namazu/secrets/stripe-key")
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	if got != "sk_live_xxx" {
+		t.Errorf("Resolve() = %q, want sk_live_xxx", got)
+	}
+
+	if _, err := sm.Resolve(ctx, "sm://projects/namazu/secrets/missing"); err == nil || !strings.Contains(err.Error(), "projects/namazu/secrets/missing/versions/latest") {
+		t.Errorf("Resolve() error = %v, want a failed access of the missing secret", err)
+	}
+	if _, err := sm.Resolve(ctx, "sm://invalid"); err == nil {
+		t.Error("Resolve() expected error for an invalid reference")
+	}
+	if len(accessed) != 2 {
+		t.Errorf("accessed %v, want 2 requests", accessed)
+	}
+}
@@ -43,6 +43,64 @@ func IsPrivateIP(ipStr string) bool {
 	return false
 }
 
+// reservedNets are ranges that are not private but are never a public webhook receiver
+var reservedNets = mustParseCIDRs(
+	"0.0.0.0/8",     // "This" network
+	"100.64.0.0/10", // Carrier-grade NAT (RFC 6598)
+	"192.0.0.0/24",  // IETF protocol assignments
+	"198.18.0.0/15", // Benchmarking (RFC 2544)
+	"240.0.0.0/4",   // Reserved, including broadcast
+	"64:ff9b::/96",  // NAT64, which maps to arbitrary IPv4 addresses
+)
+
+// metadataHosts are the hostnames of cloud metadata servers
+var metadataHosts = map[string]bool{
+	"metadata":                 true,
+	"metadata.google.internal": true,
+}
+
+func mustParseCIDRs(cidrs ...string) []*net.IPNet {
+	nets := make([]*net.IPNet, len(cidrs))
+	for i, c := range cidrs {
+		_, n, err := net.ParseCIDR(c)
+		if err != nil {
+			panic(err)
+		}
+		nets[i] = n
+	}
+	return nets
+}
+
+// IsBlockedIP checks if webhooks must not connect to ip: everything IsPrivateIP
+// reports, plus unspecified, multicast and reserved addresses. The GCP metadata
+// server (169.254.169.254) is link-local and therefore blocked.
+func IsBlockedIP(ip net.IP) bool {
+	if ip == nil {
+		return true
+	}
+	if IsPrivateIP(ip.String()) || ip.IsUnspecified() || ip.IsMulticast() || ip.IsInterfaceLocalMulticast() {
+		return true
+	}
+	for _, n := range reservedNets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckIP returns an error if webhooks must not connect to ip.
+// If allowLocal is true, loopback addresses are permitted (development mode).
+func CheckIP(ip net.IP, allowLocal bool) error {
+	if allowLocal && ip.IsLoopback() {
+		return nil
+	}
+	if IsBlockedIP(ip) {
+		return fmt.Errorf("%s is a private or reserved IP address", ip)
+	}
+	return nil
+}
+
 // IsLocalhost checks if the given host is localhost.
 // Accepts: "localhost", "127.0.0.1", "::1", "[::1]", "0.0.0.0"
 func IsLocalhost(host string) bool {
@@ -116,9 +174,13 @@ func ValidateWebhookURL(urlStr string, allowLocal bool) error {
 		return fmt.Errorf("localhost URLs are not allowed")
 	}
 
+	if metadataHosts[strings.TrimSuffix(strings.ToLower(host), ".")] {
+		return fmt.Errorf("metadata server URLs are not allowed")
+	}
+
 	// Check for private IP addresses
 	ip := net.ParseIP(host)
-	if ip != nil && IsPrivateIP(host) {
+	if ip != nil && IsBlockedIP(ip) {
 		// Allow localhost if explicitly permitted
 		if isLocal && allowLocal {
 			return nil
@@ -1,6 +1,7 @@
 package security
 
 import (
+	"net"
 	"testing"
 )
 
@@ -63,6 +64,61 @@ func TestIsPrivateIP(t *testing.T) {
 	}
 }
 
+func TestIsBlockedIP(t *testing.T) {
+	tests := []struct {
+		ip       string
+		expected bool
+	}{
+		{"10.0.0.1", true},
+		{"127.0.0.1", true},
+		{"169.254.169.254", true},
+		{"0.0.0.0", true},
+		{"0.1.2.3", true},
+		{"100.64.0.1", true},
+		{"100.127.255.255", true},
+		{"198.18.0.1", true},
+		{"224.0.0.1", true},
+		{"255.255.255.255", true},
+		{"::", true},
+		{"fe80::1", true},
+		{"fd00:ec2::254", true},
+		{"::ffff:10.0.0.1", true},
+		{"::ffff:169.254.169.254", true},
+		{"64:ff9b::a9fe:a9fe", true},
+		{"ff02::1", true},
+		{"8.8.8.8", false},
+		{"100.128.0.1", false},
+		{"203.0.113.1", false},
+		{"2001:4860:4860::8888", false},
+	}
+
+	for _, tt := range tests {
+		if got := IsBlockedIP(net.ParseIP(tt.ip)); got != tt.expected {
+			t.Errorf("IsBlockedIP(%s) = %v, expected %v", tt.ip, got, tt.expected)
+		}
+	}
+	if !IsBlockedIP(nil) {
+		t.Error("IsBlockedIP(nil) should be true")
+	}
+}
+
+func TestCheckIP(t *testing.T) {
+	if err := CheckIP(net.ParseIP("127.0.0.1"), true); err != nil {
+		t.Errorf("CheckIP() of loopback in development mode error = %v", err)
+	}
+	for _, ip := range []string{"127.0.0.1", "169.254.169.254"} {
+		if err := CheckIP(net.ParseIP(ip), false); err == nil {
+			t.Errorf("CheckIP(%s) expected error", ip)
+		}
+	}
+	if err := CheckIP(net.ParseIP("10.0.0.1"), true); err == nil {
+		t.Error("CheckIP() of a private IP in development mode expected error")
+	}
+	if err := CheckIP(net.ParseIP("93.184.216.34"), false); err != nil {
+		t.Errorf("CheckIP() of a public IP error = %v", err)
+	}
+}
+
 func TestIsLocalhost(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -1,8 +1,25 @@
 package security
 
-// WebhookURLValidator validates webhook URLs for SSRF and HTTPS enforcement
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"time"
+)
+
+// lookupTimeout bounds the DNS lookup of a webhook host during validation
+const lookupTimeout = 5 * time.Second
+
+// WebhookURLValidator validates webhook URLs for SSRF and HTTPS enforcement.
+//
+// Besides the checks of ValidateWebhookURL, it resolves the host and rejects
+// URLs whose addresses include a private or reserved IP. A host can change its
+// DNS answer after validation (DNS rebinding), so deliveries must also check
+// the address they actually dial; see CheckIP.
 type WebhookURLValidator struct {
 	allowLocalhost bool
+	lookup         func(ctx context.Context, host string) ([]net.IPAddr, error)
 }
 
 // NewWebhookURLValidator creates a new webhook URL validator
@@ -10,11 +27,37 @@ type WebhookURLValidator struct {
 func NewWebhookURLValidator(allowLocalhost bool) *WebhookURLValidator {
 	return &WebhookURLValidator{
 		allowLocalhost: allowLocalhost,
+		lookup:         net.DefaultResolver.LookupIPAddr,
 	}
 }
 
 // ValidateWebhookURL validates a webhook URL
 // Returns nil if the URL is valid, or an error describing the issue
-func (v *WebhookURLValidator) ValidateWebhookURL(url string) error {
-	return ValidateWebhookURL(url, v.allowLocalhost)
+func (v *WebhookURLValidator) ValidateWebhookURL(rawURL string) error {
+	if err := ValidateWebhookURL(rawURL, v.allowLocalhost); err != nil {
+		return err
+	}
+
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("invalid URL: %w", err)
+	}
+	host := ExtractHostWithoutPort(parsed.Host)
+	if net.ParseIP(host) != nil || (v.allowLocalhost && IsLocalhost(host)) {
+		// Checked above
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), lookupTimeout)
+	defer cancel()
+	addrs, err := v.lookup(ctx, host)
+	if err != nil {
+		return fmt.Errorf("cannot resolve host %q", host)
+	}
+	for _, a := range addrs {
+		if err := CheckIP(a.IP, v.allowLocalhost); err != nil {
+			return fmt.Errorf("host %q resolves to a private or reserved IP address", host)
+		}
+	}
+	return nil
 }
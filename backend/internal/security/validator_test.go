@@ -1,12 +1,34 @@
 package security
 
 import (
+	"context"
+	"errors"
+	"net"
 	"testing"
 )
 
+// fakeLookup resolves hosts from a fixed table
+func fakeLookup(table map[string][]string) func(ctx context.Context, host string) ([]net.IPAddr, error) {
+	return func(ctx context.Context, host string) ([]net.IPAddr, error) {
+		ips, ok := table[host]
+		if !ok {
+			return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+		}
+		addrs := make([]net.IPAddr, len(ips))
+		for i, ip := range ips {
+			addrs[i] = net.IPAddr{IP: net.ParseIP(ip)}
+		}
+		return addrs, nil
+	}
+}
+
+// publicLookup resolves example.com to a public address
+var publicLookup = fakeLookup(map[string][]string{"example.com": {"93.184.216.34"}})
+
 func TestWebhookURLValidator_ValidateWebhookURL(t *testing.T) {
 	t.Run("production mode (allowLocalhost=false)", func(t *testing.T) {
 		validator := NewWebhookURLValidator(false)
+		validator.lookup = publicLookup
 
 		tests := []struct {
 			name        string
@@ -37,6 +59,7 @@ func TestWebhookURLValidator_ValidateWebhookURL(t *testing.T) {
 
 	t.Run("development mode (allowLocalhost=true)", func(t *testing.T) {
 		validator := NewWebhookURLValidator(true)
+		validator.lookup = publicLookup
 
 		tests := []struct {
 			name        string
@@ -65,3 +88,50 @@ func TestWebhookURLValidator_ValidateWebhookURL(t *testing.T) {
 		}
 	})
 }
+
+func TestWebhookURLValidator_ResolvesHost(t *testing.T) {
+	tests := []struct {
+		name        string
+		url         string
+		allowLocal  bool
+		expectError bool
+	}{
+		{"public answer", "https://hooks.example.com/webhook", false, false},
+		{"private answer", "https://internal.example.com/webhook", false, true},
+		{"metadata answer", "https://rebind.example.com/webhook", false, true},
+		{"one private answer among public ones", "https://mixed.example.com/webhook", false, true},
+		{"CGNAT answer", "https://cgnat.example.com/webhook", false, true},
+		{"unresolvable host", "https://missing.example.com/webhook", false, true},
+		{"metadata hostname", "https://metadata.google.internal/computeMetadata/v1/", false, true},
+		{"loopback answer in development mode", "https://dev.example.com/webhook", true, false},
+		{"loopback answer in production", "https://dev.example.com/webhook", false, true},
+	}
+
+	lookup := fakeLookup(map[string][]string{
+		"hooks.example.com":    {"93.184.216.34", "2606:2800:220:1:248:1893:25c8:1946"},
+		"internal.example.com": {"10.0.0.5"},
+		"rebind.example.com":   {"169.254.169.254"},
+		"mixed.example.com":    {"93.184.216.34", "192.168.1.1"},
+		"cgnat.example.com":    {"100.64.0.1"},
+		"dev.example.com":      {"127.0.0.1"},
+	})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			validator := NewWebhookURLValidator(tt.allowLocal)
+			validator.lookup = lookup
+			err := validator.ValidateWebhookURL(tt.url)
+			if (err != nil) != tt.expectError {
+				t.Errorf("ValidateWebhookURL(%q) error = %v, expectError %v", tt.url, err, tt.expectError)
+			}
+		})
+	}
+
+	// IP literals are not looked up
+	validator := NewWebhookURLValidator(false)
+	validator.lookup = func(ctx context.Context, host string) ([]net.IPAddr, error) {
+		return nil, errors.New("unexpected lookup")
+	}
+	if err := validator.ValidateWebhookURL("https://93.184.216.34/webhook"); err != nil {
+		t.Errorf("ValidateWebhookURL() with a public IP error = %v", err)
+	}
+}
@@ -0,0 +1,78 @@
+// Package snippet generates receiver code that verifies namazu webhook
+// signatures and answers URL verification challenges.
+package snippet
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"strings"
+	"text/template"
+)
+
+// ErrUnsupportedLanguage is returned for languages without a template
+var ErrUnsupportedLanguage = errors.New("unsupported language")
+
+// Languages lists the supported snippet languages
+var Languages = []string{"go", "node", "python"}
+
+// filenames maps each language to the file name the snippet is meant to be saved as
+var filenames = map[string]string{
+	"go":     "main.go",
+	"node":   "server.js",
+	"python": "server.py",
+}
+
+//go:embed templates/*.tmpl
+var templateFS embed.FS
+
+var templates = template.Must(template.ParseFS(templateFS, "templates/*.tmpl"))
+
+// Params describes the subscription a snippet is generated for
+type Params struct {
+	SubscriptionID string
+	Name           string
+	SignVersion    string // "v0" for timestamped signatures, empty for legacy
+	SecretPrefix   string // Shown as a hint so the right secret is configured
+}
+
+// Timestamped reports whether deliveries carry X-Signature-Timestamp
+func (p Params) Timestamped() bool {
+	return p.SignVersion == "v0"
+}
+
+// Scheme returns a short description of the signature scheme
+func (p Params) Scheme() string {
+	if p.Timestamped() {
+		return `v0 (X-Signature-256: "v0=" + HMAC-SHA256(secret, "v0:{timestamp}:{body}"), X-Signature-Timestamp: unix seconds)`
+	}
+	return `legacy (X-Signature-256: "sha256=" + HMAC-SHA256(secret, body))`
+}
+
+// Filename returns the suggested file name for a language
+func Filename(lang string) string {
+	return filenames[lang]
+}
+
+// Render generates a receiver snippet in the given language
+func Render(lang string, p Params) (string, error) {
+	if _, ok := filenames[lang]; !ok {
+		return "", ErrUnsupportedLanguage
+	}
+
+	// Values end up in single-line comments; keep them on one line
+	p.Name = oneLine(p.Name)
+	p.SubscriptionID = oneLine(p.SubscriptionID)
+	p.SecretPrefix = oneLine(p.SecretPrefix)
+
+	var buf bytes.Buffer
+	if err := templates.ExecuteTemplate(&buf, lang+".tmpl", p); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// oneLine replaces line breaks so a value cannot escape a comment
+func oneLine(s string) string {
+	return strings.NewReplacer("\r", " ", "\n", " ").Replace(s)
+}
@@ -0,0 +1,68 @@
+package snippet
+
+import (
+	"errors"
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+)
+
+func TestRender_AllLanguages(t *testing.T) {
+	for _, version := range []string{"", "v0"} {
+		for _, lang := range Languages {
+			t.Run(lang+"/"+version, func(t *testing.T) {
+				code, err := Render(lang, Params{SubscriptionID: "sub-1", Name: "prod alerts", SignVersion: version, SecretPrefix: "abcd1234"})
+				if err != nil {
+					t.Fatalf("Render() error = %v", err)
+				}
+				for _, want := range []string{"prod alerts", "sub-1", "abcd1234", "url_verification", "NAMAZU_WEBHOOK_SECRET"} {
+					if !strings.Contains(code, want) {
+						t.Errorf("expected snippet to contain %q", want)
+					}
+				}
+				if strings.Contains(code, "<no value>") {
+					t.Error("snippet contains unresolved template values")
+				}
+				hasV0 := strings.Contains(code, `"v0="`) || strings.Contains(code, "X-Signature-Timestamp\")") || strings.Contains(code, "x-signature-timestamp")
+				if hasV0 != (version == "v0") {
+					t.Errorf("timestamped verification present = %v, want %v", hasV0, version == "v0")
+				}
+			})
+		}
+	}
+}
+
+func TestRender_GoSnippetParses(t *testing.T) {
+	for _, version := range []string{"", "v0"} {
+		code, err := Render("go", Params{SubscriptionID: "sub-1", Name: "prod", SignVersion: version})
+		if err != nil {
+			t.Fatalf("Render() error = %v", err)
+		}
+		if _, err := parser.ParseFile(token.NewFileSet(), "main.go", code, parser.AllErrors); err != nil {
+			t.Errorf("generated Go code (sign version %q) does not parse: %v", version, err)
+		}
+	}
+}
+
+func TestRender_NameCannotEscapeComment(t *testing.T) {
+	code, err := Render("python", Params{SubscriptionID: "sub-1", Name: "evil\nimport os; os.system('x')"})
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if strings.Contains(code, "\nimport os; os.system") {
+		t.Error("line breaks in the name must not start a new line of code")
+	}
+}
+
+func TestRender_UnsupportedLanguage(t *testing.T) {
+	if _, err := Render("cobol", Params{}); !errors.Is(err, ErrUnsupportedLanguage) {
+		t.Errorf("Render() error = %v, want ErrUnsupportedLanguage", err)
+	}
+}
+
+func TestFilename(t *testing.T) {
+	if got := Filename("node"); got != "server.js" {
+		t.Errorf("Filename(node) = %q, want %q", got, "server.js")
+	}
+}
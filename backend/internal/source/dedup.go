@@ -0,0 +1,133 @@
+package source
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// Defaults for Deduplicator
+const (
+	DefaultDedupWindow     = 2 * time.Minute
+	DefaultDedupDistanceKm = 50.0
+	DefaultDedupMagnitude  = 0.5
+
+	// dedupRetention is how long forwarded events are remembered
+	dedupRetention = 10 * time.Minute
+	earthRadiusKm  = 6371.0
+)
+
+// Deduplicator detects the same report arriving from different sources.
+// Two events are duplicates when they come from different sources, have the
+// same type and severity, occurred within Window of each other, and either
+// both have hypocenters within DistanceKm and Magnitude of each other, or
+// neither has a hypocenter and their affected areas overlap.
+// Events from the same source are never duplicates: a source's follow-up
+// reports (e.g. 震度速報 then 震源・震度情報) are all delivered.
+type Deduplicator struct {
+	Window     time.Duration
+	DistanceKm float64
+	Magnitude  float64
+
+	mu   sync.Mutex
+	seen []seenEvent
+	now  func() time.Time
+}
+
+type seenEvent struct {
+	event Event
+	at    time.Time
+}
+
+// NewDeduplicator creates a Deduplicator with the default thresholds
+func NewDeduplicator() *Deduplicator {
+	return &Deduplicator{
+		Window:     DefaultDedupWindow,
+		DistanceKm: DefaultDedupDistanceKm,
+		Magnitude:  DefaultDedupMagnitude,
+		now:        time.Now,
+	}
+}
+
+// Check returns the previously seen event that e duplicates, or nil.
+// Events that are not duplicates are remembered for later checks.
+func (d *Deduplicator) Check(e Event) Event {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := d.now()
+	kept := d.seen[:0]
+	for _, s := range d.seen {
+		if now.Sub(s.at) < dedupRetention {
+			kept = append(kept, s)
+		}
+	}
+	d.seen = kept
+
+	for _, s := range d.seen {
+		if d.matches(s.event, e) {
+			return s.event
+		}
+	}
+	d.seen = append(d.seen, seenEvent{event: e, at: now})
+	return nil
+}
+
+func (d *Deduplicator) matches(a, b Event) bool {
+	if a.GetSource() == b.GetSource() || a.GetType() != b.GetType() || a.GetSeverity() != b.GetSeverity() {
+		return false
+	}
+	if dt := a.GetOccurredAt().Sub(b.GetOccurredAt()); dt > d.Window || dt < -d.Window {
+		return false
+	}
+
+	ha, hb := hypocenterOf(a), hypocenterOf(b)
+	switch {
+	case ha != nil && hb != nil:
+		if DistanceKm(ha, hb) > d.DistanceKm {
+			return false
+		}
+		if ha.Magnitude >= 0 && hb.Magnitude >= 0 && math.Abs(ha.Magnitude-hb.Magnitude) > d.Magnitude {
+			return false
+		}
+		return true
+	case ha == nil && hb == nil:
+		return areasOverlap(a.GetAffectedAreas(), b.GetAffectedAreas())
+	default:
+		// Different kinds of report (e.g. intensity-only vs. hypocenter)
+		return false
+	}
+}
+
+func hypocenterOf(e Event) *Hypocenter {
+	if l, ok := e.(Located); ok {
+		return l.GetHypocenter()
+	}
+	return nil
+}
+
+// areasOverlap reports whether the lists share an area; two empty lists overlap
+func areasOverlap(a, b []string) bool {
+	if len(a) == 0 && len(b) == 0 {
+		return true
+	}
+	set := make(map[string]bool, len(a))
+	for _, area := range a {
+		set[area] = true
+	}
+	for _, area := range b {
+		if set[area] {
+			return true
+		}
+	}
+	return false
+}
+
+// DistanceKm returns the great-circle (haversine) distance between two hypocenters
+func DistanceKm(a, b *Hypocenter) float64 {
+	lat1, lat2 := a.Latitude*math.Pi/180, b.Latitude*math.Pi/180
+	dLat := lat2 - lat1
+	dLon := (b.Longitude - a.Longitude) * math.Pi / 180
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(h))
+}
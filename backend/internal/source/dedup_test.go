@@ -0,0 +1,161 @@
+package source
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// quakeEvent is a configurable event for deduplication tests
+type quakeEvent struct {
+	mockEvent
+	source     string
+	severity   int
+	areas      []string
+	occurredAt time.Time
+	hypocenter *Hypocenter
+}
+
+func (e *quakeEvent) GetSource() string          { return e.source }
+func (e *quakeEvent) GetSeverity() int           { return e.severity }
+func (e *quakeEvent) GetAffectedAreas() []string { return e.areas }
+func (e *quakeEvent) GetOccurredAt() time.Time   { return e.occurredAt }
+func (e *quakeEvent) GetHypocenter() *Hypocenter { return e.hypocenter }
+
+var noto = time.Date(2024, 1, 1, 16, 10, 0, 0, time.UTC)
+
+func newQuake(id, src string, hypocenter *Hypocenter) *quakeEvent {
+	return &quakeEvent{
+		mockEvent:  mockEvent{id: id},
+		source:     src,
+		severity:   100,
+		areas:      []string{"石川県", "富山県"},
+		occurredAt: noto,
+		hypocenter: hypocenter,
+	}
+}
+
+func TestDeduplicator_Check(t *testing.T) {
+	base := &Hypocenter{Latitude: 37.5, Longitude: 137.3, Magnitude: 7.6}
+
+	tests := []struct {
+		name   string
+		modify func(e *quakeEvent)
+		want   bool
+	}{
+		{"same quake from another source", func(e *quakeEvent) {}, true},
+		{"slightly different hypocenter", func(e *quakeEvent) {
+			e.hypocenter = &Hypocenter{Latitude: 37.6, Longitude: 137.2, Magnitude: 7.4}
+		}, true},
+		{"unknown magnitude", func(e *quakeEvent) {
+			e.hypocenter = &Hypocenter{Latitude: 37.5, Longitude: 137.3, Magnitude: -1}
+		}, true},
+		{"same source", func(e *quakeEvent) { e.source = "p2pquake" }, false},
+		{"far away", func(e *quakeEvent) {
+			e.hypocenter = &Hypocenter{Latitude: 35.7, Longitude: 139.7, Magnitude: 7.6}
+		}, false},
+		{"different magnitude", func(e *quakeEvent) {
+			e.hypocenter = &Hypocenter{Latitude: 37.5, Longitude: 137.3, Magnitude: 6.5}
+		}, false},
+		{"different time", func(e *quakeEvent) { e.occurredAt = noto.Add(10 * time.Minute) }, false},
+		{"different severity", func(e *quakeEvent) { e.severity = 80 }, false},
+		{"intensity-only report", func(e *quakeEvent) { e.hypocenter = nil }, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := NewDeduplicator()
+			if original := d.Check(newQuake("p2p-1", "p2pquake", base)); original != nil {
+				t.Fatalf("first event reported as duplicate of %s", original.GetID())
+			}
+
+			e := newQuake("jma-1", "jma", base)
+			tt.modify(e)
+			original := d.Check(e)
+			if got := original != nil; got != tt.want {
+				t.Errorf("duplicate = %v, want %v", got, tt.want)
+			}
+			if tt.want && original.GetID() != "p2p-1" {
+				t.Errorf("original = %s, want p2p-1", original.GetID())
+			}
+		})
+	}
+}
+
+func TestDeduplicator_IntensityOnlyReports(t *testing.T) {
+	d := NewDeduplicator()
+	d.Check(newQuake("p2p-1", "p2pquake", nil))
+
+	overlapping := newQuake("jma-1", "jma", nil)
+	overlapping.occurredAt = noto.Add(90 * time.Second) // 震度速報 carries the report time
+	overlapping.areas = []string{"石川県"}
+	if d.Check(overlapping) == nil {
+		t.Error("overlapping intensity-only report not detected as duplicate")
+	}
+
+	elsewhere := newQuake("jma-2", "jma", nil)
+	elsewhere.areas = []string{"北海道"}
+	if d.Check(elsewhere) != nil {
+		t.Error("intensity-only report for other areas detected as duplicate")
+	}
+}
+
+func TestDeduplicator_ForgetsOldEvents(t *testing.T) {
+	d := NewDeduplicator()
+	now := noto
+	d.now = func() time.Time { return now }
+
+	d.Check(newQuake("p2p-1", "p2pquake", nil))
+	now = now.Add(dedupRetention)
+	if d.Check(newQuake("jma-1", "jma", nil)) != nil {
+		t.Error("event was matched after the retention period")
+	}
+	if len(d.seen) != 1 {
+		t.Errorf("seen = %d events, want 1", len(d.seen))
+	}
+}
+
+func TestMulti_DropsDuplicates(t *testing.T) {
+	a, b := newMockSource(), newMockSource()
+	m := NewMulti(a, b)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := m.Connect(ctx); err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	defer m.Close()
+
+	hypocenter := &Hypocenter{Latitude: 37.5, Longitude: 137.3, Magnitude: 7.6}
+	a.events <- newQuake("p2p-1", "p2pquake", hypocenter)
+	select {
+	case event := <-m.Events():
+		if event.GetID() != "p2p-1" {
+			t.Fatalf("event = %s, want p2p-1", event.GetID())
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for event")
+	}
+
+	b.events <- newQuake("jma-1", "jma", hypocenter)
+	b.events <- newQuake("jma-2", "jma", nil)
+	select {
+	case event := <-m.Events():
+		if event.GetID() != "jma-2" {
+			t.Errorf("event = %s, want jma-2 (jma-1 is a duplicate)", event.GetID())
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for event")
+	}
+}
+
+func TestDistanceKm(t *testing.T) {
+	tokyo := &Hypocenter{Latitude: 35.681, Longitude: 139.767}
+	osaka := &Hypocenter{Latitude: 34.702, Longitude: 135.495}
+	if d := DistanceKm(tokyo, osaka); d < 395 || d > 410 {
+		t.Errorf("DistanceKm(Tokyo, Osaka) = %.1f, want about 403", d)
+	}
+	if d := DistanceKm(tokyo, tokyo); d != 0 {
+		t.Errorf("DistanceKm(Tokyo, Tokyo) = %f, want 0", d)
+	}
+}
@@ -0,0 +1,251 @@
+package jma
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/otiai10/namazu/backend/internal/source"
+)
+
+// DefaultFeedURL is the high-frequency feed of earthquake and volcano reports
+const DefaultFeedURL = "https://www.data.jma.go.jp/developer/xml/feed/eqvol.xml"
+
+const (
+	// pollInterval is how often the feed is fetched
+	pollInterval = 30 * time.Second
+
+	// requestTimeout bounds each feed or report request
+	requestTimeout = 10 * time.Second
+
+	// maxBodySize caps the size of a feed or report
+	maxBodySize = 5 << 20
+)
+
+// Client polls the JMA Atom feed for earthquake reports
+type Client struct {
+	feedURL      string
+	httpClient   *http.Client
+	interval     time.Duration
+	events       chan source.Event
+	done         chan struct{}
+	closeOnce    sync.Once
+	mu           sync.Mutex
+	lastModified string
+	seenIDs      map[string]struct{}
+	seenIDsList  []string // for LRU eviction
+	maxSeenIDs   int
+}
+
+// NewClient creates a new JMA feed client. An empty feedURL uses DefaultFeedURL.
+func NewClient(feedURL string) *Client {
+	if feedURL == "" {
+		feedURL = DefaultFeedURL
+	}
+	return &Client{
+		feedURL:     feedURL,
+		httpClient:  &http.Client{Timeout: requestTimeout},
+		interval:    pollInterval,
+		events:      make(chan source.Event, 100),
+		done:        make(chan struct{}),
+		seenIDs:     make(map[string]struct{}),
+		seenIDsList: make([]string, 0),
+		maxSeenIDs:  1000,
+	}
+}
+
+// Connect fetches the feed once and starts polling.
+// Entries already in the feed are marked as seen and not delivered.
+func (c *Client) Connect(ctx context.Context) error {
+	feed, err := c.fetchFeed(ctx)
+	if err != nil {
+		log.Printf("Failed to fetch JMA feed: %v", err)
+		return err
+	}
+	if feed != nil {
+		for _, entry := range feed.Entries {
+			c.isDuplicate(entry.ID)
+		}
+	}
+	log.Printf("Polling JMA feed %s every %s", c.feedURL, c.interval)
+
+	go c.pollLoop(ctx)
+	return nil
+}
+
+// Events returns the channel for receiving events
+func (c *Client) Events() <-chan source.Event {
+	return c.events
+}
+
+// Close stops polling
+func (c *Client) Close() error {
+	c.closeOnce.Do(func() { close(c.done) })
+	return nil
+}
+
+// pollLoop fetches the feed on every tick
+func (c *Client) pollLoop(ctx context.Context) {
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("JMA poll loop stopped: context cancelled")
+			return
+		case <-c.done:
+			log.Println("JMA poll loop stopped: client closed")
+			return
+		case <-ticker.C:
+			c.poll(ctx)
+		}
+	}
+}
+
+// poll fetches the feed and emits new earthquake reports, oldest first
+func (c *Client) poll(ctx context.Context) {
+	feed, err := c.fetchFeed(ctx)
+	if err != nil {
+		log.Printf("Failed to fetch JMA feed: %v", err)
+		return
+	}
+	if feed == nil {
+		return // Not modified
+	}
+
+	// The feed lists the newest entries first
+	for i := len(feed.Entries) - 1; i >= 0; i-- {
+		entry := feed.Entries[i]
+		if !IsEarthquakeReport(entry.Title) || c.isSeen(entry.ID) {
+			continue
+		}
+
+		url := entry.Link.Href
+		if url == "" {
+			url = entry.ID
+		}
+		res, err := c.get(ctx, url, "")
+		if err != nil {
+			// Not marked as seen: retried on the next poll
+			log.Printf("Failed to fetch JMA report %s: %v", url, err)
+			continue
+		}
+		if c.isDuplicate(entry.ID) {
+			continue
+		}
+
+		quake, err := ParseReport(res.body, url)
+		if err != nil {
+			log.Printf("Failed to parse JMA report %s: %v", url, err)
+			continue
+		}
+		if quake == nil {
+			continue
+		}
+		quake.ReceivedAt = time.Now()
+
+		// Send to events channel (non-blocking)
+		select {
+		case c.events <- quake:
+		default:
+			log.Println("Events channel full, dropping message")
+		}
+	}
+}
+
+// fetchFeed returns the parsed feed, or nil if it has not changed since the last fetch
+func (c *Client) fetchFeed(ctx context.Context) (*Feed, error) {
+	c.mu.Lock()
+	since := c.lastModified
+	c.mu.Unlock()
+
+	res, err := c.get(ctx, c.feedURL, since)
+	if err != nil {
+		return nil, err
+	}
+	if res.notModified {
+		return nil, nil
+	}
+
+	var feed Feed
+	if err := xml.Unmarshal(res.body, &feed); err != nil {
+		return nil, fmt.Errorf("failed to parse feed: %w", err)
+	}
+
+	c.mu.Lock()
+	c.lastModified = res.lastModified
+	c.mu.Unlock()
+	return &feed, nil
+}
+
+// response is the result of a GET request
+type response struct {
+	body         []byte
+	lastModified string
+	notModified  bool
+}
+
+// get performs a GET request, conditional when ifModifiedSince is set
+func (c *Client) get(ctx context.Context, url, ifModifiedSince string) (*response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if ifModifiedSince != "" {
+		req.Header.Set("If-Modified-Since", ifModifiedSince)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified {
+		return &response{notModified: true}, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBodySize))
+	if err != nil {
+		return nil, err
+	}
+	return &response{body: body, lastModified: resp.Header.Get("Last-Modified")}, nil
+}
+
+// isSeen checks if entry ID was already seen without recording it
+func (c *Client) isSeen(id string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, exists := c.seenIDs[id]
+	return exists
+}
+
+// isDuplicate checks if entry ID was already seen and records it
+func (c *Client) isDuplicate(id string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, exists := c.seenIDs[id]; exists {
+		return true
+	}
+
+	c.seenIDs[id] = struct{}{}
+	c.seenIDsList = append(c.seenIDsList, id)
+
+	// Evict oldest if over limit
+	if len(c.seenIDsList) > c.maxSeenIDs {
+		delete(c.seenIDs, c.seenIDsList[0])
+		c.seenIDsList = c.seenIDsList[1:]
+	}
+
+	return false
+}
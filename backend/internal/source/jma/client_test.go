@@ -0,0 +1,193 @@
+package jma
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// feedServer serves an Atom feed whose entries can be changed during a test
+type feedServer struct {
+	*httptest.Server
+	mu      sync.Mutex
+	entries []string // newest first
+	reports map[string]string
+}
+
+func newFeedServer() *feedServer {
+	s := &feedServer{reports: make(map[string]string)}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		if r.URL.Path == "/feed.xml" {
+			if r.Header.Get("If-Modified-Since") == fmt.Sprintf("v%d", len(s.entries)) {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("Last-Modified", fmt.Sprintf("v%d", len(s.entries)))
+			var b strings.Builder
+			b.WriteString(`<feed xmlns="http://www.w3.org/2005/Atom">`)
+			b.WriteString(strings.Join(s.entries, ""))
+			b.WriteString(`</feed>`)
+			_, _ = w.Write([]byte(b.String()))
+			return
+		}
+		report, ok := s.reports[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(report))
+	}))
+	return s
+}
+
+// add prepends an entry and registers its report
+func (s *feedServer) add(title, name, report string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	url := s.URL + "/data/" + name + ".xml"
+	entry := fmt.Sprintf(`<entry><title>%s</title><id>%s</id><link type="application/xml" href="%s"/></entry>`, title, url, url)
+	s.entries = append([]string{entry}, s.entries...)
+	if report != "" {
+		s.reports["/data/"+name+".xml"] = report
+	}
+}
+
+func TestNewClient(t *testing.T) {
+	client := NewClient("")
+	if client.feedURL != DefaultFeedURL {
+		t.Errorf("feedURL = %q, want %q", client.feedURL, DefaultFeedURL)
+	}
+	if client.interval != pollInterval {
+		t.Errorf("interval = %v, want %v", client.interval, pollInterval)
+	}
+	if client.maxSeenIDs != 1000 {
+		t.Errorf("maxSeenIDs = %d, want 1000", client.maxSeenIDs)
+	}
+
+	client = NewClient("https://example.com/feed.xml")
+	if client.feedURL != "https://example.com/feed.xml" {
+		t.Errorf("feedURL = %q", client.feedURL)
+	}
+}
+
+func TestClient_Poll(t *testing.T) {
+	server := newFeedServer()
+	defer server.Close()
+
+	// Already in the feed at startup: not delivered
+	server.add(TitleScaleAndDestination, "old_VXSE53", vxse53)
+
+	client := NewClient(server.URL + "/feed.xml")
+	ctx := context.Background()
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	defer client.Close()
+
+	server.add("噴火警報・予報", "volcano_VFVO50", "")
+	server.add(TitleScalePrompt, "new_VXSE51", vxse51)
+	server.add(TitleScaleAndDestination, "new_VXSE53", vxse53)
+	client.poll(ctx)
+
+	var ids []string
+	for len(client.events) > 0 {
+		ids = append(ids, (<-client.events).GetID())
+	}
+	if got := strings.Join(ids, ","); got != "jma-new_VXSE51,jma-new_VXSE53" {
+		t.Errorf("events = %s, want oldest first without the startup entry", got)
+	}
+
+	// Not modified: nothing new
+	client.poll(ctx)
+	if len(client.events) != 0 {
+		t.Errorf("events after unchanged poll = %d, want 0", len(client.events))
+	}
+}
+
+func TestClient_Poll_RetriesFailedFetch(t *testing.T) {
+	server := newFeedServer()
+	defer server.Close()
+
+	client := NewClient(server.URL + "/feed.xml")
+	ctx := context.Background()
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	defer client.Close()
+
+	// The report is not available yet
+	server.add(TitleScalePrompt, "late_VXSE51", "")
+	client.poll(ctx)
+	if len(client.events) != 0 {
+		t.Fatalf("events = %d, want 0", len(client.events))
+	}
+
+	server.mu.Lock()
+	server.reports["/data/late_VXSE51.xml"] = vxse51
+	server.entries = append(server.entries, "") // Change Last-Modified
+	server.mu.Unlock()
+
+	client.poll(ctx)
+	if len(client.events) != 1 {
+		t.Errorf("events = %d, want 1 after the report became available", len(client.events))
+	}
+}
+
+func TestClient_PollLoop(t *testing.T) {
+	server := newFeedServer()
+	defer server.Close()
+
+	client := NewClient(server.URL + "/feed.xml")
+	client.interval = 10 * time.Millisecond
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	defer client.Close()
+
+	server.add(TitleScaleAndDestination, "loop_VXSE53", vxse53)
+
+	select {
+	case event := <-client.Events():
+		if event.GetID() != "jma-loop_VXSE53" {
+			t.Errorf("GetID() = %q", event.GetID())
+		}
+		if event.GetReceivedAt().IsZero() {
+			t.Error("GetReceivedAt() is zero")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for event")
+	}
+}
+
+func TestClient_Connect_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL + "/feed.xml")
+	if err := client.Connect(context.Background()); err == nil {
+		t.Error("Connect() error = nil, want error")
+	}
+}
+
+func TestClient_Close_Idempotent(t *testing.T) {
+	client := NewClient("")
+	if err := client.Close(); err != nil {
+		t.Errorf("Close() error = %v", err)
+	}
+	if err := client.Close(); err != nil {
+		t.Errorf("second Close() error = %v", err)
+	}
+}
@@ -0,0 +1,29 @@
+// Package jma provides a polling client for the JMA (Japan Meteorological
+// Agency) XML feeds.
+//
+// The client polls the Atom feed of earthquake and volcano reports, fetches
+// the XML of each new earthquake report and normalizes it into a
+// source.Event. It is an alternative to p2pquake that reads the agency's
+// publication directly, and can run alongside it (source.type: multi).
+//
+// Features:
+//   - Polls every 30 seconds with conditional requests (If-Modified-Since)
+//   - Entries already in the feed at startup are not delivered
+//   - Handles 震度速報 (VXSE51), 震源に関する情報 (VXSE52) and
+//     震源・震度に関する情報 (VXSE53)
+//   - Skips training/test reports and cancellations
+//   - Message deduplication using LRU cache (keeps last 1000 IDs)
+//
+// Example usage:
+//
+//	client := jma.NewClient(jma.DefaultFeedURL)
+//	if err := client.Connect(ctx); err != nil {
+//	    log.Fatal(err)
+//	}
+//	defer client.Close()
+//
+//	for event := range client.Events() {
+//	    quake := event.(*jma.Quake)
+//	    fmt.Printf("%s: %s\n", quake.Title, quake.Headline)
+//	}
+package jma
@@ -0,0 +1,308 @@
+package jma
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"math"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/otiai10/namazu/backend/internal/source"
+	"github.com/otiai10/namazu/backend/internal/source/p2pquake"
+)
+
+// Report titles handled by the client
+const (
+	TitleScalePrompt         = "震度速報"        // VXSE51
+	TitleDestination         = "震源に関する情報"    // VXSE52
+	TitleScaleAndDestination = "震源・震度に関する情報" // VXSE53
+)
+
+// IsEarthquakeReport reports whether a feed entry title is a report the client handles.
+func IsEarthquakeReport(title string) bool {
+	switch title {
+	case TitleScalePrompt, TitleDestination, TitleScaleAndDestination:
+		return true
+	default:
+		return false
+	}
+}
+
+// Feed is the Atom feed published by JMA
+type Feed struct {
+	Updated string  `xml:"updated"`
+	Entries []Entry `xml:"entry"`
+}
+
+// Entry is a single report in the feed
+type Entry struct {
+	ID      string `xml:"id"`
+	Title   string `xml:"title"`
+	Updated string `xml:"updated"`
+	Link    struct {
+		Href string `xml:"href,attr"`
+	} `xml:"link"`
+}
+
+// report is the subset of the JMA XML report used for normalization
+type report struct {
+	Control struct {
+		Title  string `xml:"Title"`
+		Status string `xml:"Status"` // 通常 | 訓練 | 試験
+	} `xml:"Control"`
+	Head struct {
+		Title          string `xml:"Title"`
+		ReportDateTime string `xml:"ReportDateTime"`
+		TargetDateTime string `xml:"TargetDateTime"`
+		EventID        string `xml:"EventID"`
+		InfoType       string `xml:"InfoType"` // 発表 | 訂正 | 取消
+		Headline       struct {
+			Text string `xml:"Text"`
+		} `xml:"Headline"`
+	} `xml:"Head"`
+	Body struct {
+		Earthquake *struct {
+			OriginTime string `xml:"OriginTime"`
+			Hypocenter struct {
+				Area struct {
+					Name        string   `xml:"Name"`
+					Coordinates []string `xml:"Coordinate"`
+				} `xml:"Area"`
+			} `xml:"Hypocenter"`
+			Magnitude string `xml:"Magnitude"`
+		} `xml:"Earthquake"`
+		Intensity *struct {
+			Observation struct {
+				MaxInt string `xml:"MaxInt"`
+				Pref   []struct {
+					Name   string `xml:"Name"`
+					MaxInt string `xml:"MaxInt"`
+				} `xml:"Pref"`
+			} `xml:"Observation"`
+		} `xml:"Intensity"`
+	} `xml:"Body"`
+}
+
+// Quake is an earthquake report normalized from the JMA XML.
+// It is serialized as the webhook payload for events from this source.
+type Quake struct {
+	ID          string       `json:"id"`
+	Source      string       `json:"source"`    // Always "jma"
+	Title       string       `json:"title"`     // Report title (e.g. 震源・震度に関する情報)
+	EventID     string       `json:"event_id"`  // Shared by all reports of the same earthquake
+	InfoType    string       `json:"info_type"` // 発表 | 訂正
+	ReportedAt  time.Time    `json:"reported_at"`
+	Headline    string       `json:"headline,omitempty"`
+	Earthquake  *Earthquake  `json:"earthquake,omitempty"` // Not included in 震度速報
+	MaxScale    int          `json:"max_scale"`            // Same codes as p2pquake (10-70), 0 if not reported
+	Prefectures []Prefecture `json:"prefectures,omitempty"`
+	URL         string       `json:"url"` // Original XML
+	// Added fields for Event interface
+	ReceivedAt time.Time `json:"-"`
+	RawJSON    string    `json:"-"`
+}
+
+// Earthquake contains origin time, hypocenter and magnitude
+type Earthquake struct {
+	OriginTime time.Time  `json:"origin_time"`
+	Hypocenter Hypocenter `json:"hypocenter"`
+	Magnitude  float64    `json:"magnitude"` // -1 if unknown
+}
+
+// Hypocenter contains epicenter location info
+type Hypocenter struct {
+	Name      string  `json:"name"`
+	Latitude  float64 `json:"latitude"`  // 0 if unknown
+	Longitude float64 `json:"longitude"` // 0 if unknown
+	Depth     int     `json:"depth"`     // km, -1 if unknown
+}
+
+// Prefecture contains the maximum intensity observed in a prefecture
+type Prefecture struct {
+	Name     string `json:"name"`
+	MaxScale int    `json:"max_scale"`
+}
+
+// Compile-time interface checks
+var (
+	_ source.Event   = (*Quake)(nil)
+	_ source.Located = (*Quake)(nil)
+)
+
+// GetID returns the unique identifier
+func (q *Quake) GetID() string {
+	return q.ID
+}
+
+// GetType returns the event type
+func (q *Quake) GetType() source.EventType {
+	return source.EventTypeEarthquake
+}
+
+// GetSource returns the data source identifier
+func (q *Quake) GetSource() string {
+	return "jma"
+}
+
+// GetSeverity returns normalized severity (0-100)
+func (q *Quake) GetSeverity() int {
+	return p2pquake.ScaleToSeverity(q.MaxScale)
+}
+
+// GetAffectedAreas returns list of affected prefectures
+func (q *Quake) GetAffectedAreas() []string {
+	areas := make([]string, 0, len(q.Prefectures))
+	for _, p := range q.Prefectures {
+		areas = append(areas, p.Name)
+	}
+	return areas
+}
+
+// GetOccurredAt returns when the earthquake occurred
+func (q *Quake) GetOccurredAt() time.Time {
+	if q.Earthquake != nil {
+		return q.Earthquake.OriginTime
+	}
+	return q.ReportedAt
+}
+
+// GetHypocenter returns the hypocenter, or nil for 震度速報 and unknown hypocenters
+func (q *Quake) GetHypocenter() *source.Hypocenter {
+	if q.Earthquake == nil {
+		return nil
+	}
+	h := q.Earthquake.Hypocenter
+	if h.Latitude == 0 && h.Longitude == 0 {
+		return nil
+	}
+	return &source.Hypocenter{Name: h.Name, Latitude: h.Latitude, Longitude: h.Longitude, Depth: h.Depth, Magnitude: q.Earthquake.Magnitude}
+}
+
+// GetReceivedAt returns when the event was received
+func (q *Quake) GetReceivedAt() time.Time {
+	return q.ReceivedAt
+}
+
+// GetRawJSON returns the normalized report as JSON
+func (q *Quake) GetRawJSON() string {
+	return q.RawJSON
+}
+
+// ParseReport normalizes a JMA XML report fetched from url.
+// It returns nil, nil for reports that must not be delivered:
+// training and test reports, and cancellations.
+func ParseReport(data []byte, url string) (*Quake, error) {
+	var r report
+	if err := xml.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("failed to parse report: %w", err)
+	}
+	if r.Control.Status != "通常" || r.Head.InfoType == "取消" {
+		return nil, nil
+	}
+
+	q := &Quake{
+		ID:       "jma-" + strings.TrimSuffix(path.Base(url), ".xml"),
+		Source:   "jma",
+		Title:    r.Head.Title,
+		EventID:  r.Head.EventID,
+		InfoType: r.Head.InfoType,
+		Headline: strings.TrimSpace(r.Head.Headline.Text),
+		URL:      url,
+	}
+	if q.Title == "" {
+		q.Title = r.Control.Title
+	}
+	q.ReportedAt, _ = time.Parse(time.RFC3339, r.Head.ReportDateTime)
+
+	if eq := r.Body.Earthquake; eq != nil {
+		q.Earthquake = &Earthquake{
+			Hypocenter: Hypocenter{Name: eq.Hypocenter.Area.Name, Depth: -1},
+			Magnitude:  -1,
+		}
+		if t, err := time.Parse(time.RFC3339, eq.OriginTime); err == nil {
+			q.Earthquake.OriginTime = t
+		} else {
+			q.Earthquake.OriginTime, _ = time.Parse(time.RFC3339, r.Head.TargetDateTime)
+		}
+		if len(eq.Hypocenter.Area.Coordinates) > 0 {
+			lat, lon, depth, ok := ParseCoordinate(eq.Hypocenter.Area.Coordinates[0])
+			if ok {
+				q.Earthquake.Hypocenter.Latitude = lat
+				q.Earthquake.Hypocenter.Longitude = lon
+				q.Earthquake.Hypocenter.Depth = depth
+			}
+		}
+		if m, err := strconv.ParseFloat(strings.TrimSpace(eq.Magnitude), 64); err == nil && !math.IsNaN(m) {
+			q.Earthquake.Magnitude = m
+		}
+	}
+
+	if in := r.Body.Intensity; in != nil {
+		q.MaxScale = IntensityToScale(in.Observation.MaxInt)
+		for _, p := range in.Observation.Pref {
+			q.Prefectures = append(q.Prefectures, Prefecture{
+				Name:     p.Name,
+				MaxScale: IntensityToScale(p.MaxInt),
+			})
+		}
+	}
+
+	raw, err := json.Marshal(q)
+	if err != nil {
+		return nil, err
+	}
+	q.RawJSON = string(raw)
+	return q, nil
+}
+
+// coordinatePattern matches ISO 6709 coordinates used by JMA, e.g. "+37.5+137.3-10000/"
+var coordinatePattern = regexp.MustCompile(`^([+-]\d+(?:\.\d+)?)([+-]\d+(?:\.\d+)?)([+-]\d+)?/$`)
+
+// ParseCoordinate parses a JMA coordinate into latitude, longitude and depth in km.
+// Depth is -1 when the coordinate does not include it. ok is false when the
+// hypocenter is unknown (empty coordinate).
+func ParseCoordinate(s string) (lat, lon float64, depth int, ok bool) {
+	m := coordinatePattern.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return 0, 0, -1, false
+	}
+	lat, _ = strconv.ParseFloat(m[1], 64)
+	lon, _ = strconv.ParseFloat(m[2], 64)
+	depth = -1
+	if m[3] != "" {
+		meters, _ := strconv.Atoi(m[3])
+		depth = -meters / 1000
+	}
+	return lat, lon, depth, true
+}
+
+// IntensityToScale converts a JMA intensity ("1" - "7", "5-", "5+", ...) to
+// the p2pquake scale code (10-70). Unknown values return 0.
+func IntensityToScale(s string) int {
+	switch strings.TrimSpace(s) {
+	case "1":
+		return p2pquake.Scale1
+	case "2":
+		return p2pquake.Scale2
+	case "3":
+		return p2pquake.Scale3
+	case "4":
+		return p2pquake.Scale4
+	case "5-":
+		return p2pquake.Scale5Weak
+	case "5+":
+		return p2pquake.Scale5Strong
+	case "6-":
+		return p2pquake.Scale6Weak
+	case "6+":
+		return p2pquake.Scale6Strong
+	case "7":
+		return p2pquake.Scale7
+	default:
+		return 0
+	}
+}
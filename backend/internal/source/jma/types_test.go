@@ -0,0 +1,237 @@
+package jma
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/otiai10/namazu/backend/internal/source"
+	"github.com/otiai10/namazu/backend/internal/source/p2pquake"
+)
+
+const reportURL = "https://www.data.jma.go.jp/developer/xml/data/20240101071825_0_VXSE53_010000.xml"
+
+// vxse53 is a trimmed 震源・震度に関する情報 report
+const vxse53 = `<?xml version="1.0" encoding="UTF-8"?>
+<Report xmlns="http://xml.kishou.go.jp/jmaxml1/" xmlns:jmx="http://xml.kishou.go.jp/jmaxml1/">
+<Control>
+<Title>震源・震度に関する情報</Title>
+<DateTime>2024-01-01T07:18:25Z</DateTime>
+<Status>通常</Status>
+<EditorialOffice>気象庁本庁</EditorialOffice>
+<PublishingOffice>気象庁</PublishingOffice>
+</Control>
+<Head xmlns="http://xml.kishou.go.jp/jmaxml1/informationBasis1/">
+<Title>震源・震度情報</Title>
+<ReportDateTime>2024-01-01T16:18:00+09:00</ReportDateTime>
+<TargetDateTime>2024-01-01T16:10:00+09:00</TargetDateTime>
+<EventID>20240101161010</EventID>
+<InfoType>発表</InfoType>
+<Serial>1</Serial>
+<InfoKind>地震情報</InfoKind>
+<Headline><Text>１日１６時１０分ころ、地震がありました。</Text></Headline>
+</Head>
+<Body xmlns="http://xml.kishou.go.jp/jmaxml1/body/seismology1/" xmlns:jmx_eb="http://xml.kishou.go.jp/jmaxml1/elementBasis1/">
+<Earthquake>
+<OriginTime>2024-01-01T16:10:00+09:00</OriginTime>
+<ArrivalTime>2024-01-01T16:10:00+09:00</ArrivalTime>
+<Hypocenter>
+<Area>
+<Name>石川県能登地方</Name>
+<Code type="震央地名">390</Code>
+<jmx_eb:Coordinate description="北緯３７．５度　東経１３７．３度　深さ　１０ｋｍ" datum="日本測地系">+37.5+137.3-10000/</jmx_eb:Coordinate>
+</Area>
+</Hypocenter>
+<jmx_eb:Magnitude type="Mj" description="Ｍ７．６">7.6</jmx_eb:Magnitude>
+</Earthquake>
+<Intensity>
+<Observation>
+<MaxInt>7</MaxInt>
+<Pref><Name>石川県</Name><Code>17</Code><MaxInt>7</MaxInt></Pref>
+<Pref><Name>新潟県</Name><Code>15</Code><MaxInt>6-</MaxInt></Pref>
+<Pref><Name>富山県</Name><Code>16</Code><MaxInt>5+</MaxInt></Pref>
+</Observation>
+</Intensity>
+</Body>
+</Report>`
+
+// vxse51 is a trimmed 震度速報 report (no hypocenter)
+const vxse51 = `<?xml version="1.0" encoding="UTF-8"?>
+<Report xmlns="http://xml.kishou.go.jp/jmaxml1/">
+<Control><Title>震度速報</Title><Status>通常</Status></Control>
+<Head xmlns="http://xml.kishou.go.jp/jmaxml1/informationBasis1/">
+<Title>震度速報</Title>
+<ReportDateTime>2024-01-01T16:12:00+09:00</ReportDateTime>
+<TargetDateTime>2024-01-01T16:10:00+09:00</TargetDateTime>
+<EventID>20240101161010</EventID>
+<InfoType>発表</InfoType>
+</Head>
+<Body xmlns="http://xml.kishou.go.jp/jmaxml1/body/seismology1/">
+<Intensity>
+<Observation>
+<MaxInt>4</MaxInt>
+<Pref><Name>石川県</Name><MaxInt>4</MaxInt></Pref>
+</Observation>
+</Intensity>
+</Body>
+</Report>`
+
+func TestParseReport_VXSE53(t *testing.T) {
+	q, err := ParseReport([]byte(vxse53), reportURL)
+	if err != nil {
+		t.Fatalf("ParseReport() error = %v", err)
+	}
+	if q == nil {
+		t.Fatal("ParseReport() returned nil")
+	}
+
+	if q.GetID() != "jma-20240101071825_0_VXSE53_010000" {
+		t.Errorf("GetID() = %q", q.GetID())
+	}
+	if q.GetSource() != "jma" {
+		t.Errorf("GetSource() = %q, want jma", q.GetSource())
+	}
+	if q.GetType() != source.EventTypeEarthquake {
+		t.Errorf("GetType() = %q, want earthquake", q.GetType())
+	}
+	if q.Title != "震源・震度情報" || q.EventID != "20240101161010" || q.InfoType != "発表" {
+		t.Errorf("head = %q %q %q", q.Title, q.EventID, q.InfoType)
+	}
+	if q.MaxScale != p2pquake.Scale7 {
+		t.Errorf("MaxScale = %d, want %d", q.MaxScale, p2pquake.Scale7)
+	}
+	if q.GetSeverity() != 100 {
+		t.Errorf("GetSeverity() = %d, want 100", q.GetSeverity())
+	}
+
+	areas := q.GetAffectedAreas()
+	if strings.Join(areas, ",") != "石川県,新潟県,富山県" {
+		t.Errorf("GetAffectedAreas() = %v", areas)
+	}
+	if q.Prefectures[1].MaxScale != p2pquake.Scale6Weak {
+		t.Errorf("Prefectures[1].MaxScale = %d, want %d", q.Prefectures[1].MaxScale, p2pquake.Scale6Weak)
+	}
+
+	jst := time.FixedZone("JST", 9*60*60)
+	if want := time.Date(2024, 1, 1, 16, 10, 0, 0, jst); !q.GetOccurredAt().Equal(want) {
+		t.Errorf("GetOccurredAt() = %v, want %v", q.GetOccurredAt(), want)
+	}
+
+	h := q.Earthquake.Hypocenter
+	if h.Name != "石川県能登地方" || h.Latitude != 37.5 || h.Longitude != 137.3 || h.Depth != 10 {
+		t.Errorf("Hypocenter = %+v", h)
+	}
+	if q.Earthquake.Magnitude != 7.6 {
+		t.Errorf("Magnitude = %v, want 7.6", q.Earthquake.Magnitude)
+	}
+	if got := q.GetHypocenter(); got == nil || *got != (source.Hypocenter{Name: "石川県能登地方", Latitude: 37.5, Longitude: 137.3, Depth: 10, Magnitude: 7.6}) {
+		t.Errorf("GetHypocenter() = %+v", got)
+	}
+
+	var payload map[string]interface{}
+	if err := json.Unmarshal([]byte(q.GetRawJSON()), &payload); err != nil {
+		t.Fatalf("GetRawJSON() is not JSON: %v", err)
+	}
+	if payload["source"] != "jma" || payload["url"] != reportURL {
+		t.Errorf("payload = %v", payload)
+	}
+}
+
+func TestParseReport_VXSE51(t *testing.T) {
+	q, err := ParseReport([]byte(vxse51), "https://example.com/20240101071230_0_VXSE51_010000.xml")
+	if err != nil {
+		t.Fatalf("ParseReport() error = %v", err)
+	}
+	if q.Earthquake != nil {
+		t.Errorf("Earthquake = %+v, want nil", q.Earthquake)
+	}
+	if q.GetHypocenter() != nil {
+		t.Errorf("GetHypocenter() = %+v, want nil", q.GetHypocenter())
+	}
+	if q.GetSeverity() != 40 {
+		t.Errorf("GetSeverity() = %d, want 40", q.GetSeverity())
+	}
+
+	// Without a hypocenter the report time is used
+	jst := time.FixedZone("JST", 9*60*60)
+	if want := time.Date(2024, 1, 1, 16, 12, 0, 0, jst); !q.GetOccurredAt().Equal(want) {
+		t.Errorf("GetOccurredAt() = %v, want %v", q.GetOccurredAt(), want)
+	}
+}
+
+func TestParseReport_Skipped(t *testing.T) {
+	tests := []struct {
+		name string
+		xml  string
+	}{
+		{"training", strings.Replace(vxse51, "<Status>通常</Status>", "<Status>訓練</Status>", 1)},
+		{"cancellation", strings.Replace(vxse51, "<InfoType>発表</InfoType>", "<InfoType>取消</InfoType>", 1)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := ParseReport([]byte(tt.xml), reportURL)
+			if err != nil {
+				t.Fatalf("ParseReport() error = %v", err)
+			}
+			if q != nil {
+				t.Errorf("ParseReport() = %+v, want nil", q)
+			}
+		})
+	}
+}
+
+func TestParseReport_Invalid(t *testing.T) {
+	if _, err := ParseReport([]byte("not xml <"), reportURL); err == nil {
+		t.Error("ParseReport() error = nil, want error")
+	}
+}
+
+func TestParseCoordinate(t *testing.T) {
+	tests := []struct {
+		in        string
+		lat, lon  float64
+		depth     int
+		wantValid bool
+	}{
+		{"+37.5+137.3-10000/", 37.5, 137.3, 10, true},
+		{"+35.6+139.7+0/", 35.6, 139.7, 0, true},
+		{"+24.3+123.8/", 24.3, 123.8, -1, true},
+		{"", 0, 0, -1, false},
+	}
+	for _, tt := range tests {
+		lat, lon, depth, ok := ParseCoordinate(tt.in)
+		if lat != tt.lat || lon != tt.lon || depth != tt.depth || ok != tt.wantValid {
+			t.Errorf("ParseCoordinate(%q) = %v, %v, %d, %v", tt.in, lat, lon, depth, ok)
+		}
+	}
+}
+
+func TestIntensityToScale(t *testing.T) {
+	tests := map[string]int{
+		"1":  p2pquake.Scale1,
+		"4":  p2pquake.Scale4,
+		"5-": p2pquake.Scale5Weak,
+		"5+": p2pquake.Scale5Strong,
+		"6-": p2pquake.Scale6Weak,
+		"6+": p2pquake.Scale6Strong,
+		"7":  p2pquake.Scale7,
+		"":   0,
+	}
+	for in, want := range tests {
+		if got := IntensityToScale(in); got != want {
+			t.Errorf("IntensityToScale(%q) = %d, want %d", in, got, want)
+		}
+	}
+}
+
+func TestIsEarthquakeReport(t *testing.T) {
+	for _, title := range []string{"震度速報", "震源に関する情報", "震源・震度に関する情報"} {
+		if !IsEarthquakeReport(title) {
+			t.Errorf("IsEarthquakeReport(%q) = false", title)
+		}
+	}
+	if IsEarthquakeReport("噴火警報・予報") {
+		t.Error("IsEarthquakeReport(噴火警報・予報) = true")
+	}
+}
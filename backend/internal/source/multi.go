@@ -0,0 +1,112 @@
+package source
+
+import (
+	"context"
+	"log"
+	"sync"
+)
+
+// Multi merges the events of several sources into one stream.
+// The same earthquake reported by more than one source is forwarded once
+// (see Deduplicator).
+type Multi struct {
+	sources []Source
+	dedup   *Deduplicator
+	events  chan Event
+	done    chan struct{}
+	once    sync.Once
+}
+
+// NewMulti creates a source that connects all given sources
+func NewMulti(sources ...Source) *Multi {
+	return &Multi{
+		sources: sources,
+		dedup:   NewDeduplicator(),
+		events:  make(chan Event, 100),
+		done:    make(chan struct{}),
+	}
+}
+
+// Connect connects every source and starts forwarding their events.
+// If any source fails to connect, the ones already connected are closed.
+func (m *Multi) Connect(ctx context.Context) error {
+	for i, s := range m.sources {
+		if err := s.Connect(ctx); err != nil {
+			for _, connected := range m.sources[:i] {
+				connected.Close()
+			}
+			return err
+		}
+	}
+	for _, s := range m.sources {
+		go m.forward(ctx, s)
+	}
+	return nil
+}
+
+// Events returns the merged channel of events
+func (m *Multi) Events() <-chan Event {
+	return m.events
+}
+
+// Status reports the sources that hold a connection as one: the first
+// disconnected one, or connected since the latest connection
+func (m *Multi) Status() Status {
+	status := Status{Connected: true}
+	for _, s := range m.sources {
+		monitored, ok := s.(Monitored)
+		if !ok {
+			continue
+		}
+		st := monitored.Status()
+		if !st.Connected {
+			return st
+		}
+		if st.Since.After(status.Since) {
+			status.Since = st.Since
+		}
+	}
+	return status
+}
+
+// Close closes every source and returns the first error
+func (m *Multi) Close() error {
+	var first error
+	m.once.Do(func() {
+		close(m.done)
+		for _, s := range m.sources {
+			if err := s.Close(); err != nil && first == nil {
+				first = err
+			}
+		}
+	})
+	return first
+}
+
+// forward copies events from one source until shutdown
+func (m *Multi) forward(ctx context.Context, s Source) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-m.done:
+			return
+		case event, ok := <-s.Events():
+			if !ok {
+				return
+			}
+			if original := m.dedup.Check(event); original != nil {
+				log.Printf("Dropped event %s from %s: duplicate of %s from %s",
+					event.GetID(), event.GetSource(), original.GetID(), original.GetSource())
+				continue
+			}
+			select {
+			case m.events <- event:
+			case <-ctx.Done():
+				return
+			case <-m.done:
+				return
+			}
+		}
+	}
+}
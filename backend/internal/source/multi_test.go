@@ -0,0 +1,116 @@
+package source
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type mockEvent struct{ id string }
+
+func (e *mockEvent) GetID() string              { return e.id }
+func (e *mockEvent) GetType() EventType         { return EventTypeEarthquake }
+func (e *mockEvent) GetSource() string          { return "mock" }
+func (e *mockEvent) GetSeverity() int           { return 0 }
+func (e *mockEvent) GetAffectedAreas() []string { return nil }
+func (e *mockEvent) GetOccurredAt() time.Time   { return time.Time{} }
+func (e *mockEvent) GetReceivedAt() time.Time   { return time.Time{} }
+func (e *mockEvent) GetRawJSON() string         { return "{}" }
+
+type mockSource struct {
+	events     chan Event
+	connectErr error
+	connected  bool
+	closed     bool
+}
+
+func newMockSource() *mockSource {
+	return &mockSource{events: make(chan Event, 10)}
+}
+
+func (s *mockSource) Connect(ctx context.Context) error {
+	if s.connectErr != nil {
+		return s.connectErr
+	}
+	s.connected = true
+	return nil
+}
+
+func (s *mockSource) Events() <-chan Event { return s.events }
+
+func (s *mockSource) Close() error {
+	s.closed = true
+	return nil
+}
+
+func TestMulti_MergesEvents(t *testing.T) {
+	a, b := newMockSource(), newMockSource()
+	m := NewMulti(a, b)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := m.Connect(ctx); err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+
+	a.events <- &mockEvent{id: "a-1"}
+	b.events <- &mockEvent{id: "b-1"}
+
+	seen := map[string]bool{}
+	for i := 0; i < 2; i++ {
+		select {
+		case event := <-m.Events():
+			seen[event.GetID()] = true
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for event")
+		}
+	}
+	if !seen["a-1"] || !seen["b-1"] {
+		t.Errorf("events = %v, want a-1 and b-1", seen)
+	}
+
+	if err := m.Close(); err != nil {
+		t.Errorf("Close() error = %v", err)
+	}
+	if !a.closed || !b.closed {
+		t.Error("Close() did not close every source")
+	}
+}
+
+// monitoredSource is a source reporting a connection status
+type monitoredSource struct {
+	*mockSource
+	status Status
+}
+
+func (s *monitoredSource) Status() Status { return s.status }
+
+func TestMulti_Status(t *testing.T) {
+	earlier, later := time.Now().Add(-time.Hour), time.Now()
+	a := &monitoredSource{mockSource: newMockSource(), status: Status{Connected: true, Since: earlier}}
+	b := &monitoredSource{mockSource: newMockSource(), status: Status{Connected: true, Since: later}}
+	m := NewMulti(a, newMockSource(), b)
+
+	if got := m.Status(); !got.Connected || !got.Since.Equal(later) {
+		t.Errorf("Status() = %+v, want connected since the latest connection", got)
+	}
+
+	b.status = Status{Since: later}
+	if got := m.Status(); got.Connected || !got.Since.Equal(later) {
+		t.Errorf("Status() = %+v, want the disconnected source", got)
+	}
+}
+
+func TestMulti_ConnectError(t *testing.T) {
+	a, b := newMockSource(), newMockSource()
+	b.connectErr = errors.New("unavailable")
+	m := NewMulti(a, b)
+
+	if err := m.Connect(context.Background()); err == nil {
+		t.Fatal("Connect() error = nil, want error")
+	}
+	if !a.closed {
+		t.Error("connected source was not closed after failure")
+	}
+}
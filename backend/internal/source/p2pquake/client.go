@@ -3,20 +3,26 @@ package p2pquake
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"math/rand/v2"
 	"sync"
 	"time"
 
 	"github.com/gorilla/websocket"
 	"github.com/otiai10/namazu/backend/internal/source"
+	"github.com/otiai10/namazu/backend/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const (
 	// reconnectInterval is how often to reconnect to avoid 10-minute forced disconnect
 	reconnectInterval = 9 * time.Minute
 
-	// maxRetries is maximum number of connection retry attempts
+	// maxRetries is maximum number of attempts of the initial connection.
+	// Once connected, the client reconnects until it is closed.
 	maxRetries = 10
 
 	// initialRetryDelay is the starting delay for exponential backoff
@@ -24,29 +30,43 @@ const (
 
 	// maxRetryDelay is the maximum delay between retries
 	maxRetryDelay = 60 * time.Second
+
+	// downtimeAlarm is how long the client may stay disconnected before an alert is logged
+	downtimeAlarm = 5 * time.Minute
 )
 
 // Client is a WebSocket client for P2P地震情報 API
 type Client struct {
-	endpoint    string
-	conn        *websocket.Conn
-	events      chan source.Event
-	done        chan struct{}
-	mu          sync.Mutex
-	seenIDs     map[string]struct{}
-	seenIDsList []string // for LRU eviction
-	maxSeenIDs  int
+	endpoint       string
+	conn           *websocket.Conn
+	connectedAt    time.Time
+	disconnectedAt time.Time // Zero while connected
+	events         chan source.Event
+	done           chan struct{}
+	mu             sync.Mutex
+	seenIDs        map[string]struct{}
+	seenIDsList    []string // for LRU eviction
+	maxSeenIDs     int
+	onReconnect    func(ctx context.Context)
+
+	// Backoff and alarm settings, shortened in tests
+	initialDelay  time.Duration
+	maxDelay      time.Duration
+	downtimeAlarm time.Duration
 }
 
 // NewClient creates a new P2P地震情報 client
 func NewClient(endpoint string) *Client {
 	return &Client{
-		endpoint:    endpoint,
-		events:      make(chan source.Event, 100),
-		done:        make(chan struct{}),
-		seenIDs:     make(map[string]struct{}),
-		seenIDsList: make([]string, 0),
-		maxSeenIDs:  1000,
+		endpoint:      endpoint,
+		events:        make(chan source.Event, 100),
+		done:          make(chan struct{}),
+		seenIDs:       make(map[string]struct{}),
+		seenIDsList:   make([]string, 0),
+		maxSeenIDs:    1000,
+		initialDelay:  initialRetryDelay,
+		maxDelay:      maxRetryDelay,
+		downtimeAlarm: downtimeAlarm,
 	}
 }
 
@@ -67,11 +87,39 @@ func (c *Client) Connect(ctx context.Context) error {
 	return nil
 }
 
+// OnReconnect registers a callback run (in its own goroutine) after each
+// reconnection, e.g. to recover the events missed while disconnected.
+// It must be called before Connect.
+func (c *Client) OnReconnect(fn func(ctx context.Context)) {
+	c.onReconnect = fn
+}
+
 // Events returns the channel for receiving events
 func (c *Client) Events() <-chan source.Event {
 	return c.events
 }
 
+// DisconnectedFor returns how long the client has been without a connection
+// (0 while connected)
+func (c *Client) DisconnectedFor() time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.disconnectedAt.IsZero() {
+		return 0
+	}
+	return time.Since(c.disconnectedAt)
+}
+
+// Status reports whether the client is connected, and since when
+func (c *Client) Status() source.Status {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.conn != nil {
+		return source.Status{Connected: true, Since: c.connectedAt}
+	}
+	return source.Status{Since: c.disconnectedAt}
+}
+
 // Close closes the connection
 func (c *Client) Close() error {
 	close(c.done)
@@ -83,50 +131,121 @@ func (c *Client) Close() error {
 	return nil
 }
 
-// connect establishes WebSocket connection with retry
+// connect establishes the initial WebSocket connection with retry
 func (c *Client) connect(ctx context.Context) error {
 	var lastErr error
-	delay := initialRetryDelay
 
 	for attempt := 0; attempt < maxRetries; attempt++ {
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		case <-c.done:
-			return fmt.Errorf("client closed")
-		default:
+		lastErr = c.dial(ctx)
+		if lastErr == nil {
+			return nil
 		}
+		log.Printf("Connection attempt %d/%d failed: %v", attempt+1, maxRetries, lastErr)
 
-		c.mu.Lock()
-		conn, _, err := websocket.DefaultDialer.DialContext(ctx, c.endpoint, nil)
+		if attempt < maxRetries-1 {
+			if err := c.wait(ctx, c.backoff(attempt)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return fmt.Errorf("failed to connect after %d attempts: %w", maxRetries, lastErr)
+}
+
+// reconnect dials until it succeeds or the client is stopped, logging an
+// alert once the connection has been down for longer than downtimeAlarm.
+// P2P地震情報 pushes every message to every connection, so a new connection
+// needs no resubscription.
+func (c *Client) reconnect(ctx context.Context) error {
+	alarmed := false
+	for attempt := 0; ; attempt++ {
+		err := c.dial(ctx)
 		if err == nil {
-			c.conn = conn
-			c.mu.Unlock()
-			log.Printf("Successfully connected to %s", c.endpoint)
+			if alarmed {
+				log.Printf("ALERT resolved: reconnected to %s", c.endpoint)
+			}
 			return nil
 		}
-		c.mu.Unlock()
 
-		lastErr = err
-		log.Printf("Connection attempt %d/%d failed: %v", attempt+1, maxRetries, err)
+		down := c.DisconnectedFor()
+		log.Printf("Reconnection attempt %d failed (down for %v): %v", attempt+1, down.Round(time.Second), err)
+		if !alarmed && down > c.downtimeAlarm {
+			alarmed = true
+			log.Printf("ALERT: disconnected from %s for %v; events are being missed", c.endpoint, down.Round(time.Second))
+		}
 
-		if attempt < maxRetries-1 {
-			select {
-			case <-ctx.Done():
-				return ctx.Err()
-			case <-c.done:
-				return fmt.Errorf("client closed")
-			case <-time.After(delay):
-				// Exponential backoff with cap
-				delay *= 2
-				if delay > maxRetryDelay {
-					delay = maxRetryDelay
-				}
-			}
+		if err := c.wait(ctx, c.backoff(attempt)); err != nil {
+			return err
 		}
 	}
+}
 
-	return fmt.Errorf("failed to connect after %d attempts: %w", maxRetries, lastErr)
+// dial makes a single connection attempt
+func (c *Client) dial(ctx context.Context) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-c.done:
+		return errClosed
+	default:
+	}
+
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, c.endpoint, nil)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	select {
+	case <-c.done:
+		conn.Close()
+		return errClosed
+	default:
+	}
+	c.conn = conn
+	c.connectedAt = time.Now()
+	c.disconnectedAt = time.Time{}
+	log.Printf("Successfully connected to %s", c.endpoint)
+	return nil
+}
+
+// drop closes conn and marks the client disconnected, unless conn has already been replaced
+func (c *Client) drop(conn *websocket.Conn) {
+	c.mu.Lock()
+	if c.conn == conn {
+		c.conn = nil
+		c.disconnectedAt = time.Now()
+	}
+	c.mu.Unlock()
+	conn.Close()
+}
+
+// wait sleeps for d unless the client is stopped first
+func (c *Client) wait(ctx context.Context, d time.Duration) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-c.done:
+		return errClosed
+	case <-time.After(d):
+		return nil
+	}
+}
+
+// backoff returns the delay after a failed attempt: exponential with a cap,
+// randomized to half to full length so that restarted replicas do not retry in lockstep
+func (c *Client) backoff(attempt int) time.Duration {
+	delay := c.initialDelay
+	for range attempt {
+		delay *= 2
+		if delay >= c.maxDelay {
+			delay = c.maxDelay
+			break
+		}
+	}
+	half := delay / 2
+	return half + rand.N(half+1)
 }
 
 // readLoop reads messages from WebSocket
@@ -147,8 +266,13 @@ func (c *Client) readLoop(ctx context.Context) {
 		c.mu.Unlock()
 
 		if conn == nil {
-			log.Println("No connection available, waiting...")
-			time.Sleep(1 * time.Second)
+			if err := c.reconnect(ctx); err != nil {
+				log.Printf("Read loop stopped: %v", err)
+				return
+			}
+			if c.onReconnect != nil {
+				go c.onReconnect(ctx)
+			}
 			continue
 		}
 
@@ -156,15 +280,7 @@ func (c *Client) readLoop(ctx context.Context) {
 		messageType, data, err := conn.ReadMessage()
 		if err != nil {
 			log.Printf("Read error: %v", err)
-			// Try to reconnect
-			c.mu.Lock()
-			c.conn = nil
-			c.mu.Unlock()
-
-			if reconnectErr := c.connect(ctx); reconnectErr != nil {
-				log.Printf("Reconnection failed: %v", reconnectErr)
-				time.Sleep(1 * time.Second)
-			}
+			c.drop(conn)
 			continue
 		}
 
@@ -183,8 +299,8 @@ func (c *Client) readLoop(ctx context.Context) {
 			continue
 		}
 
-		// Filter for code 551 (JMAQuake) only
-		if rawMessage.Code != 551 {
+		// Filter for code 551 (JMAQuake) and 556 (EEW) only
+		if rawMessage.Code != CodeJMAQuake && rawMessage.Code != CodeEEW {
 			continue
 		}
 
@@ -193,26 +309,87 @@ func (c *Client) readLoop(ctx context.Context) {
 			continue
 		}
 
-		// Parse full JMAQuake message
-		var quake JMAQuake
-		if err := json.Unmarshal(data, &quake); err != nil {
-			log.Printf("Failed to parse JMAQuake message: %v", err)
+		// The receipt span is the root of the event's trace; processing continues it
+		_, span := tracing.Start(ctx, "p2pquake.receive",
+			attribute.String("p2pquake.id", rawMessage.ID),
+			attribute.Int("p2pquake.code", rawMessage.Code))
+		event, err := parseEvent(span.SpanContext(), rawMessage.Code, data)
+		if err != nil {
+			log.Printf("Failed to parse code %d message: %v", rawMessage.Code, err)
+			tracing.Fail(span, err.Error())
+			span.End()
+			continue
+		}
+		span.End()
+		if event == nil {
 			continue
 		}
-
-		// Add metadata
-		quake.ReceivedAt = time.Now()
-		quake.RawJSON = string(data)
 
 		// Send to events channel (non-blocking)
 		select {
-		case c.events <- &quake:
+		case c.events <- event:
 		default:
 			log.Println("Events channel full, dropping message")
 		}
 	}
 }
 
+// errClosed is returned by connection attempts after Close
+var errClosed = errors.New("client closed")
+
+// ErrUnsupportedCode is returned by ParseMessage for messages that are not
+// earthquake information (551) or Earthquake Early Warnings (556)
+var ErrUnsupportedCode = errors.New("unsupported p2pquake code")
+
+// ParseMessage parses a P2P地震情報 JSON message as if it had been received
+// over the WebSocket, e.g. a synthetic event injected for testing.
+// Like the client, it returns nil for EEW test broadcasts.
+func ParseMessage(data []byte) (source.Event, error) {
+	var rawMessage struct {
+		ID   string `json:"_id"`
+		Code int    `json:"code"`
+	}
+	if err := json.Unmarshal(data, &rawMessage); err != nil {
+		return nil, err
+	}
+	if rawMessage.Code != CodeJMAQuake && rawMessage.Code != CodeEEW {
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedCode, rawMessage.Code)
+	}
+	if rawMessage.ID == "" {
+		return nil, fmt.Errorf("_id is required")
+	}
+	return parseEvent(trace.SpanContext{}, rawMessage.Code, data)
+}
+
+// parseEvent parses a message of a supported code, attaching the span of its receipt.
+// It returns nil for messages that must not be delivered (EEW test broadcasts).
+func parseEvent(sc trace.SpanContext, code int, data []byte) (source.Event, error) {
+	receivedAt := time.Now()
+
+	if code == CodeEEW {
+		var eew EEW
+		if err := json.Unmarshal(data, &eew); err != nil {
+			return nil, err
+		}
+		if eew.Test {
+			return nil, nil
+		}
+		eew.ReceivedAt = receivedAt
+		eew.RawJSON = string(data)
+		eew.TraceContext = sc
+		return &eew, nil
+	}
+
+	var quake JMAQuake
+	if err := json.Unmarshal(data, &quake); err != nil {
+		return nil, err
+	}
+	quake.ReceivedAt = receivedAt
+	quake.RawJSON = string(data)
+	quake.TraceContext = sc
+	return &quake, nil
+}
+
 // isDuplicate checks if message ID was already seen
 func (c *Client) isDuplicate(id string) bool {
 	c.mu.Lock()
@@ -256,17 +433,12 @@ func (c *Client) scheduleReconnect(ctx context.Context) {
 		case <-ticker.C:
 			log.Println("Scheduled reconnection (9-minute interval)")
 
-			// Close existing connection
+			// Closing the connection makes the read loop reconnect
 			c.mu.Lock()
-			if c.conn != nil {
-				c.conn.Close()
-				c.conn = nil
-			}
+			conn := c.conn
 			c.mu.Unlock()
-
-			// Establish new connection
-			if err := c.connect(ctx); err != nil {
-				log.Printf("Scheduled reconnection failed: %v", err)
+			if conn != nil {
+				c.drop(conn)
 			}
 		}
 	}
@@ -3,10 +3,12 @@ package p2pquake
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -263,6 +265,62 @@ func TestClient_MessageParsing_Code551(t *testing.T) {
 	}
 }
 
+// Test parsing code 556 (EEW) messages
+func TestClient_MessageParsing_Code556(t *testing.T) {
+	server := newMockWSServer(t, func(conn *websocket.Conn) {
+		_ = conn.WriteMessage(websocket.TextMessage, []byte(sampleEEW))
+		time.Sleep(200 * time.Millisecond)
+	})
+	defer server.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http")
+
+	client := NewClient(wsURL)
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	go func() { _ = client.Connect(ctx) }()
+
+	select {
+	case event := <-client.Events():
+		if _, ok := event.(*EEW); !ok {
+			t.Fatalf("event = %T, want *EEW", event)
+		}
+		if event.GetType() != source.EventTypeEEW {
+			t.Errorf("GetType() = %q, want %q", event.GetType(), source.EventTypeEEW)
+		}
+	case <-time.After(1 * time.Second):
+		t.Fatal("Timeout waiting for event")
+	}
+}
+
+func TestParseMessage(t *testing.T) {
+	quake := `{"_id":"syn-1","code":551,"time":"2026/01/01 00:00:00.000","earthquake":{"maxScale":50,"hypocenter":{"name":"東京湾","magnitude":6.1,"depth":40}},"points":[{"pref":"東京都","addr":"千代田区","scale":50}]}`
+	event, err := ParseMessage([]byte(quake))
+	if err != nil {
+		t.Fatalf("ParseMessage() error = %v", err)
+	}
+	if event.GetID() != "syn-1" || event.GetSeverity() != ScaleToSeverity(50) || event.GetRawJSON() != quake || event.GetReceivedAt().IsZero() {
+		t.Errorf("ParseMessage() = %+v", event)
+	}
+
+	if eew, err := ParseMessage([]byte(sampleEEW)); err != nil || eew == nil || eew.GetType() != source.EventTypeEEW {
+		t.Errorf("ParseMessage(EEW) = %v, %v", eew, err)
+	}
+	if test, err := ParseMessage([]byte(`{"_id":"t","code":556,"test":true}`)); err != nil || test != nil {
+		t.Errorf("ParseMessage(EEW test) = %v, %v, want nil, nil", test, err)
+	}
+
+	for _, bad := range []string{`{`, `{"_id":"x","code":555}`, `{"code":551}`} {
+		if _, err := ParseMessage([]byte(bad)); err == nil {
+			t.Errorf("ParseMessage(%s) should fail", bad)
+		}
+	}
+	if _, err := ParseMessage([]byte(`{"_id":"x","code":555}`)); !errors.Is(err, ErrUnsupportedCode) {
+		t.Errorf("ParseMessage(555) error = %v, want ErrUnsupportedCode", err)
+	}
+}
+
 // Test filtering non-551 codes
 func TestClient_MessageParsing_FilterNon551(t *testing.T) {
 	server := newMockWSServer(t, func(conn *websocket.Conn) {
@@ -436,6 +494,107 @@ func TestClient_ContextCancellation(t *testing.T) {
 	// No panic means test passes
 }
 
+// Test reconnection after the server drops the connection
+func TestClient_Reconnect(t *testing.T) {
+	var connections atomic.Int32
+	server := newMockWSServer(t, func(conn *websocket.Conn) {
+		n := connections.Add(1)
+		data, _ := json.Marshal(JMAQuake{ID: fmt.Sprintf("quake-%d", n), Code: 551, Time: "2024/01/15 12:34:56"})
+		_ = conn.WriteMessage(websocket.TextMessage, data)
+		if n == 1 {
+			return // Drop the first connection
+		}
+		time.Sleep(500 * time.Millisecond)
+	})
+	defer server.Close()
+
+	client := NewClient("ws" + strings.TrimPrefix(server.URL, "http"))
+	client.initialDelay = 10 * time.Millisecond
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	defer client.Close()
+
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	for _, want := range []string{"quake-1", "quake-2"} {
+		select {
+		case event := <-client.Events():
+			if event.GetID() != want {
+				t.Errorf("GetID() = %q, want %q", event.GetID(), want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Timeout waiting for %s", want)
+		}
+	}
+	if client.DisconnectedFor() != 0 {
+		t.Error("expected the client to be connected again")
+	}
+}
+
+// Test that reconnection keeps trying while the server is down
+func TestClient_Reconnect_Unbounded(t *testing.T) {
+	client := NewClient("ws://127.0.0.1:1/ws")
+	client.initialDelay = time.Millisecond
+	client.maxDelay = 2 * time.Millisecond
+	client.disconnectedAt = time.Now()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	err := client.reconnect(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("reconnect() error = %v, want it to retry until the context ends", err)
+	}
+	if client.DisconnectedFor() < 100*time.Millisecond {
+		t.Errorf("DisconnectedFor() = %v", client.DisconnectedFor())
+	}
+}
+
+// Test the connection status before, during and after a connection
+func TestClient_Status(t *testing.T) {
+	server := newMockWSServer(t, func(conn *websocket.Conn) {
+		time.Sleep(500 * time.Millisecond)
+	})
+	defer server.Close()
+
+	client := NewClient("ws" + strings.TrimPrefix(server.URL, "http"))
+	if status := client.Status(); status.Connected || !status.Since.IsZero() {
+		t.Errorf("Status() before Connect = %+v, want never connected", status)
+	}
+
+	before := time.Now()
+	if err := client.dial(context.Background()); err != nil {
+		t.Fatalf("dial() error = %v", err)
+	}
+	status := client.Status()
+	if !status.Connected || status.Since.Before(before) {
+		t.Errorf("Status() after dial = %+v, want connected since %v", status, before)
+	}
+
+	client.mu.Lock()
+	conn := client.conn
+	client.mu.Unlock()
+	client.drop(conn)
+	if status := client.Status(); status.Connected || status.Since.IsZero() {
+		t.Errorf("Status() after drop = %+v, want disconnected since the drop", status)
+	}
+}
+
+// Test backoff growth, cap and jitter
+func TestClient_Backoff(t *testing.T) {
+	client := NewClient("wss://test.example.com/ws")
+	for attempt, want := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second} {
+		for range 20 {
+			if got := client.backoff(attempt); got < want/2 || got > want {
+				t.Fatalf("backoff(%d) = %v, want between %v and %v", attempt, got, want/2, want)
+			}
+		}
+	}
+	if got := client.backoff(20); got > maxRetryDelay || got < maxRetryDelay/2 {
+		t.Errorf("backoff(20) = %v, want capped at %v", got, maxRetryDelay)
+	}
+}
+
 // Benchmark isDuplicate operation
 func BenchmarkClient_IsDuplicate(b *testing.B) {
 	client := NewClient("wss://test.example.com/ws")
@@ -0,0 +1,154 @@
+package p2pquake
+
+import (
+	"time"
+
+	"github.com/otiai10/namazu/backend/internal/source"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Message codes of P2P地震情報
+const (
+	CodeJMAQuake = 551 // 地震情報
+	CodeEEW      = 556 // 緊急地震速報（警報）
+)
+
+// scaleOrAbove is the scaleTo value of an area forecast as "scaleFrom or above"
+const scaleOrAbove = 99
+
+// EEW represents an Earthquake Early Warning (code 556).
+// A warning is issued several times per earthquake as the estimate improves;
+// each issue has its own ID and the same Issue.EventID.
+type EEW struct {
+	ID         string         `json:"_id"`
+	Code       int            `json:"code"` // Should be 556
+	Time       string         `json:"time"`
+	Test       bool           `json:"test"` // Test broadcasts must not be delivered
+	Cancelled  bool           `json:"cancelled"`
+	Issue      EEWIssue       `json:"issue"`
+	Earthquake *EEWEarthquake `json:"earthquake,omitempty"`
+	Areas      []EEWArea      `json:"areas,omitempty"`
+	// Added fields for Event interface
+	ReceivedAt   time.Time         `json:"-"`
+	RawJSON      string            `json:"-"`
+	TraceContext trace.SpanContext `json:"-"` // Span of the receipt
+}
+
+// EEWIssue identifies the warning and its revision
+type EEWIssue struct {
+	Time    string `json:"time"`
+	EventID string `json:"eventId"`
+	Serial  string `json:"serial"`
+}
+
+// EEWEarthquake contains the estimated hypocenter
+type EEWEarthquake struct {
+	OriginTime  string     `json:"originTime"`
+	ArrivalTime string     `json:"arrivalTime"`
+	Condition   string     `json:"condition,omitempty"`
+	Hypocenter  Hypocenter `json:"hypocenter"`
+}
+
+// EEWArea contains the forecast intensity of an area
+type EEWArea struct {
+	Prefecture  string `json:"pref"`
+	Name        string `json:"name"`
+	ScaleFrom   int    `json:"scaleFrom"`
+	ScaleTo     int    `json:"scaleTo"` // 99 means "ScaleFrom or above"
+	KindCode    string `json:"kindCode"`
+	ArrivalTime string `json:"arrivalTime,omitempty"`
+}
+
+// Compile-time interface checks
+var (
+	_ source.Event   = (*EEW)(nil)
+	_ source.Located = (*EEW)(nil)
+)
+
+// GetID returns the unique identifier of this issue
+func (e *EEW) GetID() string {
+	return e.ID
+}
+
+// GetType returns the event type
+func (e *EEW) GetType() source.EventType {
+	return source.EventTypeEEW
+}
+
+// GetSource returns the data source identifier
+func (e *EEW) GetSource() string {
+	return "p2pquake"
+}
+
+// GetSeverity returns the normalized severity (0-100) of the highest forecast intensity.
+// Cancellations have no severity.
+func (e *EEW) GetSeverity() int {
+	if e.Cancelled {
+		return 0
+	}
+	maxScale := 0
+	for _, a := range e.Areas {
+		scale := a.ScaleTo
+		if scale == scaleOrAbove {
+			scale = a.ScaleFrom
+		}
+		if scale > maxScale {
+			maxScale = scale
+		}
+	}
+	return ScaleToSeverity(maxScale)
+}
+
+// GetAffectedAreas returns the prefectures with a forecast
+func (e *EEW) GetAffectedAreas() []string {
+	seen := make(map[string]bool)
+	areas := []string{}
+
+	for _, a := range e.Areas {
+		if !seen[a.Prefecture] {
+			seen[a.Prefecture] = true
+			areas = append(areas, a.Prefecture)
+		}
+	}
+
+	return areas
+}
+
+// GetOccurredAt returns the estimated origin time
+func (e *EEW) GetOccurredAt() time.Time {
+	if e.Earthquake != nil && e.Earthquake.OriginTime != "" {
+		t, err := ParseP2PTime(e.Earthquake.OriginTime)
+		if err == nil {
+			return t
+		}
+	}
+	t, _ := ParseP2PTime(e.Time)
+	return t
+}
+
+// GetHypocenter returns the estimated hypocenter, or nil if it is not reported
+func (e *EEW) GetHypocenter() *source.Hypocenter {
+	if e.Earthquake == nil {
+		return nil
+	}
+	h := e.Earthquake.Hypocenter
+	if h.Latitude <= -200 || h.Longitude <= -200 {
+		return nil
+	}
+	return &source.Hypocenter{Name: h.Name, Latitude: h.Latitude, Longitude: h.Longitude, Depth: h.Depth, Magnitude: h.Magnitude}
+}
+
+// GetReceivedAt returns when the event was received
+func (e *EEW) GetReceivedAt() time.Time {
+	return e.ReceivedAt
+}
+
+// GetRawJSON returns the original JSON
+func (e *EEW) GetRawJSON() string {
+	return e.RawJSON
+}
+
+// GetSpanContext returns the span of the receipt
+func (e *EEW) GetSpanContext() trace.SpanContext {
+	return e.TraceContext
+}
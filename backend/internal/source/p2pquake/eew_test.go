@@ -0,0 +1,112 @@
+package p2pquake
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/otiai10/namazu/backend/internal/source"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const sampleEEW = `{
+  "_id": "6027e4e2d55e1a1d7d9c1f7e",
+  "code": 556,
+  "time": "2021/02/13 23:08:08.392",
+  "test": false,
+  "cancelled": false,
+  "issue": {"time": "2021/02/13 23:08:07", "eventId": "20210213230759", "serial": "3"},
+  "earthquake": {
+    "originTime": "2021/02/13 23:07:50",
+    "arrivalTime": "2021/02/13 23:08:00",
+    "hypocenter": {"name": "福島県沖", "latitude": 37.7, "longitude": 141.8, "depth": 50, "magnitude": 7.1}
+  },
+  "areas": [
+    {"pref": "福島県", "name": "福島県中通り", "scaleFrom": 55, "scaleTo": 99, "kindCode": "19"},
+    {"pref": "福島県", "name": "福島県浜通り", "scaleFrom": 50, "scaleTo": 55, "kindCode": "10"},
+    {"pref": "宮城県", "name": "宮城県南部", "scaleFrom": 45, "scaleTo": 50, "kindCode": "10"}
+  ]
+}`
+
+func parseSampleEEW(t *testing.T) *EEW {
+	t.Helper()
+	var eew EEW
+	if err := json.Unmarshal([]byte(sampleEEW), &eew); err != nil {
+		t.Fatalf("failed to parse sample: %v", err)
+	}
+	return &eew
+}
+
+func TestEEW_Event(t *testing.T) {
+	eew := parseSampleEEW(t)
+
+	if eew.GetID() != "6027e4e2d55e1a1d7d9c1f7e" {
+		t.Errorf("GetID() = %q", eew.GetID())
+	}
+	if eew.GetType() != source.EventTypeEEW {
+		t.Errorf("GetType() = %q, want %q", eew.GetType(), source.EventTypeEEW)
+	}
+	if eew.GetSource() != "p2pquake" {
+		t.Errorf("GetSource() = %q", eew.GetSource())
+	}
+	// "震度6弱以上" counts as 6弱
+	if got, want := eew.GetSeverity(), ScaleToSeverity(Scale6Weak); got != want {
+		t.Errorf("GetSeverity() = %d, want %d", got, want)
+	}
+	if areas := eew.GetAffectedAreas(); len(areas) != 2 || areas[0] != "福島県" || areas[1] != "宮城県" {
+		t.Errorf("GetAffectedAreas() = %v, want [福島県 宮城県]", areas)
+	}
+	if got := eew.GetOccurredAt().Format("2006-01-02 15:04:05"); got != "2021-02-13 23:07:50" {
+		t.Errorf("GetOccurredAt() = %s", got)
+	}
+	if h := eew.GetHypocenter(); h == nil || h.Latitude != 37.7 || h.Magnitude != 7.1 {
+		t.Errorf("GetHypocenter() = %+v", h)
+	}
+}
+
+func TestEEW_Cancelled(t *testing.T) {
+	eew := parseSampleEEW(t)
+	eew.Cancelled = true
+
+	if eew.GetSeverity() != 0 {
+		t.Errorf("GetSeverity() = %d, want 0 for a cancellation", eew.GetSeverity())
+	}
+}
+
+func TestEEW_WithoutEarthquake(t *testing.T) {
+	eew := &EEW{Time: "2021/02/13 23:08:08"}
+
+	if eew.GetHypocenter() != nil {
+		t.Error("GetHypocenter() should be nil without an earthquake")
+	}
+	if eew.GetOccurredAt().IsZero() {
+		t.Error("GetOccurredAt() should fall back to the message time")
+	}
+	if areas := eew.GetAffectedAreas(); len(areas) != 0 {
+		t.Errorf("GetAffectedAreas() = %v, want empty", areas)
+	}
+}
+
+func TestParseEvent(t *testing.T) {
+	event, err := parseEvent(trace.SpanContext{}, CodeEEW, []byte(sampleEEW))
+	if err != nil {
+		t.Fatalf("parseEvent() error = %v", err)
+	}
+	eew, ok := event.(*EEW)
+	if !ok {
+		t.Fatalf("parseEvent() = %T, want *EEW", event)
+	}
+	if eew.RawJSON != sampleEEW || eew.ReceivedAt.IsZero() {
+		t.Error("parseEvent() should set RawJSON and ReceivedAt")
+	}
+
+	test := parseSampleEEW(t)
+	test.Test = true
+	data, _ := json.Marshal(test)
+	if event, err := parseEvent(trace.SpanContext{}, CodeEEW, data); err != nil || event != nil {
+		t.Errorf("parseEvent() = %v, %v, want test broadcasts dropped", event, err)
+	}
+
+	if _, err := parseEvent(trace.SpanContext{}, CodeEEW, []byte("{")); err == nil {
+		t.Error("parseEvent() should fail on invalid JSON")
+	}
+}
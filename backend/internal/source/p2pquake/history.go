@@ -0,0 +1,127 @@
+package p2pquake
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/otiai10/namazu/backend/internal/source"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// historyLimit is the number of messages fetched from the history API, its maximum
+const historyLimit = 100
+
+// History fetches past messages from the P2P地震情報 REST API (/v2/history),
+// to recover the events missed while the WebSocket was down
+type History struct {
+	url        string
+	httpClient *http.Client
+}
+
+// NewHistory creates a history client for the given /v2/history URL
+func NewHistory(historyURL string) *History {
+	return &History{
+		url:        historyURL,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// HistoryURL derives the /v2/history URL from a WebSocket endpoint,
+// e.g. "wss://api.p2pquake.net/v2/ws" -> "https://api.p2pquake.net/v2/history"
+func HistoryURL(wsEndpoint string) string {
+	u, err := url.Parse(wsEndpoint)
+	if err != nil {
+		return ""
+	}
+	switch u.Scheme {
+	case "wss":
+		u.Scheme = "https"
+	case "ws":
+		u.Scheme = "http"
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/ws") + "/history"
+	u.RawQuery = ""
+	return u.String()
+}
+
+// Since returns the earthquake information (code 551) that occurred after
+// the given time, oldest first, marked as backfilled.
+// Early warnings are not recovered: they are worthless once the shaking has arrived.
+func (h *History) Since(ctx context.Context, after time.Time) ([]source.Event, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s?codes=%d&limit=%d", h.url, CodeJMAQuake, historyLimit), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := h.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch history: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch history: status %d", resp.StatusCode)
+	}
+
+	var messages []json.RawMessage
+	if err := json.NewDecoder(resp.Body).Decode(&messages); err != nil {
+		return nil, fmt.Errorf("failed to decode history: %w", err)
+	}
+
+	var events []source.Event
+	for _, data := range messages {
+		quake, err := parseHistoryMessage(data)
+		if err != nil || quake == nil {
+			continue
+		}
+		if quake.GetOccurredAt().After(after) {
+			events = append(events, quake)
+		}
+	}
+	// The API returns the newest first
+	slices.Reverse(events)
+	return events, nil
+}
+
+// parseHistoryMessage parses a history message, which carries its ID as "id"
+// where WebSocket messages have "_id". Returns nil for other codes.
+func parseHistoryMessage(data []byte) (*JMAQuake, error) {
+	var raw struct {
+		ID   string `json:"id"`
+		WSID string `json:"_id"`
+		Code int    `json:"code"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	if raw.Code != CodeJMAQuake {
+		return nil, nil
+	}
+	if raw.WSID == "" {
+		if raw.ID == "" {
+			return nil, fmt.Errorf("message has no id")
+		}
+		// Deliver the same JSON as the WebSocket, so receivers find "_id"
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(data, &fields); err != nil {
+			return nil, err
+		}
+		fields["_id"], _ = json.Marshal(raw.ID)
+		var err error
+		if data, err = json.Marshal(fields); err != nil {
+			return nil, err
+		}
+	}
+
+	event, err := parseEvent(trace.SpanContext{}, raw.Code, data)
+	if err != nil {
+		return nil, err
+	}
+	quake := event.(*JMAQuake)
+	quake.Backfilled = true
+	return quake, nil
+}
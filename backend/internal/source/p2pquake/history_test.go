@@ -0,0 +1,71 @@
+package p2pquake
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/otiai10/namazu/backend/internal/source"
+)
+
+func TestHistoryURL(t *testing.T) {
+	tests := []struct {
+		endpoint string
+		want     string
+	}{
+		{endpoint: "wss://api.p2pquake.net/v2/ws", want: "https://api.p2pquake.net/v2/history"},
+		{endpoint: "ws://localhost:8080/v2/ws?token=x", want: "http://localhost:8080/v2/history"},
+	}
+	for _, tt := range tests {
+		if got := HistoryURL(tt.endpoint); got != tt.want {
+			t.Errorf("HistoryURL(%q) = %q, want %q", tt.endpoint, got, tt.want)
+		}
+	}
+}
+
+func TestHistory_Since(t *testing.T) {
+	// Newest first, as returned by the API; history messages carry "id" instead of "_id"
+	body := `[
+		{"id": "h3", "code": 551, "time": "2024/01/01 16:20:00.000", "earthquake": {"time": "2024/01/01 16:18:00", "maxScale": 40}},
+		{"id": "h2", "code": 556, "time": "2024/01/01 16:15:00.000"},
+		{"id": "h1", "code": 551, "time": "2024/01/01 16:12:00.000", "earthquake": {"time": "2024/01/01 16:10:00", "maxScale": 70}},
+		{"id": "h0", "code": 551, "time": "2024/01/01 16:00:00.000", "earthquake": {"time": "2024/01/01 15:58:00", "maxScale": 10}}
+	]`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("codes") != "551" {
+			t.Errorf("codes = %q, want 551", r.URL.Query().Get("codes"))
+		}
+		_, _ = w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	after := time.Date(2024, 1, 1, 16, 5, 0, 0, time.FixedZone("JST", 9*60*60))
+	events, err := NewHistory(server.URL).Since(context.Background(), after)
+	if err != nil {
+		t.Fatalf("Since() error = %v", err)
+	}
+	if len(events) != 2 || events[0].GetID() != "h1" || events[1].GetID() != "h3" {
+		t.Fatalf("expected h1 and h3 oldest first, got %v", events)
+	}
+	for _, event := range events {
+		if !source.IsBackfilled(event) {
+			t.Errorf("expected %s to be marked as backfilled", event.GetID())
+		}
+	}
+	if quake, err := ParseMessage([]byte(events[0].GetRawJSON())); err != nil || quake.GetID() != "h1" {
+		t.Errorf("expected the raw JSON to carry \"_id\", got %s", events[0].GetRawJSON())
+	}
+}
+
+func TestHistory_Since_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	if _, err := NewHistory(server.URL).Since(context.Background(), time.Now()); err == nil {
+		t.Error("expected an error for a failed request")
+	}
+}
@@ -7,7 +7,7 @@
 //   - Automatic reconnection every 9 minutes (before 10-minute forced disconnect)
 //   - Exponential backoff retry on connection errors
 //   - Message deduplication using LRU cache (keeps last 1000 IDs)
-//   - Filters for code 551 (JMAQuake) events only
+//   - Filters for code 551 (JMAQuake) and 556 (EEW) events only
 //   - Thread-safe operations with mutex protection
 //
 // Example usage:
@@ -21,7 +21,10 @@
 //	defer client.Close()
 //
 //	for event := range client.Events() {
-//	    quake := event.(*p2pquake.JMAQuake)
+//	    quake, ok := event.(*p2pquake.JMAQuake)
+//	    if !ok {
+//	        continue // *p2pquake.EEW
+//	    }
 //	    fmt.Printf("Earthquake: %s, Severity: %d\n",
 //	        quake.Earthquake.Hypocenter.Name,
 //	        event.GetSeverity())
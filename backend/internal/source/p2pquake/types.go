@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/otiai10/namazu/backend/internal/source"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Scale constants for Japanese seismic intensity scale
@@ -28,8 +29,10 @@ type JMAQuake struct {
 	Earthquake *Earthquake `json:"earthquake,omitempty"`
 	Points     []Point     `json:"points,omitempty"`
 	// Added fields for Event interface
-	ReceivedAt time.Time `json:"-"`
-	RawJSON    string    `json:"-"`
+	ReceivedAt   time.Time         `json:"-"`
+	RawJSON      string            `json:"-"`
+	TraceContext trace.SpanContext `json:"-"` // Span of the receipt
+	Backfilled   bool              `json:"-"` // Recovered from the history API after a reconnection
 }
 
 // Issue contains information about when/who issued the report
@@ -66,8 +69,11 @@ type Point struct {
 	IsArea     bool   `json:"isArea"`
 }
 
-// Compile-time interface check
-var _ source.Event = (*JMAQuake)(nil)
+// Compile-time interface checks
+var (
+	_ source.Event   = (*JMAQuake)(nil)
+	_ source.Located = (*JMAQuake)(nil)
+)
 
 // GetID returns the unique identifier
 func (q *JMAQuake) GetID() string {
@@ -123,6 +129,19 @@ func (q *JMAQuake) GetOccurredAt() time.Time {
 	return t
 }
 
+// GetHypocenter returns the hypocenter, or nil if it is not reported.
+// P2P地震情報 uses -200 for unknown coordinates and -1 for unknown magnitude.
+func (q *JMAQuake) GetHypocenter() *source.Hypocenter {
+	if q.Earthquake == nil {
+		return nil
+	}
+	h := q.Earthquake.Hypocenter
+	if h.Latitude <= -200 || h.Longitude <= -200 {
+		return nil
+	}
+	return &source.Hypocenter{Name: h.Name, Latitude: h.Latitude, Longitude: h.Longitude, Depth: h.Depth, Magnitude: h.Magnitude}
+}
+
 // GetReceivedAt returns when the event was received
 func (q *JMAQuake) GetReceivedAt() time.Time {
 	return q.ReceivedAt
@@ -133,6 +152,16 @@ func (q *JMAQuake) GetRawJSON() string {
 	return q.RawJSON
 }
 
+// GetSpanContext returns the span of the receipt
+func (q *JMAQuake) GetSpanContext() trace.SpanContext {
+	return q.TraceContext
+}
+
+// IsBackfilled reports whether the event was recovered from the history API
+func (q *JMAQuake) IsBackfilled() bool {
+	return q.Backfilled
+}
+
 // ParseP2PTime parses time string from P2P地震情報 API
 // Format: "2024/01/15 12:34:56" in JST
 func ParseP2PTime(s string) (time.Time, error) {
@@ -166,6 +195,18 @@ func ScaleToSeverity(scale int) int {
 	}
 }
 
+// SeverityToScale converts a normalized severity back to the highest JMA scale
+// (10-70) it reaches, or 0 below 震度1
+func SeverityToScale(severity int) int {
+	scale := 0
+	for _, s := range []int{Scale1, Scale2, Scale3, Scale4, Scale5Weak, Scale5Strong, Scale6Weak, Scale6Strong, Scale7} {
+		if severity >= ScaleToSeverity(s) {
+			scale = s
+		}
+	}
+	return scale
+}
+
 // ScaleToString returns human-readable scale name
 func ScaleToString(scale int) string {
 	switch scale {
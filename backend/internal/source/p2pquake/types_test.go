@@ -38,6 +38,20 @@ func TestScaleToSeverity(t *testing.T) {
 	}
 }
 
+func TestSeverityToScale(t *testing.T) {
+	for _, scale := range []int{Scale1, Scale3, Scale5Weak, Scale6Strong, Scale7} {
+		if got := SeverityToScale(ScaleToSeverity(scale)); got != scale {
+			t.Errorf("SeverityToScale(ScaleToSeverity(%d)) = %d", scale, got)
+		}
+	}
+	if got := SeverityToScale(65); got != Scale5Strong {
+		t.Errorf("SeverityToScale(65) = %d, want %d", got, Scale5Strong)
+	}
+	if got := SeverityToScale(5); got != 0 {
+		t.Errorf("SeverityToScale(5) = %d, want 0", got)
+	}
+}
+
 // Test ScaleToString function
 func TestScaleToString(t *testing.T) {
 	tests := []struct {
@@ -430,6 +444,39 @@ func TestJMAQuake_GetRawJSON(t *testing.T) {
 }
 
 // Test that JMAQuake implements Event interface
+func TestJMAQuake_GetHypocenter(t *testing.T) {
+	tests := []struct {
+		name  string
+		quake *JMAQuake
+		want  *source.Hypocenter
+	}{
+		{
+			name:  "no earthquake",
+			quake: &JMAQuake{},
+			want:  nil,
+		},
+		{
+			name:  "unknown hypocenter",
+			quake: &JMAQuake{Earthquake: &Earthquake{Hypocenter: Hypocenter{Latitude: -200, Longitude: -200, Magnitude: -1}}},
+			want:  nil,
+		},
+		{
+			name:  "known hypocenter",
+			quake: &JMAQuake{Earthquake: &Earthquake{Hypocenter: Hypocenter{Name: "石川県能登地方", Latitude: 37.5, Longitude: 137.2, Depth: 10, Magnitude: 7.6}}},
+			want:  &source.Hypocenter{Name: "石川県能登地方", Latitude: 37.5, Longitude: 137.2, Depth: 10, Magnitude: 7.6},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.quake.GetHypocenter()
+			if (got == nil) != (tt.want == nil) || (got != nil && *got != *tt.want) {
+				t.Errorf("GetHypocenter() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestJMAQuake_ImplementsEventInterface(t *testing.T) {
 	var _ source.Event = (*JMAQuake)(nil)
 }
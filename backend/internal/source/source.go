@@ -3,6 +3,8 @@ package source
 import (
 	"context"
 	"time"
+
+	"go.opentelemetry.io/otel/trace"
 )
 
 // EventType represents different event types
@@ -11,6 +13,7 @@ type EventType string
 const (
 	EventTypeEarthquake EventType = "earthquake"
 	EventTypeTsunami    EventType = "tsunami"
+	EventTypeEEW        EventType = "eew" // Earthquake Early Warning
 )
 
 // Source represents a data source that provides events
@@ -20,6 +23,18 @@ type Source interface {
 	Close() error
 }
 
+// Status is the connection state of a source
+type Status struct {
+	Connected bool
+	Since     time.Time // When the source connected or disconnected; zero if it never connected
+}
+
+// Monitored is implemented by sources that hold a connection, so that
+// readiness checks can tell whether events are being received.
+type Monitored interface {
+	Status() Status
+}
+
 // Event represents a generic event from any source
 type Event interface {
 	GetID() string
@@ -31,3 +46,38 @@ type Event interface {
 	GetReceivedAt() time.Time
 	GetRawJSON() string
 }
+
+// Traced is implemented by events that remember the span of their receipt,
+// so that processing them continues the same trace.
+// GetSpanContext returns an invalid span context when tracing is disabled.
+type Traced interface {
+	GetSpanContext() trace.SpanContext
+}
+
+// Backfilled is implemented by events that can be recovered after they were
+// missed live, e.g. from a history API after a reconnection.
+// IsBackfilled reports whether this event was recovered that way.
+type Backfilled interface {
+	IsBackfilled() bool
+}
+
+// IsBackfilled reports whether event was recovered after being missed live
+func IsBackfilled(event Event) bool {
+	b, ok := event.(Backfilled)
+	return ok && b.IsBackfilled()
+}
+
+// Hypocenter is the location and size of an earthquake
+type Hypocenter struct {
+	Name      string // e.g. "石川県能登地方"; empty if unknown
+	Latitude  float64
+	Longitude float64
+	Depth     int     // km, negative if unknown
+	Magnitude float64 // Negative if unknown
+}
+
+// Located is implemented by events that can report a hypocenter.
+// GetHypocenter returns nil when the event has none (e.g. intensity-only reports).
+type Located interface {
+	GetHypocenter() *Hypocenter
+}
@@ -0,0 +1,287 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// DeliveryRecord is the final outcome of delivering one event to one subscription
+type DeliveryRecord struct {
+	ID             string    `firestore:"-"`
+	SubscriptionID string    `firestore:"subscriptionId"`
+	UserID         string    `firestore:"userId"`
+	EventID        string    `firestore:"eventId"`
+	DeliveryID     string    `firestore:"deliveryId,omitempty"` // Sent as X-Delivery-Id; shared by retries
+	URL            string    `firestore:"url"`
+	StatusCode     int       `firestore:"statusCode"`
+	Success        bool      `firestore:"success"`
+	ErrorMessage   string    `firestore:"errorMessage"`
+	Attempts       int       `firestore:"attempts"`               // Requests made, including retries
+	ResponseTimeMs int64     `firestore:"responseTimeMs"`         // Duration of the last attempt
+	ResponseBody   string    `firestore:"responseBody,omitempty"` // Leading bytes of the last response
+	PayloadSHA256  string    `firestore:"payloadSha256"`          // Hex digest of the delivered body
+	Redelivery     bool      `firestore:"redelivery,omitempty"`   // Manually re-sent by the user
+	DeliveredAt    time.Time `firestore:"deliveredAt"`
+}
+
+// DeliveryRepository defines the interface for delivery record storage
+type DeliveryRepository interface {
+	// Create stores a delivery record and returns its ID
+	Create(ctx context.Context, record DeliveryRecord) (string, error)
+
+	// Get returns a delivery record by ID.
+	// Returns nil and no error if it does not exist.
+	Get(ctx context.Context, id string) (*DeliveryRecord, error)
+
+	// ListBySubscription returns the subscription's records delivered in [from, to),
+	// ordered by deliveredAt ascending
+	ListBySubscription(ctx context.Context, subscriptionID string, from, to time.Time) ([]DeliveryRecord, error)
+
+	// LastSuccess returns the subscription's most recent successful delivery.
+	// Returns nil and no error if there is none.
+	LastSuccess(ctx context.Context, subscriptionID string) (*DeliveryRecord, error)
+
+	// SummarizeEvent counts the delivery records of an event across all subscriptions
+	SummarizeEvent(ctx context.Context, eventID string) (DeliverySummary, error)
+
+	// SummarizeSubscription counts the subscription's records delivered in [from, to)
+	SummarizeSubscription(ctx context.Context, subscriptionID string, from, to time.Time) (DeliverySummary, error)
+
+	// DeleteBySubscription removes every record of the subscription and
+	// returns how many were removed
+	DeleteBySubscription(ctx context.Context, subscriptionID string) (int, error)
+}
+
+// DeliverySummary counts the outcomes of delivering one event.
+// Manual redeliveries are counted as separate records.
+type DeliverySummary struct {
+	Delivered int
+	Failed    int
+}
+
+// FirestoreDeliveryRepository implements DeliveryRepository using Firestore
+type FirestoreDeliveryRepository struct {
+	client     *firestore.Client
+	collection string
+}
+
+// Compile-time interface check
+var _ DeliveryRepository = (*FirestoreDeliveryRepository)(nil)
+
+// NewFirestoreDeliveryRepository creates a new FirestoreDeliveryRepository
+func NewFirestoreDeliveryRepository(client *firestore.Client) *FirestoreDeliveryRepository {
+	return &FirestoreDeliveryRepository{
+		client:     client,
+		collection: "deliveries",
+	}
+}
+
+// Create stores a delivery record in Firestore
+func (r *FirestoreDeliveryRepository) Create(ctx context.Context, record DeliveryRecord) (string, error) {
+	if r.client == nil {
+		return "", fmt.Errorf("firestore client is nil")
+	}
+	if record.SubscriptionID == "" {
+		return "", fmt.Errorf("subscription ID is required")
+	}
+	if record.DeliveredAt.IsZero() {
+		record.DeliveredAt = time.Now()
+	}
+
+	docRef, _, err := r.client.Collection(r.collection).Add(ctx, record)
+	if err != nil {
+		return "", fmt.Errorf("failed to create delivery record: %w", err)
+	}
+
+	return docRef.ID, nil
+}
+
+// Get retrieves a delivery record by ID from Firestore
+func (r *FirestoreDeliveryRepository) Get(ctx context.Context, id string) (*DeliveryRecord, error) {
+	if r.client == nil {
+		return nil, fmt.Errorf("firestore client is nil")
+	}
+	if id == "" {
+		return nil, fmt.Errorf("delivery ID is required")
+	}
+
+	docSnap, err := r.client.Collection(r.collection).Doc(id).Get(ctx)
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get delivery record: %w", err)
+	}
+
+	var record DeliveryRecord
+	if err := docSnap.DataTo(&record); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal delivery record: %w", err)
+	}
+	record.ID = docSnap.Ref.ID
+	return &record, nil
+}
+
+// ListBySubscription returns delivery records of a subscription in a time range.
+// Requires a composite index on (subscriptionId, deliveredAt).
+func (r *FirestoreDeliveryRepository) ListBySubscription(ctx context.Context, subscriptionID string, from, to time.Time) ([]DeliveryRecord, error) {
+	if r.client == nil {
+		return nil, fmt.Errorf("firestore client is nil")
+	}
+
+	iter := r.client.Collection(r.collection).
+		Where("subscriptionId", "==", subscriptionID).
+		Where("deliveredAt", ">=", from).
+		Where("deliveredAt", "<", to).
+		OrderBy("deliveredAt", firestore.Asc).
+		Documents(ctx)
+	defer iter.Stop()
+
+	records := make([]DeliveryRecord, 0)
+	for {
+		docSnap, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to iterate delivery records: %w", err)
+		}
+
+		var record DeliveryRecord
+		if err := docSnap.DataTo(&record); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal delivery record: %w", err)
+		}
+		record.ID = docSnap.Ref.ID
+		records = append(records, record)
+	}
+
+	return records, nil
+}
+
+// LastSuccess returns the most recent successful delivery of a subscription.
+// Requires a composite index on (subscriptionId, success, deliveredAt desc).
+func (r *FirestoreDeliveryRepository) LastSuccess(ctx context.Context, subscriptionID string) (*DeliveryRecord, error) {
+	if r.client == nil {
+		return nil, fmt.Errorf("firestore client is nil")
+	}
+
+	iter := r.client.Collection(r.collection).
+		Where("subscriptionId", "==", subscriptionID).
+		Where("success", "==", true).
+		OrderBy("deliveredAt", firestore.Desc).
+		Limit(1).
+		Documents(ctx)
+	defer iter.Stop()
+
+	docSnap, err := iter.Next()
+	if err == iterator.Done {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query delivery records: %w", err)
+	}
+
+	var record DeliveryRecord
+	if err := docSnap.DataTo(&record); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal delivery record: %w", err)
+	}
+	record.ID = docSnap.Ref.ID
+	return &record, nil
+}
+
+// SummarizeEvent counts the delivery records of an event with count aggregations
+func (r *FirestoreDeliveryRepository) SummarizeEvent(ctx context.Context, eventID string) (DeliverySummary, error) {
+	if r.client == nil {
+		return DeliverySummary{}, fmt.Errorf("firestore client is nil")
+	}
+
+	all := r.client.Collection(r.collection).Where("eventId", "==", eventID)
+	result, err := all.NewAggregationQuery().WithCount("total").Get(ctx)
+	if err != nil {
+		return DeliverySummary{}, fmt.Errorf("failed to count delivery records: %w", err)
+	}
+	succeeded := all.Where("success", "==", true)
+	delivered, err := succeeded.NewAggregationQuery().WithCount("total").Get(ctx)
+	if err != nil {
+		return DeliverySummary{}, fmt.Errorf("failed to count delivery records: %w", err)
+	}
+
+	total, ok := aggregateCount(result, "total"), aggregateCount(delivered, "total")
+	return DeliverySummary{Delivered: ok, Failed: total - ok}, nil
+}
+
+// SummarizeSubscription counts the subscription's records delivered in [from, to)
+// with count aggregations.
+// Requires the composite index on (subscriptionId, success, deliveredAt) of LastSuccess.
+func (r *FirestoreDeliveryRepository) SummarizeSubscription(ctx context.Context, subscriptionID string, from, to time.Time) (DeliverySummary, error) {
+	if r.client == nil {
+		return DeliverySummary{}, fmt.Errorf("firestore client is nil")
+	}
+
+	all := r.client.Collection(r.collection).
+		Where("subscriptionId", "==", subscriptionID).
+		Where("deliveredAt", ">=", from).
+		Where("deliveredAt", "<", to)
+	result, err := all.NewAggregationQuery().WithCount("total").Get(ctx)
+	if err != nil {
+		return DeliverySummary{}, fmt.Errorf("failed to count delivery records: %w", err)
+	}
+	succeeded := all.Where("success", "==", true)
+	delivered, err := succeeded.NewAggregationQuery().WithCount("total").Get(ctx)
+	if err != nil {
+		return DeliverySummary{}, fmt.Errorf("failed to count delivery records: %w", err)
+	}
+
+	total, ok := aggregateCount(result, "total"), aggregateCount(delivered, "total")
+	return DeliverySummary{Delivered: ok, Failed: total - ok}, nil
+}
+
+// deleteBatchSize is how many records DeleteBySubscription reads and deletes at a time
+const deleteBatchSize = 500
+
+// DeleteBySubscription removes every record of the subscription in batches
+func (r *FirestoreDeliveryRepository) DeleteBySubscription(ctx context.Context, subscriptionID string) (int, error) {
+	if r.client == nil {
+		return 0, fmt.Errorf("firestore client is nil")
+	}
+	if subscriptionID == "" {
+		return 0, fmt.Errorf("subscription ID is required")
+	}
+
+	deleted := 0
+	for {
+		docs, err := r.client.Collection(r.collection).
+			Where("subscriptionId", "==", subscriptionID).
+			Limit(deleteBatchSize).
+			Documents(ctx).GetAll()
+		if err != nil {
+			return deleted, fmt.Errorf("failed to query delivery records: %w", err)
+		}
+		if len(docs) == 0 {
+			return deleted, nil
+		}
+
+		bw := r.client.BulkWriter(ctx)
+		jobs := make([]*firestore.BulkWriterJob, 0, len(docs))
+		for _, doc := range docs {
+			job, err := bw.Delete(doc.Ref)
+			if err != nil {
+				bw.End()
+				return deleted, fmt.Errorf("failed to delete delivery record: %w", err)
+			}
+			jobs = append(jobs, job)
+		}
+		bw.End()
+		for _, job := range jobs {
+			if _, err := job.Results(); err != nil {
+				return deleted, fmt.Errorf("failed to delete delivery record: %w", err)
+			}
+			deleted++
+		}
+	}
+}
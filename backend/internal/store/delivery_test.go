@@ -0,0 +1,40 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestNewFirestoreDeliveryRepository(t *testing.T) {
+	repo := NewFirestoreDeliveryRepository(nil)
+	if repo == nil {
+		t.Fatal("NewFirestoreDeliveryRepository returned nil")
+	}
+	if repo.collection != "deliveries" {
+		t.Errorf("collection = %q, want %q", repo.collection, "deliveries")
+	}
+}
+
+func TestFirestoreDeliveryRepository_NilClient(t *testing.T) {
+	repo := NewFirestoreDeliveryRepository(nil)
+	ctx := context.Background()
+
+	if _, err := repo.Create(ctx, DeliveryRecord{SubscriptionID: "sub-1", EventID: "evt-1"}); err == nil {
+		t.Error("Create() expected error for nil client")
+	}
+	if _, err := repo.Get(ctx, "delivery-1"); err == nil {
+		t.Error("Get() expected error for nil client")
+	}
+	now := time.Now()
+	if _, err := repo.ListBySubscription(ctx, "sub-1", now.Add(-time.Hour), now); err == nil {
+		t.Error("ListBySubscription() expected error for nil client")
+	}
+	if _, err := repo.LastSuccess(ctx, "sub-1"); err == nil {
+		t.Error("LastSuccess() expected error for nil client")
+	}
+}
+
+func TestFirestoreDeliveryRepository_ImplementsInterface(t *testing.T) {
+	var _ DeliveryRepository = (*FirestoreDeliveryRepository)(nil)
+}
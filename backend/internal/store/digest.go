@@ -0,0 +1,126 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// DigestEvent summarizes an event collected for a digest
+type DigestEvent struct {
+	ID            string    `json:"id" firestore:"id"`
+	Type          string    `json:"type" firestore:"type"`
+	Source        string    `json:"source" firestore:"source"`
+	Severity      int       `json:"severity" firestore:"severity"`
+	AffectedAreas []string  `json:"affected_areas" firestore:"affectedAreas"`
+	Hypocenter    string    `json:"hypocenter,omitempty" firestore:"hypocenter,omitempty"`
+	Magnitude     float64   `json:"magnitude,omitempty" firestore:"magnitude,omitempty"`
+	OccurredAt    time.Time `json:"occurred_at" firestore:"occurredAt"`
+}
+
+// PendingDigest holds the events collected for a subscription's next digest.
+// It is persisted so that collected events survive a process restart.
+type PendingDigest struct {
+	SubscriptionID string        `firestore:"subscriptionId"` // Also the document ID
+	StartedAt      time.Time     `firestore:"startedAt"`      // When the first event was collected
+	Count          int           `firestore:"count"`          // Events collected, including those not kept in Events
+	MaxSeverity    int           `firestore:"maxSeverity"`
+	Events         []DigestEvent `firestore:"events"` // At most MaxDigestEvents, oldest first
+}
+
+// MaxDigestEvents is the number of events kept in a digest; later events are only counted
+const MaxDigestEvents = 100
+
+// DigestRepository defines the interface for persisting pending digests
+type DigestRepository interface {
+	// Save creates or replaces the subscription's pending digest
+	Save(ctx context.Context, digest PendingDigest) error
+
+	// Delete removes a subscription's pending digest (no error if it does not exist)
+	Delete(ctx context.Context, subscriptionID string) error
+
+	// List returns all pending digests
+	List(ctx context.Context) ([]PendingDigest, error)
+}
+
+// FirestoreDigestRepository implements DigestRepository using Firestore
+type FirestoreDigestRepository struct {
+	client     *firestore.Client
+	collection string
+}
+
+// Compile-time interface check
+var _ DigestRepository = (*FirestoreDigestRepository)(nil)
+
+// NewFirestoreDigestRepository creates a new FirestoreDigestRepository
+func NewFirestoreDigestRepository(client *firestore.Client) *FirestoreDigestRepository {
+	return &FirestoreDigestRepository{
+		client:     client,
+		collection: "pending_digests",
+	}
+}
+
+// Save creates or replaces a pending digest in Firestore
+func (r *FirestoreDigestRepository) Save(ctx context.Context, digest PendingDigest) error {
+	if r.client == nil {
+		return fmt.Errorf("firestore client is nil")
+	}
+	if digest.SubscriptionID == "" {
+		return fmt.Errorf("subscription ID is required")
+	}
+
+	if _, err := r.client.Collection(r.collection).Doc(digest.SubscriptionID).Set(ctx, digest); err != nil {
+		return fmt.Errorf("failed to save pending digest: %w", err)
+	}
+	return nil
+}
+
+// Delete removes a pending digest from Firestore
+func (r *FirestoreDigestRepository) Delete(ctx context.Context, subscriptionID string) error {
+	if r.client == nil {
+		return fmt.Errorf("firestore client is nil")
+	}
+	if subscriptionID == "" {
+		return fmt.Errorf("subscription ID is required")
+	}
+
+	_, err := r.client.Collection(r.collection).Doc(subscriptionID).Delete(ctx)
+	if err != nil && status.Code(err) != codes.NotFound {
+		return fmt.Errorf("failed to delete pending digest: %w", err)
+	}
+	return nil
+}
+
+// List returns all pending digests
+func (r *FirestoreDigestRepository) List(ctx context.Context) ([]PendingDigest, error) {
+	if r.client == nil {
+		return nil, fmt.Errorf("firestore client is nil")
+	}
+
+	iter := r.client.Collection(r.collection).Documents(ctx)
+	defer iter.Stop()
+
+	digests := make([]PendingDigest, 0)
+	for {
+		docSnap, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to iterate pending digests: %w", err)
+		}
+
+		var digest PendingDigest
+		if err := docSnap.DataTo(&digest); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal pending digest: %w", err)
+		}
+		digest.SubscriptionID = docSnap.Ref.ID
+		digests = append(digests, digest)
+	}
+	return digests, nil
+}
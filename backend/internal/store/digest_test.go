@@ -0,0 +1,32 @@
+package store
+
+import (
+	"context"
+	"testing"
+)
+
+func TestNewFirestoreDigestRepository(t *testing.T) {
+	repo := NewFirestoreDigestRepository(nil)
+	if repo.collection != "pending_digests" {
+		t.Errorf("collection = %q, want %q", repo.collection, "pending_digests")
+	}
+}
+
+func TestFirestoreDigestRepository_NilClient(t *testing.T) {
+	repo := NewFirestoreDigestRepository(nil)
+	ctx := context.Background()
+
+	if err := repo.Save(ctx, PendingDigest{SubscriptionID: "sub-1"}); err == nil {
+		t.Error("Save() expected error for nil client")
+	}
+	if err := repo.Delete(ctx, "sub-1"); err == nil {
+		t.Error("Delete() expected error for nil client")
+	}
+	if _, err := repo.List(ctx); err == nil {
+		t.Error("List() expected error for nil client")
+	}
+}
+
+func TestFirestoreDigestRepository_ImplementsInterface(t *testing.T) {
+	var _ DigestRepository = (*FirestoreDigestRepository)(nil)
+}
@@ -2,11 +2,17 @@ package store
 
 import (
 	"context"
+	"encoding/base64"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"cloud.google.com/go/firestore"
+	"cloud.google.com/go/firestore/apiv1/firestorepb"
 	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	"github.com/otiai10/namazu/backend/internal/source"
 )
@@ -22,6 +28,7 @@ type EventRecord struct {
 	ReceivedAt    time.Time `firestore:"receivedAt"`
 	RawJSON       string    `firestore:"rawJson"`
 	CreatedAt     time.Time `firestore:"createdAt"`
+	Backfilled    bool      `firestore:"backfilled,omitempty"` // Recovered from the source's history after being missed live
 }
 
 // EventRepository defines the interface for event storage operations
@@ -29,11 +36,137 @@ type EventRepository interface {
 	// Create stores a new event and returns its ID
 	Create(ctx context.Context, event EventRecord) (string, error)
 
-	// Get retrieves an event by ID
+	// Get retrieves an event by ID.
+	// Returns nil and no error if it does not exist.
 	Get(ctx context.Context, id string) (*EventRecord, error)
 
 	// List retrieves events ordered by occurredAt descending with pagination
 	List(ctx context.Context, limit int, startAfter *time.Time) ([]EventRecord, error)
+
+	// Query retrieves a filtered page of events ordered by occurredAt.
+	// Returns ErrInvalidCursor if q.Cursor was not issued by a previous page.
+	Query(ctx context.Context, q EventQuery) (*EventPage, error)
+}
+
+// EventDeleter is implemented by event repositories that can delete events,
+// e.g. after they were archived
+type EventDeleter interface {
+	// Delete removes the events with the given IDs. Missing events are ignored.
+	Delete(ctx context.Context, ids []string) error
+}
+
+// Compile-time interface checks
+var (
+	_ EventDeleter = (*FirestoreEventRepository)(nil)
+	_ EventDeleter = (*SQLEventRepository)(nil)
+	_ EventDeleter = (*MemoryEventRepository)(nil)
+	_ EventDeleter = (*GuardedEventRepository)(nil)
+)
+
+// ErrInvalidCursor is returned by EventRepository.Query for a malformed cursor
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// EventQuery selects a page of events. Zero-valued filters match every event.
+type EventQuery struct {
+	Limit       int        // page size, 10 when zero
+	Cursor      string     // NextCursor of the previous page, empty for the first page
+	Ascending   bool       // oldest first instead of newest first
+	MinSeverity int        // severity >= MinSeverity
+	Type        string     // event type, e.g. "earthquake"
+	Prefecture  string     // exact match against AffectedAreas
+	From        *time.Time // occurredAt >= From
+	To          *time.Time // occurredAt < To
+}
+
+// EventPage is one page of an EventQuery
+type EventPage struct {
+	Events     []EventRecord
+	NextCursor string // empty on the last page
+	Total      int    // events matching the filters across all pages
+}
+
+// limit returns the page size with the default applied
+func (q EventQuery) limit() int {
+	if q.Limit <= 0 {
+		return 10 // Default limit
+	}
+	return q.Limit
+}
+
+// matches reports whether record passes the filters (the cursor is not considered)
+func (q EventQuery) matches(record EventRecord) bool {
+	if q.Type != "" && record.Type != q.Type {
+		return false
+	}
+	if q.MinSeverity > 0 && record.Severity < q.MinSeverity {
+		return false
+	}
+	if q.From != nil && record.OccurredAt.Before(*q.From) {
+		return false
+	}
+	if q.To != nil && !record.OccurredAt.Before(*q.To) {
+		return false
+	}
+	if q.Prefecture != "" {
+		for _, area := range record.AffectedAreas {
+			if area == q.Prefecture {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// eventCursor is the position of the last event of a page.
+// Events are ordered by occurredAt with the ID breaking ties.
+type eventCursor struct {
+	OccurredAt time.Time
+	ID         string
+}
+
+// encodeEventCursor returns the opaque cursor pointing after record
+func encodeEventCursor(record EventRecord) string {
+	raw := record.OccurredAt.UTC().Format(time.RFC3339Nano) + "|" + record.ID
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// decodeEventCursor parses a cursor from encodeEventCursor. An empty cursor returns nil.
+func decodeEventCursor(s string) (*eventCursor, error) {
+	if s == "" {
+		return nil, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	occurredAt, id, ok := strings.Cut(string(raw), "|")
+	if !ok || id == "" {
+		return nil, ErrInvalidCursor
+	}
+	t, err := time.Parse(time.RFC3339Nano, occurredAt)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return &eventCursor{OccurredAt: t, ID: id}, nil
+}
+
+// compare returns -1, 0 or +1 as record sorts before, at or after the cursor in ascending order
+func (c *eventCursor) compare(record EventRecord) int {
+	if cmp := record.OccurredAt.Compare(c.OccurredAt); cmp != 0 {
+		return cmp
+	}
+	return strings.Compare(record.ID, c.ID)
+}
+
+// newEventPage builds a page from up to limit+1 ordered records; the extra one signals a next page
+func newEventPage(records []EventRecord, limit, total int) *EventPage {
+	page := &EventPage{Events: records, Total: total}
+	if len(records) > limit {
+		page.Events = records[:limit]
+		page.NextCursor = encodeEventCursor(page.Events[limit-1])
+	}
+	return page
 }
 
 // FirestoreEventRepository implements EventRepository using Firestore
@@ -94,6 +227,7 @@ func createEventRecord(event EventRecord) EventRecord {
 		ReceivedAt:    event.ReceivedAt,
 		RawJSON:       event.RawJSON,
 		CreatedAt:     time.Now(),
+		Backfilled:    event.Backfilled,
 	}
 }
 
@@ -119,6 +253,9 @@ func (r *FirestoreEventRepository) Get(ctx context.Context, id string) (*EventRe
 
 	docSnap, err := r.client.Collection(r.collection).Doc(id).Get(ctx)
 	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, nil
+		}
 		return nil, fmt.Errorf("failed to get event: %w", err)
 	}
 
@@ -173,6 +310,110 @@ func (r *FirestoreEventRepository) List(ctx context.Context, limit int, startAft
 	return records, nil
 }
 
+// Query retrieves a filtered page of events ordered by occurredAt.
+// Filters combined with the ordering need the composite indexes defined in infra.
+func (r *FirestoreEventRepository) Query(ctx context.Context, q EventQuery) (*EventPage, error) {
+	if r.client == nil {
+		return nil, fmt.Errorf("firestore client is nil")
+	}
+	cursor, err := decodeEventCursor(q.Cursor)
+	if err != nil {
+		return nil, err
+	}
+	limit := q.limit()
+
+	filtered := r.client.Collection(r.collection).Query
+	if q.Type != "" {
+		filtered = filtered.Where("type", "==", q.Type)
+	}
+	if q.Prefecture != "" {
+		filtered = filtered.Where("affectedAreas", "array-contains", q.Prefecture)
+	}
+	if q.MinSeverity > 0 {
+		filtered = filtered.Where("severity", ">=", q.MinSeverity)
+	}
+	if q.From != nil {
+		filtered = filtered.Where("occurredAt", ">=", *q.From)
+	}
+	if q.To != nil {
+		filtered = filtered.Where("occurredAt", "<", *q.To)
+	}
+
+	count, err := filtered.NewAggregationQuery().WithCount("total").Get(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count events: %w", err)
+	}
+	total := aggregateCount(count, "total")
+
+	dir := firestore.Desc
+	if q.Ascending {
+		dir = firestore.Asc
+	}
+	query := filtered.OrderBy("occurredAt", dir).OrderBy(firestore.DocumentID, dir).Limit(limit + 1)
+	if cursor != nil {
+		query = query.StartAfter(cursor.OccurredAt, cursor.ID)
+	}
+
+	iter := query.Documents(ctx)
+	defer iter.Stop()
+
+	records := make([]EventRecord, 0, limit+1)
+	for {
+		docSnap, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to iterate events: %w", err)
+		}
+
+		var record EventRecord
+		if err := docSnap.DataTo(&record); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal event: %w", err)
+		}
+		record.ID = docSnap.Ref.ID
+		records = append(records, record)
+	}
+
+	return newEventPage(records, limit, total), nil
+}
+
+// Delete removes the events with the given IDs. Missing events are ignored.
+func (r *FirestoreEventRepository) Delete(ctx context.Context, ids []string) error {
+	if r.client == nil {
+		return fmt.Errorf("firestore client is nil")
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	bw := r.client.BulkWriter(ctx)
+	jobs := make([]*firestore.BulkWriterJob, 0, len(ids))
+	for _, id := range ids {
+		job, err := bw.Delete(r.client.Collection(r.collection).Doc(id))
+		if err != nil {
+			bw.End()
+			return fmt.Errorf("failed to delete event: %w", err)
+		}
+		jobs = append(jobs, job)
+	}
+	bw.End()
+	for _, job := range jobs {
+		if _, err := job.Results(); err != nil {
+			return fmt.Errorf("failed to delete event: %w", err)
+		}
+	}
+	return nil
+}
+
+// aggregateCount reads a WithCount result
+func aggregateCount(result firestore.AggregationResult, alias string) int {
+	if v, ok := result[alias].(*firestorepb.Value); ok {
+		return int(v.GetIntegerValue())
+	}
+	return 0
+}
+
 // EventFromSource converts a source.Event to EventRecord
 func EventFromSource(event source.Event) EventRecord {
 	return EventRecord{
@@ -184,5 +425,26 @@ func EventFromSource(event source.Event) EventRecord {
 		OccurredAt:    event.GetOccurredAt(),
 		ReceivedAt:    event.GetReceivedAt(),
 		RawJSON:       event.GetRawJSON(),
+		Backfilled:    source.IsBackfilled(event),
 	}
 }
+
+// ToSource returns the stored event as a source.Event, e.g. to match it against subscription filters
+func (r EventRecord) ToSource() source.Event {
+	return recordEvent{r}
+}
+
+// recordEvent adapts an EventRecord to source.Event
+type recordEvent struct {
+	record EventRecord
+}
+
+func (e recordEvent) GetID() string              { return e.record.ID }
+func (e recordEvent) GetType() source.EventType  { return source.EventType(e.record.Type) }
+func (e recordEvent) GetSource() string          { return e.record.Source }
+func (e recordEvent) GetSeverity() int           { return e.record.Severity }
+func (e recordEvent) GetAffectedAreas() []string { return e.record.AffectedAreas }
+func (e recordEvent) GetOccurredAt() time.Time   { return e.record.OccurredAt }
+func (e recordEvent) GetReceivedAt() time.Time   { return e.record.ReceivedAt }
+func (e recordEvent) GetRawJSON() string         { return e.record.RawJSON }
+func (e recordEvent) IsBackfilled() bool         { return e.record.Backfilled }
@@ -2,6 +2,8 @@ package store
 
 import (
 	"context"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -445,3 +447,141 @@ func BenchmarkCopyStringSlice(b *testing.B) {
 		copyStringSlice(slice)
 	}
 }
+
+func TestEventRecord_ToSource(t *testing.T) {
+	occurredAt := time.Date(2024, 1, 15, 12, 30, 45, 0, time.UTC)
+	record := EventRecord{
+		ID:            "eq-2024-001",
+		Type:          "earthquake",
+		Source:        "p2pquake",
+		Severity:      80,
+		AffectedAreas: []string{"Tokyo"},
+		OccurredAt:    occurredAt,
+		RawJSON:       `{"code":551}`,
+	}
+
+	got := EventFromSource(record.ToSource())
+	if !reflect.DeepEqual(got, record) {
+		t.Errorf("round trip = %+v, want %+v", got, record)
+	}
+}
+
+func TestEventCursor(t *testing.T) {
+	record := EventRecord{ID: "quake|1", OccurredAt: time.Date(2024, 1, 15, 12, 30, 45, 123, time.UTC)}
+	cursor, err := decodeEventCursor(encodeEventCursor(record))
+	if err != nil {
+		t.Fatalf("decodeEventCursor() error = %v", err)
+	}
+	if cursor.ID != record.ID || !cursor.OccurredAt.Equal(record.OccurredAt) || cursor.compare(record) != 0 {
+		t.Errorf("decodeEventCursor() = %+v", cursor)
+	}
+
+	if cursor, err := decodeEventCursor(""); cursor != nil || err != nil {
+		t.Errorf("empty cursor = %v, %v", cursor, err)
+	}
+	for _, bad := range []string{"!!!", "bm8tc2VwYXJhdG9y", "eWVzdGVyZGF5fGlk"} {
+		if _, err := decodeEventCursor(bad); err != ErrInvalidCursor {
+			t.Errorf("decodeEventCursor(%q) error = %v, want ErrInvalidCursor", bad, err)
+		}
+	}
+}
+
+// testEventQuery checks an EventRepository's Query against a shared data set
+func testEventQuery(t *testing.T, repo EventRepository) {
+	t.Helper()
+	ctx := context.Background()
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, e := range []EventRecord{
+		{ID: "a", Type: "earthquake", Severity: 30, AffectedAreas: []string{"東京都"}, OccurredAt: base},
+		{ID: "b", Type: "earthquake", Severity: 70, AffectedAreas: []string{"宮城県", "福島県"}, OccurredAt: base.Add(time.Hour)},
+		{ID: "c", Type: "tsunami", Severity: 50, AffectedAreas: []string{"宮城県"}, OccurredAt: base.Add(time.Hour)},
+		{ID: "d", Type: "earthquake", Severity: 50, AffectedAreas: []string{"東京都"}, OccurredAt: base.Add(2 * time.Hour)},
+		{ID: "e", Type: "earthquake", Severity: 10, AffectedAreas: []string{"大阪府"}, OccurredAt: base.Add(3 * time.Hour)},
+	} {
+		if _, err := repo.Create(ctx, e); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+	}
+
+	// collect follows the cursors and returns the IDs in page order
+	collect := func(q EventQuery) ([]string, int) {
+		var ids []string
+		total := -1
+		for page := 0; page < 10; page++ {
+			result, err := repo.Query(ctx, q)
+			if err != nil {
+				t.Fatalf("Query(%+v) error = %v", q, err)
+			}
+			if total >= 0 && result.Total != total {
+				t.Errorf("Total changed between pages: %d, %d", total, result.Total)
+			}
+			total = result.Total
+			for _, e := range result.Events {
+				ids = append(ids, e.ID)
+			}
+			if result.NextCursor == "" {
+				return ids, total
+			}
+			q.Cursor = result.NextCursor
+		}
+		t.Fatal("too many pages")
+		return nil, 0
+	}
+
+	from, to := base.Add(time.Hour), base.Add(3*time.Hour)
+	tests := []struct {
+		name  string
+		query EventQuery
+		want  string
+	}{
+		{"newest first", EventQuery{Limit: 2}, "edcba"},
+		{"oldest first", EventQuery{Limit: 2, Ascending: true}, "abcde"},
+		{"type", EventQuery{Type: "earthquake", Limit: 1}, "edba"},
+		{"min severity", EventQuery{MinSeverity: 50}, "dcb"},
+		{"prefecture", EventQuery{Prefecture: "宮城県", Limit: 1, Ascending: true}, "bc"},
+		{"date range", EventQuery{From: &from, To: &to}, "dcb"},
+		{"combined", EventQuery{Type: "earthquake", MinSeverity: 20, From: &from}, "db"},
+	}
+	for _, tt := range tests {
+		ids, total := collect(tt.query)
+		if got := strings.Join(ids, ""); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+		if total != len(tt.want) {
+			t.Errorf("%s: Total = %d, want %d", tt.name, total, len(tt.want))
+		}
+	}
+
+	if _, err := repo.Query(ctx, EventQuery{Cursor: "not a cursor"}); err != ErrInvalidCursor {
+		t.Errorf("Query() with a bad cursor error = %v, want ErrInvalidCursor", err)
+	}
+}
+
+// testEventDelete checks an EventDeleter against the repository's own reads
+func testEventDelete(t *testing.T, repo interface {
+	EventRepository
+	EventDeleter
+}) {
+	t.Helper()
+	ctx := context.Background()
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, id := range []string{"event-1", "event-2", "event-3"} {
+		if _, err := repo.Create(ctx, EventRecord{ID: id, Type: "earthquake", OccurredAt: base.Add(time.Duration(i) * time.Hour)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := repo.Delete(ctx, []string{"event-1", "event-3", "missing"}); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if err := repo.Delete(ctx, nil); err != nil {
+		t.Errorf("Delete(nil) error = %v", err)
+	}
+	page, err := repo.Query(ctx, EventQuery{Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Events) != 1 || page.Events[0].ID != "event-2" {
+		t.Errorf("events after Delete() = %+v, want only event-2", page.Events)
+	}
+}
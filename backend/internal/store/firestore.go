@@ -8,6 +8,8 @@ import (
 
 	"cloud.google.com/go/firestore"
 	"google.golang.org/api/option"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 // FirestoreClient wraps the Firestore client for data persistence
@@ -73,6 +75,15 @@ func (f *FirestoreClient) Close() error {
 	return f.client.Close()
 }
 
+// Ping checks that Firestore answers, by reading a document that need not exist
+func (f *FirestoreClient) Ping(ctx context.Context) error {
+	_, err := f.client.Collection("_health").Doc("ping").Get(ctx)
+	if err != nil && status.Code(err) != codes.NotFound {
+		return err
+	}
+	return nil
+}
+
 // Client returns the underlying Firestore client
 // This allows access to Firestore operations for higher-level code
 func (f *FirestoreClient) Client() *firestore.Client {
@@ -0,0 +1,258 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/otiai10/namazu/backend/internal/config"
+)
+
+// Defaults for GuardConfig
+const (
+	DefaultMaxAttempts      = 3
+	DefaultHedgeAfter       = 250 * time.Millisecond
+	DefaultBreakerThreshold = 5
+	DefaultBreakerCooldown  = 10 * time.Second
+
+	initialBackoff = 100 * time.Millisecond
+	maxBackoff     = 2 * time.Second
+)
+
+// ErrCircuitOpen is returned without calling Firestore while the circuit breaker is open
+var ErrCircuitOpen = errors.New("firestore circuit breaker is open")
+
+// GuardConfig configures a Guard
+type GuardConfig struct {
+	MaxAttempts      int           // Attempts per call on transient errors (1 disables retries)
+	HedgeAfter       time.Duration // Delay before a hedged second read (0 disables hedging)
+	BreakerThreshold int           // Consecutive transient failures that open the circuit (0 disables)
+	BreakerCooldown  time.Duration // How long the circuit stays open before a trial call
+}
+
+// GuardConfigFromStore builds a GuardConfig from the store configuration.
+// Zero values use the defaults; negative values disable hedging or the breaker.
+func GuardConfigFromStore(cfg *config.StoreConfig) GuardConfig {
+	gc := GuardConfig{
+		MaxAttempts:      DefaultMaxAttempts,
+		HedgeAfter:       DefaultHedgeAfter,
+		BreakerThreshold: DefaultBreakerThreshold,
+		BreakerCooldown:  DefaultBreakerCooldown,
+	}
+	if cfg == nil {
+		return gc
+	}
+	if cfg.MaxAttempts > 0 {
+		gc.MaxAttempts = cfg.MaxAttempts
+	}
+	if cfg.HedgeAfterMs > 0 {
+		gc.HedgeAfter = time.Duration(cfg.HedgeAfterMs) * time.Millisecond
+	} else if cfg.HedgeAfterMs < 0 {
+		gc.HedgeAfter = 0
+	}
+	if cfg.BreakerThreshold > 0 {
+		gc.BreakerThreshold = cfg.BreakerThreshold
+	} else if cfg.BreakerThreshold < 0 {
+		gc.BreakerThreshold = 0
+	}
+	if cfg.BreakerCooldownMs > 0 {
+		gc.BreakerCooldown = time.Duration(cfg.BreakerCooldownMs) * time.Millisecond
+	}
+	return gc
+}
+
+// Guard shields callers from transient Firestore errors.
+// Calls are retried with exponential backoff on transient errors, reads can be
+// hedged (a second identical read is started if the first is slow, and the
+// first success wins), and a circuit breaker fails calls fast while Firestore
+// is down so callers don't pile up behind timeouts.
+// A single Guard is meant to be shared by all repositories of one client.
+type Guard struct {
+	cfg     GuardConfig
+	backoff time.Duration
+	now     func() time.Time
+
+	mu        sync.Mutex
+	failures  int       // Consecutive transient failures
+	openUntil time.Time // Zero while the circuit is closed
+	probing   bool      // A trial call is in flight after the cooldown
+}
+
+// NewGuard creates a new Guard
+func NewGuard(cfg GuardConfig) *Guard {
+	if cfg.MaxAttempts < 1 {
+		cfg.MaxAttempts = 1
+	}
+	if cfg.BreakerCooldown <= 0 {
+		cfg.BreakerCooldown = DefaultBreakerCooldown
+	}
+	return &Guard{cfg: cfg, backoff: initialBackoff, now: time.Now}
+}
+
+// Do runs a write through the guard (retries and circuit breaking, no hedging).
+// Writes should be idempotent, e.g. Set on a deterministic document ID.
+func (g *Guard) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	_, err := g.call(ctx, 0, func(ctx context.Context) (interface{}, error) {
+		return nil, fn(ctx)
+	})
+	return err
+}
+
+// DoOnce runs a non-idempotent write (e.g. Add with a generated ID) with
+// circuit breaking only. A retry could duplicate a write that succeeded
+// but whose response was lost.
+func (g *Guard) DoOnce(ctx context.Context, fn func(ctx context.Context) error) error {
+	if err := g.allow(); err != nil {
+		return err
+	}
+	err := fn(ctx)
+	g.record(err)
+	return err
+}
+
+// Read runs a read through the guard with retries, hedging and circuit breaking.
+// fn may run concurrently with itself and must not share mutable state between calls.
+func (g *Guard) Read(ctx context.Context, fn func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+	return g.call(ctx, g.cfg.HedgeAfter, fn)
+}
+
+func (g *Guard) call(ctx context.Context, hedgeAfter time.Duration, fn func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+	backoff := g.backoff
+	var lastErr error
+	for attempt := 1; ; attempt++ {
+		if err := g.allow(); err != nil {
+			if lastErr != nil {
+				return nil, lastErr
+			}
+			return nil, err
+		}
+
+		var v interface{}
+		var err error
+		if hedgeAfter > 0 {
+			v, err = hedge(ctx, hedgeAfter, fn)
+		} else {
+			v, err = fn(ctx)
+		}
+		g.record(err)
+
+		if err == nil || !IsTransient(err) || attempt >= g.cfg.MaxAttempts {
+			return v, err
+		}
+		lastErr = err
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, err
+		case <-timer.C:
+		}
+		backoff *= 2
+		if backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+}
+
+// hedge runs fn and, if it has not returned after delay, a second fn in parallel.
+// The first success wins and the other call is cancelled.
+func hedge(ctx context.Context, delay time.Duration, fn func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type result struct {
+		v   interface{}
+		err error
+	}
+	results := make(chan result, 2)
+	run := func() {
+		v, err := fn(ctx)
+		results <- result{v, err}
+	}
+
+	go run()
+	inflight := 1
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-timer.C:
+			inflight++
+			go run()
+		case r := <-results:
+			inflight--
+			if r.err == nil || inflight == 0 {
+				return r.v, r.err
+			}
+		}
+	}
+}
+
+// allow returns ErrCircuitOpen while the circuit is open. After the cooldown a
+// single trial call is let through; its result closes or reopens the circuit.
+func (g *Guard) allow() error {
+	if g.cfg.BreakerThreshold <= 0 {
+		return nil
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.openUntil.IsZero() {
+		return nil
+	}
+	if g.probing || g.now().Before(g.openUntil) {
+		return ErrCircuitOpen
+	}
+	g.probing = true
+	return nil
+}
+
+// record updates the breaker with the result of a call.
+// Only transient errors count as failures; anything else proves Firestore is up.
+func (g *Guard) record(err error) {
+	if g.cfg.BreakerThreshold <= 0 {
+		return
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if err != nil && IsTransient(err) {
+		g.failures++
+		if g.probing || (g.openUntil.IsZero() && g.failures >= g.cfg.BreakerThreshold) {
+			if g.openUntil.IsZero() {
+				log.Printf("Firestore circuit breaker opened after %d consecutive failures: %v", g.failures, err)
+			}
+			g.probing = false
+			g.openUntil = g.now().Add(g.cfg.BreakerCooldown)
+		}
+		return
+	}
+
+	if !g.openUntil.IsZero() {
+		log.Println("Firestore circuit breaker closed")
+	}
+	g.failures = 0
+	g.probing = false
+	g.openUntil = time.Time{}
+}
+
+// IsTransient reports whether err is a Firestore error worth retrying
+func IsTransient(err error) bool {
+	if errors.Is(err, ErrCircuitOpen) {
+		return false
+	}
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted, codes.Aborted:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,291 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/otiai10/namazu/backend/internal/config"
+)
+
+var errUnavailable = status.Error(codes.Unavailable, "connection reset")
+
+func newTestGuard(cfg GuardConfig) *Guard {
+	g := NewGuard(cfg)
+	g.backoff = time.Millisecond
+	return g
+}
+
+func TestGuard_RetriesTransientErrors(t *testing.T) {
+	g := newTestGuard(GuardConfig{MaxAttempts: 3})
+
+	var calls int
+	err := g.Do(context.Background(), func(ctx context.Context) error {
+		calls++
+		if calls < 3 {
+			return errUnavailable
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("calls = %d, want 3", calls)
+	}
+}
+
+func TestGuard_GivesUpAfterMaxAttempts(t *testing.T) {
+	g := newTestGuard(GuardConfig{MaxAttempts: 2})
+
+	var calls int
+	err := g.Do(context.Background(), func(ctx context.Context) error {
+		calls++
+		return errUnavailable
+	})
+	if status.Code(err) != codes.Unavailable {
+		t.Errorf("Do() error = %v, want Unavailable", err)
+	}
+	if calls != 2 {
+		t.Errorf("calls = %d, want 2", calls)
+	}
+}
+
+func TestGuard_DoesNotRetryPermanentErrors(t *testing.T) {
+	g := newTestGuard(GuardConfig{MaxAttempts: 3})
+
+	for _, permanent := range []error{
+		status.Error(codes.NotFound, "no such document"),
+		status.Error(codes.InvalidArgument, "bad query"),
+		errors.New("firestore client is nil"),
+	} {
+		var calls int
+		err := g.Do(context.Background(), func(ctx context.Context) error {
+			calls++
+			return permanent
+		})
+		if err != permanent {
+			t.Errorf("Do() error = %v, want %v", err, permanent)
+		}
+		if calls != 1 {
+			t.Errorf("%v: calls = %d, want 1", permanent, calls)
+		}
+	}
+}
+
+func TestGuard_DoOnce(t *testing.T) {
+	g := newTestGuard(GuardConfig{MaxAttempts: 3})
+
+	var calls int
+	err := g.DoOnce(context.Background(), func(ctx context.Context) error {
+		calls++
+		return errUnavailable
+	})
+	if err != errUnavailable {
+		t.Errorf("DoOnce() error = %v, want %v", err, errUnavailable)
+	}
+	if calls != 1 {
+		t.Errorf("calls = %d, want 1", calls)
+	}
+}
+
+func TestGuard_StopsRetryingWhenContextIsDone(t *testing.T) {
+	g := NewGuard(GuardConfig{MaxAttempts: 5})
+	g.backoff = time.Hour
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var calls int
+	done := make(chan error)
+	go func() {
+		done <- g.Do(ctx, func(ctx context.Context) error {
+			calls++
+			return errUnavailable
+		})
+	}()
+	cancel()
+
+	select {
+	case err := <-done:
+		if err != errUnavailable {
+			t.Errorf("Do() error = %v, want %v", err, errUnavailable)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Do() did not return after the context was cancelled")
+	}
+	if calls != 1 {
+		t.Errorf("calls = %d, want 1", calls)
+	}
+}
+
+func TestGuard_HedgesSlowReads(t *testing.T) {
+	g := newTestGuard(GuardConfig{MaxAttempts: 1, HedgeAfter: 10 * time.Millisecond})
+
+	var calls int32
+	start := time.Now()
+	v, err := g.Read(context.Background(), func(ctx context.Context) (interface{}, error) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			// The first read hangs until the hedged read wins
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}
+		return "hedged", nil
+	})
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	if v != "hedged" {
+		t.Errorf("Read() = %v, want the hedged result", v)
+	}
+	if atomic.LoadInt32(&calls) != 2 {
+		t.Errorf("calls = %d, want 2", calls)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Read() took %v", elapsed)
+	}
+}
+
+func TestGuard_FastReadsAreNotHedged(t *testing.T) {
+	g := newTestGuard(GuardConfig{MaxAttempts: 1, HedgeAfter: time.Second})
+
+	var calls int32
+	v, err := g.Read(context.Background(), func(ctx context.Context) (interface{}, error) {
+		atomic.AddInt32(&calls, 1)
+		return "fast", nil
+	})
+	if err != nil || v != "fast" {
+		t.Fatalf("Read() = %v, %v", v, err)
+	}
+	if calls != 1 {
+		t.Errorf("calls = %d, want 1", calls)
+	}
+}
+
+func TestGuard_HedgeWaitsForSecondAttemptAfterFailure(t *testing.T) {
+	g := newTestGuard(GuardConfig{MaxAttempts: 1, HedgeAfter: 5 * time.Millisecond})
+
+	var calls int32
+	release := make(chan struct{})
+	v, err := g.Read(context.Background(), func(ctx context.Context) (interface{}, error) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			<-release
+			return nil, errUnavailable
+		}
+		close(release) // Fail the first read after the hedge started
+		time.Sleep(5 * time.Millisecond)
+		return "second", nil
+	})
+	if err != nil || v != "second" {
+		t.Errorf("Read() = %v, %v, want the second result", v, err)
+	}
+}
+
+func TestGuard_CircuitBreaker(t *testing.T) {
+	g := newTestGuard(GuardConfig{MaxAttempts: 1, BreakerThreshold: 2, BreakerCooldown: time.Minute})
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	g.now = func() time.Time { return now }
+
+	var calls int
+	failing := func(ctx context.Context) error {
+		calls++
+		return errUnavailable
+	}
+	succeeding := func(ctx context.Context) error {
+		calls++
+		return nil
+	}
+
+	// Two consecutive failures open the circuit
+	g.Do(context.Background(), failing)
+	g.Do(context.Background(), failing)
+	if err := g.Do(context.Background(), succeeding); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("Do() error = %v, want ErrCircuitOpen", err)
+	}
+	if calls != 2 {
+		t.Errorf("calls = %d, want 2 (open circuit must not call Firestore)", calls)
+	}
+
+	// After the cooldown a failed trial reopens the circuit
+	now = now.Add(time.Minute)
+	g.Do(context.Background(), failing)
+	if err := g.Do(context.Background(), succeeding); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("Do() error = %v, want ErrCircuitOpen after a failed trial", err)
+	}
+
+	// A successful trial closes it
+	now = now.Add(time.Minute)
+	if err := g.Do(context.Background(), succeeding); err != nil {
+		t.Fatalf("Do() error = %v, want trial to succeed", err)
+	}
+	if err := g.Do(context.Background(), succeeding); err != nil {
+		t.Errorf("Do() error = %v, want closed circuit", err)
+	}
+}
+
+func TestGuard_PermanentErrorsKeepCircuitClosed(t *testing.T) {
+	g := newTestGuard(GuardConfig{MaxAttempts: 1, BreakerThreshold: 2})
+	notFound := status.Error(codes.NotFound, "no such document")
+
+	for i := 0; i < 5; i++ {
+		g.Do(context.Background(), func(ctx context.Context) error { return errUnavailable })
+		g.Do(context.Background(), func(ctx context.Context) error { return notFound })
+	}
+	if err := g.Do(context.Background(), func(ctx context.Context) error { return nil }); err != nil {
+		t.Errorf("Do() error = %v, want closed circuit", err)
+	}
+}
+
+func TestGuard_BreakerDisabled(t *testing.T) {
+	g := newTestGuard(GuardConfig{MaxAttempts: 1})
+	for i := 0; i < 10; i++ {
+		g.Do(context.Background(), func(ctx context.Context) error { return errUnavailable })
+	}
+	if err := g.Do(context.Background(), func(ctx context.Context) error { return nil }); err != nil {
+		t.Errorf("Do() error = %v, want no breaker", err)
+	}
+}
+
+func TestIsTransient(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{status.Error(codes.Unavailable, ""), true},
+		{status.Error(codes.DeadlineExceeded, ""), true},
+		{status.Error(codes.ResourceExhausted, ""), true},
+		{status.Error(codes.Aborted, ""), true},
+		{status.Error(codes.NotFound, ""), false},
+		{status.Error(codes.PermissionDenied, ""), false},
+		{ErrCircuitOpen, false},
+		{errors.New("plain"), false},
+		{nil, false},
+	}
+	for _, tt := range tests {
+		if got := IsTransient(tt.err); got != tt.want {
+			t.Errorf("IsTransient(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestGuardConfigFromStore(t *testing.T) {
+	defaults := GuardConfigFromStore(nil)
+	want := GuardConfig{
+		MaxAttempts:      DefaultMaxAttempts,
+		HedgeAfter:       DefaultHedgeAfter,
+		BreakerThreshold: DefaultBreakerThreshold,
+		BreakerCooldown:  DefaultBreakerCooldown,
+	}
+	if defaults != want {
+		t.Errorf("GuardConfigFromStore(nil) = %+v, want %+v", defaults, want)
+	}
+
+	got := GuardConfigFromStore(&config.StoreConfig{MaxAttempts: 5, HedgeAfterMs: -1, BreakerThreshold: -1, BreakerCooldownMs: 500})
+	want = GuardConfig{MaxAttempts: 5, HedgeAfter: 0, BreakerThreshold: 0, BreakerCooldown: 500 * time.Millisecond}
+	if got != want {
+		t.Errorf("GuardConfigFromStore() = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,218 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// GuardedEventRepository routes EventRepository calls through a Guard
+type GuardedEventRepository struct {
+	repo  EventRepository
+	guard *Guard
+}
+
+// Compile-time interface check
+var _ EventRepository = (*GuardedEventRepository)(nil)
+
+// NewGuardedEventRepository wraps repo with g
+func NewGuardedEventRepository(repo EventRepository, g *Guard) *GuardedEventRepository {
+	return &GuardedEventRepository{repo: repo, guard: g}
+}
+
+// Create stores an event.
+// Events with an ID are written with Set and retried; others are written once.
+func (r *GuardedEventRepository) Create(ctx context.Context, event EventRecord) (string, error) {
+	do := r.guard.Do
+	if event.ID == "" {
+		do = r.guard.DoOnce
+	}
+
+	var id string
+	err := do(ctx, func(ctx context.Context) error {
+		var err error
+		id, err = r.repo.Create(ctx, event)
+		return err
+	})
+	return id, err
+}
+
+// Get retrieves an event by ID
+func (r *GuardedEventRepository) Get(ctx context.Context, id string) (*EventRecord, error) {
+	v, err := r.guard.Read(ctx, func(ctx context.Context) (interface{}, error) {
+		return r.repo.Get(ctx, id)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(*EventRecord), nil
+}
+
+// List retrieves events ordered by occurredAt descending with pagination
+func (r *GuardedEventRepository) List(ctx context.Context, limit int, startAfter *time.Time) ([]EventRecord, error) {
+	v, err := r.guard.Read(ctx, func(ctx context.Context) (interface{}, error) {
+		return r.repo.List(ctx, limit, startAfter)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.([]EventRecord), nil
+}
+
+// Query retrieves a filtered page of events
+func (r *GuardedEventRepository) Query(ctx context.Context, q EventQuery) (*EventPage, error) {
+	v, err := r.guard.Read(ctx, func(ctx context.Context) (interface{}, error) {
+		return r.repo.Query(ctx, q)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(*EventPage), nil
+}
+
+// Delete removes events by ID. Deleting is idempotent, so it is retried.
+func (r *GuardedEventRepository) Delete(ctx context.Context, ids []string) error {
+	deleter, ok := r.repo.(EventDeleter)
+	if !ok {
+		return fmt.Errorf("event repository does not support deleting")
+	}
+	return r.guard.Do(ctx, func(ctx context.Context) error {
+		return deleter.Delete(ctx, ids)
+	})
+}
+
+// GuardedDeliveryRepository routes DeliveryRepository calls through a Guard
+type GuardedDeliveryRepository struct {
+	repo  DeliveryRepository
+	guard *Guard
+}
+
+// Compile-time interface check
+var _ DeliveryRepository = (*GuardedDeliveryRepository)(nil)
+
+// NewGuardedDeliveryRepository wraps repo with g
+func NewGuardedDeliveryRepository(repo DeliveryRepository, g *Guard) *GuardedDeliveryRepository {
+	return &GuardedDeliveryRepository{repo: repo, guard: g}
+}
+
+// Create stores a delivery record.
+// Records get generated IDs, so the write is not retried.
+func (r *GuardedDeliveryRepository) Create(ctx context.Context, record DeliveryRecord) (string, error) {
+	var id string
+	err := r.guard.DoOnce(ctx, func(ctx context.Context) error {
+		var err error
+		id, err = r.repo.Create(ctx, record)
+		return err
+	})
+	return id, err
+}
+
+// Get returns a delivery record by ID
+func (r *GuardedDeliveryRepository) Get(ctx context.Context, id string) (*DeliveryRecord, error) {
+	v, err := r.guard.Read(ctx, func(ctx context.Context) (interface{}, error) {
+		return r.repo.Get(ctx, id)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(*DeliveryRecord), nil
+}
+
+// ListBySubscription returns the subscription's records delivered in [from, to)
+func (r *GuardedDeliveryRepository) ListBySubscription(ctx context.Context, subscriptionID string, from, to time.Time) ([]DeliveryRecord, error) {
+	v, err := r.guard.Read(ctx, func(ctx context.Context) (interface{}, error) {
+		return r.repo.ListBySubscription(ctx, subscriptionID, from, to)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.([]DeliveryRecord), nil
+}
+
+// LastSuccess returns the subscription's most recent successful delivery
+func (r *GuardedDeliveryRepository) LastSuccess(ctx context.Context, subscriptionID string) (*DeliveryRecord, error) {
+	v, err := r.guard.Read(ctx, func(ctx context.Context) (interface{}, error) {
+		return r.repo.LastSuccess(ctx, subscriptionID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(*DeliveryRecord), nil
+}
+
+// SummarizeEvent counts the delivery records of an event
+func (r *GuardedDeliveryRepository) SummarizeEvent(ctx context.Context, eventID string) (DeliverySummary, error) {
+	v, err := r.guard.Read(ctx, func(ctx context.Context) (interface{}, error) {
+		return r.repo.SummarizeEvent(ctx, eventID)
+	})
+	if err != nil {
+		return DeliverySummary{}, err
+	}
+	return v.(DeliverySummary), nil
+}
+
+// SummarizeSubscription counts the subscription's records delivered in [from, to)
+func (r *GuardedDeliveryRepository) SummarizeSubscription(ctx context.Context, subscriptionID string, from, to time.Time) (DeliverySummary, error) {
+	v, err := r.guard.Read(ctx, func(ctx context.Context) (interface{}, error) {
+		return r.repo.SummarizeSubscription(ctx, subscriptionID, from, to)
+	})
+	if err != nil {
+		return DeliverySummary{}, err
+	}
+	return v.(DeliverySummary), nil
+}
+
+// DeleteBySubscription removes every record of the subscription.
+// Deleting again is harmless, so the write is retried.
+func (r *GuardedDeliveryRepository) DeleteBySubscription(ctx context.Context, subscriptionID string) (int, error) {
+	var deleted int
+	err := r.guard.Do(ctx, func(ctx context.Context) error {
+		n, err := r.repo.DeleteBySubscription(ctx, subscriptionID)
+		deleted += n
+		return err
+	})
+	return deleted, err
+}
+
+// GuardedRetryRepository routes RetryRepository calls through a Guard
+type GuardedRetryRepository struct {
+	repo  RetryRepository
+	guard *Guard
+}
+
+// Compile-time interface check
+var _ RetryRepository = (*GuardedRetryRepository)(nil)
+
+// NewGuardedRetryRepository wraps repo with g
+func NewGuardedRetryRepository(repo RetryRepository, g *Guard) *GuardedRetryRepository {
+	return &GuardedRetryRepository{repo: repo, guard: g}
+}
+
+// Save creates or replaces the pending retry (idempotent by ID)
+func (r *GuardedRetryRepository) Save(ctx context.Context, retry PendingRetry) (string, error) {
+	var id string
+	err := r.guard.Do(ctx, func(ctx context.Context) error {
+		var err error
+		id, err = r.repo.Save(ctx, retry)
+		return err
+	})
+	return id, err
+}
+
+// Delete removes a pending retry by ID
+func (r *GuardedRetryRepository) Delete(ctx context.Context, id string) error {
+	return r.guard.Do(ctx, func(ctx context.Context) error {
+		return r.repo.Delete(ctx, id)
+	})
+}
+
+// List returns all pending retries
+func (r *GuardedRetryRepository) List(ctx context.Context) ([]PendingRetry, error) {
+	v, err := r.guard.Read(ctx, func(ctx context.Context) (interface{}, error) {
+		return r.repo.List(ctx)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.([]PendingRetry), nil
+}
@@ -0,0 +1,236 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// flakyEventRepository fails the first failures calls of each method
+type flakyEventRepository struct {
+	failures int
+	calls    int
+}
+
+func (r *flakyEventRepository) fail() error {
+	r.calls++
+	if r.calls <= r.failures {
+		return errUnavailable
+	}
+	return nil
+}
+
+func (r *flakyEventRepository) Create(ctx context.Context, event EventRecord) (string, error) {
+	if err := r.fail(); err != nil {
+		return "", err
+	}
+	if event.ID == "" {
+		return "generated", nil
+	}
+	return event.ID, nil
+}
+
+func (r *flakyEventRepository) Get(ctx context.Context, id string) (*EventRecord, error) {
+	if err := r.fail(); err != nil {
+		return nil, err
+	}
+	if id == "missing" {
+		return nil, nil
+	}
+	return &EventRecord{ID: id}, nil
+}
+
+func (r *flakyEventRepository) List(ctx context.Context, limit int, startAfter *time.Time) ([]EventRecord, error) {
+	if err := r.fail(); err != nil {
+		return nil, err
+	}
+	return []EventRecord{{ID: "event-1"}}, nil
+}
+
+func (r *flakyEventRepository) Query(ctx context.Context, q EventQuery) (*EventPage, error) {
+	if err := r.fail(); err != nil {
+		return nil, err
+	}
+	return &EventPage{Events: []EventRecord{{ID: "event-1"}}, Total: 1}, nil
+}
+
+func TestGuardedEventRepository(t *testing.T) {
+	ctx := context.Background()
+	guard := newTestGuard(GuardConfig{MaxAttempts: 3})
+
+	t.Run("retries reads", func(t *testing.T) {
+		repo := NewGuardedEventRepository(&flakyEventRepository{failures: 2}, guard)
+		events, err := repo.List(ctx, 10, nil)
+		if err != nil || len(events) != 1 {
+			t.Errorf("List() = %v, %v", events, err)
+		}
+		page, err := NewGuardedEventRepository(&flakyEventRepository{failures: 2}, guard).Query(ctx, EventQuery{})
+		if err != nil || page.Total != 1 {
+			t.Errorf("Query() = %+v, %v", page, err)
+		}
+	})
+
+	t.Run("not found is nil", func(t *testing.T) {
+		repo := NewGuardedEventRepository(&flakyEventRepository{}, guard)
+		event, err := repo.Get(ctx, "missing")
+		if err != nil || event != nil {
+			t.Errorf("Get() = %v, %v, want nil, nil", event, err)
+		}
+	})
+
+	t.Run("retries writes with an ID", func(t *testing.T) {
+		inner := &flakyEventRepository{failures: 1}
+		id, err := NewGuardedEventRepository(inner, guard).Create(ctx, EventRecord{ID: "event-1"})
+		if err != nil || id != "event-1" {
+			t.Errorf("Create() = %q, %v", id, err)
+		}
+		if inner.calls != 2 {
+			t.Errorf("calls = %d, want 2", inner.calls)
+		}
+	})
+
+	t.Run("writes without an ID once", func(t *testing.T) {
+		inner := &flakyEventRepository{failures: 1}
+		if _, err := NewGuardedEventRepository(inner, guard).Create(ctx, EventRecord{}); err == nil {
+			t.Error("Create() error = nil, want the transient error")
+		}
+		if inner.calls != 1 {
+			t.Errorf("calls = %d, want 1", inner.calls)
+		}
+	})
+}
+
+// flakyRetryRepository fails the first failures calls
+type flakyRetryRepository struct {
+	failures int
+	calls    int
+	saved    []PendingRetry
+}
+
+func (r *flakyRetryRepository) Save(ctx context.Context, retry PendingRetry) (string, error) {
+	r.calls++
+	if r.calls <= r.failures {
+		return "", errUnavailable
+	}
+	r.saved = append(r.saved, retry)
+	return retry.ID, nil
+}
+
+func (r *flakyRetryRepository) Delete(ctx context.Context, id string) error {
+	r.calls++
+	if r.calls <= r.failures {
+		return errUnavailable
+	}
+	return nil
+}
+
+func (r *flakyRetryRepository) List(ctx context.Context) ([]PendingRetry, error) {
+	r.calls++
+	if r.calls <= r.failures {
+		return nil, errUnavailable
+	}
+	return r.saved, nil
+}
+
+func TestGuardedRetryRepository(t *testing.T) {
+	ctx := context.Background()
+	guard := newTestGuard(GuardConfig{MaxAttempts: 3})
+	inner := &flakyRetryRepository{failures: 1}
+	repo := NewGuardedRetryRepository(inner, guard)
+
+	if _, err := repo.Save(ctx, PendingRetry{ID: "sub-1_event-1"}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	retries, err := repo.List(ctx)
+	if err != nil || len(retries) != 1 {
+		t.Errorf("List() = %v, %v", retries, err)
+	}
+	if err := repo.Delete(ctx, "sub-1_event-1"); err != nil {
+		t.Errorf("Delete() error = %v", err)
+	}
+}
+
+// flakyDeliveryRepository always fails with a transient error
+type flakyDeliveryRepository struct {
+	calls int
+}
+
+func (r *flakyDeliveryRepository) Create(ctx context.Context, record DeliveryRecord) (string, error) {
+	r.calls++
+	return "", errUnavailable
+}
+
+func (r *flakyDeliveryRepository) Get(ctx context.Context, id string) (*DeliveryRecord, error) {
+	r.calls++
+	return nil, errUnavailable
+}
+
+func (r *flakyDeliveryRepository) ListBySubscription(ctx context.Context, subscriptionID string, from, to time.Time) ([]DeliveryRecord, error) {
+	r.calls++
+	return nil, errUnavailable
+}
+
+func (r *flakyDeliveryRepository) LastSuccess(ctx context.Context, subscriptionID string) (*DeliveryRecord, error) {
+	r.calls++
+	return nil, errUnavailable
+}
+
+func (r *flakyDeliveryRepository) SummarizeEvent(ctx context.Context, eventID string) (DeliverySummary, error) {
+	r.calls++
+	return DeliverySummary{}, errUnavailable
+}
+
+func (r *flakyDeliveryRepository) SummarizeSubscription(ctx context.Context, subscriptionID string, from, to time.Time) (DeliverySummary, error) {
+	r.calls++
+	return DeliverySummary{}, errUnavailable
+}
+
+func (r *flakyDeliveryRepository) DeleteBySubscription(ctx context.Context, subscriptionID string) (int, error) {
+	r.calls++
+	return 0, errUnavailable
+}
+
+func TestGuardedDeliveryRepository(t *testing.T) {
+	ctx := context.Background()
+	inner := &flakyDeliveryRepository{}
+	repo := NewGuardedDeliveryRepository(inner, newTestGuard(GuardConfig{MaxAttempts: 3}))
+
+	if _, err := repo.Create(ctx, DeliveryRecord{}); err == nil {
+		t.Error("Create() error = nil")
+	}
+	if inner.calls != 1 {
+		t.Errorf("Create calls = %d, want 1 (records have generated IDs)", inner.calls)
+	}
+
+	inner.calls = 0
+	if _, err := repo.Get(ctx, "delivery-1"); err == nil {
+		t.Error("Get() error = nil")
+	}
+	if inner.calls != 3 {
+		t.Errorf("Get calls = %d, want 3", inner.calls)
+	}
+
+	inner.calls = 0
+	if _, err := repo.LastSuccess(ctx, "sub-1"); err == nil {
+		t.Error("LastSuccess() error = nil")
+	}
+	if inner.calls != 3 {
+		t.Errorf("LastSuccess calls = %d, want 3", inner.calls)
+	}
+
+	inner.calls = 0
+	if _, err := repo.SummarizeEvent(ctx, "event-1"); err == nil {
+		t.Error("SummarizeEvent() error = nil")
+	}
+	if inner.calls != 3 {
+		t.Errorf("SummarizeEvent calls = %d, want 3", inner.calls)
+	}
+
+	inner.calls = 0
+	if _, err := repo.DeleteBySubscription(ctx, "sub-1"); err == nil {
+		t.Error("DeleteBySubscription() error = nil")
+	}
+	if inner.calls != 3 {
+		t.Errorf("DeleteBySubscription calls = %d, want 3", inner.calls)
+	}
+}
@@ -0,0 +1,375 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryEventRepository implements EventRepository in process memory.
+// Used under --test-mode and NAMAZU_STORE_TYPE=memory; nothing survives a restart.
+type MemoryEventRepository struct {
+	mu     sync.RWMutex
+	events map[string]EventRecord
+}
+
+// Compile-time interface check
+var _ EventRepository = (*MemoryEventRepository)(nil)
+
+// NewMemoryEventRepository creates an empty MemoryEventRepository
+func NewMemoryEventRepository() *MemoryEventRepository {
+	return &MemoryEventRepository{events: map[string]EventRecord{}}
+}
+
+// Create stores an event. Like the Firestore implementation, an event with
+// an ID replaces any stored event with the same ID.
+func (r *MemoryEventRepository) Create(ctx context.Context, event EventRecord) (string, error) {
+	record := createEventRecord(event)
+	if record.ID == "" {
+		record.ID = NewSQLID()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events[record.ID] = record
+	return record.ID, nil
+}
+
+// Get retrieves an event by ID. It returns nil and no error if not found.
+func (r *MemoryEventRepository) Get(ctx context.Context, id string) (*EventRecord, error) {
+	if id == "" {
+		return nil, fmt.Errorf("event ID is required")
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	record, ok := r.events[id]
+	if !ok {
+		return nil, nil
+	}
+	record.AffectedAreas = copyStringSlice(record.AffectedAreas)
+	return &record, nil
+}
+
+// List retrieves events ordered by occurredAt descending with pagination
+func (r *MemoryEventRepository) List(ctx context.Context, limit int, startAfter *time.Time) ([]EventRecord, error) {
+	if limit <= 0 {
+		limit = 10 // Default limit
+	}
+
+	r.mu.RLock()
+	records := make([]EventRecord, 0, len(r.events))
+	for _, record := range r.events {
+		if startAfter != nil && !record.OccurredAt.Before(*startAfter) {
+			continue
+		}
+		record.AffectedAreas = copyStringSlice(record.AffectedAreas)
+		records = append(records, record)
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].OccurredAt.After(records[j].OccurredAt)
+	})
+	if len(records) > limit {
+		records = records[:limit]
+	}
+	return records, nil
+}
+
+// MemoryDeliveryRepository implements DeliveryRepository in process memory
+type MemoryDeliveryRepository struct {
+	mu      sync.RWMutex
+	records []DeliveryRecord // In insertion order
+}
+
+// Compile-time interface check
+var _ DeliveryRepository = (*MemoryDeliveryRepository)(nil)
+
+// NewMemoryDeliveryRepository creates an empty MemoryDeliveryRepository
+func NewMemoryDeliveryRepository() *MemoryDeliveryRepository {
+	return &MemoryDeliveryRepository{}
+}
+
+// Create stores a delivery record and returns its generated ID
+func (r *MemoryDeliveryRepository) Create(ctx context.Context, record DeliveryRecord) (string, error) {
+	if record.SubscriptionID == "" {
+		return "", fmt.Errorf("subscription ID is required")
+	}
+	if record.DeliveredAt.IsZero() {
+		record.DeliveredAt = time.Now()
+	}
+	record.ID = NewSQLID()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.records = append(r.records, record)
+	return record.ID, nil
+}
+
+// Get returns a delivery record by ID. It returns nil and no error if not found.
+func (r *MemoryDeliveryRepository) Get(ctx context.Context, id string) (*DeliveryRecord, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, record := range r.records {
+		if record.ID == id {
+			return &record, nil
+		}
+	}
+	return nil, nil
+}
+
+// ListBySubscription returns the subscription's records delivered in [from, to),
+// ordered by deliveredAt ascending
+func (r *MemoryDeliveryRepository) ListBySubscription(ctx context.Context, subscriptionID string, from, to time.Time) ([]DeliveryRecord, error) {
+	r.mu.RLock()
+	records := make([]DeliveryRecord, 0)
+	for _, record := range r.records {
+		if record.SubscriptionID == subscriptionID && !record.DeliveredAt.Before(from) && record.DeliveredAt.Before(to) {
+			records = append(records, record)
+		}
+	}
+	r.mu.RUnlock()
+
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].DeliveredAt.Before(records[j].DeliveredAt)
+	})
+	return records, nil
+}
+
+// LastSuccess returns the subscription's most recent successful delivery.
+// It returns nil and no error if there is none.
+func (r *MemoryDeliveryRepository) LastSuccess(ctx context.Context, subscriptionID string) (*DeliveryRecord, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var last *DeliveryRecord
+	for i := range r.records {
+		record := r.records[i]
+		if record.SubscriptionID != subscriptionID || !record.Success {
+			continue
+		}
+		if last == nil || record.DeliveredAt.After(last.DeliveredAt) {
+			last = &record
+		}
+	}
+	return last, nil
+}
+
+// SummarizeEvent counts the delivery records of an event across all subscriptions
+func (r *MemoryDeliveryRepository) SummarizeEvent(ctx context.Context, eventID string) (DeliverySummary, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var summary DeliverySummary
+	for _, record := range r.records {
+		if record.EventID != eventID {
+			continue
+		}
+		if record.Success {
+			summary.Delivered++
+		} else {
+			summary.Failed++
+		}
+	}
+	return summary, nil
+}
+
+// SummarizeSubscription counts the subscription's records delivered in [from, to)
+func (r *MemoryDeliveryRepository) SummarizeSubscription(ctx context.Context, subscriptionID string, from, to time.Time) (DeliverySummary, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var summary DeliverySummary
+	for _, record := range r.records {
+		if record.SubscriptionID != subscriptionID || record.DeliveredAt.Before(from) || !record.DeliveredAt.Before(to) {
+			continue
+		}
+		if record.Success {
+			summary.Delivered++
+		} else {
+			summary.Failed++
+		}
+	}
+	return summary, nil
+}
+
+// DeleteBySubscription removes every record of the subscription
+func (r *MemoryDeliveryRepository) DeleteBySubscription(ctx context.Context, subscriptionID string) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	kept := r.records[:0]
+	for _, record := range r.records {
+		if record.SubscriptionID != subscriptionID {
+			kept = append(kept, record)
+		}
+	}
+	deleted := len(r.records) - len(kept)
+	r.records = kept
+	return deleted, nil
+}
+
+// MemoryRetryRepository implements RetryRepository in process memory.
+// Pending retries do not survive a restart, so nothing is resumed.
+type MemoryRetryRepository struct {
+	mu      sync.Mutex
+	retries map[string]PendingRetry
+}
+
+// Compile-time interface check
+var _ RetryRepository = (*MemoryRetryRepository)(nil)
+
+// NewMemoryRetryRepository creates an empty MemoryRetryRepository
+func NewMemoryRetryRepository() *MemoryRetryRepository {
+	return &MemoryRetryRepository{retries: map[string]PendingRetry{}}
+}
+
+// Save creates or replaces the pending retry and returns its ID
+func (r *MemoryRetryRepository) Save(ctx context.Context, retry PendingRetry) (string, error) {
+	if retry.SubscriptionID == "" || retry.EventID == "" {
+		return "", fmt.Errorf("subscription ID and event ID are required")
+	}
+	if retry.ID == "" {
+		retry.ID = PendingRetryID(retry.SubscriptionID, retry.EventID)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	if retry.CreatedAt.IsZero() {
+		retry.CreatedAt = now
+	}
+	retry.UpdatedAt = now
+	r.retries[retry.ID] = retry
+	return retry.ID, nil
+}
+
+// Delete removes a pending retry by ID (no error if it does not exist)
+func (r *MemoryRetryRepository) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.retries, id)
+	return nil
+}
+
+// List returns all pending retries, soonest first
+func (r *MemoryRetryRepository) List(ctx context.Context) ([]PendingRetry, error) {
+	r.mu.Lock()
+	retries := make([]PendingRetry, 0, len(r.retries))
+	for _, retry := range r.retries {
+		retries = append(retries, retry)
+	}
+	r.mu.Unlock()
+
+	sort.Slice(retries, func(i, j int) bool {
+		return retries[i].NextAttemptAt.Before(retries[j].NextAttemptAt)
+	})
+	return retries, nil
+}
+
+// MemoryDigestRepository implements DigestRepository in process memory.
+// Collected events are lost on restart.
+type MemoryDigestRepository struct {
+	mu      sync.Mutex
+	digests map[string]PendingDigest
+}
+
+// Compile-time interface check
+var _ DigestRepository = (*MemoryDigestRepository)(nil)
+
+// NewMemoryDigestRepository creates an empty MemoryDigestRepository
+func NewMemoryDigestRepository() *MemoryDigestRepository {
+	return &MemoryDigestRepository{digests: map[string]PendingDigest{}}
+}
+
+// Save creates or replaces the subscription's pending digest
+func (r *MemoryDigestRepository) Save(ctx context.Context, digest PendingDigest) error {
+	if digest.SubscriptionID == "" {
+		return fmt.Errorf("subscription ID is required")
+	}
+	digest.Events = append([]DigestEvent(nil), digest.Events...)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.digests[digest.SubscriptionID] = digest
+	return nil
+}
+
+// Delete removes a subscription's pending digest (no error if it does not exist)
+func (r *MemoryDigestRepository) Delete(ctx context.Context, subscriptionID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.digests, subscriptionID)
+	return nil
+}
+
+// List returns all pending digests, oldest first
+func (r *MemoryDigestRepository) List(ctx context.Context) ([]PendingDigest, error) {
+	r.mu.Lock()
+	digests := make([]PendingDigest, 0, len(r.digests))
+	for _, digest := range r.digests {
+		digest.Events = append([]DigestEvent(nil), digest.Events...)
+		digests = append(digests, digest)
+	}
+	r.mu.Unlock()
+
+	sort.Slice(digests, func(i, j int) bool {
+		return digests[i].StartedAt.Before(digests[j].StartedAt)
+	})
+	return digests, nil
+}
+
+// Delete removes the events with the given IDs. Missing events are ignored.
+func (r *MemoryEventRepository) Delete(ctx context.Context, ids []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, id := range ids {
+		delete(r.events, id)
+	}
+	return nil
+}
+
+// Query retrieves a filtered page of events ordered by occurredAt
+func (r *MemoryEventRepository) Query(ctx context.Context, q EventQuery) (*EventPage, error) {
+	cursor, err := decodeEventCursor(q.Cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	matched := make([]EventRecord, 0, len(r.events))
+	for _, record := range r.events {
+		if !q.matches(record) {
+			continue
+		}
+		record.AffectedAreas = copyStringSlice(record.AffectedAreas)
+		matched = append(matched, record)
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(matched, func(i, j int) bool {
+		a, b := matched[i], matched[j]
+		if !q.Ascending {
+			a, b = b, a
+		}
+		if !a.OccurredAt.Equal(b.OccurredAt) {
+			return a.OccurredAt.Before(b.OccurredAt)
+		}
+		return a.ID < b.ID
+	})
+
+	records := matched
+	if cursor != nil {
+		records = make([]EventRecord, 0, len(matched))
+		for _, record := range matched {
+			cmp := cursor.compare(record)
+			if (q.Ascending && cmp > 0) || (!q.Ascending && cmp < 0) {
+				records = append(records, record)
+			}
+		}
+	}
+	limit := q.limit()
+	if len(records) > limit+1 {
+		records = records[:limit+1]
+	}
+	return newEventPage(records, limit, len(matched)), nil
+}
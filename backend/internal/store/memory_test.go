@@ -0,0 +1,207 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryEventRepository(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryEventRepository()
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	if _, err := repo.Get(ctx, ""); err == nil {
+		t.Error("Get(\"\") should fail")
+	}
+	for i, id := range []string{"a", "b", "c"} {
+		if _, err := repo.Create(ctx, EventRecord{ID: id, Severity: i, AffectedAreas: []string{"東京都"}, OccurredAt: base.Add(time.Duration(i) * time.Hour)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	generated, err := repo.Create(ctx, EventRecord{OccurredAt: base.Add(-time.Hour)})
+	if err != nil || generated == "" {
+		t.Fatalf("Create() without ID = %q, %v", generated, err)
+	}
+
+	// Creating with an existing ID replaces the event
+	if _, err := repo.Create(ctx, EventRecord{ID: "b", Severity: 9, OccurredAt: base.Add(time.Hour)}); err != nil {
+		t.Fatal(err)
+	}
+	got, err := repo.Get(ctx, "b")
+	if err != nil || got == nil || got.Severity != 9 || got.CreatedAt.IsZero() {
+		t.Fatalf("Get(b) = %+v, %v", got, err)
+	}
+	if missing, err := repo.Get(ctx, "missing"); err != nil || missing != nil {
+		t.Errorf("Get(missing) = %v, %v, want nil, nil", missing, err)
+	}
+
+	// Returned events are copies
+	a, _ := repo.Get(ctx, "a")
+	a.AffectedAreas[0] = "changed"
+	if again, _ := repo.Get(ctx, "a"); again.AffectedAreas[0] != "東京都" {
+		t.Error("mutating a returned event changed the stored event")
+	}
+
+	page, err := repo.List(ctx, 2, nil)
+	if err != nil || len(page) != 2 || page[0].ID != "c" || page[1].ID != "b" {
+		t.Fatalf("List(2, nil) = %+v, %v", page, err)
+	}
+	next, err := repo.List(ctx, 10, &page[1].OccurredAt)
+	if err != nil || len(next) != 2 || next[0].ID != "a" || next[1].ID != generated {
+		t.Errorf("List(10, after b) = %+v, %v", next, err)
+	}
+	if all, _ := repo.List(ctx, 0, nil); len(all) != 4 {
+		t.Errorf("List(0, nil) returned %d events, want 4 (default limit 10)", len(all))
+	}
+}
+
+func TestMemoryEventRepository_Query(t *testing.T) {
+	testEventQuery(t, NewMemoryEventRepository())
+}
+
+func TestMemoryEventRepository_Delete(t *testing.T) {
+	testEventDelete(t, NewMemoryEventRepository())
+}
+
+func TestMemoryDeliveryRepository(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryDeliveryRepository()
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	if _, err := repo.Create(ctx, DeliveryRecord{}); err == nil {
+		t.Error("Create() without a subscription ID should fail")
+	}
+	records := []DeliveryRecord{
+		{SubscriptionID: "sub-1", EventID: "event-2", Success: true, DeliveredAt: base.Add(2 * time.Hour)},
+		{SubscriptionID: "sub-1", EventID: "event-3", Success: false, DeliveredAt: base.Add(3 * time.Hour)},
+		{SubscriptionID: "sub-1", EventID: "event-1", Success: true, DeliveredAt: base},
+		{SubscriptionID: "sub-2", EventID: "event-3", Success: true, DeliveredAt: base.Add(time.Hour)},
+	}
+	var ids []string
+	for _, record := range records {
+		id, err := repo.Create(ctx, record)
+		if err != nil || id == "" {
+			t.Fatalf("Create() = %q, %v", id, err)
+		}
+		ids = append(ids, id)
+	}
+	defaulted, err := repo.Create(ctx, DeliveryRecord{SubscriptionID: "sub-3"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := repo.Get(ctx, defaulted); got == nil || got.DeliveredAt.IsZero() {
+		t.Errorf("DeliveredAt was not defaulted: %+v", got)
+	}
+
+	got, err := repo.Get(ctx, ids[1])
+	if err != nil || got == nil || got.ID != ids[1] || got.Success {
+		t.Errorf("Get() = %+v, %v", got, err)
+	}
+	if missing, err := repo.Get(ctx, "missing"); err != nil || missing != nil {
+		t.Errorf("Get(missing) = %v, %v, want nil, nil", missing, err)
+	}
+
+	listed, err := repo.ListBySubscription(ctx, "sub-1", base, base.Add(3*time.Hour))
+	if err != nil || len(listed) != 2 || listed[0].ID != ids[2] || listed[1].ID != ids[0] {
+		t.Errorf("ListBySubscription() = %+v, %v", listed, err)
+	}
+
+	summary, err := repo.SummarizeEvent(ctx, "event-3")
+	if err != nil || summary != (DeliverySummary{Delivered: 1, Failed: 1}) {
+		t.Errorf("SummarizeEvent(event-3) = %+v, %v", summary, err)
+	}
+
+	summary, err = repo.SummarizeSubscription(ctx, "sub-1", base.Add(time.Hour), base.Add(4*time.Hour))
+	if err != nil || summary != (DeliverySummary{Delivered: 1, Failed: 1}) {
+		t.Errorf("SummarizeSubscription(sub-1) = %+v, %v", summary, err)
+	}
+
+	last, err := repo.LastSuccess(ctx, "sub-1")
+	if err != nil || last == nil || last.ID != ids[0] {
+		t.Errorf("LastSuccess(sub-1) = %+v, %v", last, err)
+	}
+	if none, err := repo.LastSuccess(ctx, "sub-9"); err != nil || none != nil {
+		t.Errorf("LastSuccess(sub-9) = %v, %v, want nil, nil", none, err)
+	}
+
+	if n, err := repo.DeleteBySubscription(ctx, "sub-1"); err != nil || n != 3 {
+		t.Errorf("DeleteBySubscription(sub-1) = %d, %v, want 3", n, err)
+	}
+	if left, _ := repo.ListBySubscription(ctx, "sub-1", base, base.Add(24*time.Hour)); len(left) != 0 {
+		t.Errorf("ListBySubscription(sub-1) after delete = %+v", left)
+	}
+	if kept, _ := repo.Get(ctx, ids[3]); kept == nil {
+		t.Error("DeleteBySubscription(sub-1) removed another subscription's record")
+	}
+}
+
+func TestMemoryRetryRepository(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryRetryRepository()
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	if _, err := repo.Save(ctx, PendingRetry{SubscriptionID: "sub-1"}); err == nil {
+		t.Error("Save() without an event ID should fail")
+	}
+	id, err := repo.Save(ctx, PendingRetry{SubscriptionID: "sub-1", EventID: "ev-1", Attempt: 1, NextAttemptAt: base.Add(time.Minute)})
+	if err != nil || id != PendingRetryID("sub-1", "ev-1") {
+		t.Fatalf("Save() = %q, %v", id, err)
+	}
+	if _, err := repo.Save(ctx, PendingRetry{SubscriptionID: "sub-2", EventID: "ev-1", Attempt: 1, NextAttemptAt: base}); err != nil {
+		t.Fatal(err)
+	}
+	// Saving the same delivery again replaces it
+	if _, err := repo.Save(ctx, PendingRetry{SubscriptionID: "sub-1", EventID: "ev-1", Attempt: 2, NextAttemptAt: base.Add(time.Hour)}); err != nil {
+		t.Fatal(err)
+	}
+
+	retries, err := repo.List(ctx)
+	if err != nil || len(retries) != 2 || retries[0].SubscriptionID != "sub-2" || retries[1].Attempt != 2 || retries[1].UpdatedAt.IsZero() {
+		t.Fatalf("List() = %+v, %v", retries, err)
+	}
+
+	if err := repo.Delete(ctx, id); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Delete(ctx, id); err != nil {
+		t.Errorf("Delete(missing) error = %v, want nil", err)
+	}
+	if retries, _ := repo.List(ctx); len(retries) != 1 {
+		t.Errorf("List() after Delete returned %d retries, want 1", len(retries))
+	}
+}
+
+func TestMemoryDigestRepository(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryDigestRepository()
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	if err := repo.Save(ctx, PendingDigest{}); err == nil {
+		t.Error("Save() without a subscription ID should fail")
+	}
+	events := []DigestEvent{{ID: "ev-1", Severity: 30}}
+	if err := repo.Save(ctx, PendingDigest{SubscriptionID: "sub-1", StartedAt: base.Add(time.Minute), Count: 1, Events: events}); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Save(ctx, PendingDigest{SubscriptionID: "sub-2", StartedAt: base, Count: 1}); err != nil {
+		t.Fatal(err)
+	}
+	// The repository keeps its own copy of the events
+	events[0].ID = "changed"
+
+	digests, err := repo.List(ctx)
+	if err != nil || len(digests) != 2 || digests[0].SubscriptionID != "sub-2" || digests[1].Events[0].ID != "ev-1" {
+		t.Fatalf("List() = %+v, %v", digests, err)
+	}
+
+	if err := repo.Delete(ctx, "sub-1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Delete(ctx, "sub-1"); err != nil {
+		t.Errorf("Delete(missing) error = %v, want nil", err)
+	}
+	if digests, _ := repo.List(ctx); len(digests) != 1 {
+		t.Errorf("List() after Delete returned %d digests, want 1", len(digests))
+	}
+}
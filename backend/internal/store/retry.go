@@ -0,0 +1,141 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// PendingRetry represents a webhook delivery whose retry schedule has not finished yet.
+// It is persisted so that the schedule can be resumed after a process restart.
+type PendingRetry struct {
+	ID             string    `firestore:"-"`
+	SubscriptionID string    `firestore:"subscriptionId"`
+	EventID        string    `firestore:"eventId"`
+	DeliveryID     string    `firestore:"deliveryId,omitempty"` // Sent as X-Delivery-Id by every attempt
+	Attempt        int       `firestore:"attempt"`              // Attempt that is scheduled next (0 is the initial delivery)
+	NextAttemptAt  time.Time `firestore:"nextAttemptAt"`        // When the scheduled attempt is due
+	ExpiresAt      time.Time `firestore:"expiresAt"`            // After this time the schedule is abandoned
+	CreatedAt      time.Time `firestore:"createdAt"`
+	UpdatedAt      time.Time `firestore:"updatedAt"`
+}
+
+// Expired reports whether the retry window has passed at the given time
+func (p PendingRetry) Expired(now time.Time) bool {
+	return !p.ExpiresAt.IsZero() && now.After(p.ExpiresAt)
+}
+
+// PendingRetryID returns the deterministic document ID for a subscription/event pair.
+// Using a deterministic ID makes Save an upsert, so each delivery has at most one record.
+func PendingRetryID(subscriptionID, eventID string) string {
+	return subscriptionID + "_" + eventID
+}
+
+// RetryRepository defines the interface for persisting unfinished retry schedules
+type RetryRepository interface {
+	// Save creates or replaces the pending retry and returns its ID
+	Save(ctx context.Context, retry PendingRetry) (string, error)
+
+	// Delete removes a pending retry by ID (no error if it does not exist)
+	Delete(ctx context.Context, id string) error
+
+	// List returns all pending retries
+	List(ctx context.Context) ([]PendingRetry, error)
+}
+
+// FirestoreRetryRepository implements RetryRepository using Firestore
+type FirestoreRetryRepository struct {
+	client     *firestore.Client
+	collection string
+}
+
+// Compile-time interface check
+var _ RetryRepository = (*FirestoreRetryRepository)(nil)
+
+// NewFirestoreRetryRepository creates a new FirestoreRetryRepository
+func NewFirestoreRetryRepository(client *firestore.Client) *FirestoreRetryRepository {
+	return &FirestoreRetryRepository{
+		client:     client,
+		collection: "pending_retries",
+	}
+}
+
+// Save creates or replaces a pending retry in Firestore
+func (r *FirestoreRetryRepository) Save(ctx context.Context, retry PendingRetry) (string, error) {
+	if r.client == nil {
+		return "", fmt.Errorf("firestore client is nil")
+	}
+	if retry.SubscriptionID == "" || retry.EventID == "" {
+		return "", fmt.Errorf("subscription ID and event ID are required")
+	}
+
+	id := retry.ID
+	if id == "" {
+		id = PendingRetryID(retry.SubscriptionID, retry.EventID)
+	}
+
+	record := retry
+	now := time.Now()
+	if record.CreatedAt.IsZero() {
+		record.CreatedAt = now
+	}
+	record.UpdatedAt = now
+
+	if _, err := r.client.Collection(r.collection).Doc(id).Set(ctx, record); err != nil {
+		return "", fmt.Errorf("failed to save pending retry: %w", err)
+	}
+
+	return id, nil
+}
+
+// Delete removes a pending retry from Firestore
+func (r *FirestoreRetryRepository) Delete(ctx context.Context, id string) error {
+	if r.client == nil {
+		return fmt.Errorf("firestore client is nil")
+	}
+	if id == "" {
+		return fmt.Errorf("pending retry ID is required")
+	}
+
+	_, err := r.client.Collection(r.collection).Doc(id).Delete(ctx)
+	if err != nil && status.Code(err) != codes.NotFound {
+		return fmt.Errorf("failed to delete pending retry: %w", err)
+	}
+
+	return nil
+}
+
+// List returns all pending retries ordered by next attempt time
+func (r *FirestoreRetryRepository) List(ctx context.Context) ([]PendingRetry, error) {
+	if r.client == nil {
+		return nil, fmt.Errorf("firestore client is nil")
+	}
+
+	iter := r.client.Collection(r.collection).OrderBy("nextAttemptAt", firestore.Asc).Documents(ctx)
+	defer iter.Stop()
+
+	retries := make([]PendingRetry, 0)
+	for {
+		docSnap, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to iterate pending retries: %w", err)
+		}
+
+		var retry PendingRetry
+		if err := docSnap.DataTo(&retry); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal pending retry: %w", err)
+		}
+		retry.ID = docSnap.Ref.ID
+		retries = append(retries, retry)
+	}
+
+	return retries, nil
+}
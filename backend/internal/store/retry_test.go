@@ -0,0 +1,65 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestPendingRetryID(t *testing.T) {
+	if got := PendingRetryID("sub-1", "evt-1"); got != "sub-1_evt-1" {
+		t.Errorf("PendingRetryID() = %q, want %q", got, "sub-1_evt-1")
+	}
+}
+
+func TestPendingRetry_Expired(t *testing.T) {
+	now := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		expiresAt time.Time
+		want      bool
+	}{
+		{name: "zero expiry never expires", expiresAt: time.Time{}, want: false},
+		{name: "future expiry", expiresAt: now.Add(time.Minute), want: false},
+		{name: "past expiry", expiresAt: now.Add(-time.Minute), want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			retry := PendingRetry{ExpiresAt: tt.expiresAt}
+			if got := retry.Expired(now); got != tt.want {
+				t.Errorf("Expired() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewFirestoreRetryRepository(t *testing.T) {
+	repo := NewFirestoreRetryRepository(nil)
+	if repo == nil {
+		t.Fatal("NewFirestoreRetryRepository returned nil")
+	}
+	if repo.collection != "pending_retries" {
+		t.Errorf("collection = %q, want %q", repo.collection, "pending_retries")
+	}
+}
+
+func TestFirestoreRetryRepository_NilClient(t *testing.T) {
+	repo := NewFirestoreRetryRepository(nil)
+	ctx := context.Background()
+
+	if _, err := repo.Save(ctx, PendingRetry{SubscriptionID: "sub-1", EventID: "evt-1"}); err == nil {
+		t.Error("Save() expected error for nil client")
+	}
+	if err := repo.Delete(ctx, "sub-1_evt-1"); err == nil {
+		t.Error("Delete() expected error for nil client")
+	}
+	if _, err := repo.List(ctx); err == nil {
+		t.Error("List() expected error for nil client")
+	}
+}
+
+func TestFirestoreRetryRepository_ImplementsInterface(t *testing.T) {
+	var _ RetryRepository = (*FirestoreRetryRepository)(nil)
+}
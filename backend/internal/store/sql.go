@@ -0,0 +1,224 @@
+package store
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	_ "github.com/jackc/pgx/v5/stdlib" // Registers the "pgx" driver
+	_ "modernc.org/sqlite"             // Registers the "sqlite" driver
+)
+
+// SQL dialects supported by OpenSQL
+const (
+	DialectSQLite   = "sqlite"
+	DialectPostgres = "postgres"
+)
+
+// sqlTimeLayout stores timestamps as fixed-width UTC text, so they sort
+// correctly as strings on every dialect
+const sqlTimeLayout = "2006-01-02T15:04:05.000000000Z"
+
+// migrations are applied in order and recorded in schema_migrations.
+// Never edit a released migration; append a new one instead.
+var migrations = [][]string{
+	// 1: subscriptions, events and users
+	{
+		`CREATE TABLE IF NOT EXISTS subscriptions (
+			id         TEXT PRIMARY KEY,
+			user_id    TEXT NOT NULL DEFAULT '',
+			created_at TEXT NOT NULL DEFAULT '',
+			data       TEXT NOT NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS subscriptions_user_id ON subscriptions (user_id)`,
+		`CREATE TABLE IF NOT EXISTS events (
+			id             TEXT PRIMARY KEY,
+			type           TEXT NOT NULL,
+			source         TEXT NOT NULL,
+			severity       INTEGER NOT NULL,
+			affected_areas TEXT NOT NULL,
+			occurred_at    TEXT NOT NULL,
+			received_at    TEXT NOT NULL,
+			raw_json       TEXT NOT NULL,
+			created_at     TEXT NOT NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS events_occurred_at ON events (occurred_at)`,
+		`CREATE TABLE IF NOT EXISTS users (
+			id                 TEXT PRIMARY KEY,
+			uid                TEXT NOT NULL UNIQUE,
+			stripe_customer_id TEXT NOT NULL DEFAULT '',
+			data               TEXT NOT NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS users_stripe_customer_id ON users (stripe_customer_id)`,
+	},
+	// 2: events recovered from the source's history after a reconnection
+	{
+		`ALTER TABLE events ADD COLUMN backfilled INTEGER NOT NULL DEFAULT 0`,
+	},
+}
+
+// SQLClient is a database/sql connection pool for self-hosting without Firestore.
+// Subscriptions, events and users have SQL repositories; features that need
+// other collections (retries, delivery logs, egress metering) stay disabled.
+type SQLClient struct {
+	db      *sql.DB
+	dialect string
+}
+
+// OpenSQL connects to a SQLite file or a Postgres database and applies
+// pending migrations.
+//
+// Parameters:
+//   - ctx: Context for the connection check and migrations
+//   - dialect: DialectSQLite or DialectPostgres
+//   - dsn: file path (or ":memory:") for SQLite, connection URL for Postgres
+func OpenSQL(ctx context.Context, dialect, dsn string) (*SQLClient, error) {
+	var driver string
+	switch dialect {
+	case DialectSQLite:
+		driver = "sqlite"
+	case DialectPostgres:
+		driver = "pgx"
+	default:
+		return nil, fmt.Errorf("unsupported SQL dialect: %q", dialect)
+	}
+
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s database: %w", dialect, err)
+	}
+	if dialect == DialectSQLite {
+		// SQLite allows one writer; a single connection also keeps ":memory:" databases shared
+		db.SetMaxOpenConns(1)
+	}
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to connect to %s database: %w", dialect, err)
+	}
+
+	client := &SQLClient{db: db, dialect: dialect}
+	if err := client.Migrate(ctx); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return client, nil
+}
+
+// DB returns the underlying connection pool
+func (c *SQLClient) DB() *sql.DB {
+	return c.db
+}
+
+// Dialect returns DialectSQLite or DialectPostgres
+func (c *SQLClient) Dialect() string {
+	return c.dialect
+}
+
+// Ping checks that the database answers
+func (c *SQLClient) Ping(ctx context.Context) error {
+	return c.db.PingContext(ctx)
+}
+
+// Close closes the connection pool
+func (c *SQLClient) Close() error {
+	return c.db.Close()
+}
+
+// Rebind converts "?" placeholders to the numbered form Postgres expects
+func (c *SQLClient) Rebind(query string) string {
+	if c.dialect != DialectPostgres {
+		return query
+	}
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// Migrate applies the migrations that have not been applied yet
+func (c *SQLClient) Migrate(ctx context.Context) error {
+	if _, err := c.db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    INTEGER PRIMARY KEY,
+		applied_at TEXT NOT NULL
+	)`); err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	var current int
+	if err := c.db.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&current); err != nil {
+		return fmt.Errorf("failed to read schema version: %w", err)
+	}
+
+	for i := current; i < len(migrations); i++ {
+		version := i + 1
+		tx, err := c.db.BeginTx(ctx, nil)
+		if err != nil {
+			return fmt.Errorf("migration %d: %w", version, err)
+		}
+		for _, stmt := range migrations[i] {
+			if _, err := tx.ExecContext(ctx, stmt); err != nil {
+				tx.Rollback()
+				return fmt.Errorf("migration %d: %w", version, err)
+			}
+		}
+		if _, err := tx.ExecContext(ctx, c.Rebind(`INSERT INTO schema_migrations (version, applied_at) VALUES (?, ?)`),
+			version, FormatSQLTime(time.Now())); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %d: %w", version, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("migration %d: %w", version, err)
+		}
+	}
+	return nil
+}
+
+// FormatSQLTime formats a timestamp for a TEXT column. The zero time is stored as "".
+func FormatSQLTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(sqlTimeLayout)
+}
+
+// ParseSQLTime parses a timestamp written by FormatSQLTime. "" is the zero time.
+func ParseSQLTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(sqlTimeLayout, s)
+}
+
+// autoIDChars are the characters of generated IDs, as in Firestore auto IDs
+const autoIDChars = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789"
+
+// NewSQLID returns a random 20-character ID in the style of Firestore auto IDs
+func NewSQLID() string {
+	b := make([]byte, 20)
+	if _, err := rand.Read(b); err != nil {
+		panic(fmt.Sprintf("crypto/rand failed: %v", err))
+	}
+	for i := range b {
+		b[i] = autoIDChars[int(b[i])%len(autoIDChars)]
+	}
+	return string(b)
+}
+
+// sqlBool stores a boolean in an INTEGER column, which both dialects support
+func sqlBool(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
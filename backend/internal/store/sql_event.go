@@ -0,0 +1,245 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// eventColumns are the columns of the events table, in the order scanEvent reads them
+const eventColumns = `id, type, source, severity, affected_areas, occurred_at, received_at, raw_json, created_at, backfilled`
+
+// SQLEventRepository implements EventRepository on SQLite or Postgres
+type SQLEventRepository struct {
+	client *SQLClient
+}
+
+// Compile-time interface check
+var _ EventRepository = (*SQLEventRepository)(nil)
+
+// NewSQLEventRepository creates a new SQLEventRepository
+func NewSQLEventRepository(client *SQLClient) *SQLEventRepository {
+	return &SQLEventRepository{client: client}
+}
+
+// Create stores an event. Like the Firestore implementation, an event with
+// an ID replaces any stored event with the same ID.
+func (r *SQLEventRepository) Create(ctx context.Context, event EventRecord) (string, error) {
+	record := createEventRecord(event)
+	if record.ID == "" {
+		record.ID = NewSQLID()
+	}
+
+	areas, err := json.Marshal(record.AffectedAreas)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode affected areas: %w", err)
+	}
+
+	_, err = r.client.DB().ExecContext(ctx, r.client.Rebind(`
+		INSERT INTO events (`+eventColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET
+			type = excluded.type, source = excluded.source, severity = excluded.severity,
+			affected_areas = excluded.affected_areas, occurred_at = excluded.occurred_at,
+			received_at = excluded.received_at, raw_json = excluded.raw_json, created_at = excluded.created_at,
+			backfilled = excluded.backfilled`),
+		record.ID, record.Type, record.Source, record.Severity, string(areas),
+		FormatSQLTime(record.OccurredAt), FormatSQLTime(record.ReceivedAt), record.RawJSON, FormatSQLTime(record.CreatedAt),
+		sqlBool(record.Backfilled))
+	if err != nil {
+		return "", fmt.Errorf("failed to create event: %w", err)
+	}
+	return record.ID, nil
+}
+
+// Get retrieves an event by ID. It returns nil and no error if not found.
+func (r *SQLEventRepository) Get(ctx context.Context, id string) (*EventRecord, error) {
+	if id == "" {
+		return nil, fmt.Errorf("event ID is required")
+	}
+
+	row := r.client.DB().QueryRowContext(ctx, r.client.Rebind(`
+		SELECT `+eventColumns+`
+		FROM events WHERE id = ?`), id)
+	record, err := scanEvent(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get event: %w", err)
+	}
+	return &record, nil
+}
+
+// List retrieves events ordered by occurredAt descending with pagination
+func (r *SQLEventRepository) List(ctx context.Context, limit int, startAfter *time.Time) ([]EventRecord, error) {
+	if limit <= 0 {
+		limit = 10 // Default limit
+	}
+
+	query := `SELECT ` + eventColumns + ` FROM events`
+	args := []interface{}{}
+	if startAfter != nil {
+		query += ` WHERE occurred_at < ?`
+		args = append(args, FormatSQLTime(*startAfter))
+	}
+	query += ` ORDER BY occurred_at DESC LIMIT ?`
+	args = append(args, limit)
+
+	rows, err := r.client.DB().QueryContext(ctx, r.client.Rebind(query), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list events: %w", err)
+	}
+	defer rows.Close()
+
+	records := make([]EventRecord, 0, limit)
+	for rows.Next() {
+		record, err := scanEvent(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read event: %w", err)
+		}
+		records = append(records, record)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate events: %w", err)
+	}
+	return records, nil
+}
+
+// Query retrieves a filtered page of events ordered by occurredAt.
+// Prefectures are matched against the JSON-encoded affected areas.
+func (r *SQLEventRepository) Query(ctx context.Context, q EventQuery) (*EventPage, error) {
+	cursor, err := decodeEventCursor(q.Cursor)
+	if err != nil {
+		return nil, err
+	}
+	limit := q.limit()
+
+	var conds []string
+	var args []interface{}
+	if q.Type != "" {
+		conds = append(conds, `type = ?`)
+		args = append(args, q.Type)
+	}
+	if q.MinSeverity > 0 {
+		conds = append(conds, `severity >= ?`)
+		args = append(args, q.MinSeverity)
+	}
+	if q.Prefecture != "" {
+		area, err := json.Marshal(q.Prefecture)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode prefecture: %w", err)
+		}
+		conds = append(conds, `affected_areas LIKE ?`)
+		args = append(args, "%"+string(area)+"%")
+	}
+	if q.From != nil {
+		conds = append(conds, `occurred_at >= ?`)
+		args = append(args, FormatSQLTime(*q.From))
+	}
+	if q.To != nil {
+		conds = append(conds, `occurred_at < ?`)
+		args = append(args, FormatSQLTime(*q.To))
+	}
+
+	var total int
+	countQuery := `SELECT COUNT(*) FROM events` + sqlWhere(conds)
+	if err := r.client.DB().QueryRowContext(ctx, r.client.Rebind(countQuery), args...).Scan(&total); err != nil {
+		return nil, fmt.Errorf("failed to count events: %w", err)
+	}
+
+	op, dir := "<", "DESC"
+	if q.Ascending {
+		op, dir = ">", "ASC"
+	}
+	if cursor != nil {
+		occurredAt := FormatSQLTime(cursor.OccurredAt)
+		conds = append(conds, `(occurred_at `+op+` ? OR (occurred_at = ? AND id `+op+` ?))`)
+		args = append(args, occurredAt, occurredAt, cursor.ID)
+	}
+	query := `SELECT ` + eventColumns + ` FROM events` +
+		sqlWhere(conds) + ` ORDER BY occurred_at ` + dir + `, id ` + dir + ` LIMIT ?`
+	args = append(args, limit+1)
+
+	rows, err := r.client.DB().QueryContext(ctx, r.client.Rebind(query), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query events: %w", err)
+	}
+	defer rows.Close()
+
+	records := make([]EventRecord, 0, limit+1)
+	for rows.Next() {
+		record, err := scanEvent(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read event: %w", err)
+		}
+		records = append(records, record)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate events: %w", err)
+	}
+	return newEventPage(records, limit, total), nil
+}
+
+// Delete removes the events with the given IDs. Missing events are ignored.
+func (r *SQLEventRepository) Delete(ctx context.Context, ids []string) error {
+	for start := 0; start < len(ids); start += sqlDeleteBatchSize {
+		batch := ids[start:min(start+sqlDeleteBatchSize, len(ids))]
+		args := make([]interface{}, len(batch))
+		for i, id := range batch {
+			args[i] = id
+		}
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(batch)), ", ")
+		if _, err := r.client.DB().ExecContext(ctx, r.client.Rebind(
+			`DELETE FROM events WHERE id IN (`+placeholders+`)`), args...); err != nil {
+			return fmt.Errorf("failed to delete events: %w", err)
+		}
+	}
+	return nil
+}
+
+// sqlDeleteBatchSize bounds the placeholders of a DELETE, well under SQLite's limit
+const sqlDeleteBatchSize = 500
+
+// sqlWhere joins conditions into a WHERE clause, or returns "" when there are none
+func sqlWhere(conds []string) string {
+	if len(conds) == 0 {
+		return ""
+	}
+	return ` WHERE ` + strings.Join(conds, ` AND `)
+}
+
+// rowScanner is satisfied by *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanEvent reads an events row selected in column order
+func scanEvent(row rowScanner) (EventRecord, error) {
+	var record EventRecord
+	var areas, occurredAt, receivedAt, createdAt string
+	var backfilled int
+	if err := row.Scan(&record.ID, &record.Type, &record.Source, &record.Severity, &areas,
+		&occurredAt, &receivedAt, &record.RawJSON, &createdAt, &backfilled); err != nil {
+		return EventRecord{}, err
+	}
+	record.Backfilled = backfilled != 0
+	if err := json.Unmarshal([]byte(areas), &record.AffectedAreas); err != nil {
+		return EventRecord{}, fmt.Errorf("invalid affected areas: %w", err)
+	}
+	var err error
+	if record.OccurredAt, err = ParseSQLTime(occurredAt); err != nil {
+		return EventRecord{}, err
+	}
+	if record.ReceivedAt, err = ParseSQLTime(receivedAt); err != nil {
+		return EventRecord{}, err
+	}
+	if record.CreatedAt, err = ParseSQLTime(createdAt); err != nil {
+		return EventRecord{}, err
+	}
+	return record, nil
+}
@@ -0,0 +1,92 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestSQLEventRepository(t *testing.T) {
+	for dialect, client := range openTestSQL(t) {
+		t.Run(dialect, func(t *testing.T) {
+			ctx := context.Background()
+			repo := NewSQLEventRepository(client)
+			base := time.Date(2026, 3, 11, 5, 46, 0, 0, time.UTC)
+
+			id, err := repo.Create(ctx, EventRecord{
+				ID:            "quake-1",
+				Type:          "earthquake",
+				Source:        "気象庁",
+				Severity:      5,
+				AffectedAreas: []string{"宮城県", "福島県"},
+				OccurredAt:    base,
+				ReceivedAt:    base.Add(time.Minute),
+				RawJSON:       `{"_id":"quake-1"}`,
+			})
+			if err != nil || id != "quake-1" {
+				t.Fatalf("Create() = %q, %v", id, err)
+			}
+
+			event, err := repo.Get(ctx, "quake-1")
+			if err != nil || event == nil {
+				t.Fatalf("Get() = %v, %v", event, err)
+			}
+			if event.Source != "気象庁" || event.Severity != 5 || len(event.AffectedAreas) != 2 ||
+				!event.OccurredAt.Equal(base) || event.RawJSON != `{"_id":"quake-1"}` || event.CreatedAt.IsZero() {
+				t.Errorf("Get() = %+v", event)
+			}
+
+			// Creating with the same ID replaces the event
+			if _, err := repo.Create(ctx, EventRecord{ID: "quake-1", Type: "earthquake", Severity: 6, OccurredAt: base}); err != nil {
+				t.Fatal(err)
+			}
+			if event, _ := repo.Get(ctx, "quake-1"); event.Severity != 6 {
+				t.Errorf("Severity = %d after replace, want 6", event.Severity)
+			}
+
+			generated, err := repo.Create(ctx, EventRecord{Type: "tsunami", OccurredAt: base.Add(time.Hour)})
+			if err != nil || generated == "" {
+				t.Fatalf("Create() without ID = %q, %v", generated, err)
+			}
+			if _, err := repo.Create(ctx, EventRecord{ID: "quake-0", Type: "earthquake", OccurredAt: base.Add(-time.Hour)}); err != nil {
+				t.Fatal(err)
+			}
+
+			if missing, err := repo.Get(ctx, "missing"); err != nil || missing != nil {
+				t.Errorf("Get(missing) = %v, %v, want nil, nil", missing, err)
+			}
+			if _, err := repo.Get(ctx, ""); err == nil {
+				t.Error("Get(\"\") should fail")
+			}
+
+			// Newest first, paginated by occurrence time
+			page, err := repo.List(ctx, 2, nil)
+			if err != nil {
+				t.Fatalf("List() error = %v", err)
+			}
+			if len(page) != 2 || page[0].ID != generated || page[1].ID != "quake-1" {
+				t.Fatalf("List() = %+v", page)
+			}
+			next, err := repo.List(ctx, 2, &page[1].OccurredAt)
+			if err != nil || len(next) != 1 || next[0].ID != "quake-0" {
+				t.Errorf("List(startAfter) = %+v, %v", next, err)
+			}
+		})
+	}
+}
+
+func TestSQLEventRepository_Delete(t *testing.T) {
+	for dialect, client := range openTestSQL(t) {
+		t.Run(dialect, func(t *testing.T) {
+			testEventDelete(t, NewSQLEventRepository(client))
+		})
+	}
+}
+
+func TestSQLEventRepository_Query(t *testing.T) {
+	for dialect, client := range openTestSQL(t) {
+		t.Run(dialect, func(t *testing.T) {
+			testEventQuery(t, NewSQLEventRepository(client))
+		})
+	}
+}
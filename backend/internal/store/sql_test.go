@@ -0,0 +1,130 @@
+package store
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+)
+
+// openTestSQL returns an in-memory SQLite client, plus a Postgres client when
+// NAMAZU_TEST_POSTGRES_DSN is set. Postgres tables are emptied first.
+func openTestSQL(t *testing.T) map[string]*SQLClient {
+	t.Helper()
+	ctx := context.Background()
+	clients := map[string]*SQLClient{}
+
+	sqlite, err := OpenSQL(ctx, DialectSQLite, ":memory:")
+	if err != nil {
+		t.Fatalf("OpenSQL(sqlite) error = %v", err)
+	}
+	t.Cleanup(func() { sqlite.Close() })
+	clients[DialectSQLite] = sqlite
+
+	if dsn := os.Getenv("NAMAZU_TEST_POSTGRES_DSN"); dsn != "" {
+		pg, err := OpenSQL(ctx, DialectPostgres, dsn)
+		if err != nil {
+			t.Fatalf("OpenSQL(postgres) error = %v", err)
+		}
+		t.Cleanup(func() { pg.Close() })
+		for _, table := range []string{"subscriptions", "events", "users"} {
+			if _, err := pg.DB().ExecContext(ctx, "DELETE FROM "+table); err != nil {
+				t.Fatalf("failed to empty %s: %v", table, err)
+			}
+		}
+		clients[DialectPostgres] = pg
+	}
+	return clients
+}
+
+func TestOpenSQL_UnsupportedDialect(t *testing.T) {
+	if _, err := OpenSQL(context.Background(), "mysql", "namazu"); err == nil {
+		t.Error("OpenSQL() should reject unsupported dialects")
+	}
+}
+
+func TestSQLClient_Migrate(t *testing.T) {
+	for dialect, client := range openTestSQL(t) {
+		t.Run(dialect, func(t *testing.T) {
+			ctx := context.Background()
+
+			// Already applied by OpenSQL; running again must be a no-op
+			if err := client.Migrate(ctx); err != nil {
+				t.Fatalf("Migrate() error = %v", err)
+			}
+			var version, count int
+			if err := client.DB().QueryRowContext(ctx, `SELECT MAX(version), COUNT(*) FROM schema_migrations`).Scan(&version, &count); err != nil {
+				t.Fatal(err)
+			}
+			if version != len(migrations) || count != len(migrations) {
+				t.Errorf("schema_migrations = version %d / %d rows, want %d", version, count, len(migrations))
+			}
+		})
+	}
+}
+
+func TestSQLClient_MigrateFile(t *testing.T) {
+	ctx := context.Background()
+	path := t.TempDir() + "/namazu.db"
+
+	client, err := OpenSQL(ctx, DialectSQLite, path)
+	if err != nil {
+		t.Fatalf("OpenSQL() error = %v", err)
+	}
+	if _, err := NewSQLEventRepository(client).Create(ctx, EventRecord{ID: "evt-1", RawJSON: "{}"}); err != nil {
+		t.Fatal(err)
+	}
+	client.Close()
+
+	// Reopening keeps the data and does not re-run migrations
+	client, err = OpenSQL(ctx, DialectSQLite, path)
+	if err != nil {
+		t.Fatalf("OpenSQL() reopen error = %v", err)
+	}
+	defer client.Close()
+	if event, err := NewSQLEventRepository(client).Get(ctx, "evt-1"); err != nil || event == nil {
+		t.Errorf("Get() after reopen = %v, %v", event, err)
+	}
+}
+
+func TestSQLClient_Rebind(t *testing.T) {
+	query := `SELECT * FROM t WHERE a = ? AND b = ?`
+	sqlite := &SQLClient{dialect: DialectSQLite}
+	if got := sqlite.Rebind(query); got != query {
+		t.Errorf("sqlite Rebind() = %q", got)
+	}
+	pg := &SQLClient{dialect: DialectPostgres}
+	if got := pg.Rebind(query); got != `SELECT * FROM t WHERE a = $1 AND b = $2` {
+		t.Errorf("postgres Rebind() = %q", got)
+	}
+}
+
+func TestSQLTime(t *testing.T) {
+	if FormatSQLTime(time.Time{}) != "" {
+		t.Error("FormatSQLTime(zero) should be empty")
+	}
+	if parsed, err := ParseSQLTime(""); err != nil || !parsed.IsZero() {
+		t.Errorf("ParseSQLTime(\"\") = %v, %v", parsed, err)
+	}
+
+	jst := time.FixedZone("JST", 9*60*60)
+	at := time.Date(2026, 3, 11, 14, 46, 18, 120000000, jst)
+	parsed, err := ParseSQLTime(FormatSQLTime(at))
+	if err != nil || !parsed.Equal(at) {
+		t.Errorf("round trip = %v, %v, want %v", parsed, err, at)
+	}
+
+	// Fixed width keeps string order equal to time order
+	earlier := FormatSQLTime(time.Date(2026, 3, 11, 5, 46, 18, 0, time.UTC))
+	later := FormatSQLTime(time.Date(2026, 3, 11, 5, 46, 18, 500000000, time.UTC))
+	if !(earlier < later) {
+		t.Errorf("%q should sort before %q", earlier, later)
+	}
+}
+
+func TestNewSQLID(t *testing.T) {
+	a, b := NewSQLID(), NewSQLID()
+	if len(a) != 20 || a == b {
+		t.Errorf("NewSQLID() = %q, %q, want distinct 20-character IDs", a, b)
+	}
+}
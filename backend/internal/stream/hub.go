@@ -0,0 +1,98 @@
+// Package stream fans out events to live clients such as browser dashboards
+package stream
+
+import (
+	"sync"
+	"time"
+
+	"github.com/otiai10/namazu/backend/internal/source"
+	"github.com/otiai10/namazu/backend/internal/store"
+	"github.com/otiai10/namazu/backend/internal/subscription"
+)
+
+// clientBufferSize is the number of events a client can fall behind before events are dropped for it
+const clientBufferSize = 16
+
+// Hub delivers published events to every subscribed client whose filter matches.
+// Publishing never blocks the event loop: a client that cannot keep up misses events.
+type Hub struct {
+	mu      sync.RWMutex
+	clients map[*Client]struct{}
+}
+
+// Client is a live subscription to the hub
+type Client struct {
+	filter  *subscription.FilterConfig
+	events  chan store.EventRecord
+	mu      sync.Mutex
+	dropped int
+}
+
+// NewHub creates an empty hub
+func NewHub() *Hub {
+	return &Hub{clients: make(map[*Client]struct{})}
+}
+
+// Subscribe registers a client that receives events matching filter.
+// A nil filter uses the same defaults as a webhook subscription without a filter.
+func (h *Hub) Subscribe(filter *subscription.FilterConfig) *Client {
+	c := &Client{filter: filter, events: make(chan store.EventRecord, clientBufferSize)}
+	h.mu.Lock()
+	h.clients[c] = struct{}{}
+	h.mu.Unlock()
+	return c
+}
+
+// Unsubscribe removes the client and closes its event channel
+func (h *Hub) Unsubscribe(c *Client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.clients[c]; ok {
+		delete(h.clients, c)
+		close(c.events)
+	}
+}
+
+// Publish sends the event to matching clients.
+// id is the stored event ID; the source ID is used when the event was not stored.
+func (h *Hub) Publish(event source.Event, id string) {
+	record := store.EventFromSource(event)
+	record.CreatedAt = time.Now().UTC()
+	if id != "" {
+		record.ID = id
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for c := range h.clients {
+		if !c.filter.Matches(event) {
+			continue
+		}
+		select {
+		case c.events <- record:
+		default:
+			c.mu.Lock()
+			c.dropped++
+			c.mu.Unlock()
+		}
+	}
+}
+
+// Count returns the number of connected clients
+func (h *Hub) Count() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.clients)
+}
+
+// Events returns the channel of matching events. It is closed by Unsubscribe.
+func (c *Client) Events() <-chan store.EventRecord {
+	return c.events
+}
+
+// Dropped returns the number of events skipped because the client was behind
+func (c *Client) Dropped() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.dropped
+}
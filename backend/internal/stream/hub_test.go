@@ -0,0 +1,78 @@
+package stream
+
+import (
+	"testing"
+
+	"github.com/otiai10/namazu/backend/internal/source/p2pquake"
+	"github.com/otiai10/namazu/backend/internal/subscription"
+)
+
+func newQuake(id string, scale int, pref string) *p2pquake.JMAQuake {
+	return &p2pquake.JMAQuake{
+		ID:         id,
+		Code:       551,
+		Earthquake: &p2pquake.Earthquake{MaxScale: scale},
+		Points:     []p2pquake.Point{{Prefecture: pref, Scale: scale}},
+	}
+}
+
+func TestHub_PublishFilters(t *testing.T) {
+	hub := NewHub()
+	all := hub.Subscribe(nil)
+	tokyo := hub.Subscribe(&subscription.FilterConfig{Prefectures: []string{"東京都"}})
+	strong := hub.Subscribe(&subscription.FilterConfig{MinScale: p2pquake.Scale5Weak})
+
+	hub.Publish(newQuake("q1", p2pquake.Scale3, "東京都"), "stored-1")
+	hub.Publish(newQuake("q2", p2pquake.Scale6Weak, "大阪府"), "")
+
+	if got := len(all.Events()); got != 2 {
+		t.Errorf("unfiltered client got %d events, want 2", got)
+	}
+	if got := len(tokyo.Events()); got != 1 {
+		t.Fatalf("prefecture client got %d events, want 1", got)
+	}
+	if record := <-tokyo.Events(); record.ID != "stored-1" || record.CreatedAt.IsZero() {
+		t.Errorf("expected the stored ID and a creation time, got %+v", record)
+	}
+	if got := len(strong.Events()); got != 1 {
+		t.Fatalf("min scale client got %d events, want 1", got)
+	}
+	if record := <-strong.Events(); record.ID != "q2" {
+		t.Errorf("expected the source ID for an unstored event, got %q", record.ID)
+	}
+}
+
+func TestHub_SlowClient(t *testing.T) {
+	hub := NewHub()
+	c := hub.Subscribe(nil)
+
+	for i := 0; i < clientBufferSize+3; i++ {
+		hub.Publish(newQuake("q", p2pquake.Scale3, "東京都"), "")
+	}
+
+	if got := len(c.Events()); got != clientBufferSize {
+		t.Errorf("buffered %d events, want %d", got, clientBufferSize)
+	}
+	if got := c.Dropped(); got != 3 {
+		t.Errorf("Dropped() = %d, want 3", got)
+	}
+}
+
+func TestHub_Unsubscribe(t *testing.T) {
+	hub := NewHub()
+	c := hub.Subscribe(nil)
+	if hub.Count() != 1 {
+		t.Fatalf("Count() = %d, want 1", hub.Count())
+	}
+
+	hub.Unsubscribe(c)
+	hub.Unsubscribe(c) // idempotent
+
+	if hub.Count() != 0 {
+		t.Errorf("Count() = %d, want 0", hub.Count())
+	}
+	if _, ok := <-c.Events(); ok {
+		t.Error("expected the event channel to be closed")
+	}
+	hub.Publish(newQuake("q", p2pquake.Scale3, "東京都"), "")
+}
@@ -0,0 +1,71 @@
+package subscription
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Delivery types that publish to AWS
+const (
+	DeliveryTypeSNS = "sns" // Amazon SNS topic
+	DeliveryTypeSQS = "sqs" // Amazon SQS queue
+)
+
+var (
+	awsRegionPattern    = regexp.MustCompile(`^[a-z]{2}(-[a-z]+)+-\d+$`)
+	snsTopicARNPattern  = regexp.MustCompile(`^arn:aws[a-z-]*:sns:([a-z0-9-]+):\d{12}:[A-Za-z0-9_-]{1,256}(\.fifo)?$`)
+	sqsQueuePathPattern = regexp.MustCompile(`^/\d{12}/[A-Za-z0-9_-]{1,80}(\.fifo)?$`)
+)
+
+// AWSConfig is where "sns" and "sqs" subscriptions publish, and the IAM
+// credentials their requests are signed with. The credentials only need
+// sns:Publish on the topic or sqs:SendMessage on the queue.
+type AWSConfig struct {
+	Region          string `json:"region"`
+	TopicARN        string `json:"topic_arn,omitempty"` // "sns"
+	QueueURL        string `json:"queue_url,omitempty"` // "sqs", e.g. https://sqs.ap-northeast-1.amazonaws.com/123456789012/quakes
+	AccessKeyID     string `json:"access_key_id"`
+	SecretAccessKey string `json:"secret_access_key,omitempty"`
+}
+
+// Validate returns an error message if the config cannot be used with the
+// given delivery type, or "" if valid. Queue URLs must point at SQS in the
+// configured region, so the config cannot be used to reach arbitrary hosts.
+func (c *AWSConfig) Validate(deliveryType string) string {
+	if !awsRegionPattern.MatchString(c.Region) {
+		return "delivery.aws.region is invalid"
+	}
+	if c.AccessKeyID == "" || c.SecretAccessKey == "" {
+		return "delivery.aws.access_key_id and delivery.aws.secret_access_key are required"
+	}
+
+	switch deliveryType {
+	case DeliveryTypeSNS:
+		m := snsTopicARNPattern.FindStringSubmatch(c.TopicARN)
+		if m == nil {
+			return "delivery.aws.topic_arn must be an SNS topic ARN"
+		}
+		if m[1] != c.Region {
+			return "delivery.aws.topic_arn must be in delivery.aws.region"
+		}
+	case DeliveryTypeSQS:
+		u, err := url.Parse(c.QueueURL)
+		if err != nil || u.Scheme != "https" || u.RawQuery != "" || u.User != nil ||
+			!strings.EqualFold(u.Host, "sqs."+c.Region+".amazonaws.com") || !sqsQueuePathPattern.MatchString(u.Path) {
+			return "delivery.aws.queue_url must be an SQS queue URL in delivery.aws.region"
+		}
+	default:
+		return "delivery.aws is only used by sns and sqs deliveries"
+	}
+	return ""
+}
+
+// Copy returns a copy of the config, or nil if c is nil
+func (c *AWSConfig) Copy() *AWSConfig {
+	if c == nil {
+		return nil
+	}
+	copied := *c
+	return &copied
+}
@@ -0,0 +1,39 @@
+package subscription
+
+import "testing"
+
+func TestAWSConfig_Validate(t *testing.T) {
+	valid := AWSConfig{Region: "ap-northeast-1", AccessKeyID: "AKID", SecretAccessKey: "secret"}
+	withTopic := func(arn string) AWSConfig { c := valid; c.TopicARN = arn; return c }
+	withQueue := func(u string) AWSConfig { c := valid; c.QueueURL = u; return c }
+	noSecret := withTopic("arn:aws:sns:ap-northeast-1:123456789012:quakes")
+	noSecret.SecretAccessKey = ""
+	badRegion := withTopic("arn:aws:sns:ap-northeast-1:123456789012:quakes")
+	badRegion.Region = "tokyo"
+
+	tests := []struct {
+		name         string
+		cfg          AWSConfig
+		deliveryType string
+		wantErr      bool
+	}{
+		{"sns topic", withTopic("arn:aws:sns:ap-northeast-1:123456789012:quakes"), DeliveryTypeSNS, false},
+		{"sns fifo topic", withTopic("arn:aws:sns:ap-northeast-1:123456789012:quakes.fifo"), DeliveryTypeSNS, false},
+		{"sns topic in another region", withTopic("arn:aws:sns:us-east-1:123456789012:quakes"), DeliveryTypeSNS, true},
+		{"sns queue ARN", withTopic("arn:aws:sqs:ap-northeast-1:123456789012:quakes"), DeliveryTypeSNS, true},
+		{"sqs queue", withQueue("https://sqs.ap-northeast-1.amazonaws.com/123456789012/quakes"), DeliveryTypeSQS, false},
+		{"sqs queue over http", withQueue("http://sqs.ap-northeast-1.amazonaws.com/123456789012/quakes"), DeliveryTypeSQS, true},
+		{"sqs queue on another host", withQueue("https://sqs.ap-northeast-1.amazonaws.com.evil.example/123456789012/quakes"), DeliveryTypeSQS, true},
+		{"sqs queue with query", withQueue("https://sqs.ap-northeast-1.amazonaws.com/123456789012/quakes?Action=DeleteQueue"), DeliveryTypeSQS, true},
+		{"missing secret", noSecret, DeliveryTypeSNS, true},
+		{"invalid region", badRegion, DeliveryTypeSNS, true},
+		{"webhook", valid, "webhook", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if msg := tt.cfg.Validate(tt.deliveryType); (msg != "") != tt.wantErr {
+				t.Errorf("Validate() = %q, wantErr %v", msg, tt.wantErr)
+			}
+		})
+	}
+}
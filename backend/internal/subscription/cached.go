@@ -0,0 +1,120 @@
+package subscription
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/otiai10/namazu/backend/internal/config"
+)
+
+// DefaultCacheTTL is how long CachedRepository serves a List result
+const DefaultCacheTTL = 30 * time.Second
+
+// CacheTTLFromStore returns the List cache TTL from the store settings (0 disables the cache)
+func CacheTTLFromStore(cfg *config.StoreConfig) time.Duration {
+	switch {
+	case cfg == nil || cfg.SubscriptionCacheTTLMs == 0:
+		return DefaultCacheTTL
+	case cfg.SubscriptionCacheTTLMs < 0:
+		return 0
+	default:
+		return time.Duration(cfg.SubscriptionCacheTTLMs) * time.Millisecond
+	}
+}
+
+// CachedRepository caches the List result, which every event reads, for a TTL.
+// Writes through this repository invalidate the cache, so changes made via the
+// API of this instance are delivered to immediately; changes made elsewhere
+// (another instance, the console) take effect within the TTL.
+// Other reads are passed through, so the API always sees the latest state.
+type CachedRepository struct {
+	repo Repository
+	ttl  time.Duration
+	now  func() time.Time
+
+	mu         sync.Mutex
+	subs       []Subscription // nil when not cached
+	expiresAt  time.Time
+	generation uint64 // incremented by Invalidate, so a List racing a write is not cached
+}
+
+// Compile-time interface check
+var _ Repository = (*CachedRepository)(nil)
+
+// NewCachedRepository wraps repo with a List cache of the given TTL (0 disables caching)
+func NewCachedRepository(repo Repository, ttl time.Duration) *CachedRepository {
+	return &CachedRepository{repo: repo, ttl: ttl, now: time.Now}
+}
+
+// List returns all subscriptions, from the cache while it is fresh
+func (r *CachedRepository) List(ctx context.Context) ([]Subscription, error) {
+	if r.ttl <= 0 {
+		return r.repo.List(ctx)
+	}
+
+	r.mu.Lock()
+	if r.subs != nil && r.now().Before(r.expiresAt) {
+		subs := copySubscriptions(r.subs)
+		r.mu.Unlock()
+		return subs, nil
+	}
+	generation := r.generation
+	r.mu.Unlock()
+
+	subs, err := r.repo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	if r.generation == generation {
+		r.subs = copySubscriptions(subs)
+		r.expiresAt = r.now().Add(r.ttl)
+	}
+	r.mu.Unlock()
+	return subs, nil
+}
+
+// Invalidate drops the cached List result, e.g. after a subscription was
+// changed without going through this repository
+func (r *CachedRepository) Invalidate() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.subs = nil
+	r.generation++
+}
+
+// ListByUserID returns all subscriptions for a specific user
+func (r *CachedRepository) ListByUserID(ctx context.Context, userID string) ([]Subscription, error) {
+	return r.repo.ListByUserID(ctx, userID)
+}
+
+// Create creates a new subscription and invalidates the cache
+func (r *CachedRepository) Create(ctx context.Context, sub Subscription) (string, error) {
+	defer r.Invalidate()
+	return r.repo.Create(ctx, sub)
+}
+
+// CreateWithinLimit creates a subscription within the owner's limit and invalidates the cache
+func (r *CachedRepository) CreateWithinLimit(ctx context.Context, sub Subscription, limit int) (string, error) {
+	defer r.Invalidate()
+	return r.repo.CreateWithinLimit(ctx, sub, limit)
+}
+
+// Get retrieves a subscription by ID
+func (r *CachedRepository) Get(ctx context.Context, id string) (*Subscription, error) {
+	return r.repo.Get(ctx, id)
+}
+
+// Update updates an existing subscription and invalidates the cache
+func (r *CachedRepository) Update(ctx context.Context, id string, sub Subscription) error {
+	defer r.Invalidate()
+	return r.repo.Update(ctx, id, sub)
+}
+
+// Delete removes a subscription by ID and invalidates the cache
+func (r *CachedRepository) Delete(ctx context.Context, id string) error {
+	defer r.Invalidate()
+	return r.repo.Delete(ctx, id)
+}
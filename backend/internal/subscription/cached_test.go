@@ -0,0 +1,102 @@
+package subscription
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/otiai10/namazu/backend/internal/config"
+)
+
+// countingRepository counts List calls
+type countingRepository struct {
+	Repository
+	lists int
+}
+
+func (r *countingRepository) List(ctx context.Context) ([]Subscription, error) {
+	r.lists++
+	return r.Repository.List(ctx)
+}
+
+func TestCachedRepository_List(t *testing.T) {
+	ctx := context.Background()
+	inner := &countingRepository{Repository: NewMemoryRepository()}
+	if _, err := inner.Create(ctx, Subscription{Name: "First"}); err != nil {
+		t.Fatal(err)
+	}
+	repo := NewCachedRepository(inner, time.Minute)
+	now := time.Now()
+	repo.now = func() time.Time { return now }
+
+	for range 3 {
+		subs, err := repo.List(ctx)
+		if err != nil || len(subs) != 1 {
+			t.Fatalf("List() = %v, %v", subs, err)
+		}
+		subs[0].Name = "Modified"
+	}
+	if inner.lists != 1 {
+		t.Errorf("lists = %d, want 1 while the cache is fresh", inner.lists)
+	}
+	if subs, _ := repo.List(ctx); subs[0].Name != "First" {
+		t.Errorf("cached subscription was modified: %q", subs[0].Name)
+	}
+
+	now = now.Add(time.Minute)
+	if _, err := repo.List(ctx); err != nil || inner.lists != 2 {
+		t.Errorf("lists = %d, want a read after the TTL", inner.lists)
+	}
+}
+
+func TestCachedRepository_WritesInvalidate(t *testing.T) {
+	ctx := context.Background()
+	inner := &countingRepository{Repository: NewMemoryRepository()}
+	repo := NewCachedRepository(inner, time.Minute)
+
+	if _, err := repo.List(ctx); err != nil {
+		t.Fatal(err)
+	}
+	id, err := repo.Create(ctx, Subscription{Name: "New"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if subs, _ := repo.List(ctx); len(subs) != 1 {
+		t.Fatalf("List() = %v, want the created subscription", subs)
+	}
+
+	if err := repo.Update(ctx, id, Subscription{Name: "Renamed"}); err != nil {
+		t.Fatal(err)
+	}
+	if subs, _ := repo.List(ctx); len(subs) != 1 || subs[0].Name != "Renamed" {
+		t.Fatalf("List() = %v, want the updated subscription", subs)
+	}
+
+	if err := repo.Delete(ctx, id); err != nil {
+		t.Fatal(err)
+	}
+	if subs, _ := repo.List(ctx); len(subs) != 0 {
+		t.Fatalf("List() = %v, want none after delete", subs)
+	}
+	if inner.lists != 4 {
+		t.Errorf("lists = %d, want a read after each write", inner.lists)
+	}
+}
+
+func TestCacheTTLFromStore(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  *config.StoreConfig
+		want time.Duration
+	}{
+		{name: "no store", want: DefaultCacheTTL},
+		{name: "default", cfg: &config.StoreConfig{}, want: DefaultCacheTTL},
+		{name: "custom", cfg: &config.StoreConfig{SubscriptionCacheTTLMs: 5000}, want: 5 * time.Second},
+		{name: "disabled", cfg: &config.StoreConfig{SubscriptionCacheTTLMs: -1}, want: 0},
+	}
+	for _, tt := range tests {
+		if got := CacheTTLFromStore(tt.cfg); got != tt.want {
+			t.Errorf("%s: CacheTTLFromStore() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package subscription
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/otiai10/namazu/backend/internal/secrets"
+)
+
+// SetEnvelope encrypts the secrets of subscriptions written from now on.
+// Secrets are decrypted when read; plaintext secrets written before encryption
+// was enabled are still read as is until EncryptSecrets rewrites them.
+func (r *FirestoreRepository) SetEnvelope(e *secrets.Envelope) {
+	r.envelope = e
+}
+
+// secretFields returns the fields of sub that are encrypted at rest, besides
+// the values of the custom headers (see mapHeaders)
+func secretFields(sub *Subscription) []*string {
+	fields := []*string{&sub.Delivery.Secret, &sub.Delivery.PreviousSecret}
+	if sub.Delivery.AWS != nil {
+		fields = append(fields, &sub.Delivery.AWS.SecretAccessKey)
+	}
+	return fields
+}
+
+// sealSecrets returns sub with its plaintext secrets encrypted
+func (r *FirestoreRepository) sealSecrets(ctx context.Context, sub Subscription) (Subscription, error) {
+	if r.envelope == nil {
+		return sub, nil
+	}
+	if sub.Delivery.AWS != nil {
+		aws := *sub.Delivery.AWS
+		sub.Delivery.AWS = &aws
+	}
+	for _, field := range secretFields(&sub) {
+		if *field == "" || secrets.IsSealed(*field) {
+			continue
+		}
+		sealed, err := r.envelope.Seal(ctx, *field)
+		if err != nil {
+			return sub, fmt.Errorf("failed to encrypt secret: %w", err)
+		}
+		*field = sealed
+	}
+	headers, err := mapHeaders(sub.Delivery.Headers, func(value string) (string, error) {
+		if value == "" || secrets.IsSealed(value) {
+			return value, nil
+		}
+		sealed, err := r.envelope.Seal(ctx, value)
+		if err != nil {
+			return "", fmt.Errorf("failed to encrypt header: %w", err)
+		}
+		return sealed, nil
+	})
+	if err != nil {
+		return sub, err
+	}
+	sub.Delivery.Headers = headers
+	return sub, nil
+}
+
+// mapHeaders returns a copy of headers with fn applied to every value.
+// Header values carry credentials such as bearer tokens, so they are
+// encrypted like the secrets.
+func mapHeaders(headers map[string]string, fn func(string) (string, error)) (map[string]string, error) {
+	if len(headers) == 0 {
+		return headers, nil
+	}
+	mapped := make(map[string]string, len(headers))
+	for name, value := range headers {
+		v, err := fn(value)
+		if err != nil {
+			return nil, err
+		}
+		mapped[name] = v
+	}
+	return mapped, nil
+}
+
+// openSecrets decrypts the encrypted secrets of sub
+func (r *FirestoreRepository) openSecrets(ctx context.Context, sub *Subscription) error {
+	for _, field := range secretFields(sub) {
+		if !secrets.IsSealed(*field) {
+			continue
+		}
+		if r.envelope == nil {
+			return errors.New("secret is encrypted but no encryption key is configured")
+		}
+		plaintext, err := r.envelope.Open(ctx, *field)
+		if err != nil {
+			return fmt.Errorf("failed to decrypt secret: %w", err)
+		}
+		*field = plaintext
+	}
+	headers, err := mapHeaders(sub.Delivery.Headers, func(value string) (string, error) {
+		if !secrets.IsSealed(value) {
+			return value, nil
+		}
+		if r.envelope == nil {
+			return "", errors.New("header is encrypted but no encryption key is configured")
+		}
+		plaintext, err := r.envelope.Open(ctx, value)
+		if err != nil {
+			return "", fmt.Errorf("failed to decrypt header: %w", err)
+		}
+		return plaintext, nil
+	})
+	if err != nil {
+		return err
+	}
+	sub.Delivery.Headers = headers
+	return nil
+}
+
+// toSubscription converts a document and decrypts its secrets
+func (r *FirestoreRepository) toSubscription(ctx context.Context, doc *firestore.DocumentSnapshot) (Subscription, error) {
+	sub, err := documentToSubscription(doc)
+	if err != nil {
+		return sub, err
+	}
+	if err := r.openSecrets(ctx, &sub); err != nil {
+		return sub, err
+	}
+	return sub, nil
+}
+
+// EncryptSecrets finds the subscriptions whose secrets or header values are stored in plaintext
+// and, if apply is set, encrypts them. It returns the number of such subscriptions.
+//
+// Only the secret fields are rewritten, on the condition that the document is
+// unchanged since it was read. A subscription changed in the meantime is
+// skipped: the change itself was written encrypted.
+func (r *FirestoreRepository) EncryptSecrets(ctx context.Context, apply bool) (int, error) {
+	if r.envelope == nil {
+		return 0, errors.New("no encryption key is configured")
+	}
+	docs, err := r.client.Collection(collectionName).Documents(ctx).GetAll()
+	if err != nil {
+		return 0, fmt.Errorf("failed to list subscriptions: %w", err)
+	}
+
+	found := 0
+	for _, doc := range docs {
+		sub, err := documentToSubscription(doc)
+		if err != nil {
+			return found, fmt.Errorf("failed to convert document %s: %w", doc.Ref.ID, err)
+		}
+		sealed, err := r.sealSecrets(ctx, sub)
+		if err != nil {
+			return found, err
+		}
+		updates := sealedUpdates(sub, sealed)
+		if len(updates) == 0 {
+			continue
+		}
+		found++
+		if !apply {
+			continue
+		}
+		_, err = doc.Ref.Update(ctx, updates, firestore.LastUpdateTime(doc.UpdateTime))
+		if status.Code(err) == codes.FailedPrecondition {
+			found--
+			continue
+		}
+		if err != nil {
+			return found, fmt.Errorf("failed to update subscription %s: %w", doc.Ref.ID, err)
+		}
+	}
+	return found, nil
+}
+
+// sealedUpdates returns the field updates that replace the plaintext secrets
+// of sub by those of sealed
+func sealedUpdates(sub, sealed Subscription) []firestore.Update {
+	var updates []firestore.Update
+	add := func(path []string, before, after string) {
+		if before != after {
+			updates = append(updates, firestore.Update{FieldPath: path, Value: after})
+		}
+	}
+	add([]string{"delivery", "secret"}, sub.Delivery.Secret, sealed.Delivery.Secret)
+	if sub.Delivery.PreviousSecret != "" && sub.Delivery.PreviousSecretExpiresAt != nil {
+		add([]string{"delivery", "previous_secret"}, sub.Delivery.PreviousSecret, sealed.Delivery.PreviousSecret)
+	}
+	if sub.Delivery.AWS != nil {
+		add([]string{"delivery", "aws", "secret_access_key"}, sub.Delivery.AWS.SecretAccessKey, sealed.Delivery.AWS.SecretAccessKey)
+	}
+	for name, value := range sub.Delivery.Headers {
+		if value != sealed.Delivery.Headers[name] {
+			updates = append(updates, firestore.Update{FieldPath: []string{"delivery", "headers"}, Value: sealed.Delivery.Headers})
+			break
+		}
+	}
+	return updates
+}
@@ -0,0 +1,108 @@
+package subscription
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/otiai10/namazu/backend/internal/secrets"
+)
+
+func newTestEnvelope(t *testing.T) *secrets.Envelope {
+	t.Helper()
+	wrapper, err := secrets.NewLocalKeyWrapper(bytes.Repeat([]byte{1}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return secrets.NewEnvelope(wrapper)
+}
+
+func TestFirestoreRepository_SealAndOpenSecrets(t *testing.T) {
+	ctx := context.Background()
+	repo := NewFirestoreRepository(nil)
+	repo.SetEnvelope(newTestEnvelope(t))
+
+	expires := time.Now().Add(time.Hour)
+	sub := Subscription{
+		Name: "AWS",
+		Delivery: DeliveryConfig{
+			Type:                    "webhook",
+			Secret:                  "whsec_current",
+			PreviousSecret:          "whsec_previous",
+			PreviousSecretExpiresAt: &expires,
+			AWS:                     &AWSConfig{AccessKeyID: "AKIA", SecretAccessKey: "aws-secret"},
+			Headers:                 map[string]string{"Authorization": "Bearer token"},
+		},
+	}
+	sealed, err := repo.sealSecrets(ctx, sub)
+	if err != nil {
+		t.Fatalf("sealSecrets() error = %v", err)
+	}
+	for _, s := range []string{sealed.Delivery.Secret, sealed.Delivery.PreviousSecret, sealed.Delivery.AWS.SecretAccessKey, sealed.Delivery.Headers["Authorization"]} {
+		if !secrets.IsSealed(s) {
+			t.Errorf("secret %q was not encrypted", s)
+		}
+	}
+	if sub.Delivery.AWS.SecretAccessKey != "aws-secret" || sealed.Delivery.AWS.AccessKeyID != "AKIA" ||
+		sub.Delivery.Headers["Authorization"] != "Bearer token" {
+		t.Error("sealSecrets() should not modify the caller's subscription")
+	}
+
+	// Sealing is idempotent
+	again, err := repo.sealSecrets(ctx, sealed)
+	if err != nil || again.Delivery.Secret != sealed.Delivery.Secret ||
+		again.Delivery.Headers["Authorization"] != sealed.Delivery.Headers["Authorization"] {
+		t.Errorf("sealSecrets() re-encrypted an encrypted secret: %v", err)
+	}
+
+	updates := sealedUpdates(sub, sealed)
+	if len(updates) != 4 {
+		t.Errorf("sealedUpdates() = %d updates, want 4", len(updates))
+	}
+	if len(sealedUpdates(sealed, again)) != 0 {
+		t.Error("sealedUpdates() should be empty for encrypted secrets")
+	}
+
+	opened := sealed
+	if err := repo.openSecrets(ctx, &opened); err != nil {
+		t.Fatalf("openSecrets() error = %v", err)
+	}
+	if opened.Delivery.Secret != "whsec_current" || opened.Delivery.PreviousSecret != "whsec_previous" ||
+		opened.Delivery.AWS.SecretAccessKey != "aws-secret" || opened.Delivery.Headers["Authorization"] != "Bearer token" {
+		t.Errorf("openSecrets() = %+v, want the plaintext secrets", opened.Delivery)
+	}
+	if !secrets.IsSealed(sealed.Delivery.Headers["Authorization"]) {
+		t.Error("openSecrets() should not modify the headers of the sealed subscription")
+	}
+
+	// Plaintext secrets written before encryption was enabled are read as is
+	plain := Subscription{Delivery: DeliveryConfig{Secret: "whsec_legacy", Headers: map[string]string{"X-Team": "ops"}}}
+	if err := repo.openSecrets(ctx, &plain); err != nil || plain.Delivery.Secret != "whsec_legacy" || plain.Delivery.Headers["X-Team"] != "ops" {
+		t.Errorf("openSecrets() = %+v, %v; want the plaintext secret", plain.Delivery, err)
+	}
+}
+
+func TestFirestoreRepository_WithoutEnvelope(t *testing.T) {
+	ctx := context.Background()
+	repo := NewFirestoreRepository(nil)
+
+	sub := Subscription{Delivery: DeliveryConfig{Secret: "whsec_plain"}}
+	stored, err := repo.sealSecrets(ctx, sub)
+	if err != nil || stored.Delivery.Secret != "whsec_plain" {
+		t.Errorf("sealSecrets() = %q, %v; want the plaintext secret", stored.Delivery.Secret, err)
+	}
+
+	encrypting := NewFirestoreRepository(nil)
+	encrypting.SetEnvelope(newTestEnvelope(t))
+	sealed, err := encrypting.sealSecrets(ctx, sub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.openSecrets(ctx, &sealed); err == nil {
+		t.Error("openSecrets() expected error for an encrypted secret without a key")
+	}
+	if _, err := repo.EncryptSecrets(ctx, false); err == nil {
+		t.Error("EncryptSecrets() expected error without a key")
+	}
+}
@@ -0,0 +1,6 @@
+package subscription
+
+// DeliveryTypeFCM notifies every mobile device the owner registered with
+// POST /api/me/devices over Firebase Cloud Messaging. It needs no delivery
+// settings of its own.
+const DeliveryTypeFCM = "fcm"
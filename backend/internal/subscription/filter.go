@@ -3,14 +3,18 @@ package subscription
 import (
 	"strings"
 
+	"github.com/otiai10/namazu/backend/internal/region"
 	"github.com/otiai10/namazu/backend/internal/source"
 	"github.com/otiai10/namazu/backend/internal/source/p2pquake"
 )
 
 // Matches checks if an event matches the filter criteria.
-// Returns true if filter is nil (no filter = match all).
-// Both MinScale AND Prefectures conditions must be satisfied (AND logic).
+// A nil filter only checks the event type against DefaultEventTypes.
+// EventTypes, MinScale, Prefectures AND the hypocenter conditions must all be satisfied (AND logic).
 func (f *FilterConfig) Matches(event source.Event) bool {
+	if !f.MatchesType(event.GetType()) {
+		return false
+	}
 	if f == nil {
 		return true
 	}
@@ -30,18 +34,90 @@ func (f *FilterConfig) Matches(event source.Event) bool {
 		}
 	}
 
+	return f.matchesHypocenter(event)
+}
+
+// HasHypocenterConditions reports whether the filter constrains the hypocenter
+func (f *FilterConfig) HasHypocenterConditions() bool {
+	return f != nil && (f.MinMagnitude > 0 || f.MaxDepthKm > 0 || f.HypocenterNameContains != "" || f.Geofence != nil)
+}
+
+// matchesHypocenter checks MinMagnitude, MaxDepthKm, HypocenterNameContains and Geofence.
+// An event that cannot report the compared value does not match.
+func (f *FilterConfig) matchesHypocenter(event source.Event) bool {
+	if !f.HasHypocenterConditions() {
+		return true
+	}
+	located, ok := event.(source.Located)
+	if !ok {
+		return false
+	}
+	h := located.GetHypocenter()
+	if h == nil {
+		return false
+	}
+
+	if f.MinMagnitude > 0 && (h.Magnitude < 0 || h.Magnitude < f.MinMagnitude) {
+		return false
+	}
+	if f.MaxDepthKm > 0 && (h.Depth < 0 || h.Depth > f.MaxDepthKm) {
+		return false
+	}
+	if f.HypocenterNameContains != "" && !strings.Contains(h.Name, f.HypocenterNameContains) {
+		return false
+	}
+	if g := f.Geofence; g != nil && source.DistanceKm(&source.Hypocenter{Latitude: g.Lat, Longitude: g.Lon}, h) > g.RadiusKm {
+		return false
+	}
 	return true
 }
 
 // matchesPrefectures checks if any affected area matches any filter prefecture.
-// Supports both exact match and prefix match (e.g., "東京" matches "東京都").
+// Supports exact and prefix match (e.g., "東京" matches "東京都"), romaji
+// ("Tokyo") and regions ("関東", "Kansai"), which match their prefectures.
 func matchesPrefectures(filterPrefectures, affectedAreas []string) bool {
+	var resolved map[string]bool
 	for _, area := range affectedAreas {
 		for _, pref := range filterPrefectures {
 			if area == pref || strings.HasPrefix(area, pref) {
 				return true
 			}
 		}
+		name, ok := region.Normalize(area)
+		if !ok {
+			continue
+		}
+		if resolved == nil {
+			resolved = make(map[string]bool)
+			for _, pref := range filterPrefectures {
+				names, _ := region.Resolve(pref)
+				for _, n := range names {
+					resolved[n] = true
+				}
+			}
+		}
+		if resolved[name] {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchesType checks if the filter selects the event type.
+// A nil filter or empty EventTypes selects DefaultEventTypes.
+// Early warnings are only selected by the EEW flag.
+func (f *FilterConfig) MatchesType(t source.EventType) bool {
+	if t == source.EventTypeEEW {
+		return f != nil && f.EEW
+	}
+	types := DefaultEventTypes
+	if f != nil && len(f.EventTypes) > 0 {
+		types = f.EventTypes
+	}
+	for _, selected := range types {
+		if selected == string(t) {
+			return true
+		}
 	}
 	return false
 }
@@ -46,7 +46,7 @@ func newMockEvent(severity int, areas []string) *mockEvent {
 }
 
 func TestFilterConfig_Matches_NilFilter(t *testing.T) {
-	// nil filter should match all events
+	// nil filter should match all earthquake events
 	var filter *FilterConfig = nil
 
 	tests := []struct {
@@ -363,6 +363,42 @@ func TestFilterConfig_Matches_PrefixMatch(t *testing.T) {
 			affectedAreas: []string{"北海道"},
 			expected:      true,
 		},
+		{
+			name:          "romaji matches the full name",
+			prefectures:   []string{"Tokyo"},
+			affectedAreas: []string{"東京都"},
+			expected:      true,
+		},
+		{
+			name:          "romaji with suffix matches the full name",
+			prefectures:   []string{"kyoto-fu"},
+			affectedAreas: []string{"京都府"},
+			expected:      true,
+		},
+		{
+			name:          "romaji of another prefecture fails",
+			prefectures:   []string{"Kyoto"},
+			affectedAreas: []string{"東京都"},
+			expected:      false,
+		},
+		{
+			name:          "region matches its prefectures",
+			prefectures:   []string{"関東"},
+			affectedAreas: []string{"石川県", "千葉県"},
+			expected:      true,
+		},
+		{
+			name:          "region alias matches its prefectures",
+			prefectures:   []string{"Kansai"},
+			affectedAreas: []string{"兵庫県"},
+			expected:      true,
+		},
+		{
+			name:          "region fails outside its prefectures",
+			prefectures:   []string{"Kanto"},
+			affectedAreas: []string{"大阪府"},
+			expected:      false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -382,7 +418,7 @@ func TestFilterConfig_Matches_PrefixMatch(t *testing.T) {
 }
 
 func TestFilterConfig_Matches_EmptyFilter(t *testing.T) {
-	// Empty filter (no conditions set) should match all events
+	// Empty filter (no conditions set) should match all earthquake events
 	filter := &FilterConfig{}
 
 	tests := []struct {
@@ -421,3 +457,189 @@ func TestFilterConfig_Matches_EmptyFilter(t *testing.T) {
 		})
 	}
 }
+
+func TestFilterConfig_Matches_EventTypes(t *testing.T) {
+	tsunami := newMockEvent(50, []string{"東京都"})
+	tsunami.eventType = source.EventTypeTsunami
+	earthquake := newMockEvent(50, []string{"東京都"})
+
+	tests := []struct {
+		name       string
+		filter     *FilterConfig
+		earthquake bool
+		tsunami    bool
+	}{
+		{name: "nil filter defaults to earthquake only", filter: nil, earthquake: true, tsunami: false},
+		{name: "empty event types default to earthquake only", filter: &FilterConfig{}, earthquake: true, tsunami: false},
+		{name: "tsunami only", filter: &FilterConfig{EventTypes: []string{"tsunami"}}, earthquake: false, tsunami: true},
+		{name: "both types", filter: &FilterConfig{EventTypes: []string{"earthquake", "tsunami"}}, earthquake: true, tsunami: true},
+		{
+			name:       "other conditions still apply",
+			filter:     &FilterConfig{EventTypes: []string{"tsunami"}, Prefectures: []string{"大阪府"}},
+			earthquake: false,
+			tsunami:    false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.Matches(earthquake); got != tt.earthquake {
+				t.Errorf("Matches(earthquake) = %v, expected %v", got, tt.earthquake)
+			}
+			if got := tt.filter.Matches(tsunami); got != tt.tsunami {
+				t.Errorf("Matches(tsunami) = %v, expected %v", got, tt.tsunami)
+			}
+		})
+	}
+}
+
+func TestFilterConfig_Matches_EEW(t *testing.T) {
+	eew := newMockEvent(70, []string{"福島県"})
+	eew.eventType = source.EventTypeEEW
+
+	tests := []struct {
+		name     string
+		filter   *FilterConfig
+		expected bool
+	}{
+		{name: "nil filter does not receive EEW", filter: nil, expected: false},
+		{name: "EEW must be opted in", filter: &FilterConfig{EventTypes: []string{"earthquake", "tsunami"}}, expected: false},
+		{name: "opted in", filter: &FilterConfig{EEW: true}, expected: true},
+		{name: "opted in with matching prefecture", filter: &FilterConfig{EEW: true, Prefectures: []string{"福島"}}, expected: true},
+		{name: "opted in with other prefecture", filter: &FilterConfig{EEW: true, Prefectures: []string{"大阪府"}}, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.Matches(eew); got != tt.expected {
+				t.Errorf("Matches() = %v, expected %v", got, tt.expected)
+			}
+		})
+	}
+
+	// Opting in to EEW keeps the default earthquake delivery
+	if !(&FilterConfig{EEW: true}).Matches(newMockEvent(50, nil)) {
+		t.Error("EEW opt-in should still receive earthquakes")
+	}
+}
+
+// locatedEvent is a mockEvent with a hypocenter
+type locatedEvent struct {
+	*mockEvent
+	hypocenter *source.Hypocenter
+}
+
+func (e *locatedEvent) GetHypocenter() *source.Hypocenter { return e.hypocenter }
+
+func TestFilterConfig_Matches_Hypocenter(t *testing.T) {
+	noto := &source.Hypocenter{Name: "石川県能登地方", Depth: 10, Magnitude: 7.6}
+	tests := []struct {
+		name   string
+		filter *FilterConfig
+		event  source.Event
+		want   bool
+	}{
+		{"no conditions without hypocenter", &FilterConfig{}, newMockEvent(50, []string{"石川県"}), true},
+		{"magnitude met", &FilterConfig{MinMagnitude: 7}, &locatedEvent{newMockEvent(50, nil), noto}, true},
+		{"magnitude too small", &FilterConfig{MinMagnitude: 8}, &locatedEvent{newMockEvent(50, nil), noto}, false},
+		{"depth met", &FilterConfig{MaxDepthKm: 10}, &locatedEvent{newMockEvent(50, nil), noto}, true},
+		{"too deep", &FilterConfig{MaxDepthKm: 5}, &locatedEvent{newMockEvent(50, nil), noto}, false},
+		{"name contains", &FilterConfig{HypocenterNameContains: "能登"}, &locatedEvent{newMockEvent(50, nil), noto}, true},
+		{"name differs", &FilterConfig{HypocenterNameContains: "千葉"}, &locatedEvent{newMockEvent(50, nil), noto}, false},
+		{"all conditions", &FilterConfig{MinMagnitude: 7, MaxDepthKm: 20, HypocenterNameContains: "石川"}, &locatedEvent{newMockEvent(50, nil), noto}, true},
+		{"event without hypocenter support", &FilterConfig{MinMagnitude: 3}, newMockEvent(50, nil), false},
+		{"hypocenter not reported", &FilterConfig{MaxDepthKm: 100}, &locatedEvent{newMockEvent(50, nil), nil}, false},
+		{"unknown magnitude", &FilterConfig{MinMagnitude: 3}, &locatedEvent{newMockEvent(50, nil), &source.Hypocenter{Depth: 10, Magnitude: -1}}, false},
+		{"unknown depth", &FilterConfig{MaxDepthKm: 100}, &locatedEvent{newMockEvent(50, nil), &source.Hypocenter{Depth: -1, Magnitude: 5}}, false},
+		{"very shallow", &FilterConfig{MaxDepthKm: 10}, &locatedEvent{newMockEvent(50, nil), &source.Hypocenter{Depth: 0, Magnitude: 5}}, true},
+		{"combined with min scale", &FilterConfig{MinScale: p2pquake.Scale7, MinMagnitude: 7}, &locatedEvent{newMockEvent(50, nil), noto}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.Matches(tt.event); got != tt.want {
+				t.Errorf("Matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilterConfig_Matches_Geofence(t *testing.T) {
+	// Centered on Kanazawa; the Noto hypocenter is about 100 km away
+	kanazawa := &Geofence{Lat: 36.56, Lon: 136.65, RadiusKm: 150}
+	noto := &source.Hypocenter{Latitude: 37.5, Longitude: 137.2, Depth: 10, Magnitude: 7.6}
+	tests := []struct {
+		name   string
+		filter *FilterConfig
+		event  source.Event
+		want   bool
+	}{
+		{"within radius", &FilterConfig{Geofence: kanazawa}, &locatedEvent{newMockEvent(50, nil), noto}, true},
+		{"outside radius", &FilterConfig{Geofence: &Geofence{Lat: 36.56, Lon: 136.65, RadiusKm: 50}}, &locatedEvent{newMockEvent(50, nil), noto}, false},
+		{"far away", &FilterConfig{Geofence: &Geofence{Lat: 26.21, Lon: 127.68, RadiusKm: 300}}, &locatedEvent{newMockEvent(50, nil), noto}, false},
+		{"regardless of affected areas", &FilterConfig{Geofence: kanazawa}, &locatedEvent{newMockEvent(50, []string{"新潟県"}), noto}, true},
+		{"combined with magnitude", &FilterConfig{Geofence: kanazawa, MinMagnitude: 8}, &locatedEvent{newMockEvent(50, nil), noto}, false},
+		{"event without hypocenter support", &FilterConfig{Geofence: kanazawa}, newMockEvent(50, nil), false},
+		{"hypocenter not reported", &FilterConfig{Geofence: kanazawa}, &locatedEvent{newMockEvent(50, nil), nil}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.Matches(tt.event); got != tt.want {
+				t.Errorf("Matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGeofence_Validate(t *testing.T) {
+	tests := []struct {
+		geofence Geofence
+		valid    bool
+	}{
+		{Geofence{Lat: 35.68, Lon: 139.77, RadiusKm: 100}, true},
+		{Geofence{Lat: 91, Lon: 139.77, RadiusKm: 100}, false},
+		{Geofence{Lat: 35.68, Lon: -181, RadiusKm: 100}, false},
+		{Geofence{Lat: 35.68, Lon: 139.77, RadiusKm: 0}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.geofence.Validate() == ""; got != tt.valid {
+			t.Errorf("Validate(%+v) valid = %v, want %v", tt.geofence, got, tt.valid)
+		}
+	}
+}
+
+func TestIsKnownEventType(t *testing.T) {
+	for _, known := range []string{"earthquake", "tsunami"} {
+		if !IsKnownEventType(known) {
+			t.Errorf("IsKnownEventType(%q) = false, want true", known)
+		}
+	}
+	for _, unknown := range []string{"", "volcano", "Earthquake"} {
+		if IsKnownEventType(unknown) {
+			t.Errorf("IsKnownEventType(%q) = true, want false", unknown)
+		}
+	}
+}
+
+// BenchmarkFilterConfig_Matches runs every condition, as a subscription
+// with a detailed filter does for each event
+func BenchmarkFilterConfig_Matches(b *testing.B) {
+	filter := &FilterConfig{
+		MinScale:               p2pquake.Scale3,
+		Prefectures:            []string{"大阪府", "東京都"},
+		MinMagnitude:           5,
+		MaxDepthKm:             100,
+		HypocenterNameContains: "東京",
+		Geofence:               &Geofence{Lat: 35.68, Lon: 139.77, RadiusKm: 100},
+	}
+	event := &locatedEvent{
+		newMockEvent(70, []string{"東京都", "神奈川県", "千葉県", "埼玉県"}),
+		&source.Hypocenter{Name: "東京湾", Latitude: 35.5, Longitude: 139.8, Depth: 30, Magnitude: 7.3},
+	}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if !filter.Matches(event) {
+			b.Fatal("expected a match")
+		}
+	}
+}
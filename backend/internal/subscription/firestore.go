@@ -4,23 +4,41 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"cloud.google.com/go/firestore"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+
+	"github.com/otiai10/namazu/backend/internal/secrets"
 )
 
 const (
 	// collectionName is the Firestore collection for subscriptions
 	collectionName = "subscriptions"
+
+	// ownersCollectionName holds a document per owner and tenant that
+	// CreateWithinLimit writes, so concurrent creates of an owner conflict
+	ownersCollectionName = "subscriptionOwners"
 )
 
 // ErrNotFound is returned when a subscription is not found
 var ErrNotFound = errors.New("subscription not found")
 
+// ErrRevisionConflict is returned when a subscription was changed after it was read
+var ErrRevisionConflict = errors.New("subscription was changed concurrently")
+
+// ErrLimitExceeded is returned by CreateWithinLimit when the owner has reached the limit
+var ErrLimitExceeded = errors.New("subscription limit reached")
+
+// ErrNameTaken is returned by CreateWithinLimit when sub.UniqueName is set and
+// the owner already has a subscription with that name
+var ErrNameTaken = errors.New("subscription name is taken")
+
 // FirestoreRepository implements Repository interface using Firestore
 type FirestoreRepository struct {
-	client *firestore.Client
+	client   *firestore.Client
+	envelope *secrets.Envelope // Encrypts secrets at rest; nil stores them in plaintext
 }
 
 // Ensure FirestoreRepository implements Repository interface
@@ -55,7 +73,7 @@ func (r *FirestoreRepository) List(ctx context.Context) ([]Subscription, error)
 
 	subscriptions := make([]Subscription, 0, len(docs))
 	for _, doc := range docs {
-		sub, err := documentToSubscription(doc)
+		sub, err := r.toSubscription(ctx, doc)
 		if err != nil {
 			return nil, fmt.Errorf("failed to convert document %s: %w", doc.Ref.ID, err)
 		}
@@ -85,7 +103,7 @@ func (r *FirestoreRepository) ListByUserID(ctx context.Context, userID string) (
 
 	subscriptions := make([]Subscription, 0, len(docs))
 	for _, doc := range docs {
-		sub, err := documentToSubscription(doc)
+		sub, err := r.toSubscription(ctx, doc)
 		if err != nil {
 			return nil, fmt.Errorf("failed to convert document %s: %w", doc.Ref.ID, err)
 		}
@@ -105,6 +123,11 @@ func (r *FirestoreRepository) ListByUserID(ctx context.Context, userID string) (
 //   - ID of the created subscription
 //   - Error if Firestore operation fails
 func (r *FirestoreRepository) Create(ctx context.Context, sub Subscription) (string, error) {
+	sub, err := r.sealSecrets(ctx, sub)
+	if err != nil {
+		return "", err
+	}
+	sub.Revision = 1
 	data := subscriptionToMap(sub)
 
 	docRef, _, err := r.client.Collection(collectionName).Add(ctx, data)
@@ -115,6 +138,81 @@ func (r *FirestoreRepository) Create(ctx context.Context, sub Subscription) (str
 	return docRef.ID, nil
 }
 
+// CreateWithinLimit creates a subscription unless its owner already has limit
+// or more in its tenant. The count and the create run in a transaction that
+// also writes the owner's document in subscriptionOwners: two concurrent
+// creates of the same owner conflict there, and Firestore retries the second
+// with the first one counted, or with its name taken if sub.UniqueName is set.
+//
+// Parameters:
+//   - ctx: Context for cancellation control
+//   - sub: Subscription to create
+//   - limit: Maximum number of subscriptions of the owner in the tenant
+//
+// Returns:
+//   - ID of the created subscription
+//   - ErrLimitExceeded if the owner has reached the limit
+//   - ErrNameTaken if sub.UniqueName is set and the owner has the name
+//   - Error if Firestore operation fails
+func (r *FirestoreRepository) CreateWithinLimit(ctx context.Context, sub Subscription, limit int) (string, error) {
+	sub, err := r.sealSecrets(ctx, sub)
+	if err != nil {
+		return "", err
+	}
+	sub.Revision = 1
+	data := subscriptionToMap(sub)
+
+	ownerRef := r.client.Collection(ownersCollectionName).Doc(ownerDocID(sub.UserID, sub.TenantID))
+	docRef := r.client.Collection(collectionName).NewDoc()
+	err = r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		if _, err := tx.Get(ownerRef); err != nil && status.Code(err) != codes.NotFound {
+			return fmt.Errorf("failed to get subscription owner: %w", err)
+		}
+		docs, err := tx.Documents(r.client.Collection(collectionName).Where("userId", "==", sub.UserID)).GetAll()
+		if err != nil {
+			return fmt.Errorf("failed to count subscriptions: %w", err)
+		}
+		used := 0
+		for _, doc := range docs {
+			if tenantID, _ := doc.Data()["tenantId"].(string); tenantID == sub.TenantID {
+				used++
+				if name, _ := doc.Data()["name"].(string); sub.UniqueName && name == sub.Name {
+					return ErrNameTaken
+				}
+			}
+		}
+		if used >= limit {
+			return ErrLimitExceeded
+		}
+
+		if err := tx.Create(docRef, data); err != nil {
+			return err
+		}
+		return tx.Set(ownerRef, map[string]interface{}{
+			"userId":        sub.UserID,
+			"tenantId":      sub.TenantID,
+			"subscriptions": used + 1,
+			"updatedAt":     time.Now().UTC(),
+		})
+	})
+	if errors.Is(err, ErrLimitExceeded) || errors.Is(err, ErrNameTaken) {
+		return "", err
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to create subscription: %w", err)
+	}
+
+	return docRef.ID, nil
+}
+
+// ownerDocID returns the ID of an owner's document in subscriptionOwners
+func ownerDocID(userID, tenantID string) string {
+	if tenantID == "" {
+		return userID
+	}
+	return tenantID + ":" + userID
+}
+
 // Get retrieves a subscription by ID
 //
 // Parameters:
@@ -133,7 +231,7 @@ func (r *FirestoreRepository) Get(ctx context.Context, id string) (*Subscription
 		return nil, fmt.Errorf("failed to get subscription: %w", err)
 	}
 
-	sub, err := documentToSubscription(doc)
+	sub, err := r.toSubscription(ctx, doc)
 	if err != nil {
 		return nil, fmt.Errorf("failed to convert document: %w", err)
 	}
@@ -141,7 +239,9 @@ func (r *FirestoreRepository) Get(ctx context.Context, id string) (*Subscription
 	return &sub, nil
 }
 
-// Update updates an existing subscription
+// Update updates an existing subscription at the next revision.
+// The revision is checked and incremented in a transaction, so of two writes
+// of the same read only the first succeeds.
 //
 // Parameters:
 //   - ctx: Context for cancellation control
@@ -149,21 +249,38 @@ func (r *FirestoreRepository) Get(ctx context.Context, id string) (*Subscription
 //   - sub: New subscription data
 //
 // Returns:
-//   - Error if subscription not found or Firestore operation fails
+//   - ErrNotFound if subscription not found
+//   - ErrRevisionConflict if sub.ChecksRevision() and sub.Revision is not the stored revision
+//   - Error if Firestore operation fails
 func (r *FirestoreRepository) Update(ctx context.Context, id string, sub Subscription) error {
 	docRef := r.client.Collection(collectionName).Doc(id)
 
-	// Check if document exists
-	_, err := docRef.Get(ctx)
+	// Sealing may call KMS, so it is done once outside the (retried) transaction
+	sub, err := r.sealSecrets(ctx, sub)
 	if err != nil {
-		if status.Code(err) == codes.NotFound {
-			return ErrNotFound
-		}
-		return fmt.Errorf("failed to check subscription existence: %w", err)
+		return err
 	}
 
-	data := subscriptionToMap(sub)
-	_, err = docRef.Set(ctx, data)
+	err = r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(docRef)
+		if err != nil {
+			if status.Code(err) == codes.NotFound {
+				return ErrNotFound
+			}
+			return fmt.Errorf("failed to check subscription existence: %w", err)
+		}
+		current, _ := doc.Data()["revision"].(int64)
+		if sub.ChecksRevision() && sub.Revision != current {
+			return ErrRevisionConflict
+		}
+
+		next := sub
+		next.Revision = current + 1
+		return tx.Set(docRef, subscriptionToMap(next))
+	})
+	if errors.Is(err, ErrNotFound) || errors.Is(err, ErrRevisionConflict) {
+		return err
+	}
 	if err != nil {
 		return fmt.Errorf("failed to update subscription: %w", err)
 	}
@@ -202,23 +319,164 @@ func (r *FirestoreRepository) Delete(ctx context.Context, id string) error {
 // subscriptionToMap converts a Subscription to a map for Firestore storage
 func subscriptionToMap(sub Subscription) map[string]interface{} {
 	data := map[string]interface{}{
-		"userId": sub.UserID,
-		"name":   sub.Name,
+		"userId":   sub.UserID,
+		"tenantId": sub.TenantID,
+		"name":     sub.Name,
 		"delivery": map[string]interface{}{
-			"type":          sub.Delivery.Type,
-			"url":           sub.Delivery.URL,
-			"secret":        sub.Delivery.Secret,
-			"secret_prefix": sub.Delivery.SecretPrefix,
-			"verified":      sub.Delivery.Verified,
-			"sign_version":  sub.Delivery.SignVersion,
+			"type":            sub.Delivery.Type,
+			"url":             sub.Delivery.URL,
+			"secret":          sub.Delivery.Secret,
+			"secret_prefix":   sub.Delivery.SecretPrefix,
+			"verified":        sub.Delivery.Verified,
+			"sign_version":    sub.Delivery.SignVersion,
+			"service_notices": sub.Delivery.ServiceNotices,
 		},
 	}
 
+	if sub.Delivery.PreviousSecret != "" && sub.Delivery.PreviousSecretExpiresAt != nil {
+		data["delivery"].(map[string]interface{})["previous_secret"] = sub.Delivery.PreviousSecret
+		data["delivery"].(map[string]interface{})["previous_secret_expires_at"] = *sub.Delivery.PreviousSecretExpiresAt
+	}
+	if sub.Delivery.VerificationSkipped {
+		data["delivery"].(map[string]interface{})["verification_skipped"] = true
+	}
+	if sub.Delivery.Template != "" {
+		data["delivery"].(map[string]interface{})["template"] = sub.Delivery.Template
+	}
+	if sub.Delivery.Enrich {
+		data["delivery"].(map[string]interface{})["enrich"] = true
+	}
+	if sub.Delivery.Format != "" {
+		data["delivery"].(map[string]interface{})["format"] = sub.Delivery.Format
+	}
+	if len(sub.Delivery.Headers) > 0 {
+		data["delivery"].(map[string]interface{})["headers"] = sub.Delivery.Headers
+	}
+	if sms := sub.Delivery.SMS; sms != nil {
+		data["delivery"].(map[string]interface{})["sms"] = map[string]interface{}{
+			"phone":     sms.Phone,
+			"min_scale": sms.MinScale,
+		}
+	}
+	if aws := sub.Delivery.AWS; aws != nil {
+		data["delivery"].(map[string]interface{})["aws"] = map[string]interface{}{
+			"region":            aws.Region,
+			"topic_arn":         aws.TopicARN,
+			"queue_url":         aws.QueueURL,
+			"access_key_id":     aws.AccessKeyID,
+			"secret_access_key": aws.SecretAccessKey,
+		}
+	}
+
+	if sub.Delivery.Retry != nil {
+		retry := map[string]interface{}{
+			"enabled":     sub.Delivery.Retry.Enabled,
+			"max_retries": sub.Delivery.Retry.MaxRetries,
+			"initial_ms":  sub.Delivery.Retry.InitialMs,
+			"max_ms":      sub.Delivery.Retry.MaxMs,
+		}
+		if sub.Delivery.Retry.Jitter > 0 {
+			retry["jitter"] = sub.Delivery.Retry.Jitter
+		}
+		if len(sub.Delivery.Retry.StatusOverrides) > 0 {
+			overrides := make([]interface{}, 0, len(sub.Delivery.Retry.StatusOverrides))
+			for _, o := range sub.Delivery.Retry.StatusOverrides {
+				overrides = append(overrides, map[string]interface{}{
+					"status":      o.Status,
+					"retry":       o.Retry,
+					"retry_after": o.RetryAfter,
+				})
+			}
+			retry["status_overrides"] = overrides
+		}
+		data["delivery"].(map[string]interface{})["retry"] = retry
+	}
+
 	if sub.Filter != nil {
-		data["filter"] = map[string]interface{}{
+		filter := map[string]interface{}{
 			"minScale":    sub.Filter.MinScale,
 			"prefectures": sub.Filter.Prefectures,
 		}
+		if len(sub.Filter.EventTypes) > 0 {
+			filter["eventTypes"] = sub.Filter.EventTypes
+		}
+		if sub.Filter.EEW {
+			filter["eew"] = true
+		}
+		if sub.Filter.MinMagnitude > 0 {
+			filter["minMagnitude"] = sub.Filter.MinMagnitude
+		}
+		if sub.Filter.MaxDepthKm > 0 {
+			filter["maxDepthKm"] = sub.Filter.MaxDepthKm
+		}
+		if sub.Filter.HypocenterNameContains != "" {
+			filter["hypocenterNameContains"] = sub.Filter.HypocenterNameContains
+		}
+		if g := sub.Filter.Geofence; g != nil {
+			filter["geofence"] = map[string]interface{}{
+				"lat":      g.Lat,
+				"lon":      g.Lon,
+				"radiusKm": g.RadiusKm,
+			}
+		}
+		data["filter"] = filter
+	}
+
+	if !sub.CreatedAt.IsZero() {
+		data["createdAt"] = sub.CreatedAt
+	}
+	if sub.Revision != 0 {
+		data["revision"] = sub.Revision
+	}
+	if !sub.UpdatedAt.IsZero() {
+		data["updatedAt"] = sub.UpdatedAt
+	}
+	if q := sub.QuietHours; q != nil {
+		quietHours := map[string]interface{}{
+			"start": q.Start,
+			"end":   q.End,
+		}
+		if q.Timezone != "" {
+			quietHours["timezone"] = q.Timezone
+		}
+		if q.MinScaleOverride > 0 {
+			quietHours["minScaleOverride"] = q.MinScaleOverride
+		}
+		data["quietHours"] = quietHours
+	}
+
+	if t := sub.Throttle; t != nil {
+		throttle := map[string]interface{}{
+			"intervalMinutes": t.IntervalMinutes,
+		}
+		if t.DedupeBy != "" {
+			throttle["dedupeBy"] = t.DedupeBy
+		}
+		data["throttle"] = throttle
+	}
+
+	if sub.Locale != "" {
+		data["locale"] = sub.Locale
+	}
+
+	if sub.Digest != nil {
+		data["digest"] = map[string]interface{}{
+			"intervalMinutes": sub.Digest.IntervalMinutes,
+		}
+	}
+
+	if sub.ExpiresAt != nil {
+		data["expiresAt"] = *sub.ExpiresAt
+	}
+	if sub.Status != "" {
+		data["status"] = sub.Status
+		data["statusReason"] = sub.StatusReason
+	}
+	if sub.StatusChangedAt != nil {
+		data["statusChangedAt"] = *sub.StatusChangedAt
+	}
+	if sub.PausedAt != nil {
+		data["pausedAt"] = *sub.PausedAt
 	}
 
 	return data
@@ -236,10 +494,18 @@ func documentToSubscription(doc *firestore.DocumentSnapshot) (Subscription, erro
 		sub.UserID = userID
 	}
 
+	if tenantID, ok := data["tenantId"].(string); ok {
+		sub.TenantID = tenantID
+	}
+
 	if name, ok := data["name"].(string); ok {
 		sub.Name = name
 	}
 
+	if locale, ok := data["locale"].(string); ok {
+		sub.Locale = locale
+	}
+
 	if delivery, ok := data["delivery"].(map[string]interface{}); ok {
 		if deliveryType, ok := delivery["type"].(string); ok {
 			sub.Delivery.Type = deliveryType
@@ -253,12 +519,93 @@ func documentToSubscription(doc *firestore.DocumentSnapshot) (Subscription, erro
 		if secretPrefix, ok := delivery["secret_prefix"].(string); ok {
 			sub.Delivery.SecretPrefix = secretPrefix
 		}
+		if previousSecret, ok := delivery["previous_secret"].(string); ok {
+			sub.Delivery.PreviousSecret = previousSecret
+		}
+		if expiresAt, ok := delivery["previous_secret_expires_at"].(time.Time); ok {
+			sub.Delivery.PreviousSecretExpiresAt = &expiresAt
+		}
 		if verified, ok := delivery["verified"].(bool); ok {
 			sub.Delivery.Verified = verified
 		}
+		if skipped, ok := delivery["verification_skipped"].(bool); ok {
+			sub.Delivery.VerificationSkipped = skipped
+		}
 		if signVersion, ok := delivery["sign_version"].(string); ok {
 			sub.Delivery.SignVersion = signVersion
 		}
+		if serviceNotices, ok := delivery["service_notices"].(bool); ok {
+			sub.Delivery.ServiceNotices = serviceNotices
+		}
+		if template, ok := delivery["template"].(string); ok {
+			sub.Delivery.Template = template
+		}
+		if enrich, ok := delivery["enrich"].(bool); ok {
+			sub.Delivery.Enrich = enrich
+		}
+		if format, ok := delivery["format"].(string); ok {
+			sub.Delivery.Format = format
+		}
+		if aws, ok := delivery["aws"].(map[string]interface{}); ok {
+			sub.Delivery.AWS = &AWSConfig{}
+			sub.Delivery.AWS.Region, _ = aws["region"].(string)
+			sub.Delivery.AWS.TopicARN, _ = aws["topic_arn"].(string)
+			sub.Delivery.AWS.QueueURL, _ = aws["queue_url"].(string)
+			sub.Delivery.AWS.AccessKeyID, _ = aws["access_key_id"].(string)
+			sub.Delivery.AWS.SecretAccessKey, _ = aws["secret_access_key"].(string)
+		}
+		if sms, ok := delivery["sms"].(map[string]interface{}); ok {
+			sub.Delivery.SMS = &SMSConfig{}
+			sub.Delivery.SMS.Phone, _ = sms["phone"].(string)
+			if minScale, ok := sms["min_scale"].(int64); ok {
+				sub.Delivery.SMS.MinScale = int(minScale)
+			}
+		}
+		if headers, ok := delivery["headers"].(map[string]interface{}); ok {
+			sub.Delivery.Headers = make(map[string]string, len(headers))
+			for name, value := range headers {
+				if value, ok := value.(string); ok {
+					sub.Delivery.Headers[name] = value
+				}
+			}
+		}
+		if retry, ok := delivery["retry"].(map[string]interface{}); ok {
+			sub.Delivery.Retry = &RetryConfig{}
+			if enabled, ok := retry["enabled"].(bool); ok {
+				sub.Delivery.Retry.Enabled = enabled
+			}
+			if maxRetries, ok := retry["max_retries"].(int64); ok {
+				sub.Delivery.Retry.MaxRetries = int(maxRetries)
+			}
+			if initialMs, ok := retry["initial_ms"].(int64); ok {
+				sub.Delivery.Retry.InitialMs = int(initialMs)
+			}
+			if maxMs, ok := retry["max_ms"].(int64); ok {
+				sub.Delivery.Retry.MaxMs = int(maxMs)
+			}
+			if jitter, ok := retry["jitter"].(float64); ok {
+				sub.Delivery.Retry.Jitter = jitter
+			}
+			if overrides, ok := retry["status_overrides"].([]interface{}); ok {
+				for _, v := range overrides {
+					m, ok := v.(map[string]interface{})
+					if !ok {
+						continue
+					}
+					var o StatusOverride
+					if status, ok := m["status"].(int64); ok {
+						o.Status = int(status)
+					}
+					if retry, ok := m["retry"].(bool); ok {
+						o.Retry = retry
+					}
+					if retryAfter, ok := m["retry_after"].(bool); ok {
+						o.RetryAfter = retryAfter
+					}
+					sub.Delivery.Retry.StatusOverrides = append(sub.Delivery.Retry.StatusOverrides, o)
+				}
+			}
+		}
 	}
 
 	if filter, ok := data["filter"].(map[string]interface{}); ok {
@@ -274,7 +621,91 @@ func documentToSubscription(doc *firestore.DocumentSnapshot) (Subscription, erro
 				}
 			}
 		}
+		if eventTypes, ok := filter["eventTypes"].([]interface{}); ok {
+			sub.Filter.EventTypes = make([]string, 0, len(eventTypes))
+			for _, t := range eventTypes {
+				if tStr, ok := t.(string); ok {
+					sub.Filter.EventTypes = append(sub.Filter.EventTypes, tStr)
+				}
+			}
+		}
+		if eew, ok := filter["eew"].(bool); ok {
+			sub.Filter.EEW = eew
+		}
+		sub.Filter.MinMagnitude = firestoreFloat(filter["minMagnitude"])
+		if maxDepth, ok := filter["maxDepthKm"].(int64); ok {
+			sub.Filter.MaxDepthKm = int(maxDepth)
+		}
+		if name, ok := filter["hypocenterNameContains"].(string); ok {
+			sub.Filter.HypocenterNameContains = name
+		}
+		if geofence, ok := filter["geofence"].(map[string]interface{}); ok {
+			sub.Filter.Geofence = &Geofence{
+				Lat:      firestoreFloat(geofence["lat"]),
+				Lon:      firestoreFloat(geofence["lon"]),
+				RadiusKm: firestoreFloat(geofence["radiusKm"]),
+			}
+		}
+	}
+
+	if createdAt, ok := data["createdAt"].(time.Time); ok {
+		sub.CreatedAt = createdAt
+	}
+	sub.Revision, _ = data["revision"].(int64)
+	if updatedAt, ok := data["updatedAt"].(time.Time); ok {
+		sub.UpdatedAt = updatedAt
+	}
+	if quietHours, ok := data["quietHours"].(map[string]interface{}); ok {
+		sub.QuietHours = &QuietHours{}
+		sub.QuietHours.Start, _ = quietHours["start"].(string)
+		sub.QuietHours.End, _ = quietHours["end"].(string)
+		sub.QuietHours.Timezone, _ = quietHours["timezone"].(string)
+		if override, ok := quietHours["minScaleOverride"].(int64); ok {
+			sub.QuietHours.MinScaleOverride = int(override)
+		}
+	}
+
+	if throttle, ok := data["throttle"].(map[string]interface{}); ok {
+		sub.Throttle = &ThrottleConfig{}
+		if interval, ok := throttle["intervalMinutes"].(int64); ok {
+			sub.Throttle.IntervalMinutes = int(interval)
+		}
+		sub.Throttle.DedupeBy, _ = throttle["dedupeBy"].(string)
+	}
+
+	if digest, ok := data["digest"].(map[string]interface{}); ok {
+		sub.Digest = &DigestConfig{}
+		if interval, ok := digest["intervalMinutes"].(int64); ok {
+			sub.Digest.IntervalMinutes = int(interval)
+		}
+	}
+
+	if expiresAt, ok := data["expiresAt"].(time.Time); ok {
+		sub.ExpiresAt = &expiresAt
+	}
+	if st, ok := data["status"].(string); ok {
+		sub.Status = st
+	}
+	if reason, ok := data["statusReason"].(string); ok {
+		sub.StatusReason = reason
+	}
+	if changedAt, ok := data["statusChangedAt"].(time.Time); ok {
+		sub.StatusChangedAt = &changedAt
+	}
+	if pausedAt, ok := data["pausedAt"].(time.Time); ok {
+		sub.PausedAt = &pausedAt
 	}
 
 	return sub, nil
 }
+
+// firestoreFloat reads a number that Firestore may return as int64 or float64
+func firestoreFloat(v interface{}) float64 {
+	switch n := v.(type) {
+	case float64:
+		return n
+	case int64:
+		return float64(n)
+	}
+	return 0
+}
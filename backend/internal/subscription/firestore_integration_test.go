@@ -4,10 +4,12 @@ package subscription
 
 import (
 	"context"
+	"errors"
 	"os"
 	"testing"
 	"time"
 
+	"cloud.google.com/go/firestore"
 	"github.com/otiai10/namazu/backend/internal/store"
 )
 
@@ -136,6 +138,67 @@ func TestFirestoreRepository_Integration(t *testing.T) {
 		}
 	})
 
+	t.Run("Update with stale revision conflicts", func(t *testing.T) {
+		stale, _ := repo.Get(ctx, createdIDs[0])
+		fresh := *stale
+		fresh.Name = "Fresh"
+		if err := repo.Update(ctx, createdIDs[0], fresh); err != nil {
+			t.Fatalf("Update failed: %v", err)
+		}
+		stale.Name = "Stale"
+		if err := repo.Update(ctx, createdIDs[0], *stale); !errors.Is(err, ErrRevisionConflict) {
+			t.Errorf("expected ErrRevisionConflict, got: %v", err)
+		}
+	})
+
+	t.Run("Concurrent updates of a subscription without a revision conflict", func(t *testing.T) {
+		// Stored before revisions existed
+		if _, err := client.Client().Collection(collectionName).Doc(createdIDs[0]).Update(ctx, []firestore.Update{{Path: "revision", Value: firestore.Delete}}); err != nil {
+			t.Fatalf("failed to remove the revision: %v", err)
+		}
+		read, _ := repo.Get(ctx, createdIDs[0])
+		if read.Revision != 0 {
+			t.Fatalf("Revision = %d, want 0", read.Revision)
+		}
+		errs := make(chan error, 2)
+		for _, name := range []string{"First", "Second"} {
+			go func() {
+				sub := *read
+				sub.Name = name
+				sub.ExpectRevision = true
+				errs <- repo.Update(ctx, createdIDs[0], sub)
+			}()
+		}
+		var conflicts int
+		for range 2 {
+			if err := <-errs; errors.Is(err, ErrRevisionConflict) {
+				conflicts++
+			} else if err != nil {
+				t.Fatalf("Update failed: %v", err)
+			}
+		}
+		if conflicts != 1 {
+			t.Errorf("conflicts = %d, want exactly one of the two writes of revision 0 to lose", conflicts)
+		}
+	})
+
+	t.Run("CreateWithinLimit rejects creates over the limit", func(t *testing.T) {
+		sub := Subscription{
+			UserID:   testUserID,
+			Name:     "Integration Test Sub 3",
+			Delivery: DeliveryConfig{Type: "webhook", URL: "https://example.com/webhook3"},
+		}
+		id, err := repo.CreateWithinLimit(ctx, sub, 3)
+		if err != nil {
+			t.Fatalf("CreateWithinLimit failed: %v", err)
+		}
+		createdIDs = append(createdIDs, id)
+
+		if _, err := repo.CreateWithinLimit(ctx, sub, 3); !errors.Is(err, ErrLimitExceeded) {
+			t.Errorf("expected ErrLimitExceeded, got: %v", err)
+		}
+	})
+
 	// Cleanup
 	t.Run("Cleanup", func(t *testing.T) {
 		for _, id := range createdIDs {
@@ -2,6 +2,7 @@ package subscription
 
 import (
 	"testing"
+	"time"
 )
 
 func TestNewFirestoreRepository(t *testing.T) {
@@ -59,6 +60,15 @@ func TestSubscriptionToMap(t *testing.T) {
 		}
 	})
 
+	t.Run("includes locale when present", func(t *testing.T) {
+		if data := subscriptionToMap(Subscription{Name: "Default"}); data["locale"] != nil {
+			t.Errorf("Expected no locale, got %v", data["locale"])
+		}
+		if data := subscriptionToMap(Subscription{Name: "English", Locale: "en"}); data["locale"] != "en" {
+			t.Errorf("Expected locale 'en', got %v", data["locale"])
+		}
+	})
+
 	t.Run("includes userId when present", func(t *testing.T) {
 		sub := Subscription{
 			ID:     "test-id",
@@ -77,6 +87,86 @@ func TestSubscriptionToMap(t *testing.T) {
 		}
 	})
 
+	t.Run("includes tenantId when present", func(t *testing.T) {
+		sub := Subscription{
+			ID:       "test-id",
+			UserID:   "user-123",
+			TenantID: "acme",
+			Name:     "Test Subscription",
+			Delivery: DeliveryConfig{
+				Type: "webhook",
+				URL:  "https://example.com/webhook",
+			},
+		}
+
+		data := subscriptionToMap(sub)
+
+		if data["tenantId"] != "acme" {
+			t.Errorf("Expected tenantId 'acme', got %v", data["tenantId"])
+		}
+	})
+
+	t.Run("includes service notices opt-in", func(t *testing.T) {
+		sub := Subscription{
+			ID:   "test-id",
+			Name: "Test Subscription",
+			Delivery: DeliveryConfig{
+				Type:           "webhook",
+				URL:            "https://example.com/webhook",
+				ServiceNotices: true,
+			},
+		}
+
+		data := subscriptionToMap(sub)
+
+		delivery := data["delivery"].(map[string]interface{})
+		if delivery["service_notices"] != true {
+			t.Errorf("Expected service_notices true, got %v", delivery["service_notices"])
+		}
+	})
+
+	t.Run("includes skipped verification only when set", func(t *testing.T) {
+		sub := Subscription{
+			ID:       "test-id",
+			Name:     "Test Subscription",
+			Delivery: DeliveryConfig{Type: "webhook", URL: "https://example.com/webhook", VerificationSkipped: true},
+		}
+
+		delivery := subscriptionToMap(sub)["delivery"].(map[string]interface{})
+		if delivery["verification_skipped"] != true {
+			t.Errorf("Expected verification_skipped true, got %v", delivery["verification_skipped"])
+		}
+
+		sub.Delivery.VerificationSkipped = false
+		delivery = subscriptionToMap(sub)["delivery"].(map[string]interface{})
+		if _, ok := delivery["verification_skipped"]; ok {
+			t.Error("Expected verification_skipped to be omitted")
+		}
+	})
+
+	t.Run("includes the payload template only when set", func(t *testing.T) {
+		sub := Subscription{
+			ID:   "test-id",
+			Name: "Test Subscription",
+			Delivery: DeliveryConfig{
+				Type:     "webhook",
+				URL:      "https://example.com/webhook",
+				Template: `{"id": {{json .Event.ID}}}`,
+			},
+		}
+
+		delivery := subscriptionToMap(sub)["delivery"].(map[string]interface{})
+		if delivery["template"] != sub.Delivery.Template {
+			t.Errorf("Expected template %q, got %v", sub.Delivery.Template, delivery["template"])
+		}
+
+		sub.Delivery.Template = ""
+		delivery = subscriptionToMap(sub)["delivery"].(map[string]interface{})
+		if _, ok := delivery["template"]; ok {
+			t.Error("Expected no template key for an empty template")
+		}
+	})
+
 	t.Run("includes empty userId when not set", func(t *testing.T) {
 		sub := Subscription{
 			ID:   "test-id",
@@ -134,6 +224,240 @@ func TestSubscriptionToMap(t *testing.T) {
 		}
 	})
 
+	t.Run("includes event types only when selected", func(t *testing.T) {
+		sub := Subscription{
+			Name:     "Typed Subscription",
+			Delivery: DeliveryConfig{Type: "webhook", URL: "https://example.com/webhook"},
+			Filter:   &FilterConfig{EventTypes: []string{"earthquake", "tsunami"}},
+		}
+
+		filter := subscriptionToMap(sub)["filter"].(map[string]interface{})
+		eventTypes, ok := filter["eventTypes"].([]string)
+		if !ok || len(eventTypes) != 2 || eventTypes[1] != "tsunami" {
+			t.Errorf("Expected eventTypes [earthquake tsunami], got %v", filter["eventTypes"])
+		}
+
+		sub.Filter.EventTypes = nil
+		filter = subscriptionToMap(sub)["filter"].(map[string]interface{})
+		if _, exists := filter["eventTypes"]; exists {
+			t.Error("Expected eventTypes to be omitted when not selected")
+		}
+	})
+
+	t.Run("includes eew only when opted in", func(t *testing.T) {
+		sub := Subscription{
+			Name:     "EEW Subscription",
+			Delivery: DeliveryConfig{Type: "webhook", URL: "https://example.com/webhook"},
+			Filter:   &FilterConfig{EEW: true},
+		}
+
+		filter := subscriptionToMap(sub)["filter"].(map[string]interface{})
+		if filter["eew"] != true {
+			t.Errorf("Expected eew true, got %v", filter["eew"])
+		}
+
+		sub.Filter.EEW = false
+		filter = subscriptionToMap(sub)["filter"].(map[string]interface{})
+		if _, exists := filter["eew"]; exists {
+			t.Error("Expected eew to be omitted when not opted in")
+		}
+	})
+
+	t.Run("includes hypocenter conditions only when set", func(t *testing.T) {
+		sub := Subscription{
+			Name:     "Deep Subscription",
+			Delivery: DeliveryConfig{Type: "webhook", URL: "https://example.com/webhook"},
+			Filter:   &FilterConfig{MinMagnitude: 5.5, MaxDepthKm: 30, HypocenterNameContains: "能登"},
+		}
+
+		filter := subscriptionToMap(sub)["filter"].(map[string]interface{})
+		if filter["minMagnitude"] != 5.5 || filter["maxDepthKm"] != 30 || filter["hypocenterNameContains"] != "能登" {
+			t.Errorf("unexpected hypocenter conditions: %v", filter)
+		}
+
+		filter = subscriptionToMap(Subscription{Filter: &FilterConfig{MinScale: 30}})["filter"].(map[string]interface{})
+		for _, key := range []string{"minMagnitude", "maxDepthKm", "hypocenterNameContains"} {
+			if _, exists := filter[key]; exists {
+				t.Errorf("Expected %s to be omitted when not set", key)
+			}
+		}
+	})
+
+	t.Run("includes geofence when set", func(t *testing.T) {
+		sub := Subscription{Filter: &FilterConfig{Geofence: &Geofence{Lat: 35.68, Lon: 139.77, RadiusKm: 100}}}
+
+		geofence, ok := subscriptionToMap(sub)["filter"].(map[string]interface{})["geofence"].(map[string]interface{})
+		if !ok {
+			t.Fatal("Expected geofence to be stored")
+		}
+		if geofence["lat"] != 35.68 || geofence["lon"] != 139.77 || geofence["radiusKm"] != 100.0 {
+			t.Errorf("unexpected geofence: %v", geofence)
+		}
+	})
+
+	t.Run("includes quiet hours when set", func(t *testing.T) {
+		sub := Subscription{QuietHours: &QuietHours{Start: "23:00", End: "07:00", MinScaleOverride: 50}}
+
+		quietHours, ok := subscriptionToMap(sub)["quietHours"].(map[string]interface{})
+		if !ok {
+			t.Fatal("Expected quietHours to be stored")
+		}
+		if quietHours["start"] != "23:00" || quietHours["end"] != "07:00" || quietHours["minScaleOverride"] != 50 {
+			t.Errorf("unexpected quiet hours: %v", quietHours)
+		}
+		if _, exists := quietHours["timezone"]; exists {
+			t.Error("Expected timezone to be omitted when not set")
+		}
+	})
+
+	t.Run("includes throttle when set", func(t *testing.T) {
+		sub := Subscription{Throttle: &ThrottleConfig{IntervalMinutes: 10, DedupeBy: DedupeByHypocenter}}
+
+		throttle, ok := subscriptionToMap(sub)["throttle"].(map[string]interface{})
+		if !ok {
+			t.Fatal("Expected throttle to be stored")
+		}
+		if throttle["intervalMinutes"] != 10 || throttle["dedupeBy"] != "hypocenter" {
+			t.Errorf("unexpected throttle: %v", throttle)
+		}
+	})
+
+	t.Run("includes digest when set", func(t *testing.T) {
+		sub := Subscription{Digest: &DigestConfig{IntervalMinutes: 60}}
+
+		digest, ok := subscriptionToMap(sub)["digest"].(map[string]interface{})
+		if !ok || digest["intervalMinutes"] != 60 {
+			t.Errorf("unexpected digest: %v", digest)
+		}
+	})
+
+	t.Run("converts subscription with retry config", func(t *testing.T) {
+		sub := Subscription{
+			Name: "Retrying Subscription",
+			Delivery: DeliveryConfig{
+				Type: "webhook",
+				URL:  "https://example.com/webhook",
+				Retry: &RetryConfig{
+					Enabled:    true,
+					MaxRetries: 5,
+					InitialMs:  500,
+					MaxMs:      30000,
+				},
+			},
+		}
+
+		data := subscriptionToMap(sub)
+
+		delivery := data["delivery"].(map[string]interface{})
+		retry, ok := delivery["retry"].(map[string]interface{})
+		if !ok {
+			t.Fatal("Expected retry to be a map")
+		}
+		if retry["enabled"] != true {
+			t.Errorf("Expected enabled true, got %v", retry["enabled"])
+		}
+		if retry["max_retries"] != 5 {
+			t.Errorf("Expected max_retries 5, got %v", retry["max_retries"])
+		}
+		if retry["initial_ms"] != 500 {
+			t.Errorf("Expected initial_ms 500, got %v", retry["initial_ms"])
+		}
+		if retry["max_ms"] != 30000 {
+			t.Errorf("Expected max_ms 30000, got %v", retry["max_ms"])
+		}
+		if _, exists := retry["jitter"]; exists {
+			t.Error("jitter should not be included when not set")
+		}
+		if _, exists := retry["status_overrides"]; exists {
+			t.Error("status_overrides should not be included when not set")
+		}
+	})
+
+	t.Run("includes retry jitter and status overrides when set", func(t *testing.T) {
+		sub := Subscription{
+			Name: "Retrying Subscription",
+			Delivery: DeliveryConfig{
+				Type: "webhook",
+				Retry: &RetryConfig{
+					Enabled:         true,
+					MaxRetries:      3,
+					Jitter:          0.2,
+					StatusOverrides: []StatusOverride{{Status: 429, Retry: true, RetryAfter: true}},
+				},
+			},
+		}
+
+		retry := subscriptionToMap(sub)["delivery"].(map[string]interface{})["retry"].(map[string]interface{})
+		if retry["jitter"] != 0.2 {
+			t.Errorf("Expected jitter 0.2, got %v", retry["jitter"])
+		}
+		overrides, ok := retry["status_overrides"].([]interface{})
+		if !ok || len(overrides) != 1 {
+			t.Fatalf("Expected 1 status override, got %v", retry["status_overrides"])
+		}
+		o := overrides[0].(map[string]interface{})
+		if o["status"] != 429 || o["retry"] != true || o["retry_after"] != true {
+			t.Errorf("unexpected status override: %v", o)
+		}
+	})
+
+	t.Run("omits retry when not configured", func(t *testing.T) {
+		sub := Subscription{
+			Name:     "Test",
+			Delivery: DeliveryConfig{Type: "webhook"},
+		}
+
+		data := subscriptionToMap(sub)
+
+		delivery := data["delivery"].(map[string]interface{})
+		if _, exists := delivery["retry"]; exists {
+			t.Error("retry should not be included when not configured")
+		}
+	})
+
+	t.Run("includes lifecycle fields when set", func(t *testing.T) {
+		created := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+		expires := created.AddDate(1, 0, 0)
+		sub := Subscription{
+			Name:            "Test",
+			Delivery:        DeliveryConfig{Type: "webhook"},
+			CreatedAt:       created,
+			ExpiresAt:       &expires,
+			Status:          StatusWarned,
+			StatusReason:    ReasonInactive,
+			StatusChangedAt: &created,
+			PausedAt:        &expires,
+		}
+
+		data := subscriptionToMap(sub)
+
+		if data["createdAt"] != created {
+			t.Errorf("createdAt = %v, want %v", data["createdAt"], created)
+		}
+		if data["expiresAt"] != expires {
+			t.Errorf("expiresAt = %v, want %v", data["expiresAt"], expires)
+		}
+		if data["status"] != StatusWarned || data["statusReason"] != ReasonInactive {
+			t.Errorf("status = %v (%v)", data["status"], data["statusReason"])
+		}
+		if data["statusChangedAt"] != created {
+			t.Errorf("statusChangedAt = %v, want %v", data["statusChangedAt"], created)
+		}
+		if data["pausedAt"] != expires {
+			t.Errorf("pausedAt = %v, want %v", data["pausedAt"], expires)
+		}
+	})
+
+	t.Run("omits lifecycle fields when not set", func(t *testing.T) {
+		data := subscriptionToMap(Subscription{Name: "Test", Delivery: DeliveryConfig{Type: "webhook"}})
+
+		for _, key := range []string{"createdAt", "expiresAt", "status", "statusReason", "statusChangedAt", "pausedAt"} {
+			if _, exists := data[key]; exists {
+				t.Errorf("%s should not be included when not set", key)
+			}
+		}
+	})
+
 	t.Run("does not include ID in map", func(t *testing.T) {
 		sub := Subscription{
 			ID:   "test-id",
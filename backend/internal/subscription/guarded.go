@@ -0,0 +1,116 @@
+package subscription
+
+import (
+	"context"
+	"log"
+	"sync"
+
+	"github.com/otiai10/namazu/backend/internal/store"
+)
+
+// GuardedRepository routes Repository calls through a store.Guard.
+// List is on the delivery hot path: if it fails even after retries, the last
+// successful result is served so that a Firestore outage delays subscription
+// changes instead of dropping notifications.
+type GuardedRepository struct {
+	repo  Repository
+	guard *store.Guard
+
+	mu   sync.RWMutex
+	last []Subscription // Last successful List result, nil until the first success
+}
+
+// Compile-time interface check
+var _ Repository = (*GuardedRepository)(nil)
+
+// NewGuardedRepository wraps repo with g
+func NewGuardedRepository(repo Repository, g *store.Guard) *GuardedRepository {
+	return &GuardedRepository{repo: repo, guard: g}
+}
+
+// List returns all subscriptions, falling back to the last known list on failure
+func (r *GuardedRepository) List(ctx context.Context) ([]Subscription, error) {
+	v, err := r.guard.Read(ctx, func(ctx context.Context) (interface{}, error) {
+		return r.repo.List(ctx)
+	})
+	if err != nil {
+		r.mu.RLock()
+		last := r.last
+		r.mu.RUnlock()
+		if last == nil || ctx.Err() != nil {
+			return nil, err
+		}
+		log.Printf("Failed to list subscriptions, using %d cached: %v", len(last), err)
+		return copySubscriptions(last), nil
+	}
+
+	subs := v.([]Subscription)
+	r.mu.Lock()
+	r.last = copySubscriptions(subs)
+	r.mu.Unlock()
+	return subs, nil
+}
+
+// ListByUserID returns all subscriptions for a specific user
+func (r *GuardedRepository) ListByUserID(ctx context.Context, userID string) ([]Subscription, error) {
+	v, err := r.guard.Read(ctx, func(ctx context.Context) (interface{}, error) {
+		return r.repo.ListByUserID(ctx, userID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.([]Subscription), nil
+}
+
+// Create creates a new subscription. IDs are generated, so the write is not retried.
+func (r *GuardedRepository) Create(ctx context.Context, sub Subscription) (string, error) {
+	var id string
+	err := r.guard.DoOnce(ctx, func(ctx context.Context) error {
+		var err error
+		id, err = r.repo.Create(ctx, sub)
+		return err
+	})
+	return id, err
+}
+
+// CreateWithinLimit creates a subscription within the owner's limit. Like Create, it is not retried.
+func (r *GuardedRepository) CreateWithinLimit(ctx context.Context, sub Subscription, limit int) (string, error) {
+	var id string
+	err := r.guard.DoOnce(ctx, func(ctx context.Context) error {
+		var err error
+		id, err = r.repo.CreateWithinLimit(ctx, sub, limit)
+		return err
+	})
+	return id, err
+}
+
+// Get retrieves a subscription by ID
+func (r *GuardedRepository) Get(ctx context.Context, id string) (*Subscription, error) {
+	v, err := r.guard.Read(ctx, func(ctx context.Context) (interface{}, error) {
+		return r.repo.Get(ctx, id)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(*Subscription), nil
+}
+
+// Update updates an existing subscription
+func (r *GuardedRepository) Update(ctx context.Context, id string, sub Subscription) error {
+	return r.guard.Do(ctx, func(ctx context.Context) error {
+		return r.repo.Update(ctx, id, sub)
+	})
+}
+
+// Delete removes a subscription by ID
+func (r *GuardedRepository) Delete(ctx context.Context, id string) error {
+	return r.guard.Do(ctx, func(ctx context.Context) error {
+		return r.repo.Delete(ctx, id)
+	})
+}
+
+func copySubscriptions(subs []Subscription) []Subscription {
+	result := make([]Subscription, len(subs))
+	copy(result, subs)
+	return result
+}
@@ -0,0 +1,89 @@
+package subscription
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/otiai10/namazu/backend/internal/store"
+)
+
+// outageRepository returns Unavailable for every call while down
+type outageRepository struct {
+	Repository
+	down  bool
+	calls int
+}
+
+func (r *outageRepository) List(ctx context.Context) ([]Subscription, error) {
+	r.calls++
+	if r.down {
+		return nil, status.Error(codes.Unavailable, "firestore is down")
+	}
+	return r.Repository.List(ctx)
+}
+
+func (r *outageRepository) Create(ctx context.Context, sub Subscription) (string, error) {
+	r.calls++
+	if r.down {
+		return "", status.Error(codes.Unavailable, "firestore is down")
+	}
+	return "sub-new", nil
+}
+
+func newOutageRepository() *outageRepository {
+	return &outageRepository{Repository: &StaticRepository{subscriptions: []Subscription{
+		{ID: "sub-1", Name: "First"},
+		{ID: "sub-2", Name: "Second"},
+	}}}
+}
+
+func TestGuardedRepository_ListFallsBackToLastKnown(t *testing.T) {
+	ctx := context.Background()
+	inner := newOutageRepository()
+	repo := NewGuardedRepository(inner, store.NewGuard(store.GuardConfig{MaxAttempts: 1}))
+
+	// No successful list yet: the error is returned
+	inner.down = true
+	if _, err := repo.List(ctx); err == nil {
+		t.Fatal("List() error = nil before any successful list")
+	}
+
+	inner.down = false
+	subs, err := repo.List(ctx)
+	if err != nil || len(subs) != 2 {
+		t.Fatalf("List() = %v, %v", subs, err)
+	}
+
+	// During the outage the cached list is served
+	inner.down = true
+	subs, err = repo.List(ctx)
+	if err != nil {
+		t.Fatalf("List() error = %v, want the cached list", err)
+	}
+	if len(subs) != 2 || subs[0].ID != "sub-1" {
+		t.Errorf("List() = %v, want the cached list", subs)
+	}
+
+	// Callers cannot modify the cache
+	subs[0].Name = "Modified"
+	subs, _ = repo.List(ctx)
+	if subs[0].Name != "First" {
+		t.Errorf("cached subscription was modified: %q", subs[0].Name)
+	}
+}
+
+func TestGuardedRepository_CreateIsNotRetried(t *testing.T) {
+	inner := newOutageRepository()
+	inner.down = true
+	repo := NewGuardedRepository(inner, store.NewGuard(store.GuardConfig{MaxAttempts: 3}))
+
+	if _, err := repo.Create(context.Background(), Subscription{Name: "New"}); err == nil {
+		t.Fatal("Create() error = nil")
+	}
+	if inner.calls != 1 {
+		t.Errorf("calls = %d, want 1", inner.calls)
+	}
+}
@@ -0,0 +1,176 @@
+package subscription
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/otiai10/namazu/backend/internal/store"
+)
+
+// MemoryRepository implements Repository in process memory.
+// Used under --test-mode and NAMAZU_STORE_TYPE=memory; nothing survives a restart.
+type MemoryRepository struct {
+	mu            sync.RWMutex
+	subscriptions map[string]Subscription
+	order         []string // IDs in creation order
+}
+
+// Compile-time interface check
+var _ Repository = (*MemoryRepository)(nil)
+
+// NewMemoryRepository creates an empty MemoryRepository
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{subscriptions: map[string]Subscription{}}
+}
+
+// List returns all subscriptions in creation order
+func (r *MemoryRepository) List(ctx context.Context) ([]Subscription, error) {
+	return r.filter(func(Subscription) bool { return true }), nil
+}
+
+// ListByUserID returns all subscriptions for a specific user in creation order
+func (r *MemoryRepository) ListByUserID(ctx context.Context, userID string) ([]Subscription, error) {
+	return r.filter(func(sub Subscription) bool { return sub.UserID == userID }), nil
+}
+
+// Create creates a new subscription and returns its generated ID
+func (r *MemoryRepository) Create(ctx context.Context, sub Subscription) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.createLocked(sub), nil
+}
+
+// CreateWithinLimit creates a subscription unless its owner already has limit
+// or more in its tenant. It returns ErrLimitExceeded if the limit is reached,
+// and ErrNameTaken if sub.UniqueName is set and the owner has the name.
+func (r *MemoryRepository) CreateWithinLimit(ctx context.Context, sub Subscription, limit int) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	used := 0
+	for _, stored := range r.subscriptions {
+		if stored.UserID == sub.UserID && stored.TenantID == sub.TenantID {
+			used++
+			if sub.UniqueName && stored.Name == sub.Name {
+				return "", ErrNameTaken
+			}
+		}
+	}
+	if used >= limit {
+		return "", ErrLimitExceeded
+	}
+	return r.createLocked(sub), nil
+}
+
+// createLocked stores a copy of sub under a new ID; r.mu must be held
+func (r *MemoryRepository) createLocked(sub Subscription) string {
+	stored := copySubscription(sub)
+	stored.ID = store.NewSQLID()
+	stored.Revision = 1
+	stored.UniqueName = false
+	r.subscriptions[stored.ID] = stored
+	r.order = append(r.order, stored.ID)
+	return stored.ID
+}
+
+// Get retrieves a subscription by ID. It returns nil and no error if not found.
+func (r *MemoryRepository) Get(ctx context.Context, id string) (*Subscription, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	sub, ok := r.subscriptions[id]
+	if !ok {
+		return nil, nil
+	}
+	copied := copySubscription(sub)
+	return &copied, nil
+}
+
+// Update replaces an existing subscription at the next revision.
+// It returns ErrNotFound if missing and ErrRevisionConflict if sub was read at another revision.
+func (r *MemoryRepository) Update(ctx context.Context, id string, sub Subscription) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	current, ok := r.subscriptions[id]
+	if !ok {
+		return ErrNotFound
+	}
+	if sub.ChecksRevision() && sub.Revision != current.Revision {
+		return ErrRevisionConflict
+	}
+	stored := copySubscription(sub)
+	stored.ID = id
+	stored.Revision = current.Revision + 1
+	stored.ExpectRevision = false
+	r.subscriptions[id] = stored
+	return nil
+}
+
+// Delete removes a subscription. It returns ErrNotFound if missing.
+func (r *MemoryRepository) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.subscriptions[id]; !ok {
+		return ErrNotFound
+	}
+	delete(r.subscriptions, id)
+	for i, ordered := range r.order {
+		if ordered == id {
+			r.order = append(r.order[:i:i], r.order[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+// filter returns copies of the subscriptions matching fn in creation order
+func (r *MemoryRepository) filter(fn func(Subscription) bool) []Subscription {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	result := make([]Subscription, 0, len(r.order))
+	for _, id := range r.order {
+		if sub := r.subscriptions[id]; fn(sub) {
+			result = append(result, copySubscription(sub))
+		}
+	}
+	return result
+}
+
+// copySubscription creates a deep copy so callers cannot mutate stored values
+func copySubscription(sub Subscription) Subscription {
+	copied := sub
+	if sub.Delivery.Retry != nil {
+		copied.Delivery.Retry = sub.Delivery.Retry.Copy()
+	}
+	copied.Delivery.PreviousSecretExpiresAt = copyTimePtr(sub.Delivery.PreviousSecretExpiresAt)
+	copied.Delivery.Headers = CopyHeaders(sub.Delivery.Headers)
+	copied.Delivery.AWS = sub.Delivery.AWS.Copy()
+	copied.Delivery.SMS = sub.Delivery.SMS.Copy()
+	if sub.Filter != nil {
+		copied.Filter = &FilterConfig{
+			MinScale:    sub.Filter.MinScale,
+			Prefectures: append([]string(nil), sub.Filter.Prefectures...),
+			EventTypes:  append([]string(nil), sub.Filter.EventTypes...),
+			EEW:         sub.Filter.EEW,
+
+			MinMagnitude:           sub.Filter.MinMagnitude,
+			MaxDepthKm:             sub.Filter.MaxDepthKm,
+			HypocenterNameContains: sub.Filter.HypocenterNameContains,
+			Geofence:               sub.Filter.Geofence.Copy(),
+		}
+	}
+	copied.QuietHours = sub.QuietHours.Copy()
+	copied.Throttle = sub.Throttle.Copy()
+	copied.Digest = sub.Digest.Copy()
+	copied.ExpiresAt = copyTimePtr(sub.ExpiresAt)
+	copied.StatusChangedAt = copyTimePtr(sub.StatusChangedAt)
+	copied.PausedAt = copyTimePtr(sub.PausedAt)
+	return copied
+}
+
+func copyTimePtr(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	copied := *t
+	return &copied
+}
@@ -0,0 +1,215 @@
+package subscription
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMemoryRepository(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryRepository()
+	expires := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	id, err := repo.Create(ctx, Subscription{
+		UserID:    "user-1",
+		Name:      "Alerts",
+		Delivery:  DeliveryConfig{Type: "webhook", URL: "https://example.com/hook", Retry: &RetryConfig{Enabled: true, MaxRetries: 3}},
+		Filter:    &FilterConfig{MinScale: 40, Prefectures: []string{"東京都"}},
+		ExpiresAt: &expires,
+	})
+	if err != nil || id == "" {
+		t.Fatalf("Create() = %q, %v", id, err)
+	}
+	other, err := repo.Create(ctx, Subscription{UserID: "user-2", Name: "Other"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := repo.Get(ctx, id)
+	if err != nil || got == nil || got.ID != id || got.Filter.Prefectures[0] != "東京都" || got.Delivery.Retry.MaxRetries != 3 {
+		t.Fatalf("Get() = %+v, %v", got, err)
+	}
+	if missing, err := repo.Get(ctx, "missing"); err != nil || missing != nil {
+		t.Errorf("Get(missing) = %v, %v, want nil, nil", missing, err)
+	}
+
+	// Returned subscriptions are copies
+	got.Filter.Prefectures[0] = "changed"
+	got.Delivery.Retry.MaxRetries = 0
+	*got.ExpiresAt = time.Time{}
+	if again, _ := repo.Get(ctx, id); again.Filter.Prefectures[0] != "東京都" || again.Delivery.Retry.MaxRetries != 3 || !again.ExpiresAt.Equal(expires) {
+		t.Errorf("mutating a returned subscription changed the stored one: %+v", again)
+	}
+
+	all, err := repo.List(ctx)
+	if err != nil || len(all) != 2 || all[0].ID != id || all[1].ID != other {
+		t.Errorf("List() = %+v, %v", all, err)
+	}
+	mine, err := repo.ListByUserID(ctx, "user-2")
+	if err != nil || len(mine) != 1 || mine[0].ID != other {
+		t.Errorf("ListByUserID() = %+v, %v", mine, err)
+	}
+
+	if err := repo.Update(ctx, id, Subscription{UserID: "user-1", Name: "Renamed", Status: StatusSuspended}); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if updated, _ := repo.Get(ctx, id); updated.Name != "Renamed" || updated.Status != StatusSuspended || updated.ID != id {
+		t.Errorf("Get() after Update = %+v", updated)
+	}
+	if err := repo.Update(ctx, "missing", Subscription{}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Update(missing) error = %v, want ErrNotFound", err)
+	}
+
+	if err := repo.Delete(ctx, id); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if err := repo.Delete(ctx, id); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Delete(missing) error = %v, want ErrNotFound", err)
+	}
+	if all, _ := repo.List(ctx); len(all) != 1 || all[0].ID != other {
+		t.Errorf("List() after Delete = %+v", all)
+	}
+}
+
+func TestMemoryRepository_Revision(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryRepository()
+
+	id, err := repo.Create(ctx, Subscription{Name: "Alerts"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	read, _ := repo.Get(ctx, id)
+	if read.Revision != 1 {
+		t.Fatalf("Revision after Create = %d, want 1", read.Revision)
+	}
+
+	first, second := *read, *read
+	first.Name = "First"
+	second.Name = "Second"
+	if err := repo.Update(ctx, id, first); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if err := repo.Update(ctx, id, second); !errors.Is(err, ErrRevisionConflict) {
+		t.Errorf("Update(stale) error = %v, want ErrRevisionConflict", err)
+	}
+	if got, _ := repo.Get(ctx, id); got.Name != "First" || got.Revision != 2 {
+		t.Errorf("Get() = %+v, want the first write at revision 2", got)
+	}
+
+	// A zero revision is not checked
+	if err := repo.Update(ctx, id, Subscription{Name: "Forced"}); err != nil {
+		t.Errorf("Update(revision 0) error = %v", err)
+	}
+	if got, _ := repo.Get(ctx, id); got.Revision != 3 {
+		t.Errorf("Revision = %d, want 3", got.Revision)
+	}
+}
+
+func TestMemoryRepository_ConcurrentUpdatesOfLegacySubscription(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryRepository()
+	// Stored before revisions existed
+	repo.subscriptions["legacy"] = Subscription{ID: "legacy", Name: "Alerts"}
+
+	read, _ := repo.Get(ctx, "legacy")
+	errs := make(chan error, 2)
+	for _, name := range []string{"First", "Second"} {
+		go func() {
+			sub := *read
+			sub.Name = name
+			sub.ExpectRevision = true
+			errs <- repo.Update(ctx, "legacy", sub)
+		}()
+	}
+	var conflicts int
+	for range 2 {
+		if err := <-errs; errors.Is(err, ErrRevisionConflict) {
+			conflicts++
+		} else if err != nil {
+			t.Fatalf("Update() error = %v", err)
+		}
+	}
+	if conflicts != 1 {
+		t.Errorf("conflicts = %d, want exactly one of the two writes of revision 0 to lose", conflicts)
+	}
+	if got, _ := repo.Get(ctx, "legacy"); got.Revision != 1 || got.ExpectRevision {
+		t.Errorf("Get() = %+v, want the winning write at revision 1", got)
+	}
+}
+
+func TestMemoryRepository_CreateWithinLimit(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryRepository()
+	_, _ = repo.Create(ctx, Subscription{UserID: "user-1", TenantID: "other", Name: "Other tenant"})
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	created, rejected := 0, 0
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := repo.CreateWithinLimit(ctx, Subscription{UserID: "user-1", Name: "Alerts"}, 3)
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+				created++
+			case errors.Is(err, ErrLimitExceeded):
+				rejected++
+			default:
+				t.Errorf("CreateWithinLimit() error = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if created != 3 || rejected != 7 {
+		t.Errorf("created %d and rejected %d, want 3 and 7", created, rejected)
+	}
+	if _, err := repo.CreateWithinLimit(ctx, Subscription{UserID: "user-2", Name: "Alerts"}, 3); err != nil {
+		t.Errorf("CreateWithinLimit(other owner) error = %v", err)
+	}
+}
+
+func TestMemoryRepository_CreateWithinLimit_UniqueName(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryRepository()
+	_, _ = repo.Create(ctx, Subscription{UserID: "user-1", TenantID: "other", Name: "Alerts"})
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	created, taken := 0, 0
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := repo.CreateWithinLimit(ctx, Subscription{UserID: "user-1", Name: "Alerts", UniqueName: true}, 100)
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+				created++
+			case errors.Is(err, ErrNameTaken):
+				taken++
+			default:
+				t.Errorf("CreateWithinLimit() error = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if created != 1 || taken != 9 {
+		t.Errorf("created %d and refused %d, want 1 and 9", created, taken)
+	}
+	if _, err := repo.CreateWithinLimit(ctx, Subscription{UserID: "user-2", Name: "Alerts", UniqueName: true}, 100); err != nil {
+		t.Errorf("CreateWithinLimit(other owner) error = %v", err)
+	}
+	if _, err := repo.CreateWithinLimit(ctx, Subscription{UserID: "user-1", Name: "Alerts"}, 100); err != nil {
+		t.Errorf("CreateWithinLimit(without UniqueName) error = %v", err)
+	}
+}
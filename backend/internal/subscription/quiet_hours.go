@@ -0,0 +1,101 @@
+package subscription
+
+import (
+	"time"
+	_ "time/tzdata" // Quiet hours must resolve time zones in minimal container images
+
+	"github.com/otiai10/namazu/backend/internal/source"
+	"github.com/otiai10/namazu/backend/internal/source/p2pquake"
+)
+
+// DefaultQuietHoursTimezone is used when QuietHours.Timezone is empty
+const DefaultQuietHoursTimezone = "Asia/Tokyo"
+
+// quietHoursLayout is the clock format of QuietHours.Start and End
+const quietHoursLayout = "15:04"
+
+// QuietHours suppresses deliveries during a daily window in the subscriber's time zone.
+// A window whose End is before its Start spans midnight (e.g. 23:00–07:00).
+type QuietHours struct {
+	Start            string `json:"start"`                        // "HH:MM", inclusive
+	End              string `json:"end"`                          // "HH:MM", exclusive
+	Timezone         string `json:"timezone,omitempty"`           // IANA name; empty means DefaultQuietHoursTimezone
+	MinScaleOverride int    `json:"min_scale_override,omitempty"` // Events at or above this scale are delivered anyway
+}
+
+// Validate returns an error message if the quiet hours are malformed, or "" if valid
+func (q *QuietHours) Validate() string {
+	start, err := time.Parse(quietHoursLayout, q.Start)
+	if err != nil {
+		return "quiet_hours.start must be HH:MM"
+	}
+	end, err := time.Parse(quietHoursLayout, q.End)
+	if err != nil {
+		return "quiet_hours.end must be HH:MM"
+	}
+	if start.Equal(end) {
+		return "quiet_hours.start and quiet_hours.end must differ"
+	}
+	if _, err := q.location(); err != nil {
+		return "unknown quiet_hours.timezone: " + q.Timezone
+	}
+	if q.MinScaleOverride < 0 {
+		return "quiet_hours.min_scale_override must not be negative"
+	}
+	return ""
+}
+
+// Active reports whether now falls inside the quiet window.
+// Malformed quiet hours are never active, so deliveries are not lost silently.
+func (q *QuietHours) Active(now time.Time) bool {
+	if q == nil {
+		return false
+	}
+	start, err := time.Parse(quietHoursLayout, q.Start)
+	if err != nil {
+		return false
+	}
+	end, err := time.Parse(quietHoursLayout, q.End)
+	if err != nil {
+		return false
+	}
+	loc, err := q.location()
+	if err != nil {
+		return false
+	}
+
+	local := now.In(loc)
+	minute := local.Hour()*60 + local.Minute()
+	from := start.Hour()*60 + start.Minute()
+	to := end.Hour()*60 + end.Minute()
+	if from < to {
+		return minute >= from && minute < to
+	}
+	return minute >= from || minute < to
+}
+
+// Allows reports whether an event may be delivered at now.
+// A nil QuietHours always allows delivery; during the window only events at or
+// above MinScaleOverride (if set) get through.
+func (q *QuietHours) Allows(event source.Event, now time.Time) bool {
+	if !q.Active(now) {
+		return true
+	}
+	return q.MinScaleOverride > 0 && event.GetSeverity() >= p2pquake.ScaleToSeverity(q.MinScaleOverride)
+}
+
+// Copy returns a copy of the quiet hours, or nil if q is nil
+func (q *QuietHours) Copy() *QuietHours {
+	if q == nil {
+		return nil
+	}
+	copied := *q
+	return &copied
+}
+
+func (q *QuietHours) location() (*time.Location, error) {
+	if q.Timezone == "" {
+		return time.LoadLocation(DefaultQuietHoursTimezone)
+	}
+	return time.LoadLocation(q.Timezone)
+}
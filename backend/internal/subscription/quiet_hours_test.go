@@ -0,0 +1,84 @@
+package subscription
+
+import (
+	"testing"
+	"time"
+
+	"github.com/otiai10/namazu/backend/internal/source/p2pquake"
+)
+
+func TestQuietHours_Active(t *testing.T) {
+	jst := time.FixedZone("JST", 9*60*60)
+	overnight := &QuietHours{Start: "23:00", End: "07:00"}
+	daytime := &QuietHours{Start: "09:00", End: "17:30", Timezone: "UTC"}
+
+	tests := []struct {
+		name  string
+		quiet *QuietHours
+		now   time.Time
+		want  bool
+	}{
+		{"nil", nil, time.Now(), false},
+		{"before overnight window", overnight, time.Date(2024, 1, 1, 22, 59, 0, 0, jst), false},
+		{"overnight window starts", overnight, time.Date(2024, 1, 1, 23, 0, 0, 0, jst), true},
+		{"after midnight", overnight, time.Date(2024, 1, 2, 3, 0, 0, 0, jst), true},
+		{"overnight window ends", overnight, time.Date(2024, 1, 2, 7, 0, 0, 0, jst), false},
+		{"default timezone is Tokyo", overnight, time.Date(2024, 1, 1, 15, 0, 0, 0, time.UTC), true},
+		{"inside daytime window", daytime, time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC), true},
+		{"daytime window in another zone", daytime, time.Date(2024, 1, 1, 12, 0, 0, 0, jst), false},
+		{"malformed", &QuietHours{Start: "25:00", End: "07:00"}, time.Date(2024, 1, 2, 3, 0, 0, 0, jst), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.quiet.Active(tt.now); got != tt.want {
+				t.Errorf("Active() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQuietHours_Allows(t *testing.T) {
+	night := time.Date(2024, 1, 1, 18, 0, 0, 0, time.UTC) // 03:00 JST
+	noon := time.Date(2024, 1, 1, 3, 0, 0, 0, time.UTC)   // 12:00 JST
+	strong := newMockEvent(p2pquake.ScaleToSeverity(p2pquake.Scale6Weak), nil)
+	weak := newMockEvent(p2pquake.ScaleToSeverity(p2pquake.Scale3), nil)
+
+	quiet := &QuietHours{Start: "23:00", End: "07:00"}
+	withOverride := &QuietHours{Start: "23:00", End: "07:00", MinScaleOverride: p2pquake.Scale5Weak}
+
+	if !(*QuietHours)(nil).Allows(weak, night) {
+		t.Error("nil quiet hours should allow every event")
+	}
+	if !quiet.Allows(weak, noon) {
+		t.Error("events outside the window should be allowed")
+	}
+	if quiet.Allows(strong, night) {
+		t.Error("events inside the window should be suppressed without an override")
+	}
+	if withOverride.Allows(weak, night) {
+		t.Error("events below the override should be suppressed")
+	}
+	if !withOverride.Allows(strong, night) {
+		t.Error("events at or above the override should be allowed")
+	}
+}
+
+func TestQuietHours_Validate(t *testing.T) {
+	tests := []struct {
+		quiet QuietHours
+		valid bool
+	}{
+		{QuietHours{Start: "23:00", End: "07:00"}, true},
+		{QuietHours{Start: "23:00", End: "07:00", Timezone: "America/New_York", MinScaleOverride: 50}, true},
+		{QuietHours{Start: "11pm", End: "07:00"}, false},
+		{QuietHours{Start: "23:00", End: ""}, false},
+		{QuietHours{Start: "07:00", End: "07:00"}, false},
+		{QuietHours{Start: "23:00", End: "07:00", Timezone: "Mars/Olympus"}, false},
+		{QuietHours{Start: "23:00", End: "07:00", MinScaleOverride: -1}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.quiet.Validate() == ""; got != tt.valid {
+			t.Errorf("Validate(%+v) valid = %v, want %v", tt.quiet, got, tt.valid)
+		}
+	}
+}
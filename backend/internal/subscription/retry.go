@@ -0,0 +1,68 @@
+package subscription
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// RetryConfig holds retry settings for delivery.
+// This is a copy of webhook.RetryConfig to avoid circular imports.
+type RetryConfig struct {
+	Enabled         bool             `json:"enabled" firestore:"enabled"`
+	MaxRetries      int              `json:"max_retries" firestore:"max_retries"`
+	InitialMs       int              `json:"initial_ms" firestore:"initial_ms"`
+	MaxMs           int              `json:"max_ms" firestore:"max_ms"`
+	Jitter          float64          `json:"jitter,omitempty" firestore:"jitter,omitempty"`                     // Fraction (0-1) by which each backoff is randomly shortened
+	StatusOverrides []StatusOverride `json:"status_overrides,omitempty" firestore:"status_overrides,omitempty"` // Per-status-code behavior
+}
+
+// StatusOverride changes how a failed response with a given status code is retried.
+// This is a copy of webhook.StatusOverride.
+type StatusOverride struct {
+	Status     int  `json:"status" firestore:"status"`
+	Retry      bool `json:"retry" firestore:"retry"`
+	RetryAfter bool `json:"retry_after,omitempty" firestore:"retry_after,omitempty"` // Honor the Retry-After header
+}
+
+// Validate returns an error message if the retry settings are malformed, or "".
+// Limits that depend on the owner's plan are checked by package plan.
+func (c *RetryConfig) Validate() string {
+	if c.MaxRetries < 0 {
+		return "delivery.retry.max_retries must not be negative"
+	}
+	if c.InitialMs < 0 || c.MaxMs < 0 {
+		return "delivery.retry.initial_ms and max_ms must not be negative"
+	}
+	if c.MaxMs > 0 && c.MaxMs < c.InitialMs {
+		return "delivery.retry.max_ms must not be less than initial_ms"
+	}
+	if c.Jitter < 0 || c.Jitter > 1 {
+		return "delivery.retry.jitter must be between 0 and 1"
+	}
+	seen := make(map[int]bool, len(c.StatusOverrides))
+	for i, o := range c.StatusOverrides {
+		if o.Status < 100 || o.Status > 599 || (o.Status >= http.StatusOK && o.Status < http.StatusMultipleChoices) {
+			return fmt.Sprintf("delivery.retry.status_overrides[%d].status must be a non-2xx HTTP status code", i)
+		}
+		if seen[o.Status] {
+			return fmt.Sprintf("delivery.retry.status_overrides[%d]: duplicate status %d", i, o.Status)
+		}
+		seen[o.Status] = true
+		if o.RetryAfter && !o.Retry {
+			return fmt.Sprintf("delivery.retry.status_overrides[%d]: retry_after requires retry", i)
+		}
+	}
+	return ""
+}
+
+// Copy returns a deep copy of the config, or nil if c is nil
+func (c *RetryConfig) Copy() *RetryConfig {
+	if c == nil {
+		return nil
+	}
+	copied := *c
+	if c.StatusOverrides != nil {
+		copied.StatusOverrides = append([]StatusOverride(nil), c.StatusOverrides...)
+	}
+	return &copied
+}
@@ -0,0 +1,48 @@
+package subscription
+
+import "testing"
+
+func TestRetryConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name  string
+		retry RetryConfig
+		valid bool
+	}{
+		{"defaults", RetryConfig{Enabled: true, MaxRetries: 3, InitialMs: 1000, MaxMs: 60000}, true},
+		{"zero values", RetryConfig{Enabled: true}, true},
+		{"negative retries", RetryConfig{MaxRetries: -1}, false},
+		{"negative initial", RetryConfig{InitialMs: -1}, false},
+		{"max below initial", RetryConfig{InitialMs: 2000, MaxMs: 1000}, false},
+		{"jitter", RetryConfig{Jitter: 0.5}, true},
+		{"jitter above 1", RetryConfig{Jitter: 1.5}, false},
+		{"negative jitter", RetryConfig{Jitter: -0.1}, false},
+		{"retry after on 429", RetryConfig{StatusOverrides: []StatusOverride{{Status: 429, Retry: true, RetryAfter: true}}}, true},
+		{"stop on 503", RetryConfig{StatusOverrides: []StatusOverride{{Status: 503}}}, true},
+		{"success status", RetryConfig{StatusOverrides: []StatusOverride{{Status: 204, Retry: true}}}, false},
+		{"unknown status", RetryConfig{StatusOverrides: []StatusOverride{{Status: 600, Retry: true}}}, false},
+		{"duplicate status", RetryConfig{StatusOverrides: []StatusOverride{{Status: 429, Retry: true}, {Status: 429}}}, false},
+		{"retry after without retry", RetryConfig{StatusOverrides: []StatusOverride{{Status: 429, RetryAfter: true}}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := tt.retry.Validate()
+			if (msg == "") != tt.valid {
+				t.Errorf("Validate() = %q, want valid=%v", msg, tt.valid)
+			}
+		})
+	}
+}
+
+func TestRetryConfig_Copy(t *testing.T) {
+	var nilRetry *RetryConfig
+	if nilRetry.Copy() != nil {
+		t.Error("Copy() of nil should be nil")
+	}
+
+	original := &RetryConfig{Enabled: true, StatusOverrides: []StatusOverride{{Status: 429, Retry: true}}}
+	copied := original.Copy()
+	copied.StatusOverrides[0].Status = 503
+	if original.StatusOverrides[0].Status != 429 {
+		t.Error("Copy() shares status overrides with the original")
+	}
+}
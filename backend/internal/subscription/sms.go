@@ -0,0 +1,57 @@
+package subscription
+
+import (
+	"regexp"
+
+	"github.com/otiai10/namazu/backend/internal/source"
+	"github.com/otiai10/namazu/backend/internal/source/p2pquake"
+)
+
+// DeliveryTypeSMS sends text messages through Twilio
+const DeliveryTypeSMS = "sms"
+
+// DefaultSMSMinScale is used when SMSConfig.MinScale is 0.
+// SMS costs money per message, so only strong shaking is sent by default.
+const DefaultSMSMinScale = p2pquake.Scale5Weak
+
+// e164Pattern matches phone numbers in E.164 format, e.g. +819012345678
+var e164Pattern = regexp.MustCompile(`^\+[1-9]\d{7,14}$`)
+
+// SMSConfig is the destination of an "sms" subscription
+type SMSConfig struct {
+	Phone    string `json:"phone"`               // E.164, e.g. "+819012345678"
+	MinScale int    `json:"min_scale,omitempty"` // p2pquake scale; 0 means DefaultSMSMinScale
+}
+
+// Validate returns an error message if the config is malformed, or "" if valid
+func (c *SMSConfig) Validate() string {
+	if !e164Pattern.MatchString(c.Phone) {
+		return "delivery.sms.phone must be an E.164 phone number (e.g. +819012345678)"
+	}
+	if c.MinScale < 0 || c.MinScale > p2pquake.Scale7 {
+		return "delivery.sms.min_scale must be a scale between 10 and 70"
+	}
+	return ""
+}
+
+// Allows reports whether an event is strong enough to be sent.
+// A nil SMSConfig (any other delivery type) allows every event.
+func (c *SMSConfig) Allows(event source.Event) bool {
+	if c == nil {
+		return true
+	}
+	minScale := c.MinScale
+	if minScale == 0 {
+		minScale = DefaultSMSMinScale
+	}
+	return event.GetSeverity() >= p2pquake.ScaleToSeverity(minScale)
+}
+
+// Copy returns a copy of the config, or nil if c is nil
+func (c *SMSConfig) Copy() *SMSConfig {
+	if c == nil {
+		return nil
+	}
+	copied := *c
+	return &copied
+}
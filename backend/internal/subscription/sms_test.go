@@ -0,0 +1,49 @@
+package subscription
+
+import (
+	"testing"
+
+	"github.com/otiai10/namazu/backend/internal/source/p2pquake"
+)
+
+func TestSMSConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     SMSConfig
+		wantErr bool
+	}{
+		{"japanese mobile", SMSConfig{Phone: "+819012345678"}, false},
+		{"with min scale", SMSConfig{Phone: "+819012345678", MinScale: p2pquake.Scale6Weak}, false},
+		{"national format", SMSConfig{Phone: "09012345678"}, true},
+		{"with separators", SMSConfig{Phone: "+81-90-1234-5678"}, true},
+		{"leading zero country code", SMSConfig{Phone: "+0123456789"}, true},
+		{"too long", SMSConfig{Phone: "+1234567890123456"}, true},
+		{"negative min scale", SMSConfig{Phone: "+819012345678", MinScale: -1}, true},
+		{"min scale above 7", SMSConfig{Phone: "+819012345678", MinScale: 80}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if msg := tt.cfg.Validate(); (msg != "") != tt.wantErr {
+				t.Errorf("Validate() = %q, wantErr %v", msg, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSMSConfig_Allows(t *testing.T) {
+	strong := newMockEvent(p2pquake.ScaleToSeverity(p2pquake.Scale5Weak), nil)
+	weak := newMockEvent(p2pquake.ScaleToSeverity(p2pquake.Scale4), nil)
+
+	var none *SMSConfig
+	if !none.Allows(weak) {
+		t.Error("nil config should allow every event")
+	}
+	defaults := &SMSConfig{Phone: "+819012345678"}
+	if !defaults.Allows(strong) || defaults.Allows(weak) {
+		t.Error("default min scale should be 5弱")
+	}
+	low := &SMSConfig{Phone: "+819012345678", MinScale: p2pquake.Scale3}
+	if !low.Allows(weak) {
+		t.Error("explicit min scale should allow weaker events")
+	}
+}
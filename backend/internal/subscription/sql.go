@@ -0,0 +1,253 @@
+package subscription
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/otiai10/namazu/backend/internal/store"
+)
+
+// SQLRepository implements Repository on SQLite or Postgres.
+// Each subscription is stored as a JSON document; the owner and creation time
+// are kept in their own columns for lookups and ordering.
+type SQLRepository struct {
+	client *store.SQLClient
+}
+
+// Compile-time interface check
+var _ Repository = (*SQLRepository)(nil)
+
+// NewSQLRepository creates a new SQLRepository
+func NewSQLRepository(client *store.SQLClient) *SQLRepository {
+	return &SQLRepository{client: client}
+}
+
+// List returns all subscriptions, oldest first
+func (r *SQLRepository) List(ctx context.Context) ([]Subscription, error) {
+	return r.query(ctx, `SELECT id, data FROM subscriptions ORDER BY created_at, id`)
+}
+
+// ListByUserID returns all subscriptions for a specific user, oldest first
+func (r *SQLRepository) ListByUserID(ctx context.Context, userID string) ([]Subscription, error) {
+	return r.query(ctx, `SELECT id, data FROM subscriptions WHERE user_id = ? ORDER BY created_at, id`, userID)
+}
+
+// Create creates a new subscription and returns its generated ID
+func (r *SQLRepository) Create(ctx context.Context, sub Subscription) (string, error) {
+	id := store.NewSQLID()
+	sub.Revision = 1
+	data, err := encodeSubscription(sub)
+	if err != nil {
+		return "", err
+	}
+
+	_, err = r.client.DB().ExecContext(ctx, r.client.Rebind(
+		`INSERT INTO subscriptions (id, user_id, created_at, data) VALUES (?, ?, ?, ?)`),
+		id, sub.UserID, store.FormatSQLTime(sub.CreatedAt), data)
+	if err != nil {
+		return "", fmt.Errorf("failed to create subscription: %w", err)
+	}
+	return id, nil
+}
+
+// CreateWithinLimit creates a subscription unless its owner already has limit
+// or more in its tenant. It returns ErrLimitExceeded if the limit is reached,
+// and ErrNameTaken if sub.UniqueName is set and the owner has the name.
+// Counting and inserting share a transaction; on Postgres an advisory lock on
+// the owner serializes them, and SQLite runs on a single connection anyway.
+func (r *SQLRepository) CreateWithinLimit(ctx context.Context, sub Subscription, limit int) (string, error) {
+	id := store.NewSQLID()
+	sub.Revision = 1
+	data, err := encodeSubscription(sub)
+	if err != nil {
+		return "", err
+	}
+
+	tx, err := r.client.DB().BeginTx(ctx, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create subscription: %w", err)
+	}
+	defer tx.Rollback()
+
+	if r.client.Dialect() == store.DialectPostgres {
+		if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext($1))`, "subscriptions:"+sub.UserID); err != nil {
+			return "", fmt.Errorf("failed to lock subscription owner: %w", err)
+		}
+	}
+
+	rows, err := tx.QueryContext(ctx, r.client.Rebind(
+		`SELECT id, data FROM subscriptions WHERE user_id = ?`), sub.UserID)
+	if err != nil {
+		return "", fmt.Errorf("failed to count subscriptions: %w", err)
+	}
+	used := 0
+	for rows.Next() {
+		var existingID, existingData string
+		if err := rows.Scan(&existingID, &existingData); err != nil {
+			rows.Close()
+			return "", fmt.Errorf("failed to read subscription: %w", err)
+		}
+		existing, err := decodeSubscription(existingID, existingData)
+		if err != nil {
+			rows.Close()
+			return "", err
+		}
+		if existing.TenantID == sub.TenantID {
+			used++
+			if sub.UniqueName && existing.Name == sub.Name {
+				rows.Close()
+				return "", ErrNameTaken
+			}
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return "", fmt.Errorf("failed to count subscriptions: %w", err)
+	}
+	if used >= limit {
+		return "", ErrLimitExceeded
+	}
+
+	if _, err := tx.ExecContext(ctx, r.client.Rebind(
+		`INSERT INTO subscriptions (id, user_id, created_at, data) VALUES (?, ?, ?, ?)`),
+		id, sub.UserID, store.FormatSQLTime(sub.CreatedAt), data); err != nil {
+		return "", fmt.Errorf("failed to create subscription: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return "", fmt.Errorf("failed to create subscription: %w", err)
+	}
+	return id, nil
+}
+
+// Get retrieves a subscription by ID. It returns nil and no error if not found.
+func (r *SQLRepository) Get(ctx context.Context, id string) (*Subscription, error) {
+	var data string
+	err := r.client.DB().QueryRowContext(ctx, r.client.Rebind(
+		`SELECT data FROM subscriptions WHERE id = ?`), id).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get subscription: %w", err)
+	}
+
+	sub, err := decodeSubscription(id, data)
+	if err != nil {
+		return nil, err
+	}
+	return &sub, nil
+}
+
+// Update replaces an existing subscription at the next revision.
+// It returns ErrNotFound if missing and ErrRevisionConflict if sub was read at
+// another revision. The row is only replaced if it still holds the document the
+// revision was read from, so of two concurrent writes only the first succeeds.
+func (r *SQLRepository) Update(ctx context.Context, id string, sub Subscription) error {
+	var currentData string
+	err := r.client.DB().QueryRowContext(ctx, r.client.Rebind(
+		`SELECT data FROM subscriptions WHERE id = ?`), id).Scan(&currentData)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get subscription: %w", err)
+	}
+	current, err := decodeSubscription(id, currentData)
+	if err != nil {
+		return err
+	}
+	if sub.ChecksRevision() && sub.Revision != current.Revision {
+		return ErrRevisionConflict
+	}
+
+	sub.Revision = current.Revision + 1
+	data, err := encodeSubscription(sub)
+	if err != nil {
+		return err
+	}
+
+	result, err := r.client.DB().ExecContext(ctx, r.client.Rebind(
+		`UPDATE subscriptions SET user_id = ?, created_at = ?, data = ? WHERE id = ? AND data = ?`),
+		sub.UserID, store.FormatSQLTime(sub.CreatedAt), data, id, currentData)
+	if err != nil {
+		return fmt.Errorf("failed to update subscription: %w", err)
+	}
+	if err := requireAffected(result); err != nil {
+		// Deleted or written by someone else since it was read
+		if existing, getErr := r.Get(ctx, id); getErr == nil && existing == nil {
+			return ErrNotFound
+		}
+		return ErrRevisionConflict
+	}
+	return nil
+}
+
+// Delete removes a subscription. It returns ErrNotFound if missing.
+func (r *SQLRepository) Delete(ctx context.Context, id string) error {
+	result, err := r.client.DB().ExecContext(ctx, r.client.Rebind(`DELETE FROM subscriptions WHERE id = ?`), id)
+	if err != nil {
+		return fmt.Errorf("failed to delete subscription: %w", err)
+	}
+	return requireAffected(result)
+}
+
+// query runs a SELECT of (id, data) and decodes every row
+func (r *SQLRepository) query(ctx context.Context, query string, args ...interface{}) ([]Subscription, error) {
+	rows, err := r.client.DB().QueryContext(ctx, r.client.Rebind(query), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list subscriptions: %w", err)
+	}
+	defer rows.Close()
+
+	subscriptions := make([]Subscription, 0)
+	for rows.Next() {
+		var id, data string
+		if err := rows.Scan(&id, &data); err != nil {
+			return nil, fmt.Errorf("failed to read subscription: %w", err)
+		}
+		sub, err := decodeSubscription(id, data)
+		if err != nil {
+			return nil, err
+		}
+		subscriptions = append(subscriptions, sub)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list subscriptions: %w", err)
+	}
+	return subscriptions, nil
+}
+
+// requireAffected returns ErrNotFound if a statement matched no rows
+func requireAffected(result sql.Result) error {
+	n, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check affected rows: %w", err)
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// encodeSubscription serializes a subscription without its ID, which is the row key
+func encodeSubscription(sub Subscription) (string, error) {
+	sub.ID = ""
+	data, err := json.Marshal(sub)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode subscription: %w", err)
+	}
+	return string(data), nil
+}
+
+// decodeSubscription restores a subscription stored by encodeSubscription
+func decodeSubscription(id, data string) (Subscription, error) {
+	var sub Subscription
+	if err := json.Unmarshal([]byte(data), &sub); err != nil {
+		return Subscription{}, fmt.Errorf("failed to decode subscription %s: %w", id, err)
+	}
+	sub.ID = id
+	return sub, nil
+}
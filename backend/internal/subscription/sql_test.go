@@ -0,0 +1,244 @@
+package subscription
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/otiai10/namazu/backend/internal/store"
+)
+
+// openTestSQL returns an in-memory SQLite client, plus a Postgres client when
+// NAMAZU_TEST_POSTGRES_DSN is set
+func openTestSQL(t *testing.T) map[string]*store.SQLClient {
+	t.Helper()
+	ctx := context.Background()
+	clients := map[string]*store.SQLClient{}
+
+	sqlite, err := store.OpenSQL(ctx, store.DialectSQLite, ":memory:")
+	if err != nil {
+		t.Fatalf("OpenSQL(sqlite) error = %v", err)
+	}
+	t.Cleanup(func() { sqlite.Close() })
+	clients[store.DialectSQLite] = sqlite
+
+	if dsn := os.Getenv("NAMAZU_TEST_POSTGRES_DSN"); dsn != "" {
+		pg, err := store.OpenSQL(ctx, store.DialectPostgres, dsn)
+		if err != nil {
+			t.Fatalf("OpenSQL(postgres) error = %v", err)
+		}
+		t.Cleanup(func() { pg.Close() })
+		if _, err := pg.DB().ExecContext(ctx, "DELETE FROM subscriptions"); err != nil {
+			t.Fatal(err)
+		}
+		clients[store.DialectPostgres] = pg
+	}
+	return clients
+}
+
+func TestSQLRepository(t *testing.T) {
+	for dialect, client := range openTestSQL(t) {
+		t.Run(dialect, func(t *testing.T) {
+			ctx := context.Background()
+			repo := NewSQLRepository(client)
+			created := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+			expires := created.AddDate(0, 6, 0)
+
+			sub := Subscription{
+				UserID: "user-1",
+				Name:   "Alerts",
+				Delivery: DeliveryConfig{
+					Type:        "webhook",
+					URL:         "https://example.com/hook",
+					Secret:      "s3cret",
+					SignVersion: "v1",
+					Retry:       &RetryConfig{Enabled: true, MaxRetries: 3, InitialMs: 1000, MaxMs: 60000},
+				},
+				Filter:    &FilterConfig{MinScale: 40, Prefectures: []string{"東京都"}, EEW: true},
+				CreatedAt: created,
+				ExpiresAt: &expires,
+			}
+			id, err := repo.Create(ctx, sub)
+			if err != nil || id == "" {
+				t.Fatalf("Create() = %q, %v", id, err)
+			}
+			other, err := repo.Create(ctx, Subscription{UserID: "user-2", Name: "Other", CreatedAt: created.Add(time.Hour)})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := repo.Get(ctx, id)
+			if err != nil || got == nil {
+				t.Fatalf("Get() = %v, %v", got, err)
+			}
+			if got.ID != id || got.Delivery.Secret != "s3cret" || got.Delivery.Retry == nil || got.Delivery.Retry.MaxRetries != 3 ||
+				got.Filter == nil || !got.Filter.EEW || got.Filter.Prefectures[0] != "東京都" ||
+				got.ExpiresAt == nil || !got.ExpiresAt.Equal(expires) || !got.CreatedAt.Equal(created) {
+				t.Errorf("Get() = %+v", got)
+			}
+			if missing, err := repo.Get(ctx, "missing"); err != nil || missing != nil {
+				t.Errorf("Get(missing) = %v, %v, want nil, nil", missing, err)
+			}
+
+			all, err := repo.List(ctx)
+			if err != nil || len(all) != 2 || all[0].ID != id || all[1].ID != other {
+				t.Errorf("List() = %+v, %v", all, err)
+			}
+			mine, err := repo.ListByUserID(ctx, "user-1")
+			if err != nil || len(mine) != 1 || mine[0].ID != id {
+				t.Errorf("ListByUserID() = %+v, %v", mine, err)
+			}
+
+			got.Status = StatusSuspended
+			got.StatusReason = ReasonFailing
+			if err := repo.Update(ctx, id, *got); err != nil {
+				t.Fatalf("Update() error = %v", err)
+			}
+			if updated, _ := repo.Get(ctx, id); updated.Status != StatusSuspended || updated.StatusReason != ReasonFailing {
+				t.Errorf("status = %s (%s) after Update", updated.Status, updated.StatusReason)
+			}
+			if err := repo.Update(ctx, "missing", *got); !errors.Is(err, ErrNotFound) {
+				t.Errorf("Update(missing) error = %v, want ErrNotFound", err)
+			}
+
+			if err := repo.Delete(ctx, id); err != nil {
+				t.Fatalf("Delete() error = %v", err)
+			}
+			if deleted, _ := repo.Get(ctx, id); deleted != nil {
+				t.Error("subscription still exists after Delete")
+			}
+			if err := repo.Delete(ctx, id); !errors.Is(err, ErrNotFound) {
+				t.Errorf("Delete(missing) error = %v, want ErrNotFound", err)
+			}
+		})
+	}
+}
+
+func TestSQLRepository_CreateWithinLimit(t *testing.T) {
+	for dialect, client := range openTestSQL(t) {
+		t.Run(dialect, func(t *testing.T) {
+			ctx := context.Background()
+			repo := NewSQLRepository(client)
+			now := time.Now().UTC()
+
+			if _, err := repo.Create(ctx, Subscription{UserID: "user-1", TenantID: "other", Name: "Other tenant", CreatedAt: now}); err != nil {
+				t.Fatal(err)
+			}
+			id, err := repo.CreateWithinLimit(ctx, Subscription{UserID: "user-1", Name: "First", CreatedAt: now}, 2)
+			if err != nil {
+				t.Fatalf("CreateWithinLimit() error = %v", err)
+			}
+			if got, _ := repo.Get(ctx, id); got == nil || got.Name != "First" || got.Revision != 1 {
+				t.Errorf("Get() = %+v, want First at revision 1", got)
+			}
+			if _, err := repo.CreateWithinLimit(ctx, Subscription{UserID: "user-1", Name: "Second", CreatedAt: now}, 2); err != nil {
+				t.Fatalf("CreateWithinLimit() error = %v", err)
+			}
+			if _, err := repo.CreateWithinLimit(ctx, Subscription{UserID: "user-1", Name: "Third", CreatedAt: now}, 2); !errors.Is(err, ErrLimitExceeded) {
+				t.Errorf("CreateWithinLimit(over limit) error = %v, want ErrLimitExceeded", err)
+			}
+			if subs, _ := repo.ListByUserID(ctx, "user-1"); len(subs) != 3 {
+				t.Errorf("ListByUserID() returned %d subscriptions, want 3", len(subs))
+			}
+
+			unique := Subscription{UserID: "user-1", Name: "First", CreatedAt: now, UniqueName: true}
+			if _, err := repo.CreateWithinLimit(ctx, unique, 10); !errors.Is(err, ErrNameTaken) {
+				t.Errorf("CreateWithinLimit(taken name) error = %v, want ErrNameTaken", err)
+			}
+			unique.TenantID = "other"
+			unique.Name = "Other tenant"
+			if _, err := repo.CreateWithinLimit(ctx, unique, 10); !errors.Is(err, ErrNameTaken) {
+				t.Errorf("CreateWithinLimit(taken name in other tenant) error = %v, want ErrNameTaken", err)
+			}
+			unique.Name = "First"
+			if _, err := repo.CreateWithinLimit(ctx, unique, 10); err != nil {
+				t.Errorf("CreateWithinLimit(name free in tenant) error = %v", err)
+			}
+		})
+	}
+}
+
+func TestSQLRepository_Revision(t *testing.T) {
+	for dialect, client := range openTestSQL(t) {
+		t.Run(dialect, func(t *testing.T) {
+			ctx := context.Background()
+			repo := NewSQLRepository(client)
+
+			id, err := repo.Create(ctx, Subscription{UserID: "user-1", Name: "Alerts", CreatedAt: time.Now().UTC()})
+			if err != nil {
+				t.Fatal(err)
+			}
+			read, _ := repo.Get(ctx, id)
+			if read.Revision != 1 {
+				t.Fatalf("Revision after Create = %d, want 1", read.Revision)
+			}
+
+			first, second := *read, *read
+			first.Name = "First"
+			second.Name = "Second"
+			if err := repo.Update(ctx, id, first); err != nil {
+				t.Fatalf("Update() error = %v", err)
+			}
+			if err := repo.Update(ctx, id, second); !errors.Is(err, ErrRevisionConflict) {
+				t.Errorf("Update(stale) error = %v, want ErrRevisionConflict", err)
+			}
+			if got, _ := repo.Get(ctx, id); got.Name != "First" || got.Revision != 2 {
+				t.Errorf("Get() = %+v, want the first write at revision 2", got)
+			}
+			if err := repo.Update(ctx, "missing", first); !errors.Is(err, ErrNotFound) {
+				t.Errorf("Update(missing) error = %v, want ErrNotFound", err)
+			}
+		})
+	}
+}
+
+func TestSQLRepository_ConcurrentUpdatesOfLegacySubscription(t *testing.T) {
+	for dialect, client := range openTestSQL(t) {
+		t.Run(dialect, func(t *testing.T) {
+			ctx := context.Background()
+			repo := NewSQLRepository(client)
+
+			id, err := repo.Create(ctx, Subscription{UserID: "user-1", Name: "Alerts", CreatedAt: time.Now().UTC()})
+			if err != nil {
+				t.Fatal(err)
+			}
+			// Stored before revisions existed
+			legacy, _ := repo.Get(ctx, id)
+			legacy.Revision = 0
+			data, _ := encodeSubscription(*legacy)
+			if _, err := client.DB().ExecContext(ctx, client.Rebind(`UPDATE subscriptions SET data = ? WHERE id = ?`), data, id); err != nil {
+				t.Fatal(err)
+			}
+
+			read, _ := repo.Get(ctx, id)
+			if read.Revision != 0 {
+				t.Fatalf("Revision = %d, want 0", read.Revision)
+			}
+			errs := make(chan error, 2)
+			for _, name := range []string{"First", "Second"} {
+				go func() {
+					sub := *read
+					sub.Name = name
+					sub.ExpectRevision = true
+					errs <- repo.Update(ctx, id, sub)
+				}()
+			}
+			var conflicts int
+			for range 2 {
+				if err := <-errs; errors.Is(err, ErrRevisionConflict) {
+					conflicts++
+				} else if err != nil {
+					t.Fatalf("Update() error = %v", err)
+				}
+			}
+			if conflicts != 1 {
+				t.Errorf("conflicts = %d, want exactly one of the two writes of revision 0 to lose", conflicts)
+			}
+			if got, _ := repo.Get(ctx, id); got.Revision != 1 {
+				t.Errorf("Revision = %d, want 1", got.Revision)
+			}
+		})
+	}
+}
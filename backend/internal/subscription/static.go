@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sync"
 
 	"github.com/otiai10/namazu/backend/internal/config"
 )
@@ -13,7 +14,9 @@ var ErrReadOnly = errors.New("static repository is read-only")
 
 // StaticRepository is a repository that loads subscriptions from config.
 // Used for Phase 1 (YAML-based configuration).
+// The subscriptions can be swapped with Replace when the config is reloaded.
 type StaticRepository struct {
+	mu            sync.RWMutex
 	subscriptions []Subscription
 }
 
@@ -26,24 +29,84 @@ type StaticRepository struct {
 // Returns:
 //   - StaticRepository instance with subscriptions loaded from config
 func NewStaticRepository(cfg *config.Config) *StaticRepository {
-	subs := make([]Subscription, len(cfg.Subscriptions))
-	for i, sub := range cfg.Subscriptions {
-		subs[i] = Subscription{
-			Name: sub.Name,
-			Delivery: DeliveryConfig{
-				Type:   sub.Delivery.Type,
-				URL:    sub.Delivery.URL,
-				Secret: sub.Delivery.Secret,
-			},
+	return &StaticRepository{subscriptions: fromConfigs(cfg.Subscriptions)}
+}
+
+// Replace swaps the subscriptions for those of cfg, as on a config reload.
+// Lists already returned are not affected.
+func (r *StaticRepository) Replace(cfg *config.Config) {
+	subs := fromConfigs(cfg.Subscriptions)
+	r.mu.Lock()
+	r.subscriptions = subs
+	r.mu.Unlock()
+}
+
+// fromConfigs converts the subscriptions of the static config
+func fromConfigs(configs []config.SubscriptionConfig) []Subscription {
+	subs := make([]Subscription, len(configs))
+	for i, sub := range configs {
+		subs[i] = FromConfig(sub)
+	}
+	return subs
+}
+
+// FromConfig converts a subscription of the static config (or of an import) to a Subscription
+func FromConfig(c config.SubscriptionConfig) Subscription {
+	sub := Subscription{
+		Name:   c.Name,
+		Locale: c.Locale,
+		Delivery: DeliveryConfig{
+			Type:   c.Delivery.Type,
+			URL:    c.Delivery.URL,
+			Secret: c.Delivery.Secret,
+		},
+	}
+	if c.Filter != nil {
+		sub.Filter = &FilterConfig{
+			MinScale:    c.Filter.MinScale,
+			Prefectures: append([]string(nil), c.Filter.Prefectures...),
+			EventTypes:  append([]string(nil), c.Filter.EventTypes...),
+			EEW:         c.Filter.EEW,
+
+			MinMagnitude:           c.Filter.MinMagnitude,
+			MaxDepthKm:             c.Filter.MaxDepthKm,
+			HypocenterNameContains: c.Filter.HypocenterNameContains,
 		}
-		if sub.Filter != nil {
-			subs[i].Filter = &FilterConfig{
-				MinScale:    sub.Filter.MinScale,
-				Prefectures: sub.Filter.Prefectures,
-			}
+		if g := c.Filter.Geofence; g != nil {
+			sub.Filter.Geofence = &Geofence{Lat: g.Lat, Lon: g.Lon, RadiusKm: g.RadiusKm}
 		}
 	}
-	return &StaticRepository{subscriptions: subs}
+	return sub
+}
+
+// ToConfig converts a Subscription to the static config format.
+// Settings the static config has no field for (quiet hours, throttle, headers, ...) are dropped.
+func ToConfig(sub Subscription) config.SubscriptionConfig {
+	c := config.SubscriptionConfig{
+		Name:   sub.Name,
+		Locale: sub.Locale,
+		Delivery: config.DeliveryConfig{
+			Type:   sub.Delivery.Type,
+			URL:    sub.Delivery.URL,
+			Secret: sub.Delivery.Secret,
+		},
+	}
+	if sub.Filter != nil {
+		c.Filter = &config.FilterConfig{
+			MinScale:    sub.Filter.MinScale,
+			Prefectures: append([]string(nil), sub.Filter.Prefectures...),
+			EventTypes:  append([]string(nil), sub.Filter.EventTypes...),
+			EEW:         sub.Filter.EEW,
+
+			MinMagnitude:           sub.Filter.MinMagnitude,
+			MaxDepthKm:             sub.Filter.MaxDepthKm,
+			HypocenterNameContains: sub.Filter.HypocenterNameContains,
+		}
+		if g := sub.Filter.Geofence; g != nil {
+			c.Filter.Geofence = &config.GeofenceConfig{Lat: g.Lat, Lon: g.Lon, RadiusKm: g.RadiusKm}
+		}
+	}
+	return c
 }
 
 // Ensure StaticRepository implements Repository interface
@@ -59,6 +122,8 @@ var _ Repository = (*StaticRepository)(nil)
 //   - Copy of all subscriptions
 //   - Error (always nil for static repository)
 func (r *StaticRepository) List(ctx context.Context) ([]Subscription, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
 	// Return a copy to prevent mutation
 	result := make([]Subscription, len(r.subscriptions))
 	copy(result, r.subscriptions)
@@ -78,6 +143,15 @@ func (r *StaticRepository) Create(ctx context.Context, sub Subscription) (string
 	return "", ErrReadOnly
 }
 
+// CreateWithinLimit is not supported for StaticRepository (read-only).
+//
+// Returns:
+//   - Empty string
+//   - ErrReadOnly error
+func (r *StaticRepository) CreateWithinLimit(ctx context.Context, sub Subscription, limit int) (string, error) {
+	return "", ErrReadOnly
+}
+
 // Get retrieves a subscription by ID from the static list.
 //
 // Parameters:
@@ -88,6 +162,8 @@ func (r *StaticRepository) Create(ctx context.Context, sub Subscription) (string
 //   - Pointer to the subscription (nil if not found)
 //   - Error (always nil for static repository)
 func (r *StaticRepository) Get(ctx context.Context, id string) (*Subscription, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
 	for _, sub := range r.subscriptions {
 		if sub.ID == id {
 			// Return a copy to prevent mutation
@@ -95,6 +171,7 @@ func (r *StaticRepository) Get(ctx context.Context, id string) (*Subscription, e
 				ID:     sub.ID,
 				UserID: sub.UserID,
 				Name:   sub.Name,
+				Locale: sub.Locale,
 				Delivery: DeliveryConfig{
 					Type:   sub.Delivery.Type,
 					URL:    sub.Delivery.URL,
@@ -107,6 +184,13 @@ func (r *StaticRepository) Get(ctx context.Context, id string) (*Subscription, e
 				result.Filter = &FilterConfig{
 					MinScale:    sub.Filter.MinScale,
 					Prefectures: prefectures,
+					EventTypes:  append([]string(nil), sub.Filter.EventTypes...),
+					EEW:         sub.Filter.EEW,
+
+					MinMagnitude:           sub.Filter.MinMagnitude,
+					MaxDepthKm:             sub.Filter.MaxDepthKm,
+					HypocenterNameContains: sub.Filter.HypocenterNameContains,
+					Geofence:               sub.Filter.Geofence.Copy(),
 				}
 			}
 			return &result, nil
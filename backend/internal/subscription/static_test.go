@@ -264,6 +264,34 @@ func TestStaticRepository_List(t *testing.T) {
 	})
 }
 
+func TestStaticRepository_Replace(t *testing.T) {
+	repo := NewStaticRepository(&config.Config{
+		Subscriptions: []config.SubscriptionConfig{
+			{Name: "Old", Delivery: config.DeliveryConfig{Type: "webhook", URL: "https://old.example.com"}},
+		},
+	})
+	ctx := context.Background()
+	before, _ := repo.List(ctx)
+
+	repo.Replace(&config.Config{
+		Subscriptions: []config.SubscriptionConfig{
+			{Name: "New 1", Delivery: config.DeliveryConfig{Type: "webhook", URL: "https://new1.example.com"}},
+			{Name: "New 2", Delivery: config.DeliveryConfig{Type: "webhook", URL: "https://new2.example.com"}},
+		},
+	})
+
+	subs, err := repo.List(ctx)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(subs) != 2 || subs[0].Name != "New 1" || subs[1].Name != "New 2" {
+		t.Errorf("Expected the replaced subscriptions, got %+v", subs)
+	}
+	if len(before) != 1 || before[0].Name != "Old" {
+		t.Errorf("Expected an earlier list to be unaffected, got %+v", before)
+	}
+}
+
 func TestStaticRepository_Create(t *testing.T) {
 	t.Run("returns read-only error", func(t *testing.T) {
 		cfg := &config.Config{
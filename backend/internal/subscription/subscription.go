@@ -2,41 +2,278 @@ package subscription
 
 import (
 	"context"
+	"time"
+
+	"github.com/otiai10/namazu/backend/internal/source"
 )
 
 // Subscription represents a notification subscription
 type Subscription struct {
 	ID       string         `json:"id,omitempty"`
-	UserID   string         `json:"userId,omitempty"` // Owner's user ID
+	UserID   string         `json:"userId,omitempty"`   // Owner's user ID
+	TenantID string         `json:"tenantId,omitempty"` // White-label tenant (empty for the default tenant)
 	Name     string         `json:"name"`
 	Delivery DeliveryConfig `json:"delivery"`
 	Filter   *FilterConfig  `json:"filter,omitempty"`
+	Locale   string         `json:"locale,omitempty"` // Language of notification text (see package i18n); empty means Japanese
+
+	QuietHours *QuietHours     `json:"quiet_hours,omitempty"` // Optional; see QuietHours.Allows
+	Throttle   *ThrottleConfig `json:"throttle,omitempty"`    // Optional; limits deliveries during swarms
+	Digest     *DigestConfig   `json:"digest,omitempty"`      // Optional; batches events into periodic summaries
+
+	// Lifecycle
+	CreatedAt       time.Time  `json:"created_at,omitempty"`
+	ExpiresAt       *time.Time `json:"expires_at,omitempty"`        // Optional; deliveries stop afterwards
+	Status          string     `json:"status,omitempty"`            // StatusActive (or empty) | StatusWarned | StatusSuspended
+	StatusReason    string     `json:"status_reason,omitempty"`     // ReasonInactive | ReasonExpiring | ReasonExpired | ReasonFailing
+	StatusChangedAt *time.Time `json:"status_changed_at,omitempty"` // Last lifecycle transition
+	PausedAt        *time.Time `json:"paused_at,omitempty"`         // Set while the owner has paused deliveries
+
+	// Concurrency control: Revision counts the writes of the subscription
+	// (1 when created, 0 for subscriptions stored before revisions existed),
+	// and UpdatedAt is the time of its last change through the API
+	Revision  int64     `json:"revision,omitempty"`
+	UpdatedAt time.Time `json:"updated_at,omitempty"`
+
+	// ExpectRevision makes Update compare Revision with the stored revision
+	// even when it is 0, as read from a subscription stored before revisions
+	// existed. It is not stored.
+	ExpectRevision bool `json:"-"`
+
+	// UniqueName makes CreateWithinLimit fail with ErrNameTaken if the owner
+	// already has a subscription named Name in its tenant. It is not stored.
+	UniqueName bool `json:"-"`
+}
+
+// ChecksRevision reports whether Update must compare sub.Revision with the
+// stored revision: when it is set, or explicitly expected
+func (s Subscription) ChecksRevision() bool {
+	return s.ExpectRevision || s.Revision != 0
+}
+
+// Lifecycle states of a subscription
+const (
+	StatusActive    = "active"    // Receives deliveries
+	StatusWarned    = "warned"    // The owner was warned; deliveries continue
+	StatusSuspended = "suspended" // Deliveries stopped until the owner reactivates it
+)
+
+// Reasons for a lifecycle state
+const (
+	ReasonInactive  = "inactive"   // No successful delivery or owner login for too long
+	ReasonExpiring  = "expiring"   // ExpiresAt is near
+	ReasonExpired   = "expired"    // ExpiresAt has passed
+	ReasonFailing   = "failing"    // Every delivery failed for too long
+	ReasonOverQuota = "over_quota" // Over the owner's plan limit after a downgrade
+	ReasonOrphaned  = "orphaned"   // Archived by `namazu backfill-owners` for having no owner
+)
+
+// IsExpired reports whether the subscription has an expiry at or before now
+func (s Subscription) IsExpired(now time.Time) bool {
+	return s.ExpiresAt != nil && !now.Before(*s.ExpiresAt)
+}
+
+// IsPaused reports whether the owner has paused deliveries.
+// Pausing is independent of the lifecycle status: a paused subscription keeps
+// its status and delivery history, and resuming restores deliveries.
+func (s Subscription) IsPaused() bool {
+	return s.PausedAt != nil
+}
+
+// Deliverable reports whether the subscription should receive deliveries at now:
+// it is neither paused, suspended nor expired.
+func (s Subscription) Deliverable(now time.Time) bool {
+	return !s.IsPaused() && s.Status != StatusSuspended && !s.IsExpired(now)
 }
 
 // DeliveryConfig represents how to deliver notifications
 type DeliveryConfig struct {
-	Type         string       `json:"type"` // "webhook" | "email" | "slack"
-	URL          string       `json:"url,omitempty"`
-	Secret       string       `json:"secret,omitempty"`
-	SecretPrefix string       `json:"secret_prefix,omitempty" firestore:"secret_prefix,omitempty"`
-	Verified     bool         `json:"verified" firestore:"verified"`
-	SignVersion  string       `json:"sign_version,omitempty" firestore:"sign_version,omitempty"`
-	Retry        *RetryConfig `json:"retry,omitempty" firestore:"retry,omitempty"`
+	Type                    string            `json:"type"` // "webhook" | "sns" | "sqs" | "sms" | "email" | "slack"
+	URL                     string            `json:"url,omitempty"`
+	Secret                  string            `json:"secret,omitempty"`
+	SecretPrefix            string            `json:"secret_prefix,omitempty" firestore:"secret_prefix,omitempty"`
+	PreviousSecret          string            `json:"previous_secret,omitempty" firestore:"previous_secret,omitempty"` // Replaced by the last rotation; still signs until PreviousSecretExpiresAt
+	PreviousSecretExpiresAt *time.Time        `json:"previous_secret_expires_at,omitempty" firestore:"previous_secret_expires_at,omitempty"`
+	Verified                bool              `json:"verified" firestore:"verified"`
+	VerificationSkipped     bool              `json:"verification_skipped,omitempty" firestore:"verification_skipped,omitempty"` // Delivered without passing URL verification; see plan.Features.SkipVerification
+	SignVersion             string            `json:"sign_version,omitempty" firestore:"sign_version,omitempty"`
+	Retry                   *RetryConfig      `json:"retry,omitempty" firestore:"retry,omitempty"`
+	ServiceNotices          bool              `json:"service_notices,omitempty" firestore:"service_notices,omitempty"` // Opt-in to operational notices
+	Template                string            `json:"template,omitempty" firestore:"template,omitempty"`               // Optional Go template for the body; see package transform
+	Enrich                  bool              `json:"enrich,omitempty" firestore:"enrich,omitempty"`                   // Add derived fields to webhook bodies; see package enrich
+	Format                  string            `json:"format,omitempty" firestore:"format,omitempty"`                   // Webhook body format: "raw" (default) or "namazu.v1"; see package schema
+	Headers                 map[string]string `json:"headers,omitempty" firestore:"headers,omitempty"`                 // Custom request headers; see webhook.ValidateHeaders
+	AWS                     *AWSConfig        `json:"aws,omitempty" firestore:"aws,omitempty"`                         // Required for "sns" and "sqs"
+	SMS                     *SMSConfig        `json:"sms,omitempty" firestore:"sms,omitempty"`                         // Required for "sms"
+}
+
+// AwaitingVerification reports whether deliveries wait for the URL verification
+// challenge: v0 webhooks are delivered once it passed or when it was skipped.
+// Legacy webhooks (no sign version) predate verification and are delivered.
+func (d DeliveryConfig) AwaitingVerification() bool {
+	return d.SignVersion == "v0" && !d.Verified && !d.VerificationSkipped
+}
+
+// ActivePreviousSecret returns the secret replaced by the last rotation while
+// its grace period lasts at now, or "" if there is none or it has expired
+func (d DeliveryConfig) ActivePreviousSecret(now time.Time) string {
+	if d.PreviousSecret == "" || d.PreviousSecretExpiresAt == nil || !now.Before(*d.PreviousSecretExpiresAt) {
+		return ""
+	}
+	return d.PreviousSecret
 }
 
-// RetryConfig holds retry settings for delivery.
-// This is a copy of webhook.RetryConfig to avoid circular imports.
-type RetryConfig struct {
-	Enabled    bool `json:"enabled" firestore:"enabled"`
-	MaxRetries int  `json:"max_retries" firestore:"max_retries"`
-	InitialMs  int  `json:"initial_ms" firestore:"initial_ms"`
-	MaxMs      int  `json:"max_ms" firestore:"max_ms"`
+// CopyHeaders returns a copy of custom delivery headers, or nil if there are none
+func CopyHeaders(headers map[string]string) map[string]string {
+	if len(headers) == 0 {
+		return nil
+	}
+	copied := make(map[string]string, len(headers))
+	for name, value := range headers {
+		copied[name] = value
+	}
+	return copied
 }
 
 // FilterConfig represents event filtering conditions
 type FilterConfig struct {
 	MinScale    int      `json:"min_scale,omitempty"`
 	Prefectures []string `json:"prefectures,omitempty"`
+	EventTypes  []string `json:"event_types,omitempty"` // Empty means DefaultEventTypes
+	EEW         bool     `json:"eew,omitempty"`         // Opt in to Earthquake Early Warnings
+
+	// Hypocenter conditions. Events without a reported hypocenter (or with the
+	// compared value unknown) do not match when any of these is set.
+	MinMagnitude           float64   `json:"min_magnitude,omitempty"`
+	MaxDepthKm             int       `json:"max_depth_km,omitempty"`
+	HypocenterNameContains string    `json:"hypocenter_name_contains,omitempty"`
+	Geofence               *Geofence `json:"geofence,omitempty"`
+}
+
+// Geofence matches events whose hypocenter lies within RadiusKm of (Lat, Lon)
+type Geofence struct {
+	Lat      float64 `json:"lat"`
+	Lon      float64 `json:"lon"`
+	RadiusKm float64 `json:"radius_km"`
+}
+
+// Copy returns a copy of the geofence, or nil if g is nil
+func (g *Geofence) Copy() *Geofence {
+	if g == nil {
+		return nil
+	}
+	copied := *g
+	return &copied
+}
+
+// Validate returns an error message if the geofence is out of range, or "" if valid
+func (g *Geofence) Validate() string {
+	if g.Lat < -90 || g.Lat > 90 {
+		return "geofence.lat must be between -90 and 90"
+	}
+	if g.Lon < -180 || g.Lon > 180 {
+		return "geofence.lon must be between -180 and 180"
+	}
+	if g.RadiusKm <= 0 {
+		return "geofence.radius_km must be positive"
+	}
+	return ""
+}
+
+// Dedupe modes of ThrottleConfig.DedupeBy
+const (
+	DedupeBySubscription = ""           // One window for all events of the subscription
+	DedupeByEventID      = "event_id"   // One window per event ID (repeated reports of an event)
+	DedupeByHypocenter   = "hypocenter" // One window per hypocenter name (e.g. a swarm off 能登)
+)
+
+// MaxThrottleIntervalMinutes bounds ThrottleConfig.IntervalMinutes
+const MaxThrottleIntervalMinutes = 24 * 60
+
+// ThrottleConfig limits a subscription to one delivery per interval.
+// An event more severe than the last delivered one is always delivered.
+type ThrottleConfig struct {
+	IntervalMinutes int    `json:"interval_minutes"`
+	DedupeBy        string `json:"dedupe_by,omitempty"` // DedupeBySubscription | DedupeByEventID | DedupeByHypocenter
+}
+
+// Interval returns IntervalMinutes as a duration
+func (t *ThrottleConfig) Interval() time.Duration {
+	return time.Duration(t.IntervalMinutes) * time.Minute
+}
+
+// Copy returns a copy of the throttle, or nil if t is nil
+func (t *ThrottleConfig) Copy() *ThrottleConfig {
+	if t == nil {
+		return nil
+	}
+	copied := *t
+	return &copied
+}
+
+// Validate returns an error message if the throttle is invalid, or "" if valid
+func (t *ThrottleConfig) Validate() string {
+	if t.IntervalMinutes <= 0 || t.IntervalMinutes > MaxThrottleIntervalMinutes {
+		return "throttle.interval_minutes must be between 1 and 1440"
+	}
+	switch t.DedupeBy {
+	case DedupeBySubscription, DedupeByEventID, DedupeByHypocenter:
+		return ""
+	default:
+		return "unknown throttle.dedupe_by: " + t.DedupeBy
+	}
+}
+
+// MaxDigestIntervalMinutes bounds DigestConfig.IntervalMinutes
+const MaxDigestIntervalMinutes = 24 * 60
+
+// DigestConfig switches a subscription to digest mode: matching events are
+// collected and delivered as one summary every IntervalMinutes, counted from
+// the first collected event.
+type DigestConfig struct {
+	IntervalMinutes int `json:"interval_minutes"`
+}
+
+// Interval returns IntervalMinutes as a duration
+func (d *DigestConfig) Interval() time.Duration {
+	return time.Duration(d.IntervalMinutes) * time.Minute
+}
+
+// Copy returns a copy of the digest config, or nil if d is nil
+func (d *DigestConfig) Copy() *DigestConfig {
+	if d == nil {
+		return nil
+	}
+	copied := *d
+	return &copied
+}
+
+// Validate returns an error message if the digest config is invalid, or "" if valid
+func (d *DigestConfig) Validate() string {
+	if d.IntervalMinutes <= 0 || d.IntervalMinutes > MaxDigestIntervalMinutes {
+		return "digest.interval_minutes must be between 1 and 1440"
+	}
+	return ""
+}
+
+// DefaultEventTypes are delivered to subscriptions that don't select event types.
+// Subscriptions created before type selection existed only received earthquakes.
+var DefaultEventTypes = []string{string(source.EventTypeEarthquake)}
+
+// KnownEventTypes lists the event types a subscription can select
+var KnownEventTypes = []string{
+	string(source.EventTypeEarthquake),
+	string(source.EventTypeTsunami),
+}
+
+// IsKnownEventType reports whether t is a selectable event type
+func IsKnownEventType(t string) bool {
+	for _, known := range KnownEventTypes {
+		if t == known {
+			return true
+		}
+	}
+	return false
 }
 
 // Repository defines the interface for subscription storage
@@ -47,15 +284,24 @@ type Repository interface {
 	// ListByUserID returns all subscriptions for a specific user
 	ListByUserID(ctx context.Context, userID string) ([]Subscription, error)
 
-	// Create creates a new subscription and returns its ID
+	// Create creates a new subscription at revision 1 and returns its ID
 	Create(ctx context.Context, sub Subscription) (string, error)
 
+	// CreateWithinLimit is Create unless the owner (sub.UserID) already has limit
+	// or more subscriptions in sub.TenantID. Counting and creating are atomic, so
+	// concurrent creates cannot exceed the limit, nor both take a name when
+	// sub.UniqueName is set.
+	// Returns ErrLimitExceeded if the limit is reached, ErrNameTaken if the name is
+	// taken
+	CreateWithinLimit(ctx context.Context, sub Subscription, limit int) (string, error)
+
 	// Get retrieves a subscription by ID
 	// Returns nil and no error if not found
 	Get(ctx context.Context, id string) (*Subscription, error)
 
-	// Update updates an existing subscription
-	// Returns error if subscription does not exist
+	// Update updates an existing subscription and increments its revision
+	// Returns ErrNotFound if subscription does not exist, and ErrRevisionConflict
+	// if sub.ChecksRevision() and sub.Revision is not the stored revision (it changed since read)
 	Update(ctx context.Context, id string, sub Subscription) error
 
 	// Delete removes a subscription by ID
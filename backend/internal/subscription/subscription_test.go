@@ -3,6 +3,7 @@ package subscription
 import (
 	"encoding/json"
 	"testing"
+	"time"
 )
 
 func TestDeliveryConfig_ZeroValue_BackwardCompatible(t *testing.T) {
@@ -197,3 +198,103 @@ func TestDocumentToSubscription_ParsesNewDeliveryFields(t *testing.T) {
 		}
 	})
 }
+
+func TestSubscription_Deliverable(t *testing.T) {
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	past := now.Add(-time.Hour)
+	future := now.Add(time.Hour)
+
+	tests := []struct {
+		name        string
+		sub         Subscription
+		wantExpired bool
+		want        bool
+	}{
+		{"legacy (no status)", Subscription{}, false, true},
+		{"active", Subscription{Status: StatusActive}, false, true},
+		{"warned", Subscription{Status: StatusWarned}, false, true},
+		{"suspended", Subscription{Status: StatusSuspended}, false, false},
+		{"paused", Subscription{Status: StatusActive, PausedAt: &past}, false, false},
+		{"expires later", Subscription{ExpiresAt: &future}, false, true},
+		{"expired", Subscription{ExpiresAt: &past}, true, false},
+		{"expires now", Subscription{ExpiresAt: &now}, true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.sub.IsExpired(now); got != tt.wantExpired {
+				t.Errorf("IsExpired() = %v, want %v", got, tt.wantExpired)
+			}
+			if got := tt.sub.Deliverable(now); got != tt.want {
+				t.Errorf("Deliverable() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestThrottleConfig_Validate(t *testing.T) {
+	tests := []struct {
+		throttle ThrottleConfig
+		valid    bool
+	}{
+		{ThrottleConfig{IntervalMinutes: 10}, true},
+		{ThrottleConfig{IntervalMinutes: 10, DedupeBy: DedupeByEventID}, true},
+		{ThrottleConfig{IntervalMinutes: MaxThrottleIntervalMinutes, DedupeBy: DedupeByHypocenter}, true},
+		{ThrottleConfig{}, false},
+		{ThrottleConfig{IntervalMinutes: MaxThrottleIntervalMinutes + 1}, false},
+		{ThrottleConfig{IntervalMinutes: 10, DedupeBy: "region"}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.throttle.Validate() == ""; got != tt.valid {
+			t.Errorf("Validate(%+v) valid = %v, want %v", tt.throttle, got, tt.valid)
+		}
+	}
+}
+
+func TestDigestConfig_Validate(t *testing.T) {
+	for _, tt := range []struct {
+		interval int
+		valid    bool
+	}{
+		{60, true},
+		{MaxDigestIntervalMinutes, true},
+		{0, false},
+		{MaxDigestIntervalMinutes + 1, false},
+	} {
+		d := DigestConfig{IntervalMinutes: tt.interval}
+		if got := d.Validate() == ""; got != tt.valid {
+			t.Errorf("Validate(%d) valid = %v, want %v", tt.interval, got, tt.valid)
+		}
+	}
+}
+
+func TestDeliveryConfig_ActivePreviousSecret(t *testing.T) {
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	past := now.Add(-time.Hour)
+	future := now.Add(time.Hour)
+
+	tests := []struct {
+		name     string
+		delivery DeliveryConfig
+		want     string
+	}{
+		{"never rotated", DeliveryConfig{Secret: "new"}, ""},
+		{"in grace period", DeliveryConfig{PreviousSecret: "old", PreviousSecretExpiresAt: &future}, "old"},
+		{"grace period over", DeliveryConfig{PreviousSecret: "old", PreviousSecretExpiresAt: &past}, ""},
+		{"ends now", DeliveryConfig{PreviousSecret: "old", PreviousSecretExpiresAt: &now}, ""},
+		{"no expiry", DeliveryConfig{PreviousSecret: "old"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.delivery.ActivePreviousSecret(now); got != tt.want {
+				t.Errorf("ActivePreviousSecret() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	expiresAt := future
+	data := subscriptionToMap(Subscription{Delivery: DeliveryConfig{Type: "webhook", PreviousSecret: "old", PreviousSecretExpiresAt: &expiresAt}})
+	delivery := data["delivery"].(map[string]interface{})
+	if delivery["previous_secret"] != "old" || delivery["previous_secret_expires_at"] != future {
+		t.Errorf("expected the previous secret to be stored, got %v", delivery)
+	}
+}
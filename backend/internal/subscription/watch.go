@@ -0,0 +1,195 @@
+package subscription
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+// Reconnection delays of WatchedRepository
+const (
+	watchInitialDelay = time.Second
+	watchMaxDelay     = time.Minute
+)
+
+// Watch listens to the subscriptions collection and calls fn with the full
+// set of subscriptions on every change, starting with the current set.
+// It blocks until ctx is done or the listener fails; the Firestore client
+// already retries transient errors, so an error means the listener must be restarted.
+func (r *FirestoreRepository) Watch(ctx context.Context, fn func([]Subscription)) error {
+	it := r.client.Collection(collectionName).Snapshots(ctx)
+	defer it.Stop()
+
+	for {
+		snap, err := it.Next()
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return fmt.Errorf("subscription listener failed: %w", err)
+		}
+		docs, err := snap.Documents.GetAll()
+		if err != nil {
+			return fmt.Errorf("failed to read subscription snapshot: %w", err)
+		}
+
+		subscriptions := make([]Subscription, 0, len(docs))
+		for _, doc := range docs {
+			sub, err := r.toSubscription(ctx, doc)
+			if err != nil {
+				return fmt.Errorf("failed to convert document %s: %w", doc.Ref.ID, err)
+			}
+			subscriptions = append(subscriptions, sub)
+		}
+		fn(subscriptions)
+	}
+}
+
+// Watcher pushes the full set of subscriptions on every change, see FirestoreRepository.Watch
+type Watcher interface {
+	Watch(ctx context.Context, fn func([]Subscription)) error
+}
+
+// WatchStats reports the health of a WatchedRepository's listener
+type WatchStats struct {
+	Connected      bool       `json:"connected"`                  // Serving List from the listener
+	Subscriptions  int        `json:"subscriptions"`              // Subscriptions held in memory
+	LastSnapshotAt *time.Time `json:"last_snapshot_at,omitempty"` // When the set last changed
+	Reconnects     int64      `json:"reconnects"`                 // Listener restarts since start
+	LastError      string     `json:"last_error,omitempty"`       // Why the listener last failed
+}
+
+// WatchedRepository holds an always-current set of subscriptions, pushed by a
+// Watcher, so that List does not read the store on every event.
+// Until the first snapshot arrives, and while the listener is reconnecting,
+// List reads the wrapped repository instead. Other calls are passed through;
+// writes reach List through the listener, typically within a second.
+type WatchedRepository struct {
+	repo    Repository
+	watcher Watcher
+
+	mu    sync.RWMutex
+	subs  []Subscription // nil while not connected
+	stats WatchStats
+}
+
+// Compile-time interface check
+var _ Repository = (*WatchedRepository)(nil)
+
+// NewWatchedRepository serves List of repo from watcher once Run is started
+func NewWatchedRepository(repo Repository, watcher Watcher) *WatchedRepository {
+	return &WatchedRepository{repo: repo, watcher: watcher}
+}
+
+// Run keeps the listener running until ctx is done, restarting it with
+// exponential backoff (1s to 1 minute, jittered) when it fails
+func (r *WatchedRepository) Run(ctx context.Context) {
+	for attempt := 0; ; attempt++ {
+		started := time.Now()
+		err := r.watcher.Watch(ctx, r.update)
+		r.disconnect(err)
+		if ctx.Err() != nil {
+			return
+		}
+		// A listener that ran for a while starts over from the shortest delay
+		if time.Since(started) > watchMaxDelay {
+			attempt = 0
+		}
+		delay := watchBackoff(attempt)
+		log.Printf("Subscription listener stopped, restarting in %v: %v", delay, err)
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// List returns all subscriptions from memory, or from the wrapped repository
+// while the listener is not connected
+func (r *WatchedRepository) List(ctx context.Context) ([]Subscription, error) {
+	r.mu.RLock()
+	subs := r.subs
+	r.mu.RUnlock()
+	if subs == nil {
+		return r.repo.List(ctx)
+	}
+	return copySubscriptions(subs), nil
+}
+
+// ListByUserID returns all subscriptions for a specific user
+func (r *WatchedRepository) ListByUserID(ctx context.Context, userID string) ([]Subscription, error) {
+	return r.repo.ListByUserID(ctx, userID)
+}
+
+// Create creates a new subscription
+func (r *WatchedRepository) Create(ctx context.Context, sub Subscription) (string, error) {
+	return r.repo.Create(ctx, sub)
+}
+
+// CreateWithinLimit creates a subscription within the owner's limit
+func (r *WatchedRepository) CreateWithinLimit(ctx context.Context, sub Subscription, limit int) (string, error) {
+	return r.repo.CreateWithinLimit(ctx, sub, limit)
+}
+
+// Get retrieves a subscription by ID
+func (r *WatchedRepository) Get(ctx context.Context, id string) (*Subscription, error) {
+	return r.repo.Get(ctx, id)
+}
+
+// Update updates an existing subscription
+func (r *WatchedRepository) Update(ctx context.Context, id string, sub Subscription) error {
+	return r.repo.Update(ctx, id, sub)
+}
+
+// Delete removes a subscription by ID
+func (r *WatchedRepository) Delete(ctx context.Context, id string) error {
+	return r.repo.Delete(ctx, id)
+}
+
+// Stats returns the health of the listener
+func (r *WatchedRepository) Stats() WatchStats {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.stats
+}
+
+// update replaces the set of subscriptions with a snapshot
+func (r *WatchedRepository) update(subs []Subscription) {
+	now := time.Now()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.subs = copySubscriptions(subs)
+	r.stats.Connected = true
+	r.stats.Subscriptions = len(subs)
+	r.stats.LastSnapshotAt = &now
+}
+
+// disconnect falls back to the wrapped repository after the listener stopped.
+// The set in memory may already be stale, so it is dropped.
+func (r *WatchedRepository) disconnect(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.subs = nil
+	r.stats.Connected = false
+	if err != nil && !errors.Is(err, context.Canceled) {
+		r.stats.Reconnects++
+		r.stats.LastError = err.Error()
+	}
+}
+
+// watchBackoff returns the delay before restart attempt n (0-based):
+// exponential and capped, randomized between half and the full delay
+func watchBackoff(attempt int) time.Duration {
+	delay := watchMaxDelay
+	if attempt < 6 {
+		delay = min(watchInitialDelay<<attempt, watchMaxDelay)
+	}
+	half := delay / 2
+	return half + rand.N(half+1)
+}
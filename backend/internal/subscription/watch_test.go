@@ -0,0 +1,131 @@
+package subscription
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// fakeWatcher pushes the snapshots sent on its channel until it is closed,
+// then fails like a broken listener
+type fakeWatcher struct {
+	snapshots chan []Subscription
+	pushed    chan struct{}
+}
+
+func newFakeWatcher() *fakeWatcher {
+	return &fakeWatcher{snapshots: make(chan []Subscription), pushed: make(chan struct{})}
+}
+
+func (w *fakeWatcher) Watch(ctx context.Context, fn func([]Subscription)) error {
+	for {
+		select {
+		case subs, ok := <-w.snapshots:
+			if !ok {
+				return errors.New("listener broken")
+			}
+			fn(subs)
+			w.pushed <- struct{}{}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (w *fakeWatcher) push(subs []Subscription) {
+	w.snapshots <- subs
+	<-w.pushed
+}
+
+func TestWatchedRepository(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	inner := &countingRepository{Repository: NewMemoryRepository()}
+	if _, err := inner.Create(ctx, Subscription{Name: "Stored"}); err != nil {
+		t.Fatal(err)
+	}
+	watcher := newFakeWatcher()
+	repo := NewWatchedRepository(inner, watcher)
+
+	// Before the first snapshot the store is read
+	if subs, err := repo.List(ctx); err != nil || len(subs) != 1 || inner.lists != 1 {
+		t.Fatalf("List() = %v, %v before the listener started", subs, err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		repo.Run(ctx)
+	}()
+	watcher.push([]Subscription{{ID: "a", Name: "Watched"}, {ID: "b"}})
+
+	for range 3 {
+		subs, err := repo.List(ctx)
+		if err != nil || len(subs) != 2 || subs[0].Name != "Watched" {
+			t.Fatalf("List() = %v, %v, want the snapshot", subs, err)
+		}
+		subs[0].Name = "Modified"
+	}
+	if inner.lists != 1 {
+		t.Errorf("lists = %d, want List served from memory", inner.lists)
+	}
+	if stats := repo.Stats(); !stats.Connected || stats.Subscriptions != 2 || stats.LastSnapshotAt == nil {
+		t.Errorf("Stats() = %+v, want a connected listener", stats)
+	}
+
+	// A deleted subscription disappears with the next snapshot
+	watcher.push([]Subscription{})
+	if subs, _ := repo.List(ctx); len(subs) != 0 {
+		t.Errorf("List() = %v, want the empty snapshot", subs)
+	}
+
+	cancel()
+	<-done
+	if stats := repo.Stats(); stats.Connected || stats.Reconnects != 0 {
+		t.Errorf("Stats() = %+v, want a cleanly stopped listener", stats)
+	}
+}
+
+func TestWatchedRepository_ListenerFailure(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	inner := &countingRepository{Repository: NewMemoryRepository()}
+	watcher := newFakeWatcher()
+	repo := NewWatchedRepository(inner, watcher)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		repo.Run(ctx)
+	}()
+	watcher.push([]Subscription{{ID: "a"}})
+	close(watcher.snapshots)
+
+	deadline := time.Now().Add(time.Second)
+	for repo.Stats().Connected && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	stats := repo.Stats()
+	if stats.Connected || stats.Reconnects != 1 || stats.LastError != "listener broken" {
+		t.Errorf("Stats() = %+v, want a failed listener", stats)
+	}
+	// The possibly stale set is dropped until the listener is back
+	if subs, err := repo.List(ctx); err != nil || len(subs) != 0 || inner.lists != 1 {
+		t.Errorf("List() = %v, %v, want the store to be read", subs, err)
+	}
+
+	cancel()
+	<-done
+}
+
+func TestWatchBackoff(t *testing.T) {
+	for attempt, want := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second} {
+		if got := watchBackoff(attempt); got < want/2 || got > want {
+			t.Errorf("watchBackoff(%d) = %v, want between %v and %v", attempt, got, want/2, want)
+		}
+	}
+	if got := watchBackoff(100); got < watchMaxDelay/2 || got > watchMaxDelay {
+		t.Errorf("watchBackoff(100) = %v, want at most %v", got, watchMaxDelay)
+	}
+}
@@ -0,0 +1,5 @@
+package subscription
+
+// DeliveryTypeWebPush notifies every browser the owner registered with
+// POST /api/me/push-subscriptions. It needs no delivery settings of its own.
+const DeliveryTypeWebPush = "webpush"
@@ -0,0 +1,36 @@
+package tenant
+
+import (
+	"context"
+	"net/http"
+)
+
+// contextKey type for context value keys
+type contextKey string
+
+const tenantKey contextKey = "tenant"
+
+// WithTenant adds a tenant to context
+func WithTenant(ctx context.Context, t *Tenant) context.Context {
+	return context.WithValue(ctx, tenantKey, t)
+}
+
+// FromContext retrieves the tenant from context.
+// Returns Default if no tenant was resolved for the request.
+func FromContext(ctx context.Context) *Tenant {
+	if t, ok := ctx.Value(tenantKey).(*Tenant); ok && t != nil {
+		return t
+	}
+	return Default
+}
+
+// Middleware returns middleware that resolves the tenant from the request host
+// and adds it to context.
+func Middleware(reg *Registry) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := WithTenant(r.Context(), reg.Resolve(r.Host))
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package tenant
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestFromContext_Default(t *testing.T) {
+	if got := FromContext(context.Background()); got != Default {
+		t.Errorf("FromContext() = %+v, want Default", got)
+	}
+}
+
+func TestWithTenant(t *testing.T) {
+	acme := &Tenant{ID: "acme"}
+	ctx := WithTenant(context.Background(), acme)
+	if got := FromContext(ctx); got != acme {
+		t.Errorf("FromContext() = %+v, want %+v", got, acme)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	reg := newTestRegistry()
+
+	var got string
+	handler := Middleware(reg)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = FromContext(r.Context()).ID
+	}))
+
+	tests := []struct {
+		host string
+		want string
+	}{
+		{host: "alerts.acme.example", want: "acme"},
+		{host: "namazu.live", want: ""},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/api/tenant", nil)
+		req.Host = tt.host
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+		if got != tt.want {
+			t.Errorf("host %q: tenant = %q, want %q", tt.host, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+// Package tenant provides white-label tenants: partner organizations that
+// serve namazu under their own domain, branding and plan catalog.
+package tenant
+
+import (
+	"net"
+	"strings"
+
+	"github.com/otiai10/namazu/backend/internal/config"
+)
+
+// Plan is a plan offered by a tenant. Unset features are inherited; see
+// package plan for how they resolve.
+type Plan struct {
+	ID               string `json:"id"`
+	Name             string `json:"name"`
+	MaxSubscriptions int    `json:"maxSubscriptions"`
+	Paid             bool   `json:"paid"`   // Whether the plan can be purchased
+	Yearly           bool   `json:"yearly"` // Whether the plan can be billed annually
+
+	// Stripe prices; not exposed to clients
+	PriceID       string   `json:"-"` // Price of new checkouts
+	YearlyPriceID string   `json:"-"` // Price of new annual checkouts
+	PriceIDs      []string `json:"-"` // Other prices that grant the plan
+
+	RetryTier        string   `json:"-"`
+	DeliveryTypes    []string `json:"-"`
+	Digest           *bool    `json:"-"`
+	Geofence         *bool    `json:"-"`
+	SkipVerification *bool    `json:"-"`
+}
+
+// HasPrice reports whether a subscription to the Stripe price grants the plan
+func (p Plan) HasPrice(priceID string) bool {
+	if priceID == "" {
+		return false
+	}
+	if p.PriceID == priceID || p.YearlyPriceID == priceID {
+		return true
+	}
+	for _, id := range p.PriceIDs {
+		if id == priceID {
+			return true
+		}
+	}
+	return false
+}
+
+// NewPlans creates a plan catalog from configuration
+func NewPlans(cfgs []config.PlanConfig) []Plan {
+	var plans []Plan
+	for _, c := range cfgs {
+		plans = append(plans, Plan{
+			ID:               c.ID,
+			Name:             c.Name,
+			MaxSubscriptions: c.MaxSubscriptions,
+			Paid:             c.PriceID != "",
+			Yearly:           c.YearlyPriceID != "",
+			PriceID:          c.PriceID,
+			YearlyPriceID:    c.YearlyPriceID,
+			PriceIDs:         c.PriceIDs,
+			RetryTier:        c.RetryTier,
+			DeliveryTypes:    c.DeliveryTypes,
+			Digest:           c.Digest,
+			Geofence:         c.Geofence,
+			SkipVerification: c.SkipVerification,
+		})
+	}
+	return plans
+}
+
+// Tenant is a white-label partner organization
+type Tenant struct {
+	ID         string
+	Name       string
+	Domains    []string
+	SenderName string // User-Agent of webhook deliveries (empty for the default)
+	EmailFrom  string // From address of notification emails (empty for the default)
+	Plans      []Plan // Plan catalog (empty for the default plans)
+}
+
+// Default is the tenant of requests whose host matches no configured tenant.
+// Its ID is empty so that data created before tenants existed belongs to it.
+var Default = &Tenant{Name: "namazu"}
+
+// SetDefaultPlans replaces the plan catalog of the default tenant, which
+// tenants without their own catalog share
+func SetDefaultPlans(cfgs []config.PlanConfig) {
+	Default.Plans = NewPlans(cfgs)
+}
+
+// IsDefault reports whether t is the default tenant
+func (t *Tenant) IsDefault() bool {
+	return t.ID == ""
+}
+
+// Plan returns the tenant's plan with the given ID
+func (t *Tenant) Plan(id string) (Plan, bool) {
+	for _, p := range t.Plans {
+		if p.ID == id {
+			return p, true
+		}
+	}
+	return Plan{}, false
+}
+
+// PlanForPrice returns the tenant's plan granted by a Stripe price
+func (t *Tenant) PlanForPrice(priceID string) (Plan, bool) {
+	for _, p := range t.Plans {
+		if p.HasPrice(priceID) {
+			return p, true
+		}
+	}
+	return Plan{}, false
+}
+
+// Registry resolves tenants by ID and by request host
+type Registry struct {
+	byID     map[string]*Tenant
+	byDomain map[string]*Tenant
+}
+
+// NewRegistry creates a Registry from configuration.
+// The configuration is expected to be validated (unique IDs and domains).
+func NewRegistry(cfgs []config.TenantConfig) *Registry {
+	r := &Registry{
+		byID:     make(map[string]*Tenant, len(cfgs)),
+		byDomain: make(map[string]*Tenant),
+	}
+	for _, c := range cfgs {
+		t := &Tenant{
+			ID:         c.ID,
+			Name:       c.Name,
+			Domains:    c.Domains,
+			SenderName: c.SenderName,
+			EmailFrom:  c.EmailFrom,
+			Plans:      NewPlans(c.Plans),
+		}
+		r.byID[t.ID] = t
+		for _, d := range c.Domains {
+			r.byDomain[strings.ToLower(d)] = t
+		}
+	}
+	return r
+}
+
+// Resolve returns the tenant serving host (a Host header value, with or without port).
+// Unknown hosts resolve to Default.
+func (r *Registry) Resolve(host string) *Tenant {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if t, ok := r.byDomain[host]; ok {
+		return t
+	}
+	return Default
+}
+
+// PlanForPrice returns the plan granted by a Stripe price in any tenant's
+// catalog, including the default one. Prices are unique across catalogs.
+func (r *Registry) PlanForPrice(priceID string) (Plan, bool) {
+	if p, ok := Default.PlanForPrice(priceID); ok {
+		return p, true
+	}
+	for _, t := range r.byID {
+		if p, ok := t.PlanForPrice(priceID); ok {
+			return p, true
+		}
+	}
+	return Plan{}, false
+}
+
+// Get returns the tenant with the given ID.
+// The empty ID and unknown IDs return Default.
+func (r *Registry) Get(id string) *Tenant {
+	if t, ok := r.byID[id]; ok {
+		return t
+	}
+	return Default
+}
@@ -0,0 +1,123 @@
+package tenant
+
+import (
+	"testing"
+
+	"github.com/otiai10/namazu/backend/internal/config"
+)
+
+func newTestRegistry() *Registry {
+	return NewRegistry([]config.TenantConfig{
+		{
+			ID:         "acme",
+			Name:       "ACME Alerts",
+			Domains:    []string{"alerts.acme.example", "Quake.ACME.example"},
+			SenderName: "acme-alerts/1.0",
+			EmailFrom:  "alerts@acme.example",
+			Plans: []config.PlanConfig{
+				{ID: "free", Name: "Starter", MaxSubscriptions: 2},
+				{ID: "pro", Name: "Business", MaxSubscriptions: 50, PriceID: "price_acme_pro"},
+			},
+		},
+		{ID: "globex", Name: "Globex", Domains: []string{"globex.example"}},
+	})
+}
+
+func TestRegistry_Resolve(t *testing.T) {
+	reg := newTestRegistry()
+
+	tests := []struct {
+		host string
+		want string
+	}{
+		{host: "alerts.acme.example", want: "acme"},
+		{host: "alerts.acme.example:8080", want: "acme"},
+		{host: "ALERTS.acme.example", want: "acme"},
+		{host: "alerts.acme.example.", want: "acme"},
+		{host: "quake.acme.example", want: "acme"},
+		{host: "globex.example", want: "globex"},
+		{host: "namazu.live", want: ""},
+		{host: "localhost:8080", want: ""},
+		{host: "", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			if got := reg.Resolve(tt.host).ID; got != tt.want {
+				t.Errorf("Resolve(%q).ID = %q, want %q", tt.host, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegistry_Get(t *testing.T) {
+	reg := newTestRegistry()
+
+	acme := reg.Get("acme")
+	if acme.Name != "ACME Alerts" || acme.SenderName != "acme-alerts/1.0" || acme.EmailFrom != "alerts@acme.example" {
+		t.Errorf("Get(acme) = %+v", acme)
+	}
+	if got := reg.Get(""); got != Default {
+		t.Errorf("Get(\"\") = %+v, want Default", got)
+	}
+	if got := reg.Get("unknown"); got != Default {
+		t.Errorf("Get(unknown) = %+v, want Default", got)
+	}
+}
+
+func TestTenant_Plan(t *testing.T) {
+	acme := newTestRegistry().Get("acme")
+
+	pro, ok := acme.Plan("pro")
+	if !ok {
+		t.Fatal("Plan(pro) not found")
+	}
+	if pro.MaxSubscriptions != 50 || pro.PriceID != "price_acme_pro" {
+		t.Errorf("Plan(pro) = %+v", pro)
+	}
+	if _, ok := acme.Plan("enterprise"); ok {
+		t.Error("Plan(enterprise) found, want not found")
+	}
+	if _, ok := Default.Plan("pro"); ok {
+		t.Error("Default.Plan(pro) found, want not found")
+	}
+}
+
+func TestRegistry_PlanForPrice(t *testing.T) {
+	reg := newTestRegistry()
+	SetDefaultPlans([]config.PlanConfig{
+		{ID: "free", MaxSubscriptions: 1},
+		{ID: "business", MaxSubscriptions: 30, PriceID: "price_business", YearlyPriceID: "price_business_yearly", PriceIDs: []string{"price_business_2025"}},
+	})
+	t.Cleanup(func() { SetDefaultPlans(nil) })
+
+	tests := []struct {
+		price  string
+		wantID string
+	}{
+		{"price_acme_pro", "pro"},
+		{"price_business", "business"},
+		{"price_business_yearly", "business"},
+		{"price_business_2025", "business"},
+		{"price_unknown", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		p, ok := reg.PlanForPrice(tt.price)
+		if ok != (tt.wantID != "") || p.ID != tt.wantID {
+			t.Errorf("PlanForPrice(%q) = %q, %v; want %q", tt.price, p.ID, ok, tt.wantID)
+		}
+	}
+	if b, _ := Default.Plan("business"); !b.Paid {
+		t.Error("business.Paid = false, want true for a plan with a price")
+	}
+}
+
+func TestTenant_IsDefault(t *testing.T) {
+	if !Default.IsDefault() {
+		t.Error("Default.IsDefault() = false, want true")
+	}
+	if newTestRegistry().Get("acme").IsDefault() {
+		t.Error("acme.IsDefault() = true, want false")
+	}
+}
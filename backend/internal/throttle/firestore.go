@@ -0,0 +1,74 @@
+package throttle
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/api/iterator"
+)
+
+// windowCollection holds one document per throttle window key
+const windowCollection = "throttle_windows"
+
+// FirestoreRepository implements Repository using Firestore
+type FirestoreRepository struct {
+	client *firestore.Client
+}
+
+// Compile-time interface check
+var _ Repository = (*FirestoreRepository)(nil)
+
+// NewFirestoreRepository creates a new FirestoreRepository
+func NewFirestoreRepository(client *firestore.Client) *FirestoreRepository {
+	return &FirestoreRepository{client: client}
+}
+
+// windowDocID returns the document ID for a key.
+// Keys may contain "/" and hypocenter names, so they are hashed.
+func windowDocID(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:16])
+}
+
+// Save creates or replaces the window with the same key
+func (r *FirestoreRepository) Save(ctx context.Context, w Window) error {
+	if r.client == nil {
+		return fmt.Errorf("firestore client is nil")
+	}
+
+	if _, err := r.client.Collection(windowCollection).Doc(windowDocID(w.Key)).Set(ctx, w); err != nil {
+		return fmt.Errorf("failed to save throttle window: %w", err)
+	}
+	return nil
+}
+
+// List returns windows that started at or after since
+func (r *FirestoreRepository) List(ctx context.Context, since time.Time) ([]Window, error) {
+	if r.client == nil {
+		return nil, fmt.Errorf("firestore client is nil")
+	}
+
+	iter := r.client.Collection(windowCollection).Where("deliveredAt", ">=", since).Documents(ctx)
+	defer iter.Stop()
+
+	windows := make([]Window, 0)
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list throttle windows: %w", err)
+		}
+		var w Window
+		if err := doc.DataTo(&w); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal throttle window: %w", err)
+		}
+		windows = append(windows, w)
+	}
+	return windows, nil
+}
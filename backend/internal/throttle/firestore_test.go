@@ -0,0 +1,33 @@
+package throttle
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestFirestoreRepository_ImplementsRepository(t *testing.T) {
+	var _ Repository = (*FirestoreRepository)(nil)
+}
+
+func TestWindowDocID(t *testing.T) {
+	id := windowDocID("sub-1/hypocenter/石川県能登地方")
+	if len(id) != 32 {
+		t.Errorf("windowDocID() = %q, want 32 hex characters", id)
+	}
+	if id == windowDocID("sub-1/hypocenter/千葉県東方沖") {
+		t.Error("windowDocID() should differ for different keys")
+	}
+}
+
+func TestFirestoreRepository_NilClient(t *testing.T) {
+	repo := NewFirestoreRepository(nil)
+	ctx := context.Background()
+
+	if err := repo.Save(ctx, Window{Key: "sub-1"}); err == nil {
+		t.Error("Save() expected error for nil client")
+	}
+	if _, err := repo.List(ctx, time.Now()); err == nil {
+		t.Error("List() expected error for nil client")
+	}
+}
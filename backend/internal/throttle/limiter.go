@@ -0,0 +1,130 @@
+package throttle
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/otiai10/namazu/backend/internal/source"
+	"github.com/otiai10/namazu/backend/internal/subscription"
+)
+
+// maxWindow is the longest window; older windows are forgotten
+const maxWindow = subscription.MaxThrottleIntervalMinutes * time.Minute
+
+// pruneInterval is how often forgotten windows are removed from memory
+const pruneInterval = time.Minute
+
+// Limiter decides whether a subscription may be notified of an event.
+// Windows are kept in memory and, when a Repository is set, persisted.
+// It is safe for concurrent use.
+type Limiter struct {
+	repo Repository // optional, can be nil
+	now  func() time.Time
+
+	mu       sync.Mutex
+	windows  map[string]Window
+	prunedAt time.Time
+}
+
+// NewLimiter creates a Limiter. A nil repo keeps windows in memory only.
+func NewLimiter(repo Repository) *Limiter {
+	return &Limiter{
+		repo:    repo,
+		now:     time.Now,
+		windows: make(map[string]Window),
+	}
+}
+
+// Restore loads the windows persisted by a previous run
+func (l *Limiter) Restore(ctx context.Context) error {
+	if l.repo == nil {
+		return nil
+	}
+	windows, err := l.repo.List(ctx, l.now().Add(-maxWindow))
+	if err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, w := range windows {
+		if current, ok := l.windows[w.Key]; !ok || w.DeliveredAt.After(current.DeliveredAt) {
+			l.windows[w.Key] = w
+		}
+	}
+	return nil
+}
+
+// Allow reports whether sub may be notified of event, and if so records the delivery.
+// Subscriptions without a throttle are always allowed. Within a window only
+// events more severe than every event delivered so far are allowed.
+func (l *Limiter) Allow(ctx context.Context, sub subscription.Subscription, event source.Event) bool {
+	if sub.Throttle == nil {
+		return true
+	}
+	now := l.now()
+	key := Key(sub, event)
+	severity := event.GetSeverity()
+
+	l.mu.Lock()
+	w, ok := l.windows[key]
+	if ok && now.Before(w.DeliveredAt.Add(sub.Throttle.Interval())) {
+		if severity <= w.Severity {
+			l.mu.Unlock()
+			return false
+		}
+		// A stronger shake: deliver, but keep the window running
+		w.Severity = severity
+	} else {
+		w = Window{Key: key, DeliveredAt: now, Severity: severity}
+	}
+	l.windows[key] = w
+	l.pruneLocked(now)
+	l.mu.Unlock()
+
+	if l.repo != nil {
+		if err := l.repo.Save(ctx, w); err != nil {
+			log.Printf("Failed to save throttle window of subscription %s: %v", sub.ID, err)
+		}
+	}
+	return true
+}
+
+// pruneLocked forgets windows that no throttle can still be in, at most
+// once per pruneInterval. l.mu must be held.
+func (l *Limiter) pruneLocked(now time.Time) {
+	if now.Sub(l.prunedAt) < pruneInterval {
+		return
+	}
+	l.prunedAt = now
+	for key, w := range l.windows {
+		if now.Sub(w.DeliveredAt) > maxWindow {
+			delete(l.windows, key)
+		}
+	}
+}
+
+// Key returns the window an event falls into for a subscription, following
+// its DedupeBy. Events without a hypocenter name fall back to their own ID
+// when deduplicated by hypocenter.
+func Key(sub subscription.Subscription, event source.Event) string {
+	id := sub.ID
+	if id == "" {
+		id = sub.Name // static subscriptions have no ID
+	}
+	switch sub.Throttle.DedupeBy {
+	case subscription.DedupeByEventID:
+		return id + "/event/" + event.GetID()
+	case subscription.DedupeByHypocenter:
+		if located, ok := event.(source.Located); ok {
+			if h := located.GetHypocenter(); h != nil && h.Name != "" {
+				return id + "/hypocenter/" + h.Name
+			}
+		}
+		return id + "/event/" + event.GetID()
+	default:
+		return id
+	}
+}
@@ -0,0 +1,217 @@
+package throttle
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/otiai10/namazu/backend/internal/source"
+	"github.com/otiai10/namazu/backend/internal/subscription"
+)
+
+// mockEvent implements source.Event and source.Located for testing
+type mockEvent struct {
+	id         string
+	severity   int
+	hypocenter *source.Hypocenter
+}
+
+func (m *mockEvent) GetID() string                     { return m.id }
+func (m *mockEvent) GetType() source.EventType         { return source.EventTypeEarthquake }
+func (m *mockEvent) GetSource() string                 { return "test" }
+func (m *mockEvent) GetSeverity() int                  { return m.severity }
+func (m *mockEvent) GetAffectedAreas() []string        { return nil }
+func (m *mockEvent) GetOccurredAt() time.Time          { return time.Time{} }
+func (m *mockEvent) GetReceivedAt() time.Time          { return time.Time{} }
+func (m *mockEvent) GetRawJSON() string                { return "{}" }
+func (m *mockEvent) GetHypocenter() *source.Hypocenter { return m.hypocenter }
+
+// memoryRepository is an in-memory Repository for testing
+type memoryRepository struct {
+	windows map[string]Window
+	err     error
+}
+
+func newMemoryRepository() *memoryRepository {
+	return &memoryRepository{windows: make(map[string]Window)}
+}
+
+func (r *memoryRepository) Save(ctx context.Context, w Window) error {
+	if r.err != nil {
+		return r.err
+	}
+	r.windows[w.Key] = w
+	return nil
+}
+
+func (r *memoryRepository) List(ctx context.Context, since time.Time) ([]Window, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	var windows []Window
+	for _, w := range r.windows {
+		if !w.DeliveredAt.Before(since) {
+			windows = append(windows, w)
+		}
+	}
+	return windows, nil
+}
+
+// newTestLimiter returns a limiter whose clock is advanced by the returned func
+func newTestLimiter(repo Repository) (*Limiter, func(time.Duration)) {
+	l := NewLimiter(repo)
+	now := time.Date(2024, 1, 1, 16, 10, 0, 0, time.UTC)
+	l.now = func() time.Time { return now }
+	return l, func(d time.Duration) { now = now.Add(d) }
+}
+
+func throttled(dedupeBy string) subscription.Subscription {
+	return subscription.Subscription{
+		ID:       "sub-1",
+		Name:     "Swarm",
+		Throttle: &subscription.ThrottleConfig{IntervalMinutes: 10, DedupeBy: dedupeBy},
+	}
+}
+
+func TestLimiter_Allow(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("without throttle", func(t *testing.T) {
+		l, _ := newTestLimiter(nil)
+		sub := subscription.Subscription{ID: "sub-1"}
+		for i := 0; i < 3; i++ {
+			if !l.Allow(ctx, sub, &mockEvent{id: "e", severity: 10}) {
+				t.Fatal("Allow() = false for a subscription without throttle")
+			}
+		}
+	})
+
+	t.Run("one delivery per interval", func(t *testing.T) {
+		l, advance := newTestLimiter(nil)
+		sub := throttled(subscription.DedupeBySubscription)
+
+		if !l.Allow(ctx, sub, &mockEvent{id: "e1", severity: 30}) {
+			t.Fatal("first event should be allowed")
+		}
+		advance(5 * time.Minute)
+		if l.Allow(ctx, sub, &mockEvent{id: "e2", severity: 30}) {
+			t.Error("event within the interval should be throttled")
+		}
+		advance(5 * time.Minute)
+		if !l.Allow(ctx, sub, &mockEvent{id: "e3", severity: 10}) {
+			t.Error("event after the interval should be allowed")
+		}
+	})
+
+	t.Run("stronger events get through", func(t *testing.T) {
+		l, advance := newTestLimiter(nil)
+		sub := throttled(subscription.DedupeBySubscription)
+
+		l.Allow(ctx, sub, &mockEvent{id: "e1", severity: 30})
+		advance(time.Minute)
+		if !l.Allow(ctx, sub, &mockEvent{id: "e2", severity: 50}) {
+			t.Error("a more severe event should be allowed")
+		}
+		advance(time.Minute)
+		if l.Allow(ctx, sub, &mockEvent{id: "e3", severity: 40}) {
+			t.Error("an event weaker than the strongest delivered should be throttled")
+		}
+		advance(8 * time.Minute)
+		if !l.Allow(ctx, sub, &mockEvent{id: "e4", severity: 10}) {
+			t.Error("the window should not be extended by stronger events")
+		}
+	})
+
+	t.Run("dedupe by event ID", func(t *testing.T) {
+		l, _ := newTestLimiter(nil)
+		sub := throttled(subscription.DedupeByEventID)
+
+		if !l.Allow(ctx, sub, &mockEvent{id: "e1", severity: 30}) || !l.Allow(ctx, sub, &mockEvent{id: "e2", severity: 30}) {
+			t.Error("different events should be allowed")
+		}
+		if l.Allow(ctx, sub, &mockEvent{id: "e1", severity: 30}) {
+			t.Error("a repeated event should be throttled")
+		}
+	})
+
+	t.Run("dedupe by hypocenter", func(t *testing.T) {
+		l, _ := newTestLimiter(nil)
+		sub := throttled(subscription.DedupeByHypocenter)
+		noto := &source.Hypocenter{Name: "石川県能登地方"}
+
+		if !l.Allow(ctx, sub, &mockEvent{id: "e1", severity: 30, hypocenter: noto}) {
+			t.Fatal("first event should be allowed")
+		}
+		if l.Allow(ctx, sub, &mockEvent{id: "e2", severity: 30, hypocenter: noto}) {
+			t.Error("another event at the same hypocenter should be throttled")
+		}
+		if !l.Allow(ctx, sub, &mockEvent{id: "e3", severity: 30, hypocenter: &source.Hypocenter{Name: "千葉県東方沖"}}) {
+			t.Error("an event at another hypocenter should be allowed")
+		}
+		if !l.Allow(ctx, sub, &mockEvent{id: "e4", severity: 30}) {
+			t.Error("an event without a hypocenter should be allowed")
+		}
+	})
+
+	t.Run("subscriptions are throttled separately", func(t *testing.T) {
+		l, _ := newTestLimiter(nil)
+		other := throttled(subscription.DedupeBySubscription)
+		other.ID = "sub-2"
+
+		l.Allow(ctx, throttled(subscription.DedupeBySubscription), &mockEvent{id: "e1", severity: 30})
+		if !l.Allow(ctx, other, &mockEvent{id: "e1", severity: 30}) {
+			t.Error("another subscription should not be throttled")
+		}
+	})
+}
+
+func TestLimiter_Persistence(t *testing.T) {
+	ctx := context.Background()
+	repo := newMemoryRepository()
+	sub := throttled(subscription.DedupeBySubscription)
+
+	l, _ := newTestLimiter(repo)
+	l.Allow(ctx, sub, &mockEvent{id: "e1", severity: 30})
+	if _, ok := repo.windows[Key(sub, &mockEvent{id: "e1"})]; !ok {
+		t.Fatal("expected the window to be saved")
+	}
+
+	restarted, advance := newTestLimiter(repo)
+	if err := restarted.Restore(ctx); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+	advance(time.Minute)
+	if restarted.Allow(ctx, sub, &mockEvent{id: "e2", severity: 30}) {
+		t.Error("the restored window should throttle the event")
+	}
+
+	repo.err = errors.New("unavailable")
+	if err := NewLimiter(repo).Restore(ctx); err == nil {
+		t.Error("Restore() expected error from the repository")
+	}
+	if !NewLimiter(repo).Allow(ctx, sub, &mockEvent{id: "e3", severity: 30}) {
+		t.Error("a failing repository should not block deliveries")
+	}
+}
+
+func TestKey(t *testing.T) {
+	event := &mockEvent{id: "e1", hypocenter: &source.Hypocenter{Name: "石川県能登地方"}}
+	tests := []struct {
+		name string
+		sub  subscription.Subscription
+		want string
+	}{
+		{"subscription", throttled(subscription.DedupeBySubscription), "sub-1"},
+		{"event ID", throttled(subscription.DedupeByEventID), "sub-1/event/e1"},
+		{"hypocenter", throttled(subscription.DedupeByHypocenter), "sub-1/hypocenter/石川県能登地方"},
+		{"static subscription", subscription.Subscription{Name: "static", Throttle: &subscription.ThrottleConfig{IntervalMinutes: 1}}, "static"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Key(tt.sub, event); got != tt.want {
+				t.Errorf("Key() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
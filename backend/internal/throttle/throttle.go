@@ -0,0 +1,24 @@
+// Package throttle limits how often a subscription is notified during
+// earthquake swarms, following each subscription's ThrottleConfig.
+package throttle
+
+import (
+	"context"
+	"time"
+)
+
+// Window is the last delivery within a throttle window
+type Window struct {
+	Key         string    `firestore:"key"`         // See Key
+	DeliveredAt time.Time `firestore:"deliveredAt"` // When the window started
+	Severity    int       `firestore:"severity"`    // Highest severity delivered in the window
+}
+
+// Repository persists throttle windows so they survive restarts
+type Repository interface {
+	// Save creates or replaces the window with the same key
+	Save(ctx context.Context, w Window) error
+
+	// List returns windows that started at or after since
+	List(ctx context.Context, since time.Time) ([]Window, error)
+}
@@ -0,0 +1,108 @@
+// Package tracing records OpenTelemetry spans from event receipt to webhook
+// delivery. Until Setup installs an exporter, spans are no-ops and no trace
+// IDs are sent to subscribers.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/otiai10/namazu/backend/internal/config"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TraceIDHeader carries the trace ID of a delivery, so subscribers can quote it
+// when reporting problems. The W3C traceparent header is sent alongside it.
+const TraceIDHeader = "X-Namazu-Trace-Id"
+
+// DefaultServiceName is the service.name reported to the collector
+const DefaultServiceName = "namazu"
+
+// instrumentationName identifies the tracer of this module
+const instrumentationName = "github.com/otiai10/namazu"
+
+// Setup installs an OTLP/HTTP exporter as the global tracer provider.
+// It returns a function that flushes pending spans and stops the exporter.
+// With a nil config or an empty endpoint, tracing stays disabled and the
+// returned function does nothing.
+func Setup(ctx context.Context, cfg *config.TracingConfig) (shutdown func(context.Context) error, err error) {
+	if cfg == nil || cfg.Endpoint == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exporter, err := otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(cfg.Endpoint))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP exporter: %w", err)
+	}
+
+	name := cfg.ServiceName
+	if name == "" {
+		name = DefaultServiceName
+	}
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(attribute.String("service.name", name)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build trace resource: %w", err)
+	}
+
+	ratio := cfg.SampleRatio
+	if ratio == 0 {
+		ratio = 1
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	return provider.Shutdown, nil
+}
+
+// Start starts a span as a child of the span in ctx, if any
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentationName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// Fail marks a span as failed with the given description
+func Fail(span trace.Span, description string) {
+	span.SetStatus(codes.Error, description)
+}
+
+// Link returns ctx with sc as its current span, so spans started from it
+// continue that trace. Use it to carry a trace across a channel or queue,
+// where the context of the producer is not available. Invalid span contexts
+// leave ctx unchanged.
+func Link(ctx context.Context, sc trace.SpanContext) context.Context {
+	if !sc.IsValid() {
+		return ctx
+	}
+	return trace.ContextWithSpanContext(ctx, sc)
+}
+
+// TraceID returns the trace ID of the span in ctx, or "" without one
+func TraceID(ctx context.Context) string {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.HasTraceID() {
+		return ""
+	}
+	return sc.TraceID().String()
+}
+
+// Inject adds TraceIDHeader and the propagation headers of the span in ctx
+// to an outgoing request. Nothing is added without a span.
+func Inject(ctx context.Context, header http.Header) {
+	id := TraceID(ctx)
+	if id == "" {
+		return
+	}
+	header.Set(TraceIDHeader, id)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(header))
+}
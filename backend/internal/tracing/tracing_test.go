@@ -0,0 +1,112 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/otiai10/namazu/backend/internal/config"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var testSpanContext = trace.NewSpanContext(trace.SpanContextConfig{
+	TraceID:    trace.TraceID{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36},
+	SpanID:     trace.SpanID{0, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7},
+	TraceFlags: trace.FlagsSampled,
+})
+
+// restoreGlobals resets the tracer provider and propagator replaced by Setup
+func restoreGlobals(t *testing.T) {
+	provider := otel.GetTracerProvider()
+	propagator := otel.GetTextMapPropagator()
+	t.Cleanup(func() {
+		if otel.GetTracerProvider() != provider {
+			otel.SetTracerProvider(provider)
+		}
+		if otel.GetTextMapPropagator() != propagator {
+			otel.SetTextMapPropagator(propagator)
+		}
+	})
+}
+
+func TestSetup_Disabled(t *testing.T) {
+	restoreGlobals(t)
+	before := otel.GetTracerProvider()
+
+	for _, cfg := range []*config.TracingConfig{nil, {SampleRatio: 0.5}} {
+		shutdown, err := Setup(context.Background(), cfg)
+		if err != nil {
+			t.Fatalf("Setup(%+v) error = %v", cfg, err)
+		}
+		if err := shutdown(context.Background()); err != nil {
+			t.Errorf("shutdown() error = %v", err)
+		}
+	}
+	if otel.GetTracerProvider() != before {
+		t.Error("Setup without an endpoint replaced the tracer provider")
+	}
+
+	// Spans are no-ops, so there is nothing to send
+	ctx, span := Start(context.Background(), "test")
+	defer span.End()
+	if id := TraceID(ctx); id != "" {
+		t.Errorf("TraceID() = %q, want empty while disabled", id)
+	}
+}
+
+func TestSetup_Exporter(t *testing.T) {
+	restoreGlobals(t)
+
+	shutdown, err := Setup(context.Background(), &config.TracingConfig{Endpoint: "http://127.0.0.1:4318", SampleRatio: 1})
+	if err != nil {
+		t.Fatalf("Setup() error = %v", err)
+	}
+	if _, ok := otel.GetTracerProvider().(*sdktrace.TracerProvider); !ok {
+		t.Errorf("tracer provider = %T, want the SDK provider", otel.GetTracerProvider())
+	}
+
+	ctx, span := Start(context.Background(), "test")
+	if !span.SpanContext().IsSampled() || TraceID(ctx) == "" {
+		t.Errorf("span %+v is not sampled", span.SpanContext())
+	}
+	span.End()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel() // Do not wait for the unreachable collector
+	_ = shutdown(ctx)
+}
+
+func TestLink(t *testing.T) {
+	if ctx := Link(context.Background(), trace.SpanContext{}); trace.SpanContextFromContext(ctx).IsValid() {
+		t.Error("Link() with an invalid span context should leave ctx unchanged")
+	}
+
+	// Spans started from the linked context continue its trace, even as no-ops
+	ctx, span := Start(Link(context.Background(), testSpanContext), "child")
+	defer span.End()
+	if got := TraceID(ctx); got != testSpanContext.TraceID().String() {
+		t.Errorf("TraceID() = %q, want %q", got, testSpanContext.TraceID())
+	}
+}
+
+func TestInject(t *testing.T) {
+	restoreGlobals(t)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	header := http.Header{}
+	Inject(context.Background(), header)
+	if len(header) != 0 {
+		t.Errorf("Inject() without a span added %v", header)
+	}
+
+	Inject(Link(context.Background(), testSpanContext), header)
+	if got := header.Get(TraceIDHeader); got != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("%s = %q", TraceIDHeader, got)
+	}
+	if got := header.Get("traceparent"); got != "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01" {
+		t.Errorf("traceparent = %q", got)
+	}
+}
@@ -0,0 +1,113 @@
+package user
+
+import (
+	"errors"
+	"time"
+)
+
+// MaxDevices is the number of mobile devices kept per user.
+// Registering another one drops the oldest.
+const MaxDevices = 10
+
+// ErrDeviceNotFound is returned when trying to remove an unknown device token
+var ErrDeviceNotFound = errors.New("device not found for user")
+
+// Device platforms
+const (
+	PlatformAndroid = "android"
+	PlatformIOS     = "ios"
+	PlatformWeb     = "web"
+)
+
+// Device is a Firebase Cloud Messaging registration token of a user's app install.
+// Prefectures and MinScale opt the device in to prefecture alert topics,
+// which are delivered independently of the user's subscriptions.
+type Device struct {
+	Token       string    `firestore:"token" json:"token"`
+	Platform    string    `firestore:"platform" json:"platform"` // PlatformAndroid | PlatformIOS | PlatformWeb
+	Name        string    `firestore:"name,omitempty" json:"name,omitempty"`
+	Prefectures []string  `firestore:"prefectures,omitempty" json:"prefectures,omitempty"`
+	MinScale    int       `firestore:"minScale,omitempty" json:"minScale,omitempty"`
+	CreatedAt   time.Time `firestore:"createdAt" json:"createdAt"`
+}
+
+// Copy creates a deep copy of the Device to prevent mutation
+func (d Device) Copy() Device {
+	copied := d
+	copied.Prefectures = append([]string(nil), d.Prefectures...)
+	return copied
+}
+
+// upsertDevice returns devices with device added, replacing any existing
+// device with the same token and keeping at most MaxDevices
+func upsertDevice(devices []Device, device Device) []Device {
+	result := make([]Device, 0, len(devices)+1)
+	for _, existing := range devices {
+		if existing.Token != device.Token {
+			result = append(result, existing)
+		}
+	}
+	result = append(result, device.Copy())
+	if len(result) > MaxDevices {
+		result = result[len(result)-MaxDevices:]
+	}
+	return result
+}
+
+// findDeviceIndex finds the index of a device by its token
+// Returns -1 if not found
+func findDeviceIndex(devices []Device, token string) int {
+	for i, device := range devices {
+		if device.Token == token {
+			return i
+		}
+	}
+	return -1
+}
+
+// deviceToMap converts a Device to a map for Firestore storage
+func deviceToMap(device Device) map[string]any {
+	data := map[string]any{
+		"token":     device.Token,
+		"platform":  device.Platform,
+		"createdAt": device.CreatedAt,
+	}
+	if device.Name != "" {
+		data["name"] = device.Name
+	}
+	if len(device.Prefectures) > 0 {
+		data["prefectures"] = device.Prefectures
+	}
+	if device.MinScale > 0 {
+		data["minScale"] = device.MinScale
+	}
+	return data
+}
+
+// mapToDevice converts a map to a Device
+func mapToDevice(data map[string]any) Device {
+	device := Device{}
+	if token, ok := data["token"].(string); ok {
+		device.Token = token
+	}
+	if platform, ok := data["platform"].(string); ok {
+		device.Platform = platform
+	}
+	if name, ok := data["name"].(string); ok {
+		device.Name = name
+	}
+	if prefectures, ok := data["prefectures"].([]any); ok {
+		for _, p := range prefectures {
+			if pref, ok := p.(string); ok {
+				device.Prefectures = append(device.Prefectures, pref)
+			}
+		}
+	}
+	if minScale, ok := data["minScale"].(int64); ok {
+		device.MinScale = int(minScale)
+	}
+	if createdAt, ok := data["createdAt"].(time.Time); ok {
+		device.CreatedAt = createdAt
+	}
+	return device
+}
@@ -0,0 +1,55 @@
+package user
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestUpsertDevice(t *testing.T) {
+	var devices []Device
+	for i := 0; i < MaxDevices+2; i++ {
+		devices = upsertDevice(devices, Device{Token: fmt.Sprintf("token-%d", i)})
+	}
+	if len(devices) != MaxDevices || devices[0].Token != "token-2" {
+		t.Fatalf("expected the oldest to be dropped, got %d starting with %s", len(devices), devices[0].Token)
+	}
+
+	devices = upsertDevice(devices, Device{Token: "token-5", Prefectures: []string{"石川県"}})
+	if len(devices) != MaxDevices {
+		t.Errorf("re-registering a token changed the count to %d", len(devices))
+	}
+	if last := devices[len(devices)-1]; last.Token != "token-5" || len(last.Prefectures) != 1 {
+		t.Errorf("expected the re-registered token to be replaced and moved last, got %+v", last)
+	}
+	if idx := findDeviceIndex(devices, "token-0"); idx != -1 {
+		t.Errorf("findDeviceIndex(dropped) = %d, want -1", idx)
+	}
+}
+
+func TestDeviceMap(t *testing.T) {
+	device := Device{
+		Token:       "fcm-token",
+		Platform:    PlatformAndroid,
+		Name:        "Pixel",
+		Prefectures: []string{"石川県", "富山県"},
+		MinScale:    40,
+		CreatedAt:   time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	data := deviceToMap(device)
+	// Firestore returns arrays as []any and integers as int64
+	data["prefectures"] = []any{"石川県", "富山県"}
+	data["minScale"] = int64(40)
+	if got := mapToDevice(data); !reflect.DeepEqual(got, device) {
+		t.Errorf("round trip = %+v, want %+v", got, device)
+	}
+
+	data = deviceToMap(Device{Token: "x", Platform: PlatformIOS})
+	for _, key := range []string{"name", "prefectures", "minScale"} {
+		if _, ok := data[key]; ok {
+			t.Errorf("expected an empty %s to be omitted", key)
+		}
+	}
+}
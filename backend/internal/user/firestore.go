@@ -52,7 +52,10 @@ func NewFirestoreRepository(client *firestore.Client) *FirestoreRepository {
 	}
 }
 
-// Create creates a new user and returns its document ID
+// Create creates a new user and returns its document ID.
+// The document ID is the UID, so two concurrent first logins cannot create
+// two users: the second create fails with ErrDuplicateUID. Users created before
+// IDs were deterministic are found by querying the UID in the same transaction.
 //
 // Parameters:
 //   - ctx: Context for cancellation control
@@ -62,18 +65,25 @@ func NewFirestoreRepository(client *firestore.Client) *FirestoreRepository {
 //   - ID of the created user document
 //   - Error if Firestore operation fails or UID already exists
 func (r *FirestoreRepository) Create(ctx context.Context, user User) (string, error) {
-	// Check if user with same UID already exists
-	existingUser, err := r.GetByUID(ctx, user.UID)
-	if err != nil {
-		return "", fmt.Errorf("failed to check existing user: %w", err)
-	}
-	if existingUser != nil {
-		return "", ErrDuplicateUID
+	if user.UID == "" {
+		return "", errors.New("user has no UID")
 	}
-
+	docRef := r.client.Collection(collectionName).Doc(user.UID)
 	data := userToMap(user)
 
-	docRef, _, err := r.client.Collection(collectionName).Add(ctx, data)
+	err := r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		legacy, err := tx.Documents(r.client.Collection(collectionName).Where("uid", "==", user.UID).Limit(1)).GetAll()
+		if err != nil {
+			return fmt.Errorf("failed to check existing user: %w", err)
+		}
+		if len(legacy) > 0 {
+			return ErrDuplicateUID
+		}
+		return tx.Create(docRef, data)
+	})
+	if errors.Is(err, ErrDuplicateUID) || status.Code(err) == codes.AlreadyExists {
+		return "", ErrDuplicateUID
+	}
 	if err != nil {
 		return "", fmt.Errorf("failed to create user: %w", err)
 	}
@@ -117,6 +127,24 @@ func (r *FirestoreRepository) Get(ctx context.Context, id string) (*User, error)
 //   - Pointer to the user (nil if not found)
 //   - Error if Firestore operation fails (nil for not found)
 func (r *FirestoreRepository) GetByUID(ctx context.Context, uid string) (*User, error) {
+	if uid == "" {
+		return nil, nil
+	}
+
+	// Users are stored under their UID, except those created before that
+	doc, err := r.client.Collection(collectionName).Doc(uid).Get(ctx)
+	if err == nil {
+		user, err := documentToUser(doc)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert document: %w", err)
+		}
+		if user.UID == uid {
+			return &user, nil
+		}
+	} else if status.Code(err) != codes.NotFound {
+		return nil, fmt.Errorf("failed to get user by UID: %w", err)
+	}
+
 	docs, err := r.client.Collection(collectionName).
 		Where("uid", "==", uid).
 		Limit(1).
@@ -168,6 +196,33 @@ func (r *FirestoreRepository) Update(ctx context.Context, id string, user User)
 	return nil
 }
 
+// Delete removes a user
+//
+// Parameters:
+//   - ctx: Context for cancellation control
+//   - id: User document ID to delete
+//
+// Returns:
+//   - Error if user not found or Firestore operation fails
+func (r *FirestoreRepository) Delete(ctx context.Context, id string) error {
+	docRef := r.client.Collection(collectionName).Doc(id)
+
+	// Check if document exists
+	_, err := docRef.Get(ctx)
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return ErrNotFound
+		}
+		return fmt.Errorf("failed to check user existence: %w", err)
+	}
+
+	if _, err := docRef.Delete(ctx); err != nil {
+		return fmt.Errorf("failed to delete user: %w", err)
+	}
+
+	return nil
+}
+
 // UpdateLastLogin updates the LastLoginAt field
 //
 // Parameters:
@@ -210,88 +265,175 @@ func (r *FirestoreRepository) UpdateLastLogin(ctx context.Context, id string, t
 // Returns:
 //   - Error if user not found, provider already exists, or Firestore operation fails
 func (r *FirestoreRepository) AddProvider(ctx context.Context, id string, provider LinkedProvider) error {
-	docRef := r.client.Collection(collectionName).Doc(id)
+	return r.modify(ctx, id, func(user User) ([]firestore.Update, error) {
+		if findProviderIndex(user.Providers, provider.ProviderID) >= 0 {
+			return nil, ErrProviderExists
+		}
+		return []firestore.Update{{Path: "providers", Value: firestore.ArrayUnion(providerToMap(provider))}}, nil
+	})
+}
 
-	// Get current user to check existing providers
-	doc, err := docRef.Get(ctx)
-	if err != nil {
-		if status.Code(err) == codes.NotFound {
-			return ErrNotFound
+// RemoveProvider removes a linked provider from a user
+//
+// Parameters:
+//   - ctx: Context for cancellation control
+//   - id: User document ID
+//   - providerID: Provider ID to remove (e.g., "google.com")
+//
+// Returns:
+//   - Error if user not found, provider not found, or Firestore operation fails
+func (r *FirestoreRepository) RemoveProvider(ctx context.Context, id string, providerID string) error {
+	return r.modify(ctx, id, func(user User) ([]firestore.Update, error) {
+		idx := findProviderIndex(user.Providers, providerID)
+		if idx < 0 {
+			return nil, ErrProviderNotFound
 		}
-		return fmt.Errorf("failed to get user: %w", err)
-	}
 
-	user, err := documentToUser(doc)
-	if err != nil {
-		return fmt.Errorf("failed to convert document: %w", err)
-	}
+		// Create new providers slice without the removed provider (immutable)
+		newProviders := make([]map[string]any, 0, len(user.Providers)-1)
+		for i, p := range user.Providers {
+			if i != idx {
+				newProviders = append(newProviders, providerToMap(p))
+			}
+		}
+		return []firestore.Update{{Path: "providers", Value: newProviders}}, nil
+	})
+}
 
-	// Check if provider already exists
-	if findProviderIndex(user.Providers, provider.ProviderID) >= 0 {
-		return ErrProviderExists
-	}
+// AddPushSubscription stores a browser push endpoint for a user
+//
+// Parameters:
+//   - ctx: Context for cancellation control
+//   - id: User document ID
+//   - sub: Push subscription to add (replaces one with the same endpoint)
+//
+// Returns:
+//   - Error if user not found or Firestore operation fails
+func (r *FirestoreRepository) AddPushSubscription(ctx context.Context, id string, sub PushSubscription) error {
+	return r.updatePushSubscriptions(ctx, id, func(subs []PushSubscription) ([]PushSubscription, error) {
+		return upsertPushSubscription(subs, sub), nil
+	})
+}
 
-	// Add provider using array union (immutable operation)
-	providerMap := providerToMap(provider)
-	_, err = docRef.Update(ctx, []firestore.Update{
-		{Path: "providers", Value: firestore.ArrayUnion(providerMap)},
-		{Path: "updatedAt", Value: time.Now().UTC()},
+// RemovePushSubscription removes a browser push endpoint from a user
+//
+// Parameters:
+//   - ctx: Context for cancellation control
+//   - id: User document ID
+//   - endpoint: Endpoint URL of the push subscription
+//
+// Returns:
+//   - Error if user not found, endpoint not found, or Firestore operation fails
+func (r *FirestoreRepository) RemovePushSubscription(ctx context.Context, id string, endpoint string) error {
+	return r.updatePushSubscriptions(ctx, id, func(subs []PushSubscription) ([]PushSubscription, error) {
+		idx := findPushSubscriptionIndex(subs, endpoint)
+		if idx < 0 {
+			return nil, ErrPushSubscriptionNotFound
+		}
+		return append(subs[:idx:idx], subs[idx+1:]...), nil
 	})
-	if err != nil {
-		return fmt.Errorf("failed to add provider: %w", err)
-	}
+}
 
-	return nil
+// AddDevice stores an FCM registration token for a user
+//
+// Parameters:
+//   - ctx: Context for cancellation control
+//   - id: User document ID
+//   - device: Device to add (replaces one with the same token)
+//
+// Returns:
+//   - Error if user not found or Firestore operation fails
+func (r *FirestoreRepository) AddDevice(ctx context.Context, id string, device Device) error {
+	return r.updateDevices(ctx, id, func(devices []Device) ([]Device, error) {
+		return upsertDevice(devices, device), nil
+	})
 }
 
-// RemoveProvider removes a linked provider from a user
+// RemoveDevice removes an FCM registration token from a user
 //
 // Parameters:
 //   - ctx: Context for cancellation control
 //   - id: User document ID
-//   - providerID: Provider ID to remove (e.g., "google.com")
+//   - token: Registration token of the device
 //
 // Returns:
-//   - Error if user not found, provider not found, or Firestore operation fails
-func (r *FirestoreRepository) RemoveProvider(ctx context.Context, id string, providerID string) error {
-	docRef := r.client.Collection(collectionName).Doc(id)
+//   - Error if user not found, token not found, or Firestore operation fails
+func (r *FirestoreRepository) RemoveDevice(ctx context.Context, id string, token string) error {
+	return r.updateDevices(ctx, id, func(devices []Device) ([]Device, error) {
+		idx := findDeviceIndex(devices, token)
+		if idx < 0 {
+			return nil, ErrDeviceNotFound
+		}
+		return append(devices[:idx:idx], devices[idx+1:]...), nil
+	})
+}
 
-	// Get current user to find the provider
-	doc, err := docRef.Get(ctx)
-	if err != nil {
-		if status.Code(err) == codes.NotFound {
-			return ErrNotFound
+// updatePushSubscriptions replaces a user's push subscriptions with fn's result
+func (r *FirestoreRepository) updatePushSubscriptions(ctx context.Context, id string, fn func([]PushSubscription) ([]PushSubscription, error)) error {
+	return r.modify(ctx, id, func(user User) ([]firestore.Update, error) {
+		subs, err := fn(user.PushSubscriptions)
+		if err != nil {
+			return nil, err
 		}
-		return fmt.Errorf("failed to get user: %w", err)
-	}
+		maps := make([]map[string]any, len(subs))
+		for i, sub := range subs {
+			maps[i] = pushSubscriptionToMap(sub)
+		}
+		return []firestore.Update{{Path: "pushSubscriptions", Value: maps}}, nil
+	})
+}
 
-	user, err := documentToUser(doc)
-	if err != nil {
-		return fmt.Errorf("failed to convert document: %w", err)
-	}
+// updateDevices replaces a user's devices with fn's result
+func (r *FirestoreRepository) updateDevices(ctx context.Context, id string, fn func([]Device) ([]Device, error)) error {
+	return r.modify(ctx, id, func(user User) ([]firestore.Update, error) {
+		devices, err := fn(user.Devices)
+		if err != nil {
+			return nil, err
+		}
+		maps := make([]map[string]any, len(devices))
+		for i, device := range devices {
+			maps[i] = deviceToMap(device)
+		}
+		return []firestore.Update{{Path: "devices", Value: maps}}, nil
+	})
+}
 
-	// Find provider index
-	idx := findProviderIndex(user.Providers, providerID)
-	if idx < 0 {
-		return ErrProviderNotFound
-	}
+// modify reads a user and applies the updates fn returns for it, with
+// updatedAt, in a transaction: a concurrent change of the user makes Firestore
+// retry with the new state instead of the updates overwriting it.
+// Errors of fn are returned as is; ErrNotFound if the user is missing.
+func (r *FirestoreRepository) modify(ctx context.Context, id string, fn func(User) ([]firestore.Update, error)) error {
+	docRef := r.client.Collection(collectionName).Doc(id)
 
-	// Create new providers slice without the removed provider (immutable)
-	newProviders := make([]map[string]any, 0, len(user.Providers)-1)
-	for i, p := range user.Providers {
-		if i != idx {
-			newProviders = append(newProviders, providerToMap(p))
+	var fnErr error
+	err := r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		fnErr = nil
+		doc, err := tx.Get(docRef)
+		if err != nil {
+			if status.Code(err) == codes.NotFound {
+				fnErr = ErrNotFound
+				return fnErr
+			}
+			return fmt.Errorf("failed to get user: %w", err)
+		}
+		user, err := documentToUser(doc)
+		if err != nil {
+			return fmt.Errorf("failed to convert document: %w", err)
 		}
-	}
 
-	_, err = docRef.Update(ctx, []firestore.Update{
-		{Path: "providers", Value: newProviders},
-		{Path: "updatedAt", Value: time.Now().UTC()},
+		updates, err := fn(user)
+		if err != nil {
+			fnErr = err
+			return err
+		}
+		return tx.Update(docRef, append(updates, firestore.Update{Path: "updatedAt", Value: time.Now().UTC()}))
 	})
+	if fnErr != nil {
+		return fnErr
+	}
 	if err != nil {
-		return fmt.Errorf("failed to remove provider: %w", err)
+		return fmt.Errorf("failed to update user: %w", err)
 	}
-
 	return nil
 }
 
@@ -314,6 +456,30 @@ func userToMap(user User) map[string]any {
 		"lastLoginAt": user.LastLoginAt,
 	}
 
+	if user.Role != "" {
+		data["role"] = user.Role
+	}
+	if user.TermsVersion != "" {
+		data["termsVersion"] = user.TermsVersion
+	}
+	if user.TermsAcceptedAt != nil {
+		data["termsAcceptedAt"] = *user.TermsAcceptedAt
+	}
+	if len(user.PushSubscriptions) > 0 {
+		pushSubscriptions := make([]map[string]any, len(user.PushSubscriptions))
+		for i, sub := range user.PushSubscriptions {
+			pushSubscriptions[i] = pushSubscriptionToMap(sub)
+		}
+		data["pushSubscriptions"] = pushSubscriptions
+	}
+	if len(user.Devices) > 0 {
+		devices := make([]map[string]any, len(user.Devices))
+		for i, device := range user.Devices {
+			devices[i] = deviceToMap(device)
+		}
+		data["devices"] = devices
+	}
+
 	// Include Stripe fields if set
 	if user.StripeCustomerID != "" {
 		data["stripeCustomerId"] = user.StripeCustomerID
@@ -327,6 +493,12 @@ func userToMap(user User) map[string]any {
 	if !user.SubscriptionEndsAt.IsZero() {
 		data["subscriptionEndsAt"] = user.SubscriptionEndsAt
 	}
+	if user.CancelAtPeriodEnd {
+		data["cancelAtPeriodEnd"] = true
+	}
+	if user.BillingInterval != "" {
+		data["billingInterval"] = user.BillingInterval
+	}
 
 	return data
 }
@@ -365,6 +537,9 @@ func documentToUser(doc *firestore.DocumentSnapshot) (User, error) {
 	if plan, ok := data["plan"].(string); ok {
 		user.Plan = plan
 	}
+	if role, ok := data["role"].(string); ok {
+		user.Role = role
+	}
 	if createdAt, ok := data["createdAt"].(time.Time); ok {
 		user.CreatedAt = createdAt
 	}
@@ -374,6 +549,12 @@ func documentToUser(doc *firestore.DocumentSnapshot) (User, error) {
 	if lastLoginAt, ok := data["lastLoginAt"].(time.Time); ok {
 		user.LastLoginAt = lastLoginAt
 	}
+	if termsVersion, ok := data["termsVersion"].(string); ok {
+		user.TermsVersion = termsVersion
+	}
+	if termsAcceptedAt, ok := data["termsAcceptedAt"].(time.Time); ok {
+		user.TermsAcceptedAt = &termsAcceptedAt
+	}
 
 	// Parse Stripe fields
 	if stripeCustomerID, ok := data["stripeCustomerId"].(string); ok {
@@ -388,6 +569,12 @@ func documentToUser(doc *firestore.DocumentSnapshot) (User, error) {
 	if subscriptionEndsAt, ok := data["subscriptionEndsAt"].(time.Time); ok {
 		user.SubscriptionEndsAt = subscriptionEndsAt
 	}
+	if cancelAtPeriodEnd, ok := data["cancelAtPeriodEnd"].(bool); ok {
+		user.CancelAtPeriodEnd = cancelAtPeriodEnd
+	}
+	if billingInterval, ok := data["billingInterval"].(string); ok {
+		user.BillingInterval = billingInterval
+	}
 
 	// Parse providers
 	if providers, ok := data["providers"].([]any); ok {
@@ -400,6 +587,26 @@ func documentToUser(doc *firestore.DocumentSnapshot) (User, error) {
 		}
 	}
 
+	// Parse push subscriptions
+	if pushSubscriptions, ok := data["pushSubscriptions"].([]any); ok {
+		user.PushSubscriptions = make([]PushSubscription, 0, len(pushSubscriptions))
+		for _, s := range pushSubscriptions {
+			if subMap, ok := s.(map[string]any); ok {
+				user.PushSubscriptions = append(user.PushSubscriptions, mapToPushSubscription(subMap))
+			}
+		}
+	}
+
+	// Parse devices
+	if devices, ok := data["devices"].([]any); ok {
+		user.Devices = make([]Device, 0, len(devices))
+		for _, d := range devices {
+			if deviceMap, ok := d.(map[string]any); ok {
+				user.Devices = append(user.Devices, mapToDevice(deviceMap))
+			}
+		}
+	}
+
 	return user, nil
 }
 
@@ -71,6 +71,9 @@ func TestFirestoreRepository_Integration(t *testing.T) {
 		if id == "" {
 			t.Fatal("Create returned empty ID")
 		}
+		if id != testUID {
+			t.Errorf("document ID = %s, want the UID %s", id, testUID)
+		}
 		createdID = id
 		t.Logf("Created user with ID: %s", id)
 	})
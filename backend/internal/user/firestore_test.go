@@ -174,6 +174,30 @@ func TestUserToMap(t *testing.T) {
 		}
 	})
 
+	t.Run("includes role only when set", func(t *testing.T) {
+		user := User{UID: "uid-admin", Plan: PlanFree, Role: RoleAdmin}
+		if data := userToMap(user); data["role"] != RoleAdmin {
+			t.Errorf("Expected role 'admin', got %v", data["role"])
+		}
+
+		user.Role = ""
+		if _, exists := userToMap(user)["role"]; exists {
+			t.Error("role should not be included when not set")
+		}
+	})
+
+	t.Run("includes accepted terms only when set", func(t *testing.T) {
+		user := User{UID: "uid-terms", Plan: PlanFree, TermsVersion: "2026-04", TermsAcceptedAt: &now}
+		data := userToMap(user)
+		if data["termsVersion"] != "2026-04" || data["termsAcceptedAt"] != now {
+			t.Errorf("Expected the accepted terms, got %v and %v", data["termsVersion"], data["termsAcceptedAt"])
+		}
+
+		if data := userToMap(User{UID: "uid-no-terms"}); data["termsVersion"] != nil || data["termsAcceptedAt"] != nil {
+			t.Error("terms should not be included when not accepted")
+		}
+	})
+
 	t.Run("omits Stripe fields when not set", func(t *testing.T) {
 		user := User{
 			ID:          "doc-id",
@@ -392,6 +416,26 @@ func TestUserCopy(t *testing.T) {
 	})
 }
 
+func TestUser_IsAdmin(t *testing.T) {
+	tests := []struct {
+		role string
+		want bool
+	}{
+		{"", false},
+		{RoleUser, false},
+		{RoleAdmin, true},
+	}
+	for _, tt := range tests {
+		u := User{Role: tt.role}
+		if got := u.IsAdmin(); got != tt.want {
+			t.Errorf("User{Role: %q}.IsAdmin() = %v, want %v", tt.role, got, tt.want)
+		}
+		if copied := u.Copy(); copied.Role != tt.role {
+			t.Errorf("Copy() Role = %q, want %q", copied.Role, tt.role)
+		}
+	}
+}
+
 func TestLinkedProviderCopy(t *testing.T) {
 	now := time.Now().UTC()
 
@@ -0,0 +1,198 @@
+package user
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/otiai10/namazu/backend/internal/store"
+)
+
+// MemoryRepository implements Repository in process memory.
+// Used under --test-mode and NAMAZU_STORE_TYPE=memory; nothing survives a restart.
+type MemoryRepository struct {
+	mu    sync.RWMutex
+	users map[string]User
+}
+
+// Compile-time interface check
+var _ Repository = (*MemoryRepository)(nil)
+
+// NewMemoryRepository creates an empty MemoryRepository
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{users: map[string]User{}}
+}
+
+// Create creates a new user and returns its generated ID.
+// It returns ErrDuplicateUID if a user with the same UID exists.
+func (r *MemoryRepository) Create(ctx context.Context, user User) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.findLocked(func(u User) bool { return u.UID == user.UID }) != nil {
+		return "", ErrDuplicateUID
+	}
+
+	stored := user.Copy()
+	stored.ID = store.NewSQLID()
+	r.users[stored.ID] = stored
+	return stored.ID, nil
+}
+
+// Get retrieves a user by ID. It returns nil and no error if not found.
+func (r *MemoryRepository) Get(ctx context.Context, id string) (*User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	user, ok := r.users[id]
+	if !ok {
+		return nil, nil
+	}
+	copied := user.Copy()
+	return &copied, nil
+}
+
+// GetByUID retrieves a user by Identity Platform UID. It returns nil and no error if not found.
+func (r *MemoryRepository) GetByUID(ctx context.Context, uid string) (*User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.findLocked(func(u User) bool { return u.UID == uid }), nil
+}
+
+// GetByStripeCustomerID retrieves a user by Stripe customer ID. It returns nil and no error if not found.
+func (r *MemoryRepository) GetByStripeCustomerID(ctx context.Context, customerID string) (*User, error) {
+	if customerID == "" {
+		return nil, nil // Users without a customer share the empty value
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.findLocked(func(u User) bool { return u.StripeCustomerID == customerID }), nil
+}
+
+// Update replaces an existing user. It returns ErrNotFound if missing.
+func (r *MemoryRepository) Update(ctx context.Context, id string, user User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.users[id]; !ok {
+		return ErrNotFound
+	}
+	stored := user.Copy()
+	stored.ID = id
+	r.users[id] = stored
+	return nil
+}
+
+// Delete removes a user. It returns ErrNotFound if missing.
+func (r *MemoryRepository) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.users[id]; !ok {
+		return ErrNotFound
+	}
+	delete(r.users, id)
+	return nil
+}
+
+// UpdateLastLogin updates the last login timestamp. It returns ErrNotFound if missing.
+func (r *MemoryRepository) UpdateLastLogin(ctx context.Context, id string, t time.Time) error {
+	return r.modify(id, func(user *User) error {
+		user.LastLoginAt = t
+		return nil
+	})
+}
+
+// AddProvider links a provider to a user.
+// It returns ErrNotFound if the user is missing and ErrProviderExists if already linked.
+func (r *MemoryRepository) AddProvider(ctx context.Context, id string, provider LinkedProvider) error {
+	return r.modify(id, func(user *User) error {
+		if findProviderIndex(user.Providers, provider.ProviderID) >= 0 {
+			return ErrProviderExists
+		}
+		user.Providers = append(user.Providers, provider)
+		return nil
+	})
+}
+
+// RemoveProvider unlinks a provider from a user.
+// It returns ErrNotFound if the user is missing and ErrProviderNotFound if not linked.
+func (r *MemoryRepository) RemoveProvider(ctx context.Context, id string, providerID string) error {
+	return r.modify(id, func(user *User) error {
+		idx := findProviderIndex(user.Providers, providerID)
+		if idx < 0 {
+			return ErrProviderNotFound
+		}
+		user.Providers = append(user.Providers[:idx:idx], user.Providers[idx+1:]...)
+		return nil
+	})
+}
+
+// AddPushSubscription stores a browser push endpoint, replacing one with the same endpoint.
+// It returns ErrNotFound if the user is missing.
+func (r *MemoryRepository) AddPushSubscription(ctx context.Context, id string, sub PushSubscription) error {
+	return r.modify(id, func(user *User) error {
+		user.PushSubscriptions = upsertPushSubscription(user.PushSubscriptions, sub)
+		return nil
+	})
+}
+
+// RemovePushSubscription removes a browser push endpoint.
+// It returns ErrNotFound if the user is missing and ErrPushSubscriptionNotFound if not registered.
+func (r *MemoryRepository) RemovePushSubscription(ctx context.Context, id string, endpoint string) error {
+	return r.modify(id, func(user *User) error {
+		idx := findPushSubscriptionIndex(user.PushSubscriptions, endpoint)
+		if idx < 0 {
+			return ErrPushSubscriptionNotFound
+		}
+		user.PushSubscriptions = append(user.PushSubscriptions[:idx:idx], user.PushSubscriptions[idx+1:]...)
+		return nil
+	})
+}
+
+// AddDevice stores an FCM registration token, replacing a device with the same token.
+// It returns ErrNotFound if the user is missing.
+func (r *MemoryRepository) AddDevice(ctx context.Context, id string, device Device) error {
+	return r.modify(id, func(user *User) error {
+		user.Devices = upsertDevice(user.Devices, device)
+		return nil
+	})
+}
+
+// RemoveDevice removes an FCM registration token.
+// It returns ErrNotFound if the user is missing and ErrDeviceNotFound if not registered.
+func (r *MemoryRepository) RemoveDevice(ctx context.Context, id string, token string) error {
+	return r.modify(id, func(user *User) error {
+		idx := findDeviceIndex(user.Devices, token)
+		if idx < 0 {
+			return ErrDeviceNotFound
+		}
+		user.Devices = append(user.Devices[:idx:idx], user.Devices[idx+1:]...)
+		return nil
+	})
+}
+
+// modify applies fn to a copy of a stored user and saves it with a new UpdatedAt
+func (r *MemoryRepository) modify(id string, fn func(*User) error) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored, ok := r.users[id]
+	if !ok {
+		return ErrNotFound
+	}
+
+	user := stored.Copy()
+	if err := fn(&user); err != nil {
+		return err
+	}
+	user.UpdatedAt = time.Now().UTC()
+	r.users[id] = user
+	return nil
+}
+
+// findLocked returns a copy of the first user matching fn. The caller holds r.mu.
+func (r *MemoryRepository) findLocked(fn func(User) bool) *User {
+	for _, user := range r.users {
+		if fn(user) {
+			copied := user.Copy()
+			return &copied
+		}
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package user
+
+import (
+	"errors"
+	"time"
+)
+
+// MaxPushSubscriptions is the number of browser push endpoints kept per user.
+// Registering another one drops the oldest.
+const MaxPushSubscriptions = 10
+
+// ErrPushSubscriptionNotFound is returned when trying to remove an unknown push endpoint
+var ErrPushSubscriptionNotFound = errors.New("push subscription not found for user")
+
+// PushSubscription is a browser's Web Push endpoint (PushSubscription.toJSON() in the Push API)
+type PushSubscription struct {
+	Endpoint  string    `firestore:"endpoint" json:"endpoint"`
+	P256DH    string    `firestore:"p256dh" json:"p256dh"` // Browser's ECDH public key, base64url
+	Auth      string    `firestore:"auth" json:"auth"`     // Authentication secret, base64url
+	UserAgent string    `firestore:"userAgent,omitempty" json:"userAgent,omitempty"`
+	CreatedAt time.Time `firestore:"createdAt" json:"createdAt"`
+}
+
+// upsertPushSubscription returns subs with sub added, replacing any existing
+// subscription for the same endpoint and keeping at most MaxPushSubscriptions
+func upsertPushSubscription(subs []PushSubscription, sub PushSubscription) []PushSubscription {
+	result := make([]PushSubscription, 0, len(subs)+1)
+	for _, existing := range subs {
+		if existing.Endpoint != sub.Endpoint {
+			result = append(result, existing)
+		}
+	}
+	result = append(result, sub)
+	if len(result) > MaxPushSubscriptions {
+		result = result[len(result)-MaxPushSubscriptions:]
+	}
+	return result
+}
+
+// findPushSubscriptionIndex finds the index of a push subscription by its endpoint
+// Returns -1 if not found
+func findPushSubscriptionIndex(subs []PushSubscription, endpoint string) int {
+	for i, sub := range subs {
+		if sub.Endpoint == endpoint {
+			return i
+		}
+	}
+	return -1
+}
+
+// pushSubscriptionToMap converts a PushSubscription to a map for Firestore storage
+func pushSubscriptionToMap(sub PushSubscription) map[string]any {
+	data := map[string]any{
+		"endpoint":  sub.Endpoint,
+		"p256dh":    sub.P256DH,
+		"auth":      sub.Auth,
+		"createdAt": sub.CreatedAt,
+	}
+	if sub.UserAgent != "" {
+		data["userAgent"] = sub.UserAgent
+	}
+	return data
+}
+
+// mapToPushSubscription converts a map to a PushSubscription
+func mapToPushSubscription(data map[string]any) PushSubscription {
+	sub := PushSubscription{}
+	if endpoint, ok := data["endpoint"].(string); ok {
+		sub.Endpoint = endpoint
+	}
+	if p256dh, ok := data["p256dh"].(string); ok {
+		sub.P256DH = p256dh
+	}
+	if auth, ok := data["auth"].(string); ok {
+		sub.Auth = auth
+	}
+	if userAgent, ok := data["userAgent"].(string); ok {
+		sub.UserAgent = userAgent
+	}
+	if createdAt, ok := data["createdAt"].(time.Time); ok {
+		sub.CreatedAt = createdAt
+	}
+	return sub
+}
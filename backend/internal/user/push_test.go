@@ -0,0 +1,44 @@
+package user
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestUpsertPushSubscription(t *testing.T) {
+	var subs []PushSubscription
+	for i := 0; i < MaxPushSubscriptions+2; i++ {
+		subs = upsertPushSubscription(subs, PushSubscription{Endpoint: fmt.Sprintf("https://push.example.com/%d", i)})
+	}
+	if len(subs) != MaxPushSubscriptions || subs[0].Endpoint != "https://push.example.com/2" {
+		t.Fatalf("expected the oldest to be dropped, got %d starting with %s", len(subs), subs[0].Endpoint)
+	}
+
+	subs = upsertPushSubscription(subs, PushSubscription{Endpoint: "https://push.example.com/5", Auth: "new"})
+	if len(subs) != MaxPushSubscriptions {
+		t.Errorf("re-registering an endpoint changed the count to %d", len(subs))
+	}
+	if last := subs[len(subs)-1]; last.Endpoint != "https://push.example.com/5" || last.Auth != "new" {
+		t.Errorf("expected the re-registered endpoint to be replaced and moved last, got %+v", last)
+	}
+	if idx := findPushSubscriptionIndex(subs, "https://push.example.com/0"); idx != -1 {
+		t.Errorf("findPushSubscriptionIndex(dropped) = %d, want -1", idx)
+	}
+}
+
+func TestPushSubscriptionMap(t *testing.T) {
+	sub := PushSubscription{
+		Endpoint:  "https://fcm.googleapis.com/fcm/send/abc",
+		P256DH:    "BNcR",
+		Auth:      "tBHI",
+		UserAgent: "Firefox",
+		CreatedAt: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	if got := mapToPushSubscription(pushSubscriptionToMap(sub)); got != sub {
+		t.Errorf("round trip = %+v, want %+v", got, sub)
+	}
+	if _, ok := pushSubscriptionToMap(PushSubscription{Endpoint: "x"})["userAgent"]; ok {
+		t.Error("expected an empty user agent to be omitted")
+	}
+}
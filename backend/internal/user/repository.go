@@ -51,6 +51,16 @@ type Repository interface {
 	//   - Error if user not found or Firestore operation fails
 	Update(ctx context.Context, id string, user User) error
 
+	// Delete removes a user
+	//
+	// Parameters:
+	//   - ctx: Context for cancellation control
+	//   - id: User document ID to delete
+	//
+	// Returns:
+	//   - Error if user not found or Firestore operation fails
+	Delete(ctx context.Context, id string) error
+
 	// UpdateLastLogin updates the LastLoginAt field
 	//
 	// Parameters:
@@ -84,6 +94,54 @@ type Repository interface {
 	//   - Error if user not found, provider not found, or Firestore operation fails
 	RemoveProvider(ctx context.Context, id string, providerID string) error
 
+	// AddPushSubscription stores a browser push endpoint for a user.
+	// An existing subscription for the same endpoint is replaced, and the
+	// oldest is dropped beyond MaxPushSubscriptions.
+	//
+	// Parameters:
+	//   - ctx: Context for cancellation control
+	//   - id: User document ID
+	//   - sub: Push subscription to add
+	//
+	// Returns:
+	//   - Error if user not found or Firestore operation fails
+	AddPushSubscription(ctx context.Context, id string, sub PushSubscription) error
+
+	// RemovePushSubscription removes a browser push endpoint from a user
+	//
+	// Parameters:
+	//   - ctx: Context for cancellation control
+	//   - id: User document ID
+	//   - endpoint: Endpoint URL of the push subscription
+	//
+	// Returns:
+	//   - Error if user not found, endpoint not found, or Firestore operation fails
+	RemovePushSubscription(ctx context.Context, id string, endpoint string) error
+
+	// AddDevice stores an FCM registration token for a user.
+	// An existing device with the same token is replaced, and the
+	// oldest is dropped beyond MaxDevices.
+	//
+	// Parameters:
+	//   - ctx: Context for cancellation control
+	//   - id: User document ID
+	//   - device: Device to add
+	//
+	// Returns:
+	//   - Error if user not found or Firestore operation fails
+	AddDevice(ctx context.Context, id string, device Device) error
+
+	// RemoveDevice removes an FCM registration token from a user
+	//
+	// Parameters:
+	//   - ctx: Context for cancellation control
+	//   - id: User document ID
+	//   - token: Registration token of the device
+	//
+	// Returns:
+	//   - Error if user not found, token not found, or Firestore operation fails
+	RemoveDevice(ctx context.Context, id string, token string) error
+
 	// GetByStripeCustomerID retrieves a user by Stripe customer ID
 	//
 	// Parameters:
@@ -0,0 +1,261 @@
+package user
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/otiai10/namazu/backend/internal/store"
+)
+
+// SQLRepository implements Repository on SQLite or Postgres.
+// Each user is stored as a JSON document; the UID and Stripe customer ID
+// are kept in their own columns for lookups.
+type SQLRepository struct {
+	client *store.SQLClient
+}
+
+// Compile-time interface check
+var _ Repository = (*SQLRepository)(nil)
+
+// NewSQLRepository creates a new SQLRepository
+func NewSQLRepository(client *store.SQLClient) *SQLRepository {
+	return &SQLRepository{client: client}
+}
+
+// Create creates a new user and returns its generated ID.
+// It returns ErrDuplicateUID if a user with the same UID exists.
+func (r *SQLRepository) Create(ctx context.Context, user User) (string, error) {
+	existing, err := r.GetByUID(ctx, user.UID)
+	if err != nil {
+		return "", fmt.Errorf("failed to check existing user: %w", err)
+	}
+	if existing != nil {
+		return "", ErrDuplicateUID
+	}
+
+	id := store.NewSQLID()
+	data, err := encodeUser(user)
+	if err != nil {
+		return "", err
+	}
+	_, err = r.client.DB().ExecContext(ctx, r.client.Rebind(
+		`INSERT INTO users (id, uid, stripe_customer_id, data) VALUES (?, ?, ?, ?)`),
+		id, user.UID, user.StripeCustomerID, data)
+	if err != nil {
+		return "", fmt.Errorf("failed to create user: %w", err)
+	}
+	return id, nil
+}
+
+// Get retrieves a user by ID. It returns nil and no error if not found.
+func (r *SQLRepository) Get(ctx context.Context, id string) (*User, error) {
+	return r.queryOne(ctx, `SELECT id, data FROM users WHERE id = ?`, id)
+}
+
+// GetByUID retrieves a user by Identity Platform UID. It returns nil and no error if not found.
+func (r *SQLRepository) GetByUID(ctx context.Context, uid string) (*User, error) {
+	return r.queryOne(ctx, `SELECT id, data FROM users WHERE uid = ?`, uid)
+}
+
+// GetByStripeCustomerID retrieves a user by Stripe customer ID. It returns nil and no error if not found.
+func (r *SQLRepository) GetByStripeCustomerID(ctx context.Context, customerID string) (*User, error) {
+	if customerID == "" {
+		return nil, nil // Users without a customer share the empty value
+	}
+	return r.queryOne(ctx, `SELECT id, data FROM users WHERE stripe_customer_id = ? ORDER BY id LIMIT 1`, customerID)
+}
+
+// Update replaces an existing user. It returns ErrNotFound if missing.
+func (r *SQLRepository) Update(ctx context.Context, id string, user User) error {
+	data, err := encodeUser(user)
+	if err != nil {
+		return err
+	}
+	result, err := r.client.DB().ExecContext(ctx, r.client.Rebind(
+		`UPDATE users SET uid = ?, stripe_customer_id = ?, data = ? WHERE id = ?`),
+		user.UID, user.StripeCustomerID, data, id)
+	if err != nil {
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Delete removes a user. It returns ErrNotFound if missing.
+func (r *SQLRepository) Delete(ctx context.Context, id string) error {
+	result, err := r.client.DB().ExecContext(ctx, r.client.Rebind(`DELETE FROM users WHERE id = ?`), id)
+	if err != nil {
+		return fmt.Errorf("failed to delete user: %w", err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to delete user: %w", err)
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// UpdateLastLogin updates the last login timestamp. It returns ErrNotFound if missing.
+func (r *SQLRepository) UpdateLastLogin(ctx context.Context, id string, t time.Time) error {
+	return r.modify(ctx, id, func(user *User) error {
+		user.LastLoginAt = t
+		return nil
+	})
+}
+
+// AddProvider links a provider to a user.
+// It returns ErrNotFound if the user is missing and ErrProviderExists if already linked.
+func (r *SQLRepository) AddProvider(ctx context.Context, id string, provider LinkedProvider) error {
+	return r.modify(ctx, id, func(user *User) error {
+		if findProviderIndex(user.Providers, provider.ProviderID) >= 0 {
+			return ErrProviderExists
+		}
+		user.Providers = append(user.Providers, provider)
+		return nil
+	})
+}
+
+// RemoveProvider unlinks a provider from a user.
+// It returns ErrNotFound if the user is missing and ErrProviderNotFound if not linked.
+func (r *SQLRepository) RemoveProvider(ctx context.Context, id string, providerID string) error {
+	return r.modify(ctx, id, func(user *User) error {
+		idx := findProviderIndex(user.Providers, providerID)
+		if idx < 0 {
+			return ErrProviderNotFound
+		}
+		user.Providers = append(user.Providers[:idx:idx], user.Providers[idx+1:]...)
+		return nil
+	})
+}
+
+// AddPushSubscription stores a browser push endpoint, replacing one with the same endpoint.
+// It returns ErrNotFound if the user is missing.
+func (r *SQLRepository) AddPushSubscription(ctx context.Context, id string, sub PushSubscription) error {
+	return r.modify(ctx, id, func(user *User) error {
+		user.PushSubscriptions = upsertPushSubscription(user.PushSubscriptions, sub)
+		return nil
+	})
+}
+
+// RemovePushSubscription removes a browser push endpoint.
+// It returns ErrNotFound if the user is missing and ErrPushSubscriptionNotFound if not registered.
+func (r *SQLRepository) RemovePushSubscription(ctx context.Context, id string, endpoint string) error {
+	return r.modify(ctx, id, func(user *User) error {
+		idx := findPushSubscriptionIndex(user.PushSubscriptions, endpoint)
+		if idx < 0 {
+			return ErrPushSubscriptionNotFound
+		}
+		user.PushSubscriptions = append(user.PushSubscriptions[:idx:idx], user.PushSubscriptions[idx+1:]...)
+		return nil
+	})
+}
+
+// AddDevice stores an FCM registration token, replacing a device with the same token.
+// It returns ErrNotFound if the user is missing.
+func (r *SQLRepository) AddDevice(ctx context.Context, id string, device Device) error {
+	return r.modify(ctx, id, func(user *User) error {
+		user.Devices = upsertDevice(user.Devices, device)
+		return nil
+	})
+}
+
+// RemoveDevice removes an FCM registration token.
+// It returns ErrNotFound if the user is missing and ErrDeviceNotFound if not registered.
+func (r *SQLRepository) RemoveDevice(ctx context.Context, id string, token string) error {
+	return r.modify(ctx, id, func(user *User) error {
+		idx := findDeviceIndex(user.Devices, token)
+		if idx < 0 {
+			return ErrDeviceNotFound
+		}
+		user.Devices = append(user.Devices[:idx:idx], user.Devices[idx+1:]...)
+		return nil
+	})
+}
+
+// modify applies fn to a stored user in a transaction and sets UpdatedAt
+func (r *SQLRepository) modify(ctx context.Context, id string, fn func(*User) error) error {
+	tx, err := r.client.DB().BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var data string
+	err = tx.QueryRowContext(ctx, r.client.Rebind(`SELECT data FROM users WHERE id = ?`), id).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	user, err := decodeUser(id, data)
+	if err != nil {
+		return err
+	}
+
+	if err := fn(&user); err != nil {
+		return err
+	}
+	user.UpdatedAt = time.Now().UTC()
+
+	encoded, err := encodeUser(user)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, r.client.Rebind(`UPDATE users SET data = ? WHERE id = ?`), encoded, id); err != nil {
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+	return nil
+}
+
+// queryOne runs a SELECT of (id, data) and decodes the first row, if any
+func (r *SQLRepository) queryOne(ctx context.Context, query string, arg string) (*User, error) {
+	var id, data string
+	err := r.client.DB().QueryRowContext(ctx, r.client.Rebind(query), arg).Scan(&id, &data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	user, err := decodeUser(id, data)
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// encodeUser serializes a user without its ID, which is the row key
+func encodeUser(user User) (string, error) {
+	user.ID = ""
+	data, err := json.Marshal(user)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode user: %w", err)
+	}
+	return string(data), nil
+}
+
+// decodeUser restores a user stored by encodeUser
+func decodeUser(id, data string) (User, error) {
+	var user User
+	if err := json.Unmarshal([]byte(data), &user); err != nil {
+		return User{}, fmt.Errorf("failed to decode user %s: %w", id, err)
+	}
+	user.ID = id
+	return user, nil
+}
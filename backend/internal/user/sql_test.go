@@ -0,0 +1,170 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/otiai10/namazu/backend/internal/store"
+)
+
+// openTestSQL returns an in-memory SQLite client, plus a Postgres client when
+// NAMAZU_TEST_POSTGRES_DSN is set
+func openTestSQL(t *testing.T) map[string]*store.SQLClient {
+	t.Helper()
+	ctx := context.Background()
+	clients := map[string]*store.SQLClient{}
+
+	sqlite, err := store.OpenSQL(ctx, store.DialectSQLite, ":memory:")
+	if err != nil {
+		t.Fatalf("OpenSQL(sqlite) error = %v", err)
+	}
+	t.Cleanup(func() { sqlite.Close() })
+	clients[store.DialectSQLite] = sqlite
+
+	if dsn := os.Getenv("NAMAZU_TEST_POSTGRES_DSN"); dsn != "" {
+		pg, err := store.OpenSQL(ctx, store.DialectPostgres, dsn)
+		if err != nil {
+			t.Fatalf("OpenSQL(postgres) error = %v", err)
+		}
+		t.Cleanup(func() { pg.Close() })
+		if _, err := pg.DB().ExecContext(ctx, "DELETE FROM users"); err != nil {
+			t.Fatal(err)
+		}
+		clients[store.DialectPostgres] = pg
+	}
+	return clients
+}
+
+func TestSQLRepository(t *testing.T) {
+	for dialect, client := range openTestSQL(t) {
+		t.Run(dialect, func(t *testing.T) {
+			ctx := context.Background()
+			repo := NewSQLRepository(client)
+			now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+			id, err := repo.Create(ctx, User{
+				UID:       "uid-1",
+				Email:     "user@example.com",
+				Plan:      PlanFree,
+				Providers: []LinkedProvider{{ProviderID: ProviderGoogle, Subject: "g-1", LinkedAt: now}},
+				CreatedAt: now,
+			})
+			if err != nil || id == "" {
+				t.Fatalf("Create() = %q, %v", id, err)
+			}
+			if _, err := repo.Create(ctx, User{UID: "uid-1"}); !errors.Is(err, ErrDuplicateUID) {
+				t.Errorf("Create(duplicate) error = %v, want ErrDuplicateUID", err)
+			}
+			if _, err := repo.Create(ctx, User{UID: "uid-2"}); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := repo.Get(ctx, id)
+			if err != nil || got == nil || got.ID != id || got.Email != "user@example.com" || len(got.Providers) != 1 {
+				t.Fatalf("Get() = %+v, %v", got, err)
+			}
+			if byUID, err := repo.GetByUID(ctx, "uid-1"); err != nil || byUID == nil || byUID.ID != id {
+				t.Errorf("GetByUID() = %+v, %v", byUID, err)
+			}
+			if missing, err := repo.Get(ctx, "missing"); err != nil || missing != nil {
+				t.Errorf("Get(missing) = %v, %v, want nil, nil", missing, err)
+			}
+
+			// Users without a Stripe customer must not match the empty ID
+			if none, err := repo.GetByStripeCustomerID(ctx, ""); err != nil || none != nil {
+				t.Errorf("GetByStripeCustomerID(\"\") = %v, %v, want nil, nil", none, err)
+			}
+			got.Plan = PlanPro
+			got.StripeCustomerID = "cus_123"
+			if err := repo.Update(ctx, id, *got); err != nil {
+				t.Fatalf("Update() error = %v", err)
+			}
+			if customer, err := repo.GetByStripeCustomerID(ctx, "cus_123"); err != nil || customer == nil || customer.Plan != PlanPro {
+				t.Errorf("GetByStripeCustomerID() = %+v, %v", customer, err)
+			}
+			if err := repo.Update(ctx, "missing", *got); !errors.Is(err, ErrNotFound) {
+				t.Errorf("Update(missing) error = %v, want ErrNotFound", err)
+			}
+
+			login := now.Add(24 * time.Hour)
+			if err := repo.UpdateLastLogin(ctx, id, login); err != nil {
+				t.Fatalf("UpdateLastLogin() error = %v", err)
+			}
+			if u, _ := repo.Get(ctx, id); !u.LastLoginAt.Equal(login) || u.UpdatedAt.IsZero() {
+				t.Errorf("LastLoginAt = %v, UpdatedAt = %v", u.LastLoginAt, u.UpdatedAt)
+			}
+			if err := repo.UpdateLastLogin(ctx, "missing", login); !errors.Is(err, ErrNotFound) {
+				t.Errorf("UpdateLastLogin(missing) error = %v, want ErrNotFound", err)
+			}
+
+			apple := LinkedProvider{ProviderID: ProviderApple, Subject: "a-1", LinkedAt: now}
+			if err := repo.AddProvider(ctx, id, apple); err != nil {
+				t.Fatalf("AddProvider() error = %v", err)
+			}
+			if err := repo.AddProvider(ctx, id, apple); !errors.Is(err, ErrProviderExists) {
+				t.Errorf("AddProvider(duplicate) error = %v, want ErrProviderExists", err)
+			}
+			if err := repo.RemoveProvider(ctx, id, ProviderGoogle); err != nil {
+				t.Fatalf("RemoveProvider() error = %v", err)
+			}
+			if err := repo.RemoveProvider(ctx, id, ProviderGoogle); !errors.Is(err, ErrProviderNotFound) {
+				t.Errorf("RemoveProvider(unlinked) error = %v, want ErrProviderNotFound", err)
+			}
+			if u, _ := repo.Get(ctx, id); len(u.Providers) != 1 || u.Providers[0].ProviderID != ProviderApple {
+				t.Errorf("Providers = %+v, want only apple", u.Providers)
+			}
+
+			push := PushSubscription{Endpoint: "https://push.example.com/1", P256DH: "key", Auth: "auth", CreatedAt: now}
+			if err := repo.AddPushSubscription(ctx, id, push); err != nil {
+				t.Fatalf("AddPushSubscription() error = %v", err)
+			}
+			push.Auth = "rotated"
+			if err := repo.AddPushSubscription(ctx, id, push); err != nil {
+				t.Fatalf("AddPushSubscription(again) error = %v", err)
+			}
+			if u, _ := repo.Get(ctx, id); len(u.PushSubscriptions) != 1 || u.PushSubscriptions[0].Auth != "rotated" {
+				t.Errorf("PushSubscriptions = %+v, want the re-registered endpoint once", u.PushSubscriptions)
+			}
+			if err := repo.AddPushSubscription(ctx, "missing", push); !errors.Is(err, ErrNotFound) {
+				t.Errorf("AddPushSubscription(missing) error = %v, want ErrNotFound", err)
+			}
+			if err := repo.RemovePushSubscription(ctx, id, push.Endpoint); err != nil {
+				t.Fatalf("RemovePushSubscription() error = %v", err)
+			}
+			if err := repo.RemovePushSubscription(ctx, id, push.Endpoint); !errors.Is(err, ErrPushSubscriptionNotFound) {
+				t.Errorf("RemovePushSubscription(removed) error = %v, want ErrPushSubscriptionNotFound", err)
+			}
+
+			device := Device{Token: "fcm-token", Platform: PlatformAndroid, Prefectures: []string{"石川県"}, CreatedAt: now}
+			if err := repo.AddDevice(ctx, id, device); err != nil {
+				t.Fatalf("AddDevice() error = %v", err)
+			}
+			device.MinScale = 40
+			if err := repo.AddDevice(ctx, id, device); err != nil {
+				t.Fatalf("AddDevice(again) error = %v", err)
+			}
+			if u, _ := repo.Get(ctx, id); len(u.Devices) != 1 || u.Devices[0].MinScale != 40 || u.Devices[0].Prefectures[0] != "石川県" {
+				t.Errorf("Devices = %+v, want the re-registered token once", u.Devices)
+			}
+			if err := repo.RemoveDevice(ctx, id, device.Token); err != nil {
+				t.Fatalf("RemoveDevice() error = %v", err)
+			}
+			if err := repo.RemoveDevice(ctx, id, device.Token); !errors.Is(err, ErrDeviceNotFound) {
+				t.Errorf("RemoveDevice(removed) error = %v, want ErrDeviceNotFound", err)
+			}
+
+			if err := repo.Delete(ctx, id); err != nil {
+				t.Fatalf("Delete() error = %v", err)
+			}
+			if u, err := repo.GetByUID(ctx, "uid-1"); err != nil || u != nil {
+				t.Errorf("GetByUID(deleted) = %v, %v, want nil, nil", u, err)
+			}
+			if err := repo.Delete(ctx, id); !errors.Is(err, ErrNotFound) {
+				t.Errorf("Delete(deleted) error = %v, want ErrNotFound", err)
+			}
+		})
+	}
+}
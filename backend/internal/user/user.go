@@ -6,22 +6,31 @@ import (
 
 // User represents an authenticated user stored in Firestore
 type User struct {
-	ID          string           `firestore:"-" json:"id,omitempty"`
-	UID         string           `firestore:"uid" json:"uid"` // Identity Platform UID
-	Email       string           `firestore:"email" json:"email"`
-	DisplayName string           `firestore:"displayName" json:"displayName"`
-	PictureURL  string           `firestore:"pictureUrl,omitempty" json:"pictureUrl,omitempty"`
-	Plan        string           `firestore:"plan" json:"plan"`           // "free" | "pro"
-	Providers   []LinkedProvider `firestore:"providers" json:"providers"` // Account Linking
-	CreatedAt   time.Time        `firestore:"createdAt" json:"createdAt"`
-	UpdatedAt   time.Time        `firestore:"updatedAt" json:"updatedAt"`
-	LastLoginAt time.Time        `firestore:"lastLoginAt" json:"lastLoginAt"`
+	ID                string             `firestore:"-" json:"id,omitempty"`
+	UID               string             `firestore:"uid" json:"uid"` // Identity Platform UID
+	Email             string             `firestore:"email" json:"email"`
+	DisplayName       string             `firestore:"displayName" json:"displayName"`
+	PictureURL        string             `firestore:"pictureUrl,omitempty" json:"pictureUrl,omitempty"`
+	Plan              string             `firestore:"plan" json:"plan"`                                               // "free" | "pro" | a plan of the catalog
+	Role              string             `firestore:"role,omitempty" json:"role,omitempty"`                           // "user" | "admin" (empty means user)
+	Providers         []LinkedProvider   `firestore:"providers" json:"providers"`                                     // Account Linking
+	PushSubscriptions []PushSubscription `firestore:"pushSubscriptions,omitempty" json:"pushSubscriptions,omitempty"` // Web Push endpoints
+	Devices           []Device           `firestore:"devices,omitempty" json:"devices,omitempty"`                     // FCM registration tokens
+	CreatedAt         time.Time          `firestore:"createdAt" json:"createdAt"`
+	UpdatedAt         time.Time          `firestore:"updatedAt" json:"updatedAt"`
+	LastLoginAt       time.Time          `firestore:"lastLoginAt" json:"lastLoginAt"`
+
+	// Terms of service
+	TermsVersion    string     `firestore:"termsVersion,omitempty" json:"termsVersion,omitempty"`       // Last accepted version
+	TermsAcceptedAt *time.Time `firestore:"termsAcceptedAt,omitempty" json:"termsAcceptedAt,omitempty"` // When TermsVersion was accepted
 
 	// Stripe integration fields
 	StripeCustomerID   string    `firestore:"stripeCustomerId,omitempty" json:"stripeCustomerId,omitempty"`
 	SubscriptionID     string    `firestore:"subscriptionId,omitempty" json:"subscriptionId,omitempty"`
 	SubscriptionStatus string    `firestore:"subscriptionStatus,omitempty" json:"subscriptionStatus,omitempty"` // "active" | "canceled" | "past_due"
 	SubscriptionEndsAt time.Time `firestore:"subscriptionEndsAt,omitempty" json:"subscriptionEndsAt,omitempty"`
+	CancelAtPeriodEnd  bool      `firestore:"cancelAtPeriodEnd,omitempty" json:"cancelAtPeriodEnd,omitempty"` // Downgrades to free at SubscriptionEndsAt
+	BillingInterval    string    `firestore:"billingInterval,omitempty" json:"billingInterval,omitempty"`     // "month" | "year"
 }
 
 // LinkedProvider represents a linked authentication provider
@@ -39,11 +48,24 @@ const (
 	PlanPro  = "pro"
 )
 
+// Role constants for access control
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+// IsAdmin reports whether the user has the admin role
+func (u User) IsAdmin() bool {
+	return u.Role == RoleAdmin
+}
+
 // SubscriptionStatus constants for Stripe subscription states
 const (
 	SubscriptionStatusActive   = "active"
 	SubscriptionStatusCanceled = "canceled"
 	SubscriptionStatusPastDue  = "past_due"
+	SubscriptionStatusTrialing = "trialing"
+	SubscriptionStatusUnpaid   = "unpaid"
 )
 
 // ProviderID constants for authentication providers
@@ -62,20 +84,38 @@ func (u User) Copy() User {
 		DisplayName:        u.DisplayName,
 		PictureURL:         u.PictureURL,
 		Plan:               u.Plan,
+		Role:               u.Role,
 		CreatedAt:          u.CreatedAt,
 		UpdatedAt:          u.UpdatedAt,
 		LastLoginAt:        u.LastLoginAt,
+		TermsVersion:       u.TermsVersion,
 		StripeCustomerID:   u.StripeCustomerID,
 		SubscriptionID:     u.SubscriptionID,
 		SubscriptionStatus: u.SubscriptionStatus,
 		SubscriptionEndsAt: u.SubscriptionEndsAt,
+		CancelAtPeriodEnd:  u.CancelAtPeriodEnd,
+		BillingInterval:    u.BillingInterval,
 	}
 
 	// Deep copy providers slice
+	if u.TermsAcceptedAt != nil {
+		acceptedAt := *u.TermsAcceptedAt
+		copied.TermsAcceptedAt = &acceptedAt
+	}
 	if u.Providers != nil {
 		copied.Providers = make([]LinkedProvider, len(u.Providers))
 		copy(copied.Providers, u.Providers)
 	}
+	if u.PushSubscriptions != nil {
+		copied.PushSubscriptions = make([]PushSubscription, len(u.PushSubscriptions))
+		copy(copied.PushSubscriptions, u.PushSubscriptions)
+	}
+	if u.Devices != nil {
+		copied.Devices = make([]Device, len(u.Devices))
+		for i, device := range u.Devices {
+			copied.Devices[i] = device.Copy()
+		}
+	}
 
 	return copied
 }
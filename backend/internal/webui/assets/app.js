@@ -0,0 +1,425 @@
+// namazu built-in UI: a minimal client of the REST API for self-hosters.
+'use strict'
+
+const SESSION_KEY = 'namazu.session'
+
+const SCALES = [
+  [0, 'フィルタなし'],
+  [10, '震度1 以上'],
+  [20, '震度2 以上'],
+  [30, '震度3 以上'],
+  [40, '震度4 以上'],
+  [45, '震度5弱 以上'],
+  [50, '震度5強 以上'],
+  [55, '震度6弱 以上'],
+  [60, '震度6強 以上'],
+  [70, '震度7'],
+]
+
+let config = { auth_enabled: false }
+
+// h creates an element. Strings are added as text, never as HTML.
+function h(tag, attrs, ...children) {
+  const el = document.createElement(tag)
+  for (const [key, value] of Object.entries(attrs || {})) {
+    if (key.startsWith('on')) {
+      el.addEventListener(key.slice(2), value)
+    } else if (value === true) {
+      el.setAttribute(key, '')
+    } else if (value !== false && value != null) {
+      el.setAttribute(key, value)
+    }
+  }
+  for (const child of children.flat()) {
+    if (child != null && child !== false) {
+      el.append(child instanceof Node ? child : String(child))
+    }
+  }
+  return el
+}
+
+function render(...nodes) {
+  document.getElementById('main').replaceChildren(...nodes)
+}
+
+function formatTime(value) {
+  return value ? new Date(value).toLocaleString() : ''
+}
+
+// Session
+
+function loadSession() {
+  try {
+    return JSON.parse(localStorage.getItem(SESSION_KEY))
+  } catch {
+    return null
+  }
+}
+
+function saveSession(session) {
+  if (session) {
+    localStorage.setItem(SESSION_KEY, JSON.stringify(session))
+  } else {
+    localStorage.removeItem(SESSION_KEY)
+  }
+  document.getElementById('logout').hidden = !session
+}
+
+async function signIn(email, password) {
+  const body = { email, password, returnSecureToken: true }
+  if (config.firebase_tenant_id) body.tenantId = config.firebase_tenant_id
+  const res = await fetch(
+    'https://identitytoolkit.googleapis.com/v1/accounts:signInWithPassword?key=' +
+      encodeURIComponent(config.firebase_api_key),
+    { method: 'POST', headers: { 'Content-Type': 'application/json' }, body: JSON.stringify(body) }
+  )
+  const data = await res.json()
+  if (!res.ok) throw new Error(data.error?.message || 'ログインに失敗しました')
+  saveSession({ idToken: data.idToken, refreshToken: data.refreshToken })
+}
+
+async function refreshSession() {
+  const session = loadSession()
+  if (!session?.refreshToken || !config.firebase_api_key) return false
+  const res = await fetch(
+    'https://securetoken.googleapis.com/v1/token?key=' + encodeURIComponent(config.firebase_api_key),
+    {
+      method: 'POST',
+      headers: { 'Content-Type': 'application/x-www-form-urlencoded' },
+      body: new URLSearchParams({ grant_type: 'refresh_token', refresh_token: session.refreshToken }),
+    }
+  )
+  if (!res.ok) return false
+  const data = await res.json()
+  saveSession({ idToken: data.id_token, refreshToken: data.refresh_token })
+  return true
+}
+
+// API
+
+class APIError extends Error {
+  constructor(status, message) {
+    super(message)
+    this.status = status
+  }
+}
+
+async function api(path, options = {}, retried = false) {
+  const headers = { ...(options.headers || {}) }
+  const session = loadSession()
+  if (session?.idToken) headers.Authorization = 'Bearer ' + session.idToken
+  if (options.body) headers['Content-Type'] = 'application/json'
+
+  const res = await fetch(path, { ...options, headers })
+  if (res.status === 401 && config.auth_enabled) {
+    if (!retried && (await refreshSession())) return api(path, options, true)
+    saveSession(null)
+    location.hash = '#/login'
+    throw new APIError(401, 'ログインが必要です')
+  }
+  if (!res.ok) {
+    let message = res.statusText
+    try {
+      message = (await res.json()).error || message
+    } catch {
+      // Not JSON
+    }
+    throw new APIError(res.status, message)
+  }
+  return res
+}
+
+// Pages
+
+function loginPage() {
+  const error = h('div', { class: 'error', hidden: true })
+  const showError = (err) => {
+    error.textContent = err.message
+    error.hidden = false
+  }
+
+  if (!config.firebase_api_key) {
+    // Without a Web API key, accept an ID token obtained elsewhere
+    const token = h('textarea', { rows: 4, required: true, class: 'mono' })
+    render(
+      h('h1', {}, 'ログイン'),
+      error,
+      h('p', { class: 'muted' },
+        'メールアドレスでのログインには auth.web_api_key（NAMAZU_AUTH_WEB_API_KEY）の設定が必要です。',
+        'Firebase ID トークンを直接入力することもできます。'),
+      h('form', {
+        onsubmit: (e) => {
+          e.preventDefault()
+          saveSession({ idToken: token.value.trim() })
+          location.hash = '#/subscriptions'
+        },
+      }, h('label', {}, 'ID トークン', token), h('button', { type: 'submit' }, 'ログイン'))
+    )
+    return
+  }
+
+  const email = h('input', { type: 'email', required: true, autocomplete: 'username' })
+  const password = h('input', { type: 'password', required: true, autocomplete: 'current-password' })
+  render(
+    h('h1', {}, 'ログイン'),
+    error,
+    h('form', {
+      onsubmit: async (e) => {
+        e.preventDefault()
+        try {
+          await signIn(email.value, password.value)
+          location.hash = '#/subscriptions'
+        } catch (err) {
+          showError(err)
+        }
+      },
+    },
+      h('label', {}, 'メールアドレス', email),
+      h('label', {}, 'パスワード', password),
+      h('button', { type: 'submit' }, 'ログイン'))
+  )
+}
+
+async function subscriptionsPage() {
+  const list = h('section', {}, h('p', { class: 'muted' }, '読み込み中...'))
+  const created = h('div')
+  render(h('h1', {}, 'Subscriptions'), created, list, createForm(created))
+
+  try {
+    const subs = await (await api('/api/subscriptions')).json()
+    if (subs.length === 0) {
+      list.replaceChildren(h('p', { class: 'muted' }, 'Subscription はまだありません。'))
+      return
+    }
+    list.replaceChildren(
+      h('table', {},
+        h('tr', {}, h('th', {}, '名前'), h('th', {}, 'URL'), h('th', {}, 'フィルタ'), h('th', {}, '')),
+        subs.map((sub) =>
+          h('tr', {},
+            h('td', {}, sub.name, sub.delivery.verified ? '' : h('div', { class: 'muted' }, '未検証')),
+            h('td', { class: 'mono' }, sub.delivery.url),
+            h('td', {}, describeFilter(sub.filter)),
+            h('td', {},
+              h('a', { href: '#/subscriptions/' + encodeURIComponent(sub.id) + '/log' }, '配信ログ'),
+              ' ',
+              h('button', {
+                class: 'danger',
+                onclick: async () => {
+                  if (!confirm(sub.name + ' を削除しますか？')) return
+                  try {
+                    await api('/api/subscriptions/' + encodeURIComponent(sub.id), { method: 'DELETE' })
+                    subscriptionsPage()
+                  } catch (err) {
+                    alert(err.message)
+                  }
+                },
+              }, '削除'))
+          )
+        )
+      )
+    )
+  } catch (err) {
+    list.replaceChildren(h('div', { class: 'error' }, err.message))
+  }
+}
+
+function describeFilter(filter) {
+  if (!filter) return 'すべて'
+  const parts = []
+  const scale = SCALES.find(([value]) => value === filter.min_scale)
+  if (scale && scale[0] > 0) parts.push(scale[1])
+  if (filter.prefectures?.length) parts.push(filter.prefectures.join(', '))
+  return parts.join(' / ') || 'すべて'
+}
+
+function createForm(created) {
+  const name = h('input', { required: true, placeholder: 'My Webhook' })
+  const url = h('input', { type: 'url', required: true, placeholder: 'https://example.com/webhook', class: 'mono' })
+  const minScale = h('select', {}, SCALES.map(([value, label]) => h('option', { value }, label)))
+  const prefectures = h('input', { placeholder: '東京都, 神奈川県' })
+  const notices = h('input', { type: 'checkbox' })
+  const error = h('div', { class: 'error', hidden: true })
+
+  return h('section', {},
+    h('h2', {}, '新規作成'),
+    error,
+    h('form', {
+      onsubmit: async (e) => {
+        e.preventDefault()
+        error.hidden = true
+        const scale = Number(minScale.value)
+        const prefs = prefectures.value.split(',').map((p) => p.trim()).filter(Boolean)
+        const body = {
+          name: name.value,
+          delivery: { type: 'webhook', url: url.value, service_notices: notices.checked || undefined },
+          filter: scale > 0 || prefs.length ? { min_scale: scale || undefined, prefectures: prefs.length ? prefs : undefined } : undefined,
+        }
+        try {
+          const sub = await (await api('/api/subscriptions', { method: 'POST', body: JSON.stringify(body) })).json()
+          await subscriptionsPage()
+          document.querySelector('#main > div').replaceChildren(
+            h('div', { class: 'notice' },
+              h('strong', {}, sub.name), ' を作成しました。Secret は再表示できないため保存してください: ',
+              h('div', { class: 'mono' }, sub.delivery.secret))
+          )
+        } catch (err) {
+          error.textContent = err.message
+          error.hidden = false
+        }
+      },
+    },
+      h('label', {}, '名前', name),
+      h('label', {}, 'Webhook URL', url),
+      h('label', {}, '最小震度', minScale),
+      h('label', {}, '対象地域 (カンマ区切り)', prefectures),
+      h('label', { class: 'check' }, notices, 'メンテナンス等のサービスからのお知らせも受け取る'),
+      h('button', { type: 'submit' }, '作成'))
+  )
+}
+
+async function eventsPage() {
+  const body = h('section', {}, h('p', { class: 'muted' }, '読み込み中...'))
+  render(h('h1', {}, '最近の地震'), body)
+
+  try {
+    const events = await (await api('/api/events?limit=50')).json()
+    if (events.length === 0) {
+      body.replaceChildren(h('p', { class: 'muted' }, 'イベントはまだありません。'))
+      return
+    }
+    body.replaceChildren(
+      h('table', {},
+        h('tr', {}, h('th', {}, '発生時刻'), h('th', {}, '最大震度'), h('th', {}, '地域')),
+        events.map((ev) =>
+          h('tr', {},
+            h('td', {}, formatTime(ev.occurredAt)),
+            h('td', {}, scaleLabel(ev.severity)),
+            h('td', {}, (ev.affectedAreas || []).join(', ')))
+        )
+      )
+    )
+  } catch (err) {
+    body.replaceChildren(h('div', { class: 'error' }, err.message))
+  }
+}
+
+// scaleLabel maps normalized event severity (not the JMA scale used by filters)
+function scaleLabel(severity) {
+  const labels = { 10: '1', 20: '2', 30: '3', 40: '4', 50: '5弱', 60: '5強', 70: '6弱', 80: '6強', 100: '7' }
+  return labels[severity] ? '震度' + labels[severity] : '-'
+}
+
+function deliveryLogPage(id) {
+  const toDate = new Date()
+  const fromDate = new Date(toDate.getTime() - 7 * 24 * 60 * 60 * 1000)
+  const from = h('input', { type: 'date', value: fromDate.toISOString().slice(0, 10) })
+  const to = h('input', { type: 'date', value: toDate.toISOString().slice(0, 10) })
+  const body = h('section')
+
+  const load = async () => {
+    // The end date is inclusive in the form
+    const end = new Date(to.value + 'T00:00:00Z')
+    end.setUTCDate(end.getUTCDate() + 1)
+    const query = new URLSearchParams({
+      from: from.value + 'T00:00:00Z',
+      to: end.toISOString().replace('.000Z', 'Z'),
+    })
+    body.replaceChildren(h('p', { class: 'muted' }, '読み込み中...'))
+    try {
+      const res = await api('/api/subscriptions/' + encodeURIComponent(id) + '/delivery-log?' + query)
+      const text = await res.text()
+      showDeliveryLog(body, text, res.headers.get('Content-Disposition'))
+    } catch (err) {
+      const message = err.status === 501 ? 'このサーバーでは配信ログのエクスポートが無効です（NAMAZU_DELIVERY_LOG_KEY）。' : err.message
+      body.replaceChildren(h('div', { class: 'error' }, message))
+    }
+  }
+
+  render(
+    h('h1', {}, '配信ログ'),
+    h('p', {}, h('a', { href: '#/subscriptions' }, '← Subscriptions')),
+    h('div', { class: 'row' },
+      h('label', {}, 'From', from),
+      h('label', {}, 'To', to),
+      h('button', { onclick: load }, '表示')),
+    h('br'),
+    body
+  )
+  load()
+}
+
+function showDeliveryLog(container, text, disposition) {
+  const lines = text.trim().split('\n').map((line) => JSON.parse(line))
+  const header = lines.find((l) => l.type === 'header') || {}
+  const signature = lines.find((l) => l.type === 'signature') || {}
+  const entries = lines.filter((l) => l.type === 'delivery')
+
+  const filename = /filename="([^"]+)"/.exec(disposition || '')?.[1] || 'delivery-log.ndjson'
+  const download = h('a', {
+    href: URL.createObjectURL(new Blob([text], { type: 'application/x-ndjson' })),
+    download: filename,
+  }, '署名付き NDJSON をダウンロード')
+
+  container.replaceChildren(
+    h('p', {}, download, ' ',
+      h('span', { class: 'muted' }, entries.length + ' 件 / 鍵 ' + (signature.key_id || header.key_id || '-'))),
+    entries.length === 0
+      ? h('p', { class: 'muted' }, 'この期間の配信はありません。')
+      : h('table', {},
+          h('tr', {}, h('th', {}, '時刻'), h('th', {}, '結果'), h('th', {}, '試行'), h('th', {}, 'イベント')),
+          entries.map((e) =>
+            h('tr', {},
+              h('td', {}, formatTime(e.delivered_at)),
+              h('td', { class: e.success ? 'ok' : 'ng' },
+                (e.success ? '成功' : '失敗') + (e.status_code ? ' (' + e.status_code + ')' : ''),
+                e.error ? h('div', { class: 'muted' }, e.error) : null),
+              h('td', {}, e.attempts),
+              h('td', { class: 'mono' }, e.event_id))
+          )
+        )
+  )
+}
+
+// Routing
+
+function route() {
+  const path = location.hash.replace(/^#/, '') || '/subscriptions'
+
+  if (path === '/logout') {
+    saveSession(null)
+    location.hash = config.auth_enabled ? '#/login' : '#/subscriptions'
+    return
+  }
+  if (path === '/login') {
+    loginPage()
+    return
+  }
+  if (path === '/events') {
+    eventsPage()
+    return
+  }
+  if (config.auth_enabled && !loadSession()) {
+    location.hash = '#/login'
+    return
+  }
+
+  const log = /^\/subscriptions\/([^/]+)\/log$/.exec(path)
+  if (log) {
+    deliveryLogPage(decodeURIComponent(log[1]))
+    return
+  }
+  subscriptionsPage()
+}
+
+async function main() {
+  try {
+    config = await (await fetch('/config.json')).json()
+  } catch {
+    // Keep defaults
+  }
+  document.getElementById('logout').hidden = !(config.auth_enabled && loadSession())
+  window.addEventListener('hashchange', route)
+  route()
+}
+
+main()
@@ -0,0 +1,65 @@
+// Package webui is a minimal web UI embedded in the binary for self-hosters
+// who do not deploy the dashboard frontend. It is plain HTML and JavaScript on
+// top of the REST API: login, subscriptions, recent events and delivery logs.
+package webui
+
+import (
+	"embed"
+	"encoding/json"
+	"io/fs"
+	"net/http"
+	"strings"
+)
+
+//go:embed assets
+var assets embed.FS
+
+// Config tells the UI how to authenticate. It is served at /config.json.
+type Config struct {
+	AuthEnabled      bool   `json:"auth_enabled"`
+	FirebaseAPIKey   string `json:"firebase_api_key,omitempty"`   // Enables email/password login
+	FirebaseTenantID string `json:"firebase_tenant_id,omitempty"` // Identity Platform tenant
+}
+
+// Handler serves the UI. Paths that are not assets serve index.html.
+// Mount it with api.WithStaticFiles so that /api and /health reach the API.
+func Handler(cfg Config) http.Handler {
+	files, err := fs.Sub(assets, "assets")
+	if err != nil {
+		panic(err) // The embedded directory always exists
+	}
+	fileServer := http.FileServer(http.FS(files))
+	configJSON, _ := json.Marshal(cfg)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		path := strings.TrimPrefix(r.URL.Path, "/")
+		if path == "config.json" {
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Cache-Control", "no-store")
+			_, _ = w.Write(configJSON)
+			return
+		}
+
+		if path != "" && path != "index.html" {
+			if _, err := fs.Stat(files, path); err == nil {
+				fileServer.ServeHTTP(w, r)
+				return
+			}
+		}
+
+		index, err := fs.ReadFile(files, "index.html")
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Header().Set("Cache-Control", "no-cache")
+		_, _ = w.Write(index)
+	})
+}
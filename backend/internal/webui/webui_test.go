@@ -0,0 +1,90 @@
+package webui
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func serve(t *testing.T, h http.Handler, method, path string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, path, nil)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestHandler_Config(t *testing.T) {
+	h := Handler(Config{AuthEnabled: true, FirebaseAPIKey: "web-key", FirebaseTenantID: "tenant-1"})
+
+	rec := serve(t, h, http.MethodGet, "/config.json")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+	if got := rec.Header().Get("Cache-Control"); got != "no-store" {
+		t.Errorf("Cache-Control = %q, want no-store", got)
+	}
+
+	var got Config
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	want := Config{AuthEnabled: true, FirebaseAPIKey: "web-key", FirebaseTenantID: "tenant-1"}
+	if got != want {
+		t.Errorf("config = %+v, want %+v", got, want)
+	}
+}
+
+func TestHandler_Index(t *testing.T) {
+	h := Handler(Config{})
+
+	for _, path := range []string{"/", "/index.html", "/subscriptions/sub-1"} {
+		rec := serve(t, h, http.MethodGet, path)
+		if rec.Code != http.StatusOK {
+			t.Errorf("%s: status = %d, want 200", path, rec.Code)
+			continue
+		}
+		if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/html") {
+			t.Errorf("%s: Content-Type = %q, want text/html", path, ct)
+		}
+		if !strings.Contains(rec.Body.String(), `<script src="/app.js"`) {
+			t.Errorf("%s: body does not load app.js", path)
+		}
+	}
+}
+
+func TestHandler_Assets(t *testing.T) {
+	h := Handler(Config{})
+
+	tests := []struct {
+		path        string
+		contentType string
+	}{
+		{"/app.js", "javascript"},
+		{"/style.css", "text/css"},
+	}
+	for _, tt := range tests {
+		rec := serve(t, h, http.MethodGet, tt.path)
+		if rec.Code != http.StatusOK {
+			t.Errorf("%s: status = %d, want 200", tt.path, rec.Code)
+			continue
+		}
+		if ct := rec.Header().Get("Content-Type"); !strings.Contains(ct, tt.contentType) {
+			t.Errorf("%s: Content-Type = %q, want %s", tt.path, ct, tt.contentType)
+		}
+	}
+}
+
+func TestHandler_MethodNotAllowed(t *testing.T) {
+	h := Handler(Config{})
+
+	rec := serve(t, h, http.MethodPost, "/")
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("status = %d, want 405", rec.Code)
+	}
+	if got := rec.Header().Get("Allow"); got != "GET, HEAD" {
+		t.Errorf("Allow = %q, want %q", got, "GET, HEAD")
+	}
+}
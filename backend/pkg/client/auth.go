@@ -0,0 +1,105 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// secureTokenURL exchanges Firebase refresh tokens for ID tokens
+const secureTokenURL = "https://securetoken.googleapis.com/v1/token"
+
+// tokenExpiryMargin refreshes ID tokens a little before they expire
+const tokenExpiryMargin = time.Minute
+
+// TokenSource supplies the Firebase ID token sent as "Authorization: Bearer"
+type TokenSource interface {
+	Token(ctx context.Context) (string, error)
+}
+
+// StaticToken is a TokenSource that always returns the same token
+type StaticToken string
+
+// Token returns the token itself
+func (t StaticToken) Token(ctx context.Context) (string, error) {
+	return string(t), nil
+}
+
+// RefreshTokenSource exchanges a Firebase refresh token for ID tokens and
+// caches each one until shortly before it expires. It is safe for concurrent use.
+type RefreshTokenSource struct {
+	apiKey     string
+	endpoint   string
+	httpClient *http.Client
+
+	mu           sync.Mutex
+	refreshToken string
+	idToken      string
+	expiresAt    time.Time
+}
+
+// NewRefreshTokenSource creates a token source for a user's refresh token,
+// using the Firebase Web API key of the deployment
+func NewRefreshTokenSource(apiKey, refreshToken string) *RefreshTokenSource {
+	return &RefreshTokenSource{
+		apiKey:       apiKey,
+		endpoint:     secureTokenURL,
+		httpClient:   &http.Client{Timeout: 10 * time.Second},
+		refreshToken: refreshToken,
+	}
+}
+
+// Token returns a cached ID token, or exchanges the refresh token for a new one
+func (s *RefreshTokenSource) Token(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.idToken != "" && time.Now().Before(s.expiresAt) {
+		return s.idToken, nil
+	}
+
+	form := url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {s.refreshToken},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.endpoint+"?key="+url.QueryEscape(s.apiKey), strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to refresh ID token: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to refresh ID token: status %d", resp.StatusCode)
+	}
+
+	var body struct {
+		IDToken      string `json:"id_token"`
+		RefreshToken string `json:"refresh_token"`
+		ExpiresIn    string `json:"expires_in"` // Seconds, as a string
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("failed to decode ID token response: %w", err)
+	}
+	if body.IDToken == "" {
+		return "", fmt.Errorf("failed to refresh ID token: empty token")
+	}
+	expiresIn, _ := strconv.Atoi(body.ExpiresIn)
+
+	s.idToken = body.IDToken
+	s.expiresAt = time.Now().Add(time.Duration(expiresIn)*time.Second - tokenExpiryMargin)
+	if body.RefreshToken != "" {
+		s.refreshToken = body.RefreshToken
+	}
+	return s.idToken, nil
+}
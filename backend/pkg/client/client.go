@@ -0,0 +1,273 @@
+// Package client is a Go client for the namazu REST API.
+//
+// It covers subscriptions, deliveries, events (listed or streamed), the
+// authenticated user's profile and billing status, and the server's health.
+// Requests and responses use the same types as the server.
+//
+//	c, err := client.New("https://namazu.example.com", client.WithToken(idToken))
+//	subs, err := c.ListSubscriptions(ctx)
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// userAgent identifies the SDK in server logs
+const userAgent = "namazu-go-client"
+
+// RetryConfig controls how failed requests are retried
+type RetryConfig struct {
+	MaxRetries int // Maximum number of retries after the first attempt (0 disables retries)
+	InitialMs  int // Backoff before the first retry, doubled for each retry
+	MaxMs      int // Cap of the backoff and of a server's Retry-After
+}
+
+// DefaultRetryConfig retries 3 times, starting at 500ms and capped at 10s
+func DefaultRetryConfig() RetryConfig {
+	return RetryConfig{
+		MaxRetries: 3,
+		InitialMs:  500,
+		MaxMs:      10000,
+	}
+}
+
+// Client calls the namazu REST API. It is safe for concurrent use.
+type Client struct {
+	baseURL    *url.URL
+	httpClient *http.Client
+	tokens     TokenSource
+	retry      RetryConfig
+}
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithHTTPClient sets the HTTP client used for requests
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = hc
+	}
+}
+
+// WithTokenSource authenticates requests with tokens from ts
+func WithTokenSource(ts TokenSource) Option {
+	return func(c *Client) {
+		c.tokens = ts
+	}
+}
+
+// WithToken authenticates requests with a fixed ID token.
+// ID tokens expire after an hour; use WithAPIKey for long-running programs.
+func WithToken(token string) Option {
+	return WithTokenSource(StaticToken(token))
+}
+
+// WithAPIKey authenticates requests with ID tokens exchanged from a refresh
+// token using the Firebase Web API key of the deployment
+func WithAPIKey(apiKey, refreshToken string) Option {
+	return WithTokenSource(NewRefreshTokenSource(apiKey, refreshToken))
+}
+
+// WithRetry sets the retry behaviour (DefaultRetryConfig otherwise)
+func WithRetry(cfg RetryConfig) Option {
+	return func(c *Client) {
+		c.retry = cfg
+	}
+}
+
+// New creates a client for the server at baseURL, e.g. "https://namazu.example.com"
+func New(baseURL string, opts ...Option) (*Client, error) {
+	u, err := url.Parse(strings.TrimSuffix(baseURL, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid base URL: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid base URL: scheme must be http or https")
+	}
+
+	c := &Client{
+		baseURL:    u,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		retry:      DefaultRetryConfig(),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
+}
+
+// APIError is a non-2xx response of the API
+type APIError struct {
+	StatusCode int
+	Code       string         // Machine-readable error code, e.g. "quota_exceeded" (empty if the response had none)
+	Message    string         // The error message of the response, or the status text
+	Details    map[string]any // Code-specific details, if any
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("namazu: %d %s", e.StatusCode, e.Message)
+}
+
+// IsNotFound reports whether err is a 404 response
+func IsNotFound(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+// do sends a request to path under /api and decodes the JSON response into out (if non-nil).
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body, out any) error {
+	return c.request(ctx, method, "/api"+path, query, nil, body, out)
+}
+
+// doIfMatch is do with an If-Match header, for writes that must not overwrite a newer version
+func (c *Client) doIfMatch(ctx context.Context, method, path, etag string, body, out any) error {
+	return c.request(ctx, method, "/api"+path, nil, http.Header{"If-Match": {etag}}, body, out)
+}
+
+// request sends a request to path and decodes the JSON response into out (if non-nil).
+// Requests are retried on connection errors, 408, 429 and 5xx responses, except
+// POSTs, which are only retried when the server did not get to handle them (429).
+func (c *Client) request(ctx context.Context, method, path string, query url.Values, header http.Header, body, out any) error {
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+	}
+
+	u := *c.baseURL
+	u.Path += path
+	u.RawQuery = query.Encode()
+
+	for attempt := 0; ; attempt++ {
+		resp, err := c.send(ctx, method, u.String(), header, payload)
+		retryable := err != nil && ctx.Err() == nil && method != http.MethodPost
+		var wait time.Duration
+		if resp != nil {
+			retryable = isRetryable(method, resp.StatusCode)
+			wait = retryAfter(resp.Header.Get("Retry-After"))
+		}
+		if !retryable || attempt >= c.retry.MaxRetries {
+			if err != nil {
+				return err
+			}
+			return decodeResponse(resp, out)
+		}
+		if resp != nil {
+			_, _ = io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		wait = max(wait, calculateBackoff(attempt, c.retry.InitialMs, c.retry.MaxMs))
+		wait = min(wait, time.Duration(c.retry.MaxMs)*time.Millisecond)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+// send makes a single attempt of a request
+func (c *Client) send(ctx context.Context, method, rawURL string, header http.Header, payload []byte) (*http.Response, error) {
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, rawURL, body)
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", userAgent)
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.tokens != nil {
+		token, err := c.tokens.Token(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get token: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return c.httpClient.Do(req)
+}
+
+// decodeResponse closes resp and decodes it into out, or into an *APIError
+func decodeResponse(resp *http.Response, out any) error {
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		apiErr := &APIError{StatusCode: resp.StatusCode, Message: http.StatusText(resp.StatusCode)}
+		var body struct {
+			Error struct {
+				Code    string         `json:"code"`
+				Message string         `json:"message"`
+				Details map[string]any `json:"details"`
+			} `json:"error"`
+		}
+		if json.NewDecoder(io.LimitReader(resp.Body, 1<<16)).Decode(&body) == nil {
+			apiErr.Code = body.Error.Code
+			apiErr.Details = body.Error.Details
+			if body.Error.Message != "" {
+				apiErr.Message = body.Error.Message
+			}
+		}
+		return apiErr
+	}
+
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// isRetryable reports whether a response of the given status is worth retrying
+func isRetryable(method string, status int) bool {
+	if status == http.StatusTooManyRequests {
+		return true
+	}
+	if method == http.MethodPost {
+		return false
+	}
+	return status == http.StatusRequestTimeout || status >= 500
+}
+
+// retryAfter parses the seconds of a Retry-After header (0 if absent)
+func retryAfter(header string) time.Duration {
+	seconds, err := strconv.Atoi(header)
+	if err != nil || seconds < 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// calculateBackoff returns the backoff duration for a given retry attempt.
+// Uses exponential backoff: initialMs * 2^attempt, capped at maxMs.
+func calculateBackoff(attempt, initialMs, maxMs int) time.Duration {
+	backoffMs := initialMs
+	for range attempt {
+		backoffMs *= 2
+		if backoffMs >= maxMs {
+			return time.Duration(maxMs) * time.Millisecond
+		}
+	}
+	return time.Duration(backoffMs) * time.Millisecond
+}
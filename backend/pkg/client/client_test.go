@@ -0,0 +1,292 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fastRetry keeps retry tests quick
+var fastRetry = RetryConfig{MaxRetries: 2, InitialMs: 1, MaxMs: 5}
+
+func newTestClient(t *testing.T, handler http.HandlerFunc, opts ...Option) *Client {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	c, err := New(server.URL, append([]Option{WithRetry(fastRetry)}, opts...)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestNew_InvalidBaseURL(t *testing.T) {
+	if _, err := New("namazu.example.com"); err == nil {
+		t.Error("expected an error for a base URL without a scheme")
+	}
+}
+
+func TestClient_Subscriptions(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer id-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.Method + " " + r.URL.Path {
+		case "POST /api/subscriptions":
+			var req SubscriptionRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Delivery.URL == "" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			w.WriteHeader(http.StatusCreated)
+			_ = json.NewEncoder(w).Encode(Subscription{ID: "sub-1", Name: req.Name, Delivery: SubscriptionDelivery{Type: req.Delivery.Type, URL: req.Delivery.URL}})
+		case "GET /api/subscriptions/sub-1":
+			_ = json.NewEncoder(w).Encode(Subscription{ID: "sub-1", Name: "Hook", Status: "active"})
+		case "PATCH /api/subscriptions/sub-1":
+			if r.Header.Get("If-Match") != `"v1"` {
+				w.WriteHeader(http.StatusPreconditionRequired)
+				return
+			}
+			var patch map[string]any
+			if err := json.NewDecoder(r.Body).Decode(&patch); err != nil || len(patch) != 1 || patch["name"] != "Renamed" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(Subscription{ID: "sub-1", Name: "Renamed"})
+		case "POST /api/subscriptions/sub-1/pause":
+			_ = json.NewEncoder(w).Encode(Subscription{ID: "sub-1", Active: false})
+		case "POST /api/subscriptions/sub-1/resume":
+			_ = json.NewEncoder(w).Encode(Subscription{ID: "sub-1", Active: true})
+		case "DELETE /api/subscriptions/sub-1":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error": {"code": "not_found", "message": "subscription not found"}}`))
+		}
+	}, WithToken("id-token"))
+	ctx := context.Background()
+
+	created, err := c.CreateSubscription(ctx, SubscriptionRequest{
+		Name:     "Hook",
+		Delivery: DeliveryConfig{Type: "webhook", URL: "https://example.com/hook"},
+		Filter:   &FilterConfig{MinScale: 40},
+	})
+	if err != nil {
+		t.Fatalf("CreateSubscription() error = %v", err)
+	}
+	if created.ID != "sub-1" || created.Delivery.URL != "https://example.com/hook" {
+		t.Errorf("unexpected subscription %+v", created)
+	}
+
+	got, err := c.GetSubscription(ctx, "sub-1")
+	if err != nil || got.Status != "active" {
+		t.Errorf("GetSubscription() = %+v, %v", got, err)
+	}
+	patched, err := c.PatchSubscription(ctx, "sub-1", `"v1"`, map[string]any{"name": "Renamed"})
+	if err != nil || patched.Name != "Renamed" {
+		t.Errorf("PatchSubscription() = %+v, %v", patched, err)
+	}
+	if paused, err := c.PauseSubscription(ctx, "sub-1"); err != nil || paused.Active {
+		t.Errorf("PauseSubscription() = %+v, %v", paused, err)
+	}
+	if resumed, err := c.ResumeSubscription(ctx, "sub-1"); err != nil || !resumed.Active {
+		t.Errorf("ResumeSubscription() = %+v, %v", resumed, err)
+	}
+	if err := c.DeleteSubscription(ctx, "sub-1"); err != nil {
+		t.Errorf("DeleteSubscription() error = %v", err)
+	}
+
+	_, err = c.GetSubscription(ctx, "missing")
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Message != "subscription not found" || apiErr.Code != "not_found" || !IsNotFound(err) {
+		t.Errorf("GetSubscription(missing) error = %v, want a 404 APIError", err)
+	}
+}
+
+func TestClient_ListEvents_Query(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		want := "cursor=c1&from=2024-01-01T00%3A00%3A00Z&limit=50&min_severity=45&order=asc&prefecture=%E7%9F%B3%E5%B7%9D%E7%9C%8C"
+		if r.URL.Path != "/api/events" || r.URL.RawQuery != want {
+			t.Errorf("query = %s %s, want %s", r.URL.Path, r.URL.RawQuery, want)
+		}
+		_ = json.NewEncoder(w).Encode(EventList{Events: []Event{{ID: "e1"}}, NextCursor: "c2", Total: 3})
+	})
+
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	list, err := c.ListEvents(context.Background(), EventQuery{
+		Limit: 50, Cursor: "c1", Prefecture: "石川県", MinSeverity: 45, From: &from, Ascending: true,
+	})
+	if err != nil {
+		t.Fatalf("ListEvents() error = %v", err)
+	}
+	if len(list.Events) != 1 || list.NextCursor != "c2" {
+		t.Errorf("unexpected list %+v", list)
+	}
+}
+
+func TestClient_Deliveries(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.RequestURI() {
+		case "GET /api/subscriptions/sub-1/deliveries?limit=5":
+			_ = json.NewEncoder(w).Encode([]Delivery{{ID: "d1", EventID: "e1", StatusCode: 500}})
+		case "POST /api/deliveries/d1/redeliver":
+			_ = json.NewEncoder(w).Encode(Redelivery{EventID: "e1", StatusCode: 200, Success: true})
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	ctx := context.Background()
+
+	deliveries, err := c.ListDeliveries(ctx, "sub-1", 5)
+	if err != nil || len(deliveries) != 1 || deliveries[0].ID != "d1" {
+		t.Fatalf("ListDeliveries() = %+v, %v", deliveries, err)
+	}
+	result, err := c.Redeliver(ctx, "d1")
+	if err != nil || !result.Success {
+		t.Errorf("Redeliver() = %+v, %v", result, err)
+	}
+}
+
+func TestClient_Health(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/health" || r.Header.Get("Authorization") != "" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(`{"status":"ok","hash":"abc123"}`))
+	})
+
+	health, err := c.Health(context.Background())
+	if err != nil || health.Status != "ok" || health.Hash != "abc123" {
+		t.Errorf("Health() = %+v, %v", health, err)
+	}
+}
+
+func TestClient_StreamEvents(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.RawQuery != "min_scale=40&prefectures=%E6%9D%B1%E4%BA%AC%E9%83%BD" || r.Header.Get("Last-Event-ID") != "e0" {
+			t.Errorf("query = %s, Last-Event-ID = %s", r.URL.RawQuery, r.Header.Get("Last-Event-ID"))
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte("id: e1\ndata: {\"id\":\"e1\",\"severity\":40}\n\n: heartbeat\n\nid: e2\ndata: {\"id\":\"e2\"}\n\n"))
+	}, WithToken("id-token"))
+
+	var got []string
+	err := c.StreamEvents(context.Background(), StreamQuery{MinScale: 40, Prefectures: []string{"東京都"}}, "e0", func(e Event) error {
+		got = append(got, e.ID)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("StreamEvents() error = %v", err)
+	}
+	if len(got) != 2 || got[0] != "e1" || got[1] != "e2" {
+		t.Errorf("events = %v, want e1 and e2", got)
+	}
+
+	// fn stops the stream
+	stop := errors.New("stop")
+	err = c.StreamEvents(context.Background(), StreamQuery{MinScale: 40, Prefectures: []string{"東京都"}}, "e0", func(e Event) error {
+		return stop
+	})
+	if !errors.Is(err, stop) {
+		t.Errorf("StreamEvents() error = %v, want the error of fn", err)
+	}
+}
+
+func TestClient_Retry(t *testing.T) {
+	tests := []struct {
+		name      string
+		method    string
+		status    int
+		wantCalls int32
+	}{
+		{name: "GET is retried on 5xx", method: http.MethodGet, status: http.StatusServiceUnavailable, wantCalls: 3},
+		{name: "POST is not retried on 5xx", method: http.MethodPost, status: http.StatusBadGateway, wantCalls: 1},
+		{name: "POST is retried on 429", method: http.MethodPost, status: http.StatusTooManyRequests, wantCalls: 3},
+		{name: "4xx is not retried", method: http.MethodGet, status: http.StatusBadRequest, wantCalls: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				calls.Add(1)
+				w.WriteHeader(tt.status)
+			})
+			err := c.do(context.Background(), tt.method, "/subscriptions", nil, nil, nil)
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) || apiErr.StatusCode != tt.status {
+				t.Errorf("error = %v, want status %d", err, tt.status)
+			}
+			if calls.Load() != tt.wantCalls {
+				t.Errorf("calls = %d, want %d", calls.Load(), tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestClient_Retry_Recovers(t *testing.T) {
+	var calls atomic.Int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(`{"plan": "pro", "hasActiveSubscription": true}`))
+	})
+
+	status, err := c.BillingStatus(context.Background())
+	if err != nil || status.Plan != "pro" {
+		t.Errorf("BillingStatus() = %+v, %v", status, err)
+	}
+}
+
+func TestClient_ContextCanceled(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}, WithRetry(RetryConfig{MaxRetries: 5, InitialMs: 1000, MaxMs: 1000}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := c.Me(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Me() error = %v, want the context error", err)
+	}
+}
+
+func TestRefreshTokenSource(t *testing.T) {
+	var exchanges atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		exchanges.Add(1)
+		if r.URL.Query().Get("key") != "web-api-key" || r.FormValue("refresh_token") != "refresh-1" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		_, _ = w.Write([]byte(`{"id_token": "id-1", "refresh_token": "refresh-1", "expires_in": "3600"}`))
+	}))
+	defer server.Close()
+
+	ts := NewRefreshTokenSource("web-api-key", "refresh-1")
+	ts.endpoint = server.URL
+	for range 2 {
+		token, err := ts.Token(context.Background())
+		if err != nil || token != "id-1" {
+			t.Fatalf("Token() = %q, %v", token, err)
+		}
+	}
+	if exchanges.Load() != 1 {
+		t.Errorf("exchanges = %d, want the ID token to be cached", exchanges.Load())
+	}
+
+	bad := NewRefreshTokenSource("web-api-key", "revoked")
+	bad.endpoint = server.URL
+	if _, err := bad.Token(context.Background()); err == nil {
+		t.Error("expected an error for a rejected refresh token")
+	}
+}
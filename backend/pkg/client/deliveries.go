@@ -0,0 +1,32 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// ListDeliveries returns the latest deliveries of a subscription in the last
+// 30 days, newest first. limit 0 leaves the page size to the server (50, max 200).
+func (c *Client) ListDeliveries(ctx context.Context, subscriptionID string, limit int) ([]Delivery, error) {
+	query := url.Values{}
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+	var deliveries []Delivery
+	if err := c.do(ctx, http.MethodGet, "/subscriptions/"+url.PathEscape(subscriptionID)+"/deliveries", query, nil, &deliveries); err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// Redeliver re-sends the event of a failed delivery to the subscription's
+// current URL, once. The attempt is recorded as a new delivery.
+func (c *Client) Redeliver(ctx context.Context, deliveryID string) (*Redelivery, error) {
+	var result Redelivery
+	if err := c.do(ctx, http.MethodPost, "/deliveries/"+url.PathEscape(deliveryID)+"/redeliver", nil, nil, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
@@ -0,0 +1,69 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// EventQuery filters GET /api/events. Zero values are left to the server's defaults.
+type EventQuery struct {
+	Limit       int        // Page size (server default 10, max 100)
+	Cursor      string     // EventList.NextCursor of the previous page
+	Type        string     // "earthquake" | "tsunami" | "eew"
+	Prefecture  string     // e.g. "東京都"
+	MinSeverity int        // Minimum JMA scale (e.g. 50 = 震度5弱)
+	From        *time.Time // Occurred at or after
+	To          *time.Time // Occurred before
+	Ascending   bool       // Oldest first
+}
+
+// values encodes the query string of the query
+func (q EventQuery) values() url.Values {
+	v := url.Values{}
+	if q.Limit > 0 {
+		v.Set("limit", strconv.Itoa(q.Limit))
+	}
+	if q.Cursor != "" {
+		v.Set("cursor", q.Cursor)
+	}
+	if q.Type != "" {
+		v.Set("type", q.Type)
+	}
+	if q.Prefecture != "" {
+		v.Set("prefecture", q.Prefecture)
+	}
+	if q.MinSeverity > 0 {
+		v.Set("min_severity", strconv.Itoa(q.MinSeverity))
+	}
+	if q.From != nil {
+		v.Set("from", q.From.Format(time.RFC3339))
+	}
+	if q.To != nil {
+		v.Set("to", q.To.Format(time.RFC3339))
+	}
+	if q.Ascending {
+		v.Set("order", "asc")
+	}
+	return v
+}
+
+// ListEvents returns a page of events, newest first unless q.Ascending
+func (c *Client) ListEvents(ctx context.Context, q EventQuery) (*EventList, error) {
+	var list EventList
+	if err := c.do(ctx, http.MethodGet, "/events", q.values(), nil, &list); err != nil {
+		return nil, err
+	}
+	return &list, nil
+}
+
+// GetEvent returns an event by ID
+func (c *Client) GetEvent(ctx context.Context, id string) (*EventDetail, error) {
+	var event EventDetail
+	if err := c.do(ctx, http.MethodGet, "/events/"+url.PathEscape(id), nil, nil, &event); err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
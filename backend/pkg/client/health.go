@@ -0,0 +1,21 @@
+package client
+
+import (
+	"context"
+	"net/http"
+)
+
+// Health is the response of the server's health check
+type Health struct {
+	Status string `json:"status"` // "ok"
+	Hash   string `json:"hash"`   // Commit the server was built from
+}
+
+// Health checks that the server is up. It does not need authentication.
+func (c *Client) Health(ctx context.Context) (*Health, error) {
+	var health Health
+	if err := c.request(ctx, http.MethodGet, "/health", nil, nil, nil, &health); err != nil {
+		return nil, err
+	}
+	return &health, nil
+}
@@ -0,0 +1,24 @@
+package client
+
+import (
+	"context"
+	"net/http"
+)
+
+// Me returns the authenticated user's profile, creating it on first use
+func (c *Client) Me(ctx context.Context) (*User, error) {
+	var u User
+	if err := c.do(ctx, http.MethodGet, "/me", nil, nil, &u); err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+// BillingStatus returns the authenticated user's plan and subscription state
+func (c *Client) BillingStatus(ctx context.Context) (*BillingStatus, error) {
+	var status BillingStatus
+	if err := c.do(ctx, http.MethodGet, "/billing/status", nil, nil, &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
@@ -0,0 +1,46 @@
+package client
+
+import (
+	"github.com/otiai10/namazu/backend/internal/api"
+	"github.com/otiai10/namazu/backend/internal/subscription"
+	"github.com/otiai10/namazu/backend/internal/user"
+)
+
+// Types shared with the server, so requests and responses stay in sync with the API
+
+// SubscriptionRequest is the body of creating or updating a subscription
+type SubscriptionRequest = api.SubscriptionRequest
+
+// Subscription is a subscription as returned by the API.
+// The webhook secret is only returned in full when the subscription is created.
+type Subscription = api.SubscriptionResponse
+
+// DeliveryConfig configures where and how events are delivered
+type DeliveryConfig = subscription.DeliveryConfig
+
+// SubscriptionDelivery is the delivery of a subscription as returned by the API, with its secrets masked
+type SubscriptionDelivery = api.SubscriptionDelivery
+
+// FilterConfig selects the events delivered to a subscription
+type FilterConfig = subscription.FilterConfig
+
+// Event is an event as listed by the API
+type Event = api.EventResponse
+
+// EventList is a page of events
+type EventList = api.EventListResponse
+
+// EventDetail is an event with its raw payload and delivery counts
+type EventDetail = api.EventDetailResponse
+
+// Delivery is an attempt to deliver an event to a subscription
+type Delivery = api.DeliveryResponse
+
+// Redelivery is the result of re-sending a failed delivery
+type Redelivery = api.RedeliverResponse
+
+// User is the authenticated user's profile
+type User = user.User
+
+// BillingStatus is the authenticated user's plan and Stripe subscription state
+type BillingStatus = api.BillingStatusResponse
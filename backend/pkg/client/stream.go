@@ -0,0 +1,113 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// maxStreamLine bounds a line of the event stream
+const maxStreamLine = 1 << 20
+
+// StreamQuery filters the event stream, with the semantics of a subscription's FilterConfig
+type StreamQuery struct {
+	MinScale    int      // Minimum JMA scale (e.g. 50 = 震度5弱)
+	Prefectures []string // e.g. "東京都"
+	EventTypes  []string // "earthquake" | "tsunami" (server default: earthquake)
+	EEW         bool     // Include Earthquake Early Warnings
+}
+
+// values encodes the query string of the query
+func (q StreamQuery) values() url.Values {
+	v := url.Values{}
+	if q.MinScale > 0 {
+		v.Set("min_scale", strconv.Itoa(q.MinScale))
+	}
+	if len(q.Prefectures) > 0 {
+		v.Set("prefectures", strings.Join(q.Prefectures, ","))
+	}
+	if len(q.EventTypes) > 0 {
+		v.Set("event_types", strings.Join(q.EventTypes, ","))
+	}
+	if q.EEW {
+		v.Set("eew", "true")
+	}
+	return v
+}
+
+// StreamEvents calls fn with every matching event as the server receives it,
+// using Server-Sent Events. It blocks until ctx is done, fn returns an error,
+// or the connection is closed; it does not reconnect. To resume after a
+// disconnection, pass the ID of the last event seen as lastEventID: the
+// events missed in between are sent first.
+func (c *Client) StreamEvents(ctx context.Context, q StreamQuery, lastEventID string, fn func(Event) error) error {
+	u := *c.baseURL
+	u.Path += "/api/events/stream"
+	u.RawQuery = q.values().Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set("User-Agent", userAgent)
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+	if c.tokens != nil {
+		token, err := c.tokens.Token(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to get token: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	// The stream outlives the client's request timeout
+	hc := *c.httpClient
+	hc.Timeout = 0
+	resp, err := hc.Do(req)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return decodeResponse(resp, nil)
+	}
+	defer resp.Body.Close()
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxStreamLine)
+	var data strings.Builder
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			// A blank line ends a message
+			if data.Len() == 0 {
+				continue
+			}
+			var event Event
+			if err := json.Unmarshal([]byte(data.String()), &event); err != nil {
+				return fmt.Errorf("failed to decode event: %w", err)
+			}
+			data.Reset()
+			if err := fn(event); err != nil {
+				return err
+			}
+		case strings.HasPrefix(line, "data:"):
+			if data.Len() > 0 {
+				data.WriteByte('\n')
+			}
+			data.WriteString(strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		}
+		// Comments (heartbeats) and id: lines need no handling: the event carries its ID
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,81 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// ListSubscriptions returns the authenticated user's subscriptions
+func (c *Client) ListSubscriptions(ctx context.Context) ([]Subscription, error) {
+	var subs []Subscription
+	if err := c.do(ctx, http.MethodGet, "/subscriptions", nil, nil, &subs); err != nil {
+		return nil, err
+	}
+	return subs, nil
+}
+
+// GetSubscription returns a subscription by ID
+func (c *Client) GetSubscription(ctx context.Context, id string) (*Subscription, error) {
+	var sub Subscription
+	if err := c.do(ctx, http.MethodGet, "/subscriptions/"+url.PathEscape(id), nil, nil, &sub); err != nil {
+		return nil, err
+	}
+	return &sub, nil
+}
+
+// CreateSubscription creates a subscription. For webhooks the returned
+// Delivery.Secret is the only time the signing secret is shown in full.
+func (c *Client) CreateSubscription(ctx context.Context, req SubscriptionRequest) (*Subscription, error) {
+	var sub Subscription
+	if err := c.do(ctx, http.MethodPost, "/subscriptions", nil, req, &sub); err != nil {
+		return nil, err
+	}
+	return &sub, nil
+}
+
+// UpdateSubscription replaces the settings of a subscription.
+// etag is the ETag of the subscription as read; if it has changed since, the
+// update fails with a 412 APIError. "*" updates whatever version is stored.
+func (c *Client) UpdateSubscription(ctx context.Context, id, etag string, req SubscriptionRequest) (*Subscription, error) {
+	var sub Subscription
+	if err := c.doIfMatch(ctx, http.MethodPut, "/subscriptions/"+url.PathEscape(id), etag, req, &sub); err != nil {
+		return nil, err
+	}
+	return &sub, nil
+}
+
+// PatchSubscription updates only the fields of a subscription set in patch,
+// a JSON merge patch (RFC 7386): members replace, objects merge and nil removes
+// a member. Delivery credentials are kept unless the patch changes them.
+// etag is as for UpdateSubscription.
+func (c *Client) PatchSubscription(ctx context.Context, id, etag string, patch map[string]any) (*Subscription, error) {
+	var sub Subscription
+	if err := c.doIfMatch(ctx, http.MethodPatch, "/subscriptions/"+url.PathEscape(id), etag, patch, &sub); err != nil {
+		return nil, err
+	}
+	return &sub, nil
+}
+
+// PauseSubscription stops deliveries to a subscription until it is resumed
+func (c *Client) PauseSubscription(ctx context.Context, id string) (*Subscription, error) {
+	var sub Subscription
+	if err := c.do(ctx, http.MethodPost, "/subscriptions/"+url.PathEscape(id)+"/pause", nil, nil, &sub); err != nil {
+		return nil, err
+	}
+	return &sub, nil
+}
+
+// ResumeSubscription restarts deliveries to a paused subscription
+func (c *Client) ResumeSubscription(ctx context.Context, id string) (*Subscription, error) {
+	var sub Subscription
+	if err := c.do(ctx, http.MethodPost, "/subscriptions/"+url.PathEscape(id)+"/resume", nil, nil, &sub); err != nil {
+		return nil, err
+	}
+	return &sub, nil
+}
+
+// DeleteSubscription deletes a subscription
+func (c *Client) DeleteSubscription(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodDelete, "/subscriptions/"+url.PathEscape(id), nil, nil, nil)
+}
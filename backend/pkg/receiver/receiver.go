@@ -0,0 +1,225 @@
+// Package receiver helps webhook consumers accept namazu deliveries.
+//
+// Handler verifies the signature of each delivery, answers url_verification
+// challenges, rejects stale timestamps and passes the parsed payload to a callback:
+//
+//	h := receiver.New(secret, func(ctx context.Context, e *receiver.Event) error {
+//		if e.Earthquake != nil {
+//			log.Printf("震度 %d: %v", e.Earthquake.GetSeverity(), e.Earthquake.GetAffectedAreas())
+//		}
+//		return nil
+//	})
+//	http.Handle("/webhook", h)
+package receiver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/otiai10/namazu/backend/internal/app"
+	"github.com/otiai10/namazu/backend/internal/delivery/webhook"
+	"github.com/otiai10/namazu/backend/internal/notice"
+	"github.com/otiai10/namazu/backend/internal/source"
+	"github.com/otiai10/namazu/backend/internal/source/p2pquake"
+)
+
+// maxBodySize bounds a delivery body
+const maxBodySize = 1 << 20
+
+// Signature headers of a delivery
+const (
+	SignatureHeader = "X-Signature-256"
+	TimestampHeader = "X-Signature-Timestamp"
+)
+
+// Kinds of payload
+const (
+	KindEarthquake = "earthquake"   // P2P地震情報 JSON (earthquake information or early warning)
+	KindNotice     = notice.Type    // Service notice
+	KindDigest     = app.DigestType // Digest of events
+	KindOther      = "other"        // Anything else, e.g. the output of a payload template
+)
+
+// Digest is the payload of a subscription in digest mode
+type Digest = app.Digest
+
+// Notice is a service notice, such as an announcement of relay maintenance
+type Notice = notice.Notice
+
+// Event is a verified delivery
+type Event struct {
+	Kind       string
+	Body       []byte       // Raw payload, as signed
+	Earthquake source.Event // Set for KindEarthquake
+	Notice     *Notice      // Set for KindNotice
+	Digest     *Digest      // Set for KindDigest
+	Redelivery bool         // Sent again from the delivery history
+	Test       bool         // Sent by a test delivery
+	EventID    string       // X-Event-Id: the same for every delivery of an event, to deduplicate on
+	DeliveryID string       // X-Delivery-Id: the same for retries, new for redeliveries
+}
+
+// HandlerFunc processes a verified delivery. Returning an error answers 500,
+// so namazu retries the delivery if the subscription has retries enabled.
+type HandlerFunc func(ctx context.Context, e *Event) error
+
+// Handler is an http.Handler that verifies deliveries before passing them to a HandlerFunc
+type Handler struct {
+	secret           string
+	fn               HandlerFunc
+	maxAge           time.Duration
+	requireTimestamp bool
+}
+
+// Option configures a Handler
+type Option func(*Handler)
+
+// WithMaxAge sets how old a timestamped (v0) signature may be (webhook.DefaultMaxAge otherwise)
+func WithMaxAge(d time.Duration) Option {
+	return func(h *Handler) {
+		h.maxAge = d
+	}
+}
+
+// RequireTimestamp rejects legacy signatures without a timestamp, except for
+// url_verification challenges, which are always signed the legacy way.
+// Use it once the subscription signs with v0, so captured deliveries cannot be replayed.
+func RequireTimestamp() Option {
+	return func(h *Handler) {
+		h.requireTimestamp = true
+	}
+}
+
+// New creates a handler for deliveries signed with secret.
+// During a secret rotation, a handler with either the new or the old secret
+// accepts deliveries, because they also carry the old secret's signature.
+func New(secret string, fn HandlerFunc, opts ...Option) *Handler {
+	h := &Handler{
+		secret: secret,
+		fn:     fn,
+		maxAge: webhook.DefaultMaxAge,
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// ServeHTTP verifies and handles a delivery
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeError(w, "payload too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		writeError(w, "failed to read body", http.StatusBadRequest)
+		return
+	}
+
+	var envelope struct {
+		Type      string `json:"type"`
+		Challenge string `json:"challenge"`
+	}
+	// Templated payloads need not be JSON objects; they are passed on as KindOther
+	_ = json.Unmarshal(body, &envelope)
+	challenge := envelope.Type == "url_verification"
+
+	if !h.verify(r.Header, body, challenge) {
+		writeError(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+
+	if challenge {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(webhook.ChallengeResponse{Challenge: envelope.Challenge})
+		return
+	}
+
+	event, err := parseEvent(envelope.Type, body)
+	if err != nil {
+		writeError(w, "invalid payload: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	event.Redelivery = r.Header.Get(webhook.RedeliveryHeader) == "true"
+	event.Test = r.Header.Get(webhook.TestHeader) == "true"
+	event.EventID = r.Header.Get(webhook.EventIDHeader)
+	event.DeliveryID = r.Header.Get(webhook.DeliveryIDHeader)
+
+	if err := h.fn(r.Context(), event); err != nil {
+		log.Printf("receiver: failed to handle delivery: %v", err)
+		writeError(w, "failed to handle delivery", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// verify checks the signature of the current secret, or of the previous one
+// during a rotation, against the secret of the handler
+func (h *Handler) verify(header http.Header, body []byte, challenge bool) bool {
+	for _, name := range []string{SignatureHeader, webhook.PreviousSignatureHeader} {
+		signature := header.Get(name)
+		switch {
+		case signature == "":
+		case strings.HasPrefix(signature, "v0="):
+			timestamp, err := strconv.ParseInt(header.Get(TimestampHeader), 10, 64)
+			if err == nil && webhook.VerifyV0(h.secret, timestamp, body, signature, h.maxAge) {
+				return true
+			}
+		case strings.HasPrefix(signature, "sha256="):
+			if (challenge || !h.requireTimestamp) && webhook.Verify(h.secret, body, signature) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// parseEvent parses a verified payload by its "type"
+func parseEvent(kind string, body []byte) (*Event, error) {
+	event := &Event{Body: body}
+	switch kind {
+	case KindNotice:
+		event.Kind = KindNotice
+		event.Notice = &Notice{}
+		if err := json.Unmarshal(body, event.Notice); err != nil {
+			return nil, err
+		}
+	case KindDigest:
+		event.Kind = KindDigest
+		event.Digest = &Digest{}
+		if err := json.Unmarshal(body, event.Digest); err != nil {
+			return nil, err
+		}
+	default:
+		// P2P地震情報 JSON has no "type"; anything else is a templated payload
+		quake, err := p2pquake.ParseMessage(body)
+		if kind != "" || err != nil || quake == nil {
+			event.Kind = KindOther
+			return event, nil
+		}
+		event.Kind = KindEarthquake
+		event.Earthquake = quake
+	}
+	return event, nil
+}
+
+// writeError writes a JSON error response
+func writeError(w http.ResponseWriter, message string, status int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
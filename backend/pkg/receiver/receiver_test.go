@@ -0,0 +1,166 @@
+package receiver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/otiai10/namazu/backend/internal/delivery/webhook"
+)
+
+const testSecret = "nmz_receiver"
+
+const quakeJSON = `{"_id": "e1", "code": 551, "time": "2024/01/01 16:10:00.000",
+	"earthquake": {"time": "2024/01/01 16:10:00", "maxScale": 70, "hypocenter": {"name": "石川県能登地方"}},
+	"points": [{"pref": "石川県", "addr": "志賀町", "scale": 70}]}`
+
+func post(h http.Handler, body string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/webhook", bytes.NewBufferString(body))
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func v0Headers(secret, body string, at time.Time) map[string]string {
+	ts := at.Unix()
+	return map[string]string{
+		SignatureHeader: webhook.SignV0(secret, ts, []byte(body)),
+		TimestampHeader: strconv.FormatInt(ts, 10),
+	}
+}
+
+func TestHandler_Earthquake(t *testing.T) {
+	var got *Event
+	h := New(testSecret, func(ctx context.Context, e *Event) error {
+		got = e
+		return nil
+	})
+
+	headers := v0Headers(testSecret, quakeJSON, time.Now())
+	headers[webhook.RedeliveryHeader] = "true"
+	headers[webhook.EventIDHeader] = "e1"
+	headers[webhook.DeliveryIDHeader] = "d1"
+	rec := post(h, quakeJSON, headers)
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusNoContent, rec.Code, rec.Body.String())
+	}
+	if got == nil || got.Kind != KindEarthquake || got.Earthquake == nil || !got.Redelivery || got.EventID != "e1" || got.DeliveryID != "d1" {
+		t.Fatalf("unexpected event %+v", got)
+	}
+	if areas := got.Earthquake.GetAffectedAreas(); got.Earthquake.GetID() != "e1" || len(areas) != 1 || areas[0] != "石川県" {
+		t.Errorf("Earthquake = %s in %v", got.Earthquake.GetID(), areas)
+	}
+}
+
+func TestHandler_Kinds(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{name: "notice", body: `{"type": "namazu.service_notice", "id": "notice-1", "title": "Maintenance"}`, want: KindNotice},
+		{name: "digest", body: `{"type": "namazu.digest", "id": "d1", "count": 2, "events": []}`, want: KindDigest},
+		{name: "template", body: `{"text": "地震です"}`, want: KindOther},
+		{name: "plain text template", body: `地震です`, want: KindOther},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got *Event
+			h := New(testSecret, func(ctx context.Context, e *Event) error {
+				got = e
+				return nil
+			})
+			rec := post(h, tt.body, map[string]string{SignatureHeader: webhook.Sign(testSecret, []byte(tt.body))})
+			if rec.Code != http.StatusNoContent {
+				t.Fatalf("expected status %d, got %d: %s", http.StatusNoContent, rec.Code, rec.Body.String())
+			}
+			if got.Kind != tt.want || string(got.Body) != tt.body {
+				t.Errorf("Kind = %q, want %q", got.Kind, tt.want)
+			}
+			if (got.Notice != nil) != (tt.want == KindNotice) || (got.Digest != nil) != (tt.want == KindDigest) {
+				t.Errorf("unexpected parsed payload %+v", got)
+			}
+		})
+	}
+}
+
+func TestHandler_Challenge(t *testing.T) {
+	called := false
+	h := New(testSecret, func(ctx context.Context, e *Event) error {
+		called = true
+		return nil
+	}, RequireTimestamp())
+
+	body := `{"type": "url_verification", "challenge": "abc123"}`
+	rec := post(h, body, map[string]string{SignatureHeader: webhook.Sign(testSecret, []byte(body))})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+	}
+	var resp webhook.ChallengeResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || resp.Challenge != "abc123" {
+		t.Errorf("unexpected challenge response %s", rec.Body.String())
+	}
+	if called {
+		t.Error("expected challenges not to reach the callback")
+	}
+
+	rec = post(h, body, map[string]string{SignatureHeader: webhook.Sign("other", []byte(body))})
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected an unsigned challenge to be rejected, got %d", rec.Code)
+	}
+}
+
+func TestHandler_Rejects(t *testing.T) {
+	h := New(testSecret, func(ctx context.Context, e *Event) error {
+		t.Error("expected the callback not to be called")
+		return nil
+	}, RequireTimestamp(), WithMaxAge(time.Minute))
+
+	tests := []struct {
+		name    string
+		headers map[string]string
+	}{
+		{name: "no signature"},
+		{name: "wrong secret", headers: v0Headers("other", quakeJSON, time.Now())},
+		{name: "stale timestamp", headers: v0Headers(testSecret, quakeJSON, time.Now().Add(-2*time.Minute))},
+		{name: "legacy signature", headers: map[string]string{SignatureHeader: webhook.Sign(testSecret, []byte(quakeJSON))}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if rec := post(h, quakeJSON, tt.headers); rec.Code != http.StatusUnauthorized {
+				t.Errorf("expected status %d, got %d", http.StatusUnauthorized, rec.Code)
+			}
+		})
+	}
+}
+
+func TestHandler_SecretRotation(t *testing.T) {
+	// During the grace period the old secret signs X-Signature-256-Previous
+	now := time.Now()
+	headers := v0Headers("nmz_new", quakeJSON, now)
+	headers[webhook.PreviousSignatureHeader] = webhook.SignV0(testSecret, now.Unix(), []byte(quakeJSON))
+
+	h := New(testSecret, func(ctx context.Context, e *Event) error { return nil })
+	if rec := post(h, quakeJSON, headers); rec.Code != http.StatusNoContent {
+		t.Errorf("expected the old secret to be accepted, got %d", rec.Code)
+	}
+}
+
+func TestHandler_CallbackError(t *testing.T) {
+	h := New(testSecret, func(ctx context.Context, e *Event) error {
+		return errors.New("database unavailable")
+	})
+	rec := post(h, quakeJSON, v0Headers(testSecret, quakeJSON, time.Now()))
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("expected status %d so the delivery is retried, got %d", http.StatusInternalServerError, rec.Code)
+	}
+}
@@ -0,0 +1,131 @@
+import { useState, useEffect, useCallback } from 'react'
+import { api, ApiError, type Delivery } from '@/lib/api'
+import { LoadingSpinner } from './LoadingSpinner'
+
+interface DeliveryHistoryProps {
+  subscriptionId: string
+}
+
+export function DeliveryHistory({ subscriptionId }: DeliveryHistoryProps) {
+  const [deliveries, setDeliveries] = useState<Delivery[]>([])
+  const [isLoading, setIsLoading] = useState(true)
+  const [error, setError] = useState<string | null>(null)
+  const [redeliveringId, setRedeliveringId] = useState<string | null>(null)
+  const [isTesting, setIsTesting] = useState(false)
+  const [testMessage, setTestMessage] = useState<string | null>(null)
+
+  const load = useCallback(async () => {
+    try {
+      setIsLoading(true)
+      setError(null)
+      setDeliveries(await api.listDeliveries(subscriptionId))
+    } catch (err) {
+      setError(
+        err instanceof ApiError && err.status === 501
+          ? 'このサーバーでは配信履歴が記録されていません'
+          : '配信履歴の読み込みに失敗しました'
+      )
+    } finally {
+      setIsLoading(false)
+    }
+  }, [subscriptionId])
+
+  useEffect(() => {
+    load()
+  }, [load])
+
+  const handleRedeliver = async (id: string) => {
+    try {
+      setRedeliveringId(id)
+      setError(null)
+      const result = await api.redeliver(id)
+      if (!result.success) {
+        setError(`再送に失敗しました${result.status_code ? `（HTTP ${result.status_code}）` : ''}`)
+      }
+      await load()
+    } catch (err) {
+      setError(err instanceof ApiError && err.status === 410
+        ? 'イベントのデータが残っていないため再送できません'
+        : '再送に失敗しました')
+    } finally {
+      setRedeliveringId(null)
+    }
+  }
+
+  const handleTest = async () => {
+    try {
+      setIsTesting(true)
+      setTestMessage(null)
+      const result = await api.testSubscription(subscriptionId)
+      setTestMessage(result.success
+        ? `テスト送信に成功しました（HTTP ${result.status_code}、${result.response_time_ms} ms）`
+        : `テスト送信に失敗しました${result.status_code ? `（HTTP ${result.status_code}）` : ''}${result.error_message ? `: ${result.error_message}` : ''}`)
+    } catch (err) {
+      setTestMessage(err instanceof ApiError && err.status === 404
+        ? '送信できる保存済みイベントがありません'
+        : 'テスト送信に失敗しました')
+    } finally {
+      setIsTesting(false)
+    }
+  }
+
+  if (isLoading && deliveries.length === 0) {
+    return <LoadingSpinner />
+  }
+
+  return (
+    <div className="mt-4 border-t border-gray-100 pt-4">
+      <div className="flex items-center justify-between gap-3 mb-2">
+        <p className="text-xs text-gray-500 min-w-0">{testMessage}</p>
+        <button
+          onClick={handleTest}
+          disabled={isTesting}
+          className="px-3 py-1 text-sm text-blue-600 hover:bg-blue-50 rounded-lg transition-colors disabled:opacity-50 shrink-0"
+        >
+          {isTesting ? '送信中...' : 'テスト送信'}
+        </button>
+      </div>
+      {error && <p className="text-sm text-red-600 mb-2">{error}</p>}
+      {deliveries.length === 0 ? (
+        <p className="text-sm text-gray-500">直近 30 日の配信はありません</p>
+      ) : (
+        <ul className="divide-y divide-gray-100 text-sm">
+          {deliveries.map((d) => (
+            <li key={d.id} className="flex items-center justify-between gap-3 py-2">
+              <div className="min-w-0">
+                <span className={d.success ? 'text-green-700' : 'text-red-700'}>
+                  {d.success ? '成功' : '失敗'}
+                  {d.status_code > 0 && ` (${d.status_code})`}
+                </span>
+                {d.redelivery && <span className="ml-2 text-xs text-gray-500">再送</span>}
+                {d.retry_count > 0 && (
+                  <span className="ml-2 text-xs text-gray-500">リトライ {d.retry_count} 回</span>
+                )}
+                <p className="text-xs text-gray-500 truncate">
+                  {new Date(d.delivered_at).toLocaleString('ja-JP')} ・ {d.event_id}
+                </p>
+                {d.error_message && (
+                  <p className="text-xs text-gray-500 truncate">{d.error_message}</p>
+                )}
+                {!d.success && d.response_body && (
+                  <pre className="mt-1 text-xs text-gray-500 whitespace-pre-wrap break-all line-clamp-3">
+                    {d.response_body}
+                  </pre>
+                )}
+              </div>
+              {!d.success && (
+                <button
+                  onClick={() => handleRedeliver(d.id)}
+                  disabled={redeliveringId !== null}
+                  className="px-3 py-1 text-sm text-blue-600 hover:bg-blue-50 rounded-lg transition-colors disabled:opacity-50 shrink-0"
+                >
+                  {redeliveringId === d.id ? '再送中...' : '再送'}
+                </button>
+              )}
+            </li>
+          ))}
+        </ul>
+      )}
+    </div>
+  )
+}
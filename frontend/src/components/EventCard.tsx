@@ -2,6 +2,12 @@ import { SeverityBadge } from './SeverityBadge'
 import { formatRelativeTime } from '@/lib/severity'
 import type { EarthquakeEvent } from '@/hooks/useEvents'
 
+const EVENT_TYPE_LABELS: Record<string, string> = {
+  earthquake: '地震情報',
+  tsunami: '津波情報',
+  eew: '緊急地震速報',
+}
+
 interface EventCardProps {
   event: EarthquakeEvent
   isNew?: boolean
@@ -15,7 +21,7 @@ export function EventCard({ event, isNew = false }: EventCardProps) {
         <div className="flex-1 min-w-0">
           <div className="flex items-center justify-between">
             <p className="text-sm font-medium text-gray-900 truncate">
-              {event.type === 'earthquake' ? '地震情報' : event.type}
+              {EVENT_TYPE_LABELS[event.type] ?? event.type}
             </p>
             <span className="text-xs text-gray-400 flex-shrink-0 ml-2">
               {formatRelativeTime(event.occurredAt)}
@@ -0,0 +1,79 @@
+import { useState, useEffect } from 'react'
+import { api } from '@/lib/api'
+import { isPushSupported, currentPushSubscription, enablePush, disablePush } from '@/lib/push'
+
+// Settings card for OS-level notifications on this browser. Enabling also
+// creates a "webpush" subscription if the user has none, so alerts start
+// arriving without visiting the subscription form.
+export function PushNotifications() {
+  const [enabled, setEnabled] = useState(false)
+  const [isBusy, setIsBusy] = useState(false)
+  const [error, setError] = useState<string | null>(null)
+
+  useEffect(() => {
+    currentPushSubscription().then((sub) => setEnabled(sub !== null))
+  }, [])
+
+  async function handleEnable() {
+    try {
+      setIsBusy(true)
+      setError(null)
+      await enablePush()
+      const subscriptions = await api.listSubscriptions()
+      if (!subscriptions.some((s) => s.delivery.type === 'webpush')) {
+        await api.createSubscription({
+          name: 'ブラウザ通知',
+          delivery: { type: 'webpush', url: '' },
+          filter: { min_scale: 40 },
+        })
+      }
+      setEnabled(true)
+    } catch (err) {
+      setError(err instanceof Error ? err.message : '通知を有効にできませんでした')
+    } finally {
+      setIsBusy(false)
+    }
+  }
+
+  async function handleDisable() {
+    try {
+      setIsBusy(true)
+      setError(null)
+      await disablePush()
+      setEnabled(false)
+    } catch (err) {
+      setError(err instanceof Error ? err.message : '通知を無効にできませんでした')
+    } finally {
+      setIsBusy(false)
+    }
+  }
+
+  return (
+    <div className="card">
+      <h2 className="text-lg font-semibold text-gray-900 mb-4">ブラウザ通知</h2>
+
+      {!isPushSupported ? (
+        <p className="text-sm text-gray-500">このブラウザはプッシュ通知に対応していません。</p>
+      ) : (
+        <div className="flex items-center justify-between">
+          <p className="text-sm text-gray-600">
+            {enabled
+              ? 'この端末で地震速報の通知を受け取ります。タブを閉じていても表示されます。'
+              : 'この端末で地震速報を OS の通知として受け取ります（震度4 以上）。'}
+          </p>
+          {enabled ? (
+            <button onClick={handleDisable} disabled={isBusy} className="btn btn-secondary">
+              無効にする
+            </button>
+          ) : (
+            <button onClick={handleEnable} disabled={isBusy} className="btn btn-primary">
+              有効にする
+            </button>
+          )}
+        </div>
+      )}
+
+      {error && <p className="mt-3 text-sm text-red-600">{error}</p>}
+    </div>
+  )
+}
@@ -1,16 +1,24 @@
+import { useState } from 'react'
 import type { Subscription } from '@/lib/api'
+import { DeliveryHistory } from './DeliveryHistory'
 
 interface SubscriptionCardProps {
   subscription: Subscription
   onEdit: () => void
   onDelete: () => void
+  onReactivate: () => void
+  onTogglePause: () => void
 }
 
 export function SubscriptionCard({
   subscription,
   onEdit,
   onDelete,
+  onReactivate,
+  onTogglePause,
 }: SubscriptionCardProps) {
+  const [showHistory, setShowHistory] = useState(false)
+
   return (
     <div className="card hover:shadow-md transition-shadow">
       <div className="flex flex-col sm:flex-row sm:justify-between sm:items-start gap-3">
@@ -22,6 +30,18 @@ export function SubscriptionCard({
             {subscription.delivery.url}
           </p>
           <div className="flex flex-wrap gap-2 mt-3">
+            {subscription.filter?.event_types?.includes('tsunami') && (
+              <span className="inline-flex items-center px-2.5 py-0.5 rounded-full text-xs font-medium bg-cyan-100 text-cyan-800">
+                {subscription.filter.event_types.includes('earthquake')
+                  ? '地震・津波'
+                  : '津波のみ'}
+              </span>
+            )}
+            {subscription.filter?.eew && (
+              <span className="inline-flex items-center px-2.5 py-0.5 rounded-full text-xs font-medium bg-red-100 text-red-800">
+                緊急地震速報
+              </span>
+            )}
             {subscription.filter?.min_scale && (
               <span className="inline-flex items-center px-2.5 py-0.5 rounded-full text-xs font-medium bg-yellow-100 text-yellow-800">
                 震度 {scaleToDisplay(subscription.filter.min_scale)} 以上
@@ -35,6 +55,34 @@ export function SubscriptionCard({
                 {pref}
               </span>
             ))}
+            {subscription.expires_at && (
+              <span className="inline-flex items-center px-2.5 py-0.5 rounded-full text-xs font-medium bg-gray-100 text-gray-800">
+                {new Date(subscription.expires_at).toLocaleDateString('ja-JP')} まで
+              </span>
+            )}
+            {subscription.status === 'warned' && (
+              <span className="inline-flex items-center px-2.5 py-0.5 rounded-full text-xs font-medium bg-orange-100 text-orange-800">
+                {subscription.status_reason === 'expiring' ? 'まもなく期限切れ' : '停止予定'}
+              </span>
+            )}
+            {subscription.status === 'suspended' && (
+              <span className="inline-flex items-center px-2.5 py-0.5 rounded-full text-xs font-medium bg-red-100 text-red-800">
+                {subscription.status_reason === 'expired'
+                  ? '期限切れで停止中'
+                  : subscription.status_reason === 'failing'
+                    ? '配信失敗で停止中'
+                    : subscription.status_reason === 'over_quota'
+                      ? 'プラン上限で停止中'
+                      : subscription.status_reason === 'orphaned'
+                        ? 'アーカイブ済み'
+                        : '停止中'}
+              </span>
+            )}
+            {!subscription.active && (
+              <span className="inline-flex items-center px-2.5 py-0.5 rounded-full text-xs font-medium bg-gray-200 text-gray-700">
+                一時停止中
+              </span>
+            )}
             {subscription.delivery.retry?.enabled && (
               <span className="inline-flex items-center px-2.5 py-0.5 rounded-full text-xs font-medium bg-green-100 text-green-800">
                 リトライ有効
@@ -43,6 +91,28 @@ export function SubscriptionCard({
           </div>
         </div>
         <div className="flex space-x-2 self-end sm:self-start sm:ml-4 shrink-0">
+          {(subscription.status === 'warned' || subscription.status === 'suspended') &&
+            subscription.status_reason !== 'expired' && (
+              <button
+                onClick={onReactivate}
+                className="px-3 py-1 text-sm text-blue-600 hover:bg-blue-50 rounded-lg transition-colors"
+              >
+                再開
+              </button>
+            )}
+          <button
+            onClick={onTogglePause}
+            className="px-3 py-1 text-sm text-gray-600 hover:bg-gray-100 rounded-lg transition-colors"
+          >
+            {subscription.active ? '一時停止' : '配信再開'}
+          </button>
+          <button
+            onClick={() => setShowHistory((v) => !v)}
+            className="px-3 py-1 text-sm text-gray-600 hover:bg-gray-100 rounded-lg transition-colors"
+            aria-expanded={showHistory}
+          >
+            配信履歴
+          </button>
           <button
             onClick={onEdit}
             className="p-2 text-gray-400 hover:text-gray-600 hover:bg-gray-100 rounded-lg transition-colors"
@@ -87,6 +157,7 @@ export function SubscriptionCard({
           </button>
         </div>
       </div>
+      {showHistory && <DeliveryHistory subscriptionId={subscription.id} />}
     </div>
   )
 }
@@ -1,7 +1,12 @@
 import { useState } from 'react'
-import { api, type Subscription, type CreateSubscriptionInput, type CreateSubscriptionResponse } from '@/lib/api'
+import { api, ApiError, type Subscription, type CreateSubscriptionInput, type CreateSubscriptionResponse, type EventType } from '@/lib/api'
 import { SecretDisplay } from './SecretDisplay'
 
+const EVENT_TYPE_OPTIONS: { value: EventType; label: string }[] = [
+  { value: 'earthquake', label: '地震' },
+  { value: 'tsunami', label: '津波' },
+]
+
 interface SubscriptionFormProps {
   subscription?: Subscription
   onClose: () => void
@@ -20,14 +25,34 @@ export function SubscriptionForm({
 
   const [name, setName] = useState(subscription?.name || '')
   const [url, setUrl] = useState(subscription?.delivery.url || '')
+  const [serviceNotices, setServiceNotices] = useState(
+    subscription?.delivery.service_notices || false
+  )
   const [minScale, setMinScale] = useState(subscription?.filter?.min_scale || 0)
   const [prefectures, setPrefectures] = useState(
     subscription?.filter?.prefectures?.join(', ') || ''
   )
+  const [eventTypes, setEventTypes] = useState<EventType[]>(
+    subscription?.filter?.event_types || ['earthquake']
+  )
+  const [eew, setEEW] = useState(subscription?.filter?.eew || false)
+  const [expiresOn, setExpiresOn] = useState(
+    subscription?.expires_at ? toDateInput(subscription.expires_at) : ''
+  )
+
+  function toggleEventType(type: EventType, checked: boolean) {
+    setEventTypes((current) =>
+      checked ? [...current, type] : current.filter((t) => t !== type)
+    )
+  }
 
   async function handleSubmit(e: React.FormEvent) {
     e.preventDefault()
     setError(null)
+    if (eventTypes.length === 0) {
+      setError('通知するイベントを1つ以上選択してください')
+      return
+    }
     setIsSubmitting(true)
 
     const input: CreateSubscriptionInput = {
@@ -35,9 +60,10 @@ export function SubscriptionForm({
       delivery: {
         type: 'webhook',
         url,
+        service_notices: serviceNotices || undefined,
       },
       filter:
-        minScale > 0 || prefectures.trim()
+        minScale > 0 || prefectures.trim() || !isDefaultEventTypes(eventTypes) || eew
           ? {
               min_scale: minScale > 0 ? minScale : undefined,
               prefectures: prefectures.trim()
@@ -46,20 +72,26 @@ export function SubscriptionForm({
                     .map((p) => p.trim())
                     .filter(Boolean)
                 : undefined,
+              event_types: isDefaultEventTypes(eventTypes) ? undefined : eventTypes,
+              eew: eew || undefined,
             }
           : undefined,
+      // The subscription stays active until the end of the selected day
+      expires_at: expiresOn
+        ? new Date(`${expiresOn}T23:59:59`).toISOString()
+        : undefined,
     }
 
     try {
       if (isEditing && subscription) {
-        await api.updateSubscription(subscription.id, input)
+        await api.updateSubscription(subscription.id, input, subscription.etag)
         onSuccess()
       } else {
         const response: CreateSubscriptionResponse = await api.createSubscription(input)
         setCreatedSecret(response.delivery.secret)
       }
     } catch (err) {
-      setError(err instanceof Error ? err.message : '保存に失敗しました')
+      setError(saveErrorMessage(err))
     } finally {
       setIsSubmitting(false)
     }
@@ -131,11 +163,60 @@ export function SubscriptionForm({
           />
         </div>
 
+        <label className="flex items-center space-x-2 text-sm text-gray-700">
+          <input
+            type="checkbox"
+            checked={serviceNotices}
+            onChange={(e) => setServiceNotices(e.target.checked)}
+          />
+          <span>メンテナンス等のサービスからのお知らせも受け取る</span>
+        </label>
+
+        <div>
+          <label className="label">有効期限 (オプション)</label>
+          <input
+            type="date"
+            value={expiresOn}
+            onChange={(e) => setExpiresOn(e.target.value)}
+            className="input"
+          />
+          <p className="text-xs text-gray-500 mt-1">
+            期限を過ぎると配信を停止します。7日前にメールでお知らせします。
+          </p>
+        </div>
+
         <div className="border-t border-gray-200 pt-4">
           <h3 className="text-sm font-medium text-gray-900 mb-3">
             フィルタ設定 (オプション)
           </h3>
 
+          <div className="mb-4">
+            <label className="label">通知するイベント</label>
+            <div className="flex space-x-4">
+              {EVENT_TYPE_OPTIONS.map(({ value, label }) => (
+                <label
+                  key={value}
+                  className="flex items-center space-x-2 text-sm text-gray-700"
+                >
+                  <input
+                    type="checkbox"
+                    checked={eventTypes.includes(value)}
+                    onChange={(e) => toggleEventType(value, e.target.checked)}
+                  />
+                  <span>{label}</span>
+                </label>
+              ))}
+              <label className="flex items-center space-x-2 text-sm text-gray-700">
+                <input
+                  type="checkbox"
+                  checked={eew}
+                  onChange={(e) => setEEW(e.target.checked)}
+                />
+                <span>緊急地震速報</span>
+              </label>
+            </div>
+          </div>
+
           <div className="grid md:grid-cols-2 gap-4">
             <div>
               <label className="label">最小震度</label>
@@ -186,3 +267,34 @@ export function SubscriptionForm({
     </div>
   )
 }
+
+// saveErrorMessage explains a failed save, branching on the error code where the message needs context
+function saveErrorMessage(err: unknown): string {
+  if (err instanceof ApiError) {
+    switch (err.code) {
+      case 'quota_exceeded':
+        return `プランの Subscription 数の上限（${err.details.limit}）に達しています`
+      case 'plan_feature':
+        return `現在のプランでは使えない機能です: ${err.message}`
+      case 'webhook_verification_failed':
+        return `Webhook URL の検証に失敗しました: ${err.message}`
+      case 'precondition_failed':
+        return '他の画面でこの Subscription が変更されました。再読み込みしてから編集し直してください'
+    }
+  }
+  return err instanceof Error ? err.message : '保存に失敗しました'
+}
+
+/**
+ * Formats an RFC 3339 timestamp as a local YYYY-MM-DD value for date inputs.
+ */
+function toDateInput(iso: string): string {
+  const d = new Date(iso)
+  const pad = (n: number) => String(n).padStart(2, '0')
+  return `${d.getFullYear()}-${pad(d.getMonth() + 1)}-${pad(d.getDate())}`
+}
+
+// Earthquake-only is the server default, so it is sent as no selection
+function isDefaultEventTypes(types: EventType[]): boolean {
+  return types.length === 1 && types[0] === 'earthquake'
+}
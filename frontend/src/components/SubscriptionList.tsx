@@ -7,6 +7,8 @@ interface SubscriptionListProps {
   isLoading: boolean
   onEdit: (sub: Subscription) => void
   onDelete: (id: string) => Promise<void>
+  onReactivate: (id: string) => Promise<void>
+  onTogglePause: (sub: Subscription) => Promise<void>
   onCreateNew: () => void
 }
 
@@ -15,6 +17,8 @@ export function SubscriptionList({
   isLoading,
   onEdit,
   onDelete,
+  onReactivate,
+  onTogglePause,
   onCreateNew,
 }: SubscriptionListProps) {
   if (isLoading) {
@@ -60,6 +64,8 @@ export function SubscriptionList({
           subscription={sub}
           onEdit={() => onEdit(sub)}
           onDelete={() => onDelete(sub.id)}
+          onReactivate={() => onReactivate(sub.id)}
+          onTogglePause={() => onTogglePause(sub)}
         />
       ))}
     </div>
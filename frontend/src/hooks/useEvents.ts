@@ -52,6 +52,19 @@ function firestoreDocToEvent(
   }
 }
 
+function responseToEvent(e: Record<string, unknown>): EarthquakeEvent {
+  return {
+    id: e.id as string,
+    type: e.type as string,
+    source: e.source as string,
+    severity: e.severity as number,
+    affectedAreas: (e.affectedAreas as string[]) || [],
+    occurredAt: new Date(e.occurredAt as string),
+    receivedAt: new Date(e.receivedAt as string),
+    createdAt: new Date(e.createdAt as string),
+  }
+}
+
 export function useEvents(): UseEventsResult {
   const [events, setEvents] = useState<EarthquakeEvent[]>([])
   const [isLoading, setIsLoading] = useState(true)
@@ -85,25 +98,17 @@ export function useEvents(): UseEventsResult {
       return () => unsubscribe()
     }
 
-    // Demo mode fallback: fetch from REST API once
+    // Demo mode fallback: fetch from REST API once, then follow the live stream
     let cancelled = false
+    let socket: WebSocket | null = null
     async function fetchEvents() {
       try {
         const data = await api.listEvents()
         if (cancelled) return
-        const mapped = (data as Array<Record<string, string | number | string[]>>).map(
-          (e) => ({
-            id: e.id as string,
-            type: e.type as string,
-            source: e.source as string,
-            severity: e.severity as number,
-            affectedAreas: (e.affectedAreas as string[]) || [],
-            occurredAt: new Date(e.occurredAt as string),
-            receivedAt: new Date(e.receivedAt as string),
-            createdAt: new Date(e.createdAt as string),
-          })
+        setEvents(
+          (data as Array<Record<string, unknown>>).map(responseToEvent)
         )
-        setEvents(mapped)
+        openStream()
       } catch (err) {
         if (cancelled) return
         setError(
@@ -113,8 +118,30 @@ export function useEvents(): UseEventsResult {
         if (!cancelled) setIsLoading(false)
       }
     }
+    async function openStream() {
+      try {
+        const ws = await api.openEventStream()
+        if (cancelled) {
+          ws.close()
+          return
+        }
+        socket = ws
+        ws.onmessage = (message) => {
+          const event = responseToEvent(JSON.parse(message.data))
+          setEvents((prev) =>
+            [event, ...prev.filter((e) => e.id !== event.id)].slice(0, EVENT_LIMIT)
+          )
+        }
+      } catch (err) {
+        // The list stays usable without live updates
+        console.error('[namazu] event stream unavailable:', err)
+      }
+    }
     fetchEvents()
-    return () => { cancelled = true }
+    return () => {
+      cancelled = true
+      socket?.close()
+    }
   }, [])
 
   return { events, isLoading, error }
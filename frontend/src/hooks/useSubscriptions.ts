@@ -11,6 +11,8 @@ interface UseSubscriptionsResult {
   openEditForm: (sub: Subscription) => void
   closeForm: () => void
   handleDelete: (id: string) => Promise<void>
+  handleReactivate: (id: string) => Promise<void>
+  handleTogglePause: (sub: Subscription) => Promise<void>
   handleFormSuccess: () => void
 }
 
@@ -48,6 +50,26 @@ export function useSubscriptions(): UseSubscriptionsResult {
     }
   }, [])
 
+  const handleReactivate = useCallback(async (id: string) => {
+    try {
+      await api.reactivateSubscription(id)
+      loadSubscriptions()
+    } catch (err) {
+      setError(err instanceof Error ? err.message : '再開に失敗しました')
+    }
+  }, [loadSubscriptions])
+
+  const handleTogglePause = useCallback(async (sub: Subscription) => {
+    try {
+      const updated = sub.active
+        ? await api.pauseSubscription(sub.id)
+        : await api.resumeSubscription(sub.id)
+      setSubscriptions((prev) => prev.map((s) => (s.id === updated.id ? updated : s)))
+    } catch (err) {
+      setError(err instanceof Error ? err.message : (sub.active ? '一時停止に失敗しました' : '再開に失敗しました'))
+    }
+  }, [])
+
   const editingSubscription = editingId
     ? subscriptions.find((s) => s.id === editingId)
     : undefined
@@ -80,6 +102,8 @@ export function useSubscriptions(): UseSubscriptionsResult {
     openEditForm,
     closeForm,
     handleDelete,
+    handleReactivate,
+    handleTogglePause,
     handleFormSuccess,
   }
 }
@@ -2,18 +2,66 @@ import { auth, isFirebaseConfigured } from './firebase'
 
 const API_BASE = '/api'
 
+// Omitting event_types delivers earthquakes only
+export type EventType = 'earthquake' | 'tsunami'
+
 interface FetchOptions extends RequestInit {
   requireAuth?: boolean
 }
 
+// Error codes of the API that the UI branches on (the full catalog is in
+// backend/internal/apierr)
+export type ApiErrorCode =
+  | 'validation_failed'
+  | 'webhook_verification_failed'
+  | 'not_owner'
+  | 'quota_exceeded'
+  | 'plan_feature'
+  | 'email_not_verified'
+  | 'terms_not_accepted'
+  | 'terms_outdated'
+  | 'rate_limited'
+  | 'precondition_failed'
+  | (string & {})
+
+// Body of an error response: {"error": {"code", "message", "details"}}
+interface ApiErrorBody {
+  error?: {
+    code?: string
+    message?: string
+    details?: Record<string, unknown>
+  }
+}
+
 class ApiError extends Error {
   constructor(
     public status: number,
-    message: string
+    message: string,
+    public code: ApiErrorCode = '',
+    public details: Record<string, unknown> = {}
   ) {
     super(message)
     this.name = 'ApiError'
   }
+
+  // fromResponse reads the error envelope of a failed response
+  static async fromResponse(response: Response): Promise<ApiError> {
+    const text = await response.text().catch(() => '')
+    try {
+      const body = JSON.parse(text) as ApiErrorBody
+      if (body.error?.code) {
+        return new ApiError(
+          response.status,
+          body.error.message || response.statusText,
+          body.error.code,
+          body.error.details ?? {}
+        )
+      }
+    } catch {
+      // Not JSON, e.g. from a proxy in front of the API
+    }
+    return new ApiError(response.status, text || 'Unknown error')
+  }
 }
 
 async function fetchWithAuth(
@@ -41,14 +89,50 @@ async function fetchWithAuth(
   })
 
   if (!response.ok) {
-    const errorText = await response.text().catch(() => 'Unknown error')
-    throw new ApiError(response.status, errorText)
+    throw await ApiError.fromResponse(response)
   }
 
   return response
 }
 
 // Subscription types
+export interface Geofence {
+  lat: number
+  lon: number
+  radius_km: number
+}
+
+export interface QuietHours {
+  start: string
+  end: string
+  timezone?: string
+  min_scale_override?: number
+}
+
+export interface Throttle {
+  interval_minutes: number
+  dedupe_by?: 'event_id' | 'hypocenter'
+}
+
+export interface AWSDelivery {
+  region: string
+  topic_arn?: string
+  queue_url?: string
+  access_key_id: string
+  secret_access_key?: string // Write-only; never returned
+}
+
+// AWSDestination is AWSDelivery as returned by the API
+export type AWSDestination = Omit<AWSDelivery, 'secret_access_key'>
+
+export interface SMSDelivery {
+  phone: string // E.164, e.g. +819012345678
+  min_scale?: number // Defaults to 50 (震度5弱)
+}
+
+// Language of notification text generated by namazu (push, SMS, digests, emails)
+export type Locale = 'ja' | 'en'
+
 export interface Subscription {
   id: string
   userId?: string
@@ -56,21 +140,69 @@ export interface Subscription {
   delivery: {
     type: string
     url: string
-    secret: string
+    secret?: string // Masked, e.g. "****1234"; rotate the secret to get a new one in full
     secret_prefix?: string
+    previous_secret?: string // Masked
+    previous_secret_expires_at?: string
     verified?: boolean
+    verification_skipped?: boolean // Delivered without passing URL verification (Pro)
     sign_version?: string
+    service_notices?: boolean
+    template?: string
+    enrich?: boolean // Add derived fields (scale labels, timestamps, map URL) under "namazu"
+    format?: 'raw' | 'namazu.v1' // Webhook body format (default raw: the source's JSON as received)
+    headers?: Record<string, string>
+    aws?: AWSDestination
+    sms?: SMSDelivery
     retry?: {
       enabled: boolean
       max_retries: number
       initial_ms: number
       max_ms: number
+      jitter?: number // 0-1: randomly shorten each backoff by up to this fraction
+      status_overrides?: {
+        status: number
+        retry: boolean
+        retry_after?: boolean // Honor Retry-After within the retry window
+      }[]
     }
   }
   filter?: {
     min_scale?: number
     prefectures?: string[]
+    event_types?: EventType[]
+    eew?: boolean
+    min_magnitude?: number
+    max_depth_km?: number
+    hypocenter_name_contains?: string
+    geofence?: Geofence
   }
+  locale?: Locale
+  quiet_hours?: QuietHours
+  throttle?: Throttle
+  digest?: { interval_minutes: number }
+  expires_at?: string
+  status?: 'active' | 'warned' | 'suspended'
+  status_reason?: 'expiring' | 'expired' | 'inactive' | 'failing' | 'over_quota' | 'orphaned'
+  active: boolean
+  paused_at?: string
+  revision?: number
+  updated_at?: string
+  etag: string // Send back as If-Match when updating
+  stats?: SubscriptionStats
+}
+
+export interface SubscriptionStats {
+  window: string
+  delivered: number
+  failed: number
+  success_rate: number | null
+  avg_latency_ms: number | null
+  last_delivery_at?: string
+  last_status?: 'success' | 'failed'
+  last_status_code?: number
+  health?: 'operational' | 'degraded' | 'failing' | 'unknown'
+  computed_at: string
 }
 
 export interface CreateSubscriptionInput {
@@ -78,11 +210,30 @@ export interface CreateSubscriptionInput {
   delivery: {
     type: string
     url: string
+    service_notices?: boolean
+    template?: string
+    enrich?: boolean // Add derived fields (scale labels, timestamps, map URL) under "namazu"
+    format?: 'raw' | 'namazu.v1' // Webhook body format (default raw: the source's JSON as received)
+    headers?: Record<string, string>
+    aws?: AWSDelivery
+    sms?: SMSDelivery
   }
   filter?: {
     min_scale?: number
     prefectures?: string[]
+    event_types?: EventType[]
+    eew?: boolean
+    min_magnitude?: number
+    max_depth_km?: number
+    hypocenter_name_contains?: string
+    geofence?: Geofence
   }
+  locale?: Locale
+  quiet_hours?: QuietHours
+  throttle?: Throttle
+  digest?: { interval_minutes: number }
+  expires_at?: string
+  skip_verification?: boolean // Pro: create without the URL verification challenge
 }
 
 export interface CreateSubscriptionResponse {
@@ -91,7 +242,7 @@ export interface CreateSubscriptionResponse {
   delivery: {
     type: string
     url: string
-    secret: string
+    secret: string // In full: the only time it is returned
     secret_prefix?: string
     verified?: boolean
     sign_version?: string
@@ -99,6 +250,12 @@ export interface CreateSubscriptionResponse {
   filter?: {
     min_scale?: number
     prefectures?: string[]
+    event_types?: EventType[]
+    eew?: boolean
+    min_magnitude?: number
+    max_depth_km?: number
+    hypocenter_name_contains?: string
+    geofence?: Geofence
   }
 }
 
@@ -109,8 +266,91 @@ export interface UserProfile {
   displayName: string
   pictureUrl?: string
   plan: string
+  role?: 'user' | 'admin'
   createdAt: string
   updatedAt: string
+  termsVersion?: string
+  termsAcceptedAt?: string
+  emailVerified: boolean
+  termsAccepted: boolean
+  currentTermsVersion?: string
+  providers: LinkedProvider[]
+}
+
+export interface LinkedProvider {
+  providerId: string
+  subject: string
+  email?: string
+  displayName?: string
+  linkedAt: string
+}
+
+export interface DeletionToken {
+  confirmation_token: string
+  expires_at: string
+}
+
+export interface DeletedAccount {
+  subscriptions: number
+  deliveries: number
+}
+
+export interface ExportEmail {
+  email: string
+  expires_at: string
+}
+
+export interface UsageSummary {
+  period: string
+  requests: number
+  bytes_sent: number
+  deliveries: number
+  events_matched: number
+  budget_bytes: number
+  throttled: boolean
+  subscriptions?: {
+    plan: string
+    count: number
+    limit: number
+  }
+}
+
+export interface Delivery {
+  id: string
+  event_id: string
+  delivery_id?: string
+  status_code: number
+  success: boolean
+  error_message?: string
+  retry_count: number
+  response_time_ms: number
+  response_body?: string
+  redelivery: boolean
+  delivered_at: string
+}
+
+export interface RedeliverResult {
+  event_id: string
+  status_code: number
+  success: boolean
+  error_message?: string
+  response_time_ms: number
+  response_body?: string
+}
+
+export interface TestDeliveryResult {
+  event_id?: string
+  status_code: number
+  success: boolean
+  error_message?: string
+  response_time_ms: number
+  response_body?: string
+}
+
+export interface RotateSecretResult {
+  secret: string
+  secret_prefix: string
+  previous_secret_expires_at?: string
 }
 
 // Billing types
@@ -119,9 +359,50 @@ export interface BillingStatus {
   hasActiveSubscription: boolean
   subscriptionStatus?: string
   subscriptionEndsAt?: string
+  cancelAtPeriodEnd?: boolean
+  billingInterval?: BillingInterval
+  renewsAt?: string
   stripeCustomerId?: string
 }
 
+export type BillingInterval = 'month' | 'year'
+
+export interface CheckoutOptions {
+  plan?: string
+  interval?: BillingInterval
+  promotionCode?: string
+}
+
+export interface BrowserPushSubscription {
+  endpoint: string
+  p256dh: string
+  auth: string
+  userAgent?: string
+  createdAt: string
+}
+
+export interface PushSubscriptionsResponse {
+  publicKey: string // VAPID applicationServerKey
+  subscriptions: BrowserPushSubscription[]
+}
+
+export interface MobileDevice {
+  token: string // FCM registration token
+  platform: 'android' | 'ios' | 'web'
+  name?: string
+  prefectures?: string[] // Prefecture alerts, delivered without a subscription
+  minScale?: number
+  createdAt: string
+}
+
+export interface DeviceRequest {
+  token: string
+  platform: 'android' | 'ios' | 'web'
+  name?: string
+  prefectures?: string[]
+  minScale?: number
+}
+
 export interface CheckoutSessionResponse {
   sessionId: string
   sessionUrl: string
@@ -144,37 +425,216 @@ export const api = {
     return response.json()
   },
 
-  async createSubscription(input: CreateSubscriptionInput): Promise<CreateSubscriptionResponse> {
+  // Pass the same idempotencyKey when retrying, so the subscription is created only once
+  async createSubscription(
+    input: CreateSubscriptionInput,
+    idempotencyKey: string = crypto.randomUUID()
+  ): Promise<CreateSubscriptionResponse> {
     const response = await fetchWithAuth('/subscriptions', {
       method: 'POST',
+      headers: { 'Idempotency-Key': idempotencyKey },
       body: JSON.stringify(input),
     })
     return response.json()
   },
 
-  async updateSubscription(id: string, input: CreateSubscriptionInput): Promise<void> {
+  // etag is the subscription's etag as read; the update fails with 412 if it changed since
+  async updateSubscription(id: string, input: CreateSubscriptionInput, etag: string): Promise<void> {
     await fetchWithAuth(`/subscriptions/${id}`, {
       method: 'PUT',
+      headers: { 'If-Match': etag },
       body: JSON.stringify(input),
     })
   },
 
+  async reactivateSubscription(id: string): Promise<void> {
+    await fetchWithAuth(`/subscriptions/${id}/reactivate`, {
+      method: 'POST',
+    })
+  },
+
+  // Sends the URL verification challenge again
+  async verifySubscription(id: string): Promise<Subscription> {
+    const response = await fetchWithAuth(`/subscriptions/${id}/verify`, {
+      method: 'POST',
+    })
+    return response.json()
+  },
+
+  async pauseSubscription(id: string): Promise<Subscription> {
+    const response = await fetchWithAuth(`/subscriptions/${id}/pause`, {
+      method: 'POST',
+    })
+    return response.json()
+  },
+
+  async resumeSubscription(id: string): Promise<Subscription> {
+    const response = await fetchWithAuth(`/subscriptions/${id}/resume`, {
+      method: 'POST',
+    })
+    return response.json()
+  },
+
+  async rotateSecret(id: string, gracePeriodSeconds?: number): Promise<RotateSecretResult> {
+    const response = await fetchWithAuth(`/subscriptions/${id}/rotate-secret`, {
+      method: 'POST',
+      body: JSON.stringify(
+        gracePeriodSeconds === undefined ? {} : { grace_period_seconds: gracePeriodSeconds }
+      ),
+    })
+    return response.json()
+  },
+
   async deleteSubscription(id: string): Promise<void> {
     await fetchWithAuth(`/subscriptions/${id}`, {
       method: 'DELETE',
     })
   },
 
+  // Deliveries
+  async listDeliveries(subscriptionId: string): Promise<Delivery[]> {
+    const response = await fetchWithAuth(`/subscriptions/${subscriptionId}/deliveries`)
+    return response.json()
+  },
+
+  async testSubscription(subscriptionId: string): Promise<TestDeliveryResult> {
+    const response = await fetchWithAuth(`/subscriptions/${subscriptionId}/test`, {
+      method: 'POST',
+    })
+    return response.json()
+  },
+
+  async redeliver(deliveryId: string): Promise<RedeliverResult> {
+    const response = await fetchWithAuth(`/deliveries/${deliveryId}/redeliver`, {
+      method: 'POST',
+    })
+    return response.json()
+  },
+
   // User profile
   async getProfile(): Promise<UserProfile> {
     const response = await fetchWithAuth('/me')
     return response.json()
   },
 
+  async acceptTerms(version: string): Promise<UserProfile> {
+    const response = await fetchWithAuth('/me/accept-terms', {
+      method: 'POST',
+      body: JSON.stringify({ version }),
+    })
+    return response.json()
+  },
+
+  // Account linking: idToken is from a sign-in with the provider to link
+  async listProviders(): Promise<LinkedProvider[]> {
+    const response = await fetchWithAuth('/me/providers')
+    return response.json()
+  },
+
+  async linkProvider(idToken: string): Promise<LinkedProvider> {
+    const response = await fetchWithAuth('/me/providers/link', {
+      method: 'POST',
+      body: JSON.stringify({ id_token: idToken }),
+    })
+    return response.json()
+  },
+
+  async unlinkProvider(providerId: string): Promise<void> {
+    await fetchWithAuth(`/me/providers/${encodeURIComponent(providerId)}`, {
+      method: 'DELETE',
+    })
+  },
+
+  async getUsage(): Promise<UsageSummary> {
+    const response = await fetchWithAuth('/me/usage')
+    return response.json()
+  },
+
+  // Account deletion: request a confirmation token, then delete with it
+  async createDeletionToken(): Promise<DeletionToken> {
+    const response = await fetchWithAuth('/me/deletion', { method: 'POST' })
+    return response.json()
+  },
+
+  async deleteAccount(confirmationToken: string): Promise<DeletedAccount> {
+    const response = await fetchWithAuth('/me', {
+      method: 'DELETE',
+      body: JSON.stringify({ confirmation_token: confirmationToken }),
+    })
+    return response.json()
+  },
+
+  // Data export: download the archive now, or email a download link
+  async exportData(): Promise<Blob> {
+    const response = await fetchWithAuth('/me/export')
+    return response.blob()
+  },
+
+  async emailExport(): Promise<ExportEmail> {
+    const response = await fetchWithAuth('/me/export', { method: 'POST' })
+    return response.json()
+  },
+
+  // Web Push
+  async listPushSubscriptions(): Promise<PushSubscriptionsResponse> {
+    const response = await fetchWithAuth('/me/push-subscriptions')
+    return response.json()
+  },
+
+  async createPushSubscription(subscription: PushSubscriptionJSON): Promise<BrowserPushSubscription> {
+    const response = await fetchWithAuth('/me/push-subscriptions', {
+      method: 'POST',
+      body: JSON.stringify(subscription),
+    })
+    return response.json()
+  },
+
+  async deletePushSubscription(endpoint: string): Promise<void> {
+    await fetchWithAuth(`/me/push-subscriptions?endpoint=${encodeURIComponent(endpoint)}`, {
+      method: 'DELETE',
+    })
+  },
+
+  // FCM devices
+  async listDevices(): Promise<MobileDevice[]> {
+    const response = await fetchWithAuth('/me/devices')
+    const data: { devices: MobileDevice[] } = await response.json()
+    return data.devices
+  },
+
+  async registerDevice(device: DeviceRequest): Promise<MobileDevice> {
+    const response = await fetchWithAuth('/me/devices', {
+      method: 'POST',
+      body: JSON.stringify(device),
+    })
+    return response.json()
+  },
+
+  async deleteDevice(token: string): Promise<void> {
+    await fetchWithAuth(`/me/devices?token=${encodeURIComponent(token)}`, {
+      method: 'DELETE',
+    })
+  },
+
   // Events (public)
   async listEvents(): Promise<unknown[]> {
     const response = await fetchWithAuth('/events', { requireAuth: false })
-    return response.json()
+    const page: { events: unknown[] } = await response.json()
+    return page.events
+  },
+
+  // Live events over WebSocket. Browsers cannot set headers on WebSocket
+  // requests, so the ID token is passed as a query parameter.
+  async openEventStream(): Promise<WebSocket> {
+    const params = new URLSearchParams()
+    if (isFirebaseConfigured && auth?.currentUser) {
+      params.set('token', await auth.currentUser.getIdToken())
+    }
+    const protocol = window.location.protocol === 'https:' ? 'wss:' : 'ws:'
+    const query = params.toString()
+    return new WebSocket(
+      `${protocol}//${window.location.host}${API_BASE}/stream${query ? `?${query}` : ''}`
+    )
   },
 
   // Health check (public)
@@ -189,18 +649,23 @@ export const api = {
     return response.json()
   },
 
-  async createCheckoutSession(): Promise<CheckoutSessionResponse> {
+  async createCheckoutSession(options: CheckoutOptions = {}): Promise<CheckoutSessionResponse> {
     const response = await fetchWithAuth('/billing/create-checkout-session', {
       method: 'POST',
+      body: JSON.stringify({
+        plan: options.plan,
+        interval: options.interval,
+        promotion_code: options.promotionCode || undefined,
+      }),
     })
     return response.json()
   },
 
-  async getPortalSession(returnUrl?: string): Promise<PortalSessionResponse> {
-    const url = returnUrl
-      ? `/billing/portal-session?return_url=${encodeURIComponent(returnUrl)}`
-      : '/billing/portal-session'
-    const response = await fetchWithAuth(url)
+  async createPortalSession(returnUrl?: string): Promise<PortalSessionResponse> {
+    const response = await fetchWithAuth('/billing/create-portal-session', {
+      method: 'POST',
+      body: JSON.stringify(returnUrl ? { return_url: returnUrl } : {}),
+    })
     return response.json()
   },
 }
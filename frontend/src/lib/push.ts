@@ -0,0 +1,60 @@
+import { api } from './api'
+
+// Web Push support for OS-level earthquake alerts. The service worker
+// (public/sw.js) shows notifications even when the dashboard is closed.
+
+export const isPushSupported =
+  typeof window !== 'undefined' &&
+  'serviceWorker' in navigator &&
+  'PushManager' in window &&
+  'Notification' in window
+
+// applicationServerKey must be the raw key bytes, not base64url
+function urlBase64ToUint8Array(base64: string): Uint8Array {
+  const padded = (base64 + '='.repeat((4 - (base64.length % 4)) % 4))
+    .replace(/-/g, '+')
+    .replace(/_/g, '/')
+  const raw = atob(padded)
+  return Uint8Array.from(raw, (c) => c.charCodeAt(0))
+}
+
+async function registration(): Promise<ServiceWorkerRegistration> {
+  await navigator.serviceWorker.register('/sw.js')
+  return navigator.serviceWorker.ready
+}
+
+// Returns this browser's push subscription, if it has one
+export async function currentPushSubscription(): Promise<PushSubscription | null> {
+  if (!isPushSupported) return null
+  const reg = await navigator.serviceWorker.getRegistration('/sw.js')
+  return reg ? reg.pushManager.getSubscription() : null
+}
+
+// Asks for permission, subscribes this browser and registers it with the API
+export async function enablePush(): Promise<void> {
+  if (!isPushSupported) {
+    throw new Error('このブラウザはプッシュ通知に対応していません')
+  }
+  const permission = await Notification.requestPermission()
+  if (permission !== 'granted') {
+    throw new Error('通知が許可されていません')
+  }
+
+  const { publicKey } = await api.listPushSubscriptions()
+  const reg = await registration()
+  const subscription =
+    (await reg.pushManager.getSubscription()) ??
+    (await reg.pushManager.subscribe({
+      userVisibleOnly: true,
+      applicationServerKey: urlBase64ToUint8Array(publicKey),
+    }))
+  await api.createPushSubscription(subscription.toJSON())
+}
+
+// Unsubscribes this browser and removes it from the API
+export async function disablePush(): Promise<void> {
+  const subscription = await currentPushSubscription()
+  if (!subscription) return
+  await api.deletePushSubscription(subscription.endpoint)
+  await subscription.unsubscribe()
+}
@@ -1,6 +1,6 @@
 import { createFileRoute, Link } from '@tanstack/react-router'
 import { useState, useEffect, useCallback } from 'react'
-import { api, BillingStatus } from '@/lib/api'
+import { api, BillingInterval, BillingStatus } from '@/lib/api'
 
 export const Route = createFileRoute('/_authenticated/billing')({
   component: BillingPage,
@@ -11,6 +11,8 @@ function BillingPage() {
   const [isLoadingStatus, setIsLoadingStatus] = useState(false)
   const [isProcessing, setIsProcessing] = useState(false)
   const [error, setError] = useState<string | null>(null)
+  const [billingInterval, setBillingInterval] = useState<BillingInterval>('month')
+  const [promotionCode, setPromotionCode] = useState('')
 
   const fetchBillingStatus = useCallback(async () => {
     setIsLoadingStatus(true)
@@ -34,12 +36,19 @@ function BillingPage() {
     setIsProcessing(true)
     setError(null)
     try {
-      const session = await api.createCheckoutSession()
+      const session = await api.createCheckoutSession({
+        interval: billingInterval,
+        promotionCode: promotionCode.trim(),
+      })
       // Redirect to Stripe Checkout
       window.location.href = session.sessionUrl
     } catch (err) {
       console.error('Failed to create checkout session:', err)
-      setError('チェックアウトセッションの作成に失敗しました。')
+      setError(
+        promotionCode.trim()
+          ? 'チェックアウトセッションの作成に失敗しました。プロモーションコードをご確認ください。'
+          : 'チェックアウトセッションの作成に失敗しました。'
+      )
       setIsProcessing(false)
     }
   }
@@ -49,7 +58,7 @@ function BillingPage() {
     setError(null)
     try {
       const returnUrl = window.location.href
-      const portal = await api.getPortalSession(returnUrl)
+      const portal = await api.createPortalSession(returnUrl)
       // Redirect to Stripe Customer Portal
       window.location.href = portal.url
     } catch (err) {
@@ -59,7 +68,8 @@ function BillingPage() {
     }
   }
 
-  const isPro = billingStatus?.plan === 'pro' && billingStatus?.hasActiveSubscription
+  // Any paid plan of the catalog counts as Pro here; the portal manages changes between them
+  const isPro = !!billingStatus && billingStatus.plan !== 'free' && billingStatus.hasActiveSubscription
   const isFree = !isPro
 
   return (
@@ -126,11 +136,21 @@ function BillingPage() {
               <p className="text-gray-600 mt-1">
                 ステータス: {formatSubscriptionStatus(billingStatus.subscriptionStatus)}
               </p>
-              {billingStatus.subscriptionEndsAt && (
+              {billingStatus.billingInterval && (
                 <p className="text-gray-600 mt-1">
-                  次回請求日: {formatDate(billingStatus.subscriptionEndsAt)}
+                  支払いサイクル: {billingStatus.billingInterval === 'year' ? '年払い' : '月払い'}
                 </p>
               )}
+              {billingStatus.renewsAt ? (
+                <p className="text-gray-600 mt-1">次回更新日: {formatDate(billingStatus.renewsAt)}</p>
+              ) : (
+                billingStatus.subscriptionEndsAt &&
+                billingStatus.cancelAtPeriodEnd && (
+                  <p className="text-gray-600 mt-1">
+                    Free プランへの移行日: {formatDate(billingStatus.subscriptionEndsAt)}
+                  </p>
+                )
+              )}
             </div>
           </div>
         </div>
@@ -174,6 +194,9 @@ function BillingPage() {
               <p className="text-3xl font-bold text-gray-900 mt-1">
                 ¥500<span className="text-base font-normal text-gray-500">/月</span>
               </p>
+              {!isPro && billingInterval === 'year' && (
+                <p className="text-sm text-gray-500 mt-1">年額はお支払い画面でご確認いただけます</p>
+              )}
             </div>
             {isPro ? (
               <span className="inline-flex items-center px-3 py-1 rounded-full text-sm font-medium bg-green-100 text-green-800">
@@ -209,20 +232,41 @@ function BillingPage() {
               )}
             </button>
           ) : (
-            <button
-              onClick={handleUpgrade}
-              disabled={isProcessing || isLoadingStatus}
-              className="w-full btn btn-primary flex items-center justify-center"
-            >
-              {isProcessing ? (
-                <>
-                  <div className="w-4 h-4 border-2 border-white/30 border-t-white rounded-full animate-spin mr-2" />
-                  処理中...
-                </>
-              ) : (
-                'Pro にアップグレード'
-              )}
-            </button>
+            <>
+              <div className="flex rounded-md border border-gray-200 mb-3" role="group">
+                {(['month', 'year'] as const).map((value) => (
+                  <button
+                    key={value}
+                    type="button"
+                    onClick={() => setBillingInterval(value)}
+                    className={`flex-1 py-2 text-sm ${billingInterval === value ? 'bg-primary-600 text-white' : 'text-gray-700'}`}
+                  >
+                    {value === 'year' ? '年払い' : '月払い'}
+                  </button>
+                ))}
+              </div>
+              <input
+                type="text"
+                value={promotionCode}
+                onChange={(e) => setPromotionCode(e.target.value)}
+                placeholder="プロモーションコード（任意）"
+                className="input w-full mb-3"
+              />
+              <button
+                onClick={handleUpgrade}
+                disabled={isProcessing || isLoadingStatus}
+                className="w-full btn btn-primary flex items-center justify-center"
+              >
+                {isProcessing ? (
+                  <>
+                    <div className="w-4 h-4 border-2 border-white/30 border-t-white rounded-full animate-spin mr-2" />
+                    処理中...
+                  </>
+                ) : (
+                  'Pro にアップグレード'
+                )}
+              </button>
+            </>
           )}
         </div>
       </div>
@@ -47,6 +47,8 @@ function Dashboard() {
             isLoading={subs.isLoading}
             onEdit={subs.openEditForm}
             onDelete={subs.handleDelete}
+            onReactivate={subs.handleReactivate}
+            onTogglePause={subs.handleTogglePause}
             onCreateNew={subs.openCreateForm}
           />
         </div>
@@ -1,8 +1,9 @@
 import { createFileRoute, Link } from '@tanstack/react-router'
 import { useState, useEffect } from 'react'
 import { useAuth } from '@/hooks/useAuth'
-import { api, type UserProfile } from '@/lib/api'
+import { api, type DeletionToken, type ExportEmail, type UserProfile } from '@/lib/api'
 import { LoadingSpinner } from '@/components/LoadingSpinner'
+import { PushNotifications } from '@/components/PushNotifications'
 
 export const Route = createFileRoute('/_authenticated/settings')({
   component: SettingsPage,
@@ -36,6 +37,15 @@ function SettingsPage() {
     }
   }
 
+  async function handleAcceptTerms(version: string) {
+    try {
+      setError(null)
+      setProfile(await api.acceptTerms(version))
+    } catch (err) {
+      setError(err instanceof Error ? err.message : '利用規約への同意に失敗しました')
+    }
+  }
+
   return (
     <div className="space-y-6 max-w-2xl">
       <div>
@@ -49,6 +59,24 @@ function SettingsPage() {
         </div>
       )}
 
+      {profile && !profile.emailVerified && (
+        <div className="bg-yellow-50 border border-yellow-200 rounded-lg p-4 text-yellow-800">
+          メールアドレスが未確認です。確認メールのリンクを開くまで、サブスクリプションを作成できない場合があります。
+        </div>
+      )}
+
+      {profile && !profile.termsAccepted && profile.currentTermsVersion && (
+        <div className="bg-yellow-50 border border-yellow-200 rounded-lg p-4 text-yellow-800 flex items-center justify-between">
+          <span>サブスクリプションを作成するには利用規約（{profile.currentTermsVersion}）への同意が必要です。</span>
+          <button
+            onClick={() => handleAcceptTerms(profile.currentTermsVersion!)}
+            className="btn btn-primary"
+          >
+            同意する
+          </button>
+        </div>
+      )}
+
       {/* Profile Section */}
       <div className="card">
         <h2 className="text-lg font-semibold text-gray-900 mb-4">プロファイル</h2>
@@ -154,6 +182,9 @@ function SettingsPage() {
         </div>
       </div>
 
+      {/* Web Push Section */}
+      <PushNotifications />
+
       {/* Linked Providers Section */}
       <div className="card">
         <h2 className="text-lg font-semibold text-gray-900 mb-4">
@@ -182,6 +213,145 @@ function SettingsPage() {
           ))}
         </div>
       </div>
+
+      {/* Data Export Section */}
+      <ExportData />
+
+      {/* Account Deletion Section */}
+      <DeleteAccount />
+    </div>
+  )
+}
+
+function ExportData() {
+  const [sent, setSent] = useState<ExportEmail | null>(null)
+  const [isWorking, setIsWorking] = useState(false)
+  const [error, setError] = useState<string | null>(null)
+
+  async function download() {
+    try {
+      setIsWorking(true)
+      setError(null)
+      const url = URL.createObjectURL(await api.exportData())
+      const a = document.createElement('a')
+      a.href = url
+      a.download = `namazu-export-${new Date().toISOString().slice(0, 10).replace(/-/g, '')}.json`
+      a.click()
+      URL.revokeObjectURL(url)
+    } catch (err) {
+      setError(err instanceof Error ? err.message : 'エクスポートに失敗しました')
+    } finally {
+      setIsWorking(false)
+    }
+  }
+
+  async function email() {
+    try {
+      setIsWorking(true)
+      setError(null)
+      setSent(await api.emailExport())
+    } catch (err) {
+      setError(err instanceof Error ? err.message : 'メールの送信に失敗しました')
+    } finally {
+      setIsWorking(false)
+    }
+  }
+
+  return (
+    <div className="card">
+      <h2 className="text-lg font-semibold text-gray-900 mb-2">データのエクスポート</h2>
+      <p className="text-sm text-gray-600 mb-4">
+        アカウント情報、サブスクリプション、直近30日間の配信履歴を JSON ファイルでダウンロードします。
+      </p>
+
+      {error && (
+        <div className="bg-red-50 border border-red-200 rounded-lg p-3 mb-4 text-sm text-red-700">
+          {error}
+        </div>
+      )}
+      {sent && (
+        <p className="text-sm text-gray-900 mb-4">
+          {sent.email} にダウンロードリンクを送りました（{new Date(sent.expires_at).toLocaleString('ja-JP')} まで有効）。
+        </p>
+      )}
+
+      <div className="flex space-x-2">
+        <button className="btn btn-primary" onClick={download} disabled={isWorking}>
+          ダウンロード
+        </button>
+        <button className="btn btn-secondary" onClick={email} disabled={isWorking}>
+          メールでリンクを受け取る
+        </button>
+      </div>
+    </div>
+  )
+}
+
+function DeleteAccount() {
+  const { signOut } = useAuth()
+  const [token, setToken] = useState<DeletionToken | null>(null)
+  const [isWorking, setIsWorking] = useState(false)
+  const [error, setError] = useState<string | null>(null)
+
+  async function requestDeletion() {
+    try {
+      setIsWorking(true)
+      setError(null)
+      setToken(await api.createDeletionToken())
+    } catch (err) {
+      setError(err instanceof Error ? err.message : '確認トークンの取得に失敗しました')
+    } finally {
+      setIsWorking(false)
+    }
+  }
+
+  async function confirmDeletion() {
+    if (!token) return
+    try {
+      setIsWorking(true)
+      setError(null)
+      await api.deleteAccount(token.confirmation_token)
+      await signOut()
+    } catch (err) {
+      setToken(null)
+      setError(err instanceof Error ? err.message : 'アカウントの削除に失敗しました')
+    } finally {
+      setIsWorking(false)
+    }
+  }
+
+  return (
+    <div className="card border border-red-200">
+      <h2 className="text-lg font-semibold text-red-700 mb-2">アカウント削除</h2>
+      <p className="text-sm text-gray-600 mb-4">
+        Pro プランのサブスクリプションを解約し、すべてのサブスクリプションと配信履歴、アカウント情報を削除します。元に戻せません。
+      </p>
+
+      {error && (
+        <div className="bg-red-50 border border-red-200 rounded-lg p-3 mb-4 text-sm text-red-700">
+          {error}
+        </div>
+      )}
+
+      {token ? (
+        <div className="space-y-3">
+          <p className="text-sm text-gray-900">
+            本当に削除しますか？ {new Date(token.expires_at).toLocaleTimeString('ja-JP')} までに確定してください。
+          </p>
+          <div className="flex space-x-2">
+            <button className="btn btn-danger" onClick={confirmDeletion} disabled={isWorking}>
+              削除を確定
+            </button>
+            <button className="btn btn-secondary" onClick={() => setToken(null)} disabled={isWorking}>
+              キャンセル
+            </button>
+          </div>
+        </div>
+      ) : (
+        <button className="btn btn-danger" onClick={requestDeletion} disabled={isWorking}>
+          アカウントを削除
+        </button>
+      )}
     </div>
   )
 }
@@ -28,6 +28,7 @@ export default defineConfig({
       '/api': {
         target: apiProxyTarget,
         changeOrigin: true,
+        ws: true,
       },
       '/health': {
         target: apiProxyTarget,
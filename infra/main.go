@@ -2,13 +2,16 @@ package main
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/pulumi/pulumi-gcp/sdk/v7/go/gcp/artifactregistry"
 	"github.com/pulumi/pulumi-gcp/sdk/v7/go/gcp/compute"
 	"github.com/pulumi/pulumi-gcp/sdk/v7/go/gcp/firebaserules"
 	"github.com/pulumi/pulumi-gcp/sdk/v7/go/gcp/firestore"
+	"github.com/pulumi/pulumi-gcp/sdk/v7/go/gcp/kms"
 	"github.com/pulumi/pulumi-gcp/sdk/v7/go/gcp/projects"
 	"github.com/pulumi/pulumi-gcp/sdk/v7/go/gcp/serviceaccount"
+	"github.com/pulumi/pulumi-gcp/sdk/v7/go/gcp/storage"
 	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
 	"github.com/pulumi/pulumi/sdk/v3/go/pulumi/config"
 )
@@ -26,6 +29,11 @@ func main() {
 		}
 		domain := cfg.Get("domain")
 		authTenantId := cfg.Get("authTenantId")
+		// Days events stay in Firestore before they are archived to Cloud Storage
+		archiveRetentionDays := cfg.GetInt("archiveRetentionDays")
+		if archiveRetentionDays == 0 {
+			archiveRetentionDays = 90
+		}
 
 		project := gcpCfg.Require("project")
 		region := gcpCfg.Get("region")
@@ -55,6 +63,9 @@ func main() {
 			"firestore":        "firestore.googleapis.com",
 			"firebaserules":    "firebaserules.googleapis.com",
 			"iam":              "iam.googleapis.com",
+			"secretmanager":    "secretmanager.googleapis.com",
+			"cloudkms":         "cloudkms.googleapis.com",
+			"storage":          "storage.googleapis.com",
 		}
 
 		enabledAPIs := make([]*projects.Service, 0, len(apis))
@@ -138,6 +149,74 @@ service cloud.firestore {
 			return err
 		}
 
+		// Composite indexes for GET /api/events: every combination of the
+		// type / prefecture / min_severity filters, ordered by occurredAt
+		// in either direction (the date range filters occurredAt itself)
+		for mask := 1; mask < 8; mask++ {
+			for _, order := range []string{"ASCENDING", "DESCENDING"} {
+				name := "events"
+				fields := firestore.IndexFieldArray{}
+				if mask&1 != 0 {
+					name += "-type"
+					fields = append(fields, &firestore.IndexFieldArgs{FieldPath: pulumi.String("type"), Order: pulumi.String("ASCENDING")})
+				}
+				if mask&2 != 0 {
+					name += "-area"
+					fields = append(fields, &firestore.IndexFieldArgs{FieldPath: pulumi.String("affectedAreas"), ArrayConfig: pulumi.String("CONTAINS")})
+				}
+				fields = append(fields, &firestore.IndexFieldArgs{FieldPath: pulumi.String("occurredAt"), Order: pulumi.String(order)})
+				if mask&4 != 0 {
+					name += "-severity"
+					fields = append(fields, &firestore.IndexFieldArgs{FieldPath: pulumi.String("severity"), Order: pulumi.String("ASCENDING")})
+				}
+				_, err = firestore.NewIndex(ctx, fmt.Sprintf("%s-%s-%s", namePrefix, name, strings.ToLower(order[:3])), &firestore.IndexArgs{
+					Project:    pulumi.String(project),
+					Database:   firestoreDB.Name,
+					Collection: pulumi.String("events"),
+					Fields:     fields,
+				}, pulumi.DependsOn([]pulumi.Resource{firestoreDB}))
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		// Composite indexes for GET /api/admin/audit: every combination of the
+		// actor / action / target filters, newest first
+		for mask := 1; mask < 8; mask++ {
+			name := "audit"
+			fields := firestore.IndexFieldArray{}
+			for i, f := range []struct{ name, path string }{{"actor", "actorUid"}, {"action", "action"}, {"target", "targetId"}} {
+				if mask&(1<<i) != 0 {
+					name += "-" + f.name
+					fields = append(fields, &firestore.IndexFieldArgs{FieldPath: pulumi.String(f.path), Order: pulumi.String("ASCENDING")})
+				}
+			}
+			fields = append(fields, &firestore.IndexFieldArgs{FieldPath: pulumi.String("createdAt"), Order: pulumi.String("DESCENDING")})
+			_, err = firestore.NewIndex(ctx, fmt.Sprintf("%s-%s", namePrefix, name), &firestore.IndexArgs{
+				Project:    pulumi.String(project),
+				Database:   firestoreDB.Name,
+				Collection: pulumi.String("audit_log"),
+				Fields:     fields,
+			}, pulumi.DependsOn([]pulumi.Resource{firestoreDB}))
+			if err != nil {
+				return err
+			}
+		}
+
+		// Shared rate limit counters (NAMAZU_RATE_LIMIT_BACKEND=firestore) are
+		// only needed for a couple of minutes; let Firestore delete them
+		_, err = firestore.NewField(ctx, fmt.Sprintf("%s-rate-limits-ttl", namePrefix), &firestore.FieldArgs{
+			Project:    pulumi.String(project),
+			Database:   firestoreDB.Name,
+			Collection: pulumi.String("rate_limits"),
+			Field:      pulumi.String("expiresAt"),
+			TtlConfig:  &firestore.FieldTtlConfigArgs{},
+		}, pulumi.DependsOn([]pulumi.Resource{firestoreDB}))
+		if err != nil {
+			return err
+		}
+
 		// =================================================================
 		// Service Account for the application
 		// =================================================================
@@ -159,6 +238,7 @@ service cloud.firestore {
 			{"artifact-registry-reader", "roles/artifactregistry.reader"},
 			{"firestore-user", "roles/datastore.user"},
 			{"logging-writer", "roles/logging.logWriter"},
+			{"secret-accessor", "roles/secretmanager.secretAccessor"}, // sm:// config values
 		}
 
 		iamBindings := make([]pulumi.Resource, 0, len(iamRoles))
@@ -174,6 +254,72 @@ service cloud.firestore {
 			iamBindings = append(iamBindings, binding)
 		}
 
+		// =================================================================
+		// Cloud KMS key encrypting subscription secrets in Firestore
+		// =================================================================
+		keyRing, err := kms.NewKeyRing(ctx, fmt.Sprintf("%s-keyring", namePrefix), &kms.KeyRingArgs{
+			Name:     pulumi.Sprintf("namazu-%s", env),
+			Location: pulumi.String(region),
+		}, pulumi.DependsOn(apiDeps))
+		if err != nil {
+			return err
+		}
+		secretsKey, err := kms.NewCryptoKey(ctx, fmt.Sprintf("%s-secrets-key", namePrefix), &kms.CryptoKeyArgs{
+			Name:           pulumi.String("subscription-secrets"),
+			KeyRing:        keyRing.ID(),
+			RotationPeriod: pulumi.String("7776000s"), // 90 days; old versions still decrypt
+		})
+		if err != nil {
+			return err
+		}
+		secretsKeyBinding, err := kms.NewCryptoKeyIAMMember(ctx, fmt.Sprintf("%s-sa-secrets-key", namePrefix), &kms.CryptoKeyIAMMemberArgs{
+			CryptoKeyId: secretsKey.ID(),
+			Role:        pulumi.String("roles/cloudkms.cryptoKeyEncrypterDecrypter"),
+			Member:      pulumi.Sprintf("serviceAccount:%s", serviceAccount.Email),
+		})
+		if err != nil {
+			return err
+		}
+		iamBindings = append(iamBindings, secretsKeyBinding)
+
+		// =================================================================
+		// Cloud Storage bucket for archived events
+		// =================================================================
+		// Events older than archiveRetentionDays are written here as daily
+		// gzipped JSONL files and deleted from Firestore
+		archiveBucket, err := storage.NewBucket(ctx, fmt.Sprintf("%s-archive", namePrefix), &storage.BucketArgs{
+			Name:                     pulumi.Sprintf("%s-%s-archive", project, env),
+			Location:                 pulumi.String(strings.ToUpper(region)),
+			UniformBucketLevelAccess: pulumi.Bool(true),
+			PublicAccessPrevention:   pulumi.String("enforced"),
+			// Archives are rarely read after the first year
+			LifecycleRules: storage.BucketLifecycleRuleArray{
+				&storage.BucketLifecycleRuleArgs{
+					Action: &storage.BucketLifecycleRuleActionArgs{
+						Type:         pulumi.String("SetStorageClass"),
+						StorageClass: pulumi.String("COLDLINE"),
+					},
+					Condition: &storage.BucketLifecycleRuleConditionArgs{
+						Age: pulumi.Int(365),
+					},
+				},
+			},
+		}, pulumi.DependsOn(apiDeps))
+		if err != nil {
+			return err
+		}
+		// The instance only adds files; names are unique per run, so it needs
+		// neither to read nor to overwrite them
+		archiveBinding, err := storage.NewBucketIAMMember(ctx, fmt.Sprintf("%s-sa-archive-writer", namePrefix), &storage.BucketIAMMemberArgs{
+			Bucket: archiveBucket.Name,
+			Role:   pulumi.String("roles/storage.objectCreator"),
+			Member: pulumi.Sprintf("serviceAccount:%s", serviceAccount.Email),
+		})
+		if err != nil {
+			return err
+		}
+		iamBindings = append(iamBindings, archiveBinding)
+
 		// =================================================================
 		// VPC Network
 		// =================================================================
@@ -314,6 +460,9 @@ STORE_PROJECT_ID=$(get_metadata "namazu-store-project-id")
 STORE_DATABASE=$(get_metadata "namazu-store-database")
 DOMAIN=$(get_metadata "namazu-domain")
 AUTH_TENANT_ID=$(get_metadata "namazu-auth-tenant-id")
+SECRETS_KMS_KEY=$(get_metadata "namazu-secrets-kms-key")
+ARCHIVE_BUCKET=$(get_metadata "namazu-archive-bucket")
+ARCHIVE_RETENTION_DAYS=$(get_metadata "namazu-archive-retention-days")
 
 # Configure docker credential helper for Artifact Registry
 docker-credential-gcr configure-docker --registries=%s-docker.pkg.dev
@@ -341,6 +490,10 @@ docker run -d \
   -e NAMAZU_STORE_PROJECT_ID=${STORE_PROJECT_ID} \
   -e NAMAZU_STORE_DATABASE=${STORE_DATABASE} \
   -e NAMAZU_AUTH_TENANT_ID=${AUTH_TENANT_ID} \
+  -e NAMAZU_SECRETS_KMS_KEY=${SECRETS_KMS_KEY} \
+  -e NAMAZU_ARCHIVE_BUCKET=${ARCHIVE_BUCKET} \
+  -e NAMAZU_ARCHIVE_PREFIX=events/ \
+  -e NAMAZU_ARCHIVE_RETENTION_DAYS=${ARCHIVE_RETENTION_DAYS} \
   ${IMAGE}
 
 # Run Caddy for HTTPS reverse proxy (only if domain is set)
@@ -387,15 +540,18 @@ fi
 				},
 			},
 			Metadata: pulumi.StringMap{
-				"namazu-registry":         pulumi.Sprintf("%s-docker.pkg.dev/%s/namazu", region, project),
-				"namazu-image":            pulumi.Sprintf("%s-docker.pkg.dev/%s/namazu/namazu:latest", region, project),
-				"namazu-source-type":      pulumi.String("p2pquake"),
-				"namazu-source-endpoint":  pulumi.String(sourceEndpoint),
-				"namazu-api-addr":         pulumi.String(":9898"),
-				"namazu-store-project-id": pulumi.String(project),
-				"namazu-store-database":   pulumi.String(dbName),
-				"namazu-domain":           pulumi.String(domain),
-				"namazu-auth-tenant-id":   pulumi.String(authTenantId),
+				"namazu-registry":               pulumi.Sprintf("%s-docker.pkg.dev/%s/namazu", region, project),
+				"namazu-image":                  pulumi.Sprintf("%s-docker.pkg.dev/%s/namazu/namazu:latest", region, project),
+				"namazu-source-type":            pulumi.String("p2pquake"),
+				"namazu-source-endpoint":        pulumi.String(sourceEndpoint),
+				"namazu-api-addr":               pulumi.String(":9898"),
+				"namazu-store-project-id":       pulumi.String(project),
+				"namazu-store-database":         pulumi.String(dbName),
+				"namazu-domain":                 pulumi.String(domain),
+				"namazu-auth-tenant-id":         pulumi.String(authTenantId),
+				"namazu-secrets-kms-key":        secretsKey.ID(),
+				"namazu-archive-bucket":         archiveBucket.Name,
+				"namazu-archive-retention-days": pulumi.String(fmt.Sprint(archiveRetentionDays)),
 			},
 			MetadataStartupScript:  startupScript,
 			AllowStoppingForUpdate: pulumi.Bool(true),
@@ -414,6 +570,7 @@ fi
 		ctx.Export("externalIp", staticIP.Address)
 		ctx.Export("serviceAccountEmail", pulumi.Sprintf("%s@%s.iam.gserviceaccount.com", saName, project))
 		ctx.Export("firestoreDatabase", firestoreDB.Name)
+		ctx.Export("archiveBucket", archiveBucket.Name)
 
 		if domain != "" {
 			ctx.Export("domain", pulumi.String(domain))